@@ -12,7 +12,7 @@ func Init() {
 	//if helpers.IsLocalDev() {
 	//	return
 	//}
-	// 1. 当open_search索引不存在时创建索引
+	// 1. 创建open_search分区索引及别名（旧的未分区索引在此迁移）
 	ctx := context.Background()
 
 	svc := recinject.NewRecSvc()
@@ -30,4 +30,7 @@ func Init() {
 
 	// 3. 开启任务（删除超过保存时间的日志）
 	go rectask.NewRemoveOldLogTask().Run()
+
+	// 4. 开启任务（按周期创建分区索引）
+	go rectask.NewRolloverIndexTask().Run()
 }
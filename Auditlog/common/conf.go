@@ -2,6 +2,7 @@
 package common
 
 import (
+	"errors"
 	"os"
 
 	"github.com/kweaver-ai/TelemetrySDK-Go/span/v2/field"
@@ -46,6 +47,18 @@ type Config struct {
 	DocumentPrivatePort     string
 	OAuthAdminHost          string
 	OAuthAdminPort          string
+	LogChainSecret          string // 哈希链检查点签名密钥
+	LogChainCheckpoint      string // 每写入多少条日志生成一个检查点
+	SiemForwardEnabled      bool   // 是否转发日志到 SIEM
+	SiemProtocol            string // 转发协议, udp/tcp/tls
+	SiemAddr                string // syslog 服务地址, host:port
+	SiemFormat              string // 转发格式, rfc5424/cef/leef
+	SiemFilters             string // 转发的日志类型及最低级别, 如 login,management:WARN
+	SiemTLSCAFile           string // tls 协议时校验服务端证书的 CA 文件
+	SiemTLSSkipVerify       bool   // tls 协议时是否跳过证书校验
+	DumpPublicKey           string // 加密转存文件的 RSA 公钥, PEM 格式, 为空时不加密
+	DumpManifestSecret      string // 转存清单签名密钥
+	DumpPrivateKey          string // 解密转存文件的 RSA 私钥, PEM 格式, 用于恢复加密的历史日志
 	LogConfig               LogConfig
 	Logger                  api.Logger
 }
@@ -69,6 +82,18 @@ func init() {
 	SvcConfig.DocumentPrivatePort = GetEnv("DOCUMENT_PRIVATE_PORT", "30920")
 	SvcConfig.OAuthAdminHost = GetEnv("HYDRA_ADMIN_HOST", "hydra-admin.anyshare")
 	SvcConfig.OAuthAdminPort = GetEnv("HYDRA_ADMIN_PORT", "4445")
+	SvcConfig.LogChainSecret = GetEnv("LOG_CHAIN_SECRET", "")
+	SvcConfig.LogChainCheckpoint = GetEnv("LOG_CHAIN_CHECKPOINT_INTERVAL", "1000")
+	SvcConfig.SiemForwardEnabled = GetEnv("SIEM_FORWARD_ENABLED", "false") == "true"
+	SvcConfig.SiemProtocol = GetEnv("SIEM_PROTOCOL", "udp")
+	SvcConfig.SiemAddr = GetEnv("SIEM_ADDR", "")
+	SvcConfig.SiemFormat = GetEnv("SIEM_FORMAT", "rfc5424")
+	SvcConfig.SiemFilters = GetEnv("SIEM_FILTERS", "login,management,operation")
+	SvcConfig.SiemTLSCAFile = GetEnv("SIEM_TLS_CA_FILE", "")
+	SvcConfig.SiemTLSSkipVerify = GetEnv("SIEM_TLS_SKIP_VERIFY", "false") == "true"
+	SvcConfig.DumpPublicKey = GetEnv("LOG_DUMP_PUBLIC_KEY", "")
+	SvcConfig.DumpManifestSecret = GetEnv("LOG_DUMP_MANIFEST_SECRET", "")
+	SvcConfig.DumpPrivateKey = GetEnv("LOG_DUMP_PRIVATE_KEY", "")
 	l := api.NewTelemetryLogger(os.Stdout, log.InfoLevel, &api.LogOptionServiceInfo{
 		Name:     SvcConfig.ServiceName,
 		Version:  SvcConfig.CommitID,
@@ -85,3 +110,18 @@ func init() {
 	l.AddTagMarker(tagMarker)
 	SvcConfig.Logger = l
 }
+
+// CheckSignSecrets 检查签名密钥, 密钥为空时哈希链检查点、锚点及转存清单的签名可被伪造
+func (c *Config) CheckSignSecrets() error {
+	if c.LogChainSecret == "" {
+		return errors.New("LOG_CHAIN_SECRET is required, see the upgrade notes in the audit-log chart")
+	}
+	if c.DumpManifestSecret == "" {
+		return errors.New("LOG_DUMP_MANIFEST_SECRET is required, see the upgrade notes in the audit-log chart")
+	}
+	// 转存清单随转存文件交给外部保存, 与哈希链使用同一密钥时泄露清单密钥即可伪造检查点
+	if c.DumpManifestSecret == c.LogChainSecret {
+		return errors.New("LOG_DUMP_MANIFEST_SECRET must differ from LOG_CHAIN_SECRET")
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSignSecrets(t *testing.T) {
+	tests := []struct {
+		name           string
+		chainSecret    string
+		manifestSecret string
+		wantErr        bool
+	}{
+		{
+			name:           "未设置哈希链密钥",
+			manifestSecret: "manifest",
+			wantErr:        true,
+		},
+		{
+			name:        "未设置转存清单密钥",
+			chainSecret: "chain",
+			wantErr:     true,
+		},
+		{
+			name:           "转存清单密钥与哈希链密钥相同",
+			chainSecret:    "secret",
+			manifestSecret: "secret",
+			wantErr:        true,
+		},
+		{
+			name:           "密钥均已设置",
+			chainSecret:    "chain",
+			manifestSecret: "manifest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{LogChainSecret: tt.chainSecret, DumpManifestSecret: tt.manifestSecret}
+			err := c.CheckSignSecrets()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
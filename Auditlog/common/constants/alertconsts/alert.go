@@ -0,0 +1,66 @@
+package alertconsts
+
+import "time"
+
+// 告警规则类型
+const (
+	RuleThreshold string = "threshold" // 滑动窗口内匹配的事件数达到阈值
+	RuleOffHours  string = "off_hours" // 非工作时间发生的事件
+)
+
+// AllRuleKind 所有的告警规则类型
+var AllRuleKind = []string{RuleThreshold, RuleOffHours}
+
+// 条件运算符
+const (
+	OpEq       string = "eq"
+	OpNe       string = "ne"
+	OpContains string = "contains"
+	OpIn       string = "in"
+)
+
+// AllOperator 所有的条件运算符
+var AllOperator = []string{OpEq, OpNe, OpContains, OpIn}
+
+// 事件字段, 审计日志与运营日志统一映射为以下字段
+const (
+	FieldUserID    string = "user_id"
+	FieldUserName  string = "user_name"
+	FieldUserType  string = "user_type"
+	FieldIP        string = "ip"
+	FieldLevel     string = "level"
+	FieldOpType    string = "op_type"
+	FieldOperation string = "operation"
+	FieldObjID     string = "obj_id"
+	FieldObjName   string = "obj_name"
+	FieldObjType   string = "obj_type"
+	FieldMsg       string = "msg"
+	FieldExMsg     string = "ex_msg"
+)
+
+// AllField 所有可用于条件和分组的字段
+var AllField = []string{
+	FieldUserID, FieldUserName, FieldUserType, FieldIP, FieldLevel, FieldOpType,
+	FieldOperation, FieldObjID, FieldObjName, FieldObjType, FieldMsg, FieldExMsg,
+}
+
+const (
+	// AlertTopic 告警消息主题
+	AlertTopic string = "isf.audit_log.alert"
+	// WindowKeyPrefix 滑动窗口计数的redis key前缀
+	WindowKeyPrefix string = "as:audit_log:alert:window:"
+	// CooldownKeyPrefix 告警抑制的redis key前缀
+	CooldownKeyPrefix string = "as:audit_log:alert:cooldown:"
+	// RuleCacheTTL 告警规则本地缓存时间
+	RuleCacheTTL = 30 * time.Second
+	// DefaultCooldownMinutes 默认的告警抑制时间, 同一规则同一分组在抑制时间内只告警一次
+	DefaultCooldownMinutes int = 10
+	// MaxWindowMinutes 滑动窗口的最大时长
+	MaxWindowMinutes int = 24 * 60
+	// EventQueueSize 待评估事件队列长度, 队列满时丢弃事件
+	EventQueueSize int = 10000
+	// DroppedEventLogInterval 队列满时每丢弃该数量的事件记录一次日志
+	DroppedEventLogInterval int64 = 1000
+	// WorkerCount 评估事件及推送告警的协程数
+	WorkerCount int = 4
+)
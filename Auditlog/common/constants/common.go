@@ -20,6 +20,11 @@ const (
 	DumpLogLockKey string = "as:audit_log:dump_log_lock"
 )
 
+// ReportScheduleLockKey 定时报表锁
+const (
+	ReportScheduleLockKey string = "as:audit_log:report_schedule_lock"
+)
+
 // SystemID 系统账户ID
 const (
 	SystemID string = "da5bfdc4-cb4b-4b28-90c2-9eca46c3e500"
@@ -0,0 +1,21 @@
+package dlqconsts
+
+// 运营日志进入死信的原因
+const (
+	ReasonInvalidBizType string = "invalid_biz_type" // 业务类型无效
+	ReasonSchemaError    string = "schema_error"     // json schema 校验出错
+	ReasonInvalidFields  string = "invalid_fields"   // 存在不符合 json schema 的字段
+	ReasonHandleError    string = "handle_error"     // 重放时领域服务处理失败
+)
+
+// AllReason 所有的死信原因
+var AllReason = []string{ReasonInvalidBizType, ReasonSchemaError, ReasonInvalidFields, ReasonHandleError}
+
+// 死信状态
+const (
+	StatusPending  int = 1 // 待处理
+	StatusReplayed int = 2 // 已重放
+)
+
+// MaxReplayBatch 单次重放的最大死信数
+const MaxReplayBatch int = 500
@@ -0,0 +1,47 @@
+package fwdconsts
+
+import "time"
+
+// 转发协议
+const (
+	ProtocolUDP string = "udp"
+	ProtocolTCP string = "tcp"
+	ProtocolTLS string = "tls"
+)
+
+// 转发格式, cef 与 leef 作为 syslog 消息体发送
+const (
+	FormatRFC5424 string = "rfc5424"
+	FormatCEF     string = "cef"
+	FormatLEEF    string = "leef"
+)
+
+// outbox 待发送队列
+const (
+	OutboxBusinessType string = "siem_forward"     // outbox业务类型
+	OutboxOpType       string = "siem_forward_msg" // outbox消息类型
+)
+
+// syslog 消息头
+const (
+	Facility int    = 13 // log audit
+	AppName  string = "audit-log"
+	SDID     string = "audit@32473" // 结构化数据id, 32473 为文档示例用企业号
+	NilValue string = "-"
+)
+
+// SendTimeout 建连与发送超时
+const SendTimeout = 5 * time.Second
+
+// CEF/LEEF 设备信息
+const (
+	DeviceVendor  string = "KWeaver"
+	DeviceProduct string = "AuditLog"
+	DeviceVersion string = "1.0"
+)
+
+// syslog 严重级别
+const (
+	SeverityWarning int = 4
+	SeverityInfo    int = 6
+)
@@ -0,0 +1,25 @@
+package lcconsts
+
+// GenesisHash 哈希链首条记录的前序哈希
+const GenesisHash string = ""
+
+// DefaultCheckpointInterval 默认每写入多少条日志生成一个签名检查点
+const DefaultCheckpointInterval int64 = 1000
+
+// VerifyBatchSize 校验哈希链时每批读取的日志数量
+const VerifyBatchSize int = 1000
+
+// 哈希链断裂原因
+const (
+	HashMismatch        string = "hash_mismatch"        // 记录内容与哈希不一致
+	PrevHashMismatch    string = "prev_hash_mismatch"   // 前序哈希与上一条记录不一致
+	RecordMissing       string = "record_missing"       // 链上的记录缺失
+	CheckpointMismatch  string = "checkpoint_mismatch"  // 检查点与记录不一致
+	CheckpointSignature string = "checkpoint_signature" // 检查点签名无效
+	HeadMismatch        string = "head_mismatch"        // 链头与最后一条记录不一致
+	HeadSignature       string = "head_signature"       // 链头签名无效
+	HeadRollback        string = "head_rollback"        // 存在序号大于链头的记录、检查点或锚点, 链头被回退
+	CheckpointMissing   string = "checkpoint_missing"   // 应生成检查点的位置缺少检查点
+	AnchorMissing       string = "anchor_missing"       // 前序记录已转储, 但没有可衔接的检查点或转储锚点
+	Unchained           string = "unchained"            // 存在未入链的记录
+)
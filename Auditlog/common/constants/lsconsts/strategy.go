@@ -15,10 +15,25 @@ const HistoryMaxBatchSize = 100000
 
 // 日志转存文件后缀
 const (
-	XMLSuffix string = "xml"
-	CSVSuffix string = "csv"
+	XMLSuffix       string = "xml"
+	CSVSuffix       string = "csv"
+	JSONLSuffix     string = "jsonl"
+	GzipSuffix      string = "gz"  // gzip 压缩, 追加在格式后, 如 csv.gz
+	EncryptedSuffix string = "enc" // 使用公钥加密, 追加在文件名最后
 )
 
+// AllDumpFormat 所有的转存格式
+var AllDumpFormat = []string{
+	XMLSuffix, CSVSuffix, JSONLSuffix,
+	XMLSuffix + "." + GzipSuffix, CSVSuffix + "." + GzipSuffix, JSONLSuffix + "." + GzipSuffix,
+}
+
+// ManifestVersion 转存清单版本
+const ManifestVersion = 1
+
+// ManifestSuffix 转存清单对象后缀, 清单与转存文件保存在同一对象存储中, 对象名为转存文件对象名加该后缀
+const ManifestSuffix = ".manifest.json"
+
 // 转存策略字段
 const (
 	RetentionPeriod     string = "retention_period"
@@ -3,3 +3,15 @@ package recconsts
 const (
 	IndexPrefix = "as-operation-log-"
 )
+
+// 索引分区周期
+const (
+	RolloverPeriodDay   = "day"
+	RolloverPeriodMonth = "month"
+)
+
+// 分区索引名的日期格式（UTC），分区索引名为 别名-日期
+const (
+	PartitionDayLayout   = "2006.01.02"
+	PartitionMonthLayout = "2006.01"
+)
@@ -0,0 +1,57 @@
+package rptconsts
+
+import "time"
+
+// 报表执行周期
+const (
+	ScheduleDaily   string = "daily"   // 每天
+	ScheduleWeekly  string = "weekly"  // 每周, 执行日为星期几, 0表示星期日
+	ScheduleMonthly string = "monthly" // 每月, 执行日为几号, 超过当月天数时在月末执行
+)
+
+// AllSchedule 所有的报表执行周期
+var AllSchedule = []string{ScheduleDaily, ScheduleWeekly, ScheduleMonthly}
+
+// 报表文件格式
+const (
+	FormatCSV   string = "csv"
+	FormatJSONL string = "jsonl"
+)
+
+// AllFormat 所有的报表文件格式
+var AllFormat = []string{FormatCSV, FormatJSONL}
+
+// 报表执行状态
+const (
+	RunStatusRunning int = 1 // 执行中
+	RunStatusSuccess int = 2 // 执行成功
+	RunStatusFailed  int = 3 // 执行失败
+)
+
+// AllColumn 报表可选的列, 与活跃日志报表字段一致, 未指定时导出所有列
+var AllColumn = []string{
+	"log_id", "date", "user_name", "ip", "mac", "op_type", "level",
+	"obj_name", "obj_type", "msg", "exmsg", "user_paths",
+}
+
+// AllConditionField 报表过滤条件可用的字段, 时间范围由执行周期决定
+var AllConditionField = []string{
+	"level", "op_type", "user_name", "user_paths", "ip", "mac", "msg", "exmsg",
+}
+
+const (
+	// ReportTopic 报表执行结果的消息主题
+	ReportTopic string = "isf.audit_log.report"
+
+	// PageSize 生成报表时分页查询日志的条数
+	PageSize int = 5000
+	// MaxRecordCount 单个报表最多导出的日志条数
+	MaxRecordCount int = 1000000
+	// MaxSubscriberCount 单个报表最多的订阅者数量
+	MaxSubscriberCount int = 100
+
+	// CheckInterval 检查到期报表的间隔
+	CheckInterval = time.Minute
+	// FileRetention 报表文件在对象存储中的保留时长
+	FileRetention = 7 * 24 * time.Hour
+)
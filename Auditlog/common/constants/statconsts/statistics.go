@@ -0,0 +1,64 @@
+package statconsts
+
+// 统计分组维度
+const (
+	GroupOpType     string = "op_type"     // 操作类型
+	GroupLevel      string = "level"       // 日志级别
+	GroupUser       string = "user"        // 用户
+	GroupDepartment string = "department"  // 用户所属部门
+	GroupIP         string = "ip"          // 访问者IP
+	GroupClientType string = "client_type" // 客户端类型
+	GroupObjType    string = "obj_type"    // 对象类型
+)
+
+// GroupExprMap 分组维度对应的SQL表达式
+var GroupExprMap = map[string]string{
+	GroupOpType:     "f_op_type",
+	GroupLevel:      "f_level",
+	GroupUser:       "f_user_id",
+	GroupDepartment: departmentExpr,
+	GroupIP:         "f_ip",
+	GroupClientType: clientTypeExpr,
+	GroupObjType:    "f_obj_type",
+}
+
+// firstPathExpr 用户所属的第一个部门路径, 多个部门路径以 ", " 分隔
+const firstPathExpr = "(CASE WHEN INSTR(COALESCE(f_user_paths, ''), ', ') > 0 " +
+	"THEN SUBSTR(f_user_paths, 1, INSTR(f_user_paths, ', ') - 1) ELSE COALESCE(f_user_paths, '') END)"
+
+// departmentExpr 用户所属的顶级部门, 取第一个部门路径的首段
+const departmentExpr = "(CASE WHEN INSTR(" + firstPathExpr + ", '/') > 0 " +
+	"THEN SUBSTR(" + firstPathExpr + ", 1, INSTR(" + firstPathExpr + ", '/') - 1) ELSE " + firstPathExpr + " END)"
+
+// clientTypeExpr 客户端类型, 用户代理为客户端类型名时直接使用, 否则按浏览器用户代理中的系统标识归类
+const clientTypeExpr = "(CASE" +
+	" WHEN LOWER(f_user_agent) IN ('ios', 'android', 'windows_phone', 'windows', 'mac_os', 'web', 'mobile_web'," +
+	" 'nas', 'console_web', 'deploy_web', 'linux', 'app') THEN LOWER(f_user_agent)" +
+	" WHEN LOWER(f_user_agent) LIKE '%windows phone%' THEN 'windows_phone'" +
+	" WHEN LOWER(f_user_agent) LIKE '%android%' THEN 'android'" +
+	" WHEN LOWER(f_user_agent) LIKE '%iphone%' OR LOWER(f_user_agent) LIKE '%ipad%' THEN 'ios'" +
+	" WHEN LOWER(f_user_agent) LIKE '%windows%' THEN 'windows'" +
+	" WHEN LOWER(f_user_agent) LIKE '%mac os%' OR LOWER(f_user_agent) LIKE '%macintosh%' THEN 'mac_os'" +
+	" WHEN LOWER(f_user_agent) LIKE '%linux%' THEN 'linux'" +
+	" ELSE 'unknown' END)"
+
+// 时间分桶粒度
+const (
+	IntervalHour string = "hour"
+	IntervalDay  string = "day"
+)
+
+// IntervalMicroMap 时间分桶粒度对应的微秒数
+var IntervalMicroMap = map[string]int64{
+	IntervalHour: 3600 * 1000 * 1000,
+	IntervalDay:  24 * 3600 * 1000 * 1000,
+}
+
+const (
+	// DefaultTopN 分组统计默认返回的分组数
+	DefaultTopN int = 10
+	// MaxTopN 分组统计最多返回的分组数
+	MaxTopN int = 100
+	// MaxBucketCount 时间分桶的最大数量
+	MaxBucketCount int64 = 1000
+)
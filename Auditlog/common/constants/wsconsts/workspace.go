@@ -0,0 +1,27 @@
+package wsconsts
+
+import "time"
+
+// 调查工作区状态
+const (
+	StatusRestoring int = 1 // 恢复中
+	StatusReady     int = 2 // 可查询
+	StatusFailed    int = 3 // 恢复失败
+)
+
+const (
+	// MaxHistoryCount 单个工作区最多恢复的历史日志文件数
+	MaxHistoryCount int = 20
+	// MaxRecordCount 单个工作区最多恢复的日志条数
+	MaxRecordCount int64 = 5000000
+	// InsertBatchSize 批量写入工作区日志的条数
+	InsertBatchSize int = 500
+
+	// DefaultExpireDays 工作区默认有效天数
+	DefaultExpireDays int = 7
+	// MaxExpireDays 工作区最长有效天数
+	MaxExpireDays int = 30
+
+	// CleanInterval 清理过期工作区的间隔
+	CleanInterval = time.Hour
+)
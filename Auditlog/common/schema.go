@@ -81,7 +81,7 @@ const (
 			},
 			"dump_format": {
 				"type": "string",
-				"enum": ["xml", "csv"]
+				"enum": ["xml", "csv", "jsonl", "xml.gz", "csv.gz", "jsonl.gz"]
 			}
 		}
 	}`
@@ -112,6 +112,265 @@ const (
 		}
     }`
 
+	AlertRule = `{
+		"type": "object",
+		"required": ["name", "log_type", "kind"],
+		"properties": {
+			"name": {
+				"type": "string",
+				"minLength": 1,
+				"maxLength": 128
+			},
+			"description": {
+				"type": "string",
+				"maxLength": 512
+			},
+			"enabled": {
+				"type": "boolean"
+			},
+			"log_type": {
+				"type": "string",
+				"minLength": 1
+			},
+			"kind": {
+				"type": "string",
+				"enum": ["threshold", "off_hours"]
+			},
+			"conditions": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"required": ["field", "operator"],
+					"properties": {
+						"field": {
+							"type": "string",
+							"enum": ["user_id", "user_name", "user_type", "ip", "level", "op_type", "operation", "obj_id", "obj_name", "obj_type", "msg", "ex_msg"]
+						},
+						"operator": {
+							"type": "string",
+							"enum": ["eq", "ne", "contains", "in"]
+						},
+						"value": {
+							"type": "string"
+						},
+						"values": {
+							"type": "array",
+							"items": {
+								"type": "string"
+							}
+						}
+					}
+				}
+			},
+			"group_by": {
+				"type": "string",
+				"enum": ["", "user_id", "user_name", "user_type", "ip", "level", "op_type", "operation", "obj_id", "obj_name", "obj_type"]
+			},
+			"threshold": {
+				"type": "integer",
+				"minimum": 1
+			},
+			"window_minutes": {
+				"type": "integer",
+				"minimum": 1,
+				"maximum": 1440
+			},
+			"work_start": {
+				"type": "string",
+				"pattern": "^(2[0-3]|[01][0-9]):[0-5][0-9]$"
+			},
+			"work_end": {
+				"type": "string",
+				"pattern": "^(2[0-3]|[01][0-9]):[0-5][0-9]$"
+			},
+			"work_days": {
+				"type": "array",
+				"items": {
+					"type": "integer",
+					"minimum": 1,
+					"maximum": 7
+				}
+			},
+			"level": {
+				"type": "integer",
+				"enum": [1, 2]
+			},
+			"webhook": {
+				"type": "string",
+				"pattern": "^(https?://.+)?$"
+			},
+			"cooldown_minutes": {
+				"type": "integer",
+				"minimum": 0
+			}
+		}
+	}`
+
+	PurgeDeadLetters = `{
+		"type": "object",
+		"properties": {
+			"ids": {
+				"type": "array",
+				"items": {
+					"type": "integer"
+				}
+			},
+			"biz_type": {
+				"type": "string"
+			},
+			"status": {
+				"type": "integer",
+				"enum": [1, 2]
+			},
+			"before": {
+				"type": "integer",
+				"minimum": 0
+			}
+		}
+	}`
+
+	ReplayDeadLetters = `{
+		"type": "object",
+		"properties": {
+			"ids": {
+				"type": "array",
+				"items": {
+					"type": "integer"
+				},
+				"maxItems": 500
+			},
+			"biz_type": {
+				"type": "string"
+			}
+		}
+	}`
+
+	LegalHold = `{
+		"type": "object",
+		"required": ["name"],
+		"properties": {
+			"name": {
+				"type": "string",
+				"minLength": 1,
+				"maxLength": 128
+			},
+			"reason": {
+				"type": "string",
+				"maxLength": 512
+			},
+			"log_type": {
+				"type": "string"
+			},
+			"begin_time": {
+				"type": "integer",
+				"minimum": 0
+			},
+			"end_time": {
+				"type": "integer",
+				"minimum": 0
+			},
+			"user_ids": {
+				"type": "array",
+				"items": {
+					"type": "string",
+					"minLength": 1
+				}
+			},
+			"departments": {
+				"type": "array",
+				"items": {
+					"type": "string",
+					"minLength": 1
+				}
+			}
+		}
+	}`
+
+	InvestigationWorkspace = `{
+		"type": "object",
+		"required": ["name", "log_type", "history_ids"],
+		"properties": {
+			"name": {
+				"type": "string",
+				"minLength": 1,
+				"maxLength": 128
+			},
+			"log_type": {
+				"type": "string",
+				"enum": ["login", "management", "operation"]
+			},
+			"history_ids": {
+				"type": "array",
+				"minItems": 1,
+				"maxItems": 20,
+				"items": {
+					"type": "string",
+					"minLength": 1
+				}
+			},
+			"expire_days": {
+				"type": "integer",
+				"minimum": 1,
+				"maximum": 30
+			}
+		}
+	}`
+
+	ReportSubscription = `{
+		"type": "object",
+		"required": ["name", "log_type", "format", "schedule", "schedule_time", "subscribers", "enabled"],
+		"properties": {
+			"name": {
+				"type": "string",
+				"minLength": 1,
+				"maxLength": 128
+			},
+			"log_type": {
+				"type": "string",
+				"enum": ["login", "management", "operation"]
+			},
+			"condition": {
+				"type": "object"
+			},
+			"columns": {
+				"type": "array",
+				"items": {
+					"type": "string",
+					"minLength": 1
+				}
+			},
+			"format": {
+				"type": "string",
+				"enum": ["csv", "jsonl"]
+			},
+			"schedule": {
+				"type": "string",
+				"enum": ["daily", "weekly", "monthly"]
+			},
+			"schedule_day": {
+				"type": "integer",
+				"minimum": 0,
+				"maximum": 31
+			},
+			"schedule_time": {
+				"type": "string",
+				"pattern": "^([01][0-9]|2[0-3]):[0-5][0-9]$"
+			},
+			"subscribers": {
+				"type": "array",
+				"minItems": 1,
+				"maxItems": 100,
+				"items": {
+					"type": "string",
+					"minLength": 1
+				}
+			},
+			"enabled": {
+				"type": "boolean"
+			}
+		}
+	}`
+
 	PutHistoryPwdStatus = `{
 		"type": "object",
 		"required": ["status"],
@@ -0,0 +1,60 @@
+package rectypes
+
+import (
+	"strings"
+	"time"
+
+	recconsts "AuditLog/common/constants/recenums"
+)
+
+// GetPartitionRange 获取t所在分区的时间范围 [begin, end)
+func GetPartitionRange(period string, t time.Time) (begin, end time.Time) {
+	t = t.UTC()
+
+	if period == recconsts.RolloverPeriodDay {
+		begin = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		end = begin.AddDate(0, 0, 1)
+
+		return
+	}
+
+	begin = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end = begin.AddDate(0, 1, 0)
+
+	return
+}
+
+// GetPartitionIndex 获取别名alias下t所在分区的索引名
+func GetPartitionIndex(alias RecLogIndex, period string, t time.Time) string {
+	layout := recconsts.PartitionMonthLayout
+	if period == recconsts.RolloverPeriodDay {
+		layout = recconsts.PartitionDayLayout
+	}
+
+	return string(alias) + "-" + t.UTC().Format(layout)
+}
+
+// ParsePartitionIndex 解析分区索引名，返回分区的时间范围
+// 同时支持按天和按月的索引名，分区周期调整后旧的分区仍能被识别
+func ParsePartitionIndex(alias RecLogIndex, index string) (begin, end time.Time, ok bool) {
+	suffix, found := strings.CutPrefix(index, string(alias)+"-")
+	if !found {
+		return
+	}
+
+	if t, err := time.Parse(recconsts.PartitionDayLayout, suffix); err == nil {
+		begin, end = GetPartitionRange(recconsts.RolloverPeriodDay, t)
+		ok = true
+
+		return
+	}
+
+	if t, err := time.Parse(recconsts.PartitionMonthLayout, suffix); err == nil {
+		begin, end = GetPartitionRange(recconsts.RolloverPeriodMonth, t)
+		ok = true
+
+		return
+	}
+
+	return
+}
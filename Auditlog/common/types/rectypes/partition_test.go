@@ -0,0 +1,55 @@
+package rectypes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	recconsts "AuditLog/common/constants/recenums"
+)
+
+func TestGetPartitionIndex(t *testing.T) {
+	alias := RecLogIndex(recconsts.IndexPrefix + "doc_operation")
+	tm := time.Date(2024, 3, 5, 23, 30, 0, 0, time.FixedZone("CST", -8*3600))
+
+	assert.Equal(t, "as-operation-log-doc_operation-2024.03", GetPartitionIndex(alias, recconsts.RolloverPeriodMonth, tm))
+	// 按UTC时间分区
+	assert.Equal(t, "as-operation-log-doc_operation-2024.03.06", GetPartitionIndex(alias, recconsts.RolloverPeriodDay, tm))
+	// 未知周期按月处理
+	assert.Equal(t, "as-operation-log-doc_operation-2024.03", GetPartitionIndex(alias, "", tm))
+}
+
+func TestGetPartitionRange(t *testing.T) {
+	tm := time.Date(2024, 12, 31, 12, 0, 0, 0, time.UTC)
+
+	begin, end := GetPartitionRange(recconsts.RolloverPeriodMonth, tm)
+	assert.Equal(t, time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), begin)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), end)
+
+	begin, end = GetPartitionRange(recconsts.RolloverPeriodDay, tm)
+	assert.Equal(t, time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), begin)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), end)
+}
+
+func TestParsePartitionIndex(t *testing.T) {
+	alias := RecLogIndex(recconsts.IndexPrefix + "doc_operation")
+
+	begin, end, ok := ParsePartitionIndex(alias, "as-operation-log-doc_operation-2024.02")
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), begin)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), end)
+
+	begin, end, ok = ParsePartitionIndex(alias, "as-operation-log-doc_operation-2024.02.29")
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), begin)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), end)
+
+	// 旧的未分区索引及其他别名的索引
+	_, _, ok = ParsePartitionIndex(alias, "as-operation-log-doc_operation")
+	assert.False(t, ok)
+	_, _, ok = ParsePartitionIndex(alias, "as-operation-log-kc_operation-2024.02")
+	assert.False(t, ok)
+	_, _, ok = ParsePartitionIndex(alias, "as-operation-log-doc_operation-backup")
+	assert.False(t, ok)
+}
@@ -0,0 +1,119 @@
+package alertutils
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"AuditLog/common/constants/alertconsts"
+	"AuditLog/models"
+	"AuditLog/models/alertmodels"
+)
+
+// 默认工作日, 周一到周五
+var defaultWorkDays = []int{1, 2, 3, 4, 5}
+
+// EventFromAuditLog 将审计日志转换为告警事件
+func EventFromAuditLog(logType, logID string, log *models.AuditLog) *alertmodels.Event {
+	return &alertmodels.Event{
+		LogType: logType,
+		LogID:   logID,
+		Date:    log.Date,
+		Fields: map[string]string{
+			alertconsts.FieldUserID:   log.UserID,
+			alertconsts.FieldUserName: log.UserName,
+			alertconsts.FieldUserType: log.UserType,
+			alertconsts.FieldIP:       log.IP,
+			alertconsts.FieldLevel:    strconv.Itoa(log.Level),
+			alertconsts.FieldOpType:   strconv.Itoa(log.OpType),
+			alertconsts.FieldObjID:    log.ObjID,
+			alertconsts.FieldObjName:  log.ObjName,
+			alertconsts.FieldObjType:  strconv.Itoa(log.ObjType),
+			alertconsts.FieldMsg:      log.Msg,
+			alertconsts.FieldExMsg:    log.Exmsg,
+		},
+	}
+}
+
+// MatchConditions 判断事件是否满足所有条件, 条件为空时匹配所有事件
+func MatchConditions(conditions []*alertmodels.Condition, event *alertmodels.Event) bool {
+	for _, cond := range conditions {
+		value := event.Fields[cond.Field]
+		var matched bool
+		switch cond.Operator {
+		case alertconsts.OpEq:
+			matched = value == cond.Value
+		case alertconsts.OpNe:
+			matched = value != cond.Value
+		case alertconsts.OpContains:
+			matched = strings.Contains(value, cond.Value)
+		case alertconsts.OpIn:
+			matched = slices.Contains(cond.Values, value)
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseClock 解析 HH:MM 格式的时间, 返回距当天零点的分钟数
+func ParseClock(s string) (minutes int, err error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid clock %q, expect HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// IsOffHours 判断时间是否在工作时间之外
+// workDays 为 1-7 表示周一到周日, 为空时默认周一到周五; workStart 晚于 workEnd 时表示跨零点的工作时间
+func IsOffHours(t time.Time, workStart, workEnd string, workDays []int) (offHours bool, err error) {
+	start, err := ParseClock(workStart)
+	if err != nil {
+		return
+	}
+	end, err := ParseClock(workEnd)
+	if err != nil {
+		return
+	}
+
+	if len(workDays) == 0 {
+		workDays = defaultWorkDays
+	}
+	weekday := int(t.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	if !slices.Contains(workDays, weekday) {
+		return true, nil
+	}
+
+	now := t.Hour()*60 + t.Minute()
+	if start <= end {
+		return now < start || now >= end, nil
+	}
+	return now < start && now >= end, nil
+}
+
+// ParseWorkDays 解析逗号分隔的工作日
+func ParseWorkDays(s string) (days []int) {
+	days = make([]int, 0)
+	for _, item := range strings.Split(s, ",") {
+		if day, err := strconv.Atoi(strings.TrimSpace(item)); err == nil {
+			days = append(days, day)
+		}
+	}
+	return
+}
+
+// FormatWorkDays 工作日格式化为逗号分隔的字符串
+func FormatWorkDays(days []int) string {
+	items := make([]string, 0, len(days))
+	for _, day := range days {
+		items = append(items, strconv.Itoa(day))
+	}
+	return strings.Join(items, ",")
+}
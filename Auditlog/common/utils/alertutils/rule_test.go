@@ -0,0 +1,99 @@
+package alertutils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"AuditLog/common/constants/alertconsts"
+	"AuditLog/models"
+	"AuditLog/models/alertmodels"
+)
+
+func TestMatchConditions(t *testing.T) {
+	event := EventFromAuditLog("login", "1", &models.AuditLog{
+		UserID:   "user1",
+		UserName: "张三",
+		Level:    2,
+		OpType:   1,
+		Msg:      "登录失败, 密码错误",
+	})
+
+	t.Run("条件为空时匹配所有事件", func(t *testing.T) {
+		assert.True(t, MatchConditions(nil, event))
+	})
+
+	t.Run("所有条件满足", func(t *testing.T) {
+		conditions := []*alertmodels.Condition{
+			{Field: alertconsts.FieldLevel, Operator: alertconsts.OpEq, Value: "2"},
+			{Field: alertconsts.FieldUserID, Operator: alertconsts.OpNe, Value: "admin"},
+			{Field: alertconsts.FieldMsg, Operator: alertconsts.OpContains, Value: "失败"},
+			{Field: alertconsts.FieldOpType, Operator: alertconsts.OpIn, Values: []string{"1", "4"}},
+		}
+		assert.True(t, MatchConditions(conditions, event))
+	})
+
+	t.Run("任一条件不满足", func(t *testing.T) {
+		conditions := []*alertmodels.Condition{
+			{Field: alertconsts.FieldLevel, Operator: alertconsts.OpEq, Value: "2"},
+			{Field: alertconsts.FieldOpType, Operator: alertconsts.OpIn, Values: []string{"4"}},
+		}
+		assert.False(t, MatchConditions(conditions, event))
+	})
+
+	t.Run("未知运算符不匹配", func(t *testing.T) {
+		conditions := []*alertmodels.Condition{{Field: alertconsts.FieldLevel, Operator: "gt", Value: "1"}}
+		assert.False(t, MatchConditions(conditions, event))
+	})
+}
+
+func TestIsOffHours(t *testing.T) {
+	// 2024-12-19 为周四
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 12, day, hour, minute, 0, 0, time.Local)
+	}
+
+	t.Run("工作日工作时间内", func(t *testing.T) {
+		off, err := IsOffHours(at(19, 9, 0), "09:00", "18:00", nil)
+		assert.NoError(t, err)
+		assert.False(t, off)
+	})
+
+	t.Run("工作日工作时间外", func(t *testing.T) {
+		off, err := IsOffHours(at(19, 18, 0), "09:00", "18:00", nil)
+		assert.NoError(t, err)
+		assert.True(t, off)
+	})
+
+	t.Run("非工作日", func(t *testing.T) {
+		off, err := IsOffHours(at(22, 10, 0), "09:00", "18:00", []int{1, 2, 3, 4, 5})
+		assert.NoError(t, err)
+		assert.True(t, off)
+
+		off, err = IsOffHours(at(22, 10, 0), "09:00", "18:00", []int{7})
+		assert.NoError(t, err)
+		assert.False(t, off)
+	})
+
+	t.Run("跨零点的工作时间", func(t *testing.T) {
+		off, err := IsOffHours(at(19, 23, 0), "22:00", "06:00", nil)
+		assert.NoError(t, err)
+		assert.False(t, off)
+
+		off, err = IsOffHours(at(19, 12, 0), "22:00", "06:00", nil)
+		assert.NoError(t, err)
+		assert.True(t, off)
+	})
+
+	t.Run("无效的时间格式", func(t *testing.T) {
+		_, err := IsOffHours(at(19, 12, 0), "9点", "18:00", nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestWorkDays(t *testing.T) {
+	assert.Equal(t, []int{1, 3, 5}, ParseWorkDays("1, 3,5,x"))
+	assert.Equal(t, []int{}, ParseWorkDays(""))
+	assert.Equal(t, "1,3,5", FormatWorkDays([]int{1, 3, 5}))
+}
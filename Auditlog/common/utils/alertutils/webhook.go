@@ -0,0 +1,36 @@
+package alertutils
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// IsForbiddenWebhookIP 判断webhook地址是否为回环、链路本地、私有等内部地址
+func IsForbiddenWebhookIP(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsPrivate() ||
+		ip.IsUnspecified()
+}
+
+// CheckWebhookURL 校验webhook地址, 只支持http和https, 不允许指向内部地址
+// 域名在推送时解析, 解析结果由webhook推送时再次校验
+func CheckWebhookURL(webhook string) error {
+	u, err := url.Parse(webhook)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("invalid webhook: %s, only http and https are supported", webhook)
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("invalid webhook: %s, internal address is not allowed", webhook)
+	}
+	if ip := net.ParseIP(host); ip != nil && IsForbiddenWebhookIP(ip) {
+		return fmt.Errorf("invalid webhook: %s, internal address is not allowed", webhook)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package alertutils
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsForbiddenWebhookIP(t *testing.T) {
+	for _, ip := range []string{"127.0.0.1", "::1", "169.254.169.254", "fe80::1", "10.0.0.1", "172.16.0.1", "192.168.1.1", "fd00::1", "0.0.0.0"} {
+		assert.True(t, IsForbiddenWebhookIP(net.ParseIP(ip)), ip)
+	}
+	for _, ip := range []string{"8.8.8.8", "2001:4860:4860::8888"} {
+		assert.False(t, IsForbiddenWebhookIP(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestCheckWebhookURL(t *testing.T) {
+	for _, webhook := range []string{"http://example.com/hook", "https://8.8.8.8:8443/hook"} {
+		assert.NoError(t, CheckWebhookURL(webhook), webhook)
+	}
+	for _, webhook := range []string{
+		"file:///etc/passwd", "example.com/hook", "http://",
+		"http://localhost/hook", "http://LOCALHOST./hook", "http://127.0.0.1:8080/hook",
+		"http://[::1]/hook", "http://169.254.169.254/latest/meta-data", "http://10.0.0.1/hook",
+	} {
+		assert.Error(t, CheckWebhookURL(webhook), webhook)
+	}
+}
@@ -0,0 +1,204 @@
+package archiveutils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"AuditLog/models/lsmodels"
+)
+
+func TestParseDumpFormat(t *testing.T) {
+	base, compressed, err := ParseDumpFormat("jsonl.gz")
+	assert.NoError(t, err)
+	assert.Equal(t, "jsonl", base)
+	assert.True(t, compressed)
+
+	base, compressed, err = ParseDumpFormat("csv")
+	assert.NoError(t, err)
+	assert.Equal(t, "csv", base)
+	assert.False(t, compressed)
+
+	_, _, err = ParseDumpFormat("txt.gz")
+	assert.Error(t, err)
+
+	assert.Equal(t, "csv.gz.enc", DumpFileSuffix("csv.gz", true))
+	assert.Equal(t, "csv.gz", DumpFileSuffix("csv.gz", false))
+}
+
+func TestEncrypt(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	t.Run("解析公钥", func(t *testing.T) {
+		pkix, _ := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+		pub, err := ParsePublicKey(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix})))
+		assert.NoError(t, err)
+		assert.True(t, pub.Equal(&priv.PublicKey))
+
+		pkcs1 := x509.MarshalPKCS1PublicKey(&priv.PublicKey)
+		pub, err = ParsePublicKey(string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: pkcs1})))
+		assert.NoError(t, err)
+		assert.True(t, pub.Equal(&priv.PublicKey))
+
+		_, err = ParsePublicKey("not a key")
+		assert.Error(t, err)
+	})
+
+	t.Run("解析私钥", func(t *testing.T) {
+		pkcs1 := x509.MarshalPKCS1PrivateKey(priv)
+		got, err := ParsePrivateKey(string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: pkcs1})))
+		assert.NoError(t, err)
+		assert.True(t, got.Equal(priv))
+
+		pkcs8, _ := x509.MarshalPKCS8PrivateKey(priv)
+		got, err = ParsePrivateKey(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})))
+		assert.NoError(t, err)
+		assert.True(t, got.Equal(priv))
+
+		_, err = ParsePrivateKey("not a key")
+		assert.Error(t, err)
+	})
+
+	encrypt := func(plain []byte) []byte {
+		buf := &bytes.Buffer{}
+		w, err := NewEncryptWriter(buf, &priv.PublicKey)
+		assert.NoError(t, err)
+		// 分多次写入, 覆盖跨块的情况
+		for i := 0; i < len(plain); i += 1000 {
+			_, err = w.Write(plain[i:min(i+1000, len(plain))])
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+	decrypt := func(data []byte) ([]byte, error) {
+		r, err := NewDecryptReader(bytes.NewReader(data), priv)
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(r)
+	}
+
+	t.Run("加解密", func(t *testing.T) {
+		for _, size := range []int{0, 100, encChunkSize, 3*encChunkSize + 17} {
+			plain := []byte(strings.Repeat("a", size))
+			data := encrypt(plain)
+			assert.NotContains(t, string(data), "aaaa")
+
+			got, err := decrypt(data)
+			assert.NoError(t, err)
+			assert.Equal(t, plain, got)
+		}
+	})
+
+	t.Run("文件被截断", func(t *testing.T) {
+		data := encrypt([]byte(strings.Repeat("a", 2*encChunkSize+1)))
+		_, err := decrypt(data[:len(data)-30])
+		assert.Error(t, err)
+
+		// 在块边界截断
+		_, err = decrypt(data[:len(data)-(1+4+16)])
+		assert.ErrorIs(t, err, ErrTruncatedArchive)
+	})
+
+	t.Run("文件被篡改", func(t *testing.T) {
+		data := encrypt([]byte("hello"))
+		data[len(data)-1] ^= 0xff
+		_, err := decrypt(data)
+		assert.Error(t, err)
+	})
+
+	t.Run("非加密文件", func(t *testing.T) {
+		_, err := decrypt([]byte("plain text"))
+		assert.ErrorIs(t, err, ErrInvalidArchive)
+	})
+
+	t.Run("打开转存文件", func(t *testing.T) {
+		plain := []byte("log content\n")
+		gz := &bytes.Buffer{}
+		gw := gzip.NewWriter(gz)
+		_, _ = gw.Write(plain)
+		assert.NoError(t, gw.Close())
+
+		cases := []struct {
+			name string
+			data []byte
+			base string
+		}{
+			{"login.csv", plain, "csv"},
+			{"login.jsonl.gz", gz.Bytes(), "jsonl"},
+			{"login.xml.gz.enc", encrypt(gz.Bytes()), "xml"},
+			{"login.2024-12-19.csv.enc", encrypt(plain), "csv"},
+		}
+		for _, c := range cases {
+			base, r, err := OpenArchive(bytes.NewReader(c.data), c.name, priv)
+			assert.NoError(t, err, c.name)
+			assert.Equal(t, c.base, base, c.name)
+			got, err := io.ReadAll(r)
+			assert.NoError(t, err, c.name)
+			assert.Equal(t, plain, got, c.name)
+		}
+
+		_, _, err := OpenArchive(bytes.NewReader(encrypt(plain)), "login.csv.enc", nil)
+		assert.Error(t, err)
+
+		_, _, err = OpenArchive(bytes.NewReader(plain), "login.txt", priv)
+		assert.Error(t, err)
+	})
+}
+
+func TestManifest(t *testing.T) {
+	content := []byte("log content")
+	manifest := &lsmodels.DumpManifest{
+		Version:     1,
+		FileName:    "login.2024-12-19.csv",
+		LogType:     "login",
+		Format:      "csv",
+		RecordCount: 1,
+		FirstLogID:  "1",
+		LastLogID:   "1",
+		Size:        int64(len(content)),
+		Checksum:    Checksum(content),
+	}
+	signature, err := SignManifest("secret", manifest)
+	assert.NoError(t, err)
+	manifest.Signature = signature
+
+	t.Run("校验通过", func(t *testing.T) {
+		assert.NoError(t, VerifyManifest("secret", manifest, content))
+	})
+
+	t.Run("密钥不同", func(t *testing.T) {
+		assert.ErrorIs(t, VerifyManifest("other", manifest, content), ErrManifestSignature)
+	})
+
+	t.Run("清单被修改", func(t *testing.T) {
+		modified := *manifest
+		modified.RecordCount = 2
+		assert.ErrorIs(t, VerifyManifest("secret", &modified, content), ErrManifestSignature)
+	})
+
+	t.Run("文件被修改", func(t *testing.T) {
+		assert.ErrorIs(t, VerifyManifest("secret", manifest, []byte("log c0ntent")), ErrManifestChecksum)
+	})
+
+	t.Run("流式校验", func(t *testing.T) {
+		assert.NoError(t, VerifyManifestSum("secret", manifest, int64(len(content)), Checksum(content)))
+		assert.ErrorIs(t, VerifyManifestSum("secret", manifest, int64(len(content))+1, Checksum(content)), ErrManifestChecksum)
+	})
+
+	t.Run("密钥为空", func(t *testing.T) {
+		_, err := SignManifest("", manifest)
+		assert.ErrorIs(t, err, ErrEmptySecret)
+		assert.ErrorIs(t, VerifyManifest("", manifest, content), ErrEmptySecret)
+	})
+}
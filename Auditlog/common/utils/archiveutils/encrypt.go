@@ -0,0 +1,276 @@
+package archiveutils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// 加密文件格式:
+//
+//	magic(6) | 密钥密文长度(2) | 密钥密文 | nonce前缀(4) | 数据块...
+//
+// 数据密钥为随机生成的 AES-256 密钥, 使用 RSA-OAEP(SHA-256) 公钥加密后写入文件头;
+// 明文按 encChunkSize 分块, 每块使用 AES-GCM 加密并以 4 字节长度开头,
+// nonce 为 nonce前缀 + 块序号, 最后一块的附加数据为 1, 以此识别文件被截断
+const (
+	encMagic           = "ALENC1"
+	encChunkSize       = 64 * 1024
+	encNoncePrefixSize = 4
+	encKeySize         = 32
+)
+
+var (
+	// ErrInvalidArchive 不是加密的转存文件
+	ErrInvalidArchive = errors.New("invalid encrypted archive")
+	// ErrTruncatedArchive 加密的转存文件不完整
+	ErrTruncatedArchive = errors.New("encrypted archive is truncated")
+)
+
+// ParsePublicKey 解析PEM格式的RSA公钥, 支持 PKIX 和 PKCS#1
+func ParsePublicKey(pemStr string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(pemStr))
+	if block == nil {
+		return nil, errors.New("public key is not in pem format")
+	}
+
+	if pub, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return pub, nil
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse public key failed: %w", err)
+	}
+	pub, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("public key is not rsa")
+	}
+	return pub, nil
+}
+
+// ParsePrivateKey 解析PEM格式的RSA私钥, 支持 PKCS#1 和 PKCS#8
+func ParsePrivateKey(pemStr string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemStr))
+	if block == nil {
+		return nil, errors.New("private key is not in pem format")
+	}
+
+	if priv, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return priv, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key failed: %w", err)
+	}
+	priv, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not rsa")
+	}
+	return priv, nil
+}
+
+type encryptWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint64
+	buf     []byte
+	closed  bool
+}
+
+// NewEncryptWriter 创建加密写入器, Close 时写入最后一块, 不关闭下层写入器
+func NewEncryptWriter(w io.Writer, pub *rsa.PublicKey) (io.WriteCloser, error) {
+	key := make([]byte, encKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("wrap data key failed: %w", err)
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := make([]byte, encNoncePrefixSize)
+	if _, err = rand.Read(prefix); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, len(encMagic)+2+len(wrapped)+len(prefix))
+	header = append(header, encMagic...)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(wrapped)))
+	header = append(header, wrapped...)
+	header = append(header, prefix...)
+	if _, err = w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &encryptWriter{
+		w:      w,
+		aead:   aead,
+		prefix: prefix,
+		buf:    make([]byte, 0, encChunkSize),
+	}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (n int, err error) {
+	if e.closed {
+		return 0, errors.New("write to closed encrypt writer")
+	}
+
+	for len(p) > 0 {
+		// 缓存已满且还有数据时才写出, 保证最后一块在 Close 时写出
+		if len(e.buf) == encChunkSize {
+			if err = e.seal(false); err != nil {
+				return n, err
+			}
+		}
+
+		size := min(encChunkSize-len(e.buf), len(p))
+		e.buf = append(e.buf, p[:size]...)
+		p = p[size:]
+		n += size
+	}
+	return n, nil
+}
+
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.seal(true)
+}
+
+func (e *encryptWriter) seal(final bool) error {
+	chunk := e.aead.Seal(nil, chunkNonce(e.prefix, e.counter), e.buf, chunkAAD(final))
+	e.counter++
+	e.buf = e.buf[:0]
+
+	if _, err := e.w.Write(binary.BigEndian.AppendUint32(nil, uint32(len(chunk)))); err != nil {
+		return err
+	}
+	_, err := e.w.Write(chunk)
+	return err
+}
+
+type decryptReader struct {
+	r       io.Reader
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint64
+	plain   []byte
+	done    bool
+}
+
+// NewDecryptReader 创建解密读取器, 用于解密转存文件
+func NewDecryptReader(r io.Reader, priv *rsa.PrivateKey) (io.Reader, error) {
+	magic := make([]byte, len(encMagic)+2)
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic[:len(encMagic)]) != encMagic {
+		return nil, ErrInvalidArchive
+	}
+
+	wrapped := make([]byte, binary.BigEndian.Uint16(magic[len(encMagic):]))
+	if _, err := io.ReadFull(r, wrapped); err != nil {
+		return nil, ErrInvalidArchive
+	}
+	key, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, priv, wrapped, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key failed: %w", err)
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := make([]byte, encNoncePrefixSize)
+	if _, err = io.ReadFull(r, prefix); err != nil {
+		return nil, ErrInvalidArchive
+	}
+
+	return &decryptReader{r: r, aead: aead, prefix: prefix}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (n int, err error) {
+	for len(d.plain) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err = d.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n = copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+func (d *decryptReader) open() (err error) {
+	size := make([]byte, 4)
+	if _, err = io.ReadFull(d.r, size); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrTruncatedArchive
+		}
+		return err
+	}
+
+	length := binary.BigEndian.Uint32(size)
+	if length > encChunkSize+uint32(d.aead.Overhead()) {
+		return ErrInvalidArchive
+	}
+	chunk := make([]byte, length)
+	if _, err = io.ReadFull(d.r, chunk); err != nil {
+		return ErrTruncatedArchive
+	}
+
+	nonce := chunkNonce(d.prefix, d.counter)
+	d.counter++
+	if d.plain, err = d.aead.Open(nil, nonce, chunk, chunkAAD(false)); err == nil {
+		return nil
+	}
+	if d.plain, err = d.aead.Open(nil, nonce, chunk, chunkAAD(true)); err != nil {
+		return fmt.Errorf("decrypt chunk failed: %w", err)
+	}
+
+	// 最后一块之后不应再有数据
+	if n, _ := d.r.Read(make([]byte, 1)); n > 0 {
+		return ErrInvalidArchive
+	}
+	d.done = true
+	return nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(prefix []byte, counter uint64) []byte {
+	return binary.BigEndian.AppendUint64(append([]byte{}, prefix...), counter)
+}
+
+func chunkAAD(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
@@ -0,0 +1,58 @@
+package archiveutils
+
+import (
+	"compress/gzip"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"AuditLog/common/constants/lsconsts"
+)
+
+// ParseDumpFormat 解析转存格式, 返回文件格式和是否gzip压缩
+func ParseDumpFormat(format string) (base string, compressed bool, err error) {
+	if !slices.Contains(lsconsts.AllDumpFormat, format) {
+		return "", false, fmt.Errorf("unsupported dump format: %s", format)
+	}
+
+	base, compressed = strings.CutSuffix(format, "."+lsconsts.GzipSuffix)
+	return base, compressed, nil
+}
+
+// DumpFileSuffix 获取转存文件后缀, 加密的文件追加 .enc
+func DumpFileSuffix(format string, encrypted bool) string {
+	if encrypted {
+		return format + "." + lsconsts.EncryptedSuffix
+	}
+	return format
+}
+
+// OpenArchive 根据转存文件名依次解密和解压, 返回文件格式和明文读取器
+func OpenArchive(r io.Reader, fileName string, priv *rsa.PrivateKey) (base string, plain io.Reader, err error) {
+	name, encrypted := strings.CutSuffix(fileName, "."+lsconsts.EncryptedSuffix)
+	if encrypted {
+		if priv == nil {
+			return "", nil, errors.New("private key is required to open encrypted archive")
+		}
+		if r, err = NewDecryptReader(r, priv); err != nil {
+			return "", nil, err
+		}
+	}
+
+	name, compressed := strings.CutSuffix(name, "."+lsconsts.GzipSuffix)
+	if compressed {
+		if r, err = gzip.NewReader(r); err != nil {
+			return "", nil, fmt.Errorf("open gzip archive failed: %w", err)
+		}
+	}
+
+	idx := strings.LastIndex(name, ".")
+	if idx < 0 || !slices.Contains(lsconsts.AllDumpFormat, name[idx+1:]) {
+		return "", nil, fmt.Errorf("unsupported dump file: %s", fileName)
+	}
+
+	return name[idx+1:], r, nil
+}
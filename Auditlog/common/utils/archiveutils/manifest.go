@@ -0,0 +1,67 @@
+package archiveutils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"AuditLog/models/lsmodels"
+)
+
+var (
+	// ErrManifestSignature 转存清单签名无效
+	ErrManifestSignature = errors.New("manifest signature is invalid")
+	// ErrManifestChecksum 转存文件与清单不一致
+	ErrManifestChecksum = errors.New("archive does not match manifest")
+	// ErrEmptySecret 签名密钥为空, 空密钥的签名可被任何人伪造
+	ErrEmptySecret = errors.New("manifest secret is empty")
+)
+
+// Checksum 计算转存文件的 sha256
+func Checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// SignManifest 使用密钥对转存清单签名, 签名内容为去掉签名字段后的json
+func SignManifest(secret string, manifest *lsmodels.DumpManifest) (string, error) {
+	if secret == "" {
+		return "", ErrEmptySecret
+	}
+
+	unsigned := *manifest
+	unsigned.Signature = ""
+
+	data, err := json.Marshal(&unsigned)
+	if err != nil {
+		return "", fmt.Errorf("marshal manifest failed: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// VerifyManifest 校验转存清单签名, 以及转存文件的大小和 sha256
+func VerifyManifest(secret string, manifest *lsmodels.DumpManifest, content []byte) error {
+	return VerifyManifestSum(secret, manifest, int64(len(content)), Checksum(content))
+}
+
+// VerifyManifestSum 使用流式读取时计算的文件大小和 sha256 校验转存清单
+func VerifyManifestSum(secret string, manifest *lsmodels.DumpManifest, size int64, checksum string) error {
+	signature, err := SignManifest(secret, manifest)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(signature), []byte(manifest.Signature)) {
+		return ErrManifestSignature
+	}
+
+	if size != manifest.Size || checksum != manifest.Checksum {
+		return ErrManifestChecksum
+	}
+	return nil
+}
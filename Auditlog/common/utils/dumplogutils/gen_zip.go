@@ -10,25 +10,34 @@ import (
 	"golang.org/x/text/encoding/simplifiedchinese"
 )
 
+// ZipEntry zip文件中的一个文件
+type ZipEntry struct {
+	Name    string
+	Content []byte
+}
+
 // GenZipFile 生成zip文件
 func GenZipFile(fileContent []byte, fileName string, pwd string) (zipContent []byte, err error) {
+	return GenZipFiles([]*ZipEntry{{Name: fileName, Content: fileContent}}, pwd)
+}
+
+// GenZipFiles 生成包含多个文件的zip文件
+func GenZipFiles(entries []*ZipEntry, pwd string) (zipContent []byte, err error) {
 	// 验证输入参数
-	if len(fileContent) == 0 {
-		return nil, errors.New("[GenZipFile] file content is empty")
-	}
+	estimatedSize := 1024
+	for _, entry := range entries {
+		if len(entry.Content) == 0 {
+			return nil, errors.New("[GenZipFile] file content is empty")
+		}
 
-	if fileName == "" {
-		return nil, errors.New("[GenZipFile] file name is empty")
-	}
+		if entry.Name == "" {
+			return nil, errors.New("[GenZipFile] file name is empty")
+		}
 
-	// 将文件名转换为GBK编码
-	gbkFileName, err := UTF8ToGBK(fileName)
-	if err != nil {
-		return nil, fmt.Errorf("[GenZipFile] convert filename to GBK error: %w", err)
+		estimatedSize += len(entry.Content)
 	}
 
 	// 创建一个预估大小的缓冲区，避免频繁扩容
-	estimatedSize := len(fileContent) + 1024
 	buf := bytes.NewBuffer(make([]byte, 0, estimatedSize))
 
 	zipWriter := zip.NewWriter(buf)
@@ -39,22 +48,32 @@ func GenZipFile(fileContent []byte, fileName string, pwd string) (zipContent []b
 			return
 		}
 
-		zipContent = buf.Bytes()
+		if err == nil {
+			zipContent = buf.Bytes()
+		}
 	}()
 
-	var writer io.Writer
-	if pwd != "" {
-		writer, err = zipWriter.Encrypt(gbkFileName, pwd, zip.StandardEncryption)
-	} else {
-		writer, err = zipWriter.Create(gbkFileName)
-	}
+	for _, entry := range entries {
+		// 将文件名转换为GBK编码
+		gbkFileName, err := UTF8ToGBK(entry.Name)
+		if err != nil {
+			return nil, fmt.Errorf("[GenZipFile] convert filename to GBK error: %w", err)
+		}
 
-	if err != nil {
-		return nil, fmt.Errorf("[GenZipFile] create zip entry error: %w", err)
-	}
+		var writer io.Writer
+		if pwd != "" {
+			writer, err = zipWriter.Encrypt(gbkFileName, pwd, zip.StandardEncryption)
+		} else {
+			writer, err = zipWriter.Create(gbkFileName)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("[GenZipFile] create zip entry error: %w", err)
+		}
 
-	if _, err = writer.Write(fileContent); err != nil {
-		return nil, fmt.Errorf("[GenZipFile] write file content error: %w", err)
+		if _, err = writer.Write(entry.Content); err != nil {
+			return nil, fmt.Errorf("[GenZipFile] write file content error: %w", err)
+		}
 	}
 
 	return
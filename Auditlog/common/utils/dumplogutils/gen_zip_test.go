@@ -63,6 +63,40 @@ func TestGenZipFile(t *testing.T) {
 	})
 }
 
+func TestGenZipFiles(t *testing.T) {
+	entries := []*ZipEntry{
+		{Name: "test.csv", Content: []byte("这是测试内容")},
+		{Name: "test.csv.manifest.json", Content: []byte(`{"version":1}`)},
+	}
+
+	t.Run("压缩多个文件", func(t *testing.T) {
+		zipContent, err := GenZipFiles(entries, "")
+		if err != nil {
+			t.Fatalf("生成zip文件失败: %v", err)
+		}
+
+		reader, err := zip.NewReader(bytes.NewReader(zipContent), int64(len(zipContent)))
+		if err != nil {
+			t.Fatalf("读取zip文件失败: %v", err)
+		}
+
+		if len(reader.File) != 2 {
+			t.Fatalf("期望2个文件，实际获得%d个文件", len(reader.File))
+		}
+
+		if reader.File[1].Name != "test.csv.manifest.json" {
+			t.Errorf("期望文件名%s，实际获得%s", "test.csv.manifest.json", reader.File[1].Name)
+		}
+	})
+
+	t.Run("文件内容为空", func(t *testing.T) {
+		_, err := GenZipFiles([]*ZipEntry{entries[0], {Name: "empty.json"}}, "")
+		if err == nil {
+			t.Error("期望返回错误，实际未返回")
+		}
+	})
+}
+
 func TestSplitFile(t *testing.T) {
 	// 测试数据准备
 	fileContent := []byte("abcdefghijklmnopqrstuvwxyz")
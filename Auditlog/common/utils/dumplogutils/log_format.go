@@ -13,6 +13,7 @@ import (
 	"AuditLog/common/utils"
 	"AuditLog/locale"
 	"AuditLog/models"
+	"AuditLog/models/lcmodels"
 )
 
 // LogInfo2CSVString 将日志信息转换为CSV格式字符串
@@ -97,6 +98,113 @@ func LogInfo2XMLString(log *models.LogPO, logType string) (string, error) {
 	), nil
 }
 
+// 转存为JSON Lines格式时的一条日志
+type jsonlLog struct {
+	LogID          string          `json:"log_id"`
+	Date           string          `json:"date"`
+	UserName       string          `json:"user_name"`
+	UserPaths      string          `json:"user_paths"`
+	Level          string          `json:"level"`
+	OpType         string          `json:"op_type"`
+	IP             string          `json:"ip"`
+	Mac            string          `json:"mac"`
+	Msg            string          `json:"msg"`
+	ExMsg          string          `json:"ex_msg"`
+	UserAgent      string          `json:"user_agent"`
+	AdditionalInfo json.RawMessage `json:"additional_info"`
+	ObjName        string          `json:"obj_name"`
+	ObjType        string          `json:"obj_type"`
+}
+
+// LogInfo2JSONLString 将日志信息转换为JSON Lines格式的一行
+func LogInfo2JSONLString(log *models.LogPO, logType string) (string, error) {
+	additionalInfo, err := formatAdditionalInfo(log.AdditionalInfo, log.ObjID)
+	if err != nil {
+		return "", fmt.Errorf("format additional info failed: %w", err)
+	}
+
+	line, err := json.Marshal(&jsonlLog{
+		LogID:          log.LogID,
+		Date:           utils.FormatTime(time.UnixMicro(log.Date).Local()),
+		UserName:       log.UserName,
+		UserPaths:      log.UserPaths,
+		Level:          locale.GetRCLogLevelI18n(context.Background(), locale.LogLevelMap[log.Level]),
+		OpType:         formatOpType(logType, log.OpType),
+		IP:             log.IP,
+		Mac:            log.MAC,
+		Msg:            log.Msg,
+		ExMsg:          log.ExMsg,
+		UserAgent:      log.UserAgent,
+		AdditionalInfo: json.RawMessage(additionalInfo),
+		ObjName:        log.ObjName,
+		ObjType:        locale.GetRCLogObjTypeI18n(context.Background(), log.ObjType),
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal log failed: %w", err)
+	}
+
+	return string(line), nil
+}
+
+// CSVHeader 获取CSV文件的表头行, 包含Excel识别UTF-8所需的BOM
+func CSVHeader(ctx context.Context) string {
+	headers := []string{
+		locale.GetI18nCtx(ctx, locale.RCLogDate),
+		locale.GetI18nCtx(ctx, locale.RCLogUser),
+		locale.GetI18nCtx(ctx, locale.RCLogUserPaths),
+		locale.GetI18nCtx(ctx, locale.RCLogLevel),
+		locale.GetI18nCtx(ctx, locale.RCLogOperation),
+		locale.GetI18nCtx(ctx, locale.RCLogIP),
+		locale.GetI18nCtx(ctx, locale.RCLogMac),
+		locale.GetI18nCtx(ctx, locale.RCLogMsg),
+		locale.GetI18nCtx(ctx, locale.RCLogExMsg),
+		locale.GetI18nCtx(ctx, locale.RCLogUserAgent),
+		locale.GetI18nCtx(ctx, locale.RCLogAdditionalInfo),
+		locale.GetI18nCtx(ctx, locale.RCLogObjName),
+		locale.GetI18nCtx(ctx, locale.RCLogObjType),
+	}
+
+	return "\uFEFF" + strings.Join(headers, ",") + "\n"
+}
+
+// ChainAnchor2CSVString 将哈希链锚点转换为CSV文件末尾的注释行
+func ChainAnchor2CSVString(anchor *lcmodels.ChainAnchor) (string, error) {
+	anchorBytes, err := json.Marshal(anchor)
+	if err != nil {
+		return "", fmt.Errorf("marshal chain anchor failed: %w", err)
+	}
+
+	return "# chain_anchor: " + string(anchorBytes), nil
+}
+
+// ChainAnchor2JSONLString 将哈希链锚点转换为JSON Lines文件的最后一行
+func ChainAnchor2JSONLString(anchor *lcmodels.ChainAnchor) (string, error) {
+	anchorBytes, err := json.Marshal(map[string]*lcmodels.ChainAnchor{"chain_anchor": anchor})
+	if err != nil {
+		return "", fmt.Errorf("marshal chain anchor failed: %w", err)
+	}
+
+	return string(anchorBytes), nil
+}
+
+// ChainAnchor2XMLString 将哈希链锚点转换为XML格式字符串
+func ChainAnchor2XMLString(anchor *lcmodels.ChainAnchor) string {
+	const xmlTemplate = `<chain-anchor log-type="%s" first-seq="%d" first-log-id="%s" first-prev-hash="%s" ` +
+		`last-seq="%d" last-log-id="%s" last-hash="%s" count="%d" signature="%s"> </chain-anchor>`
+
+	return fmt.Sprintf(xmlTemplate,
+		html.EscapeString(anchor.LogType),
+		anchor.FirstSeq,
+		html.EscapeString(anchor.FirstLogID),
+		anchor.FirstPrevHash,
+		anchor.LastSeq,
+		html.EscapeString(anchor.LastLogID),
+		anchor.LastHash,
+		anchor.Count,
+		anchor.Signature,
+	)
+}
+
 // formatOpType 根据日志类型和操作类型返回对应的国际化字符串
 func formatOpType(logType string, opType int) string {
 	ctx := context.Background()
@@ -8,6 +8,7 @@ import (
 
 	"AuditLog/common"
 	"AuditLog/models"
+	"AuditLog/models/lcmodels"
 )
 
 func TestLogInfo2CSVString(t *testing.T) {
@@ -112,6 +113,75 @@ func TestLogInfo2XMLString(t *testing.T) {
 	})
 }
 
+func TestChainAnchorString(t *testing.T) {
+	anchor := &lcmodels.ChainAnchor{
+		LogType:       common.Login,
+		FirstSeq:      1,
+		FirstLogID:    "100",
+		FirstPrevHash: "",
+		LastSeq:       3,
+		LastLogID:     "300",
+		LastHash:      "abc",
+		Count:         3,
+		Signature:     "sig",
+	}
+
+	t.Run("CSV锚点", func(t *testing.T) {
+		csvStr, err := ChainAnchor2CSVString(anchor)
+		if err != nil {
+			t.Errorf("转换CSV锚点失败: %v", err)
+		}
+
+		if !strings.HasPrefix(csvStr, "# chain_anchor: ") || !strings.Contains(csvStr, `"last_hash":"abc"`) {
+			t.Errorf("CSV锚点格式不正确: %s", csvStr)
+		}
+	})
+
+	t.Run("XML锚点", func(t *testing.T) {
+		xmlStr := ChainAnchor2XMLString(anchor)
+		if !strings.HasPrefix(xmlStr, "<chain-anchor") || !strings.Contains(xmlStr, `last-seq="3"`) {
+			t.Errorf("XML锚点格式不正确: %s", xmlStr)
+		}
+	})
+
+	t.Run("JSONL锚点", func(t *testing.T) {
+		jsonlStr, err := ChainAnchor2JSONLString(anchor)
+		if err != nil {
+			t.Errorf("转换JSONL锚点失败: %v", err)
+		}
+
+		if !strings.HasPrefix(jsonlStr, `{"chain_anchor":{"log_type":"login"`) {
+			t.Errorf("JSONL锚点格式不正确: %s", jsonlStr)
+		}
+	})
+}
+
+func TestLogInfo2JSONLString(t *testing.T) {
+	testLog := &models.LogPO{
+		LogID:          "test_log_id",
+		Date:           time.Now().UnixMicro(),
+		UserName:       "test_user",
+		Level:          1,
+		OpType:         1,
+		Msg:            "test\nmessage",
+		AdditionalInfo: `{"key":"value"}`,
+		ObjID:          "test_obj_id",
+	}
+
+	jsonlStr, err := LogInfo2JSONLString(testLog, common.Operation)
+	if err != nil {
+		t.Fatalf("转换JSONL失败: %v", err)
+	}
+
+	if strings.Contains(jsonlStr, "\n") {
+		t.Error("JSONL格式不正确，包含换行符")
+	}
+
+	if !strings.Contains(jsonlStr, `"additional_info":{"key":"value","obj_id":"test_obj_id"}`) {
+		t.Errorf("JSONL附加信息不正确: %s", jsonlStr)
+	}
+}
+
 func TestFormatAdditionalInfo(t *testing.T) {
 	t.Run("正常JSON格式", func(t *testing.T) {
 		additionalInfo := `{"key":"value"}`
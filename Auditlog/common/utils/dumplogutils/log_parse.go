@@ -0,0 +1,287 @@
+package dumplogutils
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"AuditLog/common"
+	"AuditLog/common/constants/lsconsts"
+	"AuditLog/common/utils"
+	"AuditLog/locale"
+	"AuditLog/models"
+)
+
+// 转存文件中每条日志的字段数
+const csvFieldCount = 13
+
+// 转存文件中的日志字段, 等级、操作类型、对象类型为国际化信息
+type dumpedLog struct {
+	LogID          string
+	Date           string
+	UserName       string
+	UserPaths      string
+	Level          string
+	OpType         string
+	IP             string
+	Mac            string
+	Msg            string
+	ExMsg          string
+	UserAgent      string
+	AdditionalInfo string
+	ObjName        string
+	ObjType        string
+}
+
+// ParseLogs 逐条解析转存文件中的日志, 跳过表头和哈希链锚点
+// 转存文件不含用户ID, 解析出的日志用户ID为空; CSV格式不含日志ID, 解析出的日志ID为空
+func ParseLogs(r io.Reader, format string, logType string, fn func(log *models.LogPO) error) error {
+	switch format {
+	case lsconsts.CSVSuffix:
+		return parseCSVLogs(r, logType, fn)
+	case lsconsts.JSONLSuffix:
+		return parseJSONLLogs(r, logType, fn)
+	case lsconsts.XMLSuffix:
+		return parseXMLLogs(r, logType, fn)
+	}
+
+	return fmt.Errorf("unsupported dump format: %s", format)
+}
+
+func parseCSVLogs(r io.Reader, logType string, fn func(log *models.LogPO) error) error {
+	reader := csv.NewReader(r)
+	reader.Comment = '#' // 哈希链锚点为注释行, 日志字段均有引号
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read csv record failed: %w", err)
+		}
+
+		if first {
+			record[0] = strings.TrimPrefix(record[0], "\uFEFF")
+		}
+
+		if len(record) != csvFieldCount {
+			return fmt.Errorf("invalid csv record with %d fields", len(record))
+		}
+
+		log, err := toLogPO(&dumpedLog{
+			Date:           record[0],
+			UserName:       record[1],
+			UserPaths:      record[2],
+			Level:          record[3],
+			OpType:         record[4],
+			IP:             record[5],
+			Mac:            record[6],
+			Msg:            record[7],
+			ExMsg:          record[8],
+			UserAgent:      record[9],
+			AdditionalInfo: record[10],
+			ObjName:        record[11],
+			ObjType:        record[12],
+		}, logType)
+		if err != nil {
+			// 新的转存文件自带表头, 旧的文件没有
+			if first {
+				continue
+			}
+			return err
+		}
+
+		if err = fn(log); err != nil {
+			return err
+		}
+	}
+}
+
+func parseJSONLLogs(r io.Reader, logType string, fn func(log *models.LogPO) error) error {
+	reader := bufio.NewReader(r)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("read jsonl line failed: %w", err)
+		}
+
+		if len(strings.TrimSpace(string(line))) > 0 {
+			var item struct {
+				jsonlLog
+				ChainAnchor json.RawMessage `json:"chain_anchor"`
+			}
+			if jErr := json.Unmarshal(line, &item); jErr != nil {
+				return fmt.Errorf("unmarshal jsonl line failed: %w", jErr)
+			}
+
+			if item.ChainAnchor == nil {
+				log, cErr := toLogPO(&dumpedLog{
+					LogID:          item.LogID,
+					Date:           item.Date,
+					UserName:       item.UserName,
+					UserPaths:      item.UserPaths,
+					Level:          item.Level,
+					OpType:         item.OpType,
+					IP:             item.IP,
+					Mac:            item.Mac,
+					Msg:            item.Msg,
+					ExMsg:          item.ExMsg,
+					UserAgent:      item.UserAgent,
+					AdditionalInfo: string(item.AdditionalInfo),
+					ObjName:        item.ObjName,
+					ObjType:        item.ObjType,
+				}, logType)
+				if cErr != nil {
+					return cErr
+				}
+
+				if cErr = fn(log); cErr != nil {
+					return cErr
+				}
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// 转存为XML格式时的一条日志, 字段值在Remark属性中
+type xmlLog struct {
+	LogID string `xml:"Remark,attr"`
+	Items []struct {
+		XMLName xml.Name
+		Remark  string `xml:"Remark,attr"`
+	} `xml:",any"`
+}
+
+func parseXMLLogs(r io.Reader, logType string, fn func(log *models.LogPO) error) error {
+	decoder := xml.NewDecoder(r)
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read xml token failed: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "log-id" {
+			continue
+		}
+
+		item := &xmlLog{}
+		if err = decoder.DecodeElement(item, &start); err != nil {
+			return fmt.Errorf("decode xml log failed: %w", err)
+		}
+
+		dumped := &dumpedLog{LogID: item.LogID}
+		fields := map[string]*string{
+			"date":            &dumped.Date,
+			"user-name":       &dumped.UserName,
+			"user-paths":      &dumped.UserPaths,
+			"level":           &dumped.Level,
+			"op-type":         &dumped.OpType,
+			"ip":              &dumped.IP,
+			"mac":             &dumped.Mac,
+			"msg":             &dumped.Msg,
+			"ex-msg":          &dumped.ExMsg,
+			"user-agent":      &dumped.UserAgent,
+			"additional-info": &dumped.AdditionalInfo,
+			"obj-name":        &dumped.ObjName,
+			"obj-type":        &dumped.ObjType,
+		}
+		for _, field := range item.Items {
+			if dest, ok := fields[field.XMLName.Local]; ok {
+				*dest = field.Remark
+			}
+		}
+
+		log, err := toLogPO(dumped, logType)
+		if err != nil {
+			return err
+		}
+
+		if err = fn(log); err != nil {
+			return err
+		}
+	}
+}
+
+// toLogPO 将转存的日志字段还原为日志, 国际化信息反查为对应的枚举值, 无法识别时为0
+func toLogPO(dumped *dumpedLog, logType string) (log *models.LogPO, err error) {
+	date, err := time.ParseInLocation(utils.DefaultTimeFormat, dumped.Date, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("parse log date failed: %w", err)
+	}
+
+	log = &models.LogPO{
+		LogID:     dumped.LogID,
+		UserName:  dumped.UserName,
+		UserPaths: dumped.UserPaths,
+		Date:      date.UnixMicro(),
+		IP:        dumped.IP,
+		MAC:       dumped.Mac,
+		Msg:       dumped.Msg,
+		ExMsg:     dumped.ExMsg,
+		UserAgent: dumped.UserAgent,
+		ObjName:   dumped.ObjName,
+	}
+
+	log.Level, _ = locale.ParseRCLogLevelI18n(dumped.Level)
+	log.ObjType, _ = locale.ParseRCLogObjTypeI18n(dumped.ObjType)
+
+	switch logType {
+	case common.Login:
+		log.OpType, _ = locale.ParseRCLogLoginI18n(dumped.OpType)
+	case common.Management:
+		log.OpType, _ = locale.ParseRCLogMgntI18n(dumped.OpType)
+	case common.Operation:
+		log.OpType, _ = locale.ParseRCLogOpI18n(dumped.OpType)
+	}
+
+	log.ObjID, log.AdditionalInfo, err = splitAdditionalInfo(dumped.AdditionalInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return log, nil
+}
+
+// splitAdditionalInfo 从转存的附加信息中拆分出转存时加入的 obj_id
+func splitAdditionalInfo(info string) (objID string, additionalInfo string, err error) {
+	if info == "" {
+		return "", "", nil
+	}
+
+	items := make(map[string]interface{})
+	if err = json.Unmarshal([]byte(info), &items); err != nil {
+		return "", "", fmt.Errorf("unmarshal additional info failed: %w", err)
+	}
+
+	objID, _ = items["obj_id"].(string)
+	delete(items, "obj_id")
+
+	if len(items) == 0 {
+		return objID, "", nil
+	}
+
+	infoBytes, err := json.Marshal(items)
+	if err != nil {
+		return "", "", fmt.Errorf("marshal additional info failed: %w", err)
+	}
+
+	return objID, string(infoBytes), nil
+}
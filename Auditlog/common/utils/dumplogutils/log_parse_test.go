@@ -0,0 +1,115 @@
+package dumplogutils
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"AuditLog/common"
+	"AuditLog/common/constants/lsconsts"
+	"AuditLog/models"
+	"AuditLog/models/lcmodels"
+)
+
+func TestParseLogs(t *testing.T) {
+	date := time.Date(2024, 12, 19, 10, 30, 0, 0, time.Local)
+	testLog := &models.LogPO{
+		LogID:          "1001",
+		Date:           date.UnixMicro(),
+		UserName:       "test_user",
+		UserPaths:      "组织/部门",
+		Level:          2,
+		IP:             "127.0.0.1",
+		MAC:            "00:00:00:00:00:00",
+		Msg:            `test,message"with,special"chars`,
+		ExMsg:          "line1\nline2",
+		UserAgent:      "test user agent",
+		AdditionalInfo: `{"key":"value"}`,
+		ObjID:          "test_obj_id",
+		ObjName:        "<obj>",
+	}
+	anchor := &lcmodels.ChainAnchor{LogType: common.Login, FirstLogID: "1001", LastLogID: "1001", Count: 1}
+
+	parse := func(content, format string) (logs []*models.LogPO, err error) {
+		err = ParseLogs(strings.NewReader(content), format, common.Login, func(log *models.LogPO) error {
+			logs = append(logs, log)
+			return nil
+		})
+		return
+	}
+
+	check := func(t *testing.T, logs []*models.LogPO, withID bool) {
+		assert.Len(t, logs, 2)
+		for _, log := range logs {
+			if withID {
+				assert.Equal(t, testLog.LogID, log.LogID)
+			} else {
+				assert.Empty(t, log.LogID)
+			}
+			assert.Equal(t, testLog.Date, log.Date)
+			assert.Equal(t, testLog.UserName, log.UserName)
+			assert.Equal(t, testLog.UserPaths, log.UserPaths)
+			assert.Equal(t, testLog.Level, log.Level)
+			assert.Equal(t, testLog.Msg, log.Msg)
+			assert.Equal(t, testLog.ExMsg, log.ExMsg)
+			assert.Equal(t, testLog.AdditionalInfo, log.AdditionalInfo)
+			assert.Equal(t, testLog.ObjID, log.ObjID)
+			assert.Equal(t, testLog.ObjName, log.ObjName)
+		}
+	}
+
+	t.Run("CSV", func(t *testing.T) {
+		line, err := LogInfo2CSVString(testLog, common.Login)
+		assert.NoError(t, err)
+		trailer, err := ChainAnchor2CSVString(anchor)
+		assert.NoError(t, err)
+
+		// 带表头和锚点的转存文件
+		logs, err := parse(CSVHeader(context.Background())+line+"\n"+line+"\n"+trailer+"\n", lsconsts.CSVSuffix)
+		assert.NoError(t, err)
+		check(t, logs, false)
+
+		// 旧的转存文件不含表头
+		logs, err = parse(line+"\n"+line+"\n", lsconsts.CSVSuffix)
+		assert.NoError(t, err)
+		check(t, logs, false)
+
+		_, err = parse(line+"\n\"bad\",\"record\"\n", lsconsts.CSVSuffix)
+		assert.Error(t, err)
+	})
+
+	t.Run("JSONL", func(t *testing.T) {
+		line, err := LogInfo2JSONLString(testLog, common.Login)
+		assert.NoError(t, err)
+		trailer, err := ChainAnchor2JSONLString(anchor)
+		assert.NoError(t, err)
+
+		logs, err := parse(line+"\n"+line+"\n"+trailer+"\n", lsconsts.JSONLSuffix)
+		assert.NoError(t, err)
+		check(t, logs, true)
+
+		// 最后一行没有换行符
+		logs, err = parse(line+"\n"+line, lsconsts.JSONLSuffix)
+		assert.NoError(t, err)
+		check(t, logs, true)
+	})
+
+	t.Run("XML", func(t *testing.T) {
+		line, err := LogInfo2XMLString(testLog, common.Login)
+		assert.NoError(t, err)
+
+		content := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<log>\n" + line + "\n" + line + "\n" +
+			ChainAnchor2XMLString(anchor) + "\n</log>\n"
+		logs, err := parse(content, lsconsts.XMLSuffix)
+		assert.NoError(t, err)
+		check(t, logs, true)
+	})
+
+	t.Run("不支持的格式", func(t *testing.T) {
+		_, err := parse("", "txt")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,45 @@
+package legalholdutils
+
+import (
+	"slices"
+	"strings"
+
+	"AuditLog/models/lhmodels"
+)
+
+// 日志中多个部门路径的分隔符, 与日志入库时一致
+const deptPathsSep = ", "
+
+// Scoped 保留是否限定了用户或部门
+func Scoped(hold *lhmodels.LegalHoldVO) bool {
+	return len(hold.UserIDs) > 0 || len(hold.Departments) > 0
+}
+
+// Overlaps 保留的时间范围与 [begin, end) 是否重叠, end 为0表示不限
+func Overlaps(hold *lhmodels.LegalHoldVO, begin, end int64) bool {
+	if end != 0 && hold.BeginTime >= end {
+		return false
+	}
+	return hold.EndTime == 0 || hold.EndTime > begin
+}
+
+// MatchLog 日志是否在保留范围内
+func MatchLog(hold *lhmodels.LegalHoldVO, date int64, userID, userPaths string) bool {
+	if date < hold.BeginTime || (hold.EndTime != 0 && date >= hold.EndTime) {
+		return false
+	}
+
+	if !Scoped(hold) || slices.Contains(hold.UserIDs, userID) {
+		return true
+	}
+
+	for _, path := range strings.Split(userPaths, deptPathsSep) {
+		for _, dept := range hold.Departments {
+			if path == dept || strings.HasPrefix(path, dept+"/") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,42 @@
+package legalholdutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"AuditLog/models/lhmodels"
+)
+
+func TestOverlaps(t *testing.T) {
+	hold := &lhmodels.LegalHoldVO{BeginTime: 100, EndTime: 200}
+
+	assert.True(t, Overlaps(hold, 150, 300))
+	assert.True(t, Overlaps(hold, 0, 101))
+	assert.True(t, Overlaps(hold, 0, 0))
+	assert.False(t, Overlaps(hold, 0, 100))
+	assert.False(t, Overlaps(hold, 200, 300))
+
+	// 不限结束时间
+	hold.EndTime = 0
+	assert.True(t, Overlaps(hold, 1000, 2000))
+	assert.False(t, Overlaps(hold, 0, 50))
+}
+
+func TestMatchLog(t *testing.T) {
+	hold := &lhmodels.LegalHoldVO{BeginTime: 100, EndTime: 200}
+
+	// 未限定用户和部门时按时间匹配
+	assert.True(t, MatchLog(hold, 100, "u1", ""))
+	assert.False(t, MatchLog(hold, 200, "u1", ""))
+	assert.False(t, MatchLog(hold, 99, "u1", ""))
+
+	hold.UserIDs = []string{"u1"}
+	hold.Departments = []string{"org/dept"}
+	assert.True(t, MatchLog(hold, 150, "u1", ""))
+	assert.False(t, MatchLog(hold, 150, "u2", "org/other"))
+	assert.True(t, MatchLog(hold, 150, "u2", "org/other, org/dept"))
+	assert.True(t, MatchLog(hold, 150, "u2", "org/dept/sub"))
+	assert.False(t, MatchLog(hold, 150, "u2", "org/department"))
+	assert.False(t, MatchLog(hold, 250, "u1", "org/dept"))
+}
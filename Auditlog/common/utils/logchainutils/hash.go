@@ -0,0 +1,167 @@
+package logchainutils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"AuditLog/common/constants/lcconsts"
+	"AuditLog/models"
+	"AuditLog/models/lcmodels"
+)
+
+// ErrEmptySecret 签名密钥为空, 空密钥的签名可被任何人伪造
+var ErrEmptySecret = errors.New("log chain secret is empty")
+
+// chainContent 参与哈希计算的日志内容, 字段顺序固定
+type chainContent struct {
+	Seq            int64  `json:"seq"`
+	LogID          string `json:"log_id"`
+	UserID         string `json:"user_id"`
+	UserName       string `json:"user_name"`
+	UserType       string `json:"user_type"`
+	ObjID          string `json:"obj_id"`
+	Level          int    `json:"level"`
+	OpType         int    `json:"op_type"`
+	Date           int64  `json:"date"`
+	IP             string `json:"ip"`
+	MAC            string `json:"mac"`
+	Msg            string `json:"msg"`
+	ExMsg          string `json:"ex_msg"`
+	UserAgent      string `json:"user_agent"`
+	AdditionalInfo string `json:"additional_info"`
+	UserPaths      string `json:"user_paths"`
+	ObjName        string `json:"obj_name"`
+	ObjType        int    `json:"obj_type"`
+}
+
+// NewRecord 根据待写入的审计日志生成哈希链记录
+func NewRecord(seq int64, logID string, log *models.AuditLog) *lcmodels.LogChainRecordPO {
+	return &lcmodels.LogChainRecordPO{
+		Seq:            seq,
+		LogID:          logID,
+		UserID:         log.UserID,
+		UserName:       log.UserName,
+		UserType:       log.UserType,
+		ObjID:          log.ObjID,
+		Level:          log.Level,
+		OpType:         log.OpType,
+		Date:           log.Date,
+		IP:             log.IP,
+		MAC:            log.Mac,
+		Msg:            log.Msg,
+		ExMsg:          log.Exmsg,
+		UserAgent:      log.UserAgent,
+		AdditionalInfo: log.AdditionalInfo,
+		UserPaths:      log.DeptPaths,
+		ObjName:        log.ObjName,
+		ObjType:        log.ObjType,
+	}
+}
+
+// ComputeHash 计算记录哈希 sha256(prevHash + "\n" + 记录内容)
+// char 类型字段读取时会去掉尾部空格, 计算前统一去掉, 保证写入与读取时结果一致
+func ComputeHash(prevHash string, rec *lcmodels.LogChainRecordPO) string {
+	content, _ := json.Marshal(chainContent{
+		Seq:            rec.Seq,
+		LogID:          rec.LogID,
+		UserID:         strings.TrimRight(rec.UserID, " "),
+		UserName:       strings.TrimRight(rec.UserName, " "),
+		UserType:       rec.UserType,
+		ObjID:          strings.TrimRight(rec.ObjID, " "),
+		Level:          rec.Level,
+		OpType:         rec.OpType,
+		Date:           rec.Date,
+		IP:             strings.TrimRight(rec.IP, " "),
+		MAC:            strings.TrimRight(rec.MAC, " "),
+		Msg:            rec.Msg,
+		ExMsg:          rec.ExMsg,
+		UserAgent:      rec.UserAgent,
+		AdditionalInfo: rec.AdditionalInfo,
+		UserPaths:      rec.UserPaths,
+		ObjName:        strings.TrimRight(rec.ObjName, " "),
+		ObjType:        rec.ObjType,
+	})
+
+	h := sha256.New()
+	h.Write([]byte(prevHash))
+	h.Write([]byte("\n"))
+	h.Write(content)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SignCheckpoint 使用密钥对检查点签名
+func SignCheckpoint(secret string, cp *lcmodels.LogChainCheckpointPO) (string, error) {
+	return sign(secret, fmt.Sprintf("%d|%d|%s|%s", cp.LogType, cp.Seq, cp.LogID, cp.Hash))
+}
+
+// VerifyCheckpoint 校验检查点签名, 密钥为空时返回 ErrEmptySecret
+func VerifyCheckpoint(secret string, cp *lcmodels.LogChainCheckpointPO) (bool, error) {
+	signature, err := SignCheckpoint(secret, cp)
+	if err != nil {
+		return false, err
+	}
+
+	return hmac.Equal([]byte(signature), []byte(cp.Signature)), nil
+}
+
+// SignHead 使用密钥对链头签名, 防止删除最新的记录后回退链头
+func SignHead(secret string, head *lcmodels.LogChainHeadPO) (string, error) {
+	return sign(secret, fmt.Sprintf("%d|%d|%s", head.LogType, head.Seq, head.Hash))
+}
+
+// VerifyHead 校验链头签名, 密钥为空时返回 ErrEmptySecret
+func VerifyHead(secret string, head *lcmodels.LogChainHeadPO) (bool, error) {
+	signature, err := SignHead(secret, head)
+	if err != nil {
+		return false, err
+	}
+
+	return hmac.Equal([]byte(signature), []byte(head.Signature)), nil
+}
+
+// SignAnchor 使用密钥对转储文件锚点签名
+func SignAnchor(secret string, anchor *lcmodels.ChainAnchor) (string, error) {
+	return sign(secret, fmt.Sprintf("%s|%d|%s|%s|%d|%s|%s|%d",
+		anchor.LogType,
+		anchor.FirstSeq, anchor.FirstLogID, anchor.FirstPrevHash,
+		anchor.LastSeq, anchor.LastLogID, anchor.LastHash,
+		anchor.Count,
+	))
+}
+
+// VerifyAnchor 校验转储锚点签名, 密钥为空时返回 ErrEmptySecret
+func VerifyAnchor(secret string, anchor *lcmodels.ChainAnchor) (bool, error) {
+	signature, err := SignAnchor(secret, anchor)
+	if err != nil {
+		return false, err
+	}
+
+	return hmac.Equal([]byte(signature), []byte(anchor.Signature)), nil
+}
+
+// CheckpointInterval 解析检查点间隔, 配置无效时使用默认值
+func CheckpointInterval(value string) int64 {
+	interval, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || interval <= 0 {
+		return lcconsts.DefaultCheckpointInterval
+	}
+	return interval
+}
+
+func sign(secret, data string) (string, error) {
+	if secret == "" {
+		return "", ErrEmptySecret
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(data))
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
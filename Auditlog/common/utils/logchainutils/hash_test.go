@@ -0,0 +1,136 @@
+package logchainutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"AuditLog/common/constants/lcconsts"
+	"AuditLog/models"
+	"AuditLog/models/lcmodels"
+)
+
+func TestComputeHash(t *testing.T) {
+	log := &models.AuditLog{
+		UserID:   "user1",
+		UserName: "用户1",
+		UserType: "authenticated_user",
+		Level:    1,
+		OpType:   2,
+		Date:     1734571503937827,
+		IP:       "127.0.0.1",
+		Msg:      "登录成功",
+	}
+
+	t.Run("相同内容哈希一致", func(t *testing.T) {
+		h1 := ComputeHash("", NewRecord(1, "100", log))
+		h2 := ComputeHash("", NewRecord(1, "100", log))
+		assert.Len(t, h1, 64)
+		assert.Equal(t, h1, h2)
+	})
+
+	t.Run("前序哈希参与计算", func(t *testing.T) {
+		assert.NotEqual(t, ComputeHash("", NewRecord(1, "100", log)), ComputeHash("abc", NewRecord(1, "100", log)))
+	})
+
+	t.Run("内容修改后哈希变化", func(t *testing.T) {
+		rec := NewRecord(1, "100", log)
+		h := ComputeHash("", rec)
+		rec.Msg = "登录失败"
+		assert.NotEqual(t, h, ComputeHash("", rec))
+	})
+
+	t.Run("char字段尾部空格不影响哈希", func(t *testing.T) {
+		rec := NewRecord(1, "100", log)
+		h := ComputeHash("", rec)
+		rec.UserName += "  "
+		assert.Equal(t, h, ComputeHash("", rec))
+	})
+}
+
+func TestVerifyCheckpoint(t *testing.T) {
+	cp := &lcmodels.LogChainCheckpointPO{LogType: 10, Seq: 1000, LogID: "100", Hash: "abc"}
+	var err error
+	cp.Signature, err = SignCheckpoint("secret", cp)
+	assert.NoError(t, err)
+
+	t.Run("签名有效", func(t *testing.T) {
+		ok, err := VerifyCheckpoint("secret", cp)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("密钥不同", func(t *testing.T) {
+		ok, _ := VerifyCheckpoint("other", cp)
+		assert.False(t, ok)
+	})
+
+	t.Run("检查点被修改", func(t *testing.T) {
+		modified := *cp
+		modified.Hash = "abd"
+		ok, _ := VerifyCheckpoint("secret", &modified)
+		assert.False(t, ok)
+	})
+
+	t.Run("密钥为空", func(t *testing.T) {
+		_, err := SignCheckpoint("", cp)
+		assert.ErrorIs(t, err, ErrEmptySecret)
+		_, err = VerifyCheckpoint("", cp)
+		assert.ErrorIs(t, err, ErrEmptySecret)
+	})
+}
+
+func TestSignAnchor(t *testing.T) {
+	anchor := &lcmodels.ChainAnchor{LogType: "login", FirstSeq: 1, LastSeq: 10, LastHash: "abc", Count: 10}
+	s, err := SignAnchor("secret", anchor)
+	assert.NoError(t, err)
+	assert.Len(t, s, 64)
+
+	anchor.Count = 9
+	s2, _ := SignAnchor("secret", anchor)
+	assert.NotEqual(t, s, s2)
+
+	_, err = SignAnchor("", anchor)
+	assert.ErrorIs(t, err, ErrEmptySecret)
+}
+
+func TestVerifyHead(t *testing.T) {
+	head := &lcmodels.LogChainHeadPO{LogType: 10, Seq: 3, Hash: "abc"}
+	var err error
+	head.Signature, err = SignHead("secret", head)
+	assert.NoError(t, err)
+
+	ok, err := VerifyHead("secret", head)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	rollback := *head
+	rollback.Seq = 2
+	ok, _ = VerifyHead("secret", &rollback)
+	assert.False(t, ok)
+
+	_, err = VerifyHead("", head)
+	assert.ErrorIs(t, err, ErrEmptySecret)
+}
+
+func TestVerifyAnchor(t *testing.T) {
+	anchor := &lcmodels.ChainAnchor{LogType: "login", FirstSeq: 1, LastSeq: 10, LastHash: "abc", Count: 10}
+	var err error
+	anchor.Signature, err = SignAnchor("secret", anchor)
+	assert.NoError(t, err)
+
+	ok, err := VerifyAnchor("secret", anchor)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	modified := *anchor
+	modified.LastHash = "abd"
+	ok, _ = VerifyAnchor("secret", &modified)
+	assert.False(t, ok)
+}
+
+func TestCheckpointInterval(t *testing.T) {
+	assert.Equal(t, int64(500), CheckpointInterval("500"))
+	assert.Equal(t, lcconsts.DefaultCheckpointInterval, CheckpointInterval("0"))
+	assert.Equal(t, lcconsts.DefaultCheckpointInterval, CheckpointInterval("abc"))
+}
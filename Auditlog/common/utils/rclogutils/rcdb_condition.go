@@ -7,6 +7,7 @@ import (
 
 	"AuditLog/common"
 	"AuditLog/common/constants/rclogconsts"
+	"AuditLog/common/constants/statconsts"
 	"AuditLog/models/rcvo"
 )
 
@@ -290,6 +291,52 @@ func BuildActiveCondition2(condition map[string]any, orderBy rcvo.OrderFields, i
 	return
 }
 
+// BuildStatisticsCondition 构建统计活跃日志条件, keys 不为空时只统计分组维度 group 为这些值的日志
+// 分组值来自已入库的日志内容, 通过参数绑定传入, 返回条件及对应的参数
+func BuildStatisticsCondition(condition map[string]any, inUserIDs []string, exUserIDs []string, group string, keys []string) (sqlStr string, args []any, err error) {
+	sqlStr = `[where] [in] [not_in]`
+
+	if err = AddWhereToSql(&sqlStr, condition); err != nil {
+		return
+	}
+
+	inenums := make(map[string][]string)
+	if len(inUserIDs) > 0 {
+		inenums["user_id"] = inUserIDs
+	}
+
+	AddInToSql(&sqlStr, inenums)
+
+	exenums := make(map[string][]string)
+	if len(exUserIDs) > 0 {
+		exenums["user_id"] = exUserIDs
+	}
+
+	AddNotInToSql(&sqlStr, exenums)
+
+	if len(keys) == 0 {
+		return
+	}
+
+	keyExpr, ok := statconsts.GroupExprMap[group]
+	if !ok {
+		return "", nil, fmt.Errorf("invalid group: %s", group)
+	}
+
+	args = make([]any, 0, len(keys))
+	for _, key := range keys {
+		args = append(args, key)
+	}
+	match := keyExpr + " IN (" + strings.TrimSuffix(strings.Repeat("?,", len(keys)), ",") + ")"
+	if strings.Contains(sqlStr, "WHERE") {
+		sqlStr += " AND " + match
+	} else {
+		sqlStr += " WHERE " + match
+	}
+
+	return
+}
+
 // BuildHistoryCondition 构建查询历史日志条件
 func BuildHistoryCondition(category string, condition map[string]any, orderBy rcvo.OrderFields, ids []string) (sqlStr string, err error) {
 	sqlStr = `[where] [in] [order]`
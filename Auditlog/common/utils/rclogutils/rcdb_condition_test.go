@@ -1,6 +1,7 @@
 package rclogutils
 
 import (
+	"reflect"
 	"testing"
 
 	"AuditLog/models/rcvo"
@@ -198,3 +199,66 @@ func TestBuildActiveCondition(t *testing.T) {
 		})
 	}
 }
+
+// TestBuildStatisticsCondition 测试构建统计活跃日志条件
+func TestBuildStatisticsCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition map[string]any
+		inUserIDs []string
+		exUserIDs []string
+		group     string
+		keys      []string
+		expected  string
+		args      []any
+	}{
+		{
+			name:      "无条件",
+			condition: map[string]any{},
+			expected:  "  ",
+		},
+		{
+			name:      "时间范围和排除用户",
+			condition: map[string]any{"date": []interface{}{float64(1), float64(2)}},
+			exUserIDs: []string{"u1"},
+			expected:  "WHERE (f_date BETWEEN 1 AND 2)  AND f_user_id NOT IN ('u1')",
+		},
+		{
+			name:     "限定分组值时通过参数绑定",
+			group:    "ip",
+			keys:     []string{"1.1.1.1", "\\' OR 1=1 -- "},
+			expected: "   WHERE f_ip IN (?,?)",
+			args:     []any{"1.1.1.1", "\\' OR 1=1 -- "},
+		},
+		{
+			name:      "限定分组值和时间范围",
+			condition: map[string]any{"date": []interface{}{float64(1), float64(2)}},
+			group:     "user",
+			keys:      []string{"u1"},
+			expected:  "WHERE (f_date BETWEEN 1 AND 2)   AND f_user_id IN (?)",
+			args:      []any{"u1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := BuildStatisticsCondition(tt.condition, tt.inUserIDs, tt.exUserIDs, tt.group, tt.keys)
+			if err != nil {
+				t.Errorf("期望无错误, 实际错误 %v", err)
+			}
+
+			if sql != tt.expected {
+				t.Errorf("期望 %v, 实际 %v", tt.expected, sql)
+			}
+
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("期望参数 %v, 实际参数 %v", tt.args, args)
+			}
+		})
+	}
+
+	_, _, err := BuildStatisticsCondition(nil, nil, nil, "unknown", []string{"a"})
+	if err == nil {
+		t.Errorf("期望分组维度错误")
+	}
+}
@@ -0,0 +1,245 @@
+package siemutils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"AuditLog/common/constants/fwdconsts"
+	"AuditLog/common/constants/logconsts"
+	"AuditLog/models"
+)
+
+// 日志级别名称
+var levelNames = map[string]int{
+	"INFO": logconsts.LogLevel.INFO,
+	"WARN": logconsts.LogLevel.WARN,
+}
+
+// ParseFilters 解析转发过滤配置, 格式为 "login,management:WARN,operation"
+// 返回日志类型对应的最低转发级别, 0 表示转发所有级别, 无效的配置项被忽略并返回错误
+func ParseFilters(s string) (filters map[string]int, err error) {
+	filters = make(map[string]int)
+	var invalid []string
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		logType, levelName, hasLevel := strings.Cut(item, ":")
+		if !hasLevel {
+			filters[logType] = 0
+			continue
+		}
+
+		level, ok := levelNames[strings.ToUpper(strings.TrimSpace(levelName))]
+		if !ok {
+			invalid = append(invalid, item)
+			continue
+		}
+		filters[strings.TrimSpace(logType)] = level
+	}
+
+	if len(invalid) > 0 {
+		err = fmt.Errorf("invalid forward filters: %s", strings.Join(invalid, ","))
+	}
+
+	return
+}
+
+// Format 将审计日志格式化为 RFC 5424 syslog 消息, cef/leef 格式作为消息体
+func Format(format, hostname, logType, logID string, log *models.AuditLog) (msg string, err error) {
+	var sd, body string
+	switch format {
+	case fwdconsts.FormatRFC5424:
+		sd = structuredData(logType, logID, log)
+		body = log.Msg
+	case fwdconsts.FormatCEF:
+		sd = fwdconsts.NilValue
+		body = FormatCEF(logType, logID, log)
+	case fwdconsts.FormatLEEF:
+		sd = fwdconsts.NilValue
+		body = FormatLEEF(logType, logID, log)
+	default:
+		return "", fmt.Errorf("unsupported forward format: %s", format)
+	}
+
+	pri := fwdconsts.Facility*8 + syslogSeverity(log.Level)
+	msg = fmt.Sprintf("<%d>1 %s %s %s %s %s %s %s",
+		pri,
+		timestamp(log.Date),
+		headerField(hostname, 255),
+		fwdconsts.AppName,
+		fwdconsts.NilValue,
+		headerField(logType, 32),
+		sd,
+		body,
+	)
+
+	return msg, nil
+}
+
+// FormatCEF 生成 CEF 格式的日志
+func FormatCEF(logType, logID string, log *models.AuditLog) string {
+	header := strings.Join([]string{
+		"CEF:0",
+		cefHeaderEscape(fwdconsts.DeviceVendor),
+		cefHeaderEscape(fwdconsts.DeviceProduct),
+		cefHeaderEscape(fwdconsts.DeviceVersion),
+		cefHeaderEscape(eventID(logType, log.OpType)),
+		cefHeaderEscape(log.Msg),
+		strconv.Itoa(severity(log.Level)),
+	}, "|")
+
+	ext := []string{
+		"rt=" + strconv.FormatInt(log.Date/1000, 10),
+		"externalId=" + cefValueEscape(logID),
+		"suid=" + cefValueEscape(log.UserID),
+		"suser=" + cefValueEscape(log.UserName),
+		"src=" + cefValueEscape(log.IP),
+		"smac=" + cefValueEscape(log.Mac),
+		"requestClientApplication=" + cefValueEscape(log.UserAgent),
+		"msg=" + cefValueEscape(log.Exmsg),
+		"cs1Label=logType cs1=" + cefValueEscape(logType),
+		"cs2Label=userType cs2=" + cefValueEscape(log.UserType),
+		"cs3Label=objectId cs3=" + cefValueEscape(log.ObjID),
+		"cs4Label=objectName cs4=" + cefValueEscape(log.ObjName),
+		"cs5Label=deptPaths cs5=" + cefValueEscape(log.DeptPaths),
+		"cn1Label=opType cn1=" + strconv.Itoa(log.OpType),
+	}
+
+	return header + "|" + strings.Join(ext, " ")
+}
+
+// FormatLEEF 生成 LEEF 2.0 格式的日志, 属性以制表符分隔
+func FormatLEEF(logType, logID string, log *models.AuditLog) string {
+	header := strings.Join([]string{
+		"LEEF:2.0",
+		fwdconsts.DeviceVendor,
+		fwdconsts.DeviceProduct,
+		fwdconsts.DeviceVersion,
+		eventID(logType, log.OpType),
+		"x09",
+	}, "|")
+
+	attrs := []string{
+		"devTime=" + time.UnixMicro(log.Date).UTC().Format("2006-01-02T15:04:05.000Z07:00"),
+		"devTimeFormat=yyyy-MM-dd'T'HH:mm:ss.SSSXXX",
+		"cat=" + leefValue(logType),
+		"sev=" + strconv.Itoa(severity(log.Level)),
+		"logId=" + leefValue(logID),
+		"usrName=" + leefValue(log.UserName),
+		"userId=" + leefValue(log.UserID),
+		"userType=" + leefValue(log.UserType),
+		"src=" + leefValue(log.IP),
+		"srcMAC=" + leefValue(log.Mac),
+		"userAgent=" + leefValue(log.UserAgent),
+		"opType=" + strconv.Itoa(log.OpType),
+		"objId=" + leefValue(log.ObjID),
+		"resource=" + leefValue(log.ObjName),
+		"deptPaths=" + leefValue(log.DeptPaths),
+		"msg=" + leefValue(log.Msg),
+		"exMsg=" + leefValue(log.Exmsg),
+	}
+
+	return header + "|" + strings.Join(attrs, "\t")
+}
+
+// structuredData 生成 RFC 5424 结构化数据
+func structuredData(logType, logID string, log *models.AuditLog) string {
+	params := [][2]string{
+		{"log_type", logType},
+		{"log_id", logID},
+		{"user_id", log.UserID},
+		{"user_name", log.UserName},
+		{"user_type", log.UserType},
+		{"level", strconv.Itoa(log.Level)},
+		{"op_type", strconv.Itoa(log.OpType)},
+		{"ip", log.IP},
+		{"mac", log.Mac},
+		{"obj_id", log.ObjID},
+		{"obj_name", log.ObjName},
+		{"obj_type", strconv.Itoa(log.ObjType)},
+		{"dept_paths", log.DeptPaths},
+		{"user_agent", log.UserAgent},
+		{"ex_msg", log.Exmsg},
+	}
+
+	var b strings.Builder
+	b.WriteString("[" + fwdconsts.SDID)
+	for _, p := range params {
+		b.WriteString(" " + p[0] + "=\"" + sdValueEscape(p[1]) + "\"")
+	}
+	b.WriteString("]")
+
+	return b.String()
+}
+
+func eventID(logType string, opType int) string {
+	return logType + ":" + strconv.Itoa(opType)
+}
+
+func syslogSeverity(level int) int {
+	if level == logconsts.LogLevel.WARN {
+		return fwdconsts.SeverityWarning
+	}
+	return fwdconsts.SeverityInfo
+}
+
+// severity CEF/LEEF 严重级别, 取值 0-10
+func severity(level int) int {
+	if level == logconsts.LogLevel.WARN {
+		return 7
+	}
+	return 3
+}
+
+// timestamp 微秒时间戳转为 RFC 3339 时间, 为0时使用空值
+func timestamp(micro int64) string {
+	if micro <= 0 {
+		return fwdconsts.NilValue
+	}
+	return time.UnixMicro(micro).UTC().Format("2006-01-02T15:04:05.000000Z07:00")
+}
+
+// headerField 消息头字段只允许可打印的 ASCII 字符
+func headerField(s string, maxLen int) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c < 33 || c > 126 {
+			b[i] = '_'
+		}
+	}
+	if len(b) > maxLen {
+		b = b[:maxLen]
+	}
+	if len(b) == 0 {
+		return fwdconsts.NilValue
+	}
+	return string(b)
+}
+
+var (
+	sdReplacer        = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+	cefHeaderReplacer = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+	cefValueReplacer  = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+	leefValueReplacer = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+)
+
+func sdValueEscape(s string) string {
+	return sdReplacer.Replace(s)
+}
+
+func cefHeaderEscape(s string) string {
+	return cefHeaderReplacer.Replace(s)
+}
+
+func cefValueEscape(s string) string {
+	return cefValueReplacer.Replace(s)
+}
+
+func leefValue(s string) string {
+	return leefValueReplacer.Replace(s)
+}
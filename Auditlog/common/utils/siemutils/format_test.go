@@ -0,0 +1,82 @@
+package siemutils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"AuditLog/common/constants/fwdconsts"
+	"AuditLog/models"
+)
+
+func newTestLog() *models.AuditLog {
+	return &models.AuditLog{
+		UserID:    "user1",
+		UserName:  `张三"|=]`,
+		UserType:  "authenticated_user",
+		Level:     2,
+		OpType:    3,
+		Date:      1734571503937827,
+		IP:        "127.0.0.1",
+		Msg:       "登录|成功",
+		Exmsg:     "a=b\nc",
+		UserAgent: "Mozilla/5.0",
+	}
+}
+
+func TestParseFilters(t *testing.T) {
+	t.Run("解析日志类型和最低级别", func(t *testing.T) {
+		filters, err := ParseFilters(" login, management:warn ,operation:INFO,")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int{"login": 0, "management": 2, "operation": 1}, filters)
+	})
+
+	t.Run("无效的级别被忽略", func(t *testing.T) {
+		filters, err := ParseFilters("login:DEBUG,operation")
+		assert.Error(t, err)
+		assert.Equal(t, map[string]int{"operation": 0}, filters)
+	})
+}
+
+func TestFormat(t *testing.T) {
+	log := newTestLog()
+
+	t.Run("RFC 5424", func(t *testing.T) {
+		msg, err := Format(fwdconsts.FormatRFC5424, "audit log-0", "login", "100", log)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(msg, "<108>1 2024-12-19T01:25:03.937827Z audit_log-0 audit-log - login [audit@32473 log_type=\"login\" log_id=\"100\""))
+		assert.Contains(t, msg, `user_name="张三\"|=\]"`)
+		assert.True(t, strings.HasSuffix(msg, "] 登录|成功"))
+	})
+
+	t.Run("CEF", func(t *testing.T) {
+		msg, err := Format(fwdconsts.FormatCEF, "host", "login", "100", log)
+		assert.NoError(t, err)
+		assert.Contains(t, msg, "<108>1 2024-12-19T01:25:03.937827Z host audit-log - login - CEF:0|KWeaver|AuditLog|1.0|login:3|登录\\|成功|7|rt=1734571503937 externalId=100")
+		assert.Contains(t, msg, `suser=张三"|\=]`)
+		assert.Contains(t, msg, `msg=a\=b\nc`)
+	})
+
+	t.Run("LEEF", func(t *testing.T) {
+		msg, err := Format(fwdconsts.FormatLEEF, "", "login", "100", log)
+		assert.NoError(t, err)
+		assert.Contains(t, msg, " - audit-log - login - LEEF:2.0|KWeaver|AuditLog|1.0|login:3|x09|devTime=2024-12-19T01:25:03.937Z\t")
+		assert.Contains(t, msg, "\tsev=7\tlogId=100\t")
+		assert.Contains(t, msg, "\texMsg=a=b c")
+	})
+
+	t.Run("不支持的格式", func(t *testing.T) {
+		_, err := Format("json", "host", "login", "100", log)
+		assert.Error(t, err)
+	})
+
+	t.Run("INFO 级别且无时间", func(t *testing.T) {
+		info := newTestLog()
+		info.Level = 1
+		info.Date = 0
+		msg, err := Format(fwdconsts.FormatRFC5424, "host", "operation", "1", info)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(msg, "<110>1 - host audit-log - operation "))
+	})
+}
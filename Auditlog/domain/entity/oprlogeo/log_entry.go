@@ -16,4 +16,7 @@ type LogEntry struct {
 	Rec          *RecommendInfo `json:"rec,omitempty"`           // 推荐相关对象
 	Detail       interface{}    `json:"detail,omitempty"`        // 业务模块扩展的其他字段
 	Referer      *Referer       `json:"referer,omitempty"`       // 来源
+
+	LogID string `json:"-"` // 接收时分配的日志id, 转发和告警使用同一id
+	Date  int64  `json:"-"` // 接收时间，微秒的时间戳
 }
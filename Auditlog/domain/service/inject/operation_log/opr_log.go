@@ -12,6 +12,7 @@ import (
 	"AuditLog/gocommon/api"
 	"AuditLog/infra"
 	oprlogdriveri "AuditLog/interfaces/driveradapter/operation_log"
+	"AuditLog/logics"
 )
 
 var (
@@ -36,6 +37,8 @@ func NewOprLogSvc() oprlogdriveri.IOprLogSvc {
 			httpinject.NewUmHttpAcc(),
 			httpinject.NewEFastHttpAcc(),
 			opsHttpAcc,
+			logics.NewAlertEngine(),
+			logics.NewLogForward(),
 		)
 	})
 
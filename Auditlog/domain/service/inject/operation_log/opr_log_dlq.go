@@ -0,0 +1,29 @@
+package oprinject
+
+import (
+	"sync"
+
+	"AuditLog/common"
+	oprdlqsvc "AuditLog/domain/service/opr_log_dlq"
+	"AuditLog/drivenadapters/db"
+	oprlogdriveri "AuditLog/interfaces/driveradapter/operation_log"
+	"AuditLog/logics"
+)
+
+var (
+	oprLogDLQSvcOnce sync.Once
+	oprLogDLQSvcImpl oprlogdriveri.IOprLogDLQSvc
+)
+
+func NewOprLogDLQSvc() oprlogdriveri.IOprLogDLQSvc {
+	oprLogDLQSvcOnce.Do(func() {
+		oprLogDLQSvcImpl = oprdlqsvc.NewOprLogDLQSvc(
+			common.SvcConfig.Logger,
+			db.NewOprLogDLQ(),
+			NewOprLogSvc(),
+			logics.NewLogMgnt(),
+		)
+	})
+
+	return oprLogDLQSvcImpl
+}
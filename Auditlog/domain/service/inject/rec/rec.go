@@ -12,7 +12,9 @@ import (
 	persrec_db "AuditLog/drivenadapters/db/persrec"
 	"AuditLog/drivenadapters/httpaccess/httpinject"
 	"AuditLog/infra/cmp/redisdlmcmp"
+	"AuditLog/infra/config"
 	recdriveri "AuditLog/interfaces/driveradapter/rec"
+	"AuditLog/logics"
 )
 
 var (
@@ -35,6 +37,8 @@ func NewRecSvc() recdriveri.IRecSvc {
 			opsHttpAcc,
 			persrec_db.NewSvcConfigRepo(repoBase),
 			redisdlmcmp.NewRedisDlmCmp(getDlmConf()),
+			logics.NewLegalHold(),
+			config.GetConfig().Rec.IndexRolloverPeriod,
 		)
 	})
 
@@ -0,0 +1,46 @@
+package oprsvc
+
+import (
+	"context"
+
+	"AuditLog/common/constants/alertconsts"
+	"AuditLog/common/enums/oprlogenums"
+	oprlogeo "AuditLog/domain/entity/oprlogeo"
+	"AuditLog/models/alertmodels"
+)
+
+// alertHandle 运营日志转换为告警事件并评估告警规则
+func (l *oprLogSvc) alertHandle(ctx context.Context, eos []*oprlogeo.LogEntry, bizType oprlogenums.BizType) {
+	for _, eo := range eos {
+		l.alertEngine.Evaluate(ctx, entryToEvent(eo, bizType))
+	}
+}
+
+func entryToEvent(eo *oprlogeo.LogEntry, bizType oprlogenums.BizType) *alertmodels.Event {
+	fields := map[string]string{
+		alertconsts.FieldOperation: eo.Operation,
+		alertconsts.FieldMsg:       eo.Description,
+	}
+
+	if eo.Operator != nil {
+		fields[alertconsts.FieldUserID] = eo.Operator.ID
+		fields[alertconsts.FieldUserName] = eo.Operator.Name
+		fields[alertconsts.FieldUserType] = string(eo.Operator.Type)
+		if eo.Operator.Agent != nil {
+			fields[alertconsts.FieldIP] = eo.Operator.Agent.IP
+		}
+	}
+
+	if eo.Object != nil {
+		fields[alertconsts.FieldObjID] = eo.Object.ID
+		fields[alertconsts.FieldObjName] = eo.Object.Name
+		fields[alertconsts.FieldObjType] = eo.Object.Type
+	}
+
+	return &alertmodels.Event{
+		LogType: string(bizType),
+		LogID:   eo.LogID,
+		Date:    eo.Date,
+		Fields:  fields,
+	}
+}
@@ -37,6 +37,12 @@ func (l *oprLogSvc) HandleMsg(ctx context.Context, msg []byte, bizType oprlogenu
 	// 3. 推荐相关处理 （异步处理）
 	go l.recHandle(entryEos, logMaps, bizType)
 
+	// 4. 告警规则评估
+	l.alertHandle(ctx, entryEos, bizType)
+
+	// 5. 转发到 SIEM
+	l.forwardHandle(entryEos, bizType)
+
 	// 本地开发api测试用，将logMaps通过http返回
 	if helpers.IsLocalDev() {
 		if c, ok := ctx.(*gin.Context); ok {
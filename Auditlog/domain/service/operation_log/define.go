@@ -14,6 +14,8 @@ type oprLogSvc struct {
 	userMgntRepo interfaces.UserMgntRepo
 	mqClient     api.MQClient
 	dbPool       *sqlx.DB
+	alertEngine  interfaces.AlertEngine
+	logForward   interfaces.LogForward
 
 	documentHttpAcc ihttpaccess.DocumentHttpAcc
 	umHttpAcc       ihttpaccess.UmHttpAcc
@@ -30,6 +32,8 @@ func NewOprLogSvc(
 	userHttpAcc ihttpaccess.UmHttpAcc,
 	efastHttpAcc ihttpaccess.EFastHttpAcc,
 	oprHttpAcc ihttpaccess.OpsHttpAcc,
+	alertEngine interfaces.AlertEngine,
+	logForward interfaces.LogForward,
 ) oprlogdriveri.IOprLogSvc {
 	svc := &oprLogSvc{
 		logger:          logger,
@@ -40,6 +44,8 @@ func NewOprLogSvc(
 		umHttpAcc:       userHttpAcc,
 		efastHttpAcc:    efastHttpAcc,
 		opsHttpAcc:      oprHttpAcc,
+		alertEngine:     alertEngine,
+		logForward:      logForward,
 	}
 
 	return svc
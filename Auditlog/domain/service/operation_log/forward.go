@@ -0,0 +1,55 @@
+package oprsvc
+
+import (
+	"fmt"
+
+	"AuditLog/common"
+	"AuditLog/common/constants/logconsts"
+	"AuditLog/common/enums/oprlogenums"
+	"AuditLog/common/helpers"
+	oprlogeo "AuditLog/domain/entity/oprlogeo"
+	"AuditLog/models"
+)
+
+// forwardHandle 运营日志按操作日志类型转发到 SIEM, 由转发模块按操作日志的过滤级别过滤
+// 单条日志转发失败不影响其他日志
+func (l *oprLogSvc) forwardHandle(eos []*oprlogeo.LogEntry, bizType oprlogenums.BizType) {
+	for _, eo := range eos {
+		if eo.LogID == "" {
+			continue
+		}
+
+		// 运营日志不入库, 使用单独的事务写入 outbox
+		if err := l.logForward.Forward(common.Operation, eo.LogID, entryToAuditLog(eo, bizType), nil); err != nil {
+			helpers.RecordErrLogWithPos(l.logger, err, "oprsvc.forwardHandle", "logForward.Forward")
+			continue
+		}
+	}
+}
+
+// entryToAuditLog 运营日志转换为审计日志格式, 运营日志没有级别, 按信息级别转发
+func entryToAuditLog(eo *oprlogeo.LogEntry, bizType oprlogenums.BizType) *models.AuditLog {
+	log := &models.AuditLog{
+		Level:          logconsts.LogLevel.INFO,
+		Date:           eo.Date,
+		Msg:            eo.Description,
+		AdditionalInfo: fmt.Sprintf("{\"biz_type\": %q, \"operation\": %q}", bizType, eo.Operation),
+	}
+
+	if eo.Operator != nil {
+		log.UserID = eo.Operator.ID
+		log.UserName = eo.Operator.Name
+		log.UserType = string(eo.Operator.Type)
+		if eo.Operator.Agent != nil {
+			log.IP = eo.Operator.Agent.IP
+			log.UserAgent = eo.Operator.Agent.UserAgent
+		}
+	}
+
+	if eo.Object != nil {
+		log.ObjID = eo.Object.ID
+		log.ObjName = eo.Object.Name
+	}
+
+	return log
+}
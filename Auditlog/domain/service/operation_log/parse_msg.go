@@ -2,12 +2,15 @@ package oprsvc
 
 import (
 	"context"
+	"strconv"
+	"time"
 
 	"AuditLog/common/enums/oprlogenums"
 	"AuditLog/common/helpers"
 	"AuditLog/common/utils"
 	oprlogeo "AuditLog/domain/entity/oprlogeo"
 	"AuditLog/domain/service/operation_log/complete_info"
+	"AuditLog/infra"
 	"AuditLog/infra/json_schema/jsc_opr_log"
 )
 
@@ -28,6 +31,9 @@ func (l *oprLogSvc) parseMsg(ctx context.Context, bizType oprlogenums.BizType, m
 		return
 	}
 
+	// 2.1 分配日志id和接收时间
+	l.stampEntries(entryEos)
+
 	// 3. 补全缺少的信息
 	if bizType.IsClientBizType() || jsc_opr_log.IsSpecialClientType(bizType, entryEos[0].Operation) {
 		c := complete_info.NewCompleteInfo(l.documentHttpAcc, l.umHttpAcc, l.efastHttpAcc)
@@ -54,3 +60,21 @@ func (l *oprLogSvc) MsgToMap(msg []byte) (logMaps []map[string]interface{}, err
 
 	return
 }
+
+// stampEntries 接收时为每条日志分配id和时间, 转发到 SIEM 和告警评估使用同一id和时间
+// 分配id失败的日志id为空, 不转发到 SIEM
+func (l *oprLogSvc) stampEntries(entryEos []*oprlogeo.LogEntry) {
+	now := time.Now().UnixMicro()
+
+	for _, eo := range entryEos {
+		eo.Date = now
+
+		uid, err := infra.GetUniqueID()
+		if err != nil {
+			helpers.RecordErrLogWithPos(l.logger, err, "oprsvc.stampEntries", "GetUniqueID")
+			continue
+		}
+
+		eo.LogID = strconv.FormatUint(uid, 10)
+	}
+}
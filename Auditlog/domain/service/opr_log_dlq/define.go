@@ -0,0 +1,39 @@
+package oprdlqsvc
+
+import (
+	"time"
+
+	"AuditLog/common/enums/oprlogenums"
+	"AuditLog/gocommon/api"
+	"AuditLog/infra/json_schema/jsc_opr_log"
+	"AuditLog/interfaces"
+	oprlogdriveri "AuditLog/interfaces/driveradapter/operation_log"
+)
+
+type oprLogDLQSvc struct {
+	logger    api.Logger
+	dlqRepo   interfaces.OprLogDLQRepo
+	oprLogSvc oprlogdriveri.IOprLogSvc
+	logMgnt   interfaces.LogMgnt
+
+	validate func(msg []byte, bizType oprlogenums.BizType) (operation string, invalidFields []string, err error)
+	now      func() time.Time
+}
+
+func NewOprLogDLQSvc(
+	logger api.Logger,
+	dlqRepo interfaces.OprLogDLQRepo,
+	oprLogSvc oprlogdriveri.IOprLogSvc,
+	logMgnt interfaces.LogMgnt,
+) oprlogdriveri.IOprLogDLQSvc {
+	svc := &oprLogDLQSvc{
+		logger:    logger,
+		dlqRepo:   dlqRepo,
+		oprLogSvc: oprLogSvc,
+		logMgnt:   logMgnt,
+		validate:  jsc_opr_log.ValidateOprLogJSONSchema,
+		now:       time.Now,
+	}
+
+	return svc
+}
@@ -0,0 +1,232 @@
+package oprdlqsvc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	jsoniter "github.com/json-iterator/go"
+
+	"AuditLog/common"
+	"AuditLog/common/constants/dlqconsts"
+	"AuditLog/common/constants/logconsts"
+	"AuditLog/common/enums/oprlogenums"
+	"AuditLog/errors"
+	"AuditLog/infra"
+	"AuditLog/locale"
+	"AuditLog/models"
+	"AuditLog/models/dlqmodels"
+)
+
+// Record 记录被拒绝的运营日志
+func (s *oprLogDLQSvc) Record(ctx context.Context, bizType oprlogenums.BizType, msg []byte, reason string, invalidFields []string, errMsg string) (err error) {
+	uid, err := infra.GetUniqueID()
+	if err != nil {
+		return fmt.Errorf("[OprLogDLQ][Record] new sonyflake id failed: %w", err)
+	}
+
+	fields, err := marshalFields(invalidFields)
+	if err != nil {
+		return fmt.Errorf("[OprLogDLQ][Record] marshal invalid fields failed: %w", err)
+	}
+
+	now := s.now().UnixMicro()
+	err = s.dlqRepo.New(&dlqmodels.DeadLetterPO{
+		ID:            int64(uid),
+		BizType:       string(bizType),
+		Message:       string(msg),
+		Reason:        reason,
+		InvalidFields: fields,
+		ErrMsg:        errMsg,
+		Status:        dlqconsts.StatusPending,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	})
+	if err != nil {
+		return fmt.Errorf("[OprLogDLQ][Record] save dead letter failed: %w", err)
+	}
+
+	return
+}
+
+func (s *oprLogDLQSvc) GetDeadLetters(ctx context.Context, req *dlqmodels.GetDeadLettersReq) (res *dlqmodels.GetDeadLettersRes, err error) {
+	conds := []string{}
+	params := []interface{}{}
+	if req.BizType != "" {
+		conds = append(conds, "f_biz_type=?")
+		params = append(params, req.BizType)
+	}
+	if req.Reason != "" {
+		conds = append(conds, "f_reason=?")
+		params = append(params, req.Reason)
+	}
+	if req.Status != 0 {
+		conds = append(conds, "f_status=?")
+		params = append(params, req.Status)
+	}
+	condition := whereClause(conds)
+
+	count, err := s.dlqRepo.CountByCondition(condition, params)
+	if err != nil {
+		return nil, fmt.Errorf("[OprLogDLQ][GetDeadLetters] count dead letters failed: %w", err)
+	}
+
+	condition += " ORDER BY f_created_at DESC"
+	if req.Limit > 0 {
+		condition += " LIMIT ? OFFSET ?"
+		params = append(params, req.Limit, req.Offset)
+	}
+
+	letters, err := s.dlqRepo.GetByCondition(condition, params)
+	if err != nil {
+		return nil, fmt.Errorf("[OprLogDLQ][GetDeadLetters] get dead letters failed: %w", err)
+	}
+
+	res = &dlqmodels.GetDeadLettersRes{
+		Entries:    make([]*dlqmodels.DeadLetterVO, 0, len(letters)),
+		TotalCount: count,
+	}
+	for _, letter := range letters {
+		res.Entries = append(res.Entries, letterToVO(letter, false))
+	}
+	return
+}
+
+func (s *oprLogDLQSvc) GetDeadLetter(ctx context.Context, id int64) (res *dlqmodels.DeadLetterVO, err error) {
+	letter, err := s.dlqRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if letter == nil {
+		return nil, errors.NewCtx(
+			ctx,
+			errors.DeadLetterNotFoundErr,
+			"Dead letter not found",
+			map[string]interface{}{
+				"id": []int64{id},
+			},
+		)
+	}
+	return letterToVO(letter, true), nil
+}
+
+// Purge 清除死信, 未指定ids时至少需要一个过滤条件, 避免误删全部死信
+func (s *oprLogDLQSvc) Purge(ctx context.Context, req *dlqmodels.PurgeDeadLettersReq) (res *dlqmodels.PurgeDeadLettersRes, err error) {
+	conds := []string{}
+	params := []interface{}{}
+	if len(req.IDs) > 0 {
+		conds = append(conds, inClause("f_id", len(req.IDs)))
+		for _, id := range req.IDs {
+			params = append(params, id)
+		}
+	} else {
+		if req.BizType != "" {
+			conds = append(conds, "f_biz_type=?")
+			params = append(params, req.BizType)
+		}
+		if req.Status != 0 {
+			conds = append(conds, "f_status=?")
+			params = append(params, req.Status)
+		}
+		if req.Before > 0 {
+			conds = append(conds, "f_created_at<?")
+			params = append(params, req.Before)
+		}
+	}
+	if len(conds) == 0 {
+		return nil, errors.NewCtx(ctx, errors.BadRequestErr, "ids or at least one of biz_type, status, before is required", nil)
+	}
+
+	count, err := s.dlqRepo.DeleteByCondition(whereClause(conds), params)
+	if err != nil {
+		return nil, fmt.Errorf("[OprLogDLQ][Purge] delete dead letters failed: %w", err)
+	}
+
+	if count > 0 {
+		go s.autilog(
+			ctx,
+			logconsts.LogLevel.WARN,
+			logconsts.OpType.ManagementType.DELETE,
+			fmt.Sprintf(locale.GetI18nCtx(ctx, locale.PurgeDeadLetter), count),
+			"",
+		)
+	}
+
+	return &dlqmodels.PurgeDeadLettersRes{Count: count}, nil
+}
+
+// 记录审计日志
+func (s *oprLogDLQSvc) autilog(ctx context.Context, level int, opType int, msg, exmsg string) {
+	visitor := ctx.Value(common.VisitorKey).(*models.Visitor)
+	err := s.logMgnt.SendLog(&models.SendLogVo{
+		LogType:  common.Management,
+		Language: "",
+		LogContent: &models.AuditLog{
+			UserID:    visitor.ID,
+			UserName:  visitor.Name,
+			UserType:  common.AuthenticatedUser,
+			Level:     level,
+			OpType:    opType,
+			Date:      time.Now().UnixMicro(),
+			IP:        visitor.IP,
+			Mac:       visitor.Mac,
+			Msg:       msg,
+			Exmsg:     exmsg,
+			UserAgent: visitor.AgentType,
+			OutBizID:  uuid.NewString(),
+		},
+	})
+	if err != nil {
+		s.logger.Warnf("[OprLogDLQ] send log error: %v", err)
+	}
+}
+
+func whereClause(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conds, " AND ")
+}
+
+func inClause(field string, n int) string {
+	return field + " IN (" + strings.TrimSuffix(strings.Repeat("?,", n), ",") + ")"
+}
+
+func marshalFields(fields []string) (string, error) {
+	if fields == nil {
+		fields = []string{}
+	}
+	return jsoniter.MarshalToString(fields)
+}
+
+func letterToVO(letter *dlqmodels.DeadLetterPO, withMessage bool) *dlqmodels.DeadLetterVO {
+	fields := []string{}
+	if letter.InvalidFields != "" {
+		// 入库前已序列化, 解析失败时按无错误字段处理
+		_ = jsoniter.UnmarshalFromString(letter.InvalidFields, &fields)
+	}
+
+	vo := &dlqmodels.DeadLetterVO{
+		ID:            letter.ID,
+		BizType:       letter.BizType,
+		Reason:        letter.Reason,
+		InvalidFields: fields,
+		ErrMsg:        letter.ErrMsg,
+		Status:        letter.Status,
+		Attempts:      letter.Attempts,
+		CreatedAt:     letter.CreatedAt,
+		UpdatedAt:     letter.UpdatedAt,
+		ReplayedAt:    letter.ReplayedAt,
+	}
+	if withMessage {
+		if jsoniter.Valid([]byte(letter.Message)) {
+			vo.Message = jsoniter.RawMessage(letter.Message)
+		} else {
+			// 非法json按字符串返回
+			vo.Message, _ = jsoniter.Marshal(letter.Message)
+		}
+	}
+	return vo
+}
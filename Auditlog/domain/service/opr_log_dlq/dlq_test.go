@@ -0,0 +1,202 @@
+package oprdlqsvc
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"AuditLog/common"
+	"AuditLog/common/constants/dlqconsts"
+	"AuditLog/common/enums/oprlogenums"
+	auditerrors "AuditLog/errors"
+	"AuditLog/infra/cmp/langcmp"
+	drivermock "AuditLog/interfaces/driveradapter/mock"
+	"AuditLog/interfaces/mock"
+	"AuditLog/models"
+	"AuditLog/models/dlqmodels"
+	"AuditLog/test/mock_log"
+)
+
+func TestMain(m *testing.M) {
+	langcmp.NewLangCmp().SetSysDefLang(string(langcmp.ZhCN))
+	os.Exit(m.Run())
+}
+
+func TestOprLogDLQSvc(t *testing.T) {
+	Convey("OprLogDLQSvc", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		logger := mock_log.NewMockLogger(ctrl)
+		dlqRepo := mock.NewMockOprLogDLQRepo(ctrl)
+		oprLogSvc := drivermock.NewMockIOprLogSvc(ctrl)
+		logMgnt := mock.NewMockLogMgnt(ctrl)
+
+		now := time.Date(2024, 12, 19, 10, 0, 0, 0, time.Local)
+		var invalidFields []string
+		var validateErr error
+		svc := &oprLogDLQSvc{
+			logger:    logger,
+			dlqRepo:   dlqRepo,
+			oprLogSvc: oprLogSvc,
+			logMgnt:   logMgnt,
+			validate: func(msg []byte, bizType oprlogenums.BizType) (string, []string, error) {
+				return "", invalidFields, validateErr
+			},
+			now: func() time.Time { return now },
+		}
+
+		ctx := context.WithValue(context.Background(), common.VisitorKey, &models.Visitor{
+			ID:   "test_user",
+			Name: "Test User",
+		})
+		bizType := oprlogenums.DirVisit
+		letter := &dlqmodels.DeadLetterPO{
+			ID:            1,
+			BizType:       string(bizType),
+			Message:       `{"operation":"visit"}`,
+			Reason:        dlqconsts.ReasonInvalidFields,
+			InvalidFields: `["object.id: is required"]`,
+			Status:        dlqconsts.StatusPending,
+		}
+
+		Convey("记录死信", func() {
+			dlqRepo.EXPECT().New(gomock.Any()).DoAndReturn(func(po *dlqmodels.DeadLetterPO) error {
+				assert.Equal(t, string(bizType), po.BizType)
+				assert.Equal(t, dlqconsts.ReasonInvalidFields, po.Reason)
+				assert.Equal(t, `["object.id: is required"]`, po.InvalidFields)
+				assert.Equal(t, dlqconsts.StatusPending, po.Status)
+				assert.Equal(t, now.UnixMicro(), po.CreatedAt)
+				return nil
+			})
+
+			err := svc.Record(ctx, bizType, []byte(letter.Message), dlqconsts.ReasonInvalidFields, []string{"object.id: is required"}, "")
+			assert.NoError(t, err)
+		})
+
+		Convey("获取死信列表不返回日志内容", func() {
+			dlqRepo.EXPECT().CountByCondition("WHERE f_biz_type=? AND f_status=?", []interface{}{string(bizType), dlqconsts.StatusPending}).Return(int64(3), nil)
+			dlqRepo.EXPECT().GetByCondition("WHERE f_biz_type=? AND f_status=? ORDER BY f_created_at DESC LIMIT ? OFFSET ?",
+				[]interface{}{string(bizType), dlqconsts.StatusPending, 1, 0}).Return([]*dlqmodels.DeadLetterPO{letter}, nil)
+
+			res, err := svc.GetDeadLetters(ctx, &dlqmodels.GetDeadLettersReq{BizType: string(bizType), Status: dlqconsts.StatusPending, Limit: 1})
+			assert.NoError(t, err)
+			assert.Equal(t, int64(3), res.TotalCount)
+			assert.Equal(t, []string{"object.id: is required"}, res.Entries[0].InvalidFields)
+			assert.Nil(t, res.Entries[0].Message)
+		})
+
+		Convey("获取死信详情", func() {
+			Convey("不存在", func() {
+				dlqRepo.EXPECT().GetByID(int64(1)).Return(nil, nil)
+				_, err := svc.GetDeadLetter(ctx, 1)
+				assert.Equal(t, auditerrors.DeadLetterNotFoundErr, err.(*auditerrors.ErrorResp).Code())
+			})
+
+			Convey("非法json按字符串返回", func() {
+				letter.Message = `{"operation":`
+				dlqRepo.EXPECT().GetByID(int64(1)).Return(letter, nil)
+				res, err := svc.GetDeadLetter(ctx, 1)
+				assert.NoError(t, err)
+				assert.Equal(t, `"{\"operation\":"`, string(res.Message))
+			})
+		})
+
+		Convey("清除死信", func() {
+			Convey("缺少条件", func() {
+				_, err := svc.Purge(ctx, &dlqmodels.PurgeDeadLettersReq{})
+				assert.Equal(t, auditerrors.BadRequestErr, err.(*auditerrors.ErrorResp).Code())
+			})
+
+			Convey("按ids清除", func() {
+				dlqRepo.EXPECT().DeleteByCondition("WHERE f_id IN (?,?)", []interface{}{int64(1), int64(2)}).Return(int64(2), nil)
+				logMgnt.EXPECT().SendLog(gomock.Any()).Return(nil).AnyTimes()
+
+				res, err := svc.Purge(ctx, &dlqmodels.PurgeDeadLettersReq{IDs: []int64{1, 2}, Status: dlqconsts.StatusPending})
+				assert.NoError(t, err)
+				assert.Equal(t, int64(2), res.Count)
+			})
+
+			Convey("按条件清除", func() {
+				dlqRepo.EXPECT().DeleteByCondition("WHERE f_status=? AND f_created_at<?", []interface{}{dlqconsts.StatusReplayed, now.UnixMicro()}).Return(int64(0), nil)
+
+				res, err := svc.Purge(ctx, &dlqmodels.PurgeDeadLettersReq{Status: dlqconsts.StatusReplayed, Before: now.UnixMicro()})
+				assert.NoError(t, err)
+				assert.Equal(t, int64(0), res.Count)
+			})
+		})
+
+		Convey("重放死信", func() {
+			Convey("缺少条件", func() {
+				_, err := svc.Replay(ctx, &dlqmodels.ReplayDeadLettersReq{})
+				assert.Equal(t, auditerrors.BadRequestErr, err.(*auditerrors.ErrorResp).Code())
+			})
+
+			Convey("校验通过后处理成功", func() {
+				dlqRepo.EXPECT().GetByCondition("WHERE f_status=? AND f_biz_type=? ORDER BY f_created_at LIMIT ?",
+					[]interface{}{dlqconsts.StatusPending, string(bizType), dlqconsts.MaxReplayBatch}).Return([]*dlqmodels.DeadLetterPO{letter}, nil)
+				oprLogSvc.EXPECT().HandleMsg(gomock.Any(), []byte(`[{"operation":"visit"}]`), bizType).Return(nil)
+				dlqRepo.EXPECT().UpdateReplayResult(gomock.Any()).DoAndReturn(func(po *dlqmodels.DeadLetterPO) error {
+					assert.Equal(t, dlqconsts.StatusReplayed, po.Status)
+					assert.Equal(t, 1, po.Attempts)
+					assert.Equal(t, now.UnixMicro(), po.ReplayedAt)
+					return nil
+				})
+				logMgnt.EXPECT().SendLog(gomock.Any()).Return(nil).AnyTimes()
+
+				res, err := svc.Replay(ctx, &dlqmodels.ReplayDeadLettersReq{BizType: string(bizType)})
+				assert.NoError(t, err)
+				assert.Equal(t, 1, res.Total)
+				assert.Equal(t, 1, res.Replayed)
+				assert.Empty(t, res.Failures)
+			})
+
+			Convey("仍存在错误字段", func() {
+				invalidFields = []string{"object.name: is required"}
+				dlqRepo.EXPECT().GetByCondition("WHERE f_status=? AND f_id IN (?) ORDER BY f_created_at",
+					[]interface{}{dlqconsts.StatusPending, int64(1)}).Return([]*dlqmodels.DeadLetterPO{letter}, nil)
+				logger.EXPECT().Warnf(gomock.Any(), gomock.Any())
+				dlqRepo.EXPECT().UpdateReplayResult(gomock.Any()).DoAndReturn(func(po *dlqmodels.DeadLetterPO) error {
+					assert.Equal(t, dlqconsts.StatusPending, po.Status)
+					assert.Equal(t, `["object.name: is required"]`, po.InvalidFields)
+					return nil
+				})
+				logMgnt.EXPECT().SendLog(gomock.Any()).Return(nil).AnyTimes()
+
+				res, err := svc.Replay(ctx, &dlqmodels.ReplayDeadLettersReq{IDs: []int64{1}})
+				assert.NoError(t, err)
+				assert.Equal(t, 0, res.Replayed)
+				assert.Equal(t, dlqconsts.ReasonInvalidFields, res.Failures[0].Reason)
+				assert.Equal(t, []string{"object.name: is required"}, res.Failures[0].InvalidFields)
+			})
+
+			Convey("领域服务处理失败", func() {
+				dlqRepo.EXPECT().GetByCondition(gomock.Any(), gomock.Any()).Return([]*dlqmodels.DeadLetterPO{letter}, nil)
+				oprLogSvc.EXPECT().HandleMsg(gomock.Any(), gomock.Any(), bizType).Return(errors.New("opensearch error"))
+				logger.EXPECT().Warnf(gomock.Any(), gomock.Any())
+				dlqRepo.EXPECT().UpdateReplayResult(gomock.Any()).Return(nil)
+				logMgnt.EXPECT().SendLog(gomock.Any()).Return(nil).AnyTimes()
+
+				res, err := svc.Replay(ctx, &dlqmodels.ReplayDeadLettersReq{IDs: []int64{1}})
+				assert.NoError(t, err)
+				assert.Equal(t, dlqconsts.ReasonHandleError, res.Failures[0].Reason)
+				assert.Equal(t, "opensearch error", res.Failures[0].ErrMsg)
+			})
+
+			Convey("保存重放结果失败", func() {
+				validateErr = errors.New("schema not found")
+				dlqRepo.EXPECT().GetByCondition(gomock.Any(), gomock.Any()).Return([]*dlqmodels.DeadLetterPO{letter}, nil)
+				logger.EXPECT().Warnf(gomock.Any(), gomock.Any())
+				dlqRepo.EXPECT().UpdateReplayResult(gomock.Any()).Return(errors.New("db error"))
+
+				_, err := svc.Replay(ctx, &dlqmodels.ReplayDeadLettersReq{IDs: []int64{1}})
+				assert.Error(t, err)
+			})
+		})
+	})
+}
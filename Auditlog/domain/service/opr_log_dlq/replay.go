@@ -0,0 +1,135 @@
+package oprdlqsvc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tidwall/gjson"
+
+	"AuditLog/common/constants/dlqconsts"
+	"AuditLog/common/constants/logconsts"
+	"AuditLog/common/enums/oprlogenums"
+	"AuditLog/common/utils"
+	"AuditLog/errors"
+	"AuditLog/locale"
+	"AuditLog/models/dlqmodels"
+)
+
+// Replay 重新校验并处理待处理的死信, 适用于修复 json schema 后补录日志
+// 指定ids时重放对应的死信, 否则按进入死信的时间顺序重放该业务类型下的死信
+func (s *oprLogDLQSvc) Replay(ctx context.Context, req *dlqmodels.ReplayDeadLettersReq) (res *dlqmodels.ReplayDeadLettersRes, err error) {
+	var (
+		condition string
+		params    = []interface{}{dlqconsts.StatusPending}
+	)
+	switch {
+	case len(req.IDs) > dlqconsts.MaxReplayBatch:
+		return nil, errors.NewCtx(ctx, errors.BadRequestErr, fmt.Sprintf("at most %d ids can be replayed at a time", dlqconsts.MaxReplayBatch), nil)
+	case len(req.IDs) > 0:
+		condition = "WHERE f_status=? AND " + inClause("f_id", len(req.IDs)) + " ORDER BY f_created_at"
+		for _, id := range req.IDs {
+			params = append(params, id)
+		}
+	case req.BizType != "":
+		condition = "WHERE f_status=? AND f_biz_type=? ORDER BY f_created_at LIMIT ?"
+		params = append(params, req.BizType, dlqconsts.MaxReplayBatch)
+	default:
+		return nil, errors.NewCtx(ctx, errors.BadRequestErr, "ids or biz_type is required", nil)
+	}
+
+	letters, err := s.dlqRepo.GetByCondition(condition, params)
+	if err != nil {
+		return nil, fmt.Errorf("[OprLogDLQ][Replay] get dead letters failed: %w", err)
+	}
+
+	res = &dlqmodels.ReplayDeadLettersRes{
+		Total:    len(letters),
+		Failures: make([]*dlqmodels.ReplayFailure, 0),
+	}
+	for _, letter := range letters {
+		if err = s.replayOne(ctx, letter); err != nil {
+			return nil, err
+		}
+
+		if letter.Status == dlqconsts.StatusReplayed {
+			res.Replayed++
+			continue
+		}
+		vo := letterToVO(letter, false)
+		res.Failures = append(res.Failures, &dlqmodels.ReplayFailure{
+			ID:            vo.ID,
+			Reason:        vo.Reason,
+			InvalidFields: vo.InvalidFields,
+			ErrMsg:        vo.ErrMsg,
+		})
+	}
+
+	if res.Total > 0 {
+		go s.autilog(
+			ctx,
+			logconsts.LogLevel.INFO,
+			logconsts.OpType.ManagementType.OTHER,
+			locale.GetI18nCtx(ctx, locale.ReplayDeadLetter),
+			fmt.Sprintf(locale.GetI18nCtx(ctx, locale.ReplayDeadLetterExMsg), res.Total, res.Replayed, len(res.Failures)),
+		)
+	}
+
+	return
+}
+
+// replayOne 按mq接收时的流程校验并处理单条死信, 并保存重放结果
+// 校验或处理失败时死信保持待处理状态, 原因更新为本次失败的原因
+func (s *oprLogDLQSvc) replayOne(ctx context.Context, letter *dlqmodels.DeadLetterPO) (err error) {
+	var (
+		reason        string
+		invalidFields []string
+		errMsg        string
+	)
+
+	bizType := oprlogenums.BizType(letter.BizType)
+	msg := []byte(letter.Message)
+	if !bizType.Check() {
+		reason = dlqconsts.ReasonInvalidBizType
+		errMsg = fmt.Sprintf("invalid biz_type: %s", bizType)
+	} else {
+		if !gjson.GetBytes(msg, "@this").IsArray() {
+			msg = utils.JSONObjectToArray(msg)
+		}
+
+		var validateErr error
+		_, invalidFields, validateErr = s.validate(msg, bizType)
+		switch {
+		case validateErr != nil:
+			reason = dlqconsts.ReasonSchemaError
+			errMsg = validateErr.Error()
+		case len(invalidFields) > 0:
+			reason = dlqconsts.ReasonInvalidFields
+		default:
+			if handleErr := s.oprLogSvc.HandleMsg(ctx, msg, bizType); handleErr != nil {
+				reason = dlqconsts.ReasonHandleError
+				errMsg = handleErr.Error()
+			}
+		}
+	}
+
+	now := s.now().UnixMicro()
+	letter.Attempts++
+	letter.UpdatedAt = now
+	if reason == "" {
+		letter.Status = dlqconsts.StatusReplayed
+		letter.ReplayedAt = now
+		letter.ErrMsg = ""
+	} else {
+		s.logger.Warnf("[OprLogDLQ][Replay] dead letter %v replay failed, reason: %s, err: %s", letter.ID, reason, errMsg)
+		letter.Reason = reason
+		letter.ErrMsg = errMsg
+		if letter.InvalidFields, err = marshalFields(invalidFields); err != nil {
+			return fmt.Errorf("[OprLogDLQ][Replay] marshal invalid fields failed: %w", err)
+		}
+	}
+
+	if err = s.dlqRepo.UpdateReplayResult(letter); err != nil {
+		return fmt.Errorf("[OprLogDLQ][Replay] update dead letter %v failed: %w", letter.ID, err)
+	}
+	return
+}
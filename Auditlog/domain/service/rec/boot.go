@@ -3,7 +3,6 @@ package recsvc
 import (
 	"context"
 
-	recconsts "AuditLog/common/constants/recenums"
 	"AuditLog/common/helpers"
 	"AuditLog/infra/config/mapping"
 )
@@ -13,8 +12,9 @@ func (l *recSvc) CreateByMapping(ctx context.Context) (err error) {
 		return
 	}
 
+	// 日志写入按周期分区的索引，index为分区索引的别名
 	for index, mapping := range mapping.RecMappingMap {
-		err = l.opsHttpAcc.CreateIndex(ctx, string(index), mapping, recconsts.IndexSetting)
+		err = l.RolloverIndex(ctx, string(index), mapping)
 		if err != nil {
 			return
 		}
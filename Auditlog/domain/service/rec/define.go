@@ -1,8 +1,11 @@
 package recsvc
 
 import (
+	"sync"
+
 	"AuditLog/gocommon/api"
 	"AuditLog/infra/cmp/icmp"
+	"AuditLog/interfaces"
 	persrecrepoi "AuditLog/interfaces/drivenadapter/idbaccess/persrec"
 	"AuditLog/interfaces/drivenadapter/ihttpaccess"
 	recdriveri "AuditLog/interfaces/driveradapter/rec"
@@ -15,6 +18,14 @@ type recSvc struct {
 	svcConfigRepo persrecrepoi.IPersSvcConfigRepo
 
 	dmlCmp icmp.RedisDlmCmp
+
+	legalHold interfaces.LegalHold
+
+	// rolloverPeriod 索引分区周期
+	rolloverPeriod string
+
+	// backfilling 正在后台回填旧索引的别名
+	backfilling sync.Map
 }
 
 func NewRecSvc(
@@ -22,12 +33,16 @@ func NewRecSvc(
 	oprHttpAcc ihttpaccess.OpsHttpAcc,
 	svcConfigRepo persrecrepoi.IPersSvcConfigRepo,
 	dmlCmp icmp.RedisDlmCmp,
+	legalHold interfaces.LegalHold,
+	rolloverPeriod string,
 ) recdriveri.IRecSvc {
 	svc := &recSvc{
-		logger:        logger,
-		opsHttpAcc:    oprHttpAcc,
-		svcConfigRepo: svcConfigRepo,
-		dmlCmp:        dmlCmp,
+		logger:         logger,
+		opsHttpAcc:     oprHttpAcc,
+		svcConfigRepo:  svcConfigRepo,
+		dmlCmp:         dmlCmp,
+		legalHold:      legalHold,
+		rolloverPeriod: rolloverPeriod,
 	}
 
 	return svc
@@ -2,18 +2,84 @@ package recsvc
 
 import (
 	"context"
+	"strings"
 	"time"
 
+	"AuditLog/common"
 	recconsts "AuditLog/common/constants/recenums"
 	"AuditLog/common/helpers"
+	"AuditLog/common/types/rectypes"
+	"AuditLog/common/utils/legalholdutils"
+	"AuditLog/models/lhmodels"
 )
 
 func (l *recSvc) RemoveOldLog(ctx context.Context, index string, saveDays int) (err error) {
 	// 删除日志
-	// conf.SaveDays之前的时间戳
-	oldTimeStr := time.Now().UTC().
-		AddDate(0, 0, -saveDays).
-		Format(time.RFC3339)
+	// conf.SaveDays之前的时间
+	oldTime := time.Now().UTC().AddDate(0, 0, -saveDays)
+
+	// 法律保留范围内的日志不删除, 保留操作日志或索引对应业务类型的保留均生效
+	// 各运营日志索引的用户和部门字段不统一, 限定了用户或部门的保留按整个时间范围处理
+	bizType := strings.TrimPrefix(index, recconsts.IndexPrefix)
+	holds, err := l.legalHold.GetMatchedHolds([]string{common.Operation, bizType}, 0, oldTime.UnixMicro()+1)
+	if err != nil {
+		helpers.RecordErrLogWithPos(l.logger, err, "recSvc.RemoveOldLog")
+		return
+	}
+
+	indices, err := l.opsHttpAcc.GetAliasIndices(ctx, index)
+	if err != nil {
+		helpers.RecordErrLogWithPos(l.logger, err, "recSvc.RemoveOldLog")
+		return
+	}
+
+	// 旧索引尚未迁移为分区索引时按时间范围删除文档
+	if len(indices) == 0 {
+		return l.removeOldDocs(ctx, index, oldTime, holds)
+	}
+
+	// 删除整个过期的分区索引，写索引不删除
+	for idx, isWrite := range indices {
+		begin, end, ok := rectypes.ParsePartitionIndex(rectypes.RecLogIndex(index), idx)
+		if !ok || isWrite || end.After(oldTime) {
+			continue
+		}
+
+		if isPartitionHeld(holds, begin, end) {
+			l.logger.Infof("[recSvc.RemoveOldLog] index %s is under legal hold, skip removing", idx)
+			continue
+		}
+
+		err = l.opsHttpAcc.DeleteIndex(ctx, idx)
+		if err != nil {
+			helpers.RecordErrLogWithPos(l.logger, err, "recSvc.RemoveOldLog")
+			return
+		}
+
+		l.logger.Infof("[recSvc.RemoveOldLog] expired index %s is removed", idx)
+	}
+
+	return
+}
+
+// removeOldDocs 按时间范围删除未分区索引中的日志, 只删除最早的保留开始时间之前的日志
+func (l *recSvc) removeOldDocs(ctx context.Context, index string, oldTime time.Time, holds []*lhmodels.LegalHoldVO) (err error) {
+	for _, hold := range holds {
+		// 按秒取整后删除条件仍不包含保留开始时间
+		holdTime := time.UnixMicro(hold.BeginTime).UTC().Truncate(time.Second).Add(-time.Second)
+		if holdTime.Before(oldTime) {
+			oldTime = holdTime
+		}
+	}
+
+	if len(holds) > 0 {
+		l.logger.Infof("[recSvc.RemoveOldLog] index %s is under %d legal holds, remove logs before %s", index, len(holds), oldTime.Format(time.RFC3339))
+		if oldTime.Unix() < 0 {
+			return
+		}
+	}
+
+	oldTimeStr := oldTime.Format(time.RFC3339)
 
 	err = l.opsHttpAcc.DeleteDocsByFieldRange(ctx, index, recconsts.CreatedFieldName, nil, oldTimeStr)
 	if err != nil {
@@ -23,3 +89,14 @@ func (l *recSvc) RemoveOldLog(ctx context.Context, index string, saveDays int) (
 
 	return
 }
+
+// isPartitionHeld 分区的时间范围是否与法律保留重叠
+func isPartitionHeld(holds []*lhmodels.LegalHoldVO, begin, end time.Time) bool {
+	for _, hold := range holds {
+		if legalholdutils.Overlaps(hold, begin.UnixMicro(), end.UnixMicro()) {
+			return true
+		}
+	}
+
+	return false
+}
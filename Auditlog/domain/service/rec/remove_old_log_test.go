@@ -0,0 +1,115 @@
+package recsvc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"AuditLog/common"
+	recconsts "AuditLog/common/constants/recenums"
+	"AuditLog/common/types/rectypes"
+	"AuditLog/interfaces/drivenadapter/ihttpaccess/httpaccmock"
+	"AuditLog/interfaces/mock"
+	"AuditLog/models/lhmodels"
+	"AuditLog/test/mock_log"
+)
+
+func TestRemoveOldLog(t *testing.T) {
+	Convey("RemoveOldLog", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		logger := mock_log.NewMockLogger(ctrl)
+		opsHttpAcc := httpaccmock.NewMockOpsHttpAcc(ctrl)
+		legalHold := mock.NewMockLegalHold(ctrl)
+		svc := &recSvc{
+			logger:     logger,
+			opsHttpAcc: opsHttpAcc,
+			legalHold:  legalHold,
+		}
+
+		ctx := context.Background()
+		index := recconsts.IndexPrefix + "doc_operation"
+		logger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+
+		Convey("无法律保留时按保存天数删除", func() {
+			opsHttpAcc.EXPECT().GetAliasIndices(ctx, index).Return(map[string]bool{}, nil)
+			legalHold.EXPECT().GetMatchedHolds([]string{common.Operation, "doc_operation"}, int64(0), gomock.Any()).Return(nil, nil)
+			opsHttpAcc.EXPECT().DeleteDocsByFieldRange(ctx, index, recconsts.CreatedFieldName, nil, gomock.Any()).
+				DoAndReturn(func(_ context.Context, _, _ string, _, to interface{}) error {
+					oldTime, err := time.Parse(time.RFC3339, to.(string))
+					assert.NoError(t, err)
+					assert.WithinDuration(t, time.Now().AddDate(0, 0, -30), oldTime, time.Minute)
+					return nil
+				})
+
+			assert.NoError(t, svc.RemoveOldLog(ctx, index, 30))
+		})
+
+		Convey("只删除保留开始时间之前的日志", func() {
+			opsHttpAcc.EXPECT().GetAliasIndices(ctx, index).Return(map[string]bool{}, nil)
+			holdBegin := time.Now().AddDate(0, 0, -60)
+			legalHold.EXPECT().GetMatchedHolds([]string{common.Operation, "doc_operation"}, int64(0), gomock.Any()).Return([]*lhmodels.LegalHoldVO{
+				{Name: "investigation", BeginTime: holdBegin.UnixMicro(), UserIDs: []string{"u1"}},
+			}, nil)
+			opsHttpAcc.EXPECT().DeleteDocsByFieldRange(ctx, index, recconsts.CreatedFieldName, nil, gomock.Any()).
+				DoAndReturn(func(_ context.Context, _, _ string, _, to interface{}) error {
+					oldTime, err := time.Parse(time.RFC3339, to.(string))
+					assert.NoError(t, err)
+					assert.True(t, oldTime.Before(holdBegin))
+					return nil
+				})
+
+			assert.NoError(t, svc.RemoveOldLog(ctx, index, 30))
+		})
+
+		Convey("保留不限开始时间时不删除", func() {
+			opsHttpAcc.EXPECT().GetAliasIndices(ctx, index).Return(map[string]bool{}, nil)
+			legalHold.EXPECT().GetMatchedHolds([]string{common.Operation, "doc_operation"}, int64(0), gomock.Any()).Return([]*lhmodels.LegalHoldVO{
+				{Name: "investigation"},
+			}, nil)
+
+			assert.NoError(t, svc.RemoveOldLog(ctx, index, 30))
+		})
+
+		Convey("分区索引", func() {
+			alias := rectypes.RecLogIndex(index)
+			now := time.Now().UTC()
+			curBegin, _ := rectypes.GetPartitionRange(recconsts.RolloverPeriodMonth, now)
+			expired := rectypes.GetPartitionIndex(alias, recconsts.RolloverPeriodMonth, curBegin.AddDate(0, -3, 0))
+			held := rectypes.GetPartitionIndex(alias, recconsts.RolloverPeriodMonth, curBegin.AddDate(0, -4, 0))
+			recent := rectypes.GetPartitionIndex(alias, recconsts.RolloverPeriodDay, now.AddDate(0, 0, -1))
+			current := rectypes.GetPartitionIndex(alias, recconsts.RolloverPeriodMonth, now)
+
+			Convey("删除过期的分区，保留法律保留范围内的分区", func() {
+				opsHttpAcc.EXPECT().GetAliasIndices(ctx, index).Return(map[string]bool{
+					expired: false,
+					held:    false,
+					recent:  false,
+					current: true,
+				}, nil)
+				heldBegin, heldEnd, _ := rectypes.ParsePartitionIndex(alias, held)
+				legalHold.EXPECT().GetMatchedHolds([]string{common.Operation, "doc_operation"}, int64(0), gomock.Any()).Return([]*lhmodels.LegalHoldVO{
+					{Name: "investigation", BeginTime: heldBegin.UnixMicro(), EndTime: heldEnd.UnixMicro()},
+				}, nil)
+				opsHttpAcc.EXPECT().DeleteIndex(ctx, expired).Return(nil)
+
+				assert.NoError(t, svc.RemoveOldLog(ctx, index, 30))
+			})
+
+			Convey("写索引不删除", func() {
+				opsHttpAcc.EXPECT().GetAliasIndices(ctx, index).Return(map[string]bool{
+					expired: true,
+					held:    false,
+				}, nil)
+				legalHold.EXPECT().GetMatchedHolds([]string{common.Operation, "doc_operation"}, int64(0), gomock.Any()).Return(nil, nil)
+				opsHttpAcc.EXPECT().DeleteIndex(ctx, held).Return(nil)
+
+				assert.NoError(t, svc.RemoveOldLog(ctx, index, 30))
+			})
+		})
+	})
+}
@@ -0,0 +1,331 @@
+package recsvc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	recconsts "AuditLog/common/constants/recenums"
+	"AuditLog/common/helpers"
+	"AuditLog/common/types/rectypes"
+)
+
+// RolloverIndex 按分区周期滚动索引
+// index为写入和查询使用的别名，数据实际写入 别名-日期 的分区索引
+// 1. 创建当前和下一个分区索引（提前创建，避免周期切换时写入失败）
+// 2. 将当前分区设为别名的写索引，之前的分区保留在别名下供查询
+// 别名不存在而存在同名的旧索引（未分区）时，先将旧索引切换为别名，再在后台将旧索引的数据回填到分区索引
+func (l *recSvc) RolloverIndex(ctx context.Context, index string, mapping string) (err error) {
+	if helpers.IsAaronLocalDev() {
+		return
+	}
+
+	// 加分布式锁，避免多个pod同时滚动或迁移
+	mu := l.dmlCmp.NewMutex("RolloverIndex:" + index)
+
+	err = mu.Lock(ctx)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		_err := mu.Unlock()
+		if _err != nil {
+			l.logger.Errorln("[recSvc][RolloverIndex]: dlm unlock failed:", _err)
+		}
+	}()
+
+	alias := rectypes.RecLogIndex(index)
+	now := time.Now().UTC()
+	_, curEnd := rectypes.GetPartitionRange(l.rolloverPeriod, now)
+	cur := rectypes.GetPartitionIndex(alias, l.rolloverPeriod, now)
+	next := rectypes.GetPartitionIndex(alias, l.rolloverPeriod, curEnd)
+
+	// 1. 创建分区索引，已存在则不处理
+	for _, partition := range []string{cur, next} {
+		err = l.opsHttpAcc.CreateIndex(ctx, partition, mapping, recconsts.IndexSetting)
+		if err != nil {
+			helpers.RecordErrLogWithPos(l.logger, err, "recSvc.RolloverIndex.CreateIndex")
+			return
+		}
+	}
+
+	// 2. 获取别名下的索引
+	indices, err := l.opsHttpAcc.GetAliasIndices(ctx, index)
+	if err != nil {
+		helpers.RecordErrLogWithPos(l.logger, err, "recSvc.RolloverIndex.GetAliasIndices")
+		return
+	}
+
+	if len(indices) == 0 {
+		var exists bool
+
+		exists, err = l.opsHttpAcc.IndexExists(ctx, index)
+		if err != nil {
+			helpers.RecordErrLogWithPos(l.logger, err, "recSvc.RolloverIndex.IndexExists")
+			return
+		}
+
+		if exists {
+			err = l.migrateLegacyIndex(ctx, index, now)
+			if err != nil {
+				return
+			}
+
+			l.startBackfill(index, mapping)
+
+			return
+		}
+	}
+
+	// 上次回填未完成（如pod重启）时继续回填
+	if _, ok := indices[legacyIndexName(alias)]; ok {
+		l.startBackfill(index, mapping)
+	}
+
+	if indices[cur] {
+		return
+	}
+
+	// 3. 切换写索引
+	actions := make([]map[string]interface{}, 0, len(indices)+1)
+
+	for idx, isWrite := range indices {
+		if isWrite && idx != cur {
+			actions = append(actions, aliasAddAction(idx, index, false))
+		}
+	}
+
+	actions = append(actions, aliasAddAction(cur, index, true))
+
+	err = l.opsHttpAcc.UpdateAliases(ctx, actions)
+	if err != nil {
+		helpers.RecordErrLogWithPos(l.logger, err, "recSvc.RolloverIndex.UpdateAliases")
+		return
+	}
+
+	l.logger.Infof("[recSvc.RolloverIndex] write index of %s is switched to %s", index, cur)
+
+	return
+}
+
+// migrateLegacyIndex 将旧的未分区索引切换为同名别名，当前分区为写索引
+// 1. 禁止写入旧索引，期间被拒绝的日志由写入方等待后重试
+// 2. 将旧索引复制为 别名-legacy（硬链接段文件，耗时与数据量无关）
+// 3. 原子地删除旧索引并创建同名别名，复制的旧索引保留在别名下供查询，之后的日志写入当前分区
+// 旧索引中的数据由startBackfill在后台回填到分区索引，不阻塞启动
+func (l *recSvc) migrateLegacyIndex(ctx context.Context, index string, now time.Time) (err error) {
+	alias := rectypes.RecLogIndex(index)
+	legacy := legacyIndexName(alias)
+	cur := rectypes.GetPartitionIndex(alias, l.rolloverPeriod, now)
+
+	l.logger.Infof("[recSvc.migrateLegacyIndex] migrate legacy index %s to %s", index, legacy)
+
+	// 1. 禁止写入
+	err = l.opsHttpAcc.AddWriteBlock(ctx, index)
+	if err != nil {
+		helpers.RecordErrLogWithPos(l.logger, err, "recSvc.migrateLegacyIndex.AddWriteBlock")
+		return
+	}
+
+	// 2. 复制旧索引，上次复制后切换别名失败时已存在
+	exists, err := l.opsHttpAcc.IndexExists(ctx, legacy)
+	if err != nil {
+		helpers.RecordErrLogWithPos(l.logger, err, "recSvc.migrateLegacyIndex.IndexExists")
+		return
+	}
+
+	if !exists {
+		err = l.opsHttpAcc.CloneIndex(ctx, index, legacy)
+		if err != nil {
+			helpers.RecordErrLogWithPos(l.logger, err, "recSvc.migrateLegacyIndex.CloneIndex")
+			return
+		}
+	}
+
+	// 3. 切换为别名
+	err = l.opsHttpAcc.UpdateAliases(ctx, []map[string]interface{}{
+		{"remove_index": map[string]interface{}{"index": index}},
+		aliasAddAction(cur, index, true),
+		aliasAddAction(legacy, index, false),
+	})
+	if err != nil {
+		helpers.RecordErrLogWithPos(l.logger, err, "recSvc.migrateLegacyIndex.UpdateAliases")
+		return
+	}
+
+	l.logger.Infof("[recSvc.migrateLegacyIndex] write index of %s is switched to %s", index, cur)
+
+	return
+}
+
+// startBackfill 在后台回填旧索引的数据，同一个pod内同一别名只有一个回填任务
+func (l *recSvc) startBackfill(index string, mapping string) {
+	if _, loaded := l.backfilling.LoadOrStore(index, true); loaded {
+		return
+	}
+
+	go func() {
+		defer l.backfilling.Delete(index)
+
+		defer func() {
+			if e := recover(); e != nil {
+				_err := fmt.Errorf("panic: %v", e)
+				helpers.RecordErrLogWithPos(l.logger, _err, "recSvc.startBackfill", "recover")
+			}
+		}()
+
+		// 失败时由定时滚动任务重新发起
+		_ = l.backfillLegacyIndex(context.Background(), index, mapping)
+	}()
+}
+
+// backfillLegacyIndex 将 别名-legacy 的数据回填到分区索引
+// 1. 按@timestamp将数据复制到对应的分区索引，已在别名下的分区最后复制
+// 2. 原子地将新的分区加入别名并删除 别名-legacy
+// 已在别名下的分区复制完成到切换之间，其中的旧日志会被重复查询到
+func (l *recSvc) backfillLegacyIndex(ctx context.Context, index string, mapping string) (err error) {
+	// 加分布式锁，避免多个pod同时回填
+	mu := l.dmlCmp.NewMutex("BackfillLegacyIndex:" + index)
+
+	err = mu.Lock(ctx)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		_err := mu.Unlock()
+		if _err != nil {
+			l.logger.Errorln("[recSvc][backfillLegacyIndex]: dlm unlock failed:", _err)
+		}
+	}()
+
+	alias := rectypes.RecLogIndex(index)
+	legacy := legacyIndexName(alias)
+
+	// 其他pod已完成回填
+	indices, err := l.opsHttpAcc.GetAliasIndices(ctx, index)
+	if err != nil {
+		helpers.RecordErrLogWithPos(l.logger, err, "recSvc.backfillLegacyIndex.GetAliasIndices")
+		return
+	}
+
+	if _, ok := indices[legacy]; !ok {
+		return
+	}
+
+	l.logger.Infof("[recSvc.backfillLegacyIndex] backfill %s to partitions", legacy)
+
+	minDate, err := l.opsHttpAcc.GetMinDate(ctx, legacy, recconsts.CreatedFieldName)
+	if err != nil {
+		helpers.RecordErrLogWithPos(l.logger, err, "recSvc.backfillLegacyIndex.GetMinDate")
+		return
+	}
+
+	// 1. 复制到分区索引，最后一个分区不限结束时间
+	type partitionRange struct {
+		index      string
+		begin, end time.Time
+	}
+
+	var fresh, inAlias []partitionRange
+
+	if !minDate.IsZero() {
+		now := time.Now().UTC()
+
+		for t := minDate; ; {
+			begin, end := rectypes.GetPartitionRange(l.rolloverPeriod, t)
+			p := partitionRange{index: rectypes.GetPartitionIndex(alias, l.rolloverPeriod, t), begin: begin, end: end}
+
+			last := end.After(now)
+			if last {
+				p.end = time.Time{}
+			}
+
+			if _, ok := indices[p.index]; ok {
+				inAlias = append(inAlias, p)
+			} else {
+				err = l.opsHttpAcc.CreateIndex(ctx, p.index, mapping, recconsts.IndexSetting)
+				if err != nil {
+					helpers.RecordErrLogWithPos(l.logger, err, "recSvc.backfillLegacyIndex.CreateIndex")
+					return
+				}
+
+				fresh = append(fresh, p)
+			}
+
+			if last {
+				break
+			}
+
+			t = end
+		}
+	}
+
+	for _, p := range append(fresh, inAlias...) {
+		err = l.opsHttpAcc.Reindex(ctx, legacy, p.index, timeRangeQuery(p.begin, p.end))
+		if err != nil {
+			helpers.RecordErrLogWithPos(l.logger, err, "recSvc.backfillLegacyIndex.Reindex")
+			return
+		}
+	}
+
+	// 2. 切换，回填期间滚动任务可能已将分区加入别名
+	indices, err = l.opsHttpAcc.GetAliasIndices(ctx, index)
+	if err != nil {
+		helpers.RecordErrLogWithPos(l.logger, err, "recSvc.backfillLegacyIndex.GetAliasIndices")
+		return
+	}
+
+	actions := []map[string]interface{}{
+		{"remove_index": map[string]interface{}{"index": legacy}},
+	}
+
+	for _, p := range fresh {
+		if _, ok := indices[p.index]; !ok {
+			actions = append(actions, aliasAddAction(p.index, index, false))
+		}
+	}
+
+	err = l.opsHttpAcc.UpdateAliases(ctx, actions)
+	if err != nil {
+		helpers.RecordErrLogWithPos(l.logger, err, "recSvc.backfillLegacyIndex.UpdateAliases")
+		return
+	}
+
+	l.logger.Infof("[recSvc.backfillLegacyIndex] %s is backfilled to %d partitions", legacy, len(fresh)+len(inAlias))
+
+	return
+}
+
+// legacyIndexName 迁移时旧的未分区索引复制后的索引名，不会被识别为分区索引
+func legacyIndexName(alias rectypes.RecLogIndex) string {
+	return string(alias) + "-legacy"
+}
+
+func aliasAddAction(index, alias string, isWriteIndex bool) map[string]interface{} {
+	return map[string]interface{}{
+		"add": map[string]interface{}{
+			"index":          index,
+			"alias":          alias,
+			"is_write_index": isWriteIndex,
+		},
+	}
+}
+
+// timeRangeQuery @timestamp在[begin, end)内的查询条件，end为零值时不限结束时间
+func timeRangeQuery(begin, end time.Time) map[string]interface{} {
+	r := map[string]interface{}{
+		"gte": begin.UTC().Format(time.RFC3339),
+	}
+
+	if !end.IsZero() {
+		r["lt"] = end.UTC().Format(time.RFC3339)
+	}
+
+	return map[string]interface{}{
+		"range": map[string]interface{}{
+			recconsts.CreatedFieldName: r,
+		},
+	}
+}
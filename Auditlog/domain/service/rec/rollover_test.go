@@ -0,0 +1,183 @@
+package recsvc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	recconsts "AuditLog/common/constants/recenums"
+	"AuditLog/common/types/rectypes"
+	"AuditLog/infra/cmp/icmp/cmpmock"
+	"AuditLog/interfaces/drivenadapter/ihttpaccess/httpaccmock"
+	"AuditLog/test/mock_log"
+)
+
+func TestRolloverIndex(t *testing.T) {
+	Convey("RolloverIndex", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		logger := mock_log.NewMockLogger(ctrl)
+		opsHttpAcc := httpaccmock.NewMockOpsHttpAcc(ctrl)
+		dlmCmp := cmpmock.NewMockRedisDlmCmp(ctrl)
+		mutex := cmpmock.NewMockRedisDlmMutexCmp(ctrl)
+		svc := &recSvc{
+			logger:         logger,
+			opsHttpAcc:     opsHttpAcc,
+			dmlCmp:         dlmCmp,
+			rolloverPeriod: recconsts.RolloverPeriodMonth,
+		}
+
+		ctx := context.Background()
+		index := recconsts.IndexPrefix + "doc_operation"
+		mapping := `{"properties":{"@timestamp":{"type":"date"}}}`
+		alias := rectypes.RecLogIndex(index)
+		now := time.Now().UTC()
+		curBegin, curEnd := rectypes.GetPartitionRange(recconsts.RolloverPeriodMonth, now)
+		cur := rectypes.GetPartitionIndex(alias, recconsts.RolloverPeriodMonth, now)
+		next := rectypes.GetPartitionIndex(alias, recconsts.RolloverPeriodMonth, curEnd)
+		prev := rectypes.GetPartitionIndex(alias, recconsts.RolloverPeriodMonth, curBegin.AddDate(0, -1, 0))
+
+		logger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+		dlmCmp.EXPECT().NewMutex("RolloverIndex:" + index).Return(mutex)
+		mutex.EXPECT().Lock(ctx).Return(nil)
+		mutex.EXPECT().Unlock().Return(nil)
+		opsHttpAcc.EXPECT().CreateIndex(ctx, cur, mapping, recconsts.IndexSetting).Return(nil)
+		opsHttpAcc.EXPECT().CreateIndex(ctx, next, mapping, recconsts.IndexSetting).Return(nil)
+
+		Convey("当前分区已是写索引", func() {
+			opsHttpAcc.EXPECT().GetAliasIndices(ctx, index).Return(map[string]bool{prev: false, cur: true}, nil)
+
+			assert.NoError(t, svc.RolloverIndex(ctx, index, mapping))
+		})
+
+		Convey("切换写索引到当前分区", func() {
+			opsHttpAcc.EXPECT().GetAliasIndices(ctx, index).Return(map[string]bool{prev: true}, nil)
+			opsHttpAcc.EXPECT().UpdateAliases(ctx, []map[string]interface{}{
+				aliasAddAction(prev, index, false),
+				aliasAddAction(cur, index, true),
+			}).Return(nil)
+
+			assert.NoError(t, svc.RolloverIndex(ctx, index, mapping))
+		})
+
+		Convey("首次创建别名", func() {
+			opsHttpAcc.EXPECT().GetAliasIndices(ctx, index).Return(map[string]bool{}, nil)
+			opsHttpAcc.EXPECT().IndexExists(ctx, index).Return(false, nil)
+			opsHttpAcc.EXPECT().UpdateAliases(ctx, []map[string]interface{}{
+				aliasAddAction(cur, index, true),
+			}).Return(nil)
+
+			assert.NoError(t, svc.RolloverIndex(ctx, index, mapping))
+		})
+
+		Convey("迁移旧的未分区索引", func() {
+			// 回填在后台执行，由TestBackfillLegacyIndex覆盖
+			svc.backfilling.Store(index, true)
+
+			legacy := legacyIndexName(alias)
+
+			opsHttpAcc.EXPECT().GetAliasIndices(ctx, index).Return(map[string]bool{}, nil)
+			opsHttpAcc.EXPECT().IndexExists(ctx, index).Return(true, nil)
+			gomock.InOrder(
+				opsHttpAcc.EXPECT().AddWriteBlock(ctx, index).Return(nil),
+				opsHttpAcc.EXPECT().IndexExists(ctx, legacy).Return(false, nil),
+				opsHttpAcc.EXPECT().CloneIndex(ctx, index, legacy).Return(nil),
+				opsHttpAcc.EXPECT().UpdateAliases(ctx, []map[string]interface{}{
+					{"remove_index": map[string]interface{}{"index": index}},
+					aliasAddAction(cur, index, true),
+					aliasAddAction(legacy, index, false),
+				}).Return(nil),
+			)
+
+			assert.NoError(t, svc.RolloverIndex(ctx, index, mapping))
+		})
+
+		Convey("切换别名失败时不回填", func() {
+			legacy := legacyIndexName(alias)
+
+			opsHttpAcc.EXPECT().GetAliasIndices(ctx, index).Return(map[string]bool{}, nil)
+			opsHttpAcc.EXPECT().IndexExists(ctx, index).Return(true, nil)
+			opsHttpAcc.EXPECT().AddWriteBlock(ctx, index).Return(nil)
+			opsHttpAcc.EXPECT().IndexExists(ctx, legacy).Return(true, nil)
+			opsHttpAcc.EXPECT().UpdateAliases(ctx, gomock.Any()).Return(assert.AnError)
+			logger.EXPECT().Errorln(gomock.Any()).AnyTimes()
+
+			assert.Error(t, svc.RolloverIndex(ctx, index, mapping))
+
+			_, ok := svc.backfilling.Load(index)
+			assert.False(t, ok)
+		})
+	})
+}
+
+func TestBackfillLegacyIndex(t *testing.T) {
+	Convey("backfillLegacyIndex", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		logger := mock_log.NewMockLogger(ctrl)
+		opsHttpAcc := httpaccmock.NewMockOpsHttpAcc(ctrl)
+		dlmCmp := cmpmock.NewMockRedisDlmCmp(ctrl)
+		mutex := cmpmock.NewMockRedisDlmMutexCmp(ctrl)
+		svc := &recSvc{
+			logger:         logger,
+			opsHttpAcc:     opsHttpAcc,
+			dmlCmp:         dlmCmp,
+			rolloverPeriod: recconsts.RolloverPeriodMonth,
+		}
+
+		ctx := context.Background()
+		index := recconsts.IndexPrefix + "doc_operation"
+		mapping := `{"properties":{"@timestamp":{"type":"date"}}}`
+		alias := rectypes.RecLogIndex(index)
+		legacy := legacyIndexName(alias)
+		now := time.Now().UTC()
+		curBegin, _ := rectypes.GetPartitionRange(recconsts.RolloverPeriodMonth, now)
+		cur := rectypes.GetPartitionIndex(alias, recconsts.RolloverPeriodMonth, now)
+		prev := rectypes.GetPartitionIndex(alias, recconsts.RolloverPeriodMonth, curBegin.AddDate(0, -1, 0))
+		prevBegin, prevEnd, _ := rectypes.ParsePartitionIndex(alias, prev)
+
+		logger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+		dlmCmp.EXPECT().NewMutex("BackfillLegacyIndex:" + index).Return(mutex)
+		mutex.EXPECT().Lock(ctx).Return(nil)
+		mutex.EXPECT().Unlock().Return(nil)
+
+		Convey("已由其他pod回填", func() {
+			opsHttpAcc.EXPECT().GetAliasIndices(ctx, index).Return(map[string]bool{cur: true}, nil)
+
+			assert.NoError(t, svc.backfillLegacyIndex(ctx, index, mapping))
+		})
+
+		Convey("回填到分区后删除复制的旧索引", func() {
+			indices := map[string]bool{cur: true, legacy: false}
+
+			opsHttpAcc.EXPECT().GetAliasIndices(ctx, index).Return(indices, nil).Times(2)
+			opsHttpAcc.EXPECT().GetMinDate(ctx, legacy, recconsts.CreatedFieldName).Return(curBegin.AddDate(0, 0, -10), nil)
+			opsHttpAcc.EXPECT().CreateIndex(ctx, prev, mapping, recconsts.IndexSetting).Return(nil)
+
+			// 已在别名下的当前分区最后复制
+			gomock.InOrder(
+				opsHttpAcc.EXPECT().Reindex(ctx, legacy, prev, timeRangeQuery(prevBegin, prevEnd)).Return(nil),
+				opsHttpAcc.EXPECT().Reindex(ctx, legacy, cur, timeRangeQuery(prevEnd, time.Time{})).Return(nil),
+				opsHttpAcc.EXPECT().UpdateAliases(ctx, []map[string]interface{}{
+					{"remove_index": map[string]interface{}{"index": legacy}},
+					aliasAddAction(prev, index, false),
+				}).Return(nil),
+			)
+
+			assert.NoError(t, svc.backfillLegacyIndex(ctx, index, mapping))
+		})
+
+		Convey("复制失败时保留旧索引", func() {
+			opsHttpAcc.EXPECT().GetAliasIndices(ctx, index).Return(map[string]bool{cur: true, legacy: false}, nil)
+			opsHttpAcc.EXPECT().GetMinDate(ctx, legacy, recconsts.CreatedFieldName).Return(curBegin, nil)
+			opsHttpAcc.EXPECT().Reindex(ctx, legacy, cur, timeRangeQuery(curBegin, time.Time{})).Return(assert.AnError)
+			logger.EXPECT().Errorln(gomock.Any()).AnyTimes()
+
+			assert.Error(t, svc.backfillLegacyIndex(ctx, index, mapping))
+		})
+	})
+}
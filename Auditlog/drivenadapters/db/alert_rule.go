@@ -0,0 +1,194 @@
+package db
+
+import (
+	"database/sql"
+	"sync"
+
+	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
+
+	"AuditLog/drivenadapters"
+	"AuditLog/gocommon/api"
+	"AuditLog/infra"
+	"AuditLog/interfaces"
+	"AuditLog/models/alertmodels"
+)
+
+var (
+	arOnce sync.Once
+	ar     *alertRule
+)
+
+type alertRule struct {
+	db     *sqlx.DB
+	logger api.Logger
+}
+
+func NewAlertRule() interfaces.AlertRuleRepo {
+	arOnce.Do(func() {
+		ar = &alertRule{
+			db:     drivenadapters.DBPool,
+			logger: drivenadapters.Logger,
+		}
+	})
+	return ar
+}
+
+const alertRuleFields = `f_id, f_name, f_description, f_enabled, f_log_type, f_kind, f_conditions, f_group_by,
+	f_threshold, f_window_minutes, f_work_start, f_work_end, f_work_days, f_level, f_webhook, f_cooldown_minutes,
+	f_created_at, f_created_by, f_updated_at, f_updated_by`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAlertRule(row rowScanner) (rule *alertmodels.AlertRulePO, err error) {
+	rule = &alertmodels.AlertRulePO{}
+	err = row.Scan(
+		&rule.ID,
+		&rule.Name,
+		&rule.Description,
+		&rule.Enabled,
+		&rule.LogType,
+		&rule.Kind,
+		&rule.Conditions,
+		&rule.GroupBy,
+		&rule.Threshold,
+		&rule.WindowMinutes,
+		&rule.WorkStart,
+		&rule.WorkEnd,
+		&rule.WorkDays,
+		&rule.Level,
+		&rule.Webhook,
+		&rule.CooldownMinutes,
+		&rule.CreatedAt,
+		&rule.CreatedBy,
+		&rule.UpdatedAt,
+		&rule.UpdatedBy,
+	)
+	return
+}
+
+// GetRulesByCondition 根据条件查询告警规则
+func (a *alertRule) GetRulesByCondition(condition string, params []interface{}) (res []*alertmodels.AlertRulePO, err error) {
+	sqlStr := "SELECT " + alertRuleFields + " FROM " + infra.GetDBName() + ".t_log_alert_rule " + condition
+	rows, err := a.db.Query(sqlStr, params...)
+	if err != nil {
+		a.logger.Errorf("db query alert rule error: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	res = make([]*alertmodels.AlertRulePO, 0)
+	for rows.Next() {
+		var rule *alertmodels.AlertRulePO
+		rule, err = scanAlertRule(rows)
+		if err != nil {
+			a.logger.Errorf("db scan alert rule error: %v", err)
+			return
+		}
+		res = append(res, rule)
+	}
+
+	return
+}
+
+// CountRulesByCondition 根据条件统计告警规则数量
+func (a *alertRule) CountRulesByCondition(condition string, params []interface{}) (count int64, err error) {
+	sqlStr := "SELECT COUNT(f_id) FROM " + infra.GetDBName() + ".t_log_alert_rule " + condition
+	err = a.db.QueryRow(sqlStr, params...).Scan(&count)
+	if err != nil {
+		a.logger.Errorf("db count alert rule error: %v", err)
+		return
+	}
+	return
+}
+
+// GetRuleByID 根据ID获取告警规则, 不存在时返回nil
+func (a *alertRule) GetRuleByID(id int64) (res *alertmodels.AlertRulePO, err error) {
+	sqlStr := "SELECT " + alertRuleFields + " FROM " + infra.GetDBName() + ".t_log_alert_rule WHERE f_id = ?"
+	res, err = scanAlertRule(a.db.QueryRow(sqlStr, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		a.logger.Errorf("db query alert rule error: %v", err)
+		return
+	}
+	return
+}
+
+// NewRule 新增告警规则
+func (a *alertRule) NewRule(rule *alertmodels.AlertRulePO) (err error) {
+	sqlStr := "INSERT INTO " + infra.GetDBName() +
+		`.t_log_alert_rule (
+		f_id,
+		f_name,
+		f_description,
+		f_enabled,
+		f_log_type,
+		f_kind,
+		f_conditions,
+		f_group_by,
+		f_threshold,
+		f_window_minutes,
+		f_work_start,
+		f_work_end,
+		f_work_days,
+		f_level,
+		f_webhook,
+		f_cooldown_minutes,
+		f_created_at,
+		f_created_by
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = a.db.Exec(sqlStr, rule.ID, rule.Name, rule.Description, rule.Enabled, rule.LogType, rule.Kind,
+		rule.Conditions, rule.GroupBy, rule.Threshold, rule.WindowMinutes, rule.WorkStart, rule.WorkEnd,
+		rule.WorkDays, rule.Level, rule.Webhook, rule.CooldownMinutes, rule.CreatedAt, rule.CreatedBy)
+	if err != nil {
+		a.logger.Errorf("db insert alert rule error: %v", err)
+		return
+	}
+	return
+}
+
+// UpdateRule 更新告警规则
+func (a *alertRule) UpdateRule(rule *alertmodels.AlertRulePO) (err error) {
+	sqlStr := "UPDATE " + infra.GetDBName() +
+		`.t_log_alert_rule SET
+		f_name = ?,
+		f_description = ?,
+		f_enabled = ?,
+		f_log_type = ?,
+		f_kind = ?,
+		f_conditions = ?,
+		f_group_by = ?,
+		f_threshold = ?,
+		f_window_minutes = ?,
+		f_work_start = ?,
+		f_work_end = ?,
+		f_work_days = ?,
+		f_level = ?,
+		f_webhook = ?,
+		f_cooldown_minutes = ?,
+		f_updated_at = ?,
+		f_updated_by = ?
+		WHERE f_id = ?`
+	_, err = a.db.Exec(sqlStr, rule.Name, rule.Description, rule.Enabled, rule.LogType, rule.Kind,
+		rule.Conditions, rule.GroupBy, rule.Threshold, rule.WindowMinutes, rule.WorkStart, rule.WorkEnd,
+		rule.WorkDays, rule.Level, rule.Webhook, rule.CooldownMinutes, rule.UpdatedAt, rule.UpdatedBy, rule.ID)
+	if err != nil {
+		a.logger.Errorf("db update alert rule error: %v", err)
+		return
+	}
+	return
+}
+
+// DeleteRule 删除告警规则
+func (a *alertRule) DeleteRule(id int64) (err error) {
+	sqlStr := "DELETE FROM " + infra.GetDBName() + ".t_log_alert_rule WHERE f_id = ?"
+	_, err = a.db.Exec(sqlStr, id)
+	if err != nil {
+		a.logger.Errorf("db delete alert rule error: %v", err)
+		return
+	}
+	return
+}
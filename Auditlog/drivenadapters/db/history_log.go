@@ -1,6 +1,7 @@
 package db
 
 import (
+	"database/sql"
 	"sync"
 
 	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
@@ -193,3 +194,29 @@ func (repo *historyLog) GetHistoryLogByID(id string) (log *models.HistoryPO, err
 	log = &logInfo
 	return
 }
+
+// NewManifest 保存转存清单
+func (repo *historyLog) NewManifest(historyID string, manifest string) (err error) {
+	sqlStr := "INSERT INTO " + infra.GetDBName() + ".t_history_log_manifest" +
+		" (f_history_id, f_manifest) VALUES (?, ?)"
+	_, err = repo.db.Exec(sqlStr, historyID, manifest)
+	if err != nil {
+		repo.logger.Errorf("insert history log manifest error: %v", err)
+		return
+	}
+	return
+}
+
+// GetManifest 获取转存清单, 不存在时返回空字符串
+func (repo *historyLog) GetManifest(historyID string) (manifest string, err error) {
+	sqlStr := "SELECT f_manifest FROM " + infra.GetDBName() + ".t_history_log_manifest WHERE f_history_id = ?"
+	err = repo.db.QueryRow(sqlStr, historyID).Scan(&manifest)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		repo.logger.Errorf("db get history log manifest error: %v", err)
+		return
+	}
+	return
+}
@@ -0,0 +1,299 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+	"sync"
+
+	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
+
+	"AuditLog/drivenadapters"
+	"AuditLog/gocommon/api"
+	"AuditLog/infra"
+	"AuditLog/interfaces"
+	"AuditLog/models"
+	"AuditLog/models/wsmodels"
+)
+
+var (
+	wsOnce sync.Once
+	ws     *investigation
+)
+
+type investigation struct {
+	db     *sqlx.DB
+	logger api.Logger
+}
+
+// NewInvestigation 创建调查工作区数据库对象
+func NewInvestigation() interfaces.InvestigationRepo {
+	wsOnce.Do(func() {
+		ws = &investigation{
+			db:     drivenadapters.DBPool,
+			logger: drivenadapters.Logger,
+		}
+	})
+	return ws
+}
+
+const workspaceFields = `f_id, f_name, f_log_type, f_history_ids, f_status, f_record_count, f_err_msg,
+	f_expire_at, f_created_at, f_created_by`
+
+func scanWorkspace(row rowScanner) (w *wsmodels.WorkspacePO, err error) {
+	w = &wsmodels.WorkspacePO{}
+	err = row.Scan(
+		&w.ID,
+		&w.Name,
+		&w.LogType,
+		&w.HistoryIDs,
+		&w.Status,
+		&w.RecordCount,
+		&w.ErrMsg,
+		&w.ExpireAt,
+		&w.CreatedAt,
+		&w.CreatedBy,
+	)
+	return
+}
+
+// GetWorkspacesByCondition 根据条件查询调查工作区
+func (repo *investigation) GetWorkspacesByCondition(condition string, params []interface{}) (res []*wsmodels.WorkspacePO, err error) {
+	sqlStr := "SELECT " + workspaceFields + " FROM " + infra.GetDBName() + ".t_log_investigation_workspace " + condition
+	rows, err := repo.db.Query(sqlStr, params...)
+	if err != nil {
+		repo.logger.Errorf("db query investigation workspace error: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	res = make([]*wsmodels.WorkspacePO, 0)
+	for rows.Next() {
+		var w *wsmodels.WorkspacePO
+		w, err = scanWorkspace(rows)
+		if err != nil {
+			repo.logger.Errorf("db scan investigation workspace error: %v", err)
+			return
+		}
+		res = append(res, w)
+	}
+
+	return
+}
+
+// CountWorkspacesByCondition 根据条件统计调查工作区数量
+func (repo *investigation) CountWorkspacesByCondition(condition string, params []interface{}) (count int64, err error) {
+	sqlStr := "SELECT COUNT(f_id) FROM " + infra.GetDBName() + ".t_log_investigation_workspace " + condition
+	err = repo.db.QueryRow(sqlStr, params...).Scan(&count)
+	if err != nil {
+		repo.logger.Errorf("db count investigation workspace error: %v", err)
+		return
+	}
+	return
+}
+
+// GetWorkspaceByID 根据ID获取调查工作区, 不存在时返回nil
+func (repo *investigation) GetWorkspaceByID(id int64) (res *wsmodels.WorkspacePO, err error) {
+	sqlStr := "SELECT " + workspaceFields + " FROM " + infra.GetDBName() + ".t_log_investigation_workspace WHERE f_id = ?"
+	res, err = scanWorkspace(repo.db.QueryRow(sqlStr, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		repo.logger.Errorf("db query investigation workspace error: %v", err)
+		return
+	}
+	return
+}
+
+// NewWorkspace 新增调查工作区
+func (repo *investigation) NewWorkspace(w *wsmodels.WorkspacePO) (err error) {
+	sqlStr := "INSERT INTO " + infra.GetDBName() +
+		`.t_log_investigation_workspace (
+		f_id,
+		f_name,
+		f_log_type,
+		f_history_ids,
+		f_status,
+		f_record_count,
+		f_err_msg,
+		f_expire_at,
+		f_created_at,
+		f_created_by
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = repo.db.Exec(sqlStr, w.ID, w.Name, w.LogType, w.HistoryIDs, w.Status, w.RecordCount, w.ErrMsg,
+		w.ExpireAt, w.CreatedAt, w.CreatedBy)
+	if err != nil {
+		repo.logger.Errorf("db insert investigation workspace error: %v", err)
+		return
+	}
+	return
+}
+
+// UpdateWorkspaceStatus 更新调查工作区的恢复状态
+func (repo *investigation) UpdateWorkspaceStatus(id int64, status int, recordCount int64, errMsg string) (err error) {
+	sqlStr := "UPDATE " + infra.GetDBName() +
+		".t_log_investigation_workspace SET f_status = ?, f_record_count = ?, f_err_msg = ? WHERE f_id = ?"
+	_, err = repo.db.Exec(sqlStr, status, recordCount, errMsg, id)
+	if err != nil {
+		repo.logger.Errorf("db update investigation workspace error: %v", err)
+		return
+	}
+	return
+}
+
+// DeleteWorkspace 删除调查工作区及其恢复的日志
+func (repo *investigation) DeleteWorkspace(id int64) (err error) {
+	if err = repo.DeleteLogs(id); err != nil {
+		return
+	}
+
+	sqlStr := "DELETE FROM " + infra.GetDBName() + ".t_log_investigation_workspace WHERE f_id = ?"
+	_, err = repo.db.Exec(sqlStr, id)
+	if err != nil {
+		repo.logger.Errorf("db delete investigation workspace error: %v", err)
+		return
+	}
+	return
+}
+
+// NewLogs 批量写入调查工作区的日志
+func (repo *investigation) NewLogs(workspaceID int64, logs []*models.LogPO) (err error) {
+	if len(logs) == 0 {
+		return
+	}
+
+	placeholders := make([]string, 0, len(logs))
+	params := make([]interface{}, 0, len(logs)*17)
+	for _, log := range logs {
+		placeholders = append(placeholders, "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		params = append(params, workspaceID, log.LogID, log.UserID, log.UserName, log.ObjID, log.AdditionalInfo,
+			log.Level, log.OpType, log.Date, log.IP, log.MAC, log.Msg, log.ExMsg, log.UserAgent, log.UserPaths,
+			log.ObjName, log.ObjType)
+	}
+
+	sqlStr := "INSERT INTO " + infra.GetDBName() +
+		`.t_log_investigation (
+		f_workspace_id,
+		f_log_id,
+		f_user_id,
+		f_user_name,
+		f_obj_id,
+		f_additional_info,
+		f_level,
+		f_op_type,
+		f_date,
+		f_ip,
+		f_mac,
+		f_msg,
+		f_exmsg,
+		f_user_agent,
+		f_user_paths,
+		f_obj_name,
+		f_obj_type
+		) VALUES ` + strings.Join(placeholders, ", ")
+	_, err = repo.db.Exec(sqlStr, params...)
+	if err != nil {
+		repo.logger.Errorf("db insert investigation log error: %v", err)
+		return
+	}
+	return
+}
+
+// DeleteLogs 删除调查工作区恢复的日志
+func (repo *investigation) DeleteLogs(workspaceID int64) (err error) {
+	sqlStr := "DELETE FROM " + infra.GetDBName() + ".t_log_investigation WHERE f_workspace_id = ?"
+	_, err = repo.db.Exec(sqlStr, workspaceID)
+	if err != nil {
+		repo.logger.Errorf("db delete investigation log error: %v", err)
+		return
+	}
+	return
+}
+
+// workspaceLogTable 以子查询限定调查工作区, 使活跃日志的查询条件可直接拼接
+func workspaceLogTable() string {
+	return "(SELECT * FROM " + infra.GetDBName() + ".t_log_investigation WHERE f_workspace_id = ?) t "
+}
+
+// FindCountByCondition 根据条件查询调查工作区的日志数量
+func (repo *investigation) FindCountByCondition(workspaceID int64, condition string) (count int, err error) {
+	sqlStr := "SELECT COUNT(f_log_id) FROM " + workspaceLogTable() + condition
+	err = repo.db.QueryRow(sqlStr, workspaceID).Scan(&count)
+	if err != nil {
+		repo.logger.Errorf("db investigation log [FindCountByCondition] error: %v", err)
+		return
+	}
+	return
+}
+
+// FindByCondition 根据条件查询调查工作区的日志
+func (repo *investigation) FindByCondition(workspaceID int64, offset, limit int, condition string, ids []string) (logs []*models.LogPO, err error) {
+	sqlStr := `SELECT
+		f_log_id,
+		f_user_id,
+		f_user_name,
+		f_obj_id,
+		f_level,
+		f_op_type,
+		f_date,
+		f_ip,
+		f_mac,
+		f_msg,
+		f_exmsg,
+		f_user_agent,
+		f_additional_info,
+		f_user_paths,
+		f_obj_name,
+		f_obj_type
+		FROM ` + workspaceLogTable() + condition + ` LIMIT ? OFFSET ?
+	`
+
+	rows, err := repo.db.Query(sqlStr, workspaceID, limit, offset)
+	if err != nil {
+		repo.logger.Errorf("db query investigation log error: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	resMap := make(map[string]*models.LogPO)
+	for rows.Next() {
+		log := models.LogPO{}
+		err = rows.Scan(
+			&log.LogID,
+			&log.UserID,
+			&log.UserName,
+			&log.ObjID,
+			&log.Level,
+			&log.OpType,
+			&log.Date,
+			&log.IP,
+			&log.MAC,
+			&log.Msg,
+			&log.ExMsg,
+			&log.UserAgent,
+			&log.AdditionalInfo,
+			&log.UserPaths,
+			&log.ObjName,
+			&log.ObjType,
+		)
+		if err != nil {
+			repo.logger.Errorf("db scan investigation log error: %v", err)
+			return
+		}
+
+		if len(ids) > 0 {
+			resMap[log.LogID] = &log
+		} else {
+			logs = append(logs, &log)
+		}
+	}
+
+	// 指定日志ID时按ID顺序返回
+	for _, id := range ids {
+		if log, ok := resMap[id]; ok {
+			logs = append(logs, log)
+		}
+	}
+
+	return
+}
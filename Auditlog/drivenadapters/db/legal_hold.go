@@ -0,0 +1,163 @@
+package db
+
+import (
+	"database/sql"
+	"sync"
+
+	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
+
+	"AuditLog/drivenadapters"
+	"AuditLog/gocommon/api"
+	"AuditLog/infra"
+	"AuditLog/interfaces"
+	"AuditLog/models/lhmodels"
+)
+
+var (
+	lhOnce sync.Once
+	lh     *legalHold
+)
+
+type legalHold struct {
+	db     *sqlx.DB
+	logger api.Logger
+}
+
+func NewLegalHold() interfaces.LegalHoldRepo {
+	lhOnce.Do(func() {
+		lh = &legalHold{
+			db:     drivenadapters.DBPool,
+			logger: drivenadapters.Logger,
+		}
+	})
+	return lh
+}
+
+const legalHoldFields = `f_id, f_name, f_reason, f_log_type, f_begin_time, f_end_time, f_user_ids, f_departments,
+	f_created_at, f_created_by, f_updated_at, f_updated_by`
+
+func scanLegalHold(row rowScanner) (hold *lhmodels.LegalHoldPO, err error) {
+	hold = &lhmodels.LegalHoldPO{}
+	err = row.Scan(
+		&hold.ID,
+		&hold.Name,
+		&hold.Reason,
+		&hold.LogType,
+		&hold.BeginTime,
+		&hold.EndTime,
+		&hold.UserIDs,
+		&hold.Departments,
+		&hold.CreatedAt,
+		&hold.CreatedBy,
+		&hold.UpdatedAt,
+		&hold.UpdatedBy,
+	)
+	return
+}
+
+// GetHoldsByCondition 根据条件查询法律保留
+func (l *legalHold) GetHoldsByCondition(condition string, params []interface{}) (res []*lhmodels.LegalHoldPO, err error) {
+	sqlStr := "SELECT " + legalHoldFields + " FROM " + infra.GetDBName() + ".t_log_legal_hold " + condition
+	rows, err := l.db.Query(sqlStr, params...)
+	if err != nil {
+		l.logger.Errorf("db query legal hold error: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	res = make([]*lhmodels.LegalHoldPO, 0)
+	for rows.Next() {
+		var hold *lhmodels.LegalHoldPO
+		hold, err = scanLegalHold(rows)
+		if err != nil {
+			l.logger.Errorf("db scan legal hold error: %v", err)
+			return
+		}
+		res = append(res, hold)
+	}
+
+	return
+}
+
+// CountHoldsByCondition 根据条件统计法律保留数量
+func (l *legalHold) CountHoldsByCondition(condition string, params []interface{}) (count int64, err error) {
+	sqlStr := "SELECT COUNT(f_id) FROM " + infra.GetDBName() + ".t_log_legal_hold " + condition
+	err = l.db.QueryRow(sqlStr, params...).Scan(&count)
+	if err != nil {
+		l.logger.Errorf("db count legal hold error: %v", err)
+		return
+	}
+	return
+}
+
+// GetHoldByID 根据ID获取法律保留, 不存在时返回nil
+func (l *legalHold) GetHoldByID(id int64) (res *lhmodels.LegalHoldPO, err error) {
+	sqlStr := "SELECT " + legalHoldFields + " FROM " + infra.GetDBName() + ".t_log_legal_hold WHERE f_id = ?"
+	res, err = scanLegalHold(l.db.QueryRow(sqlStr, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		l.logger.Errorf("db query legal hold error: %v", err)
+		return
+	}
+	return
+}
+
+// NewHold 新增法律保留
+func (l *legalHold) NewHold(hold *lhmodels.LegalHoldPO) (err error) {
+	sqlStr := "INSERT INTO " + infra.GetDBName() +
+		`.t_log_legal_hold (
+		f_id,
+		f_name,
+		f_reason,
+		f_log_type,
+		f_begin_time,
+		f_end_time,
+		f_user_ids,
+		f_departments,
+		f_created_at,
+		f_created_by
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = l.db.Exec(sqlStr, hold.ID, hold.Name, hold.Reason, hold.LogType, hold.BeginTime, hold.EndTime,
+		hold.UserIDs, hold.Departments, hold.CreatedAt, hold.CreatedBy)
+	if err != nil {
+		l.logger.Errorf("db insert legal hold error: %v", err)
+		return
+	}
+	return
+}
+
+// UpdateHold 更新法律保留
+func (l *legalHold) UpdateHold(hold *lhmodels.LegalHoldPO) (err error) {
+	sqlStr := "UPDATE " + infra.GetDBName() +
+		`.t_log_legal_hold SET
+		f_name = ?,
+		f_reason = ?,
+		f_log_type = ?,
+		f_begin_time = ?,
+		f_end_time = ?,
+		f_user_ids = ?,
+		f_departments = ?,
+		f_updated_at = ?,
+		f_updated_by = ?
+		WHERE f_id = ?`
+	_, err = l.db.Exec(sqlStr, hold.Name, hold.Reason, hold.LogType, hold.BeginTime, hold.EndTime,
+		hold.UserIDs, hold.Departments, hold.UpdatedAt, hold.UpdatedBy, hold.ID)
+	if err != nil {
+		l.logger.Errorf("db update legal hold error: %v", err)
+		return
+	}
+	return
+}
+
+// DeleteHold 删除法律保留
+func (l *legalHold) DeleteHold(id int64) (err error) {
+	sqlStr := "DELETE FROM " + infra.GetDBName() + ".t_log_legal_hold WHERE f_id = ?"
+	_, err = l.db.Exec(sqlStr, id)
+	if err != nil {
+		l.logger.Errorf("db delete legal hold error: %v", err)
+		return
+	}
+	return
+}
@@ -0,0 +1,335 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
+
+	"AuditLog/common"
+	"AuditLog/common/utils/logchainutils"
+	"AuditLog/drivenadapters"
+	"AuditLog/gocommon/api"
+	"AuditLog/infra"
+	"AuditLog/interfaces"
+	"AuditLog/models"
+	"AuditLog/models/lcmodels"
+)
+
+var (
+	lcOnce sync.Once
+	lc     *logChain
+)
+
+// 日志类型对应的数据表
+var chainLogTables = map[string]string{
+	common.Login:      "t_log_login",
+	common.Management: "t_log_management",
+	common.Operation:  "t_log_operation",
+}
+
+type logChain struct {
+	db     *sqlx.DB
+	logger api.Logger
+}
+
+// NewLogChain 创建日志哈希链数据库对象
+func NewLogChain() interfaces.LogChainRepo {
+	lcOnce.Do(func() {
+		lc = &logChain{
+			db:     drivenadapters.DBPool,
+			logger: drivenadapters.Logger,
+		}
+	})
+	return lc
+}
+
+// newChainedLog 写入日志并链接到同类型日志的哈希链
+func newChainedLog(db *sqlx.DB, logType string, uid uint64, log *models.AuditLog) (err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	return insertChainedLog(tx, logType, uid, log)
+}
+
+// insertChainedLog 在事务中写入日志并链接到同类型日志的哈希链
+// 通过 select ... for update 锁定链头, 保证同类型日志串行入链
+func insertChainedLog(tx *sql.Tx, logType string, uid uint64, log *models.AuditLog) (err error) {
+	typeInt := common.LogTypeMap[logType]
+	var seq int64
+	var prevHash string
+	headSQL := "SELECT f_seq, f_hash FROM " + infra.GetDBName() + ".t_log_chain_head WHERE f_log_type = ? FOR UPDATE"
+	if err = tx.QueryRow(headSQL, typeInt).Scan(&seq, &prevHash); err != nil {
+		return fmt.Errorf("lock chain head error: %w", err)
+	}
+
+	rec := logchainutils.NewRecord(seq+1, strconv.FormatUint(uid, 10), log)
+	rec.PrevHash = prevHash
+	rec.Hash = logchainutils.ComputeHash(prevHash, rec)
+
+	sqlStr := "INSERT INTO " + infra.GetDBName() + "." + chainLogTables[logType] +
+		" (f_log_id, f_user_id,f_user_name,f_user_type,f_obj_id,f_level,f_op_type,f_date,f_ip,f_mac,f_msg,f_exmsg,f_user_agent,f_additional_info,f_user_paths,f_obj_name,f_obj_type,f_chain_seq,f_prev_hash,f_hash) " +
+		" VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	_, err = tx.Exec(sqlStr, uid, log.UserID, log.UserName, log.UserType, log.ObjID, log.Level, log.OpType, log.Date, log.IP, log.Mac, log.Msg, log.Exmsg, log.UserAgent, log.AdditionalInfo, log.DeptPaths, log.ObjName, log.ObjType, rec.Seq, rec.PrevHash, rec.Hash)
+	if err != nil {
+		return err
+	}
+
+	// 链头签名后, 删除最新的记录并回退链头会被发现
+	head := &lcmodels.LogChainHeadPO{LogType: typeInt, Seq: rec.Seq, Hash: rec.Hash}
+	if head.Signature, err = logchainutils.SignHead(common.SvcConfig.LogChainSecret, head); err != nil {
+		return err
+	}
+	updateSQL := "UPDATE " + infra.GetDBName() + ".t_log_chain_head SET f_seq = ?, f_hash = ?, f_signature = ? WHERE f_log_type = ?"
+	if _, err = tx.Exec(updateSQL, head.Seq, head.Hash, head.Signature, typeInt); err != nil {
+		return err
+	}
+
+	// 定期生成签名检查点
+	if rec.Seq%logchainutils.CheckpointInterval(common.SvcConfig.LogChainCheckpoint) != 0 {
+		return nil
+	}
+
+	cp := &lcmodels.LogChainCheckpointPO{
+		LogType:   typeInt,
+		Seq:       rec.Seq,
+		LogID:     rec.LogID,
+		Hash:      rec.Hash,
+		CreatedAt: time.Now().UnixMicro(),
+	}
+	if cp.Signature, err = logchainutils.SignCheckpoint(common.SvcConfig.LogChainSecret, cp); err != nil {
+		return err
+	}
+	cpSQL := "INSERT INTO " + infra.GetDBName() + ".t_log_chain_checkpoint" +
+		" (f_log_type, f_seq, f_log_id, f_hash, f_signature, f_created_at) VALUES (?, ?, ?, ?, ?, ?)"
+	_, err = tx.Exec(cpSQL, cp.LogType, cp.Seq, cp.LogID, cp.Hash, cp.Signature, cp.CreatedAt)
+
+	return err
+}
+
+// GetHead 获取日志类型的链头
+func (repo *logChain) GetHead(logType string) (head *lcmodels.LogChainHeadPO, err error) {
+	head = &lcmodels.LogChainHeadPO{}
+	sqlStr := "SELECT f_log_type, f_seq, f_hash, f_signature FROM " + infra.GetDBName() + ".t_log_chain_head WHERE f_log_type = ?"
+	err = repo.db.QueryRow(sqlStr, common.LogTypeMap[logType]).Scan(&head.LogType, &head.Seq, &head.Hash, &head.Signature)
+	if err != nil {
+		repo.logger.Errorf("db log chain [GetHead] error: %v", err)
+		return nil, err
+	}
+	return
+}
+
+// GetRecords 按链上序号升序获取 [beginSeq, endSeq] 范围内的记录
+func (repo *logChain) GetRecords(logType string, beginSeq, endSeq int64, limit int) (recs []*lcmodels.LogChainRecordPO, err error) {
+	sqlStr := `SELECT
+		f_chain_seq,
+		f_log_id,
+		f_user_id,
+		f_user_name,
+		f_user_type,
+		f_obj_id,
+		f_level,
+		f_op_type,
+		f_date,
+		f_ip,
+		f_mac,
+		f_msg,
+		f_exmsg,
+		f_user_agent,
+		f_additional_info,
+		f_user_paths,
+		f_obj_name,
+		f_obj_type,
+		f_prev_hash,
+		f_hash
+		FROM ` + infra.GetDBName() + "." + chainLogTables[logType] +
+		` WHERE f_chain_seq >= ? AND f_chain_seq <= ? ORDER BY f_chain_seq ASC LIMIT ?`
+
+	rows, err := repo.db.Query(sqlStr, beginSeq, endSeq, limit)
+	if err != nil {
+		repo.logger.Errorf("db log chain [GetRecords] error: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rec *lcmodels.LogChainRecordPO
+		if rec, err = scanChainRecord(rows); err != nil {
+			repo.logger.Errorf("db log chain [GetRecords] scan error: %v", err)
+			return
+		}
+		recs = append(recs, rec)
+	}
+
+	return recs, rows.Err()
+}
+
+// GetRecordByLogID 根据日志id获取链上记录, 不存在时返回nil
+func (repo *logChain) GetRecordByLogID(logType string, logID string) (rec *lcmodels.LogChainRecordPO, err error) {
+	sqlStr := `SELECT
+		f_chain_seq,
+		f_log_id,
+		f_user_id,
+		f_user_name,
+		f_user_type,
+		f_obj_id,
+		f_level,
+		f_op_type,
+		f_date,
+		f_ip,
+		f_mac,
+		f_msg,
+		f_exmsg,
+		f_user_agent,
+		f_additional_info,
+		f_user_paths,
+		f_obj_name,
+		f_obj_type,
+		f_prev_hash,
+		f_hash
+		FROM ` + infra.GetDBName() + "." + chainLogTables[logType] + ` WHERE f_log_id = ?`
+
+	rows, err := repo.db.Query(sqlStr, logID)
+	if err != nil {
+		repo.logger.Errorf("db log chain [GetRecordByLogID] error: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if rec, err = scanChainRecord(rows); err != nil {
+			repo.logger.Errorf("db log chain [GetRecordByLogID] scan error: %v", err)
+			return nil, err
+		}
+	}
+
+	return rec, rows.Err()
+}
+
+// GetCheckpoints 获取 [beginSeq, endSeq] 范围内的检查点
+func (repo *logChain) GetCheckpoints(logType string, beginSeq, endSeq int64) (cps []*lcmodels.LogChainCheckpointPO, err error) {
+	sqlStr := "SELECT f_log_type, f_seq, f_log_id, f_hash, f_signature, f_created_at FROM " + infra.GetDBName() +
+		".t_log_chain_checkpoint WHERE f_log_type = ? AND f_seq >= ? AND f_seq <= ? ORDER BY f_seq ASC"
+
+	rows, err := repo.db.Query(sqlStr, common.LogTypeMap[logType], beginSeq, endSeq)
+	if err != nil {
+		repo.logger.Errorf("db log chain [GetCheckpoints] error: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		cp := &lcmodels.LogChainCheckpointPO{}
+		if err = rows.Scan(&cp.LogType, &cp.Seq, &cp.LogID, &cp.Hash, &cp.Signature, &cp.CreatedAt); err != nil {
+			repo.logger.Errorf("db log chain [GetCheckpoints] scan error: %v", err)
+			return
+		}
+		cps = append(cps, cp)
+	}
+
+	return cps, rows.Err()
+}
+
+// CountUnchained 获取未入链(序号为0)的记录数量及其中最早的日志id
+func (repo *logChain) CountUnchained(logType string) (count int64, firstLogID string, err error) {
+	var logID sql.NullInt64
+	sqlStr := "SELECT COUNT(f_log_id), MIN(f_log_id) FROM " + infra.GetDBName() + "." + chainLogTables[logType] + " WHERE f_chain_seq = 0"
+	if err = repo.db.QueryRow(sqlStr).Scan(&count, &logID); err != nil {
+		repo.logger.Errorf("db log chain [CountUnchained] error: %v", err)
+		return 0, "", err
+	}
+	if logID.Valid {
+		firstLogID = strconv.FormatInt(logID.Int64, 10)
+	}
+	return
+}
+
+// NewAnchor 保存转储锚点
+func (repo *logChain) NewAnchor(anchor *lcmodels.ChainAnchor) (err error) {
+	sqlStr := "INSERT INTO " + infra.GetDBName() + ".t_log_chain_anchor" +
+		" (f_log_type, f_first_seq, f_first_log_id, f_first_prev_hash, f_last_seq, f_last_log_id, f_last_hash, f_count, f_signature, f_created_at)" +
+		" VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	_, err = repo.db.Exec(sqlStr, common.LogTypeMap[anchor.LogType],
+		anchor.FirstSeq, anchor.FirstLogID, anchor.FirstPrevHash,
+		anchor.LastSeq, anchor.LastLogID, anchor.LastHash,
+		anchor.Count, anchor.Signature, time.Now().UnixMicro())
+	if err != nil {
+		repo.logger.Errorf("db log chain [NewAnchor] error: %v", err)
+	}
+	return
+}
+
+// GetAnchors 获取与 [beginSeq, endSeq] 范围有交集的转储锚点
+func (repo *logChain) GetAnchors(logType string, beginSeq, endSeq int64) (anchors []*lcmodels.ChainAnchor, err error) {
+	sqlStr := "SELECT f_first_seq, f_first_log_id, f_first_prev_hash, f_last_seq, f_last_log_id, f_last_hash, f_count, f_signature FROM " +
+		infra.GetDBName() + ".t_log_chain_anchor WHERE f_log_type = ? AND f_last_seq >= ? AND f_first_seq <= ? ORDER BY f_first_seq ASC"
+
+	rows, err := repo.db.Query(sqlStr, common.LogTypeMap[logType], beginSeq, endSeq)
+	if err != nil {
+		repo.logger.Errorf("db log chain [GetAnchors] error: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		anchor := &lcmodels.ChainAnchor{LogType: logType}
+		err = rows.Scan(
+			&anchor.FirstSeq, &anchor.FirstLogID, &anchor.FirstPrevHash,
+			&anchor.LastSeq, &anchor.LastLogID, &anchor.LastHash,
+			&anchor.Count, &anchor.Signature,
+		)
+		if err != nil {
+			repo.logger.Errorf("db log chain [GetAnchors] scan error: %v", err)
+			return
+		}
+		anchors = append(anchors, anchor)
+	}
+
+	return anchors, rows.Err()
+}
+
+func scanChainRecord(rows *sql.Rows) (rec *lcmodels.LogChainRecordPO, err error) {
+	rec = &lcmodels.LogChainRecordPO{}
+	var userPaths sql.NullString
+	err = rows.Scan(
+		&rec.Seq,
+		&rec.LogID,
+		&rec.UserID,
+		&rec.UserName,
+		&rec.UserType,
+		&rec.ObjID,
+		&rec.Level,
+		&rec.OpType,
+		&rec.Date,
+		&rec.IP,
+		&rec.MAC,
+		&rec.Msg,
+		&rec.ExMsg,
+		&rec.UserAgent,
+		&rec.AdditionalInfo,
+		&userPaths,
+		&rec.ObjName,
+		&rec.ObjType,
+		&rec.PrevHash,
+		&rec.Hash,
+	)
+	if err != nil {
+		return nil, err
+	}
+	rec.UserPaths = userPaths.String
+
+	return rec, nil
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
+
+	"AuditLog/common/constants/statconsts"
+	"AuditLog/infra"
+	"AuditLog/models"
+)
+
+// aggregateLogs 在数据库中按分组维度分组、按时间分桶统计日志数量
+// 分桶以 offset 对齐到本地时区, 按时间升序、数量倒序返回; 不分桶时按数量倒序返回
+func aggregateLogs(db *sqlx.DB, table string, condition string, args []any, group string, bucket, offset int64, limit int) (stats []*models.LogStatPO, err error) {
+	keyExpr, nameExpr, bucketExpr := "''", "''", "0"
+	groups := make([]string, 0, 2)
+	if group != "" {
+		var ok bool
+		if keyExpr, ok = statconsts.GroupExprMap[group]; !ok {
+			return nil, fmt.Errorf("invalid group: %s", group)
+		}
+		groups = append(groups, keyExpr)
+		if group == statconsts.GroupUser {
+			nameExpr = "MAX(f_user_name)"
+		}
+	}
+	if bucket > 0 {
+		bucketExpr = fmt.Sprintf("(f_date - MOD(f_date + %d, %d))", offset, bucket)
+		groups = append(groups, bucketExpr)
+	}
+
+	sqlStr := fmt.Sprintf("SELECT %s, %s, %s, COUNT(f_log_id) FROM %s.%s %s",
+		keyExpr, nameExpr, bucketExpr, infra.GetDBName(), table, condition)
+	if len(groups) > 0 {
+		sqlStr += " GROUP BY " + strings.Join(groups, ", ")
+		if bucket > 0 {
+			sqlStr += " ORDER BY " + bucketExpr + " ASC, COUNT(f_log_id) DESC"
+		} else {
+			sqlStr += " ORDER BY COUNT(f_log_id) DESC"
+		}
+	}
+	sqlStr += " LIMIT ?"
+
+	rows, err := db.Query(sqlStr, append(append([]any{}, args...), limit)...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		stat := &models.LogStatPO{}
+		if err = rows.Scan(&stat.Key, &stat.Name, &stat.Bucket, &stat.Count); err != nil {
+			return
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats, rows.Err()
+}
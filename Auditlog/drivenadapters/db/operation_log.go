@@ -8,6 +8,7 @@ import (
 
 	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
 
+	"AuditLog/common"
 	"AuditLog/drivenadapters"
 	"AuditLog/gocommon/api"
 	"AuditLog/infra"
@@ -43,11 +44,25 @@ func (repo *operationLog) New(log *models.AuditLog) (logID string, err error) {
 		repo.logger.Errorf("new sonyflake id error: %v", err)
 		return
 	}
-	sqlStr := "INSERT INTO " + infra.GetDBName() + ".t_log_operation" +
-		" (f_log_id, f_user_id,f_user_name,f_user_type,f_obj_id,f_level,f_op_type,f_date,f_ip,f_mac,f_msg,f_exmsg,f_user_agent,f_additional_info,f_user_paths,f_obj_name,f_obj_type) " +
-		" VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
-		// TODO 需要考虑事务一致性的问题
-	_, err = repo.db.Exec(sqlStr, uid, log.UserID, log.UserName, log.UserType, log.ObjID, log.Level, log.OpType, log.Date, log.IP, log.Mac, log.Msg, log.Exmsg, log.UserAgent, log.AdditionalInfo, log.DeptPaths, log.ObjName, log.ObjType)
+	// 写入日志并链接到哈希链
+	err = newChainedLog(repo.db, common.Operation, uid, log)
+	if err != nil {
+		repo.logger.Errorf("insert log error: %v, business key: %v", err, log.OutBizID)
+		return
+	}
+
+	return strconv.FormatUint(uid, 10), nil
+}
+
+// NewTx 在调用方事务中写入日志, 由调用方提交或回滚
+func (repo *operationLog) NewTx(log *models.AuditLog, tx *sql.Tx) (logID string, err error) {
+	uid, err := infra.GetUniqueID()
+	if err != nil {
+		repo.logger.Errorf("new sonyflake id error: %v", err)
+		return
+	}
+	// 写入日志并链接到哈希链
+	err = insertChainedLog(tx, common.Operation, uid, log)
 	if err != nil {
 		repo.logger.Errorf("insert log error: %v, business key: %v", err, log.OutBizID)
 		return
@@ -180,15 +195,15 @@ func (repo *operationLog) GetFirstLogTime() (timeMicro int64, err error) {
 	return
 }
 
-// ClearOutdatedLog 清除过期日志
-func (repo *operationLog) ClearOutdatedLog(logID, date, batchSize, sleepTime int64) (err error) {
+// ClearOutdatedLog 清除日志ID在 [beginLogID, endLogID] 范围内的过期日志
+func (repo *operationLog) ClearOutdatedLog(beginLogID, endLogID, date, batchSize, sleepTime int64) (err error) {
 	if batchSize <= 0 {
 		batchSize = 50000
 	}
 
-	sqlStr := "DELETE FROM " + infra.GetDBName() + ".t_log_operation WHERE f_log_id <= ? AND f_date < ? LIMIT ?"
+	sqlStr := "DELETE FROM " + infra.GetDBName() + ".t_log_operation WHERE f_log_id >= ? AND f_log_id <= ? AND f_date < ? LIMIT ?"
 	for {
-		stat, err := repo.db.Exec(sqlStr, logID, date, batchSize)
+		stat, err := repo.db.Exec(sqlStr, beginLogID, endLogID, date, batchSize)
 		if err != nil {
 			repo.logger.Errorf("db operation log [ClearOutdatedLog] error: %v", err)
 			return err
@@ -222,3 +237,13 @@ func (repo *operationLog) GetLogCount() (count int64, err error) {
 	}
 	return
 }
+
+// AggregateByCondition 根据条件统计操作审计日志数量
+func (repo *operationLog) AggregateByCondition(condition string, args []any, group string, bucket, offset int64, limit int) (stats []*models.LogStatPO, err error) {
+	stats, err = aggregateLogs(repo.db, "t_log_operation", condition, args, group, bucket, offset, limit)
+	if err != nil {
+		repo.logger.Errorf("db operation log [AggregateByCondition] error: %v", err)
+		return
+	}
+	return
+}
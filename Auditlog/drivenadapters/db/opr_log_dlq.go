@@ -0,0 +1,160 @@
+package db
+
+import (
+	"database/sql"
+	"sync"
+
+	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
+
+	"AuditLog/drivenadapters"
+	"AuditLog/gocommon/api"
+	"AuditLog/infra"
+	"AuditLog/interfaces"
+	"AuditLog/models/dlqmodels"
+)
+
+var (
+	dlqOnce sync.Once
+	dlq     *oprLogDLQ
+)
+
+type oprLogDLQ struct {
+	db     *sqlx.DB
+	logger api.Logger
+}
+
+func NewOprLogDLQ() interfaces.OprLogDLQRepo {
+	dlqOnce.Do(func() {
+		dlq = &oprLogDLQ{
+			db:     drivenadapters.DBPool,
+			logger: drivenadapters.Logger,
+		}
+	})
+	return dlq
+}
+
+const deadLetterFields = `f_id, f_biz_type, f_message, f_reason, f_invalid_fields, f_err_msg,
+	f_status, f_attempts, f_created_at, f_updated_at, f_replayed_at`
+
+func scanDeadLetter(row rowScanner) (letter *dlqmodels.DeadLetterPO, err error) {
+	letter = &dlqmodels.DeadLetterPO{}
+	err = row.Scan(
+		&letter.ID,
+		&letter.BizType,
+		&letter.Message,
+		&letter.Reason,
+		&letter.InvalidFields,
+		&letter.ErrMsg,
+		&letter.Status,
+		&letter.Attempts,
+		&letter.CreatedAt,
+		&letter.UpdatedAt,
+		&letter.ReplayedAt,
+	)
+	return
+}
+
+// GetByCondition 根据条件查询死信
+func (d *oprLogDLQ) GetByCondition(condition string, params []interface{}) (res []*dlqmodels.DeadLetterPO, err error) {
+	sqlStr := "SELECT " + deadLetterFields + " FROM " + infra.GetDBName() + ".t_opr_log_dead_letter " + condition
+	rows, err := d.db.Query(sqlStr, params...)
+	if err != nil {
+		d.logger.Errorf("db query dead letter error: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	res = make([]*dlqmodels.DeadLetterPO, 0)
+	for rows.Next() {
+		var letter *dlqmodels.DeadLetterPO
+		letter, err = scanDeadLetter(rows)
+		if err != nil {
+			d.logger.Errorf("db scan dead letter error: %v", err)
+			return
+		}
+		res = append(res, letter)
+	}
+
+	return
+}
+
+// CountByCondition 根据条件统计死信数量
+func (d *oprLogDLQ) CountByCondition(condition string, params []interface{}) (count int64, err error) {
+	sqlStr := "SELECT COUNT(f_id) FROM " + infra.GetDBName() + ".t_opr_log_dead_letter " + condition
+	err = d.db.QueryRow(sqlStr, params...).Scan(&count)
+	if err != nil {
+		d.logger.Errorf("db count dead letter error: %v", err)
+		return
+	}
+	return
+}
+
+// GetByID 根据ID获取死信, 不存在时返回nil
+func (d *oprLogDLQ) GetByID(id int64) (res *dlqmodels.DeadLetterPO, err error) {
+	sqlStr := "SELECT " + deadLetterFields + " FROM " + infra.GetDBName() + ".t_opr_log_dead_letter WHERE f_id = ?"
+	res, err = scanDeadLetter(d.db.QueryRow(sqlStr, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		d.logger.Errorf("db query dead letter error: %v", err)
+		return
+	}
+	return
+}
+
+// New 新增死信
+func (d *oprLogDLQ) New(letter *dlqmodels.DeadLetterPO) (err error) {
+	sqlStr := "INSERT INTO " + infra.GetDBName() +
+		`.t_opr_log_dead_letter (
+		f_id,
+		f_biz_type,
+		f_message,
+		f_reason,
+		f_invalid_fields,
+		f_err_msg,
+		f_status,
+		f_attempts,
+		f_created_at,
+		f_updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = d.db.Exec(sqlStr, letter.ID, letter.BizType, letter.Message, letter.Reason, letter.InvalidFields,
+		letter.ErrMsg, letter.Status, letter.Attempts, letter.CreatedAt, letter.UpdatedAt)
+	if err != nil {
+		d.logger.Errorf("db insert dead letter error: %v", err)
+		return
+	}
+	return
+}
+
+// UpdateReplayResult 更新重放结果
+func (d *oprLogDLQ) UpdateReplayResult(letter *dlqmodels.DeadLetterPO) (err error) {
+	sqlStr := "UPDATE " + infra.GetDBName() +
+		`.t_opr_log_dead_letter SET
+		f_reason = ?,
+		f_invalid_fields = ?,
+		f_err_msg = ?,
+		f_status = ?,
+		f_attempts = ?,
+		f_updated_at = ?,
+		f_replayed_at = ?
+		WHERE f_id = ?`
+	_, err = d.db.Exec(sqlStr, letter.Reason, letter.InvalidFields, letter.ErrMsg, letter.Status,
+		letter.Attempts, letter.UpdatedAt, letter.ReplayedAt, letter.ID)
+	if err != nil {
+		d.logger.Errorf("db update dead letter error: %v", err)
+		return
+	}
+	return
+}
+
+// DeleteByCondition 根据条件删除死信, 返回删除的数量
+func (d *oprLogDLQ) DeleteByCondition(condition string, params []interface{}) (count int64, err error) {
+	sqlStr := "DELETE FROM " + infra.GetDBName() + ".t_opr_log_dead_letter " + condition
+	result, err := d.db.Exec(sqlStr, params...)
+	if err != nil {
+		d.logger.Errorf("db delete dead letter error: %v", err)
+		return
+	}
+	return result.RowsAffected()
+}
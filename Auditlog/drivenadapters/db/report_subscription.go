@@ -0,0 +1,318 @@
+package db
+
+import (
+	"database/sql"
+	"sync"
+
+	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
+
+	"AuditLog/drivenadapters"
+	"AuditLog/gocommon/api"
+	"AuditLog/infra"
+	"AuditLog/interfaces"
+	"AuditLog/models/rptmodels"
+)
+
+var (
+	rptOnce sync.Once
+	rpt     *reportSubscription
+)
+
+type reportSubscription struct {
+	db     *sqlx.DB
+	logger api.Logger
+}
+
+// NewReportSubscription 创建报表订阅数据库对象
+func NewReportSubscription() interfaces.ReportSubscriptionRepo {
+	rptOnce.Do(func() {
+		rpt = &reportSubscription{
+			db:     drivenadapters.DBPool,
+			logger: drivenadapters.Logger,
+		}
+	})
+	return rpt
+}
+
+const subscriptionFields = `f_id, f_name, f_log_type, f_condition, f_columns, f_format, f_schedule, f_schedule_day,
+	f_schedule_time, f_subscribers, f_enabled, f_next_run_at, f_last_run_at, f_created_at, f_created_by,
+	f_updated_at, f_updated_by`
+
+const runFields = `f_id, f_subscription_id, f_status, f_begin_time, f_end_time, f_record_count, f_oss_id,
+	f_object_name, f_file_name, f_err_msg, f_started_at, f_finished_at, f_file_expired`
+
+func scanSubscription(row rowScanner) (s *rptmodels.SubscriptionPO, err error) {
+	s = &rptmodels.SubscriptionPO{}
+	err = row.Scan(
+		&s.ID,
+		&s.Name,
+		&s.LogType,
+		&s.Condition,
+		&s.Columns,
+		&s.Format,
+		&s.Schedule,
+		&s.ScheduleDay,
+		&s.ScheduleTime,
+		&s.Subscribers,
+		&s.Enabled,
+		&s.NextRunAt,
+		&s.LastRunAt,
+		&s.CreatedAt,
+		&s.CreatedBy,
+		&s.UpdatedAt,
+		&s.UpdatedBy,
+	)
+	return
+}
+
+func scanRun(row rowScanner) (run *rptmodels.RunPO, err error) {
+	run = &rptmodels.RunPO{}
+	err = row.Scan(
+		&run.ID,
+		&run.SubscriptionID,
+		&run.Status,
+		&run.BeginTime,
+		&run.EndTime,
+		&run.RecordCount,
+		&run.OssID,
+		&run.ObjectName,
+		&run.FileName,
+		&run.ErrMsg,
+		&run.StartedAt,
+		&run.FinishedAt,
+		&run.FileExpired,
+	)
+	return
+}
+
+// GetSubscriptionsByCondition 根据条件查询报表订阅
+func (repo *reportSubscription) GetSubscriptionsByCondition(condition string, params []interface{}) (res []*rptmodels.SubscriptionPO, err error) {
+	sqlStr := "SELECT " + subscriptionFields + " FROM " + infra.GetDBName() + ".t_log_report_subscription " + condition
+	rows, err := repo.db.Query(sqlStr, params...)
+	if err != nil {
+		repo.logger.Errorf("db query report subscription error: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	res = make([]*rptmodels.SubscriptionPO, 0)
+	for rows.Next() {
+		var s *rptmodels.SubscriptionPO
+		s, err = scanSubscription(rows)
+		if err != nil {
+			repo.logger.Errorf("db scan report subscription error: %v", err)
+			return
+		}
+		res = append(res, s)
+	}
+
+	return
+}
+
+// CountSubscriptionsByCondition 根据条件统计报表订阅数量
+func (repo *reportSubscription) CountSubscriptionsByCondition(condition string, params []interface{}) (count int64, err error) {
+	sqlStr := "SELECT COUNT(f_id) FROM " + infra.GetDBName() + ".t_log_report_subscription " + condition
+	err = repo.db.QueryRow(sqlStr, params...).Scan(&count)
+	if err != nil {
+		repo.logger.Errorf("db count report subscription error: %v", err)
+		return
+	}
+	return
+}
+
+// GetSubscriptionByID 根据ID获取报表订阅, 不存在时返回nil
+func (repo *reportSubscription) GetSubscriptionByID(id int64) (res *rptmodels.SubscriptionPO, err error) {
+	sqlStr := "SELECT " + subscriptionFields + " FROM " + infra.GetDBName() + ".t_log_report_subscription WHERE f_id = ?"
+	res, err = scanSubscription(repo.db.QueryRow(sqlStr, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		repo.logger.Errorf("db query report subscription error: %v", err)
+		return
+	}
+	return
+}
+
+// NewSubscription 新增报表订阅
+func (repo *reportSubscription) NewSubscription(s *rptmodels.SubscriptionPO) (err error) {
+	sqlStr := "INSERT INTO " + infra.GetDBName() +
+		`.t_log_report_subscription (
+		f_id,
+		f_name,
+		f_log_type,
+		f_condition,
+		f_columns,
+		f_format,
+		f_schedule,
+		f_schedule_day,
+		f_schedule_time,
+		f_subscribers,
+		f_enabled,
+		f_next_run_at,
+		f_created_at,
+		f_created_by
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = repo.db.Exec(sqlStr, s.ID, s.Name, s.LogType, s.Condition, s.Columns, s.Format, s.Schedule,
+		s.ScheduleDay, s.ScheduleTime, s.Subscribers, s.Enabled, s.NextRunAt, s.CreatedAt, s.CreatedBy)
+	if err != nil {
+		repo.logger.Errorf("db insert report subscription error: %v", err)
+		return
+	}
+	return
+}
+
+// UpdateSubscription 更新报表订阅
+func (repo *reportSubscription) UpdateSubscription(s *rptmodels.SubscriptionPO) (err error) {
+	sqlStr := "UPDATE " + infra.GetDBName() +
+		`.t_log_report_subscription SET
+		f_name = ?,
+		f_log_type = ?,
+		f_condition = ?,
+		f_columns = ?,
+		f_format = ?,
+		f_schedule = ?,
+		f_schedule_day = ?,
+		f_schedule_time = ?,
+		f_subscribers = ?,
+		f_enabled = ?,
+		f_next_run_at = ?,
+		f_updated_at = ?,
+		f_updated_by = ?
+		WHERE f_id = ?`
+	_, err = repo.db.Exec(sqlStr, s.Name, s.LogType, s.Condition, s.Columns, s.Format, s.Schedule, s.ScheduleDay,
+		s.ScheduleTime, s.Subscribers, s.Enabled, s.NextRunAt, s.UpdatedAt, s.UpdatedBy, s.ID)
+	if err != nil {
+		repo.logger.Errorf("db update report subscription error: %v", err)
+		return
+	}
+	return
+}
+
+// UpdateSchedule 更新报表订阅的下次执行时间和上次执行时间
+func (repo *reportSubscription) UpdateSchedule(id int64, nextRunAt, lastRunAt int64) (err error) {
+	sqlStr := "UPDATE " + infra.GetDBName() + ".t_log_report_subscription SET f_next_run_at = ?, f_last_run_at = ? WHERE f_id = ?"
+	_, err = repo.db.Exec(sqlStr, nextRunAt, lastRunAt, id)
+	if err != nil {
+		repo.logger.Errorf("db update report schedule error: %v", err)
+		return
+	}
+	return
+}
+
+// DeleteSubscription 删除报表订阅及其执行记录
+func (repo *reportSubscription) DeleteSubscription(id int64) (err error) {
+	sqlStr := "DELETE FROM " + infra.GetDBName() + ".t_log_report_run WHERE f_subscription_id = ?"
+	if _, err = repo.db.Exec(sqlStr, id); err != nil {
+		repo.logger.Errorf("db delete report run error: %v", err)
+		return
+	}
+
+	sqlStr = "DELETE FROM " + infra.GetDBName() + ".t_log_report_subscription WHERE f_id = ?"
+	if _, err = repo.db.Exec(sqlStr, id); err != nil {
+		repo.logger.Errorf("db delete report subscription error: %v", err)
+		return
+	}
+	return
+}
+
+// GetRunsByCondition 根据条件查询报表执行记录
+func (repo *reportSubscription) GetRunsByCondition(condition string, params []interface{}) (res []*rptmodels.RunPO, err error) {
+	sqlStr := "SELECT " + runFields + " FROM " + infra.GetDBName() + ".t_log_report_run " + condition
+	rows, err := repo.db.Query(sqlStr, params...)
+	if err != nil {
+		repo.logger.Errorf("db query report run error: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	res = make([]*rptmodels.RunPO, 0)
+	for rows.Next() {
+		var run *rptmodels.RunPO
+		run, err = scanRun(rows)
+		if err != nil {
+			repo.logger.Errorf("db scan report run error: %v", err)
+			return
+		}
+		res = append(res, run)
+	}
+
+	return
+}
+
+// CountRunsByCondition 根据条件统计报表执行记录数量
+func (repo *reportSubscription) CountRunsByCondition(condition string, params []interface{}) (count int64, err error) {
+	sqlStr := "SELECT COUNT(f_id) FROM " + infra.GetDBName() + ".t_log_report_run " + condition
+	err = repo.db.QueryRow(sqlStr, params...).Scan(&count)
+	if err != nil {
+		repo.logger.Errorf("db count report run error: %v", err)
+		return
+	}
+	return
+}
+
+// GetRunByID 根据ID获取报表执行记录, 不存在时返回nil
+func (repo *reportSubscription) GetRunByID(id int64) (res *rptmodels.RunPO, err error) {
+	sqlStr := "SELECT " + runFields + " FROM " + infra.GetDBName() + ".t_log_report_run WHERE f_id = ?"
+	res, err = scanRun(repo.db.QueryRow(sqlStr, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		repo.logger.Errorf("db query report run error: %v", err)
+		return
+	}
+	return
+}
+
+// NewRun 新增报表执行记录
+func (repo *reportSubscription) NewRun(run *rptmodels.RunPO) (err error) {
+	sqlStr := "INSERT INTO " + infra.GetDBName() +
+		`.t_log_report_run (
+		f_id,
+		f_subscription_id,
+		f_status,
+		f_begin_time,
+		f_end_time,
+		f_err_msg,
+		f_started_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err = repo.db.Exec(sqlStr, run.ID, run.SubscriptionID, run.Status, run.BeginTime, run.EndTime, run.ErrMsg, run.StartedAt)
+	if err != nil {
+		repo.logger.Errorf("db insert report run error: %v", err)
+		return
+	}
+	return
+}
+
+// FinishRun 更新报表执行结果
+func (repo *reportSubscription) FinishRun(run *rptmodels.RunPO) (err error) {
+	sqlStr := "UPDATE " + infra.GetDBName() +
+		`.t_log_report_run SET
+		f_status = ?,
+		f_record_count = ?,
+		f_oss_id = ?,
+		f_object_name = ?,
+		f_file_name = ?,
+		f_err_msg = ?,
+		f_finished_at = ?
+		WHERE f_id = ?`
+	_, err = repo.db.Exec(sqlStr, run.Status, run.RecordCount, run.OssID, run.ObjectName, run.FileName, run.ErrMsg,
+		run.FinishedAt, run.ID)
+	if err != nil {
+		repo.logger.Errorf("db update report run error: %v", err)
+		return
+	}
+	return
+}
+
+// SetRunFileExpired 标记报表文件已从对象存储删除
+func (repo *reportSubscription) SetRunFileExpired(id int64) (err error) {
+	sqlStr := "UPDATE " + infra.GetDBName() + ".t_log_report_run SET f_file_expired = 1 WHERE f_id = ?"
+	_, err = repo.db.Exec(sqlStr, id)
+	if err != nil {
+		repo.logger.Errorf("db update report run error: %v", err)
+		return
+	}
+	return
+}
@@ -2,6 +2,8 @@ package opshttp
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	recconsts "AuditLog/common/constants/recenums"
 	"AuditLog/common/helpers"
@@ -125,3 +127,114 @@ func (o *opsHttpAcc) DeleteDocsByFieldRange(ctx context.Context, index string, f
 
 	return
 }
+
+func (o *opsHttpAcc) IndexExists(ctx context.Context, index string) (exists bool, err error) {
+	ctx, span := o.arTrace.AddInternalTrace(ctx)
+	defer func() { o.arTrace.TelemetrySpanEnd(span, err) }()
+
+	exists, err = o.opsCmp.IndexExists(ctx, index)
+	if err != nil {
+		helpers.RecordErrLogWithPos(o.logger, err, "opsHttpAcc.IndexExists")
+		return
+	}
+
+	return
+}
+
+func (o *opsHttpAcc) GetAliasIndices(ctx context.Context, alias string) (indices map[string]bool, err error) {
+	ctx, span := o.arTrace.AddInternalTrace(ctx)
+	defer func() { o.arTrace.TelemetrySpanEnd(span, err) }()
+
+	indices, err = o.opsCmp.GetAliasIndices(ctx, alias)
+	if err != nil {
+		helpers.RecordErrLogWithPos(o.logger, err, "opsHttpAcc.GetAliasIndices")
+		return
+	}
+
+	return
+}
+
+func (o *opsHttpAcc) UpdateAliases(ctx context.Context, actions []map[string]interface{}) (err error) {
+	ctx, span := o.arTrace.AddInternalTrace(ctx)
+	defer func() { o.arTrace.TelemetrySpanEnd(span, err) }()
+
+	err = o.opsCmp.UpdateAliases(ctx, actions)
+	if err != nil {
+		helpers.RecordErrLogWithPos(o.logger, err, "opsHttpAcc.UpdateAliases")
+		return
+	}
+
+	return
+}
+
+func (o *opsHttpAcc) Reindex(ctx context.Context, source, dest string, query map[string]interface{}) (err error) {
+	ctx, span := o.arTrace.AddInternalTrace(ctx)
+	defer func() { o.arTrace.TelemetrySpanEnd(span, err) }()
+
+	err = o.opsCmp.Reindex(ctx, source, dest, query)
+	if err != nil {
+		helpers.RecordErrLogWithPos(o.logger, err, "opsHttpAcc.Reindex")
+		return
+	}
+
+	return
+}
+
+func (o *opsHttpAcc) AddWriteBlock(ctx context.Context, index string) (err error) {
+	ctx, span := o.arTrace.AddInternalTrace(ctx)
+	defer func() { o.arTrace.TelemetrySpanEnd(span, err) }()
+
+	err = o.opsCmp.AddWriteBlock(ctx, index)
+	if err != nil {
+		helpers.RecordErrLogWithPos(o.logger, err, "opsHttpAcc.AddWriteBlock")
+		return
+	}
+
+	return
+}
+
+func (o *opsHttpAcc) CloneIndex(ctx context.Context, source, target string) (err error) {
+	ctx, span := o.arTrace.AddInternalTrace(ctx)
+	defer func() { o.arTrace.TelemetrySpanEnd(span, err) }()
+
+	err = o.opsCmp.CloneIndex(ctx, source, target)
+	if err != nil {
+		helpers.RecordErrLogWithPos(o.logger, err, "opsHttpAcc.CloneIndex")
+		return
+	}
+
+	return
+}
+
+// GetMinDate 通过min聚合获取日期字段的最小值
+func (o *opsHttpAcc) GetMinDate(ctx context.Context, index string, field string) (minDate time.Time, err error) {
+	ctx, span := o.arTrace.AddInternalTrace(ctx)
+	defer func() { o.arTrace.TelemetrySpanEnd(span, err) }()
+
+	dsl := fmt.Sprintf(`{"size":0,"aggs":{"min_date":{"min":{"field":%q}}}}`, field)
+
+	resp, err := o.opsCmp.Query(ctx, dsl, index)
+	if err != nil {
+		helpers.RecordErrLogWithPos(o.logger, err, "opsHttpAcc.GetMinDate")
+		return
+	}
+
+	if resp == nil {
+		err = fmt.Errorf("query min date of index %s failed", index)
+		helpers.RecordErrLogWithPos(o.logger, err, "opsHttpAcc.GetMinDate")
+
+		return
+	}
+
+	// 日期字段的聚合值为毫秒时间戳，索引为空时为null
+	agg, _ := resp.Aggregations["min_date"].(map[string]interface{})
+
+	value, ok := agg["value"].(float64)
+	if !ok {
+		return
+	}
+
+	minDate = time.UnixMilli(int64(value)).UTC()
+
+	return
+}
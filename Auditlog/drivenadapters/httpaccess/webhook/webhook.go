@@ -0,0 +1,111 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+
+	"AuditLog/common/utils/alertutils"
+	"AuditLog/drivenadapters"
+	"AuditLog/gocommon/api"
+	"AuditLog/interfaces"
+)
+
+var (
+	wOnce sync.Once
+	w     *webhook
+)
+
+const (
+	// 推送超时时间
+	postTimeout = 10 * time.Second
+	// 允许的最大重定向次数
+	maxRedirects = 3
+)
+
+type webhook struct {
+	httpClient *http.Client
+	logger     api.Logger
+}
+
+func NewWebhook() interfaces.WebhookRepo {
+	wOnce.Do(func() {
+		w = &webhook{
+			httpClient: newHTTPClient(),
+			logger:     drivenadapters.Logger,
+		}
+	})
+	return w
+}
+
+// newHTTPClient 创建推送webhook的http客户端
+// webhook地址由用户配置, 建立连接前校验域名解析后的地址, 重定向后的地址同样经过校验
+func newHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: checkDialAddress,
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:               nil, // 不使用代理, 避免绕过地址校验
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		Timeout: postTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return nil
+		},
+	}
+}
+
+// checkDialAddress 拒绝连接回环、链路本地、私有等内部地址, address 为域名解析后的地址
+func checkDialAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || alertutils.IsForbiddenWebhookIP(ip) {
+		return fmt.Errorf("webhook address %s is not allowed", address)
+	}
+	return nil
+}
+
+// Post 以json格式推送消息到webhook地址
+func (w *webhook) Post(ctx context.Context, url string, body interface{}) (err error) {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook %v response status %v: %s", url, resp.StatusCode, respBody)
+	}
+
+	return
+}
@@ -0,0 +1,38 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPost(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	w := &webhook{httpClient: newHTTPClient()}
+
+	t.Run("拒绝推送到回环地址", func(t *testing.T) {
+		err := w.Post(context.Background(), server.URL, map[string]string{"msg": "test"})
+		assert.ErrorContains(t, err, "is not allowed")
+		assert.False(t, called)
+	})
+
+	t.Run("拒绝域名解析为回环地址", func(t *testing.T) {
+		err := w.Post(context.Background(), "http://localhost:1/hook", map[string]string{"msg": "test"})
+		assert.ErrorContains(t, err, "is not allowed")
+	})
+}
+
+func TestCheckDialAddress(t *testing.T) {
+	assert.NoError(t, checkDialAddress("tcp4", "8.8.8.8:443", nil))
+	assert.Error(t, checkDialAddress("tcp4", "10.0.0.1:443", nil))
+	assert.Error(t, checkDialAddress("tcp6", "[::1]:80", nil))
+	assert.Error(t, checkDialAddress("tcp4", "invalid", nil))
+}
@@ -21,6 +21,7 @@ var (
 type dlm struct {
 	redisClient redis.Cmdable
 	expiration  time.Duration
+	mu          sync.Mutex // 多个定时任务并发加解锁, 保护 contexts
 	contexts    map[string]context.CancelFunc
 	uniqueID    string
 }
@@ -52,7 +53,9 @@ func (d *dlm) TryLock(key string) (success bool, err error) {
 		return false, nil
 	}
 
+	d.mu.Lock()
 	d.contexts[key] = cancel
+	d.mu.Unlock()
 	go d.keepLock(ctx, key)
 	return true, nil
 }
@@ -77,11 +80,18 @@ func (d *dlm) UnLock(key string) error {
 		return fmt.Errorf("锁不存在或已被其他客户端持有")
 	}
 
+	d.cancelKeepLock(key)
+	return nil
+}
+
+// cancelKeepLock 停止锁续期
+func (d *dlm) cancelKeepLock(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	if cancel, ok := d.contexts[key]; ok {
 		cancel()
 		delete(d.contexts, key)
 	}
-	return nil
 }
 
 // keepLock 优化锁续期逻辑
@@ -116,10 +126,7 @@ func (d *dlm) keepLock(ctx context.Context, key string) {
 
 			if result.(int64) == 0 {
 				// 锁已经不属于我们了，退出续期
-				if cancel, ok := d.contexts[key]; ok {
-					cancel()
-					delete(d.contexts, key)
-				}
+				d.cancelKeepLock(key)
 				return
 			}
 		}
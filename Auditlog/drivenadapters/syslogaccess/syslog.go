@@ -0,0 +1,135 @@
+package syslogaccess
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"AuditLog/common"
+	"AuditLog/common/constants/fwdconsts"
+	"AuditLog/drivenadapters"
+	"AuditLog/gocommon/api"
+	"AuditLog/interfaces"
+)
+
+var (
+	slOnce sync.Once
+	sl     *syslogClient
+)
+
+type syslogClient struct {
+	protocol  string
+	addr      string
+	tlsConfig *tls.Config
+	timeout   time.Duration
+	logger    api.Logger
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslogClient 创建 syslog 客户端, 连接在首次发送时建立
+func NewSyslogClient() interfaces.SyslogClient {
+	slOnce.Do(func() {
+		tlsConfig, err := newTLSConfig(common.SvcConfig.SiemAddr, common.SvcConfig.SiemTLSCAFile, common.SvcConfig.SiemTLSSkipVerify)
+		if err != nil {
+			drivenadapters.Logger.Errorf("syslog client load tls config error: %v", err)
+		}
+
+		sl = newSyslogClient(common.SvcConfig.SiemProtocol, common.SvcConfig.SiemAddr, tlsConfig, drivenadapters.Logger)
+	})
+	return sl
+}
+
+func newSyslogClient(protocol, addr string, tlsConfig *tls.Config, logger api.Logger) *syslogClient {
+	return &syslogClient{
+		protocol:  protocol,
+		addr:      addr,
+		tlsConfig: tlsConfig,
+		timeout:   fwdconsts.SendTimeout,
+		logger:    logger,
+	}
+}
+
+// newTLSConfig 生成 tls 配置, 未指定 CA 文件时使用系统证书
+func newTLSConfig(addr, caFile string, skipVerify bool) (*tls.Config, error) {
+	host, _, _ := net.SplitHostPort(addr)
+	conf := &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: skipVerify, //nolint:gosec
+		MinVersion:         tls.VersionTLS12,
+	}
+	if caFile == "" {
+		return conf, nil
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return conf, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return conf, fmt.Errorf("no certificate found in %s", caFile)
+	}
+	conf.RootCAs = pool
+
+	return conf, nil
+}
+
+// Send 发送一条 syslog 消息
+// tcp/tls 使用 RFC 6587 的长度前缀分帧, 连接断开时重新建连并重发一次
+func (s *syslogClient) Send(msg string) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	frame := s.frame(msg)
+	for i := 0; i < 2; i++ {
+		if s.conn == nil {
+			if s.conn, err = s.dial(); err != nil {
+				return err
+			}
+		}
+
+		_ = s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+		if _, err = s.conn.Write(frame); err == nil {
+			return nil
+		}
+
+		s.logger.Warnf("syslog client [Send] write error: %v", err)
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+
+	return err
+}
+
+func (s *syslogClient) dial() (net.Conn, error) {
+	if s.addr == "" {
+		return nil, fmt.Errorf("syslog address is empty")
+	}
+
+	dialer := &net.Dialer{Timeout: s.timeout}
+	switch s.protocol {
+	case fwdconsts.ProtocolUDP:
+		return dialer.Dial("udp", s.addr)
+	case fwdconsts.ProtocolTCP:
+		return dialer.Dial("tcp", s.addr)
+	case fwdconsts.ProtocolTLS:
+		return tls.DialWithDialer(dialer, "tcp", s.addr, s.tlsConfig)
+	default:
+		return nil, fmt.Errorf("unsupported syslog protocol: %s", s.protocol)
+	}
+}
+
+func (s *syslogClient) frame(msg string) []byte {
+	if s.protocol == fwdconsts.ProtocolUDP {
+		return []byte(msg)
+	}
+	return []byte(strconv.Itoa(len(msg)) + " " + msg)
+}
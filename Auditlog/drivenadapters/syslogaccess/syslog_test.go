@@ -0,0 +1,107 @@
+package syslogaccess
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"AuditLog/common/constants/fwdconsts"
+	"AuditLog/test/mock_log"
+)
+
+// readFrame 读取一条长度前缀分帧的消息
+func readFrame(r *bufio.Reader) (string, error) {
+	lenStr, err := r.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(lenStr))
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, n)
+	_, err = io.ReadFull(r, buf)
+	return string(buf), err
+}
+
+func TestSyslogClientSend(t *testing.T) {
+	Convey("Send", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		logger := mock_log.NewMockLogger(ctrl)
+
+		Convey("tcp 长度前缀分帧", func() {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			assert.NoError(t, err)
+			defer ln.Close()
+
+			received := make(chan []string, 1)
+			go func() {
+				conn, acceptErr := ln.Accept()
+				if acceptErr != nil {
+					return
+				}
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				var msgs []string
+				for i := 0; i < 2; i++ {
+					msg, readErr := readFrame(r)
+					if readErr != nil {
+						break
+					}
+					msgs = append(msgs, msg)
+				}
+				received <- msgs
+			}()
+
+			client := newSyslogClient(fwdconsts.ProtocolTCP, ln.Addr().String(), nil, logger)
+			assert.NoError(t, client.Send("<110>1 - host audit-log - login - 消息 1"))
+			assert.NoError(t, client.Send("<110>1 - host audit-log - login - msg 2"))
+
+			select {
+			case msgs := <-received:
+				assert.Equal(t, []string{"<110>1 - host audit-log - login - 消息 1", "<110>1 - host audit-log - login - msg 2"}, msgs)
+			case <-time.After(3 * time.Second):
+				t.Fatal("syslog listener timeout")
+			}
+		})
+
+		Convey("udp 每条消息一个数据报", func() {
+			pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+			assert.NoError(t, err)
+			defer pc.Close()
+
+			client := newSyslogClient(fwdconsts.ProtocolUDP, pc.LocalAddr().String(), nil, logger)
+			assert.NoError(t, client.Send("<108>1 - host audit-log - login - msg"))
+
+			buf := make([]byte, 1024)
+			_ = pc.SetReadDeadline(time.Now().Add(3 * time.Second))
+			n, _, err := pc.ReadFrom(buf)
+			assert.NoError(t, err)
+			assert.Equal(t, "<108>1 - host audit-log - login - msg", string(buf[:n]))
+		})
+
+		Convey("服务不可用", func() {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			assert.NoError(t, err)
+			addr := ln.Addr().String()
+			ln.Close()
+
+			client := newSyslogClient(fwdconsts.ProtocolTCP, addr, nil, logger)
+			assert.Error(t, client.Send("msg"))
+		})
+
+		Convey("地址为空或协议不支持", func() {
+			assert.Error(t, newSyslogClient(fwdconsts.ProtocolTCP, "", nil, logger).Send("msg"))
+			assert.Error(t, newSyslogClient("http", "127.0.0.1:514", nil, logger).Send("msg"))
+		})
+	})
+}
@@ -87,6 +87,7 @@ func (ac *activeHandler) getDataList(c *gin.Context) {
 	body.Offset = req.Offset
 	body.Condition = make(map[string]interface{})
 	body.OrderBy = req.OrderBy
+	body.WorkspaceID = req.WorkspaceID
 
 	if len(req.IDs) > 0 {
 		strIDs := make([]string, len(req.IDs))
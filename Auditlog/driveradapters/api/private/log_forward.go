@@ -0,0 +1,41 @@
+package driveradapters
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"AuditLog/interfaces"
+	"AuditLog/logics"
+)
+
+var (
+	forwardOnce sync.Once
+	fwd         interfaces.PrivateRESTHandler
+)
+
+type logForwardHandler struct {
+	logForward interfaces.LogForward
+}
+
+// NewLogForwardHandler 创建日志转发handler对象
+func NewLogForwardHandler() interfaces.PrivateRESTHandler {
+	forwardOnce.Do(func() {
+		fwd = &logForwardHandler{
+			logForward: logics.NewLogForward(),
+		}
+	})
+
+	return fwd
+}
+
+// RegisterPrivate 注册私有API
+func (f *logForwardHandler) RegisterPrivate(routerGroup *gin.RouterGroup) {
+	routerGroup.GET("/forward/metrics", f.getMetrics)
+}
+
+// 获取日志转发统计
+func (f *logForwardHandler) getMetrics(c *gin.Context) {
+	c.JSON(http.StatusOK, f.logForward.GetMetrics())
+}
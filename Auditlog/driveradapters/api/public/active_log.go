@@ -50,6 +50,14 @@ func (ac *activeLogHandler) RegisterPublic(routerGroup *gin.RouterGroup) {
 		ac.getDataList,
 	)
 
+	statisticsRoler := middleware.PermissionMiddleware([]string{common.SuperAdmin, common.SecAdmin, common.SysAdmin, common.AuditAdmin, common.OrgAudit})
+	routerGroup.POST(
+		"/report-center/active/:category/statistics",
+		statisticsRoler,
+		middleware.VisitorParser,
+		ac.getStatistics,
+	)
+
 	getFieldValuesRoler := middleware.PermissionMiddleware([]string{common.SuperAdmin, common.SecAdmin, common.SysAdmin, common.AuditAdmin, common.OrgAudit})
 	routerGroup.POST(
 		"/report-center/active/:category/field/:field/values",
@@ -81,6 +89,7 @@ func (ac *activeLogHandler) getDataList(c *gin.Context) {
 	body.Offset = req.Offset
 	body.Condition = make(map[string]interface{})
 	body.OrderBy = req.OrderBy
+	body.WorkspaceID = req.WorkspaceID
 
 	if len(req.IDs) > 0 {
 		strIDs := make([]string, len(req.IDs))
@@ -141,6 +150,54 @@ func (ac *activeLogHandler) getDataList(c *gin.Context) {
 	c.JSON(http.StatusOK, list)
 }
 
+// getStatistics 获取活跃日志统计
+func (ac *activeLogHandler) getStatistics(c *gin.Context) {
+	// 获取path中参数
+	logType := c.Param("category")
+
+	// 验证 logType 是否合法
+	if err := paramutils.CategoryCheck(c, logType); err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	req := &rcvo.ReportGetStatisticsReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	// 校验搜索参数是否合法
+	var searchParams []string
+	for k := range req.Condition {
+		searchParams = append(searchParams, k)
+	}
+
+	if err := paramutils.ParamsCheck(c, searchParams, avaliableParams.SearchFields, "condition"); err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	// 时间范围由毫秒转换为微秒
+	if v, ok := req.Condition[rclogconsts.Date]; ok {
+		if dateRange, ok := v.([]interface{}); ok && len(dateRange) == 2 {
+			begin, _ := dateRange[0].(float64)
+			end, _ := dateRange[1].(float64)
+			req.Condition[rclogconsts.Date] = []interface{}{begin * 1000, end * 1000}
+		}
+	}
+
+	visitor := c.Value(common.VisitorKey).(*models.Visitor)
+
+	stats, err := ac.activeLog.GetActiveStatistics(c, logType, req, visitor.ID)
+	if err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
 // getFieldValues 获取活跃日志报表字段值
 func (ac *activeLogHandler) getFieldValues(c *gin.Context) {
 	// 获取path中参数
@@ -0,0 +1,187 @@
+package driveradapters
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"AuditLog/common"
+	"AuditLog/errors"
+	"AuditLog/interfaces"
+	"AuditLog/logics"
+	"AuditLog/middleware"
+	"AuditLog/models/alertmodels"
+)
+
+var (
+	arOnce sync.Once
+	ar     interfaces.PublicRESTHandler
+)
+
+type alertRuleHandler struct {
+	arSvc interfaces.AlertRule
+}
+
+// NewAlertRuleHandler 创建告警规则handler对象
+func NewAlertRuleHandler() interfaces.PublicRESTHandler {
+	arOnce.Do(func() {
+		ar = &alertRuleHandler{
+			arSvc: logics.NewAlertRule(),
+		}
+	})
+
+	return ar
+}
+
+func (a *alertRuleHandler) RegisterPublic(routerGroup *gin.RouterGroup) {
+	roler := middleware.PermissionMiddleware([]string{common.AuditAdmin})
+	routerGroup.GET(
+		"/alert-rules",
+		roler,
+		a.getRules,
+	)
+	routerGroup.POST(
+		"/alert-rules",
+		roler,
+		middleware.ValidateMiddleware(common.AlertRule),
+		middleware.VisitorParser,
+		a.newRule,
+	)
+	routerGroup.GET(
+		"/alert-rules/:id",
+		roler,
+		a.getRule,
+	)
+	routerGroup.PUT(
+		"/alert-rules/:id",
+		roler,
+		middleware.ValidateMiddleware(common.AlertRule),
+		middleware.VisitorParser,
+		a.updateRule,
+	)
+	routerGroup.DELETE(
+		"/alert-rules/:id",
+		roler,
+		middleware.VisitorParser,
+		a.deleteRule,
+	)
+}
+
+// 获取告警规则列表
+func (a *alertRuleHandler) getRules(c *gin.Context) {
+	req := &alertmodels.GetAlertRulesReq{
+		LogType: c.Query("log_type"),
+		Limit:   200,
+		Offset:  0,
+	}
+
+	parseIntParam := func(value string, min int, max int, dest *int, field string) bool {
+		if value == "" {
+			return true
+		}
+
+		val, err := strconv.Atoi(value)
+		if err != nil || val < min || val > max {
+			common.ErrResponse(c, errors.NewCtx(c, errors.BadRequestErr, "invalid "+field, nil))
+			return false
+		}
+
+		*dest = val
+
+		return true
+	}
+
+	if !parseIntParam(c.Query("limit"), 1, 1000, &req.Limit, "limit") ||
+		!parseIntParam(c.Query("offset"), 0, math.MaxInt, &req.Offset, "offset") {
+		return
+	}
+
+	rules, err := a.arSvc.GetRules(c, req)
+	if err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// 获取告警规则
+func (a *alertRuleHandler) getRule(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	rule, err := a.arSvc.GetRule(c, id)
+	if err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// 新增告警规则
+func (a *alertRuleHandler) newRule(c *gin.Context) {
+	reqBody := &alertmodels.AlertRuleVO{}
+	if err := common.ParseBody(c, reqBody); err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	id, err := a.arSvc.NewRule(c, reqBody)
+	if err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, map[string]interface{}{"id": id})
+}
+
+// 更新告警规则
+func (a *alertRuleHandler) updateRule(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	reqBody := &alertmodels.AlertRuleVO{}
+	if err := common.ParseBody(c, reqBody); err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	if err := a.arSvc.UpdateRule(c, id, reqBody); err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// 删除告警规则
+func (a *alertRuleHandler) deleteRule(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := a.arSvc.DeleteRule(c, id); err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func parseIDParam(c *gin.Context) (id int64, ok bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		common.ErrResponse(c, errors.NewCtx(c, errors.BadRequestErr, "invalid id", nil))
+		return 0, false
+	}
+	return id, true
+}
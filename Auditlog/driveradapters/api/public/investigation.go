@@ -0,0 +1,151 @@
+package driveradapters
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"AuditLog/common"
+	"AuditLog/errors"
+	"AuditLog/interfaces"
+	"AuditLog/logics"
+	"AuditLog/middleware"
+	"AuditLog/models/wsmodels"
+)
+
+var (
+	wsOnce sync.Once
+	ws     interfaces.PublicRESTHandler
+)
+
+type investigationHandler struct {
+	wsSvc interfaces.Investigation
+}
+
+// NewInvestigationHandler 创建调查工作区handler对象
+func NewInvestigationHandler() interfaces.PublicRESTHandler {
+	wsOnce.Do(func() {
+		ws = &investigationHandler{
+			wsSvc: logics.NewInvestigation(),
+		}
+	})
+
+	return ws
+}
+
+func (i *investigationHandler) RegisterPublic(routerGroup *gin.RouterGroup) {
+	roler := middleware.PermissionMiddleware([]string{common.AuditAdmin})
+	routerGroup.GET(
+		"/investigation-workspaces",
+		roler,
+		middleware.VisitorParser,
+		i.getWorkspaces,
+	)
+	routerGroup.POST(
+		"/investigation-workspaces",
+		roler,
+		middleware.ValidateMiddleware(common.InvestigationWorkspace),
+		middleware.VisitorParser,
+		i.newWorkspace,
+	)
+	routerGroup.GET(
+		"/investigation-workspaces/:id",
+		roler,
+		middleware.VisitorParser,
+		i.getWorkspace,
+	)
+	routerGroup.DELETE(
+		"/investigation-workspaces/:id",
+		roler,
+		middleware.VisitorParser,
+		i.deleteWorkspace,
+	)
+}
+
+// 获取调查工作区列表
+func (i *investigationHandler) getWorkspaces(c *gin.Context) {
+	req := &wsmodels.GetWorkspacesReq{
+		Limit:  200,
+		Offset: 0,
+	}
+
+	parseIntParam := func(value string, min int, max int, dest *int, field string) bool {
+		if value == "" {
+			return true
+		}
+
+		val, err := strconv.Atoi(value)
+		if err != nil || val < min || val > max {
+			common.ErrResponse(c, errors.NewCtx(c, errors.BadRequestErr, "invalid "+field, nil))
+			return false
+		}
+
+		*dest = val
+
+		return true
+	}
+
+	if !parseIntParam(c.Query("limit"), 1, 1000, &req.Limit, "limit") ||
+		!parseIntParam(c.Query("offset"), 0, math.MaxInt, &req.Offset, "offset") {
+		return
+	}
+
+	workspaces, err := i.wsSvc.GetWorkspaces(c, req)
+	if err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, workspaces)
+}
+
+// 获取调查工作区
+func (i *investigationHandler) getWorkspace(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	workspace, err := i.wsSvc.GetWorkspace(c, id)
+	if err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, workspace)
+}
+
+// 新建调查工作区, 从历史日志文件恢复日志
+func (i *investigationHandler) newWorkspace(c *gin.Context) {
+	reqBody := &wsmodels.NewWorkspaceReq{}
+	if err := common.ParseBody(c, reqBody); err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	id, err := i.wsSvc.NewWorkspace(c, reqBody)
+	if err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, map[string]interface{}{"id": id})
+}
+
+// 删除调查工作区
+func (i *investigationHandler) deleteWorkspace(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := i.wsSvc.DeleteWorkspace(c, id); err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
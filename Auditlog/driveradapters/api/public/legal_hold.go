@@ -0,0 +1,178 @@
+package driveradapters
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"AuditLog/common"
+	"AuditLog/errors"
+	"AuditLog/interfaces"
+	"AuditLog/logics"
+	"AuditLog/middleware"
+	"AuditLog/models/lhmodels"
+)
+
+var (
+	lhOnce sync.Once
+	lh     interfaces.PublicRESTHandler
+)
+
+type legalHoldHandler struct {
+	lhSvc interfaces.LegalHold
+}
+
+// NewLegalHoldHandler 创建法律保留handler对象
+func NewLegalHoldHandler() interfaces.PublicRESTHandler {
+	lhOnce.Do(func() {
+		lh = &legalHoldHandler{
+			lhSvc: logics.NewLegalHold(),
+		}
+	})
+
+	return lh
+}
+
+func (l *legalHoldHandler) RegisterPublic(routerGroup *gin.RouterGroup) {
+	roler := middleware.PermissionMiddleware([]string{common.AuditAdmin})
+	routerGroup.GET(
+		"/legal-holds",
+		roler,
+		l.getHolds,
+	)
+	routerGroup.POST(
+		"/legal-holds",
+		roler,
+		middleware.ValidateMiddleware(common.LegalHold),
+		middleware.VisitorParser,
+		l.newHold,
+	)
+	routerGroup.GET(
+		"/legal-holds/:id",
+		roler,
+		l.getHold,
+	)
+	routerGroup.PUT(
+		"/legal-holds/:id",
+		roler,
+		middleware.ValidateMiddleware(common.LegalHold),
+		middleware.VisitorParser,
+		l.updateHold,
+	)
+	routerGroup.DELETE(
+		"/legal-holds/:id",
+		roler,
+		middleware.VisitorParser,
+		l.deleteHold,
+	)
+}
+
+// 获取法律保留列表
+func (l *legalHoldHandler) getHolds(c *gin.Context) {
+	req := &lhmodels.GetLegalHoldsReq{
+		LogType: c.Query("log_type"),
+		Limit:   200,
+		Offset:  0,
+	}
+
+	parseIntParam := func(value string, min int, max int, dest *int, field string) bool {
+		if value == "" {
+			return true
+		}
+
+		val, err := strconv.Atoi(value)
+		if err != nil || val < min || val > max {
+			common.ErrResponse(c, errors.NewCtx(c, errors.BadRequestErr, "invalid "+field, nil))
+			return false
+		}
+
+		*dest = val
+
+		return true
+	}
+
+	if !parseIntParam(c.Query("limit"), 1, 1000, &req.Limit, "limit") ||
+		!parseIntParam(c.Query("offset"), 0, math.MaxInt, &req.Offset, "offset") {
+		return
+	}
+
+	holds, err := l.lhSvc.GetHolds(c, req)
+	if err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, holds)
+}
+
+// 获取法律保留
+func (l *legalHoldHandler) getHold(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	hold, err := l.lhSvc.GetHold(c, id)
+	if err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, hold)
+}
+
+// 新增法律保留
+func (l *legalHoldHandler) newHold(c *gin.Context) {
+	reqBody := &lhmodels.LegalHoldVO{}
+	if err := common.ParseBody(c, reqBody); err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	id, err := l.lhSvc.NewHold(c, reqBody)
+	if err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, map[string]interface{}{"id": id})
+}
+
+// 更新法律保留
+func (l *legalHoldHandler) updateHold(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	reqBody := &lhmodels.LegalHoldVO{}
+	if err := common.ParseBody(c, reqBody); err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	if err := l.lhSvc.UpdateHold(c, id, reqBody); err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// 解除法律保留
+func (l *legalHoldHandler) deleteHold(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := l.lhSvc.DeleteHold(c, id); err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
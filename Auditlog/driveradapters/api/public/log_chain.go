@@ -0,0 +1,88 @@
+package driveradapters
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"AuditLog/common"
+	"AuditLog/errors"
+	"AuditLog/interfaces"
+	"AuditLog/logics"
+	"AuditLog/middleware"
+)
+
+var (
+	lcOnce sync.Once
+	lc     interfaces.PublicRESTHandler
+)
+
+type logChainHandler struct {
+	lcSvc interfaces.LogChain
+}
+
+func NewLogChainHandler() interfaces.PublicRESTHandler {
+	lcOnce.Do(func() {
+		lc = &logChainHandler{
+			lcSvc: logics.NewLogChain(),
+		}
+	})
+
+	return lc
+}
+
+func (l *logChainHandler) RegisterPublic(routerGroup *gin.RouterGroup) {
+	roler := middleware.PermissionMiddleware([]string{common.SuperAdmin, common.SecAdmin, common.AuditAdmin})
+	routerGroup.GET(
+		"/log-chain/:category/verification",
+		roler,
+		l.verify,
+	)
+}
+
+// 校验日志哈希链
+func (l *logChainHandler) verify(c *gin.Context) {
+	category := c.Param("category")
+	if !common.InArray(category, common.AllLogType) {
+		common.ErrResponse(c, errors.NewCtx(c, errors.BadRequestErr, "invalid category", nil))
+		return
+	}
+
+	// 解析序号范围, 为空时由逻辑层使用默认范围
+	parseSeq := func(value string, dest *int64, field string) bool {
+		if value == "" {
+			return true
+		}
+
+		val, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || val < 1 {
+			common.ErrResponse(c, errors.NewCtx(c, errors.BadRequestErr, "invalid "+field, nil))
+			return false
+		}
+
+		*dest = val
+
+		return true
+	}
+
+	var beginSeq, endSeq int64
+	if !parseSeq(c.Query("begin_seq"), &beginSeq, "begin_seq") ||
+		!parseSeq(c.Query("end_seq"), &endSeq, "end_seq") {
+		return
+	}
+
+	if beginSeq > 0 && endSeq > 0 && beginSeq > endSeq {
+		common.ErrResponse(c, errors.NewCtx(c, errors.BadRequestErr, "begin_seq is greater than end_seq", nil))
+		return
+	}
+
+	res, err := l.lcSvc.Verify(c, category, beginSeq, endSeq)
+	if err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
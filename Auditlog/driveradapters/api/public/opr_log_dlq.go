@@ -0,0 +1,164 @@
+package driveradapters
+
+import (
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"AuditLog/common"
+	"AuditLog/common/constants/dlqconsts"
+	oprinject "AuditLog/domain/service/inject/operation_log"
+	"AuditLog/errors"
+	"AuditLog/interfaces"
+	oprlogdriveri "AuditLog/interfaces/driveradapter/operation_log"
+	"AuditLog/middleware"
+	"AuditLog/models/dlqmodels"
+)
+
+var (
+	dlqOnce sync.Once
+	dlq     interfaces.PublicRESTHandler
+)
+
+type oprLogDLQHandler struct {
+	dlqSvc oprlogdriveri.IOprLogDLQSvc
+}
+
+// NewOprLogDLQHandler 创建运营日志死信handler对象
+func NewOprLogDLQHandler() interfaces.PublicRESTHandler {
+	dlqOnce.Do(func() {
+		dlq = &oprLogDLQHandler{
+			dlqSvc: oprinject.NewOprLogDLQSvc(),
+		}
+	})
+
+	return dlq
+}
+
+func (d *oprLogDLQHandler) RegisterPublic(routerGroup *gin.RouterGroup) {
+	roler := middleware.PermissionMiddleware([]string{common.SuperAdmin, common.SysAdmin})
+	routerGroup.GET(
+		"/opr-log-dead-letters",
+		roler,
+		d.getDeadLetters,
+	)
+	routerGroup.GET(
+		"/opr-log-dead-letters/:id",
+		roler,
+		d.getDeadLetter,
+	)
+	routerGroup.POST(
+		"/opr-log-dead-letters/purge",
+		roler,
+		middleware.ValidateMiddleware(common.PurgeDeadLetters),
+		middleware.VisitorParser,
+		d.purge,
+	)
+	routerGroup.POST(
+		"/opr-log-dead-letters/replay",
+		roler,
+		middleware.ValidateMiddleware(common.ReplayDeadLetters),
+		middleware.VisitorParser,
+		d.replay,
+	)
+}
+
+// 获取死信列表
+func (d *oprLogDLQHandler) getDeadLetters(c *gin.Context) {
+	req := &dlqmodels.GetDeadLettersReq{
+		BizType: c.Query("biz_type"),
+		Reason:  c.Query("reason"),
+		Limit:   200,
+		Offset:  0,
+	}
+
+	if req.Reason != "" && !slices.Contains(dlqconsts.AllReason, req.Reason) {
+		common.ErrResponse(c, errors.NewCtx(c, errors.BadRequestErr, "invalid reason", nil))
+		return
+	}
+
+	parseIntParam := func(value string, min int, max int, dest *int, field string) bool {
+		if value == "" {
+			return true
+		}
+
+		val, err := strconv.Atoi(value)
+		if err != nil || val < min || val > max {
+			common.ErrResponse(c, errors.NewCtx(c, errors.BadRequestErr, "invalid "+field, nil))
+			return false
+		}
+
+		*dest = val
+
+		return true
+	}
+
+	if !parseIntParam(c.Query("status"), dlqconsts.StatusPending, dlqconsts.StatusReplayed, &req.Status, "status") ||
+		!parseIntParam(c.Query("limit"), 1, 1000, &req.Limit, "limit") ||
+		!parseIntParam(c.Query("offset"), 0, math.MaxInt, &req.Offset, "offset") {
+		return
+	}
+
+	res, err := d.dlqSvc.GetDeadLetters(c, req)
+	if err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// 获取死信详情
+func (d *oprLogDLQHandler) getDeadLetter(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		common.ErrResponse(c, errors.NewCtx(c, errors.BadRequestErr, "invalid id", nil))
+		return
+	}
+
+	res, err := d.dlqSvc.GetDeadLetter(c, id)
+	if err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// 清除死信
+func (d *oprLogDLQHandler) purge(c *gin.Context) {
+	reqBody := &dlqmodels.PurgeDeadLettersReq{}
+	if err := common.ParseBody(c, reqBody); err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	res, err := d.dlqSvc.Purge(c, reqBody)
+	if err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// 重放死信
+func (d *oprLogDLQHandler) replay(c *gin.Context) {
+	reqBody := &dlqmodels.ReplayDeadLettersReq{}
+	if err := common.ParseBody(c, reqBody); err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	res, err := d.dlqSvc.Replay(c, reqBody)
+	if err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
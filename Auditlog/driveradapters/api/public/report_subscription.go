@@ -0,0 +1,238 @@
+package driveradapters
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"AuditLog/common"
+	"AuditLog/errors"
+	"AuditLog/interfaces"
+	"AuditLog/logics"
+	"AuditLog/middleware"
+	"AuditLog/models/rptmodels"
+)
+
+var (
+	rptOnce sync.Once
+	rpt     interfaces.PublicRESTHandler
+)
+
+type reportSubscriptionHandler struct {
+	rptSvc interfaces.ReportSubscription
+}
+
+// NewReportSubscriptionHandler 创建报表订阅handler对象
+func NewReportSubscriptionHandler() interfaces.PublicRESTHandler {
+	rptOnce.Do(func() {
+		rpt = &reportSubscriptionHandler{
+			rptSvc: logics.NewReportSubscription(),
+		}
+	})
+
+	return rpt
+}
+
+func (r *reportSubscriptionHandler) RegisterPublic(routerGroup *gin.RouterGroup) {
+	roler := middleware.PermissionMiddleware([]string{common.AuditAdmin})
+	routerGroup.GET(
+		"/report-subscriptions",
+		roler,
+		r.getSubscriptions,
+	)
+	routerGroup.POST(
+		"/report-subscriptions",
+		roler,
+		middleware.ValidateMiddleware(common.ReportSubscription),
+		middleware.VisitorParser,
+		r.newSubscription,
+	)
+	routerGroup.GET(
+		"/report-subscriptions/:id",
+		roler,
+		r.getSubscription,
+	)
+	routerGroup.PUT(
+		"/report-subscriptions/:id",
+		roler,
+		middleware.ValidateMiddleware(common.ReportSubscription),
+		middleware.VisitorParser,
+		r.updateSubscription,
+	)
+	routerGroup.DELETE(
+		"/report-subscriptions/:id",
+		roler,
+		middleware.VisitorParser,
+		r.deleteSubscription,
+	)
+	routerGroup.GET(
+		"/report-subscriptions/:id/runs",
+		roler,
+		r.getRuns,
+	)
+	routerGroup.GET(
+		"/report-subscriptions/:id/runs/:run_id/download",
+		roler,
+		r.downloadRun,
+	)
+}
+
+// parseLimitOffset 解析分页参数
+func parseLimitOffset(c *gin.Context, limit *int, offset *int) bool {
+	parseIntParam := func(value string, min int, max int, dest *int, field string) bool {
+		if value == "" {
+			return true
+		}
+
+		val, err := strconv.Atoi(value)
+		if err != nil || val < min || val > max {
+			common.ErrResponse(c, errors.NewCtx(c, errors.BadRequestErr, "invalid "+field, nil))
+			return false
+		}
+
+		*dest = val
+
+		return true
+	}
+
+	return parseIntParam(c.Query("limit"), 1, 1000, limit, "limit") &&
+		parseIntParam(c.Query("offset"), 0, math.MaxInt, offset, "offset")
+}
+
+// 获取报表订阅列表
+func (r *reportSubscriptionHandler) getSubscriptions(c *gin.Context) {
+	req := &rptmodels.GetSubscriptionsReq{
+		LogType: c.Query("log_type"),
+		Limit:   200,
+		Offset:  0,
+	}
+	if !parseLimitOffset(c, &req.Limit, &req.Offset) {
+		return
+	}
+
+	subs, err := r.rptSvc.GetSubscriptions(c, req)
+	if err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subs)
+}
+
+// 获取报表订阅
+func (r *reportSubscriptionHandler) getSubscription(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	sub, err := r.rptSvc.GetSubscription(c, id)
+	if err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+// 新增报表订阅
+func (r *reportSubscriptionHandler) newSubscription(c *gin.Context) {
+	reqBody := &rptmodels.SubscriptionVO{}
+	if err := common.ParseBody(c, reqBody); err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	id, err := r.rptSvc.NewSubscription(c, reqBody)
+	if err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, map[string]interface{}{"id": id})
+}
+
+// 更新报表订阅
+func (r *reportSubscriptionHandler) updateSubscription(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	reqBody := &rptmodels.SubscriptionVO{}
+	if err := common.ParseBody(c, reqBody); err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	if err := r.rptSvc.UpdateSubscription(c, id, reqBody); err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// 删除报表订阅
+func (r *reportSubscriptionHandler) deleteSubscription(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := r.rptSvc.DeleteSubscription(c, id); err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// 获取报表执行记录
+func (r *reportSubscriptionHandler) getRuns(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	req := &rptmodels.GetRunsReq{
+		Limit:  200,
+		Offset: 0,
+	}
+	if !parseLimitOffset(c, &req.Limit, &req.Offset) {
+		return
+	}
+
+	runs, err := r.rptSvc.GetRuns(c, id, req)
+	if err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// 获取报表文件下载信息
+func (r *reportSubscriptionHandler) downloadRun(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	runID, err := strconv.ParseInt(c.Param("run_id"), 10, 64)
+	if err != nil {
+		common.ErrResponse(c, errors.NewCtx(c, errors.BadRequestErr, "invalid run_id", nil))
+		return
+	}
+
+	info, err := r.rptSvc.GetRunDownloadInfo(c, id, runID)
+	if err != nil {
+		common.ErrResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
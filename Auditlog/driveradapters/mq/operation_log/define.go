@@ -14,6 +14,7 @@ type oprLogMqHandler struct {
 	logger    api.Logger
 	client    api.MQClient
 	oprLogSvc oprlogdriveri.IOprLogSvc
+	dlqSvc    oprlogdriveri.IOprLogDLQSvc
 }
 
 var (
@@ -27,6 +28,7 @@ func NewOprLogMqHandler() interfaces.MQHandler {
 			logger:    common.SvcConfig.Logger,
 			client:    api.NewMQClient(),
 			oprLogSvc: oprinject.NewOprLogSvc(),
+			dlqSvc:    oprinject.NewOprLogDLQSvc(),
 		}
 	})
 
@@ -7,6 +7,7 @@ import (
 
 	"github.com/tidwall/gjson"
 
+	"AuditLog/common/constants/dlqconsts"
 	"AuditLog/common/enums/oprlogenums"
 	"AuditLog/common/helpers"
 	"AuditLog/common/helpers/panichelper"
@@ -68,7 +69,7 @@ func (m *oprLogMqHandler) commonHandle(bizType oprlogenums.BizType, msg []byte)
 	// 1. 检查业务类型
 	if !bizType.Check() {
 		m.logger.Warnf("[运营日志上报][oprLogMqHandler] biz_tye: [%s], invalid bizType", bizType)
-		err = nil
+		err = m.dlqSvc.Record(ctx, bizType, msg, dlqconsts.ReasonInvalidBizType, nil, fmt.Sprintf("invalid biz_type: %s", bizType))
 		return
 	}
 
@@ -81,7 +82,7 @@ func (m *oprLogMqHandler) commonHandle(bizType oprlogenums.BizType, msg []byte)
 	operation, invalidFields, err := jsc_opr_log.ValidateOprLogJSONSchema(msg, bizType)
 	if err != nil {
 		m.logger.Errorf("[运营日志上报][oprLogMqHandler] biz_tye: [%s], jsc_opr_log.ValidateOprLogJSONSchema(msg, bizType) err %v", bizType, err)
-		err = nil
+		err = m.dlqSvc.Record(ctx, bizType, msg, dlqconsts.ReasonSchemaError, nil, err.Error())
 		return
 	}
 
@@ -94,6 +95,9 @@ func (m *oprLogMqHandler) commonHandle(bizType oprlogenums.BizType, msg []byte)
 		}
 
 		m.logger.Errorf("[运营日志上报][oprLogMqHandler] biz_tye: [%s], operation: [%s], invalidFields: ( %s ), log content: ( %s )", bizType, operation, strings.Join(invalidFieldsPretty, " , "), string(msg))
+
+		// 写入死信, 写入失败时返回错误由mq重新投递
+		err = m.dlqSvc.Record(ctx, bizType, msg, dlqconsts.ReasonInvalidFields, invalidFields, "")
 		return
 	}
 
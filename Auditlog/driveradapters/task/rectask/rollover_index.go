@@ -0,0 +1,68 @@
+package rectask
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"AuditLog/common/helpers"
+	recinject "AuditLog/domain/service/inject/rec"
+	"AuditLog/infra/config"
+	"AuditLog/infra/config/mapping"
+	recdriveri "AuditLog/interfaces/driveradapter/rec"
+)
+
+// RolloverIndexTask 定时创建新的分区索引并切换写索引
+type RolloverIndexTask struct {
+	svc     recdriveri.IRecSvc
+	recConf *config.RecConf
+}
+
+func NewRolloverIndexTask() *RolloverIndexTask {
+	conf := config.GetConfig().Rec
+
+	svc := recinject.NewRecSvc()
+
+	return &RolloverIndexTask{
+		recConf: conf,
+		svc:     svc,
+	}
+}
+
+func (t *RolloverIndexTask) Run() {
+	if helpers.IsAaronLocalDev() {
+		return
+	}
+	logPrefix := "[rec_task][RolloverIndexTask]"
+
+	defer func() {
+		if _err := recover(); _err != nil {
+			log.Printf("%s: run panic recover, err:%v", logPrefix, _err)
+		}
+	}()
+
+	ctx := context.Background()
+
+	// 下一个分区索引会提前创建，执行间隔小于分区周期即可（多pod部署时由分布式锁保证只有一个pod执行）
+	duration := time.Second * time.Duration(t.recConf.RolloverIndexTaskIntervalSecond)
+
+	ticker := time.NewTicker(duration)
+
+	for range ticker.C {
+		log.Printf("%s: run start\n", logPrefix)
+
+		t.do(ctx)
+
+		log.Printf("%s: run end\n", logPrefix)
+	}
+}
+
+func (t *RolloverIndexTask) do(ctx context.Context) {
+	for index, m := range mapping.RecMappingMap {
+		err := t.svc.RolloverIndex(ctx, string(index), m)
+		if err != nil {
+			log.Printf("[rec_task][RolloverIndexTask] rollover index failed, index: %s, err: %v", index, err)
+			continue
+		}
+	}
+}
@@ -26,6 +26,28 @@ const (
 	ScopeStrategyNotFoundErr = 404062001
 	// ScopeStrategyConflictErr 日志查看范围策略已存在
 	ScopeStrategyConflictErr = 409062001
+	// AlertRuleNotFoundErr 告警规则不存在
+	AlertRuleNotFoundErr = 404062002
+	// AlertRuleConflictErr 告警规则名称已存在
+	AlertRuleConflictErr = 409062002
+	// DeadLetterNotFoundErr 运营日志死信不存在
+	DeadLetterNotFoundErr = 404062003
+	// LegalHoldNotFoundErr 法律保留不存在
+	LegalHoldNotFoundErr = 404062004
+	// LegalHoldConflictErr 法律保留名称已存在
+	LegalHoldConflictErr = 409062003
+	// WorkspaceNotFoundErr 调查工作区不存在
+	WorkspaceNotFoundErr = 404062005
+	// WorkspaceNotReadyErr 调查工作区未完成恢复
+	WorkspaceNotReadyErr = 409062004
+	// ReportSubscriptionNotFoundErr 报表订阅不存在
+	ReportSubscriptionNotFoundErr = 404062006
+	// ReportSubscriptionConflictErr 报表订阅名称已存在
+	ReportSubscriptionConflictErr = 409062005
+	// ReportRunNotFoundErr 报表执行记录不存在
+	ReportRunNotFoundErr = 404062007
+	// ReportFileUnavailableErr 报表文件未生成或已删除
+	ReportFileUnavailableErr = 409062006
 	// PasswordRequiredErr 密码为空
 	PasswordRequiredErr = 400062001
 	// PasswordInvalidErr 密码无效
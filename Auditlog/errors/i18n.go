@@ -157,6 +157,138 @@ var i18ns = I18nMap{
 			langcmp.En:   "",
 		},
 	},
+	AlertRuleNotFoundErr: {
+		Description: map[langcmp.Lang]string{
+			langcmp.ZhCN: "该告警规则已不存在。",
+			langcmp.ZhTW: "該告警規則已不存在。",
+			langcmp.En:   "The alert rule does not exist.",
+		},
+		Solution: map[langcmp.Lang]string{
+			langcmp.ZhCN: "",
+			langcmp.ZhTW: "",
+			langcmp.En:   "",
+		},
+	},
+	AlertRuleConflictErr: {
+		Description: map[langcmp.Lang]string{
+			langcmp.ZhCN: "该告警规则名称已存在。",
+			langcmp.ZhTW: "該告警規則名稱已存在。",
+			langcmp.En:   "An alert rule with this name already exists.",
+		},
+		Solution: map[langcmp.Lang]string{
+			langcmp.ZhCN: "",
+			langcmp.ZhTW: "",
+			langcmp.En:   "",
+		},
+	},
+	DeadLetterNotFoundErr: {
+		Description: map[langcmp.Lang]string{
+			langcmp.ZhCN: "该运营日志死信已不存在。",
+			langcmp.ZhTW: "該運營日誌死信已不存在。",
+			langcmp.En:   "The dead letter does not exist.",
+		},
+		Solution: map[langcmp.Lang]string{
+			langcmp.ZhCN: "",
+			langcmp.ZhTW: "",
+			langcmp.En:   "",
+		},
+	},
+	LegalHoldNotFoundErr: {
+		Description: map[langcmp.Lang]string{
+			langcmp.ZhCN: "该法律保留已不存在。",
+			langcmp.ZhTW: "該法律保留已不存在。",
+			langcmp.En:   "The legal hold does not exist.",
+		},
+		Solution: map[langcmp.Lang]string{
+			langcmp.ZhCN: "",
+			langcmp.ZhTW: "",
+			langcmp.En:   "",
+		},
+	},
+	LegalHoldConflictErr: {
+		Description: map[langcmp.Lang]string{
+			langcmp.ZhCN: "该法律保留名称已存在。",
+			langcmp.ZhTW: "該法律保留名稱已存在。",
+			langcmp.En:   "A legal hold with this name already exists.",
+		},
+		Solution: map[langcmp.Lang]string{
+			langcmp.ZhCN: "",
+			langcmp.ZhTW: "",
+			langcmp.En:   "",
+		},
+	},
+	WorkspaceNotFoundErr: {
+		Description: map[langcmp.Lang]string{
+			langcmp.ZhCN: "该调查工作区已不存在。",
+			langcmp.ZhTW: "該調查工作區已不存在。",
+			langcmp.En:   "The investigation workspace does not exist.",
+		},
+		Solution: map[langcmp.Lang]string{
+			langcmp.ZhCN: "",
+			langcmp.ZhTW: "",
+			langcmp.En:   "",
+		},
+	},
+	WorkspaceNotReadyErr: {
+		Description: map[langcmp.Lang]string{
+			langcmp.ZhCN: "该调查工作区尚未完成恢复或恢复失败。",
+			langcmp.ZhTW: "該調查工作區尚未完成恢復或恢復失敗。",
+			langcmp.En:   "The investigation workspace is still restoring or failed to restore.",
+		},
+		Solution: map[langcmp.Lang]string{
+			langcmp.ZhCN: "请等待恢复完成，或重新创建调查工作区。",
+			langcmp.ZhTW: "請等待恢復完成，或重新建立調查工作區。",
+			langcmp.En:   "Wait for the restoration to finish, or create the workspace again.",
+		},
+	},
+	ReportSubscriptionNotFoundErr: {
+		Description: map[langcmp.Lang]string{
+			langcmp.ZhCN: "该报表订阅已不存在。",
+			langcmp.ZhTW: "該報表訂閱已不存在。",
+			langcmp.En:   "The report subscription does not exist.",
+		},
+		Solution: map[langcmp.Lang]string{
+			langcmp.ZhCN: "",
+			langcmp.ZhTW: "",
+			langcmp.En:   "",
+		},
+	},
+	ReportSubscriptionConflictErr: {
+		Description: map[langcmp.Lang]string{
+			langcmp.ZhCN: "该报表名称已存在。",
+			langcmp.ZhTW: "該報表名稱已存在。",
+			langcmp.En:   "The report name already exists.",
+		},
+		Solution: map[langcmp.Lang]string{
+			langcmp.ZhCN: "请重新输入报表名称。",
+			langcmp.ZhTW: "請重新輸入報表名稱。",
+			langcmp.En:   "Please enter another report name.",
+		},
+	},
+	ReportRunNotFoundErr: {
+		Description: map[langcmp.Lang]string{
+			langcmp.ZhCN: "该报表执行记录已不存在。",
+			langcmp.ZhTW: "該報表執行記錄已不存在。",
+			langcmp.En:   "The report run does not exist.",
+		},
+		Solution: map[langcmp.Lang]string{
+			langcmp.ZhCN: "",
+			langcmp.ZhTW: "",
+			langcmp.En:   "",
+		},
+	},
+	ReportFileUnavailableErr: {
+		Description: map[langcmp.Lang]string{
+			langcmp.ZhCN: "该报表文件未生成或已过期删除。",
+			langcmp.ZhTW: "該報表檔案未產生或已過期刪除。",
+			langcmp.En:   "The report file was not generated or has expired.",
+		},
+		Solution: map[langcmp.Lang]string{
+			langcmp.ZhCN: "",
+			langcmp.ZhTW: "",
+			langcmp.En:   "",
+		},
+	},
 }
 
 func RegisterI18ns(i18nMap I18nMap) {
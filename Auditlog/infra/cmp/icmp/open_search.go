@@ -28,4 +28,14 @@ type IOpsCmp interface {
 	DeleteDocsByFieldRange(ctx context.Context, index string, field string, from, to interface{}) (err error)
 
 	Query(ctx context.Context, dslQuery string, index string) (*models.OSResp, error)
+
+	GetAliasIndices(ctx context.Context, alias string) (indices map[string]bool, err error)
+
+	UpdateAliases(ctx context.Context, actions []map[string]interface{}) (err error)
+
+	Reindex(ctx context.Context, source, dest string, query map[string]interface{}) (err error)
+
+	AddWriteBlock(ctx context.Context, index string) (err error)
+
+	CloneIndex(ctx context.Context, source, target string) (err error)
 }
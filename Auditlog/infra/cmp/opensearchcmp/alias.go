@@ -0,0 +1,138 @@
+package opensearchcmp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/opensearch-project/opensearch-go/opensearchapi"
+)
+
+// GetAliasIndices 获取别名下的索引，value为是否为写索引；别名不存在时返回空
+func (o *OpsCmp) GetAliasIndices(ctx context.Context, alias string) (indices map[string]bool, err error) {
+	ctx, span := o.arTrace.AddInternalTrace(ctx)
+	defer func() { o.arTrace.TelemetrySpanEnd(span, err) }()
+
+	indices = make(map[string]bool)
+
+	req := opensearchapi.IndicesGetAliasRequest{
+		Name: []string{alias},
+	}
+
+	res, err := req.Do(ctx, o.client)
+	if err != nil {
+		return nil, fmt.Errorf("error performing request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return
+	}
+
+	if res.IsError() {
+		return nil, fmt.Errorf("error getting alias: %s", res.String())
+	}
+
+	// {"index": {"aliases": {"alias": {"is_write_index": true}}}}
+	var body map[string]struct {
+		Aliases map[string]struct {
+			IsWriteIndex *bool `json:"is_write_index"`
+		} `json:"aliases"`
+	}
+
+	if err = json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	for index, v := range body {
+		a, ok := v.Aliases[alias]
+		if !ok {
+			continue
+		}
+
+		indices[index] = a.IsWriteIndex != nil && *a.IsWriteIndex
+	}
+
+	return
+}
+
+// UpdateAliases 原子地执行一组别名操作（add、remove、remove_index）
+func (o *OpsCmp) UpdateAliases(ctx context.Context, actions []map[string]interface{}) (err error) {
+	ctx, span := o.arTrace.AddInternalTrace(ctx)
+	defer func() { o.arTrace.TelemetrySpanEnd(span, err) }()
+
+	bodyBytes, err := json.Marshal(map[string]interface{}{
+		"actions": actions,
+	})
+	if err != nil {
+		return fmt.Errorf("error marshalling request body: %w", err)
+	}
+
+	req := opensearchapi.IndicesUpdateAliasesRequest{
+		Body: bytes.NewReader(bodyBytes),
+	}
+
+	res, err := req.Do(ctx, o.client)
+	if err != nil {
+		return fmt.Errorf("error performing request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error updating aliases: %s", res.String())
+	}
+
+	return nil
+}
+
+// Reindex 将source中满足query的文档复制到dest，dest中已存在的文档（_id相同）跳过
+func (o *OpsCmp) Reindex(ctx context.Context, source, dest string, query map[string]interface{}) (err error) {
+	ctx, span := o.arTrace.AddInternalTrace(ctx)
+	defer func() { o.arTrace.TelemetrySpanEnd(span, err) }()
+
+	src := map[string]interface{}{
+		"index": source,
+	}
+
+	if query != nil {
+		src["query"] = query
+	}
+
+	bodyBytes, err := json.Marshal(map[string]interface{}{
+		"conflicts": "proceed",
+		"source":    src,
+		"dest": map[string]interface{}{
+			"index":   dest,
+			"op_type": "create",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error marshalling request body: %w", err)
+	}
+
+	waitForCompletion := true
+	refresh := true
+
+	req := opensearchapi.ReindexRequest{
+		Body:              bytes.NewReader(bodyBytes),
+		WaitForCompletion: &waitForCompletion,
+		Refresh:           &refresh,
+	}
+
+	res, err := req.Do(ctx, o.client)
+	if err != nil {
+		return fmt.Errorf("error performing reindex: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error reindexing documents: %s", res.String())
+	}
+
+	return nil
+}
@@ -3,7 +3,10 @@ package opensearchcmp
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/opensearch-project/opensearch-go/opensearchapi"
 
@@ -11,6 +14,12 @@ import (
 	"AuditLog/common/utils"
 )
 
+// 迁移旧索引时会短暂禁止写入，被拒绝的文档等待后重试
+var (
+	bulkRetryTimes    = 30
+	bulkRetryInterval = time.Second
+)
+
 func (o *OpsCmp) BatchCreate(ctx context.Context, index string, docs []map[string]interface{}, isWithID bool) (err error) {
 	defer func() {
 		if err != nil {
@@ -18,9 +27,11 @@ func (o *OpsCmp) BatchCreate(ctx context.Context, index string, docs []map[strin
 		}
 	}()
 
-	var buf bytes.Buffer
+	lines := make([][]byte, 0, len(docs))
 
 	for i, item := range docs {
+		var buf bytes.Buffer
+
 		// 1. Write the meta data
 		if isWithID {
 			if id, ok := item["id"]; ok {
@@ -49,13 +60,42 @@ func (o *OpsCmp) BatchCreate(ctx context.Context, index string, docs []map[strin
 
 		buf.Write(dataJSON)
 		buf.WriteString("\n")
+
+		lines = append(lines, buf.Bytes())
 	}
 
+	// 3. 只重试被禁止写入或限流的文档
+	for retry := 0; ; retry++ {
+		lines, err = o.bulk(ctx, lines)
+		if err != nil || len(lines) == 0 {
+			return
+		}
+
+		if retry >= bulkRetryTimes {
+			err = fmt.Errorf("bulk request error: %d docs are rejected after %d retries", len(lines), retry)
+			return
+		}
+
+		o.logger.Warnf("[BatchCreate]: %d docs are rejected by %s, retry later", len(lines), index)
+
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			return
+		case <-time.After(bulkRetryInterval):
+		}
+	}
+}
+
+// bulk 执行批量写入，返回可重试的失败文档；存在不可重试的失败时返回错误
+func (o *OpsCmp) bulk(ctx context.Context, lines [][]byte) (retryLines [][]byte, err error) {
+	body := bytes.Join(lines, nil)
+
 	// Print the request body for debugging
-	o.logger.Debugf("[BatchCreateInterfaces]: Bulk Request Body:\n%s\n", buf.String())
+	o.logger.Debugf("[BatchCreateInterfaces]: Bulk Request Body:\n%s\n", string(body))
 
 	bulkRequest := opensearchapi.BulkRequest{
-		Body: bytes.NewReader(buf.Bytes()),
+		Body: bytes.NewReader(body),
 	}
 
 	res, err := bulkRequest.Do(ctx, o.client)
@@ -70,9 +110,48 @@ func (o *OpsCmp) BatchCreate(ctx context.Context, index string, docs []map[strin
 		return
 	}
 
+	// 单个文档写入失败时响应状态码仍为200，需检查每个文档的结果
+	var result bulkResult
+
+	if err = json.NewDecoder(res.Body).Decode(&result); err != nil {
+		err = fmt.Errorf("error parsing the bulk response body: %w", err)
+		return
+	}
+
+	if !result.Errors {
+		return
+	}
+
+	for i, item := range result.Items {
+		r := item["index"]
+		if r.Error == nil || i >= len(lines) {
+			continue
+		}
+
+		if r.Status == http.StatusTooManyRequests || r.Error.Type == "cluster_block_exception" {
+			retryLines = append(retryLines, lines[i])
+			continue
+		}
+
+		err = fmt.Errorf("bulk request error: %s: %s", r.Error.Type, r.Error.Reason)
+
+		return
+	}
+
 	return
 }
 
+type bulkResult struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
 // BatchCreateInterface If `batchSize` is 0 or negative, it calls the `BatchCreate` function with all docs at once; otherwise, it processes the docs in batches of the specified size.
 func (o *OpsCmp) BatchCreateInterface(ctx context.Context, index string, docs interface{}, isWithID bool, batchSize int) (err error) {
 	defer func() {
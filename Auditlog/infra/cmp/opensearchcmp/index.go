@@ -86,3 +86,51 @@ func (o *OpsCmp) DeleteIndex(ctx context.Context, index string) (err error) {
 
 	return
 }
+
+// AddWriteBlock 禁止写入索引，已禁止时不报错
+func (o *OpsCmp) AddWriteBlock(ctx context.Context, index string) (err error) {
+	ctx, span := o.arTrace.AddInternalTrace(ctx)
+	defer func() { o.arTrace.TelemetrySpanEnd(span, err) }()
+
+	req := opensearchapi.IndicesAddBlockRequest{
+		Index: []string{index},
+		Block: "write",
+	}
+
+	res, err := req.Do(ctx, o.client)
+	if err != nil {
+		return fmt.Errorf("error performing request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error adding write block: %s", res.String())
+	}
+
+	return nil
+}
+
+// CloneIndex 将禁止写入的source复制为新索引target，复制通过硬链接段文件完成，不重新索引文档
+func (o *OpsCmp) CloneIndex(ctx context.Context, source, target string) (err error) {
+	ctx, span := o.arTrace.AddInternalTrace(ctx)
+	defer func() { o.arTrace.TelemetrySpanEnd(span, err) }()
+
+	req := opensearchapi.IndicesCloneRequest{
+		Index:  source,
+		Target: target,
+	}
+
+	res, err := req.Do(ctx, o.client)
+	if err != nil {
+		return fmt.Errorf("error performing request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error cloning index: %s", res.String())
+	}
+
+	return nil
+}
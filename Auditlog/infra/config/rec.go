@@ -2,12 +2,17 @@ package config
 
 import (
 	"AuditLog/common"
+	recconsts "AuditLog/common/constants/recenums"
 )
 
 // RecConf 日志
 type RecConf struct {
 	SaveDays                       int `yaml:"save_days"`
 	RemoveOldLogTaskIntervalSecond int `yaml:"remove_old_log_task_interval_second"`
+
+	// IndexRolloverPeriod 索引分区周期，day或month
+	IndexRolloverPeriod             string `yaml:"index_rollover_period"`
+	RolloverIndexTaskIntervalSecond int    `yaml:"rollover_index_task_interval_second"`
 }
 
 func (l *RecConf) loadConf() {
@@ -15,4 +20,12 @@ func (l *RecConf) loadConf() {
 	if err != nil {
 		panic(err)
 	}
+
+	if l.IndexRolloverPeriod != recconsts.RolloverPeriodDay {
+		l.IndexRolloverPeriod = recconsts.RolloverPeriodMonth
+	}
+
+	if l.RolloverIndexTaskIntervalSecond <= 0 {
+		l.RolloverIndexTaskIntervalSecond = 3600
+	}
 }
@@ -12,6 +12,7 @@ package httpaccmock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -40,6 +41,20 @@ func (m *MockOpsHttpAcc) EXPECT() *MockOpsHttpAccMockRecorder {
 	return m.recorder
 }
 
+// AddWriteBlock mocks base method.
+func (m *MockOpsHttpAcc) AddWriteBlock(ctx context.Context, index string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddWriteBlock", ctx, index)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddWriteBlock indicates an expected call of AddWriteBlock.
+func (mr *MockOpsHttpAccMockRecorder) AddWriteBlock(ctx, index any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWriteBlock", reflect.TypeOf((*MockOpsHttpAcc)(nil).AddWriteBlock), ctx, index)
+}
+
 // BatchCreate mocks base method.
 func (m *MockOpsHttpAcc) BatchCreate(ctx context.Context, index string, data []map[string]any, isWithID bool) error {
 	m.ctrl.T.Helper()
@@ -68,6 +83,20 @@ func (mr *MockOpsHttpAccMockRecorder) BatchCreateInterface(ctx, index, docs, isW
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchCreateInterface", reflect.TypeOf((*MockOpsHttpAcc)(nil).BatchCreateInterface), ctx, index, docs, isWithID)
 }
 
+// CloneIndex mocks base method.
+func (m *MockOpsHttpAcc) CloneIndex(ctx context.Context, source, target string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloneIndex", ctx, source, target)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloneIndex indicates an expected call of CloneIndex.
+func (mr *MockOpsHttpAccMockRecorder) CloneIndex(ctx, source, target any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloneIndex", reflect.TypeOf((*MockOpsHttpAcc)(nil).CloneIndex), ctx, source, target)
+}
+
 // CreateIndex mocks base method.
 func (m *MockOpsHttpAcc) CreateIndex(ctx context.Context, index, mapping, setting string) error {
 	m.ctrl.T.Helper()
@@ -137,3 +166,76 @@ func (mr *MockOpsHttpAccMockRecorder) DeleteIndex(ctx, index any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIndex", reflect.TypeOf((*MockOpsHttpAcc)(nil).DeleteIndex), ctx, index)
 }
+
+// GetAliasIndices mocks base method.
+func (m *MockOpsHttpAcc) GetAliasIndices(ctx context.Context, alias string) (map[string]bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAliasIndices", ctx, alias)
+	ret0, _ := ret[0].(map[string]bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAliasIndices indicates an expected call of GetAliasIndices.
+func (mr *MockOpsHttpAccMockRecorder) GetAliasIndices(ctx, alias any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAliasIndices", reflect.TypeOf((*MockOpsHttpAcc)(nil).GetAliasIndices), ctx, alias)
+}
+
+// GetMinDate mocks base method.
+func (m *MockOpsHttpAcc) GetMinDate(ctx context.Context, index, field string) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMinDate", ctx, index, field)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMinDate indicates an expected call of GetMinDate.
+func (mr *MockOpsHttpAccMockRecorder) GetMinDate(ctx, index, field any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMinDate", reflect.TypeOf((*MockOpsHttpAcc)(nil).GetMinDate), ctx, index, field)
+}
+
+// IndexExists mocks base method.
+func (m *MockOpsHttpAcc) IndexExists(ctx context.Context, index string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IndexExists", ctx, index)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IndexExists indicates an expected call of IndexExists.
+func (mr *MockOpsHttpAccMockRecorder) IndexExists(ctx, index any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IndexExists", reflect.TypeOf((*MockOpsHttpAcc)(nil).IndexExists), ctx, index)
+}
+
+// Reindex mocks base method.
+func (m *MockOpsHttpAcc) Reindex(ctx context.Context, source, dest string, query map[string]any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reindex", ctx, source, dest, query)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reindex indicates an expected call of Reindex.
+func (mr *MockOpsHttpAccMockRecorder) Reindex(ctx, source, dest, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reindex", reflect.TypeOf((*MockOpsHttpAcc)(nil).Reindex), ctx, source, dest, query)
+}
+
+// UpdateAliases mocks base method.
+func (m *MockOpsHttpAcc) UpdateAliases(ctx context.Context, actions []map[string]any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAliases", ctx, actions)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAliases indicates an expected call of UpdateAliases.
+func (mr *MockOpsHttpAccMockRecorder) UpdateAliases(ctx, actions any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAliases", reflect.TypeOf((*MockOpsHttpAcc)(nil).UpdateAliases), ctx, actions)
+}
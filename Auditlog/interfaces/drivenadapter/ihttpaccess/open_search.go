@@ -1,6 +1,9 @@
 package ihttpaccess
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 //go:generate mockgen -source=./open_search.go -destination ./httpaccmock/open_search_mock.go -package httpaccmock
 type OpsHttpAcc interface {
@@ -18,4 +21,22 @@ type OpsHttpAcc interface {
 	DeleteDocByField(ctx context.Context, index string, field string, value interface{}) (err error)
 
 	DeleteDocsByFieldRange(ctx context.Context, index string, field string, from, to interface{}) (err error)
+
+	IndexExists(ctx context.Context, index string) (exists bool, err error)
+
+	// GetAliasIndices 获取别名下的索引，value为是否为写索引
+	GetAliasIndices(ctx context.Context, alias string) (indices map[string]bool, err error)
+
+	UpdateAliases(ctx context.Context, actions []map[string]interface{}) (err error)
+
+	Reindex(ctx context.Context, source, dest string, query map[string]interface{}) (err error)
+
+	// AddWriteBlock 禁止写入索引
+	AddWriteBlock(ctx context.Context, index string) (err error)
+
+	// CloneIndex 将禁止写入的source复制为新索引target
+	CloneIndex(ctx context.Context, source, target string) (err error)
+
+	// GetMinDate 获取索引中日期字段的最小值，索引为空时返回零值
+	GetMinDate(ctx context.Context, index string, field string) (minDate time.Time, err error)
 }
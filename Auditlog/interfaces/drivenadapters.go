@@ -6,8 +6,14 @@ import (
 	"net/http"
 
 	"AuditLog/models"
+	"AuditLog/models/alertmodels"
+	"AuditLog/models/dlqmodels"
+	"AuditLog/models/lcmodels"
+	"AuditLog/models/lhmodels"
 	"AuditLog/models/lsmodels"
 	"AuditLog/models/rcvo"
+	"AuditLog/models/rptmodels"
+	"AuditLog/models/wsmodels"
 	"AuditLog/tapi/sharemgnt"
 )
 
@@ -57,11 +63,32 @@ type AuditLogPub interface {
 
 type LogRepo interface {
 	New(log *models.AuditLog) (logID string, err error)
+	// NewTx 在调用方事务中写入日志, 由调用方提交或回滚
+	NewTx(log *models.AuditLog, tx *sql.Tx) (logID string, err error)
 	FindByCondition(offset, limit int, condition string, ids []string) (logs []*models.LogPO, err error)
 	FindCountByCondition(condition string) (count int, err error)
 	GetFirstLogTime() (timeMicro int64, err error)
-	ClearOutdatedLog(logID, date, batchSize, sleepTime int64) (err error)
+	ClearOutdatedLog(beginLogID, endLogID, date, batchSize, sleepTime int64) (err error)
 	GetLogCount() (count int64, err error)
+	// AggregateByCondition 按分组维度分组、按时间分桶统计日志数量, args 为条件中的绑定参数, group 为空时不分组, bucket 为0时不分桶
+	AggregateByCondition(condition string, args []any, group string, bucket, offset int64, limit int) (stats []*models.LogStatPO, err error)
+}
+
+type LogChainRepo interface {
+	// GetHead 获取日志类型的链头
+	GetHead(logType string) (head *lcmodels.LogChainHeadPO, err error)
+	// GetRecords 按链上序号升序获取 [beginSeq, endSeq] 范围内的记录
+	GetRecords(logType string, beginSeq, endSeq int64, limit int) (recs []*lcmodels.LogChainRecordPO, err error)
+	// GetRecordByLogID 根据日志id获取链上记录, 不存在时返回nil
+	GetRecordByLogID(logType string, logID string) (rec *lcmodels.LogChainRecordPO, err error)
+	// GetCheckpoints 获取 [beginSeq, endSeq] 范围内的检查点
+	GetCheckpoints(logType string, beginSeq, endSeq int64) (cps []*lcmodels.LogChainCheckpointPO, err error)
+	// CountUnchained 获取未入链(序号为0)的记录数量及其中最早的日志id
+	CountUnchained(logType string) (count int64, firstLogID string, err error)
+	// NewAnchor 保存转储锚点
+	NewAnchor(anchor *lcmodels.ChainAnchor) (err error)
+	// GetAnchors 获取与 [beginSeq, endSeq] 范围有交集的转储锚点
+	GetAnchors(logType string, beginSeq, endSeq int64) (anchors []*lcmodels.ChainAnchor, err error)
 }
 
 type HistoryRepo interface {
@@ -70,6 +97,9 @@ type HistoryRepo interface {
 	FindCountByCondition(condition string) (count int, err error)
 	GetHistoryLogsByType(logType int8) (logs []*models.HistoryPO, err error)
 	GetHistoryLogByID(id string) (log *models.HistoryPO, err error)
+	NewManifest(historyID string, manifest string) (err error)
+	// GetManifest 获取转存清单, 不存在时返回空字符串
+	GetManifest(historyID string) (manifest string, err error)
 }
 
 type DumpStrategyTx interface {
@@ -103,12 +133,79 @@ type LogScopeStrategyRepo interface {
 	GetStrategyByID(id int64) (res *lsmodels.ScopeStrategyPO, err error)
 }
 
+type AlertRuleRepo interface {
+	GetRulesByCondition(condition string, params []interface{}) (res []*alertmodels.AlertRulePO, err error)
+	CountRulesByCondition(condition string, params []interface{}) (count int64, err error)
+	// GetRuleByID 根据ID获取告警规则, 不存在时返回nil
+	GetRuleByID(id int64) (res *alertmodels.AlertRulePO, err error)
+	NewRule(rule *alertmodels.AlertRulePO) (err error)
+	UpdateRule(rule *alertmodels.AlertRulePO) (err error)
+	DeleteRule(id int64) (err error)
+}
+
+type LegalHoldRepo interface {
+	GetHoldsByCondition(condition string, params []interface{}) (res []*lhmodels.LegalHoldPO, err error)
+	CountHoldsByCondition(condition string, params []interface{}) (count int64, err error)
+	// GetHoldByID 根据ID获取法律保留, 不存在时返回nil
+	GetHoldByID(id int64) (res *lhmodels.LegalHoldPO, err error)
+	NewHold(hold *lhmodels.LegalHoldPO) (err error)
+	UpdateHold(hold *lhmodels.LegalHoldPO) (err error)
+	DeleteHold(id int64) (err error)
+}
+
+type InvestigationRepo interface {
+	GetWorkspacesByCondition(condition string, params []interface{}) (res []*wsmodels.WorkspacePO, err error)
+	CountWorkspacesByCondition(condition string, params []interface{}) (count int64, err error)
+	// GetWorkspaceByID 根据ID获取调查工作区, 不存在时返回nil
+	GetWorkspaceByID(id int64) (res *wsmodels.WorkspacePO, err error)
+	NewWorkspace(w *wsmodels.WorkspacePO) (err error)
+	UpdateWorkspaceStatus(id int64, status int, recordCount int64, errMsg string) (err error)
+	// DeleteWorkspace 删除调查工作区及其恢复的日志
+	DeleteWorkspace(id int64) (err error)
+	NewLogs(workspaceID int64, logs []*models.LogPO) (err error)
+	DeleteLogs(workspaceID int64) (err error)
+	FindByCondition(workspaceID int64, offset, limit int, condition string, ids []string) (logs []*models.LogPO, err error)
+	FindCountByCondition(workspaceID int64, condition string) (count int, err error)
+}
+
+type ReportSubscriptionRepo interface {
+	GetSubscriptionsByCondition(condition string, params []interface{}) (res []*rptmodels.SubscriptionPO, err error)
+	CountSubscriptionsByCondition(condition string, params []interface{}) (count int64, err error)
+	// GetSubscriptionByID 根据ID获取报表订阅, 不存在时返回nil
+	GetSubscriptionByID(id int64) (res *rptmodels.SubscriptionPO, err error)
+	NewSubscription(s *rptmodels.SubscriptionPO) (err error)
+	UpdateSubscription(s *rptmodels.SubscriptionPO) (err error)
+	// UpdateSchedule 更新报表订阅的下次执行时间和上次执行时间
+	UpdateSchedule(id int64, nextRunAt, lastRunAt int64) (err error)
+	// DeleteSubscription 删除报表订阅及其执行记录
+	DeleteSubscription(id int64) (err error)
+	GetRunsByCondition(condition string, params []interface{}) (res []*rptmodels.RunPO, err error)
+	CountRunsByCondition(condition string, params []interface{}) (count int64, err error)
+	// GetRunByID 根据ID获取报表执行记录, 不存在时返回nil
+	GetRunByID(id int64) (res *rptmodels.RunPO, err error)
+	NewRun(run *rptmodels.RunPO) (err error)
+	// FinishRun 更新报表执行结果
+	FinishRun(run *rptmodels.RunPO) (err error)
+	// SetRunFileExpired 标记报表文件已从对象存储删除
+	SetRunFileExpired(id int64) (err error)
+}
+
+type WebhookRepo interface {
+	// Post 以json格式推送消息到webhook地址
+	Post(ctx context.Context, url string, body interface{}) (err error)
+}
+
 type DBOutbox interface {
 	AddOutboxInfos(businessType string, messages []string, tx *sql.Tx) error
 	GetPushMessage(businessType string, tx *sql.Tx) (messageID int64, message string, err error)
 	DeleteOutboxInfoByID(messageID int64, tx *sql.Tx) error
 }
 
+type SyslogClient interface {
+	// Send 发送一条 syslog 消息, 连接断开时重新建连
+	Send(msg string) (err error)
+}
+
 type DLM interface {
 	// TryLock 获取锁，只尝试一次，失败不会阻塞
 	TryLock(key string) (success bool, err error)
@@ -127,3 +224,15 @@ type KcRepo interface {
 type PersonalConfigRepo interface {
 	GetModuleInfoByName(moduleName string) (res *models.ServiceModuleInfo, statusCode int, err error)
 }
+
+type OprLogDLQRepo interface {
+	GetByCondition(condition string, params []interface{}) (res []*dlqmodels.DeadLetterPO, err error)
+	CountByCondition(condition string, params []interface{}) (count int64, err error)
+	// GetByID 根据ID获取死信, 不存在时返回nil
+	GetByID(id int64) (res *dlqmodels.DeadLetterPO, err error)
+	New(letter *dlqmodels.DeadLetterPO) (err error)
+	// UpdateReplayResult 更新重放结果
+	UpdateReplayResult(letter *dlqmodels.DeadLetterPO) (err error)
+	// DeleteByCondition 根据条件删除死信, 返回删除的数量
+	DeleteByCondition(condition string, params []interface{}) (count int64, err error)
+}
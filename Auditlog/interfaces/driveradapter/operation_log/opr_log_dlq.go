@@ -0,0 +1,19 @@
+package oprlogdriveri
+
+import (
+	"context"
+
+	"AuditLog/common/enums/oprlogenums"
+	"AuditLog/models/dlqmodels"
+)
+
+//go:generate mockgen -package mock -source opr_log_dlq.go -destination ../mock/opr_log_dlq.go
+type IOprLogDLQSvc interface {
+	// Record 记录被拒绝的运营日志
+	Record(ctx context.Context, bizType oprlogenums.BizType, msg []byte, reason string, invalidFields []string, errMsg string) (err error)
+	GetDeadLetters(ctx context.Context, req *dlqmodels.GetDeadLettersReq) (res *dlqmodels.GetDeadLettersRes, err error)
+	GetDeadLetter(ctx context.Context, id int64) (res *dlqmodels.DeadLetterVO, err error)
+	Purge(ctx context.Context, req *dlqmodels.PurgeDeadLettersReq) (res *dlqmodels.PurgeDeadLettersRes, err error)
+	// Replay 重新校验并处理死信
+	Replay(ctx context.Context, req *dlqmodels.ReplayDeadLettersReq) (res *dlqmodels.ReplayDeadLettersRes, err error)
+}
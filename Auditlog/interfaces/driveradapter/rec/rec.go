@@ -8,6 +8,8 @@ import (
 type IRecSvc interface {
 	CreateByMapping(ctx context.Context) (err error)
 
+	RolloverIndex(ctx context.Context, index string, mapping string) (err error)
+
 	RemoveOldLog(ctx context.Context, index string, saveDays int) (err error)
 
 	RemoveNotUseOpensearchIndexOnce(ctx context.Context) (err error)
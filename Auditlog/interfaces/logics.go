@@ -5,8 +5,14 @@ import (
 	"database/sql"
 
 	"AuditLog/models"
+	"AuditLog/models/alertmodels"
+	"AuditLog/models/fwdmodels"
+	"AuditLog/models/lcmodels"
+	"AuditLog/models/lhmodels"
 	"AuditLog/models/lsmodels"
 	"AuditLog/models/rcvo"
+	"AuditLog/models/rptmodels"
+	"AuditLog/models/wsmodels"
 )
 
 //go:generate mockgen -package mock -source ../interfaces/logics.go -destination ../interfaces/mock/mock_logics.go
@@ -30,6 +36,8 @@ type ActiveLog interface {
 	GetActiveMetadata() (meta *rcvo.ReportMetadataRes, err error)
 	GetActiveDataList(ctx context.Context, logType string, req *rcvo.ReportGetDataListReq, userID string) (res *rcvo.ActiveReportListRes, err error)
 	GetActiveFieldValues(ctx context.Context, logType string, req *rcvo.ReportGetFieldValuesReq) (res *rcvo.ReportFieldValuesRes, err error)
+	// GetActiveStatistics 获取活跃日志统计, 按分组维度和时间分桶在数据库中聚合
+	GetActiveStatistics(ctx context.Context, logType string, req *rcvo.ReportGetStatisticsReq, userID string) (res *rcvo.ReportStatisticsRes, err error)
 }
 
 type HistoryLog interface {
@@ -46,6 +54,73 @@ type DumpLog interface {
 	InitDumpLog(ctx context.Context)
 }
 
+type LogChain interface {
+	// Verify 校验 [beginSeq, endSeq] 范围内的哈希链, 返回第一处断裂
+	Verify(ctx context.Context, logType string, beginSeq, endSeq int64) (res *lcmodels.VerifyRes, err error)
+}
+
+type LogForward interface {
+	// Forward 将审计日志写入 outbox, 由推送线程转发到 SIEM, 发送失败时定时重试
+	// tx 不为空时在调用方事务中写入 outbox, 调用方提交后调用 NotifyPushThread; tx 为空时单独写入
+	Forward(logType, logID string, log *models.AuditLog, tx *sql.Tx) (err error)
+	// NotifyPushThread 触发 outbox 推送线程
+	NotifyPushThread()
+	// GetMetrics 获取转发统计
+	GetMetrics() (metrics *fwdmodels.ForwardMetrics)
+}
+
+type AlertEngine interface {
+	// Evaluate 将事件加入队列, 由后台协程使用已启用的告警规则评估并推送告警
+	Evaluate(ctx context.Context, event *alertmodels.Event)
+	// InvalidateRules 规则变更后清除本地规则缓存
+	InvalidateRules()
+}
+
+type AlertRule interface {
+	GetRules(ctx context.Context, req *alertmodels.GetAlertRulesReq) (res *alertmodels.GetAlertRulesRes, err error)
+	GetRule(ctx context.Context, id int64) (res *alertmodels.AlertRuleVO, err error)
+	NewRule(ctx context.Context, req *alertmodels.AlertRuleVO) (id int64, err error)
+	UpdateRule(ctx context.Context, id int64, req *alertmodels.AlertRuleVO) (err error)
+	DeleteRule(ctx context.Context, id int64) (err error)
+}
+
+type LegalHold interface {
+	GetHolds(ctx context.Context, req *lhmodels.GetLegalHoldsReq) (res *lhmodels.GetLegalHoldsRes, err error)
+	GetHold(ctx context.Context, id int64) (res *lhmodels.LegalHoldVO, err error)
+	NewHold(ctx context.Context, req *lhmodels.LegalHoldVO) (id int64, err error)
+	UpdateHold(ctx context.Context, id int64, req *lhmodels.LegalHoldVO) (err error)
+	DeleteHold(ctx context.Context, id int64) (err error)
+	// GetMatchedHolds 获取任一日志类型在 [begin, end) 时间范围内生效的法律保留, end 为0表示不限
+	GetMatchedHolds(logTypes []string, begin, end int64) (holds []*lhmodels.LegalHoldVO, err error)
+}
+
+type Investigation interface {
+	GetWorkspaces(ctx context.Context, req *wsmodels.GetWorkspacesReq) (res *wsmodels.GetWorkspacesRes, err error)
+	GetWorkspace(ctx context.Context, id int64) (res *wsmodels.WorkspaceVO, err error)
+	// NewWorkspace 创建调查工作区, 历史日志文件在后台恢复
+	NewWorkspace(ctx context.Context, req *wsmodels.NewWorkspaceReq) (id int64, err error)
+	DeleteWorkspace(ctx context.Context, id int64) (err error)
+	// InitInvestigation 定时删除过期的调查工作区
+	InitInvestigation(ctx context.Context)
+	// CleanExpiredWorkspaces 删除已过期的调查工作区
+	CleanExpiredWorkspaces(ctx context.Context) (err error)
+}
+
+type ReportSubscription interface {
+	GetSubscriptions(ctx context.Context, req *rptmodels.GetSubscriptionsReq) (res *rptmodels.GetSubscriptionsRes, err error)
+	GetSubscription(ctx context.Context, id int64) (res *rptmodels.SubscriptionVO, err error)
+	NewSubscription(ctx context.Context, req *rptmodels.SubscriptionVO) (id int64, err error)
+	UpdateSubscription(ctx context.Context, id int64, req *rptmodels.SubscriptionVO) (err error)
+	DeleteSubscription(ctx context.Context, id int64) (err error)
+	GetRuns(ctx context.Context, id int64, req *rptmodels.GetRunsReq) (res *rptmodels.GetRunsRes, err error)
+	// GetRunDownloadInfo 获取报表文件的下载信息
+	GetRunDownloadInfo(ctx context.Context, id int64, runID int64) (res *models.OSSRequestInfo, err error)
+	// InitReportScheduler 定时执行到期的报表订阅, 多实例部署时仅持有锁的实例执行
+	InitReportScheduler(ctx context.Context)
+	// RunDueSubscriptions 执行到期的报表订阅, 并删除超过保留时长的报表文件
+	RunDueSubscriptions(ctx context.Context) (err error)
+}
+
 type LogStrategy interface {
 	GetDumpStrategy(ctx context.Context, fields []string) (res map[string]interface{}, err error)
 	SetDumpStrategy(ctx context.Context, req map[string]interface{}) (err error)
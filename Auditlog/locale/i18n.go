@@ -189,6 +189,131 @@ var i18ns = map[string]map[langcmp.Lang]string{
 		langcmp.ZhTW: "匯出歷史日誌檔案 <%s> 成功",
 		langcmp.En:   "Successfully export historical log <%s> successfully",
 	},
+	NewAlertRule: {
+		langcmp.ZhCN: "新建 告警规则“%s” 成功",
+		langcmp.ZhTW: "新建 告警規則「%s」 成功",
+		langcmp.En:   "Successfully created alert rule \"%s\"",
+	},
+	EditAlertRule: {
+		langcmp.ZhCN: "编辑 告警规则“%s” 成功",
+		langcmp.ZhTW: "編輯 告警規則「%s」 成功",
+		langcmp.En:   "Successfully edited alert rule \"%s\"",
+	},
+	DeleteAlertRule: {
+		langcmp.ZhCN: "删除 告警规则“%s” 成功",
+		langcmp.ZhTW: "刪除 告警規則「%s」 成功",
+		langcmp.En:   "Successfully deleted alert rule \"%s\"",
+	},
+	AlertRuleKind: {
+		langcmp.ZhCN: "规则类型",
+		langcmp.ZhTW: "規則類型",
+		langcmp.En:   "Rule Type",
+	},
+	AlertKindThreshold: {
+		langcmp.ZhCN: "阈值",
+		langcmp.ZhTW: "閾值",
+		langcmp.En:   "Threshold",
+	},
+	AlertKindOffHours: {
+		langcmp.ZhCN: "非工作时间",
+		langcmp.ZhTW: "非工作時間",
+		langcmp.En:   "Off Hours",
+	},
+	AlertTriggered: {
+		langcmp.ZhCN: "触发 告警规则“%s”",
+		langcmp.ZhTW: "觸發 告警規則「%s」",
+		langcmp.En:   "Alert rule \"%s\" triggered",
+	},
+	AlertThresholdExMsg: {
+		langcmp.ZhCN: "%[2]d 分钟内匹配 %[1]d 条日志",
+		langcmp.ZhTW: "%[2]d 分鐘內匹配 %[1]d 條日誌",
+		langcmp.En:   "%[1]d matching logs within %[2]d minutes",
+	},
+	AlertOffHoursExMsg: {
+		langcmp.ZhCN: "非工作时间的操作：%s",
+		langcmp.ZhTW: "非工作時間的操作：%s",
+		langcmp.En:   "Operation outside working hours: %s",
+	},
+	ReplayDeadLetter: {
+		langcmp.ZhCN: "重放 运营日志死信 成功",
+		langcmp.ZhTW: "重放 運營日誌死信 成功",
+		langcmp.En:   "Successfully replayed operation log dead letters",
+	},
+	ReplayDeadLetterExMsg: {
+		langcmp.ZhCN: "共 %d 条, 成功 %d 条, 失败 %d 条",
+		langcmp.ZhTW: "共 %d 條, 成功 %d 條, 失敗 %d 條",
+		langcmp.En:   "Total: %d, succeeded: %d, failed: %d",
+	},
+	PurgeDeadLetter: {
+		langcmp.ZhCN: "清除 运营日志死信 %d 条 成功",
+		langcmp.ZhTW: "清除 運營日誌死信 %d 條 成功",
+		langcmp.En:   "Successfully purged %d operation log dead letters",
+	},
+	NewLegalHold: {
+		langcmp.ZhCN: "新建 法律保留“%s” 成功",
+		langcmp.ZhTW: "新建 法律保留「%s」 成功",
+		langcmp.En:   "Successfully created legal hold \"%s\"",
+	},
+	EditLegalHold: {
+		langcmp.ZhCN: "编辑 法律保留“%s” 成功",
+		langcmp.ZhTW: "編輯 法律保留「%s」 成功",
+		langcmp.En:   "Successfully edited legal hold \"%s\"",
+	},
+	DeleteLegalHold: {
+		langcmp.ZhCN: "解除 法律保留“%s” 成功",
+		langcmp.ZhTW: "解除 法律保留「%s」 成功",
+		langcmp.En:   "Successfully released legal hold \"%s\"",
+	},
+	LegalHoldExMsg: {
+		langcmp.ZhCN: "原因：%s；日志类型：%s；时间范围：%s ~ %s；用户：%s；部门：%s",
+		langcmp.ZhTW: "原因：%s；日誌類型：%s；時間範圍：%s ~ %s；用戶：%s；部門：%s",
+		langcmp.En:   "Reason: %s; Log Type: %s; Time Range: %s ~ %s; Users: %s; Departments: %s",
+	},
+	LegalHoldAllLogs: {
+		langcmp.ZhCN: "所有日志",
+		langcmp.ZhTW: "所有日誌",
+		langcmp.En:   "All Logs",
+	},
+	LegalHoldNoLimit: {
+		langcmp.ZhCN: "不限",
+		langcmp.ZhTW: "不限",
+		langcmp.En:   "Unlimited",
+	},
+	NewWorkspace: {
+		langcmp.ZhCN: "新建 调查工作区“%s” 成功",
+		langcmp.ZhTW: "新建 調查工作區「%s」 成功",
+		langcmp.En:   "Successfully created investigation workspace \"%s\"",
+	},
+	DeleteWorkspace: {
+		langcmp.ZhCN: "删除 调查工作区“%s” 成功",
+		langcmp.ZhTW: "刪除 調查工作區「%s」 成功",
+		langcmp.En:   "Successfully deleted investigation workspace \"%s\"",
+	},
+	WorkspaceExMsg: {
+		langcmp.ZhCN: "日志类型：%s；历史日志：%s",
+		langcmp.ZhTW: "日誌類型：%s；歷史日誌：%s",
+		langcmp.En:   "Log Type: %s; History Logs: %s",
+	},
+	NewReportSubscription: {
+		langcmp.ZhCN: "新建 报表订阅“%s” 成功",
+		langcmp.ZhTW: "新建 報表訂閱「%s」 成功",
+		langcmp.En:   "Successfully created report subscription \"%s\"",
+	},
+	EditReportSubscription: {
+		langcmp.ZhCN: "编辑 报表订阅“%s” 成功",
+		langcmp.ZhTW: "編輯 報表訂閱「%s」 成功",
+		langcmp.En:   "Successfully edited report subscription \"%s\"",
+	},
+	DeleteReportSubscription: {
+		langcmp.ZhCN: "删除 报表订阅“%s” 成功",
+		langcmp.ZhTW: "刪除 報表訂閱「%s」 成功",
+		langcmp.En:   "Successfully deleted report subscription \"%s\"",
+	},
+	ReportSubscriptionExMsg: {
+		langcmp.ZhCN: "日志类型：%s；执行周期：%s；订阅者：%s",
+		langcmp.ZhTW: "日誌類型：%s；執行週期：%s；訂閱者：%s",
+		langcmp.En:   "Log Type: %s; Schedule: %s; Subscribers: %s",
+	},
 	LogDumpPeriod: {
 		langcmp.ZhCN: "转存周期",
 		langcmp.ZhTW: "轉存週期",
@@ -68,6 +68,51 @@ const (
 	ExportLogSuccess       string = "export_log_success"        // 导出日志成功
 )
 
+// 日志告警
+const (
+	NewAlertRule        string = "new_alert_rule"         // 新建告警规则日志
+	EditAlertRule       string = "edit_alert_rule"        // 编辑告警规则日志
+	DeleteAlertRule     string = "delete_alert_rule"      // 删除告警规则日志
+	AlertRuleKind       string = "alert_rule_kind"        // 规则类型
+	AlertKindThreshold  string = "alert_kind_threshold"   // 阈值规则
+	AlertKindOffHours   string = "alert_kind_off_hours"   // 非工作时间规则
+	AlertTriggered      string = "alert_triggered"        // 触发告警日志
+	AlertThresholdExMsg string = "alert_threshold_ex_msg" // 阈值告警附加信息
+	AlertOffHoursExMsg  string = "alert_off_hours_ex_msg" // 非工作时间告警附加信息
+)
+
+// 运营日志死信
+const (
+	ReplayDeadLetter      string = "replay_dead_letter"        // 重放运营日志死信日志
+	ReplayDeadLetterExMsg string = "replay_dead_letter_ex_msg" // 重放结果
+	PurgeDeadLetter       string = "purge_dead_letter"         // 清除运营日志死信日志
+)
+
+// 法律保留
+const (
+	NewLegalHold     string = "new_legal_hold"      // 新建法律保留日志
+	EditLegalHold    string = "edit_legal_hold"     // 编辑法律保留日志
+	DeleteLegalHold  string = "delete_legal_hold"   // 解除法律保留日志
+	LegalHoldExMsg   string = "legal_hold_ex_msg"   // 法律保留附加信息
+	LegalHoldAllLogs string = "legal_hold_all_logs" // 所有日志类型
+	LegalHoldNoLimit string = "legal_hold_no_limit" // 不限
+)
+
+// 调查工作区
+const (
+	NewWorkspace    string = "new_investigation_workspace"    // 新建调查工作区日志
+	DeleteWorkspace string = "delete_investigation_workspace" // 删除调查工作区日志
+	WorkspaceExMsg  string = "investigation_workspace_ex_msg" // 调查工作区附加信息
+)
+
+// 报表订阅
+const (
+	NewReportSubscription    string = "new_report_subscription"    // 新建报表订阅日志
+	EditReportSubscription   string = "edit_report_subscription"   // 编辑报表订阅日志
+	DeleteReportSubscription string = "delete_report_subscription" // 删除报表订阅日志
+	ReportSubscriptionExMsg  string = "report_subscription_ex_msg" // 报表订阅附加信息
+)
+
 var LogTypeMap = map[int]string{
 	0:  LogTypeOther,
 	10: LogTypeLogin,
@@ -85,3 +130,9 @@ var LogLevelMap = map[int]string{
 	1: RCLogLevelInfo,
 	2: RCLogLevelWarn,
 }
+
+// AlertKindMap 告警规则类型映射
+var AlertKindMap = map[string]string{
+	"threshold": AlertKindThreshold,
+	"off_hours": AlertKindOffHours,
+}
@@ -71,3 +71,53 @@ func GetRCLogMgntI18n(ctx context.Context, key int) string {
 func GetRCLogOpI18n(ctx context.Context, key int) string {
 	return getI18nWithDefaultByInt(ctx, key, conf.MapOperOperTypeLang)
 }
+
+// containsI18n 国际化信息中是否有与value相同的翻译
+func containsI18n(langs map[langcmp.Lang]string, value string) bool {
+	for _, v := range langs {
+		if v != "" && v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// parseI18nByInt 根据任意语言的国际化信息反查key
+func parseI18nByInt(value string, i18nMap map[int]map[langcmp.Lang]string) (key int, ok bool) {
+	for k, langs := range i18nMap {
+		if containsI18n(langs, value) {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+// ParseRCLogLevelI18n 根据任意语言的日志等级国际化信息获取日志等级
+func ParseRCLogLevelI18n(value string) (level int, ok bool) {
+	for k, key := range LogLevelMap {
+		if containsI18n(rcLevelI18n[key], value) {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+// ParseRCLogObjTypeI18n 根据任意语言的对象类型国际化信息获取对象类型
+func ParseRCLogObjTypeI18n(value string) (objType int, ok bool) {
+	return parseI18nByInt(value, conf.MapObjectTypeLang)
+}
+
+// ParseRCLogLoginI18n 根据任意语言的登录日志操作类型国际化信息获取操作类型
+func ParseRCLogLoginI18n(value string) (opType int, ok bool) {
+	return parseI18nByInt(value, conf.MapLoginOperTypeLang)
+}
+
+// ParseRCLogMgntI18n 根据任意语言的管理日志操作类型国际化信息获取操作类型
+func ParseRCLogMgntI18n(value string) (opType int, ok bool) {
+	return parseI18nByInt(value, conf.MapManageOperTypeLang)
+}
+
+// ParseRCLogOpI18n 根据任意语言的操作日志操作类型国际化信息获取操作类型
+func ParseRCLogOpI18n(value string) (opType int, ok bool) {
+	return parseI18nByInt(value, conf.MapOperOperTypeLang)
+}
@@ -99,6 +99,30 @@ func TestGetRCLogOpI18n(t *testing.T) {
 	assert.NotEmpty(t, result)
 }
 
+func TestParseRCLogI18n(t *testing.T) {
+	conf.InitJsonConf()
+
+	level, ok := ParseRCLogLevelI18n("Warning")
+	assert.True(t, ok)
+	assert.Equal(t, 2, level)
+
+	_, ok = ParseRCLogLevelI18n("")
+	assert.False(t, ok)
+
+	// 任意语言的翻译都能反查
+	for _, ctx := range []context.Context{createGinContextWithLang("zh-CN"), createGinContextWithLang("EN-US")} {
+		value := GetRCLogLoginI18n(ctx, 1)
+		opType, ok := ParseRCLogLoginI18n(value)
+		assert.True(t, ok)
+		assert.Equal(t, value, GetRCLogLoginI18n(ctx, opType))
+
+		value = GetRCLogMgntI18n(ctx, 1)
+		opType, ok = ParseRCLogMgntI18n(value)
+		assert.True(t, ok)
+		assert.Equal(t, value, GetRCLogMgntI18n(ctx, opType))
+	}
+}
+
 // 辅助函数：创建带有语言设置的 Gin Context
 func createGinContextWithLang(lang string) context.Context {
 	gin.SetMode(gin.TestMode)
@@ -6,9 +6,13 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"AuditLog/common"
 	"AuditLog/common/conf"
+	"AuditLog/common/constants/rclogconsts"
+	"AuditLog/common/constants/statconsts"
+	"AuditLog/common/constants/wsconsts"
 	"AuditLog/common/utils/rclogutils"
 	"AuditLog/errors"
 	"AuditLog/gocommon/api"
@@ -34,6 +38,7 @@ type ActiveLog struct {
 	userMgntRepo     interfaces.UserMgntRepo
 	shareMgntRepo    interfaces.ShareMgntRepo
 	docCenterRepo    interfaces.DocCenterRepo
+	wsRepo           interfaces.InvestigationRepo
 }
 
 func NewActiveLog() interfaces.ActiveLog {
@@ -48,6 +53,7 @@ func NewActiveLog() interfaces.ActiveLog {
 			userMgntRepo:     userMgntRepo,
 			shareMgntRepo:    shareMgntRepo,
 			docCenterRepo:    docCenterRepo,
+			wsRepo:           investigationRepo,
 		}
 	})
 
@@ -180,7 +186,12 @@ func (al *ActiveLog) GetActiveDataList(ctx context.Context, logType string, req
 		exUserIDs []string
 	)
 
-	if len(req.IDs) == 0 {
+	if req.WorkspaceID != 0 {
+		// 转存文件不含用户ID, 工作区的日志仅创建者可查看, 不按可查看范围过滤
+		if err = al.checkWorkspace(ctx, logType, req.WorkspaceID, userID); err != nil {
+			return nil, err
+		}
+	} else if len(req.IDs) == 0 {
 		inUserIDs, exUserIDs, err = al.getUserIds(ctx, logType, userID)
 		if err != nil {
 			return nil, err
@@ -195,12 +206,14 @@ func (al *ActiveLog) GetActiveDataList(ctx context.Context, logType string, req
 	// 获取日志信息
 	var logs []*models.LogPO
 
-	switch logType {
-	case common.Login:
+	switch {
+	case req.WorkspaceID != 0:
+		logs, err = al.wsRepo.FindByCondition(req.WorkspaceID, req.Offset, req.Limit, sqlStr, req.IDs)
+	case logType == common.Login:
 		logs, err = al.loginLogRepo.FindByCondition(req.Offset, req.Limit, sqlStr, req.IDs)
-	case common.Management:
+	case logType == common.Management:
 		logs, err = al.mgntLogRepo.FindByCondition(req.Offset, req.Limit, sqlStr, req.IDs)
-	case common.Operation:
+	case logType == common.Operation:
 		logs, err = al.operLogRepo.FindByCondition(req.Offset, req.Limit, sqlStr, req.IDs)
 	default:
 		return nil, fmt.Errorf("[GetActiveDataList]: invalid logType: %s", logType)
@@ -246,12 +259,14 @@ func (al *ActiveLog) GetActiveDataList(ctx context.Context, logType string, req
 	// 获取日志总数
 	nTotalCount := len(req.IDs)
 	if nTotalCount == 0 {
-		switch logType {
-		case common.Login:
+		switch {
+		case req.WorkspaceID != 0:
+			nTotalCount, err = al.wsRepo.FindCountByCondition(req.WorkspaceID, sqlStr)
+		case logType == common.Login:
 			nTotalCount, err = al.loginLogRepo.FindCountByCondition(sqlStr)
-		case common.Management:
+		case logType == common.Management:
 			nTotalCount, err = al.mgntLogRepo.FindCountByCondition(sqlStr)
-		case common.Operation:
+		case logType == common.Operation:
 			nTotalCount, err = al.operLogRepo.FindCountByCondition(sqlStr)
 		}
 
@@ -267,6 +282,177 @@ func (al *ActiveLog) GetActiveDataList(ctx context.Context, logType string, req
 	return
 }
 
+// GetActiveStatistics 获取活跃日志统计, 按可查看范围过滤后在数据库中聚合
+// 同时指定分组和时间分桶时, 先取数量最多的 TopN 个分组, 再统计这些分组在各时间分桶的数量
+func (al *ActiveLog) GetActiveStatistics(ctx context.Context, logType string, req *rcvo.ReportGetStatisticsReq, userID string) (res *rcvo.ReportStatisticsRes, err error) {
+	var tErr error
+	_, span := al.tracer.AddInternalTrace(ctx)
+	defer func() { al.tracer.TelemetrySpanEnd(span, tErr) }()
+
+	var repo interfaces.LogRepo
+	switch logType {
+	case common.Login:
+		repo = al.loginLogRepo
+	case common.Management:
+		repo = al.mgntLogRepo
+	case common.Operation:
+		repo = al.operLogRepo
+	default:
+		return nil, fmt.Errorf("[GetActiveStatistics]: invalid logType: %s", logType)
+	}
+
+	bucket, bucketCount, topN, err := al.checkStatisticsReq(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	inUserIDs, exUserIDs, err := al.getUserIds(ctx, logType, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlStr, _, err := rclogutils.BuildStatisticsCondition(req.Condition, inUserIDs, exUserIDs, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// 时间分桶按本地时区对齐
+	_, offsetSec := time.Now().Zone()
+	offset := int64(offsetSec) * 1000 * 1000
+
+	var stats []*models.LogStatPO
+	switch {
+	case req.GroupBy != "" && bucket > 0:
+		var top []*models.LogStatPO
+		if top, err = repo.AggregateByCondition(sqlStr, nil, req.GroupBy, 0, 0, topN); err != nil || len(top) == 0 {
+			break
+		}
+
+		keys := make([]string, 0, len(top))
+		for _, stat := range top {
+			keys = append(keys, stat.Key)
+		}
+		var args []any
+		if sqlStr, args, err = rclogutils.BuildStatisticsCondition(req.Condition, inUserIDs, exUserIDs, req.GroupBy, keys); err != nil {
+			break
+		}
+		stats, err = repo.AggregateByCondition(sqlStr, args, req.GroupBy, bucket, offset, len(keys)*int(bucketCount))
+	case req.GroupBy != "":
+		stats, err = repo.AggregateByCondition(sqlStr, nil, req.GroupBy, 0, 0, topN)
+	default:
+		stats, err = repo.AggregateByCondition(sqlStr, nil, "", bucket, offset, int(bucketCount))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]rcvo.ReportStatistic, 0, len(stats))
+	for _, stat := range stats {
+		entries = append(entries, rcvo.ReportStatistic{
+			Key:    stat.Key,
+			Name:   al.statisticName(ctx, logType, req.GroupBy, stat),
+			Bucket: stat.Bucket / 1000,
+			Count:  stat.Count,
+		})
+	}
+
+	return &rcvo.ReportStatisticsRes{Entries: entries}, nil
+}
+
+// checkStatisticsReq 校验统计参数, 返回分桶微秒数、分桶数量和分组数
+func (al *ActiveLog) checkStatisticsReq(ctx context.Context, req *rcvo.ReportGetStatisticsReq) (bucket int64, bucketCount int64, topN int, err error) {
+	badRequest := func(msg string) error {
+		return errors.NewCtx(ctx, errors.BadRequestErr, msg, nil)
+	}
+
+	if _, ok := statconsts.GroupExprMap[req.GroupBy]; req.GroupBy != "" && !ok {
+		return 0, 0, 0, badRequest(fmt.Sprintf("invalid group_by: %s", req.GroupBy))
+	}
+
+	topN = req.TopN
+	if topN == 0 {
+		topN = statconsts.DefaultTopN
+	}
+	if topN < 0 || topN > statconsts.MaxTopN {
+		return 0, 0, 0, badRequest(fmt.Sprintf("top_n must be between 1 and %d", statconsts.MaxTopN))
+	}
+
+	var begin, end float64
+	v, hasDate := req.Condition[rclogconsts.Date]
+	if hasDate {
+		dateRange, _ := v.([]interface{})
+		if len(dateRange) != 2 {
+			return 0, 0, 0, badRequest("invalid date condition")
+		}
+		var beginOK, endOK bool
+		begin, beginOK = dateRange[0].(float64)
+		end, endOK = dateRange[1].(float64)
+		if !beginOK || !endOK || end < begin {
+			return 0, 0, 0, badRequest("invalid date condition")
+		}
+	}
+
+	bucketCount = 1
+	if req.Interval == "" {
+		return
+	}
+
+	var ok bool
+	if bucket, ok = statconsts.IntervalMicroMap[req.Interval]; !ok {
+		return 0, 0, 0, badRequest(fmt.Sprintf("invalid interval: %s", req.Interval))
+	}
+	if !hasDate {
+		return 0, 0, 0, badRequest("interval requires a date condition")
+	}
+
+	// 首尾可能各跨一个分桶
+	bucketCount = (int64(end)-int64(begin))/bucket + 2
+	if bucketCount > statconsts.MaxBucketCount {
+		return 0, 0, 0, badRequest(fmt.Sprintf("too many buckets, at most %d", statconsts.MaxBucketCount))
+	}
+	return
+}
+
+// statisticName 获取分组值的显示名称
+func (al *ActiveLog) statisticName(ctx context.Context, logType string, groupBy string, stat *models.LogStatPO) string {
+	value, _ := strconv.Atoi(stat.Key)
+
+	switch groupBy {
+	case statconsts.GroupOpType:
+		switch logType {
+		case common.Management:
+			return locale.GetRCLogMgntI18n(ctx, value)
+		case common.Login:
+			return locale.GetRCLogLoginI18n(ctx, value)
+		case common.Operation:
+			return locale.GetRCLogOpI18n(ctx, value)
+		}
+	case statconsts.GroupLevel:
+		return locale.GetRCLogLevelI18n(ctx, locale.LogLevelMap[value])
+	case statconsts.GroupObjType:
+		return locale.GetRCLogObjTypeI18n(ctx, value)
+	case statconsts.GroupUser:
+		return stat.Name
+	}
+
+	return stat.Key
+}
+
+// checkWorkspace 校验调查工作区由用户创建、已完成恢复且未过期, 日志类型与查询一致
+func (al *ActiveLog) checkWorkspace(ctx context.Context, logType string, workspaceID int64, userID string) (err error) {
+	w, err := al.wsRepo.GetWorkspaceByID(workspaceID)
+	if err != nil {
+		return err
+	}
+	if w == nil || w.CreatedBy != userID || w.LogType != logType || w.ExpireAt < time.Now().UnixMicro() {
+		return workspaceNotFoundErr(ctx, workspaceID)
+	}
+	if w.Status != wsconsts.StatusReady {
+		return errors.NewCtx(ctx, errors.WorkspaceNotReadyErr, "Investigation workspace is not ready", nil)
+	}
+	return
+}
+
 // 获取活跃日志报表字段值
 func (al *ActiveLog) GetActiveFieldValues(ctx context.Context, logType string, req *rcvo.ReportGetFieldValuesReq) (res *rcvo.ReportFieldValuesRes, err error) {
 	var tErr error
@@ -4,16 +4,20 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 
 	"AuditLog/common"
+	"AuditLog/common/constants/wsconsts"
+	errs "AuditLog/errors"
 	"AuditLog/interfaces"
 	"AuditLog/interfaces/mock"
 	"AuditLog/models"
 	"AuditLog/models/rcvo"
+	"AuditLog/models/wsmodels"
 	"AuditLog/test/mock_log"
 	"AuditLog/test/mock_trace"
 )
@@ -141,6 +145,130 @@ func TestGetActiveDataList(t *testing.T) {
 	})
 }
 
+func TestGetActiveDataListFromWorkspace(t *testing.T) {
+	Convey("GetActiveDataList from investigation workspace", t, func() {
+		logger, tracer, logRepo, userMgnt, logScopeStrategy := newActiveDependencies(t)
+		wsRepo := mock.NewMockInvestigationRepo(gomock.NewController(t))
+		activeLog := &ActiveLog{
+			logger:           logger,
+			tracer:           tracer,
+			loginLogRepo:     logRepo,
+			userMgntRepo:     userMgnt,
+			logScopeStrategy: logScopeStrategy,
+			wsRepo:           wsRepo,
+		}
+
+		tracer.EXPECT().AddInternalTrace(gomock.Any()).AnyTimes()
+		tracer.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		ctx := context.Background()
+		userID := "111"
+		req := &rcvo.ReportGetDataListReq{
+			Offset:      0,
+			Limit:       10,
+			Condition:   map[string]any{},
+			OrderBy:     rcvo.OrderFields{},
+			WorkspaceID: 1,
+		}
+		workspace := &wsmodels.WorkspacePO{
+			ID:        1,
+			LogType:   common.Login,
+			Status:    wsconsts.StatusReady,
+			CreatedBy: userID,
+			ExpireAt:  time.Now().Add(time.Hour).UnixMicro(),
+		}
+
+		Convey("查询工作区中恢复的日志, 不按可查看范围过滤", func() {
+			wsRepo.EXPECT().GetWorkspaceByID(int64(1)).Return(workspace, nil)
+			wsRepo.EXPECT().FindByCondition(int64(1), req.Offset, req.Limit, gomock.Any(), req.IDs).
+				Return([]*models.LogPO{{LogID: "1211", UserName: "admin"}}, nil)
+			wsRepo.EXPECT().FindCountByCondition(int64(1), gomock.Any()).Return(1, nil)
+
+			res, err := activeLog.GetActiveDataList(ctx, common.Login, req, userID)
+			assert.NoError(t, err)
+			assert.Equal(t, "1211", res.Entries[0].ID)
+			assert.Equal(t, 1, res.TotalCount)
+		})
+
+		Convey("工作区未完成恢复", func() {
+			workspace.Status = wsconsts.StatusRestoring
+			wsRepo.EXPECT().GetWorkspaceByID(int64(1)).Return(workspace, nil)
+
+			_, err := activeLog.GetActiveDataList(ctx, common.Login, req, userID)
+			assert.Equal(t, errs.WorkspaceNotReadyErr, err.(*errs.ErrorResp).Code())
+		})
+
+		Convey("日志类型不一致", func() {
+			wsRepo.EXPECT().GetWorkspaceByID(int64(1)).Return(workspace, nil)
+
+			_, err := activeLog.GetActiveDataList(ctx, common.Operation, req, userID)
+			assert.Equal(t, errs.WorkspaceNotFoundErr, err.(*errs.ErrorResp).Code())
+		})
+	})
+}
+
+func TestGetActiveStatistics(t *testing.T) {
+	Convey("GetActiveStatistics", t, func() {
+		logger, tracer, logRepo, userMgnt, logScopeStrategy := newActiveDependencies(t)
+		activeLog := newActiveLog(logger, tracer, logRepo, userMgnt, logScopeStrategy)
+
+		tracer.EXPECT().AddInternalTrace(gomock.Any()).AnyTimes()
+		tracer.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+		logger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+		ctx := context.Background()
+		userID := "111"
+		userMgnt.EXPECT().GetUserInfoByID([]string{userID}).
+			Return([]models.User{{ID: userID, Roles: []string{common.SuperAdmin}}}, 200, nil).AnyTimes()
+
+		Convey("按用户统计数量最多的用户", func() {
+			logRepo.EXPECT().AggregateByCondition(gomock.Any(), nil, "user", int64(0), int64(0), 5).
+				Return([]*models.LogStatPO{{Key: "u1", Name: "用户1", Count: 3}}, nil)
+
+			res, err := activeLog.GetActiveStatistics(ctx, common.Login, &rcvo.ReportGetStatisticsReq{GroupBy: "user", TopN: 5}, userID)
+			assert.NoError(t, err)
+			assert.Equal(t, []rcvo.ReportStatistic{{Key: "u1", Name: "用户1", Count: 3}}, res.Entries)
+		})
+
+		Convey("按用户和天统计时先取数量最多的用户", func() {
+			day := int64(24 * 3600 * 1000 * 1000)
+			req := &rcvo.ReportGetStatisticsReq{
+				Condition: map[string]any{"date": []interface{}{float64(0), float64(2*day - 1)}},
+				GroupBy:   "user",
+				Interval:  "day",
+			}
+			gomock.InOrder(
+				logRepo.EXPECT().AggregateByCondition(gomock.Any(), nil, "user", int64(0), int64(0), 10).
+					Return([]*models.LogStatPO{{Key: "u1"}, {Key: "u2"}}, nil),
+				logRepo.EXPECT().AggregateByCondition(gomock.Any(), []any{"u1", "u2"}, "user", day, gomock.Any(), 2*3).
+					DoAndReturn(func(condition string, _ []any, _ string, _, _ int64, _ int) ([]*models.LogStatPO, error) {
+						assert.Contains(t, condition, "f_user_id IN (?,?)")
+						return []*models.LogStatPO{{Key: "u1", Name: "用户1", Bucket: day, Count: 2}}, nil
+					}),
+			)
+
+			res, err := activeLog.GetActiveStatistics(ctx, common.Login, req, userID)
+			assert.NoError(t, err)
+			assert.Equal(t, day/1000, res.Entries[0].Bucket)
+		})
+
+		Convey("参数错误", func() {
+			cases := []*rcvo.ReportGetStatisticsReq{
+				{GroupBy: "unknown"},
+				{TopN: 1000},
+				{Interval: "day"},
+				{Interval: "minute", Condition: map[string]any{"date": []interface{}{float64(0), float64(1)}}},
+				{Interval: "hour", Condition: map[string]any{"date": []interface{}{float64(0), float64(1) * 3600 * 1000 * 1000 * 1000}}},
+				{Condition: map[string]any{"date": []interface{}{"a", "b"}}},
+			}
+			for _, req := range cases {
+				_, err := activeLog.GetActiveStatistics(ctx, common.Login, req, userID)
+				assert.Equal(t, errs.BadRequestErr, err.(*errs.ErrorResp).Code())
+			}
+		})
+	})
+}
+
 func TestGetActiveMetadata(t *testing.T) {
 	Convey("GetActiveMetadata", t, func() {
 		logger, tracer, logRepo, userMgnt, logScopeStrategy := newActiveDependencies(t)
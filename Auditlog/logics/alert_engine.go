@@ -0,0 +1,312 @@
+package logics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
+
+	"AuditLog/common"
+	"AuditLog/common/constants/alertconsts"
+	"AuditLog/common/constants/logconsts"
+	"AuditLog/common/utils/alertutils"
+	"AuditLog/common/utils/dumplogutils"
+	"AuditLog/gocommon/api"
+	"AuditLog/interfaces"
+	"AuditLog/locale"
+	"AuditLog/models"
+	"AuditLog/models/alertmodels"
+)
+
+var (
+	aeOnce sync.Once
+	ae     *alertEngine
+)
+
+// 解析后的告警规则
+type compiledRule struct {
+	*alertmodels.AlertRulePO
+	conditions []*alertmodels.Condition
+	workDays   []int
+}
+
+type alertEngine struct {
+	logger      api.Logger
+	ruleRepo    interfaces.AlertRuleRepo
+	mgntLogRepo interfaces.LogRepo
+	dbPool      *sqlx.DB
+	webhook     interfaces.WebhookRepo
+	logForward  interfaces.LogForward
+	cache       redis.Cmdable
+	mqClient    api.MQClient
+	now         func() time.Time
+	events      chan *alertmodels.Event // 待评估的事件
+	dropped     atomic.Int64            // 队列满时丢弃的事件数
+
+	mu       sync.RWMutex
+	rules    map[string][]*compiledRule // 日志类型对应的已启用规则
+	loadedAt time.Time
+}
+
+func NewAlertEngine() interfaces.AlertEngine {
+	aeOnce.Do(func() {
+		ae = &alertEngine{
+			logger:      logger,
+			ruleRepo:    alertRuleRepo,
+			mgntLogRepo: mgntLogRepo,
+			dbPool:      dbPool,
+			webhook:     webhookRepo,
+			logForward:  NewLogForward(),
+			cache:       redisClient,
+			mqClient:    mqClient,
+			now:         time.Now,
+			events:      make(chan *alertmodels.Event, alertconsts.EventQueueSize),
+		}
+		for i := 0; i < alertconsts.WorkerCount; i++ {
+			go ae.startWorker()
+		}
+	})
+	return ae
+}
+
+// Evaluate 将事件加入评估队列, 由后台协程评估, 不阻塞日志接收
+// 队列满时丢弃事件
+func (e *alertEngine) Evaluate(ctx context.Context, event *alertmodels.Event) {
+	if event == nil {
+		return
+	}
+
+	select {
+	case e.events <- event:
+	default:
+		// 队列持续满时每丢弃一定数量的事件记录一次日志, 避免日志过多
+		if dropped := e.dropped.Add(1); dropped%alertconsts.DroppedEventLogInterval == 1 {
+			e.logger.Warnf("[AlertEngine] event queue is full, drop event of log %v, %v events dropped in total", event.LogID, dropped)
+		}
+	}
+}
+
+// startWorker 评估协程, 从队列中取出事件逐条评估
+func (e *alertEngine) startWorker() {
+	for event := range e.events {
+		e.evaluate(context.Background(), event)
+	}
+}
+
+// evaluate 使用已启用的告警规则评估事件
+// 阈值规则的滑动窗口计数和告警抑制保存在redis中, 多实例共享
+func (e *alertEngine) evaluate(ctx context.Context, event *alertmodels.Event) {
+	defer func() {
+		if err := recover(); err != nil {
+			e.logger.Errorf("[AlertEngine] evaluate event of log %v panic: %v", event.LogID, err)
+		}
+	}()
+
+	for _, rule := range e.getRules()[event.LogType] {
+		if !alertutils.MatchConditions(rule.conditions, event) {
+			continue
+		}
+
+		var groupValue string
+		if rule.GroupBy != "" {
+			groupValue = event.Fields[rule.GroupBy]
+		}
+
+		var count int64
+		switch rule.Kind {
+		case alertconsts.RuleThreshold:
+			var err error
+			count, err = e.countInWindow(ctx, rule, groupValue, event)
+			if err != nil {
+				e.logger.Warnf("[AlertEngine] count events of rule %v error: %v", rule.ID, err)
+				continue
+			}
+			if count < int64(rule.Threshold) {
+				continue
+			}
+		case alertconsts.RuleOffHours:
+			eventTime := e.now()
+			if event.Date > 0 {
+				eventTime = time.UnixMicro(event.Date)
+			}
+			offHours, err := alertutils.IsOffHours(eventTime, rule.WorkStart, rule.WorkEnd, rule.workDays)
+			if err != nil {
+				e.logger.Warnf("[AlertEngine] invalid working hours of rule %v: %v", rule.ID, err)
+				continue
+			}
+			if !offHours {
+				continue
+			}
+			count = 1
+		default:
+			continue
+		}
+
+		if !e.acquireCooldown(ctx, rule, groupValue) {
+			continue
+		}
+
+		alert := &alertmodels.Alert{
+			ID:            uuid.NewString(),
+			RuleID:        rule.ID,
+			RuleName:      rule.Name,
+			Kind:          rule.Kind,
+			LogType:       event.LogType,
+			GroupBy:       rule.GroupBy,
+			GroupValue:    groupValue,
+			Count:         count,
+			WindowMinutes: rule.WindowMinutes,
+			Level:         rule.Level,
+			TriggeredAt:   e.now().UnixMicro(),
+			Event:         event,
+		}
+		e.deliver(ctx, rule, alert)
+	}
+}
+
+// InvalidateRules 清除本地规则缓存, 下次评估时重新加载
+// 其他实例的缓存在 RuleCacheTTL 后过期
+func (e *alertEngine) InvalidateRules() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.loadedAt = time.Time{}
+}
+
+// getRules 获取已启用的规则, 缓存过期时重新加载, 加载失败时继续使用旧规则
+func (e *alertEngine) getRules() map[string][]*compiledRule {
+	e.mu.RLock()
+	if !e.loadedAt.IsZero() && e.now().Sub(e.loadedAt) < alertconsts.RuleCacheTTL {
+		defer e.mu.RUnlock()
+		return e.rules
+	}
+	e.mu.RUnlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.loadedAt.IsZero() && e.now().Sub(e.loadedAt) < alertconsts.RuleCacheTTL {
+		return e.rules
+	}
+	e.loadedAt = e.now()
+
+	pos, err := e.ruleRepo.GetRulesByCondition("WHERE f_enabled=?", []interface{}{true})
+	if err != nil {
+		e.logger.Errorf("[AlertEngine] load rules error: %v", err)
+		return e.rules
+	}
+
+	rules := make(map[string][]*compiledRule)
+	for _, po := range pos {
+		vo := ruleToVO(po)
+		rules[po.LogType] = append(rules[po.LogType], &compiledRule{
+			AlertRulePO: po,
+			conditions:  vo.Conditions,
+			workDays:    vo.WorkDays,
+		})
+	}
+	e.rules = rules
+	return e.rules
+}
+
+// countInWindow 将事件加入规则的滑动窗口, 返回窗口内的事件数
+func (e *alertEngine) countInWindow(ctx context.Context, rule *compiledRule, groupValue string, event *alertmodels.Event) (count int64, err error) {
+	key := alertconsts.WindowKeyPrefix + strconv.FormatInt(rule.ID, 10) + ":" + groupValue
+	window := time.Duration(rule.WindowMinutes) * time.Minute
+	now := e.now()
+
+	member := event.LogID
+	if member == "" {
+		member = uuid.NewString()
+	}
+
+	var card *redis.IntCmd
+	_, err = e.cache.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.Add(-window).UnixMicro(), 10))
+		pipe.ZAdd(ctx, key, &redis.Z{Score: float64(now.UnixMicro()), Member: member})
+		card = pipe.ZCard(ctx, key)
+		pipe.Expire(ctx, key, window)
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	return card.Val(), nil
+}
+
+// acquireCooldown 同一规则同一分组在抑制时间内只告警一次, redis异常时不抑制
+func (e *alertEngine) acquireCooldown(ctx context.Context, rule *compiledRule, groupValue string) bool {
+	if rule.CooldownMinutes <= 0 {
+		return true
+	}
+
+	key := alertconsts.CooldownKeyPrefix + strconv.FormatInt(rule.ID, 10) + ":" + groupValue
+	ok, err := e.cache.SetNX(ctx, key, e.now().UnixMicro(), time.Duration(rule.CooldownMinutes)*time.Minute).Result()
+	if err != nil {
+		e.logger.Warnf("[AlertEngine] set cooldown of rule %v error: %v", rule.ID, err)
+		return true
+	}
+	return ok
+}
+
+// deliver 推送告警到消息队列和webhook, 并记录管理日志
+func (e *alertEngine) deliver(ctx context.Context, rule *compiledRule, alert *alertmodels.Alert) {
+	msg, err := jsoniter.Marshal(alert)
+	if err != nil {
+		e.logger.Errorf("[AlertEngine] marshal alert error: %v", err)
+		return
+	}
+
+	if err = e.mqClient.Publish(alertconsts.AlertTopic, msg); err != nil {
+		e.logger.Warnf("[AlertEngine] publish alert of rule %v error: %v", rule.ID, err)
+	}
+
+	if rule.Webhook != "" {
+		if err = e.webhook.Post(ctx, rule.Webhook, alert); err != nil {
+			e.logger.Warnf("[AlertEngine] post alert of rule %v to webhook error: %v", rule.ID, err)
+		}
+	}
+
+	e.record(ctx, alert)
+}
+
+// record 告警记录为管理日志
+// 直接写入数据库而不经过日志接收流程, 避免告警日志再次触发告警
+func (e *alertEngine) record(ctx context.Context, alert *alertmodels.Alert) {
+	var exmsg string
+	switch alert.Kind {
+	case alertconsts.RuleThreshold:
+		exmsg = fmt.Sprintf(locale.GetI18nCtx(ctx, locale.AlertThresholdExMsg), alert.Count, alert.WindowMinutes)
+	case alertconsts.RuleOffHours:
+		exmsg = fmt.Sprintf(locale.GetI18nCtx(ctx, locale.AlertOffHoursExMsg), alert.Event.Fields[alertconsts.FieldMsg])
+	}
+	if alert.GroupBy != "" {
+		exmsg += fmt.Sprintf("; %s: %s", alert.GroupBy, alert.GroupValue)
+	}
+
+	userInfo := dumplogutils.GetSystemAccount(ctx)
+	log := &models.AuditLog{
+		UserID:         userInfo.ID,
+		UserName:       userInfo.DisplayName,
+		UserType:       common.InternalService,
+		Level:          alert.Level,
+		OpType:         logconsts.OpType.ManagementType.OTHER,
+		Date:           alert.TriggeredAt,
+		IP:             "127.0.0.1",
+		Msg:            fmt.Sprintf(locale.GetI18nCtx(ctx, locale.AlertTriggered), alert.RuleName),
+		Exmsg:          exmsg,
+		ObjID:          strconv.FormatInt(alert.RuleID, 10),
+		ObjName:        alert.RuleName,
+		AdditionalInfo: fmt.Sprintf("{\"alert_id\": \"%s\", \"log_id\": \"%s\"}", alert.ID, alert.Event.LogID),
+		OutBizID:       alert.ID,
+	}
+
+	if _, err := newForwardedLog(e.dbPool, e.mgntLogRepo, e.logForward, common.Management, log); err != nil {
+		e.logger.Errorf("[AlertEngine] record alert of rule %v error: %v", alert.RuleID, err)
+	}
+}
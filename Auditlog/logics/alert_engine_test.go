@@ -0,0 +1,203 @@
+package logics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/go-redis/redismock/v8"
+	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"AuditLog/common"
+	"AuditLog/common/constants/alertconsts"
+	"AuditLog/interfaces/mock"
+	"AuditLog/models"
+	"AuditLog/models/alertmodels"
+	"AuditLog/test/mock_log"
+	mock_msqclient "AuditLog/test/mock_mqclient"
+)
+
+func TestAlertEngine(t *testing.T) {
+	Convey("AlertEngine", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		logger := mock_log.NewMockLogger(ctrl)
+		ruleRepo := mock.NewMockAlertRuleRepo(ctrl)
+		mgntLog := mock.NewMockLogRepo(ctrl)
+		webhook := mock.NewMockWebhookRepo(ctrl)
+		logForward := mock.NewMockLogForward(ctrl)
+		mqClient := mock_msqclient.NewMockMQClient(ctrl)
+		redisClient, redisMock := redismock.NewClientMock()
+		dPool, sqlMock, err := sqlx.New()
+		assert.NoError(t, err)
+		defer dPool.Close()
+
+		// 2024-12-19 为周四
+		now := time.Date(2024, 12, 19, 10, 0, 0, 0, time.Local)
+		engine := &alertEngine{
+			logger:      logger,
+			ruleRepo:    ruleRepo,
+			mgntLogRepo: mgntLog,
+			dbPool:      dPool,
+			webhook:     webhook,
+			logForward:  logForward,
+			cache:       redisClient,
+			mqClient:    mqClient,
+			now:         func() time.Time { return now },
+		}
+
+		ctx := context.Background()
+		event := &alertmodels.Event{
+			LogType: common.Login,
+			LogID:   "100",
+			Date:    now.UnixMicro(),
+			Fields: map[string]string{
+				alertconsts.FieldUserID: "user1",
+				alertconsts.FieldLevel:  "2",
+				alertconsts.FieldMsg:    "登录失败",
+			},
+		}
+		thresholdRule := &alertmodels.AlertRulePO{
+			ID:              1,
+			Name:            "登录失败",
+			Enabled:         true,
+			LogType:         common.Login,
+			Kind:            alertconsts.RuleThreshold,
+			Conditions:      `[{"field":"level","operator":"eq","value":"2"}]`,
+			GroupBy:         alertconsts.FieldUserID,
+			Threshold:       3,
+			WindowMinutes:   5,
+			Level:           2,
+			Webhook:         "http://example.com/hook",
+			CooldownMinutes: 10,
+		}
+		windowKey := alertconsts.WindowKeyPrefix + "1:user1"
+		cooldownKey := alertconsts.CooldownKeyPrefix + "1:user1"
+		expectWindow := func(count int64) {
+			redisMock.ExpectTxPipeline()
+			redisMock.ExpectZRemRangeByScore(windowKey, "-inf", strconv.FormatInt(now.Add(-5*time.Minute).UnixMicro(), 10)).SetVal(0)
+			redisMock.ExpectZAdd(windowKey, &redis.Z{Score: float64(now.UnixMicro()), Member: "100"}).SetVal(1)
+			redisMock.ExpectZCard(windowKey).SetVal(count)
+			redisMock.ExpectExpire(windowKey, 5*time.Minute).SetVal(true)
+			redisMock.ExpectTxPipelineExec()
+		}
+
+		Convey("事件加入评估队列", func() {
+			engine.events = make(chan *alertmodels.Event, 1)
+			engine.Evaluate(ctx, event)
+			assert.Equal(t, event, <-engine.events)
+		})
+
+		Convey("评估队列已满时丢弃事件", func() {
+			engine.events = make(chan *alertmodels.Event, 1)
+			engine.events <- event
+			logger.EXPECT().Warnf(gomock.Any(), "100", int64(1))
+			engine.Evaluate(ctx, event)
+			assert.Equal(t, 1, len(engine.events))
+			assert.Equal(t, int64(1), engine.dropped.Load())
+
+			// 未达到日志间隔时只计数
+			engine.Evaluate(ctx, event)
+			assert.Equal(t, int64(2), engine.dropped.Load())
+		})
+
+		Convey("加载规则失败", func() {
+			ruleRepo.EXPECT().GetRulesByCondition(gomock.Any(), gomock.Any()).Return(nil, errors.New("db error"))
+			logger.EXPECT().Errorf(gomock.Any(), gomock.Any())
+			engine.evaluate(ctx, event)
+			assert.NoError(t, redisMock.ExpectationsWereMet())
+		})
+
+		Convey("规则缓存", func() {
+			ruleRepo.EXPECT().GetRulesByCondition("WHERE f_enabled=?", []interface{}{true}).Return([]*alertmodels.AlertRulePO{}, nil).Times(2)
+			engine.evaluate(ctx, event)
+			engine.evaluate(ctx, event)
+			engine.InvalidateRules()
+			engine.evaluate(ctx, event)
+		})
+
+		Convey("条件不匹配", func() {
+			ruleRepo.EXPECT().GetRulesByCondition(gomock.Any(), gomock.Any()).Return([]*alertmodels.AlertRulePO{thresholdRule}, nil)
+			event.Fields[alertconsts.FieldLevel] = "1"
+			engine.evaluate(ctx, event)
+			assert.NoError(t, redisMock.ExpectationsWereMet())
+		})
+
+		Convey("阈值规则未达到阈值", func() {
+			ruleRepo.EXPECT().GetRulesByCondition(gomock.Any(), gomock.Any()).Return([]*alertmodels.AlertRulePO{thresholdRule}, nil)
+			expectWindow(2)
+			engine.evaluate(ctx, event)
+			assert.NoError(t, redisMock.ExpectationsWereMet())
+		})
+
+		Convey("阈值规则触发告警", func() {
+			ruleRepo.EXPECT().GetRulesByCondition(gomock.Any(), gomock.Any()).Return([]*alertmodels.AlertRulePO{thresholdRule}, nil)
+			expectWindow(3)
+			redisMock.ExpectSetNX(cooldownKey, now.UnixMicro(), 10*time.Minute).SetVal(true)
+
+			mqClient.EXPECT().Publish(alertconsts.AlertTopic, gomock.Any()).Return(nil)
+			webhook.EXPECT().Post(gomock.Any(), "http://example.com/hook", gomock.Any()).Return(errors.New("timeout"))
+			logger.EXPECT().Warnf(gomock.Any(), gomock.Any())
+			sqlMock.ExpectBegin()
+			mgntLog.EXPECT().NewTx(gomock.Any(), gomock.Any()).DoAndReturn(func(log *models.AuditLog, _ *sql.Tx) (string, error) {
+				assert.Equal(t, common.InternalService, log.UserType)
+				assert.Equal(t, 2, log.Level)
+				assert.Equal(t, "1", log.ObjID)
+				return "200", nil
+			})
+			logForward.EXPECT().Forward(common.Management, "200", gomock.Any(), gomock.Any()).Return(nil)
+			sqlMock.ExpectCommit()
+			logForward.EXPECT().NotifyPushThread()
+
+			engine.evaluate(ctx, event)
+			assert.NoError(t, redisMock.ExpectationsWereMet())
+		})
+
+		Convey("告警抑制中", func() {
+			ruleRepo.EXPECT().GetRulesByCondition(gomock.Any(), gomock.Any()).Return([]*alertmodels.AlertRulePO{thresholdRule}, nil)
+			expectWindow(5)
+			redisMock.ExpectSetNX(cooldownKey, now.UnixMicro(), 10*time.Minute).SetVal(false)
+			engine.evaluate(ctx, event)
+			assert.NoError(t, redisMock.ExpectationsWereMet())
+		})
+
+		Convey("非工作时间规则", func() {
+			offHoursRule := &alertmodels.AlertRulePO{
+				ID:        2,
+				Name:      "非工作时间登录",
+				Enabled:   true,
+				LogType:   common.Login,
+				Kind:      alertconsts.RuleOffHours,
+				WorkStart: "09:00",
+				WorkEnd:   "18:00",
+				WorkDays:  "1,2,3,4,5",
+				Level:     1,
+			}
+			ruleRepo.EXPECT().GetRulesByCondition(gomock.Any(), gomock.Any()).Return([]*alertmodels.AlertRulePO{offHoursRule}, nil)
+
+			Convey("工作时间内不告警", func() {
+				engine.evaluate(ctx, event)
+			})
+
+			Convey("非工作时间告警", func() {
+				event.Date = now.Add(12 * time.Hour).UnixMicro()
+
+				mqClient.EXPECT().Publish(alertconsts.AlertTopic, gomock.Any()).Return(nil)
+				sqlMock.ExpectBegin()
+				mgntLog.EXPECT().NewTx(gomock.Any(), gomock.Any()).Return("201", nil)
+				logForward.EXPECT().Forward(common.Management, "201", gomock.Any(), gomock.Any()).Return(errors.New("db error"))
+				sqlMock.ExpectRollback()
+				logger.EXPECT().Errorf(gomock.Any(), gomock.Any(), gomock.Any())
+
+				engine.evaluate(ctx, event)
+			})
+		})
+	})
+}
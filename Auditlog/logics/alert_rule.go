@@ -0,0 +1,355 @@
+package logics
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	jsoniter "github.com/json-iterator/go"
+
+	"AuditLog/common"
+	"AuditLog/common/constants/alertconsts"
+	"AuditLog/common/constants/logconsts"
+	"AuditLog/common/enums/oprlogenums"
+	"AuditLog/common/utils/alertutils"
+	"AuditLog/errors"
+	"AuditLog/gocommon/api"
+	"AuditLog/infra"
+	"AuditLog/interfaces"
+	"AuditLog/locale"
+	"AuditLog/models"
+	"AuditLog/models/alertmodels"
+)
+
+var (
+	arOnce sync.Once
+	ar     *alertRule
+)
+
+type alertRule struct {
+	logger      api.Logger
+	ruleRepo    interfaces.AlertRuleRepo
+	logMgnt     interfaces.LogMgnt
+	alertEngine interfaces.AlertEngine
+}
+
+func NewAlertRule() interfaces.AlertRule {
+	arOnce.Do(func() {
+		ar = &alertRule{
+			logger:      logger,
+			ruleRepo:    alertRuleRepo,
+			logMgnt:     NewLogMgnt(),
+			alertEngine: NewAlertEngine(),
+		}
+	})
+	return ar
+}
+
+func (a *alertRule) GetRules(ctx context.Context, req *alertmodels.GetAlertRulesReq) (res *alertmodels.GetAlertRulesRes, err error) {
+	var condition string
+	params := []interface{}{}
+	if req.LogType != "" {
+		condition = "WHERE f_log_type=?"
+		params = append(params, req.LogType)
+	}
+
+	count, err := a.ruleRepo.CountRulesByCondition(condition, params)
+	if err != nil {
+		return nil, fmt.Errorf("[GetAlertRules] count rules failed: %w", err)
+	}
+
+	condition += " ORDER BY f_created_at DESC"
+	if req.Limit > 0 {
+		condition += " LIMIT ? OFFSET ?"
+		params = append(params, req.Limit, req.Offset)
+	}
+
+	rules, err := a.ruleRepo.GetRulesByCondition(condition, params)
+	if err != nil {
+		return nil, fmt.Errorf("[GetAlertRules] get rules failed: %w", err)
+	}
+
+	res = &alertmodels.GetAlertRulesRes{
+		Entries:    make([]*alertmodels.AlertRuleVO, 0, len(rules)),
+		TotalCount: count,
+	}
+	for _, rule := range rules {
+		res.Entries = append(res.Entries, ruleToVO(rule))
+	}
+	return
+}
+
+func (a *alertRule) GetRule(ctx context.Context, id int64) (res *alertmodels.AlertRuleVO, err error) {
+	rule, err := a.ruleRepo.GetRuleByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, a.notFoundErr(ctx, id)
+	}
+	return ruleToVO(rule), nil
+}
+
+func (a *alertRule) NewRule(ctx context.Context, req *alertmodels.AlertRuleVO) (id int64, err error) {
+	if err = a.validate(ctx, req); err != nil {
+		return 0, err
+	}
+
+	existing, err := a.ruleRepo.GetRulesByCondition("WHERE f_name=?", []interface{}{req.Name})
+	if err != nil {
+		return 0, err
+	}
+	if len(existing) > 0 {
+		return 0, errors.NewCtx(ctx, errors.AlertRuleConflictErr, "Alert rule already exists", nil)
+	}
+
+	uid, err := infra.GetUniqueID()
+	if err != nil {
+		a.logger.Errorf("new sonyflake id error: %v", err)
+		return 0, err
+	}
+
+	visitor := ctx.Value(common.VisitorKey).(*models.Visitor)
+	rule, err := voToRule(req)
+	if err != nil {
+		return 0, err
+	}
+	rule.ID = int64(uid)
+	rule.CreatedBy = visitor.ID
+	rule.CreatedAt = time.Now().UnixMicro()
+	if err = a.ruleRepo.NewRule(rule); err != nil {
+		return 0, err
+	}
+	a.alertEngine.InvalidateRules()
+
+	go a.autilog(
+		ctx,
+		req,
+		logconsts.LogLevel.INFO,
+		logconsts.OpType.ManagementType.CREATE,
+		locale.NewAlertRule,
+	)
+
+	return int64(uid), nil
+}
+
+func (a *alertRule) UpdateRule(ctx context.Context, id int64, req *alertmodels.AlertRuleVO) (err error) {
+	if err = a.validate(ctx, req); err != nil {
+		return err
+	}
+
+	checked, err := a.ruleRepo.GetRuleByID(id)
+	if err != nil {
+		return err
+	}
+	if checked == nil {
+		return a.notFoundErr(ctx, id)
+	}
+
+	existing, err := a.ruleRepo.GetRulesByCondition("WHERE f_name=?", []interface{}{req.Name})
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 && existing[0].ID != id {
+		return errors.NewCtx(ctx, errors.AlertRuleConflictErr, "Alert rule already exists", nil)
+	}
+
+	visitor := ctx.Value(common.VisitorKey).(*models.Visitor)
+	rule, err := voToRule(req)
+	if err != nil {
+		return err
+	}
+	rule.ID = id
+	rule.UpdatedBy = visitor.ID
+	rule.UpdatedAt = time.Now().UnixMicro()
+	if err = a.ruleRepo.UpdateRule(rule); err != nil {
+		return err
+	}
+	a.alertEngine.InvalidateRules()
+
+	go a.autilog(
+		ctx,
+		req,
+		logconsts.LogLevel.INFO,
+		logconsts.OpType.ManagementType.EDIT,
+		locale.EditAlertRule,
+	)
+
+	return
+}
+
+func (a *alertRule) DeleteRule(ctx context.Context, id int64) (err error) {
+	rule, err := a.ruleRepo.GetRuleByID(id)
+	if err != nil {
+		return err
+	}
+	if rule == nil {
+		return
+	}
+	if err = a.ruleRepo.DeleteRule(id); err != nil {
+		return err
+	}
+	a.alertEngine.InvalidateRules()
+
+	go a.autilog(
+		ctx,
+		ruleToVO(rule),
+		logconsts.LogLevel.WARN,
+		logconsts.OpType.ManagementType.DELETE,
+		locale.DeleteAlertRule,
+	)
+
+	return
+}
+
+// validate 校验json schema无法覆盖的规则参数, 并填充默认值
+func (a *alertRule) validate(ctx context.Context, req *alertmodels.AlertRuleVO) (err error) {
+	badRequest := func(msg string) error {
+		return errors.NewCtx(ctx, errors.BadRequestErr, msg, nil)
+	}
+
+	if !slices.Contains(common.AllLogType, req.LogType) && !oprlogenums.BizType(req.LogType).Check() {
+		return badRequest(fmt.Sprintf("invalid log_type: %s", req.LogType))
+	}
+
+	for _, cond := range req.Conditions {
+		if cond.Operator == alertconsts.OpIn && len(cond.Values) == 0 {
+			return badRequest(fmt.Sprintf("values is required when operator of %s is in", cond.Field))
+		}
+	}
+
+	if req.Webhook != "" {
+		if err = alertutils.CheckWebhookURL(req.Webhook); err != nil {
+			return badRequest(err.Error())
+		}
+	}
+
+	switch req.Kind {
+	case alertconsts.RuleThreshold:
+		if req.Threshold < 1 || req.WindowMinutes < 1 || req.WindowMinutes > alertconsts.MaxWindowMinutes {
+			return badRequest("threshold and window_minutes are required for threshold rule")
+		}
+	case alertconsts.RuleOffHours:
+		if _, err = alertutils.ParseClock(req.WorkStart); err != nil {
+			return badRequest(err.Error())
+		}
+		if _, err = alertutils.ParseClock(req.WorkEnd); err != nil {
+			return badRequest(err.Error())
+		}
+	default:
+		return badRequest(fmt.Sprintf("invalid kind: %s", req.Kind))
+	}
+
+	if req.Level == 0 {
+		req.Level = logconsts.LogLevel.WARN
+	}
+	if req.CooldownMinutes == 0 {
+		req.CooldownMinutes = alertconsts.DefaultCooldownMinutes
+	}
+	return nil
+}
+
+func (a *alertRule) notFoundErr(ctx context.Context, id int64) error {
+	return errors.NewCtx(
+		ctx,
+		errors.AlertRuleNotFoundErr,
+		"Alert rule not found",
+		map[string]interface{}{
+			"id": []int64{id},
+		},
+	)
+}
+
+// 记录审计日志
+func (a *alertRule) autilog(ctx context.Context, rule *alertmodels.AlertRuleVO, level int, opType int, opKey string) {
+	visitor := ctx.Value(common.VisitorKey).(*models.Visitor)
+	err := a.logMgnt.SendLog(&models.SendLogVo{
+		LogType:  common.Management,
+		Language: "",
+		LogContent: &models.AuditLog{
+			UserID:   visitor.ID,
+			UserName: visitor.Name,
+			UserType: common.AuthenticatedUser,
+			Level:    level,
+			OpType:   opType,
+			Date:     time.Now().UnixMicro(),
+			IP:       visitor.IP,
+			Mac:      visitor.Mac,
+			Msg:      fmt.Sprintf(locale.GetI18nCtx(ctx, opKey), rule.Name),
+			Exmsg: fmt.Sprintf(
+				locale.GetI18nCtx(ctx, locale.AlertRuleKind)+": %s; "+
+					locale.GetI18nCtx(ctx, locale.LogType)+": %s",
+				locale.GetI18nCtx(ctx, locale.AlertKindMap[rule.Kind]),
+				rule.LogType,
+			),
+			UserAgent: visitor.AgentType,
+			OutBizID:  uuid.NewString(),
+		},
+	})
+	if err != nil {
+		a.logger.Warnf("[AlertRule] send log error: %v", err)
+	}
+}
+
+func voToRule(vo *alertmodels.AlertRuleVO) (rule *alertmodels.AlertRulePO, err error) {
+	conditions := vo.Conditions
+	if conditions == nil {
+		conditions = []*alertmodels.Condition{}
+	}
+	buf, err := jsoniter.MarshalToString(conditions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &alertmodels.AlertRulePO{
+		Name:            vo.Name,
+		Description:     vo.Description,
+		Enabled:         vo.Enabled,
+		LogType:         vo.LogType,
+		Kind:            vo.Kind,
+		Conditions:      buf,
+		GroupBy:         vo.GroupBy,
+		Threshold:       vo.Threshold,
+		WindowMinutes:   vo.WindowMinutes,
+		WorkStart:       vo.WorkStart,
+		WorkEnd:         vo.WorkEnd,
+		WorkDays:        alertutils.FormatWorkDays(vo.WorkDays),
+		Level:           vo.Level,
+		Webhook:         vo.Webhook,
+		CooldownMinutes: vo.CooldownMinutes,
+	}, nil
+}
+
+func ruleToVO(rule *alertmodels.AlertRulePO) *alertmodels.AlertRuleVO {
+	conditions := []*alertmodels.Condition{}
+	if rule.Conditions != "" {
+		// 规则入库前已校验, 解析失败时按无条件处理
+		_ = jsoniter.UnmarshalFromString(rule.Conditions, &conditions)
+	}
+
+	return &alertmodels.AlertRuleVO{
+		ID:              rule.ID,
+		Name:            rule.Name,
+		Description:     rule.Description,
+		Enabled:         rule.Enabled,
+		LogType:         rule.LogType,
+		Kind:            rule.Kind,
+		Conditions:      conditions,
+		GroupBy:         rule.GroupBy,
+		Threshold:       rule.Threshold,
+		WindowMinutes:   rule.WindowMinutes,
+		WorkStart:       rule.WorkStart,
+		WorkEnd:         rule.WorkEnd,
+		WorkDays:        alertutils.ParseWorkDays(rule.WorkDays),
+		Level:           rule.Level,
+		Webhook:         rule.Webhook,
+		CooldownMinutes: rule.CooldownMinutes,
+		CreatedAt:       rule.CreatedAt,
+		CreatedBy:       rule.CreatedBy,
+		UpdatedAt:       rule.UpdatedAt,
+		UpdatedBy:       rule.UpdatedBy,
+	}
+}
@@ -0,0 +1,186 @@
+package logics
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"AuditLog/common"
+	"AuditLog/common/constants/alertconsts"
+	"AuditLog/errors"
+	"AuditLog/interfaces/mock"
+	"AuditLog/models"
+	"AuditLog/models/alertmodels"
+	"AuditLog/test/mock_log"
+)
+
+func TestAlertRule(t *testing.T) {
+	Convey("AlertRule", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		logger := mock_log.NewMockLogger(ctrl)
+		ruleRepo := mock.NewMockAlertRuleRepo(ctrl)
+		logMgnt := mock.NewMockLogMgnt(ctrl)
+		engine := mock.NewMockAlertEngine(ctrl)
+		alertRule := &alertRule{
+			logger:      logger,
+			ruleRepo:    ruleRepo,
+			logMgnt:     logMgnt,
+			alertEngine: engine,
+		}
+
+		ctx := context.WithValue(context.Background(), common.VisitorKey, &models.Visitor{
+			ID:   "test_user",
+			Name: "Test User",
+		})
+		req := &alertmodels.AlertRuleVO{
+			Name:    "登录失败",
+			Enabled: true,
+			LogType: common.Login,
+			Kind:    alertconsts.RuleThreshold,
+			Conditions: []*alertmodels.Condition{
+				{Field: alertconsts.FieldLevel, Operator: alertconsts.OpEq, Value: "2"},
+			},
+			GroupBy:       alertconsts.FieldUserID,
+			Threshold:     5,
+			WindowMinutes: 10,
+		}
+		po := &alertmodels.AlertRulePO{
+			ID:         1,
+			Name:       "登录失败",
+			LogType:    common.Login,
+			Kind:       alertconsts.RuleThreshold,
+			Conditions: `[{"field":"level","operator":"eq","value":"2"}]`,
+			WorkDays:   "1,2",
+		}
+
+		Convey("获取规则列表", func() {
+			ruleRepo.EXPECT().CountRulesByCondition("WHERE f_log_type=?", []interface{}{common.Login}).Return(int64(3), nil)
+			ruleRepo.EXPECT().GetRulesByCondition("WHERE f_log_type=? ORDER BY f_created_at DESC LIMIT ? OFFSET ?", []interface{}{common.Login, 1, 0}).
+				Return([]*alertmodels.AlertRulePO{po}, nil)
+
+			res, err := alertRule.GetRules(ctx, &alertmodels.GetAlertRulesReq{LogType: common.Login, Limit: 1})
+			assert.NoError(t, err)
+			assert.Equal(t, int64(3), res.TotalCount)
+			assert.Equal(t, 1, len(res.Entries))
+			assert.Equal(t, []int{1, 2}, res.Entries[0].WorkDays)
+			assert.Equal(t, "2", res.Entries[0].Conditions[0].Value)
+		})
+
+		Convey("获取不存在的规则", func() {
+			ruleRepo.EXPECT().GetRuleByID(int64(1)).Return(nil, nil)
+			_, err := alertRule.GetRule(ctx, 1)
+			assert.Equal(t, errors.AlertRuleNotFoundErr, err.(*errors.ErrorResp).Code())
+		})
+
+		Convey("新建规则", func() {
+			Convey("成功并填充默认值", func() {
+				ruleRepo.EXPECT().GetRulesByCondition("WHERE f_name=?", []interface{}{"登录失败"}).Return([]*alertmodels.AlertRulePO{}, nil)
+				ruleRepo.EXPECT().NewRule(gomock.Any()).DoAndReturn(func(rule *alertmodels.AlertRulePO) error {
+					assert.Equal(t, "test_user", rule.CreatedBy)
+					assert.Equal(t, 2, rule.Level)
+					assert.Equal(t, alertconsts.DefaultCooldownMinutes, rule.CooldownMinutes)
+					assert.Equal(t, `[{"field":"level","operator":"eq","value":"2"}]`, rule.Conditions)
+					return nil
+				})
+				engine.EXPECT().InvalidateRules()
+				logMgnt.EXPECT().SendLog(gomock.Any()).Return(nil).AnyTimes()
+
+				id, err := alertRule.NewRule(ctx, req)
+				assert.NoError(t, err)
+				assert.NotZero(t, id)
+			})
+
+			Convey("名称已存在", func() {
+				ruleRepo.EXPECT().GetRulesByCondition("WHERE f_name=?", []interface{}{"登录失败"}).Return([]*alertmodels.AlertRulePO{po}, nil)
+				_, err := alertRule.NewRule(ctx, req)
+				assert.Equal(t, errors.AlertRuleConflictErr, err.(*errors.ErrorResp).Code())
+			})
+
+			Convey("无效的日志类型", func() {
+				req.LogType = "unknown"
+				_, err := alertRule.NewRule(ctx, req)
+				assert.Equal(t, errors.BadRequestErr, err.(*errors.ErrorResp).Code())
+			})
+
+			Convey("阈值规则缺少窗口", func() {
+				req.WindowMinutes = 0
+				_, err := alertRule.NewRule(ctx, req)
+				assert.Equal(t, errors.BadRequestErr, err.(*errors.ErrorResp).Code())
+			})
+
+			Convey("webhook不是http地址", func() {
+				for _, webhook := range []string{"file:///etc/passwd", "gopher://example.com", "example.com/hook", "http://"} {
+					req.Webhook = webhook
+					_, err := alertRule.NewRule(ctx, req)
+					assert.Equal(t, errors.BadRequestErr, err.(*errors.ErrorResp).Code(), webhook)
+				}
+			})
+
+			Convey("webhook指向内部地址", func() {
+				for _, webhook := range []string{"http://localhost/hook", "http://127.0.0.1/hook", "http://169.254.169.254/latest/meta-data", "http://192.168.1.1/hook"} {
+					req.Webhook = webhook
+					_, err := alertRule.NewRule(ctx, req)
+					assert.Equal(t, errors.BadRequestErr, err.(*errors.ErrorResp).Code(), webhook)
+				}
+			})
+
+			Convey("非工作时间规则缺少工作时间", func() {
+				req.Kind = alertconsts.RuleOffHours
+				req.WorkStart = "09:00"
+				_, err := alertRule.NewRule(ctx, req)
+				assert.Equal(t, errors.BadRequestErr, err.(*errors.ErrorResp).Code())
+			})
+		})
+
+		Convey("更新规则", func() {
+			Convey("规则不存在", func() {
+				ruleRepo.EXPECT().GetRuleByID(int64(1)).Return(nil, nil)
+				err := alertRule.UpdateRule(ctx, 1, req)
+				assert.Equal(t, errors.AlertRuleNotFoundErr, err.(*errors.ErrorResp).Code())
+			})
+
+			Convey("webhook不是http地址", func() {
+				req.Webhook = "ftp://example.com/hook"
+				err := alertRule.UpdateRule(ctx, 1, req)
+				assert.Equal(t, errors.BadRequestErr, err.(*errors.ErrorResp).Code())
+			})
+
+			Convey("名称与其他规则重复", func() {
+				ruleRepo.EXPECT().GetRuleByID(int64(2)).Return(po, nil)
+				ruleRepo.EXPECT().GetRulesByCondition("WHERE f_name=?", []interface{}{"登录失败"}).Return([]*alertmodels.AlertRulePO{po}, nil)
+				err := alertRule.UpdateRule(ctx, 2, req)
+				assert.Equal(t, errors.AlertRuleConflictErr, err.(*errors.ErrorResp).Code())
+			})
+
+			Convey("成功", func() {
+				ruleRepo.EXPECT().GetRuleByID(int64(1)).Return(po, nil)
+				ruleRepo.EXPECT().GetRulesByCondition("WHERE f_name=?", []interface{}{"登录失败"}).Return([]*alertmodels.AlertRulePO{po}, nil)
+				ruleRepo.EXPECT().UpdateRule(gomock.Any()).Return(nil)
+				engine.EXPECT().InvalidateRules()
+				logMgnt.EXPECT().SendLog(gomock.Any()).Return(nil).AnyTimes()
+
+				assert.NoError(t, alertRule.UpdateRule(ctx, 1, req))
+			})
+		})
+
+		Convey("删除规则", func() {
+			Convey("规则不存在时直接返回", func() {
+				ruleRepo.EXPECT().GetRuleByID(int64(1)).Return(nil, nil)
+				assert.NoError(t, alertRule.DeleteRule(ctx, 1))
+			})
+
+			Convey("成功", func() {
+				ruleRepo.EXPECT().GetRuleByID(int64(1)).Return(po, nil)
+				ruleRepo.EXPECT().DeleteRule(int64(1)).Return(nil)
+				engine.EXPECT().InvalidateRules()
+				logMgnt.EXPECT().SendLog(gomock.Any()).Return(nil).AnyTimes()
+
+				assert.NoError(t, alertRule.DeleteRule(ctx, 1))
+			})
+		})
+	})
+}
@@ -1,11 +1,18 @@
 package logics
 
 import (
+	"compress/gzip"
 	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"hash"
+	"io"
 	"path"
+	"slices"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 
@@ -18,13 +25,19 @@ import (
 	"AuditLog/common/constants/rclogconsts"
 	"AuditLog/common/helpers"
 	"AuditLog/common/utils"
+	"AuditLog/common/utils/archiveutils"
 	"AuditLog/common/utils/dumplogutils"
+	"AuditLog/common/utils/legalholdutils"
+	"AuditLog/common/utils/logchainutils"
 	"AuditLog/common/utils/rclogutils"
 	"AuditLog/gocommon/api"
 	"AuditLog/infra/config"
 	"AuditLog/interfaces"
 	"AuditLog/locale"
 	"AuditLog/models"
+	"AuditLog/models/lcmodels"
+	"AuditLog/models/lhmodels"
+	"AuditLog/models/lsmodels"
 	"AuditLog/models/rcvo"
 )
 
@@ -33,6 +46,13 @@ var (
 	dl     *DumpLog
 )
 
+// 一段连续转储的日志, 段之间为法律保留的日志
+type dumpRun struct {
+	firstLogID string // 段内最小的日志ID
+	lastLogID  string // 段内最大的日志ID
+	count      int64
+}
+
 type DumpLog struct {
 	accountID       string
 	logger          api.Logger
@@ -43,9 +63,15 @@ type DumpLog struct {
 	mgntLogRepo     interfaces.LogRepo     // 数据库对象
 	operLogRepo     interfaces.LogRepo     // 数据库对象
 	historyLogRepo  interfaces.HistoryRepo // 数据库对象
+	logChainRepo    interfaces.LogChainRepo
+	chainSecret     string // 哈希链锚点签名密钥
 	ossGateway      interfaces.OssGatewayRepo
 	logStrategyRepo interfaces.LogStrategyRepo
 	logMgnt         interfaces.LogMgnt
+	legalHold       interfaces.LegalHold
+	dumpPublicKey   *rsa.PublicKey // 转储文件加密公钥, 为空时不加密
+	dumpKeyErr      error          // 公钥解析错误, 不为空时拒绝转储
+	manifestSecret  string         // 转储清单签名密钥
 }
 
 func NewDumpLog() interfaces.DumpLog {
@@ -60,9 +86,20 @@ func NewDumpLog() interfaces.DumpLog {
 			mgntLogRepo:     mgntLogRepo,
 			operLogRepo:     operLogRepo,
 			historyLogRepo:  historyRepo,
+			logChainRepo:    logChainRepo,
+			chainSecret:     common.SvcConfig.LogChainSecret,
 			ossGateway:      ossGateway,
 			logStrategyRepo: logStrategyRepo,
 			logMgnt:         NewLogMgnt(),
+			legalHold:       NewLegalHold(),
+			manifestSecret:  common.SvcConfig.DumpManifestSecret,
+		}
+
+		if common.SvcConfig.DumpPublicKey != "" {
+			dl.dumpPublicKey, dl.dumpKeyErr = archiveutils.ParsePublicKey(common.SvcConfig.DumpPublicKey)
+			if dl.dumpKeyErr != nil {
+				logger.Errorf("[NewDumpLog] parse dump public key failed: %v", dl.dumpKeyErr)
+			}
 		}
 	})
 
@@ -190,48 +227,65 @@ func (d *DumpLog) checkDumpExpiredLog(ctx context.Context) {
 	}
 
 	for _, logType := range common.AllLogType {
-		firstLogTime, err := d.getFirstLogTime(logType)
-		if err != nil {
-			d.logger.Warnf("[checkDumpExpiredLog] get first log time error: %v", err)
-			continue
-		}
+		// 法律保留的日志不清理, 首条日志处于保留时从上一个周期的结束时间继续检查后续周期
+		var nextBegin time.Time
+		for {
+			firstLogTime, err := d.getFirstLogTime(logType)
+			if err != nil {
+				d.logger.Warnf("[checkDumpExpiredLog] get first log time error: %v", err)
+				break
+			}
 
-		if firstLogTime.IsZero() {
-			continue
-		}
+			if firstLogTime.IsZero() {
+				break
+			}
+
+			if firstLogTime.Before(nextBegin) {
+				firstLogTime = nextBegin
+			}
+
+			periodStartTime, periodEndTime := getRetentionPeriod(firstLogTime, retentionPeriod, retentionPeriodUnit)
+			if periodEndTime.IsZero() || !time.Now().After(periodEndTime) {
+				break
+			}
 
-		firstLogTimeLocal := firstLogTime.Local()
-		dayStart := time.Date(firstLogTimeLocal.Year(), firstLogTimeLocal.Month(), firstLogTimeLocal.Day(), 0, 0, 0, 0, firstLogTimeLocal.Location())
-		var periodStartTime time.Time
-		var periodEndTime time.Time
-		switch retentionPeriodUnit {
-		case lsconsts.Day:
-			periodStartTime = dayStart
-			periodEndTime = dayStart.AddDate(0, 0, retentionPeriod)
-		case lsconsts.Week:
-			weekStart := dayStart.AddDate(0, 0, -int(dayStart.Weekday()))
-			periodStartTime = weekStart
-			periodEndTime = weekStart.AddDate(0, 0, retentionPeriod*7)
-		case lsconsts.Month:
-			monthStart := time.Date(dayStart.Year(), dayStart.Month(), 1, 0, 0, 0, 0, dayStart.Location())
-			periodStartTime = monthStart
-			periodEndTime = monthStart.AddDate(0, retentionPeriod, 0)
-		case lsconsts.Year:
-			yearStart := time.Date(dayStart.Year(), 1, 1, 0, 0, 0, 0, dayStart.Location())
-			periodStartTime = yearStart
-			periodEndTime = yearStart.AddDate(retentionPeriod, 0, 0)
-		}
-
-		if time.Now().After(periodEndTime) {
 			// 日志已过期，执行相应操作
 			d.logger.Infof("[checkDumpExpiredLog] log type %s has expired", logType)
 			if err := d.dumpLog(ctx, logType, periodStartTime.UTC(), periodEndTime.UTC()); err != nil {
 				d.logger.Warnf("[checkDumpExpiredLog] dump log error: %v", err)
+				break
 			}
+
+			nextBegin = periodEndTime
 		}
 	}
 }
 
+// 获取时间所在的保留周期
+func getRetentionPeriod(t time.Time, retentionPeriod int, retentionPeriodUnit string) (periodStartTime, periodEndTime time.Time) {
+	local := t.Local()
+	dayStart := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	switch retentionPeriodUnit {
+	case lsconsts.Day:
+		periodStartTime = dayStart
+		periodEndTime = dayStart.AddDate(0, 0, retentionPeriod)
+	case lsconsts.Week:
+		weekStart := dayStart.AddDate(0, 0, -int(dayStart.Weekday()))
+		periodStartTime = weekStart
+		periodEndTime = weekStart.AddDate(0, 0, retentionPeriod*7)
+	case lsconsts.Month:
+		monthStart := time.Date(dayStart.Year(), dayStart.Month(), 1, 0, 0, 0, 0, dayStart.Location())
+		periodStartTime = monthStart
+		periodEndTime = monthStart.AddDate(0, retentionPeriod, 0)
+	case lsconsts.Year:
+		yearStart := time.Date(dayStart.Year(), 1, 1, 0, 0, 0, 0, dayStart.Location())
+		periodStartTime = yearStart
+		periodEndTime = yearStart.AddDate(retentionPeriod, 0, 0)
+	}
+
+	return
+}
+
 // 检查日志是否超出范围
 func (d *DumpLog) checkDumpOutOfRangeLog(ctx context.Context) {
 	for _, logType := range common.AllLogType {
@@ -247,6 +301,8 @@ func (d *DumpLog) checkDumpOutOfRangeLog(ctx context.Context) {
 		}
 
 		// 循环处理直到日志数量低于阈值的一半
+		// 法律保留的日志不清理, 从上一次转储的结束时间继续转储, 转储到当前时间为止
+		var nextBegin time.Time
 		for count >= threshold/2 {
 			firstLogTime, err := d.getFirstLogTime(logType)
 			if err != nil {
@@ -258,6 +314,13 @@ func (d *DumpLog) checkDumpOutOfRangeLog(ctx context.Context) {
 				break
 			}
 
+			if firstLogTime.Before(nextBegin) {
+				firstLogTime = nextBegin
+			}
+			if firstLogTime.After(time.Now()) {
+				break
+			}
+
 			// 计算结束时间
 			localBegin := firstLogTime.Local()
 			var localEnd time.Time
@@ -271,6 +334,7 @@ func (d *DumpLog) checkDumpOutOfRangeLog(ctx context.Context) {
 				d.logger.Warnf("[checkDumpOutOfRangeLog] log type: %s, dump error: %v", logType, err)
 				break
 			}
+			nextBegin = localEnd
 
 			count, err = d.getLogCountByType(logType)
 			if err != nil {
@@ -283,14 +347,26 @@ func (d *DumpLog) checkDumpOutOfRangeLog(ctx context.Context) {
 
 // 转储日志
 func (d *DumpLog) dumpLog(ctx context.Context, logType string, beginLogTime time.Time, endLogTime time.Time) (err error) {
-	var suffix string = "csv"
+	var format string = lsconsts.CSVSuffix
 	if s, err := d.logStrategyRepo.GetDumpFormat(); err == nil {
-		suffix = s
+		format = s
 	} else {
-		d.logger.Warnf("[dumpLog] get retention format error: %v, use default suffix %s", err, suffix)
+		d.logger.Warnf("[dumpLog] get retention format error: %v, use default suffix %s", err, format)
+	}
+
+	suffix, compressed, err := archiveutils.ParseDumpFormat(format)
+	if err != nil {
+		d.logger.Warnf("[dumpLog] %v, use default suffix %s", err, lsconsts.CSVSuffix)
+		format, suffix, compressed = lsconsts.CSVSuffix, lsconsts.CSVSuffix, false
 	}
 
-	fileName, localBegin, localEnd := d.getDumpFileName(ctx, logType, suffix, beginLogTime, endLogTime.Add(-time.Nanosecond))
+	// 公钥配置错误时不转储, 避免日志以明文转储后被清理
+	if d.dumpKeyErr != nil {
+		return fmt.Errorf("[dumpLog] invalid dump public key: %w", d.dumpKeyErr)
+	}
+	encrypted := d.dumpPublicKey != nil
+
+	fileName, localBegin, localEnd := d.getDumpFileName(ctx, logType, archiveutils.DumpFileSuffix(format, encrypted), beginLogTime, endLogTime.Add(-time.Nanosecond))
 
 	logs, err := d.getPeriodsOfLogLimit(logType, -1, beginLogTime, endLogTime, lsconsts.HistoryMaxBatchSize)
 	if err != nil {
@@ -302,15 +378,23 @@ func (d *DumpLog) dumpLog(ctx context.Context, logType string, beginLogTime time
 		return
 	}
 
-	endLogID, err := strconv.Atoi(logs[0].LogID)
+	// 法律保留范围内的日志不转储也不清理, 只转储其余日志
+	held, dumpable, err := d.getHeldLogs(logType, beginLogTime, endLogTime, logs)
 	if err != nil {
-		d.logger.Warnf("[dumpLog] convert end log id to int error: %v", err)
+		d.logger.Warnf("[dumpLog] get legal hold error: %v", err)
+		return
+	}
+	if !dumpable {
+		d.logger.Infof("[dumpLog] logs of %s between %s and %s are all under legal hold, skip dumping", logType, beginLogTime, endLogTime)
 		return
 	}
+	if len(held) > 0 {
+		d.logger.Infof("[dumpLog] %d logs of %s between %s and %s are under legal hold, keep them", len(held), logType, beginLogTime, endLogTime)
+	}
 
 	d.logger.Infof(
-		"[dumpLog] Begin to dump log, file_name: %s, records: %d, end_log_id: %d, begin_time: %s, end_time: %s",
-		fileName, len(logs), endLogID, localBegin, localEnd,
+		"[dumpLog] Begin to dump log, file_name: %s, begin_time: %s, end_time: %s",
+		fileName, localBegin, localEnd,
 	)
 
 	// 获取可用的OSS ID
@@ -342,27 +426,83 @@ func (d *DumpLog) dumpLog(ctx context.Context, logType string, beginLogTime time
 		return
 	}
 
-	// 分片信息
-	partInfos := make(map[int]*models.OSSUploadPartInfo)
-	size, logCount := int64(0), int64(len(logs))
-	sn := 1
-	content := strings.Builder{}
-	content.Grow(int(uploadInfo.PartSize))
+	// 写入顺序: 日志内容 -> gzip压缩 -> 公钥加密 -> 分片上传
+	parts := &partWriter{
+		d:          d,
+		objectName: objectName,
+		ossID:      ossID,
+		uploadID:   uploadInfo.UploadID,
+		partSize:   int(uploadInfo.PartSize),
+		sn:         1,
+		buf:        make([]byte, 0, uploadInfo.PartSize),
+		partInfos:  make(map[int]*models.OSSUploadPartInfo),
+		hash:       sha256.New(),
+	}
+	var out io.Writer = parts
 
-	if suffix == lsconsts.XMLSuffix {
-		content.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<log>\n")
+	var encWriter io.WriteCloser
+	if encrypted {
+		if encWriter, err = archiveutils.NewEncryptWriter(out, d.dumpPublicKey); err != nil {
+			d.logger.Errorf("[dumpLog] new encrypt writer error: %v", err)
+			return
+		}
+		out = encWriter
+	}
+
+	var gzWriter *gzip.Writer
+	if compressed {
+		gzWriter = gzip.NewWriter(out)
+		out = gzWriter
+	}
+
+	writeContent := func(str string) error {
+		_, err := io.WriteString(out, str)
+		return err
 	}
 
+	// 转储文件自带表头, 下载时不再添加
+	switch suffix {
+	case lsconsts.XMLSuffix:
+		err = writeContent("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<log>\n")
+	case lsconsts.CSVSuffix:
+		err = writeContent(dumplogutils.CSVHeader(ctx))
+	}
+	if err != nil {
+		return err
+	}
+
+	// 按日志ID从大到小转储, 法律保留的日志将转储的日志分为多段, 每段生成哈希链锚点并单独清理
+	runs := make([]*dumpRun, 0, 1)
+	var run *dumpRun
+	var logCount, minDate, maxDate int64
 	for {
-		for i, log := range logs {
+		for _, log := range logs {
+			if held[log.LogID] {
+				run = nil
+				continue
+			}
+			if run == nil {
+				run = &dumpRun{lastLogID: log.LogID}
+				runs = append(runs, run)
+			}
+			run.firstLogID = log.LogID
+			run.count++
+
 			var str string
-			if suffix == lsconsts.XMLSuffix {
+			switch suffix {
+			case lsconsts.XMLSuffix:
 				str, err = dumplogutils.LogInfo2XMLString(log, logType)
 				if err != nil {
 					d.logger.Warnf("[dumpLog] log info to xml string error: %v", err)
 					return
 				}
-			} else {
+			case lsconsts.JSONLSuffix:
+				str, err = dumplogutils.LogInfo2JSONLString(log, logType)
+				if err != nil {
+					d.logger.Warnf("[dumpLog] log info to json string error: %v", err)
+					return
+				}
+			default:
 				str, err = dumplogutils.LogInfo2CSVString(log, logType)
 				if err != nil {
 					d.logger.Warnf("[dumpLog] log info to string error: %v", err)
@@ -371,39 +511,15 @@ func (d *DumpLog) dumpLog(ctx context.Context, logType string, beginLogTime time
 			}
 			str += "\n"
 
-			// 添加XML尾部
-			if suffix == lsconsts.XMLSuffix && len(logs) != lsconsts.HistoryMaxBatchSize && i == len(logs)-1 {
-				str += "</log>\n"
+			if err = writeContent(str); err != nil {
+				return err
 			}
 
-			// 检查是否需要上传当前块
-			if content.Len()+len(str) > int(uploadInfo.PartSize) {
-				appendingSize := int(uploadInfo.PartSize) - content.Len()
-				content.WriteString(str[:appendingSize])
-				str = str[appendingSize:]
-
-				// 上传数据块
-				partInfo, err := d.uploadBlock(
-					objectName,
-					ossID,
-					uploadInfo.UploadID,
-					sn,
-					content.String(),
-				)
-				if err != nil {
-					d.logger.Warnf("[dumpLog] upload block failed: %v", err)
-					return err
-				}
-				d.logger.Infof("[dumpLog] Uploaded part, account %s, objID %s, sn %d", d.accountID, objID, sn)
-
-				partInfos[sn] = partInfo
-				sn++
-				size += int64(content.Len())
-
-				content.Reset()
+			if logCount == 0 {
+				minDate, maxDate = log.Date, log.Date
 			}
-
-			content.WriteString(str)
+			minDate, maxDate = min(minDate, log.Date), max(maxDate, log.Date)
+			logCount++
 		}
 
 		if len(logs) != lsconsts.HistoryMaxBatchSize {
@@ -429,70 +545,104 @@ func (d *DumpLog) dumpLog(ctx context.Context, logType string, beginLogTime time
 			d.logger.Warnf("[dumpLog] get next batch logs failed: %v", err)
 			return err
 		}
-		logCount += int64(len(logs))
 
 		d.logger.Infof("[dumpLog] Got next batch records, count %d", len(logs))
 	}
 
-	// 上传最后的数据块
-	if content.Len() > 0 {
-		partInfo, err := d.uploadBlock(
-			objectName,
-			ossID,
-			uploadInfo.UploadID,
-			sn,
-			content.String(),
-		)
-		if err != nil {
-			d.logger.Errorf("[dumpLog] upload final block failed: %v", err)
+	// 转储期间日志被清理时没有可转储的日志, 不完成上传
+	if len(runs) == 0 {
+		return
+	}
+	slices.Reverse(runs)
+	firstLogID, lastLogID := runs[0].firstLogID, runs[len(runs)-1].lastLogID
+
+	// 写入哈希链锚点和XML尾部, 锚点用于衔接转储后数据库中剩余的链, 获取失败时不转储
+	anchors, err := d.getChainAnchors(logType, runs)
+	if err != nil {
+		d.logger.Errorf("[dumpLog] get chain anchor error: %v", err)
+		return err
+	}
+	if err = writeContent(d.getDumpTrailer(suffix, anchors)); err != nil {
+		return err
+	}
+
+	// 依次关闭压缩和加密写入器, 写出缓存的数据
+	if gzWriter != nil {
+		if err = gzWriter.Close(); err != nil {
+			d.logger.Errorf("[dumpLog] close gzip writer failed: %v", err)
 			return err
 		}
+	}
+	if encWriter != nil {
+		if err = encWriter.Close(); err != nil {
+			d.logger.Errorf("[dumpLog] close encrypt writer failed: %v", err)
+			return err
+		}
+	}
 
-		partInfos[sn] = partInfo
-		size += int64(content.Len())
+	// 上传最后的数据块
+	if err = parts.flush(); err != nil {
+		d.logger.Errorf("[dumpLog] upload final block failed: %v", err)
+		return err
 	}
 
 	// 完成上传
-	if err := d.completeUpload(objectName, ossID, uploadInfo.UploadID, partInfos); err != nil {
+	if err := d.completeUpload(objectName, ossID, uploadInfo.UploadID, parts.partInfos); err != nil {
 		d.logger.Errorf("[dumpLog] complete upload failed: %v", err)
 		return err
 	}
 
 	d.logger.Infof(
-		"[dumpLog] dump log success, file_name: %s, records: %d, end_log_id: %d, begin_time: %s, end_time: %s",
-		fileName, logCount, endLogID, localBegin, localEnd,
+		"[dumpLog] dump log success, file_name: %s, records: %d, end_log_id: %s, begin_time: %s, end_time: %s",
+		fileName, logCount, lastLogID, localBegin, localEnd,
 	)
 
 	// 记录历史日志
 	historyInfo := &models.HistoryPO{
 		ID:       path.Join(d.accountID, objID),
 		Name:     fileName,
-		Size:     int64(size),
+		Size:     parts.size,
 		Type:     int8(common.LogTypeMap[logType]),
 		Date:     beginLogTime.UnixMicro(),
 		DumpDate: time.Now().UnixMicro(),
 		OssID:    ossID,
 	}
 
+	// 保存转存清单, 用于离线校验下载的历史日志
+	manifest := &lsmodels.DumpManifest{
+		Version:     lsconsts.ManifestVersion,
+		FileName:    fileName,
+		LogType:     logType,
+		Format:      format,
+		Encrypted:   encrypted,
+		RecordCount: logCount,
+		BeginTime:   minDate,
+		EndTime:     maxDate,
+		FirstLogID:  firstLogID,
+		LastLogID:   lastLogID,
+		Size:        parts.size,
+		Checksum:    hex.EncodeToString(parts.hash.Sum(nil)),
+		DumpDate:    historyInfo.DumpDate,
+	}
+	if err = d.saveManifest(historyInfo.ID, ossID, objectName, manifest); err != nil {
+		d.logger.Errorf("[dumpLog] save manifest failed: %v", err)
+		return err
+	}
+
 	if err := d.historyLogRepo.New(historyInfo); err != nil {
 		d.logger.Errorf("[dumpLog] add history log failed: %v", err)
 		return err
 	}
 
-	batchSize := d.logDumpConfig.GetDumpLogNum()
-	sleepTime := d.logDumpConfig.GetDumpIntervalTime()
-
-	// 清理过期日志
-	switch logType {
-	case common.Login:
-		err = d.loginLogRepo.ClearOutdatedLog(int64(endLogID), endLogTime.UnixMicro(), batchSize, sleepTime)
-	case common.Management:
-		err = d.mgntLogRepo.ClearOutdatedLog(int64(endLogID), endLogTime.UnixMicro(), batchSize, sleepTime)
-	case common.Operation:
-		err = d.operLogRepo.ClearOutdatedLog(int64(endLogID), endLogTime.UnixMicro(), batchSize, sleepTime)
+	for _, anchor := range anchors {
+		if err = d.logChainRepo.NewAnchor(anchor); err != nil {
+			d.logger.Errorf("[dumpLog] save chain anchor failed: %v", err)
+			return err
+		}
 	}
 
-	if err != nil {
+	// 清理已转储的日志
+	if err = d.clearDumpedLogs(logType, runs, endLogTime); err != nil {
 		d.logger.Errorf("[dumpLog] clear outdated log failed: %v", err)
 		return err
 	}
@@ -528,6 +678,183 @@ func (d *DumpLog) dumpLog(ctx context.Context, logType string, beginLogTime time
 	return
 }
 
+// 获取转储范围内处于法律保留的日志ID, dumpable 表示是否有不在保留范围内的日志, logs 为转储范围内的第一批日志
+func (d *DumpLog) getHeldLogs(logType string, beginLogTime, endLogTime time.Time, logs []*models.LogPO) (held map[string]bool, dumpable bool, err error) {
+	// 转储范围包含结束时间
+	begin, end := beginLogTime.UnixMicro(), endLogTime.UnixMicro()+1
+	holds, err := d.legalHold.GetMatchedHolds([]string{logType}, begin, end)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(holds) == 0 {
+		return nil, true, nil
+	}
+
+	// 未限定用户或部门且覆盖整个转储范围的保留, 所有日志均保留
+	for _, h := range holds {
+		if !legalholdutils.Scoped(h) && h.BeginTime <= begin && (h.EndTime == 0 || h.EndTime >= end) {
+			return nil, false, nil
+		}
+	}
+
+	// 逐批检查日志是否命中保留
+	held = make(map[string]bool)
+	for {
+		for _, log := range logs {
+			if isLogHeld(holds, log) {
+				held[log.LogID] = true
+			} else {
+				dumpable = true
+			}
+		}
+
+		if len(logs) != lsconsts.HistoryMaxBatchSize {
+			return held, dumpable, nil
+		}
+
+		lastLog := logs[len(logs)-1]
+		lastLogID, err := strconv.Atoi(lastLog.LogID)
+		if err != nil {
+			return nil, false, err
+		}
+
+		logs, err = d.getPeriodsOfLogLimit(
+			logType,
+			lastLogID-1,
+			beginLogTime,
+			time.UnixMicro(lastLog.Date),
+			lsconsts.HistoryMaxBatchSize,
+		)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+}
+
+// 日志是否命中任一法律保留
+func isLogHeld(holds []*lhmodels.LegalHoldVO, log *models.LogPO) bool {
+	for _, h := range holds {
+		if legalholdutils.MatchLog(h, log.Date, log.UserID, log.UserPaths) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// 清理已转储的日志, 按段删除, 段之间法律保留的日志不删除
+func (d *DumpLog) clearDumpedLogs(logType string, runs []*dumpRun, endLogTime time.Time) (err error) {
+	var repo interfaces.LogRepo
+	switch logType {
+	case common.Login:
+		repo = d.loginLogRepo
+	case common.Management:
+		repo = d.mgntLogRepo
+	case common.Operation:
+		repo = d.operLogRepo
+	default:
+		return
+	}
+
+	batchSize := d.logDumpConfig.GetDumpLogNum()
+	sleepTime := d.logDumpConfig.GetDumpIntervalTime()
+	for _, run := range runs {
+		firstLogID, err := strconv.ParseInt(run.firstLogID, 10, 64)
+		if err != nil {
+			return err
+		}
+		lastLogID, err := strconv.ParseInt(run.lastLogID, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		if err = repo.ClearOutdatedLog(firstLogID, lastLogID, endLogTime.UnixMicro(), batchSize, sleepTime); err != nil {
+			return err
+		}
+	}
+
+	return
+}
+
+// 获取转储文件尾部, 包含每段日志的哈希链锚点, 日志未入链时没有锚点
+func (d *DumpLog) getDumpTrailer(suffix string, anchors []*lcmodels.ChainAnchor) (trailer string) {
+	for _, anchor := range anchors {
+		var str string
+		var err error
+		switch suffix {
+		case lsconsts.XMLSuffix:
+			str = dumplogutils.ChainAnchor2XMLString(anchor)
+		case lsconsts.JSONLSuffix:
+			if str, err = dumplogutils.ChainAnchor2JSONLString(anchor); err != nil {
+				d.logger.Warnf("[dumpLog] chain anchor to json string error: %v", err)
+				return ""
+			}
+		default:
+			if str, err = dumplogutils.ChainAnchor2CSVString(anchor); err != nil {
+				d.logger.Warnf("[dumpLog] chain anchor to string error: %v", err)
+				return ""
+			}
+		}
+		trailer += str + "\n"
+	}
+
+	if suffix == lsconsts.XMLSuffix {
+		trailer += "</log>\n"
+	}
+
+	return trailer
+}
+
+// 获取每段转储日志的哈希链锚点, 日志未入链的段没有锚点
+func (d *DumpLog) getChainAnchors(logType string, runs []*dumpRun) (anchors []*lcmodels.ChainAnchor, err error) {
+	anchors = make([]*lcmodels.ChainAnchor, 0, len(runs))
+	for _, run := range runs {
+		anchor, err := d.getChainAnchor(logType, run.firstLogID, run.lastLogID, run.count)
+		if err != nil {
+			return nil, err
+		}
+		if anchor != nil {
+			anchors = append(anchors, anchor)
+		}
+	}
+
+	return anchors, nil
+}
+
+// 获取转储日志的哈希链锚点, 日志写入时未入链则返回nil
+func (d *DumpLog) getChainAnchor(logType, firstLogID, lastLogID string, count int64) (anchor *lcmodels.ChainAnchor, err error) {
+	first, err := d.logChainRepo.GetRecordByLogID(logType, firstLogID)
+	if err != nil {
+		return nil, err
+	}
+
+	last, err := d.logChainRepo.GetRecordByLogID(logType, lastLogID)
+	if err != nil {
+		return nil, err
+	}
+
+	if first == nil || last == nil || first.Hash == "" || last.Hash == "" {
+		return nil, nil
+	}
+
+	anchor = &lcmodels.ChainAnchor{
+		LogType:       logType,
+		FirstSeq:      first.Seq,
+		FirstLogID:    first.LogID,
+		FirstPrevHash: first.PrevHash,
+		LastSeq:       last.Seq,
+		LastLogID:     last.LogID,
+		LastHash:      last.Hash,
+		Count:         count,
+	}
+	if anchor.Signature, err = logchainutils.SignAnchor(d.chainSecret, anchor); err != nil {
+		return nil, err
+	}
+
+	return anchor, nil
+}
+
 // 获取过期日志的周期
 func (d *DumpLog) getPeriodsOfLogLimit(logType string, maxLogID int, beginLogTime time.Time, endLogTime time.Time, maxBatchSize int) (logs []*models.LogPO, err error) {
 	sqlStr, err := rclogutils.BuildActiveCondition2(
@@ -628,6 +955,76 @@ func (d *DumpLog) uploadBlock(objectName, ossID, uploadID string, partNumber int
 	return partInfo, nil
 }
 
+// 签名并保存转存清单, 清单同时上传到转存文件所在的对象存储, 数据库不可用时也能离线校验
+func (d *DumpLog) saveManifest(historyID, ossID, objectName string, manifest *lsmodels.DumpManifest) (err error) {
+	if manifest.Signature, err = archiveutils.SignManifest(d.manifestSecret, manifest); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	if err = uploadToOSSByID(d.ossGateway, ossID, objectName+lsconsts.ManifestSuffix, data); err != nil {
+		return err
+	}
+
+	return d.historyLogRepo.NewManifest(historyID, string(data))
+}
+
+// partWriter 按分片大小缓存写入的数据, 缓存满时上传分片, 同时统计上传文件的大小和校验和
+type partWriter struct {
+	d          *DumpLog
+	objectName string
+	ossID      string
+	uploadID   string
+	partSize   int
+	sn         int
+	buf        []byte
+	partInfos  map[int]*models.OSSUploadPartInfo
+	size       int64
+	hash       hash.Hash
+}
+
+func (w *partWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		appending := min(w.partSize-len(w.buf), len(p))
+		w.buf = append(w.buf, p[:appending]...)
+		p, n = p[appending:], n+appending
+
+		if len(w.buf) >= w.partSize {
+			if err = w.flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+
+	return n, nil
+}
+
+// 上传缓存的数据块
+func (w *partWriter) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	partInfo, err := w.d.uploadBlock(w.objectName, w.ossID, w.uploadID, w.sn, string(w.buf))
+	if err != nil {
+		w.d.logger.Warnf("[dumpLog] upload block failed: %v", err)
+		return err
+	}
+	w.d.logger.Infof("[dumpLog] Uploaded part, account %s, object %s, sn %d", w.d.accountID, w.objectName, w.sn)
+
+	w.hash.Write(w.buf)
+	w.partInfos[w.sn] = partInfo
+	w.size += int64(len(w.buf))
+	w.sn++
+	w.buf = w.buf[:0]
+
+	return nil
+}
+
 // completeUpload 完成上传
 func (d *DumpLog) completeUpload(objectName, ossID, uploadID string, partInfos map[int]*models.OSSUploadPartInfo) (err error) {
 	// 转换分片信息
@@ -1,8 +1,15 @@
 package logics
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"io"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,9 +18,14 @@ import (
 	"go.uber.org/mock/gomock"
 
 	"AuditLog/common"
+	"AuditLog/common/constants/lsconsts"
+	"AuditLog/common/utils/archiveutils"
 	configMock "AuditLog/infra/config/mock"
 	"AuditLog/interfaces/mock"
 	"AuditLog/models"
+	"AuditLog/models/lcmodels"
+	"AuditLog/models/lhmodels"
+	"AuditLog/models/lsmodels"
 	"AuditLog/test/mock_log"
 	"AuditLog/test/mock_trace"
 )
@@ -70,6 +82,8 @@ func newDumpLog(
 		ossGateway:      ossGateway,
 		logStrategyRepo: logStrategyRepo,
 		logMgnt:         logMgnt,
+		chainSecret:     "test_secret",
+		manifestSecret:  "test_secret",
 	}
 }
 
@@ -101,6 +115,8 @@ func TestDumpLog(t *testing.T) {
 	Convey("DumpLog", t, func() {
 		logger, tracer, dlmLock, logDumpConfig, loginLogRepo, mgntLogRepo, operLogRepo, historyRepo, ossGateway, logStrategyRepo, logMgnt := newDumpLogDependencies(t)
 		dumpLog := newDumpLog(logger, tracer, dlmLock, logDumpConfig, loginLogRepo, mgntLogRepo, operLogRepo, historyRepo, ossGateway, logStrategyRepo, logMgnt)
+		legalHold := mock.NewMockLegalHold(gomock.NewController(t))
+		dumpLog.legalHold = legalHold
 
 		Convey("转储日志成功", func() {
 			ctx := context.Background()
@@ -125,6 +141,7 @@ func TestDumpLog(t *testing.T) {
 				},
 			}
 			loginLogRepo.EXPECT().FindByCondition(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(logs, nil)
+			legalHold.EXPECT().GetMatchedHolds([]string{common.Login}, gomock.Any(), gomock.Any()).Return(nil, nil)
 
 			// Mock OSS相关操作
 			ossGateway.EXPECT().GetAvailableOSSID().Return("test_oss_id", nil)
@@ -132,18 +149,38 @@ func TestDumpLog(t *testing.T) {
 			ossGateway.EXPECT().GetUploadInfo(gomock.Any(), gomock.Any()).Return(&models.OSSUploadInfo{
 				UploadID: "test_upload_id",
 				PartSize: 1024 * 1024,
-			}, 200, nil)
+			}, 200, nil).Times(2)
 
 			// Mock上传分块
-			ossGateway.EXPECT().GetUploadPartRequestInfo(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(&models.OSSRequestInfo{
-				URL:     "test_url",
-				Method:  "PUT",
-				Headers: map[string]string{},
-			}, 200, nil).AnyTimes()
-			ossGateway.EXPECT().UploadPartByURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(&models.OSSUploadPartInfo{
-				Etag: "test_etag",
-				Size: 100,
-			}, 200, nil).AnyTimes()
+			// 以对象名作为分片上传地址, 区分转存文件和转存清单
+			ossGateway.EXPECT().GetUploadPartRequestInfo(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_, objName, _ string, _ int) (*models.OSSRequestInfo, int, error) {
+					return &models.OSSRequestInfo{URL: objName, Method: "PUT", Headers: map[string]string{}}, 200, nil
+				}).AnyTimes()
+			var uploaded, uploadedManifest string
+			ossGateway.EXPECT().UploadPartByURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(url, _, body string, _ map[string]string) (*models.OSSUploadPartInfo, int, error) {
+					if strings.HasSuffix(url, lsconsts.ManifestSuffix) {
+						uploadedManifest += body
+					} else {
+						uploaded += body
+					}
+					return &models.OSSUploadPartInfo{Etag: "test_etag", Size: 100}, 200, nil
+				}).AnyTimes()
+
+			// Mock哈希链锚点
+			logChainRepo := mock.NewMockLogChainRepo(gomock.NewController(t))
+			logChainRepo.EXPECT().GetRecordByLogID(common.Login, "1").Return(&lcmodels.LogChainRecordPO{
+				Seq:   1,
+				LogID: "1",
+				Hash:  "test_hash",
+			}, nil).Times(2)
+			var anchor *lcmodels.ChainAnchor
+			logChainRepo.EXPECT().NewAnchor(gomock.Any()).DoAndReturn(func(a *lcmodels.ChainAnchor) error {
+				anchor = a
+				return nil
+			})
+			dumpLog.logChainRepo = logChainRepo
 
 			// Mock完成上传
 			ossGateway.EXPECT().GetCompleteUploadRequestInfo(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(&models.OSSRequestInfo{
@@ -151,25 +188,265 @@ func TestDumpLog(t *testing.T) {
 				Method:      "POST",
 				RequestBody: "",
 				Headers:     map[string]string{},
-			}, 200, nil)
+			}, 200, nil).Times(2)
 			ossGateway.EXPECT().CompleteUploadByURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(&http.Response{
 				StatusCode: 200,
-			}, 200, nil)
+			}, 200, nil).Times(2)
 
 			logDumpConfig.EXPECT().GetDumpLogNum().Return(int64(100))
 			logDumpConfig.EXPECT().GetDumpIntervalTime().Return(int64(100))
 
-			// Mock保存历史记录
+			// Mock保存转存清单和历史记录
+			var manifest string
+			historyRepo.EXPECT().NewManifest(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ string, m string) error {
+					manifest = m
+					return nil
+				})
 			historyRepo.EXPECT().New(gomock.Any()).Return(nil)
 
 			// Mock清理日志
-			loginLogRepo.EXPECT().ClearOutdatedLog(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			loginLogRepo.EXPECT().ClearOutdatedLog(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 
 			// Mock发送管理日志
 			logMgnt.EXPECT().SendLog(gomock.Any()).Return(nil).AnyTimes()
 
 			err := dumpLog.dumpLog(ctx, common.Login, time.UnixMicro(beginTime), time.Time{})
 			assert.NoError(t, err)
+			assert.Contains(t, uploaded, `# chain_anchor: {"log_type":"login","first_seq":1,"first_log_id":"1"`)
+			assert.Equal(t, "test_hash", anchor.LastHash)
+
+			assert.Equal(t, manifest, uploadedManifest)
+			m := &lsmodels.DumpManifest{}
+			assert.NoError(t, json.Unmarshal([]byte(manifest), m))
+			assert.Equal(t, int64(1), m.RecordCount)
+			assert.Equal(t, beginTime, m.BeginTime)
+			assert.Equal(t, "1", m.FirstLogID)
+			assert.False(t, m.Encrypted)
+			assert.NoError(t, archiveutils.VerifyManifest(dumpLog.manifestSecret, m, []byte(uploaded)))
+		})
+
+		Convey("转储日志成功 - 加密的jsonl.gz格式", func() {
+			ctx := context.Background()
+			beginTime := int64(1734571503937827)
+
+			privKey, err := rsa.GenerateKey(rand.Reader, 2048)
+			assert.NoError(t, err)
+			dumpLog.dumpPublicKey = &privKey.PublicKey
+			dumpLog.manifestSecret = "test_secret"
+
+			logger.EXPECT().Infof(gomock.Any(), gomock.Any()).Return().AnyTimes()
+			logger.EXPECT().Warnf(gomock.Any(), gomock.Any()).Return().AnyTimes()
+
+			logStrategyRepo.EXPECT().GetDumpFormat().Return("jsonl.gz", nil)
+			loginLogRepo.EXPECT().FindByCondition(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return([]*models.LogPO{
+				{LogID: "2", Date: beginTime + 1, UserName: "test_user", Msg: "test_msg_2"},
+				{LogID: "1", Date: beginTime, UserName: "test_user", Msg: "test_msg_1"},
+			}, nil)
+			legalHold.EXPECT().GetMatchedHolds([]string{common.Login}, gomock.Any(), gomock.Any()).Return(nil, nil)
+
+			ossGateway.EXPECT().GetAvailableOSSID().Return("test_oss_id", nil)
+			logStrategyRepo.EXPECT().GetLogPrefix().Return("test_prefix", nil)
+			ossGateway.EXPECT().GetUploadInfo(gomock.Any(), gomock.Any()).Return(&models.OSSUploadInfo{
+				UploadID: "test_upload_id",
+				PartSize: 64,
+			}, 200, nil).Times(2)
+			// 以对象名作为分片上传地址, 区分转存文件和转存清单
+			ossGateway.EXPECT().GetUploadPartRequestInfo(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_, objName, _ string, _ int) (*models.OSSRequestInfo, int, error) {
+					return &models.OSSRequestInfo{URL: objName, Method: "PUT", Headers: map[string]string{}}, 200, nil
+				}).AnyTimes()
+			var uploaded, uploadedManifest string
+			ossGateway.EXPECT().UploadPartByURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(url, _, body string, _ map[string]string) (*models.OSSUploadPartInfo, int, error) {
+					if strings.HasSuffix(url, lsconsts.ManifestSuffix) {
+						uploadedManifest += body
+					} else {
+						uploaded += body
+					}
+					return &models.OSSUploadPartInfo{Etag: "test_etag", Size: len(body)}, 200, nil
+				}).AnyTimes()
+
+			// 日志未入链, 不写入锚点
+			logChainRepo := mock.NewMockLogChainRepo(gomock.NewController(t))
+			logChainRepo.EXPECT().GetRecordByLogID(common.Login, gomock.Any()).Return(nil, nil).Times(2)
+			dumpLog.logChainRepo = logChainRepo
+
+			ossGateway.EXPECT().GetCompleteUploadRequestInfo(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(&models.OSSRequestInfo{
+				URL:     "test_url",
+				Method:  "POST",
+				Headers: map[string]string{},
+			}, 200, nil).Times(2)
+			ossGateway.EXPECT().CompleteUploadByURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(&http.Response{
+				StatusCode: 200,
+			}, 200, nil).Times(2)
+
+			logDumpConfig.EXPECT().GetDumpLogNum().Return(int64(100))
+			logDumpConfig.EXPECT().GetDumpIntervalTime().Return(int64(100))
+
+			var manifest string
+			historyRepo.EXPECT().NewManifest(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ string, m string) error {
+					manifest = m
+					return nil
+				})
+			var history *models.HistoryPO
+			historyRepo.EXPECT().New(gomock.Any()).DoAndReturn(func(po *models.HistoryPO) error {
+				history = po
+				return nil
+			})
+			loginLogRepo.EXPECT().ClearOutdatedLog(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			logMgnt.EXPECT().SendLog(gomock.Any()).Return(nil).AnyTimes()
+
+			err = dumpLog.dumpLog(ctx, common.Login, time.UnixMicro(beginTime), time.Time{})
+			assert.NoError(t, err)
+			assert.True(t, strings.HasSuffix(history.Name, ".jsonl.gz.enc"))
+			assert.Equal(t, int64(len(uploaded)), history.Size)
+
+			assert.Equal(t, manifest, uploadedManifest)
+			m := &lsmodels.DumpManifest{}
+			assert.NoError(t, json.Unmarshal([]byte(manifest), m))
+			assert.Equal(t, "jsonl.gz", m.Format)
+			assert.True(t, m.Encrypted)
+			assert.Equal(t, int64(2), m.RecordCount)
+			assert.Equal(t, beginTime, m.BeginTime)
+			assert.Equal(t, beginTime+1, m.EndTime)
+			assert.Equal(t, "1", m.FirstLogID)
+			assert.Equal(t, "2", m.LastLogID)
+			assert.NoError(t, archiveutils.VerifyManifest("test_secret", m, []byte(uploaded)))
+
+			// 解密并解压后得到两行日志
+			dr, err := archiveutils.NewDecryptReader(bytes.NewReader([]byte(uploaded)), privKey)
+			assert.NoError(t, err)
+			gr, err := gzip.NewReader(dr)
+			assert.NoError(t, err)
+			content, err := io.ReadAll(gr)
+			assert.NoError(t, err)
+			lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+			assert.Len(t, lines, 2)
+			assert.Contains(t, lines[0], `"msg":"test_msg_2"`)
+		})
+
+		Convey("转储日志成功 - 部分日志处于法律保留", func() {
+			ctx := context.Background()
+			beginTime := int64(1734571503937827)
+
+			logger.EXPECT().Infof(gomock.Any(), gomock.Any()).Return().AnyTimes()
+
+			logStrategyRepo.EXPECT().GetDumpFormat().Return("csv", nil)
+			loginLogRepo.EXPECT().FindByCondition(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return([]*models.LogPO{
+				{LogID: "3", Date: beginTime + 2, UserID: "u3", UserPaths: "org/other", Msg: "test_msg_3"},
+				{LogID: "2", Date: beginTime + 1, UserID: "u2", UserPaths: "org/dept/sub", Msg: "test_msg_2"},
+				{LogID: "1", Date: beginTime, UserID: "u1", UserPaths: "org/other", Msg: "test_msg_1"},
+			}, nil)
+			legalHold.EXPECT().GetMatchedHolds([]string{common.Login}, beginTime, gomock.Any()).Return([]*lhmodels.LegalHoldVO{
+				{Name: "other_user", UserIDs: []string{"u4"}},
+				{Name: "investigation", Departments: []string{"org/dept"}},
+			}, nil)
+
+			ossGateway.EXPECT().GetAvailableOSSID().Return("test_oss_id", nil)
+			logStrategyRepo.EXPECT().GetLogPrefix().Return("test_prefix", nil)
+			ossGateway.EXPECT().GetUploadInfo(gomock.Any(), gomock.Any()).Return(&models.OSSUploadInfo{
+				UploadID: "test_upload_id",
+				PartSize: 1024 * 1024,
+			}, 200, nil).Times(2)
+			// 以对象名作为分片上传地址, 区分转存文件和转存清单
+			ossGateway.EXPECT().GetUploadPartRequestInfo(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_, objName, _ string, _ int) (*models.OSSRequestInfo, int, error) {
+					return &models.OSSRequestInfo{URL: objName, Method: "PUT", Headers: map[string]string{}}, 200, nil
+				}).AnyTimes()
+			var uploaded, uploadedManifest string
+			ossGateway.EXPECT().UploadPartByURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(url, _, body string, _ map[string]string) (*models.OSSUploadPartInfo, int, error) {
+					if strings.HasSuffix(url, lsconsts.ManifestSuffix) {
+						uploadedManifest += body
+					} else {
+						uploaded += body
+					}
+					return &models.OSSUploadPartInfo{Etag: "test_etag", Size: len(body)}, 200, nil
+				}).AnyTimes()
+
+			// 保留的日志两侧各生成一个锚点
+			logChainRepo := mock.NewMockLogChainRepo(gomock.NewController(t))
+			logChainRepo.EXPECT().GetRecordByLogID(common.Login, "1").Return(&lcmodels.LogChainRecordPO{
+				Seq: 1, LogID: "1", Hash: "hash_1",
+			}, nil).Times(2)
+			logChainRepo.EXPECT().GetRecordByLogID(common.Login, "3").Return(&lcmodels.LogChainRecordPO{
+				Seq: 3, LogID: "3", PrevHash: "hash_2", Hash: "hash_3",
+			}, nil).Times(2)
+			anchors := make([]*lcmodels.ChainAnchor, 0)
+			logChainRepo.EXPECT().NewAnchor(gomock.Any()).DoAndReturn(func(a *lcmodels.ChainAnchor) error {
+				anchors = append(anchors, a)
+				return nil
+			}).Times(2)
+			dumpLog.logChainRepo = logChainRepo
+
+			ossGateway.EXPECT().GetCompleteUploadRequestInfo(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(&models.OSSRequestInfo{
+				URL:     "test_url",
+				Method:  "POST",
+				Headers: map[string]string{},
+			}, 200, nil).Times(2)
+			ossGateway.EXPECT().CompleteUploadByURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(&http.Response{
+				StatusCode: 200,
+			}, 200, nil).Times(2)
+
+			var manifest string
+			historyRepo.EXPECT().NewManifest(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ string, m string) error {
+					manifest = m
+					return nil
+				})
+			historyRepo.EXPECT().New(gomock.Any()).Return(nil)
+
+			// 按段清理, 保留的日志不清理
+			logDumpConfig.EXPECT().GetDumpLogNum().Return(int64(100))
+			logDumpConfig.EXPECT().GetDumpIntervalTime().Return(int64(100))
+			loginLogRepo.EXPECT().ClearOutdatedLog(int64(1), int64(1), gomock.Any(), int64(100), int64(100)).Return(nil)
+			loginLogRepo.EXPECT().ClearOutdatedLog(int64(3), int64(3), gomock.Any(), int64(100), int64(100)).Return(nil)
+			logMgnt.EXPECT().SendLog(gomock.Any()).Return(nil).AnyTimes()
+
+			err := dumpLog.dumpLog(ctx, common.Login, time.UnixMicro(beginTime), time.UnixMicro(beginTime+10))
+			assert.NoError(t, err)
+			assert.Contains(t, uploaded, "test_msg_1")
+			assert.Contains(t, uploaded, "test_msg_3")
+			assert.NotContains(t, uploaded, "test_msg_2")
+			assert.Len(t, anchors, 2)
+			assert.Equal(t, "hash_1", anchors[0].LastHash)
+			assert.Equal(t, "hash_2", anchors[1].FirstPrevHash)
+
+			assert.Equal(t, manifest, uploadedManifest)
+			m := &lsmodels.DumpManifest{}
+			assert.NoError(t, json.Unmarshal([]byte(manifest), m))
+			assert.Equal(t, int64(2), m.RecordCount)
+			assert.Equal(t, "1", m.FirstLogID)
+			assert.Equal(t, "3", m.LastLogID)
+			assert.Equal(t, beginTime+2, m.EndTime)
+		})
+
+		Convey("全部日志处于法律保留时不转储", func() {
+			beginTime := int64(1734571503937827)
+
+			logger.EXPECT().Infof(gomock.Any(), gomock.Any()).Return()
+			logStrategyRepo.EXPECT().GetDumpFormat().Return("csv", nil)
+			loginLogRepo.EXPECT().FindByCondition(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return([]*models.LogPO{
+				{LogID: "1", Date: beginTime, UserID: "u1"},
+			}, nil)
+			legalHold.EXPECT().GetMatchedHolds([]string{common.Login}, beginTime, gomock.Any()).Return([]*lhmodels.LegalHoldVO{
+				{Name: "investigation", BeginTime: beginTime - 1},
+			}, nil)
+
+			// 不上传也不清理日志
+			err := dumpLog.dumpLog(context.Background(), common.Login, time.UnixMicro(beginTime), time.UnixMicro(beginTime+10))
+			assert.NoError(t, err)
+		})
+
+		Convey("转储日志失败 - 公钥无效", func() {
+			dumpLog.dumpKeyErr = archiveutils.ErrInvalidArchive
+
+			logStrategyRepo.EXPECT().GetDumpFormat().Return("csv", nil)
+
+			err := dumpLog.dumpLog(context.Background(), common.Login, time.Now(), time.Time{})
+			assert.Error(t, err)
 		})
 	})
 }
@@ -295,14 +572,15 @@ func TestCheckDumpExpiredLog(t *testing.T) {
 				Return(&http.Response{StatusCode: 200}, 200, nil).AnyTimes()
 
 			// Mock 历史记录
+			historyRepo.EXPECT().NewManifest(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 			historyRepo.EXPECT().New(gomock.Any()).Return(nil).AnyTimes()
 
 			// Mock 清理日志
-			loginLogRepo.EXPECT().ClearOutdatedLog(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			loginLogRepo.EXPECT().ClearOutdatedLog(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 				Return(nil).AnyTimes()
-			mgntLogRepo.EXPECT().ClearOutdatedLog(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			mgntLogRepo.EXPECT().ClearOutdatedLog(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 				Return(nil).AnyTimes()
-			operLogRepo.EXPECT().ClearOutdatedLog(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			operLogRepo.EXPECT().ClearOutdatedLog(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 				Return(nil).AnyTimes()
 
 			// Mock 发送管理日志
@@ -313,6 +591,36 @@ func TestCheckDumpExpiredLog(t *testing.T) {
 	})
 }
 
+func TestCheckDumpOutOfRangeLog(t *testing.T) {
+	Convey("CheckDumpOutOfRangeLog", t, func() {
+		logger, tracer, dlmLock, logDumpConfig, loginLogRepo, mgntLogRepo, operLogRepo, historyRepo, ossGateway, logStrategyRepo, logMgnt := newDumpLogDependencies(t)
+		dumpLog := newDumpLog(logger, tracer, dlmLock, logDumpConfig, loginLogRepo, mgntLogRepo, operLogRepo, historyRepo, ossGateway, logStrategyRepo, logMgnt)
+
+		Convey("首条日志处于法律保留时从上一次转储的结束时间继续转储", func() {
+			logDumpConfig.EXPECT().GetDumpThresholdByType(gomock.Any()).Return(int64(10)).AnyTimes()
+			loginLogRepo.EXPECT().GetLogCount().Return(int64(100), nil).AnyTimes()
+			mgntLogRepo.EXPECT().GetLogCount().Return(int64(0), nil)
+			operLogRepo.EXPECT().GetLogCount().Return(int64(0), nil)
+
+			// 保留的日志未被清理, 首条日志时间和日志数量不变
+			now := time.Now()
+			firstLogTime := time.Date(now.Year(), now.Month()-3, 1, 12, 0, 0, 0, time.Local)
+			loginLogRepo.EXPECT().GetFirstLogTime().Return(firstLogTime.UnixMicro(), nil).AnyTimes()
+			logStrategyRepo.EXPECT().GetDumpFormat().Return("csv", nil).AnyTimes()
+
+			conditions := make([]string, 0)
+			loginLogRepo.EXPECT().FindByCondition(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_, _ int, condition string, _ []string) ([]*models.LogPO, error) {
+					conditions = append(conditions, condition)
+					return []*models.LogPO{}, nil
+				}).AnyTimes()
+
+			dumpLog.checkDumpOutOfRangeLog(context.Background())
+			assert.Len(t, conditions, 4)
+		})
+	})
+}
+
 func TestGetFirstLogTime(t *testing.T) {
 	Convey("GetFirstLogTime", t, func() {
 		logger, tracer, dlmLock, logDumpConfig, loginLogRepo, mgntLogRepo, operLogRepo, historyRepo, ossGateway, logStrategyRepo, logMgnt := newDumpLogDependencies(t)
@@ -2,6 +2,7 @@ package logics
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"strings"
@@ -15,6 +16,7 @@ import (
 	"AuditLog/common/constants/logconsts"
 	"AuditLog/common/constants/lsconsts"
 	"AuditLog/common/utils"
+	"AuditLog/common/utils/archiveutils"
 	"AuditLog/common/utils/dumplogutils"
 	"AuditLog/common/utils/rclogutils"
 	"AuditLog/errors"
@@ -41,6 +43,7 @@ type HistoryLog struct {
 	logScopeStrategyRepo interfaces.LogScopeStrategyRepo
 	ossGateway           interfaces.OssGatewayRepo
 	logMgnt              interfaces.LogMgnt
+	manifestSecret       string // 转存清单签名密钥
 }
 
 func NewHistoryLog() interfaces.HistoryLog {
@@ -55,6 +58,7 @@ func NewHistoryLog() interfaces.HistoryLog {
 			logScopeStrategyRepo: logScopeStrategyRepo,
 			ossGateway:           ossGateway,
 			logMgnt:              NewLogMgnt(),
+			manifestSecret:       common.SvcConfig.DumpManifestSecret,
 		}
 	})
 
@@ -365,81 +369,26 @@ func (h *HistoryLog) compressLog(ctx context.Context, downloadTaskId string, req
 
 func (h *HistoryLog) doCompressLog(ctx context.Context, downloadTaskId string, req *lsmodels.HistoryLogDownloadReq, logInfo *models.HistoryPO) (ossID string, err error) {
 	// 下载历史日志文件
-	fileContent, err := h.genDownloadFile(ctx, logInfo)
+	fileContent, manifest, err := h.genDownloadFile(ctx, logInfo)
 	if err != nil {
 		h.logger.Errorf("[doCompressLog] gen download file error: %v", err)
 		return
 	}
 
-	// 生成zip文件
-	zipContent, err := dumplogutils.GenZipFile(fileContent, logInfo.Name, req.Password)
-	if err != nil {
-		h.logger.Errorf("[doCompressLog] gen zip file error: %v", err)
-		return
+	// 生成zip文件, 有转存清单时一并打包, 便于离线校验
+	entries := []*dumplogutils.ZipEntry{{Name: logInfo.Name, Content: fileContent}}
+	if manifest != "" {
+		entries = append(entries, &dumplogutils.ZipEntry{Name: logInfo.Name + ".manifest.json", Content: []byte(manifest)})
 	}
-
-	ossID, err = h.ossGateway.GetAvailableOSSID()
+	zipContent, err := dumplogutils.GenZipFiles(entries, req.Password)
 	if err != nil {
-		h.logger.Errorf("[doCompressLog] get oss id error: %v", err)
-		return
-	}
-
-	uploadInfo, _, err := h.ossGateway.GetUploadInfo(ossID, downloadTaskId)
-	if err != nil {
-		h.logger.Errorf("[doCompressLog] get upload info error: %v", err)
+		h.logger.Errorf("[doCompressLog] gen zip file error: %v", err)
 		return
 	}
 
-	// 对zip文件进行分块上传
-	uploadID := uploadInfo.UploadID
-	partInfos := make(map[int]models.OSSUploadPartInfo)
-
-	parts, err := dumplogutils.SplitFile(zipContent, int64(uploadInfo.PartSize))
+	ossID, err = uploadToOSS(h.ossGateway, downloadTaskId, zipContent)
 	if err != nil {
-		h.logger.Errorf("[doCompressLog] split file error: %v", err)
-		return
-	}
-
-	partNumber := 1
-	for _, partData := range parts {
-		uploadPartRequestInfo, _, err := h.ossGateway.GetUploadPartRequestInfo(ossID, downloadTaskId, uploadID, partNumber)
-		if err != nil || uploadPartRequestInfo == nil {
-			h.logger.Errorf("[doCompressLog] get upload part request info error: %v", err)
-			return "", err
-		}
-
-		partInfo, _, err := h.ossGateway.UploadPartByURL(
-			uploadPartRequestInfo.URL,
-			uploadPartRequestInfo.Method,
-			string(partData),
-			uploadPartRequestInfo.Headers,
-		)
-		if err != nil || partInfo == nil {
-			h.logger.Errorf("[doCompressLog] upload part error: %v", err)
-			return "", err
-		}
-
-		partInfos[partNumber] = models.OSSUploadPartInfo{
-			Etag: partInfo.Etag,
-			Size: partInfo.Size,
-		}
-		partNumber++
-	}
-
-	completeUploadRequestInfo, _, err := h.ossGateway.GetCompleteUploadRequestInfo(ossID, downloadTaskId, uploadID, partInfos)
-	if err != nil || completeUploadRequestInfo == nil {
-		h.logger.Errorf("[doCompressLog] get complete upload request info error: %v", err)
-		return "", err
-	}
-
-	completeUploadResponse, _, err := h.ossGateway.CompleteUploadByURL(
-		completeUploadRequestInfo.URL,
-		completeUploadRequestInfo.Method,
-		completeUploadRequestInfo.RequestBody,
-		completeUploadRequestInfo.Headers,
-	)
-	if err != nil || completeUploadResponse == nil {
-		h.logger.Errorf("[doCompressLog] complete upload error: %v", err)
+		h.logger.Errorf("[doCompressLog] upload zip file error: %v", err)
 		return "", err
 	}
 	h.logger.Infof("[doCompressLog] complete upload zip file of %s to oss, ossID: %s, fileId: %s", logInfo.Name, ossID, downloadTaskId)
@@ -458,7 +407,7 @@ func (h *HistoryLog) doCompressLog(ctx context.Context, downloadTaskId string, r
 }
 
 // genDownloadFile 生成历史审计日志下载文件
-func (h *HistoryLog) genDownloadFile(ctx context.Context, logInfo *models.HistoryPO) (fileContent []byte, err error) {
+func (h *HistoryLog) genDownloadFile(ctx context.Context, logInfo *models.HistoryPO) (fileContent []byte, manifest string, err error) {
 	prefix, err := h.logStrategyRepo.GetLogPrefix()
 	if err != nil {
 		h.logger.Errorf("[genDownloadFile] get log prefix error: %v", err)
@@ -479,28 +428,21 @@ func (h *HistoryLog) genDownloadFile(ctx context.Context, logInfo *models.Histor
 	readCount := int64(0)
 	readBlockSize := int64(5 * 1024 * 1024) // 5MB
 
-	// 写入Excel BOM头
-	fileContent = append(fileContent, []byte{0xEF, 0xBB, 0xBF}...)
-
-	// 如果是CSV文件，写入表头
-	if strings.HasSuffix(logInfo.Name, ".csv") {
-		headers := []string{
-			locale.GetI18nCtx(ctx, locale.RCLogDate),
-			locale.GetI18nCtx(ctx, locale.RCLogUser),
-			locale.GetI18nCtx(ctx, locale.RCLogUserPaths),
-			locale.GetI18nCtx(ctx, locale.RCLogLevel),
-			locale.GetI18nCtx(ctx, locale.RCLogOperation),
-			locale.GetI18nCtx(ctx, locale.RCLogIP),
-			locale.GetI18nCtx(ctx, locale.RCLogMac),
-			locale.GetI18nCtx(ctx, locale.RCLogMsg),
-			locale.GetI18nCtx(ctx, locale.RCLogExMsg),
-			locale.GetI18nCtx(ctx, locale.RCLogUserAgent),
-			locale.GetI18nCtx(ctx, locale.RCLogAdditionalInfo),
-			locale.GetI18nCtx(ctx, locale.RCLogObjName),
-			locale.GetI18nCtx(ctx, locale.RCLogObjType),
+	manifest, err = h.historyLogRepo.GetManifest(logInfo.ID)
+	if err != nil {
+		h.logger.Errorf("[genDownloadFile] get manifest error: %v", err)
+		return
+	}
+
+	// 无转存清单的历史文件不含表头, 下载时补充
+	if manifest == "" {
+		// 写入Excel BOM头
+		fileContent = append(fileContent, []byte{0xEF, 0xBB, 0xBF}...)
+
+		// 如果是CSV文件，写入表头
+		if strings.HasSuffix(logInfo.Name, ".csv") {
+			fileContent = append(fileContent, []byte(strings.TrimPrefix(dumplogutils.CSVHeader(ctx), "\uFEFF"))...)
 		}
-		fileContent = append(fileContent, []byte(strings.Join(headers, ","))...)
-		fileContent = append(fileContent, []byte("\n")...)
 	}
 
 	// 分块下载并写入文件
@@ -518,13 +460,13 @@ func (h *HistoryLog) genDownloadFile(ctx context.Context, logInfo *models.Histor
 		)
 		if err != nil {
 			h.logger.Errorf("[genDownloadFile] download block by url error: %v", err)
-			return nil, err
+			return nil, "", err
 		}
 
 		body, err := io.ReadAll(rsp.Body)
 		if err != nil {
 			h.logger.Errorf("[genDownloadFile] read block body error: %v", err)
-			return nil, err
+			return nil, "", err
 		}
 
 		fileContent = append(fileContent, body...)
@@ -534,22 +476,32 @@ func (h *HistoryLog) genDownloadFile(ctx context.Context, logInfo *models.Histor
 	// 验证下载的数据大小
 	if logInfo.Size != readCount {
 		cause := fmt.Sprintf("download history log failed, expected size: %d, actual size: %d", logInfo.Size, readCount)
-		return nil, errors.NewCtx(ctx, errors.InternalErr, cause, nil)
+		return nil, "", errors.NewCtx(ctx, errors.InternalErr, cause, nil)
+	}
+
+	if manifest != "" {
+		if err = h.verifyManifest(manifest, fileContent); err != nil {
+			h.logger.Errorf("[genDownloadFile] verify manifest of %s error: %v", logInfo.Name, err)
+			return nil, "", errors.NewCtx(ctx, errors.InternalErr, err.Error(), nil)
+		}
 	}
 
-	return fileContent, nil
+	return fileContent, manifest, nil
 }
 
-// deleteLogFile 删除oss上压缩后的历史审计日志文件
-func (h *HistoryLog) deleteLogFile(ossID string, fileId string, fileName string) {
-	delInfo, _, err := h.ossGateway.GetDeleteRequestInfo(ossID, fileId)
-	if err != nil {
-		h.logger.Errorf("[deleteLogFile] get delete info error: %v", err)
-		return
+// verifyManifest 校验转存清单签名以及文件校验和
+func (h *HistoryLog) verifyManifest(manifest string, content []byte) error {
+	m := &lsmodels.DumpManifest{}
+	if err := json.Unmarshal([]byte(manifest), m); err != nil {
+		return fmt.Errorf("unmarshal manifest failed: %w", err)
 	}
 
-	_, _, err = h.ossGateway.DeleteObjectByURL(delInfo.URL, delInfo.Method, delInfo.RequestBody, delInfo.Headers)
-	if err != nil {
+	return archiveutils.VerifyManifest(h.manifestSecret, m, content)
+}
+
+// deleteLogFile 删除oss上压缩后的历史审计日志文件
+func (h *HistoryLog) deleteLogFile(ossID string, fileId string, fileName string) {
+	if err := deleteFromOSS(h.ossGateway, ossID, fileId); err != nil {
 		h.logger.Errorf("[deleteLogFile] delete zip file of %s from oss error: %v", fileName, err)
 		return
 	}
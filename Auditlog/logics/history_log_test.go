@@ -314,6 +314,9 @@ func TestCompressLog(t *testing.T) {
 					Headers: map[string]string{},
 				}, 200, nil)
 
+			// Mock获取转存清单, 无清单时按旧格式下载
+			historyRepo.EXPECT().GetManifest(logInfo.ID).Return("", nil)
+
 			// Mock下载块
 			ossGateway.EXPECT().DownloadBlockByURL(
 				gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
@@ -371,6 +374,21 @@ func TestCompressLog(t *testing.T) {
 			concreteHistoryLog.compressLog(ctx, downloadTaskId, req, logInfo)
 		})
 
+		Convey("压缩日志失败 - 文件与转存清单不一致", func() {
+			logStrategyRepo.EXPECT().GetLogPrefix().Return("", nil)
+			ossGateway.EXPECT().GetDownLoadInfo(logInfo.OssID, gomock.Any(), logInfo.Name, true).
+				Return(&models.OSSRequestInfo{URL: "test_url", Method: "GET"}, 200, nil)
+			historyRepo.EXPECT().GetManifest(logInfo.ID).Return(`{"version":1,"checksum":"mismatch"}`, nil)
+			ossGateway.EXPECT().DownloadBlockByURL(
+				gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+			).Return(&http.Response{
+				Body: io.NopCloser(bytes.NewReader(make([]byte, logInfo.Size))),
+			}, 200, nil)
+
+			_, err := historyLog.(*HistoryLog).doCompressLog(ctx, downloadTaskId, req, logInfo)
+			assert.Error(t, err)
+		})
+
 		Convey("压缩日志失败 - 获取文件内容失败", func() {
 			// Mock获取日志前缀失败
 			logStrategyRepo.EXPECT().GetLogPrefix().Return("", errors.NewCtx(ctx, errors.InternalErr, "mock error", nil))
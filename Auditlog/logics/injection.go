@@ -9,23 +9,30 @@ import (
 )
 
 var (
-	logger               api.Logger                      = nil
-	loginLogRepo         interfaces.LogRepo              = nil
-	mgntLogRepo          interfaces.LogRepo              = nil
-	operLogRepo          interfaces.LogRepo              = nil
-	historyRepo          interfaces.HistoryRepo          = nil
-	userMgntRepo         interfaces.UserMgntRepo         = nil
-	shareMgntRepo        interfaces.ShareMgntRepo        = nil
-	docCenterRepo        interfaces.DocCenterRepo        = nil
-	ossGateway           interfaces.OssGatewayRepo       = nil
-	logStrategyRepo      interfaces.LogStrategyRepo      = nil
-	logScopeStrategyRepo interfaces.LogScopeStrategyRepo = nil
-	redisClient          redis.Cmdable                   = nil
-	mqClient             api.MQClient                    = nil
-	dbOutbox             interfaces.DBOutbox             = nil
-	dbPool               *sqlx.DB                        = nil
-	tracer               api.Tracer                      = nil
-	dlmLock              interfaces.DLM                  = nil
+	logger                 api.Logger                        = nil
+	loginLogRepo           interfaces.LogRepo                = nil
+	mgntLogRepo            interfaces.LogRepo                = nil
+	operLogRepo            interfaces.LogRepo                = nil
+	historyRepo            interfaces.HistoryRepo            = nil
+	logChainRepo           interfaces.LogChainRepo           = nil
+	syslogClient           interfaces.SyslogClient           = nil
+	alertRuleRepo          interfaces.AlertRuleRepo          = nil
+	legalHoldRepo          interfaces.LegalHoldRepo          = nil
+	investigationRepo      interfaces.InvestigationRepo      = nil
+	reportSubscriptionRepo interfaces.ReportSubscriptionRepo = nil
+	webhookRepo            interfaces.WebhookRepo            = nil
+	userMgntRepo           interfaces.UserMgntRepo           = nil
+	shareMgntRepo          interfaces.ShareMgntRepo          = nil
+	docCenterRepo          interfaces.DocCenterRepo          = nil
+	ossGateway             interfaces.OssGatewayRepo         = nil
+	logStrategyRepo        interfaces.LogStrategyRepo        = nil
+	logScopeStrategyRepo   interfaces.LogScopeStrategyRepo   = nil
+	redisClient            redis.Cmdable                     = nil
+	mqClient               api.MQClient                      = nil
+	dbOutbox               interfaces.DBOutbox               = nil
+	dbPool                 *sqlx.DB                          = nil
+	tracer                 api.Tracer                        = nil
+	dlmLock                interfaces.DLM                    = nil
 )
 
 func SetLogger(i api.Logger) {
@@ -95,3 +102,31 @@ func SetTracer(i api.Tracer) {
 func SetDLM(i interfaces.DLM) {
 	dlmLock = i
 }
+
+func SetLogChainRepo(i interfaces.LogChainRepo) {
+	logChainRepo = i
+}
+
+func SetSyslogClient(i interfaces.SyslogClient) {
+	syslogClient = i
+}
+
+func SetAlertRuleRepo(i interfaces.AlertRuleRepo) {
+	alertRuleRepo = i
+}
+
+func SetLegalHoldRepo(i interfaces.LegalHoldRepo) {
+	legalHoldRepo = i
+}
+
+func SetInvestigationRepo(i interfaces.InvestigationRepo) {
+	investigationRepo = i
+}
+
+func SetReportSubscriptionRepo(i interfaces.ReportSubscriptionRepo) {
+	reportSubscriptionRepo = i
+}
+
+func SetWebhookRepo(i interfaces.WebhookRepo) {
+	webhookRepo = i
+}
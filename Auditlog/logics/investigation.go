@@ -0,0 +1,498 @@
+package logics
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	jsoniter "github.com/json-iterator/go"
+
+	"AuditLog/common"
+	"AuditLog/common/constants/logconsts"
+	"AuditLog/common/constants/wsconsts"
+	"AuditLog/common/utils/archiveutils"
+	"AuditLog/common/utils/dumplogutils"
+	"AuditLog/errors"
+	"AuditLog/gocommon/api"
+	"AuditLog/infra"
+	"AuditLog/interfaces"
+	"AuditLog/locale"
+	"AuditLog/models"
+	"AuditLog/models/lsmodels"
+	"AuditLog/models/wsmodels"
+)
+
+var (
+	wsOnce sync.Once
+	ws     *investigation
+)
+
+var errWorkspaceDeleted = stderrors.New("workspace has been deleted")
+
+type investigation struct {
+	logger          api.Logger
+	wsRepo          interfaces.InvestigationRepo
+	historyLogRepo  interfaces.HistoryRepo
+	logStrategyRepo interfaces.LogStrategyRepo
+	ossGateway      interfaces.OssGatewayRepo
+	logMgnt         interfaces.LogMgnt
+	dumpPrivateKey  *rsa.PrivateKey // 转存文件解密私钥, 为空时无法恢复加密的历史日志
+	manifestSecret  string          // 转存清单签名密钥
+}
+
+func NewInvestigation() interfaces.Investigation {
+	wsOnce.Do(func() {
+		ws = &investigation{
+			logger:          logger,
+			wsRepo:          investigationRepo,
+			historyLogRepo:  historyRepo,
+			logStrategyRepo: logStrategyRepo,
+			ossGateway:      ossGateway,
+			logMgnt:         NewLogMgnt(),
+			manifestSecret:  common.SvcConfig.DumpManifestSecret,
+		}
+
+		if common.SvcConfig.DumpPrivateKey != "" {
+			var err error
+			if ws.dumpPrivateKey, err = archiveutils.ParsePrivateKey(common.SvcConfig.DumpPrivateKey); err != nil {
+				logger.Errorf("[NewInvestigation] parse dump private key failed: %v", err)
+			}
+		}
+	})
+	return ws
+}
+
+// InitInvestigation 定时删除过期的调查工作区
+func (i *investigation) InitInvestigation(ctx context.Context) {
+	ticker := time.NewTicker(wsconsts.CleanInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := i.CleanExpiredWorkspaces(ctx); err != nil {
+			i.logger.Warnf("[InitInvestigation] clean expired workspaces error: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (i *investigation) GetWorkspaces(ctx context.Context, req *wsmodels.GetWorkspacesReq) (res *wsmodels.GetWorkspacesRes, err error) {
+	visitor := ctx.Value(common.VisitorKey).(*models.Visitor)
+	condition := "WHERE f_created_by=?"
+	params := []interface{}{visitor.ID}
+
+	count, err := i.wsRepo.CountWorkspacesByCondition(condition, params)
+	if err != nil {
+		return nil, fmt.Errorf("[GetWorkspaces] count workspaces failed: %w", err)
+	}
+
+	condition += " ORDER BY f_created_at DESC"
+	if req.Limit > 0 {
+		condition += " LIMIT ? OFFSET ?"
+		params = append(params, req.Limit, req.Offset)
+	}
+
+	workspaces, err := i.wsRepo.GetWorkspacesByCondition(condition, params)
+	if err != nil {
+		return nil, fmt.Errorf("[GetWorkspaces] get workspaces failed: %w", err)
+	}
+
+	res = &wsmodels.GetWorkspacesRes{
+		Entries:    make([]*wsmodels.WorkspaceVO, 0, len(workspaces)),
+		TotalCount: count,
+	}
+	for _, w := range workspaces {
+		res.Entries = append(res.Entries, workspaceToVO(w))
+	}
+	return
+}
+
+func (i *investigation) GetWorkspace(ctx context.Context, id int64) (res *wsmodels.WorkspaceVO, err error) {
+	w, err := i.getOwnWorkspace(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil {
+		return nil, workspaceNotFoundErr(ctx, id)
+	}
+	return workspaceToVO(w), nil
+}
+
+// NewWorkspace 创建调查工作区, 历史日志文件在后台恢复
+func (i *investigation) NewWorkspace(ctx context.Context, req *wsmodels.NewWorkspaceReq) (id int64, err error) {
+	histories, err := i.validate(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+
+	uid, err := infra.GetUniqueID()
+	if err != nil {
+		i.logger.Errorf("new sonyflake id error: %v", err)
+		return 0, err
+	}
+
+	historyIDs, err := jsoniter.MarshalToString(req.HistoryIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	expireDays := req.ExpireDays
+	if expireDays == 0 {
+		expireDays = wsconsts.DefaultExpireDays
+	}
+
+	visitor := ctx.Value(common.VisitorKey).(*models.Visitor)
+	now := time.Now()
+	w := &wsmodels.WorkspacePO{
+		ID:         int64(uid),
+		Name:       req.Name,
+		LogType:    req.LogType,
+		HistoryIDs: historyIDs,
+		Status:     wsconsts.StatusRestoring,
+		ExpireAt:   now.AddDate(0, 0, expireDays).UnixMicro(),
+		CreatedAt:  now.UnixMicro(),
+		CreatedBy:  visitor.ID,
+	}
+	if err = i.wsRepo.NewWorkspace(w); err != nil {
+		return 0, err
+	}
+
+	// 恢复耗时较长, 不随请求取消
+	go i.restore(context.WithoutCancel(ctx), w, histories)
+
+	go i.autilog(ctx, workspaceToVO(w), logconsts.OpType.ManagementType.CREATE, locale.NewWorkspace)
+
+	return w.ID, nil
+}
+
+func (i *investigation) DeleteWorkspace(ctx context.Context, id int64) (err error) {
+	w, err := i.getOwnWorkspace(ctx, id)
+	if err != nil {
+		return err
+	}
+	if w == nil {
+		return
+	}
+	if err = i.wsRepo.DeleteWorkspace(id); err != nil {
+		return err
+	}
+
+	go i.autilog(ctx, workspaceToVO(w), logconsts.OpType.ManagementType.DELETE, locale.DeleteWorkspace)
+
+	return
+}
+
+// CleanExpiredWorkspaces 删除已过期的调查工作区
+func (i *investigation) CleanExpiredWorkspaces(ctx context.Context) (err error) {
+	workspaces, err := i.wsRepo.GetWorkspacesByCondition("WHERE f_expire_at<?", []interface{}{time.Now().UnixMicro()})
+	if err != nil {
+		return fmt.Errorf("[CleanExpiredWorkspaces] get expired workspaces failed: %w", err)
+	}
+
+	for _, w := range workspaces {
+		if err = i.wsRepo.DeleteWorkspace(w.ID); err != nil {
+			return fmt.Errorf("[CleanExpiredWorkspaces] delete workspace %d failed: %w", w.ID, err)
+		}
+		i.logger.Infof("[CleanExpiredWorkspaces] deleted expired workspace %d", w.ID)
+	}
+	return
+}
+
+// getOwnWorkspace 获取当前用户创建的调查工作区, 不存在时返回nil
+func (i *investigation) getOwnWorkspace(ctx context.Context, id int64) (w *wsmodels.WorkspacePO, err error) {
+	w, err = i.wsRepo.GetWorkspaceByID(id)
+	if err != nil || w == nil {
+		return
+	}
+
+	visitor := ctx.Value(common.VisitorKey).(*models.Visitor)
+	if w.CreatedBy != visitor.ID {
+		return nil, nil
+	}
+	return
+}
+
+// validate 校验json schema无法覆盖的参数, 返回待恢复的历史日志
+func (i *investigation) validate(ctx context.Context, req *wsmodels.NewWorkspaceReq) (histories []*models.HistoryPO, err error) {
+	badRequest := func(msg string) error {
+		return errors.NewCtx(ctx, errors.BadRequestErr, msg, nil)
+	}
+
+	if !slices.Contains(common.AllLogType, req.LogType) {
+		return nil, badRequest(fmt.Sprintf("invalid log_type: %s", req.LogType))
+	}
+
+	if req.ExpireDays < 0 || req.ExpireDays > wsconsts.MaxExpireDays {
+		return nil, badRequest(fmt.Sprintf("expire_days must not exceed %d", wsconsts.MaxExpireDays))
+	}
+
+	slices.Sort(req.HistoryIDs)
+	req.HistoryIDs = slices.Compact(req.HistoryIDs)
+	if len(req.HistoryIDs) == 0 || len(req.HistoryIDs) > wsconsts.MaxHistoryCount {
+		return nil, badRequest(fmt.Sprintf("history_ids must contain 1 to %d items", wsconsts.MaxHistoryCount))
+	}
+
+	for _, historyID := range req.HistoryIDs {
+		history, hErr := i.historyLogRepo.GetHistoryLogByID(historyID)
+		if hErr != nil && hErr != sql.ErrNoRows {
+			return nil, hErr
+		}
+		if history == nil {
+			return nil, badRequest(fmt.Sprintf("history log %s not found", historyID))
+		}
+		if int(history.Type) != common.LogTypeMap[req.LogType] {
+			return nil, badRequest(fmt.Sprintf("history log %s is not a %s log", historyID, req.LogType))
+		}
+		histories = append(histories, history)
+	}
+	return
+}
+
+// restore 依次恢复历史日志文件, 任一文件失败时清除已恢复的日志
+func (i *investigation) restore(ctx context.Context, w *wsmodels.WorkspacePO, histories []*models.HistoryPO) {
+	var (
+		count int64
+		err   error
+	)
+	for _, history := range histories {
+		if err = i.restoreHistory(ctx, w, history, &count); err != nil {
+			err = fmt.Errorf("restore %s failed: %w", history.Name, err)
+			break
+		}
+	}
+
+	if stderrors.Is(err, errWorkspaceDeleted) {
+		i.logger.Infof("[restore] workspace %d deleted while restoring", w.ID)
+		if dErr := i.wsRepo.DeleteLogs(w.ID); dErr != nil {
+			i.logger.Errorf("[restore] delete logs of workspace %d error: %v", w.ID, dErr)
+		}
+		return
+	}
+
+	if err != nil {
+		i.logger.Errorf("[restore] workspace %d: %v", w.ID, err)
+		if dErr := i.wsRepo.DeleteLogs(w.ID); dErr != nil {
+			i.logger.Errorf("[restore] delete logs of workspace %d error: %v", w.ID, dErr)
+		}
+		if uErr := i.wsRepo.UpdateWorkspaceStatus(w.ID, wsconsts.StatusFailed, 0, err.Error()); uErr != nil {
+			i.logger.Errorf("[restore] update workspace %d error: %v", w.ID, uErr)
+		}
+		return
+	}
+
+	if err = i.wsRepo.UpdateWorkspaceStatus(w.ID, wsconsts.StatusReady, count, ""); err != nil {
+		i.logger.Errorf("[restore] update workspace %d error: %v", w.ID, err)
+	}
+}
+
+// restoreHistory 流式下载并解析一个历史日志文件, 分批写入工作区, 有转存清单时校验文件完整性
+func (i *investigation) restoreHistory(ctx context.Context, w *wsmodels.WorkspacePO, history *models.HistoryPO, count *int64) (err error) {
+	prefix, err := i.logStrategyRepo.GetLogPrefix()
+	if err != nil {
+		return fmt.Errorf("get log prefix failed: %w", err)
+	}
+
+	objectName := history.ID
+	if prefix != "" {
+		objectName = fmt.Sprintf("%s/%s", prefix, objectName)
+	}
+
+	dlInfo, _, err := i.ossGateway.GetDownLoadInfo(history.OssID, objectName, history.Name, true)
+	if err != nil {
+		return fmt.Errorf("get download info failed: %w", err)
+	}
+
+	manifest, err := i.historyLogRepo.GetManifest(history.ID)
+	if err != nil {
+		return fmt.Errorf("get manifest failed: %w", err)
+	}
+
+	// 校验和基于下载的原始文件计算
+	hasher := sha256.New()
+	blocks := &ossBlockReader{oss: i.ossGateway, info: dlInfo, size: history.Size}
+	raw := io.TeeReader(blocks, hasher)
+
+	format, plain, err := archiveutils.OpenArchive(raw, history.Name, i.dumpPrivateKey)
+	if err != nil {
+		return err
+	}
+
+	batch := make([]*models.LogPO, 0, wsconsts.InsertBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		// 恢复过程中工作区可能被删除, 此时停止写入
+		checked, fErr := i.wsRepo.GetWorkspaceByID(w.ID)
+		if fErr != nil {
+			return fErr
+		}
+		if checked == nil {
+			return errWorkspaceDeleted
+		}
+		if fErr := i.wsRepo.NewLogs(w.ID, batch); fErr != nil {
+			return fErr
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	err = dumplogutils.ParseLogs(plain, format, w.LogType, func(log *models.LogPO) error {
+		*count++
+		if *count > wsconsts.MaxRecordCount {
+			return fmt.Errorf("workspace exceeds %d logs", wsconsts.MaxRecordCount)
+		}
+
+		// CSV格式不含日志ID, 恢复时重新生成
+		if _, pErr := strconv.ParseUint(log.LogID, 10, 64); pErr != nil {
+			uid, uErr := infra.GetUniqueID()
+			if uErr != nil {
+				return uErr
+			}
+			log.LogID = strconv.FormatUint(uid, 10)
+		}
+
+		batch = append(batch, log)
+		if len(batch) >= wsconsts.InsertBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err = flush(); err != nil {
+		return err
+	}
+
+	// 读取压缩流之后的剩余数据, 保证校验和覆盖整个文件
+	if _, err = io.Copy(io.Discard, raw); err != nil {
+		return err
+	}
+	if blocks.offset != history.Size {
+		return fmt.Errorf("expected size: %d, actual size: %d", history.Size, blocks.offset)
+	}
+
+	if manifest == "" {
+		return nil
+	}
+
+	m := &lsmodels.DumpManifest{}
+	if err = json.Unmarshal([]byte(manifest), m); err != nil {
+		return fmt.Errorf("unmarshal manifest failed: %w", err)
+	}
+	return archiveutils.VerifyManifestSum(i.manifestSecret, m, blocks.offset, hex.EncodeToString(hasher.Sum(nil)))
+}
+
+// ossBlockReader 按块下载OSS对象, 以流的方式读取
+type ossBlockReader struct {
+	oss    interfaces.OssGatewayRepo
+	info   *models.OSSRequestInfo
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (r *ossBlockReader) Read(p []byte) (n int, err error) {
+	readBlockSize := int64(5 * 1024 * 1024) // 5MB
+
+	for r.body == nil {
+		if r.offset >= r.size {
+			return 0, io.EOF
+		}
+
+		end := common.Min(r.offset+readBlockSize, r.size)
+		rsp, _, dErr := r.oss.DownloadBlockByURL(r.info.URL, r.info.Method, r.info.RequestBody, r.info.Headers, r.offset, end-1)
+		if dErr != nil {
+			return 0, fmt.Errorf("download block failed: %w", dErr)
+		}
+		r.body = rsp.Body
+	}
+
+	n, err = r.body.Read(p)
+	r.offset += int64(n)
+	if err == io.EOF {
+		_ = r.body.Close()
+		r.body = nil
+		err = nil
+	}
+	return
+}
+
+func workspaceNotFoundErr(ctx context.Context, id int64) error {
+	return errors.NewCtx(
+		ctx,
+		errors.WorkspaceNotFoundErr,
+		"Investigation workspace not found",
+		map[string]interface{}{
+			"id": []int64{id},
+		},
+	)
+}
+
+// 记录审计日志
+func (i *investigation) autilog(ctx context.Context, w *wsmodels.WorkspaceVO, opType int, opKey string) {
+	visitor := ctx.Value(common.VisitorKey).(*models.Visitor)
+
+	err := i.logMgnt.SendLog(&models.SendLogVo{
+		LogType:  common.Management,
+		Language: "",
+		LogContent: &models.AuditLog{
+			UserID:   visitor.ID,
+			UserName: visitor.Name,
+			UserType: common.AuthenticatedUser,
+			Level:    logconsts.LogLevel.INFO,
+			OpType:   opType,
+			Date:     time.Now().UnixMicro(),
+			IP:       visitor.IP,
+			Mac:      visitor.Mac,
+			Msg:      fmt.Sprintf(locale.GetI18nCtx(ctx, opKey), w.Name),
+			Exmsg: fmt.Sprintf(
+				locale.GetI18nCtx(ctx, locale.WorkspaceExMsg),
+				locale.GetI18nCtx(ctx, locale.LogTypeMap[common.LogTypeMap[w.LogType]]),
+				strings.Join(w.HistoryIDs, ", "),
+			),
+			UserAgent: visitor.AgentType,
+			OutBizID:  uuid.NewString(),
+		},
+	})
+	if err != nil {
+		i.logger.Warnf("[Investigation] send log error: %v", err)
+	}
+}
+
+func workspaceToVO(w *wsmodels.WorkspacePO) *wsmodels.WorkspaceVO {
+	historyIDs := []string{}
+	if w.HistoryIDs != "" {
+		_ = jsoniter.UnmarshalFromString(w.HistoryIDs, &historyIDs)
+	}
+
+	return &wsmodels.WorkspaceVO{
+		ID:          w.ID,
+		Name:        w.Name,
+		LogType:     w.LogType,
+		HistoryIDs:  historyIDs,
+		Status:      w.Status,
+		RecordCount: w.RecordCount,
+		ErrMsg:      w.ErrMsg,
+		ExpireAt:    w.ExpireAt,
+		CreatedAt:   w.CreatedAt,
+		CreatedBy:   w.CreatedBy,
+	}
+}
@@ -0,0 +1,166 @@
+package logics
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"AuditLog/common"
+	"AuditLog/common/constants/wsconsts"
+	"AuditLog/common/utils/archiveutils"
+	"AuditLog/common/utils/dumplogutils"
+	"AuditLog/errors"
+	"AuditLog/interfaces/mock"
+	"AuditLog/models"
+	"AuditLog/models/lsmodels"
+	"AuditLog/models/wsmodels"
+	"AuditLog/test/mock_log"
+)
+
+func TestInvestigation(t *testing.T) {
+	Convey("Investigation", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		logger := mock_log.NewMockLogger(ctrl)
+		wsRepo := mock.NewMockInvestigationRepo(ctrl)
+		historyLogRepo := mock.NewMockHistoryRepo(ctrl)
+		logStrategyRepo := mock.NewMockLogStrategyRepo(ctrl)
+		oss := mock.NewMockOssGatewayRepo(ctrl)
+		logMgnt := mock.NewMockLogMgnt(ctrl)
+		inv := &investigation{
+			logger:          logger,
+			wsRepo:          wsRepo,
+			historyLogRepo:  historyLogRepo,
+			logStrategyRepo: logStrategyRepo,
+			ossGateway:      oss,
+			logMgnt:         logMgnt,
+			manifestSecret:  "secret",
+		}
+
+		logger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+		logger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+
+		ctx := context.WithValue(context.Background(), common.VisitorKey, &models.Visitor{
+			ID:   "test_user",
+			Name: "Test User",
+		})
+		po := &wsmodels.WorkspacePO{
+			ID:         1,
+			Name:       "调查-001",
+			LogType:    common.Login,
+			HistoryIDs: `["h1"]`,
+			Status:     wsconsts.StatusReady,
+			CreatedBy:  "test_user",
+		}
+
+		Convey("获取工作区列表", func() {
+			wsRepo.EXPECT().CountWorkspacesByCondition("WHERE f_created_by=?", []interface{}{"test_user"}).Return(int64(3), nil)
+			wsRepo.EXPECT().GetWorkspacesByCondition("WHERE f_created_by=? ORDER BY f_created_at DESC LIMIT ? OFFSET ?", []interface{}{"test_user", 1, 0}).
+				Return([]*wsmodels.WorkspacePO{po}, nil)
+
+			res, err := inv.GetWorkspaces(ctx, &wsmodels.GetWorkspacesReq{Limit: 1})
+			assert.NoError(t, err)
+			assert.Equal(t, int64(3), res.TotalCount)
+			assert.Equal(t, []string{"h1"}, res.Entries[0].HistoryIDs)
+		})
+
+		Convey("获取其他用户的工作区", func() {
+			wsRepo.EXPECT().GetWorkspaceByID(int64(1)).Return(&wsmodels.WorkspacePO{ID: 1, CreatedBy: "other"}, nil)
+			_, err := inv.GetWorkspace(ctx, 1)
+			assert.Equal(t, errors.WorkspaceNotFoundErr, err.(*errors.ErrorResp).Code())
+		})
+
+		Convey("新建工作区", func() {
+			req := &wsmodels.NewWorkspaceReq{
+				Name:       "调查-001",
+				LogType:    common.Login,
+				HistoryIDs: []string{"h1"},
+			}
+
+			Convey("历史日志不存在", func() {
+				historyLogRepo.EXPECT().GetHistoryLogByID("h1").Return(nil, sql.ErrNoRows)
+				_, err := inv.NewWorkspace(ctx, req)
+				assert.Equal(t, errors.BadRequestErr, err.(*errors.ErrorResp).Code())
+			})
+
+			Convey("历史日志类型不一致", func() {
+				historyLogRepo.EXPECT().GetHistoryLogByID("h1").Return(&models.HistoryPO{ID: "h1", Type: int8(common.LogTypeMap[common.Operation])}, nil)
+				_, err := inv.NewWorkspace(ctx, req)
+				assert.Equal(t, errors.BadRequestErr, err.(*errors.ErrorResp).Code())
+			})
+
+			Convey("有效天数超出上限", func() {
+				req.ExpireDays = wsconsts.MaxExpireDays + 1
+				_, err := inv.NewWorkspace(ctx, req)
+				assert.Equal(t, errors.BadRequestErr, err.(*errors.ErrorResp).Code())
+			})
+		})
+
+		Convey("恢复历史日志", func() {
+			date := time.Date(2024, 12, 19, 10, 30, 0, 0, time.Local)
+			line, err := dumplogutils.LogInfo2JSONLString(&models.LogPO{
+				LogID:    "1001",
+				Date:     date.UnixMicro(),
+				UserName: "test_user",
+				Level:    1,
+				OpType:   1,
+				Msg:      "test message",
+			}, common.Login)
+			assert.NoError(t, err)
+			content := []byte(line + "\n" + line + "\n")
+
+			history := &models.HistoryPO{ID: "h1", Name: "login.jsonl", Size: int64(len(content)), OssID: "oss"}
+			logStrategyRepo.EXPECT().GetLogPrefix().Return("prefix", nil)
+			oss.EXPECT().GetDownLoadInfo("oss", "prefix/h1", "login.jsonl", true).Return(&models.OSSRequestInfo{URL: "url"}, 200, nil)
+			oss.EXPECT().DownloadBlockByURL("url", gomock.Any(), gomock.Any(), gomock.Any(), int64(0), int64(len(content)-1)).
+				Return(&http.Response{Body: io.NopCloser(bytes.NewReader(content))}, 206, nil)
+			wsRepo.EXPECT().GetWorkspaceByID(int64(1)).Return(po, nil).AnyTimes()
+
+			manifest := &lsmodels.DumpManifest{FileName: "login.jsonl", Size: int64(len(content)), Checksum: archiveutils.Checksum(content)}
+			manifest.Signature, err = archiveutils.SignManifest("secret", manifest)
+			assert.NoError(t, err)
+
+			Convey("成功", func() {
+				manifestStr, _ := json.Marshal(manifest)
+				historyLogRepo.EXPECT().GetManifest("h1").Return(string(manifestStr), nil)
+				wsRepo.EXPECT().NewLogs(int64(1), gomock.Any()).DoAndReturn(func(_ int64, logs []*models.LogPO) error {
+					assert.Len(t, logs, 2)
+					assert.Equal(t, "1001", logs[0].LogID)
+					assert.Equal(t, date.UnixMicro(), logs[0].Date)
+					return nil
+				})
+				wsRepo.EXPECT().UpdateWorkspaceStatus(int64(1), wsconsts.StatusReady, int64(2), "").Return(nil)
+
+				inv.restore(ctx, po, []*models.HistoryPO{history})
+			})
+
+			Convey("转存清单校验失败时清除已恢复的日志", func() {
+				manifest.Checksum = "bad"
+				manifestStr, _ := json.Marshal(manifest)
+				historyLogRepo.EXPECT().GetManifest("h1").Return(string(manifestStr), nil)
+				wsRepo.EXPECT().NewLogs(int64(1), gomock.Any()).Return(nil)
+				wsRepo.EXPECT().DeleteLogs(int64(1)).Return(nil)
+				wsRepo.EXPECT().UpdateWorkspaceStatus(int64(1), wsconsts.StatusFailed, int64(0), gomock.Any()).Return(nil)
+
+				inv.restore(ctx, po, []*models.HistoryPO{history})
+			})
+		})
+
+		Convey("删除过期工作区", func() {
+			wsRepo.EXPECT().GetWorkspacesByCondition("WHERE f_expire_at<?", gomock.Any()).Return([]*wsmodels.WorkspacePO{po}, nil)
+			wsRepo.EXPECT().DeleteWorkspace(int64(1)).Return(nil)
+
+			err := inv.CleanExpiredWorkspaces(ctx)
+			assert.NoError(t, err)
+		})
+	})
+}
@@ -0,0 +1,371 @@
+package logics
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	jsoniter "github.com/json-iterator/go"
+
+	"AuditLog/common"
+	"AuditLog/common/constants/logconsts"
+	"AuditLog/common/enums/oprlogenums"
+	"AuditLog/common/utils"
+	"AuditLog/errors"
+	"AuditLog/gocommon/api"
+	"AuditLog/infra"
+	"AuditLog/interfaces"
+	"AuditLog/locale"
+	"AuditLog/models"
+	"AuditLog/models/lhmodels"
+)
+
+var (
+	lhOnce sync.Once
+	lh     *legalHold
+)
+
+type legalHold struct {
+	logger   api.Logger
+	holdRepo interfaces.LegalHoldRepo
+	logMgnt  interfaces.LogMgnt
+}
+
+func NewLegalHold() interfaces.LegalHold {
+	lhOnce.Do(func() {
+		lh = &legalHold{
+			logger:   logger,
+			holdRepo: legalHoldRepo,
+			logMgnt:  NewLogMgnt(),
+		}
+	})
+	return lh
+}
+
+func (l *legalHold) GetHolds(ctx context.Context, req *lhmodels.GetLegalHoldsReq) (res *lhmodels.GetLegalHoldsRes, err error) {
+	var condition string
+	params := []interface{}{}
+	if req.LogType != "" {
+		condition = "WHERE f_log_type=?"
+		params = append(params, req.LogType)
+	}
+
+	count, err := l.holdRepo.CountHoldsByCondition(condition, params)
+	if err != nil {
+		return nil, fmt.Errorf("[GetLegalHolds] count holds failed: %w", err)
+	}
+
+	condition += " ORDER BY f_created_at DESC"
+	if req.Limit > 0 {
+		condition += " LIMIT ? OFFSET ?"
+		params = append(params, req.Limit, req.Offset)
+	}
+
+	holds, err := l.holdRepo.GetHoldsByCondition(condition, params)
+	if err != nil {
+		return nil, fmt.Errorf("[GetLegalHolds] get holds failed: %w", err)
+	}
+
+	res = &lhmodels.GetLegalHoldsRes{
+		Entries:    make([]*lhmodels.LegalHoldVO, 0, len(holds)),
+		TotalCount: count,
+	}
+	for _, hold := range holds {
+		res.Entries = append(res.Entries, holdToVO(hold))
+	}
+	return
+}
+
+func (l *legalHold) GetHold(ctx context.Context, id int64) (res *lhmodels.LegalHoldVO, err error) {
+	hold, err := l.holdRepo.GetHoldByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if hold == nil {
+		return nil, l.notFoundErr(ctx, id)
+	}
+	return holdToVO(hold), nil
+}
+
+func (l *legalHold) NewHold(ctx context.Context, req *lhmodels.LegalHoldVO) (id int64, err error) {
+	if err = l.validate(ctx, req); err != nil {
+		return 0, err
+	}
+
+	existing, err := l.holdRepo.GetHoldsByCondition("WHERE f_name=?", []interface{}{req.Name})
+	if err != nil {
+		return 0, err
+	}
+	if len(existing) > 0 {
+		return 0, errors.NewCtx(ctx, errors.LegalHoldConflictErr, "Legal hold already exists", nil)
+	}
+
+	uid, err := infra.GetUniqueID()
+	if err != nil {
+		l.logger.Errorf("new sonyflake id error: %v", err)
+		return 0, err
+	}
+
+	visitor := ctx.Value(common.VisitorKey).(*models.Visitor)
+	hold, err := voToHold(req)
+	if err != nil {
+		return 0, err
+	}
+	hold.ID = int64(uid)
+	hold.CreatedBy = visitor.ID
+	hold.CreatedAt = time.Now().UnixMicro()
+	if err = l.holdRepo.NewHold(hold); err != nil {
+		return 0, err
+	}
+
+	go l.autilog(
+		ctx,
+		req,
+		logconsts.LogLevel.INFO,
+		logconsts.OpType.ManagementType.CREATE,
+		locale.NewLegalHold,
+	)
+
+	return int64(uid), nil
+}
+
+func (l *legalHold) UpdateHold(ctx context.Context, id int64, req *lhmodels.LegalHoldVO) (err error) {
+	if err = l.validate(ctx, req); err != nil {
+		return err
+	}
+
+	checked, err := l.holdRepo.GetHoldByID(id)
+	if err != nil {
+		return err
+	}
+	if checked == nil {
+		return l.notFoundErr(ctx, id)
+	}
+
+	existing, err := l.holdRepo.GetHoldsByCondition("WHERE f_name=?", []interface{}{req.Name})
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 && existing[0].ID != id {
+		return errors.NewCtx(ctx, errors.LegalHoldConflictErr, "Legal hold already exists", nil)
+	}
+
+	visitor := ctx.Value(common.VisitorKey).(*models.Visitor)
+	hold, err := voToHold(req)
+	if err != nil {
+		return err
+	}
+	hold.ID = id
+	hold.UpdatedBy = visitor.ID
+	hold.UpdatedAt = time.Now().UnixMicro()
+	if err = l.holdRepo.UpdateHold(hold); err != nil {
+		return err
+	}
+
+	// 缩小保留范围会使日志可被清理, 记为警告
+	go l.autilog(
+		ctx,
+		req,
+		logconsts.LogLevel.WARN,
+		logconsts.OpType.ManagementType.EDIT,
+		locale.EditLegalHold,
+	)
+
+	return
+}
+
+func (l *legalHold) DeleteHold(ctx context.Context, id int64) (err error) {
+	hold, err := l.holdRepo.GetHoldByID(id)
+	if err != nil {
+		return err
+	}
+	if hold == nil {
+		return
+	}
+	if err = l.holdRepo.DeleteHold(id); err != nil {
+		return err
+	}
+
+	go l.autilog(
+		ctx,
+		holdToVO(hold),
+		logconsts.LogLevel.WARN,
+		logconsts.OpType.ManagementType.DELETE,
+		locale.DeleteLegalHold,
+	)
+
+	return
+}
+
+// GetMatchedHolds 获取任一日志类型在 [begin, end) 时间范围内生效的法律保留, end 为0表示不限
+func (l *legalHold) GetMatchedHolds(logTypes []string, begin, end int64) (holds []*lhmodels.LegalHoldVO, err error) {
+	condition := "WHERE (f_log_type=''"
+	params := make([]interface{}, 0, len(logTypes)+2)
+	for _, logType := range logTypes {
+		condition += " OR f_log_type=?"
+		params = append(params, logType)
+	}
+	condition += ") AND (f_end_time=0 OR f_end_time>?)"
+	params = append(params, begin)
+	if end != 0 {
+		condition += " AND f_begin_time<?"
+		params = append(params, end)
+	}
+
+	pos, err := l.holdRepo.GetHoldsByCondition(condition, params)
+	if err != nil {
+		return nil, fmt.Errorf("[GetMatchedHolds] get holds failed: %w", err)
+	}
+
+	holds = make([]*lhmodels.LegalHoldVO, 0, len(pos))
+	for _, po := range pos {
+		holds = append(holds, holdToVO(po))
+	}
+	return
+}
+
+// validate 校验json schema无法覆盖的参数
+func (l *legalHold) validate(ctx context.Context, req *lhmodels.LegalHoldVO) (err error) {
+	badRequest := func(msg string) error {
+		return errors.NewCtx(ctx, errors.BadRequestErr, msg, nil)
+	}
+
+	if req.LogType != "" && !slices.Contains(common.AllLogType, req.LogType) && !oprlogenums.BizType(req.LogType).Check() {
+		return badRequest(fmt.Sprintf("invalid log_type: %s", req.LogType))
+	}
+
+	if req.EndTime != 0 && req.EndTime <= req.BeginTime {
+		return badRequest("end_time must be greater than begin_time")
+	}
+
+	for _, dept := range req.Departments {
+		if strings.TrimRight(dept, "/") == "" {
+			return badRequest(fmt.Sprintf("invalid department: %s", dept))
+		}
+	}
+	return nil
+}
+
+func (l *legalHold) notFoundErr(ctx context.Context, id int64) error {
+	return errors.NewCtx(
+		ctx,
+		errors.LegalHoldNotFoundErr,
+		"Legal hold not found",
+		map[string]interface{}{
+			"id": []int64{id},
+		},
+	)
+}
+
+// 记录审计日志
+func (l *legalHold) autilog(ctx context.Context, hold *lhmodels.LegalHoldVO, level int, opType int, opKey string) {
+	visitor := ctx.Value(common.VisitorKey).(*models.Visitor)
+
+	noLimit := locale.GetI18nCtx(ctx, locale.LegalHoldNoLimit)
+	formatTime := func(t int64) string {
+		if t == 0 {
+			return noLimit
+		}
+		return utils.FormatTime(time.UnixMicro(t).Local())
+	}
+	formatList := func(list []string) string {
+		if len(list) == 0 {
+			return noLimit
+		}
+		return strings.Join(list, ", ")
+	}
+	logType := hold.LogType
+	if logType == "" {
+		logType = locale.GetI18nCtx(ctx, locale.LegalHoldAllLogs)
+	}
+
+	err := l.logMgnt.SendLog(&models.SendLogVo{
+		LogType:  common.Management,
+		Language: "",
+		LogContent: &models.AuditLog{
+			UserID:   visitor.ID,
+			UserName: visitor.Name,
+			UserType: common.AuthenticatedUser,
+			Level:    level,
+			OpType:   opType,
+			Date:     time.Now().UnixMicro(),
+			IP:       visitor.IP,
+			Mac:      visitor.Mac,
+			Msg:      fmt.Sprintf(locale.GetI18nCtx(ctx, opKey), hold.Name),
+			Exmsg: fmt.Sprintf(
+				locale.GetI18nCtx(ctx, locale.LegalHoldExMsg),
+				hold.Reason,
+				logType,
+				formatTime(hold.BeginTime),
+				formatTime(hold.EndTime),
+				formatList(hold.UserIDs),
+				formatList(hold.Departments),
+			),
+			UserAgent: visitor.AgentType,
+			OutBizID:  uuid.NewString(),
+		},
+	})
+	if err != nil {
+		l.logger.Warnf("[LegalHold] send log error: %v", err)
+	}
+}
+
+func voToHold(vo *lhmodels.LegalHoldVO) (hold *lhmodels.LegalHoldPO, err error) {
+	userIDs := vo.UserIDs
+	if userIDs == nil {
+		userIDs = []string{}
+	}
+	users, err := jsoniter.MarshalToString(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	departments := vo.Departments
+	if departments == nil {
+		departments = []string{}
+	}
+	depts, err := jsoniter.MarshalToString(departments)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lhmodels.LegalHoldPO{
+		Name:        vo.Name,
+		Reason:      vo.Reason,
+		LogType:     vo.LogType,
+		BeginTime:   vo.BeginTime,
+		EndTime:     vo.EndTime,
+		UserIDs:     users,
+		Departments: depts,
+	}, nil
+}
+
+func holdToVO(hold *lhmodels.LegalHoldPO) *lhmodels.LegalHoldVO {
+	userIDs, departments := []string{}, []string{}
+	// 入库前已校验, 解析失败时按不限处理
+	if hold.UserIDs != "" {
+		_ = jsoniter.UnmarshalFromString(hold.UserIDs, &userIDs)
+	}
+	if hold.Departments != "" {
+		_ = jsoniter.UnmarshalFromString(hold.Departments, &departments)
+	}
+
+	return &lhmodels.LegalHoldVO{
+		ID:          hold.ID,
+		Name:        hold.Name,
+		Reason:      hold.Reason,
+		LogType:     hold.LogType,
+		BeginTime:   hold.BeginTime,
+		EndTime:     hold.EndTime,
+		UserIDs:     userIDs,
+		Departments: departments,
+		CreatedAt:   hold.CreatedAt,
+		CreatedBy:   hold.CreatedBy,
+		UpdatedAt:   hold.UpdatedAt,
+		UpdatedBy:   hold.UpdatedBy,
+	}
+}
@@ -0,0 +1,151 @@
+package logics
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"AuditLog/common"
+	"AuditLog/errors"
+	"AuditLog/interfaces/mock"
+	"AuditLog/models"
+	"AuditLog/models/lhmodels"
+	"AuditLog/test/mock_log"
+)
+
+func TestLegalHold(t *testing.T) {
+	Convey("LegalHold", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		logger := mock_log.NewMockLogger(ctrl)
+		holdRepo := mock.NewMockLegalHoldRepo(ctrl)
+		logMgnt := mock.NewMockLogMgnt(ctrl)
+		legalHold := &legalHold{
+			logger:   logger,
+			holdRepo: holdRepo,
+			logMgnt:  logMgnt,
+		}
+
+		ctx := context.WithValue(context.Background(), common.VisitorKey, &models.Visitor{
+			ID:   "test_user",
+			Name: "Test User",
+		})
+		req := &lhmodels.LegalHoldVO{
+			Name:        "调查-001",
+			Reason:      "内部调查",
+			LogType:     common.Operation,
+			BeginTime:   100,
+			EndTime:     200,
+			UserIDs:     []string{"u1"},
+			Departments: []string{"组织/部门"},
+		}
+		po := &lhmodels.LegalHoldPO{
+			ID:          1,
+			Name:        "调查-001",
+			LogType:     common.Operation,
+			BeginTime:   100,
+			EndTime:     200,
+			UserIDs:     `["u1"]`,
+			Departments: `["组织/部门"]`,
+		}
+
+		Convey("获取保留列表", func() {
+			holdRepo.EXPECT().CountHoldsByCondition("WHERE f_log_type=?", []interface{}{common.Operation}).Return(int64(3), nil)
+			holdRepo.EXPECT().GetHoldsByCondition("WHERE f_log_type=? ORDER BY f_created_at DESC LIMIT ? OFFSET ?", []interface{}{common.Operation, 1, 0}).
+				Return([]*lhmodels.LegalHoldPO{po}, nil)
+
+			res, err := legalHold.GetHolds(ctx, &lhmodels.GetLegalHoldsReq{LogType: common.Operation, Limit: 1})
+			assert.NoError(t, err)
+			assert.Equal(t, int64(3), res.TotalCount)
+			assert.Equal(t, []string{"u1"}, res.Entries[0].UserIDs)
+			assert.Equal(t, []string{"组织/部门"}, res.Entries[0].Departments)
+		})
+
+		Convey("获取不存在的保留", func() {
+			holdRepo.EXPECT().GetHoldByID(int64(1)).Return(nil, nil)
+			_, err := legalHold.GetHold(ctx, 1)
+			assert.Equal(t, errors.LegalHoldNotFoundErr, err.(*errors.ErrorResp).Code())
+		})
+
+		Convey("新建保留", func() {
+			Convey("成功并记录管理日志", func() {
+				holdRepo.EXPECT().GetHoldsByCondition("WHERE f_name=?", []interface{}{"调查-001"}).Return([]*lhmodels.LegalHoldPO{}, nil)
+				holdRepo.EXPECT().NewHold(gomock.Any()).DoAndReturn(func(hold *lhmodels.LegalHoldPO) error {
+					assert.Equal(t, "test_user", hold.CreatedBy)
+					assert.Equal(t, `["u1"]`, hold.UserIDs)
+					assert.Equal(t, `["组织/部门"]`, hold.Departments)
+					return nil
+				})
+				sent := make(chan *models.SendLogVo, 1)
+				logMgnt.EXPECT().SendLog(gomock.Any()).DoAndReturn(func(vo *models.SendLogVo) error {
+					sent <- vo
+					return nil
+				})
+
+				id, err := legalHold.NewHold(ctx, req)
+				assert.NoError(t, err)
+				assert.NotZero(t, id)
+
+				vo := <-sent
+				assert.Equal(t, common.Management, vo.LogType)
+				assert.Equal(t, "test_user", vo.LogContent.UserID)
+			})
+
+			Convey("名称已存在", func() {
+				holdRepo.EXPECT().GetHoldsByCondition("WHERE f_name=?", []interface{}{"调查-001"}).Return([]*lhmodels.LegalHoldPO{po}, nil)
+				_, err := legalHold.NewHold(ctx, req)
+				assert.Equal(t, errors.LegalHoldConflictErr, err.(*errors.ErrorResp).Code())
+			})
+
+			Convey("无效的日志类型", func() {
+				req.LogType = "unknown"
+				_, err := legalHold.NewHold(ctx, req)
+				assert.Equal(t, errors.BadRequestErr, err.(*errors.ErrorResp).Code())
+			})
+
+			Convey("结束时间早于开始时间", func() {
+				req.EndTime = 50
+				_, err := legalHold.NewHold(ctx, req)
+				assert.Equal(t, errors.BadRequestErr, err.(*errors.ErrorResp).Code())
+			})
+		})
+
+		Convey("更新保留", func() {
+			Convey("不存在", func() {
+				holdRepo.EXPECT().GetHoldByID(int64(1)).Return(nil, nil)
+				err := legalHold.UpdateHold(ctx, 1, req)
+				assert.Equal(t, errors.LegalHoldNotFoundErr, err.(*errors.ErrorResp).Code())
+			})
+
+			Convey("名称与其他保留冲突", func() {
+				holdRepo.EXPECT().GetHoldByID(int64(2)).Return(&lhmodels.LegalHoldPO{ID: 2}, nil)
+				holdRepo.EXPECT().GetHoldsByCondition("WHERE f_name=?", []interface{}{"调查-001"}).Return([]*lhmodels.LegalHoldPO{po}, nil)
+				err := legalHold.UpdateHold(ctx, 2, req)
+				assert.Equal(t, errors.LegalHoldConflictErr, err.(*errors.ErrorResp).Code())
+			})
+		})
+
+		Convey("解除保留", func() {
+			holdRepo.EXPECT().GetHoldByID(int64(1)).Return(po, nil)
+			holdRepo.EXPECT().DeleteHold(int64(1)).Return(nil)
+			logMgnt.EXPECT().SendLog(gomock.Any()).Return(nil).AnyTimes()
+
+			assert.NoError(t, legalHold.DeleteHold(ctx, 1))
+		})
+
+		Convey("获取生效的保留", func() {
+			holdRepo.EXPECT().GetHoldsByCondition(
+				"WHERE (f_log_type='' OR f_log_type=? OR f_log_type=?) AND (f_end_time=0 OR f_end_time>?) AND f_begin_time<?",
+				[]interface{}{common.Operation, "doc_operation", int64(150), int64(300)},
+			).Return([]*lhmodels.LegalHoldPO{po}, nil)
+
+			holds, err := legalHold.GetMatchedHolds([]string{common.Operation, "doc_operation"}, 150, 300)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, len(holds))
+			assert.Equal(t, []string{"u1"}, holds[0].UserIDs)
+		})
+	})
+}
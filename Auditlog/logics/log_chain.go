@@ -0,0 +1,395 @@
+package logics
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"AuditLog/common"
+	"AuditLog/common/constants/lcconsts"
+	"AuditLog/common/utils/logchainutils"
+	"AuditLog/gocommon/api"
+	"AuditLog/interfaces"
+	"AuditLog/models/lcmodels"
+)
+
+var (
+	lcOnce sync.Once
+	lc     *LogChain
+)
+
+type LogChain struct {
+	logger             api.Logger
+	logChainRepo       interfaces.LogChainRepo
+	secret             string // 检查点签名密钥
+	checkpointInterval int64  // 每写入多少条日志生成一个检查点
+	batchSize          int
+}
+
+func NewLogChain() interfaces.LogChain {
+	lcOnce.Do(func() {
+		lc = &LogChain{
+			logger:             logger,
+			logChainRepo:       logChainRepo,
+			secret:             common.SvcConfig.LogChainSecret,
+			checkpointInterval: logchainutils.CheckpointInterval(common.SvcConfig.LogChainCheckpoint),
+			batchSize:          lcconsts.VerifyBatchSize,
+		}
+	})
+	return lc
+}
+
+// chainAnchors 校验范围内的转储锚点, 分别按第一条和最后一条记录的序号索引
+type chainAnchors struct {
+	byFirst map[int64]*lcmodels.ChainAnchor
+	byLast  map[int64]*lcmodels.ChainAnchor
+}
+
+// Verify 校验哈希链
+// 1. 链头需要签名有效, 且不存在序号大于链头的记录、检查点或锚点
+// 2. 范围默认为 [现存第一条记录, 链头], 结束序号超过链头时截断到链头
+// 3. 逐条重新计算哈希并检查与上一条记录的链接, 已转储的记录需要由转储锚点衔接, 否则视为记录缺失
+// 4. 范围内第一条记录的前序记录不在数据库中时, 其前序哈希需要与前序记录的检查点或转储锚点一致
+// 5. 范围内每个检查点位置都需要有签名有效的检查点, 且与对应记录一致
+// 6. 校验到链头时, 链头需要与最后一条记录一致
+// 未入链(序号为0)的记录不受哈希链保护, 存在时校验失败
+func (l *LogChain) Verify(ctx context.Context, logType string, beginSeq, endSeq int64) (res *lcmodels.VerifyRes, err error) {
+	// 密钥为空时检查点签名可被伪造, 拒绝校验
+	if l.secret == "" {
+		l.logger.Errorf("[Verify] %v", logchainutils.ErrEmptySecret)
+		return nil, logchainutils.ErrEmptySecret
+	}
+
+	head, err := l.logChainRepo.GetHead(logType)
+	if err != nil {
+		l.logger.Errorf("[Verify] get chain head error: %v", err)
+		return nil, err
+	}
+
+	// 未指定起始序号时从现存的第一条记录开始, 之前的记录可能已转储
+	fromFirst := beginSeq <= 0
+	if fromFirst {
+		beginSeq = 1
+	}
+	if endSeq <= 0 || endSeq > head.Seq {
+		endSeq = head.Seq
+	}
+
+	res = &lcmodels.VerifyRes{
+		LogType:  logType,
+		BeginSeq: beginSeq,
+		EndSeq:   endSeq,
+		Valid:    true,
+	}
+
+	unchained, firstUnchained, err := l.logChainRepo.CountUnchained(logType)
+	if err != nil {
+		l.logger.Errorf("[Verify] count unchained records error: %v", err)
+		return nil, err
+	}
+	res.UnchainedCount = unchained
+
+	broken, err := l.checkHead(logType, head)
+	if err != nil {
+		return nil, err
+	}
+	if broken == nil && beginSeq <= endSeq {
+		if broken, err = l.checkRange(logType, head, res, fromFirst); err != nil {
+			return nil, err
+		}
+	}
+	if broken == nil && unchained > 0 {
+		broken = &lcmodels.BrokenLink{LogID: firstUnchained, Reason: lcconsts.Unchained}
+	}
+
+	if broken != nil {
+		res.Valid = false
+		res.FirstBroken = broken
+	}
+
+	return res, nil
+}
+
+// checkHead 校验链头签名, 并检查链头之后是否还有记录、检查点或锚点
+// 删除最新的记录后回退链头时, 之前生成的检查点仍在链头之后
+func (l *LogChain) checkHead(logType string, head *lcmodels.LogChainHeadPO) (*lcmodels.BrokenLink, error) {
+	// 初始化时写入的链头没有签名
+	genesis := head.Seq == 0 && head.Hash == lcconsts.GenesisHash && head.Signature == ""
+	if !genesis {
+		if ok, err := logchainutils.VerifyHead(l.secret, head); err != nil || !ok {
+			return &lcmodels.BrokenLink{
+				Seq:          head.Seq,
+				Reason:       lcconsts.HeadSignature,
+				ExpectedHash: head.Hash,
+			}, nil
+		}
+	}
+
+	recs, err := l.logChainRepo.GetRecords(logType, head.Seq+1, math.MaxInt64, 1)
+	if err != nil {
+		l.logger.Errorf("[Verify] get chain records after head error: %v", err)
+		return nil, err
+	}
+	if len(recs) > 0 {
+		return &lcmodels.BrokenLink{
+			Seq:          recs[0].Seq,
+			LogID:        recs[0].LogID,
+			Reason:       lcconsts.HeadRollback,
+			ExpectedHash: head.Hash,
+			ActualHash:   recs[0].Hash,
+		}, nil
+	}
+
+	cps, err := l.logChainRepo.GetCheckpoints(logType, head.Seq+1, math.MaxInt64)
+	if err != nil {
+		l.logger.Errorf("[Verify] get checkpoints after head error: %v", err)
+		return nil, err
+	}
+	if len(cps) > 0 {
+		return &lcmodels.BrokenLink{
+			Seq:          cps[0].Seq,
+			LogID:        cps[0].LogID,
+			Reason:       lcconsts.HeadRollback,
+			ExpectedHash: head.Hash,
+			ActualHash:   cps[0].Hash,
+		}, nil
+	}
+
+	anchors, err := l.logChainRepo.GetAnchors(logType, head.Seq+1, math.MaxInt64)
+	if err != nil {
+		l.logger.Errorf("[Verify] get anchors after head error: %v", err)
+		return nil, err
+	}
+	if len(anchors) > 0 {
+		return &lcmodels.BrokenLink{
+			Seq:          anchors[0].LastSeq,
+			LogID:        anchors[0].LastLogID,
+			Reason:       lcconsts.HeadRollback,
+			ExpectedHash: head.Hash,
+			ActualHash:   anchors[0].LastHash,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// checkRange 校验 [res.BeginSeq, res.EndSeq] 范围内的记录, 返回第一处断裂
+func (l *LogChain) checkRange(logType string, head *lcmodels.LogChainHeadPO, res *lcmodels.VerifyRes, fromFirst bool) (*lcmodels.BrokenLink, error) {
+	beginSeq, endSeq := res.BeginSeq, res.EndSeq
+
+	// 包含范围之前的一条记录, 用于衔接范围内的第一条记录
+	cps, err := l.logChainRepo.GetCheckpoints(logType, beginSeq-1, endSeq)
+	if err != nil {
+		l.logger.Errorf("[Verify] get checkpoints error: %v", err)
+		return nil, err
+	}
+	cpMap := make(map[int64]*lcmodels.LogChainCheckpointPO, len(cps))
+	for _, cp := range cps {
+		cpMap[cp.Seq] = cp
+	}
+
+	anchorList, err := l.logChainRepo.GetAnchors(logType, beginSeq-1, endSeq)
+	if err != nil {
+		l.logger.Errorf("[Verify] get anchors error: %v", err)
+		return nil, err
+	}
+	anchors := &chainAnchors{
+		byFirst: make(map[int64]*lcmodels.ChainAnchor, len(anchorList)),
+		byLast:  make(map[int64]*lcmodels.ChainAnchor, len(anchorList)),
+	}
+	for _, anchor := range anchorList {
+		anchors.byFirst[anchor.FirstSeq] = anchor
+		anchors.byLast[anchor.LastSeq] = anchor
+	}
+
+	// 指定起始序号时读取前一条记录, 用于校验范围内第一条记录的链接
+	var prev *lcmodels.LogChainRecordPO
+	if !fromFirst && beginSeq > 1 {
+		var prevRecs []*lcmodels.LogChainRecordPO
+		prevRecs, err = l.logChainRepo.GetRecords(logType, beginSeq-1, beginSeq-1, 1)
+		if err != nil {
+			l.logger.Errorf("[Verify] get previous chain record error: %v", err)
+			return nil, err
+		}
+		if len(prevRecs) > 0 {
+			prev = prevRecs[0]
+		}
+	}
+
+	for cursor := beginSeq; cursor <= endSeq; {
+		var recs []*lcmodels.LogChainRecordPO
+		recs, err = l.logChainRepo.GetRecords(logType, cursor, endSeq, l.batchSize)
+		if err != nil {
+			l.logger.Errorf("[Verify] get chain records error: %v", err)
+			return nil, err
+		}
+
+		for _, rec := range recs {
+			if res.CheckedCount == 0 {
+				if fromFirst {
+					res.BeginSeq = rec.Seq
+				} else if rec.Seq != beginSeq {
+					return l.missingLink(prev, beginSeq), nil
+				}
+			}
+
+			expectedPrev, broken := l.expectedPrevHash(prev, rec, cpMap, anchors)
+			if broken != nil {
+				return broken, nil
+			}
+			if broken = l.checkRecord(expectedPrev, rec, cpMap[rec.Seq]); broken != nil {
+				return broken, nil
+			}
+			res.CheckedCount++
+			prev = rec
+		}
+
+		if len(recs) < l.batchSize {
+			break
+		}
+		cursor = recs[len(recs)-1].Seq + 1
+	}
+
+	// 范围末尾的记录缺失
+	if res.CheckedCount == 0 || prev.Seq < endSeq {
+		return l.missingLink(prev, res.BeginSeq), nil
+	}
+
+	// 检查点不随记录转储, 已转储的记录对应的检查点也需要存在
+	first := (res.BeginSeq + l.checkpointInterval - 1) / l.checkpointInterval * l.checkpointInterval
+	for seq := first; seq <= endSeq; seq += l.checkpointInterval {
+		cp := cpMap[seq]
+		if cp == nil {
+			return &lcmodels.BrokenLink{Seq: seq, Reason: lcconsts.CheckpointMissing}, nil
+		}
+		if !l.validCheckpoint(cp) {
+			return &lcmodels.BrokenLink{
+				Seq:          cp.Seq,
+				LogID:        cp.LogID,
+				Reason:       lcconsts.CheckpointSignature,
+				ExpectedHash: cp.Hash,
+			}, nil
+		}
+	}
+
+	if endSeq == head.Seq && prev.Hash != head.Hash {
+		return &lcmodels.BrokenLink{
+			Seq:          prev.Seq,
+			LogID:        prev.LogID,
+			Reason:       lcconsts.HeadMismatch,
+			ExpectedHash: head.Hash,
+			ActualHash:   prev.Hash,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// expectedPrevHash 获取记录应有的前序哈希, prev 为链上的上一条记录, 范围内第一条记录时为nil
+// 与 prev 之间的记录已转储时依次衔接转储锚点, 前序记录不在数据库中时由其检查点或以其结尾的转储锚点确定
+func (l *LogChain) expectedPrevHash(
+	prev, rec *lcmodels.LogChainRecordPO,
+	cps map[int64]*lcmodels.LogChainCheckpointPO,
+	anchors *chainAnchors,
+) (string, *lcmodels.BrokenLink) {
+	switch {
+	case prev != nil && rec.Seq == prev.Seq+1:
+		return prev.Hash, nil
+	case prev != nil:
+		hash := prev.Hash
+		for seq := prev.Seq + 1; seq < rec.Seq; {
+			anchor := anchors.byFirst[seq]
+			if anchor == nil || anchor.LastSeq >= rec.Seq || anchor.FirstPrevHash != hash || !l.validAnchor(anchor) {
+				return "", &lcmodels.BrokenLink{Seq: seq, Reason: lcconsts.RecordMissing, ExpectedHash: hash}
+			}
+			hash, seq = anchor.LastHash, anchor.LastSeq+1
+		}
+		return hash, nil
+	case rec.Seq == 1:
+		// 链上第一条记录的前序哈希为创世哈希
+		return lcconsts.GenesisHash, nil
+	}
+
+	if cp := cps[rec.Seq-1]; cp != nil && l.validCheckpoint(cp) {
+		return cp.Hash, nil
+	}
+	if anchor := anchors.byLast[rec.Seq-1]; anchor != nil && l.validAnchor(anchor) {
+		return anchor.LastHash, nil
+	}
+
+	return "", &lcmodels.BrokenLink{
+		Seq:        rec.Seq,
+		LogID:      rec.LogID,
+		Reason:     lcconsts.AnchorMissing,
+		ActualHash: rec.PrevHash,
+	}
+}
+
+// checkRecord 校验单条记录的前序哈希、哈希及对应的检查点
+func (l *LogChain) checkRecord(expectedPrev string, rec *lcmodels.LogChainRecordPO, cp *lcmodels.LogChainCheckpointPO) *lcmodels.BrokenLink {
+	if rec.PrevHash != expectedPrev {
+		return &lcmodels.BrokenLink{
+			Seq:          rec.Seq,
+			LogID:        rec.LogID,
+			Reason:       lcconsts.PrevHashMismatch,
+			ExpectedHash: expectedPrev,
+			ActualHash:   rec.PrevHash,
+		}
+	}
+
+	if hash := logchainutils.ComputeHash(rec.PrevHash, rec); hash != rec.Hash {
+		return &lcmodels.BrokenLink{
+			Seq:          rec.Seq,
+			LogID:        rec.LogID,
+			Reason:       lcconsts.HashMismatch,
+			ExpectedHash: hash,
+			ActualHash:   rec.Hash,
+		}
+	}
+
+	if cp == nil {
+		return nil
+	}
+	if !l.validCheckpoint(cp) {
+		return &lcmodels.BrokenLink{
+			Seq:          cp.Seq,
+			LogID:        cp.LogID,
+			Reason:       lcconsts.CheckpointSignature,
+			ExpectedHash: cp.Hash,
+			ActualHash:   rec.Hash,
+		}
+	}
+	if cp.Hash != rec.Hash || cp.LogID != rec.LogID {
+		return &lcmodels.BrokenLink{
+			Seq:          rec.Seq,
+			LogID:        rec.LogID,
+			Reason:       lcconsts.CheckpointMismatch,
+			ExpectedHash: cp.Hash,
+			ActualHash:   rec.Hash,
+		}
+	}
+
+	return nil
+}
+
+func (l *LogChain) validCheckpoint(cp *lcmodels.LogChainCheckpointPO) bool {
+	ok, err := logchainutils.VerifyCheckpoint(l.secret, cp)
+	return err == nil && ok
+}
+
+func (l *LogChain) validAnchor(anchor *lcmodels.ChainAnchor) bool {
+	ok, err := logchainutils.VerifyAnchor(l.secret, anchor)
+	return err == nil && ok
+}
+
+// missingLink 生成记录缺失的断裂信息, 缺失的是 prev 的下一条记录, prev 为nil时是范围的第一条记录
+func (l *LogChain) missingLink(prev *lcmodels.LogChainRecordPO, beginSeq int64) *lcmodels.BrokenLink {
+	if prev == nil {
+		return &lcmodels.BrokenLink{Seq: beginSeq, Reason: lcconsts.RecordMissing}
+	}
+	return &lcmodels.BrokenLink{
+		Seq:          prev.Seq + 1,
+		Reason:       lcconsts.RecordMissing,
+		ExpectedHash: prev.Hash,
+	}
+}
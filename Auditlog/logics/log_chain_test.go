@@ -0,0 +1,297 @@
+package logics
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"AuditLog/common"
+	"AuditLog/common/constants/lcconsts"
+	"AuditLog/common/utils/logchainutils"
+	"AuditLog/interfaces/mock"
+	"AuditLog/models"
+	"AuditLog/models/lcmodels"
+	"AuditLog/test/mock_log"
+)
+
+func newLogChain(logger *mock_log.MockLogger, repo *mock.MockLogChainRepo) *LogChain {
+	return &LogChain{
+		logger:             logger,
+		logChainRepo:       repo,
+		secret:             "secret",
+		checkpointInterval: 2,
+		batchSize:          2,
+	}
+}
+
+// newChainRecords 生成从 beginSeq 开始、前序哈希为 prevHash 的 n 条链上记录
+func newChainRecords(beginSeq int64, prevHash string, n int) []*lcmodels.LogChainRecordPO {
+	recs := make([]*lcmodels.LogChainRecordPO, 0, n)
+	for i := 0; i < n; i++ {
+		seq := beginSeq + int64(i)
+		rec := logchainutils.NewRecord(seq, "log"+string(rune('a'+i)), &models.AuditLog{
+			UserID:   "user1",
+			UserName: "用户1",
+			Msg:      "msg",
+			Date:     seq,
+		})
+		rec.PrevHash = prevHash
+		rec.Hash = logchainutils.ComputeHash(prevHash, rec)
+		prevHash = rec.Hash
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+func newSignedHead(seq int64, hash string) *lcmodels.LogChainHeadPO {
+	head := &lcmodels.LogChainHeadPO{LogType: 10, Seq: seq, Hash: hash}
+	head.Signature, _ = logchainutils.SignHead("secret", head)
+	return head
+}
+
+func newSignedCheckpoint(rec *lcmodels.LogChainRecordPO) *lcmodels.LogChainCheckpointPO {
+	cp := &lcmodels.LogChainCheckpointPO{LogType: 10, Seq: rec.Seq, LogID: rec.LogID, Hash: rec.Hash}
+	cp.Signature, _ = logchainutils.SignCheckpoint("secret", cp)
+	return cp
+}
+
+func newSignedAnchor(firstSeq int64, firstPrevHash string, lastSeq int64, lastHash string) *lcmodels.ChainAnchor {
+	anchor := &lcmodels.ChainAnchor{
+		LogType:       common.Login,
+		FirstSeq:      firstSeq,
+		FirstPrevHash: firstPrevHash,
+		LastSeq:       lastSeq,
+		LastHash:      lastHash,
+		Count:         lastSeq - firstSeq + 1,
+	}
+	anchor.Signature, _ = logchainutils.SignAnchor("secret", anchor)
+	return anchor
+}
+
+func TestLogChainVerify(t *testing.T) {
+	Convey("Verify", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		logger := mock_log.NewMockLogger(ctrl)
+		repo := mock.NewMockLogChainRepo(ctrl)
+		lc := newLogChain(logger, repo)
+		ctx := context.Background()
+
+		recs := newChainRecords(1, lcconsts.GenesisHash, 3)
+		head := newSignedHead(3, recs[2].Hash)
+		cp := newSignedCheckpoint(recs[1])
+
+		// expectHead 链头之后没有记录、检查点及锚点
+		expectHead := func(head *lcmodels.LogChainHeadPO) {
+			repo.EXPECT().GetHead(common.Login).Return(head, nil)
+			repo.EXPECT().CountUnchained(common.Login).Return(int64(0), "", nil)
+			repo.EXPECT().GetRecords(common.Login, head.Seq+1, int64(math.MaxInt64), 1).Return(nil, nil)
+			repo.EXPECT().GetCheckpoints(common.Login, head.Seq+1, int64(math.MaxInt64)).Return(nil, nil)
+			repo.EXPECT().GetAnchors(common.Login, head.Seq+1, int64(math.MaxInt64)).Return(nil, nil)
+		}
+
+		Convey("密钥为空时拒绝校验", func() {
+			logger.EXPECT().Errorf(gomock.Any(), gomock.Any())
+			lc.secret = ""
+			_, err := lc.Verify(ctx, common.Login, 0, 0)
+			assert.ErrorIs(t, err, logchainutils.ErrEmptySecret)
+		})
+
+		Convey("获取链头失败", func() {
+			logger.EXPECT().Errorf(gomock.Any(), gomock.Any())
+			repo.EXPECT().GetHead(common.Login).Return(nil, errors.New("db error"))
+			_, err := lc.Verify(ctx, common.Login, 0, 0)
+			assert.Error(t, err)
+		})
+
+		Convey("完整的链校验通过", func() {
+			expectHead(head)
+			repo.EXPECT().GetCheckpoints(common.Login, int64(0), int64(3)).Return([]*lcmodels.LogChainCheckpointPO{cp}, nil)
+			repo.EXPECT().GetAnchors(common.Login, int64(0), int64(3)).Return(nil, nil)
+			repo.EXPECT().GetRecords(common.Login, int64(1), int64(3), 2).Return(recs[:2], nil)
+			repo.EXPECT().GetRecords(common.Login, int64(3), int64(3), 2).Return(recs[2:], nil)
+
+			res, err := lc.Verify(ctx, common.Login, 0, 0)
+			assert.NoError(t, err)
+			assert.True(t, res.Valid)
+			assert.Equal(t, int64(3), res.CheckedCount)
+			assert.Nil(t, res.FirstBroken)
+		})
+
+		Convey("记录被修改", func() {
+			recs[1].Msg = "modified"
+			expectHead(head)
+			repo.EXPECT().GetCheckpoints(common.Login, int64(0), int64(3)).Return(nil, nil)
+			repo.EXPECT().GetAnchors(common.Login, int64(0), int64(3)).Return(nil, nil)
+			repo.EXPECT().GetRecords(common.Login, int64(1), int64(3), 2).Return(recs[:2], nil)
+
+			res, err := lc.Verify(ctx, common.Login, 0, 0)
+			assert.NoError(t, err)
+			assert.False(t, res.Valid)
+			assert.Equal(t, int64(1), res.CheckedCount)
+			assert.Equal(t, lcconsts.HashMismatch, res.FirstBroken.Reason)
+			assert.Equal(t, int64(2), res.FirstBroken.Seq)
+		})
+
+		Convey("记录被删除", func() {
+			expectHead(head)
+			repo.EXPECT().GetCheckpoints(common.Login, int64(0), int64(3)).Return(nil, nil)
+			repo.EXPECT().GetAnchors(common.Login, int64(0), int64(3)).Return(nil, nil)
+			repo.EXPECT().GetRecords(common.Login, int64(1), int64(3), 2).Return([]*lcmodels.LogChainRecordPO{recs[0], recs[2]}, nil)
+
+			res, err := lc.Verify(ctx, common.Login, 0, 0)
+			assert.NoError(t, err)
+			assert.False(t, res.Valid)
+			assert.Equal(t, lcconsts.RecordMissing, res.FirstBroken.Reason)
+			assert.Equal(t, int64(2), res.FirstBroken.Seq)
+		})
+
+		Convey("中间已转储的记录由锚点衔接", func() {
+			anchor := newSignedAnchor(2, recs[0].Hash, 2, recs[1].Hash)
+			expectHead(head)
+			repo.EXPECT().GetCheckpoints(common.Login, int64(0), int64(3)).Return([]*lcmodels.LogChainCheckpointPO{cp}, nil)
+			repo.EXPECT().GetAnchors(common.Login, int64(0), int64(3)).Return([]*lcmodels.ChainAnchor{anchor}, nil)
+			repo.EXPECT().GetRecords(common.Login, int64(1), int64(3), 2).Return([]*lcmodels.LogChainRecordPO{recs[0], recs[2]}, nil)
+
+			res, err := lc.Verify(ctx, common.Login, 0, 0)
+			assert.NoError(t, err)
+			assert.True(t, res.Valid)
+			assert.Equal(t, int64(2), res.CheckedCount)
+		})
+
+		Convey("指定范围时校验与前一条记录的链接", func() {
+			recs[2].PrevHash = "forged"
+			recs[2].Hash = logchainutils.ComputeHash(recs[2].PrevHash, recs[2])
+			head = newSignedHead(3, recs[2].Hash)
+
+			expectHead(head)
+			repo.EXPECT().GetCheckpoints(common.Login, int64(2), int64(3)).Return([]*lcmodels.LogChainCheckpointPO{cp}, nil)
+			repo.EXPECT().GetAnchors(common.Login, int64(2), int64(3)).Return(nil, nil)
+			repo.EXPECT().GetRecords(common.Login, int64(2), int64(2), 1).Return(recs[1:2], nil)
+			repo.EXPECT().GetRecords(common.Login, int64(3), int64(3), 2).Return(recs[2:], nil)
+
+			res, err := lc.Verify(ctx, common.Login, 3, 10)
+			assert.NoError(t, err)
+			assert.False(t, res.Valid)
+			assert.Equal(t, int64(3), res.EndSeq)
+			assert.Equal(t, lcconsts.PrevHashMismatch, res.FirstBroken.Reason)
+			assert.Equal(t, recs[1].Hash, res.FirstBroken.ExpectedHash)
+		})
+
+		Convey("检查点签名无效", func() {
+			forged := &lcmodels.LogChainCheckpointPO{LogType: 10, Seq: 1, LogID: recs[0].LogID, Hash: recs[0].Hash, Signature: "forged"}
+			expectHead(head)
+			repo.EXPECT().GetCheckpoints(common.Login, int64(0), int64(3)).Return([]*lcmodels.LogChainCheckpointPO{forged}, nil)
+			repo.EXPECT().GetAnchors(common.Login, int64(0), int64(3)).Return(nil, nil)
+			repo.EXPECT().GetRecords(common.Login, int64(1), int64(3), 2).Return(recs[:2], nil)
+
+			res, err := lc.Verify(ctx, common.Login, 0, 0)
+			assert.NoError(t, err)
+			assert.False(t, res.Valid)
+			assert.Equal(t, lcconsts.CheckpointSignature, res.FirstBroken.Reason)
+		})
+
+		Convey("检查点被删除", func() {
+			expectHead(head)
+			repo.EXPECT().GetCheckpoints(common.Login, int64(0), int64(3)).Return(nil, nil)
+			repo.EXPECT().GetAnchors(common.Login, int64(0), int64(3)).Return(nil, nil)
+			repo.EXPECT().GetRecords(common.Login, int64(1), int64(3), 2).Return(recs[:2], nil)
+			repo.EXPECT().GetRecords(common.Login, int64(3), int64(3), 2).Return(recs[2:], nil)
+
+			res, err := lc.Verify(ctx, common.Login, 0, 0)
+			assert.NoError(t, err)
+			assert.False(t, res.Valid)
+			assert.Equal(t, lcconsts.CheckpointMissing, res.FirstBroken.Reason)
+			assert.Equal(t, int64(2), res.FirstBroken.Seq)
+		})
+
+		Convey("前面的记录已转储", func() {
+			remain := newChainRecords(2, "dumped", 2)
+			head = newSignedHead(3, remain[1].Hash)
+			expectHead(head)
+			repo.EXPECT().GetCheckpoints(common.Login, int64(0), int64(3)).Return([]*lcmodels.LogChainCheckpointPO{newSignedCheckpoint(remain[0])}, nil)
+			repo.EXPECT().GetRecords(common.Login, int64(1), int64(3), 2).Return(remain, nil)
+
+			Convey("由转储锚点衔接", func() {
+				anchor := newSignedAnchor(1, lcconsts.GenesisHash, 1, "dumped")
+				repo.EXPECT().GetAnchors(common.Login, int64(0), int64(3)).Return([]*lcmodels.ChainAnchor{anchor}, nil)
+
+				res, err := lc.Verify(ctx, common.Login, 0, 0)
+				assert.NoError(t, err)
+				assert.True(t, res.Valid)
+				assert.Equal(t, int64(2), res.BeginSeq)
+				assert.Equal(t, int64(2), res.CheckedCount)
+			})
+
+			Convey("没有可衔接的锚点", func() {
+				repo.EXPECT().GetAnchors(common.Login, int64(0), int64(3)).Return(nil, nil)
+
+				res, err := lc.Verify(ctx, common.Login, 0, 0)
+				assert.NoError(t, err)
+				assert.False(t, res.Valid)
+				assert.Equal(t, lcconsts.AnchorMissing, res.FirstBroken.Reason)
+				assert.Equal(t, int64(2), res.FirstBroken.Seq)
+			})
+		})
+
+		Convey("链头与最后一条记录不一致", func() {
+			head = newSignedHead(3, "forged")
+			expectHead(head)
+			repo.EXPECT().GetCheckpoints(common.Login, int64(0), int64(3)).Return([]*lcmodels.LogChainCheckpointPO{cp}, nil)
+			repo.EXPECT().GetAnchors(common.Login, int64(0), int64(3)).Return(nil, nil)
+			repo.EXPECT().GetRecords(common.Login, int64(1), int64(3), 2).Return(recs[:2], nil)
+			repo.EXPECT().GetRecords(common.Login, int64(3), int64(3), 2).Return(recs[2:], nil)
+
+			res, err := lc.Verify(ctx, common.Login, 0, 0)
+			assert.NoError(t, err)
+			assert.False(t, res.Valid)
+			assert.Equal(t, lcconsts.HeadMismatch, res.FirstBroken.Reason)
+		})
+
+		Convey("链头签名无效", func() {
+			head.Signature = "forged"
+			repo.EXPECT().GetHead(common.Login).Return(head, nil)
+			repo.EXPECT().CountUnchained(common.Login).Return(int64(0), "", nil)
+
+			res, err := lc.Verify(ctx, common.Login, 0, 0)
+			assert.NoError(t, err)
+			assert.False(t, res.Valid)
+			assert.Equal(t, lcconsts.HeadSignature, res.FirstBroken.Reason)
+		})
+
+		Convey("删除最新的记录后回退链头", func() {
+			head = newSignedHead(1, recs[0].Hash)
+			repo.EXPECT().GetHead(common.Login).Return(head, nil)
+			repo.EXPECT().CountUnchained(common.Login).Return(int64(0), "", nil)
+			repo.EXPECT().GetRecords(common.Login, int64(2), int64(math.MaxInt64), 1).Return(nil, nil)
+			repo.EXPECT().GetCheckpoints(common.Login, int64(2), int64(math.MaxInt64)).Return([]*lcmodels.LogChainCheckpointPO{cp}, nil)
+
+			res, err := lc.Verify(ctx, common.Login, 0, 0)
+			assert.NoError(t, err)
+			assert.False(t, res.Valid)
+			assert.Equal(t, lcconsts.HeadRollback, res.FirstBroken.Reason)
+			assert.Equal(t, int64(2), res.FirstBroken.Seq)
+		})
+
+		Convey("存在未入链的记录", func() {
+			head = &lcmodels.LogChainHeadPO{LogType: 10}
+			repo.EXPECT().GetHead(common.Login).Return(head, nil)
+			repo.EXPECT().CountUnchained(common.Login).Return(int64(2), "100", nil)
+			repo.EXPECT().GetRecords(common.Login, int64(1), int64(math.MaxInt64), 1).Return(nil, nil)
+			repo.EXPECT().GetCheckpoints(common.Login, int64(1), int64(math.MaxInt64)).Return(nil, nil)
+			repo.EXPECT().GetAnchors(common.Login, int64(1), int64(math.MaxInt64)).Return(nil, nil)
+
+			res, err := lc.Verify(ctx, common.Login, 0, 0)
+			assert.NoError(t, err)
+			assert.False(t, res.Valid)
+			assert.Equal(t, int64(2), res.UnchainedCount)
+			assert.Equal(t, lcconsts.Unchained, res.FirstBroken.Reason)
+			assert.Equal(t, "100", res.FirstBroken.LogID)
+		})
+	})
+}
@@ -0,0 +1,229 @@
+package logics
+
+import (
+	"database/sql"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
+
+	"AuditLog/common"
+	"AuditLog/common/constants/fwdconsts"
+	"AuditLog/common/utils/siemutils"
+	"AuditLog/gocommon/api"
+	"AuditLog/interfaces"
+	"AuditLog/models"
+	"AuditLog/models/fwdmodels"
+)
+
+var (
+	lfOnce sync.Once
+	lf     *logForward
+)
+
+type logForward struct {
+	logger       api.Logger
+	syslogClient interfaces.SyslogClient
+	outbox       interfaces.Outbox
+	dbPool       *sqlx.DB
+	enabled      bool
+	format       string
+	hostname     string
+	filters      map[string]int // 日志类型对应的最低转发级别
+
+	sent     atomic.Int64
+	failed   atomic.Int64
+	queued   atomic.Int64
+	filtered atomic.Int64
+	dropped  atomic.Int64
+
+	errMu         sync.Mutex
+	lastError     string
+	lastErrorTime int64
+}
+
+func NewLogForward() interfaces.LogForward {
+	lfOnce.Do(func() {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = common.SvcConfig.ServiceName
+		}
+
+		filters, err := siemutils.ParseFilters(common.SvcConfig.SiemFilters)
+		if err != nil {
+			logger.Warnf("[LogForward] %v", err)
+		}
+
+		lf = &logForward{
+			logger:       logger,
+			syslogClient: syslogClient,
+			dbPool:       dbPool,
+			enabled:      common.SvcConfig.SiemForwardEnabled,
+			format:       common.SvcConfig.SiemFormat,
+			hostname:     hostname,
+			filters:      filters,
+		}
+
+		// 未开启转发时不启动 outbox 推送线程
+		if lf.enabled {
+			lf.outbox = NewOutbox(fwdconsts.OutboxBusinessType)
+			lf.outbox.RegisterHandlers(fwdconsts.OutboxOpType, lf.send)
+		}
+	})
+	return lf
+}
+
+// Forward 转发一条审计日志
+// 按日志类型和级别过滤后写入 outbox, 由推送线程按写入顺序发送, 不阻塞日志写入
+// tx 不为空时在日志入库的事务中写入 outbox, 日志与转发消息同时提交或回滚, 调用方提交后调用 NotifyPushThread 触发推送
+// tx 为空时使用单独的事务写入并触发推送
+// 服务重启或 SIEM 不可用时消息保留在 outbox 中, 恢复后继续发送
+func (l *logForward) Forward(logType, logID string, log *models.AuditLog, tx *sql.Tx) (err error) {
+	if !l.enabled {
+		return
+	}
+
+	minLevel, ok := l.filters[logType]
+	if !ok || log.Level < minLevel {
+		l.filtered.Add(1)
+		return
+	}
+
+	msg, err := siemutils.Format(l.format, l.hostname, logType, logID, log)
+	if err != nil {
+		// 格式化失败的日志不转发, 不影响日志入库
+		l.logger.Errorf("[LogForward] format log error: %v", err)
+		l.dropped.Add(1)
+		return nil
+	}
+
+	fwdMsg := &fwdmodels.ForwardMsg{LogType: logType, LogID: logID, Message: msg}
+	if tx != nil {
+		err = l.outbox.AddOutboxInfo(fwdconsts.OutboxOpType, fwdMsg, tx)
+	} else {
+		err = l.enqueue(fwdMsg)
+	}
+	if err != nil {
+		l.logger.Errorf("[LogForward] add log %v to outbox error: %v", logID, err)
+		l.dropped.Add(1)
+		return
+	}
+	l.queued.Add(1)
+	return
+}
+
+// NotifyPushThread 触发 outbox 推送线程
+func (l *logForward) NotifyPushThread() {
+	if !l.enabled {
+		return
+	}
+	l.outbox.NotifyPushOutboxThread()
+}
+
+// enqueue 使用单独的事务写入 outbox
+func (l *logForward) enqueue(msg *fwdmodels.ForwardMsg) (err error) {
+	tx, err := l.dbPool.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			if err = tx.Commit(); err != nil {
+				return
+			}
+			// 触发outbox消息推送线程
+			l.outbox.NotifyPushOutboxThread()
+		default:
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				l.logger.Errorf("[LogForward] transaction rollback error: %v", rollbackErr)
+			}
+		}
+	}()
+
+	return l.outbox.AddOutboxInfo(fwdconsts.OutboxOpType, msg, tx)
+}
+
+// newForwardedLog 日志入库, 并在同一事务中写入转发到 SIEM 的 outbox 消息
+// 日志入库成功则转发消息一定写入 outbox, 写入 outbox 失败时日志同时回滚, 由调用方重试
+func newForwardedLog(db *sqlx.DB, repo interfaces.LogRepo, forward interfaces.LogForward, logType string, log *models.AuditLog) (logID string, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			if err = tx.Commit(); err != nil {
+				logger.Errorf("new log transaction commit error: %v", err)
+				return
+			}
+			// 提交后触发转发推送线程
+			forward.NotifyPushThread()
+		default:
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				logger.Errorf("new log transaction rollback error: %v", rollbackErr)
+			}
+		}
+	}()
+
+	if logID, err = repo.NewTx(log, tx); err != nil {
+		return
+	}
+	err = forward.Forward(logType, logID, log, tx)
+	return
+}
+
+// send outbox 推送线程发送一条消息, 失败时返回错误, 消息保留在 outbox 中由推送线程定时重试
+func (l *logForward) send(content interface{}) (err error) {
+	var msg fwdmodels.ForwardMsg
+	buf, err := jsoniter.Marshal(content)
+	if err != nil {
+		return err
+	}
+	if err = jsoniter.Unmarshal(buf, &msg); err != nil {
+		l.logger.Errorf("[LogForward] invalid outbox message: %v", err)
+		l.dropped.Add(1)
+		return nil
+	}
+
+	if err = l.syslogClient.Send(msg.Message); err != nil {
+		l.recordError(err)
+		l.logger.Warnf("[LogForward] send log %v error, retry later: %v", msg.LogID, err)
+		return err
+	}
+
+	l.sent.Add(1)
+	return nil
+}
+
+func (l *logForward) recordError(err error) {
+	l.failed.Add(1)
+
+	l.errMu.Lock()
+	defer l.errMu.Unlock()
+	l.lastError = err.Error()
+	l.lastErrorTime = time.Now().UnixMicro()
+}
+
+// GetMetrics 获取转发统计
+func (l *logForward) GetMetrics() (metrics *fwdmodels.ForwardMetrics) {
+	l.errMu.Lock()
+	defer l.errMu.Unlock()
+
+	return &fwdmodels.ForwardMetrics{
+		Enabled:       l.enabled,
+		Sent:          l.sent.Load(),
+		Failed:        l.failed.Load(),
+		Queued:        l.queued.Load(),
+		Filtered:      l.filtered.Load(),
+		Dropped:       l.dropped.Load(),
+		LastError:     l.lastError,
+		LastErrorTime: l.lastErrorTime,
+	}
+}
@@ -0,0 +1,131 @@
+package logics
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"AuditLog/common"
+	"AuditLog/common/constants/fwdconsts"
+	"AuditLog/interfaces/mock"
+	"AuditLog/models"
+	"AuditLog/models/fwdmodels"
+	"AuditLog/test/mock_log"
+)
+
+func TestLogForward(t *testing.T) {
+	Convey("LogForward", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		logger := mock_log.NewMockLogger(ctrl)
+		client := mock.NewMockSyslogClient(ctrl)
+		ob := mock.NewMockOutbox(ctrl)
+
+		dPool, sqlMock, err := sqlx.New()
+		assert.NoError(t, err)
+		defer dPool.Close()
+
+		fwd := &logForward{
+			logger:       logger,
+			syslogClient: client,
+			outbox:       ob,
+			dbPool:       dPool,
+			enabled:      true,
+			format:       fwdconsts.FormatRFC5424,
+			hostname:     "host",
+			filters:      map[string]int{common.Login: 0, common.Management: 2},
+		}
+		log := &models.AuditLog{UserID: "user1", UserName: "user1", Level: 1, Date: 1734571503937827, Msg: "msg"}
+
+		Convey("未开启转发", func() {
+			fwd.enabled = false
+			assert.NoError(t, fwd.Forward(common.Login, "1", log, nil))
+			assert.Equal(t, int64(0), fwd.GetMetrics().Filtered)
+		})
+
+		Convey("按日志类型和级别过滤", func() {
+			assert.NoError(t, fwd.Forward(common.Operation, "1", log, nil))
+			assert.NoError(t, fwd.Forward(common.Management, "2", log, nil))
+			assert.Equal(t, int64(2), fwd.GetMetrics().Filtered)
+		})
+
+		Convey("写入 outbox, 不在写入日志的线程中发送", func() {
+			sqlMock.ExpectBegin()
+			ob.EXPECT().AddOutboxInfo(fwdconsts.OutboxOpType, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(opType string, content interface{}, tx interface{}) error {
+					msg := content.(*fwdmodels.ForwardMsg)
+					assert.Equal(t, "1", msg.LogID)
+					assert.Contains(t, msg.Message, "msg")
+					return nil
+				})
+			sqlMock.ExpectCommit()
+			ob.EXPECT().NotifyPushOutboxThread()
+
+			assert.NoError(t, fwd.Forward(common.Login, "1", log, nil))
+			metrics := fwd.GetMetrics()
+			assert.Equal(t, int64(1), metrics.Queued)
+			assert.Equal(t, int64(0), metrics.Sent)
+		})
+
+		Convey("写入 outbox 失败", func() {
+			logger.EXPECT().Errorf(gomock.Any(), gomock.Any(), gomock.Any())
+			sqlMock.ExpectBegin()
+			ob.EXPECT().AddOutboxInfo(fwdconsts.OutboxOpType, gomock.Any(), gomock.Any()).Return(errors.New("db error"))
+			sqlMock.ExpectRollback()
+
+			assert.Error(t, fwd.Forward(common.Login, "1", log, nil))
+			assert.Equal(t, int64(1), fwd.GetMetrics().Dropped)
+		})
+
+		Convey("在日志入库的事务中写入 outbox, 提交后触发推送", func() {
+			loginLog := mock.NewMockLogRepo(ctrl)
+			sqlMock.ExpectBegin()
+			loginLog.EXPECT().NewTx(log, gomock.Any()).Return("3", nil)
+			ob.EXPECT().AddOutboxInfo(fwdconsts.OutboxOpType, gomock.Any(), gomock.Any()).Return(nil)
+			sqlMock.ExpectCommit()
+			ob.EXPECT().NotifyPushOutboxThread()
+
+			logID, err := newForwardedLog(dPool, loginLog, fwd, common.Login, log)
+			assert.NoError(t, err)
+			assert.Equal(t, "3", logID)
+			assert.Equal(t, int64(1), fwd.GetMetrics().Queued)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+
+		Convey("写入 outbox 失败时日志同时回滚", func() {
+			loginLog := mock.NewMockLogRepo(ctrl)
+			logger.EXPECT().Errorf(gomock.Any(), gomock.Any(), gomock.Any())
+			sqlMock.ExpectBegin()
+			loginLog.EXPECT().NewTx(log, gomock.Any()).Return("3", nil)
+			ob.EXPECT().AddOutboxInfo(fwdconsts.OutboxOpType, gomock.Any(), gomock.Any()).Return(errors.New("db error"))
+			sqlMock.ExpectRollback()
+
+			_, err := newForwardedLog(dPool, loginLog, fwd, common.Login, log)
+			assert.Error(t, err)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+
+		Convey("推送线程发送", func() {
+			content := map[string]interface{}{"log_type": "login", "log_id": "1", "message": "<110>1 msg"}
+
+			Convey("成功", func() {
+				client.EXPECT().Send("<110>1 msg").Return(nil)
+				assert.NoError(t, fwd.send(content))
+				metrics := fwd.GetMetrics()
+				assert.Equal(t, int64(1), metrics.Sent)
+				assert.True(t, metrics.Enabled)
+			})
+
+			Convey("失败时返回错误由推送线程重试", func() {
+				client.EXPECT().Send("<110>1 msg").Return(errors.New("timeout"))
+				logger.EXPECT().Warnf(gomock.Any(), gomock.Any(), gomock.Any())
+				assert.Error(t, fwd.send(content))
+				assert.Equal(t, &fwdmodels.ForwardMetrics{Enabled: true, Failed: 1, LastError: "timeout", LastErrorTime: fwd.GetMetrics().LastErrorTime}, fwd.GetMetrics())
+			})
+		})
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/go-redis/redis/v8"
 
 	"AuditLog/common"
+	"AuditLog/common/utils/alertutils"
 	"AuditLog/errors"
 	"AuditLog/gocommon/api"
 	gettext "AuditLog/infra"
@@ -36,6 +37,8 @@ type logMgnt struct {
 	uniqueCacheID string
 	mqClient      api.MQClient
 	outbox        interfaces.Outbox
+	logForward    interfaces.LogForward
+	alertEngine   interfaces.AlertEngine
 	dbPool        *sqlx.DB
 	cacheTimeout  time.Duration
 }
@@ -53,6 +56,8 @@ func NewLogMgnt() interfaces.LogMgnt {
 			uniqueCacheID: "as:audit_log:unique_id:",
 			mqClient:      mqClient,
 			outbox:        o,
+			logForward:    NewLogForward(),
+			alertEngine:   NewAlertEngine(),
 			dbPool:        dbPool,
 			cacheTimeout:  time.Second * 300,
 		}
@@ -171,22 +176,28 @@ func (l *logMgnt) ReceiveLog(info *models.ReceiveLogVo) (err error) {
 	// 根据日志类型入库
 	logType := info.LogType
 	logContent := info.LogContent
+	var repo interfaces.LogRepo
 	if logType == "login" {
-		_, err = l.loginLogRepo.New(logContent)
+		repo = l.loginLogRepo
 	} else if logType == "management" {
-		_, err = l.mgntLogRepo.New(logContent)
+		repo = l.mgntLogRepo
 	} else if logType == "operation" {
-		_, err = l.operLogRepo.New(logContent)
+		repo = l.operLogRepo
 	} else {
 		l.logger.Warnf("category is invalid")
 		return
 	}
 
+	// 入库并在同一事务中写入 SIEM 转发消息
+	logID, err := newForwardedLog(l.dbPool, repo, l.logForward, logType, logContent)
 	if err != nil {
 		l.logger.Errorf("insert log error: %v", err)
 		return err
 	}
 
+	// 告警规则评估
+	l.alertEngine.Evaluate(ctx, alertutils.EventFromAuditLog(logType, logID, logContent))
+
 	// 写缓存
 	err = l.cache.Set(ctx, uniqueCacheID, true, l.cacheTimeout).Err()
 	if err != nil {
@@ -302,22 +313,28 @@ func (l *logMgnt) ReceiveAuditLog(info *models.ReceiveLogVo) (err error) {
 	// 根据日志类型入库
 	logType := info.LogType
 	logContent := info.LogContent
+	var repo interfaces.LogRepo
 	if logType == "login" {
-		_, err = l.loginLogRepo.New(logContent)
+		repo = l.loginLogRepo
 	} else if logType == "management" {
-		_, err = l.mgntLogRepo.New(logContent)
+		repo = l.mgntLogRepo
 	} else if logType == "operation" {
-		_, err = l.operLogRepo.New(logContent)
+		repo = l.operLogRepo
 	} else {
 		l.logger.Warnf("category is invalid")
 		return
 	}
 
+	// 入库并在同一事务中写入 SIEM 转发消息
+	logID, err := newForwardedLog(l.dbPool, repo, l.logForward, logType, logContent)
 	if err != nil {
 		l.logger.Errorf("insert log error: %v", err)
 		return err
 	}
 
+	// 告警规则评估
+	l.alertEngine.Evaluate(ctx, alertutils.EventFromAuditLog(logType, logID, logContent))
+
 	// 写缓存
 	err = l.cache.Set(ctx, uniqueCacheID, true, l.cacheTimeout).Err()
 	if err != nil {
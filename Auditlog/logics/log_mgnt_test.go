@@ -1,6 +1,7 @@
 package logics
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -12,24 +13,27 @@ import (
 	"go.uber.org/mock/gomock"
 
 	"AuditLog/common"
+	"AuditLog/common/utils/alertutils"
 	"AuditLog/interfaces"
 	"AuditLog/interfaces/mock"
 	"AuditLog/models"
+	"AuditLog/models/alertmodels"
 	"AuditLog/test/mock_log"
 	mock_msqclient "AuditLog/test/mock_mqclient"
 )
 
 func newdepend(t *testing.T) (*mock_log.MockLogger, *mock.MockLogRepo, *mock.MockLogRepo, *mock.MockLogRepo, *mock.MockUserMgntRepo,
-	*mock_msqclient.MockMQClient, *mock.MockOutbox,
+	*mock_msqclient.MockMQClient, *mock.MockOutbox, *mock.MockLogForward, *mock.MockAlertEngine,
 ) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	return mock_log.NewMockLogger(ctrl), mock.NewMockLogRepo(ctrl), mock.NewMockLogRepo(ctrl), mock.NewMockLogRepo(ctrl), mock.NewMockUserMgntRepo(ctrl),
-		mock_msqclient.NewMockMQClient(ctrl), mock.NewMockOutbox(ctrl)
+		mock_msqclient.NewMockMQClient(ctrl), mock.NewMockOutbox(ctrl), mock.NewMockLogForward(ctrl), mock.NewMockAlertEngine(ctrl)
 }
 
 func newLogMgnt(logger *mock_log.MockLogger, loginLog *mock.MockLogRepo, operLog *mock.MockLogRepo, mgntLog *mock.MockLogRepo, userMgnt *mock.MockUserMgntRepo,
-	dbPool *sqlx.DB, cache *redis.Client, mqClient *mock_msqclient.MockMQClient, outbox *mock.MockOutbox,
+	dbPool *sqlx.DB, cache *redis.Client, mqClient *mock_msqclient.MockMQClient, outbox *mock.MockOutbox, logForward *mock.MockLogForward,
+	alertEngine *mock.MockAlertEngine,
 ) interfaces.LogMgnt {
 	logmgnt := &logMgnt{
 		logger:        logger,
@@ -41,6 +45,8 @@ func newLogMgnt(logger *mock_log.MockLogger, loginLog *mock.MockLogRepo, operLog
 		uniqueCacheID: "as:audit_log:unique_id:",
 		mqClient:      mqClient,
 		outbox:        outbox,
+		logForward:    logForward,
+		alertEngine:   alertEngine,
 		dbPool:        dbPool,
 		cacheTimeout:  300 * time.Second,
 	}
@@ -51,7 +57,7 @@ func newLogMgnt(logger *mock_log.MockLogger, loginLog *mock.MockLogRepo, operLog
 func TestReceiveLog(t *testing.T) {
 	Convey("ReceiveLog", t, func() {
 		// ctx := context.Background()
-		dbPool, _, err := sqlx.New()
+		dbPool, txMock, err := sqlx.New()
 		assert.Equal(t, err, nil)
 		defer func() {
 			if closeErr := dbPool.Close(); closeErr != nil {
@@ -60,8 +66,8 @@ func TestReceiveLog(t *testing.T) {
 		}()
 
 		redisClient, redisMock := redismock.NewClientMock()
-		logger, loginLog, operLog, mgntLog, userMgnt, mqClient, outbox := newdepend(t)
-		logmgnt := newLogMgnt(logger, loginLog, operLog, mgntLog, userMgnt, dbPool, redisClient, mqClient, outbox)
+		logger, loginLog, operLog, mgntLog, userMgnt, mqClient, outbox, logForward, alertEngine := newdepend(t)
+		logmgnt := newLogMgnt(logger, loginLog, operLog, mgntLog, userMgnt, dbPool, redisClient, mqClient, outbox, logForward, alertEngine)
 		Convey("用户 记录登录日志成功, 只有user_id 200", func() {
 			info := &models.ReceiveLogVo{
 				Language: "zh-cn",
@@ -124,7 +130,15 @@ func TestReceiveLog(t *testing.T) {
 			userMgnt.EXPECT().GetUserInfoByID([]string{"111"}).Return(userInfo, 200, nil)
 			logger.EXPECT().Infof(gomock.Any(), gomock.Any())
 			logger.EXPECT().Warnf(gomock.Any(), gomock.Any())
-			loginLog.EXPECT().New(gomock.Any()).Return("", nil)
+			txMock.ExpectBegin()
+			loginLog.EXPECT().NewTx(gomock.Any(), gomock.Any()).Return("", nil)
+			logForward.EXPECT().Forward("login", "", info.LogContent, gomock.Any()).Return(nil)
+			txMock.ExpectCommit()
+			logForward.EXPECT().NotifyPushThread()
+			alertEngine.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Do(func(_ context.Context, event *alertmodels.Event) {
+				// 用户名等字段在接收时填充, 调用时再比较
+				assert.Equal(t, event, alertutils.EventFromAuditLog("login", "", info.LogContent))
+			})
 			err = logmgnt.ReceiveLog(info)
 			assert.Equal(t, err, nil)
 		})
@@ -189,7 +203,15 @@ func TestReceiveLog(t *testing.T) {
 			userMgnt.EXPECT().GetUserInfoByID([]string{"111"}).Return(userInfo, 200, nil)
 			logger.EXPECT().Infof(gomock.Any(), gomock.Any())
 			logger.EXPECT().Warnf(gomock.Any(), gomock.Any())
-			operLog.EXPECT().New(gomock.Any()).Return("", nil)
+			txMock.ExpectBegin()
+			operLog.EXPECT().NewTx(gomock.Any(), gomock.Any()).Return("", nil)
+			logForward.EXPECT().Forward("operation", "", info.LogContent, gomock.Any()).Return(nil)
+			txMock.ExpectCommit()
+			logForward.EXPECT().NotifyPushThread()
+			alertEngine.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Do(func(_ context.Context, event *alertmodels.Event) {
+				// 用户名等字段在接收时填充, 调用时再比较
+				assert.Equal(t, event, alertutils.EventFromAuditLog("operation", "", info.LogContent))
+			})
 			err = logmgnt.ReceiveLog(info)
 			assert.Equal(t, err, nil)
 		})
@@ -254,7 +276,15 @@ func TestReceiveLog(t *testing.T) {
 			userMgnt.EXPECT().GetUserInfoByID([]string{"111"}).Return(userInfo, 200, nil)
 			logger.EXPECT().Infof(gomock.Any(), gomock.Any())
 			logger.EXPECT().Warnf(gomock.Any(), gomock.Any())
-			mgntLog.EXPECT().New(gomock.Any()).Return("", nil)
+			txMock.ExpectBegin()
+			mgntLog.EXPECT().NewTx(gomock.Any(), gomock.Any()).Return("", nil)
+			logForward.EXPECT().Forward("management", "", info.LogContent, gomock.Any()).Return(nil)
+			txMock.ExpectCommit()
+			logForward.EXPECT().NotifyPushThread()
+			alertEngine.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Do(func(_ context.Context, event *alertmodels.Event) {
+				// 用户名等字段在接收时填充, 调用时再比较
+				assert.Equal(t, event, alertutils.EventFromAuditLog("management", "", info.LogContent))
+			})
 			err = logmgnt.ReceiveLog(info)
 			assert.Equal(t, err, nil)
 		})
@@ -360,7 +390,15 @@ func TestReceiveLog(t *testing.T) {
 			userMgnt.EXPECT().GetAppInfoByID("111").Return(userInfo, 200, nil)
 			logger.EXPECT().Infof(gomock.Any(), gomock.Any())
 			logger.EXPECT().Warnf(gomock.Any(), gomock.Any())
-			mgntLog.EXPECT().New(gomock.Any()).Return("", nil)
+			txMock.ExpectBegin()
+			mgntLog.EXPECT().NewTx(gomock.Any(), gomock.Any()).Return("", nil)
+			logForward.EXPECT().Forward("management", "", info.LogContent, gomock.Any()).Return(nil)
+			txMock.ExpectCommit()
+			logForward.EXPECT().NotifyPushThread()
+			alertEngine.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Do(func(_ context.Context, event *alertmodels.Event) {
+				// 用户名等字段在接收时填充, 调用时再比较
+				assert.Equal(t, event, alertutils.EventFromAuditLog("management", "", info.LogContent))
+			})
 			err = logmgnt.ReceiveLog(info)
 			assert.Equal(t, err, nil)
 		})
@@ -378,8 +416,8 @@ func TestSendLog(t *testing.T) {
 		}()
 
 		redisClient, _ := redismock.NewClientMock()
-		logger, loginLog, operLog, mgntLog, userMgnt, mqClient, outbox := newdepend(t)
-		logmgnt := newLogMgnt(logger, loginLog, operLog, mgntLog, userMgnt, dbPool, redisClient, mqClient, outbox)
+		logger, loginLog, operLog, mgntLog, userMgnt, mqClient, outbox, _, _ := newdepend(t)
+		logmgnt := newLogMgnt(logger, loginLog, operLog, mgntLog, userMgnt, dbPool, redisClient, mqClient, outbox, nil, nil)
 		Convey("发送登录日志成功", func() {
 			info := &models.SendLogVo{
 				Language: "zh-cn",
@@ -481,8 +519,8 @@ func TestWriteAuditLoginLog(t *testing.T) {
 		}()
 
 		redisClient, _ := redismock.NewClientMock()
-		logger, loginLog, operLog, mgntLog, userMgnt, mqClient, outbox := newdepend(t)
-		logmgnt := newLogMgnt(logger, loginLog, operLog, mgntLog, userMgnt, dbPool, redisClient, mqClient, outbox)
+		logger, loginLog, operLog, mgntLog, userMgnt, mqClient, outbox, _, _ := newdepend(t)
+		logmgnt := newLogMgnt(logger, loginLog, operLog, mgntLog, userMgnt, dbPool, redisClient, mqClient, outbox, nil, nil)
 		Convey("记录登录日志成功", func() {
 			entity := &models.AuditLog{
 				UserID:         "111",
@@ -519,8 +557,8 @@ func TestWriteAuditOperationLog(t *testing.T) {
 		}()
 
 		redisClient, _ := redismock.NewClientMock()
-		logger, loginLog, operLog, mgntLog, userMgnt, mqClient, outbox := newdepend(t)
-		logmgnt := newLogMgnt(logger, loginLog, operLog, mgntLog, userMgnt, dbPool, redisClient, mqClient, outbox)
+		logger, loginLog, operLog, mgntLog, userMgnt, mqClient, outbox, _, _ := newdepend(t)
+		logmgnt := newLogMgnt(logger, loginLog, operLog, mgntLog, userMgnt, dbPool, redisClient, mqClient, outbox, nil, nil)
 		Convey("记录操作日志成功", func() {
 			entity := &models.AuditLog{
 				UserID:         "111",
@@ -557,8 +595,8 @@ func TestWriteAuditManagementLog(t *testing.T) {
 		}()
 
 		redisClient, _ := redismock.NewClientMock()
-		logger, loginLog, operLog, mgntLog, userMgnt, mqClient, outbox := newdepend(t)
-		logmgnt := newLogMgnt(logger, loginLog, operLog, mgntLog, userMgnt, dbPool, redisClient, mqClient, outbox)
+		logger, loginLog, operLog, mgntLog, userMgnt, mqClient, outbox, _, _ := newdepend(t)
+		logmgnt := newLogMgnt(logger, loginLog, operLog, mgntLog, userMgnt, dbPool, redisClient, mqClient, outbox, nil, nil)
 		Convey("记录管理日志成功", func() {
 			entity := &models.AuditLog{
 				UserID:         "111",
@@ -0,0 +1,92 @@
+package logics
+
+import (
+	"fmt"
+
+	"AuditLog/common/utils/dumplogutils"
+	"AuditLog/interfaces"
+	"AuditLog/models"
+)
+
+// uploadToOSS 分块上传文件到可用的对象存储, 返回对象存储ID
+func uploadToOSS(oss interfaces.OssGatewayRepo, objectName string, content []byte) (ossID string, err error) {
+	ossID, err = oss.GetAvailableOSSID()
+	if err != nil {
+		return "", fmt.Errorf("get oss id error: %w", err)
+	}
+
+	if err = uploadToOSSByID(oss, ossID, objectName, content); err != nil {
+		return "", err
+	}
+	return ossID, nil
+}
+
+// uploadToOSSByID 分块上传文件到指定的对象存储
+func uploadToOSSByID(oss interfaces.OssGatewayRepo, ossID, objectName string, content []byte) (err error) {
+	uploadInfo, _, err := oss.GetUploadInfo(ossID, objectName)
+	if err != nil {
+		return fmt.Errorf("get upload info error: %w", err)
+	}
+
+	uploadID := uploadInfo.UploadID
+	partInfos := make(map[int]models.OSSUploadPartInfo)
+
+	parts, err := dumplogutils.SplitFile(content, int64(uploadInfo.PartSize))
+	if err != nil {
+		return fmt.Errorf("split file error: %w", err)
+	}
+
+	partNumber := 1
+	for _, partData := range parts {
+		uploadPartRequestInfo, _, err := oss.GetUploadPartRequestInfo(ossID, objectName, uploadID, partNumber)
+		if err != nil || uploadPartRequestInfo == nil {
+			return fmt.Errorf("get upload part request info error: %v", err)
+		}
+
+		partInfo, _, err := oss.UploadPartByURL(
+			uploadPartRequestInfo.URL,
+			uploadPartRequestInfo.Method,
+			string(partData),
+			uploadPartRequestInfo.Headers,
+		)
+		if err != nil || partInfo == nil {
+			return fmt.Errorf("upload part error: %v", err)
+		}
+
+		partInfos[partNumber] = models.OSSUploadPartInfo{
+			Etag: partInfo.Etag,
+			Size: partInfo.Size,
+		}
+		partNumber++
+	}
+
+	completeUploadRequestInfo, _, err := oss.GetCompleteUploadRequestInfo(ossID, objectName, uploadID, partInfos)
+	if err != nil || completeUploadRequestInfo == nil {
+		return fmt.Errorf("get complete upload request info error: %v", err)
+	}
+
+	completeUploadResponse, _, err := oss.CompleteUploadByURL(
+		completeUploadRequestInfo.URL,
+		completeUploadRequestInfo.Method,
+		completeUploadRequestInfo.RequestBody,
+		completeUploadRequestInfo.Headers,
+	)
+	if err != nil || completeUploadResponse == nil {
+		return fmt.Errorf("complete upload error: %v", err)
+	}
+
+	return nil
+}
+
+// deleteFromOSS 删除对象存储中的文件
+func deleteFromOSS(oss interfaces.OssGatewayRepo, ossID, objectName string) (err error) {
+	delInfo, _, err := oss.GetDeleteRequestInfo(ossID, objectName)
+	if err != nil {
+		return fmt.Errorf("get delete info error: %w", err)
+	}
+
+	if _, _, err = oss.DeleteObjectByURL(delInfo.URL, delInfo.Method, delInfo.RequestBody, delInfo.Headers); err != nil {
+		return fmt.Errorf("delete object error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,889 @@
+package logics
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	jsoniter "github.com/json-iterator/go"
+
+	"AuditLog/common"
+	"AuditLog/common/constants"
+	"AuditLog/common/constants/logconsts"
+	"AuditLog/common/constants/rclogconsts"
+	"AuditLog/common/constants/rptconsts"
+	"AuditLog/common/utils"
+	"AuditLog/common/utils/dumplogutils"
+	"AuditLog/errors"
+	"AuditLog/gocommon/api"
+	"AuditLog/infra"
+	"AuditLog/interfaces"
+	"AuditLog/locale"
+	"AuditLog/models"
+	"AuditLog/models/rcvo"
+	"AuditLog/models/rptmodels"
+)
+
+var (
+	rptOnce sync.Once
+	rpt     *reportSubscription
+)
+
+// reportColumnKeys 报表列的表头国际化key
+var reportColumnKeys = map[string]string{
+	"log_id":     locale.RCLogID,
+	"date":       locale.RCLogDate,
+	"user_name":  locale.RCLogUser,
+	"ip":         locale.RCLogIP,
+	"mac":        locale.RCLogMac,
+	"op_type":    locale.RCLogOpType,
+	"level":      locale.RCLogLevel,
+	"obj_name":   locale.RCLogObjName,
+	"obj_type":   locale.RCLogObjType,
+	"msg":        locale.RCLogMsg,
+	"exmsg":      locale.RCLogExMsg,
+	"user_paths": locale.RCLogUserPaths,
+}
+
+type reportSubscription struct {
+	logger     api.Logger
+	rptRepo    interfaces.ReportSubscriptionRepo
+	activeLog  interfaces.ActiveLog
+	ossGateway interfaces.OssGatewayRepo
+	mqClient   api.MQClient
+	dlmLock    interfaces.DLM
+	logMgnt    interfaces.LogMgnt
+}
+
+func NewReportSubscription() interfaces.ReportSubscription {
+	rptOnce.Do(func() {
+		rpt = &reportSubscription{
+			logger:     logger,
+			rptRepo:    reportSubscriptionRepo,
+			activeLog:  NewActiveLog(),
+			ossGateway: ossGateway,
+			mqClient:   mqClient,
+			dlmLock:    dlmLock,
+			logMgnt:    NewLogMgnt(),
+		}
+	})
+	return rpt
+}
+
+// InitReportScheduler 定时执行到期的报表订阅, 多实例部署时仅持有锁的实例执行
+func (r *reportSubscription) InitReportScheduler(ctx context.Context) {
+	ticker := time.NewTicker(rptconsts.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		locked, err := r.dlmLock.TryLock(constants.ReportScheduleLockKey)
+		if err != nil {
+			r.logger.Warnf("[InitReportScheduler] dlm lock error: %v", err)
+			continue
+		}
+		if !locked {
+			continue
+		}
+
+		if err = r.RunDueSubscriptions(ctx); err != nil {
+			r.logger.Warnf("[InitReportScheduler] run due subscriptions error: %v", err)
+		}
+
+		if err = r.dlmLock.UnLock(constants.ReportScheduleLockKey); err != nil {
+			r.logger.Warnf("[InitReportScheduler] dlm unlock error: %v", err)
+		}
+	}
+}
+
+// RunDueSubscriptions 执行到期的报表订阅, 并删除超过保留时长的报表文件
+// 服务停止期间错过的多次执行只补执行一次, 数据范围为最近一次应执行时间的上一周期
+func (r *reportSubscription) RunDueSubscriptions(ctx context.Context) (err error) {
+	now := time.Now()
+	subs, err := r.rptRepo.GetSubscriptionsByCondition(
+		"WHERE f_enabled=? AND f_next_run_at<=? ORDER BY f_next_run_at",
+		[]interface{}{true, now.UnixMicro()},
+	)
+	if err != nil {
+		return fmt.Errorf("[RunDueSubscriptions] get due subscriptions failed: %w", err)
+	}
+
+	for _, s := range subs {
+		runAt := time.UnixMicro(s.NextRunAt)
+		if latest := prevRunTime(s, now); latest.After(runAt) {
+			runAt = latest
+		}
+
+		// 先更新下次执行时间, 避免执行失败或实例退出后重复执行
+		if err = r.rptRepo.UpdateSchedule(s.ID, nextRunTime(s, now).UnixMicro(), now.UnixMicro()); err != nil {
+			return fmt.Errorf("[RunDueSubscriptions] update schedule of %d failed: %w", s.ID, err)
+		}
+
+		r.run(ctx, s, runAt)
+	}
+
+	if err = r.cleanExpiredFiles(now); err != nil {
+		return fmt.Errorf("[RunDueSubscriptions] clean expired files failed: %w", err)
+	}
+	return nil
+}
+
+func (r *reportSubscription) GetSubscriptions(ctx context.Context, req *rptmodels.GetSubscriptionsReq) (res *rptmodels.GetSubscriptionsRes, err error) {
+	var condition string
+	params := []interface{}{}
+	if req.LogType != "" {
+		condition = "WHERE f_log_type=?"
+		params = append(params, req.LogType)
+	}
+
+	count, err := r.rptRepo.CountSubscriptionsByCondition(condition, params)
+	if err != nil {
+		return nil, fmt.Errorf("[GetSubscriptions] count subscriptions failed: %w", err)
+	}
+
+	condition += " ORDER BY f_created_at DESC"
+	if req.Limit > 0 {
+		condition += " LIMIT ? OFFSET ?"
+		params = append(params, req.Limit, req.Offset)
+	}
+
+	subs, err := r.rptRepo.GetSubscriptionsByCondition(condition, params)
+	if err != nil {
+		return nil, fmt.Errorf("[GetSubscriptions] get subscriptions failed: %w", err)
+	}
+
+	res = &rptmodels.GetSubscriptionsRes{
+		Entries:    make([]*rptmodels.SubscriptionVO, 0, len(subs)),
+		TotalCount: count,
+	}
+	for _, s := range subs {
+		res.Entries = append(res.Entries, subscriptionToVO(s))
+	}
+	return
+}
+
+func (r *reportSubscription) GetSubscription(ctx context.Context, id int64) (res *rptmodels.SubscriptionVO, err error) {
+	s, err := r.rptRepo.GetSubscriptionByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, r.notFoundErr(ctx, id)
+	}
+	return subscriptionToVO(s), nil
+}
+
+func (r *reportSubscription) NewSubscription(ctx context.Context, req *rptmodels.SubscriptionVO) (id int64, err error) {
+	if err = r.validate(ctx, req); err != nil {
+		return 0, err
+	}
+
+	existing, err := r.rptRepo.GetSubscriptionsByCondition("WHERE f_name=?", []interface{}{req.Name})
+	if err != nil {
+		return 0, err
+	}
+	if len(existing) > 0 {
+		return 0, errors.NewCtx(ctx, errors.ReportSubscriptionConflictErr, "Report subscription already exists", nil)
+	}
+
+	uid, err := infra.GetUniqueID()
+	if err != nil {
+		r.logger.Errorf("new sonyflake id error: %v", err)
+		return 0, err
+	}
+
+	s, err := voToSubscription(req)
+	if err != nil {
+		return 0, err
+	}
+
+	visitor := ctx.Value(common.VisitorKey).(*models.Visitor)
+	now := time.Now()
+	s.ID = int64(uid)
+	s.NextRunAt = nextRunTime(s, now).UnixMicro()
+	s.CreatedBy = visitor.ID
+	s.CreatedAt = now.UnixMicro()
+	if err = r.rptRepo.NewSubscription(s); err != nil {
+		return 0, err
+	}
+
+	go r.autilog(ctx, req, logconsts.OpType.ManagementType.CREATE, locale.NewReportSubscription)
+
+	return s.ID, nil
+}
+
+func (r *reportSubscription) UpdateSubscription(ctx context.Context, id int64, req *rptmodels.SubscriptionVO) (err error) {
+	if err = r.validate(ctx, req); err != nil {
+		return err
+	}
+
+	checked, err := r.rptRepo.GetSubscriptionByID(id)
+	if err != nil {
+		return err
+	}
+	if checked == nil {
+		return r.notFoundErr(ctx, id)
+	}
+
+	existing, err := r.rptRepo.GetSubscriptionsByCondition("WHERE f_name=?", []interface{}{req.Name})
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 && existing[0].ID != id {
+		return errors.NewCtx(ctx, errors.ReportSubscriptionConflictErr, "Report subscription already exists", nil)
+	}
+
+	s, err := voToSubscription(req)
+	if err != nil {
+		return err
+	}
+
+	// 执行周期可能变化, 按新的周期重新计算下次执行时间
+	visitor := ctx.Value(common.VisitorKey).(*models.Visitor)
+	now := time.Now()
+	s.ID = id
+	s.NextRunAt = nextRunTime(s, now).UnixMicro()
+	s.UpdatedBy = visitor.ID
+	s.UpdatedAt = now.UnixMicro()
+	if err = r.rptRepo.UpdateSubscription(s); err != nil {
+		return err
+	}
+
+	go r.autilog(ctx, req, logconsts.OpType.ManagementType.EDIT, locale.EditReportSubscription)
+
+	return
+}
+
+func (r *reportSubscription) DeleteSubscription(ctx context.Context, id int64) (err error) {
+	s, err := r.rptRepo.GetSubscriptionByID(id)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		return
+	}
+
+	runs, err := r.rptRepo.GetRunsByCondition(
+		"WHERE f_subscription_id=? AND f_file_expired=? AND f_oss_id<>?",
+		[]interface{}{id, false, ""},
+	)
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		if dErr := deleteFromOSS(r.ossGateway, run.OssID, run.ObjectName); dErr != nil {
+			r.logger.Warnf("[DeleteSubscription] delete report file of run %d error: %v", run.ID, dErr)
+		}
+	}
+
+	if err = r.rptRepo.DeleteSubscription(id); err != nil {
+		return err
+	}
+
+	go r.autilog(ctx, subscriptionToVO(s), logconsts.OpType.ManagementType.DELETE, locale.DeleteReportSubscription)
+
+	return
+}
+
+func (r *reportSubscription) GetRuns(ctx context.Context, id int64, req *rptmodels.GetRunsReq) (res *rptmodels.GetRunsRes, err error) {
+	s, err := r.rptRepo.GetSubscriptionByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, r.notFoundErr(ctx, id)
+	}
+
+	condition := "WHERE f_subscription_id=?"
+	params := []interface{}{id}
+
+	count, err := r.rptRepo.CountRunsByCondition(condition, params)
+	if err != nil {
+		return nil, fmt.Errorf("[GetRuns] count runs failed: %w", err)
+	}
+
+	condition += " ORDER BY f_started_at DESC"
+	if req.Limit > 0 {
+		condition += " LIMIT ? OFFSET ?"
+		params = append(params, req.Limit, req.Offset)
+	}
+
+	runs, err := r.rptRepo.GetRunsByCondition(condition, params)
+	if err != nil {
+		return nil, fmt.Errorf("[GetRuns] get runs failed: %w", err)
+	}
+
+	res = &rptmodels.GetRunsRes{
+		Entries:    make([]*rptmodels.RunVO, 0, len(runs)),
+		TotalCount: count,
+	}
+	for _, run := range runs {
+		res.Entries = append(res.Entries, runToVO(run))
+	}
+	return
+}
+
+// GetRunDownloadInfo 获取报表文件的下载信息
+func (r *reportSubscription) GetRunDownloadInfo(ctx context.Context, id int64, runID int64) (res *models.OSSRequestInfo, err error) {
+	run, err := r.rptRepo.GetRunByID(runID)
+	if err != nil {
+		return nil, err
+	}
+	if run == nil || run.SubscriptionID != id {
+		return nil, errors.NewCtx(
+			ctx,
+			errors.ReportRunNotFoundErr,
+			"Report run not found",
+			map[string]interface{}{
+				"id": []int64{runID},
+			},
+		)
+	}
+	if run.Status != rptconsts.RunStatusSuccess || run.FileExpired {
+		return nil, errors.NewCtx(ctx, errors.ReportFileUnavailableErr, "Report file unavailable", nil)
+	}
+
+	res, _, err = r.ossGateway.GetDownLoadInfo(run.OssID, run.ObjectName, run.FileName, false)
+	if err != nil {
+		r.logger.Errorf("[GetRunDownloadInfo] get download info error: %v", err)
+		return nil, err
+	}
+	return
+}
+
+// run 生成一次报表并上传到对象存储, 记录执行结果并通知订阅者
+func (r *reportSubscription) run(ctx context.Context, s *rptmodels.SubscriptionPO, runAt time.Time) {
+	uid, err := infra.GetUniqueID()
+	if err != nil {
+		r.logger.Errorf("new sonyflake id error: %v", err)
+		return
+	}
+
+	begin, end := reportRange(s.Schedule, runAt)
+	run := &rptmodels.RunPO{
+		ID:             int64(uid),
+		SubscriptionID: s.ID,
+		Status:         rptconsts.RunStatusRunning,
+		BeginTime:      begin.UnixMicro(),
+		EndTime:        end.UnixMicro(),
+		StartedAt:      time.Now().UnixMicro(),
+	}
+	if err = r.rptRepo.NewRun(run); err != nil {
+		r.logger.Errorf("[ReportSubscription] new run of %d error: %v", s.ID, err)
+		return
+	}
+
+	if err = r.generate(ctx, s, run); err != nil {
+		r.logger.Errorf("[ReportSubscription] run %d of %d error: %v", run.ID, s.ID, err)
+		run.Status = rptconsts.RunStatusFailed
+		run.ErrMsg = err.Error()
+	} else {
+		run.Status = rptconsts.RunStatusSuccess
+	}
+	run.FinishedAt = time.Now().UnixMicro()
+
+	if err = r.rptRepo.FinishRun(run); err != nil {
+		r.logger.Errorf("[ReportSubscription] finish run %d error: %v", run.ID, err)
+	}
+
+	r.notify(s, run)
+}
+
+// generate 分页查询报表数据, 生成压缩文件并上传到对象存储
+func (r *reportSubscription) generate(ctx context.Context, s *rptmodels.SubscriptionPO, run *rptmodels.RunPO) (err error) {
+	condition := map[string]any{}
+	if s.Condition != "" {
+		if err = jsoniter.UnmarshalFromString(s.Condition, &condition); err != nil {
+			return fmt.Errorf("unmarshal condition failed: %w", err)
+		}
+	}
+	// 查询条件直接拼接到sql中, 需转义单引号
+	for k, v := range condition {
+		if str, ok := v.(string); ok {
+			condition[k] = strings.ReplaceAll(str, "'", "''")
+		}
+	}
+	condition[rclogconsts.Date] = []interface{}{float64(run.BeginTime), float64(run.EndTime - 1)}
+
+	req := &rcvo.ReportGetDataListReq{
+		Limit:     rptconsts.PageSize,
+		Condition: condition,
+		OrderBy: rcvo.OrderFields{
+			{Field: rclogconsts.Date, Direction: "asc"},
+			{Field: rclogconsts.LogID, Direction: "asc"},
+		},
+	}
+
+	var entries rcvo.ActiveLogReports
+	for {
+		// 按订阅创建者的日志查看范围过滤
+		res, qErr := r.activeLog.GetActiveDataList(ctx, s.LogType, req, s.CreatedBy)
+		if qErr != nil {
+			return fmt.Errorf("get logs failed: %w", qErr)
+		}
+		if req.Offset == 0 && res.TotalCount > rptconsts.MaxRecordCount {
+			return fmt.Errorf("report exceeds %d logs", rptconsts.MaxRecordCount)
+		}
+
+		entries = append(entries, res.Entries...)
+		if len(res.Entries) < req.Limit {
+			break
+		}
+		req.Offset += req.Limit
+	}
+
+	columns := rptconsts.AllColumn
+	if s.Columns != "" {
+		var selected []string
+		if err = jsoniter.UnmarshalFromString(s.Columns, &selected); err != nil {
+			return fmt.Errorf("unmarshal columns failed: %w", err)
+		}
+		if len(selected) > 0 {
+			columns = selected
+		}
+	}
+
+	content, err := renderReport(ctx, s.Format, columns, entries)
+	if err != nil {
+		return fmt.Errorf("render report failed: %w", err)
+	}
+
+	baseName := fmt.Sprintf("%s_%s", s.Name, time.UnixMicro(run.BeginTime).Format("20060102"))
+	zipContent, err := dumplogutils.GenZipFiles([]*dumplogutils.ZipEntry{{Name: baseName + "." + s.Format, Content: content}}, "")
+	if err != nil {
+		return fmt.Errorf("gen zip file failed: %w", err)
+	}
+
+	objectName := strconv.FormatInt(run.ID, 10)
+	ossID, err := uploadToOSS(r.ossGateway, objectName, zipContent)
+	if err != nil {
+		return err
+	}
+
+	run.RecordCount = int64(len(entries))
+	run.OssID = ossID
+	run.ObjectName = objectName
+	run.FileName = baseName + ".zip"
+	return nil
+}
+
+// notify 推送报表执行结果到消息队列, 由消息服务通知订阅者
+func (r *reportSubscription) notify(s *rptmodels.SubscriptionPO, run *rptmodels.RunPO) {
+	subscribers := []string{}
+	if s.Subscribers != "" {
+		_ = jsoniter.UnmarshalFromString(s.Subscribers, &subscribers)
+	}
+
+	msg, err := jsoniter.Marshal(&rptmodels.RunNotification{
+		SubscriptionID: s.ID,
+		Name:           s.Name,
+		RunID:          run.ID,
+		Status:         run.Status,
+		Subscribers:    subscribers,
+		FileName:       run.FileName,
+		RecordCount:    run.RecordCount,
+		BeginTime:      run.BeginTime,
+		EndTime:        run.EndTime,
+		ErrMsg:         run.ErrMsg,
+	})
+	if err != nil {
+		r.logger.Errorf("[ReportSubscription] marshal notification error: %v", err)
+		return
+	}
+
+	if err = r.mqClient.Publish(rptconsts.ReportTopic, msg); err != nil {
+		r.logger.Errorf("[ReportSubscription] publish notification of run %d error: %v", run.ID, err)
+	}
+}
+
+// cleanExpiredFiles 删除超过保留时长的报表文件, 保留执行记录
+func (r *reportSubscription) cleanExpiredFiles(now time.Time) (err error) {
+	runs, err := r.rptRepo.GetRunsByCondition(
+		"WHERE f_file_expired=? AND f_oss_id<>? AND f_started_at<?",
+		[]interface{}{false, "", now.Add(-rptconsts.FileRetention).UnixMicro()},
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, run := range runs {
+		if err = deleteFromOSS(r.ossGateway, run.OssID, run.ObjectName); err != nil {
+			return fmt.Errorf("delete report file of run %d failed: %w", run.ID, err)
+		}
+		if err = r.rptRepo.SetRunFileExpired(run.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate 校验json schema无法覆盖的参数, 并规范化过滤条件、列和订阅者
+func (r *reportSubscription) validate(ctx context.Context, req *rptmodels.SubscriptionVO) (err error) {
+	badRequest := func(msg string) error {
+		return errors.NewCtx(ctx, errors.BadRequestErr, msg, nil)
+	}
+
+	if !slices.Contains(common.AllLogType, req.LogType) {
+		return badRequest(fmt.Sprintf("invalid log_type: %s", req.LogType))
+	}
+	if !slices.Contains(rptconsts.AllFormat, req.Format) {
+		return badRequest(fmt.Sprintf("invalid format: %s", req.Format))
+	}
+	if _, _, err = parseScheduleTime(req.ScheduleTime); err != nil {
+		return badRequest(fmt.Sprintf("invalid schedule_time: %s", req.ScheduleTime))
+	}
+
+	switch req.Schedule {
+	case rptconsts.ScheduleDaily:
+		req.ScheduleDay = 0
+	case rptconsts.ScheduleWeekly:
+		if req.ScheduleDay < 0 || req.ScheduleDay > 6 {
+			return badRequest("schedule_day of weekly report must be between 0 and 6")
+		}
+	case rptconsts.ScheduleMonthly:
+		if req.ScheduleDay < 1 || req.ScheduleDay > 31 {
+			return badRequest("schedule_day of monthly report must be between 1 and 31")
+		}
+	default:
+		return badRequest(fmt.Sprintf("invalid schedule: %s", req.Schedule))
+	}
+
+	// 时间范围由执行周期决定, 其余字段与活跃日志报表的查询条件一致
+	for k, v := range req.Condition {
+		if !slices.Contains(rptconsts.AllConditionField, k) {
+			return badRequest(fmt.Sprintf("invalid condition field: %s", k))
+		}
+
+		var value string
+		switch vt := v.(type) {
+		case string:
+			value = vt
+		case float64:
+			value = strconv.FormatFloat(vt, 'f', -1, 64)
+		default:
+			return badRequest(fmt.Sprintf("invalid condition value of %s", k))
+		}
+		if k == rclogconsts.LogLevel || k == rclogconsts.OpType {
+			if _, aErr := strconv.Atoi(value); aErr != nil {
+				return badRequest(fmt.Sprintf("invalid condition value of %s", k))
+			}
+		}
+		req.Condition[k] = value
+	}
+
+	columns := make([]string, 0, len(req.Columns))
+	for _, column := range req.Columns {
+		if !slices.Contains(rptconsts.AllColumn, column) {
+			return badRequest(fmt.Sprintf("invalid column: %s", column))
+		}
+		if !slices.Contains(columns, column) {
+			columns = append(columns, column)
+		}
+	}
+	req.Columns = columns
+
+	slices.Sort(req.Subscribers)
+	req.Subscribers = slices.Compact(req.Subscribers)
+	if len(req.Subscribers) == 0 || len(req.Subscribers) > rptconsts.MaxSubscriberCount {
+		return badRequest(fmt.Sprintf("subscribers must contain 1 to %d items", rptconsts.MaxSubscriberCount))
+	}
+	return nil
+}
+
+func (r *reportSubscription) notFoundErr(ctx context.Context, id int64) error {
+	return errors.NewCtx(
+		ctx,
+		errors.ReportSubscriptionNotFoundErr,
+		"Report subscription not found",
+		map[string]interface{}{
+			"id": []int64{id},
+		},
+	)
+}
+
+// 记录审计日志
+func (r *reportSubscription) autilog(ctx context.Context, s *rptmodels.SubscriptionVO, opType int, opKey string) {
+	visitor := ctx.Value(common.VisitorKey).(*models.Visitor)
+
+	err := r.logMgnt.SendLog(&models.SendLogVo{
+		LogType:  common.Management,
+		Language: "",
+		LogContent: &models.AuditLog{
+			UserID:   visitor.ID,
+			UserName: visitor.Name,
+			UserType: common.AuthenticatedUser,
+			Level:    logconsts.LogLevel.INFO,
+			OpType:   opType,
+			Date:     time.Now().UnixMicro(),
+			IP:       visitor.IP,
+			Mac:      visitor.Mac,
+			Msg:      fmt.Sprintf(locale.GetI18nCtx(ctx, opKey), s.Name),
+			Exmsg: fmt.Sprintf(
+				locale.GetI18nCtx(ctx, locale.ReportSubscriptionExMsg),
+				locale.GetI18nCtx(ctx, locale.LogTypeMap[common.LogTypeMap[s.LogType]]),
+				s.Schedule,
+				strings.Join(s.Subscribers, ", "),
+			),
+			UserAgent: visitor.AgentType,
+			OutBizID:  uuid.NewString(),
+		},
+	})
+	if err != nil {
+		r.logger.Warnf("[ReportSubscription] send log error: %v", err)
+	}
+}
+
+// parseScheduleTime 解析 HH:MM 格式的执行时间
+func parseScheduleTime(scheduleTime string) (hour, minute int, err error) {
+	t, err := time.Parse("15:04", scheduleTime)
+	if err != nil {
+		return 0, 0, err
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// nextRunTime 获取 after 之后的下一次执行时间
+func nextRunTime(s *rptmodels.SubscriptionPO, after time.Time) time.Time {
+	after = after.Local()
+	hour, minute, _ := parseScheduleTime(s.ScheduleTime)
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.Local)
+	}
+
+	switch s.Schedule {
+	case rptconsts.ScheduleWeekly:
+		t := at(after.Year(), after.Month(), after.Day()+(s.ScheduleDay-int(after.Weekday())+7)%7)
+		if !t.After(after) {
+			t = t.AddDate(0, 0, 7)
+		}
+		return t
+	case rptconsts.ScheduleMonthly:
+		for i := 0; ; i++ {
+			first := time.Date(after.Year(), after.Month()+time.Month(i), 1, 0, 0, 0, 0, time.Local)
+			// 执行日超过当月天数时在月末执行
+			lastDay := first.AddDate(0, 1, -1).Day()
+			t := at(first.Year(), first.Month(), min(s.ScheduleDay, lastDay))
+			if t.After(after) {
+				return t
+			}
+		}
+	default:
+		t := at(after.Year(), after.Month(), after.Day())
+		if !t.After(after) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t
+	}
+}
+
+// prevRunTime 获取不晚于 now 的最近一次执行时间
+func prevRunTime(s *rptmodels.SubscriptionPO, now time.Time) time.Time {
+	var back time.Time
+	switch s.Schedule {
+	case rptconsts.ScheduleWeekly:
+		back = now.AddDate(0, 0, -7)
+	case rptconsts.ScheduleMonthly:
+		back = now.AddDate(0, -2, 0)
+	default:
+		back = now.AddDate(0, 0, -1)
+	}
+
+	prev := nextRunTime(s, back)
+	for next := nextRunTime(s, prev); !next.After(now); next = nextRunTime(s, next) {
+		prev = next
+	}
+	return prev
+}
+
+// reportRange 获取报表的数据时间范围 [begin, end), 为执行时间所在日、周、月之前的一个完整周期
+func reportRange(schedule string, runAt time.Time) (begin, end time.Time) {
+	runAt = runAt.Local()
+	end = time.Date(runAt.Year(), runAt.Month(), runAt.Day(), 0, 0, 0, 0, time.Local)
+
+	switch schedule {
+	case rptconsts.ScheduleWeekly:
+		begin = end.AddDate(0, 0, -7)
+	case rptconsts.ScheduleMonthly:
+		end = end.AddDate(0, 0, 1-end.Day())
+		begin = end.AddDate(0, -1, 0)
+	default:
+		begin = end.AddDate(0, 0, -1)
+	}
+	return
+}
+
+// renderReport 按指定的列生成报表文件内容
+func renderReport(ctx context.Context, format string, columns []string, entries rcvo.ActiveLogReports) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if format == rptconsts.FormatJSONL {
+		// 按列的顺序输出字段
+		for i := range entries {
+			buf.WriteByte('{')
+			for j, column := range columns {
+				var value any = reportValue(&entries[i], column)
+				if column == rclogconsts.Date {
+					value = entries[i].CreatedTime
+				}
+
+				b, err := jsoniter.Marshal(value)
+				if err != nil {
+					return nil, err
+				}
+				if j > 0 {
+					buf.WriteByte(',')
+				}
+				buf.WriteString(strconv.Quote(column))
+				buf.WriteByte(':')
+				buf.Write(b)
+			}
+			buf.WriteString("}\n")
+		}
+		return buf.Bytes(), nil
+	}
+
+	// 写入Excel BOM头
+	buf.WriteString("\uFEFF")
+	w := csv.NewWriter(&buf)
+
+	headers := make([]string, 0, len(columns))
+	for _, column := range columns {
+		headers = append(headers, locale.GetI18nCtx(ctx, reportColumnKeys[column]))
+	}
+	if err := w.Write(headers); err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		record := make([]string, 0, len(columns))
+		for _, column := range columns {
+			record = append(record, reportValue(&entries[i], column))
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// reportValue 获取报表列的显示值
+func reportValue(entry *rcvo.ActiveLogReport, column string) string {
+	switch column {
+	case "log_id":
+		return entry.ID
+	case "date":
+		return utils.FormatTime(time.UnixMilli(entry.CreatedTime).Local())
+	case "user_name":
+		return entry.UserName
+	case "ip":
+		return entry.IP
+	case "mac":
+		return entry.Mac
+	case "op_type":
+		return entry.OpType
+	case "level":
+		return entry.Level
+	case "obj_name":
+		return entry.ObjName
+	case "obj_type":
+		return entry.ObjType
+	case "msg":
+		return entry.Msg
+	case "exmsg":
+		return entry.ExMsg
+	case "user_paths":
+		return entry.UserPaths
+	}
+	return ""
+}
+
+func subscriptionToVO(s *rptmodels.SubscriptionPO) *rptmodels.SubscriptionVO {
+	condition := map[string]any{}
+	columns := []string{}
+	subscribers := []string{}
+	if s.Condition != "" {
+		_ = jsoniter.UnmarshalFromString(s.Condition, &condition)
+	}
+	if s.Columns != "" {
+		_ = jsoniter.UnmarshalFromString(s.Columns, &columns)
+	}
+	if s.Subscribers != "" {
+		_ = jsoniter.UnmarshalFromString(s.Subscribers, &subscribers)
+	}
+
+	return &rptmodels.SubscriptionVO{
+		ID:           s.ID,
+		Name:         s.Name,
+		LogType:      s.LogType,
+		Condition:    condition,
+		Columns:      columns,
+		Format:       s.Format,
+		Schedule:     s.Schedule,
+		ScheduleDay:  s.ScheduleDay,
+		ScheduleTime: s.ScheduleTime,
+		Subscribers:  subscribers,
+		Enabled:      s.Enabled,
+		NextRunAt:    s.NextRunAt,
+		LastRunAt:    s.LastRunAt,
+		CreatedAt:    s.CreatedAt,
+		CreatedBy:    s.CreatedBy,
+		UpdatedAt:    s.UpdatedAt,
+		UpdatedBy:    s.UpdatedBy,
+	}
+}
+
+func voToSubscription(req *rptmodels.SubscriptionVO) (s *rptmodels.SubscriptionPO, err error) {
+	condition := req.Condition
+	if condition == nil {
+		condition = map[string]any{}
+	}
+	s = &rptmodels.SubscriptionPO{
+		Name:         req.Name,
+		LogType:      req.LogType,
+		Format:       req.Format,
+		Schedule:     req.Schedule,
+		ScheduleDay:  req.ScheduleDay,
+		ScheduleTime: req.ScheduleTime,
+		Enabled:      req.Enabled,
+	}
+	if s.Condition, err = jsoniter.MarshalToString(condition); err != nil {
+		return nil, err
+	}
+	if s.Columns, err = jsoniter.MarshalToString(req.Columns); err != nil {
+		return nil, err
+	}
+	if s.Subscribers, err = jsoniter.MarshalToString(req.Subscribers); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func runToVO(run *rptmodels.RunPO) *rptmodels.RunVO {
+	return &rptmodels.RunVO{
+		ID:             run.ID,
+		SubscriptionID: run.SubscriptionID,
+		Status:         run.Status,
+		BeginTime:      run.BeginTime,
+		EndTime:        run.EndTime,
+		RecordCount:    run.RecordCount,
+		FileName:       run.FileName,
+		FileExpired:    run.FileExpired,
+		ErrMsg:         run.ErrMsg,
+		StartedAt:      run.StartedAt,
+		FinishedAt:     run.FinishedAt,
+	}
+}
@@ -0,0 +1,273 @@
+package logics
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"AuditLog/common"
+	"AuditLog/common/constants/rclogconsts"
+	"AuditLog/common/constants/rptconsts"
+	"AuditLog/errors"
+	"AuditLog/interfaces/mock"
+	"AuditLog/models"
+	"AuditLog/models/rcvo"
+	"AuditLog/models/rptmodels"
+	"AuditLog/test/mock_log"
+	mock_msqclient "AuditLog/test/mock_mqclient"
+)
+
+func TestReportSchedule(t *testing.T) {
+	Convey("ReportSchedule", t, func() {
+		// 2024-05-15 星期三
+		now := time.Date(2024, 5, 15, 10, 0, 0, 0, time.Local)
+
+		Convey("每天执行", func() {
+			s := &rptmodels.SubscriptionPO{Schedule: rptconsts.ScheduleDaily, ScheduleTime: "08:30"}
+			assert.Equal(t, time.Date(2024, 5, 16, 8, 30, 0, 0, time.Local), nextRunTime(s, now))
+			assert.Equal(t, time.Date(2024, 5, 15, 8, 30, 0, 0, time.Local), prevRunTime(s, now))
+
+			s.ScheduleTime = "10:00"
+			assert.Equal(t, time.Date(2024, 5, 16, 10, 0, 0, 0, time.Local), nextRunTime(s, now))
+		})
+
+		Convey("每周执行", func() {
+			s := &rptmodels.SubscriptionPO{Schedule: rptconsts.ScheduleWeekly, ScheduleDay: 1, ScheduleTime: "08:00"}
+			assert.Equal(t, time.Date(2024, 5, 20, 8, 0, 0, 0, time.Local), nextRunTime(s, now))
+			assert.Equal(t, time.Date(2024, 5, 13, 8, 0, 0, 0, time.Local), prevRunTime(s, now))
+
+			s.ScheduleDay = 3
+			s.ScheduleTime = "12:00"
+			assert.Equal(t, time.Date(2024, 5, 15, 12, 0, 0, 0, time.Local), nextRunTime(s, now))
+		})
+
+		Convey("每月执行, 执行日超过当月天数时在月末执行", func() {
+			s := &rptmodels.SubscriptionPO{Schedule: rptconsts.ScheduleMonthly, ScheduleDay: 31, ScheduleTime: "08:00"}
+			assert.Equal(t, time.Date(2024, 5, 31, 8, 0, 0, 0, time.Local), nextRunTime(s, now))
+			assert.Equal(t, time.Date(2024, 6, 30, 8, 0, 0, 0, time.Local), nextRunTime(s, time.Date(2024, 5, 31, 8, 0, 0, 0, time.Local)))
+			assert.Equal(t, time.Date(2024, 4, 30, 8, 0, 0, 0, time.Local), prevRunTime(s, now))
+		})
+
+		Convey("报表数据范围", func() {
+			begin, end := reportRange(rptconsts.ScheduleDaily, now)
+			assert.Equal(t, time.Date(2024, 5, 14, 0, 0, 0, 0, time.Local), begin)
+			assert.Equal(t, time.Date(2024, 5, 15, 0, 0, 0, 0, time.Local), end)
+
+			begin, end = reportRange(rptconsts.ScheduleWeekly, now)
+			assert.Equal(t, time.Date(2024, 5, 8, 0, 0, 0, 0, time.Local), begin)
+			assert.Equal(t, time.Date(2024, 5, 15, 0, 0, 0, 0, time.Local), end)
+
+			begin, end = reportRange(rptconsts.ScheduleMonthly, now)
+			assert.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local), begin)
+			assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local), end)
+		})
+	})
+}
+
+func TestReportSubscription(t *testing.T) {
+	Convey("ReportSubscription", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		logger := mock_log.NewMockLogger(ctrl)
+		rptRepo := mock.NewMockReportSubscriptionRepo(ctrl)
+		activeLog := mock.NewMockActiveLog(ctrl)
+		oss := mock.NewMockOssGatewayRepo(ctrl)
+		mqClient := mock_msqclient.NewMockMQClient(ctrl)
+		logMgnt := mock.NewMockLogMgnt(ctrl)
+		r := &reportSubscription{
+			logger:     logger,
+			rptRepo:    rptRepo,
+			activeLog:  activeLog,
+			ossGateway: oss,
+			mqClient:   mqClient,
+			dlmLock:    mock.NewMockDLM(ctrl),
+			logMgnt:    logMgnt,
+		}
+
+		logger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+		logger.EXPECT().Warnf(gomock.Any(), gomock.Any()).AnyTimes()
+
+		ctx := context.WithValue(context.Background(), common.VisitorKey, &models.Visitor{
+			ID:   "test_user",
+			Name: "Test User",
+		})
+		req := &rptmodels.SubscriptionVO{
+			Name:         "每日登录报表",
+			LogType:      common.Login,
+			Condition:    map[string]any{"level": float64(2), "user_name": "admin"},
+			Columns:      []string{"date", "user_name", "date"},
+			Format:       rptconsts.FormatCSV,
+			Schedule:     rptconsts.ScheduleDaily,
+			ScheduleTime: "08:00",
+			Subscribers:  []string{"u2", "u1", "u2"},
+			Enabled:      true,
+		}
+
+		Convey("新建报表订阅", func() {
+			Convey("成功", func() {
+				rptRepo.EXPECT().GetSubscriptionsByCondition("WHERE f_name=?", []interface{}{req.Name}).Return(nil, nil)
+				rptRepo.EXPECT().NewSubscription(gomock.Any()).DoAndReturn(func(s *rptmodels.SubscriptionPO) error {
+					assert.JSONEq(t, `{"level":"2","user_name":"admin"}`, s.Condition)
+					assert.Equal(t, `["date","user_name"]`, s.Columns)
+					assert.Equal(t, `["u1","u2"]`, s.Subscribers)
+					assert.Equal(t, "test_user", s.CreatedBy)
+					assert.Greater(t, s.NextRunAt, time.Now().UnixMicro())
+					return nil
+				})
+				logMgnt.EXPECT().SendLog(gomock.Any()).Return(nil).AnyTimes()
+
+				_, err := r.NewSubscription(ctx, req)
+				assert.NoError(t, err)
+			})
+
+			Convey("名称已存在", func() {
+				rptRepo.EXPECT().GetSubscriptionsByCondition("WHERE f_name=?", []interface{}{req.Name}).
+					Return([]*rptmodels.SubscriptionPO{{ID: 2}}, nil)
+
+				_, err := r.NewSubscription(ctx, req)
+				assert.Equal(t, errors.ReportSubscriptionConflictErr, err.(*errors.ErrorResp).Code())
+			})
+
+			Convey("过滤条件不能指定时间范围", func() {
+				req.Condition = map[string]any{rclogconsts.Date: []interface{}{float64(1), float64(2)}}
+				_, err := r.NewSubscription(ctx, req)
+				assert.Equal(t, errors.BadRequestErr, err.(*errors.ErrorResp).Code())
+			})
+
+			Convey("每周执行的执行日无效", func() {
+				req.Schedule = rptconsts.ScheduleWeekly
+				req.ScheduleDay = 7
+				_, err := r.NewSubscription(ctx, req)
+				assert.Equal(t, errors.BadRequestErr, err.(*errors.ErrorResp).Code())
+			})
+		})
+
+		Convey("执行到期的报表订阅", func() {
+			sub := &rptmodels.SubscriptionPO{
+				ID:           1,
+				Name:         "daily",
+				LogType:      common.Login,
+				Condition:    `{"user_name":"o'neil"}`,
+				Columns:      `["user_name","level"]`,
+				Format:       rptconsts.FormatCSV,
+				Schedule:     rptconsts.ScheduleDaily,
+				ScheduleTime: "00:00",
+				Subscribers:  `["u1"]`,
+				Enabled:      true,
+				NextRunAt:    time.Now().Add(-time.Minute).UnixMicro(),
+				CreatedBy:    "creator",
+			}
+			rptRepo.EXPECT().GetSubscriptionsByCondition("WHERE f_enabled=? AND f_next_run_at<=? ORDER BY f_next_run_at", gomock.Any()).
+				Return([]*rptmodels.SubscriptionPO{sub}, nil)
+			rptRepo.EXPECT().UpdateSchedule(int64(1), gomock.Any(), gomock.Any()).DoAndReturn(func(_ int64, next, _ int64) error {
+				assert.Greater(t, next, time.Now().UnixMicro())
+				return nil
+			})
+			rptRepo.EXPECT().NewRun(gomock.Any()).DoAndReturn(func(run *rptmodels.RunPO) error {
+				assert.Equal(t, rptconsts.RunStatusRunning, run.Status)
+				assert.Equal(t, 24*time.Hour, time.Duration(run.EndTime-run.BeginTime)*time.Microsecond)
+				return nil
+			})
+			rptRepo.EXPECT().GetRunsByCondition("WHERE f_file_expired=? AND f_oss_id<>? AND f_started_at<?", gomock.Any()).
+				Return([]*rptmodels.RunPO{{ID: 9, OssID: "oss", ObjectName: "9"}}, nil)
+			oss.EXPECT().GetDeleteRequestInfo("oss", "9").Return(&models.OSSRequestInfo{URL: "url"}, 200, nil)
+			oss.EXPECT().DeleteObjectByURL("url", gomock.Any(), gomock.Any(), gomock.Any()).Return(&http.Response{}, 200, nil)
+			rptRepo.EXPECT().SetRunFileExpired(int64(9)).Return(nil)
+
+			Convey("成功", func() {
+				activeLog.EXPECT().GetActiveDataList(gomock.Any(), common.Login, gomock.Any(), "creator").
+					DoAndReturn(func(_ context.Context, _ string, req *rcvo.ReportGetDataListReq, _ string) (*rcvo.ActiveReportListRes, error) {
+						assert.Equal(t, "o''neil", req.Condition["user_name"])
+						assert.Len(t, req.Condition[rclogconsts.Date], 2)
+						return &rcvo.ActiveReportListRes{
+							Entries:    rcvo.ActiveLogReports{{UserName: "o'neil", Level: "信息"}},
+							TotalCount: 1,
+						}, nil
+					})
+				oss.EXPECT().GetAvailableOSSID().Return("oss", nil)
+				oss.EXPECT().GetUploadInfo("oss", gomock.Any()).Return(&models.OSSUploadInfo{UploadID: "upload", PartSize: 1024 * 1024}, 200, nil)
+				oss.EXPECT().GetUploadPartRequestInfo("oss", gomock.Any(), "upload", 1).Return(&models.OSSRequestInfo{URL: "url"}, 200, nil)
+				oss.EXPECT().UploadPartByURL("url", gomock.Any(), gomock.Any(), gomock.Any()).Return(&models.OSSUploadPartInfo{Etag: "etag"}, 200, nil)
+				oss.EXPECT().GetCompleteUploadRequestInfo("oss", gomock.Any(), "upload", gomock.Any()).Return(&models.OSSRequestInfo{URL: "url"}, 200, nil)
+				oss.EXPECT().CompleteUploadByURL("url", gomock.Any(), gomock.Any(), gomock.Any()).Return(&http.Response{}, 200, nil)
+				rptRepo.EXPECT().FinishRun(gomock.Any()).DoAndReturn(func(run *rptmodels.RunPO) error {
+					assert.Equal(t, rptconsts.RunStatusSuccess, run.Status)
+					assert.Equal(t, int64(1), run.RecordCount)
+					assert.Equal(t, "oss", run.OssID)
+					assert.True(t, strings.HasSuffix(run.FileName, ".zip"))
+					return nil
+				})
+				mqClient.EXPECT().Publish(rptconsts.ReportTopic, gomock.Any()).DoAndReturn(func(_ string, msg []byte) error {
+					n := &rptmodels.RunNotification{}
+					assert.NoError(t, jsoniter.Unmarshal(msg, n))
+					assert.Equal(t, rptconsts.RunStatusSuccess, n.Status)
+					assert.Equal(t, []string{"u1"}, n.Subscribers)
+					return nil
+				})
+
+				err := r.RunDueSubscriptions(context.Background())
+				assert.NoError(t, err)
+			})
+
+			Convey("查询日志失败时记录失败原因并通知订阅者", func() {
+				activeLog.EXPECT().GetActiveDataList(gomock.Any(), common.Login, gomock.Any(), "creator").
+					Return(nil, errors.NewCtx(ctx, errors.ForbiddenErr, "No permission", nil))
+				rptRepo.EXPECT().FinishRun(gomock.Any()).DoAndReturn(func(run *rptmodels.RunPO) error {
+					assert.Equal(t, rptconsts.RunStatusFailed, run.Status)
+					assert.NotEmpty(t, run.ErrMsg)
+					return nil
+				})
+				mqClient.EXPECT().Publish(rptconsts.ReportTopic, gomock.Any()).DoAndReturn(func(_ string, msg []byte) error {
+					n := &rptmodels.RunNotification{}
+					assert.NoError(t, jsoniter.Unmarshal(msg, n))
+					assert.Equal(t, rptconsts.RunStatusFailed, n.Status)
+					assert.NotEmpty(t, n.ErrMsg)
+					return nil
+				})
+
+				err := r.RunDueSubscriptions(context.Background())
+				assert.NoError(t, err)
+			})
+		})
+
+		Convey("下载执行失败的报表", func() {
+			rptRepo.EXPECT().GetRunByID(int64(9)).Return(&rptmodels.RunPO{ID: 9, SubscriptionID: 1, Status: rptconsts.RunStatusFailed}, nil)
+			_, err := r.GetRunDownloadInfo(ctx, 1, 9)
+			assert.Equal(t, errors.ReportFileUnavailableErr, err.(*errors.ErrorResp).Code())
+		})
+
+		Convey("下载其他报表的文件", func() {
+			rptRepo.EXPECT().GetRunByID(int64(9)).Return(&rptmodels.RunPO{ID: 9, SubscriptionID: 2, Status: rptconsts.RunStatusSuccess}, nil)
+			_, err := r.GetRunDownloadInfo(ctx, 1, 9)
+			assert.Equal(t, errors.ReportRunNotFoundErr, err.(*errors.ErrorResp).Code())
+		})
+	})
+}
+
+func TestRenderReport(t *testing.T) {
+	Convey("RenderReport", t, func() {
+		entries := rcvo.ActiveLogReports{{ID: "1", UserName: `a"b`, CreatedTime: 1700000000000}}
+
+		Convey("JSON Lines", func() {
+			content, err := renderReport(context.Background(), rptconsts.FormatJSONL, []string{"log_id", "date"}, entries)
+			assert.NoError(t, err)
+			assert.Equal(t, `{"log_id":"1","date":1700000000000}`+"\n", string(content))
+		})
+
+		Convey("CSV", func() {
+			content, err := renderReport(context.Background(), rptconsts.FormatCSV, []string{"user_name"}, entries)
+			assert.NoError(t, err)
+			lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+			assert.Len(t, lines, 2)
+			assert.True(t, strings.HasPrefix(lines[0], "\uFEFF"))
+			assert.Equal(t, `"a""b"`, lines[1])
+		})
+	})
+}
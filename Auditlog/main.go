@@ -29,7 +29,9 @@ import (
 	"AuditLog/drivenadapters/httpaccess/doccenter"
 	"AuditLog/drivenadapters/httpaccess/ossgateway"
 	"AuditLog/drivenadapters/httpaccess/usermgnt"
+	"AuditLog/drivenadapters/httpaccess/webhook"
 	"AuditLog/drivenadapters/redisaccess"
+	"AuditLog/drivenadapters/syslogaccess"
 	"AuditLog/drivenadapters/thrift"
 	"AuditLog/driveradapters"
 	"AuditLog/driveradapters/api/middleware"
@@ -57,13 +59,20 @@ type auditLog struct {
 	logHandler     interfaces.PrivateRESTHandler
 	historyHandler interfaces.PrivateRESTHandler
 	activeHandler  interfaces.PrivateRESTHandler
+	forwardHandler interfaces.PrivateRESTHandler
 
 	healthPubHandler interfaces.PublicRESTHandler
 	// oprLogPubHandler   interfaces.PublicRESTHandler
-	logStrategyHandler interfaces.PublicRESTHandler
-	historyLogHandler  interfaces.PublicRESTHandler
-	activeLogHandler   interfaces.PublicRESTHandler
-	activeLogV2Handler interfaces.PublicRESTHandler
+	logStrategyHandler   interfaces.PublicRESTHandler
+	historyLogHandler    interfaces.PublicRESTHandler
+	activeLogHandler     interfaces.PublicRESTHandler
+	activeLogV2Handler   interfaces.PublicRESTHandler
+	logChainHandler      interfaces.PublicRESTHandler
+	alertRuleHandler     interfaces.PublicRESTHandler
+	oprLogDLQHandler     interfaces.PublicRESTHandler
+	legalHoldHandler     interfaces.PublicRESTHandler
+	investigationHandler interfaces.PublicRESTHandler
+	reportSubHandler     interfaces.PublicRESTHandler
 
 	mqHandler       interfaces.MQHandler
 	oprLogMqHandler interfaces.MQHandler
@@ -97,6 +106,8 @@ func (a *auditLog) Start() {
 
 	// 5 初始化历史日志转存
 	go a.initDumpLog()
+	go logics.NewInvestigation().InitInvestigation(ctx)
+	go logics.NewReportSubscription().InitReportScheduler(ctx)
 
 	// 6. 打印build信息
 	go printBuildInfo()
@@ -133,6 +144,7 @@ func (a *auditLog) privateAPIServe() {
 	a.logHandler.RegisterPrivate(group)
 	a.historyHandler.RegisterPrivate(group)
 	a.activeHandler.RegisterPrivate(group)
+	a.forwardHandler.RegisterPrivate(group)
 
 	// 4 个性化
 	persGroup := server.Group(fmt.Sprintf("/api/%s/v1", persconsts.PersSvcName))
@@ -198,6 +210,12 @@ func (a *auditLog) publicAPIServe() {
 	a.logStrategyHandler.RegisterPublic(group)
 	a.historyLogHandler.RegisterPublic(group)
 	a.activeLogHandler.RegisterPublic(group)
+	a.logChainHandler.RegisterPublic(group)
+	a.alertRuleHandler.RegisterPublic(group)
+	a.oprLogDLQHandler.RegisterPublic(group)
+	a.legalHoldHandler.RegisterPublic(group)
+	a.investigationHandler.RegisterPublic(group)
+	a.reportSubHandler.RegisterPublic(group)
 
 	// 5. 个性化 group
 	persGroup := server.Group(fmt.Sprintf("/api/%s/v1", persconsts.PersSvcName))
@@ -234,6 +252,10 @@ func (a *auditLog) gracefulShutdown() {
 			log.Printf("[%s] dump log dlm unlock error: %v", constants.DumpLogLockKey, err)
 		}
 
+		if err := a.dlmLock.UnLock(constants.ReportScheduleLockKey); err != nil {
+			log.Printf("[%s] report schedule dlm unlock error: %v", constants.ReportScheduleLockKey, err)
+		}
+
 		log.Println("shutting down dlm...")
 	}
 
@@ -267,6 +289,10 @@ func main() {
 	// 初始化配置
 	conf.InitJsonConf()
 
+	if err := common.SvcConfig.CheckSignSecrets(); err != nil {
+		log.Fatalf("check sign secrets: %v", err)
+	}
+
 	global.BuildInfo = &helpers.BuildInfo{
 		BranchName:      branchName,
 		BuildTime:       buildTime,
@@ -319,6 +345,7 @@ func main() {
 	mgntLogRepo := db.NewManagementLog()
 	operLogRepo := db.NewOperationLog()
 	historyRepo := db.NewHisotryLog()
+	logChainRepo := db.NewLogChain()
 	logStrategyRepo := db.NewLogStrategy()
 	logScopeStrategyRepo := db.NewScopeStrategy()
 	userMgntRepo := usermgnt.NewUserMgnt()
@@ -333,6 +360,7 @@ func main() {
 	logics.SetMgntLogRepo(mgntLogRepo)
 	logics.SetOperLogRepo(operLogRepo)
 	logics.SetHistoryRepo(historyRepo)
+	logics.SetLogChainRepo(logChainRepo)
 	logics.SetLogStrategyRepo(logStrategyRepo)
 	logics.SetLogScopeStrategyRepo(logScopeStrategyRepo)
 	logics.SetUserMgntRepo(userMgntRepo)
@@ -360,19 +388,43 @@ func main() {
 	dlm := redisaccess.NewDLM()
 	logics.SetDLM(dlm)
 
+	// 2.9 syslog client
+	syslogClient := syslogaccess.NewSyslogClient()
+	logics.SetSyslogClient(syslogClient)
+
+	// 2.10 alert
+	logics.SetAlertRuleRepo(db.NewAlertRule())
+	logics.SetWebhookRepo(webhook.NewWebhook())
+
+	// 2.11 legal hold
+	logics.SetLegalHoldRepo(db.NewLegalHold())
+
+	// 2.12 investigation workspace
+	logics.SetInvestigationRepo(db.NewInvestigation())
+
+	// 2.13 report subscription
+	logics.SetReportSubscriptionRepo(db.NewReportSubscription())
+
 	// 3. 启动服务
 	a := &auditLog{
 		healthHandler:  private.NewHealthHandler(),
 		logHandler:     private.NewLogHandler(),
 		historyHandler: private.NewHistoryHandler(),
 		activeHandler:  private.NewActiveHandler(),
+		forwardHandler: private.NewLogForwardHandler(),
 
 		healthPubHandler: public.NewHealthHandler(),
 		// oprLogPubHandler:   public.NewOperationLogHandler(),
-		logStrategyHandler: public.NewLogStrategyHandler(),
-		historyLogHandler:  public.NewHistoryLogHandler(),
-		activeLogHandler:   public.NewActiveLogHandler(),
-		activeLogV2Handler: driveradapters.NewActiveLogV2Handler(),
+		logStrategyHandler:   public.NewLogStrategyHandler(),
+		historyLogHandler:    public.NewHistoryLogHandler(),
+		activeLogHandler:     public.NewActiveLogHandler(),
+		activeLogV2Handler:   driveradapters.NewActiveLogV2Handler(),
+		logChainHandler:      public.NewLogChainHandler(),
+		alertRuleHandler:     public.NewAlertRuleHandler(),
+		oprLogDLQHandler:     public.NewOprLogDLQHandler(),
+		legalHoldHandler:     public.NewLegalHoldHandler(),
+		investigationHandler: public.NewInvestigationHandler(),
+		reportSubHandler:     public.NewReportSubscriptionHandler(),
 
 		mqHandler:       mq.NewMQHandler(),
 		oprLogMqHandler: oprlogmq.NewOprLogMqHandler(),
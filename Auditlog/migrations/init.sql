@@ -22,13 +22,17 @@ CREATE TABLE IF NOT EXISTS `t_log_login` (
   `f_user_paths` text COMMENT '用户所属部门信息',
   `f_obj_name` char(128) NOT NULL DEFAULT '' COMMENT '对象名称',
   `f_obj_type` tinyint(4) NOT NULL DEFAULT 0 COMMENT '对象类型',
+  `f_chain_seq` bigint(20) NOT NULL DEFAULT 0 COMMENT '哈希链序号',
+  `f_prev_hash` char(64) NOT NULL DEFAULT '' COMMENT '前序记录哈希',
+  `f_hash` char(64) NOT NULL DEFAULT '' COMMENT '本记录哈希',
   PRIMARY KEY (`f_log_id`),
   KEY `t_log_f_user_id_index` (`f_user_id`) USING BTREE,
   KEY `t_log_f_user_name_index` (`f_user_name`) USING BTREE,
   KEY `t_log_f_op_type_index` (`f_op_type`) USING BTREE,
   KEY `t_log_f_date_index` (`f_date`) USING BTREE,
   KEY `t_log_f_ip_index` (`f_ip`) USING BTREE,
-  KEY `t_log_f_mac_index` (`f_mac`) USING BTREE
+  KEY `t_log_f_mac_index` (`f_mac`) USING BTREE,
+  KEY `t_log_f_chain_seq_index` (`f_chain_seq`) USING BTREE
 ) ENGINE=InnoDB;
 
 CREATE TABLE IF NOT EXISTS `t_log_management` (
@@ -49,13 +53,17 @@ CREATE TABLE IF NOT EXISTS `t_log_management` (
   `f_user_paths` text COMMENT '用户所属部门信息',
   `f_obj_name` char(128) NOT NULL DEFAULT '' COMMENT '对象名称',
   `f_obj_type` tinyint(4) NOT NULL DEFAULT 0 COMMENT '对象类型',
+  `f_chain_seq` bigint(20) NOT NULL DEFAULT 0 COMMENT '哈希链序号',
+  `f_prev_hash` char(64) NOT NULL DEFAULT '' COMMENT '前序记录哈希',
+  `f_hash` char(64) NOT NULL DEFAULT '' COMMENT '本记录哈希',
   PRIMARY KEY (`f_log_id`),
   KEY `t_log_f_user_id_index` (`f_user_id`) USING BTREE,
   KEY `t_log_f_user_name_index` (`f_user_name`) USING BTREE,
   KEY `t_log_f_op_type_index` (`f_op_type`) USING BTREE,
   KEY `t_log_f_date_index` (`f_date`) USING BTREE,
   KEY `t_log_f_ip_index` (`f_ip`) USING BTREE,
-  KEY `t_log_f_mac_index` (`f_mac`) USING BTREE
+  KEY `t_log_f_mac_index` (`f_mac`) USING BTREE,
+  KEY `t_log_f_chain_seq_index` (`f_chain_seq`) USING BTREE
 ) ENGINE=InnoDB;
 
 CREATE TABLE IF NOT EXISTS `t_log_operation` (
@@ -76,15 +84,102 @@ CREATE TABLE IF NOT EXISTS `t_log_operation` (
   `f_user_paths` text COMMENT '用户所属部门信息',
   `f_obj_name` char(128) NOT NULL DEFAULT '' COMMENT '对象名称',
   `f_obj_type` tinyint(4) NOT NULL DEFAULT 0 COMMENT '对象类型',
+  `f_chain_seq` bigint(20) NOT NULL DEFAULT 0 COMMENT '哈希链序号',
+  `f_prev_hash` char(64) NOT NULL DEFAULT '' COMMENT '前序记录哈希',
+  `f_hash` char(64) NOT NULL DEFAULT '' COMMENT '本记录哈希',
   PRIMARY KEY (`f_log_id`),
   KEY `t_log_f_user_id_index` (`f_user_id`) USING BTREE,
   KEY `t_log_f_user_name_index` (`f_user_name`) USING BTREE,
   KEY `t_log_f_op_type_index` (`f_op_type`) USING BTREE,
   KEY `t_log_f_date_index` (`f_date`) USING BTREE,
   KEY `t_log_f_ip_index` (`f_ip`) USING BTREE,
-  KEY `t_log_f_mac_index` (`f_mac`) USING BTREE
+  KEY `t_log_f_mac_index` (`f_mac`) USING BTREE,
+  KEY `t_log_f_chain_seq_index` (`f_chain_seq`) USING BTREE
 ) ENGINE=InnoDB;
 
+-- 升级: CREATE TABLE IF NOT EXISTS 不会修改已存在的表, 为已有的日志表添加哈希链字段
+-- 根据 information_schema 判断字段是否存在, 重复执行时跳过
+SET @sql = (SELECT IF(COUNT(*) = 0,
+  'ALTER TABLE `t_log_login`
+    ADD COLUMN `f_chain_seq` bigint(20) NOT NULL DEFAULT 0 COMMENT ''哈希链序号'',
+    ADD COLUMN `f_prev_hash` char(64) NOT NULL DEFAULT '''' COMMENT ''前序记录哈希'',
+    ADD COLUMN `f_hash` char(64) NOT NULL DEFAULT '''' COMMENT ''本记录哈希'',
+    ADD KEY `t_log_f_chain_seq_index` (`f_chain_seq`) USING BTREE',
+  'SELECT 1')
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 't_log_login' AND COLUMN_NAME = 'f_chain_seq');
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @sql = (SELECT IF(COUNT(*) = 0,
+  'ALTER TABLE `t_log_management`
+    ADD COLUMN `f_chain_seq` bigint(20) NOT NULL DEFAULT 0 COMMENT ''哈希链序号'',
+    ADD COLUMN `f_prev_hash` char(64) NOT NULL DEFAULT '''' COMMENT ''前序记录哈希'',
+    ADD COLUMN `f_hash` char(64) NOT NULL DEFAULT '''' COMMENT ''本记录哈希'',
+    ADD KEY `t_log_f_chain_seq_index` (`f_chain_seq`) USING BTREE',
+  'SELECT 1')
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 't_log_management' AND COLUMN_NAME = 'f_chain_seq');
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @sql = (SELECT IF(COUNT(*) = 0,
+  'ALTER TABLE `t_log_operation`
+    ADD COLUMN `f_chain_seq` bigint(20) NOT NULL DEFAULT 0 COMMENT ''哈希链序号'',
+    ADD COLUMN `f_prev_hash` char(64) NOT NULL DEFAULT '''' COMMENT ''前序记录哈希'',
+    ADD COLUMN `f_hash` char(64) NOT NULL DEFAULT '''' COMMENT ''本记录哈希'',
+    ADD KEY `t_log_f_chain_seq_index` (`f_chain_seq`) USING BTREE',
+  'SELECT 1')
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 't_log_operation' AND COLUMN_NAME = 'f_chain_seq');
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+CREATE TABLE IF NOT EXISTS `t_log_chain_head` (
+  `f_log_type` tinyint(4) NOT NULL COMMENT '日志类型, 10: 访问日志, 11: 管理日志, 12: 操作日志',
+  `f_seq` bigint(20) NOT NULL DEFAULT 0 COMMENT '最后一条记录的哈希链序号',
+  `f_hash` char(64) NOT NULL DEFAULT '' COMMENT '最后一条记录的哈希',
+  `f_signature` char(64) NOT NULL DEFAULT '' COMMENT '链头签名',
+  PRIMARY KEY (`f_log_type`)
+) ENGINE=InnoDB;
+
+INSERT INTO t_log_chain_head (f_log_type, f_seq, f_hash) SELECT 10, 0, '' FROM DUAL WHERE NOT EXISTS (SELECT 1 FROM t_log_chain_head WHERE f_log_type = 10);
+
+INSERT INTO t_log_chain_head (f_log_type, f_seq, f_hash) SELECT 11, 0, '' FROM DUAL WHERE NOT EXISTS (SELECT 1 FROM t_log_chain_head WHERE f_log_type = 11);
+
+INSERT INTO t_log_chain_head (f_log_type, f_seq, f_hash) SELECT 12, 0, '' FROM DUAL WHERE NOT EXISTS (SELECT 1 FROM t_log_chain_head WHERE f_log_type = 12);
+
+CREATE TABLE IF NOT EXISTS `t_log_chain_checkpoint` (
+  `f_id` bigint(20) NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `f_log_type` tinyint(4) NOT NULL COMMENT '日志类型',
+  `f_seq` bigint(20) NOT NULL COMMENT '检查点对应记录的哈希链序号',
+  `f_log_id` bigint(20) NOT NULL COMMENT '检查点对应记录的日志id',
+  `f_hash` char(64) NOT NULL COMMENT '检查点对应记录的哈希',
+  `f_signature` char(64) NOT NULL COMMENT '检查点签名',
+  `f_created_at` bigint(20) NOT NULL COMMENT '创建时间',
+  PRIMARY KEY (`f_id`),
+  UNIQUE KEY `uk_log_type_seq` (`f_log_type`, `f_seq`)
+) ENGINE=InnoDB;
+
+CREATE TABLE IF NOT EXISTS `t_log_chain_anchor` (
+  `f_id` bigint(20) NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `f_log_type` tinyint(4) NOT NULL COMMENT '日志类型',
+  `f_first_seq` bigint(20) NOT NULL COMMENT '转储的第一条记录的哈希链序号',
+  `f_first_log_id` bigint(20) NOT NULL COMMENT '转储的第一条记录的日志id',
+  `f_first_prev_hash` char(64) NOT NULL COMMENT '转储的第一条记录的前序哈希',
+  `f_last_seq` bigint(20) NOT NULL COMMENT '转储的最后一条记录的哈希链序号',
+  `f_last_log_id` bigint(20) NOT NULL COMMENT '转储的最后一条记录的日志id',
+  `f_last_hash` char(64) NOT NULL COMMENT '转储的最后一条记录的哈希',
+  `f_count` bigint(20) NOT NULL COMMENT '转储的记录数量',
+  `f_signature` char(64) NOT NULL COMMENT '锚点签名',
+  `f_created_at` bigint(20) NOT NULL COMMENT '创建时间',
+  PRIMARY KEY (`f_id`),
+  UNIQUE KEY `uk_log_type_last_seq` (`f_log_type`, `f_last_seq`)
+) ENGINE=InnoDB COMMENT='哈希链转储锚点';
+
 CREATE TABLE IF NOT EXISTS `t_history_log_info` (
   `f_id` char(128) NOT NULL COMMENT '唯一标识',
   `f_name` char(128) NOT NULL COMMENT '日志记录名',
@@ -96,6 +191,12 @@ CREATE TABLE IF NOT EXISTS `t_history_log_info` (
   PRIMARY KEY (`f_id`)
 ) ENGINE=InnoDB;
 
+CREATE TABLE IF NOT EXISTS `t_history_log_manifest` (
+  `f_history_id` char(128) NOT NULL COMMENT '历史日志ID',
+  `f_manifest` text NOT NULL COMMENT '转存清单, json格式',
+  PRIMARY KEY (`f_history_id`)
+) ENGINE=InnoDB COMMENT='历史日志转存清单';
+
 CREATE TABLE IF NOT EXISTS `t_log_config` (
   `f_key` char(40) NOT NULL,
   `f_value` char(40) NOT NULL,
@@ -128,6 +229,7 @@ CREATE TABLE IF NOT EXISTS `t_auditlog_outbox_lock` (
 ) ENGINE=InnoDB COMMENT='outbox分布式锁表';
 
 INSERT INTO t_auditlog_outbox_lock(f_business_type) SELECT 'client_log' FROM DUAL WHERE NOT EXISTS(SELECT f_business_type FROM t_auditlog_outbox_lock WHERE f_business_type = 'client_log');
+INSERT INTO t_auditlog_outbox_lock(f_business_type) SELECT 'siem_forward' FROM DUAL WHERE NOT EXISTS(SELECT f_business_type FROM t_auditlog_outbox_lock WHERE f_business_type = 'siem_forward');
 
 CREATE TABLE IF NOT EXISTS `t_log_scope_strategy` (
   `f_id` bigint(20) NOT NULL,
@@ -210,6 +312,147 @@ WHERE NOT EXISTS (
   WHERE f_id = 111000222000333007 OR (f_log_type= 12 AND f_log_category = 2 AND f_role = 'sec_admin')
 );
 
+CREATE TABLE IF NOT EXISTS `t_log_alert_rule` (
+  `f_id` bigint(20) NOT NULL,
+  `f_name` varchar(128) NOT NULL COMMENT '规则名称',
+  `f_description` varchar(512) NOT NULL DEFAULT '' COMMENT '规则描述',
+  `f_enabled` tinyint(1) NOT NULL DEFAULT 1 COMMENT '是否启用',
+  `f_log_type` varchar(64) NOT NULL COMMENT '日志类型',
+  `f_kind` varchar(32) NOT NULL COMMENT '规则类型, threshold: 阈值, off_hours: 非工作时间',
+  `f_conditions` text NOT NULL COMMENT '匹配条件',
+  `f_group_by` varchar(32) NOT NULL DEFAULT '' COMMENT '分组字段',
+  `f_threshold` int(11) NOT NULL DEFAULT 0 COMMENT '阈值',
+  `f_window_minutes` int(11) NOT NULL DEFAULT 0 COMMENT '滑动窗口时长, 分钟',
+  `f_work_start` char(5) NOT NULL DEFAULT '' COMMENT '工作时间开始',
+  `f_work_end` char(5) NOT NULL DEFAULT '' COMMENT '工作时间结束',
+  `f_work_days` varchar(32) NOT NULL DEFAULT '' COMMENT '工作日',
+  `f_level` tinyint(4) NOT NULL DEFAULT 2 COMMENT '告警级别',
+  `f_webhook` varchar(1024) NOT NULL DEFAULT '' COMMENT '告警推送地址',
+  `f_cooldown_minutes` int(11) NOT NULL DEFAULT 0 COMMENT '告警抑制时长, 分钟',
+  `f_created_at` bigint(20) NOT NULL DEFAULT 0 COMMENT '创建时间',
+  `f_created_by` varchar(64) NOT NULL DEFAULT '' COMMENT '创建人员',
+  `f_updated_at` bigint(20) NOT NULL DEFAULT 0 COMMENT '更新时间',
+  `f_updated_by` varchar(64) NOT NULL DEFAULT '' COMMENT '更新人员',
+  PRIMARY KEY (`f_id`),
+  UNIQUE KEY `uk_name` (`f_name`),
+  KEY `idx_log_type` (`f_log_type`)
+) ENGINE=InnoDB COMMENT='日志告警规则';
+
+CREATE TABLE IF NOT EXISTS `t_log_legal_hold` (
+  `f_id` bigint(20) NOT NULL,
+  `f_name` varchar(128) NOT NULL COMMENT '保留名称',
+  `f_reason` varchar(512) NOT NULL DEFAULT '' COMMENT '保留原因',
+  `f_log_type` varchar(64) NOT NULL DEFAULT '' COMMENT '日志类型, 为空时保留所有类型',
+  `f_begin_time` bigint(20) NOT NULL DEFAULT 0 COMMENT '保留范围开始时间',
+  `f_end_time` bigint(20) NOT NULL DEFAULT 0 COMMENT '保留范围结束时间, 0表示不限',
+  `f_user_ids` text NOT NULL COMMENT '保留的用户ID',
+  `f_departments` text NOT NULL COMMENT '保留的部门路径',
+  `f_created_at` bigint(20) NOT NULL DEFAULT 0 COMMENT '创建时间',
+  `f_created_by` varchar(64) NOT NULL DEFAULT '' COMMENT '创建人员',
+  `f_updated_at` bigint(20) NOT NULL DEFAULT 0 COMMENT '更新时间',
+  `f_updated_by` varchar(64) NOT NULL DEFAULT '' COMMENT '更新人员',
+  PRIMARY KEY (`f_id`),
+  UNIQUE KEY `uk_name` (`f_name`),
+  KEY `idx_log_type` (`f_log_type`)
+) ENGINE=InnoDB COMMENT='日志法律保留';
+
+CREATE TABLE IF NOT EXISTS `t_opr_log_dead_letter` (
+  `f_id` bigint(20) NOT NULL COMMENT '死信ID',
+  `f_biz_type` varchar(64) NOT NULL COMMENT '运营日志业务类型',
+  `f_message` mediumtext NOT NULL COMMENT '日志内容',
+  `f_reason` varchar(32) NOT NULL COMMENT '进入死信的原因',
+  `f_invalid_fields` text NOT NULL COMMENT '不符合json schema的字段',
+  `f_err_msg` text NOT NULL COMMENT '错误信息',
+  `f_status` tinyint(4) NOT NULL DEFAULT 1 COMMENT '状态, 1: 待处理, 2: 已重放',
+  `f_attempts` int(11) NOT NULL DEFAULT 0 COMMENT '重放次数',
+  `f_created_at` bigint(20) NOT NULL DEFAULT 0 COMMENT '创建时间',
+  `f_updated_at` bigint(20) NOT NULL DEFAULT 0 COMMENT '更新时间',
+  `f_replayed_at` bigint(20) NOT NULL DEFAULT 0 COMMENT '重放成功时间',
+  PRIMARY KEY (`f_id`),
+  KEY `idx_biz_type_status` (`f_biz_type`, `f_status`),
+  KEY `idx_created_at` (`f_created_at`)
+) ENGINE=InnoDB COMMENT='运营日志死信';
+
+CREATE TABLE IF NOT EXISTS `t_log_investigation_workspace` (
+  `f_id` bigint(20) NOT NULL,
+  `f_name` varchar(128) NOT NULL COMMENT '工作区名称',
+  `f_log_type` varchar(32) NOT NULL COMMENT '日志类型',
+  `f_history_ids` text NOT NULL COMMENT '恢复的历史日志ID',
+  `f_status` tinyint(4) NOT NULL DEFAULT 1 COMMENT '状态, 1: 恢复中, 2: 可查询, 3: 恢复失败',
+  `f_record_count` bigint(20) NOT NULL DEFAULT 0 COMMENT '已恢复的日志条数',
+  `f_err_msg` text NOT NULL COMMENT '恢复失败的原因',
+  `f_expire_at` bigint(20) NOT NULL DEFAULT 0 COMMENT '过期时间',
+  `f_created_at` bigint(20) NOT NULL DEFAULT 0 COMMENT '创建时间',
+  `f_created_by` varchar(64) NOT NULL DEFAULT '' COMMENT '创建人员',
+  PRIMARY KEY (`f_id`),
+  KEY `idx_created_by` (`f_created_by`),
+  KEY `idx_expire_at` (`f_expire_at`)
+) ENGINE=InnoDB COMMENT='日志调查工作区';
+
+CREATE TABLE IF NOT EXISTS `t_log_investigation` (
+  `f_workspace_id` bigint(20) NOT NULL COMMENT '调查工作区ID',
+  `f_log_id` bigint(20) NOT NULL COMMENT '日志id',
+  `f_user_id` char(40) NOT NULL DEFAULT '' COMMENT '用户id',
+  `f_user_name` char(128) NOT NULL COMMENT '用户显示名',
+  `f_obj_id` char(40) NOT NULL COMMENT '对象id',
+  `f_additional_info` text NOT NULL COMMENT '附加信息',
+  `f_level` tinyint(4) NOT NULL COMMENT '日志级别, 1: 信息, 2: 警告',
+  `f_op_type` tinyint(4) NOT NULL COMMENT '操作类型',
+  `f_date` bigint(20) NOT NULL COMMENT '日志记录时间, 微秒的时间戳',
+  `f_ip` char(40) NOT NULL COMMENT '访问者的IP',
+  `f_mac` char(40) NOT NULL DEFAULT '' COMMENT '文档入口属于哪个站点',
+  `f_msg` text NOT NULL COMMENT '日志描述',
+  `f_exmsg` text NOT NULL COMMENT '日志附加描述',
+  `f_user_agent` varchar(1024) NOT NULL DEFAULT '' COMMENT '用户代理',
+  `f_user_paths` text COMMENT '用户所属部门信息',
+  `f_obj_name` char(128) NOT NULL DEFAULT '' COMMENT '对象名称',
+  `f_obj_type` tinyint(4) NOT NULL DEFAULT 0 COMMENT '对象类型',
+  PRIMARY KEY (`f_workspace_id`, `f_log_id`),
+  KEY `idx_workspace_date` (`f_workspace_id`, `f_date`)
+) ENGINE=InnoDB COMMENT='调查工作区恢复的日志';
+
+CREATE TABLE IF NOT EXISTS `t_log_report_subscription` (
+  `f_id` bigint(20) NOT NULL,
+  `f_name` varchar(128) NOT NULL COMMENT '报表名称',
+  `f_log_type` varchar(32) NOT NULL COMMENT '日志类型',
+  `f_condition` text NOT NULL COMMENT '过滤条件',
+  `f_columns` text NOT NULL COMMENT '导出的列',
+  `f_format` varchar(16) NOT NULL COMMENT '文件格式',
+  `f_schedule` varchar(16) NOT NULL COMMENT '执行周期, daily/weekly/monthly',
+  `f_schedule_day` tinyint(4) NOT NULL DEFAULT 0 COMMENT '执行日',
+  `f_schedule_time` varchar(8) NOT NULL COMMENT '执行时间, HH:MM',
+  `f_subscribers` text NOT NULL COMMENT '订阅者用户ID',
+  `f_enabled` tinyint(1) NOT NULL DEFAULT 1 COMMENT '是否启用',
+  `f_next_run_at` bigint(20) NOT NULL DEFAULT 0 COMMENT '下次执行时间',
+  `f_last_run_at` bigint(20) NOT NULL DEFAULT 0 COMMENT '上次执行时间',
+  `f_created_at` bigint(20) NOT NULL DEFAULT 0 COMMENT '创建时间',
+  `f_created_by` varchar(64) NOT NULL DEFAULT '' COMMENT '创建人员',
+  `f_updated_at` bigint(20) NOT NULL DEFAULT 0 COMMENT '更新时间',
+  `f_updated_by` varchar(64) NOT NULL DEFAULT '' COMMENT '更新人员',
+  PRIMARY KEY (`f_id`),
+  UNIQUE KEY `uk_name` (`f_name`),
+  KEY `idx_next_run_at` (`f_enabled`, `f_next_run_at`)
+) ENGINE=InnoDB COMMENT='审计报表订阅';
+
+CREATE TABLE IF NOT EXISTS `t_log_report_run` (
+  `f_id` bigint(20) NOT NULL,
+  `f_subscription_id` bigint(20) NOT NULL COMMENT '报表订阅ID',
+  `f_status` tinyint(4) NOT NULL DEFAULT 1 COMMENT '状态, 1: 执行中, 2: 成功, 3: 失败',
+  `f_begin_time` bigint(20) NOT NULL DEFAULT 0 COMMENT '报表数据开始时间',
+  `f_end_time` bigint(20) NOT NULL DEFAULT 0 COMMENT '报表数据结束时间',
+  `f_record_count` bigint(20) NOT NULL DEFAULT 0 COMMENT '导出的日志条数',
+  `f_oss_id` varchar(128) NOT NULL DEFAULT '' COMMENT '对象存储ID',
+  `f_object_name` varchar(128) NOT NULL DEFAULT '' COMMENT '对象存储中的文件名',
+  `f_file_name` varchar(255) NOT NULL DEFAULT '' COMMENT '下载文件名',
+  `f_err_msg` text NOT NULL COMMENT '失败原因',
+  `f_started_at` bigint(20) NOT NULL DEFAULT 0 COMMENT '开始执行时间',
+  `f_finished_at` bigint(20) NOT NULL DEFAULT 0 COMMENT '结束执行时间',
+  `f_file_expired` tinyint(1) NOT NULL DEFAULT 0 COMMENT '报表文件是否已删除',
+  PRIMARY KEY (`f_id`),
+  KEY `idx_subscription_id` (`f_subscription_id`, `f_started_at`),
+  KEY `idx_started_at` (`f_started_at`)
+) ENGINE=InnoDB COMMENT='审计报表执行记录';
+
 -- 暂时只用于redis分布式锁的value，保证value的唯一性
 -- 【注意】这个和Personalization共用一张表，如果调整，两边都注意下是否一起调整相应地方
 create table if not exists t_pers_rec_unique_id
@@ -0,0 +1,25 @@
+package alertmodels
+
+// 告警规则
+type AlertRulePO struct {
+	ID              int64  `gorm:"column:f_id;primaryKey"`                 // 主键ID
+	Name            string `gorm:"column:f_name;type:varchar(128);unique"` // 规则名称
+	Description     string `gorm:"column:f_description;type:varchar(512)"` // 规则描述
+	Enabled         bool   `gorm:"column:f_enabled"`                       // 是否启用
+	LogType         string `gorm:"column:f_log_type;type:varchar(64)"`     // 日志类型：login/management/operation 或运营日志业务类型
+	Kind            string `gorm:"column:f_kind;type:varchar(32)"`         // 规则类型：threshold/off_hours
+	Conditions      string `gorm:"column:f_conditions;type:text"`          // 匹配条件, json数组
+	GroupBy         string `gorm:"column:f_group_by;type:varchar(32)"`     // 分组字段, 为空时不分组
+	Threshold       int    `gorm:"column:f_threshold"`                     // 阈值
+	WindowMinutes   int    `gorm:"column:f_window_minutes"`                // 滑动窗口时长, 分钟
+	WorkStart       string `gorm:"column:f_work_start;type:char(5)"`       // 工作时间开始, HH:MM
+	WorkEnd         string `gorm:"column:f_work_end;type:char(5)"`         // 工作时间结束, HH:MM
+	WorkDays        string `gorm:"column:f_work_days;type:varchar(32)"`    // 工作日, 1-7 表示周一到周日, 逗号分隔
+	Level           int    `gorm:"column:f_level"`                         // 告警级别：1-信息，2-警告
+	Webhook         string `gorm:"column:f_webhook;type:varchar(1024)"`    // 告警推送地址
+	CooldownMinutes int    `gorm:"column:f_cooldown_minutes"`              // 告警抑制时长, 分钟
+	CreatedAt       int64  `gorm:"column:f_created_at"`                    // 创建时间
+	CreatedBy       string `gorm:"column:f_created_by;type:varchar(64)"`   // 创建者ID
+	UpdatedAt       int64  `gorm:"column:f_updated_at"`                    // 更新时间
+	UpdatedBy       string `gorm:"column:f_updated_by;type:varchar(64)"`   // 更新者ID
+}
@@ -0,0 +1,67 @@
+package alertmodels
+
+// 告警规则匹配条件
+type Condition struct {
+	Field    string   `json:"field" validate:"required"`
+	Operator string   `json:"operator" validate:"required"`
+	Value    string   `json:"value"`
+	Values   []string `json:"values,omitempty"` // operator 为 in 时使用
+}
+
+type AlertRuleVO struct {
+	ID              int64        `json:"id"`
+	Name            string       `json:"name" validate:"required"`
+	Description     string       `json:"description"`
+	Enabled         bool         `json:"enabled"`
+	LogType         string       `json:"log_type" validate:"required"`
+	Kind            string       `json:"kind" validate:"required"`
+	Conditions      []*Condition `json:"conditions"`
+	GroupBy         string       `json:"group_by"`
+	Threshold       int          `json:"threshold"`
+	WindowMinutes   int          `json:"window_minutes"`
+	WorkStart       string       `json:"work_start"`
+	WorkEnd         string       `json:"work_end"`
+	WorkDays        []int        `json:"work_days"`
+	Level           int          `json:"level"`
+	Webhook         string       `json:"webhook"`
+	CooldownMinutes int          `json:"cooldown_minutes"`
+	CreatedAt       int64        `json:"created_at"`
+	CreatedBy       string       `json:"created_by"`
+	UpdatedAt       int64        `json:"updated_at"`
+	UpdatedBy       string       `json:"updated_by"`
+}
+
+type GetAlertRulesReq struct {
+	LogType string `json:"log_type"`
+	Limit   int    `json:"limit"`
+	Offset  int    `json:"offset"`
+}
+
+type GetAlertRulesRes struct {
+	Entries    []*AlertRuleVO `json:"entries"`
+	TotalCount int64          `json:"total_count"`
+}
+
+// 告警规则评估的事件, 审计日志和运营日志统一转换为事件
+type Event struct {
+	LogType string            `json:"log_type"`
+	LogID   string            `json:"log_id"`
+	Date    int64             `json:"date"` // 事件时间，微秒的时间戳
+	Fields  map[string]string `json:"fields"`
+}
+
+// 告警消息, 通过消息队列和 webhook 推送
+type Alert struct {
+	ID            string `json:"id"`
+	RuleID        int64  `json:"rule_id"`
+	RuleName      string `json:"rule_name"`
+	Kind          string `json:"kind"`
+	LogType       string `json:"log_type"`
+	GroupBy       string `json:"group_by,omitempty"`
+	GroupValue    string `json:"group_value,omitempty"`
+	Count         int64  `json:"count"` // 窗口内匹配的事件数
+	WindowMinutes int    `json:"window_minutes,omitempty"`
+	Level         int    `json:"level"`
+	TriggeredAt   int64  `json:"triggered_at"` // 告警时间，微秒的时间戳
+	Event         *Event `json:"event"`        // 触发告警的事件
+}
@@ -0,0 +1,16 @@
+package dlqmodels
+
+// 运营日志死信
+type DeadLetterPO struct {
+	ID            int64  `json:"id"`
+	BizType       string `json:"biz_type"`
+	Message       string `json:"message"`        // 原始日志内容
+	Reason        string `json:"reason"`         // 进入死信的原因
+	InvalidFields string `json:"invalid_fields"` // 不符合 json schema 的字段, json 数组
+	ErrMsg        string `json:"err_msg"`        // 错误信息
+	Status        int    `json:"status"`
+	Attempts      int    `json:"attempts"` // 重放次数
+	CreatedAt     int64  `json:"created_at"`
+	UpdatedAt     int64  `json:"updated_at"`
+	ReplayedAt    int64  `json:"replayed_at"`
+}
@@ -0,0 +1,61 @@
+package dlqmodels
+
+import jsoniter "github.com/json-iterator/go"
+
+type DeadLetterVO struct {
+	ID            int64               `json:"id"`
+	BizType       string              `json:"biz_type"`
+	Message       jsoniter.RawMessage `json:"message,omitempty"` // 列表中不返回日志内容
+	Reason        string              `json:"reason"`
+	InvalidFields []string            `json:"invalid_fields"`
+	ErrMsg        string              `json:"err_msg"`
+	Status        int                 `json:"status"`
+	Attempts      int                 `json:"attempts"`
+	CreatedAt     int64               `json:"created_at"`
+	UpdatedAt     int64               `json:"updated_at"`
+	ReplayedAt    int64               `json:"replayed_at"`
+}
+
+type GetDeadLettersReq struct {
+	BizType string `json:"biz_type"`
+	Reason  string `json:"reason"`
+	Status  int    `json:"status"`
+	Limit   int    `json:"limit"`
+	Offset  int    `json:"offset"`
+}
+
+type GetDeadLettersRes struct {
+	Entries    []*DeadLetterVO `json:"entries"`
+	TotalCount int64           `json:"total_count"`
+}
+
+// 清除死信, 指定 ids 时按 ids 清除, 否则按条件清除
+type PurgeDeadLettersReq struct {
+	IDs     []int64 `json:"ids"`
+	BizType string  `json:"biz_type"`
+	Status  int     `json:"status"`
+	Before  int64   `json:"before"` // 清除该时间之前进入死信的日志, 微秒的时间戳
+}
+
+type PurgeDeadLettersRes struct {
+	Count int64 `json:"count"`
+}
+
+// 重放死信, 指定 ids 时按 ids 重放, 否则重放该业务类型下待处理的死信
+type ReplayDeadLettersReq struct {
+	IDs     []int64 `json:"ids"`
+	BizType string  `json:"biz_type"`
+}
+
+type ReplayFailure struct {
+	ID            int64    `json:"id"`
+	Reason        string   `json:"reason"`
+	InvalidFields []string `json:"invalid_fields"`
+	ErrMsg        string   `json:"err_msg"`
+}
+
+type ReplayDeadLettersRes struct {
+	Total    int              `json:"total"`
+	Replayed int              `json:"replayed"`
+	Failures []*ReplayFailure `json:"failures"`
+}
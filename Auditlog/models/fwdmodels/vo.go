@@ -0,0 +1,20 @@
+package fwdmodels
+
+// 日志转发统计
+type ForwardMetrics struct {
+	Enabled       bool   `json:"enabled"`
+	Sent          int64  `json:"sent"`     // 本实例发送成功数
+	Failed        int64  `json:"failed"`   // 本实例发送失败次数, 包含重试失败
+	Queued        int64  `json:"queued"`   // 本实例写入 outbox 的日志数
+	Filtered      int64  `json:"filtered"` // 被过滤的日志数
+	Dropped       int64  `json:"dropped"`  // 格式化或写入 outbox 失败而丢弃的日志数
+	LastError     string `json:"last_error"`
+	LastErrorTime int64  `json:"last_error_time"`
+}
+
+// outbox 中的转发消息, 消息在入队前已格式化, 发送时原样发送
+type ForwardMsg struct {
+	LogType string `json:"log_type"`
+	LogID   string `json:"log_id"`
+	Message string `json:"message"`
+}
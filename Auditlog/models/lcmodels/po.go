@@ -0,0 +1,43 @@
+package lcmodels
+
+// 哈希链上的日志记录, 包含参与哈希计算的全部字段
+type LogChainRecordPO struct {
+	Seq            int64  `gorm:"column:f_chain_seq"`       // 链上序号
+	LogID          string `gorm:"column:f_log_id"`          // 日志id
+	UserID         string `gorm:"column:f_user_id"`         // 用户id
+	UserName       string `gorm:"column:f_user_name"`       // 用户显示名
+	UserType       string `gorm:"column:f_user_type"`       // 用户类型
+	ObjID          string `gorm:"column:f_obj_id"`          // 对象id
+	Level          int    `gorm:"column:f_level"`           // 日志级别
+	OpType         int    `gorm:"column:f_op_type"`         // 操作类型
+	Date           int64  `gorm:"column:f_date"`            // 日志记录时间
+	IP             string `gorm:"column:f_ip"`              // 访问者IP
+	MAC            string `gorm:"column:f_mac"`             // 文档入口所属站点
+	Msg            string `gorm:"column:f_msg"`             // 日志描述
+	ExMsg          string `gorm:"column:f_exmsg"`           // 日志附加描述
+	UserAgent      string `gorm:"column:f_user_agent"`      // 用户代理
+	AdditionalInfo string `gorm:"column:f_additional_info"` // 附加信息
+	UserPaths      string `gorm:"column:f_user_paths"`      // 用户所属部门信息
+	ObjName        string `gorm:"column:f_obj_name"`        // 对象名称
+	ObjType        int    `gorm:"column:f_obj_type"`        // 对象类型
+	PrevHash       string `gorm:"column:f_prev_hash"`       // 前序记录哈希
+	Hash           string `gorm:"column:f_hash"`            // 本记录哈希
+}
+
+// 哈希链链头, 每种日志类型一条
+type LogChainHeadPO struct {
+	LogType   int    `gorm:"column:f_log_type;primaryKey"` // 日志类型：10-访问日志，11-管理日志，12-操作日志
+	Seq       int64  `gorm:"column:f_seq"`                 // 最后一条记录的序号
+	Hash      string `gorm:"column:f_hash"`                // 最后一条记录的哈希
+	Signature string `gorm:"column:f_signature"`           // 签名
+}
+
+// 哈希链签名检查点
+type LogChainCheckpointPO struct {
+	LogType   int    `gorm:"column:f_log_type"`   // 日志类型
+	Seq       int64  `gorm:"column:f_seq"`        // 检查点对应记录的序号
+	LogID     string `gorm:"column:f_log_id"`     // 检查点对应记录的日志id
+	Hash      string `gorm:"column:f_hash"`       // 检查点对应记录的哈希
+	Signature string `gorm:"column:f_signature"`  // 签名
+	CreatedAt int64  `gorm:"column:f_created_at"` // 创建时间
+}
@@ -0,0 +1,34 @@
+package lcmodels
+
+// 哈希链校验结果
+type VerifyRes struct {
+	LogType        string      `json:"log_type"`
+	BeginSeq       int64       `json:"begin_seq"`
+	EndSeq         int64       `json:"end_seq"`
+	CheckedCount   int64       `json:"checked_count"`
+	UnchainedCount int64       `json:"unchained_count"` // 未入链的记录数量, 包括启用哈希链之前写入的记录
+	Valid          bool        `json:"valid"`
+	FirstBroken    *BrokenLink `json:"first_broken,omitempty"`
+}
+
+// 哈希链第一处断裂
+type BrokenLink struct {
+	Seq          int64  `json:"seq"`
+	LogID        string `json:"log_id"`
+	Reason       string `json:"reason"`
+	ExpectedHash string `json:"expected_hash"`
+	ActualHash   string `json:"actual_hash"`
+}
+
+// 转储文件中的哈希链锚点, 转储后可与数据库中剩余的链衔接校验
+type ChainAnchor struct {
+	LogType       string `json:"log_type"`
+	FirstSeq      int64  `json:"first_seq"`
+	FirstLogID    string `json:"first_log_id"`
+	FirstPrevHash string `json:"first_prev_hash"`
+	LastSeq       int64  `json:"last_seq"`
+	LastLogID     string `json:"last_log_id"`
+	LastHash      string `json:"last_hash"`
+	Count         int64  `json:"count"`
+	Signature     string `json:"signature"`
+}
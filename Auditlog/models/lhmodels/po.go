@@ -0,0 +1,17 @@
+package lhmodels
+
+// 法律保留
+type LegalHoldPO struct {
+	ID          int64  `gorm:"column:f_id;primaryKey"`                 // 主键ID
+	Name        string `gorm:"column:f_name;type:varchar(128);unique"` // 保留名称
+	Reason      string `gorm:"column:f_reason;type:varchar(512)"`      // 保留原因
+	LogType     string `gorm:"column:f_log_type;type:varchar(64)"`     // 日志类型：login/management/operation 或运营日志业务类型, 为空时保留所有类型
+	BeginTime   int64  `gorm:"column:f_begin_time"`                    // 保留范围开始时间，微秒的时间戳, 0表示不限
+	EndTime     int64  `gorm:"column:f_end_time"`                      // 保留范围结束时间，微秒的时间戳, 0表示不限
+	UserIDs     string `gorm:"column:f_user_ids;type:text"`            // 保留的用户ID, json数组, 为空时不限
+	Departments string `gorm:"column:f_departments;type:text"`         // 保留的部门路径, json数组, 为空时不限
+	CreatedAt   int64  `gorm:"column:f_created_at"`                    // 创建时间
+	CreatedBy   string `gorm:"column:f_created_by;type:varchar(64)"`   // 创建者ID
+	UpdatedAt   int64  `gorm:"column:f_updated_at"`                    // 更新时间
+	UpdatedBy   string `gorm:"column:f_updated_by;type:varchar(64)"`   // 更新者ID
+}
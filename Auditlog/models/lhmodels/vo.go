@@ -0,0 +1,27 @@
+package lhmodels
+
+type LegalHoldVO struct {
+	ID          int64    `json:"id"`
+	Name        string   `json:"name" validate:"required"`
+	Reason      string   `json:"reason"`
+	LogType     string   `json:"log_type"`
+	BeginTime   int64    `json:"begin_time"`
+	EndTime     int64    `json:"end_time"`
+	UserIDs     []string `json:"user_ids"`
+	Departments []string `json:"departments"` // 部门路径, 如 "组织/部门", 包含子部门
+	CreatedAt   int64    `json:"created_at"`
+	CreatedBy   string   `json:"created_by"`
+	UpdatedAt   int64    `json:"updated_at"`
+	UpdatedBy   string   `json:"updated_by"`
+}
+
+type GetLegalHoldsReq struct {
+	LogType string `json:"log_type"`
+	Limit   int    `json:"limit"`
+	Offset  int    `json:"offset"`
+}
+
+type GetLegalHoldsRes struct {
+	Entries    []*LegalHoldVO `json:"entries"`
+	TotalCount int64          `json:"total_count"`
+}
@@ -8,6 +8,24 @@ type DumpStrategy struct {
 	DumpFormat          string `json:"dump_format" validate:"required"`
 }
 
+// 转存清单, 与转存文件一同保存, 用于离线校验下载的历史日志
+type DumpManifest struct {
+	Version     int    `json:"version"`
+	FileName    string `json:"file_name"`
+	LogType     string `json:"log_type"`
+	Format      string `json:"format"`       // 转存格式, 如 csv.gz
+	Encrypted   bool   `json:"encrypted"`    // 是否使用公钥加密
+	RecordCount int64  `json:"record_count"` // 日志条数
+	BeginTime   int64  `json:"begin_time"`   // 最早一条日志的时间, 微秒的时间戳
+	EndTime     int64  `json:"end_time"`     // 最晚一条日志的时间, 微秒的时间戳
+	FirstLogID  string `json:"first_log_id"`
+	LastLogID   string `json:"last_log_id"`
+	Size        int64  `json:"size"`     // 转存文件大小
+	Checksum    string `json:"checksum"` // 转存文件的 sha256, 十六进制
+	DumpDate    int64  `json:"dump_date"`
+	Signature   string `json:"signature"` // 除签名外所有字段的 HMAC-SHA256
+}
+
 type ScopeStrategyVO struct {
 	ID          int      `json:"id"`
 	LogType     int8     `json:"type" validate:"required"`
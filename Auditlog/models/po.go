@@ -34,6 +34,14 @@ type LogPO struct {
 	ObjType        int    `gorm:"column:f_obj_type;type:tinyint(4);not null;default:0" json:"objType"`         // 对象类型
 }
 
+// LogStatPO 日志聚合统计结果
+type LogStatPO struct {
+	Key    string // 分组值, 不分组时为空
+	Name   string // 按用户分组时为用户显示名
+	Bucket int64  // 时间分桶的开始时间, 微秒的时间戳, 不分桶时为0
+	Count  int64
+}
+
 type HistoryPO struct {
 	ID       string `gorm:"column:f_id;type:char(128);not null"`
 	Name     string `gorm:"column:f_name;type:char(128);not null"`
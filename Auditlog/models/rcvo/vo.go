@@ -64,6 +64,29 @@ type ReportGetDataListReq struct {
 	Condition map[string]any `json:"condition"`
 	IDs       []string       `json:"ids"`
 	OrderBy   OrderFields    `json:"order_by"`
+	// WorkspaceID 调查工作区ID, 不为0时查询工作区中从历史日志恢复的日志
+	WorkspaceID int64 `json:"workspace_id"`
+}
+
+// 获取活跃日志统计请求
+type ReportGetStatisticsReq struct {
+	Condition map[string]any `json:"condition"`
+	GroupBy   string         `json:"group_by"` // 分组维度, 为空时不分组
+	Interval  string         `json:"interval"` // 时间分桶粒度, 为空时不分桶, 不为空时必须指定时间范围
+	TopN      int            `json:"top_n"`    // 按数量倒序返回的分组数
+}
+
+// 活跃日志统计数据
+type ReportStatistic struct {
+	Key    string `json:"key"`              // 分组值
+	Name   string `json:"name"`             // 分组显示名称
+	Bucket int64  `json:"bucket,omitempty"` // 时间分桶的开始时间, 毫秒的时间戳
+	Count  int64  `json:"count"`
+}
+
+// 获取活跃日志统计响应
+type ReportStatisticsRes struct {
+	Entries []ReportStatistic `json:"entries"`
 }
 
 // 获取活跃日志数据列表请求
@@ -0,0 +1,39 @@
+package rptmodels
+
+// 报表订阅
+type SubscriptionPO struct {
+	ID           int64  `gorm:"column:f_id;primaryKey"`                 // 主键ID
+	Name         string `gorm:"column:f_name;type:varchar(128);unique"` // 报表名称
+	LogType      string `gorm:"column:f_log_type;type:varchar(64)"`     // 日志类型：login/management/operation
+	Condition    string `gorm:"column:f_condition;type:text"`           // 过滤条件, json对象, 与活跃日志报表的查询条件一致
+	Columns      string `gorm:"column:f_columns;type:text"`             // 导出的列, json数组, 为空时导出所有列
+	Format       string `gorm:"column:f_format;type:varchar(16)"`       // 文件格式：csv/jsonl
+	Schedule     string `gorm:"column:f_schedule;type:varchar(16)"`     // 执行周期：daily/weekly/monthly
+	ScheduleDay  int    `gorm:"column:f_schedule_day"`                  // 执行日, 每周为星期几(0-6), 每月为几号(1-31)
+	ScheduleTime string `gorm:"column:f_schedule_time;type:varchar(8)"` // 执行时间, 格式为 HH:MM
+	Subscribers  string `gorm:"column:f_subscribers;type:text"`         // 订阅者用户ID, json数组
+	Enabled      bool   `gorm:"column:f_enabled"`                       // 是否启用
+	NextRunAt    int64  `gorm:"column:f_next_run_at"`                   // 下次执行时间，微秒的时间戳
+	LastRunAt    int64  `gorm:"column:f_last_run_at"`                   // 上次执行时间，微秒的时间戳
+	CreatedAt    int64  `gorm:"column:f_created_at"`                    // 创建时间
+	CreatedBy    string `gorm:"column:f_created_by;type:varchar(64)"`   // 创建者ID, 按创建者的日志查看范围生成报表
+	UpdatedAt    int64  `gorm:"column:f_updated_at"`                    // 更新时间
+	UpdatedBy    string `gorm:"column:f_updated_by;type:varchar(64)"`   // 更新者ID
+}
+
+// 报表执行记录
+type RunPO struct {
+	ID             int64  `gorm:"column:f_id;primaryKey"`                 // 主键ID
+	SubscriptionID int64  `gorm:"column:f_subscription_id"`               // 报表订阅ID
+	Status         int    `gorm:"column:f_status"`                        // 执行状态：1 执行中，2 成功，3 失败
+	BeginTime      int64  `gorm:"column:f_begin_time"`                    // 报表数据开始时间，微秒的时间戳
+	EndTime        int64  `gorm:"column:f_end_time"`                      // 报表数据结束时间(不含)，微秒的时间戳
+	RecordCount    int64  `gorm:"column:f_record_count"`                  // 导出的日志条数
+	OssID          string `gorm:"column:f_oss_id;type:varchar(128)"`      // 对象存储ID
+	ObjectName     string `gorm:"column:f_object_name;type:varchar(128)"` // 对象存储中的文件名
+	FileName       string `gorm:"column:f_file_name;type:varchar(255)"`   // 下载文件名
+	ErrMsg         string `gorm:"column:f_err_msg;type:text"`             // 失败原因
+	StartedAt      int64  `gorm:"column:f_started_at"`                    // 开始执行时间
+	FinishedAt     int64  `gorm:"column:f_finished_at"`                   // 结束执行时间
+	FileExpired    bool   `gorm:"column:f_file_expired"`                  // 报表文件是否已从对象存储删除
+}
@@ -0,0 +1,70 @@
+package rptmodels
+
+type SubscriptionVO struct {
+	ID           int64          `json:"id"`
+	Name         string         `json:"name"`
+	LogType      string         `json:"log_type"`
+	Condition    map[string]any `json:"condition"` // 过滤条件, 与活跃日志报表的查询条件一致, 不含时间范围
+	Columns      []string       `json:"columns"`   // 导出的列, 为空时导出所有列
+	Format       string         `json:"format"`
+	Schedule     string         `json:"schedule"`
+	ScheduleDay  int            `json:"schedule_day"`
+	ScheduleTime string         `json:"schedule_time"`
+	Subscribers  []string       `json:"subscribers"`
+	Enabled      bool           `json:"enabled"`
+	NextRunAt    int64          `json:"next_run_at"`
+	LastRunAt    int64          `json:"last_run_at"`
+	CreatedAt    int64          `json:"created_at"`
+	CreatedBy    string         `json:"created_by"`
+	UpdatedAt    int64          `json:"updated_at"`
+	UpdatedBy    string         `json:"updated_by"`
+}
+
+type GetSubscriptionsReq struct {
+	LogType string `json:"log_type"`
+	Limit   int    `json:"limit"`
+	Offset  int    `json:"offset"`
+}
+
+type GetSubscriptionsRes struct {
+	Entries    []*SubscriptionVO `json:"entries"`
+	TotalCount int64             `json:"total_count"`
+}
+
+type RunVO struct {
+	ID             int64  `json:"id"`
+	SubscriptionID int64  `json:"subscription_id"`
+	Status         int    `json:"status"`
+	BeginTime      int64  `json:"begin_time"`
+	EndTime        int64  `json:"end_time"`
+	RecordCount    int64  `json:"record_count"`
+	FileName       string `json:"file_name"`
+	FileExpired    bool   `json:"file_expired"`
+	ErrMsg         string `json:"err_msg"`
+	StartedAt      int64  `json:"started_at"`
+	FinishedAt     int64  `json:"finished_at"`
+}
+
+type GetRunsReq struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+type GetRunsRes struct {
+	Entries    []*RunVO `json:"entries"`
+	TotalCount int64    `json:"total_count"`
+}
+
+// RunNotification 报表执行结果通知, 执行成功和失败时均推送到消息队列
+type RunNotification struct {
+	SubscriptionID int64    `json:"subscription_id"`
+	Name           string   `json:"name"`
+	RunID          int64    `json:"run_id"`
+	Status         int      `json:"status"`
+	Subscribers    []string `json:"subscribers"`
+	FileName       string   `json:"file_name"`
+	RecordCount    int64    `json:"record_count"`
+	BeginTime      int64    `json:"begin_time"`
+	EndTime        int64    `json:"end_time"`
+	ErrMsg         string   `json:"err_msg"`
+}
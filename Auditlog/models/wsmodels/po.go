@@ -0,0 +1,15 @@
+package wsmodels
+
+// 调查工作区, 用于查询从历史日志文件恢复的日志
+type WorkspacePO struct {
+	ID          int64  `gorm:"column:f_id;primaryKey"`               // 主键ID
+	Name        string `gorm:"column:f_name;type:varchar(128)"`      // 工作区名称
+	LogType     string `gorm:"column:f_log_type;type:varchar(32)"`   // 日志类型：login/management/operation
+	HistoryIDs  string `gorm:"column:f_history_ids;type:text"`       // 恢复的历史日志ID, json数组
+	Status      int    `gorm:"column:f_status"`                      // 状态, 1: 恢复中, 2: 可查询, 3: 恢复失败
+	RecordCount int64  `gorm:"column:f_record_count"`                // 已恢复的日志条数
+	ErrMsg      string `gorm:"column:f_err_msg;type:text"`           // 恢复失败的原因
+	ExpireAt    int64  `gorm:"column:f_expire_at"`                   // 过期时间, 过期后删除工作区及恢复的日志
+	CreatedAt   int64  `gorm:"column:f_created_at"`                  // 创建时间
+	CreatedBy   string `gorm:"column:f_created_by;type:varchar(64)"` // 创建者ID
+}
@@ -0,0 +1,32 @@
+package wsmodels
+
+type WorkspaceVO struct {
+	ID          int64    `json:"id"`
+	Name        string   `json:"name"`
+	LogType     string   `json:"log_type"`
+	HistoryIDs  []string `json:"history_ids"`
+	Status      int      `json:"status"`
+	RecordCount int64    `json:"record_count"`
+	ErrMsg      string   `json:"err_msg"`
+	ExpireAt    int64    `json:"expire_at"`
+	CreatedAt   int64    `json:"created_at"`
+	CreatedBy   string   `json:"created_by"`
+}
+
+// NewWorkspaceReq 从历史日志文件恢复日志到调查工作区
+type NewWorkspaceReq struct {
+	Name       string   `json:"name"`
+	LogType    string   `json:"log_type"`
+	HistoryIDs []string `json:"history_ids"`
+	ExpireDays int      `json:"expire_days"` // 有效天数, 为0时使用默认值
+}
+
+type GetWorkspacesReq struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+type GetWorkspacesRes struct {
+	Entries    []*WorkspaceVO `json:"entries"`
+	TotalCount int64          `json:"total_count"`
+}
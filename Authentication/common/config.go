@@ -14,30 +14,79 @@ var SvcConfig Config
 
 // Config 配置文件
 type Config struct {
-	Lang                      string      `yaml:"lang"`
-	SvcHost                   string      `yaml:"svc_host"`
-	SvcPublicPort             int         `yaml:"svc_public_port"`
-	SvcPrivatePort            int         `yaml:"svc_private_port"`
-	DBClientPingSleep         int         `yaml:"db_client_ping_sleep"`
-	MaxOpenConns              int         `yaml:"max_open_conns"`
-	OAuthPublicHost           string      `yaml:"oauth_public_host"`
-	OAuthPublicPort           int         `yaml:"oauth_public_port"`
-	OAuthAdminHost            string      `yaml:"oauth_admin_host"`
-	OAuthAdminPort            int         `yaml:"oauth_admin_port"`
-	UserManagementPrivateHost string      `yaml:"user_management_private_host"`
-	UserManagementPrivatePort int         `yaml:"user_management_private_port"`
-	EacpPrivateHost           string      `yaml:"eacp_private_host"`
-	EacpPrivatePort           int         `yaml:"eacp_private_port"`
-	ShareMgntHost             string      `yaml:"sharemgnt_host"`
-	ShareMgntPort             int         `yaml:"sharemgnt_port"`
-	BusinessTimeOffset        int64       `yaml:"business_time_offset"`
-	FlowExpiredTime           int64       `yaml:"flow_expired_time"`
-	FlowCleanTime             string      `yaml:"flow_clean_time"`
-	LogLevel                  int         `yaml:"log_level"`
-	SystemID                  string      `yaml:"system_id"`
-	Redis                     RedisConfig `yaml:"redis"`
+	Lang                      string         `yaml:"lang"`
+	SvcHost                   string         `yaml:"svc_host"`
+	SvcPublicPort             int            `yaml:"svc_public_port"`
+	SvcPrivatePort            int            `yaml:"svc_private_port"`
+	DBClientPingSleep         int            `yaml:"db_client_ping_sleep"`
+	MaxOpenConns              int            `yaml:"max_open_conns"`
+	OAuthPublicHost           string         `yaml:"oauth_public_host"`
+	OAuthPublicPort           int            `yaml:"oauth_public_port"`
+	OAuthAdminHost            string         `yaml:"oauth_admin_host"`
+	OAuthAdminPort            int            `yaml:"oauth_admin_port"`
+	UserManagementPrivateHost string         `yaml:"user_management_private_host"`
+	UserManagementPrivatePort int            `yaml:"user_management_private_port"`
+	EacpPrivateHost           string         `yaml:"eacp_private_host"`
+	EacpPrivatePort           int            `yaml:"eacp_private_port"`
+	ShareMgntHost             string         `yaml:"sharemgnt_host"`
+	ShareMgntPort             int            `yaml:"sharemgnt_port"`
+	BusinessTimeOffset        int64          `yaml:"business_time_offset"`
+	FlowExpiredTime           int64          `yaml:"flow_expired_time"`
+	FlowCleanTime             string         `yaml:"flow_clean_time"`
+	LogLevel                  int            `yaml:"log_level"`
+	SystemID                  string         `yaml:"system_id"`
+	TOTPIssuer                string         `yaml:"totp_issuer"`
+	TOTPSecretKey             string         `yaml:"totp_secret_key"`
+	Redis                     RedisConfig    `yaml:"redis"`
+	WebAuthn                  WebAuthnConfig `yaml:"webauthn"`
+	SAML                      SAMLConfig     `yaml:"saml"`
+	SAMLPrivateKey            string         `yaml:"saml_private_key"`
+	LDAP                      LDAPConfig     `yaml:"ldap"`
+	LDAPBindPasswords         LDAPPasswords  `yaml:"ldap_bind_passwords"`
 }
 
+// WebAuthnConfig 安全密钥配置信息
+type WebAuthnConfig struct {
+	RPID           string   `yaml:"rp_id"`           // 依赖方标识，通常为访问域名，为空时不启用安全密钥
+	RPName         string   `yaml:"rp_name"`         // 依赖方名称，注册时展示给用户
+	Origins        []string `yaml:"origins"`         // 允许的来源，例如 https://anyshare.example.com
+	Attestation    string   `yaml:"attestation"`     // 证明策略 none/direct，direct 时仅接受已校验的 packed 证明
+	AllowedAAGUIDs []string `yaml:"allowed_aaguids"` // 允许注册的认证器型号，为空时不限制
+	AttestationCA  string   `yaml:"attestation_ca"`  // 证明证书的可信根证书，PEM格式，为空时不校验证书链
+}
+
+// SAMLConfig SAML服务提供方配置信息，签名私钥saml_private_key配置在secret文件中
+type SAMLConfig struct {
+	EntityID      string `yaml:"entity_id"`       // 服务提供方标识，为空时不启用SAML
+	BaseURL       string `yaml:"base_url"`        // 对外访问地址，用于生成断言消费及单点登出地址，例如 https://anyshare.example.com
+	Certificate   string `yaml:"certificate"`     // 签名证书，PEM格式
+	LoginPageURL  string `yaml:"login_page_url"`  // 断言校验通过后携带state跳转的登录页地址
+	LogoutPageURL string `yaml:"logout_page_url"` // 单点登出完成后跳转的地址
+}
+
+// LDAPConfig LDAP/AD直连认证配置，未配置的域仍由sharemgnt完成域认证
+type LDAPConfig struct {
+	Directories []LDAPDirectory `yaml:"directories"`
+}
+
+// LDAPDirectory LDAP/AD目录配置，服务账户密码配置在secret文件的ldap_bind_passwords中
+type LDAPDirectory struct {
+	DomainPath     string   `yaml:"domain_path"`     // 用户所属域路径，与用户信息中的domain_path一致
+	Servers        []string `yaml:"servers"`         // 域控制器地址 host[:port]，按顺序故障转移
+	Security       string   `yaml:"security"`        // 连接方式 ldaps/starttls/none，默认ldaps
+	CACert         string   `yaml:"ca_cert"`         // 域控制器证书的可信根证书，PEM格式，为空时使用系统根证书
+	BindDN         string   `yaml:"bind_dn"`         // 查找用户的服务账户，为空时匿名查找
+	BaseDN         string   `yaml:"base_dn"`         // 查找用户的根节点
+	UserFilter     string   `yaml:"user_filter"`     // 查找用户的过滤条件，{account}替换为转义后的账户名，为空时按域类型取默认值
+	GroupBaseDN    string   `yaml:"group_base_dn"`   // 查找组的根节点，为空时与base_dn相同
+	RequiredGroups []string `yaml:"required_groups"` // 允许登录的组DN，包含嵌套组的成员，为空时不限制
+	PoolSize       int      `yaml:"pool_size"`       // 每个目录保留的空闲连接数，默认5
+	Timeout        int      `yaml:"timeout"`         // 连接及操作超时时间，单位为秒，默认5
+}
+
+// LDAPPasswords LDAP服务账户密码，按域路径索引
+type LDAPPasswords map[string]string
+
 // RedisConfig 配置信息
 type RedisConfig struct {
 	ConnectType string           `yaml:"connectType"` // sentinel/standalone/master-slave 对应哨兵、单机、主从三种连接方式
@@ -95,6 +144,9 @@ func InitConfig() {
 	svcConfig := SvcConfig
 	svcConfig.Redis.ConnectInfo.Password = ""
 	svcConfig.Redis.ConnectInfo.SentinelPassword = ""
+	svcConfig.TOTPSecretKey = ""
+	svcConfig.SAMLPrivateKey = ""
+	svcConfig.LDAPBindPasswords = nil
 
 	configLog.Infoln(svcConfig)
 
@@ -68,6 +68,10 @@ const (
 	OTPTimeout int = 401020166
 	// OTPTooManyWrongTime 动态错误次数过多
 	OTPTooManyWrongTime int = 401020167
+	// OTPNotEnrolled 未绑定动态密码
+	OTPNotEnrolled int = 401020168
+	// WebAuthnVerifyFailed 安全密钥校验失败
+	WebAuthnVerifyFailed int = 401020169
 	// ImageVCodeMoreThanTheLimie 验证码输入已达到限定次数
 	ImageVCodeMoreThanTheLimie int = 401020161
 	// MFAOTPServerError MFA动态密码服务器异常
@@ -78,6 +82,14 @@ const (
 	// MFAConfigError = 401020618
 	// FailedThirdConfig 第三方认证配置错误
 	FailedThirdConfig int = 401020602
+	// OIDCAuthFailed OIDC身份提供方认证失败
+	OIDCAuthFailed int = 401020620
+	// OIDCAccountNotMatched OIDC身份未匹配到账户
+	OIDCAccountNotMatched int = 401020621
+	// SAMLAuthFailed SAML身份提供方认证失败
+	SAMLAuthFailed int = 401020622
+	// SAMLAccountNotMatched SAML身份未匹配到账户
+	SAMLAccountNotMatched int = 401020623
 )
 
 var (
@@ -238,6 +250,16 @@ var (
 			rest.Languages[1]: "動態密碼錯誤",
 			rest.Languages[2]: "Wrong one time password.",
 		},
+		OTPNotEnrolled: {
+			rest.Languages[0]: "未绑定动态密码，请联系管理员",
+			rest.Languages[1]: "未綁定動態密碼，請聯繫管理員",
+			rest.Languages[2]: "One time password is not enrolled, please contact admin.",
+		},
+		WebAuthnVerifyFailed: {
+			rest.Languages[0]: "安全密钥校验失败",
+			rest.Languages[1]: "安全金鑰驗證失敗",
+			rest.Languages[2]: "Security key verification failed.",
+		},
 		ImageVCodeMoreThanTheLimie: {
 			rest.Languages[0]: "验证码输入已达到限定次数",
 			rest.Languages[1]: "驗證碼輸入已達到限定次數",
@@ -263,5 +285,25 @@ var (
 			rest.Languages[1]: "協力廠商認證模組載入失敗: %s",
 			rest.Languages[2]: "Failed to invoke the third-party authentication tool: %s",
 		},
+		OIDCAuthFailed: {
+			rest.Languages[0]: "身份提供方认证失败",
+			rest.Languages[1]: "身分識別提供者認證失敗",
+			rest.Languages[2]: "Identity provider authentication failed.",
+		},
+		OIDCAccountNotMatched: {
+			rest.Languages[0]: "未找到与身份提供方账户关联的用户",
+			rest.Languages[1]: "未找到與身分識別提供者帳戶關聯的使用者",
+			rest.Languages[2]: "No user is associated with the identity provider account.",
+		},
+		SAMLAuthFailed: {
+			rest.Languages[0]: "SAML身份提供方认证失败",
+			rest.Languages[1]: "SAML身分識別提供者認證失敗",
+			rest.Languages[2]: "SAML identity provider authentication failed.",
+		},
+		SAMLAccountNotMatched: {
+			rest.Languages[0]: "未找到与SAML身份提供方账户关联的用户",
+			rest.Languages[1]: "未找到與SAML身分識別提供者帳戶關聯的使用者",
+			rest.Languages[2]: "No user is associated with the SAML identity provider account.",
+		},
 	}
 )
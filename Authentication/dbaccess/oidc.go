@@ -0,0 +1,263 @@
+package dbaccess
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/kweaver-ai/go-lib/observable"
+	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
+
+	"Authentication/common"
+	"Authentication/interfaces"
+)
+
+type oidc struct {
+	dbTrace *sqlx.DB
+	logger  common.Logger
+	trace   observable.Tracer
+}
+
+var (
+	oidcOnce sync.Once
+	oi       *oidc
+)
+
+const oidcProviderFields = "f_id, f_name, f_issuer, f_client_id, f_client_secret, f_scopes, f_redirect_uri, " +
+	"f_mapping_rules, f_jit_provision, f_enabled, f_create_time, f_update_time"
+
+// NewOIDC 创建oidc对象
+func NewOIDC() *oidc {
+	oidcOnce.Do(func() {
+		oi = &oidc{
+			dbTrace: dbTracePool,
+			logger:  common.NewLogger(),
+			trace:   common.SvcARTrace,
+		}
+	})
+	return oi
+}
+
+// AddProvider 添加身份提供方
+func (o *oidc) AddProvider(ctx context.Context, provider *interfaces.OIDCProvider) (err error) {
+	o.trace.SetClientSpanName("数据访问层-添加OIDC身份提供方")
+	newCtx, span := o.trace.AddClientTrace(ctx)
+	defer func() { o.trace.TelemetrySpanEnd(span, err) }()
+
+	scopes, rules, err := marshalProviderLists(provider)
+	if err != nil {
+		return err
+	}
+
+	sqlStr := "insert into t_oidc_provider(`f_id`, `f_name`, `f_issuer`, `f_client_id`, `f_client_secret`, `f_scopes`, " +
+		"`f_redirect_uri`, `f_mapping_rules`, `f_jit_provision`, `f_enabled`, `f_create_time`, `f_update_time`) " +
+		"values(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	_, err = o.dbTrace.ExecContext(newCtx, sqlStr, provider.ID, provider.Name, provider.Issuer, provider.ClientID,
+		provider.ClientSecret, scopes, provider.RedirectURI, rules, provider.JITProvision, provider.Enabled,
+		provider.CreateTime, provider.UpdateTime)
+	if err != nil {
+		o.logger.Errorln(err, sqlStr)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateProvider 更新身份提供方，不存在时返回false
+func (o *oidc) UpdateProvider(ctx context.Context, provider *interfaces.OIDCProvider) (ok bool, err error) {
+	o.trace.SetClientSpanName("数据访问层-更新OIDC身份提供方")
+	newCtx, span := o.trace.AddClientTrace(ctx)
+	defer func() { o.trace.TelemetrySpanEnd(span, err) }()
+
+	scopes, rules, err := marshalProviderLists(provider)
+	if err != nil {
+		return false, err
+	}
+
+	sqlStr := "update t_oidc_provider set f_name = ?, f_issuer = ?, f_client_id = ?, f_client_secret = ?, f_scopes = ?, " +
+		"f_redirect_uri = ?, f_mapping_rules = ?, f_jit_provision = ?, f_enabled = ?, f_update_time = ? where f_id = ?"
+	result, err := o.dbTrace.ExecContext(newCtx, sqlStr, provider.Name, provider.Issuer, provider.ClientID,
+		provider.ClientSecret, scopes, provider.RedirectURI, rules, provider.JITProvision, provider.Enabled,
+		provider.UpdateTime, provider.ID)
+	if err != nil {
+		o.logger.Errorln(err, sqlStr)
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// DeleteProvider 删除身份提供方，不存在时返回false
+func (o *oidc) DeleteProvider(ctx context.Context, id string) (ok bool, err error) {
+	o.trace.SetClientSpanName("数据访问层-删除OIDC身份提供方")
+	newCtx, span := o.trace.AddClientTrace(ctx)
+	defer func() { o.trace.TelemetrySpanEnd(span, err) }()
+
+	sqlStr := "delete from t_oidc_provider where f_id = ?"
+	result, err := o.dbTrace.ExecContext(newCtx, sqlStr, id)
+	if err != nil {
+		o.logger.Errorln(err, sqlStr)
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// GetProvider 获取身份提供方，不存在时返回nil
+func (o *oidc) GetProvider(ctx context.Context, id string) (provider *interfaces.OIDCProvider, err error) {
+	o.trace.SetClientSpanName("数据访问层-获取OIDC身份提供方")
+	newCtx, span := o.trace.AddClientTrace(ctx)
+	defer func() { o.trace.TelemetrySpanEnd(span, err) }()
+
+	sqlStr := "select " + oidcProviderFields + " from t_oidc_provider where f_id = ?"
+	provider, err = scanProvider(o.dbTrace.QueryRowContext(newCtx, sqlStr, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		o.logger.Errorln(err, sqlStr)
+		return nil, err
+	}
+
+	return provider, nil
+}
+
+// GetProviders 获取所有身份提供方
+func (o *oidc) GetProviders(ctx context.Context) (providers []interfaces.OIDCProvider, err error) {
+	o.trace.SetClientSpanName("数据访问层-获取OIDC身份提供方列表")
+	newCtx, span := o.trace.AddClientTrace(ctx)
+	defer func() { o.trace.TelemetrySpanEnd(span, err) }()
+
+	sqlStr := "select " + oidcProviderFields + " from t_oidc_provider order by f_create_time"
+	rows, err := o.dbTrace.QueryContext(newCtx, sqlStr)
+	if err != nil {
+		o.logger.Errorln(err, sqlStr)
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			o.logger.Errorln(closeErr)
+		}
+	}()
+
+	providers = make([]interfaces.OIDCProvider, 0)
+	for rows.Next() {
+		provider, scanErr := scanProvider(rows)
+		if scanErr != nil {
+			err = scanErr
+			o.logger.Errorln(err, sqlStr)
+			return nil, err
+		}
+		providers = append(providers, *provider)
+	}
+	if err = rows.Err(); err != nil {
+		o.logger.Errorln(err, sqlStr)
+		return nil, err
+	}
+
+	return providers, nil
+}
+
+// CreateState 保存授权请求状态，并清理创建时间早于expireBefore的状态
+func (o *oidc) CreateState(ctx context.Context, state *interfaces.OIDCAuthState, expireBefore int64) (err error) {
+	o.trace.SetClientSpanName("数据访问层-保存OIDC授权请求状态")
+	newCtx, span := o.trace.AddClientTrace(ctx)
+	defer func() { o.trace.TelemetrySpanEnd(span, err) }()
+
+	sqlStr := "delete from t_oidc_state where f_create_time < ?"
+	if _, err = o.dbTrace.ExecContext(newCtx, sqlStr, expireBefore); err != nil {
+		o.logger.Errorln(err, sqlStr)
+		return err
+	}
+
+	sqlStr = "insert into t_oidc_state(`f_state`, `f_provider_id`, `f_nonce`, `f_code_verifier`, `f_create_time`) " +
+		"values(?, ?, ?, ?, ?)"
+	_, err = o.dbTrace.ExecContext(newCtx, sqlStr, state.State, state.ProviderID, state.Nonce, state.CodeVerifier,
+		state.CreateTime)
+	if err != nil {
+		o.logger.Errorln(err, sqlStr)
+		return err
+	}
+
+	return nil
+}
+
+// ConsumeState 获取并删除授权请求状态，保证状态只能使用一次，不存在时返回nil
+func (o *oidc) ConsumeState(ctx context.Context, stateStr string) (state *interfaces.OIDCAuthState, err error) {
+	o.trace.SetClientSpanName("数据访问层-使用OIDC授权请求状态")
+	newCtx, span := o.trace.AddClientTrace(ctx)
+	defer func() { o.trace.TelemetrySpanEnd(span, err) }()
+
+	state = &interfaces.OIDCAuthState{State: stateStr}
+	sqlStr := "select f_provider_id, f_nonce, f_code_verifier, f_create_time from t_oidc_state where f_state = ?"
+	err = o.dbTrace.QueryRowContext(newCtx, sqlStr, stateStr).Scan(&state.ProviderID, &state.Nonce,
+		&state.CodeVerifier, &state.CreateTime)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		o.logger.Errorln(err, sqlStr)
+		return nil, err
+	}
+
+	// 删除成功代表本次使用有效，并发使用同一状态时只有一个请求能够成功
+	sqlStr = "delete from t_oidc_state where f_state = ?"
+	result, err := o.dbTrace.ExecContext(newCtx, sqlStr, stateStr)
+	if err != nil {
+		o.logger.Errorln(err, sqlStr)
+		return nil, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, nil
+	}
+
+	return state, nil
+}
+
+// marshalProviderLists 权限范围及映射规则以json数组保存
+func marshalProviderLists(provider *interfaces.OIDCProvider) (scopes, rules string, err error) {
+	scopes, err = jsoniter.MarshalToString(provider.Scopes)
+	if err != nil {
+		return "", "", err
+	}
+	rules, err = jsoniter.MarshalToString(provider.MappingRules)
+	if err != nil {
+		return "", "", err
+	}
+	return scopes, rules, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanProvider(row rowScanner) (*interfaces.OIDCProvider, error) {
+	var scopes, rules string
+	provider := &interfaces.OIDCProvider{}
+	err := row.Scan(&provider.ID, &provider.Name, &provider.Issuer, &provider.ClientID, &provider.ClientSecret,
+		&scopes, &provider.RedirectURI, &rules, &provider.JITProvision, &provider.Enabled, &provider.CreateTime,
+		&provider.UpdateTime)
+	if err != nil {
+		return nil, err
+	}
+	if err = jsoniter.UnmarshalFromString(scopes, &provider.Scopes); err != nil {
+		return nil, err
+	}
+	if err = jsoniter.UnmarshalFromString(rules, &provider.MappingRules); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
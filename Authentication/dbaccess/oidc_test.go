@@ -0,0 +1,238 @@
+package dbaccess
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/assert"
+
+	"Authentication/common"
+	"Authentication/interfaces"
+	mocks "Authentication/interfaces/mock"
+)
+
+func newDBOIDC(ptrDB *sqlx.DB, trace interfaces.TraceClient) *oidc {
+	return &oidc{
+		dbTrace: ptrDB,
+		logger:  common.NewLogger(),
+		trace:   trace,
+	}
+}
+
+var oidcProviderColumns = []string{"f_id", "f_name", "f_issuer", "f_client_id", "f_client_secret", "f_scopes",
+	"f_redirect_uri", "f_mapping_rules", "f_jit_provision", "f_enabled", "f_create_time", "f_update_time"}
+
+func TestOIDCAddProvider(t *testing.T) {
+	Convey("AddProvider", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		o := newDBOIDC(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		provider := &interfaces.OIDCProvider{
+			ID:           "p1",
+			Scopes:       []string{"openid", "email"},
+			MappingRules: []interfaces.OIDCMappingRule{{Claim: "email", MatchBy: interfaces.OIDCMatchByEmail}},
+		}
+
+		Convey("success", func() {
+			mock.ExpectExec("insert into t_oidc_provider").
+				WithArgs("p1", "", "", "", "", `["openid","email"]`, "", `[{"claim":"email","match_by":"email"}]`,
+					false, false, 0, 0).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			err := o.AddProvider(ctx, provider)
+			assert.Equal(t, err, nil)
+		})
+	})
+}
+
+func TestOIDCGetProvider(t *testing.T) {
+	Convey("GetProvider", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		o := newDBOIDC(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		Convey("not exist", func() {
+			mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows(oidcProviderColumns))
+
+			provider, err := o.GetProvider(ctx, "p1")
+			assert.Equal(t, err, nil)
+			assert.Assert(t, provider == nil)
+		})
+
+		Convey("db unavailable", func() {
+			tmpErr := fmt.Errorf("unknown error")
+			mock.ExpectQuery("").WillReturnError(tmpErr)
+
+			_, err := o.GetProvider(ctx, "p1")
+			assert.Equal(t, err, tmpErr)
+		})
+
+		Convey("invalid mapping rules", func() {
+			mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows(oidcProviderColumns).
+				AddRow("p1", "idp", "https://idp", "client", "secret", `["openid"]`, "https://cb", "{", 1, 1, 1, 2))
+
+			_, err := o.GetProvider(ctx, "p1")
+			assert.Assert(t, err != nil)
+		})
+
+		Convey("success", func() {
+			mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows(oidcProviderColumns).
+				AddRow("p1", "idp", "https://idp", "client", "secret", `["openid"]`, "https://cb",
+					`[{"claim":"sub","match_by":"third_id"}]`, 1, 0, 1, 2))
+
+			provider, err := o.GetProvider(ctx, "p1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, provider.ID, "p1")
+			assert.Equal(t, provider.Issuer, "https://idp")
+			assert.Equal(t, provider.ClientSecret, "secret")
+			assert.DeepEqual(t, provider.Scopes, []string{"openid"})
+			assert.DeepEqual(t, provider.MappingRules, []interfaces.OIDCMappingRule{{Claim: "sub", MatchBy: interfaces.OIDCMatchByThirdID}})
+			assert.Equal(t, provider.JITProvision, true)
+			assert.Equal(t, provider.Enabled, false)
+			assert.Equal(t, provider.UpdateTime, int64(2))
+		})
+	})
+}
+
+func TestOIDCGetProviders(t *testing.T) {
+	Convey("GetProviders", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		o := newDBOIDC(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		Convey("empty", func() {
+			mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows(oidcProviderColumns))
+
+			providers, err := o.GetProviders(ctx)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(providers), 0)
+		})
+
+		Convey("success", func() {
+			mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows(oidcProviderColumns).
+				AddRow("p1", "idp1", "https://idp1", "c1", "s1", `["openid"]`, "https://cb", `[]`, 0, 1, 1, 1).
+				AddRow("p2", "idp2", "https://idp2", "c2", "s2", `["openid"]`, "https://cb", `[]`, 0, 0, 2, 2))
+
+			providers, err := o.GetProviders(ctx)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(providers), 2)
+			assert.Equal(t, providers[0].ID, "p1")
+			assert.Equal(t, providers[1].Enabled, false)
+		})
+	})
+}
+
+func TestOIDCUpdateProvider(t *testing.T) {
+	Convey("UpdateProvider", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		o := newDBOIDC(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		Convey("not exist", func() {
+			mock.ExpectExec("update t_oidc_provider").WillReturnResult(sqlmock.NewResult(0, 0))
+
+			ok, err := o.UpdateProvider(ctx, &interfaces.OIDCProvider{ID: "p1"})
+			assert.Equal(t, err, nil)
+			assert.Equal(t, ok, false)
+		})
+
+		Convey("success", func() {
+			mock.ExpectExec("update t_oidc_provider").WillReturnResult(sqlmock.NewResult(0, 1))
+
+			ok, err := o.UpdateProvider(ctx, &interfaces.OIDCProvider{ID: "p1"})
+			assert.Equal(t, err, nil)
+			assert.Equal(t, ok, true)
+		})
+	})
+}
+
+func TestOIDCConsumeState(t *testing.T) {
+	Convey("ConsumeState", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		o := newDBOIDC(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		fields := []string{"f_provider_id", "f_nonce", "f_code_verifier", "f_create_time"}
+
+		Convey("not exist", func() {
+			mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows(fields))
+
+			state, err := o.ConsumeState(ctx, "s1")
+			assert.Equal(t, err, nil)
+			assert.Assert(t, state == nil)
+		})
+
+		Convey("consumed concurrently", func() {
+			mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows(fields).AddRow("p1", "n", "v", 100))
+			mock.ExpectExec("delete from t_oidc_state").WillReturnResult(sqlmock.NewResult(0, 0))
+
+			state, err := o.ConsumeState(ctx, "s1")
+			assert.Equal(t, err, nil)
+			assert.Assert(t, state == nil)
+		})
+
+		Convey("success", func() {
+			mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows(fields).AddRow("p1", "n", "v", 100))
+			mock.ExpectExec("delete from t_oidc_state").WithArgs("s1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+			state, err := o.ConsumeState(ctx, "s1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, state.State, "s1")
+			assert.Equal(t, state.ProviderID, "p1")
+			assert.Equal(t, state.Nonce, "n")
+			assert.Equal(t, state.CodeVerifier, "v")
+			assert.Equal(t, state.CreateTime, int64(100))
+		})
+	})
+}
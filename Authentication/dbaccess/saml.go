@@ -0,0 +1,400 @@
+package dbaccess
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/kweaver-ai/go-lib/observable"
+	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
+
+	"Authentication/common"
+	"Authentication/interfaces"
+)
+
+type saml struct {
+	dbTrace *sqlx.DB
+	logger  common.Logger
+	trace   observable.Tracer
+}
+
+var (
+	samlOnce sync.Once
+	sa       *saml
+)
+
+const (
+	samlProviderFields = "f_id, f_name, f_entity_id, f_metadata, f_mapping_rules, f_name_attribute, f_jit_provision, " +
+		"f_enabled, f_create_time, f_update_time"
+	samlSessionFields = "f_user_id, f_provider_id, f_name_id, f_name_id_format, f_name_qualifier, f_sp_name_qualifier, " +
+		"f_session_index, f_create_time"
+)
+
+// NewSAML 创建saml对象
+func NewSAML() *saml {
+	samlOnce.Do(func() {
+		sa = &saml{
+			dbTrace: dbTracePool,
+			logger:  common.NewLogger(),
+			trace:   common.SvcARTrace,
+		}
+	})
+	return sa
+}
+
+// AddProvider 添加身份提供方
+func (s *saml) AddProvider(ctx context.Context, provider *interfaces.SAMLProvider) (err error) {
+	s.trace.SetClientSpanName("数据访问层-添加SAML身份提供方")
+	newCtx, span := s.trace.AddClientTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	rules, err := jsoniter.MarshalToString(provider.MappingRules)
+	if err != nil {
+		return err
+	}
+
+	sqlStr := "insert into t_saml_provider(`f_id`, `f_name`, `f_entity_id`, `f_metadata`, `f_mapping_rules`, " +
+		"`f_name_attribute`, `f_jit_provision`, `f_enabled`, `f_create_time`, `f_update_time`) " +
+		"values(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	_, err = s.dbTrace.ExecContext(newCtx, sqlStr, provider.ID, provider.Name, provider.EntityID, provider.Metadata,
+		rules, provider.NameAttribute, provider.JITProvision, provider.Enabled, provider.CreateTime, provider.UpdateTime)
+	if err != nil {
+		s.logger.Errorln(err, sqlStr)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateProvider 更新身份提供方，不存在时返回false
+func (s *saml) UpdateProvider(ctx context.Context, provider *interfaces.SAMLProvider) (ok bool, err error) {
+	s.trace.SetClientSpanName("数据访问层-更新SAML身份提供方")
+	newCtx, span := s.trace.AddClientTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	rules, err := jsoniter.MarshalToString(provider.MappingRules)
+	if err != nil {
+		return false, err
+	}
+
+	sqlStr := "update t_saml_provider set f_name = ?, f_entity_id = ?, f_metadata = ?, f_mapping_rules = ?, " +
+		"f_name_attribute = ?, f_jit_provision = ?, f_enabled = ?, f_update_time = ? where f_id = ?"
+	result, err := s.dbTrace.ExecContext(newCtx, sqlStr, provider.Name, provider.EntityID, provider.Metadata, rules,
+		provider.NameAttribute, provider.JITProvision, provider.Enabled, provider.UpdateTime, provider.ID)
+	if err != nil {
+		s.logger.Errorln(err, sqlStr)
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// DeleteProvider 删除身份提供方及其登录会话，不存在时返回false
+func (s *saml) DeleteProvider(ctx context.Context, id string) (ok bool, err error) {
+	s.trace.SetClientSpanName("数据访问层-删除SAML身份提供方")
+	newCtx, span := s.trace.AddClientTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	sqlStr := "delete from t_saml_provider where f_id = ?"
+	result, err := s.dbTrace.ExecContext(newCtx, sqlStr, id)
+	if err != nil {
+		s.logger.Errorln(err, sqlStr)
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	sqlStr = "delete from t_saml_session where f_provider_id = ?"
+	if _, err = s.dbTrace.ExecContext(newCtx, sqlStr, id); err != nil {
+		s.logger.Errorln(err, sqlStr)
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// GetProvider 获取身份提供方，不存在时返回nil
+func (s *saml) GetProvider(ctx context.Context, id string) (provider *interfaces.SAMLProvider, err error) {
+	s.trace.SetClientSpanName("数据访问层-获取SAML身份提供方")
+	newCtx, span := s.trace.AddClientTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	sqlStr := "select " + samlProviderFields + " from t_saml_provider where f_id = ?"
+	provider, err = scanSAMLProvider(s.dbTrace.QueryRowContext(newCtx, sqlStr, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		s.logger.Errorln(err, sqlStr)
+		return nil, err
+	}
+
+	return provider, nil
+}
+
+// GetProviderByEntityID 根据身份提供方标识获取身份提供方，不存在时返回nil
+func (s *saml) GetProviderByEntityID(ctx context.Context, entityID string) (provider *interfaces.SAMLProvider, err error) {
+	s.trace.SetClientSpanName("数据访问层-根据标识获取SAML身份提供方")
+	newCtx, span := s.trace.AddClientTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	sqlStr := "select " + samlProviderFields + " from t_saml_provider where f_entity_id = ?"
+	provider, err = scanSAMLProvider(s.dbTrace.QueryRowContext(newCtx, sqlStr, entityID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		s.logger.Errorln(err, sqlStr)
+		return nil, err
+	}
+
+	return provider, nil
+}
+
+// GetProviders 获取所有身份提供方
+func (s *saml) GetProviders(ctx context.Context) (providers []interfaces.SAMLProvider, err error) {
+	s.trace.SetClientSpanName("数据访问层-获取SAML身份提供方列表")
+	newCtx, span := s.trace.AddClientTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	sqlStr := "select " + samlProviderFields + " from t_saml_provider order by f_create_time"
+	rows, err := s.dbTrace.QueryContext(newCtx, sqlStr)
+	if err != nil {
+		s.logger.Errorln(err, sqlStr)
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			s.logger.Errorln(closeErr)
+		}
+	}()
+
+	providers = make([]interfaces.SAMLProvider, 0)
+	for rows.Next() {
+		provider, scanErr := scanSAMLProvider(rows)
+		if scanErr != nil {
+			err = scanErr
+			s.logger.Errorln(err, sqlStr)
+			return nil, err
+		}
+		providers = append(providers, *provider)
+	}
+	if err = rows.Err(); err != nil {
+		s.logger.Errorln(err, sqlStr)
+		return nil, err
+	}
+
+	return providers, nil
+}
+
+// CreateState 保存认证请求状态，并清理创建时间早于expireBefore的状态
+func (s *saml) CreateState(ctx context.Context, state *interfaces.SAMLAuthState, expireBefore int64) (err error) {
+	s.trace.SetClientSpanName("数据访问层-保存SAML认证请求状态")
+	newCtx, span := s.trace.AddClientTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	sqlStr := "delete from t_saml_state where f_create_time < ?"
+	if _, err = s.dbTrace.ExecContext(newCtx, sqlStr, expireBefore); err != nil {
+		s.logger.Errorln(err, sqlStr)
+		return err
+	}
+
+	sqlStr = "insert into t_saml_state(`f_state`, `f_provider_id`, `f_request_id`, `f_user_id`, `f_create_time`) " +
+		"values(?, ?, ?, ?, ?)"
+	_, err = s.dbTrace.ExecContext(newCtx, sqlStr, state.State, state.ProviderID, state.RequestID, state.UserID,
+		state.CreateTime)
+	if err != nil {
+		s.logger.Errorln(err, sqlStr)
+		return err
+	}
+
+	return nil
+}
+
+// GetState 获取认证请求状态，不存在时返回nil
+func (s *saml) GetState(ctx context.Context, stateStr string) (state *interfaces.SAMLAuthState, err error) {
+	s.trace.SetClientSpanName("数据访问层-获取SAML认证请求状态")
+	newCtx, span := s.trace.AddClientTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	state = &interfaces.SAMLAuthState{State: stateStr}
+	sqlStr := "select f_provider_id, f_request_id, f_user_id, f_create_time from t_saml_state where f_state = ?"
+	err = s.dbTrace.QueryRowContext(newCtx, sqlStr, stateStr).Scan(&state.ProviderID, &state.RequestID,
+		&state.UserID, &state.CreateTime)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		s.logger.Errorln(err, sqlStr)
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// BindStateUser 断言校验通过后绑定用户，状态不存在或已绑定时返回false，保证断言只能使用一次
+func (s *saml) BindStateUser(ctx context.Context, stateStr, userID string) (ok bool, err error) {
+	s.trace.SetClientSpanName("数据访问层-SAML认证请求状态绑定用户")
+	newCtx, span := s.trace.AddClientTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	sqlStr := "update t_saml_state set f_user_id = ? where f_state = ? and f_user_id = ''"
+	result, err := s.dbTrace.ExecContext(newCtx, sqlStr, userID, stateStr)
+	if err != nil {
+		s.logger.Errorln(err, sqlStr)
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// ConsumeState 获取并删除认证请求状态，保证状态只能使用一次，不存在时返回nil
+func (s *saml) ConsumeState(ctx context.Context, stateStr string) (state *interfaces.SAMLAuthState, err error) {
+	s.trace.SetClientSpanName("数据访问层-使用SAML认证请求状态")
+	newCtx, span := s.trace.AddClientTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	state = &interfaces.SAMLAuthState{State: stateStr}
+	sqlStr := "select f_provider_id, f_request_id, f_user_id, f_create_time from t_saml_state where f_state = ?"
+	err = s.dbTrace.QueryRowContext(newCtx, sqlStr, stateStr).Scan(&state.ProviderID, &state.RequestID,
+		&state.UserID, &state.CreateTime)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		s.logger.Errorln(err, sqlStr)
+		return nil, err
+	}
+
+	// 删除成功代表本次使用有效，并发使用同一状态时只有一个请求能够成功
+	sqlStr = "delete from t_saml_state where f_state = ?"
+	result, err := s.dbTrace.ExecContext(newCtx, sqlStr, stateStr)
+	if err != nil {
+		s.logger.Errorln(err, sqlStr)
+		return nil, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, nil
+	}
+
+	return state, nil
+}
+
+// AddSession 保存登录会话，并清理创建时间早于expireBefore的会话
+func (s *saml) AddSession(ctx context.Context, session *interfaces.SAMLSession, expireBefore int64) (err error) {
+	s.trace.SetClientSpanName("数据访问层-保存SAML登录会话")
+	newCtx, span := s.trace.AddClientTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	sqlStr := "delete from t_saml_session where f_create_time < ?"
+	if _, err = s.dbTrace.ExecContext(newCtx, sqlStr, expireBefore); err != nil {
+		s.logger.Errorln(err, sqlStr)
+		return err
+	}
+
+	sqlStr = "insert into t_saml_session(`f_user_id`, `f_provider_id`, `f_name_id`, `f_name_id_format`, " +
+		"`f_name_qualifier`, `f_sp_name_qualifier`, `f_session_index`, `f_create_time`) values(?, ?, ?, ?, ?, ?, ?, ?)"
+	_, err = s.dbTrace.ExecContext(newCtx, sqlStr, session.UserID, session.ProviderID, session.NameID,
+		session.NameIDFormat, session.NameQualifier, session.SPNameQualifier, session.SessionIndex, session.CreateTime)
+	if err != nil {
+		s.logger.Errorln(err, sqlStr)
+		return err
+	}
+
+	return nil
+}
+
+// GetSessionsByUserID 获取用户的登录会话，按创建时间倒序
+func (s *saml) GetSessionsByUserID(ctx context.Context, userID string) (sessions []interfaces.SAMLSession, err error) {
+	s.trace.SetClientSpanName("数据访问层-获取用户SAML登录会话")
+	newCtx, span := s.trace.AddClientTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	sqlStr := "select " + samlSessionFields + " from t_saml_session where f_user_id = ? order by f_create_time desc"
+	return s.getSessions(newCtx, sqlStr, userID)
+}
+
+// GetSessionsByNameID 获取身份提供方断言主体对应的登录会话
+func (s *saml) GetSessionsByNameID(ctx context.Context, providerID, nameID string) (sessions []interfaces.SAMLSession, err error) {
+	s.trace.SetClientSpanName("数据访问层-根据断言主体获取SAML登录会话")
+	newCtx, span := s.trace.AddClientTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	sqlStr := "select " + samlSessionFields + " from t_saml_session where f_provider_id = ? and f_name_id = ?"
+	return s.getSessions(newCtx, sqlStr, providerID, nameID)
+}
+
+// DeleteSessions 删除用户在身份提供方的登录会话
+func (s *saml) DeleteSessions(ctx context.Context, userID, providerID string) (err error) {
+	s.trace.SetClientSpanName("数据访问层-删除SAML登录会话")
+	newCtx, span := s.trace.AddClientTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	sqlStr := "delete from t_saml_session where f_user_id = ? and f_provider_id = ?"
+	if _, err = s.dbTrace.ExecContext(newCtx, sqlStr, userID, providerID); err != nil {
+		s.logger.Errorln(err, sqlStr)
+		return err
+	}
+
+	return nil
+}
+
+func (s *saml) getSessions(ctx context.Context, sqlStr string, args ...interface{}) ([]interfaces.SAMLSession, error) {
+	rows, err := s.dbTrace.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		s.logger.Errorln(err, sqlStr)
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			s.logger.Errorln(closeErr)
+		}
+	}()
+
+	sessions := make([]interfaces.SAMLSession, 0)
+	for rows.Next() {
+		var session interfaces.SAMLSession
+		err = rows.Scan(&session.UserID, &session.ProviderID, &session.NameID, &session.NameIDFormat,
+			&session.NameQualifier, &session.SPNameQualifier, &session.SessionIndex, &session.CreateTime)
+		if err != nil {
+			s.logger.Errorln(err, sqlStr)
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	if err = rows.Err(); err != nil {
+		s.logger.Errorln(err, sqlStr)
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func scanSAMLProvider(row rowScanner) (*interfaces.SAMLProvider, error) {
+	var rules string
+	provider := &interfaces.SAMLProvider{}
+	err := row.Scan(&provider.ID, &provider.Name, &provider.EntityID, &provider.Metadata, &rules,
+		&provider.NameAttribute, &provider.JITProvision, &provider.Enabled, &provider.CreateTime, &provider.UpdateTime)
+	if err != nil {
+		return nil, err
+	}
+	if err = jsoniter.UnmarshalFromString(rules, &provider.MappingRules); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
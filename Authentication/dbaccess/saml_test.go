@@ -0,0 +1,309 @@
+package dbaccess
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/assert"
+
+	"Authentication/common"
+	"Authentication/interfaces"
+	mocks "Authentication/interfaces/mock"
+)
+
+func newDBSAML(ptrDB *sqlx.DB, trace interfaces.TraceClient) *saml {
+	return &saml{
+		dbTrace: ptrDB,
+		logger:  common.NewLogger(),
+		trace:   trace,
+	}
+}
+
+var (
+	samlProviderColumns = []string{"f_id", "f_name", "f_entity_id", "f_metadata", "f_mapping_rules", "f_name_attribute",
+		"f_jit_provision", "f_enabled", "f_create_time", "f_update_time"}
+	samlSessionColumns = []string{"f_user_id", "f_provider_id", "f_name_id", "f_name_id_format", "f_name_qualifier",
+		"f_sp_name_qualifier", "f_session_index", "f_create_time"}
+	samlStateColumns = []string{"f_provider_id", "f_request_id", "f_user_id", "f_create_time"}
+)
+
+func TestSAMLAddProvider(t *testing.T) {
+	Convey("AddProvider", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		s := newDBSAML(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		provider := &interfaces.SAMLProvider{
+			ID:           "p1",
+			EntityID:     "https://idp",
+			MappingRules: []interfaces.SAMLMappingRule{{Attribute: "mail", MatchBy: interfaces.OIDCMatchByEmail}},
+		}
+
+		Convey("success", func() {
+			mock.ExpectExec("insert into t_saml_provider").
+				WithArgs("p1", "", "https://idp", "", `[{"attribute":"mail","match_by":"email"}]`, "", false, false, 0, 0).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			err := s.AddProvider(ctx, provider)
+			assert.Equal(t, err, nil)
+		})
+	})
+}
+
+func TestSAMLGetProvider(t *testing.T) {
+	Convey("GetProvider", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		s := newDBSAML(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		Convey("not exist", func() {
+			mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows(samlProviderColumns))
+
+			provider, err := s.GetProvider(ctx, "p1")
+			assert.Equal(t, err, nil)
+			assert.Assert(t, provider == nil)
+		})
+
+		Convey("db unavailable", func() {
+			tmpErr := fmt.Errorf("unknown error")
+			mock.ExpectQuery("").WillReturnError(tmpErr)
+
+			_, err := s.GetProvider(ctx, "p1")
+			assert.Equal(t, err, tmpErr)
+		})
+
+		Convey("success", func() {
+			mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows(samlProviderColumns).
+				AddRow("p1", "adfs", "https://idp", "<md/>", `[{"attribute":"NameID","match_by":"third_id"}]`, "displayName", 1, 0, 1, 2))
+
+			provider, err := s.GetProvider(ctx, "p1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, provider.ID, "p1")
+			assert.Equal(t, provider.EntityID, "https://idp")
+			assert.Equal(t, provider.Metadata, "<md/>")
+			assert.DeepEqual(t, provider.MappingRules, []interfaces.SAMLMappingRule{{Attribute: interfaces.SAMLNameID, MatchBy: interfaces.OIDCMatchByThirdID}})
+			assert.Equal(t, provider.NameAttribute, "displayName")
+			assert.Equal(t, provider.JITProvision, true)
+			assert.Equal(t, provider.Enabled, false)
+			assert.Equal(t, provider.UpdateTime, int64(2))
+		})
+	})
+}
+
+func TestSAMLGetProviderByEntityID(t *testing.T) {
+	Convey("GetProviderByEntityID", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		s := newDBSAML(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		Convey("not exist", func() {
+			mock.ExpectQuery("").WithArgs("https://idp").WillReturnRows(sqlmock.NewRows(samlProviderColumns))
+
+			provider, err := s.GetProviderByEntityID(ctx, "https://idp")
+			assert.Equal(t, err, nil)
+			assert.Assert(t, provider == nil)
+		})
+
+		Convey("success", func() {
+			mock.ExpectQuery("").WithArgs("https://idp").WillReturnRows(sqlmock.NewRows(samlProviderColumns).
+				AddRow("p1", "adfs", "https://idp", "<md/>", `[]`, "", 0, 1, 1, 1))
+
+			provider, err := s.GetProviderByEntityID(ctx, "https://idp")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, provider.ID, "p1")
+		})
+	})
+}
+
+func TestSAMLDeleteProvider(t *testing.T) {
+	Convey("DeleteProvider", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		s := newDBSAML(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		Convey("success", func() {
+			mock.ExpectExec("delete from t_saml_provider").WithArgs("p1").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec("delete from t_saml_session").WithArgs("p1").WillReturnResult(sqlmock.NewResult(0, 3))
+
+			ok, err := s.DeleteProvider(ctx, "p1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, ok, true)
+		})
+	})
+}
+
+func TestSAMLBindStateUser(t *testing.T) {
+	Convey("BindStateUser", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		s := newDBSAML(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		Convey("already bound", func() {
+			mock.ExpectExec("update t_saml_state").WithArgs("u1", "s1").WillReturnResult(sqlmock.NewResult(0, 0))
+
+			ok, err := s.BindStateUser(ctx, "s1", "u1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, ok, false)
+		})
+
+		Convey("success", func() {
+			mock.ExpectExec("update t_saml_state").WithArgs("u1", "s1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+			ok, err := s.BindStateUser(ctx, "s1", "u1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, ok, true)
+		})
+	})
+}
+
+func TestSAMLConsumeState(t *testing.T) {
+	Convey("ConsumeState", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		s := newDBSAML(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		Convey("not exist", func() {
+			mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows(samlStateColumns))
+
+			state, err := s.ConsumeState(ctx, "s1")
+			assert.Equal(t, err, nil)
+			assert.Assert(t, state == nil)
+		})
+
+		Convey("consumed concurrently", func() {
+			mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows(samlStateColumns).AddRow("p1", "_r1", "u1", 100))
+			mock.ExpectExec("delete from t_saml_state").WillReturnResult(sqlmock.NewResult(0, 0))
+
+			state, err := s.ConsumeState(ctx, "s1")
+			assert.Equal(t, err, nil)
+			assert.Assert(t, state == nil)
+		})
+
+		Convey("success", func() {
+			mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows(samlStateColumns).AddRow("p1", "_r1", "u1", 100))
+			mock.ExpectExec("delete from t_saml_state").WithArgs("s1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+			state, err := s.ConsumeState(ctx, "s1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, state.State, "s1")
+			assert.Equal(t, state.ProviderID, "p1")
+			assert.Equal(t, state.RequestID, "_r1")
+			assert.Equal(t, state.UserID, "u1")
+			assert.Equal(t, state.CreateTime, int64(100))
+		})
+	})
+}
+
+func TestSAMLSessions(t *testing.T) {
+	Convey("Sessions", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		s := newDBSAML(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		Convey("add session", func() {
+			session := &interfaces.SAMLSession{UserID: "u1", ProviderID: "p1", NameID: "n1", SessionIndex: "_s1", CreateTime: 100}
+			mock.ExpectExec("delete from t_saml_session").WithArgs(int64(10)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec("insert into t_saml_session").WithArgs("u1", "p1", "n1", "", "", "", "_s1", 100).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+
+			err := s.AddSession(ctx, session, 10)
+			assert.Equal(t, err, nil)
+		})
+
+		Convey("get sessions by user id", func() {
+			mock.ExpectQuery("").WithArgs("u1").WillReturnRows(sqlmock.NewRows(samlSessionColumns).
+				AddRow("u1", "p2", "n2", "", "", "", "_s2", 200).
+				AddRow("u1", "p1", "n1", "fmt", "nq", "spnq", "_s1", 100))
+
+			sessions, err := s.GetSessionsByUserID(ctx, "u1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(sessions), 2)
+			assert.Equal(t, sessions[0].ProviderID, "p2")
+			assert.DeepEqual(t, sessions[1], interfaces.SAMLSession{UserID: "u1", ProviderID: "p1", NameID: "n1",
+				NameIDFormat: "fmt", NameQualifier: "nq", SPNameQualifier: "spnq", SessionIndex: "_s1", CreateTime: 100})
+		})
+
+		Convey("get sessions by name id", func() {
+			mock.ExpectQuery("").WithArgs("p1", "n1").WillReturnRows(sqlmock.NewRows(samlSessionColumns))
+
+			sessions, err := s.GetSessionsByNameID(ctx, "p1", "n1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(sessions), 0)
+		})
+
+		Convey("delete sessions", func() {
+			mock.ExpectExec("delete from t_saml_session").WithArgs("u1", "p1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+			err := s.DeleteSessions(ctx, "u1", "p1")
+			assert.Equal(t, err, nil)
+		})
+	})
+}
@@ -0,0 +1,369 @@
+package dbaccess
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/kweaver-ai/go-lib/observable"
+	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
+
+	"Authentication/common"
+	"Authentication/interfaces"
+)
+
+type totp struct {
+	dbTrace   *sqlx.DB
+	secretKey string
+	logger    common.Logger
+	trace     observable.Tracer
+}
+
+var (
+	totpOnce sync.Once
+	tp       *totp
+)
+
+// NewTOTP 创建totp对象
+func NewTOTP() *totp {
+	totpOnce.Do(func() {
+		tp = &totp{
+			dbTrace:   dbTracePool,
+			secretKey: common.SvcConfig.TOTPSecretKey,
+			logger:    common.NewLogger(),
+			trace:     common.SvcARTrace,
+		}
+	})
+	return tp
+}
+
+// GetByUserID 获取用户动态口令信息，不存在时返回nil
+func (tp *totp) GetByUserID(ctx context.Context, userID string) (info *interfaces.TOTPInfo, err error) {
+	tp.trace.SetClientSpanName("数据访问层-获取用户动态口令信息")
+	newCtx, span := tp.trace.AddClientTrace(ctx)
+	defer func() { tp.trace.TelemetrySpanEnd(span, err) }()
+
+	info = &interfaces.TOTPInfo{}
+	secret := ""
+	sqlStr := "select f_secret, f_status, f_last_time_step, f_fail_count, f_last_fail_time, f_create_time, f_update_time " +
+		"from t_totp where f_user_id = ?"
+	err = tp.dbTrace.QueryRowContext(newCtx, sqlStr, userID).Scan(&secret, &info.Status, &info.LastTimeStep,
+		&info.FailCount, &info.LastFailTime, &info.CreateTime, &info.UpdateTime)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		tp.logger.Errorln(err, sqlStr)
+		return nil, err
+	}
+
+	plainText, err := tp.decryptSecret(secret)
+	if err != nil {
+		tp.logger.Errorf("decrypt totp secret failed, user: %s, err: %v", userID, err)
+		return nil, err
+	}
+	info.UserID = userID
+	info.Secret = plainText
+
+	return info, nil
+}
+
+// SavePending 保存待确认的动态口令密钥，覆盖用户已有的记录并清空恢复码
+func (tp *totp) SavePending(ctx context.Context, info *interfaces.TOTPInfo) (err error) {
+	tp.trace.SetClientSpanName("数据访问层-保存待确认的动态口令密钥")
+	newCtx, span := tp.trace.AddClientTrace(ctx)
+	defer func() { tp.trace.TelemetrySpanEnd(span, err) }()
+
+	secret, err := tp.encryptSecret(info.Secret)
+	if err != nil {
+		return err
+	}
+
+	tx, err := tp.dbTrace.BeginTx(newCtx, nil)
+	if err != nil {
+		return err
+	}
+	defer tp.endTx(tx, &err)
+
+	if _, err = tx.ExecContext(newCtx, "delete from t_totp_recovery_code where f_user_id = ?", info.UserID); err != nil {
+		tp.logger.Errorln(err)
+		return err
+	}
+	if _, err = tx.ExecContext(newCtx, "delete from t_totp where f_user_id = ?", info.UserID); err != nil {
+		tp.logger.Errorln(err)
+		return err
+	}
+
+	sqlStr := "insert into t_totp(`f_user_id`, `f_secret`, `f_status`, `f_last_time_step`, `f_fail_count`, " +
+		"`f_last_fail_time`, `f_create_time`, `f_update_time`) values(?, ?, ?, 0, 0, 0, ?, ?)"
+	if _, err = tx.ExecContext(newCtx, sqlStr, info.UserID, secret, interfaces.TOTPPending, info.CreateTime, info.UpdateTime); err != nil {
+		tp.logger.Errorln(err, sqlStr)
+		return err
+	}
+
+	return nil
+}
+
+// Enable 启用动态口令并写入恢复码
+func (tp *totp) Enable(ctx context.Context, userID string, timeStep int64, codeHashes []string) (err error) {
+	tp.trace.SetClientSpanName("数据访问层-启用动态口令")
+	newCtx, span := tp.trace.AddClientTrace(ctx)
+	defer func() { tp.trace.TelemetrySpanEnd(span, err) }()
+
+	tx, err := tp.dbTrace.BeginTx(newCtx, nil)
+	if err != nil {
+		return err
+	}
+	defer tp.endTx(tx, &err)
+
+	sqlStr := "update t_totp set f_status = ?, f_last_time_step = ?, f_fail_count = 0, f_update_time = ? " +
+		"where f_user_id = ? and f_status = ?"
+	result, err := tx.ExecContext(newCtx, sqlStr, interfaces.TOTPEnabled, timeStep, common.Now().Unix(), userID, interfaces.TOTPPending)
+	if err != nil {
+		tp.logger.Errorln(err, sqlStr)
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		// 并发确认或期间被重置
+		err = errors.New("totp enrollment not pending")
+		return err
+	}
+
+	return tp.insertRecoveryCodes(newCtx, tx, userID, codeHashes)
+}
+
+// UpdateLastTimeStep 更新最近一次校验通过的时间步长，并清空失败次数
+func (tp *totp) UpdateLastTimeStep(ctx context.Context, userID string, timeStep int64) (ok bool, err error) {
+	tp.trace.SetClientSpanName("数据访问层-更新动态口令时间步长")
+	newCtx, span := tp.trace.AddClientTrace(ctx)
+	defer func() { tp.trace.TelemetrySpanEnd(span, err) }()
+
+	// 条件更新保证同一时间步长内的动态口令只能被使用一次
+	sqlStr := "update t_totp set f_last_time_step = ?, f_fail_count = 0, f_update_time = ? " +
+		"where f_user_id = ? and f_last_time_step < ?"
+	result, err := tp.dbTrace.ExecContext(newCtx, sqlStr, timeStep, common.Now().Unix(), userID, timeStep)
+	if err != nil {
+		tp.logger.Errorln(err, sqlStr)
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// AddFailCount 校验前计入一次失败，失败次数达到上限且仍在锁定时间内时不计入并返回false
+func (tp *totp) AddFailCount(ctx context.Context, userID string, now int64, maxFailCount int, lockTime int64) (ok bool, err error) {
+	tp.trace.SetClientSpanName("数据访问层-增加动态口令校验失败次数")
+	newCtx, span := tp.trace.AddClientTrace(ctx)
+	defer func() { tp.trace.TelemetrySpanEnd(span, err) }()
+
+	// 条件更新在同一语句中检查锁定并增加失败次数，超过锁定时间后重新计数
+	sqlStr := "update t_totp set f_fail_count = case when f_last_fail_time <= ? then 1 else f_fail_count + 1 end, " +
+		"f_last_fail_time = ? where f_user_id = ? and (f_fail_count < ? or f_last_fail_time <= ?)"
+	result, err := tp.dbTrace.ExecContext(newCtx, sqlStr, now-lockTime, now, userID, maxFailCount, now-lockTime)
+	if err != nil {
+		tp.logger.Errorln(err, sqlStr)
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// UpdateFailInfo 更新校验失败信息
+func (tp *totp) UpdateFailInfo(ctx context.Context, userID string, failCount int, lastFailTime int64) (err error) {
+	tp.trace.SetClientSpanName("数据访问层-更新动态口令校验失败信息")
+	newCtx, span := tp.trace.AddClientTrace(ctx)
+	defer func() { tp.trace.TelemetrySpanEnd(span, err) }()
+
+	sqlStr := "update t_totp set f_fail_count = ?, f_last_fail_time = ? where f_user_id = ?"
+	if _, err = tp.dbTrace.ExecContext(newCtx, sqlStr, failCount, lastFailTime, userID); err != nil {
+		tp.logger.Errorln(err, sqlStr)
+		return err
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes 替换用户的恢复码
+func (tp *totp) ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) (err error) {
+	tp.trace.SetClientSpanName("数据访问层-替换动态口令恢复码")
+	newCtx, span := tp.trace.AddClientTrace(ctx)
+	defer func() { tp.trace.TelemetrySpanEnd(span, err) }()
+
+	tx, err := tp.dbTrace.BeginTx(newCtx, nil)
+	if err != nil {
+		return err
+	}
+	defer tp.endTx(tx, &err)
+
+	if _, err = tx.ExecContext(newCtx, "delete from t_totp_recovery_code where f_user_id = ?", userID); err != nil {
+		tp.logger.Errorln(err)
+		return err
+	}
+
+	return tp.insertRecoveryCodes(newCtx, tx, userID, codeHashes)
+}
+
+// UseRecoveryCode 使用恢复码，恢复码不存在或已使用时返回false
+func (tp *totp) UseRecoveryCode(ctx context.Context, userID, codeHash string) (ok bool, err error) {
+	tp.trace.SetClientSpanName("数据访问层-使用动态口令恢复码")
+	newCtx, span := tp.trace.AddClientTrace(ctx)
+	defer func() { tp.trace.TelemetrySpanEnd(span, err) }()
+
+	// 恢复码使用后即删除，删除成功代表本次使用有效
+	sqlStr := "delete from t_totp_recovery_code where f_user_id = ? and f_code_hash = ?"
+	result, err := tp.dbTrace.ExecContext(newCtx, sqlStr, userID, codeHash)
+	if err != nil {
+		tp.logger.Errorln(err, sqlStr)
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// CountRecoveryCodes 获取用户剩余恢复码数量
+func (tp *totp) CountRecoveryCodes(ctx context.Context, userID string) (count int, err error) {
+	tp.trace.SetClientSpanName("数据访问层-获取剩余动态口令恢复码数量")
+	newCtx, span := tp.trace.AddClientTrace(ctx)
+	defer func() { tp.trace.TelemetrySpanEnd(span, err) }()
+
+	sqlStr := "select count(*) from t_totp_recovery_code where f_user_id = ?"
+	if err = tp.dbTrace.QueryRowContext(newCtx, sqlStr, userID).Scan(&count); err != nil {
+		tp.logger.Errorln(err, sqlStr)
+		return 0, err
+	}
+	return count, nil
+}
+
+// DeleteByUserID 删除用户动态口令及恢复码
+func (tp *totp) DeleteByUserID(ctx context.Context, userID string) (err error) {
+	tp.trace.SetClientSpanName("数据访问层-删除用户动态口令")
+	newCtx, span := tp.trace.AddClientTrace(ctx)
+	defer func() { tp.trace.TelemetrySpanEnd(span, err) }()
+
+	tx, err := tp.dbTrace.BeginTx(newCtx, nil)
+	if err != nil {
+		return err
+	}
+	defer tp.endTx(tx, &err)
+
+	if _, err = tx.ExecContext(newCtx, "delete from t_totp_recovery_code where f_user_id = ?", userID); err != nil {
+		tp.logger.Errorln(err)
+		return err
+	}
+	if _, err = tx.ExecContext(newCtx, "delete from t_totp where f_user_id = ?", userID); err != nil {
+		tp.logger.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+// insertRecoveryCodes 写入恢复码
+func (tp *totp) insertRecoveryCodes(ctx context.Context, tx *sql.Tx, userID string, codeHashes []string) (err error) {
+	if len(codeHashes) == 0 {
+		return nil
+	}
+
+	createTime := common.Now().Unix()
+	sqlStr := "insert into t_totp_recovery_code(`f_user_id`, `f_code_hash`, `f_create_time`) values"
+	args := make([]interface{}, 0, len(codeHashes)*3)
+	for i, codeHash := range codeHashes {
+		if i > 0 {
+			sqlStr += ","
+		}
+		sqlStr += "(?, ?, ?)"
+		args = append(args, userID, codeHash, createTime)
+	}
+
+	if _, err = tx.ExecContext(ctx, sqlStr, args...); err != nil {
+		tp.logger.Errorln(err, sqlStr)
+		return err
+	}
+	return nil
+}
+
+// endTx 根据执行结果提交或回滚事务
+func (tp *totp) endTx(tx *sql.Tx, err *error) {
+	if *err == nil {
+		if *err = tx.Commit(); *err != nil {
+			tp.logger.Errorf("Transaction Commit Error:%v", *err)
+		}
+		return
+	}
+
+	if rollbackErr := tx.Rollback(); rollbackErr != nil {
+		tp.logger.Errorf("Transaction Rollback Error:%v", rollbackErr)
+	}
+}
+
+// encryptSecret AES-GCM 加密动态口令密钥
+func (tp *totp) encryptSecret(plainText string) (string, error) {
+	gcm, err := tp.newGCM()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(gcm.Seal(nonce, nonce, []byte(plainText), nil)), nil
+}
+
+// decryptSecret AES-GCM 解密动态口令密钥
+func (tp *totp) decryptSecret(cipherText string) (string, error) {
+	gcm, err := tp.newGCM()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := hex.DecodeString(cipherText)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("invalid totp secret cipher text")
+	}
+
+	plainText, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plainText), nil
+}
+
+func (tp *totp) newGCM() (cipher.AEAD, error) {
+	if tp.secretKey == "" {
+		return nil, errors.New("totp_secret_key is not configured")
+	}
+
+	key := sha256.Sum256([]byte(tp.secretKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,284 @@
+package dbaccess
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/assert"
+
+	"Authentication/common"
+	"Authentication/interfaces"
+	mocks "Authentication/interfaces/mock"
+)
+
+func newDBTOTP(ptrDB *sqlx.DB, trace interfaces.TraceClient) *totp {
+	return &totp{
+		dbTrace:   ptrDB,
+		secretKey: "test-secret-key",
+		logger:    common.NewLogger(),
+		trace:     trace,
+	}
+}
+
+func TestTOTPSecretCrypto(t *testing.T) {
+	Convey("encrypt and decrypt totp secret", t, func() {
+		tp := newDBTOTP(nil, nil)
+
+		Convey("success", func() {
+			cipherText, err := tp.encryptSecret("GEZDGNBVGY3TQOJQ")
+			assert.Equal(t, err, nil)
+			assert.Assert(t, cipherText != "GEZDGNBVGY3TQOJQ")
+
+			plainText, err := tp.decryptSecret(cipherText)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, plainText, "GEZDGNBVGY3TQOJQ")
+		})
+
+		Convey("wrong key", func() {
+			cipherText, _ := tp.encryptSecret("GEZDGNBVGY3TQOJQ")
+			other := newDBTOTP(nil, nil)
+			other.secretKey = "other-key"
+
+			_, err := other.decryptSecret(cipherText)
+			assert.Assert(t, err != nil)
+		})
+
+		Convey("key not configured", func() {
+			tp.secretKey = ""
+
+			_, err := tp.encryptSecret("GEZDGNBVGY3TQOJQ")
+			assert.Assert(t, err != nil)
+		})
+	})
+}
+
+func TestTOTPGetByUserID(t *testing.T) {
+	Convey("GetByUserID", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		tp := newDBTOTP(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		fields := []string{"f_secret", "f_status", "f_last_time_step", "f_fail_count", "f_last_fail_time",
+			"f_create_time", "f_update_time"}
+
+		Convey("not exist", func() {
+			mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows(fields))
+
+			info, err := tp.GetByUserID(ctx, "user1")
+			assert.Equal(t, err, nil)
+			assert.Assert(t, info == nil)
+		})
+
+		Convey("db unavailable", func() {
+			tmpErr := fmt.Errorf("unknown error")
+			mock.ExpectQuery("").WillReturnError(tmpErr)
+
+			_, err := tp.GetByUserID(ctx, "user1")
+			assert.Equal(t, err, tmpErr)
+		})
+
+		Convey("success", func() {
+			secret, _ := tp.encryptSecret("GEZDGNBVGY3TQOJQ")
+			mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows(fields).AddRow(secret, 2, 100, 1, 200, 1, 2))
+
+			info, err := tp.GetByUserID(ctx, "user1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, info.UserID, "user1")
+			assert.Equal(t, info.Secret, "GEZDGNBVGY3TQOJQ")
+			assert.Equal(t, info.Status, interfaces.TOTPEnabled)
+			assert.Equal(t, info.LastTimeStep, int64(100))
+			assert.Equal(t, info.FailCount, 1)
+		})
+	})
+}
+
+func TestTOTPEnable(t *testing.T) {
+	Convey("Enable", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		tp := newDBTOTP(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		Convey("not pending, rollback", func() {
+			mock.ExpectBegin()
+			mock.ExpectExec("update t_totp").WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectRollback()
+
+			err := tp.Enable(ctx, "user1", 100, []string{"hash1"})
+			assert.Assert(t, err != nil)
+			assert.Equal(t, mock.ExpectationsWereMet(), nil)
+		})
+
+		Convey("success", func() {
+			mock.ExpectBegin()
+			mock.ExpectExec("update t_totp").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec("insert into t_totp_recovery_code").WithArgs("user1", "hash1", sqlmock.AnyArg(),
+				"user1", "hash2", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(2, 2))
+			mock.ExpectCommit()
+
+			err := tp.Enable(ctx, "user1", 100, []string{"hash1", "hash2"})
+			assert.Equal(t, err, nil)
+			assert.Equal(t, mock.ExpectationsWereMet(), nil)
+		})
+	})
+}
+
+func TestTOTPUpdateLastTimeStep(t *testing.T) {
+	Convey("UpdateLastTimeStep", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		tp := newDBTOTP(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		Convey("time step already used", func() {
+			mock.ExpectExec("").WillReturnResult(sqlmock.NewResult(0, 0))
+
+			ok, err := tp.UpdateLastTimeStep(ctx, "user1", 100)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, ok, false)
+		})
+
+		Convey("success", func() {
+			mock.ExpectExec("").WillReturnResult(sqlmock.NewResult(0, 1))
+
+			ok, err := tp.UpdateLastTimeStep(ctx, "user1", 100)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, ok, true)
+		})
+	})
+}
+
+func TestTOTPAddFailCount(t *testing.T) {
+	Convey("AddFailCount", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		tp := newDBTOTP(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		Convey("locked", func() {
+			mock.ExpectExec("").WithArgs(int64(700), int64(1000), "user1", 5, int64(700)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+			ok, err := tp.AddFailCount(ctx, "user1", 1000, 5, 300)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, ok, false)
+		})
+
+		Convey("success", func() {
+			mock.ExpectExec("").WithArgs(int64(700), int64(1000), "user1", 5, int64(700)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+			ok, err := tp.AddFailCount(ctx, "user1", 1000, 5, 300)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, ok, true)
+		})
+	})
+}
+
+func TestTOTPUseRecoveryCode(t *testing.T) {
+	Convey("UseRecoveryCode", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		tp := newDBTOTP(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		Convey("code not exist", func() {
+			mock.ExpectExec("").WillReturnResult(sqlmock.NewResult(0, 0))
+
+			ok, err := tp.UseRecoveryCode(ctx, "user1", "hash1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, ok, false)
+		})
+
+		Convey("success", func() {
+			mock.ExpectExec("").WillReturnResult(sqlmock.NewResult(0, 1))
+
+			ok, err := tp.UseRecoveryCode(ctx, "user1", "hash1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, ok, true)
+		})
+	})
+}
+
+func TestTOTPDeleteByUserID(t *testing.T) {
+	Convey("DeleteByUserID", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		tp := newDBTOTP(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		Convey("failed, rollback", func() {
+			tmpErr := fmt.Errorf("unknown error")
+			mock.ExpectBegin()
+			mock.ExpectExec("delete from t_totp_recovery_code").WillReturnError(tmpErr)
+			mock.ExpectRollback()
+
+			err := tp.DeleteByUserID(ctx, "user1")
+			assert.Equal(t, err, tmpErr)
+			assert.Equal(t, mock.ExpectationsWereMet(), nil)
+		})
+
+		Convey("success", func() {
+			mock.ExpectBegin()
+			mock.ExpectExec("delete from t_totp_recovery_code").WillReturnResult(sqlmock.NewResult(0, 2))
+			mock.ExpectExec("delete from t_totp").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			err := tp.DeleteByUserID(ctx, "user1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, mock.ExpectationsWereMet(), nil)
+		})
+	})
+}
@@ -0,0 +1,234 @@
+package dbaccess
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/kweaver-ai/go-lib/observable"
+	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
+
+	"Authentication/common"
+	"Authentication/interfaces"
+)
+
+type webAuthn struct {
+	dbTrace *sqlx.DB
+	logger  common.Logger
+	trace   observable.Tracer
+}
+
+var (
+	waOnce sync.Once
+	wa     *webAuthn
+)
+
+// NewWebAuthn 创建webAuthn对象
+func NewWebAuthn() *webAuthn {
+	waOnce.Do(func() {
+		wa = &webAuthn{
+			dbTrace: dbTracePool,
+			logger:  common.NewLogger(),
+			trace:   common.SvcARTrace,
+		}
+	})
+	return wa
+}
+
+// CreateChallenge 保存挑战，并清理创建时间早于expireBefore的挑战
+func (wa *webAuthn) CreateChallenge(ctx context.Context, challenge *interfaces.WebAuthnChallenge, expireBefore int64) (err error) {
+	wa.trace.SetClientSpanName("数据访问层-保存安全密钥挑战")
+	newCtx, span := wa.trace.AddClientTrace(ctx)
+	defer func() { wa.trace.TelemetrySpanEnd(span, err) }()
+
+	sqlStr := "delete from t_webauthn_challenge where f_create_time < ?"
+	if _, err = wa.dbTrace.ExecContext(newCtx, sqlStr, expireBefore); err != nil {
+		wa.logger.Errorln(err, sqlStr)
+		return err
+	}
+
+	sqlStr = "insert into t_webauthn_challenge(`f_id`, `f_challenge`, `f_type`, `f_user_id`, `f_create_time`) " +
+		"values(?, ?, ?, ?, ?)"
+	_, err = wa.dbTrace.ExecContext(newCtx, sqlStr, challenge.ID, challenge.Challenge, challenge.Type,
+		challenge.UserID, challenge.CreateTime)
+	if err != nil {
+		wa.logger.Errorln(err, sqlStr)
+		return err
+	}
+
+	return nil
+}
+
+// ConsumeChallenge 获取并删除挑战，保证挑战只能使用一次，不存在时返回nil
+func (wa *webAuthn) ConsumeChallenge(ctx context.Context, id string) (challenge *interfaces.WebAuthnChallenge, err error) {
+	wa.trace.SetClientSpanName("数据访问层-使用安全密钥挑战")
+	newCtx, span := wa.trace.AddClientTrace(ctx)
+	defer func() { wa.trace.TelemetrySpanEnd(span, err) }()
+
+	challenge = &interfaces.WebAuthnChallenge{ID: id}
+	sqlStr := "select f_challenge, f_type, f_user_id, f_create_time from t_webauthn_challenge where f_id = ?"
+	err = wa.dbTrace.QueryRowContext(newCtx, sqlStr, id).Scan(&challenge.Challenge, &challenge.Type,
+		&challenge.UserID, &challenge.CreateTime)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		wa.logger.Errorln(err, sqlStr)
+		return nil, err
+	}
+
+	// 删除成功代表本次使用有效，并发使用同一挑战时只有一个请求能够成功
+	sqlStr = "delete from t_webauthn_challenge where f_id = ?"
+	result, err := wa.dbTrace.ExecContext(newCtx, sqlStr, id)
+	if err != nil {
+		wa.logger.Errorln(err, sqlStr)
+		return nil, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, nil
+	}
+
+	return challenge, nil
+}
+
+// AddCredential 添加凭据
+func (wa *webAuthn) AddCredential(ctx context.Context, credential *interfaces.WebAuthnCredential) (err error) {
+	wa.trace.SetClientSpanName("数据访问层-添加安全密钥")
+	newCtx, span := wa.trace.AddClientTrace(ctx)
+	defer func() { wa.trace.TelemetrySpanEnd(span, err) }()
+
+	sqlStr := "insert into t_webauthn_credential(`f_credential_id`, `f_user_id`, `f_name`, `f_public_key`, " +
+		"`f_algorithm`, `f_sign_count`, `f_aaguid`, `f_attestation_fmt`, `f_create_time`, `f_last_used_time`) " +
+		"values(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	_, err = wa.dbTrace.ExecContext(newCtx, sqlStr, credential.ID, credential.UserID, credential.Name,
+		credential.PublicKey, credential.Algorithm, credential.SignCount, credential.AAGUID,
+		credential.AttestationFmt, credential.CreateTime, credential.LastUsedTime)
+	if err != nil {
+		wa.logger.Errorln(err, sqlStr)
+		return err
+	}
+
+	return nil
+}
+
+// GetCredential 获取凭据，不存在时返回nil
+func (wa *webAuthn) GetCredential(ctx context.Context, credentialID string) (credential *interfaces.WebAuthnCredential, err error) {
+	wa.trace.SetClientSpanName("数据访问层-获取安全密钥")
+	newCtx, span := wa.trace.AddClientTrace(ctx)
+	defer func() { wa.trace.TelemetrySpanEnd(span, err) }()
+
+	credential = &interfaces.WebAuthnCredential{ID: credentialID}
+	sqlStr := "select f_user_id, f_name, f_public_key, f_algorithm, f_sign_count, f_aaguid, f_attestation_fmt, " +
+		"f_create_time, f_last_used_time from t_webauthn_credential where f_credential_id = ?"
+	err = wa.dbTrace.QueryRowContext(newCtx, sqlStr, credentialID).Scan(&credential.UserID, &credential.Name,
+		&credential.PublicKey, &credential.Algorithm, &credential.SignCount, &credential.AAGUID,
+		&credential.AttestationFmt, &credential.CreateTime, &credential.LastUsedTime)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		wa.logger.Errorln(err, sqlStr)
+		return nil, err
+	}
+
+	return credential, nil
+}
+
+// GetCredentialsByUserID 获取用户的所有凭据
+func (wa *webAuthn) GetCredentialsByUserID(ctx context.Context, userID string) (credentials []interfaces.WebAuthnCredential, err error) {
+	wa.trace.SetClientSpanName("数据访问层-获取用户安全密钥列表")
+	newCtx, span := wa.trace.AddClientTrace(ctx)
+	defer func() { wa.trace.TelemetrySpanEnd(span, err) }()
+
+	sqlStr := "select f_credential_id, f_name, f_public_key, f_algorithm, f_sign_count, f_aaguid, f_attestation_fmt, " +
+		"f_create_time, f_last_used_time from t_webauthn_credential where f_user_id = ? order by f_create_time"
+	rows, err := wa.dbTrace.QueryContext(newCtx, sqlStr, userID)
+	if err != nil {
+		wa.logger.Errorln(err, sqlStr)
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			wa.logger.Errorln(closeErr)
+		}
+	}()
+
+	credentials = make([]interfaces.WebAuthnCredential, 0)
+	for rows.Next() {
+		credential := interfaces.WebAuthnCredential{UserID: userID}
+		err = rows.Scan(&credential.ID, &credential.Name, &credential.PublicKey, &credential.Algorithm,
+			&credential.SignCount, &credential.AAGUID, &credential.AttestationFmt, &credential.CreateTime,
+			&credential.LastUsedTime)
+		if err != nil {
+			wa.logger.Errorln(err, sqlStr)
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+	if err = rows.Err(); err != nil {
+		wa.logger.Errorln(err, sqlStr)
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+// UpdateSignCount 更新签名计数器及使用时间，计数器已被并发修改时返回false
+func (wa *webAuthn) UpdateSignCount(ctx context.Context, credentialID string, oldCount, newCount uint32, lastUsedTime int64) (ok bool, err error) {
+	wa.trace.SetClientSpanName("数据访问层-更新安全密钥签名计数器")
+	newCtx, span := wa.trace.AddClientTrace(ctx)
+	defer func() { wa.trace.TelemetrySpanEnd(span, err) }()
+
+	// 条件更新保证同一签名计数器只能被使用一次
+	sqlStr := "update t_webauthn_credential set f_sign_count = ?, f_last_used_time = ? " +
+		"where f_credential_id = ? and f_sign_count = ?"
+	result, err := wa.dbTrace.ExecContext(newCtx, sqlStr, newCount, lastUsedTime, credentialID, oldCount)
+	if err != nil {
+		wa.logger.Errorln(err, sqlStr)
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// DeleteCredential 删除用户的指定凭据，凭据不存在时返回false
+func (wa *webAuthn) DeleteCredential(ctx context.Context, userID, credentialID string) (ok bool, err error) {
+	wa.trace.SetClientSpanName("数据访问层-删除安全密钥")
+	newCtx, span := wa.trace.AddClientTrace(ctx)
+	defer func() { wa.trace.TelemetrySpanEnd(span, err) }()
+
+	sqlStr := "delete from t_webauthn_credential where f_user_id = ? and f_credential_id = ?"
+	result, err := wa.dbTrace.ExecContext(newCtx, sqlStr, userID, credentialID)
+	if err != nil {
+		wa.logger.Errorln(err, sqlStr)
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// DeleteCredentialsByUserID 删除用户的所有凭据
+func (wa *webAuthn) DeleteCredentialsByUserID(ctx context.Context, userID string) (err error) {
+	wa.trace.SetClientSpanName("数据访问层-删除用户所有安全密钥")
+	newCtx, span := wa.trace.AddClientTrace(ctx)
+	defer func() { wa.trace.TelemetrySpanEnd(span, err) }()
+
+	sqlStr := "delete from t_webauthn_credential where f_user_id = ?"
+	if _, err = wa.dbTrace.ExecContext(newCtx, sqlStr, userID); err != nil {
+		wa.logger.Errorln(err, sqlStr)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package dbaccess
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/assert"
+
+	"Authentication/common"
+	"Authentication/interfaces"
+	mocks "Authentication/interfaces/mock"
+)
+
+func newDBWebAuthn(ptrDB *sqlx.DB, trace interfaces.TraceClient) *webAuthn {
+	return &webAuthn{
+		dbTrace: ptrDB,
+		logger:  common.NewLogger(),
+		trace:   trace,
+	}
+}
+
+func TestWebAuthnConsumeChallenge(t *testing.T) {
+	Convey("ConsumeChallenge", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		w := newDBWebAuthn(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		fields := []string{"f_challenge", "f_type", "f_user_id", "f_create_time"}
+
+		Convey("not exist", func() {
+			mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows(fields))
+
+			challenge, err := w.ConsumeChallenge(ctx, "id1")
+			assert.Equal(t, err, nil)
+			assert.Assert(t, challenge == nil)
+		})
+
+		Convey("db unavailable", func() {
+			tmpErr := fmt.Errorf("unknown error")
+			mock.ExpectQuery("").WillReturnError(tmpErr)
+
+			_, err := w.ConsumeChallenge(ctx, "id1")
+			assert.Equal(t, err, tmpErr)
+		})
+
+		Convey("consumed concurrently", func() {
+			mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows(fields).AddRow("abc", 2, "", 100))
+			mock.ExpectExec("delete from t_webauthn_challenge").WillReturnResult(sqlmock.NewResult(0, 0))
+
+			challenge, err := w.ConsumeChallenge(ctx, "id1")
+			assert.Equal(t, err, nil)
+			assert.Assert(t, challenge == nil)
+		})
+
+		Convey("success", func() {
+			mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows(fields).AddRow("abc", 1, "user1", 100))
+			mock.ExpectExec("delete from t_webauthn_challenge").WithArgs("id1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+			challenge, err := w.ConsumeChallenge(ctx, "id1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, challenge.ID, "id1")
+			assert.Equal(t, challenge.Challenge, "abc")
+			assert.Equal(t, challenge.Type, interfaces.WebAuthnRegistration)
+			assert.Equal(t, challenge.UserID, "user1")
+			assert.Equal(t, challenge.CreateTime, int64(100))
+		})
+	})
+}
+
+func TestWebAuthnGetCredential(t *testing.T) {
+	Convey("GetCredential", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		w := newDBWebAuthn(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		fields := []string{"f_user_id", "f_name", "f_public_key", "f_algorithm", "f_sign_count", "f_aaguid",
+			"f_attestation_fmt", "f_create_time", "f_last_used_time"}
+
+		Convey("not exist", func() {
+			mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows(fields))
+
+			credential, err := w.GetCredential(ctx, "cred1")
+			assert.Equal(t, err, nil)
+			assert.Assert(t, credential == nil)
+		})
+
+		Convey("success", func() {
+			mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows(fields).
+				AddRow("user1", "key", []byte{1, 2}, -7, 10, "00", "none", 1, 2))
+
+			credential, err := w.GetCredential(ctx, "cred1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, credential.ID, "cred1")
+			assert.Equal(t, credential.UserID, "user1")
+			assert.Equal(t, credential.Algorithm, -7)
+			assert.Equal(t, credential.SignCount, uint32(10))
+			assert.DeepEqual(t, credential.PublicKey, []byte{1, 2})
+		})
+	})
+}
+
+func TestWebAuthnGetCredentialsByUserID(t *testing.T) {
+	Convey("GetCredentialsByUserID", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		w := newDBWebAuthn(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		fields := []string{"f_credential_id", "f_name", "f_public_key", "f_algorithm", "f_sign_count", "f_aaguid",
+			"f_attestation_fmt", "f_create_time", "f_last_used_time"}
+
+		Convey("db unavailable", func() {
+			tmpErr := fmt.Errorf("unknown error")
+			mock.ExpectQuery("").WillReturnError(tmpErr)
+
+			_, err := w.GetCredentialsByUserID(ctx, "user1")
+			assert.Equal(t, err, tmpErr)
+		})
+
+		Convey("success", func() {
+			mock.ExpectQuery("").WithArgs("user1").WillReturnRows(sqlmock.NewRows(fields).
+				AddRow("cred1", "key1", []byte{1}, -7, 1, "00", "none", 1, 2).
+				AddRow("cred2", "key2", []byte{2}, -257, 0, "00", "packed", 3, 0))
+
+			credentials, err := w.GetCredentialsByUserID(ctx, "user1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(credentials), 2)
+			assert.Equal(t, credentials[0].ID, "cred1")
+			assert.Equal(t, credentials[1].UserID, "user1")
+			assert.Equal(t, credentials[1].AttestationFmt, "packed")
+		})
+	})
+}
+
+func TestWebAuthnUpdateSignCount(t *testing.T) {
+	Convey("UpdateSignCount", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		w := newDBWebAuthn(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		Convey("sign count changed concurrently", func() {
+			mock.ExpectExec("").WillReturnResult(sqlmock.NewResult(0, 0))
+
+			ok, err := w.UpdateSignCount(ctx, "cred1", 1, 2, 100)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, ok, false)
+		})
+
+		Convey("success", func() {
+			mock.ExpectExec("").WithArgs(uint32(2), int64(100), "cred1", uint32(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+			ok, err := w.UpdateSignCount(ctx, "cred1", 1, 2, 100)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, ok, true)
+		})
+	})
+}
+
+func TestWebAuthnDeleteCredential(t *testing.T) {
+	Convey("DeleteCredential", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mocks.NewMockTraceClient(ctrl)
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		w := newDBWebAuthn(db, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetClientSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddClientTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		Convey("not exist", func() {
+			mock.ExpectExec("").WillReturnResult(sqlmock.NewResult(0, 0))
+
+			ok, err := w.DeleteCredential(ctx, "user1", "cred1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, ok, false)
+		})
+
+		Convey("success", func() {
+			mock.ExpectExec("").WithArgs("user1", "cred1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+			ok, err := w.DeleteCredential(ctx, "user1", "cred1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, ok, true)
+		})
+	})
+}
@@ -76,3 +76,41 @@ func (s *eacp) ThirdPartyAuthentication(ctx context.Context, visitor *interfaces
 	}
 	return info, nil
 }
+
+func (s *eacp) CheckLoginPolicy(ctx context.Context, visitor *interfaces.Visitor, req *interfaces.LoginPolicyInfo) error {
+	var err error
+	s.trace.SetClientSpanName("适配器-登录策略检查")
+	newCtx, span := s.trace.AddClientTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	policyInfo := map[string]interface{}{
+		"user": map[string]interface{}{
+			"id":       req.UserID,
+			"priority": req.Priority,
+			"enabled":  req.Enabled,
+			"type":     "user",
+		},
+		"client_id": req.ClientID,
+		"ip":        req.IP,
+		"ext": map[string]interface{}{
+			"account_type": req.AccountType,
+			"client_type":  req.ClientType,
+			"login_ip":     req.IP,
+			"udid":         req.Udid,
+		},
+	}
+
+	target := fmt.Sprintf("%v/api/eacp/v1/policy/check", s.privateAddr)
+
+	headers := map[string]string{
+		"x-error-code": ErrCodeTypeToStr[visitor.ErrorCodeType],
+	}
+
+	_, _, err = s.httpClient.Post(newCtx, target, headers, policyInfo)
+	if err != nil {
+		s.log.Errorf("Check login policy failed: %v, url: %v", err, target)
+		return err
+	}
+
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/kweaver-ai/go-lib/tclient"
 
 	"Authentication/common"
+	"Authentication/interfaces"
 	"Authentication/tapi/ethriftexception"
 	"Authentication/tapi/sharemgnt"
 )
@@ -185,6 +186,47 @@ func (s *shareMgnt) UsrmSendAnonymousSMSVCode(ctx context.Context, phoneNumber,
 	return nil
 }
 
+// UsrmAddUser 新建第三方账户，账户创建在未分配组中，返回账户ID
+func (s *shareMgnt) UsrmAddUser(ctx context.Context, info *interfaces.NewUserInfo) (userID string, err error) {
+	s.trace.SetClientSpanName("出栈适配器层-新建第三方账户")
+	newCtx, span := s.trace.AddClientTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	var shareMgntClient *sharemgnt.NcTShareMgntClient
+	transport, err := tclient.NewTClient(sharemgnt.NewNcTShareMgntClientFactory, &shareMgntClient, common.SvcConfig.ShareMgntHost, common.SvcConfig.ShareMgntPort)
+	if err != nil {
+		s.logger.Errorf("Create shareMgntClient error: %v", err)
+		return "", err
+	}
+	defer func() {
+		if closeErr := transport.Close(); closeErr != nil {
+			s.logger.Errorln(closeErr)
+		}
+	}()
+
+	userType := sharemgnt.NcTUsrmUserType_NCT_USER_TYPE_THIRD
+	user := &sharemgnt.NcTUsrmUserInfo{
+		LoginName:     info.Account,
+		DisplayName:   &info.Name,
+		UserType:      &userType,
+		DepartmentIds: []string{sharemgnt.NCT_UNDISTRIBUTE_USER_GROUP},
+	}
+	if info.Email != "" {
+		user.Email = &info.Email
+	}
+	if info.ThirdID != "" {
+		user.ThirdId = &info.ThirdID
+	}
+
+	userID, err = shareMgntClient.Usrm_AddUser(newCtx, &sharemgnt.NcTUsrmAddUserInfo{User: user}, sharemgnt.NCT_USER_ADMIN)
+	if err != nil {
+		s.logger.Errorf("Call shareMgntClient.Usrm_AddUser error: %v", err)
+		return "", convertThriftErrToRest(err)
+	}
+
+	return userID, nil
+}
+
 func convertThriftErrToRest(err error) (restError error) {
 	switch v := err.(type) {
 	case *ethriftexception.NcTException:
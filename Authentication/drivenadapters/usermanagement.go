@@ -151,6 +151,29 @@ func (u *userManagement) AccountMatch(ctx context.Context, visitor *interfaces.V
 		return
 	}
 
+	result, info = u.parseMatchResult(resParam)
+	return
+}
+
+// UserMatch 根据邮箱或第三方用户ID匹配账户，field为email或third_id
+func (u *userManagement) UserMatch(ctx context.Context, visitor *interfaces.Visitor, field, value string) (result bool, info interfaces.UserBaseInfo, err error) {
+	u.trace.SetClientSpanName("适配器-用户匹配")
+	newCtx, span := u.trace.AddClientTrace(ctx)
+	defer func() { u.trace.TelemetrySpanEnd(span, err) }()
+
+	target := fmt.Sprintf("%s/api/user-management/v1/user-match?%s=%s", u.privateAddr, field, url.QueryEscape(value))
+	resParam, err := u.httpClient.Get(newCtx, target, map[string]string{"x-error-code": ErrCodeTypeToStr[visitor.ErrorCodeType]})
+	if err != nil {
+		u.log.Errorf("UserMatch failed:%v, url:%v", err, target)
+		return
+	}
+
+	result, info = u.parseMatchResult(resParam)
+	return
+}
+
+// parseMatchResult 解析账户匹配结果
+func (u *userManagement) parseMatchResult(resParam interface{}) (result bool, info interfaces.UserBaseInfo) {
 	result = resParam.(map[string]interface{})["result"].(bool)
 	if result {
 		userInfo := resParam.(map[string]interface{})["user"]
@@ -234,15 +257,19 @@ func (u *userManagement) GetUserInfo(ctx context.Context, visitor *interfaces.Vi
 	newCtx, span := u.trace.AddClientTrace(ctx)
 	defer func() { u.trace.TelemetrySpanEnd(span, err) }()
 
-	fields := "account"
+	fields := "account,enabled,priority"
 	target := fmt.Sprintf("%s/api/user-management/v1/users/%s/%s", u.privateAddr, userID, fields)
 	resParam, err := u.httpClient2.Get(newCtx, target, map[string]string{"x-error-code": ErrCodeTypeToStr[visitor.ErrorCodeType]})
 	if err != nil {
 		return nil, err
 	}
 
+	userInfo := resParam.([]interface{})[0].(map[string]interface{})
 	info = &interfaces.UserBaseInfo{
-		Account: resParam.([]interface{})[0].(map[string]interface{})["account"].(string),
+		ID:            userID,
+		Account:       userInfo["account"].(string),
+		DisableStatus: !userInfo["enabled"].(bool),
+		Priority:      int(userInfo["priority"].(float64)),
 	}
 	return info, nil
 }
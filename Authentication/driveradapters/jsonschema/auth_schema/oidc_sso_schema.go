@@ -0,0 +1,12 @@
+// Package authschema jsonschema定义层
+package authschema
+
+import (
+	_ "embed" // 标准用法
+)
+
+var (
+	// OIDCSSOSchemaStr OIDC身份提供方登录schema str
+	//go:embed oidc_sso_schema.json
+	OIDCSSOSchemaStr string
+)
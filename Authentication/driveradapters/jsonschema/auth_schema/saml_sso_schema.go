@@ -0,0 +1,12 @@
+// Package authschema jsonschema定义层
+package authschema
+
+import (
+	_ "embed" // 标准用法
+)
+
+var (
+	// SAMLSSOSchemaStr SAML身份提供方登录schema str
+	//go:embed saml_sso_schema.json
+	SAMLSSOSchemaStr string
+)
@@ -0,0 +1,12 @@
+// Package authschema jsonschema定义层
+package authschema
+
+import (
+	_ "embed" // 标准用法
+)
+
+var (
+	// WebAuthnSSOSchemaStr 安全密钥免密登录schema str
+	//go:embed webauthn_sso_schema.json
+	WebAuthnSSOSchemaStr string
+)
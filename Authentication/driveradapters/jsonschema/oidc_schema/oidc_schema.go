@@ -0,0 +1,12 @@
+// Package oidcschema jsonschema定义层
+package oidcschema
+
+import (
+	_ "embed" // 标准用法
+)
+
+var (
+	// ProviderSchemaStr OIDC身份提供方配置schema str
+	//go:embed provider.json
+	ProviderSchemaStr string
+)
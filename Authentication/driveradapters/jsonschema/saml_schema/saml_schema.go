@@ -0,0 +1,12 @@
+// Package samlschema jsonschema定义层
+package samlschema
+
+import (
+	_ "embed" // 标准用法
+)
+
+var (
+	// ProviderSchemaStr SAML身份提供方配置schema str
+	//go:embed provider.json
+	ProviderSchemaStr string
+)
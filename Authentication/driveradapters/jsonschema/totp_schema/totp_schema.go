@@ -0,0 +1,12 @@
+// Package totpschema jsonschema定义层
+package totpschema
+
+import (
+	_ "embed" // 标准用法
+)
+
+var (
+	// TOTPCodeSchemaStr 动态口令校验schema str
+	//go:embed totp_code.json
+	TOTPCodeSchemaStr string
+)
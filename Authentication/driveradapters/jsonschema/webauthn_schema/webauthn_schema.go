@@ -0,0 +1,12 @@
+// Package webauthnschema jsonschema定义层
+package webauthnschema
+
+import (
+	_ "embed" // 标准用法
+)
+
+var (
+	// RegistrationSchemaStr 安全密钥注册schema str
+	//go:embed registration.json
+	RegistrationSchemaStr string
+)
@@ -36,6 +36,9 @@ type restHandler struct {
 	anonyousLogin2Schema *gojsonschema.Schema
 	pwdAuthSchemaStr     *gojsonschema.Schema
 	accessTokenSchema    *gojsonschema.Schema
+	webAuthnSSOSchema    *gojsonschema.Schema
+	oidcSSOSchema        *gojsonschema.Schema
+	samlSSOSchema        *gojsonschema.Schema
 }
 
 var (
@@ -64,6 +67,18 @@ func NewRESTHandler() RESTHandler {
 		if err != nil {
 			common.NewLogger().Fatalln(err)
 		}
+		webAuthnSSOSchema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(authSchema.WebAuthnSSOSchemaStr))
+		if err != nil {
+			common.NewLogger().Fatalln(err)
+		}
+		oidcSSOSchema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(authSchema.OIDCSSOSchemaStr))
+		if err != nil {
+			common.NewLogger().Fatalln(err)
+		}
+		samlSSOSchema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(authSchema.SAMLSSOSchemaStr))
+		if err != nil {
+			common.NewLogger().Fatalln(err)
+		}
 
 		r = &restHandler{
 			login:                login.NewLogin(),
@@ -71,6 +86,9 @@ func NewRESTHandler() RESTHandler {
 			anonyousLogin2Schema: anonyousLogin2Schema,
 			pwdAuthSchemaStr:     pwdAuthSchemaStr,
 			accessTokenSchema:    accessTokenSchema1,
+			webAuthnSSOSchema:    webAuthnSSOSchema,
+			oidcSSOSchema:        oidcSSOSchema,
+			samlSSOSchema:        samlSSOSchema,
 		}
 	})
 
@@ -80,6 +98,9 @@ func NewRESTHandler() RESTHandler {
 // RegisterPublic 注册开放API
 func (r *restHandler) RegisterPublic(engine *gin.Engine) {
 	engine.POST("/api/authentication/v1/sso", observable.MiddlewareTrace(common.SvcARTrace), r.singleSignOn)
+	engine.POST("/api/authentication/v1/webauthn/sso", observable.MiddlewareTrace(common.SvcARTrace), r.webAuthnSignOn)
+	engine.POST("/api/authentication/v1/oidc/sso", observable.MiddlewareTrace(common.SvcARTrace), r.oidcSignOn)
+	engine.POST("/api/authentication/v1/saml/sso", observable.MiddlewareTrace(common.SvcARTrace), r.samlSignOn)
 	engine.POST("/api/authentication/v1/pwd-auth", observable.MiddlewareTrace(common.SvcARTrace), r.pwdAuth)
 	engine.POST("/api/authentication/v1/access_token", observable.MiddlewareTrace(common.SvcARTrace), r.getAccessToken)
 	engine.POST("/api/authentication/v1/anonymous", r.anonymous)
@@ -278,6 +299,188 @@ func (r *restHandler) singleSignOn(c *gin.Context) {
 	rest.ReplyOK(c, http.StatusOK, resInfo)
 }
 
+// webAuthnSignOn 安全密钥免密登录
+func (r *restHandler) webAuthnSignOn(c *gin.Context) {
+	var reqJSON struct {
+		ClientID     string                           `json:"client_id"`
+		RedirectURI  string                           `json:"redirect_uri"`
+		ResponseType string                           `json:"response_type"`
+		Scope        string                           `json:"scope"`
+		Udids        []string                         `json:"udids"`
+		Assertion    interfaces.WebAuthnAssertionResp `json:"assertion"`
+	}
+	if err := util.ValidateAndBindGin(c, r.webAuthnSSOSchema, &reqJSON); err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	req := interfaces.WebAuthnLoginInfo{
+		ClientID:     reqJSON.ClientID,
+		RedirectURI:  reqJSON.RedirectURI,
+		ResponseType: reqJSON.ResponseType,
+		Scope:        reqJSON.Scope,
+		Udids:        reqJSON.Udids,
+		IP:           c.ClientIP(),
+		Assertion:    reqJSON.Assertion,
+	}
+	if req.Udids == nil {
+		req.Udids = []string{}
+	}
+
+	visitor := interfaces.Visitor{
+		IP:            req.IP,
+		UserAgent:     c.Request.UserAgent(),
+		Language:      driveradapters.GetXLang(c),
+		ErrorCodeType: util.GetErrorCodeType(c),
+	}
+	tokenInfo, err := r.login.WebAuthnSignOn(c, &visitor, &req)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	resInfo := make(map[string]interface{})
+	switch tokenInfo.ResponseType {
+	case "code":
+		resInfo = map[string]interface{}{
+			"code":  tokenInfo.Code,
+			"scope": tokenInfo.Scope,
+		}
+	case "token id_token":
+		resInfo = map[string]interface{}{
+			"access_token": tokenInfo.AccessToken,
+			"expirses_in":  tokenInfo.ExpirsesIn,
+			"id_token":     tokenInfo.IDToken,
+			"scope":        tokenInfo.Scope,
+			"token_type":   tokenInfo.TokenType,
+		}
+	}
+
+	rest.ReplyOK(c, http.StatusOK, resInfo)
+}
+
+// oidcSignOn 第三方OIDC身份提供方登录，使用授权回调返回的state及code完成认证
+func (r *restHandler) oidcSignOn(c *gin.Context) {
+	var reqJSON struct {
+		ClientID     string   `json:"client_id"`
+		RedirectURI  string   `json:"redirect_uri"`
+		ResponseType string   `json:"response_type"`
+		Scope        string   `json:"scope"`
+		Udids        []string `json:"udids"`
+		State        string   `json:"state"`
+		Code         string   `json:"code"`
+	}
+	if err := util.ValidateAndBindGin(c, r.oidcSSOSchema, &reqJSON); err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	req := interfaces.OIDCLoginInfo{
+		ClientID:     reqJSON.ClientID,
+		RedirectURI:  reqJSON.RedirectURI,
+		ResponseType: reqJSON.ResponseType,
+		Scope:        reqJSON.Scope,
+		Udids:        reqJSON.Udids,
+		IP:           c.ClientIP(),
+		State:        reqJSON.State,
+		Code:         reqJSON.Code,
+	}
+	if req.Udids == nil {
+		req.Udids = []string{}
+	}
+
+	visitor := interfaces.Visitor{
+		IP:            req.IP,
+		UserAgent:     c.Request.UserAgent(),
+		Language:      driveradapters.GetXLang(c),
+		ErrorCodeType: util.GetErrorCodeType(c),
+	}
+	tokenInfo, err := r.login.OIDCSignOn(c, &visitor, &req)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	resInfo := make(map[string]interface{})
+	switch tokenInfo.ResponseType {
+	case "code":
+		resInfo = map[string]interface{}{
+			"code":  tokenInfo.Code,
+			"scope": tokenInfo.Scope,
+		}
+	case "token id_token":
+		resInfo = map[string]interface{}{
+			"access_token": tokenInfo.AccessToken,
+			"expirses_in":  tokenInfo.ExpirsesIn,
+			"id_token":     tokenInfo.IDToken,
+			"scope":        tokenInfo.Scope,
+			"token_type":   tokenInfo.TokenType,
+		}
+	}
+
+	rest.ReplyOK(c, http.StatusOK, resInfo)
+}
+
+// samlSignOn SAML身份提供方登录，使用断言消费服务跳转登录页携带的state完成认证
+func (r *restHandler) samlSignOn(c *gin.Context) {
+	var reqJSON struct {
+		ClientID     string   `json:"client_id"`
+		RedirectURI  string   `json:"redirect_uri"`
+		ResponseType string   `json:"response_type"`
+		Scope        string   `json:"scope"`
+		Udids        []string `json:"udids"`
+		State        string   `json:"state"`
+	}
+	if err := util.ValidateAndBindGin(c, r.samlSSOSchema, &reqJSON); err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	req := interfaces.SAMLLoginInfo{
+		ClientID:     reqJSON.ClientID,
+		RedirectURI:  reqJSON.RedirectURI,
+		ResponseType: reqJSON.ResponseType,
+		Scope:        reqJSON.Scope,
+		Udids:        reqJSON.Udids,
+		IP:           c.ClientIP(),
+		State:        reqJSON.State,
+	}
+	if req.Udids == nil {
+		req.Udids = []string{}
+	}
+
+	visitor := interfaces.Visitor{
+		IP:            req.IP,
+		UserAgent:     c.Request.UserAgent(),
+		Language:      driveradapters.GetXLang(c),
+		ErrorCodeType: util.GetErrorCodeType(c),
+	}
+	tokenInfo, err := r.login.SAMLSignOn(c, &visitor, &req)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	resInfo := make(map[string]interface{})
+	switch tokenInfo.ResponseType {
+	case "code":
+		resInfo = map[string]interface{}{
+			"code":  tokenInfo.Code,
+			"scope": tokenInfo.Scope,
+		}
+	case "token id_token":
+		resInfo = map[string]interface{}{
+			"access_token": tokenInfo.AccessToken,
+			"expirses_in":  tokenInfo.ExpirsesIn,
+			"id_token":     tokenInfo.IDToken,
+			"scope":        tokenInfo.Scope,
+			"token_type":   tokenInfo.TokenType,
+		}
+	}
+
+	rest.ReplyOK(c, http.StatusOK, resInfo)
+}
+
 // Anonymous 匿名登录
 func (r *restHandler) anonymous(c *gin.Context) {
 	// 获取请求参数
@@ -434,6 +637,11 @@ func (r *restHandler) checkLoginOption(option *interfaces.ClientLoginOption) (er
 			err = rest.NewHTTPError("invalid type", rest.BadRequest, map[string]interface{}{"params": "option.vcode"})
 			return
 		}
+	case interfaces.DualAuthWebAuthn:
+		if option.WebAuthn == nil {
+			err = rest.NewHTTPError("invalid type", rest.BadRequest, map[string]interface{}{"params": "option.webauthn"})
+			return
+		}
 	}
 
 	return
@@ -0,0 +1,259 @@
+// Package oidc 协议层
+package oidc
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kweaver-ai/go-lib/observable"
+	"github.com/kweaver-ai/go-lib/rest"
+	"github.com/xeipuuv/gojsonschema"
+
+	"Authentication/common"
+	"Authentication/driveradapters"
+	oidcschema "Authentication/driveradapters/jsonschema/oidc_schema"
+	"Authentication/driveradapters/util"
+	"Authentication/interfaces"
+	loidc "Authentication/logics/oidc"
+)
+
+// RESTHandler RESTful api Handler接口
+type RESTHandler interface {
+	// RegisterPublic 注册外部API
+	RegisterPublic(engine *gin.Engine)
+}
+
+type restHandler struct {
+	oidc           interfaces.LogicsOIDC
+	hydra          interfaces.Hydra
+	providerSchema *gojsonschema.Schema
+}
+
+// providerReq 身份提供方配置请求
+type providerReq struct {
+	Name         string                       `json:"name"`
+	Issuer       string                       `json:"issuer"`
+	ClientID     string                       `json:"client_id"`
+	ClientSecret string                       `json:"client_secret"`
+	Scopes       []string                     `json:"scopes"`
+	RedirectURI  string                       `json:"redirect_uri"`
+	MappingRules []interfaces.OIDCMappingRule `json:"mapping_rules"`
+	JITProvision bool                         `json:"jit_provision"`
+	Enabled      bool                         `json:"enabled"`
+}
+
+var (
+	once sync.Once
+	r    RESTHandler
+)
+
+// NewRESTHandler 创建oidc handler对象
+func NewRESTHandler() RESTHandler {
+	once.Do(func() {
+		providerSchema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(oidcschema.ProviderSchemaStr))
+		if err != nil {
+			common.NewLogger().Fatalln(err)
+		}
+
+		r = &restHandler{
+			oidc:           loidc.NewOIDC(),
+			hydra:          util.NewHydra(),
+			providerSchema: providerSchema,
+		}
+	})
+
+	return r
+}
+
+// RegisterPublic 注册外部API
+func (r *restHandler) RegisterPublic(engine *gin.Engine) {
+	engine.GET("/api/authentication/v1/oidc/providers", observable.MiddlewareTrace(common.SvcARTrace), r.listEnabledProviders)
+	engine.POST("/api/authentication/v1/oidc/providers/:id/authorize", observable.MiddlewareTrace(common.SvcARTrace), r.beginAuth)
+
+	engine.GET("/api/authentication/v1/oidc/management/providers", observable.MiddlewareTrace(common.SvcARTrace), r.listProviders)
+	engine.POST("/api/authentication/v1/oidc/management/providers", observable.MiddlewareTrace(common.SvcARTrace), r.addProvider)
+	engine.GET("/api/authentication/v1/oidc/management/providers/:id", observable.MiddlewareTrace(common.SvcARTrace), r.getProvider)
+	engine.PUT("/api/authentication/v1/oidc/management/providers/:id", observable.MiddlewareTrace(common.SvcARTrace), r.updateProvider)
+	engine.DELETE("/api/authentication/v1/oidc/management/providers/:id", observable.MiddlewareTrace(common.SvcARTrace), r.deleteProvider)
+}
+
+// listEnabledProviders 获取已启用的身份提供方，用于登录页展示
+func (r *restHandler) listEnabledProviders(c *gin.Context) {
+	providers, err := r.oidc.ListEnabledProviders(c)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	entries := make([]map[string]interface{}, 0, len(providers))
+	for i := range providers {
+		entries = append(entries, map[string]interface{}{
+			"id":   providers[i].ID,
+			"name": providers[i].Name,
+		})
+	}
+	rest.ReplyOK(c, http.StatusOK, map[string]interface{}{
+		"entries":     entries,
+		"total_count": len(entries),
+	})
+}
+
+// beginAuth 生成身份提供方授权地址，客户端跳转后由身份提供方回调并携带state及code
+func (r *restHandler) beginAuth(c *gin.Context) {
+	visitor := interfaces.Visitor{
+		IP:            c.ClientIP(),
+		UserAgent:     c.Request.UserAgent(),
+		Language:      driveradapters.GetXLang(c),
+		ErrorCodeType: util.GetErrorCodeType(c),
+	}
+
+	authURL, err := r.oidc.BeginAuth(c, &visitor, c.Param("id"))
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusOK, map[string]interface{}{
+		"authorization_url": authURL,
+	})
+}
+
+// listProviders 管理员获取所有身份提供方
+func (r *restHandler) listProviders(c *gin.Context) {
+	// token内省
+	visitor, err := util.Verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	providers, err := r.oidc.ListProviders(c, &visitor)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	entries := make([]map[string]interface{}, 0, len(providers))
+	for i := range providers {
+		entries = append(entries, providerInfo(&providers[i]))
+	}
+	rest.ReplyOK(c, http.StatusOK, map[string]interface{}{
+		"entries":     entries,
+		"total_count": len(entries),
+	})
+}
+
+// addProvider 管理员添加身份提供方
+func (r *restHandler) addProvider(c *gin.Context) {
+	// token内省
+	visitor, err := util.Verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	var req providerReq
+	if err = util.ValidateAndBindGin(c, r.providerSchema, &req); err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	id, err := r.oidc.AddProvider(c, &visitor, req.toProvider(""))
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusCreated, map[string]interface{}{"id": id})
+}
+
+// getProvider 管理员获取身份提供方
+func (r *restHandler) getProvider(c *gin.Context) {
+	// token内省
+	visitor, err := util.Verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	provider, err := r.oidc.GetProvider(c, &visitor, c.Param("id"))
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusOK, providerInfo(provider))
+}
+
+// updateProvider 管理员更新身份提供方，未传入客户端密钥时保持不变
+func (r *restHandler) updateProvider(c *gin.Context) {
+	// token内省
+	visitor, err := util.Verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	var req providerReq
+	if err = util.ValidateAndBindGin(c, r.providerSchema, &req); err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	if err = r.oidc.UpdateProvider(c, &visitor, req.toProvider(c.Param("id"))); err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusNoContent, nil)
+}
+
+// deleteProvider 管理员删除身份提供方
+func (r *restHandler) deleteProvider(c *gin.Context) {
+	// token内省
+	visitor, err := util.Verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	if err = r.oidc.DeleteProvider(c, &visitor, c.Param("id")); err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusNoContent, nil)
+}
+
+func (req *providerReq) toProvider(id string) *interfaces.OIDCProvider {
+	return &interfaces.OIDCProvider{
+		ID:           id,
+		Name:         req.Name,
+		Issuer:       req.Issuer,
+		ClientID:     req.ClientID,
+		ClientSecret: req.ClientSecret,
+		Scopes:       req.Scopes,
+		RedirectURI:  req.RedirectURI,
+		MappingRules: req.MappingRules,
+		JITProvision: req.JITProvision,
+		Enabled:      req.Enabled,
+	}
+}
+
+// providerInfo 身份提供方信息，不返回客户端密钥
+func providerInfo(provider *interfaces.OIDCProvider) map[string]interface{} {
+	return map[string]interface{}{
+		"id":            provider.ID,
+		"name":          provider.Name,
+		"issuer":        provider.Issuer,
+		"client_id":     provider.ClientID,
+		"scopes":        provider.Scopes,
+		"redirect_uri":  provider.RedirectURI,
+		"mapping_rules": provider.MappingRules,
+		"jit_provision": provider.JITProvision,
+		"enabled":       provider.Enabled,
+		"create_time":   provider.CreateTime,
+		"update_time":   provider.UpdateTime,
+	}
+}
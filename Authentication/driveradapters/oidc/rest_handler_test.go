@@ -0,0 +1,382 @@
+// Package oidc 协议层
+package oidc
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/kweaver-ai/go-lib/rest"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/xeipuuv/gojsonschema"
+	"go.uber.org/mock/gomock"
+
+	"Authentication/common"
+	oidcschema "Authentication/driveradapters/jsonschema/oidc_schema"
+	"Authentication/interfaces"
+	"Authentication/interfaces/mock"
+)
+
+const (
+	providersURL       = "/api/authentication/v1/oidc/providers"
+	authorizeURL       = "/api/authentication/v1/oidc/providers/p1/authorize"
+	manageProvidersURL = "/api/authentication/v1/oidc/management/providers"
+	manageProviderURL  = "/api/authentication/v1/oidc/management/providers/p1"
+)
+
+func setGinMode() func() {
+	old := gin.Mode()
+	gin.SetMode(gin.TestMode)
+	return func() {
+		gin.SetMode(old)
+	}
+}
+
+func newOIDCHandler(oidc interfaces.LogicsOIDC, hydra interfaces.Hydra) *restHandler {
+	providerSchema, _ := gojsonschema.NewSchema(gojsonschema.NewStringLoader(oidcschema.ProviderSchemaStr))
+	return &restHandler{
+		oidc:           oidc,
+		hydra:          hydra,
+		providerSchema: providerSchema,
+	}
+}
+
+func providerBody(t *testing.T, body map[string]interface{}) io.Reader {
+	buf, err := jsoniter.Marshal(body)
+	assert.Equal(t, err, nil)
+	return bytes.NewReader(buf)
+}
+
+func validProviderBody() map[string]interface{} {
+	return map[string]interface{}{
+		"name":          "corp idp",
+		"issuer":        "https://idp.example.com",
+		"client_id":     "anyshare",
+		"client_secret": "secret",
+		"scopes":        []string{"openid", "email"},
+		"redirect_uri":  "https://anyshare.example.com/oauth2/oidc/callback",
+		"mapping_rules": []map[string]interface{}{{"claim": "email", "match_by": "email"}},
+		"jit_provision": true,
+		"enabled":       true,
+	}
+}
+
+func TestListEnabledProviders(t *testing.T) {
+	Convey("TestListEnabledProviders", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		oidc := mock.NewMockLogicsOIDC(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newOIDCHandler(oidc, hydra)
+		handler.RegisterPublic(engine)
+
+		Convey("success", func() {
+			oidc.EXPECT().ListEnabledProviders(gomock.Any()).Return([]interfaces.OIDCProvider{
+				{ID: "p1", Name: "corp idp", ClientSecret: "secret", Enabled: true},
+			}, nil)
+			req := httptest.NewRequest("GET", providersURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusOK)
+
+			var res map[string]interface{}
+			body, _ := io.ReadAll(result.Body)
+			_ = jsoniter.Unmarshal(body, &res)
+			assert.Equal(t, res["total_count"], float64(1))
+			entry := res["entries"].([]interface{})[0].(map[string]interface{})
+			assert.Equal(t, entry["id"], "p1")
+			assert.Equal(t, entry["name"], "corp idp")
+			assert.Equal(t, len(entry), 2)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
+
+func TestBeginAuth(t *testing.T) {
+	Convey("TestBeginAuth", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		oidc := mock.NewMockLogicsOIDC(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newOIDCHandler(oidc, hydra)
+		handler.RegisterPublic(engine)
+
+		Convey("provider disabled", func() {
+			oidc.EXPECT().BeginAuth(gomock.Any(), gomock.Any(), "p1").Return("", rest.NewHTTPErrorV2(rest.Forbidden, "provider disabled"))
+			req := httptest.NewRequest("POST", authorizeURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusForbidden)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("success", func() {
+			oidc.EXPECT().BeginAuth(gomock.Any(), gomock.Any(), "p1").Return("https://idp.example.com/authorize?state=s", nil)
+			req := httptest.NewRequest("POST", authorizeURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusOK)
+
+			var res map[string]interface{}
+			body, _ := io.ReadAll(result.Body)
+			_ = jsoniter.Unmarshal(body, &res)
+			assert.Equal(t, res["authorization_url"], "https://idp.example.com/authorize?state=s")
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
+
+func TestAddProvider(t *testing.T) {
+	Convey("TestAddProvider", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		oidc := mock.NewMockLogicsOIDC(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newOIDCHandler(oidc, hydra)
+		handler.RegisterPublic(engine)
+
+		introspectInfo := interfaces.TokenIntrospectInfo{Active: true, VisitorID: "266c6a42-6131-4d62-8f39-853e7093701c"}
+
+		Convey("token过期", func() {
+			introspectInfo.Active = false
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			req := httptest.NewRequest("POST", manageProvidersURL, providerBody(t, validProviderBody()))
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusUnauthorized)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("invalid match_by", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			body := validProviderBody()
+			body["mapping_rules"] = []map[string]interface{}{{"claim": "email", "match_by": "phone"}}
+			req := httptest.NewRequest("POST", manageProvidersURL, providerBody(t, body))
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusBadRequest)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("success", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			var provider *interfaces.OIDCProvider
+			oidc.EXPECT().AddProvider(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_, _ interface{}, p *interfaces.OIDCProvider) (string, error) {
+					provider = p
+					return "p1", nil
+				})
+			req := httptest.NewRequest("POST", manageProvidersURL, providerBody(t, validProviderBody()))
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusCreated)
+			assert.Equal(t, provider.Issuer, "https://idp.example.com")
+			assert.Equal(t, provider.ClientSecret, "secret")
+			assert.Equal(t, provider.MappingRules, []interfaces.OIDCMappingRule{{Claim: "email", MatchBy: interfaces.OIDCMatchByEmail}})
+			assert.Equal(t, provider.JITProvision, true)
+
+			var res map[string]interface{}
+			resBody, _ := io.ReadAll(result.Body)
+			_ = jsoniter.Unmarshal(resBody, &res)
+			assert.Equal(t, res["id"], "p1")
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
+
+func TestGetProvider(t *testing.T) {
+	Convey("TestGetProvider", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		oidc := mock.NewMockLogicsOIDC(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newOIDCHandler(oidc, hydra)
+		handler.RegisterPublic(engine)
+
+		introspectInfo := interfaces.TokenIntrospectInfo{Active: true, VisitorID: "266c6a42-6131-4d62-8f39-853e7093701c"}
+
+		Convey("secret not returned", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			oidc.EXPECT().GetProvider(gomock.Any(), gomock.Any(), "p1").Return(&interfaces.OIDCProvider{
+				ID:           "p1",
+				Issuer:       "https://idp.example.com",
+				ClientID:     "anyshare",
+				ClientSecret: "secret",
+			}, nil)
+			req := httptest.NewRequest("GET", manageProviderURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusOK)
+
+			var res map[string]interface{}
+			body, _ := io.ReadAll(result.Body)
+			_ = jsoniter.Unmarshal(body, &res)
+			assert.Equal(t, res["client_id"], "anyshare")
+			_, ok := res["client_secret"]
+			assert.Equal(t, ok, false)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
+
+func TestUpdateProvider(t *testing.T) {
+	Convey("TestUpdateProvider", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		oidc := mock.NewMockLogicsOIDC(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newOIDCHandler(oidc, hydra)
+		handler.RegisterPublic(engine)
+
+		introspectInfo := interfaces.TokenIntrospectInfo{Active: true, VisitorID: "266c6a42-6131-4d62-8f39-853e7093701c"}
+
+		Convey("missing issuer", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			body := validProviderBody()
+			delete(body, "issuer")
+			req := httptest.NewRequest("PUT", manageProviderURL, providerBody(t, body))
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusBadRequest)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("success", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			oidc.EXPECT().UpdateProvider(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_, _ interface{}, p *interfaces.OIDCProvider) error {
+					assert.Equal(t, p.ID, "p1")
+					return nil
+				})
+			req := httptest.NewRequest("PUT", manageProviderURL, providerBody(t, validProviderBody()))
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusNoContent)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
+
+func TestDeleteProvider(t *testing.T) {
+	Convey("TestDeleteProvider", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		oidc := mock.NewMockLogicsOIDC(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newOIDCHandler(oidc, hydra)
+		handler.RegisterPublic(engine)
+
+		introspectInfo := interfaces.TokenIntrospectInfo{Active: true, VisitorID: "266c6a42-6131-4d62-8f39-853e7093701c"}
+
+		Convey("not admin", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			oidc.EXPECT().DeleteProvider(gomock.Any(), gomock.Any(), "p1").Return(rest.NewHTTPErrorV2(rest.Forbidden, "no permission"))
+			req := httptest.NewRequest("DELETE", manageProviderURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusForbidden)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("success", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			oidc.EXPECT().DeleteProvider(gomock.Any(), gomock.Any(), "p1").Return(nil)
+			req := httptest.NewRequest("DELETE", manageProviderURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusNoContent)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
@@ -0,0 +1,383 @@
+// Package saml 协议层
+package saml
+
+import (
+	"html"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kweaver-ai/go-lib/observable"
+	"github.com/kweaver-ai/go-lib/rest"
+	"github.com/xeipuuv/gojsonschema"
+
+	"Authentication/common"
+	"Authentication/driveradapters"
+	samlschema "Authentication/driveradapters/jsonschema/saml_schema"
+	"Authentication/driveradapters/util"
+	"Authentication/interfaces"
+	lsaml "Authentication/logics/saml"
+)
+
+// RESTHandler RESTful api Handler接口
+type RESTHandler interface {
+	// RegisterPublic 注册外部API
+	RegisterPublic(engine *gin.Engine)
+}
+
+type restHandler struct {
+	saml           interfaces.LogicsSAML
+	hydra          interfaces.Hydra
+	providerSchema *gojsonschema.Schema
+}
+
+// providerReq 身份提供方配置请求
+type providerReq struct {
+	Name          string                       `json:"name"`
+	Metadata      string                       `json:"metadata"`
+	MappingRules  []interfaces.SAMLMappingRule `json:"mapping_rules"`
+	NameAttribute string                       `json:"name_attribute"`
+	JITProvision  bool                         `json:"jit_provision"`
+	Enabled       bool                         `json:"enabled"`
+}
+
+const (
+	metadataContentType = "application/samlmetadata+xml"
+	htmlContentType     = "text/html; charset=utf-8"
+)
+
+var (
+	once sync.Once
+	r    RESTHandler
+)
+
+// NewRESTHandler 创建saml handler对象
+func NewRESTHandler() RESTHandler {
+	once.Do(func() {
+		providerSchema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(samlschema.ProviderSchemaStr))
+		if err != nil {
+			common.NewLogger().Fatalln(err)
+		}
+
+		r = &restHandler{
+			saml:           lsaml.NewSAML(),
+			hydra:          util.NewHydra(),
+			providerSchema: providerSchema,
+		}
+	})
+
+	return r
+}
+
+// RegisterPublic 注册外部API
+func (r *restHandler) RegisterPublic(engine *gin.Engine) {
+	engine.GET("/api/authentication/v1/saml/metadata", observable.MiddlewareTrace(common.SvcARTrace), r.metadata)
+	engine.GET("/api/authentication/v1/saml/providers", observable.MiddlewareTrace(common.SvcARTrace), r.listEnabledProviders)
+	engine.POST("/api/authentication/v1/saml/providers/:id/authorize", observable.MiddlewareTrace(common.SvcARTrace), r.beginAuth)
+	engine.POST("/api/authentication/v1/saml/acs", observable.MiddlewareTrace(common.SvcARTrace), r.consumeResponse)
+	engine.POST("/api/authentication/v1/saml/logout", observable.MiddlewareTrace(common.SvcARTrace), r.beginLogout)
+	engine.GET("/api/authentication/v1/saml/slo", observable.MiddlewareTrace(common.SvcARTrace), r.handleLogout)
+	engine.POST("/api/authentication/v1/saml/slo", observable.MiddlewareTrace(common.SvcARTrace), r.handleLogout)
+
+	engine.GET("/api/authentication/v1/saml/management/providers", observable.MiddlewareTrace(common.SvcARTrace), r.listProviders)
+	engine.POST("/api/authentication/v1/saml/management/providers", observable.MiddlewareTrace(common.SvcARTrace), r.addProvider)
+	engine.GET("/api/authentication/v1/saml/management/providers/:id", observable.MiddlewareTrace(common.SvcARTrace), r.getProvider)
+	engine.PUT("/api/authentication/v1/saml/management/providers/:id", observable.MiddlewareTrace(common.SvcARTrace), r.updateProvider)
+	engine.DELETE("/api/authentication/v1/saml/management/providers/:id", observable.MiddlewareTrace(common.SvcARTrace), r.deleteProvider)
+}
+
+// metadata 获取服务提供方元数据，供身份提供方导入
+func (r *restHandler) metadata(c *gin.Context) {
+	data, err := r.saml.SPMetadata(c)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	c.Data(http.StatusOK, metadataContentType, data)
+}
+
+// listEnabledProviders 获取已启用的身份提供方，用于登录页展示
+func (r *restHandler) listEnabledProviders(c *gin.Context) {
+	providers, err := r.saml.ListEnabledProviders(c)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	entries := make([]map[string]interface{}, 0, len(providers))
+	for i := range providers {
+		entries = append(entries, map[string]interface{}{
+			"id":   providers[i].ID,
+			"name": providers[i].Name,
+		})
+	}
+	rest.ReplyOK(c, http.StatusOK, map[string]interface{}{
+		"entries":     entries,
+		"total_count": len(entries),
+	})
+}
+
+// beginAuth 生成签名的AuthnRequest，客户端按照绑定方式发送到身份提供方
+func (r *restHandler) beginAuth(c *gin.Context) {
+	visitor := newVisitor(c)
+	redirect, err := r.saml.BeginAuth(c, &visitor, c.Param("id"))
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusOK, redirectInfo(redirect))
+}
+
+// consumeResponse 断言消费服务，身份提供方通过HTTP-POST绑定提交响应，校验通过后跳转登录页
+func (r *restHandler) consumeResponse(c *gin.Context) {
+	msg := interfaces.SAMLMessage{
+		SAMLResponse: c.PostForm("SAMLResponse"),
+		RelayState:   c.PostForm("RelayState"),
+	}
+
+	visitor := newVisitor(c)
+	redirectURL, err := r.saml.ConsumeResponse(c, &visitor, &msg)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, redirectURL)
+}
+
+// beginLogout 服务提供方发起单点登出，用户没有SAML登录会话时无需跳转
+func (r *restHandler) beginLogout(c *gin.Context) {
+	// token内省
+	visitor, err := util.Verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	redirect, err := r.saml.BeginLogout(c, &visitor)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+	if redirect == nil {
+		rest.ReplyOK(c, http.StatusNoContent, nil)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusOK, redirectInfo(redirect))
+}
+
+// handleLogout 单点登出服务，接收身份提供方通过HTTP-Redirect或HTTP-POST绑定发送的登出报文
+func (r *restHandler) handleLogout(c *gin.Context) {
+	var msg interfaces.SAMLMessage
+	if c.Request.Method == http.MethodGet {
+		msg = interfaces.SAMLMessage{
+			Redirect:     true,
+			RawQuery:     c.Request.URL.RawQuery,
+			SAMLRequest:  c.Query("SAMLRequest"),
+			SAMLResponse: c.Query("SAMLResponse"),
+			RelayState:   c.Query("RelayState"),
+		}
+	} else {
+		msg = interfaces.SAMLMessage{
+			SAMLRequest:  c.PostForm("SAMLRequest"),
+			SAMLResponse: c.PostForm("SAMLResponse"),
+			RelayState:   c.PostForm("RelayState"),
+		}
+	}
+
+	visitor := newVisitor(c)
+	redirect, err := r.saml.HandleLogout(c, &visitor, &msg)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	switch {
+	case redirect == nil:
+		rest.ReplyOK(c, http.StatusNoContent, nil)
+	case redirect.Binding == interfaces.SAMLBindingPost:
+		c.Data(http.StatusOK, htmlContentType, []byte(postForm(redirect)))
+	default:
+		c.Redirect(http.StatusFound, redirect.URL)
+	}
+}
+
+// listProviders 管理员获取所有身份提供方
+func (r *restHandler) listProviders(c *gin.Context) {
+	// token内省
+	visitor, err := util.Verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	providers, err := r.saml.ListProviders(c, &visitor)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	entries := make([]map[string]interface{}, 0, len(providers))
+	for i := range providers {
+		entries = append(entries, providerInfo(&providers[i]))
+	}
+	rest.ReplyOK(c, http.StatusOK, map[string]interface{}{
+		"entries":     entries,
+		"total_count": len(entries),
+	})
+}
+
+// addProvider 管理员导入身份提供方元数据
+func (r *restHandler) addProvider(c *gin.Context) {
+	// token内省
+	visitor, err := util.Verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	var req providerReq
+	if err = util.ValidateAndBindGin(c, r.providerSchema, &req); err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	id, err := r.saml.AddProvider(c, &visitor, req.toProvider(""))
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusCreated, map[string]interface{}{"id": id})
+}
+
+// getProvider 管理员获取身份提供方
+func (r *restHandler) getProvider(c *gin.Context) {
+	// token内省
+	visitor, err := util.Verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	provider, err := r.saml.GetProvider(c, &visitor, c.Param("id"))
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusOK, providerInfo(provider))
+}
+
+// updateProvider 管理员更新身份提供方
+func (r *restHandler) updateProvider(c *gin.Context) {
+	// token内省
+	visitor, err := util.Verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	var req providerReq
+	if err = util.ValidateAndBindGin(c, r.providerSchema, &req); err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	if err = r.saml.UpdateProvider(c, &visitor, req.toProvider(c.Param("id"))); err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusNoContent, nil)
+}
+
+// deleteProvider 管理员删除身份提供方
+func (r *restHandler) deleteProvider(c *gin.Context) {
+	// token内省
+	visitor, err := util.Verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	if err = r.saml.DeleteProvider(c, &visitor, c.Param("id")); err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusNoContent, nil)
+}
+
+func (req *providerReq) toProvider(id string) *interfaces.SAMLProvider {
+	return &interfaces.SAMLProvider{
+		ID:            id,
+		Name:          req.Name,
+		Metadata:      req.Metadata,
+		MappingRules:  req.MappingRules,
+		NameAttribute: req.NameAttribute,
+		JITProvision:  req.JITProvision,
+		Enabled:       req.Enabled,
+	}
+}
+
+// providerInfo 身份提供方信息
+func providerInfo(provider *interfaces.SAMLProvider) map[string]interface{} {
+	return map[string]interface{}{
+		"id":             provider.ID,
+		"name":           provider.Name,
+		"entity_id":      provider.EntityID,
+		"metadata":       provider.Metadata,
+		"mapping_rules":  provider.MappingRules,
+		"name_attribute": provider.NameAttribute,
+		"jit_provision":  provider.JITProvision,
+		"enabled":        provider.Enabled,
+		"create_time":    provider.CreateTime,
+		"update_time":    provider.UpdateTime,
+	}
+}
+
+// redirectInfo 需要客户端发送到身份提供方的报文
+func redirectInfo(redirect *interfaces.SAMLRedirect) map[string]interface{} {
+	params := redirect.Params
+	if params == nil {
+		params = map[string]string{}
+	}
+	return map[string]interface{}{
+		"binding": redirect.Binding,
+		"url":     redirect.URL,
+		"params":  params,
+	}
+}
+
+// postForm 生成自动提交的表单页面，用于HTTP-POST绑定
+func postForm(redirect *interfaces.SAMLRedirect) string {
+	names := make([]string, 0, len(redirect.Params))
+	for name := range redirect.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(`<!DOCTYPE html><html><body onload="document.forms[0].submit()">`)
+	b.WriteString(`<form method="post" action="` + html.EscapeString(redirect.URL) + `">`)
+	for _, name := range names {
+		b.WriteString(`<input type="hidden" name="` + html.EscapeString(name) + `" value="` + html.EscapeString(redirect.Params[name]) + `"/>`)
+	}
+	b.WriteString(`<noscript><input type="submit" value="Continue"/></noscript></form></body></html>`)
+	return b.String()
+}
+
+func newVisitor(c *gin.Context) interfaces.Visitor {
+	return interfaces.Visitor{
+		IP:            c.ClientIP(),
+		UserAgent:     c.Request.UserAgent(),
+		Language:      driveradapters.GetXLang(c),
+		ErrorCodeType: util.GetErrorCodeType(c),
+	}
+}
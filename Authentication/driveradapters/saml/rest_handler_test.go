@@ -0,0 +1,683 @@
+// Package saml 协议层
+package saml
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/kweaver-ai/go-lib/rest"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/xeipuuv/gojsonschema"
+	"go.uber.org/mock/gomock"
+
+	"Authentication/common"
+	samlschema "Authentication/driveradapters/jsonschema/saml_schema"
+	"Authentication/interfaces"
+	"Authentication/interfaces/mock"
+)
+
+const (
+	metadataURL        = "/api/authentication/v1/saml/metadata"
+	providersURL       = "/api/authentication/v1/saml/providers"
+	authorizeURL       = "/api/authentication/v1/saml/providers/p1/authorize"
+	acsURL             = "/api/authentication/v1/saml/acs"
+	logoutURL          = "/api/authentication/v1/saml/logout"
+	sloURL             = "/api/authentication/v1/saml/slo"
+	manageProvidersURL = "/api/authentication/v1/saml/management/providers"
+	manageProviderURL  = "/api/authentication/v1/saml/management/providers/p1"
+)
+
+func setGinMode() func() {
+	old := gin.Mode()
+	gin.SetMode(gin.TestMode)
+	return func() {
+		gin.SetMode(old)
+	}
+}
+
+func newSAMLHandler(saml interfaces.LogicsSAML, hydra interfaces.Hydra) *restHandler {
+	providerSchema, _ := gojsonschema.NewSchema(gojsonschema.NewStringLoader(samlschema.ProviderSchemaStr))
+	return &restHandler{
+		saml:           saml,
+		hydra:          hydra,
+		providerSchema: providerSchema,
+	}
+}
+
+func providerBody(t *testing.T, body map[string]interface{}) io.Reader {
+	buf, err := jsoniter.Marshal(body)
+	assert.Equal(t, err, nil)
+	return bytes.NewReader(buf)
+}
+
+func validProviderBody() map[string]interface{} {
+	return map[string]interface{}{
+		"name":           "corp idp",
+		"metadata":       "<md:EntityDescriptor/>",
+		"mapping_rules":  []map[string]interface{}{{"attribute": "mail", "match_by": "email"}},
+		"name_attribute": "displayName",
+		"jit_provision":  true,
+		"enabled":        true,
+	}
+}
+
+func formBody(values url.Values) io.Reader {
+	return strings.NewReader(values.Encode())
+}
+
+func TestMetadata(t *testing.T) {
+	Convey("TestMetadata", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		saml := mock.NewMockLogicsSAML(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newSAMLHandler(saml, hydra)
+		handler.RegisterPublic(engine)
+
+		Convey("not enabled", func() {
+			saml.EXPECT().SPMetadata(gomock.Any()).Return(nil, rest.NewHTTPErrorV2(rest.Forbidden, "saml is not enabled"))
+			req := httptest.NewRequest("GET", metadataURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusForbidden)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("success", func() {
+			saml.EXPECT().SPMetadata(gomock.Any()).Return([]byte("<md:EntityDescriptor/>"), nil)
+			req := httptest.NewRequest("GET", metadataURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusOK)
+			assert.Equal(t, result.Header.Get("Content-Type"), metadataContentType)
+
+			body, _ := io.ReadAll(result.Body)
+			assert.Equal(t, string(body), "<md:EntityDescriptor/>")
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
+
+func TestListEnabledProviders(t *testing.T) {
+	Convey("TestListEnabledProviders", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		saml := mock.NewMockLogicsSAML(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newSAMLHandler(saml, hydra)
+		handler.RegisterPublic(engine)
+
+		Convey("success", func() {
+			saml.EXPECT().ListEnabledProviders(gomock.Any()).Return([]interfaces.SAMLProvider{
+				{ID: "p1", Name: "corp idp", Metadata: "<md:EntityDescriptor/>", Enabled: true},
+			}, nil)
+			req := httptest.NewRequest("GET", providersURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusOK)
+
+			var res map[string]interface{}
+			body, _ := io.ReadAll(result.Body)
+			_ = jsoniter.Unmarshal(body, &res)
+			assert.Equal(t, res["total_count"], float64(1))
+			entry := res["entries"].([]interface{})[0].(map[string]interface{})
+			assert.Equal(t, entry["id"], "p1")
+			assert.Equal(t, entry["name"], "corp idp")
+			assert.Equal(t, len(entry), 2)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
+
+func TestBeginAuth(t *testing.T) {
+	Convey("TestBeginAuth", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		saml := mock.NewMockLogicsSAML(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newSAMLHandler(saml, hydra)
+		handler.RegisterPublic(engine)
+
+		Convey("provider disabled", func() {
+			saml.EXPECT().BeginAuth(gomock.Any(), gomock.Any(), "p1").Return(nil, rest.NewHTTPErrorV2(rest.Forbidden, "provider disabled"))
+			req := httptest.NewRequest("POST", authorizeURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusForbidden)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("redirect binding", func() {
+			saml.EXPECT().BeginAuth(gomock.Any(), gomock.Any(), "p1").Return(&interfaces.SAMLRedirect{
+				Binding: interfaces.SAMLBindingRedirect,
+				URL:     "https://idp.example.com/sso?SAMLRequest=r&RelayState=s",
+			}, nil)
+			req := httptest.NewRequest("POST", authorizeURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusOK)
+
+			var res map[string]interface{}
+			body, _ := io.ReadAll(result.Body)
+			_ = jsoniter.Unmarshal(body, &res)
+			assert.Equal(t, res["binding"], "redirect")
+			assert.Equal(t, res["url"], "https://idp.example.com/sso?SAMLRequest=r&RelayState=s")
+			assert.Equal(t, res["params"], map[string]interface{}{})
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("post binding", func() {
+			saml.EXPECT().BeginAuth(gomock.Any(), gomock.Any(), "p1").Return(&interfaces.SAMLRedirect{
+				Binding: interfaces.SAMLBindingPost,
+				URL:     "https://idp.example.com/sso",
+				Params:  map[string]string{"SAMLRequest": "r", "RelayState": "s"},
+			}, nil)
+			req := httptest.NewRequest("POST", authorizeURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusOK)
+
+			var res map[string]interface{}
+			body, _ := io.ReadAll(result.Body)
+			_ = jsoniter.Unmarshal(body, &res)
+			assert.Equal(t, res["binding"], "post")
+			assert.Equal(t, res["params"], map[string]interface{}{"SAMLRequest": "r", "RelayState": "s"})
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
+
+func TestConsumeResponse(t *testing.T) {
+	Convey("TestConsumeResponse", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		saml := mock.NewMockLogicsSAML(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newSAMLHandler(saml, hydra)
+		handler.RegisterPublic(engine)
+
+		form := url.Values{"SAMLResponse": {"cmVzcG9uc2U="}, "RelayState": {"s1"}}
+
+		Convey("auth failed", func() {
+			saml.EXPECT().ConsumeResponse(gomock.Any(), gomock.Any(), gomock.Any()).Return("", rest.NewHTTPError("", common.SAMLAuthFailed, nil))
+			req := httptest.NewRequest("POST", acsURL, formBody(form))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusUnauthorized)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("success", func() {
+			saml.EXPECT().ConsumeResponse(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_, _ interface{}, msg *interfaces.SAMLMessage) (string, error) {
+					assert.Equal(t, msg.Redirect, false)
+					assert.Equal(t, msg.SAMLResponse, "cmVzcG9uc2U=")
+					assert.Equal(t, msg.RelayState, "s1")
+					return "https://anyshare.example.com/login?state=s1", nil
+				})
+			req := httptest.NewRequest("POST", acsURL, formBody(form))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusFound)
+			assert.Equal(t, result.Header.Get("Location"), "https://anyshare.example.com/login?state=s1")
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
+
+func TestBeginLogout(t *testing.T) {
+	Convey("TestBeginLogout", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		saml := mock.NewMockLogicsSAML(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newSAMLHandler(saml, hydra)
+		handler.RegisterPublic(engine)
+
+		introspectInfo := interfaces.TokenIntrospectInfo{Active: true, VisitorID: "266c6a42-6131-4d62-8f39-853e7093701c"}
+
+		Convey("token过期", func() {
+			introspectInfo.Active = false
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			req := httptest.NewRequest("POST", logoutURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusUnauthorized)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("no saml session", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			saml.EXPECT().BeginLogout(gomock.Any(), gomock.Any()).Return(nil, nil)
+			req := httptest.NewRequest("POST", logoutURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusNoContent)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("success", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			saml.EXPECT().BeginLogout(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ interface{}, visitor *interfaces.Visitor) (*interfaces.SAMLRedirect, error) {
+					assert.Equal(t, visitor.ID, "266c6a42-6131-4d62-8f39-853e7093701c")
+					return &interfaces.SAMLRedirect{Binding: interfaces.SAMLBindingRedirect, URL: "https://idp.example.com/slo?SAMLRequest=r"}, nil
+				})
+			req := httptest.NewRequest("POST", logoutURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusOK)
+
+			var res map[string]interface{}
+			body, _ := io.ReadAll(result.Body)
+			_ = jsoniter.Unmarshal(body, &res)
+			assert.Equal(t, res["url"], "https://idp.example.com/slo?SAMLRequest=r")
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
+
+func TestHandleLogout(t *testing.T) {
+	Convey("TestHandleLogout", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		saml := mock.NewMockLogicsSAML(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newSAMLHandler(saml, hydra)
+		handler.RegisterPublic(engine)
+
+		Convey("redirect binding request", func() {
+			rawQuery := "SAMLRequest=cmVx&RelayState=s1&SigAlg=alg&Signature=sig"
+			saml.EXPECT().HandleLogout(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_, _ interface{}, msg *interfaces.SAMLMessage) (*interfaces.SAMLRedirect, error) {
+					assert.Equal(t, msg.Redirect, true)
+					assert.Equal(t, msg.RawQuery, rawQuery)
+					assert.Equal(t, msg.SAMLRequest, "cmVx")
+					assert.Equal(t, msg.RelayState, "s1")
+					return &interfaces.SAMLRedirect{Binding: interfaces.SAMLBindingRedirect, URL: "https://idp.example.com/slo?SAMLResponse=r"}, nil
+				})
+			req := httptest.NewRequest("GET", sloURL+"?"+rawQuery, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusFound)
+			assert.Equal(t, result.Header.Get("Location"), "https://idp.example.com/slo?SAMLResponse=r")
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("post binding request", func() {
+			saml.EXPECT().HandleLogout(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_, _ interface{}, msg *interfaces.SAMLMessage) (*interfaces.SAMLRedirect, error) {
+					assert.Equal(t, msg.Redirect, false)
+					assert.Equal(t, msg.SAMLRequest, "cmVx")
+					return &interfaces.SAMLRedirect{
+						Binding: interfaces.SAMLBindingPost,
+						URL:     "https://idp.example.com/slo",
+						Params:  map[string]string{"SAMLResponse": "r", "RelayState": `"><script>`},
+					}, nil
+				})
+			req := httptest.NewRequest("POST", sloURL, formBody(url.Values{"SAMLRequest": {"cmVx"}}))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusOK)
+
+			body, _ := io.ReadAll(result.Body)
+			assert.Equal(t, strings.Contains(string(body), `action="https://idp.example.com/slo"`), true)
+			assert.Equal(t, strings.Contains(string(body), `name="SAMLResponse" value="r"`), true)
+			assert.Equal(t, strings.Contains(string(body), "<script>"), false)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("logout response without page", func() {
+			saml.EXPECT().HandleLogout(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+			req := httptest.NewRequest("GET", sloURL+"?SAMLResponse=cmVz", http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusNoContent)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("invalid message", func() {
+			saml.EXPECT().HandleLogout(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, rest.NewHTTPError("invalid message", rest.BadRequest, nil))
+			req := httptest.NewRequest("GET", sloURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusBadRequest)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
+
+func TestAddProvider(t *testing.T) {
+	Convey("TestAddProvider", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		saml := mock.NewMockLogicsSAML(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newSAMLHandler(saml, hydra)
+		handler.RegisterPublic(engine)
+
+		introspectInfo := interfaces.TokenIntrospectInfo{Active: true, VisitorID: "266c6a42-6131-4d62-8f39-853e7093701c"}
+
+		Convey("token过期", func() {
+			introspectInfo.Active = false
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			req := httptest.NewRequest("POST", manageProvidersURL, providerBody(t, validProviderBody()))
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusUnauthorized)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("invalid match_by", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			body := validProviderBody()
+			body["mapping_rules"] = []map[string]interface{}{{"attribute": "mail", "match_by": "phone"}}
+			req := httptest.NewRequest("POST", manageProvidersURL, providerBody(t, body))
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusBadRequest)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("success", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			var provider *interfaces.SAMLProvider
+			saml.EXPECT().AddProvider(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_, _ interface{}, p *interfaces.SAMLProvider) (string, error) {
+					provider = p
+					return "p1", nil
+				})
+			req := httptest.NewRequest("POST", manageProvidersURL, providerBody(t, validProviderBody()))
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusCreated)
+			assert.Equal(t, provider.Metadata, "<md:EntityDescriptor/>")
+			assert.Equal(t, provider.MappingRules, []interfaces.SAMLMappingRule{{Attribute: "mail", MatchBy: interfaces.OIDCMatchByEmail}})
+			assert.Equal(t, provider.NameAttribute, "displayName")
+			assert.Equal(t, provider.JITProvision, true)
+
+			var res map[string]interface{}
+			resBody, _ := io.ReadAll(result.Body)
+			_ = jsoniter.Unmarshal(resBody, &res)
+			assert.Equal(t, res["id"], "p1")
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
+
+func TestGetProvider(t *testing.T) {
+	Convey("TestGetProvider", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		saml := mock.NewMockLogicsSAML(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newSAMLHandler(saml, hydra)
+		handler.RegisterPublic(engine)
+
+		introspectInfo := interfaces.TokenIntrospectInfo{Active: true, VisitorID: "266c6a42-6131-4d62-8f39-853e7093701c"}
+
+		Convey("success", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			saml.EXPECT().GetProvider(gomock.Any(), gomock.Any(), "p1").Return(&interfaces.SAMLProvider{
+				ID:       "p1",
+				EntityID: "https://idp.example.com/metadata",
+				Metadata: "<md:EntityDescriptor/>",
+			}, nil)
+			req := httptest.NewRequest("GET", manageProviderURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusOK)
+
+			var res map[string]interface{}
+			body, _ := io.ReadAll(result.Body)
+			_ = jsoniter.Unmarshal(body, &res)
+			assert.Equal(t, res["entity_id"], "https://idp.example.com/metadata")
+			assert.Equal(t, res["metadata"], "<md:EntityDescriptor/>")
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
+
+func TestUpdateProvider(t *testing.T) {
+	Convey("TestUpdateProvider", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		saml := mock.NewMockLogicsSAML(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newSAMLHandler(saml, hydra)
+		handler.RegisterPublic(engine)
+
+		introspectInfo := interfaces.TokenIntrospectInfo{Active: true, VisitorID: "266c6a42-6131-4d62-8f39-853e7093701c"}
+
+		Convey("missing metadata", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			body := validProviderBody()
+			delete(body, "metadata")
+			req := httptest.NewRequest("PUT", manageProviderURL, providerBody(t, body))
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusBadRequest)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("success", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			saml.EXPECT().UpdateProvider(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_, _ interface{}, p *interfaces.SAMLProvider) error {
+					assert.Equal(t, p.ID, "p1")
+					return nil
+				})
+			req := httptest.NewRequest("PUT", manageProviderURL, providerBody(t, validProviderBody()))
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusNoContent)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
+
+func TestDeleteProvider(t *testing.T) {
+	Convey("TestDeleteProvider", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		saml := mock.NewMockLogicsSAML(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newSAMLHandler(saml, hydra)
+		handler.RegisterPublic(engine)
+
+		introspectInfo := interfaces.TokenIntrospectInfo{Active: true, VisitorID: "266c6a42-6131-4d62-8f39-853e7093701c"}
+
+		Convey("not admin", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			saml.EXPECT().DeleteProvider(gomock.Any(), gomock.Any(), "p1").Return(rest.NewHTTPErrorV2(rest.Forbidden, "no permission"))
+			req := httptest.NewRequest("DELETE", manageProviderURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusForbidden)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("success", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			saml.EXPECT().DeleteProvider(gomock.Any(), gomock.Any(), "p1").Return(nil)
+			req := httptest.NewRequest("DELETE", manageProviderURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusNoContent)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
@@ -0,0 +1,191 @@
+// Package totp 协议层
+package totp
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kweaver-ai/go-lib/observable"
+	"github.com/kweaver-ai/go-lib/rest"
+	"github.com/xeipuuv/gojsonschema"
+
+	"Authentication/common"
+	totpschema "Authentication/driveradapters/jsonschema/totp_schema"
+	"Authentication/driveradapters/util"
+	"Authentication/interfaces"
+	"Authentication/logics/totp"
+)
+
+// RESTHandler RESTful api Handler接口
+type RESTHandler interface {
+	// RegisterPublic 注册外部API
+	RegisterPublic(engine *gin.Engine)
+}
+
+type restHandler struct {
+	totp       interfaces.LogicsTOTP
+	hydra      interfaces.Hydra
+	codeSchema *gojsonschema.Schema
+}
+
+var (
+	once sync.Once
+	r    RESTHandler
+)
+
+// NewRESTHandler 创建totp handler对象
+func NewRESTHandler() RESTHandler {
+	once.Do(func() {
+		codeSchema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(totpschema.TOTPCodeSchemaStr))
+		if err != nil {
+			common.NewLogger().Fatalln(err)
+		}
+
+		r = &restHandler{
+			totp:       totp.NewTOTP(),
+			hydra:      util.NewHydra(),
+			codeSchema: codeSchema,
+		}
+	})
+
+	return r
+}
+
+// RegisterPublic 注册外部API
+func (r *restHandler) RegisterPublic(engine *gin.Engine) {
+	engine.GET("/api/authentication/v1/totp", observable.MiddlewareTrace(common.SvcARTrace), r.getStatus)
+	engine.POST("/api/authentication/v1/totp/enroll", observable.MiddlewareTrace(common.SvcARTrace), r.enroll)
+	engine.POST("/api/authentication/v1/totp/confirm", observable.MiddlewareTrace(common.SvcARTrace), r.confirm)
+	engine.POST("/api/authentication/v1/totp/recovery-codes", observable.MiddlewareTrace(common.SvcARTrace), r.regenerateRecoveryCodes)
+	engine.POST("/api/authentication/v1/totp/disable", observable.MiddlewareTrace(common.SvcARTrace), r.disable)
+	engine.DELETE("/api/authentication/v1/totp/users/:user_id", observable.MiddlewareTrace(common.SvcARTrace), r.reset)
+}
+
+// getStatus 获取当前用户动态口令状态
+func (r *restHandler) getStatus(c *gin.Context) {
+	// token内省
+	visitor, err := util.Verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	status, err := r.totp.GetStatus(c, &visitor)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusOK, map[string]interface{}{
+		"enabled":             status.Enabled,
+		"recovery_code_count": status.RecoveryCodeCount,
+	})
+}
+
+// enroll 生成动态口令密钥
+func (r *restHandler) enroll(c *gin.Context) {
+	// token内省
+	visitor, err := util.Verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	enrollment, err := r.totp.Enroll(c, &visitor)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusOK, map[string]interface{}{
+		"secret":           enrollment.Secret,
+		"provisioning_uri": enrollment.ProvisioningURI,
+	})
+}
+
+// confirm 确认绑定动态口令
+func (r *restHandler) confirm(c *gin.Context) {
+	visitor, code, ok := r.parseCodeReq(c)
+	if !ok {
+		return
+	}
+
+	recoveryCodes, err := r.totp.ConfirmEnrollment(c, &visitor, code)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusOK, map[string]interface{}{
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+// regenerateRecoveryCodes 重新生成恢复码
+func (r *restHandler) regenerateRecoveryCodes(c *gin.Context) {
+	visitor, code, ok := r.parseCodeReq(c)
+	if !ok {
+		return
+	}
+
+	recoveryCodes, err := r.totp.RegenerateRecoveryCodes(c, &visitor, code)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusOK, map[string]interface{}{
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+// disable 解除绑定动态口令
+func (r *restHandler) disable(c *gin.Context) {
+	visitor, code, ok := r.parseCodeReq(c)
+	if !ok {
+		return
+	}
+
+	if err := r.totp.Disable(c, &visitor, code); err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusNoContent, nil)
+}
+
+// reset 管理员重置用户动态口令
+func (r *restHandler) reset(c *gin.Context) {
+	// token内省
+	visitor, err := util.Verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	if err = r.totp.Reset(c, &visitor, c.Param("user_id")); err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusNoContent, nil)
+}
+
+// parseCodeReq 内省令牌并解析请求中的动态口令
+func (r *restHandler) parseCodeReq(c *gin.Context) (visitor interfaces.Visitor, code string, ok bool) {
+	// token内省
+	visitor, err := util.Verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	var jsonV map[string]interface{}
+	if err = util.ValidateAndBindGin(c, r.codeSchema, &jsonV); err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	return visitor, jsonV["code"].(string), true
+}
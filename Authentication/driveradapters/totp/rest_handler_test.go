@@ -0,0 +1,200 @@
+// Package totp 协议层
+package totp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert"
+	jsoniter "github.com/json-iterator/go"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/xeipuuv/gojsonschema"
+	"go.uber.org/mock/gomock"
+
+	"Authentication/common"
+	totpschema "Authentication/driveradapters/jsonschema/totp_schema"
+	"Authentication/interfaces"
+	"Authentication/interfaces/mock"
+)
+
+const (
+	totpURL    = "/api/authentication/v1/totp"
+	confirmURL = "/api/authentication/v1/totp/confirm"
+	resetURL   = "/api/authentication/v1/totp/users/dfc9b098-dac4-11ee-b50a-028586548cf7"
+)
+
+func setGinMode() func() {
+	old := gin.Mode()
+	gin.SetMode(gin.TestMode)
+	return func() {
+		gin.SetMode(old)
+	}
+}
+
+func newTOTPHandler(totp interfaces.LogicsTOTP, hydra interfaces.Hydra) *restHandler {
+	codeSchema, _ := gojsonschema.NewSchema(gojsonschema.NewStringLoader(totpschema.TOTPCodeSchemaStr))
+	return &restHandler{
+		totp:       totp,
+		hydra:      hydra,
+		codeSchema: codeSchema,
+	}
+}
+
+func TestGetStatus(t *testing.T) {
+	Convey("TestGetStatus", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		totp := mock.NewMockLogicsTOTP(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newTOTPHandler(totp, hydra)
+		handler.RegisterPublic(engine)
+
+		introspectInfo := interfaces.TokenIntrospectInfo{Active: true, VisitorID: "dfc9b098-dac4-11ee-b50a-028586548cf7"}
+
+		Convey("token过期", func() {
+			introspectInfo.Active = false
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			req := httptest.NewRequest("GET", totpURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusUnauthorized)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("success", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			totp.EXPECT().GetStatus(gomock.Any(), gomock.Any()).Return(&interfaces.TOTPStatusInfo{Enabled: true, RecoveryCodeCount: 8}, nil)
+			req := httptest.NewRequest("GET", totpURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusOK)
+
+			respBody, _ := io.ReadAll(result.Body)
+			var body map[string]interface{}
+			_ = jsoniter.Unmarshal(respBody, &body)
+			assert.Equal(t, body["enabled"], true)
+			assert.Equal(t, body["recovery_code_count"], float64(8))
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
+
+func TestConfirm(t *testing.T) {
+	Convey("TestConfirm", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		totp := mock.NewMockLogicsTOTP(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newTOTPHandler(totp, hydra)
+		handler.RegisterPublic(engine)
+
+		introspectInfo := interfaces.TokenIntrospectInfo{Active: true, VisitorID: "dfc9b098-dac4-11ee-b50a-028586548cf7"}
+
+		Convey("参数错误", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			req := httptest.NewRequest("POST", confirmURL, bytes.NewReader([]byte(`{"code": 123456}`)))
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusBadRequest)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("逻辑层失败", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			totp.EXPECT().ConfirmEnrollment(gomock.Any(), gomock.Any(), "123456").Return(nil, errors.New("test"))
+			req := httptest.NewRequest("POST", confirmURL, bytes.NewReader([]byte(`{"code": "123456"}`)))
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusInternalServerError)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("success", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			totp.EXPECT().ConfirmEnrollment(gomock.Any(), gomock.Any(), "123456").Return([]string{"abcde-fghjk"}, nil)
+			req := httptest.NewRequest("POST", confirmURL, bytes.NewReader([]byte(`{"code": "123456"}`)))
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusOK)
+
+			respBody, _ := io.ReadAll(result.Body)
+			var body map[string]interface{}
+			_ = jsoniter.Unmarshal(respBody, &body)
+			assert.Equal(t, body["recovery_codes"], []interface{}{"abcde-fghjk"})
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
+
+func TestReset(t *testing.T) {
+	Convey("TestReset", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		totp := mock.NewMockLogicsTOTP(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newTOTPHandler(totp, hydra)
+		handler.RegisterPublic(engine)
+
+		introspectInfo := interfaces.TokenIntrospectInfo{Active: true, VisitorID: "266c6a42-6131-4d62-8f39-853e7093701c"}
+
+		Convey("success", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			totp.EXPECT().Reset(gomock.Any(), gomock.Any(), "dfc9b098-dac4-11ee-b50a-028586548cf7").Return(nil)
+			req := httptest.NewRequest("DELETE", resetURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusNoContent)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
@@ -0,0 +1,241 @@
+// Package webauthn 协议层
+package webauthn
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kweaver-ai/go-lib/observable"
+	"github.com/kweaver-ai/go-lib/rest"
+	"github.com/xeipuuv/gojsonschema"
+
+	"Authentication/common"
+	"Authentication/driveradapters"
+	webauthnschema "Authentication/driveradapters/jsonschema/webauthn_schema"
+	"Authentication/driveradapters/util"
+	"Authentication/interfaces"
+	"Authentication/logics/webauthn"
+)
+
+// RESTHandler RESTful api Handler接口
+type RESTHandler interface {
+	// RegisterPublic 注册外部API
+	RegisterPublic(engine *gin.Engine)
+}
+
+type restHandler struct {
+	webAuthn           interfaces.LogicsWebAuthn
+	hydra              interfaces.Hydra
+	registrationSchema *gojsonschema.Schema
+}
+
+var (
+	once sync.Once
+	r    RESTHandler
+)
+
+// NewRESTHandler 创建webauthn handler对象
+func NewRESTHandler() RESTHandler {
+	once.Do(func() {
+		registrationSchema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(webauthnschema.RegistrationSchemaStr))
+		if err != nil {
+			common.NewLogger().Fatalln(err)
+		}
+
+		r = &restHandler{
+			webAuthn:           webauthn.NewWebAuthn(),
+			hydra:              util.NewHydra(),
+			registrationSchema: registrationSchema,
+		}
+	})
+
+	return r
+}
+
+// RegisterPublic 注册外部API
+func (r *restHandler) RegisterPublic(engine *gin.Engine) {
+	engine.POST("/api/authentication/v1/webauthn/registration/options", observable.MiddlewareTrace(common.SvcARTrace), r.beginRegistration)
+	engine.POST("/api/authentication/v1/webauthn/registration", observable.MiddlewareTrace(common.SvcARTrace), r.finishRegistration)
+	engine.POST("/api/authentication/v1/webauthn/assertion/options", observable.MiddlewareTrace(common.SvcARTrace), r.beginAssertion)
+	engine.GET("/api/authentication/v1/webauthn/users/:user_id/credentials", observable.MiddlewareTrace(common.SvcARTrace), r.listCredentials)
+	engine.DELETE("/api/authentication/v1/webauthn/users/:user_id/credentials", observable.MiddlewareTrace(common.SvcARTrace), r.revokeAll)
+	engine.DELETE("/api/authentication/v1/webauthn/users/:user_id/credentials/:credential_id", observable.MiddlewareTrace(common.SvcARTrace), r.deleteCredential)
+}
+
+// beginRegistration 生成安全密钥注册选项，返回内容可直接用于 navigator.credentials.create
+func (r *restHandler) beginRegistration(c *gin.Context) {
+	// token内省
+	visitor, err := util.Verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	options, err := r.webAuthn.BeginRegistration(c, &visitor)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	pubKeyCredParams := make([]map[string]interface{}, 0, len(options.Algorithms))
+	for _, alg := range options.Algorithms {
+		pubKeyCredParams = append(pubKeyCredParams, map[string]interface{}{"type": "public-key", "alg": alg})
+	}
+
+	rest.ReplyOK(c, http.StatusOK, map[string]interface{}{
+		"challenge_id": options.ChallengeID,
+		"public_key": map[string]interface{}{
+			"challenge": options.Challenge,
+			"rp": map[string]interface{}{
+				"id":   options.RPID,
+				"name": options.RPName,
+			},
+			"user": map[string]interface{}{
+				"id":          options.UserHandle,
+				"name":        options.UserName,
+				"displayName": options.UserName,
+			},
+			"pubKeyCredParams":   pubKeyCredParams,
+			"timeout":            options.Timeout,
+			"attestation":        options.Attestation,
+			"excludeCredentials": credentialDescriptors(options.ExcludeCredentialIDs),
+			"authenticatorSelection": map[string]interface{}{
+				"residentKey":      "preferred",
+				"userVerification": "preferred",
+			},
+		},
+	})
+}
+
+// finishRegistration 校验注册响应并保存安全密钥
+func (r *restHandler) finishRegistration(c *gin.Context) {
+	// token内省
+	visitor, err := util.Verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	var req interfaces.WebAuthnRegistrationReq
+	if err = util.ValidateAndBindGin(c, r.registrationSchema, &req); err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	credential, err := r.webAuthn.FinishRegistration(c, &visitor, &req)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusCreated, credentialInfo(credential))
+}
+
+// beginAssertion 生成安全密钥认证选项，返回内容可直接用于 navigator.credentials.get
+func (r *restHandler) beginAssertion(c *gin.Context) {
+	visitor := interfaces.Visitor{
+		IP:            c.ClientIP(),
+		UserAgent:     c.Request.UserAgent(),
+		Language:      driveradapters.GetXLang(c),
+		ErrorCodeType: util.GetErrorCodeType(c),
+	}
+
+	options, err := r.webAuthn.BeginAssertion(c, &visitor)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusOK, map[string]interface{}{
+		"challenge_id": options.ChallengeID,
+		"public_key": map[string]interface{}{
+			"challenge":        options.Challenge,
+			"rpId":             options.RPID,
+			"timeout":          options.Timeout,
+			"userVerification": "preferred",
+			"allowCredentials": []interface{}{},
+		},
+	})
+}
+
+// listCredentials 获取用户的安全密钥列表
+func (r *restHandler) listCredentials(c *gin.Context) {
+	// token内省
+	visitor, err := util.Verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	credentials, err := r.webAuthn.ListCredentials(c, &visitor, c.Param("user_id"))
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	entries := make([]map[string]interface{}, 0, len(credentials))
+	for i := range credentials {
+		entries = append(entries, credentialInfo(&credentials[i]))
+	}
+	rest.ReplyOK(c, http.StatusOK, map[string]interface{}{
+		"entries":     entries,
+		"total_count": len(entries),
+	})
+}
+
+// deleteCredential 删除用户的指定安全密钥
+func (r *restHandler) deleteCredential(c *gin.Context) {
+	// token内省
+	visitor, err := util.Verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	if err = r.webAuthn.DeleteCredential(c, &visitor, c.Param("user_id"), c.Param("credential_id")); err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusNoContent, nil)
+}
+
+// revokeAll 管理员吊销用户的所有安全密钥
+func (r *restHandler) revokeAll(c *gin.Context) {
+	// token内省
+	visitor, err := util.Verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	if err = r.webAuthn.RevokeAll(c, &visitor, c.Param("user_id")); err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusNoContent, nil)
+}
+
+// credentialDescriptors 生成凭据描述列表
+func credentialDescriptors(ids []string) []map[string]interface{} {
+	descriptors := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		descriptors = append(descriptors, map[string]interface{}{"type": "public-key", "id": id})
+	}
+	return descriptors
+}
+
+// credentialInfo 安全密钥信息，不返回公钥
+func credentialInfo(credential *interfaces.WebAuthnCredential) map[string]interface{} {
+	return map[string]interface{}{
+		"id":              credential.ID,
+		"name":            credential.Name,
+		"aaguid":          credential.AAGUID,
+		"attestation_fmt": credential.AttestationFmt,
+		"sign_count":      credential.SignCount,
+		"create_time":     credential.CreateTime,
+		"last_used_time":  credential.LastUsedTime,
+	}
+}
@@ -0,0 +1,303 @@
+// Package webauthn 协议层
+package webauthn
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert"
+	jsoniter "github.com/json-iterator/go"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/xeipuuv/gojsonschema"
+	"go.uber.org/mock/gomock"
+
+	"Authentication/common"
+	webauthnschema "Authentication/driveradapters/jsonschema/webauthn_schema"
+	"Authentication/interfaces"
+	"Authentication/interfaces/mock"
+)
+
+const (
+	registrationOptionsURL = "/api/authentication/v1/webauthn/registration/options"
+	registrationURL        = "/api/authentication/v1/webauthn/registration"
+	assertionOptionsURL    = "/api/authentication/v1/webauthn/assertion/options"
+	credentialsURL         = "/api/authentication/v1/webauthn/users/dfc9b098-dac4-11ee-b50a-028586548cf7/credentials"
+)
+
+func setGinMode() func() {
+	old := gin.Mode()
+	gin.SetMode(gin.TestMode)
+	return func() {
+		gin.SetMode(old)
+	}
+}
+
+func newWebAuthnHandler(webAuthn interfaces.LogicsWebAuthn, hydra interfaces.Hydra) *restHandler {
+	registrationSchema, _ := gojsonschema.NewSchema(gojsonschema.NewStringLoader(webauthnschema.RegistrationSchemaStr))
+	return &restHandler{
+		webAuthn:           webAuthn,
+		hydra:              hydra,
+		registrationSchema: registrationSchema,
+	}
+}
+
+func TestBeginRegistration(t *testing.T) {
+	Convey("TestBeginRegistration", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		webAuthn := mock.NewMockLogicsWebAuthn(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newWebAuthnHandler(webAuthn, hydra)
+		handler.RegisterPublic(engine)
+
+		introspectInfo := interfaces.TokenIntrospectInfo{Active: true, VisitorID: "dfc9b098-dac4-11ee-b50a-028586548cf7"}
+
+		Convey("token过期", func() {
+			introspectInfo.Active = false
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			req := httptest.NewRequest("POST", registrationOptionsURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusUnauthorized)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("success", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			webAuthn.EXPECT().BeginRegistration(gomock.Any(), gomock.Any()).Return(&interfaces.WebAuthnRegistrationOptions{
+				ChallengeID:          "challenge1",
+				Challenge:            "Y2hhbGxlbmdl",
+				RPID:                 "anyshare.example.com",
+				RPName:               "AnyShare",
+				UserHandle:           "dXNlcg",
+				UserName:             "user1",
+				Algorithms:           []int{-7},
+				Attestation:          "none",
+				ExcludeCredentialIDs: []string{"cred1"},
+				Timeout:              300000,
+			}, nil)
+			req := httptest.NewRequest("POST", registrationOptionsURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusOK)
+
+			respBody, _ := io.ReadAll(result.Body)
+			var body map[string]interface{}
+			_ = jsoniter.Unmarshal(respBody, &body)
+			assert.Equal(t, body["challenge_id"], "challenge1")
+			publicKey := body["public_key"].(map[string]interface{})
+			assert.Equal(t, publicKey["challenge"], "Y2hhbGxlbmdl")
+			assert.Equal(t, publicKey["rp"], map[string]interface{}{"id": "anyshare.example.com", "name": "AnyShare"})
+			assert.Equal(t, publicKey["pubKeyCredParams"], []interface{}{map[string]interface{}{"type": "public-key", "alg": float64(-7)}})
+			assert.Equal(t, publicKey["excludeCredentials"], []interface{}{map[string]interface{}{"type": "public-key", "id": "cred1"}})
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
+
+func TestFinishRegistration(t *testing.T) {
+	Convey("TestFinishRegistration", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		webAuthn := mock.NewMockLogicsWebAuthn(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newWebAuthnHandler(webAuthn, hydra)
+		handler.RegisterPublic(engine)
+
+		introspectInfo := interfaces.TokenIntrospectInfo{Active: true, VisitorID: "dfc9b098-dac4-11ee-b50a-028586548cf7"}
+		reqBody := []byte(`{"challenge_id": "challenge1", "name": "key1", "id": "cred1", "client_data_json": "e30", "attestation_object": "oA"}`)
+
+		Convey("参数错误", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			req := httptest.NewRequest("POST", registrationURL, bytes.NewReader([]byte(`{"challenge_id": "challenge1"}`)))
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusBadRequest)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("逻辑层失败", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			webAuthn.EXPECT().FinishRegistration(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("test"))
+			req := httptest.NewRequest("POST", registrationURL, bytes.NewReader(reqBody))
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusInternalServerError)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("success", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			webAuthn.EXPECT().FinishRegistration(gomock.Any(), gomock.Any(), &interfaces.WebAuthnRegistrationReq{
+				ChallengeID:       "challenge1",
+				Name:              "key1",
+				CredentialID:      "cred1",
+				ClientDataJSON:    "e30",
+				AttestationObject: "oA",
+			}).Return(&interfaces.WebAuthnCredential{ID: "cred1", Name: "key1", PublicKey: []byte{1}}, nil)
+			req := httptest.NewRequest("POST", registrationURL, bytes.NewReader(reqBody))
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusCreated)
+
+			respBody, _ := io.ReadAll(result.Body)
+			var body map[string]interface{}
+			_ = jsoniter.Unmarshal(respBody, &body)
+			assert.Equal(t, body["id"], "cred1")
+			assert.Equal(t, body["name"], "key1")
+			_, ok := body["public_key"]
+			assert.Equal(t, ok, false)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
+
+func TestBeginAssertion(t *testing.T) {
+	Convey("TestBeginAssertion", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		webAuthn := mock.NewMockLogicsWebAuthn(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newWebAuthnHandler(webAuthn, hydra)
+		handler.RegisterPublic(engine)
+
+		Convey("success", func() {
+			webAuthn.EXPECT().BeginAssertion(gomock.Any(), gomock.Any()).Return(&interfaces.WebAuthnAssertionOptions{
+				ChallengeID: "challenge1",
+				Challenge:   "Y2hhbGxlbmdl",
+				RPID:        "anyshare.example.com",
+				Timeout:     300000,
+			}, nil)
+			req := httptest.NewRequest("POST", assertionOptionsURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusOK)
+
+			respBody, _ := io.ReadAll(result.Body)
+			var body map[string]interface{}
+			_ = jsoniter.Unmarshal(respBody, &body)
+			assert.Equal(t, body["challenge_id"], "challenge1")
+			publicKey := body["public_key"].(map[string]interface{})
+			assert.Equal(t, publicKey["rpId"], "anyshare.example.com")
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
+
+func TestCredentials(t *testing.T) {
+	Convey("TestCredentials", t, func() {
+		test := setGinMode()
+		defer test()
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		common.InitARTrace("authentication")
+
+		webAuthn := mock.NewMockLogicsWebAuthn(ctrl)
+		hydra := mock.NewMockHydra(ctrl)
+		handler := newWebAuthnHandler(webAuthn, hydra)
+		handler.RegisterPublic(engine)
+
+		introspectInfo := interfaces.TokenIntrospectInfo{Active: true, VisitorID: "dfc9b098-dac4-11ee-b50a-028586548cf7"}
+
+		Convey("list", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			webAuthn.EXPECT().ListCredentials(gomock.Any(), gomock.Any(), "dfc9b098-dac4-11ee-b50a-028586548cf7").
+				Return([]interfaces.WebAuthnCredential{{ID: "cred1"}, {ID: "cred2"}}, nil)
+			req := httptest.NewRequest("GET", credentialsURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusOK)
+
+			respBody, _ := io.ReadAll(result.Body)
+			var body map[string]interface{}
+			_ = jsoniter.Unmarshal(respBody, &body)
+			assert.Equal(t, body["total_count"], float64(2))
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("delete", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			webAuthn.EXPECT().DeleteCredential(gomock.Any(), gomock.Any(), "dfc9b098-dac4-11ee-b50a-028586548cf7", "cred1").Return(nil)
+			req := httptest.NewRequest("DELETE", credentialsURL+"/cred1", http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusNoContent)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+
+		Convey("revoke all", func() {
+			hydra.EXPECT().Introspect(gomock.Any()).Return(introspectInfo, nil)
+			webAuthn.EXPECT().RevokeAll(gomock.Any(), gomock.Any(), "dfc9b098-dac4-11ee-b50a-028586548cf7").Return(nil)
+			req := httptest.NewRequest("DELETE", credentialsURL, http.NoBody)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			result := w.Result()
+			assert.Equal(t, result.StatusCode, http.StatusNoContent)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
@@ -216,3 +216,275 @@ type DBUnorderedOutbox interface {
 	// RestartUnorderedOutboxInfo 重置无序outbox信息状态
 	RestartUnorderedOutboxInfo(updatedTime int64) (err error)
 }
+
+// TOTPStatus 动态口令绑定状态
+type TOTPStatus int
+
+const (
+	_ TOTPStatus = iota
+	// TOTPPending 已生成密钥，待确认
+	TOTPPending
+	// TOTPEnabled 已启用
+	TOTPEnabled
+)
+
+// TOTPInfo 用户动态口令信息
+type TOTPInfo struct {
+	UserID       string     // 用户唯一标识
+	Secret       string     // 动态口令密钥，BASE32编码
+	Status       TOTPStatus // 绑定状态
+	LastTimeStep int64      // 最近一次校验通过的时间步长
+	FailCount    int        // 连续校验失败次数
+	LastFailTime int64      // 最近一次校验失败时间
+	CreateTime   int64      // 创建时间
+	UpdateTime   int64      // 更新时间
+}
+
+// DBTOTP 数据访问层动态口令
+type DBTOTP interface {
+	// GetByUserID 获取用户动态口令信息，不存在时返回nil
+	GetByUserID(ctx context.Context, userID string) (*TOTPInfo, error)
+
+	// SavePending 保存待确认的动态口令密钥，覆盖用户已有的记录并清空恢复码
+	SavePending(ctx context.Context, info *TOTPInfo) error
+
+	// Enable 启用动态口令并写入恢复码
+	Enable(ctx context.Context, userID string, timeStep int64, codeHashes []string) error
+
+	// UpdateLastTimeStep 更新最近一次校验通过的时间步长，并清空失败次数
+	// 时间步长未超过已使用的时间步长时返回false
+	UpdateLastTimeStep(ctx context.Context, userID string, timeStep int64) (bool, error)
+
+	// AddFailCount 校验前计入一次失败，失败次数达到上限且仍在锁定时间内时不计入并返回false
+	AddFailCount(ctx context.Context, userID string, now int64, maxFailCount int, lockTime int64) (ok bool, err error)
+
+	// UpdateFailInfo 更新校验失败信息
+	UpdateFailInfo(ctx context.Context, userID string, failCount int, lastFailTime int64) error
+
+	// ReplaceRecoveryCodes 替换用户的恢复码
+	ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error
+
+	// UseRecoveryCode 使用恢复码，恢复码不存在或已使用时返回false
+	UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error)
+
+	// CountRecoveryCodes 获取用户剩余恢复码数量
+	CountRecoveryCodes(ctx context.Context, userID string) (int, error)
+
+	// DeleteByUserID 删除用户动态口令及恢复码
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+// WebAuthnCeremonyType WebAuthn仪式类型
+type WebAuthnCeremonyType int
+
+const (
+	_ WebAuthnCeremonyType = iota
+	// WebAuthnRegistration 注册
+	WebAuthnRegistration
+	// WebAuthnAssertion 认证
+	WebAuthnAssertion
+)
+
+// WebAuthnChallenge WebAuthn挑战信息
+type WebAuthnChallenge struct {
+	ID         string               // 挑战唯一标识
+	Challenge  string               // 挑战值，base64url编码
+	Type       WebAuthnCeremonyType // 仪式类型
+	UserID     string               // 注册时为当前用户，认证时为空
+	CreateTime int64                // 创建时间
+}
+
+// WebAuthnCredential 用户WebAuthn凭据信息
+type WebAuthnCredential struct {
+	ID             string // 凭据ID，base64url编码
+	UserID         string // 用户唯一标识
+	Name           string // 凭据名称
+	PublicKey      []byte // COSE格式公钥
+	Algorithm      int    // COSE签名算法
+	SignCount      uint32 // 签名计数器
+	AAGUID         string // 认证器型号标识，十六进制编码
+	AttestationFmt string // 注册时的证明格式
+	CreateTime     int64  // 创建时间
+	LastUsedTime   int64  // 最近一次使用时间
+}
+
+// DBWebAuthn 数据访问层WebAuthn
+type DBWebAuthn interface {
+	// CreateChallenge 保存挑战，并清理创建时间早于expireBefore的挑战
+	CreateChallenge(ctx context.Context, challenge *WebAuthnChallenge, expireBefore int64) error
+
+	// ConsumeChallenge 获取并删除挑战，保证挑战只能使用一次，不存在时返回nil
+	ConsumeChallenge(ctx context.Context, id string) (*WebAuthnChallenge, error)
+
+	// AddCredential 添加凭据
+	AddCredential(ctx context.Context, credential *WebAuthnCredential) error
+
+	// GetCredential 获取凭据，不存在时返回nil
+	GetCredential(ctx context.Context, credentialID string) (*WebAuthnCredential, error)
+
+	// GetCredentialsByUserID 获取用户的所有凭据
+	GetCredentialsByUserID(ctx context.Context, userID string) ([]WebAuthnCredential, error)
+
+	// UpdateSignCount 更新签名计数器及使用时间，计数器已被并发修改时返回false
+	UpdateSignCount(ctx context.Context, credentialID string, oldCount, newCount uint32, lastUsedTime int64) (bool, error)
+
+	// DeleteCredential 删除用户的指定凭据，凭据不存在时返回false
+	DeleteCredential(ctx context.Context, userID, credentialID string) (bool, error)
+
+	// DeleteCredentialsByUserID 删除用户的所有凭据
+	DeleteCredentialsByUserID(ctx context.Context, userID string) error
+}
+
+// OIDC账户匹配方式
+const (
+	// OIDCMatchByAccount 按账户名匹配
+	OIDCMatchByAccount = "account"
+	// OIDCMatchByEmail 按邮箱匹配
+	OIDCMatchByEmail = "email"
+	// OIDCMatchByThirdID 按第三方用户ID匹配，第三方用户ID为“签发者|声明值”
+	OIDCMatchByThirdID = "third_id"
+)
+
+// OIDCMappingRule OIDC声明与账户的映射规则
+type OIDCMappingRule struct {
+	Claim   string `json:"claim"`    // ID令牌中的声明名称
+	MatchBy string `json:"match_by"` // 账户匹配方式
+}
+
+// OIDCProvider 上游OIDC身份提供方配置
+type OIDCProvider struct {
+	ID           string            // 唯一标识
+	Name         string            // 名称
+	Issuer       string            // 颁发者，用于服务发现及ID令牌校验
+	ClientID     string            // 客户端ID
+	ClientSecret string            // 客户端密钥
+	Scopes       []string          // 申请的权限范围
+	RedirectURI  string            // 授权回调地址
+	MappingRules []OIDCMappingRule // 账户映射规则，按顺序匹配
+	JITProvision bool              // 未匹配到账户时是否自动创建账户
+	Enabled      bool              // 是否启用
+	CreateTime   int64             // 创建时间
+	UpdateTime   int64             // 更新时间
+}
+
+// OIDCAuthState OIDC授权请求状态
+type OIDCAuthState struct {
+	State        string // 状态值
+	ProviderID   string // 身份提供方唯一标识
+	Nonce        string // ID令牌防重放随机值
+	CodeVerifier string // PKCE校验值
+	CreateTime   int64  // 创建时间
+}
+
+// DBOIDC 数据访问层OIDC身份提供方
+type DBOIDC interface {
+	// AddProvider 添加身份提供方
+	AddProvider(ctx context.Context, provider *OIDCProvider) error
+
+	// UpdateProvider 更新身份提供方，不存在时返回false
+	UpdateProvider(ctx context.Context, provider *OIDCProvider) (bool, error)
+
+	// DeleteProvider 删除身份提供方，不存在时返回false
+	DeleteProvider(ctx context.Context, id string) (bool, error)
+
+	// GetProvider 获取身份提供方，不存在时返回nil
+	GetProvider(ctx context.Context, id string) (*OIDCProvider, error)
+
+	// GetProviders 获取所有身份提供方
+	GetProviders(ctx context.Context) ([]OIDCProvider, error)
+
+	// CreateState 保存授权请求状态，并清理创建时间早于expireBefore的状态
+	CreateState(ctx context.Context, state *OIDCAuthState, expireBefore int64) error
+
+	// ConsumeState 获取并删除授权请求状态，保证状态只能使用一次，不存在时返回nil
+	ConsumeState(ctx context.Context, state string) (*OIDCAuthState, error)
+}
+
+// SAMLNameID 映射规则中代表断言主体NameID的属性名称
+const SAMLNameID = "NameID"
+
+// SAMLMappingRule SAML断言属性与账户的映射规则，匹配方式与OIDC一致
+type SAMLMappingRule struct {
+	Attribute string `json:"attribute"` // 断言中的属性名称或友好名称，NameID代表断言主体
+	MatchBy   string `json:"match_by"`  // 账户匹配方式
+}
+
+// SAMLProvider SAML身份提供方配置
+type SAMLProvider struct {
+	ID            string            // 唯一标识
+	Name          string            // 名称
+	EntityID      string            // 身份提供方标识，取自元数据
+	Metadata      string            // 身份提供方元数据
+	MappingRules  []SAMLMappingRule // 账户映射规则，按顺序匹配
+	NameAttribute string            // 自动创建账户时作为显示名的属性
+	JITProvision  bool              // 未匹配到账户时是否自动创建账户
+	Enabled       bool              // 是否启用
+	CreateTime    int64             // 创建时间
+	UpdateTime    int64             // 更新时间
+}
+
+// SAMLAuthState SAML认证请求状态
+type SAMLAuthState struct {
+	State      string // 状态值，作为RelayState传递
+	ProviderID string // 身份提供方唯一标识
+	RequestID  string // AuthnRequest的ID，用于校验响应的InResponseTo
+	UserID     string // 断言校验通过后映射的用户，为空表示尚未完成断言校验
+	CreateTime int64  // 创建时间
+}
+
+// SAMLSession 通过SAML身份提供方登录的会话，用于单点登出
+type SAMLSession struct {
+	UserID          string // 用户ID
+	ProviderID      string // 身份提供方唯一标识
+	NameID          string // 断言主体
+	NameIDFormat    string // 断言主体格式
+	NameQualifier   string // 断言主体的身份提供方限定名
+	SPNameQualifier string // 断言主体的服务提供方限定名
+	SessionIndex    string // 身份提供方会话索引
+	CreateTime      int64  // 创建时间
+}
+
+// DBSAML 数据访问层SAML身份提供方
+type DBSAML interface {
+	// AddProvider 添加身份提供方
+	AddProvider(ctx context.Context, provider *SAMLProvider) error
+
+	// UpdateProvider 更新身份提供方，不存在时返回false
+	UpdateProvider(ctx context.Context, provider *SAMLProvider) (bool, error)
+
+	// DeleteProvider 删除身份提供方，不存在时返回false
+	DeleteProvider(ctx context.Context, id string) (bool, error)
+
+	// GetProvider 获取身份提供方，不存在时返回nil
+	GetProvider(ctx context.Context, id string) (*SAMLProvider, error)
+
+	// GetProviderByEntityID 根据身份提供方标识获取身份提供方，不存在时返回nil
+	GetProviderByEntityID(ctx context.Context, entityID string) (*SAMLProvider, error)
+
+	// GetProviders 获取所有身份提供方
+	GetProviders(ctx context.Context) ([]SAMLProvider, error)
+
+	// CreateState 保存认证请求状态，并清理创建时间早于expireBefore的状态
+	CreateState(ctx context.Context, state *SAMLAuthState, expireBefore int64) error
+
+	// GetState 获取认证请求状态，不存在时返回nil
+	GetState(ctx context.Context, state string) (*SAMLAuthState, error)
+
+	// BindStateUser 断言校验通过后绑定用户，状态不存在或已绑定时返回false，保证断言只能使用一次
+	BindStateUser(ctx context.Context, state, userID string) (bool, error)
+
+	// ConsumeState 获取并删除认证请求状态，保证状态只能使用一次，不存在时返回nil
+	ConsumeState(ctx context.Context, state string) (*SAMLAuthState, error)
+
+	// AddSession 保存登录会话，并清理创建时间早于expireBefore的会话
+	AddSession(ctx context.Context, session *SAMLSession, expireBefore int64) error
+
+	// GetSessionsByUserID 获取用户的登录会话，按创建时间倒序
+	GetSessionsByUserID(ctx context.Context, userID string) ([]SAMLSession, error)
+
+	// GetSessionsByNameID 获取身份提供方断言主体对应的登录会话
+	GetSessionsByNameID(ctx context.Context, providerID, nameID string) ([]SAMLSession, error)
+
+	// DeleteSessions 删除用户在身份提供方的登录会话
+	DeleteSessions(ctx context.Context, userID, providerID string) error
+}
@@ -35,10 +35,24 @@ type ThirdPartyCredential struct {
 	Params interface{}
 }
 
+// LoginPolicyInfo 登录策略检查信息
+type LoginPolicyInfo struct {
+	UserID      string
+	Priority    int
+	Enabled     bool
+	ClientID    string
+	IP          string
+	AccountType string
+	ClientType  string
+	Udid        string
+}
+
 // DnEacp eacp接口
 type DnEacp interface {
 	// ThirdPartyAuthentication 第三方认证
 	ThirdPartyAuthentication(ctx context.Context, visitor *Visitor, req *ThirdPartyAuthInfo) (*LoginInfo, error)
+	// CheckLoginPolicy 登录IP、设备禁用/擦除、设备绑定、客户端类型等登录策略检查
+	CheckLoginPolicy(ctx context.Context, visitor *Visitor, req *LoginPolicyInfo) error
 }
 
 // RegisterInfo 客户端注册信息
@@ -192,6 +206,7 @@ type UserBaseInfo struct {
 	PwdErrCnt      int
 	PwdErrLastTime int64
 	DisableStatus  bool
+	Priority       int
 	LDAPType       LDAPServerType
 	DomainPath     string
 }
@@ -214,6 +229,16 @@ type DnUserManagement interface {
 	GetAnonymityInfoByID(ctx context.Context, visitor *Visitor, anonymityID string) (verifyMobile bool, err error)
 	// GetUserInfo 获取实名账户信息
 	GetUserInfo(ctx context.Context, visitor *Visitor, userID string) (info *UserBaseInfo, err error)
+	// UserMatch 根据邮箱或第三方用户ID匹配账户，field为email或third_id
+	UserMatch(ctx context.Context, visitor *Visitor, field, value string) (bool, UserBaseInfo, error)
+}
+
+// NewUserInfo 新建账户信息
+type NewUserInfo struct {
+	Account string // 账户名
+	Name    string // 显示名
+	Email   string // 邮箱
+	ThirdID string // 第三方用户ID
 }
 
 // AppInfo 应用账户信息
@@ -241,6 +266,9 @@ type DnShareMgnt interface {
 
 	// 发送匿名账户短信验证码
 	UsrmSendAnonymousSMSVCode(ctx context.Context, phoneNumber, vcode string) error
+
+	// 新建第三方账户，返回账户ID
+	UsrmAddUser(ctx context.Context, info *NewUserInfo) (string, error)
 }
 
 // DnEacpLog 日志处理接口
@@ -67,6 +67,9 @@ const (
 
 	// DualAuthOTP 双因子认证--OTP
 	DualAuthOTP
+
+	// DualAuthWebAuthn 双因子认证--安全密钥
+	DualAuthWebAuthn
 )
 
 // ClientLoginReq 用户登录请求信息
@@ -82,6 +85,9 @@ type ClientLoginOption struct {
 	UUID      string       `json:"uuid"`
 	VCode     string       `json:"vcode"`
 	VCodeType NCTVcodeType `json:"vcodeType"`
+
+	// WebAuthn 安全密钥认证响应，仅VCodeType为DualAuthWebAuthn时使用
+	WebAuthn *WebAuthnAssertionResp `json:"webauthn"`
 }
 
 // AccessTokenReq 获取访问令牌时的请求信息结构体
@@ -106,6 +112,15 @@ type Login interface {
 	// SingleSignOn 单点登录
 	SingleSignOn(ctx context.Context, visitor *Visitor, req *SSOLoginInfo) (*TokenInfo, error)
 
+	// WebAuthnSignOn 安全密钥免密登录
+	WebAuthnSignOn(ctx context.Context, visitor *Visitor, req *WebAuthnLoginInfo) (*TokenInfo, error)
+
+	// OIDCSignOn 上游OIDC身份提供方登录
+	OIDCSignOn(ctx context.Context, visitor *Visitor, req *OIDCLoginInfo) (*TokenInfo, error)
+
+	// SAMLSignOn SAML身份提供方登录
+	SAMLSignOn(ctx context.Context, visitor *Visitor, req *SAMLLoginInfo) (*TokenInfo, error)
+
 	// PwdAuth 账户密码校验
 	PwdAuth(ctx context.Context, visitor *Visitor, req *AccessTokenReq) (*TokenInfo, error)
 
@@ -280,3 +295,240 @@ type LogicsAudit interface {
 type LogicsAuditLogAsyncTask interface {
 	Log(topic string, message interface{}) (err error)
 }
+
+// TOTPEnrollment 动态口令绑定信息
+type TOTPEnrollment struct {
+	Secret          string // 动态口令密钥，BASE32编码
+	ProvisioningURI string // otpauth协议地址，用于生成二维码
+}
+
+// TOTPStatusInfo 动态口令状态信息
+type TOTPStatusInfo struct {
+	Enabled           bool // 是否已启用
+	RecoveryCodeCount int  // 剩余恢复码数量
+}
+
+// LogicsTOTP 逻辑层动态口令
+type LogicsTOTP interface {
+	// GetStatus 获取当前用户动态口令状态
+	GetStatus(ctx context.Context, visitor *Visitor) (*TOTPStatusInfo, error)
+
+	// Enroll 生成动态口令密钥，确认前不生效
+	Enroll(ctx context.Context, visitor *Visitor) (*TOTPEnrollment, error)
+
+	// ConfirmEnrollment 校验动态口令并启用，返回恢复码
+	ConfirmEnrollment(ctx context.Context, visitor *Visitor, code string) (recoveryCodes []string, err error)
+
+	// RegenerateRecoveryCodes 校验动态口令并重新生成恢复码
+	RegenerateRecoveryCodes(ctx context.Context, visitor *Visitor, code string) (recoveryCodes []string, err error)
+
+	// Disable 校验动态口令并解除绑定
+	Disable(ctx context.Context, visitor *Visitor, code string) error
+
+	// Reset 管理员重置用户动态口令
+	Reset(ctx context.Context, visitor *Visitor, userID string) error
+
+	// Validate 登录时校验动态口令或恢复码
+	Validate(ctx context.Context, visitor *Visitor, userID, code string) error
+}
+
+// WebAuthnRegistrationOptions 安全密钥注册选项
+type WebAuthnRegistrationOptions struct {
+	ChallengeID          string   // 挑战唯一标识，完成注册时回传
+	Challenge            string   // 挑战值，base64url编码
+	RPID                 string   // 依赖方标识
+	RPName               string   // 依赖方名称
+	UserHandle           string   // 用户句柄，base64url编码
+	UserName             string   // 用户账户名
+	Algorithms           []int    // 支持的COSE签名算法
+	Attestation          string   // 证明传递偏好
+	ExcludeCredentialIDs []string // 用户已注册的凭据ID，避免重复注册
+	Timeout              int64    // 超时时间，单位为毫秒
+}
+
+// WebAuthnRegistrationReq 安全密钥注册请求，二进制字段均为base64url编码
+type WebAuthnRegistrationReq struct {
+	ChallengeID       string `json:"challenge_id"`
+	Name              string `json:"name"`
+	CredentialID      string `json:"id"`
+	ClientDataJSON    string `json:"client_data_json"`
+	AttestationObject string `json:"attestation_object"`
+}
+
+// WebAuthnAssertionOptions 安全密钥认证选项
+type WebAuthnAssertionOptions struct {
+	ChallengeID string // 挑战唯一标识，认证时回传
+	Challenge   string // 挑战值，base64url编码
+	RPID        string // 依赖方标识
+	Timeout     int64  // 超时时间，单位为毫秒
+}
+
+// WebAuthnAssertionResp 安全密钥认证响应，二进制字段均为base64url编码
+type WebAuthnAssertionResp struct {
+	ChallengeID       string `json:"challenge_id"`
+	CredentialID      string `json:"id"`
+	ClientDataJSON    string `json:"client_data_json"`
+	AuthenticatorData string `json:"authenticator_data"`
+	Signature         string `json:"signature"`
+	UserHandle        string `json:"user_handle"`
+}
+
+// WebAuthnLoginInfo 安全密钥免密登录请求信息
+type WebAuthnLoginInfo struct {
+	ClientID     string
+	RedirectURI  string
+	ResponseType string
+	Scope        string
+	Udids        []string
+	IP           string
+	Assertion    WebAuthnAssertionResp
+}
+
+// LogicsWebAuthn 逻辑层安全密钥
+type LogicsWebAuthn interface {
+	// BeginRegistration 生成当前用户的安全密钥注册选项
+	BeginRegistration(ctx context.Context, visitor *Visitor) (*WebAuthnRegistrationOptions, error)
+
+	// FinishRegistration 校验注册响应并保存凭据
+	FinishRegistration(ctx context.Context, visitor *Visitor, req *WebAuthnRegistrationReq) (*WebAuthnCredential, error)
+
+	// ListCredentials 获取用户的安全密钥列表，仅本人或管理员可用
+	ListCredentials(ctx context.Context, visitor *Visitor, userID string) ([]WebAuthnCredential, error)
+
+	// DeleteCredential 删除用户的指定安全密钥，仅本人或管理员可用
+	DeleteCredential(ctx context.Context, visitor *Visitor, userID, credentialID string) error
+
+	// RevokeAll 管理员吊销用户的所有安全密钥
+	RevokeAll(ctx context.Context, visitor *Visitor, userID string) error
+
+	// BeginAssertion 生成安全密钥认证选项
+	BeginAssertion(ctx context.Context, visitor *Visitor) (*WebAuthnAssertionOptions, error)
+
+	// Authenticate 作为首要认证因子校验认证响应，要求用户验证，返回凭据所属用户
+	Authenticate(ctx context.Context, visitor *Visitor, resp *WebAuthnAssertionResp) (userID string, err error)
+
+	// Validate 作为双因子认证校验认证响应，凭据必须属于指定用户
+	Validate(ctx context.Context, visitor *Visitor, userID string, resp *WebAuthnAssertionResp) error
+}
+
+// OIDCLoginInfo 上游OIDC身份提供方登录请求信息
+type OIDCLoginInfo struct {
+	ClientID     string
+	RedirectURI  string
+	ResponseType string
+	Scope        string
+	Udids        []string
+	IP           string
+	State        string // 身份提供方回调返回的状态值
+	Code         string // 身份提供方回调返回的授权码
+}
+
+// LogicsOIDC 逻辑层上游OIDC身份提供方
+type LogicsOIDC interface {
+	// AddProvider 管理员添加身份提供方，返回身份提供方唯一标识
+	AddProvider(ctx context.Context, visitor *Visitor, provider *OIDCProvider) (string, error)
+
+	// UpdateProvider 管理员更新身份提供方，客户端密钥为空时保持不变
+	UpdateProvider(ctx context.Context, visitor *Visitor, provider *OIDCProvider) error
+
+	// DeleteProvider 管理员删除身份提供方
+	DeleteProvider(ctx context.Context, visitor *Visitor, id string) error
+
+	// GetProvider 管理员获取身份提供方
+	GetProvider(ctx context.Context, visitor *Visitor, id string) (*OIDCProvider, error)
+
+	// ListProviders 管理员获取所有身份提供方
+	ListProviders(ctx context.Context, visitor *Visitor) ([]OIDCProvider, error)
+
+	// ListEnabledProviders 获取已启用的身份提供方，用于登录页展示
+	ListEnabledProviders(ctx context.Context) ([]OIDCProvider, error)
+
+	// BeginAuth 生成身份提供方授权地址
+	BeginAuth(ctx context.Context, visitor *Visitor, providerID string) (authURL string, err error)
+
+	// Authenticate 使用授权码完成认证，返回映射或自动创建的账户
+	Authenticate(ctx context.Context, visitor *Visitor, state, code string) (userID string, err error)
+}
+
+// SAML报文发送方式
+const (
+	// SAMLBindingRedirect HTTP-Redirect绑定，浏览器跳转到URL
+	SAMLBindingRedirect = "redirect"
+	// SAMLBindingPost HTTP-POST绑定，浏览器以表单提交Params到URL
+	SAMLBindingPost = "post"
+)
+
+// SAMLRedirect 需要浏览器发送到对端的SAML报文
+type SAMLRedirect struct {
+	Binding string            // 绑定方式
+	URL     string            // redirect时为完整跳转地址，post时为表单提交地址
+	Params  map[string]string // post时的表单参数
+}
+
+// SAMLMessage 通过SAML绑定接收的报文
+type SAMLMessage struct {
+	Redirect     bool   // 是否通过HTTP-Redirect绑定接收
+	RawQuery     string // HTTP-Redirect绑定的原始查询参数，用于校验签名
+	SAMLRequest  string // 请求报文
+	SAMLResponse string // 响应报文
+	RelayState   string // 状态值
+}
+
+// SAMLLoginInfo SAML身份提供方登录请求信息
+type SAMLLoginInfo struct {
+	ClientID     string
+	RedirectURI  string
+	ResponseType string
+	Scope        string
+	Udids        []string
+	IP           string
+	State        string // 断言校验通过后跳转登录页携带的状态值
+}
+
+// LogicsSAML 逻辑层SAML服务提供方
+type LogicsSAML interface {
+	// SPMetadata 获取服务提供方元数据
+	SPMetadata(ctx context.Context) ([]byte, error)
+
+	// AddProvider 管理员导入身份提供方元数据，返回身份提供方唯一标识
+	AddProvider(ctx context.Context, visitor *Visitor, provider *SAMLProvider) (string, error)
+
+	// UpdateProvider 管理员更新身份提供方
+	UpdateProvider(ctx context.Context, visitor *Visitor, provider *SAMLProvider) error
+
+	// DeleteProvider 管理员删除身份提供方
+	DeleteProvider(ctx context.Context, visitor *Visitor, id string) error
+
+	// GetProvider 管理员获取身份提供方
+	GetProvider(ctx context.Context, visitor *Visitor, id string) (*SAMLProvider, error)
+
+	// ListProviders 管理员获取所有身份提供方
+	ListProviders(ctx context.Context, visitor *Visitor) ([]SAMLProvider, error)
+
+	// ListEnabledProviders 获取已启用的身份提供方，用于登录页展示
+	ListEnabledProviders(ctx context.Context) ([]SAMLProvider, error)
+
+	// BeginAuth 生成签名的AuthnRequest
+	BeginAuth(ctx context.Context, visitor *Visitor, providerID string) (*SAMLRedirect, error)
+
+	// ConsumeResponse 断言消费服务，校验身份提供方响应并映射账户，返回携带状态值的登录页地址
+	ConsumeResponse(ctx context.Context, visitor *Visitor, msg *SAMLMessage) (redirectURL string, err error)
+
+	// Authenticate 使用断言校验通过的状态值完成认证，返回映射或自动创建的账户
+	Authenticate(ctx context.Context, visitor *Visitor, state string) (userID string, err error)
+
+	// BeginLogout 服务提供方发起单点登出，用户没有SAML登录会话时返回nil
+	BeginLogout(ctx context.Context, visitor *Visitor) (*SAMLRedirect, error)
+
+	// HandleLogout 处理身份提供方发送的LogoutRequest或LogoutResponse，返回需要浏览器跳转的报文或页面，无需跳转时返回nil
+	HandleLogout(ctx context.Context, visitor *Visitor, msg *SAMLMessage) (*SAMLRedirect, error)
+}
+
+// LogicsLDAP 逻辑层LDAP/AD直连域认证
+type LogicsLDAP interface {
+	// Configured 域是否配置了直连目录，未配置时由sharemgnt完成域认证
+	Configured(domainPath string) bool
+
+	// Authenticate 使用服务账户查找域用户后以用户身份绑定校验密码，配置了允许登录的组时校验嵌套组成员关系
+	Authenticate(ctx context.Context, visitor *Visitor, userInfo *UserBaseInfo, password string) error
+}
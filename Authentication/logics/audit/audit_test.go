@@ -11,6 +11,7 @@ import (
 	"go.uber.org/mock/gomock"
 
 	"Authentication/common"
+	"Authentication/interfaces"
 	"Authentication/interfaces/mock"
 )
 
@@ -78,3 +79,43 @@ func TestLog(t *testing.T) {
 		})
 	})
 }
+
+func TestLogManagement(t *testing.T) {
+	Convey("LogManagement", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		auditlog := mock.NewMockLogicsAudit(ctrl)
+		visitor := &interfaces.Visitor{IP: "1.2.3.4", Mac: "mac", UserAgent: "agent"}
+		info := &ManagementLog{
+			UserID: "user1",
+			Level:  LevelWarn,
+			OpType: OpDelete,
+			ObjID:  "obj1",
+			Msg:    "msg",
+			ExMsg:  "ex_msg",
+		}
+
+		Convey("success", func() {
+			var body map[string]interface{}
+			auditlog.EXPECT().Log(TopicManagementLog, gomock.Any()).DoAndReturn(func(_ string, msg interface{}) error {
+				body = msg.(map[string]interface{})
+				return nil
+			})
+			LogManagement(auditlog, common.NewLogger(), visitor, info)
+			assert.Equal(t, body["user_id"], "user1")
+			assert.Equal(t, body["level"], LevelWarn)
+			assert.Equal(t, body["op_type"], OpDelete)
+			assert.Equal(t, body["obj_id"], "obj1")
+			assert.Equal(t, body["msg"], "msg")
+			assert.Equal(t, body["ex_msg"], "ex_msg")
+			assert.Equal(t, body["ip"], "1.2.3.4")
+			assert.NotEqual(t, body["out_biz_id"], "")
+		})
+
+		Convey("log failed", func() {
+			auditlog.EXPECT().Log(TopicManagementLog, gomock.Any()).Return(errors.New("some error"))
+			LogManagement(auditlog, common.NewLogger(), visitor, info)
+		})
+	})
+}
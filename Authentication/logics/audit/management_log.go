@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"github.com/oklog/ulid/v2"
+
+	"Authentication/common"
+	"Authentication/interfaces"
+)
+
+// TopicManagementLog 管理日志主题
+const TopicManagementLog = "as.audit_log.log_management"
+
+// LogLevel 管理日志级别
+type LogLevel int
+
+// 管理日志级别
+const (
+	LevelInfo LogLevel = 1
+	LevelWarn LogLevel = 2
+)
+
+// ManageOpType 管理日志操作类型
+type ManageOpType int
+
+// 管理日志操作类型
+const (
+	OpCreate ManageOpType = 1
+	OpSet    ManageOpType = 3
+	OpDelete ManageOpType = 4
+)
+
+// ManagementLog 管理日志信息
+type ManagementLog struct {
+	UserID string
+	Level  LogLevel
+	OpType ManageOpType
+	ObjID  string
+	Msg    string
+	ExMsg  string
+}
+
+// LogManagement 通过审计日志模块记录管理日志，失败时仅记录错误
+func LogManagement(a interfaces.LogicsAudit, logger common.Logger, visitor *interfaces.Visitor, info *ManagementLog) {
+	body := make(map[string]interface{})
+	body["user_id"] = info.UserID
+	body["user_type"] = "authenticated_user"
+	body["level"] = info.Level
+	body["date"] = common.Now().UnixNano() / 1e3
+	body["ip"] = visitor.IP
+	body["mac"] = visitor.Mac
+	body["msg"] = info.Msg
+	body["ex_msg"] = info.ExMsg
+	body["user_agent"] = visitor.UserAgent
+	body["op_type"] = info.OpType
+	body["out_biz_id"] = ulid.Make().String()
+	body["obj_id"] = info.ObjID
+
+	if err := a.Log(TopicManagementLog, body); err != nil {
+		logger.Errorf("write management log failed, user: %s, err: %v", info.UserID, err)
+	}
+}
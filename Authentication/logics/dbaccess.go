@@ -32,6 +32,14 @@ var (
 	DBFlowClean interfaces.DBFlowClean
 	// DBUnorderedOutbox 实例
 	DBUnorderedOutbox interfaces.DBUnorderedOutbox
+	// DBTOTP 实例
+	DBTOTP interfaces.DBTOTP
+	// DBWebAuthn 实例
+	DBWebAuthn interfaces.DBWebAuthn
+	// DBOIDC 实例
+	DBOIDC interfaces.DBOIDC
+	// DBSAML 实例
+	DBSAML interfaces.DBSAML
 )
 
 // SetDBSession 设置实例
@@ -92,3 +100,23 @@ func SetDBFlowClean(i interfaces.DBFlowClean) {
 func SetDBUnorderedOutbox(i interfaces.DBUnorderedOutbox) {
 	DBUnorderedOutbox = i
 }
+
+// SetDBTOTP 设置实例
+func SetDBTOTP(i interfaces.DBTOTP) {
+	DBTOTP = i
+}
+
+// SetDBWebAuthn 设置实例
+func SetDBWebAuthn(i interfaces.DBWebAuthn) {
+	DBWebAuthn = i
+}
+
+// SetDBOIDC 设置实例
+func SetDBOIDC(i interfaces.DBOIDC) {
+	DBOIDC = i
+}
+
+// SetDBSAML 设置实例
+func SetDBSAML(i interfaces.DBSAML) {
+	DBSAML = i
+}
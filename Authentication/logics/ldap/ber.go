@@ -0,0 +1,229 @@
+package ldap
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// BER 标签类别及构造位，参考 X.690
+const (
+	classUniversal   = 0x00
+	classApplication = 0x40
+	classContext     = 0x80
+	typeConstructed  = 0x20
+)
+
+// 通用类型标签
+const (
+	tagBoolean     = 0x01
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagEnumerated  = 0x0a
+	tagSequence    = 0x10 | typeConstructed
+	tagSet         = 0x11 | typeConstructed
+)
+
+const (
+	// berMaxDepth 最大嵌套深度，防止恶意数据导致栈溢出
+	berMaxDepth = 32
+	// maxMessageSize 单个LDAP消息的最大长度
+	maxMessageSize = 8 << 20
+)
+
+var (
+	errBERMalformed   = errors.New("malformed ber data")
+	errMessageTooLong = errors.New("ldap message too long")
+)
+
+// packet BER数据项，构造类型保存子项，基本类型保存内容
+type packet struct {
+	tag      byte
+	value    []byte
+	children []*packet
+}
+
+func (p *packet) constructed() bool {
+	return p.tag&typeConstructed != 0
+}
+
+// child 获取第i个子项，不存在时返回nil
+func (p *packet) child(i int) *packet {
+	if i < 0 || i >= len(p.children) {
+		return nil
+	}
+	return p.children[i]
+}
+
+func (p *packet) str() string {
+	return string(p.value)
+}
+
+// integer 解析整数或枚举值，超过32位时返回错误
+func (p *packet) integer() (int, error) {
+	if p.constructed() || len(p.value) == 0 || len(p.value) > 4 {
+		return 0, errBERMalformed
+	}
+	v := int32(int8(p.value[0]))
+	for _, b := range p.value[1:] {
+		v = v<<8 | int32(b)
+	}
+	return int(v), nil
+}
+
+func (p *packet) boolean() bool {
+	return len(p.value) == 1 && p.value[0] != 0
+}
+
+func newSequence(tag byte, children ...*packet) *packet {
+	return &packet{tag: tag | typeConstructed, children: children}
+}
+
+func newOctetString(tag byte, s string) *packet {
+	return &packet{tag: tag, value: []byte(s)}
+}
+
+func newInteger(tag byte, v int) *packet {
+	var buf []byte
+	n := int32(v)
+	for {
+		buf = append([]byte{byte(n)}, buf...)
+		if (n >= -128 && n < 128) || len(buf) == 4 {
+			break
+		}
+		n >>= 8
+	}
+	return &packet{tag: tag, value: buf}
+}
+
+func newBoolean(tag byte, v bool) *packet {
+	if v {
+		return &packet{tag: tag, value: []byte{0xff}}
+	}
+	return &packet{tag: tag, value: []byte{0x00}}
+}
+
+// encode 按照DER长度规则编码
+func (p *packet) encode() []byte {
+	content := p.value
+	if p.constructed() {
+		content = nil
+		for _, c := range p.children {
+			content = append(content, c.encode()...)
+		}
+	}
+
+	out := []byte{p.tag}
+	out = append(out, encodeLength(len(content))...)
+	return append(out, content...)
+}
+
+func encodeLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	var buf []byte
+	for ; n > 0; n >>= 8 {
+		buf = append([]byte{byte(n)}, buf...)
+	}
+	return append([]byte{0x80 | byte(len(buf))}, buf...)
+}
+
+// decodePacket 解析完整的BER数据项，不允许有多余数据
+func decodePacket(data []byte) (*packet, error) {
+	p, rest, err := decodeItem(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errBERMalformed
+	}
+	return p, nil
+}
+
+func decodeItem(data []byte, depth int) (p *packet, rest []byte, err error) {
+	if depth > berMaxDepth || len(data) < 2 {
+		return nil, nil, errBERMalformed
+	}
+
+	// LDAP使用的标签号均小于31，不支持多字节标签
+	tag := data[0]
+	if tag&0x1f == 0x1f {
+		return nil, nil, errBERMalformed
+	}
+	length, n, err := decodeLength(data[1:])
+	if err != nil {
+		return nil, nil, err
+	}
+	data = data[1+n:]
+	if length > len(data) {
+		return nil, nil, errBERMalformed
+	}
+
+	p = &packet{tag: tag}
+	content := data[:length]
+	if !p.constructed() {
+		p.value = content
+		return p, data[length:], nil
+	}
+
+	for len(content) > 0 {
+		var c *packet
+		if c, content, err = decodeItem(content, depth+1); err != nil {
+			return nil, nil, err
+		}
+		p.children = append(p.children, c)
+	}
+	return p, data[length:], nil
+}
+
+// decodeLength 解析长度，返回长度及其占用的字节数，不支持不定长编码
+func decodeLength(data []byte) (length, n int, err error) {
+	if len(data) == 0 {
+		return 0, 0, errBERMalformed
+	}
+	if data[0] < 0x80 {
+		return int(data[0]), 1, nil
+	}
+
+	size := int(data[0] & 0x7f)
+	if size == 0 || size > 4 || len(data) < 1+size {
+		return 0, 0, errBERMalformed
+	}
+	for _, b := range data[1 : 1+size] {
+		length = length<<8 | int(b)
+	}
+	if length < 0 || length > maxMessageSize {
+		return 0, 0, errMessageTooLong
+	}
+	return length, 1 + size, nil
+}
+
+// readPacket 从连接中读取一个完整的LDAP消息
+func readPacket(r *bufio.Reader) (*packet, error) {
+	head := make([]byte, 2, 6)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, err
+	}
+	if head[1] >= 0x80 {
+		size := int(head[1] & 0x7f)
+		if size == 0 || size > 4 {
+			return nil, errBERMalformed
+		}
+		head = head[:2+size]
+		if _, err := io.ReadFull(r, head[2:]); err != nil {
+			return nil, err
+		}
+	}
+
+	length, _, err := decodeLength(head[1:])
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, len(head)+length)
+	copy(data, head)
+	if _, err = io.ReadFull(r, data[len(head):]); err != nil {
+		return nil, err
+	}
+	return decodePacket(data)
+}
@@ -0,0 +1,398 @@
+package ldap
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// 协议操作标签，参考 RFC 4511 4.2 - 4.12
+const (
+	opBindRequest       = classApplication | typeConstructed | 0
+	opBindResponse      = classApplication | typeConstructed | 1
+	opUnbindRequest     = classApplication | 2
+	opSearchRequest     = classApplication | typeConstructed | 3
+	opSearchResultEntry = classApplication | typeConstructed | 4
+	opSearchResultDone  = classApplication | typeConstructed | 5
+	opSearchResultRef   = classApplication | typeConstructed | 19
+	opExtendedRequest   = classApplication | typeConstructed | 23
+	opExtendedResponse  = classApplication | typeConstructed | 24
+	tagControls         = classContext | typeConstructed | 0
+	tagSimpleAuth       = classContext | 0
+	tagExtendedName     = classContext | 0
+	tagPolicyError      = classContext | 1
+)
+
+// 结果码
+const (
+	resultSuccess            = 0
+	resultSizeLimitExceeded  = 4
+	resultNoSuchObject       = 32
+	resultInvalidCredentials = 49
+	resultUnwillingToPerform = 53
+)
+
+// 搜索范围
+const (
+	scopeBaseObject   = 0
+	scopeWholeSubtree = 2
+)
+
+const (
+	ldapVersion = 3
+	// oidStartTLS StartTLS扩展操作，参考 RFC 4511 4.14
+	oidStartTLS = "1.3.6.1.4.1.1466.20037"
+	// oidPasswordPolicy 密码策略控制，参考 draft-behera-ldap-password-policy
+	oidPasswordPolicy = "1.3.6.1.4.1.42.2.27.8.5.1"
+)
+
+// 密码策略错误
+const (
+	policyNone             = -1
+	policyPasswordExpired  = 0
+	policyAccountLocked    = 1
+	policyChangeAfterReset = 2
+)
+
+var (
+	errUnexpectedResponse = errors.New("unexpected ldap response")
+	errDisconnected       = errors.New("ldap server closed the connection")
+)
+
+// resultError LDAP操作失败的结果
+type resultError struct {
+	code        int
+	message     string
+	policyError int
+}
+
+func (e *resultError) Error() string {
+	return fmt.Sprintf("ldap result code %d: %s", e.code, e.message)
+}
+
+// entry 搜索结果条目，属性名按照服务端返回保留大小写
+type entry struct {
+	dn         string
+	attributes map[string][]string
+}
+
+// get 按属性名获取属性值，属性名不区分大小写
+func (e *entry) get(attr string) []string {
+	for k, v := range e.attributes {
+		if strings.EqualFold(k, attr) {
+			return v
+		}
+	}
+	return nil
+}
+
+// searchRequest 搜索请求
+type searchRequest struct {
+	baseDN     string
+	scope      int
+	filter     string
+	attributes []string
+	sizeLimit  int
+}
+
+// conn LDAP连接，同一时间只处理一个操作
+type conn struct {
+	netConn net.Conn
+	r       *bufio.Reader
+	msgID   int
+	timeout time.Duration
+	server  *server
+	// bound 是否以服务账户身份绑定，用户绑定后需要重新绑定
+	bound  bool
+	usedAt time.Time
+	broken bool
+}
+
+// dial 建立连接，ldaps时直接建立TLS连接，starttls时在明文连接上升级
+func dial(ctx context.Context, s *server, security string, tlsConfig *tls.Config, timeout time.Duration) (*conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &conn{timeout: timeout, server: s}
+	if security == securityLDAPS {
+		netConn, err = handshake(ctx, netConn, s, tlsConfig, timeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+	c.setConn(netConn)
+
+	if security == securityStartTLS {
+		if err = c.startTLS(ctx); err != nil {
+			c.close()
+			return nil, err
+		}
+		if netConn, err = handshake(ctx, c.netConn, s, tlsConfig, timeout); err != nil {
+			return nil, err
+		}
+		c.setConn(netConn)
+	}
+	c.usedAt = time.Now()
+	return c, nil
+}
+
+func handshake(ctx context.Context, netConn net.Conn, s *server, tlsConfig *tls.Config, timeout time.Duration) (net.Conn, error) {
+	cfg := tlsConfig.Clone()
+	cfg.ServerName = s.host
+	tlsConn := tls.Client(netConn, cfg)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		_ = netConn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+func (c *conn) setConn(netConn net.Conn) {
+	c.netConn = netConn
+	c.r = bufio.NewReader(netConn)
+}
+
+func (c *conn) close() {
+	c.broken = true
+	_ = c.netConn.Close()
+}
+
+// unbind 通知服务端并关闭连接
+func (c *conn) unbind() {
+	if !c.broken {
+		c.msgID++
+		msg := newSequence(tagSequence, newInteger(tagInteger, c.msgID), &packet{tag: opUnbindRequest})
+		_ = c.netConn.SetWriteDeadline(time.Now().Add(c.timeout))
+		_, _ = c.netConn.Write(msg.encode())
+	}
+	c.close()
+}
+
+func (c *conn) startTLS(ctx context.Context) error {
+	op := newSequence(opExtendedRequest, newOctetString(tagExtendedName, oidStartTLS))
+	resp, _, err := c.roundTrip(ctx, op, nil, opExtendedResponse)
+	if err != nil {
+		return err
+	}
+	return checkResult(resp, nil)
+}
+
+// bind 简单绑定，返回密码策略控制中的错误
+func (c *conn) bind(ctx context.Context, dn, password string) error {
+	op := newSequence(opBindRequest,
+		newInteger(tagInteger, ldapVersion),
+		newOctetString(tagOctetString, dn),
+		newOctetString(tagSimpleAuth, password),
+	)
+	controls := newSequence(tagControls, newSequence(tagSequence, newOctetString(tagOctetString, oidPasswordPolicy)))
+
+	resp, respControls, err := c.roundTrip(ctx, op, controls, opBindResponse)
+	if err != nil {
+		return err
+	}
+	return checkResult(resp, respControls)
+}
+
+// search 搜索条目，忽略引用，超出条目数量限制时返回已获取的条目
+func (c *conn) search(ctx context.Context, req *searchRequest) ([]*entry, error) {
+	filter, err := compileFilter(req.filter)
+	if err != nil {
+		return nil, err
+	}
+	attrs := newSequence(tagSequence)
+	for _, a := range req.attributes {
+		attrs.children = append(attrs.children, newOctetString(tagOctetString, a))
+	}
+	op := newSequence(opSearchRequest,
+		newOctetString(tagOctetString, req.baseDN),
+		newInteger(tagEnumerated, req.scope),
+		// 不解引用别名
+		newInteger(tagEnumerated, 0),
+		newInteger(tagInteger, req.sizeLimit),
+		newInteger(tagInteger, int(c.timeout/time.Second)),
+		newBoolean(tagBoolean, false),
+		filter,
+		attrs,
+	)
+
+	if err = c.send(ctx, op, nil); err != nil {
+		return nil, err
+	}
+
+	var entries []*entry
+	for {
+		resp, _, err := c.receive()
+		if err != nil {
+			return nil, err
+		}
+
+		switch resp.tag {
+		case opSearchResultEntry:
+			e, err := parseEntry(resp)
+			if err != nil {
+				c.close()
+				return nil, err
+			}
+			entries = append(entries, e)
+		case opSearchResultRef:
+		case opSearchResultDone:
+			err = checkResult(resp, nil)
+			if e, ok := err.(*resultError); ok && e.code == resultSizeLimitExceeded {
+				err = nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return entries, nil
+		default:
+			c.close()
+			return nil, errUnexpectedResponse
+		}
+	}
+}
+
+// roundTrip 发送请求并读取单个响应
+func (c *conn) roundTrip(ctx context.Context, op, controls *packet, expected byte) (resp, respControls *packet, err error) {
+	if err = c.send(ctx, op, controls); err != nil {
+		return nil, nil, err
+	}
+	if resp, respControls, err = c.receive(); err != nil {
+		return nil, nil, err
+	}
+	if resp.tag != expected {
+		c.close()
+		return nil, nil, errUnexpectedResponse
+	}
+	return resp, respControls, nil
+}
+
+func (c *conn) send(ctx context.Context, op, controls *packet) error {
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := c.netConn.SetDeadline(deadline); err != nil {
+		c.close()
+		return err
+	}
+
+	c.msgID++
+	msg := newSequence(tagSequence, newInteger(tagInteger, c.msgID), op)
+	if controls != nil {
+		msg.children = append(msg.children, controls)
+	}
+	if _, err := c.netConn.Write(msg.encode()); err != nil {
+		c.close()
+		return err
+	}
+	c.usedAt = time.Now()
+	return nil
+}
+
+// receive 读取当前请求的响应，连接出错或收到断开通知时关闭连接
+func (c *conn) receive() (op, controls *packet, err error) {
+	msg, err := readPacket(c.r)
+	if err != nil {
+		c.close()
+		return nil, nil, err
+	}
+	if msg.tag != tagSequence || len(msg.children) < 2 {
+		c.close()
+		return nil, nil, errUnexpectedResponse
+	}
+
+	id, err := msg.child(0).integer()
+	if err != nil {
+		c.close()
+		return nil, nil, err
+	}
+	if id == 0 {
+		// 未经请求的通知，目前只有断开通知 1.3.6.1.4.1.1466.20036
+		c.close()
+		return nil, nil, errDisconnected
+	}
+	if id != c.msgID {
+		c.close()
+		return nil, nil, errUnexpectedResponse
+	}
+
+	if ctrl := msg.child(2); ctrl != nil && ctrl.tag == tagControls {
+		controls = ctrl
+	}
+	return msg.child(1), controls, nil
+}
+
+// checkResult 解析LDAPResult，失败时返回resultError
+func checkResult(resp, controls *packet) error {
+	if len(resp.children) < 3 {
+		return errUnexpectedResponse
+	}
+	code, err := resp.child(0).integer()
+	if err != nil {
+		return err
+	}
+
+	policyError := parsePolicyControl(controls)
+	if code == resultSuccess && policyError == policyNone {
+		return nil
+	}
+	return &resultError{code: code, message: resp.child(2).str(), policyError: policyError}
+}
+
+// parsePolicyControl 解析密码策略响应控制中的错误
+func parsePolicyControl(controls *packet) int {
+	if controls == nil {
+		return policyNone
+	}
+	for _, ctrl := range controls.children {
+		if ctrl.child(0) == nil || ctrl.child(0).str() != oidPasswordPolicy {
+			continue
+		}
+		// 控制值为最后一项，criticality可能省略
+		if len(ctrl.children) < 2 || ctrl.children[len(ctrl.children)-1].tag != tagOctetString {
+			return policyNone
+		}
+		policy, err := decodePacket(ctrl.children[len(ctrl.children)-1].value)
+		if err != nil {
+			return policyNone
+		}
+		for _, item := range policy.children {
+			if item.tag == tagPolicyError {
+				if v, err := item.integer(); err == nil {
+					return v
+				}
+			}
+		}
+	}
+	return policyNone
+}
+
+func parseEntry(resp *packet) (*entry, error) {
+	if len(resp.children) != 2 || resp.child(1).tag != tagSequence {
+		return nil, errUnexpectedResponse
+	}
+
+	e := &entry{dn: resp.child(0).str(), attributes: make(map[string][]string)}
+	for _, attr := range resp.child(1).children {
+		if len(attr.children) != 2 {
+			return nil, errUnexpectedResponse
+		}
+		values := make([]string, 0, len(attr.child(1).children))
+		for _, v := range attr.child(1).children {
+			values = append(values, v.str())
+		}
+		e.attributes[attr.child(0).str()] = values
+	}
+	return e, nil
+}
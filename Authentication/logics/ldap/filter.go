@@ -0,0 +1,283 @@
+package ldap
+
+import (
+	"errors"
+	"strings"
+)
+
+// 过滤条件标签，参考 RFC 4511 4.5.1
+const (
+	filterAnd            = 0
+	filterOr             = 1
+	filterNot            = 2
+	filterEquality       = 3
+	filterSubstrings     = 4
+	filterGreaterOrEqual = 5
+	filterLessOrEqual    = 6
+	filterPresent        = 7
+	filterApprox         = 8
+	filterExtensible     = 9
+)
+
+// 子串过滤条件标签
+const (
+	substringInitial = 0
+	substringAny     = 1
+	substringFinal   = 2
+)
+
+// 扩展匹配条件标签
+const (
+	matchingRuleTag = 1
+	matchingTypeTag = 2
+	matchValueTag   = 3
+	dnAttributesTag = 4
+)
+
+var errFilterInvalid = errors.New("invalid ldap filter")
+
+// escapeFilter 转义过滤条件中的断言值，参考 RFC 4515 3
+func escapeFilter(s string) string {
+	const hex = "0123456789abcdef"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '*', '(', ')', '\\', 0:
+			b.WriteByte('\\')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0x0f])
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// compileFilter 将字符串形式的过滤条件编码为BER
+func compileFilter(filter string) (*packet, error) {
+	p, rest, err := compileItem(filter, 0)
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, errFilterInvalid
+	}
+	return p, nil
+}
+
+func compileItem(s string, depth int) (p *packet, rest string, err error) {
+	if depth > berMaxDepth || len(s) < 3 || s[0] != '(' {
+		return nil, "", errFilterInvalid
+	}
+	s = s[1:]
+
+	switch s[0] {
+	case '&', '|':
+		tag := byte(filterAnd)
+		if s[0] == '|' {
+			tag = filterOr
+		}
+		p = newSequence(classContext | tag)
+		s = s[1:]
+		for len(s) > 0 && s[0] == '(' {
+			var c *packet
+			if c, s, err = compileItem(s, depth+1); err != nil {
+				return nil, "", err
+			}
+			p.children = append(p.children, c)
+		}
+		if len(p.children) == 0 {
+			return nil, "", errFilterInvalid
+		}
+	case '!':
+		var c *packet
+		if c, s, err = compileItem(s[1:], depth+1); err != nil {
+			return nil, "", err
+		}
+		p = newSequence(classContext|filterNot, c)
+	default:
+		end := strings.IndexByte(s, ')')
+		if end < 0 {
+			return nil, "", errFilterInvalid
+		}
+		if p, err = compileSimple(s[:end]); err != nil {
+			return nil, "", err
+		}
+		s = s[end:]
+	}
+
+	if len(s) == 0 || s[0] != ')' {
+		return nil, "", errFilterInvalid
+	}
+	return p, s[1:], nil
+}
+
+// compileSimple 编码不带括号的单个比较条件
+func compileSimple(item string) (*packet, error) {
+	eq := strings.IndexByte(item, '=')
+	if eq <= 0 {
+		return nil, errFilterInvalid
+	}
+	attr, value := item[:eq], item[eq+1:]
+
+	switch attr[len(attr)-1] {
+	case '>':
+		return compileAVA(filterGreaterOrEqual, attr[:len(attr)-1], value)
+	case '<':
+		return compileAVA(filterLessOrEqual, attr[:len(attr)-1], value)
+	case '~':
+		return compileAVA(filterApprox, attr[:len(attr)-1], value)
+	case ':':
+		return compileExtensible(attr[:len(attr)-1], value)
+	}
+
+	if value == "*" {
+		if !validAttribute(attr) {
+			return nil, errFilterInvalid
+		}
+		return newOctetString(classContext|filterPresent, attr), nil
+	}
+	if strings.Contains(value, "*") {
+		return compileSubstrings(attr, value)
+	}
+	return compileAVA(filterEquality, attr, value)
+}
+
+func compileAVA(tag byte, attr, value string) (*packet, error) {
+	if !validAttribute(attr) {
+		return nil, errFilterInvalid
+	}
+	v, err := unescapeFilter(value)
+	if err != nil {
+		return nil, err
+	}
+	return newSequence(classContext|tag,
+		newOctetString(tagOctetString, attr),
+		newOctetString(tagOctetString, v),
+	), nil
+}
+
+func compileSubstrings(attr, value string) (*packet, error) {
+	if !validAttribute(attr) {
+		return nil, errFilterInvalid
+	}
+
+	parts := strings.Split(value, "*")
+	subs := newSequence(tagSequence)
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		v, err := unescapeFilter(part)
+		if err != nil {
+			return nil, err
+		}
+		tag := byte(substringAny)
+		switch i {
+		case 0:
+			tag = substringInitial
+		case len(parts) - 1:
+			tag = substringFinal
+		}
+		subs.children = append(subs.children, newOctetString(classContext|tag, v))
+	}
+	if len(subs.children) == 0 {
+		return nil, errFilterInvalid
+	}
+	return newSequence(classContext|filterSubstrings, newOctetString(tagOctetString, attr), subs), nil
+}
+
+// compileExtensible 编码扩展匹配条件，格式为 attr[:dn][:rule]:=value 或 [:dn]:rule:=value
+func compileExtensible(desc, value string) (*packet, error) {
+	parts := strings.Split(desc, ":")
+	attr, parts := parts[0], parts[1:]
+	dnAttributes := false
+	if len(parts) > 0 && strings.EqualFold(parts[0], "dn") {
+		dnAttributes = true
+		parts = parts[1:]
+	}
+	rule := ""
+	if len(parts) == 1 {
+		rule = parts[0]
+	} else if len(parts) > 1 {
+		return nil, errFilterInvalid
+	}
+	if (attr == "" && rule == "") || (attr != "" && !validAttribute(attr)) || (rule != "" && !validAttribute(rule)) {
+		return nil, errFilterInvalid
+	}
+
+	v, err := unescapeFilter(value)
+	if err != nil {
+		return nil, err
+	}
+	p := newSequence(classContext | filterExtensible)
+	if rule != "" {
+		p.children = append(p.children, newOctetString(classContext|matchingRuleTag, rule))
+	}
+	if attr != "" {
+		p.children = append(p.children, newOctetString(classContext|matchingTypeTag, attr))
+	}
+	p.children = append(p.children, newOctetString(classContext|matchValueTag, v))
+	if dnAttributes {
+		p.children = append(p.children, newBoolean(classContext|dnAttributesTag, true))
+	}
+	return p, nil
+}
+
+// validAttribute 属性描述仅允许字母、数字、连字符、点及分号选项
+func validAttribute(attr string) bool {
+	if attr == "" {
+		return false
+	}
+	for i := 0; i < len(attr); i++ {
+		c := attr[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.' || c == ';') {
+			return false
+		}
+	}
+	return true
+}
+
+func unescapeFilter(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		if strings.ContainsAny(s, "()") {
+			return "", errFilterInvalid
+		}
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(', ')':
+			return "", errFilterInvalid
+		case '\\':
+			if i+2 >= len(s) {
+				return "", errFilterInvalid
+			}
+			hi, ok1 := fromHex(s[i+1])
+			lo, ok2 := fromHex(s[i+2])
+			if !ok1 || !ok2 {
+				return "", errFilterInvalid
+			}
+			b.WriteByte(hi<<4 | lo)
+			i += 2
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+func fromHex(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
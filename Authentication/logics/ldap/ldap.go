@@ -0,0 +1,423 @@
+// Package ldap 逻辑层
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kweaver-ai/go-lib/observable"
+	"github.com/kweaver-ai/go-lib/rest"
+
+	"Authentication/common"
+	"Authentication/interfaces"
+)
+
+var (
+	lOnce sync.Once
+	l     *ldap
+)
+
+// 连接方式
+const (
+	securityLDAPS    = "ldaps"
+	securityStartTLS = "starttls"
+	securityNone     = "none"
+)
+
+const (
+	defaultPoolSize = 5
+	defaultTimeout  = 5 * time.Second
+	// maxGroupDepth 非AD目录逐层查找嵌套组的最大层数
+	maxGroupDepth = 8
+	// accountPlaceholder 用户过滤条件中账户名的占位符
+	accountPlaceholder = "{account}"
+	// oidMatchingRuleInChain AD嵌套组成员匹配规则
+	oidMatchingRuleInChain = "1.2.840.113556.1.4.1941"
+	// noAttributes 搜索时不返回属性
+	noAttributes = "1.1"
+)
+
+// 用户过滤条件默认值
+const (
+	defaultADUserFilter   = "(&(objectCategory=person)(objectClass=user)(|(sAMAccountName={account})(userPrincipalName={account})))"
+	defaultLDAPUserFilter = "(&(objectClass=person)(uid={account}))"
+)
+
+var errUserAmbiguous = errors.New("more than one ldap entry matches the account")
+
+// adBindErrors AD绑定失败时诊断信息中的子错误码
+var adBindErrors = map[string]int{
+	"525": common.DomainUserNotExist,
+	"52e": common.InvalidAccountORPassword,
+	"530": common.ForbiddenLogin,
+	"531": common.ForbiddenLogin,
+	"532": common.PasswordExpire,
+	"533": common.UserDisabled,
+	"701": common.UserDisabled,
+	"773": common.PasswordISInitial,
+	"775": common.AccountLocked,
+}
+
+// policyErrors 密码策略控制中的错误
+var policyErrors = map[int]int{
+	policyPasswordExpired:  common.PasswordExpire,
+	policyAccountLocked:    common.AccountLocked,
+	policyChangeAfterReset: common.PasswordISInitial,
+}
+
+type ldap struct {
+	directories map[string]*directory // 按小写的域路径索引
+	logger      common.Logger
+	trace       observable.Tracer
+}
+
+// directory 已配置的LDAP/AD目录
+type directory struct {
+	domainPath     string
+	bindDN         string
+	bindPassword   string
+	baseDN         string
+	userFilter     string
+	groupBaseDN    string
+	requiredGroups []string
+	pool           *pool
+}
+
+// NewLDAP 创建LDAP/AD直连认证对象
+func NewLDAP() *ldap {
+	lOnce.Do(func() {
+		l = &ldap{
+			directories: make(map[string]*directory),
+			logger:      common.NewLogger(),
+			trace:       common.SvcARTrace,
+		}
+
+		for i := range common.SvcConfig.LDAP.Directories {
+			config := &common.SvcConfig.LDAP.Directories[i]
+			dir, err := newDirectory(config, common.SvcConfig.LDAPBindPasswords[config.DomainPath])
+			if err != nil {
+				l.logger.Errorf("ldap directory %q is invalid, it is authenticated by sharemgnt instead, err: %v", config.DomainPath, err)
+				continue
+			}
+			l.directories[strings.ToLower(dir.domainPath)] = dir
+		}
+	})
+
+	return l
+}
+
+// newDirectory 校验目录配置并创建连接池
+func newDirectory(config *common.LDAPDirectory, bindPassword string) (*directory, error) {
+	if config.DomainPath == "" || config.BaseDN == "" || len(config.Servers) == 0 {
+		return nil, errors.New("domain_path, base_dn and servers are required")
+	}
+	if config.UserFilter != "" {
+		if !strings.Contains(config.UserFilter, accountPlaceholder) {
+			return nil, errors.New("user_filter must contain " + accountPlaceholder)
+		}
+		if _, err := compileFilter(strings.ReplaceAll(config.UserFilter, accountPlaceholder, "x")); err != nil {
+			return nil, err
+		}
+	}
+
+	security := strings.ToLower(config.Security)
+	defaultPort := "389"
+	switch security {
+	case "", securityLDAPS:
+		security = securityLDAPS
+		defaultPort = "636"
+	case securityStartTLS, securityNone:
+	default:
+		return nil, errors.New("unsupported security: " + config.Security)
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if config.CACert != "" {
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM([]byte(config.CACert)) {
+			return nil, errors.New("ca_cert is not PEM encoded")
+		}
+	}
+
+	servers := make([]*server, 0, len(config.Servers))
+	for _, addr := range config.Servers {
+		s, err := newServer(addr, defaultPort)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, s)
+	}
+
+	poolSize := config.PoolSize
+	if poolSize <= 0 {
+		poolSize = defaultPoolSize
+	}
+	timeout := time.Duration(config.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	groupBaseDN := config.GroupBaseDN
+	if groupBaseDN == "" {
+		groupBaseDN = config.BaseDN
+	}
+
+	return &directory{
+		domainPath:     config.DomainPath,
+		bindDN:         config.BindDN,
+		bindPassword:   bindPassword,
+		baseDN:         config.BaseDN,
+		userFilter:     config.UserFilter,
+		groupBaseDN:    groupBaseDN,
+		requiredGroups: config.RequiredGroups,
+		pool:           newPool(servers, security, tlsConfig, timeout, poolSize),
+	}, nil
+}
+
+// Configured 域是否配置了直连目录，未配置时由原有服务完成域认证
+func (l *ldap) Configured(domainPath string) bool {
+	_, ok := l.directories[strings.ToLower(domainPath)]
+	return ok
+}
+
+// Authenticate 使用服务账户查找域用户后以用户身份绑定校验密码，配置了允许登录的组时校验嵌套组成员关系
+func (l *ldap) Authenticate(ctx context.Context, visitor *interfaces.Visitor, userInfo *interfaces.UserBaseInfo, password string) (err error) {
+	l.trace.SetInternalSpanName("逻辑层-LDAP域认证")
+	newCtx, span := l.trace.AddInternalTrace(ctx)
+	defer func() { l.trace.TelemetrySpanEnd(span, err) }()
+
+	dir, ok := l.directories[strings.ToLower(userInfo.DomainPath)]
+	if !ok {
+		return rest.NewHTTPError("", common.DomainNotExist, nil)
+	}
+	// 空密码的简单绑定会被服务端当作匿名绑定
+	if password == "" {
+		return rest.NewHTTPError("", common.InvalidAccountORPassword, nil)
+	}
+
+	isAD := userInfo.LDAPType == interfaces.WindowAD
+	return dir.withConn(newCtx, func(c *conn) error {
+		if err := dir.serviceBind(newCtx, c); err != nil {
+			l.logger.Errorf("ldap service account bind failed, domain: %s, err: %v", dir.domainPath, err)
+			return unavailable(err)
+		}
+
+		userDN, err := dir.findUser(newCtx, c, userInfo.Account, isAD)
+		if err == errUserAmbiguous {
+			l.logger.Warnf("ldap account %q is ambiguous, domain: %s", userInfo.Account, dir.domainPath)
+			return rest.NewHTTPError("", common.InvalidAccountORPassword, nil)
+		}
+		if err != nil {
+			l.logger.Errorf("ldap search user failed, domain: %s, err: %v", dir.domainPath, err)
+			return unavailable(err)
+		}
+		if userDN == "" {
+			return rest.NewHTTPError("", common.DomainUserNotExist, nil)
+		}
+
+		c.bound = false
+		if err = c.bind(newCtx, userDN, password); err != nil {
+			return bindError(err)
+		}
+
+		if len(dir.requiredGroups) == 0 {
+			return nil
+		}
+		if err = dir.serviceBind(newCtx, c); err != nil {
+			l.logger.Errorf("ldap service account bind failed, domain: %s, err: %v", dir.domainPath, err)
+			return unavailable(err)
+		}
+		member, err := dir.inRequiredGroups(newCtx, c, userDN, isAD)
+		if err != nil {
+			l.logger.Errorf("ldap search groups failed, domain: %s, err: %v", dir.domainPath, err)
+			return unavailable(err)
+		}
+		if !member {
+			return rest.NewHTTPError("", common.ForbiddenLogin, nil)
+		}
+		return nil
+	})
+}
+
+// bindError 转换用户绑定失败的错误，按照密码策略控制及AD子错误码细分
+func bindError(err error) error {
+	var re *resultError
+	if !errors.As(err, &re) {
+		return unavailable(err)
+	}
+
+	if code, ok := policyErrors[re.policyError]; ok {
+		return rest.NewHTTPError("", code, nil)
+	}
+	switch re.code {
+	case resultInvalidCredentials:
+		if code, ok := adBindErrors[adSubCode(re.message)]; ok {
+			return rest.NewHTTPError("", code, nil)
+		}
+		return rest.NewHTTPError("", common.InvalidAccountORPassword, nil)
+	case resultUnwillingToPerform:
+		// 部分目录对禁用的账户返回unwillingToPerform
+		return rest.NewHTTPError(re.message, common.UserDisabled, nil)
+	}
+	return unavailable(err)
+}
+
+// unavailable 网络错误、服务账户配置错误等无法完成认证的错误
+func unavailable(err error) error {
+	return rest.NewHTTPError(err.Error(), common.DomainServerUnavailable, nil)
+}
+
+// adSubCode 解析AD诊断信息中的子错误码，例如 "80090308: LdapErr: DSID-0C09044E, comment: AcceptSecurityContext error, data 52e, v4563"
+func adSubCode(message string) string {
+	i := strings.Index(message, "data ")
+	if i < 0 {
+		return ""
+	}
+	code := message[i+len("data "):]
+	if j := strings.IndexAny(code, ", "); j >= 0 {
+		code = code[:j]
+	}
+	return strings.ToLower(code)
+}
+
+// withConn 从连接池获取连接执行操作，复用的连接已失效时使用新连接重试一次
+func (d *directory) withConn(ctx context.Context, fn func(c *conn) error) error {
+	c, reused, err := d.pool.get(ctx)
+	if err != nil {
+		return unavailable(err)
+	}
+
+	err = fn(c)
+	if err != nil && reused && c.broken {
+		if c, err = d.pool.dial(ctx); err != nil {
+			return unavailable(err)
+		}
+		err = fn(c)
+	}
+	d.pool.put(c)
+	return err
+}
+
+// serviceBind 以服务账户身份绑定，未配置服务账户时匿名绑定
+func (d *directory) serviceBind(ctx context.Context, c *conn) error {
+	if c.bound {
+		return nil
+	}
+	if err := c.bind(ctx, d.bindDN, d.bindPassword); err != nil {
+		return err
+	}
+	c.bound = true
+	return nil
+}
+
+// findUser 查找用户DN，用户不存在时返回空字符串
+func (d *directory) findUser(ctx context.Context, c *conn, account string, isAD bool) (string, error) {
+	filter := d.userFilter
+	if filter == "" {
+		filter = defaultLDAPUserFilter
+		if isAD {
+			filter = defaultADUserFilter
+		}
+	}
+
+	entries, err := c.search(ctx, &searchRequest{
+		baseDN:     d.baseDN,
+		scope:      scopeWholeSubtree,
+		filter:     strings.ReplaceAll(filter, accountPlaceholder, escapeFilter(account)),
+		attributes: []string{noAttributes},
+		sizeLimit:  2,
+	})
+	if err != nil {
+		return "", err
+	}
+	switch len(entries) {
+	case 0:
+		return "", nil
+	case 1:
+		return entries[0].dn, nil
+	default:
+		return "", errUserAmbiguous
+	}
+}
+
+// inRequiredGroups 用户是否直接或通过嵌套组属于任一允许登录的组
+// AD使用LDAP_MATCHING_RULE_IN_CHAIN由服务端展开嵌套组，其他目录逐层查找包含成员的组
+func (d *directory) inRequiredGroups(ctx context.Context, c *conn, userDN string, isAD bool) (bool, error) {
+	if isAD {
+		for _, group := range d.requiredGroups {
+			entries, err := c.search(ctx, &searchRequest{
+				baseDN:     group,
+				scope:      scopeBaseObject,
+				filter:     fmt.Sprintf("(member:%s:=%s)", oidMatchingRuleInChain, escapeFilter(userDN)),
+				attributes: []string{noAttributes},
+			})
+			var re *resultError
+			if errors.As(err, &re) && re.code == resultNoSuchObject {
+				continue
+			}
+			if err != nil {
+				return false, err
+			}
+			if len(entries) > 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	required := make(map[string]bool, len(d.requiredGroups))
+	for _, group := range d.requiredGroups {
+		required[normalizeDN(group)] = true
+	}
+	visited := map[string]bool{normalizeDN(userDN): true}
+	members := []string{userDN}
+	for depth := 0; depth < maxGroupDepth && len(members) > 0; depth++ {
+		var b strings.Builder
+		b.WriteString("(|")
+		for _, m := range members {
+			v := escapeFilter(m)
+			b.WriteString("(member=" + v + ")(uniqueMember=" + v + ")")
+		}
+		b.WriteString(")")
+
+		entries, err := c.search(ctx, &searchRequest{
+			baseDN:     d.groupBaseDN,
+			scope:      scopeWholeSubtree,
+			filter:     b.String(),
+			attributes: []string{noAttributes},
+		})
+		if err != nil {
+			return false, err
+		}
+
+		members = members[:0]
+		for _, e := range entries {
+			dn := normalizeDN(e.dn)
+			if required[dn] {
+				return true, nil
+			}
+			if !visited[dn] {
+				visited[dn] = true
+				members = append(members, e.dn)
+			}
+		}
+	}
+	return false, nil
+}
+
+// normalizeDN 统一DN的大小写及分隔符两侧的空格，用于比较
+func normalizeDN(dn string) string {
+	rdns := strings.Split(strings.ToLower(dn), ",")
+	for i, rdn := range rdns {
+		if k, v, ok := strings.Cut(rdn, "="); ok {
+			rdn = strings.TrimSpace(k) + "=" + strings.TrimSpace(v)
+		}
+		rdns[i] = strings.TrimSpace(rdn)
+	}
+	return strings.Join(rdns, ",")
+}
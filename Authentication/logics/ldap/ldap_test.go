@@ -0,0 +1,261 @@
+package ldap
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kweaver-ai/go-lib/rest"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/assert"
+
+	"Authentication/common"
+	"Authentication/interfaces"
+	"Authentication/interfaces/mock"
+)
+
+const (
+	testLDAPDomain = "example.com"
+	testADDomain   = "corp.com"
+)
+
+// testLDAPEntries OpenLDAP目录，alice通过嵌套组属于staff组
+func testLDAPEntries() []*testEntry {
+	return append(testEntries(),
+		&testEntry{dn: "ou=groups,dc=example,dc=com"},
+		&testEntry{dn: "cn=staff,ou=groups,dc=example,dc=com",
+			attrs: map[string][]string{"objectClass": {"groupOfNames"}, "member": {"cn=team,ou=groups,dc=example,dc=com"}}},
+		&testEntry{dn: "cn=team,ou=groups,dc=example,dc=com",
+			attrs: map[string][]string{"objectClass": {"groupOfUniqueNames"}, "uniqueMember": {"uid=alice,ou=users,dc=example,dc=com"}}},
+	)
+}
+
+// testADEntries AD目录，carol通过嵌套组属于vpn组
+func testADEntries() []*testEntry {
+	user := func(dn, account, password string) *testEntry {
+		return &testEntry{dn: dn, password: password, attrs: map[string][]string{
+			"objectCategory":    {"person"},
+			"objectClass":       {"top", "person", "user"},
+			"sAMAccountName":    {account},
+			"userPrincipalName": {account + "@corp.com"},
+		}}
+	}
+	return []*testEntry{
+		{dn: "DC=corp,DC=com"},
+		{dn: "CN=svc,CN=Users,DC=corp,DC=com", password: "svc-secret"},
+		user("CN=Carol,OU=Staff,DC=corp,DC=com", "carol", "carol-secret"),
+		user("CN=Dave,OU=Staff,DC=corp,DC=com", "dave", "dave-secret"),
+		{dn: "CN=VPN,OU=Groups,DC=corp,DC=com", attrs: map[string][]string{"member": {"CN=Eng,OU=Groups,DC=corp,DC=com"}}},
+		{dn: "CN=Eng,OU=Groups,DC=corp,DC=com", attrs: map[string][]string{"member": {"CN=Carol,OU=Staff,DC=corp,DC=com"}}},
+	}
+}
+
+func newTestLDAP(t *testing.T, trace interfaces.TraceClient, configs ...*common.LDAPDirectory) *ldap {
+	l := &ldap{
+		directories: make(map[string]*directory),
+		logger:      common.NewLogger(),
+		trace:       trace,
+	}
+	for _, config := range configs {
+		dir, err := newDirectory(config, "svc-secret")
+		assert.NilError(t, err)
+		l.directories[config.DomainPath] = dir
+	}
+	return l
+}
+
+func TestNewDirectory(t *testing.T) {
+	dir, err := newDirectory(&common.LDAPDirectory{DomainPath: testLDAPDomain, BaseDN: "dc=example,dc=com", Servers: []string{"dc1", "dc2:3269"}}, "")
+	assert.NilError(t, err)
+	assert.Equal(t, dir.pool.security, securityLDAPS)
+	assert.Equal(t, dir.pool.servers[0].addr, "dc1:636")
+	assert.Equal(t, dir.pool.servers[1].addr, "dc2:3269")
+	assert.Equal(t, dir.pool.timeout, defaultTimeout)
+	assert.Equal(t, cap(dir.pool.idle), defaultPoolSize)
+	assert.Equal(t, dir.groupBaseDN, "dc=example,dc=com")
+
+	dir, err = newDirectory(&common.LDAPDirectory{DomainPath: testLDAPDomain, BaseDN: "dc=example,dc=com", Servers: []string{"dc1"},
+		Security: "StartTLS", PoolSize: 2, Timeout: 1}, "")
+	assert.NilError(t, err)
+	assert.Equal(t, dir.pool.security, securityStartTLS)
+	assert.Equal(t, dir.pool.servers[0].addr, "dc1:389")
+	assert.Equal(t, cap(dir.pool.idle), 2)
+
+	for _, config := range []*common.LDAPDirectory{
+		{BaseDN: "dc=example,dc=com", Servers: []string{"dc1"}},
+		{DomainPath: testLDAPDomain, Servers: []string{"dc1"}},
+		{DomainPath: testLDAPDomain, BaseDN: "dc=example,dc=com"},
+		{DomainPath: testLDAPDomain, BaseDN: "dc=example,dc=com", Servers: []string{":389"}},
+		{DomainPath: testLDAPDomain, BaseDN: "dc=example,dc=com", Servers: []string{"dc1"}, Security: "ssl"},
+		{DomainPath: testLDAPDomain, BaseDN: "dc=example,dc=com", Servers: []string{"dc1"}, CACert: "cert"},
+		{DomainPath: testLDAPDomain, BaseDN: "dc=example,dc=com", Servers: []string{"dc1"}, UserFilter: "(uid=admin)"},
+		{DomainPath: testLDAPDomain, BaseDN: "dc=example,dc=com", Servers: []string{"dc1"}, UserFilter: "(uid={account}"},
+	} {
+		_, err = newDirectory(config, "")
+		assert.Assert(t, err != nil, config)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	tlsConfig, caPEM := newTestCert("127.0.0.1")
+
+	Convey("Authenticate", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx := context.Background()
+		trace := mock.NewMockTraceClient(ctrl)
+		trace.EXPECT().SetInternalSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddInternalTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		ldapServer := newTestServer(t, securityStartTLS, tlsConfig, testLDAPEntries())
+		adServer := newTestServer(t, securityLDAPS, tlsConfig, testADEntries())
+		ldapConfig := &common.LDAPDirectory{
+			DomainPath:     testLDAPDomain,
+			Servers:        []string{ldapServer.addr()},
+			Security:       securityStartTLS,
+			CACert:         caPEM,
+			BindDN:         "cn=svc,dc=example,dc=com",
+			BaseDN:         "dc=example,dc=com",
+			GroupBaseDN:    "ou=groups,dc=example,dc=com",
+			RequiredGroups: []string{"cn=staff,ou=groups,dc=example,dc=com"},
+		}
+		adConfig := &common.LDAPDirectory{
+			DomainPath:     testADDomain,
+			Servers:        []string{adServer.addr()},
+			CACert:         caPEM,
+			BindDN:         "CN=svc,CN=Users,DC=corp,DC=com",
+			BaseDN:         "DC=corp,DC=com",
+			RequiredGroups: []string{"CN=Missing,OU=Groups,DC=corp,DC=com", "CN=VPN,OU=Groups,DC=corp,DC=com"},
+		}
+		l := newTestLDAP(t, trace, ldapConfig, adConfig)
+
+		visitor := &interfaces.Visitor{IP: "127.0.0.1"}
+		ldapUser := func(account string) *interfaces.UserBaseInfo {
+			return &interfaces.UserBaseInfo{Account: account, AuthType: interfaces.Domain, LDAPType: interfaces.OtherLDAP, DomainPath: testLDAPDomain}
+		}
+		adUser := func(account string) *interfaces.UserBaseInfo {
+			return &interfaces.UserBaseInfo{Account: account, AuthType: interfaces.Domain, LDAPType: interfaces.WindowAD, DomainPath: testADDomain}
+		}
+
+		Convey("configured", func() {
+			assert.Equal(t, l.Configured("Example.COM"), true)
+			assert.Equal(t, l.Configured("other.com"), false)
+
+			err := l.Authenticate(ctx, visitor, &interfaces.UserBaseInfo{Account: "alice", DomainPath: "other.com"}, "alice-secret")
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.DomainNotExist)
+		})
+
+		Convey("ldap success with nested group", func() {
+			err := l.Authenticate(ctx, visitor, ldapUser("alice"), "alice-secret")
+			assert.Equal(t, err, nil)
+			assert.DeepEqual(t, ldapServer.bindDNs(), []string{
+				"cn=svc,dc=example,dc=com",
+				"uid=alice,ou=users,dc=example,dc=com",
+				"cn=svc,dc=example,dc=com",
+			})
+		})
+
+		Convey("ldap user not in required groups", func() {
+			err := l.Authenticate(ctx, visitor, ldapUser("bob"), "bob-secret")
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.ForbiddenLogin)
+		})
+
+		Convey("ldap wrong password", func() {
+			err := l.Authenticate(ctx, visitor, ldapUser("alice"), "wrong")
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.InvalidAccountORPassword)
+		})
+
+		Convey("empty password", func() {
+			err := l.Authenticate(ctx, visitor, ldapUser("alice"), "")
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.InvalidAccountORPassword)
+			assert.Equal(t, len(ldapServer.bindDNs()), 0)
+		})
+
+		Convey("ldap user not exist", func() {
+			err := l.Authenticate(ctx, visitor, ldapUser("*"), "secret")
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.DomainUserNotExist)
+		})
+
+		Convey("ldap password policy", func() {
+			ldapServer.setBindResult("uid=alice,ou=users,dc=example,dc=com", testBindResult{code: resultInvalidCredentials, policyError: policyAccountLocked})
+			ldapServer.setBindResult("uid=bob,ou=users,dc=example,dc=com", testBindResult{code: resultSuccess, policyError: policyChangeAfterReset})
+
+			err := l.Authenticate(ctx, visitor, ldapUser("alice"), "alice-secret")
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.AccountLocked)
+			err = l.Authenticate(ctx, visitor, ldapUser("bob"), "bob-secret")
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.PasswordISInitial)
+		})
+
+		Convey("service account bind failed", func() {
+			ldapServer.setBindResult("cn=svc,dc=example,dc=com", testBindResult{code: resultInvalidCredentials, message: "data 775", policyError: policyNone})
+
+			err := l.Authenticate(ctx, visitor, ldapUser("alice"), "alice-secret")
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.DomainServerUnavailable)
+		})
+
+		Convey("ad success by account and user principal name", func() {
+			err := l.Authenticate(ctx, visitor, adUser("carol"), "carol-secret")
+			assert.Equal(t, err, nil)
+			err = l.Authenticate(ctx, visitor, adUser("carol@corp.com"), "carol-secret")
+			assert.Equal(t, err, nil)
+		})
+
+		Convey("ad user not in required groups", func() {
+			err := l.Authenticate(ctx, visitor, adUser("dave"), "dave-secret")
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.ForbiddenLogin)
+		})
+
+		Convey("ad bind sub codes", func() {
+			msg := "80090308: LdapErr: DSID-0C09044E, comment: AcceptSecurityContext error, data %s, v4563"
+			for code, want := range map[string]int{
+				"52e": common.InvalidAccountORPassword,
+				"532": common.PasswordExpire,
+				"533": common.UserDisabled,
+				"773": common.PasswordISInitial,
+				"775": common.AccountLocked,
+			} {
+				adServer.setBindResult("CN=Carol,OU=Staff,DC=corp,DC=com", testBindResult{
+					code:        resultInvalidCredentials,
+					message:     strings.Replace(msg, "%s", code, 1),
+					policyError: policyNone,
+				})
+				err := l.Authenticate(ctx, visitor, adUser("carol"), "carol-secret")
+				assert.Equal(t, err.(*rest.HTTPError).Code, want, code)
+			}
+		})
+
+		Convey("failover", func() {
+			ldapConfig.Servers = []string{"127.0.0.1:1", ldapServer.addr()}
+			l = newTestLDAP(t, trace, ldapConfig)
+
+			err := l.Authenticate(ctx, visitor, ldapUser("alice"), "alice-secret")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, l.directories[testLDAPDomain].pool.servers[0].available(time.Now()), false)
+		})
+
+		Convey("all servers unavailable", func() {
+			ldapServer.close()
+
+			err := l.Authenticate(ctx, visitor, ldapUser("alice"), "alice-secret")
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.DomainServerUnavailable)
+		})
+
+		Convey("reuse pooled connection", func() {
+			err := l.Authenticate(ctx, visitor, ldapUser("alice"), "alice-secret")
+			assert.Equal(t, err, nil)
+			err = l.Authenticate(ctx, visitor, ldapUser("alice"), "alice-secret")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, ldapServer.acceptedConns(), 1)
+
+			// 服务端断开空闲连接后使用新连接重试
+			ldapServer.dropConns()
+			err = l.Authenticate(ctx, visitor, ldapUser("alice"), "alice-secret")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, ldapServer.acceptedConns(), 2)
+		})
+	})
+}
@@ -0,0 +1,127 @@
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// serverRetryInterval 域控制器连接失败后，在该时间内优先尝试其他域控制器
+	serverRetryInterval = 30 * time.Second
+	// idleTimeout 空闲连接超过该时间后关闭，避免使用已被服务端断开的连接
+	idleTimeout = 60 * time.Second
+)
+
+var errNoServer = errors.New("no ldap server configured")
+
+// server 域控制器
+type server struct {
+	addr string
+	host string
+
+	mu        sync.Mutex
+	downUntil time.Time
+}
+
+func newServer(addr, defaultPort string) (*server, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		// 未指定端口
+		host, port = addr, defaultPort
+	}
+	if host == "" {
+		return nil, errors.New("invalid ldap server address: " + addr)
+	}
+	return &server{addr: net.JoinHostPort(host, port), host: host}, nil
+}
+
+func (s *server) available(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !now.Before(s.downUntil)
+}
+
+func (s *server) markDown(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.downUntil = now.Add(serverRetryInterval)
+}
+
+// pool 同一目录的连接池，新建连接时按配置顺序选择可用的域控制器
+type pool struct {
+	servers   []*server
+	security  string
+	tlsConfig *tls.Config
+	timeout   time.Duration
+	idle      chan *conn
+}
+
+func newPool(servers []*server, security string, tlsConfig *tls.Config, timeout time.Duration, size int) *pool {
+	return &pool{
+		servers:   servers,
+		security:  security,
+		tlsConfig: tlsConfig,
+		timeout:   timeout,
+		idle:      make(chan *conn, size),
+	}
+}
+
+// get 获取空闲连接，没有可用的空闲连接时新建连接，reused表示是否为复用的连接
+func (p *pool) get(ctx context.Context) (c *conn, reused bool, err error) {
+	for {
+		select {
+		case c = <-p.idle:
+			if time.Since(c.usedAt) < idleTimeout {
+				return c, true, nil
+			}
+			c.unbind()
+		default:
+			c, err = p.dial(ctx)
+			return c, false, err
+		}
+	}
+}
+
+// put 归还连接，出错的连接直接关闭，连接池已满时关闭多余连接
+func (p *pool) put(c *conn) {
+	if c.broken {
+		return
+	}
+	select {
+	case p.idle <- c:
+	default:
+		c.unbind()
+	}
+}
+
+// dial 依次尝试可用的域控制器，全部不可用时再尝试一遍被标记为不可用的域控制器
+func (p *pool) dial(ctx context.Context) (*conn, error) {
+	now := time.Now()
+	var candidates, down []*server
+	for _, s := range p.servers {
+		if s.available(now) {
+			candidates = append(candidates, s)
+		} else {
+			down = append(down, s)
+		}
+	}
+	candidates = append(candidates, down...)
+
+	err := errNoServer
+	for _, s := range candidates {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		var c *conn
+		if c, err = dial(ctx, s, p.security, p.tlsConfig, p.timeout); err == nil {
+			return c, nil
+		}
+		s.markDown(time.Now())
+	}
+	return nil, err
+}
@@ -0,0 +1,225 @@
+package ldap
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestBER(t *testing.T) {
+	// 整数使用最短的补码编码
+	for v, want := range map[int]string{0: "020100", 127: "02017f", 128: "02020080", 256: "02020100", -1: "0201ff", -129: "0202ff7f"} {
+		p := newInteger(tagInteger, v)
+		assert.Equal(t, hex.EncodeToString(p.encode()), want)
+		got, err := p.integer()
+		assert.NilError(t, err)
+		assert.Equal(t, got, v)
+	}
+
+	// 长度超过127时使用长格式
+	long := newSequence(tagSequence, newOctetString(tagOctetString, strings.Repeat("a", 200)))
+	data := long.encode()
+	assert.Equal(t, hex.EncodeToString(data[:6]), "3081cb0481c8")
+	decoded, err := decodePacket(data)
+	assert.NilError(t, err)
+	assert.Equal(t, decoded.child(0).str(), strings.Repeat("a", 200))
+
+	msg, err := readPacket(bufio.NewReader(bytes.NewReader(data)))
+	assert.NilError(t, err)
+	assert.Equal(t, len(msg.children), 1)
+
+	// 长度超出数据、多余数据、不定长编码及过深的嵌套
+	_, err = decodePacket([]byte{0x04, 0x05, 'a'})
+	assert.Equal(t, err, errBERMalformed)
+	_, err = decodePacket([]byte{0x04, 0x01, 'a', 0x00})
+	assert.Equal(t, err, errBERMalformed)
+	_, err = decodePacket([]byte{0x30, 0x80, 0x00, 0x00})
+	assert.Equal(t, err, errBERMalformed)
+	_, err = decodePacket([]byte{0x04, 0x84, 0x7f, 0xff, 0xff, 0xff})
+	assert.Equal(t, err, errMessageTooLong)
+	nested := newOctetString(tagOctetString, "x")
+	for i := 0; i <= berMaxDepth; i++ {
+		nested = newSequence(tagSequence, nested)
+	}
+	_, err = decodePacket(nested.encode())
+	assert.Equal(t, err, errBERMalformed)
+}
+
+func TestCompileFilter(t *testing.T) {
+	cases := map[string]string{
+		"(uid=a)":                   "a3080403756964040161",
+		"(objectClass=*)":           "870b6f626a656374436c617373",
+		"(!(uid=a))":                "a20aa3080403756964040161",
+		"(cn>=b)":                   "a5070402636e040162",
+		"(cn=a*b*c)":                "a40f0402636e3009800161810162820163",
+		"(uid=a\\2ab)":              "a30a04037569640403612a62",
+		"(&(uid=a)(|(cn=b)(sn=c)))": "a01ea3080403756964040161a112a3070402636e040162a3070402736e040163",
+		"(member:1.2.840.113556.1.4.1941:=cn=g)": "a927" + "8117" + hex.EncodeToString([]byte(oidMatchingRuleInChain)) +
+			"8206" + hex.EncodeToString([]byte("member")) + "8304" + hex.EncodeToString([]byte("cn=g")),
+	}
+	for filter, want := range cases {
+		p, err := compileFilter(filter)
+		assert.NilError(t, err, filter)
+		assert.Equal(t, hex.EncodeToString(p.encode()), want, filter)
+	}
+
+	for _, filter := range []string{"", "uid=a", "(uid=a", "(uid=a))", "(&)", "(=a)", "(u id=a)", "(uid=a(b)", "(uid=\\zz)", "(uid=\\2)", "(:=a)", "(cn:x:y:=a)"} {
+		_, err := compileFilter(filter)
+		assert.Equal(t, err, errFilterInvalid, filter)
+	}
+
+	// 转义后的值不会改变过滤条件的结构
+	p, err := compileFilter("(uid=" + escapeFilter("*)(uid=admin") + ")")
+	assert.NilError(t, err)
+	assert.Equal(t, p.child(1).str(), "*)(uid=admin")
+	assert.Equal(t, escapeFilter("a*(b)\\\x00"), "a\\2a\\28b\\29\\5c\\00")
+}
+
+func TestNormalizeDN(t *testing.T) {
+	assert.Equal(t, normalizeDN("CN=Alice Smith, OU=Users ,DC=Example,DC=com"), "cn=alice smith,ou=users,dc=example,dc=com")
+	assert.Equal(t, adSubCode("80090308: LdapErr: DSID-0C09044E, comment: AcceptSecurityContext error, data 775, v4563"), "775")
+	assert.Equal(t, adSubCode("80090308: LdapErr: DSID-0C09044E, comment: AcceptSecurityContext error, data 52E, v4563"), "52e")
+	assert.Equal(t, adSubCode("invalid credentials"), "")
+}
+
+func testEntries() []*testEntry {
+	return []*testEntry{
+		{dn: "dc=example,dc=com"},
+		{dn: "cn=svc,dc=example,dc=com", password: "svc-secret"},
+		{dn: "uid=alice,ou=users,dc=example,dc=com", password: "alice-secret",
+			attrs: map[string][]string{"objectClass": {"person"}, "uid": {"alice"}}},
+		{dn: "uid=bob,ou=users,dc=example,dc=com", password: "bob-secret",
+			attrs: map[string][]string{"objectClass": {"person"}, "uid": {"bob"}}},
+	}
+}
+
+func TestConn(t *testing.T) {
+	ctx := context.Background()
+	tlsConfig, caPEM := newTestCert("127.0.0.1")
+	clientTLS := &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: x509Pool(t, caPEM)}
+
+	for _, security := range []string{securityNone, securityLDAPS, securityStartTLS} {
+		srv := newTestServer(t, security, tlsConfig, testEntries())
+		s, err := newServer(srv.addr(), "389")
+		assert.NilError(t, err)
+
+		c, err := dial(ctx, s, security, clientTLS, time.Second)
+		assert.NilError(t, err, security)
+		_, isTLS := c.netConn.(*tls.Conn)
+		assert.Equal(t, isTLS, security != securityNone, security)
+
+		assert.NilError(t, c.bind(ctx, "cn=svc,dc=example,dc=com", "svc-secret"))
+		entries, err := c.search(ctx, &searchRequest{
+			baseDN: "dc=example,dc=com",
+			scope:  scopeWholeSubtree,
+			filter: "(&(objectClass=person)(uid=alice))",
+		})
+		assert.NilError(t, err)
+		assert.Equal(t, len(entries), 1)
+		assert.Equal(t, entries[0].dn, "uid=alice,ou=users,dc=example,dc=com")
+		assert.DeepEqual(t, entries[0].get("UID"), []string{"alice"})
+
+		// 超出条目数量限制时返回已获取的条目
+		entries, err = c.search(ctx, &searchRequest{baseDN: "dc=example,dc=com", scope: scopeWholeSubtree, filter: "(objectClass=person)", sizeLimit: 1})
+		assert.NilError(t, err)
+		assert.Equal(t, len(entries), 1)
+
+		_, err = c.search(ctx, &searchRequest{baseDN: "dc=other", scope: scopeBaseObject, filter: "(objectClass=*)"})
+		assert.Equal(t, err.(*resultError).code, resultNoSuchObject)
+
+		err = c.bind(ctx, "uid=alice,ou=users,dc=example,dc=com", "wrong")
+		assert.Equal(t, err.(*resultError).code, resultInvalidCredentials)
+		assert.Equal(t, err.(*resultError).policyError, policyNone)
+		assert.Equal(t, c.broken, false)
+		c.unbind()
+	}
+
+	// 证书不受信任
+	srv := newTestServer(t, securityStartTLS, tlsConfig, testEntries())
+	s, _ := newServer(srv.addr(), "389")
+	_, err := dial(ctx, s, securityStartTLS, &tls.Config{MinVersion: tls.VersionTLS12}, time.Second)
+	assert.ErrorContains(t, err, "certificate")
+
+	// 服务端不支持StartTLS
+	srv = newTestServer(t, securityNone, tlsConfig, testEntries())
+	s, _ = newServer(srv.addr(), "389")
+	_, err = dial(ctx, s, securityStartTLS, clientTLS, time.Second)
+	assert.Equal(t, err.(*resultError).code, 2)
+}
+
+func TestPasswordPolicyControl(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t, securityNone, nil, testEntries())
+	srv.setBindResult("uid=alice,ou=users,dc=example,dc=com", testBindResult{code: resultInvalidCredentials, policyError: policyAccountLocked})
+	// 初次登录需要修改密码时绑定成功，但密码策略控制返回错误
+	srv.setBindResult("uid=bob,ou=users,dc=example,dc=com", testBindResult{code: resultSuccess, policyError: policyChangeAfterReset})
+
+	s, _ := newServer(srv.addr(), "389")
+	c, err := dial(ctx, s, securityNone, nil, time.Second)
+	assert.NilError(t, err)
+	defer c.unbind()
+
+	err = c.bind(ctx, "uid=alice,ou=users,dc=example,dc=com", "alice-secret")
+	assert.Equal(t, err.(*resultError).policyError, policyAccountLocked)
+	err = c.bind(ctx, "uid=bob,ou=users,dc=example,dc=com", "bob-secret")
+	assert.Equal(t, err.(*resultError).code, resultSuccess)
+	assert.Equal(t, err.(*resultError).policyError, policyChangeAfterReset)
+}
+
+func TestPoolFailover(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t, securityNone, nil, testEntries())
+
+	// 第一个域控制器不可用
+	down, err := newServer("127.0.0.1:1", "389")
+	assert.NilError(t, err)
+	up, err := newServer(srv.addr(), "389")
+	assert.NilError(t, err)
+	p := newPool([]*server{down, up}, securityNone, nil, time.Second, 1)
+
+	c, reused, err := p.get(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, reused, false)
+	assert.Equal(t, c.server, up)
+	assert.Equal(t, down.available(time.Now()), false)
+
+	// 归还的连接被复用，超出连接池大小的连接被关闭
+	extra, _, err := p.get(ctx)
+	assert.NilError(t, err)
+	p.put(c)
+	p.put(extra)
+	assert.Equal(t, extra.broken, true)
+	c2, reused, err := p.get(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, reused, true)
+	assert.Equal(t, c2, c)
+
+	// 出错的连接不再归还
+	c2.close()
+	p.put(c2)
+	assert.Equal(t, len(p.idle), 0)
+
+	// 空闲超时的连接被关闭
+	c3, _, err := p.get(ctx)
+	assert.NilError(t, err)
+	c3.usedAt = time.Now().Add(-2 * idleTimeout)
+	p.put(c3)
+	c4, reused, err := p.get(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, reused, false)
+	assert.Equal(t, c3.broken, true)
+	c4.unbind()
+
+	// 全部不可用
+	up.markDown(time.Now())
+	srv.close()
+	_, _, err = p.get(ctx)
+	assert.Assert(t, err != nil)
+}
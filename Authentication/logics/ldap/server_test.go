@@ -0,0 +1,386 @@
+package ldap
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testEntry 模拟目录中的条目
+type testEntry struct {
+	dn       string
+	attrs    map[string][]string
+	password string
+}
+
+// testBindResult 模拟绑定失败的结果，用于AD子错误码及密码策略控制
+type testBindResult struct {
+	code        int
+	message     string
+	policyError int
+}
+
+// testServer 兼容OpenLDAP/AD协议的内存目录服务，仅实现认证所需的操作
+type testServer struct {
+	t         *testing.T
+	listener  net.Listener
+	security  string
+	tlsConfig *tls.Config
+	entries   []*testEntry
+
+	mu          sync.Mutex
+	bindResults map[string]testBindResult
+	binds       []string
+	conns       []net.Conn
+	accepted    int
+}
+
+// newTestCert 生成自签名的服务端证书，返回证书配置及PEM格式的根证书
+func newTestCert(host string) (*tls.Config, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: host},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP(host)},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		panic(err)
+	}
+	cert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, string(caPEM)
+}
+
+// x509Pool 信任测试根证书的证书池
+func x509Pool(t *testing.T, caPEM string) *x509.CertPool {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(caPEM)) {
+		t.Fatal("invalid ca cert")
+	}
+	return pool
+}
+
+func newTestServer(t *testing.T, security string, tlsConfig *tls.Config, entries []*testEntry) *testServer {
+	var listener net.Listener
+	var err error
+	if security == securityLDAPS {
+		listener, err = tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	} else {
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &testServer{
+		t:           t,
+		listener:    listener,
+		security:    security,
+		tlsConfig:   tlsConfig,
+		entries:     entries,
+		bindResults: make(map[string]testBindResult),
+	}
+	go s.serve()
+	t.Cleanup(s.close)
+	return s
+}
+
+func (s *testServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *testServer) setBindResult(dn string, result testBindResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bindResults[normalizeDN(dn)] = result
+}
+
+// bindDNs 获取所有绑定请求的DN
+func (s *testServer) bindDNs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.binds...)
+}
+
+// acceptedConns 获取建立过的连接数
+func (s *testServer) acceptedConns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.accepted
+}
+
+// dropConns 断开所有连接，模拟服务端回收空闲连接
+func (s *testServer) dropConns() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.conns {
+		_ = c.Close()
+	}
+	s.conns = nil
+}
+
+func (s *testServer) close() {
+	_ = s.listener.Close()
+	s.dropConns()
+}
+
+func (s *testServer) serve() {
+	for {
+		c, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns = append(s.conns, c)
+		s.accepted++
+		s.mu.Unlock()
+		go s.handle(c)
+	}
+}
+
+func (s *testServer) handle(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	boundDN := ""
+
+	for {
+		msg, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		id, _ := msg.child(0).integer()
+		op := msg.child(1)
+		reply := func(resp *packet, controls *packet) bool {
+			out := newSequence(tagSequence, newInteger(tagInteger, id), resp)
+			if controls != nil {
+				out.children = append(out.children, controls)
+			}
+			_, err := c.Write(out.encode())
+			return err == nil
+		}
+
+		switch op.tag {
+		case opBindRequest:
+			dn, password := op.child(1).str(), op.child(2).str()
+			result, controls := s.bind(dn, password)
+			if result.code == resultSuccess {
+				boundDN = dn
+			} else {
+				boundDN = ""
+			}
+			if !reply(testResult(opBindResponse, result.code, result.message), controls) {
+				return
+			}
+		case opSearchRequest:
+			entries, code := s.search(op, boundDN)
+			for _, e := range entries {
+				if !reply(testEntryPacket(e), nil) {
+					return
+				}
+			}
+			if !reply(testResult(opSearchResultDone, code, ""), nil) {
+				return
+			}
+		case opExtendedRequest:
+			if op.child(0).str() != oidStartTLS || s.security != securityStartTLS {
+				if !reply(testResult(opExtendedResponse, 2, "unsupported"), nil) {
+					return
+				}
+				continue
+			}
+			if !reply(testResult(opExtendedResponse, resultSuccess, ""), nil) {
+				return
+			}
+			tlsConn := tls.Server(c, s.tlsConfig)
+			if err = tlsConn.Handshake(); err != nil {
+				return
+			}
+			c = tlsConn
+			r = bufio.NewReader(c)
+		case opUnbindRequest:
+			return
+		}
+	}
+}
+
+func (s *testServer) bind(dn, password string) (testBindResult, *packet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.binds = append(s.binds, dn)
+
+	if result, ok := s.bindResults[normalizeDN(dn)]; ok {
+		var controls *packet
+		if result.policyError != policyNone {
+			value := newSequence(tagSequence, &packet{tag: tagPolicyError, value: []byte{byte(result.policyError)}})
+			controls = newSequence(tagControls, newSequence(tagSequence,
+				newOctetString(tagOctetString, oidPasswordPolicy),
+				newOctetString(tagOctetString, string(value.encode())),
+			))
+		}
+		return result, controls
+	}
+	if dn == "" && password == "" {
+		return testBindResult{code: resultSuccess}, nil
+	}
+	for _, e := range s.entries {
+		if normalizeDN(e.dn) == normalizeDN(dn) && e.password != "" && e.password == password {
+			return testBindResult{code: resultSuccess}, nil
+		}
+	}
+	return testBindResult{code: resultInvalidCredentials, message: "invalid credentials"}, nil
+}
+
+// search 匿名连接不允许搜索，与AD的默认行为一致
+func (s *testServer) search(op *packet, boundDN string) ([]*testEntry, int) {
+	if boundDN == "" {
+		return nil, 50
+	}
+
+	base := normalizeDN(op.child(0).str())
+	scope, _ := op.child(1).integer()
+	sizeLimit, _ := op.child(3).integer()
+	filter := op.child(6)
+
+	baseExists := false
+	var result []*testEntry
+	for _, e := range s.entries {
+		dn := normalizeDN(e.dn)
+		if dn == base {
+			baseExists = true
+		}
+		inScope := dn == base
+		if scope == scopeWholeSubtree {
+			inScope = inScope || strings.HasSuffix(dn, ","+base)
+		}
+		if inScope && s.match(e, filter) {
+			result = append(result, e)
+		}
+	}
+	if !baseExists {
+		return nil, resultNoSuchObject
+	}
+	if sizeLimit > 0 && len(result) > sizeLimit {
+		return result[:sizeLimit], resultSizeLimitExceeded
+	}
+	return result, resultSuccess
+}
+
+func (s *testServer) match(e *testEntry, f *packet) bool {
+	switch f.tag &^ typeConstructed {
+	case classContext | filterAnd:
+		for _, c := range f.children {
+			if !s.match(e, c) {
+				return false
+			}
+		}
+		return true
+	case classContext | filterOr:
+		for _, c := range f.children {
+			if s.match(e, c) {
+				return true
+			}
+		}
+		return false
+	case classContext | filterNot:
+		return !s.match(e, f.child(0))
+	case classContext | filterPresent:
+		return len(testAttr(e, f.str())) > 0
+	case classContext | filterEquality:
+		return testHasValue(testAttr(e, f.child(0).str()), f.child(1).str())
+	case classContext | filterExtensible:
+		var rule, attr, value string
+		for _, c := range f.children {
+			switch c.tag {
+			case classContext | matchingRuleTag:
+				rule = c.str()
+			case classContext | matchingTypeTag:
+				attr = c.str()
+			case classContext | matchValueTag:
+				value = c.str()
+			}
+		}
+		if rule == oidMatchingRuleInChain {
+			return s.memberInChain(e, attr, value, map[string]bool{})
+		}
+		return testHasValue(testAttr(e, attr), value)
+	}
+	return false
+}
+
+// memberInChain 模拟AD的LDAP_MATCHING_RULE_IN_CHAIN，递归展开组成员
+func (s *testServer) memberInChain(e *testEntry, attr, value string, visited map[string]bool) bool {
+	if visited[normalizeDN(e.dn)] {
+		return false
+	}
+	visited[normalizeDN(e.dn)] = true
+
+	for _, m := range testAttr(e, attr) {
+		if normalizeDN(m) == normalizeDN(value) {
+			return true
+		}
+		for _, child := range s.entries {
+			if normalizeDN(child.dn) == normalizeDN(m) && s.memberInChain(child, attr, value, visited) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func testAttr(e *testEntry, attr string) []string {
+	for k, v := range e.attrs {
+		if strings.EqualFold(k, attr) {
+			return v
+		}
+	}
+	return nil
+}
+
+func testHasValue(values []string, value string) bool {
+	for _, v := range values {
+		if normalizeDN(v) == normalizeDN(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func testResult(tag byte, code int, message string) *packet {
+	return newSequence(tag,
+		newInteger(tagEnumerated, code),
+		newOctetString(tagOctetString, ""),
+		newOctetString(tagOctetString, message),
+	)
+}
+
+func testEntryPacket(e *testEntry) *packet {
+	attrs := newSequence(tagSequence)
+	for k, values := range e.attrs {
+		set := newSequence(tagSet)
+		for _, v := range values {
+			set.children = append(set.children, newOctetString(tagOctetString, v))
+		}
+		attrs.children = append(attrs.children, newSequence(tagSequence, newOctetString(tagOctetString, k), set))
+	}
+	return newSequence(opSearchResultEntry, newOctetString(tagOctetString, e.dn), attrs)
+}
@@ -22,8 +22,13 @@ import (
 	"Authentication/logics"
 	Assertion "Authentication/logics/assertion"
 	"Authentication/logics/conf"
+	"Authentication/logics/ldap"
+	"Authentication/logics/oidc"
+	"Authentication/logics/saml"
 	"Authentication/logics/sms"
 	tic "Authentication/logics/ticket"
+	"Authentication/logics/totp"
+	"Authentication/logics/webauthn"
 )
 
 var (
@@ -51,6 +56,11 @@ type login struct {
 	aSMS              interfaces.LogicsAnonymousSMS
 	accessTokenPerm   interfaces.AccessTokenPerm
 	ticket            interfaces.LogicsTicket
+	totp              interfaces.LogicsTOTP
+	webAuthn          interfaces.LogicsWebAuthn
+	oidc              interfaces.LogicsOIDC
+	saml              interfaces.LogicsSAML
+	ldap              interfaces.LogicsLDAP
 	privateKey        *rsa.PrivateKey
 	trace             observable.Tracer
 	i18n              *common.I18n
@@ -96,6 +106,11 @@ func NewLogin() *login {
 			aSMS:            sms.NewAnonymousSMS(),
 			accessTokenPerm: accesstokenperm.NewAccessTokenPerm(),
 			ticket:          tic.NewTicket(),
+			totp:            totp.NewTOTP(),
+			webAuthn:        webauthn.NewWebAuthn(),
+			oidc:            oidc.NewOIDC(),
+			saml:            saml.NewSAML(),
+			ldap:            ldap.NewLDAP(),
 			privateKey:      privateKey,
 			trace:           common.SvcARTrace,
 			i18n: common.NewI18n(common.I18nMap{
@@ -194,6 +209,136 @@ func (l *login) SingleSignOn(ctx context.Context, visitor *interfaces.Visitor, r
 	return info, nil
 }
 
+// WebAuthnSignOn 安全密钥免密登录，校验通过后按照单点登录流程完成授权
+func (l *login) WebAuthnSignOn(ctx context.Context, visitor *interfaces.Visitor, reqInfo *interfaces.WebAuthnLoginInfo) (info *interfaces.TokenInfo, err error) {
+	l.trace.SetInternalSpanName("逻辑层-安全密钥登录")
+	newCtx, span := l.trace.AddInternalTrace(ctx)
+	defer func() { l.trace.TelemetrySpanEnd(span, err) }()
+
+	oauthReqInfo := &interfaces.AuthorizeInfo{
+		ClientID:     reqInfo.ClientID,
+		RedirectURI:  reqInfo.RedirectURI,
+		ResponseType: reqInfo.ResponseType,
+		Scope:        reqInfo.Scope,
+	}
+
+	return l.realNameSignOn(newCtx, visitor, oauthReqInfo, reqInfo.Udids, reqInfo.IP, func(ctx context.Context) (string, error) {
+		return l.webAuthn.Authenticate(ctx, visitor, &reqInfo.Assertion)
+	})
+}
+
+// OIDCSignOn 上游OIDC身份提供方登录，认证通过后按照单点登录流程完成授权
+func (l *login) OIDCSignOn(ctx context.Context, visitor *interfaces.Visitor, reqInfo *interfaces.OIDCLoginInfo) (info *interfaces.TokenInfo, err error) {
+	l.trace.SetInternalSpanName("逻辑层-OIDC身份提供方登录")
+	newCtx, span := l.trace.AddInternalTrace(ctx)
+	defer func() { l.trace.TelemetrySpanEnd(span, err) }()
+
+	oauthReqInfo := &interfaces.AuthorizeInfo{
+		ClientID:     reqInfo.ClientID,
+		RedirectURI:  reqInfo.RedirectURI,
+		ResponseType: reqInfo.ResponseType,
+		Scope:        reqInfo.Scope,
+	}
+
+	return l.realNameSignOn(newCtx, visitor, oauthReqInfo, reqInfo.Udids, reqInfo.IP, func(ctx context.Context) (string, error) {
+		return l.oidc.Authenticate(ctx, visitor, reqInfo.State, reqInfo.Code)
+	})
+}
+
+// SAMLSignOn SAML身份提供方登录，断言校验通过后按照单点登录流程完成授权
+func (l *login) SAMLSignOn(ctx context.Context, visitor *interfaces.Visitor, reqInfo *interfaces.SAMLLoginInfo) (info *interfaces.TokenInfo, err error) {
+	l.trace.SetInternalSpanName("逻辑层-SAML身份提供方登录")
+	newCtx, span := l.trace.AddInternalTrace(ctx)
+	defer func() { l.trace.TelemetrySpanEnd(span, err) }()
+
+	oauthReqInfo := &interfaces.AuthorizeInfo{
+		ClientID:     reqInfo.ClientID,
+		RedirectURI:  reqInfo.RedirectURI,
+		ResponseType: reqInfo.ResponseType,
+		Scope:        reqInfo.Scope,
+	}
+
+	return l.realNameSignOn(newCtx, visitor, oauthReqInfo, reqInfo.Udids, reqInfo.IP, func(ctx context.Context) (string, error) {
+		return l.saml.Authenticate(ctx, visitor, reqInfo.State)
+	})
+}
+
+// realNameSignOn 由authenticate完成实名用户认证，再按照单点登录流程完成授权
+func (l *login) realNameSignOn(ctx context.Context, visitor *interfaces.Visitor, oauthReqInfo *interfaces.AuthorizeInfo, udids []string,
+	ip string, authenticate func(ctx context.Context) (string, error)) (info *interfaces.TokenInfo, err error) {
+	loginChallenge, loginSession, err := l.hydraPublic.AuthorizeRequest(oauthReqInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceInfo, err := l.hydraAdmin.GetLoginRequestInformation(loginChallenge)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userInfo, err := l.userManagement.GetUserInfo(ctx, visitor, userID)
+	if err != nil {
+		return nil, err
+	}
+	if userInfo.DisableStatus {
+		return nil, rest.NewHTTPError("", common.UserDisabled, nil)
+	}
+
+	udid := ""
+	if len(udids) > 0 {
+		udid = udids[0]
+	}
+
+	// 由eacp完成登录IP、设备禁用/擦除、设备绑定及客户端类型等登录策略检查
+	policyInfo := &interfaces.LoginPolicyInfo{
+		UserID:      userID,
+		Priority:    userInfo.Priority,
+		Enabled:     !userInfo.DisableStatus,
+		ClientID:    oauthReqInfo.ClientID,
+		IP:          ip,
+		AccountType: "other",
+		ClientType:  deviceInfo.ClientType,
+		Udid:        udid,
+	}
+	if err = l.eacp.CheckLoginPolicy(ctx, visitor, policyInfo); err != nil {
+		return nil, err
+	}
+
+	redirURL, err := l.hydraAdmin.AcceptLoginRequest(userID, loginChallenge)
+	if err != nil {
+		return nil, err
+	}
+
+	consentChallenge, loginSession, err := l.hydraPublic.VerifierLogin(redirURL, loginSession)
+	if err != nil {
+		return nil, err
+	}
+
+	consentContext := map[string]interface{}{
+		"visitor_type": "realname",
+		"login_ip":     ip,
+		"account_type": policyInfo.AccountType,
+		"udid":         udid,
+		"client_type":  deviceInfo.ClientType,
+	}
+	redirURL, err = l.hydraAdmin.AcceptConsentRequest(oauthReqInfo.Scope, consentChallenge, consentContext)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err = l.hydraPublic.VerifierConsent(redirURL, oauthReqInfo.ResponseType, loginSession)
+	if err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
 func (l *login) Anonymous(visitor *interfaces.Visitor, reqInfo *interfaces.AnonymousLoginInfo) (*interfaces.TokenInfo, error) {
 	oauthReqInfo := &interfaces.AuthorizeInfo{
 		ClientID:     reqInfo.ClientID,
@@ -378,6 +523,9 @@ func (l *login) ClientAccountAuth(ctx context.Context, visitor *interfaces.Visit
 		} else if req.Option.VCodeType == interfaces.DualAuthOTP {
 			err = rest.NewHTTPError("", common.OTPWrong, detail)
 			return
+		} else if req.Option.VCodeType == interfaces.DualAuthWebAuthn {
+			err = rest.NewHTTPError("", common.WebAuthnVerifyFailed, detail)
+			return
 		}
 	}
 
@@ -387,7 +535,7 @@ func (l *login) ClientAccountAuth(ctx context.Context, visitor *interfaces.Visit
 	}
 
 	// 校验验证码
-	if err = l.validateVCode(&userInfo, &req.Option, &config, detail); err != nil {
+	if err = l.validateVCode(newCtx, visitor, &userInfo, &req.Option, &config, detail); err != nil {
 		return
 	}
 
@@ -629,6 +777,14 @@ func (l *login) validatePassword(ctx context.Context, visitor *interfaces.Visito
 			err = rest.NewHTTPError("", l.authFailedMap[reason], detail)
 		}
 	case interfaces.Domain:
+		if l.ldap.Configured(userInfo.DomainPath) {
+			err = l.ldap.Authenticate(ctx, visitor, userInfo, password)
+			if v, ok := err.(*rest.HTTPError); ok {
+				err = rest.NewHTTPError(v.Cause, v.Code, detail)
+			}
+			return
+		}
+
 		result, err = l.sharemgnt.UsrmDomainAuth(ctx, userInfo.Account, userInfo.DomainPath, password, l.ldapServerTypeMap[userInfo.LDAPType])
 		if err != nil {
 			switch v := err.(type) {
@@ -663,7 +819,8 @@ func (l *login) validatePassword(ctx context.Context, visitor *interfaces.Visito
 	return
 }
 
-func (l *login) validateVCode(userInfo *interfaces.UserBaseInfo, option *interfaces.ClientLoginOption, config *interfaces.Config, detail map[string]interface{}) (err error) {
+func (l *login) validateVCode(ctx context.Context, visitor *interfaces.Visitor, userInfo *interfaces.UserBaseInfo,
+	option *interfaces.ClientLoginOption, config *interfaces.Config, detail map[string]interface{}) (err error) {
 	// 有点奇怪，正常只有图形验证码 config.VCodeConfig.Enable才为true
 	// 没看懂
 	if config.VCodeConfig.Enable && userInfo.PwdErrCnt >= config.VCodeConfig.PWDErrCnt {
@@ -676,11 +833,19 @@ func (l *login) validateVCode(userInfo *interfaces.UserBaseInfo, option *interfa
 	case interfaces.DualAuthSMS:
 		err = l.sharemgnt.UsrmSMSValidate(userInfo.ID, option.VCode)
 	case interfaces.DualAuthOTP:
-		err = l.sharemgnt.UsrmOTPValidate(userInfo.ID, option.VCode)
+		err = l.validateOTP(ctx, visitor, userInfo.ID, option.VCode)
+	case interfaces.DualAuthWebAuthn:
+		if option.WebAuthn == nil {
+			err = rest.NewHTTPError("", common.WebAuthnVerifyFailed, nil)
+			break
+		}
+		err = l.webAuthn.Validate(ctx, visitor, userInfo.ID, option.WebAuthn)
 	}
 
 	if err != nil {
 		switch v := err.(type) {
+		case *rest.HTTPError:
+			err = rest.NewHTTPError(v.Cause, v.Code, detail)
 		case *ethriftexception.NcTException:
 			err = rest.NewHTTPError("", int(rest.Unauthorized+v.GetErrID()), detail)
 		default:
@@ -693,6 +858,16 @@ func (l *login) validateVCode(userInfo *interfaces.UserBaseInfo, option *interfa
 	return
 }
 
+// validateOTP 校验动态口令，未绑定本服务动态口令的用户使用 ShareMgnt 的动态口令校验
+func (l *login) validateOTP(ctx context.Context, visitor *interfaces.Visitor, userID, code string) (err error) {
+	err = l.totp.Validate(ctx, visitor, userID, code)
+	if v, ok := err.(*rest.HTTPError); ok && v.Code == common.OTPNotEnrolled {
+		return l.sharemgnt.UsrmOTPValidate(userID, code)
+	}
+
+	return err
+}
+
 func (l *login) validateTicket(ctx context.Context, visitor *interfaces.Visitor, ticket, clientID string) (account string, err error) {
 	ticketID, err := logics.DecodeAndDecrypt(ticket, l.privateKey)
 	if err != nil {
@@ -237,6 +237,187 @@ func TestSingleSignOn(t *testing.T) {
 	})
 }
 
+func TestOIDCSignOn(t *testing.T) {
+	Convey("oidcsignon", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		hydraAdmin := mock.NewMockDnHydraAdmin(ctrl)
+		hydraPublic := mock.NewMockDnHydraPublic(ctrl)
+		um := mock.NewMockDnUserManagement(ctrl)
+		eacp := mock.NewMockDnEacp(ctrl)
+		lOIDC := mock.NewMockLogicsOIDC(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		n := newLogin(hydraAdmin, hydraPublic, um, eacp, nil, nil, nil, nil, nil)
+		n.trace = trace
+		n.oidc = lOIDC
+
+		loginInfo := &interfaces.OIDCLoginInfo{
+			ClientID:     "00002da3-b64f-4d61-9269-48fc77966ec8",
+			RedirectURI:  "https://127.0.0.1:9010/callback",
+			ResponseType: "code",
+			Scope:        "offline",
+			Udids:        []string{"udid1"},
+			IP:           "1.2.3.4",
+			State:        "state1",
+			Code:         "code1",
+		}
+		ctx := context.Background()
+		visitor := &interfaces.Visitor{Language: interfaces.SimplifiedChinese}
+		device := &interfaces.DeviceInfo{ClientType: "web"}
+
+		trace.EXPECT().SetInternalSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddInternalTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+		hydraPublic.EXPECT().AuthorizeRequest(gomock.Any()).AnyTimes().Return("login_challenge", nil, nil)
+		hydraAdmin.EXPECT().GetLoginRequestInformation(gomock.Any()).AnyTimes().Return(device, nil)
+		policyInfo := &interfaces.LoginPolicyInfo{
+			UserID:      "user1",
+			Priority:    999,
+			Enabled:     true,
+			ClientID:    "00002da3-b64f-4d61-9269-48fc77966ec8",
+			IP:          "1.2.3.4",
+			AccountType: "other",
+			ClientType:  "web",
+			Udid:        "udid1",
+		}
+		userInfo := &interfaces.UserBaseInfo{ID: "user1", Account: "account1", Priority: 999}
+
+		Convey("authenticate failed", func() {
+			testErr := rest.NewHTTPError("", common.OIDCAuthFailed, nil)
+			lOIDC.EXPECT().Authenticate(gomock.Any(), visitor, "state1", "code1").Return("", testErr)
+			tokenInfo, err := n.OIDCSignOn(ctx, visitor, loginInfo)
+			assert.Equal(t, tokenInfo, nil)
+			assert.Equal(t, err, testErr)
+		})
+
+		Convey("user disabled", func() {
+			lOIDC.EXPECT().Authenticate(gomock.Any(), visitor, "state1", "code1").Return("user1", nil)
+			um.EXPECT().GetUserInfo(gomock.Any(), gomock.Any(), "user1").Return(&interfaces.UserBaseInfo{DisableStatus: true}, nil)
+			tokenInfo, err := n.OIDCSignOn(ctx, visitor, loginInfo)
+			assert.Equal(t, tokenInfo, nil)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.UserDisabled)
+		})
+
+		Convey("login policy check failed", func() {
+			testErr := rest.NewHTTPError("", rest.Forbidden, nil)
+			lOIDC.EXPECT().Authenticate(gomock.Any(), visitor, "state1", "code1").Return("user1", nil)
+			um.EXPECT().GetUserInfo(gomock.Any(), gomock.Any(), "user1").Return(userInfo, nil)
+			eacp.EXPECT().CheckLoginPolicy(gomock.Any(), visitor, policyInfo).Return(testErr)
+			tokenInfo, err := n.OIDCSignOn(ctx, visitor, loginInfo)
+			assert.Equal(t, tokenInfo, nil)
+			assert.Equal(t, err, testErr)
+		})
+
+		Convey("success", func() {
+			token := &interfaces.TokenInfo{ResponseType: "code", Code: "hydra_code"}
+			consentContext := map[string]interface{}{
+				"visitor_type": "realname",
+				"login_ip":     "1.2.3.4",
+				"account_type": "other",
+				"udid":         "udid1",
+				"client_type":  "web",
+			}
+			lOIDC.EXPECT().Authenticate(gomock.Any(), visitor, "state1", "code1").Return("user1", nil)
+			um.EXPECT().GetUserInfo(gomock.Any(), gomock.Any(), "user1").Return(userInfo, nil)
+			eacp.EXPECT().CheckLoginPolicy(gomock.Any(), visitor, policyInfo).Return(nil)
+			hydraAdmin.EXPECT().AcceptLoginRequest("user1", "login_challenge").Return("redirURL", nil)
+			hydraPublic.EXPECT().VerifierLogin(gomock.Any(), gomock.Any()).Return("consent_challenge", nil, nil)
+			hydraAdmin.EXPECT().AcceptConsentRequest("offline", "consent_challenge", consentContext).Return("redirURL", nil)
+			hydraPublic.EXPECT().VerifierConsent(gomock.Any(), "code", gomock.Any()).Return(token, nil)
+			tokenInfo, err := n.OIDCSignOn(ctx, visitor, loginInfo)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, tokenInfo, token)
+		})
+	})
+}
+
+func TestSAMLSignOn(t *testing.T) {
+	Convey("samlsignon", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		hydraAdmin := mock.NewMockDnHydraAdmin(ctrl)
+		hydraPublic := mock.NewMockDnHydraPublic(ctrl)
+		um := mock.NewMockDnUserManagement(ctrl)
+		eacp := mock.NewMockDnEacp(ctrl)
+		lSAML := mock.NewMockLogicsSAML(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		n := newLogin(hydraAdmin, hydraPublic, um, eacp, nil, nil, nil, nil, nil)
+		n.trace = trace
+		n.saml = lSAML
+
+		loginInfo := &interfaces.SAMLLoginInfo{
+			ClientID:     "00002da3-b64f-4d61-9269-48fc77966ec8",
+			RedirectURI:  "https://127.0.0.1:9010/callback",
+			ResponseType: "code",
+			Scope:        "offline",
+			Udids:        []string{"udid1"},
+			IP:           "1.2.3.4",
+			State:        "state1",
+		}
+		ctx := context.Background()
+		visitor := &interfaces.Visitor{Language: interfaces.SimplifiedChinese}
+		device := &interfaces.DeviceInfo{ClientType: "web"}
+
+		trace.EXPECT().SetInternalSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddInternalTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+		hydraPublic.EXPECT().AuthorizeRequest(gomock.Any()).AnyTimes().Return("login_challenge", nil, nil)
+		hydraAdmin.EXPECT().GetLoginRequestInformation(gomock.Any()).AnyTimes().Return(device, nil)
+		policyInfo := &interfaces.LoginPolicyInfo{
+			UserID:      "user1",
+			Priority:    999,
+			Enabled:     true,
+			ClientID:    "00002da3-b64f-4d61-9269-48fc77966ec8",
+			IP:          "1.2.3.4",
+			AccountType: "other",
+			ClientType:  "web",
+			Udid:        "udid1",
+		}
+		userInfo := &interfaces.UserBaseInfo{ID: "user1", Account: "account1", Priority: 999}
+
+		Convey("authenticate failed", func() {
+			testErr := rest.NewHTTPError("", common.SAMLAuthFailed, nil)
+			lSAML.EXPECT().Authenticate(gomock.Any(), visitor, "state1").Return("", testErr)
+			tokenInfo, err := n.SAMLSignOn(ctx, visitor, loginInfo)
+			assert.Equal(t, tokenInfo, nil)
+			assert.Equal(t, err, testErr)
+		})
+
+		Convey("login policy check failed", func() {
+			testErr := rest.NewHTTPError("", rest.Forbidden, nil)
+			lSAML.EXPECT().Authenticate(gomock.Any(), visitor, "state1").Return("user1", nil)
+			um.EXPECT().GetUserInfo(gomock.Any(), gomock.Any(), "user1").Return(userInfo, nil)
+			eacp.EXPECT().CheckLoginPolicy(gomock.Any(), visitor, policyInfo).Return(testErr)
+			tokenInfo, err := n.SAMLSignOn(ctx, visitor, loginInfo)
+			assert.Equal(t, tokenInfo, nil)
+			assert.Equal(t, err, testErr)
+		})
+
+		Convey("success", func() {
+			token := &interfaces.TokenInfo{ResponseType: "code", Code: "hydra_code"}
+			consentContext := map[string]interface{}{
+				"visitor_type": "realname",
+				"login_ip":     "1.2.3.4",
+				"account_type": "other",
+				"udid":         "udid1",
+				"client_type":  "web",
+			}
+			lSAML.EXPECT().Authenticate(gomock.Any(), visitor, "state1").Return("user1", nil)
+			um.EXPECT().GetUserInfo(gomock.Any(), gomock.Any(), "user1").Return(userInfo, nil)
+			eacp.EXPECT().CheckLoginPolicy(gomock.Any(), visitor, policyInfo).Return(nil)
+			hydraAdmin.EXPECT().AcceptLoginRequest("user1", "login_challenge").Return("redirURL", nil)
+			hydraPublic.EXPECT().VerifierLogin(gomock.Any(), gomock.Any()).Return("consent_challenge", nil, nil)
+			hydraAdmin.EXPECT().AcceptConsentRequest("offline", "consent_challenge", consentContext).Return("redirURL", nil)
+			hydraPublic.EXPECT().VerifierConsent(gomock.Any(), "code", gomock.Any()).Return(token, nil)
+			tokenInfo, err := n.SAMLSignOn(ctx, visitor, loginInfo)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, tokenInfo, token)
+		})
+	})
+}
+
 func TestAnonymous(t *testing.T) {
 	Convey("anonumous", t, func() {
 		test := setGinMode()
@@ -548,6 +729,12 @@ func TestClientAccountAuth(t *testing.T) {
 		trace := mock.NewMockTraceClient(ctrl)
 		login := newLogin(nil, nil, userMgnt, nil, shareMgnt, loginDB, config, nil, nil)
 		login.trace = trace
+		totp := mock.NewMockLogicsTOTP(ctrl)
+		login.totp = totp
+		webAuthn := mock.NewMockLogicsWebAuthn(ctrl)
+		login.webAuthn = webAuthn
+		lLDAP := mock.NewMockLogicsLDAP(ctrl)
+		login.ldap = lLDAP
 
 		reqInfo := &interfaces.ClientLoginReq{
 			Method:   "GET",
@@ -677,6 +864,82 @@ func TestClientAccountAuth(t *testing.T) {
 			assert.Equal(t, userID, "")
 			assert.Equal(t, err, rest.NewHTTPError("", int(rest.Unauthorized+int32(common.ImageVCodeISWrong%1e6)), detail))
 		})
+		Convey("validate otp, failed", func() {
+			reqInfo.Option.VCodeType = interfaces.DualAuthOTP
+			cfg.VCodeConfig.Enable = false
+			userInfo := interfaces.UserBaseInfo{
+				ID:             "id1",
+				AuthType:       interfaces.Local,
+				PwdErrCnt:      1,
+				PwdErrLastTime: time.Now().Unix(),
+			}
+			config.EXPECT().GetConfigFromShareMgnt(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(cfg, nil)
+			loginDB.EXPECT().GetDomainStatus().AnyTimes().Return(true, nil)
+			userMgnt.EXPECT().AccountMatch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(true, userInfo, nil)
+			totp.EXPECT().Validate(gomock.Any(), gomock.Any(), "id1", "code1").Return(rest.NewHTTPError("", common.OTPWrong, nil))
+
+			userID, err := login.ClientAccountAuth(ctx, &visitor, reqInfo)
+
+			assert.Equal(t, userID, "")
+			assert.Equal(t, err, rest.NewHTTPError("", common.OTPWrong, detail))
+		})
+		Convey("validate otp, not enrolled, fall back to sharemgnt", func() {
+			reqInfo.Option.VCodeType = interfaces.DualAuthOTP
+			cfg.VCodeConfig.Enable = false
+			userInfo := interfaces.UserBaseInfo{
+				ID:             "id1",
+				AuthType:       interfaces.Local,
+				PwdErrCnt:      1,
+				PwdErrLastTime: time.Now().Unix(),
+			}
+			tmpErr := &ethriftexception.NcTException{
+				ErrID: int32(common.OTPWrong % 1e6),
+			}
+			config.EXPECT().GetConfigFromShareMgnt(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(cfg, nil)
+			loginDB.EXPECT().GetDomainStatus().AnyTimes().Return(true, nil)
+			userMgnt.EXPECT().AccountMatch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(true, userInfo, nil)
+			totp.EXPECT().Validate(gomock.Any(), gomock.Any(), "id1", "code1").Return(rest.NewHTTPError("", common.OTPNotEnrolled, nil))
+			shareMgnt.EXPECT().UsrmOTPValidate("id1", "code1").Return(tmpErr)
+
+			userID, err := login.ClientAccountAuth(ctx, &visitor, reqInfo)
+
+			assert.Equal(t, userID, "")
+			assert.Equal(t, err, rest.NewHTTPError("", int(rest.Unauthorized+int32(common.OTPWrong%1e6)), detail))
+		})
+		Convey("validate webauthn, assertion missing", func() {
+			reqInfo.Option.VCodeType = interfaces.DualAuthWebAuthn
+			cfg.VCodeConfig.Enable = false
+			userInfo := interfaces.UserBaseInfo{
+				ID:       "id1",
+				AuthType: interfaces.Local,
+			}
+			config.EXPECT().GetConfigFromShareMgnt(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(cfg, nil)
+			loginDB.EXPECT().GetDomainStatus().AnyTimes().Return(true, nil)
+			userMgnt.EXPECT().AccountMatch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(true, userInfo, nil)
+
+			userID, err := login.ClientAccountAuth(ctx, &visitor, reqInfo)
+
+			assert.Equal(t, userID, "")
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.WebAuthnVerifyFailed)
+		})
+		Convey("validate webauthn, failed", func() {
+			reqInfo.Option.VCodeType = interfaces.DualAuthWebAuthn
+			reqInfo.Option.WebAuthn = &interfaces.WebAuthnAssertionResp{ChallengeID: "challenge1"}
+			cfg.VCodeConfig.Enable = false
+			userInfo := interfaces.UserBaseInfo{
+				ID:       "id1",
+				AuthType: interfaces.Local,
+			}
+			config.EXPECT().GetConfigFromShareMgnt(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(cfg, nil)
+			loginDB.EXPECT().GetDomainStatus().AnyTimes().Return(true, nil)
+			userMgnt.EXPECT().AccountMatch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(true, userInfo, nil)
+			webAuthn.EXPECT().Validate(gomock.Any(), gomock.Any(), "id1", reqInfo.Option.WebAuthn).Return(rest.NewHTTPError("", common.WebAuthnVerifyFailed, nil))
+
+			userID, err := login.ClientAccountAuth(ctx, &visitor, reqInfo)
+
+			assert.Equal(t, userID, "")
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.WebAuthnVerifyFailed)
+		})
 		Convey("local auth failed", func() {
 			reqInfo.Option.VCodeType = interfaces.DualAuthOTP
 			cfg.EnablePWDLock = false
@@ -695,7 +958,7 @@ func TestClientAccountAuth(t *testing.T) {
 			config.EXPECT().GetConfigFromShareMgnt(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(cfg, nil)
 			loginDB.EXPECT().GetDomainStatus().AnyTimes().Return(true, nil)
 			userMgnt.EXPECT().AccountMatch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(true, userInfo, nil)
-			shareMgnt.EXPECT().UsrmOTPValidate(gomock.Any(), gomock.Any()).Return(nil)
+			totp.EXPECT().Validate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 			userMgnt.EXPECT().UserAuth(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(false, "invalid_password", nil)
 
 			userID, err := login.ClientAccountAuth(ctx, &visitor, reqInfo)
@@ -723,7 +986,8 @@ func TestClientAccountAuth(t *testing.T) {
 			config.EXPECT().GetConfigFromShareMgnt(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(cfg, nil)
 			loginDB.EXPECT().GetDomainStatus().AnyTimes().Return(true, nil)
 			userMgnt.EXPECT().AccountMatch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(true, userInfo, nil)
-			shareMgnt.EXPECT().UsrmOTPValidate(gomock.Any(), gomock.Any()).Return(nil)
+			totp.EXPECT().Validate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			lLDAP.EXPECT().Configured("").Return(false)
 			shareMgnt.EXPECT().UsrmDomainAuth(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(false, tmpErr)
 
 			userID, err := login.ClientAccountAuth(ctx, &visitor, reqInfo)
@@ -731,6 +995,33 @@ func TestClientAccountAuth(t *testing.T) {
 			assert.Equal(t, userID, "")
 			assert.Equal(t, err, rest.NewHTTPError("", int(rest.Unauthorized+int32(common.DomainNotExist%1e6)), detail))
 		})
+		Convey("direct ldap auth failed", func() {
+			reqInfo.Option.VCodeType = interfaces.DualAuthOTP
+			cfg.EnablePWDLock = false
+			cfg.EnableThirdPWDLock = false
+			cfg.VCodeConfig.Enable = false
+			userInfo := interfaces.UserBaseInfo{
+				ID:             "id1",
+				Account:        "account1",
+				AuthType:       interfaces.Domain,
+				PwdErrCnt:      1,
+				PwdErrLastTime: time.Now().Unix(),
+				DisableStatus:  false,
+				LDAPType:       interfaces.WindowAD,
+				DomainPath:     "example.com",
+			}
+			config.EXPECT().GetConfigFromShareMgnt(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(cfg, nil)
+			loginDB.EXPECT().GetDomainStatus().AnyTimes().Return(true, nil)
+			userMgnt.EXPECT().AccountMatch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(true, userInfo, nil)
+			totp.EXPECT().Validate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			lLDAP.EXPECT().Configured("example.com").Return(true)
+			lLDAP.EXPECT().Authenticate(gomock.Any(), gomock.Any(), gomock.Any(), "111111").Return(rest.NewHTTPError("", common.AccountLocked, nil))
+
+			userID, err := login.ClientAccountAuth(ctx, &visitor, reqInfo)
+
+			assert.Equal(t, userID, "")
+			assert.Equal(t, err, rest.NewHTTPError("", common.AccountLocked, detail))
+		})
 		Convey("third auth failed", func() {
 			reqInfo.Option.VCodeType = interfaces.DualAuthOTP
 			cfg.EnablePWDLock = false
@@ -749,7 +1040,7 @@ func TestClientAccountAuth(t *testing.T) {
 			config.EXPECT().GetConfigFromShareMgnt(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(cfg, nil)
 			loginDB.EXPECT().GetDomainStatus().AnyTimes().Return(true, nil)
 			userMgnt.EXPECT().AccountMatch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(true, userInfo, nil)
-			shareMgnt.EXPECT().UsrmOTPValidate(gomock.Any(), gomock.Any()).Return(nil)
+			totp.EXPECT().Validate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 			shareMgnt.EXPECT().UsrmThirdAuth(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(false, tmpErr)
 
 			userID, err := login.ClientAccountAuth(ctx, &visitor, reqInfo)
@@ -776,7 +1067,7 @@ func TestClientAccountAuth(t *testing.T) {
 			config.EXPECT().GetConfigFromShareMgnt(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(cfg, nil)
 			loginDB.EXPECT().GetDomainStatus().AnyTimes().Return(true, nil)
 			userMgnt.EXPECT().AccountMatch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(true, userInfo, nil)
-			shareMgnt.EXPECT().UsrmOTPValidate(gomock.Any(), gomock.Any()).Return(nil)
+			totp.EXPECT().Validate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 			userMgnt.EXPECT().UserAuth(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(false, "invalid_password", nil)
 			userMgnt.EXPECT().UpdatePWDErrInfo(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
 
@@ -802,7 +1093,7 @@ func TestClientAccountAuth(t *testing.T) {
 			config.EXPECT().GetConfigFromShareMgnt(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(cfg, nil)
 			loginDB.EXPECT().GetDomainStatus().AnyTimes().Return(true, nil)
 			userMgnt.EXPECT().AccountMatch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(true, userInfo, nil)
-			shareMgnt.EXPECT().UsrmOTPValidate(gomock.Any(), gomock.Any()).Return(nil)
+			totp.EXPECT().Validate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 			userMgnt.EXPECT().UserAuth(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(true, "", nil)
 			userMgnt.EXPECT().UpdatePWDErrInfo(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
 
@@ -830,7 +1121,7 @@ func TestClientAccountAuth(t *testing.T) {
 			config.EXPECT().GetConfigFromShareMgnt(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(cfg, nil)
 			loginDB.EXPECT().GetDomainStatus().AnyTimes().Return(true, nil)
 			userMgnt.EXPECT().AccountMatch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(true, userInfo, nil)
-			shareMgnt.EXPECT().UsrmOTPValidate(gomock.Any(), gomock.Any()).Return(nil)
+			totp.EXPECT().Validate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 			userMgnt.EXPECT().UserAuth(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(true, "", nil)
 
 			userID, err := login.ClientAccountAuth(ctx, &visitor, reqInfo)
@@ -856,7 +1147,7 @@ func TestClientAccountAuth(t *testing.T) {
 			config.EXPECT().GetConfigFromShareMgnt(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(cfg, nil)
 			loginDB.EXPECT().GetDomainStatus().AnyTimes().Return(true, nil)
 			userMgnt.EXPECT().AccountMatch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(true, userInfo, nil)
-			shareMgnt.EXPECT().UsrmOTPValidate(gomock.Any(), gomock.Any()).Return(nil)
+			totp.EXPECT().Validate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 			userMgnt.EXPECT().UserAuth(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(true, "", nil)
 
 			userID, err := login.ClientAccountAuth(ctx, &visitor, reqInfo)
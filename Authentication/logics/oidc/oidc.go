@@ -0,0 +1,565 @@
+// Package oidc 逻辑层
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kweaver-ai/go-lib/observable"
+	"github.com/kweaver-ai/go-lib/rest"
+	"github.com/oklog/ulid/v2"
+
+	"Authentication/common"
+	"Authentication/interfaces"
+	"Authentication/logics"
+	"Authentication/logics/audit"
+)
+
+var (
+	oOnce sync.Once
+	o     *oidc
+)
+
+const (
+	// randomSize 状态值、防重放随机值及PKCE校验值长度，单位为字节
+	randomSize = 32
+	// stateTimeout 授权请求状态有效期，单位为秒
+	stateTimeout = 10 * 60
+	// httpTimeout 请求身份提供方超时时间
+	httpTimeout = 10 * time.Second
+	// scopeOpenID OIDC必需的权限范围
+	scopeOpenID = "openid"
+)
+
+// 审计日志内容唯一标识
+const (
+	_ int = iota
+	i18nAddProvider
+	i18nUpdateProvider
+	i18nDeleteProvider
+	i18nProvisionUser
+	i18nOIDCExMsg
+)
+
+var svcLanguages = map[string]interfaces.Language{
+	"zh_CN": interfaces.SimplifiedChinese,
+	"zh_TW": interfaces.TraditionalChinese,
+	"en_US": interfaces.AmericanEnglish,
+}
+
+var matchTypes = map[string]bool{
+	interfaces.OIDCMatchByAccount: true,
+	interfaces.OIDCMatchByEmail:   true,
+	interfaces.OIDCMatchByThirdID: true,
+}
+
+type oidc struct {
+	db        interfaces.DBOIDC
+	userMgnt  interfaces.DnUserManagement
+	shareMgnt interfaces.DnShareMgnt
+	audit     interfaces.LogicsAudit
+	client    *http.Client
+	mu        sync.Mutex
+	cache     map[string]*providerCache
+	lang      interfaces.Language
+	i18n      *common.I18n
+	logger    common.Logger
+	trace     observable.Tracer
+}
+
+// NewOIDC 创建上游OIDC身份提供方处理对象
+func NewOIDC() *oidc {
+	oOnce.Do(func() {
+		o = &oidc{
+			db:        logics.DBOIDC,
+			userMgnt:  logics.DnUserManagement,
+			shareMgnt: logics.DnShareMgnt,
+			audit:     audit.NewAudit(),
+			client:    &http.Client{Timeout: httpTimeout},
+			cache:     make(map[string]*providerCache),
+			lang:      svcLanguages[common.SvcConfig.Lang],
+			i18n: common.NewI18n(common.I18nMap{
+				i18nAddProvider: {
+					interfaces.SimplifiedChinese:  "添加身份提供方“%s” 成功",
+					interfaces.TraditionalChinese: "新增身分識別提供者“%s” 成功",
+					interfaces.AmericanEnglish:    "Add identity provider \"%s\" successfully",
+				},
+				i18nUpdateProvider: {
+					interfaces.SimplifiedChinese:  "修改身份提供方“%s” 成功",
+					interfaces.TraditionalChinese: "修改身分識別提供者“%s” 成功",
+					interfaces.AmericanEnglish:    "Update identity provider \"%s\" successfully",
+				},
+				i18nDeleteProvider: {
+					interfaces.SimplifiedChinese:  "删除身份提供方“%s” 成功",
+					interfaces.TraditionalChinese: "刪除身分識別提供者“%s” 成功",
+					interfaces.AmericanEnglish:    "Delete identity provider \"%s\" successfully",
+				},
+				i18nProvisionUser: {
+					interfaces.SimplifiedChinese:  "通过身份提供方“%s”自动创建用户“%s” 成功",
+					interfaces.TraditionalChinese: "透過身分識別提供者“%s”自動建立使用者“%s” 成功",
+					interfaces.AmericanEnglish:    "Create user \"%[2]s\" automatically through identity provider \"%[1]s\" successfully",
+				},
+				i18nOIDCExMsg: {
+					interfaces.SimplifiedChinese:  "颁发者：%s",
+					interfaces.TraditionalChinese: "簽發者：%s",
+					interfaces.AmericanEnglish:    "Issuer: %s",
+				},
+			}),
+			logger: common.NewLogger(),
+			trace:  common.SvcARTrace,
+		}
+	})
+
+	return o
+}
+
+// AddProvider 管理员添加身份提供方，返回身份提供方唯一标识
+func (o *oidc) AddProvider(ctx context.Context, visitor *interfaces.Visitor, provider *interfaces.OIDCProvider) (id string, err error) {
+	o.trace.SetInternalSpanName("逻辑层-添加OIDC身份提供方")
+	newCtx, span := o.trace.AddInternalTrace(ctx)
+	defer func() { o.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = o.checkAdmin(newCtx, visitor); err != nil {
+		return "", err
+	}
+	if err = checkProvider(provider); err != nil {
+		return "", err
+	}
+
+	now := common.Now().Unix()
+	provider.ID = ulid.Make().String()
+	provider.Scopes = normalizeScopes(provider.Scopes)
+	provider.CreateTime = now
+	provider.UpdateTime = now
+	if err = o.db.AddProvider(newCtx, provider); err != nil {
+		return "", err
+	}
+
+	o.writeLog(visitor, visitor.ID, audit.LevelInfo, audit.OpCreate, provider.ID, provider.Issuer, i18nAddProvider, provider.Name)
+	return provider.ID, nil
+}
+
+// UpdateProvider 管理员更新身份提供方，客户端密钥为空时保持不变
+func (o *oidc) UpdateProvider(ctx context.Context, visitor *interfaces.Visitor, provider *interfaces.OIDCProvider) (err error) {
+	o.trace.SetInternalSpanName("逻辑层-更新OIDC身份提供方")
+	newCtx, span := o.trace.AddInternalTrace(ctx)
+	defer func() { o.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = o.checkAdmin(newCtx, visitor); err != nil {
+		return err
+	}
+	if err = checkProvider(provider); err != nil {
+		return err
+	}
+
+	existing, err := o.db.GetProvider(newCtx, provider.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return rest.NewHTTPErrorV2(rest.URINotExist, "identity provider not found")
+	}
+
+	if provider.ClientSecret == "" {
+		provider.ClientSecret = existing.ClientSecret
+	}
+	provider.Scopes = normalizeScopes(provider.Scopes)
+	provider.CreateTime = existing.CreateTime
+	provider.UpdateTime = common.Now().Unix()
+	ok, err := o.db.UpdateProvider(newCtx, provider)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return rest.NewHTTPErrorV2(rest.URINotExist, "identity provider not found")
+	}
+
+	o.writeLog(visitor, visitor.ID, audit.LevelInfo, audit.OpSet, provider.ID, provider.Issuer, i18nUpdateProvider, provider.Name)
+	return nil
+}
+
+// DeleteProvider 管理员删除身份提供方
+func (o *oidc) DeleteProvider(ctx context.Context, visitor *interfaces.Visitor, id string) (err error) {
+	o.trace.SetInternalSpanName("逻辑层-删除OIDC身份提供方")
+	newCtx, span := o.trace.AddInternalTrace(ctx)
+	defer func() { o.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = o.checkAdmin(newCtx, visitor); err != nil {
+		return err
+	}
+
+	provider, err := o.db.GetProvider(newCtx, id)
+	if err != nil {
+		return err
+	}
+	if provider == nil {
+		return rest.NewHTTPErrorV2(rest.URINotExist, "identity provider not found")
+	}
+
+	ok, err := o.db.DeleteProvider(newCtx, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return rest.NewHTTPErrorV2(rest.URINotExist, "identity provider not found")
+	}
+
+	o.writeLog(visitor, visitor.ID, audit.LevelWarn, audit.OpDelete, provider.ID, provider.Issuer, i18nDeleteProvider, provider.Name)
+	return nil
+}
+
+// GetProvider 管理员获取身份提供方
+func (o *oidc) GetProvider(ctx context.Context, visitor *interfaces.Visitor, id string) (provider *interfaces.OIDCProvider, err error) {
+	o.trace.SetInternalSpanName("逻辑层-获取OIDC身份提供方")
+	newCtx, span := o.trace.AddInternalTrace(ctx)
+	defer func() { o.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = o.checkAdmin(newCtx, visitor); err != nil {
+		return nil, err
+	}
+
+	provider, err = o.db.GetProvider(newCtx, id)
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil {
+		return nil, rest.NewHTTPErrorV2(rest.URINotExist, "identity provider not found")
+	}
+	return provider, nil
+}
+
+// ListProviders 管理员获取所有身份提供方
+func (o *oidc) ListProviders(ctx context.Context, visitor *interfaces.Visitor) (providers []interfaces.OIDCProvider, err error) {
+	o.trace.SetInternalSpanName("逻辑层-获取OIDC身份提供方列表")
+	newCtx, span := o.trace.AddInternalTrace(ctx)
+	defer func() { o.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = o.checkAdmin(newCtx, visitor); err != nil {
+		return nil, err
+	}
+
+	return o.db.GetProviders(newCtx)
+}
+
+// ListEnabledProviders 获取已启用的身份提供方，用于登录页展示
+func (o *oidc) ListEnabledProviders(ctx context.Context) (providers []interfaces.OIDCProvider, err error) {
+	o.trace.SetInternalSpanName("逻辑层-获取已启用的OIDC身份提供方")
+	newCtx, span := o.trace.AddInternalTrace(ctx)
+	defer func() { o.trace.TelemetrySpanEnd(span, err) }()
+
+	all, err := o.db.GetProviders(newCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	providers = make([]interfaces.OIDCProvider, 0, len(all))
+	for i := range all {
+		if all[i].Enabled {
+			providers = append(providers, all[i])
+		}
+	}
+	return providers, nil
+}
+
+// BeginAuth 生成身份提供方授权地址，使用授权码模式及PKCE
+func (o *oidc) BeginAuth(ctx context.Context, visitor *interfaces.Visitor, providerID string) (authURL string, err error) {
+	o.trace.SetInternalSpanName("逻辑层-生成OIDC授权地址")
+	newCtx, span := o.trace.AddInternalTrace(ctx)
+	defer func() { o.trace.TelemetrySpanEnd(span, err) }()
+
+	provider, err := o.getEnabledProvider(newCtx, providerID)
+	if err != nil {
+		return "", err
+	}
+
+	cache, err := o.getProviderCache(newCtx, provider.Issuer)
+	if err != nil {
+		return "", err
+	}
+
+	state, err1 := randomString()
+	nonce, err2 := randomString()
+	verifier, err3 := randomString()
+	if err1 != nil || err2 != nil || err3 != nil {
+		return "", rest.NewHTTPErrorV2(rest.InternalServerError, "generate random failed")
+	}
+
+	now := common.Now().Unix()
+	authState := &interfaces.OIDCAuthState{
+		State:        state,
+		ProviderID:   provider.ID,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		CreateTime:   now,
+	}
+	if err = o.db.CreateState(newCtx, authState, now-stateTimeout); err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {provider.ClientID},
+		"redirect_uri":          {provider.RedirectURI},
+		"scope":                 {strings.Join(provider.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(cache.metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return cache.metadata.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Authenticate 使用授权码完成认证，返回映射或自动创建的账户
+func (o *oidc) Authenticate(ctx context.Context, visitor *interfaces.Visitor, stateStr, code string) (userID string, err error) {
+	o.trace.SetInternalSpanName("逻辑层-OIDC身份提供方认证")
+	newCtx, span := o.trace.AddInternalTrace(ctx)
+	defer func() { o.trace.TelemetrySpanEnd(span, err) }()
+
+	state, err := o.db.ConsumeState(newCtx, stateStr)
+	if err != nil {
+		return "", err
+	}
+	if state == nil || common.Now().Unix()-state.CreateTime > stateTimeout {
+		return "", o.authFailed("", errStateInvalid)
+	}
+
+	provider, err := o.getEnabledProvider(newCtx, state.ProviderID)
+	if err != nil {
+		return "", err
+	}
+
+	cache, err := o.getProviderCache(newCtx, provider.Issuer)
+	if err != nil {
+		return "", err
+	}
+
+	rawIDToken, err := o.exchangeCode(newCtx, provider, cache.metadata.TokenEndpoint, code, state.CodeVerifier)
+	if err != nil {
+		return "", o.authFailed(provider.Issuer, err)
+	}
+
+	claims, err := o.verifyIDToken(newCtx, provider, rawIDToken, state.Nonce)
+	if err != nil {
+		return "", o.authFailed(provider.Issuer, err)
+	}
+
+	matched, info, err := o.matchUser(newCtx, visitor, provider, claims)
+	if err != nil {
+		return "", err
+	}
+	if matched {
+		return info.ID, nil
+	}
+
+	if !provider.JITProvision {
+		o.logger.Warnf("oidc account not matched, issuer: %s, sub: %s", provider.Issuer, claimString(claims, "sub"))
+		return "", rest.NewHTTPError("", common.OIDCAccountNotMatched, nil)
+	}
+	return o.provision(newCtx, visitor, provider, claims)
+}
+
+// matchUser 按映射规则顺序匹配账户，声明缺失时跳过该规则
+func (o *oidc) matchUser(ctx context.Context, visitor *interfaces.Visitor, provider *interfaces.OIDCProvider,
+	claims map[string]interface{}) (matched bool, info interfaces.UserBaseInfo, err error) {
+	for _, rule := range provider.MappingRules {
+		value := claimString(claims, rule.Claim)
+		if value == "" {
+			continue
+		}
+
+		switch rule.MatchBy {
+		case interfaces.OIDCMatchByAccount:
+			matched, info, err = o.userMgnt.AccountMatch(ctx, visitor, value, false, false)
+		case interfaces.OIDCMatchByEmail:
+			// 身份提供方未声明邮箱已验证时，不能用于匹配账户
+			if !emailVerified(claims) {
+				continue
+			}
+			matched, info, err = o.userMgnt.UserMatch(ctx, visitor, rule.MatchBy, value)
+		case interfaces.OIDCMatchByThirdID:
+			matched, info, err = o.userMgnt.UserMatch(ctx, visitor, rule.MatchBy, scopedThirdID(provider.Issuer, value))
+		default:
+			continue
+		}
+		if err != nil || matched {
+			return matched, info, err
+		}
+	}
+
+	return false, info, nil
+}
+
+// provision 自动创建账户，账户名及第三方用户ID取自映射规则中对应的声明
+func (o *oidc) provision(ctx context.Context, visitor *interfaces.Visitor, provider *interfaces.OIDCProvider,
+	claims map[string]interface{}) (userID string, err error) {
+	info := &interfaces.NewUserInfo{
+		Name: claimString(claims, "name"),
+	}
+	if emailVerified(claims) {
+		info.Email = claimString(claims, "email")
+	}
+	for _, rule := range provider.MappingRules {
+		value := claimString(claims, rule.Claim)
+		switch {
+		case rule.MatchBy == interfaces.OIDCMatchByAccount && info.Account == "":
+			info.Account = value
+		case rule.MatchBy == interfaces.OIDCMatchByThirdID && info.ThirdID == "" && value != "":
+			info.ThirdID = scopedThirdID(provider.Issuer, value)
+		}
+	}
+	if info.Account == "" {
+		info.Account = claimString(claims, "preferred_username")
+	}
+	if info.Account == "" {
+		o.logger.Warnf("oidc provision failed, issuer: %s, reason: account claim missing", provider.Issuer)
+		return "", rest.NewHTTPError("", common.OIDCAccountNotMatched, nil)
+	}
+	if info.Name == "" {
+		info.Name = info.Account
+	}
+
+	userID, err = o.shareMgnt.UsrmAddUser(ctx, info)
+	if err != nil {
+		return "", err
+	}
+
+	o.writeLog(visitor, userID, audit.LevelInfo, audit.OpCreate, userID, provider.Issuer, i18nProvisionUser, provider.Name, info.Account)
+	return userID, nil
+}
+
+// getEnabledProvider 获取已启用的身份提供方，不存在或未启用时不允许认证
+func (o *oidc) getEnabledProvider(ctx context.Context, id string) (*interfaces.OIDCProvider, error) {
+	provider, err := o.db.GetProvider(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil || !provider.Enabled {
+		return nil, rest.NewHTTPErrorV2(rest.Forbidden, "identity provider is not enabled")
+	}
+	return provider, nil
+}
+
+// authFailed 记录认证失败原因，并返回统一的认证失败错误，避免泄露校验细节
+func (o *oidc) authFailed(issuer string, reason error) error {
+	o.logger.Warnf("oidc authenticate failed, issuer: %s, reason: %v", issuer, reason)
+	return rest.NewHTTPError("", common.OIDCAuthFailed, nil)
+}
+
+func (o *oidc) checkAdmin(ctx context.Context, visitor *interfaces.Visitor) (err error) {
+	var roleTypes []interfaces.RoleType
+	// 实名用户获取对应角色信息
+	if visitor.Type == interfaces.RealName {
+		roleTypes, err = o.userMgnt.GetUserRolesByUserID(ctx, visitor, visitor.ID)
+		if err != nil {
+			return
+		}
+	}
+
+	return logics.CheckVisitorType(visitor, roleTypes, []interfaces.VisitorType{interfaces.RealName},
+		[]interfaces.RoleType{interfaces.SuperAdmin, interfaces.SystemAdmin, interfaces.SecurityAdmin})
+}
+
+// writeLog 通过审计日志模块记录管理日志
+func (o *oidc) writeLog(visitor *interfaces.Visitor, userID string, level audit.LogLevel, opType audit.ManageOpType, objID, issuer string, msgID int, args ...any) {
+	audit.LogManagement(o.audit, o.logger, visitor, &audit.ManagementLog{
+		UserID: userID,
+		Level:  level,
+		OpType: opType,
+		ObjID:  objID,
+		Msg:    o.i18n.Load(msgID, o.lang, args...),
+		ExMsg:  o.i18n.Load(i18nOIDCExMsg, o.lang, issuer),
+	})
+}
+
+// checkProvider 校验身份提供方配置
+func checkProvider(provider *interfaces.OIDCProvider) error {
+	if !isHTTPURL(provider.Issuer) {
+		return rest.NewHTTPError("invalid issuer", rest.BadRequest, map[string]interface{}{"params": []string{"issuer"}})
+	}
+	if !isHTTPURL(provider.RedirectURI) {
+		return rest.NewHTTPError("invalid redirect_uri", rest.BadRequest, map[string]interface{}{"params": []string{"redirect_uri"}})
+	}
+	if len(provider.MappingRules) == 0 {
+		return rest.NewHTTPError("mapping_rules is required", rest.BadRequest, map[string]interface{}{"params": []string{"mapping_rules"}})
+	}
+	for _, rule := range provider.MappingRules {
+		if rule.Claim == "" || !matchTypes[rule.MatchBy] {
+			return rest.NewHTTPError("invalid mapping rule", rest.BadRequest, map[string]interface{}{"params": []string{"mapping_rules"}})
+		}
+	}
+	return nil
+}
+
+// normalizeScopes 去除重复及空的权限范围，并保证包含openid
+func normalizeScopes(scopes []string) []string {
+	result := []string{scopeOpenID}
+	seen := map[string]bool{scopeOpenID: true}
+	for _, scope := range scopes {
+		if scope == "" || seen[scope] {
+			continue
+		}
+		seen[scope] = true
+		result = append(result, scope)
+	}
+	return result
+}
+
+func isHTTPURL(str string) bool {
+	u, err := url.Parse(str)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
+
+// randomString 生成base64url编码的随机值
+func randomString() (string, error) {
+	buf := make([]byte, randomSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// claimString 获取字符串或数值类型的声明
+func claimString(claims map[string]interface{}, name string) string {
+	switch v := claims[name].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// emailVerified 仅在身份提供方明确声明邮箱已验证时返回true，未返回email_verified声明时视为未验证
+func emailVerified(claims map[string]interface{}) bool {
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	default:
+		return false
+	}
+}
+
+// scopedThirdID 第三方用户ID以签发者限定，避免不同身份提供方的sub相同时匹配到同一账户
+func scopedThirdID(issuer, sub string) string {
+	return issuer + "|" + sub
+}
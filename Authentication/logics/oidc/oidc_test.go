@@ -0,0 +1,598 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kweaver-ai/go-lib/rest"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+	"gotest.tools/assert"
+
+	"Authentication/common"
+	"Authentication/interfaces"
+	"Authentication/interfaces/mock"
+	laudit "Authentication/logics/audit"
+)
+
+const (
+	testClientID     = "client1"
+	testClientSecret = "secret1"
+	testRedirectURI  = "https://anyshare.example.com/oidc/callback"
+	testCode         = "code1"
+	testProviderID   = "01HZX5Q7N0Y6S8D3T4V5W6X7Y8"
+)
+
+// mockIdP 本地模拟的OIDC身份提供方，提供服务发现、JWKS及令牌接口
+type mockIdP struct {
+	server    *httptest.Server
+	mu        sync.Mutex
+	key       *rsa.PrivateKey
+	kid       string
+	claims    map[string]interface{}
+	tokenForm url.Values
+	tokenAuth [2]string
+	jwksHits  int
+	tokenCode int
+}
+
+func newMockIdP() *mockIdP {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	idp := &mockIdP{key: key, kid: "key1", tokenCode: http.StatusOK}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+		idp.jwksHits++
+		_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &idp.key.PublicKey, KeyID: idp.kid, Algorithm: string(jose.RS256), Use: "sig"},
+		}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		user, pass, _ := r.BasicAuth()
+		idp.mu.Lock()
+		idp.tokenForm = r.PostForm
+		idp.tokenAuth = [2]string{user, pass}
+		code := idp.tokenCode
+		idp.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if code != http.StatusOK {
+			w.WriteHeader(code)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"id_token":     idp.sign(jose.RS256, idp.key, idp.kid, idp.claims),
+		})
+	})
+	idp.server = httptest.NewServer(mux)
+	return idp
+}
+
+// sign 签发ID令牌
+func (idp *mockIdP) sign(alg jose.SignatureAlgorithm, key interface{}, kid string, claims map[string]interface{}) string {
+	opts := (&jose.SignerOptions{}).WithType("JWT")
+	if kid != "" {
+		opts = opts.WithHeader("kid", kid)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, opts)
+	if err != nil {
+		panic(err)
+	}
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
+
+// rotateKey 模拟身份提供方轮换签名密钥
+func (idp *mockIdP) rotateKey(kid string) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.key = key
+	idp.kid = kid
+}
+
+func (idp *mockIdP) defaultClaims(nonce string) map[string]interface{} {
+	now := common.Now()
+	return map[string]interface{}{
+		"iss":                idp.server.URL,
+		"sub":                "idp-user-1",
+		"aud":                testClientID,
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"iat":                now.Unix(),
+		"nonce":              nonce,
+		"email":              "user1@example.com",
+		"email_verified":     true,
+		"preferred_username": "user1",
+		"name":               "User One",
+	}
+}
+
+func newOIDC(db interfaces.DBOIDC, userMgnt interfaces.DnUserManagement, shareMgnt interfaces.DnShareMgnt,
+	audit interfaces.LogicsAudit, trace interfaces.TraceClient, client *http.Client) *oidc {
+	return &oidc{
+		db:        db,
+		userMgnt:  userMgnt,
+		shareMgnt: shareMgnt,
+		audit:     audit,
+		client:    client,
+		cache:     make(map[string]*providerCache),
+		lang:      interfaces.SimplifiedChinese,
+		i18n:      common.NewI18n(common.I18nMap{}),
+		logger:    common.NewLogger(),
+		trace:     trace,
+	}
+}
+
+func testProvider(issuer string) *interfaces.OIDCProvider {
+	return &interfaces.OIDCProvider{
+		ID:           testProviderID,
+		Name:         "idp",
+		Issuer:       issuer,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		Scopes:       []string{"openid", "email"},
+		RedirectURI:  testRedirectURI,
+		MappingRules: []interfaces.OIDCMappingRule{
+			{Claim: "sub", MatchBy: interfaces.OIDCMatchByThirdID},
+			{Claim: "email", MatchBy: interfaces.OIDCMatchByEmail},
+		},
+		Enabled: true,
+	}
+}
+
+func TestAddProvider(t *testing.T) {
+	Convey("AddProvider", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBOIDC(ctrl)
+		userMgnt := mock.NewMockDnUserManagement(ctrl)
+		audit := mock.NewMockLogicsAudit(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		o := newOIDC(db, userMgnt, nil, audit, trace, http.DefaultClient)
+
+		ctx := context.Background()
+		trace.EXPECT().SetInternalSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddInternalTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		visitor := &interfaces.Visitor{ID: "admin", Type: interfaces.RealName}
+		provider := testProvider("https://idp.example.com")
+		provider.ID = ""
+		provider.Scopes = []string{"email", "email", ""}
+
+		Convey("not admin", func() {
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any(), "admin").Return([]interfaces.RoleType{interfaces.NormalUser}, nil)
+
+			_, err := o.AddProvider(ctx, visitor, provider)
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.Unauthorized)
+		})
+
+		Convey("invalid issuer", func() {
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any(), "admin").Return([]interfaces.RoleType{interfaces.SuperAdmin}, nil)
+			provider.Issuer = "idp.example.com"
+
+			_, err := o.AddProvider(ctx, visitor, provider)
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.BadRequest)
+		})
+
+		Convey("invalid mapping rule", func() {
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any(), "admin").Return([]interfaces.RoleType{interfaces.SuperAdmin}, nil)
+			provider.MappingRules = []interfaces.OIDCMappingRule{{Claim: "sub", MatchBy: "phone"}}
+
+			_, err := o.AddProvider(ctx, visitor, provider)
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.BadRequest)
+		})
+
+		Convey("success", func() {
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any(), "admin").Return([]interfaces.RoleType{interfaces.SecurityAdmin}, nil)
+			db.EXPECT().AddProvider(gomock.Any(), provider).Return(nil)
+			audit.EXPECT().Log(laudit.TopicManagementLog, gomock.Any()).Return(nil)
+
+			id, err := o.AddProvider(ctx, visitor, provider)
+			assert.Equal(t, err, nil)
+			assert.Assert(t, id != "")
+			assert.Equal(t, provider.ID, id)
+			assert.DeepEqual(t, provider.Scopes, []string{"openid", "email"})
+			assert.Assert(t, provider.CreateTime > 0)
+		})
+	})
+}
+
+func TestUpdateProvider(t *testing.T) {
+	Convey("UpdateProvider", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBOIDC(ctrl)
+		userMgnt := mock.NewMockDnUserManagement(ctrl)
+		audit := mock.NewMockLogicsAudit(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		o := newOIDC(db, userMgnt, nil, audit, trace, http.DefaultClient)
+
+		ctx := context.Background()
+		trace.EXPECT().SetInternalSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddInternalTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		visitor := &interfaces.Visitor{ID: "admin", Type: interfaces.RealName}
+		userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any(), "admin").Return([]interfaces.RoleType{interfaces.SuperAdmin}, nil)
+
+		existing := testProvider("https://idp.example.com")
+		existing.CreateTime = 100
+
+		Convey("not exist", func() {
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(nil, nil)
+
+			err := o.UpdateProvider(ctx, visitor, testProvider("https://idp.example.com"))
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.URINotExist)
+		})
+
+		Convey("keep client secret", func() {
+			provider := testProvider("https://idp2.example.com")
+			provider.ClientSecret = ""
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(existing, nil)
+			db.EXPECT().UpdateProvider(gomock.Any(), provider).Return(true, nil)
+			audit.EXPECT().Log(laudit.TopicManagementLog, gomock.Any()).Return(nil)
+
+			err := o.UpdateProvider(ctx, visitor, provider)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, provider.ClientSecret, testClientSecret)
+			assert.Equal(t, provider.CreateTime, int64(100))
+		})
+	})
+}
+
+func TestListEnabledProviders(t *testing.T) {
+	Convey("ListEnabledProviders", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBOIDC(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		o := newOIDC(db, nil, nil, nil, trace, http.DefaultClient)
+
+		ctx := context.Background()
+		trace.EXPECT().SetInternalSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddInternalTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		db.EXPECT().GetProviders(gomock.Any()).Return([]interfaces.OIDCProvider{
+			{ID: "p1", Enabled: true}, {ID: "p2"}, {ID: "p3", Enabled: true},
+		}, nil)
+
+		providers, err := o.ListEnabledProviders(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(providers), 2)
+		assert.Equal(t, providers[0].ID, "p1")
+		assert.Equal(t, providers[1].ID, "p3")
+	})
+}
+
+func TestBeginAuth(t *testing.T) {
+	Convey("BeginAuth", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		idp := newMockIdP()
+		defer idp.server.Close()
+
+		db := mock.NewMockDBOIDC(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		o := newOIDC(db, nil, nil, nil, trace, idp.server.Client())
+
+		ctx := context.Background()
+		trace.EXPECT().SetInternalSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddInternalTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		visitor := &interfaces.Visitor{}
+		provider := testProvider(idp.server.URL)
+
+		Convey("not enabled", func() {
+			provider.Enabled = false
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+
+			_, err := o.BeginAuth(ctx, visitor, testProviderID)
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.Forbidden)
+		})
+
+		Convey("issuer mismatch", func() {
+			provider.Issuer = idp.server.URL + "/"
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+
+			_, err := o.BeginAuth(ctx, visitor, testProviderID)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.CannotConnectThirdPartyServer)
+		})
+
+		Convey("success", func() {
+			var saved *interfaces.OIDCAuthState
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+			db.EXPECT().CreateState(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, state *interfaces.OIDCAuthState, expireBefore int64) error {
+					saved = state
+					assert.Equal(t, expireBefore, state.CreateTime-stateTimeout)
+					return nil
+				})
+
+			authURL, err := o.BeginAuth(ctx, visitor, testProviderID)
+			assert.Equal(t, err, nil)
+
+			u, err := url.Parse(authURL)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, u.Scheme+"://"+u.Host+u.Path, idp.server.URL+"/authorize")
+			query := u.Query()
+			challenge := sha256.Sum256([]byte(saved.CodeVerifier))
+			assert.Equal(t, query.Get("response_type"), "code")
+			assert.Equal(t, query.Get("client_id"), testClientID)
+			assert.Equal(t, query.Get("redirect_uri"), testRedirectURI)
+			assert.Equal(t, query.Get("scope"), "openid email")
+			assert.Equal(t, query.Get("state"), saved.State)
+			assert.Equal(t, query.Get("nonce"), saved.Nonce)
+			assert.Equal(t, query.Get("code_challenge"), base64.RawURLEncoding.EncodeToString(challenge[:]))
+			assert.Equal(t, query.Get("code_challenge_method"), "S256")
+			assert.Equal(t, saved.ProviderID, testProviderID)
+		})
+	})
+}
+
+//nolint:lll
+func TestAuthenticate(t *testing.T) {
+	Convey("Authenticate", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		idp := newMockIdP()
+		defer idp.server.Close()
+
+		db := mock.NewMockDBOIDC(ctrl)
+		userMgnt := mock.NewMockDnUserManagement(ctrl)
+		shareMgnt := mock.NewMockDnShareMgnt(ctrl)
+		audit := mock.NewMockLogicsAudit(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		o := newOIDC(db, userMgnt, shareMgnt, audit, trace, idp.server.Client())
+
+		ctx := context.Background()
+		trace.EXPECT().SetInternalSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddInternalTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		visitor := &interfaces.Visitor{}
+		provider := testProvider(idp.server.URL)
+		state := &interfaces.OIDCAuthState{
+			State:        "state1",
+			ProviderID:   testProviderID,
+			Nonce:        "nonce1",
+			CodeVerifier: "verifier1",
+			CreateTime:   common.Now().Unix(),
+		}
+		idp.claims = idp.defaultClaims("nonce1")
+
+		Convey("state not found", func() {
+			db.EXPECT().ConsumeState(gomock.Any(), "state1").Return(nil, nil)
+
+			_, err := o.Authenticate(ctx, visitor, "state1", testCode)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.OIDCAuthFailed)
+		})
+
+		Convey("state expired", func() {
+			state.CreateTime -= stateTimeout + 1
+			db.EXPECT().ConsumeState(gomock.Any(), "state1").Return(state, nil)
+
+			_, err := o.Authenticate(ctx, visitor, "state1", testCode)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.OIDCAuthFailed)
+		})
+
+		Convey("provider disabled after authorize", func() {
+			provider.Enabled = false
+			db.EXPECT().ConsumeState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+
+			_, err := o.Authenticate(ctx, visitor, "state1", testCode)
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.Forbidden)
+		})
+
+		Convey("token endpoint rejects code", func() {
+			idp.tokenCode = http.StatusBadRequest
+			db.EXPECT().ConsumeState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+
+			_, err := o.Authenticate(ctx, visitor, "state1", testCode)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.OIDCAuthFailed)
+		})
+
+		Convey("nonce mismatch", func() {
+			idp.claims["nonce"] = "other"
+			db.EXPECT().ConsumeState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+
+			_, err := o.Authenticate(ctx, visitor, "state1", testCode)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.OIDCAuthFailed)
+		})
+
+		Convey("audience mismatch", func() {
+			idp.claims["aud"] = "other-client"
+			db.EXPECT().ConsumeState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+
+			_, err := o.Authenticate(ctx, visitor, "state1", testCode)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.OIDCAuthFailed)
+		})
+
+		Convey("multiple audiences without azp", func() {
+			idp.claims["aud"] = []string{testClientID, "other-client"}
+			db.EXPECT().ConsumeState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+
+			_, err := o.Authenticate(ctx, visitor, "state1", testCode)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.OIDCAuthFailed)
+		})
+
+		Convey("token expired", func() {
+			idp.claims["exp"] = common.Now().Add(-10 * time.Minute).Unix()
+			db.EXPECT().ConsumeState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+
+			_, err := o.Authenticate(ctx, visitor, "state1", testCode)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.OIDCAuthFailed)
+		})
+
+		Convey("issuer mismatch", func() {
+			idp.claims["iss"] = "https://evil.example.com"
+			db.EXPECT().ConsumeState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+
+			_, err := o.Authenticate(ctx, visitor, "state1", testCode)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.OIDCAuthFailed)
+		})
+
+		Convey("symmetric algorithm is not allowed", func() {
+			token := idp.sign(jose.HS256, []byte("0123456789abcdef0123456789abcdef"), idp.kid, idp.claims)
+			_, err := o.verifyIDToken(ctx, provider, token, "nonce1")
+			assert.Equal(t, err, errAlgNotAllowed)
+		})
+
+		Convey("signed by unknown key", func() {
+			other, _ := rsa.GenerateKey(rand.Reader, 2048)
+			token := idp.sign(jose.RS256, other, idp.kid, idp.claims)
+			_, err := o.verifyIDToken(ctx, provider, token, "nonce1")
+			assert.Equal(t, err, errSignatureInvalid)
+		})
+
+		Convey("key rotation refreshes jwks", func() {
+			_, err := o.getProviderCache(ctx, provider.Issuer)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, idp.jwksHits, 1)
+
+			idp.rotateKey("key2")
+			token := idp.sign(jose.RS256, idp.key, "key2", idp.claims)
+
+			// 刷新间隔内不重新获取JWKS
+			_, err = o.verifyIDToken(ctx, provider, token, "nonce1")
+			assert.Equal(t, err, errSignatureInvalid)
+			assert.Equal(t, idp.jwksHits, 1)
+
+			o.cache[provider.Issuer].keysFetchTime -= jwksRefreshInterval
+			claims, err := o.verifyIDToken(ctx, provider, token, "nonce1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, claims["sub"], "idp-user-1")
+			assert.Equal(t, idp.jwksHits, 2)
+		})
+
+		Convey("matched by third id", func() {
+			db.EXPECT().ConsumeState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+			userMgnt.EXPECT().UserMatch(gomock.Any(), visitor, interfaces.OIDCMatchByThirdID, provider.Issuer+"|idp-user-1").Return(true, interfaces.UserBaseInfo{ID: "user1"}, nil)
+
+			userID, err := o.Authenticate(ctx, visitor, "state1", testCode)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, userID, "user1")
+			assert.Equal(t, idp.tokenForm.Get("grant_type"), "authorization_code")
+			assert.Equal(t, idp.tokenForm.Get("code"), testCode)
+			assert.Equal(t, idp.tokenForm.Get("redirect_uri"), testRedirectURI)
+			assert.Equal(t, idp.tokenForm.Get("code_verifier"), "verifier1")
+			assert.Equal(t, idp.tokenAuth, [2]string{testClientID, testClientSecret})
+		})
+
+		Convey("matched by email after third id", func() {
+			db.EXPECT().ConsumeState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+			userMgnt.EXPECT().UserMatch(gomock.Any(), visitor, interfaces.OIDCMatchByThirdID, provider.Issuer+"|idp-user-1").Return(false, interfaces.UserBaseInfo{}, nil)
+			userMgnt.EXPECT().UserMatch(gomock.Any(), visitor, interfaces.OIDCMatchByEmail, "user1@example.com").Return(true, interfaces.UserBaseInfo{ID: "user2"}, nil)
+
+			userID, err := o.Authenticate(ctx, visitor, "state1", testCode)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, userID, "user2")
+		})
+
+		Convey("unverified email is not used", func() {
+			idp.claims["email_verified"] = false
+			db.EXPECT().ConsumeState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+			userMgnt.EXPECT().UserMatch(gomock.Any(), visitor, interfaces.OIDCMatchByThirdID, provider.Issuer+"|idp-user-1").Return(false, interfaces.UserBaseInfo{}, nil)
+
+			_, err := o.Authenticate(ctx, visitor, "state1", testCode)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.OIDCAccountNotMatched)
+		})
+
+		Convey("missing email_verified is not used", func() {
+			delete(idp.claims, "email_verified")
+			db.EXPECT().ConsumeState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+			userMgnt.EXPECT().UserMatch(gomock.Any(), visitor, interfaces.OIDCMatchByThirdID, provider.Issuer+"|idp-user-1").Return(false, interfaces.UserBaseInfo{}, nil)
+
+			_, err := o.Authenticate(ctx, visitor, "state1", testCode)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.OIDCAccountNotMatched)
+		})
+
+		Convey("matched by account", func() {
+			provider.MappingRules = []interfaces.OIDCMappingRule{{Claim: "preferred_username", MatchBy: interfaces.OIDCMatchByAccount}}
+			db.EXPECT().ConsumeState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+			userMgnt.EXPECT().AccountMatch(gomock.Any(), visitor, "user1", false, false).Return(true, interfaces.UserBaseInfo{ID: "user3"}, nil)
+
+			userID, err := o.Authenticate(ctx, visitor, "state1", testCode)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, userID, "user3")
+		})
+
+		Convey("just-in-time provisioning", func() {
+			provider.JITProvision = true
+			db.EXPECT().ConsumeState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+			userMgnt.EXPECT().UserMatch(gomock.Any(), visitor, gomock.Any(), gomock.Any()).Times(2).Return(false, interfaces.UserBaseInfo{}, nil)
+			shareMgnt.EXPECT().UsrmAddUser(gomock.Any(), &interfaces.NewUserInfo{
+				Account: "user1",
+				Name:    "User One",
+				Email:   "user1@example.com",
+				ThirdID: provider.Issuer + "|idp-user-1",
+			}).Return("new-user", nil)
+			audit.EXPECT().Log(laudit.TopicManagementLog, gomock.Any()).Return(nil)
+
+			userID, err := o.Authenticate(ctx, visitor, "state1", testCode)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, userID, "new-user")
+		})
+
+		Convey("public client without secret", func() {
+			provider.ClientSecret = ""
+			db.EXPECT().ConsumeState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+			userMgnt.EXPECT().UserMatch(gomock.Any(), visitor, interfaces.OIDCMatchByThirdID, provider.Issuer+"|idp-user-1").Return(true, interfaces.UserBaseInfo{ID: "user1"}, nil)
+
+			_, err := o.Authenticate(ctx, visitor, "state1", testCode)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, idp.tokenForm.Get("client_id"), testClientID)
+			assert.Equal(t, idp.tokenAuth, [2]string{"", ""})
+		})
+	})
+}
@@ -0,0 +1,318 @@
+package oidc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kweaver-ai/go-lib/rest"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"Authentication/common"
+	"Authentication/interfaces"
+)
+
+const (
+	// metadataTTL 身份提供方元数据及JWKS缓存有效期，单位为秒
+	metadataTTL = 60 * 60
+	// jwksRefreshInterval 遇到未知密钥时重新获取JWKS的最小间隔，单位为秒
+	jwksRefreshInterval = 60
+	// clockLeeway ID令牌时间校验允许的时钟偏差
+	clockLeeway = time.Minute
+	// maxResponseSize 身份提供方响应大小上限
+	maxResponseSize = 1 << 20
+)
+
+var (
+	errStateInvalid       = errors.New("state is invalid or expired")
+	errIssuerMismatch     = errors.New("issuer in discovery document mismatch")
+	errMetadataIncomplete = errors.New("discovery document is incomplete")
+	errIDTokenMissing     = errors.New("id_token missing in token response")
+	errAlgNotAllowed      = errors.New("id_token signing algorithm is not allowed")
+	errSignatureInvalid   = errors.New("id_token signature is invalid")
+	errTimeClaimMissing   = errors.New("id_token exp or iat missing")
+	errAZPMismatch        = errors.New("id_token azp mismatch")
+	errNonceMismatch      = errors.New("id_token nonce mismatch")
+)
+
+// allowedAlgorithms ID令牌仅允许非对称签名算法，避免使用公钥作为HMAC密钥伪造签名
+var allowedAlgorithms = map[string]bool{
+	string(jose.RS256): true,
+	string(jose.RS384): true,
+	string(jose.RS512): true,
+	string(jose.PS256): true,
+	string(jose.PS384): true,
+	string(jose.PS512): true,
+	string(jose.ES256): true,
+	string(jose.ES384): true,
+	string(jose.ES512): true,
+	string(jose.EdDSA): true,
+}
+
+// providerMetadata 身份提供方服务发现文档
+type providerMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// providerCache 身份提供方元数据缓存，缓存对象只读，刷新时整体替换
+type providerCache struct {
+	metadata      providerMetadata
+	keys          []jose.JSONWebKey
+	fetchTime     int64
+	keysFetchTime int64
+}
+
+// idTokenClaims ID令牌标准声明
+type idTokenClaims struct {
+	jwt.Claims
+	Nonce           string `json:"nonce"`
+	AuthorizedParty string `json:"azp"`
+}
+
+// getProviderCache 获取身份提供方元数据及JWKS，缓存过期时重新获取
+func (o *oidc) getProviderCache(ctx context.Context, issuer string) (*providerCache, error) {
+	now := common.Now().Unix()
+	o.mu.Lock()
+	cache := o.cache[issuer]
+	o.mu.Unlock()
+	if cache != nil && now-cache.fetchTime < metadataTTL {
+		return cache, nil
+	}
+
+	var metadata providerMetadata
+	if err := o.getJSON(ctx, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", &metadata); err != nil {
+		return nil, o.connectFailed(issuer, err)
+	}
+	if metadata.Issuer != issuer {
+		return nil, o.connectFailed(issuer, errIssuerMismatch)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, o.connectFailed(issuer, errMetadataIncomplete)
+	}
+
+	keys, err := o.getKeySet(ctx, metadata.JWKSURI)
+	if err != nil {
+		return nil, o.connectFailed(issuer, err)
+	}
+
+	cache = &providerCache{metadata: metadata, keys: keys, fetchTime: now, keysFetchTime: now}
+	o.mu.Lock()
+	o.cache[issuer] = cache
+	o.mu.Unlock()
+	return cache, nil
+}
+
+// getVerificationKeys 获取可用于校验签名的密钥，密钥轮换导致未知kid时重新获取一次JWKS
+func (o *oidc) getVerificationKeys(ctx context.Context, issuer, kid string) ([]jose.JSONWebKey, error) {
+	cache, err := o.getProviderCache(ctx, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := selectKeys(cache.keys, kid)
+	now := common.Now().Unix()
+	if len(keys) > 0 || now-cache.keysFetchTime < jwksRefreshInterval {
+		return keys, nil
+	}
+
+	newKeys, err := o.getKeySet(ctx, cache.metadata.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshed := *cache
+	refreshed.keys = newKeys
+	refreshed.keysFetchTime = now
+	o.mu.Lock()
+	o.cache[issuer] = &refreshed
+	o.mu.Unlock()
+	return selectKeys(newKeys, kid), nil
+}
+
+// getKeySet 获取JWKS，忽略无法解析的密钥
+func (o *oidc) getKeySet(ctx context.Context, jwksURI string) ([]jose.JSONWebKey, error) {
+	var set struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := o.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make([]jose.JSONWebKey, 0, len(set.Keys))
+	for _, raw := range set.Keys {
+		var key jose.JSONWebKey
+		if err := key.UnmarshalJSON(raw); err != nil {
+			o.logger.Warnf("oidc ignore invalid jwk, uri: %s, err: %v", jwksURI, err)
+			continue
+		}
+		if !key.IsPublic() || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// exchangeCode 使用授权码及PKCE校验值换取ID令牌
+func (o *oidc) exchangeCode(ctx context.Context, provider *interfaces.OIDCProvider, tokenEndpoint, code, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {provider.RedirectURI},
+		"code_verifier": {verifier},
+	}
+	// 未配置客户端密钥时作为公开客户端，仅依靠PKCE保护授权码
+	if provider.ClientSecret == "" {
+		form.Set("client_id", provider.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if provider.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(provider.ClientID), url.QueryEscape(provider.ClientSecret))
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			o.logger.Errorln(closeErr)
+		}
+	}()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err = json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&body); err != nil {
+		return "", fmt.Errorf("decode token response failed, status: %d, err: %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %d: %s %s", resp.StatusCode, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return "", errIDTokenMissing
+	}
+	return body.IDToken, nil
+}
+
+// verifyIDToken 校验ID令牌签名、颁发者、受众、有效期及防重放随机值，返回所有声明
+//
+//nolint:gocyclo
+func (o *oidc) verifyIDToken(ctx context.Context, provider *interfaces.OIDCProvider, rawToken, nonce string) (map[string]interface{}, error) {
+	token, err := jwt.ParseSigned(rawToken)
+	if err != nil {
+		return nil, err
+	}
+	if len(token.Headers) != 1 {
+		return nil, errSignatureInvalid
+	}
+	header := token.Headers[0]
+	if !allowedAlgorithms[header.Algorithm] {
+		return nil, errAlgNotAllowed
+	}
+
+	keys, err := o.getVerificationKeys(ctx, provider.Issuer, header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var std idTokenClaims
+	var claims map[string]interface{}
+	verified := false
+	for i := range keys {
+		if keys[i].Algorithm != "" && keys[i].Algorithm != header.Algorithm {
+			continue
+		}
+		if token.Claims(keys[i].Key, &std, &claims) == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, errSignatureInvalid
+	}
+
+	expected := jwt.Expected{
+		Issuer:   provider.Issuer,
+		Audience: jwt.Audience{provider.ClientID},
+		Time:     common.Now(),
+	}
+	if err = std.Claims.ValidateWithLeeway(expected, clockLeeway); err != nil {
+		return nil, err
+	}
+	if std.Expiry == nil || std.IssuedAt == nil {
+		return nil, errTimeClaimMissing
+	}
+	// 存在多个受众时，授权方必须为当前客户端
+	if (len(std.Audience) > 1 || std.AuthorizedParty != "") && std.AuthorizedParty != provider.ClientID {
+		return nil, errAZPMismatch
+	}
+	if subtle.ConstantTimeCompare([]byte(std.Nonce), []byte(nonce)) != 1 {
+		return nil, errNonceMismatch
+	}
+
+	return claims, nil
+}
+
+// getJSON 请求身份提供方并解析json响应
+func (o *oidc) getJSON(ctx context.Context, target string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, http.NoBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			o.logger.Errorln(closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, target)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(out)
+}
+
+// connectFailed 记录获取身份提供方元数据失败原因
+func (o *oidc) connectFailed(issuer string, reason error) error {
+	o.logger.Errorf("oidc get provider metadata failed, issuer: %s, reason: %v", issuer, reason)
+	return rest.NewHTTPError("", common.CannotConnectThirdPartyServer, nil)
+}
+
+// selectKeys 按kid选择密钥，ID令牌未指定kid时尝试所有密钥
+func selectKeys(keys []jose.JSONWebKey, kid string) []jose.JSONWebKey {
+	if kid == "" {
+		return keys
+	}
+	selected := make([]jose.JSONWebKey, 0, 1)
+	for i := range keys {
+		if keys[i].KeyID == kid {
+			selected = append(selected, keys[i])
+		}
+	}
+	return selected
+}
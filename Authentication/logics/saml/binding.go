@@ -0,0 +1,219 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+
+	"Authentication/interfaces"
+)
+
+const (
+	paramSAMLRequest  = "SAMLRequest"
+	paramSAMLResponse = "SAMLResponse"
+	paramRelayState   = "RelayState"
+	paramSigAlg       = "SigAlg"
+	paramSignature    = "Signature"
+
+	// maxMessageSize 解码后报文大小上限
+	maxMessageSize = 1 << 20
+)
+
+var (
+	errMessageTooLarge = errors.New("saml message is too large")
+	errMessageMissing  = errors.New("saml message missing")
+)
+
+// encodeRedirect 按HTTP-Redirect绑定编码报文，报文经DEFLATE压缩，对查询参数签名
+func (s *signer) encodeRedirect(target, param string, doc []byte, relayState string) (*interfaces.SAMLRedirect, error) {
+	var compressed bytes.Buffer
+	writer, err := flate.NewWriter(&compressed, flate.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = writer.Write(doc); err != nil {
+		return nil, err
+	}
+	if err = writer.Close(); err != nil {
+		return nil, err
+	}
+
+	query := param + "=" + url.QueryEscape(base64.StdEncoding.EncodeToString(compressed.Bytes()))
+	if relayState != "" {
+		query += "&" + paramRelayState + "=" + url.QueryEscape(relayState)
+	}
+	query += "&" + paramSigAlg + "=" + url.QueryEscape(s.algorithm())
+	sig, err := s.sign([]byte(query))
+	if err != nil {
+		return nil, err
+	}
+	query += "&" + paramSignature + "=" + url.QueryEscape(base64.StdEncoding.EncodeToString(sig))
+
+	separator := "?"
+	if strings.Contains(target, "?") {
+		separator = "&"
+	}
+	return &interfaces.SAMLRedirect{Binding: interfaces.SAMLBindingRedirect, URL: target + separator + query}, nil
+}
+
+// encodePost 按HTTP-POST绑定编码报文，报文使用信封式签名
+func (s *signer) encodePost(target, param string, doc []byte, relayState string) (*interfaces.SAMLRedirect, error) {
+	signed, err := s.signEnveloped(doc)
+	if err != nil {
+		return nil, err
+	}
+	params := map[string]string{param: base64.StdEncoding.EncodeToString(signed)}
+	if relayState != "" {
+		params[paramRelayState] = relayState
+	}
+	return &interfaces.SAMLRedirect{Binding: interfaces.SAMLBindingPost, URL: target, Params: params}, nil
+}
+
+// encode 按对端服务地址的绑定编码报文
+func (s *signer) encode(e *endpoint, target, param string, doc []byte, relayState string) (*interfaces.SAMLRedirect, error) {
+	if e.binding == bindingRedirect {
+		return s.encodeRedirect(target, param, doc, relayState)
+	}
+	return s.encodePost(target, param, doc, relayState)
+}
+
+// decodeMessage 解码并校验收到的报文签名，HTTP-Redirect绑定校验查询参数签名，HTTP-POST绑定校验报文信封式签名，
+// certsFor根据报文的Issuer获取身份提供方证书
+func decodeMessage(msg *interfaces.SAMLMessage, param string, certsFor func(root *xmlNode) ([]*x509.Certificate, error)) (*xmlNode, error) {
+	var raw map[string]string
+	encoded := msg.SAMLRequest
+	if param == paramSAMLResponse {
+		encoded = msg.SAMLResponse
+	}
+	if msg.Redirect {
+		// 签名基于原始编码的查询参数，报文同样取自原始查询参数，保证校验内容与使用内容一致
+		var err error
+		if raw, err = parseRawQuery(msg.RawQuery); err != nil {
+			return nil, err
+		}
+		if encoded, err = url.QueryUnescape(raw[param]); err != nil {
+			return nil, err
+		}
+	}
+	if encoded == "" {
+		return nil, errMessageMissing
+	}
+	data, err := base64.StdEncoding.DecodeString(stripSpace(encoded))
+	if err != nil {
+		return nil, err
+	}
+
+	if msg.Redirect {
+		data, err = inflate(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(data) > maxMessageSize {
+		return nil, errMessageTooLarge
+	}
+
+	root, err := parseXML(data)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := certsFor(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if msg.Redirect {
+		err = verifyRedirectSignature(raw, param, certs)
+	} else {
+		err = verifyEnveloped(root, certs)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// decodePost 解码HTTP-POST绑定的报文，签名由调用方按报文类型校验
+func decodePost(encoded string) (*xmlNode, error) {
+	if encoded == "" {
+		return nil, errMessageMissing
+	}
+	data, err := base64.StdEncoding.DecodeString(stripSpace(encoded))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxMessageSize {
+		return nil, errMessageTooLarge
+	}
+	return parseXML(data)
+}
+
+// parseRawQuery 解析查询参数并保留原始编码，重复的参数视为非法
+func parseRawQuery(rawQuery string) (map[string]string, error) {
+	raw := make(map[string]string)
+	for _, pair := range strings.Split(rawQuery, "&") {
+		key, value, _ := strings.Cut(pair, "=")
+		if _, exists := raw[key]; exists {
+			return nil, errSignatureInvalid
+		}
+		raw[key] = value
+	}
+	return raw, nil
+}
+
+// verifyRedirectSignature 使用原始编码的查询参数校验HTTP-Redirect绑定签名
+func verifyRedirectSignature(raw map[string]string, param string, certs []*x509.Certificate) error {
+	rawSigAlg, ok := raw[paramSigAlg]
+	if !ok || raw[paramSignature] == "" {
+		return errSignatureMissing
+	}
+	sigAlg, err := url.QueryUnescape(rawSigAlg)
+	if err != nil {
+		return errSignatureInvalid
+	}
+	hash, ok := signatureAlgorithms[sigAlg]
+	if !ok {
+		return errSignatureAlgorithm
+	}
+	sigStr, err := url.QueryUnescape(raw[paramSignature])
+	if err != nil {
+		return errSignatureInvalid
+	}
+	sig, err := base64.StdEncoding.DecodeString(sigStr)
+	if err != nil {
+		return errSignatureInvalid
+	}
+
+	signed := param + "=" + raw[param]
+	if relayState, ok := raw[paramRelayState]; ok {
+		signed += "&" + paramRelayState + "=" + relayState
+	}
+	signed += "&" + paramSigAlg + "=" + rawSigAlg
+
+	for _, cert := range certs {
+		if verifySignature(cert.PublicKey, hash, []byte(signed), sig) == nil {
+			return nil
+		}
+	}
+	return errSignatureInvalid
+}
+
+// inflate 解压HTTP-Redirect绑定报文，限制解压后大小
+func inflate(data []byte) ([]byte, error) {
+	reader := flate.NewReader(bytes.NewReader(data))
+	defer func() { _ = reader.Close() }()
+
+	result, err := io.ReadAll(io.LimitReader(reader, maxMessageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(result) > maxMessageSize {
+		return nil, errMessageTooLarge
+	}
+	return result, nil
+}
@@ -0,0 +1,396 @@
+package saml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	nsXML     = "http://www.w3.org/XML/1998/namespace"
+	prefixXML = "xml"
+	// maxXMLDepth 报文最大嵌套深度
+	maxXMLDepth = 64
+)
+
+var (
+	errXMLDirective   = errors.New("xml directive is not allowed")
+	errXMLMalformed   = errors.New("xml is malformed")
+	errXMLNamespace   = errors.New("xml namespace prefix is not declared")
+	errXMLTooDeep     = errors.New("xml nesting is too deep")
+	errXMLDuplicateID = errors.New("xml contains duplicate ID")
+)
+
+// xmlAttr 元素属性，保留原始前缀
+type xmlAttr struct {
+	prefix string
+	local  string
+	value  string
+}
+
+// xmlNode 保留命名空间前缀的元素节点，用于规范化及签名校验
+type xmlNode struct {
+	prefix   string
+	local    string
+	space    string
+	attrs    []xmlAttr
+	decls    map[string]string // 本元素声明的命名空间，默认命名空间前缀为空
+	children []interface{}     // *xmlNode、xml.CharData、xml.Comment、xml.ProcInst
+	parent   *xmlNode
+}
+
+// parseXML 解析报文为元素树，禁止DTD以避免实体扩展攻击，并拒绝重复的ID属性以避免签名包装攻击
+func parseXML(data []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+
+	var root, current *xmlNode
+	depth := 0
+	for {
+		tok, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if root != nil && current == nil {
+				return nil, errXMLMalformed
+			}
+			depth++
+			if depth > maxXMLDepth {
+				return nil, errXMLTooDeep
+			}
+			node := &xmlNode{prefix: t.Name.Space, local: t.Name.Local, decls: map[string]string{}, parent: current}
+			for _, attr := range t.Attr {
+				switch {
+				case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+					node.decls[""] = attr.Value
+				case attr.Name.Space == "xmlns":
+					node.decls[attr.Name.Local] = attr.Value
+				default:
+					node.attrs = append(node.attrs, xmlAttr{prefix: attr.Name.Space, local: attr.Name.Local, value: attr.Value})
+				}
+			}
+			space, ok := node.lookup(node.prefix)
+			if !ok {
+				return nil, errXMLNamespace
+			}
+			node.space = space
+			for _, attr := range node.attrs {
+				if _, ok := node.lookup(attr.prefix); attr.prefix != "" && !ok {
+					return nil, errXMLNamespace
+				}
+			}
+
+			if current == nil {
+				root = node
+			} else {
+				current.children = append(current.children, node)
+			}
+			current = node
+		case xml.EndElement:
+			if current == nil || current.prefix != t.Name.Space || current.local != t.Name.Local {
+				return nil, errXMLMalformed
+			}
+			depth--
+			current = current.parent
+		case xml.CharData:
+			if current != nil {
+				current.children = append(current.children, t.Copy())
+			} else if len(bytes.TrimSpace(t)) > 0 {
+				return nil, errXMLMalformed
+			}
+		case xml.Comment:
+			if current != nil {
+				current.children = append(current.children, t.Copy())
+			}
+		case xml.ProcInst:
+			if current != nil {
+				current.children = append(current.children, t.Copy())
+			}
+		case xml.Directive:
+			return nil, errXMLDirective
+		}
+	}
+	if root == nil || current != nil {
+		return nil, errXMLMalformed
+	}
+
+	ids := make(map[string]bool)
+	var err error
+	root.walk(func(n *xmlNode) bool {
+		if id := n.attr("ID"); id != "" {
+			if ids[id] {
+				err = errXMLDuplicateID
+				return false
+			}
+			ids[id] = true
+		}
+		return true
+	})
+	return root, err
+}
+
+// lookup 获取前缀对应的命名空间
+func (n *xmlNode) lookup(prefix string) (string, bool) {
+	if prefix == prefixXML {
+		return nsXML, true
+	}
+	for node := n; node != nil; node = node.parent {
+		if space, ok := node.decls[prefix]; ok {
+			return space, true
+		}
+	}
+	// 未声明默认命名空间时为空命名空间
+	return "", prefix == ""
+}
+
+// is 判断元素的命名空间及名称
+func (n *xmlNode) is(space, local string) bool {
+	return n.space == space && n.local == local
+}
+
+// attr 获取无前缀属性的值
+func (n *xmlNode) attr(local string) string {
+	for _, a := range n.attrs {
+		if a.prefix == "" && a.local == local {
+			return a.value
+		}
+	}
+	return ""
+}
+
+// hasAttr 判断是否存在无前缀属性
+func (n *xmlNode) hasAttr(local string) bool {
+	for _, a := range n.attrs {
+		if a.prefix == "" && a.local == local {
+			return true
+		}
+	}
+	return false
+}
+
+// elements 获取指定命名空间及名称的子元素
+func (n *xmlNode) elements(space, local string) []*xmlNode {
+	var result []*xmlNode
+	for _, child := range n.children {
+		if c, ok := child.(*xmlNode); ok && c.is(space, local) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// element 获取第一个指定命名空间及名称的子元素
+func (n *xmlNode) element(space, local string) *xmlNode {
+	for _, child := range n.children {
+		if c, ok := child.(*xmlNode); ok && c.is(space, local) {
+			return c
+		}
+	}
+	return nil
+}
+
+// text 获取元素的文本内容
+func (n *xmlNode) text() string {
+	var buf strings.Builder
+	for _, child := range n.children {
+		switch c := child.(type) {
+		case xml.CharData:
+			buf.Write(c)
+		case *xmlNode:
+			buf.WriteString(c.text())
+		}
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// walk 深度优先遍历元素，回调返回false时停止
+func (n *xmlNode) walk(fn func(*xmlNode) bool) bool {
+	if !fn(n) {
+		return false
+	}
+	for _, child := range n.children {
+		if c, ok := child.(*xmlNode); ok && !c.walk(fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// canonicalize 按排他式XML规范化(不含注释)输出元素，exclude为需要剔除的子元素，
+// inclusivePrefixes为InclusiveNamespaces PrefixList中的前缀，#default代表默认命名空间
+func canonicalize(n, exclude *xmlNode, inclusivePrefixes []string) ([]byte, error) {
+	inclusive := make(map[string]bool, len(inclusivePrefixes))
+	for _, p := range inclusivePrefixes {
+		if p == "#default" {
+			p = ""
+		}
+		inclusive[p] = true
+	}
+
+	var buf bytes.Buffer
+	if err := n.writeCanonical(&buf, exclude, inclusive, map[string]string{}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (n *xmlNode) writeCanonical(buf *bytes.Buffer, exclude *xmlNode, inclusive map[string]bool, rendered map[string]string) error {
+	// 可见使用的命名空间：元素前缀及属性前缀
+	used := map[string]bool{n.prefix: true}
+	for _, a := range n.attrs {
+		if a.prefix != "" && a.prefix != prefixXML {
+			used[a.prefix] = true
+		}
+	}
+	for p := range inclusive {
+		if _, ok := n.lookup(p); ok {
+			used[p] = true
+		}
+	}
+
+	prefixes := make([]string, 0, len(used))
+	for p := range used {
+		prefixes = append(prefixes, p)
+	}
+	sort.Strings(prefixes)
+
+	childRendered := make(map[string]string, len(rendered)+len(prefixes))
+	for k, v := range rendered {
+		childRendered[k] = v
+	}
+
+	buf.WriteByte('<')
+	writeQName(buf, n.prefix, n.local)
+	for _, p := range prefixes {
+		space, ok := n.lookup(p)
+		if !ok {
+			return errXMLNamespace
+		}
+		prev, wasRendered := rendered[p]
+		if p == "" && space == "" && (!wasRendered || prev == "") {
+			continue
+		}
+		if wasRendered && prev == space {
+			continue
+		}
+		childRendered[p] = space
+		if p == "" {
+			buf.WriteString(` xmlns="`)
+		} else {
+			buf.WriteString(` xmlns:`)
+			buf.WriteString(p)
+			buf.WriteString(`="`)
+		}
+		escapeAttr(buf, space)
+		buf.WriteByte('"')
+	}
+
+	type sortAttr struct {
+		space string
+		xmlAttr
+	}
+	attrs := make([]sortAttr, 0, len(n.attrs))
+	for _, a := range n.attrs {
+		space := ""
+		if a.prefix != "" {
+			space, _ = n.lookup(a.prefix)
+		}
+		attrs = append(attrs, sortAttr{space: space, xmlAttr: a})
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		if attrs[i].space != attrs[j].space {
+			return attrs[i].space < attrs[j].space
+		}
+		return attrs[i].local < attrs[j].local
+	})
+	for _, a := range attrs {
+		buf.WriteByte(' ')
+		writeQName(buf, a.prefix, a.local)
+		buf.WriteString(`="`)
+		escapeAttr(buf, a.value)
+		buf.WriteByte('"')
+	}
+	buf.WriteByte('>')
+
+	for _, child := range n.children {
+		switch c := child.(type) {
+		case *xmlNode:
+			if c == exclude {
+				continue
+			}
+			if err := c.writeCanonical(buf, exclude, inclusive, childRendered); err != nil {
+				return err
+			}
+		case xml.CharData:
+			escapeText(buf, string(c))
+		case xml.ProcInst:
+			buf.WriteString("<?")
+			buf.WriteString(c.Target)
+			if len(c.Inst) > 0 {
+				buf.WriteByte(' ')
+				buf.Write(c.Inst)
+			}
+			buf.WriteString("?>")
+		}
+	}
+
+	buf.WriteString("</")
+	writeQName(buf, n.prefix, n.local)
+	buf.WriteByte('>')
+	return nil
+}
+
+func writeQName(buf *bytes.Buffer, prefix, local string) {
+	if prefix != "" {
+		buf.WriteString(prefix)
+		buf.WriteByte(':')
+	}
+	buf.WriteString(local)
+}
+
+func escapeAttr(buf *bytes.Buffer, s string) {
+	for _, r := range s {
+		switch r {
+		case '&':
+			buf.WriteString("&amp;")
+		case '<':
+			buf.WriteString("&lt;")
+		case '"':
+			buf.WriteString("&quot;")
+		case '\t':
+			buf.WriteString("&#x9;")
+		case '\n':
+			buf.WriteString("&#xA;")
+		case '\r':
+			buf.WriteString("&#xD;")
+		default:
+			buf.WriteRune(r)
+		}
+	}
+}
+
+func escapeText(buf *bytes.Buffer, s string) {
+	for _, r := range s {
+		switch r {
+		case '&':
+			buf.WriteString("&amp;")
+		case '<':
+			buf.WriteString("&lt;")
+		case '>':
+			buf.WriteString("&gt;")
+		case '\r':
+			buf.WriteString("&#xD;")
+		default:
+			buf.WriteRune(r)
+		}
+	}
+}
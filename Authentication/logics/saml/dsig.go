@@ -0,0 +1,278 @@
+package saml
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	// 注册摘要算法
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+const (
+	nsDSig = "http://www.w3.org/2000/09/xmldsig#"
+
+	algExcC14N     = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	algEnveloped   = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	algSHA256      = "http://www.w3.org/2001/04/xmlenc#sha256"
+	algSHA384      = "http://www.w3.org/2001/04/xmldsig-more#sha384"
+	algSHA512      = "http://www.w3.org/2001/04/xmlenc#sha512"
+	algRSASHA256   = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	algRSASHA384   = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha384"
+	algRSASHA512   = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha512"
+	algECDSASHA256 = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha256"
+	algECDSASHA384 = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha384"
+	algECDSASHA512 = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha512"
+)
+
+var (
+	errSignatureMissing   = errors.New("signature missing")
+	errSignatureInvalid   = errors.New("signature is invalid")
+	errSignatureAlgorithm = errors.New("signature algorithm is not allowed")
+	errReferenceInvalid   = errors.New("signature reference is invalid")
+	errDigestMismatch     = errors.New("signature digest mismatch")
+)
+
+// digestAlgorithms 允许的摘要算法，不接受SHA-1
+var digestAlgorithms = map[string]crypto.Hash{
+	algSHA256: crypto.SHA256,
+	algSHA384: crypto.SHA384,
+	algSHA512: crypto.SHA512,
+}
+
+// signatureAlgorithms 允许的签名算法，不接受SHA-1
+var signatureAlgorithms = map[string]crypto.Hash{
+	algRSASHA256:   crypto.SHA256,
+	algRSASHA384:   crypto.SHA384,
+	algRSASHA512:   crypto.SHA512,
+	algECDSASHA256: crypto.SHA256,
+	algECDSASHA384: crypto.SHA384,
+	algECDSASHA512: crypto.SHA512,
+}
+
+// verifyEnveloped 校验元素的信封式签名，签名必须是元素的直接子元素且引用该元素自身的ID，
+// 只信任身份提供方元数据中的证书，忽略报文中携带的KeyInfo
+func verifyEnveloped(n *xmlNode, certs []*x509.Certificate) error {
+	signatures := n.elements(nsDSig, "Signature")
+	if len(signatures) == 0 {
+		return errSignatureMissing
+	}
+	if len(signatures) > 1 {
+		return errSignatureInvalid
+	}
+	signature := signatures[0]
+
+	signedInfo := signature.element(nsDSig, "SignedInfo")
+	signatureValue := signature.element(nsDSig, "SignatureValue")
+	if signedInfo == nil || signatureValue == nil {
+		return errSignatureInvalid
+	}
+
+	c14nMethod := signedInfo.element(nsDSig, "CanonicalizationMethod")
+	sigMethod := signedInfo.element(nsDSig, "SignatureMethod")
+	if c14nMethod == nil || sigMethod == nil || c14nMethod.attr("Algorithm") != algExcC14N {
+		return errSignatureAlgorithm
+	}
+	hash, ok := signatureAlgorithms[sigMethod.attr("Algorithm")]
+	if !ok {
+		return errSignatureAlgorithm
+	}
+
+	if err := verifyReference(n, signature, signedInfo); err != nil {
+		return err
+	}
+
+	canonical, err := canonicalize(signedInfo, nil, inclusivePrefixes(c14nMethod))
+	if err != nil {
+		return err
+	}
+	sigBytes, err := base64.StdEncoding.DecodeString(stripSpace(signatureValue.text()))
+	if err != nil {
+		return errSignatureInvalid
+	}
+
+	for _, cert := range certs {
+		if verifySignature(cert.PublicKey, hash, canonical, sigBytes) == nil {
+			return nil
+		}
+	}
+	return errSignatureInvalid
+}
+
+// verifyReference 校验签名引用，仅允许唯一引用指向被签名元素，且只允许信封式签名及排他式规范化转换
+func verifyReference(n, signature, signedInfo *xmlNode) error {
+	references := signedInfo.elements(nsDSig, "Reference")
+	id := n.attr("ID")
+	if len(references) != 1 || id == "" || references[0].attr("URI") != "#"+id {
+		return errReferenceInvalid
+	}
+	reference := references[0]
+
+	var prefixes []string
+	enveloped := false
+	if transforms := reference.element(nsDSig, "Transforms"); transforms != nil {
+		for _, transform := range transforms.elements(nsDSig, "Transform") {
+			switch transform.attr("Algorithm") {
+			case algEnveloped:
+				enveloped = true
+			case algExcC14N:
+				prefixes = inclusivePrefixes(transform)
+			default:
+				return errSignatureAlgorithm
+			}
+		}
+	}
+	if !enveloped {
+		return errReferenceInvalid
+	}
+
+	digestMethod := reference.element(nsDSig, "DigestMethod")
+	digestValue := reference.element(nsDSig, "DigestValue")
+	if digestMethod == nil || digestValue == nil {
+		return errReferenceInvalid
+	}
+	hash, ok := digestAlgorithms[digestMethod.attr("Algorithm")]
+	if !ok {
+		return errSignatureAlgorithm
+	}
+
+	canonical, err := canonicalize(n, signature, prefixes)
+	if err != nil {
+		return err
+	}
+	h := hash.New()
+	h.Write(canonical)
+	expected, err := base64.StdEncoding.DecodeString(stripSpace(digestValue.text()))
+	if err != nil || subtle.ConstantTimeCompare(h.Sum(nil), expected) != 1 {
+		return errDigestMismatch
+	}
+	return nil
+}
+
+// inclusivePrefixes 获取转换中InclusiveNamespaces声明的前缀列表
+func inclusivePrefixes(transform *xmlNode) []string {
+	for _, child := range transform.children {
+		if c, ok := child.(*xmlNode); ok && c.is(algExcC14N, "InclusiveNamespaces") {
+			return strings.Fields(c.attr("PrefixList"))
+		}
+	}
+	return nil
+}
+
+// verifySignature 使用公钥校验签名，ECDSA签名为r||s格式
+func verifySignature(publicKey crypto.PublicKey, hash crypto.Hash, data, sig []byte) error {
+	h := hash.New()
+	h.Write(data)
+	digest := h.Sum(nil)
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, hash, digest, sig)
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return errSignatureInvalid
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return errSignatureInvalid
+		}
+		return nil
+	default:
+		return errSignatureAlgorithm
+	}
+}
+
+// signer 服务提供方签名密钥
+type signer struct {
+	key  *rsa.PrivateKey
+	cert *x509.Certificate
+}
+
+// algorithm 签名算法标识
+func (s *signer) algorithm() string {
+	return algRSASHA256
+}
+
+// sign 对数据签名
+func (s *signer) sign(data []byte) ([]byte, error) {
+	digest := crypto.SHA256.New()
+	digest.Write(data)
+	return rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest.Sum(nil))
+}
+
+// signEnveloped 为报文根元素生成信封式签名，签名插入到Issuer元素之后
+func (s *signer) signEnveloped(doc []byte) ([]byte, error) {
+	root, err := parseXML(doc)
+	if err != nil {
+		return nil, err
+	}
+	id := root.attr("ID")
+	canonical, err := canonicalize(root, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	digest := crypto.SHA256.New()
+	digest.Write(canonical)
+
+	signedInfo := fmt.Sprintf(`<ds:SignedInfo xmlns:ds="%s">`+
+		`<ds:CanonicalizationMethod Algorithm="%s"></ds:CanonicalizationMethod>`+
+		`<ds:SignatureMethod Algorithm="%s"></ds:SignatureMethod>`+
+		`<ds:Reference URI="#%s"><ds:Transforms>`+
+		`<ds:Transform Algorithm="%s"></ds:Transform><ds:Transform Algorithm="%s"></ds:Transform>`+
+		`</ds:Transforms><ds:DigestMethod Algorithm="%s"></ds:DigestMethod>`+
+		`<ds:DigestValue>%s</ds:DigestValue></ds:Reference></ds:SignedInfo>`,
+		nsDSig, algExcC14N, s.algorithm(), id, algEnveloped, algExcC14N, algSHA256,
+		base64.StdEncoding.EncodeToString(digest.Sum(nil)))
+
+	// SignedInfo自身声明了命名空间且无其他可见使用的命名空间，规范化结果与上下文无关
+	signedInfoNode, err := parseXML([]byte(signedInfo))
+	if err != nil {
+		return nil, err
+	}
+	canonicalSignedInfo, err := canonicalize(signedInfoNode, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := s.sign(canonicalSignedInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	signature := fmt.Sprintf(`<ds:Signature xmlns:ds="%s">%s<ds:SignatureValue>%s</ds:SignatureValue>`+
+		`<ds:KeyInfo><ds:X509Data><ds:X509Certificate>%s</ds:X509Certificate></ds:X509Data></ds:KeyInfo></ds:Signature>`,
+		nsDSig, canonicalSignedInfo, base64.StdEncoding.EncodeToString(sig),
+		base64.StdEncoding.EncodeToString(s.cert.Raw))
+
+	// 报文由本服务生成，Issuer为根元素的第一个子元素
+	idx := bytes.Index(doc, []byte("</saml:Issuer>"))
+	if idx < 0 {
+		return nil, errXMLMalformed
+	}
+	idx += len("</saml:Issuer>")
+	signed := make([]byte, 0, len(doc)+len(signature))
+	signed = append(signed, doc[:idx]...)
+	signed = append(signed, signature...)
+	signed = append(signed, doc[idx:]...)
+	return signed, nil
+}
+
+func stripSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,184 @@
+package saml
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"Authentication/interfaces"
+)
+
+// newTestSigner 生成自签名证书及私钥
+func newTestSigner(cn string) *signer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		panic(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		panic(err)
+	}
+	return &signer{key: key, cert: cert}
+}
+
+const testAuthnRequest = `<samlp:AuthnRequest xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" ` +
+	`xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_abc" Version="2.0">` +
+	`<saml:Issuer>sp</saml:Issuer><samlp:NameIDPolicy AllowCreate="true"/></samlp:AuthnRequest>`
+
+func TestParseXML(t *testing.T) {
+	_, err := parseXML([]byte(`<!DOCTYPE a [<!ENTITY x "y">]><a>&x;</a>`))
+	assert.Equal(t, err, errXMLDirective)
+
+	_, err = parseXML([]byte(`<a><b ID="1"/><c ID="1"/></a>`))
+	assert.Equal(t, err, errXMLDuplicateID)
+
+	_, err = parseXML([]byte(`<a><b></a>`))
+	assert.Assert(t, err != nil)
+
+	_, err = parseXML([]byte(`<x:a/>`))
+	assert.Equal(t, err, errXMLNamespace)
+
+	_, err = parseXML([]byte(strings.Repeat("<a>", maxXMLDepth+1) + strings.Repeat("</a>", maxXMLDepth+1)))
+	assert.Equal(t, err, errXMLTooDeep)
+}
+
+func TestCanonicalize(t *testing.T) {
+	// W3C Exclusive XML Canonicalization 规范中的示例
+	doc := `<n0:local xmlns:n0="foo:bar" xmlns:n3="ftp://example.org">
+  <n1:elem2 xmlns:n1="http://example.net" xml:lang="en">
+    <n3:stuff xmlns:n3="ftp://example.org"/>
+  </n1:elem2>
+</n0:local>`
+	root, err := parseXML([]byte(doc))
+	assert.NilError(t, err)
+	out, err := canonicalize(root.element("http://example.net", "elem2"), nil, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), "<n1:elem2 xmlns:n1=\"http://example.net\" xml:lang=\"en\">\n"+
+		"    <n3:stuff xmlns:n3=\"ftp://example.org\"></n3:stuff>\n  </n1:elem2>")
+
+	// 属性排序、转义、默认命名空间取消及注释移除
+	root, err = parseXML([]byte(`<a xmlns="urn:a" b="2" xmlns:x="urn:x" x:c="1" a="&lt;&quot;&#10;">` +
+		`<x:b/><c xmlns=""><d>t&amp;&gt;&#13;</d></c><!-- c --></a>`))
+	assert.NilError(t, err)
+	out, err = canonicalize(root, nil, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), `<a xmlns="urn:a" xmlns:x="urn:x" a="&lt;&quot;&#xA;" b="2" x:c="1">`+
+		`<x:b></x:b><c xmlns=""><d>t&amp;&gt;&#xD;</d></c></a>`)
+
+	// InclusiveNamespaces声明的前缀即使未被使用也输出
+	root, err = parseXML([]byte(`<p xmlns:y="urn:y"><a xmlns="urn:a"/></p>`))
+	assert.NilError(t, err)
+	out, err = canonicalize(root.element("urn:a", "a"), nil, []string{"y"})
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), `<a xmlns="urn:a" xmlns:y="urn:y"></a>`)
+}
+
+func TestSignEnveloped(t *testing.T) {
+	sp := newTestSigner("sp")
+	signed, err := sp.signEnveloped([]byte(testAuthnRequest))
+	assert.NilError(t, err)
+
+	root, err := parseXML(signed)
+	assert.NilError(t, err)
+	assert.NilError(t, verifyEnveloped(root, []*x509.Certificate{sp.cert}))
+
+	// 非元数据中的证书不被信任
+	other := newTestSigner("other")
+	assert.Equal(t, verifyEnveloped(root, []*x509.Certificate{other.cert}), errSignatureInvalid)
+
+	// 篡改内容
+	tampered := strings.Replace(string(signed), `AllowCreate="true"`, `AllowCreate="false"`, 1)
+	root, err = parseXML([]byte(tampered))
+	assert.NilError(t, err)
+	assert.Equal(t, verifyEnveloped(root, []*x509.Certificate{sp.cert}), errDigestMismatch)
+
+	// 引用指向其他元素
+	tampered = strings.Replace(string(signed), `ID="_abc"`, `ID="_other"`, 1)
+	root, err = parseXML([]byte(tampered))
+	assert.NilError(t, err)
+	assert.Equal(t, verifyEnveloped(root, []*x509.Certificate{sp.cert}), errReferenceInvalid)
+
+	// 不接受SHA-1
+	tampered = strings.Replace(string(signed), algRSASHA256, "http://www.w3.org/2000/09/xmldsig#rsa-sha1", 1)
+	root, err = parseXML([]byte(tampered))
+	assert.NilError(t, err)
+	assert.Equal(t, verifyEnveloped(root, []*x509.Certificate{sp.cert}), errSignatureAlgorithm)
+
+	// 未签名
+	root, err = parseXML([]byte(testAuthnRequest))
+	assert.NilError(t, err)
+	assert.Equal(t, verifyEnveloped(root, []*x509.Certificate{sp.cert}), errSignatureMissing)
+}
+
+func TestRedirectBinding(t *testing.T) {
+	sp := newTestSigner("sp")
+	certsFor := func(*xmlNode) ([]*x509.Certificate, error) { return []*x509.Certificate{sp.cert}, nil }
+
+	redirect, err := sp.encodeRedirect("https://idp.example.com/sso?tenant=1", paramSAMLRequest, []byte(testAuthnRequest), "state1")
+	assert.NilError(t, err)
+	assert.Equal(t, redirect.Binding, interfaces.SAMLBindingRedirect)
+	u, err := url.Parse(redirect.URL)
+	assert.NilError(t, err)
+	assert.Equal(t, u.Query().Get("tenant"), "1")
+	assert.Equal(t, u.Query().Get(paramRelayState), "state1")
+	assert.Equal(t, u.Query().Get(paramSigAlg), algRSASHA256)
+
+	// 身份提供方收到的查询参数不包含服务提供方地址中的参数
+	rawQuery := strings.TrimPrefix(u.RawQuery, "tenant=1&")
+	msg := &interfaces.SAMLMessage{Redirect: true, RawQuery: rawQuery, SAMLRequest: u.Query().Get(paramSAMLRequest)}
+	root, err := decodeMessage(msg, paramSAMLRequest, certsFor)
+	assert.NilError(t, err)
+	assert.Assert(t, root.is(nsSAMLP, "AuthnRequest"))
+
+	// 篡改RelayState
+	msg.RawQuery = strings.Replace(rawQuery, "RelayState=state1", "RelayState=state2", 1)
+	_, err = decodeMessage(msg, paramSAMLRequest, certsFor)
+	assert.Equal(t, err, errSignatureInvalid)
+
+	// 重复参数
+	msg.RawQuery = rawQuery + "&RelayState=state2"
+	_, err = decodeMessage(msg, paramSAMLRequest, certsFor)
+	assert.Equal(t, err, errSignatureInvalid)
+
+	// 未签名
+	msg.RawQuery = rawQuery[:strings.Index(rawQuery, "&SigAlg=")]
+	_, err = decodeMessage(msg, paramSAMLRequest, certsFor)
+	assert.Equal(t, err, errSignatureMissing)
+}
+
+func TestPostBinding(t *testing.T) {
+	sp := newTestSigner("sp")
+	certsFor := func(*xmlNode) ([]*x509.Certificate, error) { return []*x509.Certificate{sp.cert}, nil }
+
+	redirect, err := sp.encodePost("https://idp.example.com/slo", paramSAMLRequest, []byte(testAuthnRequest), "state1")
+	assert.NilError(t, err)
+	assert.Equal(t, redirect.Binding, interfaces.SAMLBindingPost)
+	assert.Equal(t, redirect.URL, "https://idp.example.com/slo")
+	assert.Equal(t, redirect.Params[paramRelayState], "state1")
+
+	msg := &interfaces.SAMLMessage{SAMLRequest: redirect.Params[paramSAMLRequest]}
+	root, err := decodeMessage(msg, paramSAMLRequest, certsFor)
+	assert.NilError(t, err)
+	assert.Equal(t, root.attr("ID"), "_abc")
+
+	_, err = decodeMessage(&interfaces.SAMLMessage{}, paramSAMLRequest, certsFor)
+	assert.Equal(t, err, errMessageMissing)
+}
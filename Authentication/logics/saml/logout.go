@@ -0,0 +1,219 @@
+package saml
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"Authentication/common"
+	"Authentication/interfaces"
+)
+
+const (
+	statusResponder     = "urn:oasis:names:tc:SAML:2.0:status:Responder"
+	statusPartialLogout = "urn:oasis:names:tc:SAML:2.0:status:PartialLogout"
+)
+
+var (
+	errLogoutMessage = errors.New("message is not a LogoutRequest or LogoutResponse")
+	errUnknownIssuer = errors.New("issuer is not a configured identity provider")
+	errLogoutExpired = errors.New("logout request is expired")
+)
+
+// BeginLogout 服务提供方发起单点登出，向用户最近登录的身份提供方发送LogoutRequest，
+// 用户没有SAML登录会话或身份提供方不支持单点登出时返回nil
+func (s *saml) BeginLogout(ctx context.Context, visitor *interfaces.Visitor) (redirect *interfaces.SAMLRedirect, err error) {
+	s.trace.SetInternalSpanName("逻辑层-SAML发起单点登出")
+	newCtx, span := s.trace.AddInternalTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	if s.signer == nil {
+		return nil, nil
+	}
+
+	sessions, err := s.db.GetSessionsByUserID(newCtx, visitor.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+	session := sessions[0]
+
+	provider, err := s.db.GetProvider(newCtx, session.ProviderID)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.db.DeleteSessions(newCtx, visitor.ID, session.ProviderID); err != nil {
+		return nil, err
+	}
+	if provider == nil {
+		return nil, nil
+	}
+	meta, err := parseIDPMetadata(provider.Metadata)
+	if err != nil || meta.slo == nil {
+		return nil, nil
+	}
+
+	requestID, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	doc := fmt.Sprintf(`<samlp:LogoutRequest xmlns:samlp="%s" xmlns:saml="%s" ID="%s" Version="2.0" IssueInstant="%s" Destination="%s">`+
+		`<saml:Issuer>%s</saml:Issuer>%s`,
+		nsSAMLP, nsSAML, requestID, issueInstant(), xmlEscape(meta.slo.location), xmlEscape(s.entityID), nameIDElement(&session))
+	if session.SessionIndex != "" {
+		doc += fmt.Sprintf(`<samlp:SessionIndex>%s</samlp:SessionIndex>`, xmlEscape(session.SessionIndex))
+	}
+	doc += `</samlp:LogoutRequest>`
+
+	return s.signer.encode(meta.slo, meta.slo.location, paramSAMLRequest, []byte(doc), "")
+}
+
+// HandleLogout 处理身份提供方发送的LogoutRequest或LogoutResponse，报文必须由身份提供方签名。
+// 收到LogoutRequest时注销对应用户的登录会话并回复LogoutResponse，收到LogoutResponse时跳转到登出完成页面
+func (s *saml) HandleLogout(ctx context.Context, visitor *interfaces.Visitor, msg *interfaces.SAMLMessage) (redirect *interfaces.SAMLRedirect, err error) {
+	s.trace.SetInternalSpanName("逻辑层-SAML处理单点登出")
+	newCtx, span := s.trace.AddInternalTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = s.checkEnabled(); err != nil {
+		return nil, err
+	}
+
+	param := paramSAMLRequest
+	if msg.SAMLResponse != "" {
+		param = paramSAMLResponse
+	}
+
+	var provider *interfaces.SAMLProvider
+	var meta *idpMetadata
+	root, err := decodeMessage(msg, param, func(root *xmlNode) ([]*x509.Certificate, error) {
+		issuer := root.element(nsSAML, "Issuer")
+		if issuer == nil {
+			return nil, errUnknownIssuer
+		}
+		p, err := s.db.GetProviderByEntityID(newCtx, issuer.text())
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			return nil, errUnknownIssuer
+		}
+		m, err := parseIDPMetadata(p.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		provider, meta = p, m
+		return m.certs, nil
+	})
+	if err != nil {
+		entityID := ""
+		if provider != nil {
+			entityID = provider.EntityID
+		}
+		return nil, s.authFailed(entityID, err)
+	}
+	if root.hasAttr("Destination") && root.attr("Destination") != s.sloURL {
+		return nil, s.authFailed(provider.EntityID, errDestination)
+	}
+
+	switch {
+	case param == paramSAMLRequest && root.is(nsSAMLP, "LogoutRequest"):
+		return s.logoutByIDP(newCtx, provider, meta, root, msg.RelayState)
+	case param == paramSAMLResponse && root.is(nsSAMLP, "LogoutResponse"):
+		if err = checkStatus(root); err != nil {
+			s.logger.Warnf("saml logout response is not success, entity id: %s, reason: %v", provider.EntityID, err)
+		}
+		if s.logoutPage == "" {
+			return nil, nil
+		}
+		return &interfaces.SAMLRedirect{Binding: interfaces.SAMLBindingRedirect, URL: s.logoutPage}, nil
+	default:
+		return nil, s.authFailed(provider.EntityID, errLogoutMessage)
+	}
+}
+
+// logoutByIDP 身份提供方发起单点登出，注销断言主体及会话索引对应用户的全部登录会话，并回复LogoutResponse
+func (s *saml) logoutByIDP(ctx context.Context, provider *interfaces.SAMLProvider, meta *idpMetadata,
+	root *xmlNode, relayState string) (*interfaces.SAMLRedirect, error) {
+	if root.attr("Version") != "2.0" {
+		return nil, s.authFailed(provider.EntityID, errVersion)
+	}
+	if notOnOrAfter := root.attr("NotOnOrAfter"); notOnOrAfter != "" && !notExpired(common.Now(), notOnOrAfter) {
+		return nil, s.authFailed(provider.EntityID, errLogoutExpired)
+	}
+	nameID := root.element(nsSAML, "NameID")
+	if nameID == nil || nameID.text() == "" {
+		return nil, s.authFailed(provider.EntityID, errSubjectMissing)
+	}
+
+	sessions, err := s.db.GetSessionsByNameID(ctx, provider.ID, nameID.text())
+	if err != nil {
+		return nil, err
+	}
+	indexes := make(map[string]bool)
+	for _, index := range root.elements(nsSAMLP, "SessionIndex") {
+		indexes[index.text()] = true
+	}
+
+	status := statusSuccess
+	users := make(map[string]bool)
+	for i := range sessions {
+		if len(indexes) > 0 && !indexes[sessions[i].SessionIndex] {
+			continue
+		}
+		userID := sessions[i].UserID
+		if users[userID] {
+			continue
+		}
+		users[userID] = true
+
+		if err = s.hydraAdmin.DeleteSession(userID, ""); err != nil {
+			s.logger.Errorf("saml logout delete session failed, user: %s, err: %v", userID, err)
+			status = statusPartialLogout
+			continue
+		}
+		if err = s.db.DeleteSessions(ctx, userID, provider.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if meta.slo == nil {
+		return nil, nil
+	}
+	target := meta.slo.location
+	if meta.slo.responseLocation != "" {
+		target = meta.slo.responseLocation
+	}
+	responseID, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	statusCode := fmt.Sprintf(`<samlp:StatusCode Value="%s"></samlp:StatusCode>`, statusSuccess)
+	if status != statusSuccess {
+		statusCode = fmt.Sprintf(`<samlp:StatusCode Value="%s"><samlp:StatusCode Value="%s"></samlp:StatusCode></samlp:StatusCode>`,
+			statusResponder, status)
+	}
+	doc := fmt.Sprintf(`<samlp:LogoutResponse xmlns:samlp="%s" xmlns:saml="%s" ID="%s" Version="2.0" IssueInstant="%s" `+
+		`Destination="%s" InResponseTo="%s"><saml:Issuer>%s</saml:Issuer><samlp:Status>%s</samlp:Status></samlp:LogoutResponse>`,
+		nsSAMLP, nsSAML, responseID, issueInstant(), xmlEscape(target), xmlEscape(root.attr("ID")), xmlEscape(s.entityID), statusCode)
+
+	return s.signer.encode(meta.slo, target, paramSAMLResponse, []byte(doc), relayState)
+}
+
+// nameIDElement 生成登录会话断言主体对应的NameID元素
+func nameIDElement(session *interfaces.SAMLSession) string {
+	attrs := ""
+	for _, attr := range []struct{ name, value string }{
+		{"Format", session.NameIDFormat},
+		{"NameQualifier", session.NameQualifier},
+		{"SPNameQualifier", session.SPNameQualifier},
+	} {
+		if attr.value != "" {
+			attrs += fmt.Sprintf(` %s="%s"`, attr.name, xmlEscape(attr.value))
+		}
+	}
+	return fmt.Sprintf(`<saml:NameID%s>%s</saml:NameID>`, attrs, xmlEscape(session.NameID))
+}
@@ -0,0 +1,164 @@
+package saml
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+const (
+	nsMD    = "urn:oasis:names:tc:SAML:2.0:metadata"
+	nsSAML  = "urn:oasis:names:tc:SAML:2.0:assertion"
+	nsSAMLP = "urn:oasis:names:tc:SAML:2.0:protocol"
+
+	bindingRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	bindingPOST     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+
+	nameIDPersistent  = "urn:oasis:names:tc:SAML:2.0:nameid-format:persistent"
+	nameIDTransient   = "urn:oasis:names:tc:SAML:2.0:nameid-format:transient"
+	nameIDEmail       = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+	nameIDUnspecified = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+)
+
+var (
+	errMetadataInvalid   = errors.New("metadata must contain exactly one EntityDescriptor with IDPSSODescriptor")
+	errMetadataNoSSO     = errors.New("metadata has no SingleSignOnService with HTTP-Redirect or HTTP-POST binding")
+	errMetadataNoCert    = errors.New("metadata has no signing certificate")
+	errMetadataEntityID  = errors.New("metadata entityID is empty")
+	errMetadataBadCert   = errors.New("metadata contains invalid certificate")
+	errMetadataBadTarget = errors.New("metadata endpoint location is invalid")
+)
+
+// endpoint 身份提供方服务地址
+type endpoint struct {
+	binding          string
+	location         string
+	responseLocation string
+}
+
+// idpMetadata 身份提供方元数据
+type idpMetadata struct {
+	entityID string
+	sso      endpoint
+	slo      *endpoint
+	certs    []*x509.Certificate
+}
+
+// parseIDPMetadata 解析身份提供方元数据，服务地址优先使用HTTP-Redirect绑定
+func parseIDPMetadata(data string) (*idpMetadata, error) {
+	root, err := parseXML([]byte(data))
+	if err != nil {
+		return nil, err
+	}
+	if root.is(nsMD, "EntitiesDescriptor") {
+		entities := root.elements(nsMD, "EntityDescriptor")
+		if len(entities) != 1 {
+			return nil, errMetadataInvalid
+		}
+		root = entities[0]
+	}
+	if !root.is(nsMD, "EntityDescriptor") {
+		return nil, errMetadataInvalid
+	}
+	idp := root.element(nsMD, "IDPSSODescriptor")
+	if idp == nil {
+		return nil, errMetadataInvalid
+	}
+
+	meta := &idpMetadata{entityID: root.attr("entityID")}
+	if meta.entityID == "" {
+		return nil, errMetadataEntityID
+	}
+
+	sso := selectEndpoint(idp.elements(nsMD, "SingleSignOnService"))
+	if sso == nil {
+		return nil, errMetadataNoSSO
+	}
+	meta.sso = *sso
+	meta.slo = selectEndpoint(idp.elements(nsMD, "SingleLogoutService"))
+	for _, e := range []*endpoint{&meta.sso, meta.slo} {
+		if e != nil && (!isHTTPURL(e.location) || (e.responseLocation != "" && !isHTTPURL(e.responseLocation))) {
+			return nil, errMetadataBadTarget
+		}
+	}
+
+	for _, kd := range idp.elements(nsMD, "KeyDescriptor") {
+		if use := kd.attr("use"); use != "" && use != "signing" {
+			continue
+		}
+		keyInfo := kd.element(nsDSig, "KeyInfo")
+		if keyInfo == nil {
+			continue
+		}
+		for _, x509Data := range keyInfo.elements(nsDSig, "X509Data") {
+			for _, certNode := range x509Data.elements(nsDSig, "X509Certificate") {
+				der, err := base64.StdEncoding.DecodeString(stripSpace(certNode.text()))
+				if err != nil {
+					return nil, errMetadataBadCert
+				}
+				cert, err := x509.ParseCertificate(der)
+				if err != nil {
+					return nil, errMetadataBadCert
+				}
+				meta.certs = append(meta.certs, cert)
+			}
+		}
+	}
+	if len(meta.certs) == 0 {
+		return nil, errMetadataNoCert
+	}
+
+	return meta, nil
+}
+
+// selectEndpoint 选择服务地址，优先HTTP-Redirect绑定，不支持的绑定忽略
+func selectEndpoint(nodes []*xmlNode) *endpoint {
+	var post *endpoint
+	for _, n := range nodes {
+		e := &endpoint{binding: n.attr("Binding"), location: n.attr("Location"), responseLocation: n.attr("ResponseLocation")}
+		switch e.binding {
+		case bindingRedirect:
+			return e
+		case bindingPOST:
+			if post == nil {
+				post = e
+			}
+		}
+	}
+	return post
+}
+
+// buildSPMetadata 生成服务提供方元数据
+func buildSPMetadata(entityID, acsURL, sloURL string, cert *x509.Certificate) []byte {
+	var buf bytes.Buffer
+	certStr := base64.StdEncoding.EncodeToString(cert.Raw)
+	fmt.Fprintf(&buf, `<md:EntityDescriptor xmlns:md="%s" entityID="%s">`, nsMD, xmlEscape(entityID))
+	fmt.Fprintf(&buf, `<md:SPSSODescriptor AuthnRequestsSigned="true" WantAssertionsSigned="true" protocolSupportEnumeration="%s">`, nsSAMLP)
+	fmt.Fprintf(&buf, `<md:KeyDescriptor use="signing"><ds:KeyInfo xmlns:ds="%s"><ds:X509Data><ds:X509Certificate>%s</ds:X509Certificate></ds:X509Data></ds:KeyInfo></md:KeyDescriptor>`, nsDSig, certStr)
+	fmt.Fprintf(&buf, `<md:SingleLogoutService Binding="%s" Location="%s"/>`, bindingRedirect, xmlEscape(sloURL))
+	fmt.Fprintf(&buf, `<md:SingleLogoutService Binding="%s" Location="%s"/>`, bindingPOST, xmlEscape(sloURL))
+	for _, format := range []string{nameIDPersistent, nameIDEmail, nameIDUnspecified} {
+		fmt.Fprintf(&buf, `<md:NameIDFormat>%s</md:NameIDFormat>`, format)
+	}
+	fmt.Fprintf(&buf, `<md:AssertionConsumerService Binding="%s" Location="%s" index="0" isDefault="true"/>`, bindingPOST, xmlEscape(acsURL))
+	buf.WriteString(`</md:SPSSODescriptor></md:EntityDescriptor>`)
+	return buf.Bytes()
+}
+
+func isHTTPURL(str string) bool {
+	u, err := url.Parse(str)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
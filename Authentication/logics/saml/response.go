@@ -0,0 +1,258 @@
+package saml
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"Authentication/common"
+	"Authentication/interfaces"
+)
+
+const (
+	statusSuccess = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	cmBearer      = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	// clockSkew 断言时间校验允许的时钟偏差
+	clockSkew = 3 * time.Minute
+	// timeFormat SAML时间格式
+	timeFormat = "2006-01-02T15:04:05Z"
+	// nameIDAttribute 映射规则中代表断言主体的属性名称
+	nameIDAttribute = interfaces.SAMLNameID
+)
+
+var (
+	errNotResponse          = errors.New("message is not a Response")
+	errVersion              = errors.New("message version is not 2.0")
+	errDestination          = errors.New("message destination mismatch")
+	errInResponseTo         = errors.New("InResponseTo mismatch")
+	errIssuer               = errors.New("issuer mismatch")
+	errStatus               = errors.New("status is not success")
+	errEncryptedAssertion   = errors.New("encrypted assertion is not supported")
+	errAssertionCount       = errors.New("response must contain exactly one assertion")
+	errSubjectMissing       = errors.New("assertion subject NameID missing")
+	errSubjectConfirmation  = errors.New("no valid bearer subject confirmation")
+	errConditionsTime       = errors.New("assertion is not yet valid or expired")
+	errAudience             = errors.New("assertion audience mismatch")
+	errAudienceMissing      = errors.New("assertion has no AudienceRestriction")
+	errAuthnStatementAbsent = errors.New("assertion has no AuthnStatement")
+)
+
+// assertionInfo 校验通过的断言内容
+type assertionInfo struct {
+	nameID          string
+	nameIDFormat    string
+	nameQualifier   string
+	spNameQualifier string
+	sessionIndex    string
+	attributes      map[string][]string // 属性名称及友好名称对应的值
+}
+
+// value 获取属性的第一个值，NameID代表断言主体
+func (a *assertionInfo) value(name string) string {
+	if name == nameIDAttribute {
+		return a.nameID
+	}
+	if values := a.attributes[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// responseContext 校验响应所需的上下文
+type responseContext struct {
+	meta      *idpMetadata
+	entityID  string // 服务提供方标识
+	acsURL    string // 断言消费地址
+	requestID string // AuthnRequest的ID
+}
+
+// validateResponse 校验身份提供方响应，响应或断言至少有一个被身份提供方签名，签名校验通过后只从被签名的元素中读取内容
+//
+//nolint:gocyclo
+func validateResponse(root *xmlNode, rc *responseContext) (*assertionInfo, error) {
+	if !root.is(nsSAMLP, "Response") {
+		return nil, errNotResponse
+	}
+	if root.attr("Version") != "2.0" {
+		return nil, errVersion
+	}
+	if root.hasAttr("Destination") && root.attr("Destination") != rc.acsURL {
+		return nil, errDestination
+	}
+	if root.attr("InResponseTo") != rc.requestID {
+		return nil, errInResponseTo
+	}
+	if issuer := root.element(nsSAML, "Issuer"); issuer != nil && issuer.text() != rc.meta.entityID {
+		return nil, errIssuer
+	}
+
+	responseSigned := root.element(nsDSig, "Signature") != nil
+	if responseSigned {
+		if err := verifyEnveloped(root, rc.meta.certs); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkStatus(root); err != nil {
+		return nil, err
+	}
+
+	if len(root.elements(nsSAML, "EncryptedAssertion")) > 0 {
+		return nil, errEncryptedAssertion
+	}
+	assertions := root.elements(nsSAML, "Assertion")
+	if len(assertions) != 1 {
+		return nil, errAssertionCount
+	}
+	assertion := assertions[0]
+	if !responseSigned {
+		if err := verifyEnveloped(assertion, rc.meta.certs); err != nil {
+			return nil, err
+		}
+	}
+
+	if assertion.attr("Version") != "2.0" {
+		return nil, errVersion
+	}
+	if issuer := assertion.element(nsSAML, "Issuer"); issuer == nil || issuer.text() != rc.meta.entityID {
+		return nil, errIssuer
+	}
+
+	now := common.Now()
+	info := &assertionInfo{attributes: make(map[string][]string)}
+	subject := assertion.element(nsSAML, "Subject")
+	if subject == nil {
+		return nil, errSubjectMissing
+	}
+	nameID := subject.element(nsSAML, "NameID")
+	if nameID == nil || nameID.text() == "" {
+		return nil, errSubjectMissing
+	}
+	info.nameID = nameID.text()
+	info.nameIDFormat = nameID.attr("Format")
+	info.nameQualifier = nameID.attr("NameQualifier")
+	info.spNameQualifier = nameID.attr("SPNameQualifier")
+	// 仅断言被签名时，响应中的InResponseTo不可信，必须由断言的主体确认绑定本次请求
+	if !hasValidBearer(subject, rc, now, !responseSigned) {
+		return nil, errSubjectConfirmation
+	}
+
+	if err := checkConditions(assertion.element(nsSAML, "Conditions"), rc.entityID, now); err != nil {
+		return nil, err
+	}
+
+	authn := assertion.element(nsSAML, "AuthnStatement")
+	if authn == nil {
+		return nil, errAuthnStatementAbsent
+	}
+	info.sessionIndex = authn.attr("SessionIndex")
+	if notOnOrAfter := authn.attr("SessionNotOnOrAfter"); notOnOrAfter != "" && !notExpired(now, notOnOrAfter) {
+		return nil, errConditionsTime
+	}
+
+	for _, statement := range assertion.elements(nsSAML, "AttributeStatement") {
+		for _, attr := range statement.elements(nsSAML, "Attribute") {
+			values := make([]string, 0, 1)
+			for _, v := range attr.elements(nsSAML, "AttributeValue") {
+				values = append(values, v.text())
+			}
+			for _, name := range []string{attr.attr("Name"), attr.attr("FriendlyName")} {
+				if name != "" {
+					info.attributes[name] = append(info.attributes[name], values...)
+				}
+			}
+		}
+	}
+
+	return info, nil
+}
+
+// hasValidBearer 至少存在一个接收方、有效期及请求ID均匹配的bearer主体确认
+func hasValidBearer(subject *xmlNode, rc *responseContext, now time.Time, requireInResponseTo bool) bool {
+	for _, confirmation := range subject.elements(nsSAML, "SubjectConfirmation") {
+		if confirmation.attr("Method") != cmBearer {
+			continue
+		}
+		data := confirmation.element(nsSAML, "SubjectConfirmationData")
+		if data == nil || data.attr("Recipient") != rc.acsURL {
+			continue
+		}
+		if !notExpired(now, data.attr("NotOnOrAfter")) {
+			continue
+		}
+		if notBefore := data.attr("NotBefore"); notBefore != "" && !reached(now, notBefore) {
+			continue
+		}
+		if (requireInResponseTo || data.hasAttr("InResponseTo")) && data.attr("InResponseTo") != rc.requestID {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// checkConditions 校验断言有效期及受众限制，至少存在一个受众限制，且每个受众限制都必须包含服务提供方
+// 没有受众限制的断言可被颁发给其他服务提供方后重放到本服务
+func checkConditions(conditions *xmlNode, entityID string, now time.Time) error {
+	if conditions == nil {
+		return errAudienceMissing
+	}
+	if notBefore := conditions.attr("NotBefore"); notBefore != "" && !reached(now, notBefore) {
+		return errConditionsTime
+	}
+	if notOnOrAfter := conditions.attr("NotOnOrAfter"); notOnOrAfter != "" && !notExpired(now, notOnOrAfter) {
+		return errConditionsTime
+	}
+	restrictions := conditions.elements(nsSAML, "AudienceRestriction")
+	if len(restrictions) == 0 {
+		return errAudienceMissing
+	}
+	for _, restriction := range restrictions {
+		matched := false
+		for _, audience := range restriction.elements(nsSAML, "Audience") {
+			if audience.text() == entityID {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return errAudience
+		}
+	}
+	return nil
+}
+
+// checkStatus 校验响应状态为成功
+func checkStatus(root *xmlNode) error {
+	status := root.element(nsSAMLP, "Status")
+	if status == nil {
+		return errStatus
+	}
+	code := status.element(nsSAMLP, "StatusCode")
+	if code == nil || code.attr("Value") != statusSuccess {
+		value := ""
+		if code != nil {
+			value = code.attr("Value")
+		}
+		return fmt.Errorf("%w: %s", errStatus, value)
+	}
+	return nil
+}
+
+// notExpired 判断当前时间是否早于截止时间，允许时钟偏差，截止时间无法解析时视为已过期
+func notExpired(now time.Time, notOnOrAfter string) bool {
+	t, err := time.Parse(time.RFC3339Nano, notOnOrAfter)
+	if err != nil {
+		return false
+	}
+	return now.Before(t.Add(clockSkew))
+}
+
+// reached 判断当前时间是否已到达生效时间，允许时钟偏差，生效时间无法解析时视为未生效
+func reached(now time.Time, notBefore string) bool {
+	t, err := time.Parse(time.RFC3339Nano, notBefore)
+	if err != nil {
+		return false
+	}
+	return !now.Before(t.Add(-clockSkew))
+}
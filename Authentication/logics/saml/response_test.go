@@ -0,0 +1,312 @@
+package saml
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"Authentication/common"
+)
+
+const (
+	testSPEntityID  = "https://anyshare.example.com/saml"
+	testBaseURL     = "https://anyshare.example.com"
+	testACSURL      = testBaseURL + acsPath
+	testSLOURL      = testBaseURL + sloPath
+	testIDPEntityID = "https://idp.example.com/saml"
+	testIDPSSOURL   = "https://idp.example.com/sso"
+	testIDPSLOURL   = "https://idp.example.com/slo"
+	testRequestID   = "_req1"
+)
+
+// testIDP 本地模拟的SAML身份提供方，生成元数据及签名的响应
+type testIDP struct {
+	*signer
+}
+
+func newTestIDP() *testIDP {
+	return &testIDP{signer: newTestSigner("idp")}
+}
+
+// metadata 生成身份提供方元数据，sloBinding为空时不提供单点登出服务
+func (idp *testIDP) metadata(ssoBinding, sloBinding string) string {
+	slo := ""
+	if sloBinding != "" {
+		slo = fmt.Sprintf(`<md:SingleLogoutService Binding="%s" Location="%s"/>`, sloBinding, testIDPSLOURL)
+	}
+	return fmt.Sprintf(`<md:EntityDescriptor xmlns:md="%s" entityID="%s"><md:IDPSSODescriptor protocolSupportEnumeration="%s">`+
+		`<md:KeyDescriptor use="signing"><ds:KeyInfo xmlns:ds="%s"><ds:X509Data><ds:X509Certificate>%s</ds:X509Certificate>`+
+		`</ds:X509Data></ds:KeyInfo></md:KeyDescriptor>%s<md:SingleSignOnService Binding="%s" Location="%s"/>`+
+		`</md:IDPSSODescriptor></md:EntityDescriptor>`,
+		nsMD, testIDPEntityID, nsSAMLP, nsDSig, base64.StdEncoding.EncodeToString(idp.cert.Raw), slo, ssoBinding, testIDPSSOURL)
+}
+
+// testAssertion 断言内容，字段为空时不生成对应属性
+type testAssertion struct {
+	issuer       string
+	nameID       string
+	nameIDFormat string
+	recipient    string
+	inResponseTo string
+	notOnOrAfter time.Time
+	audience     string
+	noConditions bool
+	sessionIndex string
+	attributes   map[string]string
+}
+
+func defaultAssertion() *testAssertion {
+	return &testAssertion{
+		issuer:       testIDPEntityID,
+		nameID:       "user1",
+		nameIDFormat: nameIDPersistent,
+		recipient:    testACSURL,
+		inResponseTo: testRequestID,
+		notOnOrAfter: common.Now().Add(5 * time.Minute),
+		audience:     testSPEntityID,
+		sessionIndex: "_session1",
+		attributes: map[string]string{
+			"urn:oid:0.9.2342.19200300.100.1.3": "user1@example.com",
+			"displayName":                       "User One",
+		},
+	}
+}
+
+// assertion 生成断言，签名时使用信封式签名
+func (idp *testIDP) assertion(a *testAssertion, sign bool) string {
+	now := common.Now().UTC()
+	var attrs strings.Builder
+	names := make([]string, 0, len(a.attributes))
+	for name := range a.attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&attrs, `<saml:Attribute Name="%s"><saml:AttributeValue>%s</saml:AttributeValue></saml:Attribute>`,
+			name, xmlEscape(a.attributes[name]))
+	}
+	inResponseTo := ""
+	if a.inResponseTo != "" {
+		inResponseTo = fmt.Sprintf(` InResponseTo="%s"`, a.inResponseTo)
+	}
+	conditions := ""
+	if !a.noConditions {
+		audience := ""
+		if a.audience != "" {
+			audience = fmt.Sprintf(`<saml:AudienceRestriction><saml:Audience>%s</saml:Audience></saml:AudienceRestriction>`, a.audience)
+		}
+		conditions = fmt.Sprintf(`<saml:Conditions NotBefore="%s" NotOnOrAfter="%s">%s</saml:Conditions>`,
+			now.Add(-time.Minute).Format(timeFormat), a.notOnOrAfter.UTC().Format(timeFormat), audience)
+	}
+
+	doc := fmt.Sprintf(`<saml:Assertion xmlns:saml="%s" ID="_assertion1" Version="2.0" IssueInstant="%s">`+
+		`<saml:Issuer>%s</saml:Issuer><saml:Subject><saml:NameID Format="%s">%s</saml:NameID>`+
+		`<saml:SubjectConfirmation Method="%s"><saml:SubjectConfirmationData Recipient="%s" NotOnOrAfter="%s"%s/>`+
+		`</saml:SubjectConfirmation></saml:Subject>%s`+
+		`<saml:AuthnStatement AuthnInstant="%s" SessionIndex="%s"></saml:AuthnStatement>`+
+		`<saml:AttributeStatement>%s</saml:AttributeStatement></saml:Assertion>`,
+		nsSAML, now.Format(timeFormat), a.issuer, a.nameIDFormat, xmlEscape(a.nameID),
+		cmBearer, a.recipient, a.notOnOrAfter.UTC().Format(timeFormat), inResponseTo,
+		conditions, now.Format(timeFormat), a.sessionIndex, attrs.String())
+	if !sign {
+		return doc
+	}
+	signed, err := idp.signEnveloped([]byte(doc))
+	if err != nil {
+		panic(err)
+	}
+	return string(signed)
+}
+
+// response 生成base64编码的响应，assertions为响应中包含的断言
+func (idp *testIDP) response(inResponseTo string, sign bool, assertions ...string) string {
+	doc := fmt.Sprintf(`<samlp:Response xmlns:samlp="%s" xmlns:saml="%s" ID="_response1" Version="2.0" IssueInstant="%s" `+
+		`Destination="%s" InResponseTo="%s"><saml:Issuer>%s</saml:Issuer>`+
+		`<samlp:Status><samlp:StatusCode Value="%s"/></samlp:Status>%s</samlp:Response>`,
+		nsSAMLP, nsSAML, common.Now().UTC().Format(timeFormat), testACSURL, inResponseTo, testIDPEntityID,
+		statusSuccess, strings.Join(assertions, ""))
+	data := []byte(doc)
+	if sign {
+		var err error
+		if data, err = idp.signEnveloped(data); err != nil {
+			panic(err)
+		}
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func TestParseIDPMetadata(t *testing.T) {
+	idp := newTestIDP()
+
+	meta, err := parseIDPMetadata(idp.metadata(bindingPOST, bindingRedirect))
+	assert.NilError(t, err)
+	assert.Equal(t, meta.entityID, testIDPEntityID)
+	assert.Equal(t, meta.sso.binding, bindingPOST)
+	assert.Equal(t, meta.sso.location, testIDPSSOURL)
+	assert.Equal(t, meta.slo.binding, bindingRedirect)
+	assert.Equal(t, len(meta.certs), 1)
+
+	meta, err = parseIDPMetadata(idp.metadata(bindingRedirect, ""))
+	assert.NilError(t, err)
+	assert.Assert(t, meta.slo == nil)
+
+	_, err = parseIDPMetadata(idp.metadata("urn:oasis:names:tc:SAML:2.0:bindings:SOAP", ""))
+	assert.Equal(t, err, errMetadataNoSSO)
+
+	_, err = parseIDPMetadata(strings.Replace(idp.metadata(bindingPOST, ""), testIDPSSOURL, "javascript:alert(1)", 1))
+	assert.Equal(t, err, errMetadataBadTarget)
+
+	_, err = parseIDPMetadata(strings.Replace(idp.metadata(bindingPOST, ""), "IDPSSODescriptor", "SPSSODescriptor", 2))
+	assert.Equal(t, err, errMetadataInvalid)
+}
+
+func TestBuildSPMetadata(t *testing.T) {
+	sp := newTestSigner("sp")
+	root, err := parseXML(buildSPMetadata(testSPEntityID, testACSURL, testSLOURL, sp.cert))
+	assert.NilError(t, err)
+	assert.Equal(t, root.attr("entityID"), testSPEntityID)
+
+	descriptor := root.element(nsMD, "SPSSODescriptor")
+	assert.Assert(t, descriptor != nil)
+	assert.Equal(t, descriptor.attr("AuthnRequestsSigned"), "true")
+	assert.Equal(t, descriptor.element(nsMD, "AssertionConsumerService").attr("Location"), testACSURL)
+	assert.Equal(t, len(descriptor.elements(nsMD, "SingleLogoutService")), 2)
+}
+
+//nolint:funlen
+func TestValidateResponse(t *testing.T) {
+	idp := newTestIDP()
+	meta, err := parseIDPMetadata(idp.metadata(bindingRedirect, bindingRedirect))
+	assert.NilError(t, err)
+	rc := &responseContext{meta: meta, entityID: testSPEntityID, acsURL: testACSURL, requestID: testRequestID}
+
+	validate := func(encoded string) (*assertionInfo, error) {
+		root, err := decodePost(encoded)
+		assert.NilError(t, err)
+		return validateResponse(root, rc)
+	}
+
+	// 断言被签名
+	info, err := validate(idp.response(testRequestID, false, idp.assertion(defaultAssertion(), true)))
+	assert.NilError(t, err)
+	assert.Equal(t, info.nameID, "user1")
+	assert.Equal(t, info.nameIDFormat, nameIDPersistent)
+	assert.Equal(t, info.sessionIndex, "_session1")
+	assert.Equal(t, info.value(nameIDAttribute), "user1")
+	assert.Equal(t, info.value("urn:oid:0.9.2342.19200300.100.1.3"), "user1@example.com")
+	assert.Equal(t, info.value("displayName"), "User One")
+	assert.Equal(t, info.value("missing"), "")
+
+	// 仅响应被签名
+	_, err = validate(idp.response(testRequestID, true, idp.assertion(defaultAssertion(), false)))
+	assert.NilError(t, err)
+
+	// 响应及断言均未签名
+	_, err = validate(idp.response(testRequestID, false, idp.assertion(defaultAssertion(), false)))
+	assert.Equal(t, err, errSignatureMissing)
+
+	// 其他密钥签名
+	other := newTestIDP()
+	_, err = validate(other.response(testRequestID, false, other.assertion(defaultAssertion(), true)))
+	assert.Equal(t, err, errSignatureInvalid)
+
+	// 签名后篡改断言主体
+	signed := strings.Replace(idp.assertion(defaultAssertion(), true), ">user1<", ">admin<", 1)
+	_, err = validate(idp.response(testRequestID, false, signed))
+	assert.Equal(t, err, errDigestMismatch)
+
+	// 签名包装攻击：在被签名的断言之外注入伪造断言
+	forged := defaultAssertion()
+	forged.nameID = "admin"
+	forgedXML := strings.Replace(idp.assertion(forged, false), "_assertion1", "_forged", 1)
+	_, err = validate(idp.response(testRequestID, false, forgedXML, idp.assertion(defaultAssertion(), true)))
+	assert.Equal(t, err, errAssertionCount)
+	_, err = validate(idp.response(testRequestID, false,
+		forgedXML+`<samlp:Extensions>`+idp.assertion(defaultAssertion(), true)+`</samlp:Extensions>`))
+	assert.Equal(t, err, errSignatureMissing)
+
+	// 响应不是对本次请求的回复
+	_, err = validate(idp.response("_other", false, idp.assertion(defaultAssertion(), true)))
+	assert.Equal(t, err, errInResponseTo)
+
+	// 响应未签名时断言必须绑定本次请求
+	a := defaultAssertion()
+	a.inResponseTo = ""
+	_, err = validate(idp.response(testRequestID, false, idp.assertion(a, true)))
+	assert.Equal(t, err, errSubjectConfirmation)
+	_, err = validate(idp.response(testRequestID, true, idp.assertion(a, false)))
+	assert.NilError(t, err)
+
+	a = defaultAssertion()
+	a.inResponseTo = "_other"
+	_, err = validate(idp.response(testRequestID, true, idp.assertion(a, false)))
+	assert.Equal(t, err, errSubjectConfirmation)
+
+	// 接收方不是本服务
+	a = defaultAssertion()
+	a.recipient = "https://evil.example.com" + acsPath
+	_, err = validate(idp.response(testRequestID, false, idp.assertion(a, true)))
+	assert.Equal(t, err, errSubjectConfirmation)
+
+	// 受众不是本服务
+	a = defaultAssertion()
+	a.audience = "https://evil.example.com/saml"
+	_, err = validate(idp.response(testRequestID, false, idp.assertion(a, true)))
+	assert.Equal(t, err, errAudience)
+
+	// 缺少受众限制，断言可能是颁发给其他服务提供方的
+	a = defaultAssertion()
+	a.audience = ""
+	_, err = validate(idp.response(testRequestID, false, idp.assertion(a, true)))
+	assert.Equal(t, err, errAudienceMissing)
+
+	// 缺少Conditions
+	a = defaultAssertion()
+	a.noConditions = true
+	_, err = validate(idp.response(testRequestID, false, idp.assertion(a, true)))
+	assert.Equal(t, err, errAudienceMissing)
+
+	// 断言已过期
+	a = defaultAssertion()
+	a.notOnOrAfter = common.Now().Add(-clockSkew - time.Minute)
+	_, err = validate(idp.response(testRequestID, false, idp.assertion(a, true)))
+	assert.Equal(t, err, errSubjectConfirmation)
+
+	// 断言颁发者不是身份提供方
+	a = defaultAssertion()
+	a.issuer = "https://evil.example.com/saml"
+	_, err = validate(idp.response(testRequestID, false, idp.assertion(a, true)))
+	assert.Equal(t, err, errIssuer)
+
+	// 加密断言
+	_, err = validate(idp.response(testRequestID, true, `<saml:EncryptedAssertion></saml:EncryptedAssertion>`))
+	assert.Equal(t, err, errEncryptedAssertion)
+
+	// 认证失败状态
+	data, _ := base64.StdEncoding.DecodeString(idp.response(testRequestID, false))
+	failed := base64.StdEncoding.EncodeToString([]byte(strings.Replace(string(data), statusSuccess,
+		"urn:oasis:names:tc:SAML:2.0:status:Responder", 1)))
+	_, err = validate(failed)
+	assert.ErrorContains(t, err, errStatus.Error())
+}
+
+func TestValidateResponseCertificates(t *testing.T) {
+	// 元数据包含多个证书时，任一证书校验通过即可，用于身份提供方轮换证书
+	idp := newTestIDP()
+	old := newTestIDP()
+	meta, err := parseIDPMetadata(idp.metadata(bindingRedirect, ""))
+	assert.NilError(t, err)
+	meta.certs = []*x509.Certificate{old.cert, idp.cert}
+	rc := &responseContext{meta: meta, entityID: testSPEntityID, acsURL: testACSURL, requestID: testRequestID}
+
+	root, err := decodePost(idp.response(testRequestID, false, idp.assertion(defaultAssertion(), true)))
+	assert.NilError(t, err)
+	_, err = validateResponse(root, rc)
+	assert.NilError(t, err)
+}
@@ -0,0 +1,704 @@
+// Package saml 逻辑层
+package saml
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/kweaver-ai/go-lib/observable"
+	"github.com/kweaver-ai/go-lib/rest"
+	"github.com/oklog/ulid/v2"
+
+	"Authentication/common"
+	"Authentication/interfaces"
+	"Authentication/logics"
+	"Authentication/logics/audit"
+)
+
+var (
+	sOnce sync.Once
+	s     *saml
+)
+
+const (
+	// randomSize 状态值长度，单位为字节
+	randomSize = 32
+	// requestIDSize 报文ID随机部分长度，单位为字节
+	requestIDSize = 20
+	// stateTimeout 认证请求状态有效期，单位为秒
+	stateTimeout = 10 * 60
+	// sessionTimeout 登录会话保留时间，超过后不再参与单点登出，单位为秒
+	sessionTimeout = 30 * 24 * 60 * 60
+
+	// acsPath 断言消费服务路径
+	acsPath = "/api/authentication/v1/saml/acs"
+	// sloPath 单点登出服务路径
+	sloPath = "/api/authentication/v1/saml/slo"
+)
+
+// 审计日志内容唯一标识
+const (
+	_ int = iota
+	i18nAddProvider
+	i18nUpdateProvider
+	i18nDeleteProvider
+	i18nProvisionUser
+	i18nSAMLExMsg
+)
+
+var (
+	errStateInvalid   = errors.New("state is invalid or expired")
+	errStateUsed      = errors.New("state has been used")
+	errBindingInvalid = errors.New("response must use HTTP-POST binding")
+)
+
+var svcLanguages = map[string]interfaces.Language{
+	"zh_CN": interfaces.SimplifiedChinese,
+	"zh_TW": interfaces.TraditionalChinese,
+	"en_US": interfaces.AmericanEnglish,
+}
+
+var matchTypes = map[string]bool{
+	interfaces.OIDCMatchByAccount: true,
+	interfaces.OIDCMatchByEmail:   true,
+	interfaces.OIDCMatchByThirdID: true,
+}
+
+type saml struct {
+	db         interfaces.DBSAML
+	userMgnt   interfaces.DnUserManagement
+	shareMgnt  interfaces.DnShareMgnt
+	hydraAdmin interfaces.DnHydraAdmin
+	audit      interfaces.LogicsAudit
+	signer     *signer // 为nil时未启用SAML
+	entityID   string
+	acsURL     string
+	sloURL     string
+	loginPage  string
+	logoutPage string
+	lang       interfaces.Language
+	i18n       *common.I18n
+	logger     common.Logger
+	trace      observable.Tracer
+}
+
+// NewSAML 创建SAML服务提供方处理对象
+func NewSAML() *saml {
+	sOnce.Do(func() {
+		config := common.SvcConfig.SAML
+		baseURL := strings.TrimSuffix(config.BaseURL, "/")
+		s = &saml{
+			db:         logics.DBSAML,
+			userMgnt:   logics.DnUserManagement,
+			shareMgnt:  logics.DnShareMgnt,
+			hydraAdmin: logics.DnHydraAdmin,
+			audit:      audit.NewAudit(),
+			entityID:   config.EntityID,
+			acsURL:     baseURL + acsPath,
+			sloURL:     baseURL + sloPath,
+			loginPage:  config.LoginPageURL,
+			logoutPage: config.LogoutPageURL,
+			lang:       svcLanguages[common.SvcConfig.Lang],
+			i18n: common.NewI18n(common.I18nMap{
+				i18nAddProvider: {
+					interfaces.SimplifiedChinese:  "添加SAML身份提供方“%s” 成功",
+					interfaces.TraditionalChinese: "新增SAML身分識別提供者“%s” 成功",
+					interfaces.AmericanEnglish:    "Add SAML identity provider \"%s\" successfully",
+				},
+				i18nUpdateProvider: {
+					interfaces.SimplifiedChinese:  "修改SAML身份提供方“%s” 成功",
+					interfaces.TraditionalChinese: "修改SAML身分識別提供者“%s” 成功",
+					interfaces.AmericanEnglish:    "Update SAML identity provider \"%s\" successfully",
+				},
+				i18nDeleteProvider: {
+					interfaces.SimplifiedChinese:  "删除SAML身份提供方“%s” 成功",
+					interfaces.TraditionalChinese: "刪除SAML身分識別提供者“%s” 成功",
+					interfaces.AmericanEnglish:    "Delete SAML identity provider \"%s\" successfully",
+				},
+				i18nProvisionUser: {
+					interfaces.SimplifiedChinese:  "通过SAML身份提供方“%s”自动创建用户“%s” 成功",
+					interfaces.TraditionalChinese: "透過SAML身分識別提供者“%s”自動建立使用者“%s” 成功",
+					interfaces.AmericanEnglish:    "Create user \"%[2]s\" automatically through SAML identity provider \"%[1]s\" successfully",
+				},
+				i18nSAMLExMsg: {
+					interfaces.SimplifiedChinese:  "身份提供方标识：%s",
+					interfaces.TraditionalChinese: "身分識別提供者標識：%s",
+					interfaces.AmericanEnglish:    "Entity ID: %s",
+				},
+			}),
+			logger: common.NewLogger(),
+			trace:  common.SvcARTrace,
+		}
+
+		if config.EntityID == "" {
+			return
+		}
+		sig, err := newSigner(config.Certificate, common.SvcConfig.SAMLPrivateKey)
+		if err != nil {
+			s.logger.Errorf("saml signing key is invalid, saml is disabled, err: %v", err)
+			return
+		}
+		s.signer = sig
+	})
+
+	return s
+}
+
+// newSigner 解析PEM格式的签名证书及RSA私钥，私钥支持PKCS#1及PKCS#8格式
+func newSigner(certPEM, keyPEM string) (*signer, error) {
+	certBlock, _ := pem.Decode([]byte(certPEM))
+	if certBlock == nil {
+		return nil, errors.New("certificate is not PEM encoded")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	keyBlock, _ := pem.Decode([]byte(keyPEM))
+	if keyBlock == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+	var key *rsa.PrivateKey
+	if key, err = x509.ParsePKCS1PrivateKey(keyBlock.Bytes); err != nil {
+		parsed, err := x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		if key, ok = parsed.(*rsa.PrivateKey); !ok {
+			return nil, errors.New("private key is not RSA")
+		}
+	}
+
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok || pub.N.Cmp(key.N) != 0 || pub.E != key.E {
+		return nil, errors.New("private key does not match certificate")
+	}
+	return &signer{key: key, cert: cert}, nil
+}
+
+// SPMetadata 获取服务提供方元数据
+func (s *saml) SPMetadata(ctx context.Context) (data []byte, err error) {
+	s.trace.SetInternalSpanName("逻辑层-获取SAML服务提供方元数据")
+	_, span := s.trace.AddInternalTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = s.checkEnabled(); err != nil {
+		return nil, err
+	}
+	return buildSPMetadata(s.entityID, s.acsURL, s.sloURL, s.signer.cert), nil
+}
+
+// AddProvider 管理员导入身份提供方元数据，返回身份提供方唯一标识
+func (s *saml) AddProvider(ctx context.Context, visitor *interfaces.Visitor, provider *interfaces.SAMLProvider) (id string, err error) {
+	s.trace.SetInternalSpanName("逻辑层-添加SAML身份提供方")
+	newCtx, span := s.trace.AddInternalTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = s.checkAdmin(newCtx, visitor); err != nil {
+		return "", err
+	}
+	if err = checkProvider(provider); err != nil {
+		return "", err
+	}
+	if err = s.checkEntityIDUnique(newCtx, provider); err != nil {
+		return "", err
+	}
+
+	now := common.Now().Unix()
+	provider.ID = ulid.Make().String()
+	provider.CreateTime = now
+	provider.UpdateTime = now
+	if err = s.db.AddProvider(newCtx, provider); err != nil {
+		return "", err
+	}
+
+	s.writeLog(visitor, visitor.ID, audit.LevelInfo, audit.OpCreate, provider.ID, provider.EntityID, i18nAddProvider, provider.Name)
+	return provider.ID, nil
+}
+
+// UpdateProvider 管理员更新身份提供方
+func (s *saml) UpdateProvider(ctx context.Context, visitor *interfaces.Visitor, provider *interfaces.SAMLProvider) (err error) {
+	s.trace.SetInternalSpanName("逻辑层-更新SAML身份提供方")
+	newCtx, span := s.trace.AddInternalTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = s.checkAdmin(newCtx, visitor); err != nil {
+		return err
+	}
+	if err = checkProvider(provider); err != nil {
+		return err
+	}
+
+	existing, err := s.db.GetProvider(newCtx, provider.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return rest.NewHTTPErrorV2(rest.URINotExist, "identity provider not found")
+	}
+	if err = s.checkEntityIDUnique(newCtx, provider); err != nil {
+		return err
+	}
+
+	provider.CreateTime = existing.CreateTime
+	provider.UpdateTime = common.Now().Unix()
+	ok, err := s.db.UpdateProvider(newCtx, provider)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return rest.NewHTTPErrorV2(rest.URINotExist, "identity provider not found")
+	}
+
+	s.writeLog(visitor, visitor.ID, audit.LevelInfo, audit.OpSet, provider.ID, provider.EntityID, i18nUpdateProvider, provider.Name)
+	return nil
+}
+
+// DeleteProvider 管理员删除身份提供方
+func (s *saml) DeleteProvider(ctx context.Context, visitor *interfaces.Visitor, id string) (err error) {
+	s.trace.SetInternalSpanName("逻辑层-删除SAML身份提供方")
+	newCtx, span := s.trace.AddInternalTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = s.checkAdmin(newCtx, visitor); err != nil {
+		return err
+	}
+
+	provider, err := s.db.GetProvider(newCtx, id)
+	if err != nil {
+		return err
+	}
+	if provider == nil {
+		return rest.NewHTTPErrorV2(rest.URINotExist, "identity provider not found")
+	}
+
+	ok, err := s.db.DeleteProvider(newCtx, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return rest.NewHTTPErrorV2(rest.URINotExist, "identity provider not found")
+	}
+
+	s.writeLog(visitor, visitor.ID, audit.LevelWarn, audit.OpDelete, provider.ID, provider.EntityID, i18nDeleteProvider, provider.Name)
+	return nil
+}
+
+// GetProvider 管理员获取身份提供方
+func (s *saml) GetProvider(ctx context.Context, visitor *interfaces.Visitor, id string) (provider *interfaces.SAMLProvider, err error) {
+	s.trace.SetInternalSpanName("逻辑层-获取SAML身份提供方")
+	newCtx, span := s.trace.AddInternalTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = s.checkAdmin(newCtx, visitor); err != nil {
+		return nil, err
+	}
+
+	provider, err = s.db.GetProvider(newCtx, id)
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil {
+		return nil, rest.NewHTTPErrorV2(rest.URINotExist, "identity provider not found")
+	}
+	return provider, nil
+}
+
+// ListProviders 管理员获取所有身份提供方
+func (s *saml) ListProviders(ctx context.Context, visitor *interfaces.Visitor) (providers []interfaces.SAMLProvider, err error) {
+	s.trace.SetInternalSpanName("逻辑层-获取SAML身份提供方列表")
+	newCtx, span := s.trace.AddInternalTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = s.checkAdmin(newCtx, visitor); err != nil {
+		return nil, err
+	}
+
+	return s.db.GetProviders(newCtx)
+}
+
+// ListEnabledProviders 获取已启用的身份提供方，用于登录页展示，未启用SAML时返回空列表
+func (s *saml) ListEnabledProviders(ctx context.Context) (providers []interfaces.SAMLProvider, err error) {
+	s.trace.SetInternalSpanName("逻辑层-获取已启用的SAML身份提供方")
+	newCtx, span := s.trace.AddInternalTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	providers = make([]interfaces.SAMLProvider, 0)
+	if s.signer == nil {
+		return providers, nil
+	}
+
+	all, err := s.db.GetProviders(newCtx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range all {
+		if all[i].Enabled {
+			providers = append(providers, all[i])
+		}
+	}
+	return providers, nil
+}
+
+// BeginAuth 生成签名的AuthnRequest，状态值作为RelayState传递
+func (s *saml) BeginAuth(ctx context.Context, visitor *interfaces.Visitor, providerID string) (redirect *interfaces.SAMLRedirect, err error) {
+	s.trace.SetInternalSpanName("逻辑层-生成SAML认证请求")
+	newCtx, span := s.trace.AddInternalTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = s.checkEnabled(); err != nil {
+		return nil, err
+	}
+	provider, err := s.getEnabledProvider(newCtx, providerID)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := parseIDPMetadata(provider.Metadata)
+	if err != nil {
+		return nil, s.authFailed(provider.EntityID, err)
+	}
+
+	state, err1 := randomString()
+	requestID, err2 := newID()
+	if err1 != nil || err2 != nil {
+		return nil, rest.NewHTTPErrorV2(rest.InternalServerError, "generate random failed")
+	}
+
+	now := common.Now().Unix()
+	authState := &interfaces.SAMLAuthState{
+		State:      state,
+		ProviderID: provider.ID,
+		RequestID:  requestID,
+		CreateTime: now,
+	}
+	if err = s.db.CreateState(newCtx, authState, now-stateTimeout); err != nil {
+		return nil, err
+	}
+
+	doc := fmt.Sprintf(`<samlp:AuthnRequest xmlns:samlp="%s" xmlns:saml="%s" ID="%s" Version="2.0" IssueInstant="%s" `+
+		`Destination="%s" ProtocolBinding="%s" AssertionConsumerServiceURL="%s">`+
+		`<saml:Issuer>%s</saml:Issuer><samlp:NameIDPolicy AllowCreate="true"></samlp:NameIDPolicy></samlp:AuthnRequest>`,
+		nsSAMLP, nsSAML, requestID, issueInstant(), xmlEscape(meta.sso.location), bindingPOST, xmlEscape(s.acsURL), xmlEscape(s.entityID))
+	redirect, err = s.signer.encode(&meta.sso, meta.sso.location, paramSAMLRequest, []byte(doc), state)
+	if err != nil {
+		return nil, rest.NewHTTPErrorV2(rest.InternalServerError, "sign authn request failed")
+	}
+	return redirect, nil
+}
+
+// ConsumeResponse 断言消费服务，校验身份提供方响应并映射账户，映射结果绑定到状态值，返回携带状态值的登录页地址
+func (s *saml) ConsumeResponse(ctx context.Context, visitor *interfaces.Visitor, msg *interfaces.SAMLMessage) (redirectURL string, err error) {
+	s.trace.SetInternalSpanName("逻辑层-SAML断言消费")
+	newCtx, span := s.trace.AddInternalTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = s.checkEnabled(); err != nil {
+		return "", err
+	}
+	if msg.Redirect {
+		return "", s.authFailed("", errBindingInvalid)
+	}
+
+	state, err := s.db.GetState(newCtx, msg.RelayState)
+	if err != nil {
+		return "", err
+	}
+	if state == nil || common.Now().Unix()-state.CreateTime > stateTimeout {
+		return "", s.authFailed("", errStateInvalid)
+	}
+	if state.UserID != "" {
+		return "", s.authFailed("", errStateUsed)
+	}
+
+	provider, err := s.getEnabledProvider(newCtx, state.ProviderID)
+	if err != nil {
+		return "", err
+	}
+	meta, err := parseIDPMetadata(provider.Metadata)
+	if err != nil {
+		return "", s.authFailed(provider.EntityID, err)
+	}
+
+	root, err := decodePost(msg.SAMLResponse)
+	if err != nil {
+		return "", s.authFailed(provider.EntityID, err)
+	}
+	assertion, err := validateResponse(root, &responseContext{
+		meta:      meta,
+		entityID:  s.entityID,
+		acsURL:    s.acsURL,
+		requestID: state.RequestID,
+	})
+	if err != nil {
+		return "", s.authFailed(provider.EntityID, err)
+	}
+
+	userID, err := s.mapUser(newCtx, visitor, provider, assertion)
+	if err != nil {
+		return "", err
+	}
+
+	ok, err := s.db.BindStateUser(newCtx, state.State, userID)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", s.authFailed(provider.EntityID, errStateUsed)
+	}
+
+	now := common.Now().Unix()
+	session := &interfaces.SAMLSession{
+		UserID:          userID,
+		ProviderID:      provider.ID,
+		NameID:          assertion.nameID,
+		NameIDFormat:    assertion.nameIDFormat,
+		NameQualifier:   assertion.nameQualifier,
+		SPNameQualifier: assertion.spNameQualifier,
+		SessionIndex:    assertion.sessionIndex,
+		CreateTime:      now,
+	}
+	if err = s.db.AddSession(newCtx, session, now-sessionTimeout); err != nil {
+		return "", err
+	}
+
+	return appendQuery(s.loginPage, url.Values{"state": {state.State}}), nil
+}
+
+// Authenticate 使用断言校验通过的状态值完成认证，返回映射或自动创建的账户
+func (s *saml) Authenticate(ctx context.Context, visitor *interfaces.Visitor, stateStr string) (userID string, err error) {
+	s.trace.SetInternalSpanName("逻辑层-SAML身份提供方认证")
+	newCtx, span := s.trace.AddInternalTrace(ctx)
+	defer func() { s.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = s.checkEnabled(); err != nil {
+		return "", err
+	}
+
+	state, err := s.db.ConsumeState(newCtx, stateStr)
+	if err != nil {
+		return "", err
+	}
+	if state == nil || state.UserID == "" || common.Now().Unix()-state.CreateTime > stateTimeout {
+		return "", s.authFailed("", errStateInvalid)
+	}
+
+	if _, err = s.getEnabledProvider(newCtx, state.ProviderID); err != nil {
+		return "", err
+	}
+	return state.UserID, nil
+}
+
+// mapUser 按映射规则匹配账户，未匹配时根据配置自动创建账户
+func (s *saml) mapUser(ctx context.Context, visitor *interfaces.Visitor, provider *interfaces.SAMLProvider,
+	assertion *assertionInfo) (userID string, err error) {
+	matched, info, err := s.matchUser(ctx, visitor, provider, assertion)
+	if err != nil {
+		return "", err
+	}
+	if matched {
+		return info.ID, nil
+	}
+
+	if !provider.JITProvision {
+		s.logger.Warnf("saml account not matched, entity id: %s, name id: %s", provider.EntityID, assertion.nameID)
+		return "", rest.NewHTTPError("", common.SAMLAccountNotMatched, nil)
+	}
+	return s.provision(ctx, visitor, provider, assertion)
+}
+
+// matchUser 按映射规则顺序匹配账户，属性缺失时跳过该规则
+func (s *saml) matchUser(ctx context.Context, visitor *interfaces.Visitor, provider *interfaces.SAMLProvider,
+	assertion *assertionInfo) (matched bool, info interfaces.UserBaseInfo, err error) {
+	for _, rule := range provider.MappingRules {
+		value := assertion.value(rule.Attribute)
+		if value == "" {
+			continue
+		}
+
+		switch rule.MatchBy {
+		case interfaces.OIDCMatchByAccount:
+			matched, info, err = s.userMgnt.AccountMatch(ctx, visitor, value, false, false)
+		case interfaces.OIDCMatchByEmail:
+			matched, info, err = s.userMgnt.UserMatch(ctx, visitor, rule.MatchBy, value)
+		case interfaces.OIDCMatchByThirdID:
+			matched, info, err = s.userMgnt.UserMatch(ctx, visitor, rule.MatchBy, scopedThirdID(provider.EntityID, value))
+		default:
+			continue
+		}
+		if err != nil || matched {
+			return matched, info, err
+		}
+	}
+
+	return false, info, nil
+}
+
+// provision 自动创建账户，账户名、邮箱及第三方用户ID取自映射规则中对应的属性，
+// 未配置账户名映射时使用非临时格式的NameID作为账户名
+func (s *saml) provision(ctx context.Context, visitor *interfaces.Visitor, provider *interfaces.SAMLProvider,
+	assertion *assertionInfo) (userID string, err error) {
+	info := &interfaces.NewUserInfo{}
+	if provider.NameAttribute != "" {
+		info.Name = assertion.value(provider.NameAttribute)
+	}
+	for _, rule := range provider.MappingRules {
+		value := assertion.value(rule.Attribute)
+		switch {
+		case rule.MatchBy == interfaces.OIDCMatchByAccount && info.Account == "":
+			info.Account = value
+		case rule.MatchBy == interfaces.OIDCMatchByEmail && info.Email == "":
+			info.Email = value
+		case rule.MatchBy == interfaces.OIDCMatchByThirdID && info.ThirdID == "" && value != "":
+			info.ThirdID = scopedThirdID(provider.EntityID, value)
+		}
+	}
+	if info.Account == "" && assertion.nameIDFormat != nameIDTransient {
+		info.Account = assertion.nameID
+	}
+	if info.Account == "" {
+		s.logger.Warnf("saml provision failed, entity id: %s, reason: account attribute missing", provider.EntityID)
+		return "", rest.NewHTTPError("", common.SAMLAccountNotMatched, nil)
+	}
+	if info.Name == "" {
+		info.Name = info.Account
+	}
+
+	userID, err = s.shareMgnt.UsrmAddUser(ctx, info)
+	if err != nil {
+		return "", err
+	}
+
+	s.writeLog(visitor, userID, audit.LevelInfo, audit.OpCreate, userID, provider.EntityID, i18nProvisionUser, provider.Name, info.Account)
+	return userID, nil
+}
+
+// checkEntityIDUnique 同一身份提供方标识只允许配置一次
+func (s *saml) checkEntityIDUnique(ctx context.Context, provider *interfaces.SAMLProvider) error {
+	existing, err := s.db.GetProviderByEntityID(ctx, provider.EntityID)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != provider.ID {
+		return rest.NewHTTPErrorV2(rest.Conflict, "identity provider with the same entity id already exists")
+	}
+	return nil
+}
+
+// getEnabledProvider 获取已启用的身份提供方，不存在或未启用时不允许认证
+func (s *saml) getEnabledProvider(ctx context.Context, id string) (*interfaces.SAMLProvider, error) {
+	provider, err := s.db.GetProvider(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil || !provider.Enabled {
+		return nil, rest.NewHTTPErrorV2(rest.Forbidden, "identity provider is not enabled")
+	}
+	return provider, nil
+}
+
+// checkEnabled 未配置服务提供方标识或签名密钥无效时不允许SAML认证
+func (s *saml) checkEnabled() error {
+	if s.signer == nil {
+		return rest.NewHTTPErrorV2(rest.Forbidden, "saml is not enabled")
+	}
+	return nil
+}
+
+// authFailed 记录认证失败原因，并返回统一的认证失败错误，避免泄露校验细节
+func (s *saml) authFailed(entityID string, reason error) error {
+	s.logger.Warnf("saml authenticate failed, entity id: %s, reason: %v", entityID, reason)
+	return rest.NewHTTPError("", common.SAMLAuthFailed, nil)
+}
+
+func (s *saml) checkAdmin(ctx context.Context, visitor *interfaces.Visitor) (err error) {
+	var roleTypes []interfaces.RoleType
+	// 实名用户获取对应角色信息
+	if visitor.Type == interfaces.RealName {
+		roleTypes, err = s.userMgnt.GetUserRolesByUserID(ctx, visitor, visitor.ID)
+		if err != nil {
+			return
+		}
+	}
+
+	return logics.CheckVisitorType(visitor, roleTypes, []interfaces.VisitorType{interfaces.RealName},
+		[]interfaces.RoleType{interfaces.SuperAdmin, interfaces.SystemAdmin, interfaces.SecurityAdmin})
+}
+
+// writeLog 通过审计日志模块记录管理日志
+func (s *saml) writeLog(visitor *interfaces.Visitor, userID string, level audit.LogLevel, opType audit.ManageOpType, objID, entityID string, msgID int, args ...any) {
+	audit.LogManagement(s.audit, s.logger, visitor, &audit.ManagementLog{
+		UserID: userID,
+		Level:  level,
+		OpType: opType,
+		ObjID:  objID,
+		Msg:    s.i18n.Load(msgID, s.lang, args...),
+		ExMsg:  s.i18n.Load(i18nSAMLExMsg, s.lang, entityID),
+	})
+}
+
+// checkProvider 校验身份提供方配置，身份提供方标识取自元数据
+func checkProvider(provider *interfaces.SAMLProvider) error {
+	meta, err := parseIDPMetadata(provider.Metadata)
+	if err != nil {
+		return rest.NewHTTPError("invalid metadata: "+err.Error(), rest.BadRequest, map[string]interface{}{"params": []string{"metadata"}})
+	}
+	if len(provider.MappingRules) == 0 {
+		return rest.NewHTTPError("mapping_rules is required", rest.BadRequest, map[string]interface{}{"params": []string{"mapping_rules"}})
+	}
+	for _, rule := range provider.MappingRules {
+		if rule.Attribute == "" || !matchTypes[rule.MatchBy] {
+			return rest.NewHTTPError("invalid mapping rule", rest.BadRequest, map[string]interface{}{"params": []string{"mapping_rules"}})
+		}
+	}
+	provider.EntityID = meta.entityID
+	return nil
+}
+
+// scopedThirdID 第三方用户ID以身份提供方标识限定，避免不同身份提供方的用户标识相同时匹配到同一账户
+func scopedThirdID(entityID, nameID string) string {
+	return entityID + "|" + nameID
+}
+
+// appendQuery 为地址追加查询参数
+func appendQuery(target string, query url.Values) string {
+	separator := "?"
+	if strings.Contains(target, "?") {
+		separator = "&"
+	}
+	return target + separator + query.Encode()
+}
+
+// randomString 生成base64url编码的随机值
+func randomString() (string, error) {
+	buf := make([]byte, randomSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// newID 生成报文ID，ID必须以字母或下划线开头
+func newID() (string, error) {
+	buf := make([]byte, requestIDSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "_" + hex.EncodeToString(buf), nil
+}
+
+// issueInstant 报文生成时间
+func issueInstant() string {
+	return common.Now().UTC().Format(timeFormat)
+}
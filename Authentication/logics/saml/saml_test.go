@@ -0,0 +1,735 @@
+package saml
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/kweaver-ai/go-lib/rest"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/assert"
+
+	"Authentication/common"
+	"Authentication/interfaces"
+	"Authentication/interfaces/mock"
+	laudit "Authentication/logics/audit"
+)
+
+const (
+	testProviderID = "01HZX5Q7N0Y6S8D3T4V5W6X7Y8"
+	testLoginPage  = "https://anyshare.example.com/login/saml"
+	testLogoutPage = "https://anyshare.example.com/logout"
+)
+
+func newSAML(db interfaces.DBSAML, userMgnt interfaces.DnUserManagement, shareMgnt interfaces.DnShareMgnt,
+	hydraAdmin interfaces.DnHydraAdmin, audit interfaces.LogicsAudit, trace interfaces.TraceClient) *saml {
+	return &saml{
+		db:         db,
+		userMgnt:   userMgnt,
+		shareMgnt:  shareMgnt,
+		hydraAdmin: hydraAdmin,
+		audit:      audit,
+		signer:     newTestSigner("sp"),
+		entityID:   testSPEntityID,
+		acsURL:     testACSURL,
+		sloURL:     testSLOURL,
+		loginPage:  testLoginPage,
+		logoutPage: testLogoutPage,
+		lang:       interfaces.SimplifiedChinese,
+		i18n:       common.NewI18n(common.I18nMap{}),
+		logger:     common.NewLogger(),
+		trace:      trace,
+	}
+}
+
+func testProvider(idp *testIDP) *interfaces.SAMLProvider {
+	return &interfaces.SAMLProvider{
+		ID:       testProviderID,
+		Name:     "adfs",
+		EntityID: testIDPEntityID,
+		Metadata: idp.metadata(bindingRedirect, bindingRedirect),
+		MappingRules: []interfaces.SAMLMappingRule{
+			{Attribute: nameIDAttribute, MatchBy: interfaces.OIDCMatchByThirdID},
+			{Attribute: "urn:oid:0.9.2342.19200300.100.1.3", MatchBy: interfaces.OIDCMatchByEmail},
+		},
+		NameAttribute: "displayName",
+		Enabled:       true,
+	}
+}
+
+func expectTrace(trace *mock.MockTraceClient, ctx context.Context) {
+	trace.EXPECT().SetInternalSpanName(gomock.Any()).AnyTimes()
+	trace.EXPECT().AddInternalTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+	trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+}
+
+func TestNewSigner(t *testing.T) {
+	sp := newTestSigner("sp")
+	certPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: sp.cert.Raw}))
+	pkcs1 := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(sp.key)}))
+	der, err := x509.MarshalPKCS8PrivateKey(sp.key)
+	assert.NilError(t, err)
+	pkcs8 := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+	s, err := newSigner(certPEM, pkcs1)
+	assert.NilError(t, err)
+	assert.Equal(t, s.key.N.Cmp(sp.key.N), 0)
+
+	_, err = newSigner(certPEM, pkcs8)
+	assert.NilError(t, err)
+
+	other := newTestSigner("other")
+	otherKey := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(other.key)}))
+	_, err = newSigner(certPEM, otherKey)
+	assert.ErrorContains(t, err, "does not match")
+
+	_, err = newSigner("", pkcs1)
+	assert.ErrorContains(t, err, "not PEM")
+}
+
+func TestAddProvider(t *testing.T) {
+	Convey("AddProvider", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBSAML(ctrl)
+		userMgnt := mock.NewMockDnUserManagement(ctrl)
+		audit := mock.NewMockLogicsAudit(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		s := newSAML(db, userMgnt, nil, nil, audit, trace)
+
+		ctx := context.Background()
+		expectTrace(trace, ctx)
+
+		visitor := &interfaces.Visitor{ID: "admin", Type: interfaces.RealName}
+		provider := testProvider(newTestIDP())
+		provider.ID = ""
+		provider.EntityID = ""
+
+		Convey("not admin", func() {
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any(), "admin").Return([]interfaces.RoleType{interfaces.NormalUser}, nil)
+
+			_, err := s.AddProvider(ctx, visitor, provider)
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.Unauthorized)
+		})
+
+		Convey("invalid metadata", func() {
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any(), "admin").Return([]interfaces.RoleType{interfaces.SuperAdmin}, nil)
+			provider.Metadata = "<md:EntityDescriptor"
+
+			_, err := s.AddProvider(ctx, visitor, provider)
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.BadRequest)
+		})
+
+		Convey("invalid mapping rule", func() {
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any(), "admin").Return([]interfaces.RoleType{interfaces.SuperAdmin}, nil)
+			provider.MappingRules = []interfaces.SAMLMappingRule{{Attribute: nameIDAttribute, MatchBy: "phone"}}
+
+			_, err := s.AddProvider(ctx, visitor, provider)
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.BadRequest)
+		})
+
+		Convey("entity id exists", func() {
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any(), "admin").Return([]interfaces.RoleType{interfaces.SuperAdmin}, nil)
+			db.EXPECT().GetProviderByEntityID(gomock.Any(), testIDPEntityID).Return(&interfaces.SAMLProvider{ID: "other"}, nil)
+
+			_, err := s.AddProvider(ctx, visitor, provider)
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.Conflict)
+		})
+
+		Convey("success", func() {
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any(), "admin").Return([]interfaces.RoleType{interfaces.SecurityAdmin}, nil)
+			db.EXPECT().GetProviderByEntityID(gomock.Any(), testIDPEntityID).Return(nil, nil)
+			db.EXPECT().AddProvider(gomock.Any(), provider).Return(nil)
+			audit.EXPECT().Log(laudit.TopicManagementLog, gomock.Any()).Return(nil)
+
+			id, err := s.AddProvider(ctx, visitor, provider)
+			assert.NilError(t, err)
+			assert.Assert(t, id != "")
+			assert.Equal(t, provider.ID, id)
+			assert.Equal(t, provider.EntityID, testIDPEntityID)
+			assert.Assert(t, provider.CreateTime > 0)
+		})
+	})
+}
+
+func TestUpdateProvider(t *testing.T) {
+	Convey("UpdateProvider", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBSAML(ctrl)
+		userMgnt := mock.NewMockDnUserManagement(ctrl)
+		audit := mock.NewMockLogicsAudit(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		s := newSAML(db, userMgnt, nil, nil, audit, trace)
+
+		ctx := context.Background()
+		expectTrace(trace, ctx)
+
+		visitor := &interfaces.Visitor{ID: "admin", Type: interfaces.RealName}
+		userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any(), "admin").Return([]interfaces.RoleType{interfaces.SuperAdmin}, nil)
+		idp := newTestIDP()
+		existing := testProvider(idp)
+		existing.CreateTime = 100
+
+		Convey("not exist", func() {
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(nil, nil)
+
+			err := s.UpdateProvider(ctx, visitor, testProvider(idp))
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.URINotExist)
+		})
+
+		Convey("success", func() {
+			provider := testProvider(idp)
+			provider.Name = "adfs2"
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(existing, nil)
+			db.EXPECT().GetProviderByEntityID(gomock.Any(), testIDPEntityID).Return(existing, nil)
+			db.EXPECT().UpdateProvider(gomock.Any(), provider).Return(true, nil)
+			audit.EXPECT().Log(laudit.TopicManagementLog, gomock.Any()).Return(nil)
+
+			err := s.UpdateProvider(ctx, visitor, provider)
+			assert.NilError(t, err)
+			assert.Equal(t, provider.CreateTime, int64(100))
+		})
+	})
+}
+
+func TestListEnabledProviders(t *testing.T) {
+	Convey("ListEnabledProviders", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBSAML(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		s := newSAML(db, nil, nil, nil, nil, trace)
+
+		ctx := context.Background()
+		expectTrace(trace, ctx)
+
+		Convey("saml disabled", func() {
+			s.signer = nil
+
+			providers, err := s.ListEnabledProviders(ctx)
+			assert.NilError(t, err)
+			assert.Equal(t, len(providers), 0)
+		})
+
+		Convey("success", func() {
+			db.EXPECT().GetProviders(gomock.Any()).Return([]interfaces.SAMLProvider{
+				{ID: "p1", Enabled: true}, {ID: "p2"}, {ID: "p3", Enabled: true},
+			}, nil)
+
+			providers, err := s.ListEnabledProviders(ctx)
+			assert.NilError(t, err)
+			assert.Equal(t, len(providers), 2)
+			assert.Equal(t, providers[0].ID, "p1")
+			assert.Equal(t, providers[1].ID, "p3")
+		})
+	})
+}
+
+func TestSPMetadata(t *testing.T) {
+	Convey("SPMetadata", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		trace := mock.NewMockTraceClient(ctrl)
+		s := newSAML(nil, nil, nil, nil, nil, trace)
+
+		ctx := context.Background()
+		expectTrace(trace, ctx)
+
+		Convey("saml disabled", func() {
+			s.signer = nil
+
+			_, err := s.SPMetadata(ctx)
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.Forbidden)
+		})
+
+		Convey("success", func() {
+			data, err := s.SPMetadata(ctx)
+			assert.NilError(t, err)
+			assert.Assert(t, strings.Contains(string(data), `entityID="`+testSPEntityID+`"`))
+			assert.Assert(t, strings.Contains(string(data), testACSURL))
+		})
+	})
+}
+
+func TestBeginAuth(t *testing.T) {
+	Convey("BeginAuth", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBSAML(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		s := newSAML(db, nil, nil, nil, nil, trace)
+
+		ctx := context.Background()
+		expectTrace(trace, ctx)
+
+		visitor := &interfaces.Visitor{}
+		idp := newTestIDP()
+		provider := testProvider(idp)
+
+		Convey("not enabled", func() {
+			provider.Enabled = false
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+
+			_, err := s.BeginAuth(ctx, visitor, testProviderID)
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.Forbidden)
+		})
+
+		Convey("redirect binding", func() {
+			var saved *interfaces.SAMLAuthState
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+			db.EXPECT().CreateState(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, state *interfaces.SAMLAuthState, expireBefore int64) error {
+					saved = state
+					assert.Equal(t, expireBefore, state.CreateTime-stateTimeout)
+					return nil
+				})
+
+			redirect, err := s.BeginAuth(ctx, visitor, testProviderID)
+			assert.NilError(t, err)
+			assert.Equal(t, redirect.Binding, interfaces.SAMLBindingRedirect)
+			assert.Equal(t, saved.ProviderID, testProviderID)
+
+			u, err := url.Parse(redirect.URL)
+			assert.NilError(t, err)
+			assert.Equal(t, u.Scheme+"://"+u.Host+u.Path, testIDPSSOURL)
+			assert.Equal(t, u.Query().Get(paramRelayState), saved.State)
+
+			// 身份提供方使用服务提供方元数据中的证书校验签名
+			msg := &interfaces.SAMLMessage{Redirect: true, RawQuery: u.RawQuery}
+			root, err := decodeMessage(msg, paramSAMLRequest, func(*xmlNode) ([]*x509.Certificate, error) {
+				return []*x509.Certificate{s.signer.cert}, nil
+			})
+			assert.NilError(t, err)
+			assert.Assert(t, root.is(nsSAMLP, "AuthnRequest"))
+			assert.Equal(t, root.attr("ID"), saved.RequestID)
+			assert.Equal(t, root.attr("Destination"), testIDPSSOURL)
+			assert.Equal(t, root.attr("AssertionConsumerServiceURL"), testACSURL)
+			assert.Equal(t, root.element(nsSAML, "Issuer").text(), testSPEntityID)
+		})
+
+		Convey("post binding", func() {
+			provider.Metadata = idp.metadata(bindingPOST, "")
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+			db.EXPECT().CreateState(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+			redirect, err := s.BeginAuth(ctx, visitor, testProviderID)
+			assert.NilError(t, err)
+			assert.Equal(t, redirect.Binding, interfaces.SAMLBindingPost)
+			assert.Equal(t, redirect.URL, testIDPSSOURL)
+			assert.Assert(t, redirect.Params[paramSAMLRequest] != "")
+			assert.Assert(t, redirect.Params[paramRelayState] != "")
+		})
+	})
+}
+
+//nolint:funlen
+func TestConsumeResponse(t *testing.T) {
+	Convey("ConsumeResponse", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBSAML(ctrl)
+		userMgnt := mock.NewMockDnUserManagement(ctrl)
+		shareMgnt := mock.NewMockDnShareMgnt(ctrl)
+		audit := mock.NewMockLogicsAudit(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		s := newSAML(db, userMgnt, shareMgnt, nil, audit, trace)
+
+		ctx := context.Background()
+		expectTrace(trace, ctx)
+
+		visitor := &interfaces.Visitor{}
+		idp := newTestIDP()
+		provider := testProvider(idp)
+		state := &interfaces.SAMLAuthState{
+			State:      "state1",
+			ProviderID: testProviderID,
+			RequestID:  testRequestID,
+			CreateTime: common.Now().Unix(),
+		}
+		msg := &interfaces.SAMLMessage{
+			SAMLResponse: idp.response(testRequestID, false, idp.assertion(defaultAssertion(), true)),
+			RelayState:   "state1",
+		}
+
+		Convey("saml disabled", func() {
+			s.signer = nil
+
+			_, err := s.ConsumeResponse(ctx, visitor, msg)
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.Forbidden)
+		})
+
+		Convey("redirect binding", func() {
+			msg.Redirect = true
+
+			_, err := s.ConsumeResponse(ctx, visitor, msg)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.SAMLAuthFailed)
+		})
+
+		Convey("state not found", func() {
+			db.EXPECT().GetState(gomock.Any(), "state1").Return(nil, nil)
+
+			_, err := s.ConsumeResponse(ctx, visitor, msg)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.SAMLAuthFailed)
+		})
+
+		Convey("state expired", func() {
+			state.CreateTime -= stateTimeout + 1
+			db.EXPECT().GetState(gomock.Any(), "state1").Return(state, nil)
+
+			_, err := s.ConsumeResponse(ctx, visitor, msg)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.SAMLAuthFailed)
+		})
+
+		Convey("state used", func() {
+			state.UserID = "user1"
+			db.EXPECT().GetState(gomock.Any(), "state1").Return(state, nil)
+
+			_, err := s.ConsumeResponse(ctx, visitor, msg)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.SAMLAuthFailed)
+		})
+
+		Convey("invalid response", func() {
+			msg.SAMLResponse = idp.response("_other", false, idp.assertion(defaultAssertion(), true))
+			db.EXPECT().GetState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+
+			_, err := s.ConsumeResponse(ctx, visitor, msg)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.SAMLAuthFailed)
+		})
+
+		Convey("match by email", func() {
+			db.EXPECT().GetState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+			userMgnt.EXPECT().UserMatch(gomock.Any(), visitor, interfaces.OIDCMatchByThirdID, provider.EntityID+"|user1").Return(false, interfaces.UserBaseInfo{}, nil)
+			userMgnt.EXPECT().UserMatch(gomock.Any(), visitor, interfaces.OIDCMatchByEmail, "user1@example.com").
+				Return(true, interfaces.UserBaseInfo{ID: "uid1"}, nil)
+			db.EXPECT().BindStateUser(gomock.Any(), "state1", "uid1").Return(true, nil)
+			db.EXPECT().AddSession(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, session *interfaces.SAMLSession, expireBefore int64) error {
+					assert.Equal(t, session.UserID, "uid1")
+					assert.Equal(t, session.ProviderID, testProviderID)
+					assert.Equal(t, session.NameID, "user1")
+					assert.Equal(t, session.NameIDFormat, nameIDPersistent)
+					assert.Equal(t, session.SessionIndex, "_session1")
+					assert.Equal(t, expireBefore, session.CreateTime-sessionTimeout)
+					return nil
+				})
+
+			redirectURL, err := s.ConsumeResponse(ctx, visitor, msg)
+			assert.NilError(t, err)
+			assert.Equal(t, redirectURL, testLoginPage+"?state=state1")
+		})
+
+		Convey("replayed", func() {
+			db.EXPECT().GetState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+			userMgnt.EXPECT().UserMatch(gomock.Any(), visitor, interfaces.OIDCMatchByThirdID, provider.EntityID+"|user1").
+				Return(true, interfaces.UserBaseInfo{ID: "uid1"}, nil)
+			db.EXPECT().BindStateUser(gomock.Any(), "state1", "uid1").Return(false, nil)
+
+			_, err := s.ConsumeResponse(ctx, visitor, msg)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.SAMLAuthFailed)
+		})
+
+		Convey("not matched", func() {
+			db.EXPECT().GetState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+			userMgnt.EXPECT().UserMatch(gomock.Any(), visitor, gomock.Any(), gomock.Any()).Times(2).Return(false, interfaces.UserBaseInfo{}, nil)
+
+			_, err := s.ConsumeResponse(ctx, visitor, msg)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.SAMLAccountNotMatched)
+		})
+
+		Convey("match failed", func() {
+			db.EXPECT().GetState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+			userMgnt.EXPECT().UserMatch(gomock.Any(), visitor, gomock.Any(), gomock.Any()).Return(false, interfaces.UserBaseInfo{}, errors.New("err"))
+
+			_, err := s.ConsumeResponse(ctx, visitor, msg)
+			assert.ErrorContains(t, err, "err")
+		})
+
+		Convey("provision", func() {
+			provider.JITProvision = true
+			db.EXPECT().GetState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+			userMgnt.EXPECT().UserMatch(gomock.Any(), visitor, gomock.Any(), gomock.Any()).Times(2).Return(false, interfaces.UserBaseInfo{}, nil)
+			shareMgnt.EXPECT().UsrmAddUser(gomock.Any(), &interfaces.NewUserInfo{
+				Account: "user1",
+				Name:    "User One",
+				Email:   "user1@example.com",
+				ThirdID: provider.EntityID + "|user1",
+			}).Return("uid2", nil)
+			audit.EXPECT().Log(laudit.TopicManagementLog, gomock.Any()).Return(nil)
+			db.EXPECT().BindStateUser(gomock.Any(), "state1", "uid2").Return(true, nil)
+			db.EXPECT().AddSession(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+			redirectURL, err := s.ConsumeResponse(ctx, visitor, msg)
+			assert.NilError(t, err)
+			assert.Equal(t, redirectURL, testLoginPage+"?state=state1")
+		})
+
+		Convey("provision with transient name id", func() {
+			provider.JITProvision = true
+			provider.MappingRules = provider.MappingRules[1:]
+			a := defaultAssertion()
+			a.nameIDFormat = nameIDTransient
+			msg.SAMLResponse = idp.response(testRequestID, false, idp.assertion(a, true))
+			db.EXPECT().GetState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+			userMgnt.EXPECT().UserMatch(gomock.Any(), visitor, gomock.Any(), gomock.Any()).Return(false, interfaces.UserBaseInfo{}, nil)
+
+			_, err := s.ConsumeResponse(ctx, visitor, msg)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.SAMLAccountNotMatched)
+		})
+	})
+}
+
+func TestAuthenticate(t *testing.T) {
+	Convey("Authenticate", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBSAML(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		s := newSAML(db, nil, nil, nil, nil, trace)
+
+		ctx := context.Background()
+		expectTrace(trace, ctx)
+
+		visitor := &interfaces.Visitor{}
+		provider := testProvider(newTestIDP())
+		state := &interfaces.SAMLAuthState{
+			State:      "state1",
+			ProviderID: testProviderID,
+			RequestID:  testRequestID,
+			UserID:     "uid1",
+			CreateTime: common.Now().Unix(),
+		}
+
+		Convey("state not found", func() {
+			db.EXPECT().ConsumeState(gomock.Any(), "state1").Return(nil, nil)
+
+			_, err := s.Authenticate(ctx, visitor, "state1")
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.SAMLAuthFailed)
+		})
+
+		Convey("response not consumed", func() {
+			state.UserID = ""
+			db.EXPECT().ConsumeState(gomock.Any(), "state1").Return(state, nil)
+
+			_, err := s.Authenticate(ctx, visitor, "state1")
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.SAMLAuthFailed)
+		})
+
+		Convey("state expired", func() {
+			state.CreateTime -= stateTimeout + 1
+			db.EXPECT().ConsumeState(gomock.Any(), "state1").Return(state, nil)
+
+			_, err := s.Authenticate(ctx, visitor, "state1")
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.SAMLAuthFailed)
+		})
+
+		Convey("provider disabled", func() {
+			provider.Enabled = false
+			db.EXPECT().ConsumeState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+
+			_, err := s.Authenticate(ctx, visitor, "state1")
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.Forbidden)
+		})
+
+		Convey("success", func() {
+			db.EXPECT().ConsumeState(gomock.Any(), "state1").Return(state, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+
+			userID, err := s.Authenticate(ctx, visitor, "state1")
+			assert.NilError(t, err)
+			assert.Equal(t, userID, "uid1")
+		})
+	})
+}
+
+func TestBeginLogout(t *testing.T) {
+	Convey("BeginLogout", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBSAML(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		s := newSAML(db, nil, nil, nil, nil, trace)
+
+		ctx := context.Background()
+		expectTrace(trace, ctx)
+
+		visitor := &interfaces.Visitor{ID: "uid1"}
+		idp := newTestIDP()
+		provider := testProvider(idp)
+		session := interfaces.SAMLSession{
+			UserID:       "uid1",
+			ProviderID:   testProviderID,
+			NameID:       "user1",
+			NameIDFormat: nameIDPersistent,
+			SessionIndex: "_session1",
+		}
+
+		Convey("no session", func() {
+			db.EXPECT().GetSessionsByUserID(gomock.Any(), "uid1").Return(nil, nil)
+
+			redirect, err := s.BeginLogout(ctx, visitor)
+			assert.NilError(t, err)
+			assert.Assert(t, redirect == nil)
+		})
+
+		Convey("idp without single logout", func() {
+			provider.Metadata = idp.metadata(bindingRedirect, "")
+			db.EXPECT().GetSessionsByUserID(gomock.Any(), "uid1").Return([]interfaces.SAMLSession{session}, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+			db.EXPECT().DeleteSessions(gomock.Any(), "uid1", testProviderID).Return(nil)
+
+			redirect, err := s.BeginLogout(ctx, visitor)
+			assert.NilError(t, err)
+			assert.Assert(t, redirect == nil)
+		})
+
+		Convey("success", func() {
+			db.EXPECT().GetSessionsByUserID(gomock.Any(), "uid1").Return([]interfaces.SAMLSession{session}, nil)
+			db.EXPECT().GetProvider(gomock.Any(), testProviderID).Return(provider, nil)
+			db.EXPECT().DeleteSessions(gomock.Any(), "uid1", testProviderID).Return(nil)
+
+			redirect, err := s.BeginLogout(ctx, visitor)
+			assert.NilError(t, err)
+			assert.Equal(t, redirect.Binding, interfaces.SAMLBindingRedirect)
+
+			u, err := url.Parse(redirect.URL)
+			assert.NilError(t, err)
+			msg := &interfaces.SAMLMessage{Redirect: true, RawQuery: u.RawQuery}
+			root, err := decodeMessage(msg, paramSAMLRequest, func(*xmlNode) ([]*x509.Certificate, error) {
+				return []*x509.Certificate{s.signer.cert}, nil
+			})
+			assert.NilError(t, err)
+			assert.Assert(t, root.is(nsSAMLP, "LogoutRequest"))
+			assert.Equal(t, root.attr("Destination"), testIDPSLOURL)
+			assert.Equal(t, root.element(nsSAML, "NameID").text(), "user1")
+			assert.Equal(t, root.element(nsSAML, "NameID").attr("Format"), nameIDPersistent)
+			assert.Equal(t, root.element(nsSAMLP, "SessionIndex").text(), "_session1")
+		})
+	})
+}
+
+// logoutRequest 模拟身份提供方通过HTTP-Redirect绑定发送签名的LogoutRequest
+func (idp *testIDP) logoutRequest(nameID, sessionIndex string) *interfaces.SAMLMessage {
+	doc := `<samlp:LogoutRequest xmlns:samlp="` + nsSAMLP + `" xmlns:saml="` + nsSAML + `" ID="_logout1" Version="2.0" ` +
+		`IssueInstant="` + issueInstant() + `" Destination="` + testSLOURL + `"><saml:Issuer>` + testIDPEntityID + `</saml:Issuer>` +
+		`<saml:NameID>` + nameID + `</saml:NameID><samlp:SessionIndex>` + sessionIndex + `</samlp:SessionIndex></samlp:LogoutRequest>`
+	redirect, err := idp.encodeRedirect(testSLOURL, paramSAMLRequest, []byte(doc), "relay1")
+	if err != nil {
+		panic(err)
+	}
+	u, _ := url.Parse(redirect.URL)
+	return &interfaces.SAMLMessage{Redirect: true, RawQuery: u.RawQuery, SAMLRequest: u.Query().Get(paramSAMLRequest), RelayState: "relay1"}
+}
+
+//nolint:funlen
+func TestHandleLogout(t *testing.T) {
+	Convey("HandleLogout", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBSAML(ctrl)
+		hydraAdmin := mock.NewMockDnHydraAdmin(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		s := newSAML(db, nil, nil, hydraAdmin, nil, trace)
+
+		ctx := context.Background()
+		expectTrace(trace, ctx)
+
+		visitor := &interfaces.Visitor{}
+		idp := newTestIDP()
+		provider := testProvider(idp)
+		sessions := []interfaces.SAMLSession{
+			{UserID: "uid1", ProviderID: testProviderID, NameID: "user1", SessionIndex: "_session1"},
+			{UserID: "uid2", ProviderID: testProviderID, NameID: "user1", SessionIndex: "_session2"},
+		}
+
+		Convey("unknown issuer", func() {
+			db.EXPECT().GetProviderByEntityID(gomock.Any(), testIDPEntityID).Return(nil, nil)
+
+			_, err := s.HandleLogout(ctx, visitor, idp.logoutRequest("user1", "_session1"))
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.SAMLAuthFailed)
+		})
+
+		Convey("signed by other key", func() {
+			db.EXPECT().GetProviderByEntityID(gomock.Any(), testIDPEntityID).Return(provider, nil)
+
+			_, err := s.HandleLogout(ctx, visitor, newTestIDP().logoutRequest("user1", "_session1"))
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.SAMLAuthFailed)
+		})
+
+		Convey("logout request", func() {
+			db.EXPECT().GetProviderByEntityID(gomock.Any(), testIDPEntityID).Return(provider, nil)
+			db.EXPECT().GetSessionsByNameID(gomock.Any(), testProviderID, "user1").Return(sessions, nil)
+			hydraAdmin.EXPECT().DeleteSession("uid1", "").Return(nil)
+			db.EXPECT().DeleteSessions(gomock.Any(), "uid1", testProviderID).Return(nil)
+
+			redirect, err := s.HandleLogout(ctx, visitor, idp.logoutRequest("user1", "_session1"))
+			assert.NilError(t, err)
+			assert.Equal(t, redirect.Binding, interfaces.SAMLBindingRedirect)
+
+			u, err := url.Parse(redirect.URL)
+			assert.NilError(t, err)
+			assert.Equal(t, u.Query().Get(paramRelayState), "relay1")
+			msg := &interfaces.SAMLMessage{Redirect: true, RawQuery: u.RawQuery}
+			root, err := decodeMessage(msg, paramSAMLResponse, func(*xmlNode) ([]*x509.Certificate, error) {
+				return []*x509.Certificate{s.signer.cert}, nil
+			})
+			assert.NilError(t, err)
+			assert.Assert(t, root.is(nsSAMLP, "LogoutResponse"))
+			assert.Equal(t, root.attr("InResponseTo"), "_logout1")
+			assert.NilError(t, checkStatus(root))
+		})
+
+		Convey("delete session failed", func() {
+			db.EXPECT().GetProviderByEntityID(gomock.Any(), testIDPEntityID).Return(provider, nil)
+			db.EXPECT().GetSessionsByNameID(gomock.Any(), testProviderID, "user1").Return(sessions, nil)
+			hydraAdmin.EXPECT().DeleteSession("uid2", "").Return(errors.New("hydra unavailable"))
+
+			redirect, err := s.HandleLogout(ctx, visitor, idp.logoutRequest("user1", "_session2"))
+			assert.NilError(t, err)
+
+			u, err := url.Parse(redirect.URL)
+			assert.NilError(t, err)
+			root, err := decodeMessage(&interfaces.SAMLMessage{Redirect: true, RawQuery: u.RawQuery}, paramSAMLResponse,
+				func(*xmlNode) ([]*x509.Certificate, error) { return []*x509.Certificate{s.signer.cert}, nil })
+			assert.NilError(t, err)
+			assert.ErrorContains(t, checkStatus(root), statusResponder)
+		})
+
+		Convey("logout response", func() {
+			doc := `<samlp:LogoutResponse xmlns:samlp="` + nsSAMLP + `" xmlns:saml="` + nsSAML + `" ID="_resp1" Version="2.0" ` +
+				`IssueInstant="` + issueInstant() + `" Destination="` + testSLOURL + `" InResponseTo="_req1">` +
+				`<saml:Issuer>` + testIDPEntityID + `</saml:Issuer><samlp:Status><samlp:StatusCode Value="` + statusSuccess + `"/>` +
+				`</samlp:Status></samlp:LogoutResponse>`
+			post, err := idp.encodePost(testSLOURL, paramSAMLResponse, []byte(doc), "")
+			assert.NilError(t, err)
+			db.EXPECT().GetProviderByEntityID(gomock.Any(), testIDPEntityID).Return(provider, nil)
+
+			redirect, err := s.HandleLogout(ctx, visitor, &interfaces.SAMLMessage{SAMLResponse: post.Params[paramSAMLResponse]})
+			assert.NilError(t, err)
+			assert.Equal(t, redirect.Binding, interfaces.SAMLBindingRedirect)
+			assert.Equal(t, redirect.URL, testLogoutPage)
+		})
+	})
+}
@@ -0,0 +1,567 @@
+// Package totp 逻辑层
+package totp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // RFC 6238 默认算法，客户端兼容性最好
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kweaver-ai/go-lib/observable"
+	"github.com/kweaver-ai/go-lib/rest"
+
+	"Authentication/common"
+	"Authentication/interfaces"
+	"Authentication/logics"
+	"Authentication/logics/audit"
+)
+
+var (
+	tOnce sync.Once
+	t     *totp
+)
+
+const (
+	// timeStep 时间步长，单位为秒
+	timeStep = 30
+	// codeDigits 动态口令位数
+	codeDigits = 6
+	// allowedSkew 允许的时间步长偏差，用于兼容客户端时钟误差
+	allowedSkew = 1
+	// secretSize 密钥长度，单位为字节
+	secretSize = 20
+	// recoveryCodeCount 恢复码数量
+	recoveryCodeCount = 10
+	// recoveryCodeLength 恢复码长度，不含分隔符
+	recoveryCodeLength = 10
+	// recoveryCodeAlphabet 恢复码字符集，去除了易混淆的字符
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	// maxFailCount 连续校验失败次数上限
+	maxFailCount = 5
+	// failLockTime 校验失败次数达到上限后的锁定时间，单位为秒
+	failLockTime = 5 * 60
+	// defaultIssuer 默认签发者名称
+	defaultIssuer = "AnyShare"
+)
+
+// 审计日志内容唯一标识
+const (
+	_ int = iota
+	i18nEnableTOTP
+	i18nDisableTOTP
+	i18nRegenerateRecoveryCodes
+	i18nResetTOTP
+	i18nRecoveryCodeUsed
+	i18nTOTPExMsg
+)
+
+var svcLanguages = map[string]interfaces.Language{
+	"zh_CN": interfaces.SimplifiedChinese,
+	"zh_TW": interfaces.TraditionalChinese,
+	"en_US": interfaces.AmericanEnglish,
+}
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type totp struct {
+	db       interfaces.DBTOTP
+	userMgnt interfaces.DnUserManagement
+	audit    interfaces.LogicsAudit
+	issuer   string
+	lang     interfaces.Language
+	i18n     *common.I18n
+	logger   common.Logger
+	trace    observable.Tracer
+}
+
+// NewTOTP 创建动态口令处理对象
+func NewTOTP() *totp {
+	tOnce.Do(func() {
+		issuer := common.SvcConfig.TOTPIssuer
+		if issuer == "" {
+			issuer = defaultIssuer
+		}
+
+		t = &totp{
+			db:       logics.DBTOTP,
+			userMgnt: logics.DnUserManagement,
+			audit:    audit.NewAudit(),
+			issuer:   issuer,
+			lang:     svcLanguages[common.SvcConfig.Lang],
+			i18n: common.NewI18n(common.I18nMap{
+				i18nEnableTOTP: {
+					interfaces.SimplifiedChinese:  "启用动态密码 成功",
+					interfaces.TraditionalChinese: "啟用動態密碼 成功",
+					interfaces.AmericanEnglish:    "Enable one time password successfully",
+				},
+				i18nDisableTOTP: {
+					interfaces.SimplifiedChinese:  "解除绑定动态密码 成功",
+					interfaces.TraditionalChinese: "解除綁定動態密碼 成功",
+					interfaces.AmericanEnglish:    "Disable one time password successfully",
+				},
+				i18nRegenerateRecoveryCodes: {
+					interfaces.SimplifiedChinese:  "重新生成动态密码恢复码 成功",
+					interfaces.TraditionalChinese: "重新產生動態密碼復原碼 成功",
+					interfaces.AmericanEnglish:    "Regenerate one time password recovery codes successfully",
+				},
+				i18nResetTOTP: {
+					interfaces.SimplifiedChinese:  "重置用户“%s”的动态密码 成功",
+					interfaces.TraditionalChinese: "重設使用者“%s”的動態密碼 成功",
+					interfaces.AmericanEnglish:    "Reset one time password of user \"%s\" successfully",
+				},
+				i18nRecoveryCodeUsed: {
+					interfaces.SimplifiedChinese:  "使用动态密码恢复码 成功，剩余恢复码%d个",
+					interfaces.TraditionalChinese: "使用動態密碼復原碼 成功，剩餘復原碼%d個",
+					interfaces.AmericanEnglish:    "Use one time password recovery code successfully, %d recovery codes left",
+				},
+				i18nTOTPExMsg: {
+					interfaces.SimplifiedChinese:  "认证方式：动态密码",
+					interfaces.TraditionalChinese: "認證方式：動態密碼",
+					interfaces.AmericanEnglish:    "Authentication: One Time Password",
+				},
+			}),
+			logger: common.NewLogger(),
+			trace:  common.SvcARTrace,
+		}
+	})
+
+	return t
+}
+
+// GetStatus 获取当前用户动态口令状态
+func (t *totp) GetStatus(ctx context.Context, visitor *interfaces.Visitor) (status *interfaces.TOTPStatusInfo, err error) {
+	t.trace.SetInternalSpanName("逻辑层-获取动态口令状态")
+	newCtx, span := t.trace.AddInternalTrace(ctx)
+	defer func() { t.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = checkRealName(visitor); err != nil {
+		return nil, err
+	}
+
+	info, err := t.db.GetByUserID(newCtx, visitor.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	status = &interfaces.TOTPStatusInfo{}
+	if info == nil || info.Status != interfaces.TOTPEnabled {
+		return status, nil
+	}
+
+	status.Enabled = true
+	status.RecoveryCodeCount, err = t.db.CountRecoveryCodes(newCtx, visitor.ID)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// Enroll 生成动态口令密钥，确认前不生效
+func (t *totp) Enroll(ctx context.Context, visitor *interfaces.Visitor) (enrollment *interfaces.TOTPEnrollment, err error) {
+	t.trace.SetInternalSpanName("逻辑层-生成动态口令密钥")
+	newCtx, span := t.trace.AddInternalTrace(ctx)
+	defer func() { t.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = checkRealName(visitor); err != nil {
+		return nil, err
+	}
+
+	info, err := t.db.GetByUserID(newCtx, visitor.ID)
+	if err != nil {
+		return nil, err
+	}
+	if info != nil && info.Status == interfaces.TOTPEnabled {
+		return nil, rest.NewHTTPErrorV2(rest.Conflict, "totp is already enabled")
+	}
+
+	userInfo, err := t.userMgnt.GetUserInfo(newCtx, visitor, visitor.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := common.Now().Unix()
+	err = t.db.SavePending(newCtx, &interfaces.TOTPInfo{
+		UserID:     visitor.ID,
+		Secret:     secret,
+		Status:     interfaces.TOTPPending,
+		CreateTime: now,
+		UpdateTime: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &interfaces.TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: provisioningURI(t.issuer, userInfo.Account, secret),
+	}, nil
+}
+
+// ConfirmEnrollment 校验动态口令并启用，返回恢复码
+func (t *totp) ConfirmEnrollment(ctx context.Context, visitor *interfaces.Visitor, code string) (recoveryCodes []string, err error) {
+	t.trace.SetInternalSpanName("逻辑层-确认绑定动态口令")
+	newCtx, span := t.trace.AddInternalTrace(ctx)
+	defer func() { t.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = checkRealName(visitor); err != nil {
+		return nil, err
+	}
+
+	info, err := t.db.GetByUserID(newCtx, visitor.ID)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil || info.Status != interfaces.TOTPPending {
+		return nil, rest.NewHTTPErrorV2(rest.Conflict, "totp enrollment is not pending")
+	}
+
+	step, ok := matchTimeStep(info.Secret, code, common.Now())
+	if !ok {
+		return nil, rest.NewHTTPError("", common.OTPWrong, nil)
+	}
+
+	recoveryCodes, codeHashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = t.db.Enable(newCtx, visitor.ID, step, codeHashes); err != nil {
+		return nil, err
+	}
+
+	t.writeLog(visitor, visitor.ID, audit.LevelInfo, audit.OpCreate, visitor.ID, i18nEnableTOTP)
+	return recoveryCodes, nil
+}
+
+// RegenerateRecoveryCodes 校验动态口令并重新生成恢复码
+func (t *totp) RegenerateRecoveryCodes(ctx context.Context, visitor *interfaces.Visitor, code string) (recoveryCodes []string, err error) {
+	t.trace.SetInternalSpanName("逻辑层-重新生成动态口令恢复码")
+	newCtx, span := t.trace.AddInternalTrace(ctx)
+	defer func() { t.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = checkRealName(visitor); err != nil {
+		return nil, err
+	}
+
+	info, err := t.getEnabled(newCtx, visitor.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = t.verify(newCtx, visitor, info, code); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, codeHashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = t.db.ReplaceRecoveryCodes(newCtx, visitor.ID, codeHashes); err != nil {
+		return nil, err
+	}
+
+	t.writeLog(visitor, visitor.ID, audit.LevelInfo, audit.OpSet, visitor.ID, i18nRegenerateRecoveryCodes)
+	return recoveryCodes, nil
+}
+
+// Disable 校验动态口令并解除绑定
+func (t *totp) Disable(ctx context.Context, visitor *interfaces.Visitor, code string) (err error) {
+	t.trace.SetInternalSpanName("逻辑层-解除绑定动态口令")
+	newCtx, span := t.trace.AddInternalTrace(ctx)
+	defer func() { t.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = checkRealName(visitor); err != nil {
+		return err
+	}
+
+	info, err := t.getEnabled(newCtx, visitor.ID)
+	if err != nil {
+		return err
+	}
+
+	if err = t.verify(newCtx, visitor, info, code); err != nil {
+		return err
+	}
+
+	if err = t.db.DeleteByUserID(newCtx, visitor.ID); err != nil {
+		return err
+	}
+
+	t.writeLog(visitor, visitor.ID, audit.LevelWarn, audit.OpDelete, visitor.ID, i18nDisableTOTP)
+	return nil
+}
+
+// Reset 管理员重置用户动态口令
+func (t *totp) Reset(ctx context.Context, visitor *interfaces.Visitor, userID string) (err error) {
+	t.trace.SetInternalSpanName("逻辑层-重置用户动态口令")
+	newCtx, span := t.trace.AddInternalTrace(ctx)
+	defer func() { t.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = t.checkAdmin(newCtx, visitor); err != nil {
+		return err
+	}
+
+	// 检查用户是否存在
+	userInfo, err := t.userMgnt.GetUserInfo(newCtx, visitor, userID)
+	if err != nil {
+		return err
+	}
+
+	if err = t.db.DeleteByUserID(newCtx, userID); err != nil {
+		return err
+	}
+
+	t.writeLog(visitor, visitor.ID, audit.LevelWarn, audit.OpDelete, userID, i18nResetTOTP, userInfo.Account)
+	return nil
+}
+
+// Validate 登录时校验动态口令或恢复码
+func (t *totp) Validate(ctx context.Context, visitor *interfaces.Visitor, userID, code string) (err error) {
+	t.trace.SetInternalSpanName("逻辑层-校验动态口令")
+	newCtx, span := t.trace.AddInternalTrace(ctx)
+	defer func() { t.trace.TelemetrySpanEnd(span, err) }()
+
+	info, err := t.db.GetByUserID(newCtx, userID)
+	if err != nil {
+		return err
+	}
+	if info == nil || info.Status != interfaces.TOTPEnabled {
+		return rest.NewHTTPError("", common.OTPNotEnrolled, nil)
+	}
+
+	return t.verify(newCtx, visitor, info, code)
+}
+
+// getEnabled 获取已启用的动态口令信息
+func (t *totp) getEnabled(ctx context.Context, userID string) (info *interfaces.TOTPInfo, err error) {
+	info, err = t.db.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil || info.Status != interfaces.TOTPEnabled {
+		return nil, rest.NewHTTPErrorV2(rest.Conflict, "totp is not enabled")
+	}
+	return info, nil
+}
+
+// verify 校验动态口令或恢复码，连续失败次数达到上限时锁定一段时间
+// 校验前先计入一次失败并检查锁定，校验通过后清空失败次数，避免并发校验绕过失败次数上限
+func (t *totp) verify(ctx context.Context, visitor *interfaces.Visitor, info *interfaces.TOTPInfo, code string) (err error) {
+	now := common.Now()
+	ok, err := t.db.AddFailCount(ctx, info.UserID, now.Unix(), maxFailCount, failLockTime)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return rest.NewHTTPError("", common.OTPTooManyWrongTime, nil)
+	}
+
+	passed := false
+	if isTOTPCode(code) {
+		step, ok := matchTimeStep(info.Secret, code, now)
+		if ok {
+			// 同一时间步长内已使用过的动态口令视为校验失败
+			passed, err = t.db.UpdateLastTimeStep(ctx, info.UserID, step)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		passed, err = t.useRecoveryCode(ctx, visitor, info, code)
+		if err != nil {
+			return err
+		}
+	}
+
+	if passed {
+		return nil
+	}
+
+	return rest.NewHTTPError("", common.OTPWrong, nil)
+}
+
+// useRecoveryCode 使用恢复码，成功后清空失败次数并记录日志
+func (t *totp) useRecoveryCode(ctx context.Context, visitor *interfaces.Visitor, info *interfaces.TOTPInfo, code string) (ok bool, err error) {
+	ok, err = t.db.UseRecoveryCode(ctx, info.UserID, hashRecoveryCode(code))
+	if err != nil || !ok {
+		return
+	}
+
+	if err = t.db.UpdateFailInfo(ctx, info.UserID, 0, 0); err != nil {
+		return false, err
+	}
+
+	count, err := t.db.CountRecoveryCodes(ctx, info.UserID)
+	if err != nil {
+		t.logger.Errorf("CountRecoveryCodes failed, user: %s, err: %v", info.UserID, err)
+		err = nil
+	}
+
+	t.writeLog(visitor, info.UserID, audit.LevelWarn, audit.OpSet, info.UserID, i18nRecoveryCodeUsed, count)
+	return true, nil
+}
+
+func (t *totp) checkAdmin(ctx context.Context, visitor *interfaces.Visitor) (err error) {
+	var roleTypes []interfaces.RoleType
+	// 实名用户获取对应角色信息
+	if visitor.Type == interfaces.RealName {
+		roleTypes, err = t.userMgnt.GetUserRolesByUserID(ctx, visitor, visitor.ID)
+		if err != nil {
+			return
+		}
+	}
+
+	return logics.CheckVisitorType(visitor, roleTypes, []interfaces.VisitorType{interfaces.RealName},
+		[]interfaces.RoleType{interfaces.SuperAdmin, interfaces.SystemAdmin, interfaces.SecurityAdmin})
+}
+
+// writeLog 通过审计日志模块记录管理日志
+func (t *totp) writeLog(visitor *interfaces.Visitor, userID string, level audit.LogLevel, opType audit.ManageOpType, objID string, msgID int, args ...any) {
+	audit.LogManagement(t.audit, t.logger, visitor, &audit.ManagementLog{
+		UserID: userID,
+		Level:  level,
+		OpType: opType,
+		ObjID:  objID,
+		Msg:    t.i18n.Load(msgID, t.lang, args...),
+		ExMsg:  t.i18n.Load(i18nTOTPExMsg, t.lang),
+	})
+}
+
+// checkRealName 仅允许实名用户操作自己的动态口令
+func checkRealName(visitor *interfaces.Visitor) error {
+	if visitor.Type != interfaces.RealName || visitor.ID == "" {
+		return rest.NewHTTPError("Unsupported user type", rest.Unauthorized, nil)
+	}
+	return nil
+}
+
+// generateSecret 生成BASE32编码的随机密钥
+func generateSecret() (string, error) {
+	buf := make([]byte, secretSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base32NoPadding.EncodeToString(buf), nil
+}
+
+// provisioningURI 生成otpauth协议地址，参考 Key Uri Format
+func provisioningURI(issuer, account, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(codeDigits))
+	params.Set("period", fmt.Sprint(timeStep))
+
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// generateCode 按照 RFC 4226 计算指定计数器的动态口令
+func generateCode(secret string, counter int64) (string, error) {
+	key, err := base32NoPadding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(counter))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < codeDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", codeDigits, value%mod), nil
+}
+
+// matchTimeStep 在允许的偏差范围内查找动态口令对应的时间步长
+func matchTimeStep(secret, code string, now time.Time) (step int64, ok bool) {
+	if !isTOTPCode(code) {
+		return 0, false
+	}
+
+	current := now.Unix() / timeStep
+	for i := -allowedSkew; i <= allowedSkew; i++ {
+		expected, err := generateCode(secret, current+int64(i))
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return current + int64(i), true
+		}
+	}
+	return 0, false
+}
+
+// isTOTPCode 判断是否为动态口令格式
+func isTOTPCode(code string) bool {
+	if len(code) != codeDigits {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// generateRecoveryCodes 生成恢复码及其摘要
+func generateRecoveryCodes() (codes, hashes []string, err error) {
+	codes = make([]string, 0, recoveryCodeCount)
+	hashes = make([]string, 0, recoveryCodeCount)
+	exists := make(map[string]bool, recoveryCodeCount)
+	alphabetSize := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	for len(codes) < recoveryCodeCount {
+		var sb strings.Builder
+		for i := 0; i < recoveryCodeLength; i++ {
+			if i == recoveryCodeLength/2 {
+				sb.WriteByte('-')
+			}
+			n, err := rand.Int(rand.Reader, alphabetSize)
+			if err != nil {
+				return nil, nil, err
+			}
+			sb.WriteByte(recoveryCodeAlphabet[n.Int64()])
+		}
+
+		code := sb.String()
+		if exists[code] {
+			continue
+		}
+		exists[code] = true
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode 计算恢复码摘要，忽略大小写和分隔符
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,353 @@
+package totp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kweaver-ai/go-lib/rest"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/assert"
+
+	"Authentication/common"
+	"Authentication/interfaces"
+	"Authentication/interfaces/mock"
+	laudit "Authentication/logics/audit"
+)
+
+// rfcSecret RFC 6238 附录B测试密钥 "12345678901234567890" 的BASE32编码
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+const userID = "dfc9b098-dac4-11ee-b50a-028586548cf7"
+
+func newTOTP(db interfaces.DBTOTP, userMgnt interfaces.DnUserManagement, audit interfaces.LogicsAudit,
+	trace interfaces.TraceClient) *totp {
+	return &totp{
+		db:       db,
+		userMgnt: userMgnt,
+		audit:    audit,
+		issuer:   defaultIssuer,
+		lang:     interfaces.SimplifiedChinese,
+		i18n:     common.NewI18n(common.I18nMap{}),
+		logger:   common.NewLogger(),
+		trace:    trace,
+	}
+}
+
+func TestGenerateCode(t *testing.T) {
+	Convey("generateCode, RFC 6238 test vectors", t, func() {
+		cases := map[int64]string{
+			59:         "287082",
+			1111111109: "081804",
+			1111111111: "050471",
+			1234567890: "005924",
+			2000000000: "279037",
+		}
+		for ts, expected := range cases {
+			code, err := generateCode(rfcSecret, ts/timeStep)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, code, expected)
+		}
+	})
+
+	Convey("generateCode, invalid secret", t, func() {
+		_, err := generateCode("!!!", 1)
+		assert.Assert(t, err != nil)
+	})
+}
+
+func TestMatchTimeStep(t *testing.T) {
+	Convey("matchTimeStep", t, func() {
+		now := time.Unix(1111111109, 0)
+		current := now.Unix() / timeStep
+
+		Convey("current step", func() {
+			step, ok := matchTimeStep(rfcSecret, "081804", now)
+			assert.Equal(t, ok, true)
+			assert.Equal(t, step, current)
+		})
+
+		Convey("previous step within skew", func() {
+			step, ok := matchTimeStep(rfcSecret, "081804", now.Add(timeStep*time.Second))
+			assert.Equal(t, ok, true)
+			assert.Equal(t, step, current)
+		})
+
+		Convey("out of skew", func() {
+			_, ok := matchTimeStep(rfcSecret, "081804", now.Add(2*timeStep*time.Second))
+			assert.Equal(t, ok, false)
+		})
+
+		Convey("invalid format", func() {
+			_, ok := matchTimeStep(rfcSecret, "08180a", now)
+			assert.Equal(t, ok, false)
+		})
+	})
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	Convey("generateRecoveryCodes", t, func() {
+		codes, hashes, err := generateRecoveryCodes()
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(codes), recoveryCodeCount)
+		assert.Equal(t, len(hashes), recoveryCodeCount)
+		for i, code := range codes {
+			assert.Equal(t, len(code), recoveryCodeLength+1)
+			assert.Equal(t, code[recoveryCodeLength/2], byte('-'))
+			assert.Equal(t, hashes[i], hashRecoveryCode(code))
+		}
+	})
+
+	Convey("hashRecoveryCode ignores case and separator", t, func() {
+		assert.Equal(t, hashRecoveryCode("abcde-fghjk"), hashRecoveryCode("ABCDE FGHJK"))
+		assert.Equal(t, hashRecoveryCode("abcde-fghjk"), hashRecoveryCode("abcdefghjk"))
+	})
+}
+
+func TestProvisioningURI(t *testing.T) {
+	Convey("provisioningURI", t, func() {
+		uri := provisioningURI("AnyShare", "user 1", rfcSecret)
+		assert.Assert(t, strings.HasPrefix(uri, "otpauth://totp/AnyShare:user%201?"))
+		assert.Assert(t, strings.Contains(uri, "secret="+rfcSecret))
+		assert.Assert(t, strings.Contains(uri, "issuer=AnyShare"))
+		assert.Assert(t, strings.Contains(uri, "digits=6"))
+		assert.Assert(t, strings.Contains(uri, "period=30"))
+	})
+}
+
+func TestEnroll(t *testing.T) {
+	Convey("Enroll", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBTOTP(ctrl)
+		userMgnt := mock.NewMockDnUserManagement(ctrl)
+		audit := mock.NewMockLogicsAudit(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		tp := newTOTP(db, userMgnt, audit, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetInternalSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddInternalTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		visitor := &interfaces.Visitor{ID: userID, Type: interfaces.RealName}
+
+		Convey("unsupported visitor type", func() {
+			_, err := tp.Enroll(ctx, &interfaces.Visitor{ID: "app", Type: interfaces.Business})
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.Unauthorized)
+		})
+
+		Convey("already enabled", func() {
+			db.EXPECT().GetByUserID(gomock.Any(), userID).Return(&interfaces.TOTPInfo{Status: interfaces.TOTPEnabled}, nil)
+
+			_, err := tp.Enroll(ctx, visitor)
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.Conflict)
+		})
+
+		Convey("success", func() {
+			db.EXPECT().GetByUserID(gomock.Any(), userID).Return(nil, nil)
+			userMgnt.EXPECT().GetUserInfo(gomock.Any(), gomock.Any(), userID).Return(&interfaces.UserBaseInfo{Account: "user1"}, nil)
+			var saved *interfaces.TOTPInfo
+			db.EXPECT().SavePending(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, info *interfaces.TOTPInfo) error {
+				saved = info
+				return nil
+			})
+
+			enrollment, err := tp.Enroll(ctx, visitor)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, saved.Status, interfaces.TOTPPending)
+			assert.Equal(t, saved.Secret, enrollment.Secret)
+			assert.Assert(t, strings.Contains(enrollment.ProvisioningURI, "secret="+enrollment.Secret))
+		})
+	})
+}
+
+func TestConfirmEnrollment(t *testing.T) {
+	Convey("ConfirmEnrollment", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBTOTP(ctrl)
+		audit := mock.NewMockLogicsAudit(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		tp := newTOTP(db, nil, audit, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetInternalSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddInternalTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		visitor := &interfaces.Visitor{ID: userID, Type: interfaces.RealName}
+		step := common.Now().Unix() / timeStep
+		code, _ := generateCode(rfcSecret, step)
+		pending := &interfaces.TOTPInfo{UserID: userID, Secret: rfcSecret, Status: interfaces.TOTPPending}
+
+		Convey("not pending", func() {
+			db.EXPECT().GetByUserID(gomock.Any(), userID).Return(nil, nil)
+
+			_, err := tp.ConfirmEnrollment(ctx, visitor, code)
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.Conflict)
+		})
+
+		Convey("wrong code", func() {
+			db.EXPECT().GetByUserID(gomock.Any(), userID).Return(pending, nil)
+
+			_, err := tp.ConfirmEnrollment(ctx, visitor, "abcdef")
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.OTPWrong)
+		})
+
+		Convey("success", func() {
+			db.EXPECT().GetByUserID(gomock.Any(), userID).Return(pending, nil)
+			db.EXPECT().Enable(gomock.Any(), userID, gomock.Any(), gomock.Len(recoveryCodeCount)).Return(nil)
+			audit.EXPECT().Log(laudit.TopicManagementLog, gomock.Any()).Return(nil)
+
+			recoveryCodes, err := tp.ConfirmEnrollment(ctx, visitor, code)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(recoveryCodes), recoveryCodeCount)
+		})
+	})
+}
+
+func TestValidate(t *testing.T) {
+	Convey("Validate", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBTOTP(ctrl)
+		audit := mock.NewMockLogicsAudit(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		tp := newTOTP(db, nil, audit, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetInternalSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddInternalTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		visitor := &interfaces.Visitor{}
+		now := common.Now()
+		step := now.Unix() / timeStep
+		code, _ := generateCode(rfcSecret, step)
+		info := &interfaces.TOTPInfo{UserID: userID, Secret: rfcSecret, Status: interfaces.TOTPEnabled, LastTimeStep: step - 2}
+
+		Convey("db error", func() {
+			testErr := errors.New("db error")
+			db.EXPECT().GetByUserID(gomock.Any(), userID).Return(nil, testErr)
+
+			err := tp.Validate(ctx, visitor, userID, code)
+			assert.Equal(t, err, testErr)
+		})
+
+		Convey("not enrolled", func() {
+			db.EXPECT().GetByUserID(gomock.Any(), userID).Return(&interfaces.TOTPInfo{Status: interfaces.TOTPPending}, nil)
+
+			err := tp.Validate(ctx, visitor, userID, code)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.OTPNotEnrolled)
+		})
+
+		Convey("too many failures", func() {
+			db.EXPECT().GetByUserID(gomock.Any(), userID).Return(info, nil)
+			db.EXPECT().AddFailCount(gomock.Any(), userID, gomock.Any(), maxFailCount, int64(failLockTime)).Return(false, nil)
+
+			err := tp.Validate(ctx, visitor, userID, code)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.OTPTooManyWrongTime)
+		})
+
+		Convey("add fail count error", func() {
+			testErr := errors.New("db error")
+			db.EXPECT().GetByUserID(gomock.Any(), userID).Return(info, nil)
+			db.EXPECT().AddFailCount(gomock.Any(), userID, gomock.Any(), maxFailCount, int64(failLockTime)).Return(false, testErr)
+
+			err := tp.Validate(ctx, visitor, userID, code)
+			assert.Equal(t, err, testErr)
+		})
+
+		Convey("success", func() {
+			db.EXPECT().GetByUserID(gomock.Any(), userID).Return(info, nil)
+			db.EXPECT().AddFailCount(gomock.Any(), userID, gomock.Any(), maxFailCount, int64(failLockTime)).Return(true, nil)
+			db.EXPECT().UpdateLastTimeStep(gomock.Any(), userID, step).Return(true, nil)
+
+			err := tp.Validate(ctx, visitor, userID, code)
+			assert.Equal(t, err, nil)
+		})
+
+		Convey("replayed code", func() {
+			db.EXPECT().GetByUserID(gomock.Any(), userID).Return(info, nil)
+			db.EXPECT().AddFailCount(gomock.Any(), userID, gomock.Any(), maxFailCount, int64(failLockTime)).Return(true, nil)
+			db.EXPECT().UpdateLastTimeStep(gomock.Any(), userID, step).Return(false, nil)
+
+			err := tp.Validate(ctx, visitor, userID, code)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.OTPWrong)
+		})
+
+		Convey("wrong code", func() {
+			wrongCode, _ := generateCode(rfcSecret, step+5)
+			db.EXPECT().GetByUserID(gomock.Any(), userID).Return(info, nil)
+			db.EXPECT().AddFailCount(gomock.Any(), userID, gomock.Any(), maxFailCount, int64(failLockTime)).Return(true, nil)
+
+			err := tp.Validate(ctx, visitor, userID, wrongCode)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.OTPWrong)
+		})
+
+		Convey("recovery code", func() {
+			db.EXPECT().GetByUserID(gomock.Any(), userID).Return(info, nil)
+			db.EXPECT().AddFailCount(gomock.Any(), userID, gomock.Any(), maxFailCount, int64(failLockTime)).Return(true, nil)
+			db.EXPECT().UseRecoveryCode(gomock.Any(), userID, hashRecoveryCode("abcde-fghjk")).Return(true, nil)
+			db.EXPECT().UpdateFailInfo(gomock.Any(), userID, 0, int64(0)).Return(nil)
+			db.EXPECT().CountRecoveryCodes(gomock.Any(), userID).Return(9, nil)
+			audit.EXPECT().Log(laudit.TopicManagementLog, gomock.Any()).Return(nil)
+
+			err := tp.Validate(ctx, visitor, userID, "abcde-fghjk")
+			assert.Equal(t, err, nil)
+		})
+
+		Convey("used recovery code", func() {
+			db.EXPECT().GetByUserID(gomock.Any(), userID).Return(info, nil)
+			db.EXPECT().AddFailCount(gomock.Any(), userID, gomock.Any(), maxFailCount, int64(failLockTime)).Return(true, nil)
+			db.EXPECT().UseRecoveryCode(gomock.Any(), userID, gomock.Any()).Return(false, nil)
+
+			err := tp.Validate(ctx, visitor, userID, "abcde-fghjk")
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.OTPWrong)
+		})
+	})
+}
+
+func TestReset(t *testing.T) {
+	Convey("Reset", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBTOTP(ctrl)
+		userMgnt := mock.NewMockDnUserManagement(ctrl)
+		audit := mock.NewMockLogicsAudit(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		tp := newTOTP(db, userMgnt, audit, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetInternalSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddInternalTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		visitor := &interfaces.Visitor{ID: "admin", Type: interfaces.RealName}
+
+		Convey("not admin", func() {
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any(), "admin").Return([]interfaces.RoleType{interfaces.NormalUser}, nil)
+
+			err := tp.Reset(ctx, visitor, userID)
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.Unauthorized)
+		})
+
+		Convey("success", func() {
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any(), "admin").Return([]interfaces.RoleType{interfaces.SecurityAdmin}, nil)
+			userMgnt.EXPECT().GetUserInfo(gomock.Any(), gomock.Any(), userID).Return(&interfaces.UserBaseInfo{Account: "user1"}, nil)
+			db.EXPECT().DeleteByUserID(gomock.Any(), userID).Return(nil)
+			audit.EXPECT().Log(laudit.TopicManagementLog, gomock.Any()).Return(nil)
+
+			err := tp.Reset(ctx, visitor, userID)
+			assert.Equal(t, err, nil)
+		})
+	})
+}
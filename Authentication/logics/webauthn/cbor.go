@@ -0,0 +1,142 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// CBOR 主类型，参考 RFC 8949
+const (
+	cborUint       = 0
+	cborNegInt     = 1
+	cborBytes      = 2
+	cborText       = 3
+	cborArray      = 4
+	cborMap        = 5
+	cborTag        = 6
+	cborSimpleType = 7
+)
+
+// cborMaxDepth 最大嵌套深度，防止恶意数据导致栈溢出
+const cborMaxDepth = 16
+
+var errCBORMalformed = errors.New("malformed cbor data")
+
+// decodeCBOR 解析一个CBOR数据项，返回解析结果及剩余数据
+// 整数解析为int64，字节串解析为[]byte，文本解析为string，数组解析为[]interface{}，
+// 映射解析为map[interface{}]interface{}，标签仅保留内容。
+// WebAuthn 要求认证器使用 CTAP2 规范编码，因此不支持不定长编码
+func decodeCBOR(data []byte) (v interface{}, rest []byte, err error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (v interface{}, rest []byte, err error) {
+	if depth > cborMaxDepth {
+		return nil, nil, errCBORMalformed
+	}
+
+	major, arg, rest, err := decodeCBORHead(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case cborUint:
+		if arg > math.MaxInt64 {
+			return nil, nil, errCBORMalformed
+		}
+		return int64(arg), rest, nil
+	case cborNegInt:
+		if arg > math.MaxInt64 {
+			return nil, nil, errCBORMalformed
+		}
+		return -1 - int64(arg), rest, nil
+	case cborBytes, cborText:
+		if arg > uint64(len(rest)) {
+			return nil, nil, errCBORMalformed
+		}
+		if major == cborBytes {
+			return rest[:arg], rest[arg:], nil
+		}
+		return string(rest[:arg]), rest[arg:], nil
+	case cborArray:
+		// 每个元素至少占用一个字节
+		if arg > uint64(len(rest)) {
+			return nil, nil, errCBORMalformed
+		}
+		items := make([]interface{}, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			var item interface{}
+			if item, rest, err = decodeCBORItem(rest, depth+1); err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, rest, nil
+	case cborMap:
+		if arg > uint64(len(rest)) {
+			return nil, nil, errCBORMalformed
+		}
+		items := make(map[interface{}]interface{}, arg)
+		for i := uint64(0); i < arg; i++ {
+			var key, value interface{}
+			if key, rest, err = decodeCBORItem(rest, depth+1); err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, errCBORMalformed
+			}
+			if value, rest, err = decodeCBORItem(rest, depth+1); err != nil {
+				return nil, nil, err
+			}
+			items[key] = value
+		}
+		return items, rest, nil
+	case cborTag:
+		return decodeCBORItem(rest, depth+1)
+	default:
+		return decodeCBORSimple(data[0]&0x1f, arg, rest)
+	}
+}
+
+// decodeCBORHead 解析数据项头部，返回主类型及参数
+func decodeCBORHead(data []byte) (major byte, arg uint64, rest []byte, err error) {
+	if len(data) == 0 {
+		return 0, 0, nil, errCBORMalformed
+	}
+
+	major = data[0] >> 5
+	info := data[0] & 0x1f
+	data = data[1:]
+	switch {
+	case info < 24:
+		return major, uint64(info), data, nil
+	case info == 24 && len(data) >= 1:
+		return major, uint64(data[0]), data[1:], nil
+	case info == 25 && len(data) >= 2:
+		return major, uint64(binary.BigEndian.Uint16(data)), data[2:], nil
+	case info == 26 && len(data) >= 4:
+		return major, uint64(binary.BigEndian.Uint32(data)), data[4:], nil
+	case info == 27 && len(data) >= 8:
+		return major, binary.BigEndian.Uint64(data), data[8:], nil
+	}
+	return 0, 0, nil, errCBORMalformed
+}
+
+// decodeCBORSimple 解析简单值及浮点数，浮点数仅跳过不解析
+func decodeCBORSimple(info byte, arg uint64, rest []byte) (v interface{}, _ []byte, err error) {
+	switch info {
+	case 20:
+		return false, rest, nil
+	case 21:
+		return true, rest, nil
+	case 22, 23:
+		return nil, rest, nil
+	case 25, 26, 27:
+		return arg, rest, nil
+	}
+	return nil, nil, errCBORMalformed
+}
@@ -0,0 +1,117 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// COSE 签名算法，参考 IANA COSE Algorithms
+const (
+	algES256 = -7
+	algEdDSA = -8
+	algRS256 = -257
+)
+
+// COSE 密钥参数
+const (
+	coseKeyKty = 1
+	coseKeyAlg = 3
+
+	coseKtyOKP = 1
+	coseKtyEC2 = 2
+	coseKtyRSA = 3
+
+	coseCrvP256    = 1
+	coseCrvEd25519 = 6
+)
+
+// supportedAlgorithms 支持的签名算法，按优先级排序
+var supportedAlgorithms = []int{algES256, algEdDSA, algRS256}
+
+var errUnsupportedKey = errors.New("unsupported cose key")
+
+// parseCOSEKey 解析COSE格式公钥，返回签名算法及公钥
+func parseCOSEKey(data []byte) (alg int, pub crypto.PublicKey, err error) {
+	v, rest, err := decodeCBOR(data)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(rest) != 0 {
+		return 0, nil, errCBORMalformed
+	}
+	key, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return 0, nil, errCBORMalformed
+	}
+
+	kty, _ := key[int64(coseKeyKty)].(int64)
+	alg64, _ := key[int64(coseKeyAlg)].(int64)
+	crv, _ := key[int64(-1)].(int64)
+	alg = int(alg64)
+
+	switch {
+	case kty == coseKtyEC2 && alg == algES256 && crv == coseCrvP256:
+		x, okX := key[int64(-2)].([]byte)
+		y, okY := key[int64(-3)].([]byte)
+		if !okX || !okY || len(x) != 32 || len(y) != 32 {
+			return 0, nil, errUnsupportedKey
+		}
+		pubKey := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pubKey.Curve.IsOnCurve(pubKey.X, pubKey.Y) {
+			return 0, nil, errUnsupportedKey
+		}
+		return alg, pubKey, nil
+	case kty == coseKtyOKP && alg == algEdDSA && crv == coseCrvEd25519:
+		x, okX := key[int64(-2)].([]byte)
+		if !okX || len(x) != ed25519.PublicKeySize {
+			return 0, nil, errUnsupportedKey
+		}
+		return alg, ed25519.PublicKey(x), nil
+	case kty == coseKtyRSA && alg == algRS256:
+		n, okN := key[int64(-1)].([]byte)
+		e, okE := key[int64(-2)].([]byte)
+		if !okN || !okE || len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return 0, nil, errUnsupportedKey
+		}
+		exp := 0
+		for _, b := range e {
+			exp = exp<<8 | int(b)
+		}
+		return alg, &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exp}, nil
+	}
+
+	return 0, nil, errUnsupportedKey
+}
+
+// verifySignature 使用指定算法及公钥校验签名
+func verifySignature(alg int, pub crypto.PublicKey, data, sig []byte) bool {
+	switch alg {
+	case algES256:
+		pubKey, ok := pub.(*ecdsa.PublicKey)
+		if !ok {
+			return false
+		}
+		digest := sha256.Sum256(data)
+		return ecdsa.VerifyASN1(pubKey, digest[:], sig)
+	case algEdDSA:
+		pubKey, ok := pub.(ed25519.PublicKey)
+		if !ok {
+			return false
+		}
+		return ed25519.Verify(pubKey, data, sig)
+	case algRS256:
+		pubKey, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+		digest := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(pubKey, crypto.SHA256, digest[:], sig) == nil
+	}
+	return false
+}
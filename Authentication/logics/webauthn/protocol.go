@@ -0,0 +1,268 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// 认证器数据标志位
+const (
+	flagUserPresent  byte = 0x01
+	flagUserVerified byte = 0x04
+	flagAttested     byte = 0x40
+	flagExtension    byte = 0x80
+)
+
+// clientData 类型
+const (
+	clientDataCreate = "webauthn.create"
+	clientDataGet    = "webauthn.get"
+)
+
+// 证明策略
+const (
+	attestationNone   = "none"
+	attestationDirect = "direct"
+)
+
+// maxCredentialIDLength 凭据ID最大长度，base64url编码后不超过数据库字段长度
+const maxCredentialIDLength = 384
+
+// 校验失败原因，仅用于记录日志
+var (
+	errChallengeInvalid   = errors.New("challenge not found, expired or mismatched")
+	errCredentialNotFound = errors.New("credential not found")
+	errCredentialMismatch = errors.New("credential mismatch")
+	errRPIDMismatch       = errors.New("rp id hash mismatch")
+	errUserNotPresent     = errors.New("user not present")
+	errUserNotVerified    = errors.New("user not verified")
+	errAAGUIDNotAllowed   = errors.New("authenticator aaguid not allowed")
+	errInvalidSignature   = errors.New("invalid assertion signature")
+	errSignCountInvalid   = errors.New("sign count not increased")
+)
+
+// oidFIDOGenCeAAGUID 证明证书中认证器型号扩展
+var oidFIDOGenCeAAGUID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}
+
+// clientData 客户端数据
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// authenticatorData 认证器数据
+type authenticatorData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	AAGUID       []byte
+	CredentialID []byte
+	PublicKey    []byte
+}
+
+// attestationObject 注册证明
+type attestationObject struct {
+	Fmt      string
+	AttStmt  map[interface{}]interface{}
+	AuthData []byte
+}
+
+// decodeBase64URL 解析base64url编码数据，兼容带填充的编码
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// parseClientData 解析并校验客户端数据
+func parseClientData(data []byte, expectedType, challenge string, origins []string) error {
+	var cd clientData
+	if err := json.Unmarshal(data, &cd); err != nil {
+		return err
+	}
+
+	if cd.Type != expectedType {
+		return errors.New("unexpected client data type")
+	}
+
+	// 客户端可能使用带填充的编码
+	if subtle.ConstantTimeCompare([]byte(strings.TrimRight(cd.Challenge, "=")), []byte(challenge)) != 1 {
+		return errors.New("challenge mismatch")
+	}
+
+	for _, origin := range origins {
+		if cd.Origin == origin {
+			return nil
+		}
+	}
+	return errors.New("origin not allowed")
+}
+
+// parseAuthenticatorData 解析认证器数据
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, errors.New("authenticator data too short")
+	}
+
+	ad := &authenticatorData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	rest := data[37:]
+
+	if ad.Flags&flagAttested != 0 {
+		if len(rest) < 18 {
+			return nil, errors.New("attested credential data too short")
+		}
+		ad.AAGUID = rest[:16]
+		idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLen == 0 || idLen > maxCredentialIDLength || len(rest) < idLen {
+			return nil, errors.New("invalid credential id")
+		}
+		ad.CredentialID = rest[:idLen]
+		rest = rest[idLen:]
+
+		// 公钥为CBOR编码，解析后才能确定长度
+		_, keyRest, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, err
+		}
+		ad.PublicKey = rest[:len(rest)-len(keyRest)]
+		rest = keyRest
+	}
+
+	if ad.Flags&flagExtension != 0 {
+		_, extRest, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, err
+		}
+		rest = extRest
+	}
+
+	if len(rest) != 0 {
+		return nil, errors.New("unexpected trailing authenticator data")
+	}
+	return ad, nil
+}
+
+// parseAttestationObject 解析注册证明
+func parseAttestationObject(data []byte) (*attestationObject, error) {
+	v, rest, err := decodeCBOR(data)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := v.(map[interface{}]interface{})
+	if !ok || len(rest) != 0 {
+		return nil, errCBORMalformed
+	}
+
+	att := &attestationObject{}
+	att.Fmt, _ = obj["fmt"].(string)
+	att.AttStmt, _ = obj["attStmt"].(map[interface{}]interface{})
+	att.AuthData, _ = obj["authData"].([]byte)
+	if att.Fmt == "" || att.AttStmt == nil || att.AuthData == nil {
+		return nil, errCBORMalformed
+	}
+	return att, nil
+}
+
+// verifyAttestation 按照证明策略校验注册证明
+// none 策略不校验证明声明；direct 策略仅接受 packed 格式，并校验证明签名，配置了可信根证书时同时校验证书链
+func verifyAttestation(policy string, att *attestationObject, aaguid, clientDataHash []byte,
+	credAlg int, credPub crypto.PublicKey, roots *x509.CertPool) error {
+	if policy != attestationDirect {
+		return nil
+	}
+	if att.Fmt != "packed" {
+		return errors.New("attestation format not allowed")
+	}
+
+	alg, _ := att.AttStmt["alg"].(int64)
+	sig, _ := att.AttStmt["sig"].([]byte)
+	if len(sig) == 0 {
+		return errors.New("attestation signature missing")
+	}
+	signed := append(append([]byte{}, att.AuthData...), clientDataHash...)
+
+	x5c, hasX5C := att.AttStmt["x5c"].([]interface{})
+	if !hasX5C {
+		// 自证明无法确认认证器来源，配置了可信根证书时不接受
+		if roots != nil {
+			return errors.New("self attestation not trusted")
+		}
+		if int(alg) != credAlg || !verifySignature(credAlg, credPub, signed, sig) {
+			return errors.New("invalid self attestation signature")
+		}
+		return nil
+	}
+
+	certs := make([]*x509.Certificate, 0, len(x5c))
+	for _, item := range x5c {
+		der, ok := item.([]byte)
+		if !ok {
+			return errCBORMalformed
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return errors.New("attestation certificate missing")
+	}
+	leaf := certs[0]
+	if leaf.IsCA {
+		return errors.New("attestation certificate must not be a ca")
+	}
+
+	sigAlg, ok := map[int64]x509.SignatureAlgorithm{
+		algES256: x509.ECDSAWithSHA256,
+		algRS256: x509.SHA256WithRSA,
+		algEdDSA: x509.PureEd25519,
+	}[alg]
+	if !ok {
+		return errors.New("unsupported attestation algorithm")
+	}
+	if err := leaf.CheckSignature(sigAlg, signed, sig); err != nil {
+		return err
+	}
+
+	// 证书中包含认证器型号时，必须与认证器数据一致
+	for _, ext := range leaf.Extensions {
+		if !ext.Id.Equal(oidFIDOGenCeAAGUID) {
+			continue
+		}
+		var certAAGUID []byte
+		if _, err := asn1.Unmarshal(ext.Value, &certAAGUID); err != nil {
+			return err
+		}
+		if subtle.ConstantTimeCompare(certAAGUID, aaguid) != 1 {
+			return errors.New("attestation aaguid mismatch")
+		}
+	}
+
+	if roots != nil {
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		_, err := leaf.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,370 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gotest.tools/assert"
+)
+
+const (
+	testRPID   = "anyshare.example.com"
+	testOrigin = "https://anyshare.example.com"
+)
+
+var testAAGUID = []byte{0xee, 0x88, 0x28, 0x79, 0x72, 0x1c, 0x49, 0x13, 0x97, 0x75, 0x3d, 0xfc, 0xce, 0x97, 0x07, 0x2a}
+
+// encodeCBOR 测试用CBOR编码，仅支持测试数据用到的类型
+func encodeCBOR(v interface{}) []byte {
+	head := func(major byte, n uint64) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n <= 0xff:
+			return []byte{major<<5 | 24, byte(n)}
+		case n <= 0xffff:
+			buf := []byte{major<<5 | 25, 0, 0}
+			binary.BigEndian.PutUint16(buf[1:], uint16(n))
+			return buf
+		default:
+			buf := []byte{major<<5 | 26, 0, 0, 0, 0}
+			binary.BigEndian.PutUint32(buf[1:], uint32(n))
+			return buf
+		}
+	}
+
+	switch x := v.(type) {
+	case int:
+		if x < 0 {
+			return head(cborNegInt, uint64(-1-x))
+		}
+		return head(cborUint, uint64(x))
+	case []byte:
+		return append(head(cborBytes, uint64(len(x))), x...)
+	case string:
+		return append(head(cborText, uint64(len(x))), x...)
+	case []interface{}:
+		out := head(cborArray, uint64(len(x)))
+		for _, item := range x {
+			out = append(out, encodeCBOR(item)...)
+		}
+		return out
+	case map[interface{}]interface{}:
+		out := head(cborMap, uint64(len(x)))
+		for key, value := range x {
+			out = append(out, encodeCBOR(key)...)
+			out = append(out, encodeCBOR(value)...)
+		}
+		return out
+	}
+	panic("unsupported type")
+}
+
+// testAuthenticator 模拟认证器
+type testAuthenticator struct {
+	credentialID []byte
+	key          *ecdsa.PrivateKey
+	publicKey    []byte
+	signCount    uint32
+	flags        byte
+}
+
+func newTestAuthenticator() *testAuthenticator {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	credentialID := make([]byte, 16)
+	_, _ = rand.Read(credentialID)
+
+	// 映射编码顺序不固定，公钥只编码一次
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	key.X.FillBytes(x)
+	key.Y.FillBytes(y)
+	return &testAuthenticator{
+		credentialID: credentialID,
+		key:          key,
+		publicKey:    encodeCBOR(map[interface{}]interface{}{1: 2, 3: -7, -1: 1, -2: x, -3: y}),
+		flags:        flagUserPresent | flagUserVerified,
+	}
+}
+
+func (a *testAuthenticator) credentialIDStr() string {
+	return base64.RawURLEncoding.EncodeToString(a.credentialID)
+}
+
+func (a *testAuthenticator) authData(rpID string, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+	flags := a.flags
+	if attested {
+		flags |= flagAttested
+	}
+	data = append(data, flags, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[33:37], a.signCount)
+	if attested {
+		data = append(data, testAAGUID...)
+		data = append(data, byte(len(a.credentialID)>>8), byte(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.publicKey...)
+	}
+	return data
+}
+
+func (a *testAuthenticator) sign(data []byte) []byte {
+	digest := sha256.Sum256(data)
+	sig, _ := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	return sig
+}
+
+func testClientData(typ, challenge, origin string) []byte {
+	data, _ := json.Marshal(map[string]interface{}{"type": typ, "challenge": challenge, "origin": origin})
+	return data
+}
+
+// newTestAttestationCert 生成测试用证明证书及其根证书
+func newTestAttestationCert(aaguid []byte) (leafKey *ecdsa.PrivateKey, leafDER []byte, roots *x509.CertPool) {
+	rootKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rootTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	rootDER, _ := x509.CreateCertificate(rand.Reader, rootTmpl, rootTmpl, &rootKey.PublicKey, rootKey)
+	rootCert, _ := x509.ParseCertificate(rootDER)
+
+	aaguidExt, _ := asn1.Marshal(aaguid)
+	leafKey, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	leafTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "test authenticator"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		ExtraExtensions:       []pkix.Extension{{Id: oidFIDOGenCeAAGUID, Value: aaguidExt}},
+	}
+	leafDER, _ = x509.CreateCertificate(rand.Reader, leafTmpl, rootCert, &leafKey.PublicKey, rootKey)
+
+	roots = x509.NewCertPool()
+	roots.AddCert(rootCert)
+	return leafKey, leafDER, roots
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	Convey("decodeCBOR", t, func() {
+		Convey("integers, strings and containers", func() {
+			data := encodeCBOR(map[interface{}]interface{}{
+				1:      -7,
+				"name": "key",
+				"list": []interface{}{1000, []byte{1, 2}},
+			})
+			v, rest, err := decodeCBOR(append(data, 0xff))
+			assert.Equal(t, err, nil)
+			assert.DeepEqual(t, rest, []byte{0xff})
+
+			m := v.(map[interface{}]interface{})
+			assert.Equal(t, m[int64(1)], int64(-7))
+			assert.Equal(t, m["name"], "key")
+			assert.DeepEqual(t, m["list"], []interface{}{int64(1000), []byte{1, 2}})
+		})
+
+		Convey("simple values", func() {
+			v, _, err := decodeCBOR([]byte{0xf5})
+			assert.Equal(t, err, nil)
+			assert.Equal(t, v, true)
+		})
+
+		Convey("truncated data", func() {
+			_, _, err := decodeCBOR([]byte{0x58, 0x05, 0x01})
+			assert.Equal(t, err, errCBORMalformed)
+		})
+
+		Convey("indefinite length not supported", func() {
+			_, _, err := decodeCBOR([]byte{0x5f, 0x41, 0x01, 0xff})
+			assert.Equal(t, err, errCBORMalformed)
+		})
+
+		Convey("nested too deep", func() {
+			data := make([]byte, 0, cborMaxDepth+2)
+			for i := 0; i < cborMaxDepth+2; i++ {
+				data = append(data, 0x81)
+			}
+			_, _, err := decodeCBOR(append(data, 0x01))
+			assert.Equal(t, err, errCBORMalformed)
+		})
+	})
+}
+
+func TestParseCOSEKey(t *testing.T) {
+	Convey("parseCOSEKey", t, func() {
+		Convey("ES256", func() {
+			a := newTestAuthenticator()
+			alg, pub, err := parseCOSEKey(a.publicKey)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, alg, algES256)
+
+			data := []byte("signed data")
+			assert.Equal(t, verifySignature(alg, pub, data, a.sign(data)), true)
+			assert.Equal(t, verifySignature(alg, pub, []byte("other data"), a.sign(data)), false)
+		})
+
+		Convey("EdDSA", func() {
+			pubKey, priKey, _ := ed25519.GenerateKey(rand.Reader)
+			alg, pub, err := parseCOSEKey(encodeCBOR(map[interface{}]interface{}{1: 1, 3: -8, -1: 6, -2: []byte(pubKey)}))
+			assert.Equal(t, err, nil)
+			assert.Equal(t, alg, algEdDSA)
+
+			data := []byte("signed data")
+			assert.Equal(t, verifySignature(alg, pub, data, ed25519.Sign(priKey, data)), true)
+		})
+
+		Convey("point not on curve", func() {
+			_, _, err := parseCOSEKey(encodeCBOR(map[interface{}]interface{}{
+				1: 2, 3: -7, -1: 1, -2: make([]byte, 32), -3: make([]byte, 32),
+			}))
+			assert.Equal(t, err, errUnsupportedKey)
+		})
+
+		Convey("unsupported algorithm", func() {
+			_, _, err := parseCOSEKey(encodeCBOR(map[interface{}]interface{}{1: 2, 3: -35, -1: 2}))
+			assert.Equal(t, err, errUnsupportedKey)
+		})
+	})
+}
+
+func TestParseClientData(t *testing.T) {
+	Convey("parseClientData", t, func() {
+		origins := []string{testOrigin}
+
+		Convey("success", func() {
+			err := parseClientData(testClientData(clientDataGet, "abc", testOrigin), clientDataGet, "abc", origins)
+			assert.Equal(t, err, nil)
+		})
+
+		Convey("wrong type", func() {
+			err := parseClientData(testClientData(clientDataCreate, "abc", testOrigin), clientDataGet, "abc", origins)
+			assert.Assert(t, err != nil)
+		})
+
+		Convey("wrong challenge", func() {
+			err := parseClientData(testClientData(clientDataGet, "abd", testOrigin), clientDataGet, "abc", origins)
+			assert.Assert(t, err != nil)
+		})
+
+		Convey("origin not allowed", func() {
+			err := parseClientData(testClientData(clientDataGet, "abc", "https://evil.example.com"), clientDataGet, "abc", origins)
+			assert.Assert(t, err != nil)
+		})
+	})
+}
+
+func TestParseAuthenticatorData(t *testing.T) {
+	Convey("parseAuthenticatorData", t, func() {
+		a := newTestAuthenticator()
+		a.signCount = 5
+
+		Convey("with attested credential data", func() {
+			ad, err := parseAuthenticatorData(a.authData(testRPID, true))
+			assert.Equal(t, err, nil)
+			assert.Equal(t, ad.SignCount, uint32(5))
+			assert.DeepEqual(t, ad.AAGUID, testAAGUID)
+			assert.DeepEqual(t, ad.CredentialID, a.credentialID)
+			assert.DeepEqual(t, ad.PublicKey, a.publicKey)
+		})
+
+		Convey("without attested credential data", func() {
+			ad, err := parseAuthenticatorData(a.authData(testRPID, false))
+			assert.Equal(t, err, nil)
+			assert.Assert(t, ad.CredentialID == nil)
+		})
+
+		Convey("trailing data", func() {
+			_, err := parseAuthenticatorData(append(a.authData(testRPID, false), 0x00))
+			assert.Assert(t, err != nil)
+		})
+
+		Convey("too short", func() {
+			_, err := parseAuthenticatorData(make([]byte, 36))
+			assert.Assert(t, err != nil)
+		})
+	})
+}
+
+func TestVerifyAttestation(t *testing.T) {
+	Convey("verifyAttestation", t, func() {
+		a := newTestAuthenticator()
+		authData := a.authData(testRPID, true)
+		clientDataHash := sha256.Sum256(testClientData(clientDataCreate, "abc", testOrigin))
+		signed := append(append([]byte{}, authData...), clientDataHash[:]...)
+		credAlg, credPub, _ := parseCOSEKey(a.publicKey)
+
+		Convey("none policy accepts any format", func() {
+			att := &attestationObject{Fmt: "none", AttStmt: map[interface{}]interface{}{}, AuthData: authData}
+			err := verifyAttestation(attestationNone, att, testAAGUID, clientDataHash[:], credAlg, credPub, nil)
+			assert.Equal(t, err, nil)
+		})
+
+		Convey("direct policy rejects none format", func() {
+			att := &attestationObject{Fmt: "none", AttStmt: map[interface{}]interface{}{}, AuthData: authData}
+			err := verifyAttestation(attestationDirect, att, testAAGUID, clientDataHash[:], credAlg, credPub, nil)
+			assert.Assert(t, err != nil)
+		})
+
+		Convey("packed self attestation", func() {
+			att := &attestationObject{Fmt: "packed", AuthData: authData, AttStmt: map[interface{}]interface{}{
+				"alg": int64(algES256), "sig": a.sign(signed),
+			}}
+			err := verifyAttestation(attestationDirect, att, testAAGUID, clientDataHash[:], credAlg, credPub, nil)
+			assert.Equal(t, err, nil)
+
+			// 配置了可信根证书时不接受自证明
+			_, _, roots := newTestAttestationCert(testAAGUID)
+			err = verifyAttestation(attestationDirect, att, testAAGUID, clientDataHash[:], credAlg, credPub, roots)
+			assert.Assert(t, err != nil)
+		})
+
+		Convey("packed full attestation", func() {
+			leafKey, leafDER, roots := newTestAttestationCert(testAAGUID)
+			digest := sha256.Sum256(signed)
+			sig, _ := ecdsa.SignASN1(rand.Reader, leafKey, digest[:])
+			att := &attestationObject{Fmt: "packed", AuthData: authData, AttStmt: map[interface{}]interface{}{
+				"alg": int64(algES256), "sig": sig, "x5c": []interface{}{leafDER},
+			}}
+
+			err := verifyAttestation(attestationDirect, att, testAAGUID, clientDataHash[:], credAlg, credPub, roots)
+			assert.Equal(t, err, nil)
+
+			Convey("untrusted root", func() {
+				_, _, otherRoots := newTestAttestationCert(testAAGUID)
+				err := verifyAttestation(attestationDirect, att, testAAGUID, clientDataHash[:], credAlg, credPub, otherRoots)
+				assert.Assert(t, err != nil)
+			})
+
+			Convey("aaguid mismatch", func() {
+				err := verifyAttestation(attestationDirect, att, make([]byte, 16), clientDataHash[:], credAlg, credPub, roots)
+				assert.Assert(t, err != nil)
+			})
+
+			Convey("signature mismatch", func() {
+				att.AttStmt["sig"] = a.sign(signed)
+				err := verifyAttestation(attestationDirect, att, testAAGUID, clientDataHash[:], credAlg, credPub, roots)
+				assert.Assert(t, err != nil)
+			})
+		})
+	})
+}
@@ -0,0 +1,595 @@
+// Package webauthn 逻辑层
+package webauthn
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"github.com/kweaver-ai/go-lib/observable"
+	"github.com/kweaver-ai/go-lib/rest"
+	"github.com/oklog/ulid/v2"
+
+	"Authentication/common"
+	"Authentication/interfaces"
+	"Authentication/logics"
+	"Authentication/logics/audit"
+)
+
+var (
+	wOnce sync.Once
+	w     *webAuthn
+)
+
+const (
+	// challengeSize 挑战值长度，单位为字节
+	challengeSize = 32
+	// challengeTimeout 挑战有效期，单位为秒
+	challengeTimeout = 5 * 60
+	// maxCredentialCount 单个用户允许注册的安全密钥数量上限
+	maxCredentialCount = 20
+	// defaultRPName 默认依赖方名称
+	defaultRPName = "AnyShare"
+)
+
+// 审计日志内容唯一标识
+const (
+	_ int = iota
+	i18nAddCredential
+	i18nDeleteCredential
+	i18nRevokeCredential
+	i18nRevokeAllCredentials
+	i18nSignCountInvalid
+	i18nWebAuthnExMsg
+)
+
+var svcLanguages = map[string]interfaces.Language{
+	"zh_CN": interfaces.SimplifiedChinese,
+	"zh_TW": interfaces.TraditionalChinese,
+	"en_US": interfaces.AmericanEnglish,
+}
+
+type webAuthn struct {
+	db       interfaces.DBWebAuthn
+	userMgnt interfaces.DnUserManagement
+	audit    interfaces.LogicsAudit
+	config   common.WebAuthnConfig
+	roots    *x509.CertPool
+	aaguids  map[string]bool
+	lang     interfaces.Language
+	i18n     *common.I18n
+	logger   common.Logger
+	trace    observable.Tracer
+}
+
+// NewWebAuthn 创建安全密钥处理对象
+func NewWebAuthn() *webAuthn {
+	wOnce.Do(func() {
+		config := common.SvcConfig.WebAuthn
+		if config.RPName == "" {
+			config.RPName = defaultRPName
+		}
+		if config.Attestation == "" {
+			config.Attestation = attestationNone
+		}
+
+		var roots *x509.CertPool
+		if config.AttestationCA != "" {
+			roots = x509.NewCertPool()
+			if !roots.AppendCertsFromPEM([]byte(config.AttestationCA)) {
+				common.NewLogger().Fatalln("invalid webauthn attestation_ca")
+			}
+		}
+
+		aaguids := make(map[string]bool, len(config.AllowedAAGUIDs))
+		for _, aaguid := range config.AllowedAAGUIDs {
+			aaguids[normalizeAAGUID(aaguid)] = true
+		}
+
+		w = &webAuthn{
+			db:       logics.DBWebAuthn,
+			userMgnt: logics.DnUserManagement,
+			audit:    audit.NewAudit(),
+			config:   config,
+			roots:    roots,
+			aaguids:  aaguids,
+			lang:     svcLanguages[common.SvcConfig.Lang],
+			i18n: common.NewI18n(common.I18nMap{
+				i18nAddCredential: {
+					interfaces.SimplifiedChinese:  "添加安全密钥“%s” 成功",
+					interfaces.TraditionalChinese: "新增安全金鑰“%s” 成功",
+					interfaces.AmericanEnglish:    "Add security key \"%s\" successfully",
+				},
+				i18nDeleteCredential: {
+					interfaces.SimplifiedChinese:  "删除安全密钥“%s” 成功",
+					interfaces.TraditionalChinese: "刪除安全金鑰“%s” 成功",
+					interfaces.AmericanEnglish:    "Delete security key \"%s\" successfully",
+				},
+				i18nRevokeCredential: {
+					interfaces.SimplifiedChinese:  "吊销用户“%s”的安全密钥“%s” 成功",
+					interfaces.TraditionalChinese: "撤銷使用者“%s”的安全金鑰“%s” 成功",
+					interfaces.AmericanEnglish:    "Revoke security key \"%[2]s\" of user \"%[1]s\" successfully",
+				},
+				i18nRevokeAllCredentials: {
+					interfaces.SimplifiedChinese:  "吊销用户“%s”的所有安全密钥 成功",
+					interfaces.TraditionalChinese: "撤銷使用者“%s”的所有安全金鑰 成功",
+					interfaces.AmericanEnglish:    "Revoke all security keys of user \"%s\" successfully",
+				},
+				i18nSignCountInvalid: {
+					interfaces.SimplifiedChinese:  "安全密钥“%s”签名计数器异常，可能已被复制",
+					interfaces.TraditionalChinese: "安全金鑰“%s”簽章計數器異常，可能已被複製",
+					interfaces.AmericanEnglish:    "Signature counter of security key \"%s\" is invalid, it may have been cloned",
+				},
+				i18nWebAuthnExMsg: {
+					interfaces.SimplifiedChinese:  "认证方式：安全密钥",
+					interfaces.TraditionalChinese: "認證方式：安全金鑰",
+					interfaces.AmericanEnglish:    "Authentication: Security Key",
+				},
+			}),
+			logger: common.NewLogger(),
+			trace:  common.SvcARTrace,
+		}
+	})
+
+	return w
+}
+
+// BeginRegistration 生成当前用户的安全密钥注册选项
+func (w *webAuthn) BeginRegistration(ctx context.Context, visitor *interfaces.Visitor) (options *interfaces.WebAuthnRegistrationOptions, err error) {
+	w.trace.SetInternalSpanName("逻辑层-生成安全密钥注册选项")
+	newCtx, span := w.trace.AddInternalTrace(ctx)
+	defer func() { w.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = w.checkEnabled(); err != nil {
+		return nil, err
+	}
+	if err = checkRealName(visitor); err != nil {
+		return nil, err
+	}
+
+	userInfo, err := w.userMgnt.GetUserInfo(newCtx, visitor, visitor.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := w.db.GetCredentialsByUserID(newCtx, visitor.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(credentials) >= maxCredentialCount {
+		return nil, rest.NewHTTPErrorV2(rest.Conflict, "too many security keys")
+	}
+
+	challenge, err := w.newChallenge(newCtx, interfaces.WebAuthnRegistration, visitor.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	excludeIDs := make([]string, 0, len(credentials))
+	for i := range credentials {
+		excludeIDs = append(excludeIDs, credentials[i].ID)
+	}
+
+	return &interfaces.WebAuthnRegistrationOptions{
+		ChallengeID:          challenge.ID,
+		Challenge:            challenge.Challenge,
+		RPID:                 w.config.RPID,
+		RPName:               w.config.RPName,
+		UserHandle:           base64.RawURLEncoding.EncodeToString([]byte(visitor.ID)),
+		UserName:             userInfo.Account,
+		Algorithms:           supportedAlgorithms,
+		Attestation:          w.config.Attestation,
+		ExcludeCredentialIDs: excludeIDs,
+		Timeout:              challengeTimeout * 1000,
+	}, nil
+}
+
+// FinishRegistration 校验注册响应并保存凭据
+//
+//nolint:gocyclo
+func (w *webAuthn) FinishRegistration(ctx context.Context, visitor *interfaces.Visitor, req *interfaces.WebAuthnRegistrationReq) (credential *interfaces.WebAuthnCredential, err error) {
+	w.trace.SetInternalSpanName("逻辑层-完成安全密钥注册")
+	newCtx, span := w.trace.AddInternalTrace(ctx)
+	defer func() { w.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = w.checkEnabled(); err != nil {
+		return nil, err
+	}
+	if err = checkRealName(visitor); err != nil {
+		return nil, err
+	}
+
+	challenge, err := w.consumeChallenge(newCtx, req.ChallengeID, interfaces.WebAuthnRegistration, visitor.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	rawID, err1 := decodeBase64URL(req.CredentialID)
+	clientDataJSON, err2 := decodeBase64URL(req.ClientDataJSON)
+	attObjData, err3 := decodeBase64URL(req.AttestationObject)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return nil, rest.NewHTTPErrorV2(rest.BadRequest, "invalid base64url encoding")
+	}
+
+	if err = parseClientData(clientDataJSON, clientDataCreate, challenge.Challenge, w.config.Origins); err != nil {
+		return nil, w.verifyFailed(visitor.ID, err)
+	}
+
+	att, err := parseAttestationObject(attObjData)
+	if err != nil {
+		return nil, w.verifyFailed(visitor.ID, err)
+	}
+	authData, err := parseAuthenticatorData(att.AuthData)
+	if err != nil {
+		return nil, w.verifyFailed(visitor.ID, err)
+	}
+	if err = w.checkAuthenticatorData(authData, false); err != nil {
+		return nil, w.verifyFailed(visitor.ID, err)
+	}
+	if authData.Flags&flagAttested == 0 || !bytes.Equal(authData.CredentialID, rawID) {
+		return nil, w.verifyFailed(visitor.ID, errCredentialMismatch)
+	}
+
+	alg, pub, err := parseCOSEKey(authData.PublicKey)
+	if err != nil {
+		return nil, w.verifyFailed(visitor.ID, err)
+	}
+
+	aaguid := hex.EncodeToString(authData.AAGUID)
+	if len(w.aaguids) > 0 && !w.aaguids[aaguid] {
+		return nil, w.verifyFailed(visitor.ID, errAAGUIDNotAllowed)
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	if err = verifyAttestation(w.config.Attestation, att, authData.AAGUID, clientDataHash[:], alg, pub, w.roots); err != nil {
+		return nil, w.verifyFailed(visitor.ID, err)
+	}
+
+	credentialID := base64.RawURLEncoding.EncodeToString(rawID)
+	existing, err := w.db.GetCredential(newCtx, credentialID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, rest.NewHTTPErrorV2(rest.Conflict, "security key is already registered")
+	}
+
+	now := common.Now().Unix()
+	credential = &interfaces.WebAuthnCredential{
+		ID:             credentialID,
+		UserID:         visitor.ID,
+		Name:           req.Name,
+		PublicKey:      authData.PublicKey,
+		Algorithm:      alg,
+		SignCount:      authData.SignCount,
+		AAGUID:         aaguid,
+		AttestationFmt: att.Fmt,
+		CreateTime:     now,
+	}
+	if err = w.db.AddCredential(newCtx, credential); err != nil {
+		return nil, err
+	}
+
+	w.writeLog(visitor, visitor.ID, audit.LevelInfo, audit.OpCreate, visitor.ID, i18nAddCredential, credential.Name)
+	return credential, nil
+}
+
+// ListCredentials 获取用户的安全密钥列表，仅本人或管理员可用
+func (w *webAuthn) ListCredentials(ctx context.Context, visitor *interfaces.Visitor, userID string) (credentials []interfaces.WebAuthnCredential, err error) {
+	w.trace.SetInternalSpanName("逻辑层-获取安全密钥列表")
+	newCtx, span := w.trace.AddInternalTrace(ctx)
+	defer func() { w.trace.TelemetrySpanEnd(span, err) }()
+
+	if !isSelf(visitor, userID) {
+		if err = w.checkAdmin(newCtx, visitor); err != nil {
+			return nil, err
+		}
+	}
+
+	return w.db.GetCredentialsByUserID(newCtx, userID)
+}
+
+// DeleteCredential 删除用户的指定安全密钥，仅本人或管理员可用
+func (w *webAuthn) DeleteCredential(ctx context.Context, visitor *interfaces.Visitor, userID, credentialID string) (err error) {
+	w.trace.SetInternalSpanName("逻辑层-删除安全密钥")
+	newCtx, span := w.trace.AddInternalTrace(ctx)
+	defer func() { w.trace.TelemetrySpanEnd(span, err) }()
+
+	self := isSelf(visitor, userID)
+	if !self {
+		if err = w.checkAdmin(newCtx, visitor); err != nil {
+			return err
+		}
+	}
+
+	credential, err := w.db.GetCredential(newCtx, credentialID)
+	if err != nil {
+		return err
+	}
+	if credential == nil || credential.UserID != userID {
+		return rest.NewHTTPErrorV2(rest.URINotExist, "security key not found")
+	}
+
+	ok, err := w.db.DeleteCredential(newCtx, userID, credentialID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return rest.NewHTTPErrorV2(rest.URINotExist, "security key not found")
+	}
+
+	if self {
+		w.writeLog(visitor, visitor.ID, audit.LevelWarn, audit.OpDelete, userID, i18nDeleteCredential, credential.Name)
+		return nil
+	}
+
+	userInfo, err := w.userMgnt.GetUserInfo(newCtx, visitor, userID)
+	if err != nil {
+		w.logger.Errorf("GetUserInfo failed, user: %s, err: %v", userID, err)
+		return nil
+	}
+	w.writeLog(visitor, visitor.ID, audit.LevelWarn, audit.OpDelete, userID, i18nRevokeCredential, userInfo.Account, credential.Name)
+	return nil
+}
+
+// RevokeAll 管理员吊销用户的所有安全密钥
+func (w *webAuthn) RevokeAll(ctx context.Context, visitor *interfaces.Visitor, userID string) (err error) {
+	w.trace.SetInternalSpanName("逻辑层-吊销用户所有安全密钥")
+	newCtx, span := w.trace.AddInternalTrace(ctx)
+	defer func() { w.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = w.checkAdmin(newCtx, visitor); err != nil {
+		return err
+	}
+
+	// 检查用户是否存在
+	userInfo, err := w.userMgnt.GetUserInfo(newCtx, visitor, userID)
+	if err != nil {
+		return err
+	}
+
+	if err = w.db.DeleteCredentialsByUserID(newCtx, userID); err != nil {
+		return err
+	}
+
+	w.writeLog(visitor, visitor.ID, audit.LevelWarn, audit.OpDelete, userID, i18nRevokeAllCredentials, userInfo.Account)
+	return nil
+}
+
+// BeginAssertion 生成安全密钥认证选项
+// 不指定可用凭据，由认证器选择可发现凭据，避免通过认证选项泄露账户信息
+func (w *webAuthn) BeginAssertion(ctx context.Context, visitor *interfaces.Visitor) (options *interfaces.WebAuthnAssertionOptions, err error) {
+	w.trace.SetInternalSpanName("逻辑层-生成安全密钥认证选项")
+	newCtx, span := w.trace.AddInternalTrace(ctx)
+	defer func() { w.trace.TelemetrySpanEnd(span, err) }()
+
+	if err = w.checkEnabled(); err != nil {
+		return nil, err
+	}
+
+	challenge, err := w.newChallenge(newCtx, interfaces.WebAuthnAssertion, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &interfaces.WebAuthnAssertionOptions{
+		ChallengeID: challenge.ID,
+		Challenge:   challenge.Challenge,
+		RPID:        w.config.RPID,
+		Timeout:     challengeTimeout * 1000,
+	}, nil
+}
+
+// Authenticate 作为首要认证因子校验认证响应，要求用户验证，返回凭据所属用户
+func (w *webAuthn) Authenticate(ctx context.Context, visitor *interfaces.Visitor, resp *interfaces.WebAuthnAssertionResp) (userID string, err error) {
+	w.trace.SetInternalSpanName("逻辑层-安全密钥认证")
+	newCtx, span := w.trace.AddInternalTrace(ctx)
+	defer func() { w.trace.TelemetrySpanEnd(span, err) }()
+
+	credential, err := w.verifyAssertion(newCtx, visitor, resp, "", true)
+	if err != nil {
+		return "", err
+	}
+	return credential.UserID, nil
+}
+
+// Validate 作为双因子认证校验认证响应，凭据必须属于指定用户
+func (w *webAuthn) Validate(ctx context.Context, visitor *interfaces.Visitor, userID string, resp *interfaces.WebAuthnAssertionResp) (err error) {
+	w.trace.SetInternalSpanName("逻辑层-校验安全密钥")
+	newCtx, span := w.trace.AddInternalTrace(ctx)
+	defer func() { w.trace.TelemetrySpanEnd(span, err) }()
+
+	_, err = w.verifyAssertion(newCtx, visitor, resp, userID, false)
+	return err
+}
+
+// verifyAssertion 校验认证响应并更新签名计数器
+// userID 不为空时凭据必须属于该用户，requireUV 为 true 时要求认证器完成用户验证
+//
+//nolint:gocyclo
+func (w *webAuthn) verifyAssertion(ctx context.Context, visitor *interfaces.Visitor, resp *interfaces.WebAuthnAssertionResp,
+	userID string, requireUV bool) (credential *interfaces.WebAuthnCredential, err error) {
+	if err = w.checkEnabled(); err != nil {
+		return nil, err
+	}
+
+	challenge, err := w.consumeChallenge(ctx, resp.ChallengeID, interfaces.WebAuthnAssertion, "")
+	if err != nil {
+		return nil, err
+	}
+
+	rawID, err1 := decodeBase64URL(resp.CredentialID)
+	clientDataJSON, err2 := decodeBase64URL(resp.ClientDataJSON)
+	authDataRaw, err3 := decodeBase64URL(resp.AuthenticatorData)
+	sig, err4 := decodeBase64URL(resp.Signature)
+	userHandle, err5 := decodeBase64URL(resp.UserHandle)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil || err5 != nil {
+		return nil, rest.NewHTTPErrorV2(rest.BadRequest, "invalid base64url encoding")
+	}
+
+	credential, err = w.db.GetCredential(ctx, base64.RawURLEncoding.EncodeToString(rawID))
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil {
+		return nil, w.verifyFailed(userID, errCredentialNotFound)
+	}
+	if userID != "" && credential.UserID != userID {
+		return nil, w.verifyFailed(userID, errCredentialMismatch)
+	}
+	if len(userHandle) > 0 && string(userHandle) != credential.UserID {
+		return nil, w.verifyFailed(credential.UserID, errCredentialMismatch)
+	}
+
+	if err = parseClientData(clientDataJSON, clientDataGet, challenge.Challenge, w.config.Origins); err != nil {
+		return nil, w.verifyFailed(credential.UserID, err)
+	}
+
+	authData, err := parseAuthenticatorData(authDataRaw)
+	if err != nil {
+		return nil, w.verifyFailed(credential.UserID, err)
+	}
+	if err = w.checkAuthenticatorData(authData, requireUV); err != nil {
+		return nil, w.verifyFailed(credential.UserID, err)
+	}
+
+	alg, pub, err := parseCOSEKey(credential.PublicKey)
+	if err != nil {
+		return nil, w.verifyFailed(credential.UserID, err)
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte{}, authDataRaw...), clientDataHash[:]...)
+	if !verifySignature(alg, pub, signed, sig) {
+		return nil, w.verifyFailed(credential.UserID, errInvalidSignature)
+	}
+
+	// 认证器支持签名计数器时，计数器必须递增，否则凭据可能已被复制
+	if (authData.SignCount != 0 || credential.SignCount != 0) && authData.SignCount <= credential.SignCount {
+		w.writeLog(visitor, credential.UserID, audit.LevelWarn, audit.OpSet, credential.UserID, i18nSignCountInvalid, credential.Name)
+		return nil, w.verifyFailed(credential.UserID, errSignCountInvalid)
+	}
+
+	ok, err := w.db.UpdateSignCount(ctx, credential.ID, credential.SignCount, authData.SignCount, common.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, w.verifyFailed(credential.UserID, errSignCountInvalid)
+	}
+
+	credential.SignCount = authData.SignCount
+	return credential, nil
+}
+
+// newChallenge 生成并保存挑战，同时清理已过期的挑战
+func (w *webAuthn) newChallenge(ctx context.Context, ceremony interfaces.WebAuthnCeremonyType, userID string) (challenge *interfaces.WebAuthnChallenge, err error) {
+	buf := make([]byte, challengeSize)
+	if _, err = rand.Read(buf); err != nil {
+		return nil, err
+	}
+
+	now := common.Now().Unix()
+	challenge = &interfaces.WebAuthnChallenge{
+		ID:         ulid.Make().String(),
+		Challenge:  base64.RawURLEncoding.EncodeToString(buf),
+		Type:       ceremony,
+		UserID:     userID,
+		CreateTime: now,
+	}
+	if err = w.db.CreateChallenge(ctx, challenge, now-challengeTimeout); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// consumeChallenge 使用挑战，挑战不存在、类型不符、用户不符或已过期时校验失败
+func (w *webAuthn) consumeChallenge(ctx context.Context, id string, ceremony interfaces.WebAuthnCeremonyType, userID string) (challenge *interfaces.WebAuthnChallenge, err error) {
+	challenge, err = w.db.ConsumeChallenge(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if challenge == nil || challenge.Type != ceremony || challenge.UserID != userID ||
+		common.Now().Unix()-challenge.CreateTime > challengeTimeout {
+		return nil, w.verifyFailed(userID, errChallengeInvalid)
+	}
+	return challenge, nil
+}
+
+// checkAuthenticatorData 校验依赖方标识及用户在场、用户验证标志
+func (w *webAuthn) checkAuthenticatorData(authData *authenticatorData, requireUV bool) error {
+	rpIDHash := sha256.Sum256([]byte(w.config.RPID))
+	if !bytes.Equal(authData.RPIDHash, rpIDHash[:]) {
+		return errRPIDMismatch
+	}
+	if authData.Flags&flagUserPresent == 0 {
+		return errUserNotPresent
+	}
+	if requireUV && authData.Flags&flagUserVerified == 0 {
+		return errUserNotVerified
+	}
+	return nil
+}
+
+// checkEnabled 未配置依赖方标识时不启用安全密钥
+func (w *webAuthn) checkEnabled() error {
+	if w.config.RPID == "" {
+		return rest.NewHTTPErrorV2(rest.Forbidden, "webauthn is not enabled")
+	}
+	return nil
+}
+
+// verifyFailed 记录校验失败原因，并返回统一的校验失败错误，避免泄露校验细节
+func (w *webAuthn) verifyFailed(userID string, reason error) error {
+	w.logger.Warnf("webauthn verify failed, user: %s, reason: %v", userID, reason)
+	return rest.NewHTTPError("", common.WebAuthnVerifyFailed, nil)
+}
+
+func (w *webAuthn) checkAdmin(ctx context.Context, visitor *interfaces.Visitor) (err error) {
+	var roleTypes []interfaces.RoleType
+	// 实名用户获取对应角色信息
+	if visitor.Type == interfaces.RealName {
+		roleTypes, err = w.userMgnt.GetUserRolesByUserID(ctx, visitor, visitor.ID)
+		if err != nil {
+			return
+		}
+	}
+
+	return logics.CheckVisitorType(visitor, roleTypes, []interfaces.VisitorType{interfaces.RealName},
+		[]interfaces.RoleType{interfaces.SuperAdmin, interfaces.SystemAdmin, interfaces.SecurityAdmin})
+}
+
+// writeLog 通过审计日志模块记录管理日志
+func (w *webAuthn) writeLog(visitor *interfaces.Visitor, userID string, level audit.LogLevel, opType audit.ManageOpType, objID string, msgID int, args ...any) {
+	audit.LogManagement(w.audit, w.logger, visitor, &audit.ManagementLog{
+		UserID: userID,
+		Level:  level,
+		OpType: opType,
+		ObjID:  objID,
+		Msg:    w.i18n.Load(msgID, w.lang, args...),
+		ExMsg:  w.i18n.Load(i18nWebAuthnExMsg, w.lang),
+	})
+}
+
+// checkRealName 仅允许实名用户注册安全密钥
+func checkRealName(visitor *interfaces.Visitor) error {
+	if visitor.Type != interfaces.RealName || visitor.ID == "" {
+		return rest.NewHTTPError("Unsupported user type", rest.Unauthorized, nil)
+	}
+	return nil
+}
+
+// isSelf 判断是否为实名用户操作自己的安全密钥
+func isSelf(visitor *interfaces.Visitor, userID string) bool {
+	return visitor.Type == interfaces.RealName && visitor.ID != "" && visitor.ID == userID
+}
+
+// normalizeAAGUID 统一认证器型号格式为不含分隔符的小写十六进制
+func normalizeAAGUID(aaguid string) string {
+	return strings.ToLower(strings.ReplaceAll(aaguid, "-", ""))
+}
@@ -0,0 +1,493 @@
+package webauthn
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/kweaver-ai/go-lib/rest"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/assert"
+
+	"Authentication/common"
+	"Authentication/interfaces"
+	"Authentication/interfaces/mock"
+	laudit "Authentication/logics/audit"
+)
+
+const userID = "dfc9b098-dac4-11ee-b50a-028586548cf7"
+
+func newWebAuthn(db interfaces.DBWebAuthn, userMgnt interfaces.DnUserManagement, audit interfaces.LogicsAudit,
+	trace interfaces.TraceClient) *webAuthn {
+	return &webAuthn{
+		db:       db,
+		userMgnt: userMgnt,
+		audit:    audit,
+		config: common.WebAuthnConfig{
+			RPID:        testRPID,
+			RPName:      defaultRPName,
+			Origins:     []string{testOrigin},
+			Attestation: attestationNone,
+		},
+		aaguids: map[string]bool{},
+		lang:    interfaces.SimplifiedChinese,
+		i18n:    common.NewI18n(common.I18nMap{}),
+		logger:  common.NewLogger(),
+		trace:   trace,
+	}
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// registrationReq 模拟浏览器生成注册响应
+func registrationReq(a *testAuthenticator, challenge, rpID string) *interfaces.WebAuthnRegistrationReq {
+	attObj := encodeCBOR(map[interface{}]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[interface{}]interface{}{},
+		"authData": a.authData(rpID, true),
+	})
+	return &interfaces.WebAuthnRegistrationReq{
+		ChallengeID:       "challenge1",
+		Name:              "key1",
+		CredentialID:      a.credentialIDStr(),
+		ClientDataJSON:    encode(testClientData(clientDataCreate, challenge, testOrigin)),
+		AttestationObject: encode(attObj),
+	}
+}
+
+// assertionResp 模拟浏览器生成认证响应
+func assertionResp(a *testAuthenticator, challenge string) *interfaces.WebAuthnAssertionResp {
+	authData := a.authData(testRPID, false)
+	clientDataJSON := testClientData(clientDataGet, challenge, testOrigin)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	sig := a.sign(append(append([]byte{}, authData...), clientDataHash[:]...))
+	return &interfaces.WebAuthnAssertionResp{
+		ChallengeID:       "challenge1",
+		CredentialID:      a.credentialIDStr(),
+		ClientDataJSON:    encode(clientDataJSON),
+		AuthenticatorData: encode(authData),
+		Signature:         encode(sig),
+		UserHandle:        encode([]byte(userID)),
+	}
+}
+
+func TestBeginRegistration(t *testing.T) {
+	Convey("BeginRegistration", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBWebAuthn(ctrl)
+		userMgnt := mock.NewMockDnUserManagement(ctrl)
+		audit := mock.NewMockLogicsAudit(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		wa := newWebAuthn(db, userMgnt, audit, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetInternalSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddInternalTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		visitor := &interfaces.Visitor{ID: userID, Type: interfaces.RealName}
+
+		Convey("not enabled", func() {
+			wa.config.RPID = ""
+			_, err := wa.BeginRegistration(ctx, visitor)
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.Forbidden)
+		})
+
+		Convey("unsupported visitor type", func() {
+			_, err := wa.BeginRegistration(ctx, &interfaces.Visitor{ID: "app", Type: interfaces.Business})
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.Unauthorized)
+		})
+
+		Convey("too many security keys", func() {
+			userMgnt.EXPECT().GetUserInfo(gomock.Any(), gomock.Any(), userID).Return(&interfaces.UserBaseInfo{Account: "user1"}, nil)
+			db.EXPECT().GetCredentialsByUserID(gomock.Any(), userID).Return(make([]interfaces.WebAuthnCredential, maxCredentialCount), nil)
+
+			_, err := wa.BeginRegistration(ctx, visitor)
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.Conflict)
+		})
+
+		Convey("success", func() {
+			var saved *interfaces.WebAuthnChallenge
+			userMgnt.EXPECT().GetUserInfo(gomock.Any(), gomock.Any(), userID).Return(&interfaces.UserBaseInfo{Account: "user1"}, nil)
+			db.EXPECT().GetCredentialsByUserID(gomock.Any(), userID).Return([]interfaces.WebAuthnCredential{{ID: "cred1"}}, nil)
+			db.EXPECT().CreateChallenge(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, challenge *interfaces.WebAuthnChallenge, expireBefore int64) error {
+					saved = challenge
+					assert.Equal(t, expireBefore, challenge.CreateTime-challengeTimeout)
+					return nil
+				})
+
+			options, err := wa.BeginRegistration(ctx, visitor)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, options.ChallengeID, saved.ID)
+			assert.Equal(t, options.Challenge, saved.Challenge)
+			assert.Equal(t, saved.Type, interfaces.WebAuthnRegistration)
+			assert.Equal(t, saved.UserID, userID)
+			assert.Equal(t, options.RPID, testRPID)
+			assert.Equal(t, options.UserName, "user1")
+			assert.Equal(t, options.UserHandle, encode([]byte(userID)))
+			assert.DeepEqual(t, options.ExcludeCredentialIDs, []string{"cred1"})
+		})
+	})
+}
+
+func TestFinishRegistration(t *testing.T) {
+	Convey("FinishRegistration", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBWebAuthn(ctrl)
+		userMgnt := mock.NewMockDnUserManagement(ctrl)
+		audit := mock.NewMockLogicsAudit(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		wa := newWebAuthn(db, userMgnt, audit, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetInternalSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddInternalTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		visitor := &interfaces.Visitor{ID: userID, Type: interfaces.RealName}
+		a := newTestAuthenticator()
+		challenge := &interfaces.WebAuthnChallenge{
+			ID:         "challenge1",
+			Challenge:  "Y2hhbGxlbmdl",
+			Type:       interfaces.WebAuthnRegistration,
+			UserID:     userID,
+			CreateTime: common.Now().Unix(),
+		}
+
+		Convey("challenge not found", func() {
+			db.EXPECT().ConsumeChallenge(gomock.Any(), "challenge1").Return(nil, nil)
+
+			_, err := wa.FinishRegistration(ctx, visitor, registrationReq(a, challenge.Challenge, testRPID))
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.WebAuthnVerifyFailed)
+		})
+
+		Convey("challenge belongs to other user", func() {
+			challenge.UserID = "other"
+			db.EXPECT().ConsumeChallenge(gomock.Any(), "challenge1").Return(challenge, nil)
+
+			_, err := wa.FinishRegistration(ctx, visitor, registrationReq(a, challenge.Challenge, testRPID))
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.WebAuthnVerifyFailed)
+		})
+
+		Convey("challenge expired", func() {
+			challenge.CreateTime -= challengeTimeout + 1
+			db.EXPECT().ConsumeChallenge(gomock.Any(), "challenge1").Return(challenge, nil)
+
+			_, err := wa.FinishRegistration(ctx, visitor, registrationReq(a, challenge.Challenge, testRPID))
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.WebAuthnVerifyFailed)
+		})
+
+		Convey("challenge mismatch", func() {
+			db.EXPECT().ConsumeChallenge(gomock.Any(), "challenge1").Return(challenge, nil)
+
+			_, err := wa.FinishRegistration(ctx, visitor, registrationReq(a, "other", testRPID))
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.WebAuthnVerifyFailed)
+		})
+
+		Convey("rp id mismatch", func() {
+			db.EXPECT().ConsumeChallenge(gomock.Any(), "challenge1").Return(challenge, nil)
+
+			_, err := wa.FinishRegistration(ctx, visitor, registrationReq(a, challenge.Challenge, "evil.example.com"))
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.WebAuthnVerifyFailed)
+		})
+
+		Convey("aaguid not allowed", func() {
+			wa.aaguids = map[string]bool{normalizeAAGUID("00000000-0000-0000-0000-000000000001"): true}
+			db.EXPECT().ConsumeChallenge(gomock.Any(), "challenge1").Return(challenge, nil)
+
+			_, err := wa.FinishRegistration(ctx, visitor, registrationReq(a, challenge.Challenge, testRPID))
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.WebAuthnVerifyFailed)
+		})
+
+		Convey("already registered", func() {
+			db.EXPECT().ConsumeChallenge(gomock.Any(), "challenge1").Return(challenge, nil)
+			db.EXPECT().GetCredential(gomock.Any(), a.credentialIDStr()).Return(&interfaces.WebAuthnCredential{}, nil)
+
+			_, err := wa.FinishRegistration(ctx, visitor, registrationReq(a, challenge.Challenge, testRPID))
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.Conflict)
+		})
+
+		Convey("success", func() {
+			wa.aaguids = map[string]bool{hex.EncodeToString(testAAGUID): true}
+			db.EXPECT().ConsumeChallenge(gomock.Any(), "challenge1").Return(challenge, nil)
+			db.EXPECT().GetCredential(gomock.Any(), a.credentialIDStr()).Return(nil, nil)
+			db.EXPECT().AddCredential(gomock.Any(), gomock.Any()).Return(nil)
+			audit.EXPECT().Log(laudit.TopicManagementLog, gomock.Any()).Return(nil)
+
+			credential, err := wa.FinishRegistration(ctx, visitor, registrationReq(a, challenge.Challenge, testRPID))
+			assert.Equal(t, err, nil)
+			assert.Equal(t, credential.ID, a.credentialIDStr())
+			assert.Equal(t, credential.UserID, userID)
+			assert.Equal(t, credential.Name, "key1")
+			assert.Equal(t, credential.Algorithm, algES256)
+			assert.Equal(t, credential.AAGUID, hex.EncodeToString(testAAGUID))
+			assert.Equal(t, credential.AttestationFmt, "none")
+			assert.DeepEqual(t, credential.PublicKey, a.publicKey)
+		})
+	})
+}
+
+func TestAuthenticate(t *testing.T) {
+	Convey("Authenticate", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBWebAuthn(ctrl)
+		userMgnt := mock.NewMockDnUserManagement(ctrl)
+		audit := mock.NewMockLogicsAudit(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		wa := newWebAuthn(db, userMgnt, audit, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetInternalSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddInternalTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		visitor := &interfaces.Visitor{}
+		a := newTestAuthenticator()
+		a.signCount = 6
+		challenge := &interfaces.WebAuthnChallenge{
+			ID:         "challenge1",
+			Challenge:  "Y2hhbGxlbmdl",
+			Type:       interfaces.WebAuthnAssertion,
+			CreateTime: common.Now().Unix(),
+		}
+		credential := &interfaces.WebAuthnCredential{
+			ID:        a.credentialIDStr(),
+			UserID:    userID,
+			Name:      "key1",
+			PublicKey: a.publicKey,
+			Algorithm: algES256,
+			SignCount: 5,
+		}
+
+		Convey("registration challenge can not be used", func() {
+			challenge.Type = interfaces.WebAuthnRegistration
+			db.EXPECT().ConsumeChallenge(gomock.Any(), "challenge1").Return(challenge, nil)
+
+			_, err := wa.Authenticate(ctx, visitor, assertionResp(a, challenge.Challenge))
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.WebAuthnVerifyFailed)
+		})
+
+		Convey("credential not found", func() {
+			db.EXPECT().ConsumeChallenge(gomock.Any(), "challenge1").Return(challenge, nil)
+			db.EXPECT().GetCredential(gomock.Any(), a.credentialIDStr()).Return(nil, nil)
+
+			_, err := wa.Authenticate(ctx, visitor, assertionResp(a, challenge.Challenge))
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.WebAuthnVerifyFailed)
+		})
+
+		Convey("user handle mismatch", func() {
+			db.EXPECT().ConsumeChallenge(gomock.Any(), "challenge1").Return(challenge, nil)
+			db.EXPECT().GetCredential(gomock.Any(), a.credentialIDStr()).Return(credential, nil)
+
+			resp := assertionResp(a, challenge.Challenge)
+			resp.UserHandle = encode([]byte("other"))
+			_, err := wa.Authenticate(ctx, visitor, resp)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.WebAuthnVerifyFailed)
+		})
+
+		Convey("user not verified", func() {
+			a.flags = flagUserPresent
+			db.EXPECT().ConsumeChallenge(gomock.Any(), "challenge1").Return(challenge, nil)
+			db.EXPECT().GetCredential(gomock.Any(), a.credentialIDStr()).Return(credential, nil)
+
+			_, err := wa.Authenticate(ctx, visitor, assertionResp(a, challenge.Challenge))
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.WebAuthnVerifyFailed)
+		})
+
+		Convey("invalid signature", func() {
+			db.EXPECT().ConsumeChallenge(gomock.Any(), "challenge1").Return(challenge, nil)
+			db.EXPECT().GetCredential(gomock.Any(), a.credentialIDStr()).Return(credential, nil)
+
+			resp := assertionResp(a, challenge.Challenge)
+			resp.Signature = encode(a.sign([]byte("other data")))
+			_, err := wa.Authenticate(ctx, visitor, resp)
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.WebAuthnVerifyFailed)
+		})
+
+		Convey("sign count not increased", func() {
+			a.signCount = 5
+			db.EXPECT().ConsumeChallenge(gomock.Any(), "challenge1").Return(challenge, nil)
+			db.EXPECT().GetCredential(gomock.Any(), a.credentialIDStr()).Return(credential, nil)
+			audit.EXPECT().Log(laudit.TopicManagementLog, gomock.Any()).Return(nil)
+
+			_, err := wa.Authenticate(ctx, visitor, assertionResp(a, challenge.Challenge))
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.WebAuthnVerifyFailed)
+		})
+
+		Convey("sign count changed concurrently", func() {
+			db.EXPECT().ConsumeChallenge(gomock.Any(), "challenge1").Return(challenge, nil)
+			db.EXPECT().GetCredential(gomock.Any(), a.credentialIDStr()).Return(credential, nil)
+			db.EXPECT().UpdateSignCount(gomock.Any(), a.credentialIDStr(), uint32(5), uint32(6), gomock.Any()).Return(false, nil)
+
+			_, err := wa.Authenticate(ctx, visitor, assertionResp(a, challenge.Challenge))
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.WebAuthnVerifyFailed)
+		})
+
+		Convey("success", func() {
+			db.EXPECT().ConsumeChallenge(gomock.Any(), "challenge1").Return(challenge, nil)
+			db.EXPECT().GetCredential(gomock.Any(), a.credentialIDStr()).Return(credential, nil)
+			db.EXPECT().UpdateSignCount(gomock.Any(), a.credentialIDStr(), uint32(5), uint32(6), gomock.Any()).Return(true, nil)
+
+			id, err := wa.Authenticate(ctx, visitor, assertionResp(a, challenge.Challenge))
+			assert.Equal(t, err, nil)
+			assert.Equal(t, id, userID)
+		})
+
+		Convey("authenticator without sign count", func() {
+			a.signCount = 0
+			credential.SignCount = 0
+			db.EXPECT().ConsumeChallenge(gomock.Any(), "challenge1").Return(challenge, nil)
+			db.EXPECT().GetCredential(gomock.Any(), a.credentialIDStr()).Return(credential, nil)
+			db.EXPECT().UpdateSignCount(gomock.Any(), a.credentialIDStr(), uint32(0), uint32(0), gomock.Any()).Return(true, nil)
+
+			id, err := wa.Authenticate(ctx, visitor, assertionResp(a, challenge.Challenge))
+			assert.Equal(t, err, nil)
+			assert.Equal(t, id, userID)
+		})
+	})
+}
+
+func TestValidate(t *testing.T) {
+	Convey("Validate", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBWebAuthn(ctrl)
+		userMgnt := mock.NewMockDnUserManagement(ctrl)
+		audit := mock.NewMockLogicsAudit(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		wa := newWebAuthn(db, userMgnt, audit, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetInternalSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddInternalTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		visitor := &interfaces.Visitor{}
+		a := newTestAuthenticator()
+		// 作为双因子认证时只要求用户在场
+		a.flags = flagUserPresent
+		a.signCount = 1
+		challenge := &interfaces.WebAuthnChallenge{
+			ID:         "challenge1",
+			Challenge:  "Y2hhbGxlbmdl",
+			Type:       interfaces.WebAuthnAssertion,
+			CreateTime: common.Now().Unix(),
+		}
+		credential := &interfaces.WebAuthnCredential{
+			ID:        a.credentialIDStr(),
+			UserID:    userID,
+			PublicKey: a.publicKey,
+			Algorithm: algES256,
+		}
+
+		Convey("credential belongs to other user", func() {
+			db.EXPECT().ConsumeChallenge(gomock.Any(), "challenge1").Return(challenge, nil)
+			db.EXPECT().GetCredential(gomock.Any(), a.credentialIDStr()).Return(credential, nil)
+
+			err := wa.Validate(ctx, visitor, "other", assertionResp(a, challenge.Challenge))
+			assert.Equal(t, err.(*rest.HTTPError).Code, common.WebAuthnVerifyFailed)
+		})
+
+		Convey("success", func() {
+			db.EXPECT().ConsumeChallenge(gomock.Any(), "challenge1").Return(challenge, nil)
+			db.EXPECT().GetCredential(gomock.Any(), a.credentialIDStr()).Return(credential, nil)
+			db.EXPECT().UpdateSignCount(gomock.Any(), a.credentialIDStr(), uint32(0), uint32(1), gomock.Any()).Return(true, nil)
+
+			err := wa.Validate(ctx, visitor, userID, assertionResp(a, challenge.Challenge))
+			assert.Equal(t, err, nil)
+		})
+	})
+}
+
+func TestDeleteCredential(t *testing.T) {
+	Convey("DeleteCredential", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBWebAuthn(ctrl)
+		userMgnt := mock.NewMockDnUserManagement(ctrl)
+		audit := mock.NewMockLogicsAudit(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		wa := newWebAuthn(db, userMgnt, audit, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetInternalSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddInternalTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		visitor := &interfaces.Visitor{ID: userID, Type: interfaces.RealName}
+
+		Convey("credential of other user", func() {
+			db.EXPECT().GetCredential(gomock.Any(), "cred1").Return(&interfaces.WebAuthnCredential{ID: "cred1", UserID: "other"}, nil)
+
+			err := wa.DeleteCredential(ctx, visitor, userID, "cred1")
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.URINotExist)
+		})
+
+		Convey("other user, not admin", func() {
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any(), userID).Return([]interfaces.RoleType{interfaces.NormalUser}, nil)
+
+			err := wa.DeleteCredential(ctx, visitor, "other", "cred1")
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.Unauthorized)
+		})
+
+		Convey("success", func() {
+			db.EXPECT().GetCredential(gomock.Any(), "cred1").Return(&interfaces.WebAuthnCredential{ID: "cred1", UserID: userID}, nil)
+			db.EXPECT().DeleteCredential(gomock.Any(), userID, "cred1").Return(true, nil)
+			audit.EXPECT().Log(laudit.TopicManagementLog, gomock.Any()).Return(nil)
+
+			err := wa.DeleteCredential(ctx, visitor, userID, "cred1")
+			assert.Equal(t, err, nil)
+		})
+	})
+}
+
+func TestRevokeAll(t *testing.T) {
+	Convey("RevokeAll", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBWebAuthn(ctrl)
+		userMgnt := mock.NewMockDnUserManagement(ctrl)
+		audit := mock.NewMockLogicsAudit(ctrl)
+		trace := mock.NewMockTraceClient(ctrl)
+		wa := newWebAuthn(db, userMgnt, audit, trace)
+
+		ctx := context.Background()
+		trace.EXPECT().SetInternalSpanName(gomock.Any()).AnyTimes()
+		trace.EXPECT().AddInternalTrace(gomock.Any()).AnyTimes().Return(ctx, nil)
+		trace.EXPECT().TelemetrySpanEnd(gomock.Any(), gomock.Any()).AnyTimes()
+
+		visitor := &interfaces.Visitor{ID: "admin", Type: interfaces.RealName}
+
+		Convey("not admin", func() {
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any(), "admin").Return([]interfaces.RoleType{interfaces.NormalUser}, nil)
+
+			err := wa.RevokeAll(ctx, visitor, userID)
+			assert.Equal(t, err.(*rest.HTTPError).Code, rest.Unauthorized)
+		})
+
+		Convey("success", func() {
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any(), "admin").Return([]interfaces.RoleType{interfaces.SecurityAdmin}, nil)
+			userMgnt.EXPECT().GetUserInfo(gomock.Any(), gomock.Any(), userID).Return(&interfaces.UserBaseInfo{Account: "user1"}, nil)
+			db.EXPECT().DeleteCredentialsByUserID(gomock.Any(), userID).Return(nil)
+			audit.EXPECT().Log(laudit.TopicManagementLog, gomock.Any()).Return(nil)
+
+			err := wa.RevokeAll(ctx, visitor, userID)
+			assert.Equal(t, err, nil)
+		})
+	})
+}
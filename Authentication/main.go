@@ -16,11 +16,15 @@ import (
 	"Authentication/driveradapters/conf"
 	"Authentication/driveradapters/login"
 	mq "Authentication/driveradapters/mq_handler"
+	"Authentication/driveradapters/oidc"
 	"Authentication/driveradapters/probe"
 	"Authentication/driveradapters/register"
+	"Authentication/driveradapters/saml"
 	"Authentication/driveradapters/session"
 	"Authentication/driveradapters/sms"
 	"Authentication/driveradapters/ticket"
+	"Authentication/driveradapters/totp"
+	"Authentication/driveradapters/webauthn"
 	"Authentication/logics"
 	flowclean "Authentication/logics/flow_clean"
 )
@@ -39,6 +43,10 @@ type authentication struct {
 	smsHandler             sms.RESTHandler
 	ticketHandler          ticket.RESTHandler
 	auditHandler           audit.RESTHandler
+	totpHandler            totp.RESTHandler
+	webAuthnHandler        webauthn.RESTHandler
+	oidcHandler            oidc.RESTHandler
+	samlHandler            saml.RESTHandler
 }
 
 // Start 启动服务
@@ -70,6 +78,10 @@ func (a *authentication) Start() {
 		a.accessTokenPermHandler.RegisterPublic(engine)
 		a.smsHandler.RegisterPublic(engine)
 		a.ticketHandler.RegisterPublic(engine)
+		a.totpHandler.RegisterPublic(engine)
+		a.webAuthnHandler.RegisterPublic(engine)
+		a.oidcHandler.RegisterPublic(engine)
+		a.samlHandler.RegisterPublic(engine)
 
 		// 注册开放端口探针
 		a.probeHandler.RegisterPublic(engine)
@@ -138,6 +150,10 @@ func main() {
 	logics.SetDBTicket(dbaccess.NewTicket())
 	logics.SetDBFlowClean(dbaccess.NewFlowClean())
 	logics.SetDBUnorderedOutbox(dbaccess.NewUnorderedOutbox())
+	logics.SetDBTOTP(dbaccess.NewTOTP())
+	logics.SetDBWebAuthn(dbaccess.NewWebAuthn())
+	logics.SetDBOIDC(dbaccess.NewOIDC())
+	logics.SetDBSAML(dbaccess.NewSAML())
 
 	// drivenadapters 依赖注入
 	logics.SetDnHydraAdmin(drivenadapters.NewHydraAdmin())
@@ -164,6 +180,10 @@ func main() {
 		smsHandler:             sms.NewRESTHandler(),
 		ticketHandler:          ticket.NewRESTHandler(),
 		auditHandler:           audit.NewRESTHandler(),
+		totpHandler:            totp.NewRESTHandler(),
+		webAuthnHandler:        webauthn.NewRESTHandler(),
+		oidcHandler:            oidc.NewRESTHandler(),
+		samlHandler:            saml.NewRESTHandler(),
 	}
 
 	server.Start()
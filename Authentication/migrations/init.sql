@@ -110,3 +110,118 @@ CREATE TABLE IF NOT EXISTS t_outbox_unordered (
   KEY idx_f_created_at(f_created_at),
   KEY idx_f_updated_at(f_updated_at)
 )ENGINE = InnoDB COMMENT='无序outbox信息表';
+
+CREATE TABLE IF NOT EXISTS `t_totp` (
+    `f_user_id` char(40) NOT NULL COMMENT '用户唯一标识',
+    `f_secret` varchar(255) NOT NULL COMMENT '加密后的动态口令密钥',
+    `f_status` tinyint(4) NOT NULL COMMENT '绑定状态(1 待确认,2 已启用)',
+    `f_last_time_step` bigint(20) NOT NULL DEFAULT 0 COMMENT '最近一次校验通过的时间步长',
+    `f_fail_count` int(11) NOT NULL DEFAULT 0 COMMENT '连续校验失败次数',
+    `f_last_fail_time` bigint(20) NOT NULL DEFAULT 0 COMMENT '最近一次校验失败时间',
+    `f_create_time` bigint(20) NOT NULL COMMENT '创建时间',
+    `f_update_time` bigint(20) NOT NULL COMMENT '更新时间',
+    PRIMARY KEY (`f_user_id`)
+) ENGINE=InnoDB COMMENT='用户动态口令表';
+
+CREATE TABLE IF NOT EXISTS `t_totp_recovery_code` (
+    `f_primary_id` bigint(20) NOT NULL AUTO_INCREMENT COMMENT '自增主键',
+    `f_user_id` char(40) NOT NULL COMMENT '用户唯一标识',
+    `f_code_hash` char(64) NOT NULL COMMENT '恢复码SHA256摘要',
+    `f_create_time` bigint(20) NOT NULL COMMENT '创建时间',
+    PRIMARY KEY (`f_primary_id`),
+    UNIQUE KEY `uk_user_id_code_hash` (`f_user_id`, `f_code_hash`)
+) ENGINE=InnoDB COMMENT='动态口令恢复码表';
+
+CREATE TABLE IF NOT EXISTS `t_webauthn_credential` (
+    `f_primary_id` bigint(20) NOT NULL AUTO_INCREMENT COMMENT '自增主键',
+    `f_credential_id` varchar(512) NOT NULL COMMENT '凭据ID，base64url编码',
+    `f_user_id` char(40) NOT NULL COMMENT '用户唯一标识',
+    `f_name` varchar(128) NOT NULL COMMENT '凭据名称',
+    `f_public_key` blob NOT NULL COMMENT 'COSE格式公钥',
+    `f_algorithm` int(11) NOT NULL COMMENT 'COSE签名算法',
+    `f_sign_count` bigint(20) NOT NULL DEFAULT 0 COMMENT '签名计数器',
+    `f_aaguid` char(32) NOT NULL DEFAULT '' COMMENT '认证器型号标识',
+    `f_attestation_fmt` varchar(32) NOT NULL DEFAULT '' COMMENT '注册时的证明格式',
+    `f_create_time` bigint(20) NOT NULL COMMENT '创建时间',
+    `f_last_used_time` bigint(20) NOT NULL DEFAULT 0 COMMENT '最近一次使用时间',
+    PRIMARY KEY (`f_primary_id`),
+    UNIQUE KEY `uk_credential_id` (`f_credential_id`),
+    KEY `idx_user_id` (`f_user_id`)
+) ENGINE=InnoDB COMMENT='用户安全密钥表';
+
+CREATE TABLE IF NOT EXISTS `t_webauthn_challenge` (
+    `f_id` char(26) NOT NULL COMMENT '挑战唯一标识',
+    `f_challenge` varchar(64) NOT NULL COMMENT '挑战值，base64url编码',
+    `f_type` tinyint(4) NOT NULL COMMENT '仪式类型(1 注册,2 认证)',
+    `f_user_id` char(40) NOT NULL DEFAULT '' COMMENT '注册时为当前用户，认证时为空',
+    `f_create_time` bigint(20) NOT NULL COMMENT '创建时间',
+    PRIMARY KEY (`f_id`),
+    KEY `idx_create_time` (`f_create_time`)
+) ENGINE=InnoDB COMMENT='安全密钥挑战表';
+
+CREATE TABLE IF NOT EXISTS `t_oidc_provider` (
+    `f_id` char(26) NOT NULL COMMENT '身份提供方唯一标识',
+    `f_name` varchar(128) NOT NULL COMMENT '名称',
+    `f_issuer` varchar(512) NOT NULL COMMENT '颁发者',
+    `f_client_id` varchar(256) NOT NULL COMMENT '客户端ID',
+    `f_client_secret` varchar(512) NOT NULL DEFAULT '' COMMENT '客户端密钥',
+    `f_scopes` varchar(512) NOT NULL COMMENT '权限范围，json数组',
+    `f_redirect_uri` varchar(512) NOT NULL COMMENT '授权回调地址',
+    `f_mapping_rules` text NOT NULL COMMENT '账户映射规则，json数组',
+    `f_jit_provision` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否自动创建账户(0 否,1 是)',
+    `f_enabled` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否启用(0 否,1 是)',
+    `f_create_time` bigint(20) NOT NULL COMMENT '创建时间',
+    `f_update_time` bigint(20) NOT NULL COMMENT '更新时间',
+    PRIMARY KEY (`f_id`)
+) ENGINE=InnoDB COMMENT='OIDC身份提供方表';
+
+CREATE TABLE IF NOT EXISTS `t_oidc_state` (
+    `f_state` char(43) NOT NULL COMMENT '状态值',
+    `f_provider_id` char(26) NOT NULL COMMENT '身份提供方唯一标识',
+    `f_nonce` char(43) NOT NULL COMMENT 'ID令牌防重放随机值',
+    `f_code_verifier` char(43) NOT NULL COMMENT 'PKCE校验值',
+    `f_create_time` bigint(20) NOT NULL COMMENT '创建时间',
+    PRIMARY KEY (`f_state`),
+    KEY `idx_create_time` (`f_create_time`)
+) ENGINE=InnoDB COMMENT='OIDC授权请求状态表';
+
+CREATE TABLE IF NOT EXISTS `t_saml_provider` (
+    `f_id` char(26) NOT NULL COMMENT '身份提供方唯一标识',
+    `f_name` varchar(128) NOT NULL COMMENT '名称',
+    `f_entity_id` varchar(255) NOT NULL COMMENT '身份提供方标识',
+    `f_metadata` mediumtext NOT NULL COMMENT '身份提供方元数据',
+    `f_mapping_rules` text NOT NULL COMMENT '账户映射规则，json数组',
+    `f_name_attribute` varchar(255) NOT NULL DEFAULT '' COMMENT '自动创建账户时作为显示名的属性',
+    `f_jit_provision` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否自动创建账户(0 否,1 是)',
+    `f_enabled` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否启用(0 否,1 是)',
+    `f_create_time` bigint(20) NOT NULL COMMENT '创建时间',
+    `f_update_time` bigint(20) NOT NULL COMMENT '更新时间',
+    PRIMARY KEY (`f_id`),
+    UNIQUE KEY `uk_entity_id` (`f_entity_id`)
+) ENGINE=InnoDB COMMENT='SAML身份提供方表';
+
+CREATE TABLE IF NOT EXISTS `t_saml_state` (
+    `f_state` char(43) NOT NULL COMMENT '状态值',
+    `f_provider_id` char(26) NOT NULL COMMENT '身份提供方唯一标识',
+    `f_request_id` char(41) NOT NULL COMMENT 'AuthnRequest的ID',
+    `f_user_id` char(40) NOT NULL DEFAULT '' COMMENT '断言校验通过后映射的用户，为空表示尚未完成断言校验',
+    `f_create_time` bigint(20) NOT NULL COMMENT '创建时间',
+    PRIMARY KEY (`f_state`),
+    KEY `idx_create_time` (`f_create_time`)
+) ENGINE=InnoDB COMMENT='SAML认证请求状态表';
+
+CREATE TABLE IF NOT EXISTS `t_saml_session` (
+    `f_id` bigint(20) NOT NULL AUTO_INCREMENT COMMENT '自增ID',
+    `f_user_id` char(40) NOT NULL COMMENT '用户唯一标识',
+    `f_provider_id` char(26) NOT NULL COMMENT '身份提供方唯一标识',
+    `f_name_id` varchar(255) NOT NULL COMMENT '断言主体',
+    `f_name_id_format` varchar(255) NOT NULL DEFAULT '' COMMENT '断言主体格式',
+    `f_name_qualifier` varchar(255) NOT NULL DEFAULT '' COMMENT '断言主体的身份提供方限定名',
+    `f_sp_name_qualifier` varchar(255) NOT NULL DEFAULT '' COMMENT '断言主体的服务提供方限定名',
+    `f_session_index` varchar(255) NOT NULL DEFAULT '' COMMENT '身份提供方会话索引',
+    `f_create_time` bigint(20) NOT NULL COMMENT '创建时间',
+    PRIMARY KEY (`f_id`),
+    KEY `idx_user_id` (`f_user_id`),
+    KEY `idx_provider_name_id` (`f_provider_id`, `f_name_id`),
+    KEY `idx_create_time` (`f_create_time`)
+) ENGINE=InnoDB COMMENT='SAML登录会话表';
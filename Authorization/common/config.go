@@ -31,6 +31,8 @@ type Config struct {
 
 	Redis RedisConfig `yaml:"redis"`
 
+	PolicyCalcCache PolicyCalcCacheConfig `yaml:"policy_calc_cache"`
+
 	// 以下从环境变量中获取
 
 	MQConfigFilePath string
@@ -48,6 +50,13 @@ type RedisConfig struct {
 	KeyName     string           `yaml:"keyName"`    // 当 enableSSL 为 true 时需要，表示secret里 key 密钥的名字
 }
 
+// PolicyCalcCacheConfig 策略计算缓存配置
+type PolicyCalcCacheConfig struct {
+	Disabled   bool `yaml:"disabled"`    // 是否关闭缓存
+	TTLSeconds int  `yaml:"ttl_seconds"` // 缓存有效期, 未配置时默认 10 秒, 也是多实例下授权变更生效的最长延迟
+	MaxEntries int  `yaml:"max_entries"` // 访问令牌缓存、策略缓存各自的最大条目数, 未配置时默认 100000
+}
+
 // RedisConnectInfo 配置信息
 type RedisConnectInfo struct {
 	Username         string `yaml:"username"`
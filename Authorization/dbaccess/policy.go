@@ -291,6 +291,47 @@ func (d *policy) GetByPolicyIDs(ctx context.Context, policyIDs []string) (polici
 	return policies, nil
 }
 
+// GetByResourceType 获取资源类型下的所有策略
+func (d *policy) GetByResourceType(ctx context.Context, resourceType string) (policies []interfaces.PolicyInfo, err error) {
+	strSQL := "select f_id, f_resource_id, f_resource_type, f_resource_name, f_accessor_id, f_accessor_type, f_accessor_name, f_operation, f_condition, f_end_time, f_create_time from " +
+		common.GetDBName(databaseName) + ".t_policy where f_resource_type = ? order by f_primary_id"
+	rows, err := d.db.Query(strSQL, resourceType)
+	if err != nil {
+		d.logger.Errorf("GetByResourceType sql: %s, err: %v", strSQL, err)
+		return nil, err
+	}
+	defer func() {
+		if rows != nil {
+			if rowsErr := rows.Err(); rowsErr != nil {
+				d.logger.Errorln(rowsErr)
+			}
+		}
+		if closeErr := rows.Close(); closeErr != nil {
+			d.logger.Errorln(closeErr)
+		}
+	}()
+
+	policies = make([]interfaces.PolicyInfo, 0)
+	for rows.Next() {
+		var policy interfaces.PolicyInfo
+		var operationStr string
+		err := rows.Scan(&policy.ID, &policy.ResourceID, &policy.ResourceType,
+			&policy.ResourceName, &policy.AccessorID, &policy.AccessorType, &policy.AccessorName,
+			&operationStr, &policy.Condition, &policy.EndTime, &policy.CreateTime)
+		if err != nil {
+			d.logger.Errorf("GetByResourceType sql: %s, err: %v", strSQL, err)
+			return nil, err
+		}
+		policy.Operation, err = d.operationStrToInfo(operationStr)
+		if err != nil {
+			d.logger.Errorf("GetByResourceType sql: %s, err: %v", strSQL, err)
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
 // DeleteByResourceIDs 删除策略 根据资源id删除策略
 func (d *policy) DeleteByResourceIDs(ctx context.Context, resources []interfaces.PolicyDeleteResourceInfo) error {
 	if len(resources) == 0 {
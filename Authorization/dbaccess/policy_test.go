@@ -361,6 +361,72 @@ func TestDBGetByResourceIDs(t *testing.T) {
 	})
 }
 
+func TestDBGetByResourceType(t *testing.T) {
+	Convey("TestDBGetByResourceType", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		defer func(db *sqlx.DB) {
+			err := db.Close()
+			if err != nil {
+				return
+			}
+		}(db)
+
+		mockErr := errors.New("test error")
+		ctx := context.Background()
+
+		b := &policy{
+			db:     db,
+			logger: common.NewLogger(),
+		}
+
+		columns := []string{
+			"f_id", "f_resource_id", "f_resource_type", "f_resource_name",
+			"f_accessor_id", "f_accessor_type", "f_accessor_name", "f_operation", "f_condition", "f_end_time", "f_create_time",
+		}
+
+		Convey("query error", func() {
+			mock.ExpectQuery("^select.*f_id.*f_resource_type = \\?").WillReturnError(mockErr)
+			policies, err := b.GetByResourceType(ctx, "type-1")
+			assert.Equal(t, err, mockErr)
+			assert.Equal(t, len(policies), 0)
+			assert.Equal(t, mock.ExpectationsWereMet(), nil)
+		})
+
+		Convey("json unmarshal error", func() {
+			mock.ExpectQuery("^select.*f_id.*f_resource_type = \\?").WillReturnRows(
+				sqlmock.NewRows(columns).
+					AddRow("policy-1", "resource-1", "type-1", "name-1", "accessor-1", interfaces.AccessorUser,
+						"accessor-name-1", invalidJSON, "", -1, 1234567890),
+			)
+			_, err := b.GetByResourceType(ctx, "type-1")
+			assert.NotEqual(t, err, nil)
+			assert.Equal(t, mock.ExpectationsWereMet(), nil)
+		})
+
+		Convey("Success", func() {
+			operationJSON, _ := b.operationInfoToString(interfaces.PolicyOperation{
+				Allow: []interfaces.PolicyOperationItem{{ID: "op1"}},
+			})
+			mock.ExpectQuery("^select.*f_id.*f_resource_type = \\?").WithArgs("type-1").WillReturnRows(
+				sqlmock.NewRows(columns).
+					AddRow("policy-1", "resource-1", "type-1", "name-1", "accessor-1", interfaces.AccessorUser,
+						"accessor-name-1", operationJSON, "", -1, 1234567890).
+					AddRow("policy-2", "*", "type-1", "name-2", "accessor-2", interfaces.AccessorRole,
+						"accessor-name-2", operationJSON, "", -1, 1234567890),
+			)
+			policies, err := b.GetByResourceType(ctx, "type-1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(policies), 2)
+			assert.Equal(t, policies[1].ResourceID, "*")
+			assert.Equal(t, policies[1].Operation.Allow[0].ID, "op1")
+			assert.Equal(t, mock.ExpectationsWereMet(), nil)
+		})
+	})
+}
+
 func TestDBGetByPolicyIDs(t *testing.T) {
 	Convey("TestDBGetByPolicyIDs", t, func() {
 		ctrl := gomock.NewController(t)
@@ -0,0 +1,204 @@
+// Package dbaccess 数据访问层 - 互斥角色集
+package dbaccess
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
+
+	"Authorization/common"
+	"Authorization/interfaces"
+)
+
+type roleExclusion struct {
+	db     *sqlx.DB
+	logger common.Logger
+}
+
+var (
+	roleExclusionOnce sync.Once
+	roleExclusionDB   *roleExclusion
+)
+
+// NewRoleExclusion 创建数据库操作对象--和互斥角色集相关
+func NewRoleExclusion() *roleExclusion {
+	roleExclusionOnce.Do(func() {
+		roleExclusionDB = &roleExclusion{
+			db:     dbPool,
+			logger: common.NewLogger(),
+		}
+	})
+	return roleExclusionDB
+}
+
+// Add 添加互斥角色集
+func (r *roleExclusion) Add(ctx context.Context, info *interfaces.RoleExclusionInfo) (err error) {
+	curTime := common.GetCurrentMicrosecondTimestamp()
+	roleIDsByte, err := json.Marshal(info.RoleIDs)
+	if err != nil {
+		r.logger.Errorln(err)
+		return err
+	}
+	strSQL := "insert into " + common.GetDBName(databaseName) +
+		".t_role_exclusion(f_id, f_name, f_description, f_role_ids, f_created_at, f_modified_at) values(?,?,?,?,?,?)"
+	_, err = r.db.Exec(strSQL, info.ID, info.Name, info.Description, string(roleIDsByte), curTime, curTime)
+	if err != nil {
+		r.logger.Errorf("Add sql: %s, err: %v", strSQL, err)
+		return err
+	}
+	return nil
+}
+
+// Update 更新互斥角色集
+func (r *roleExclusion) Update(ctx context.Context, id, name string, nameChanged bool, description string, descriptionChanged bool,
+	roleIDs []string, roleIDsChanged bool,
+) (err error) {
+	var args []any
+	sqlStr := fmt.Sprintf("update %s.t_role_exclusion set ", common.GetDBName(databaseName))
+
+	if nameChanged {
+		args = append(args, name)
+		sqlStr += "f_name = ?, "
+	}
+
+	if descriptionChanged {
+		args = append(args, description)
+		sqlStr += "f_description = ?, "
+	}
+
+	if roleIDsChanged {
+		roleIDsByte, err := json.Marshal(roleIDs)
+		if err != nil {
+			r.logger.Errorln(err)
+			return err
+		}
+		args = append(args, string(roleIDsByte))
+		sqlStr += "f_role_ids = ?, "
+	}
+
+	curTime := common.GetCurrentMicrosecondTimestamp()
+	sqlStr += "f_modified_at = ? where f_id = ?"
+	args = append(args, curTime, id)
+
+	if _, err := r.db.Exec(sqlStr, args...); err != nil {
+		r.logger.Errorf("Update sql: %s, args: %v, err: %v", sqlStr, args, err)
+		return err
+	}
+	return nil
+}
+
+// Delete 删除互斥角色集
+func (r *roleExclusion) Delete(ctx context.Context, id string) (err error) {
+	strSQL := "delete from " + common.GetDBName(databaseName) + ".t_role_exclusion where f_id = ?"
+	_, err = r.db.Exec(strSQL, id)
+	if err != nil {
+		r.logger.Errorf("Delete sql: %s, err: %v", strSQL, err)
+		return err
+	}
+	return nil
+}
+
+// GetByID 指定ID获取互斥角色集
+func (r *roleExclusion) GetByID(ctx context.Context, id string) (info interfaces.RoleExclusionInfo, err error) {
+	strSQL := "select f_id, f_name, f_description, f_role_ids, f_created_at, f_modified_at from " + common.GetDBName(databaseName) +
+		".t_role_exclusion where f_id = ?"
+	infos, err := r.query(strSQL, id)
+	if err != nil || len(infos) == 0 {
+		return info, err
+	}
+	return infos[0], nil
+}
+
+// GetByName 指定名称获取互斥角色集
+func (r *roleExclusion) GetByName(ctx context.Context, name string) (info interfaces.RoleExclusionInfo, err error) {
+	strSQL := "select f_id, f_name, f_description, f_role_ids, f_created_at, f_modified_at from " + common.GetDBName(databaseName) +
+		".t_role_exclusion where f_name = ?"
+	infos, err := r.query(strSQL, name)
+	if err != nil || len(infos) == 0 {
+		return info, err
+	}
+	return infos[0], nil
+}
+
+// Get 分页获取互斥角色集
+func (r *roleExclusion) Get(ctx context.Context, info *interfaces.RoleExclusionSearchInfo) (count int, infos []interfaces.RoleExclusionInfo, err error) {
+	var countRows *sql.Rows
+	countSQL := "select count(1) from " + common.GetDBName(databaseName) + ".t_role_exclusion"
+	countRows, err = r.db.Query(countSQL)
+	if err != nil {
+		r.logger.Errorf("Get sql: %s, err: %v", countSQL, err)
+		return 0, nil, err
+	}
+	defer func() {
+		if countRowsErr := countRows.Err(); countRowsErr != nil {
+			r.logger.Errorln(countRowsErr)
+		}
+		if closeErr := countRows.Close(); closeErr != nil {
+			r.logger.Errorln(closeErr)
+		}
+	}()
+	for countRows.Next() {
+		err = countRows.Scan(&count)
+		if err != nil {
+			r.logger.Errorln(err, countSQL)
+			return 0, nil, err
+		}
+	}
+
+	strSQL := "select f_id, f_name, f_description, f_role_ids, f_created_at, f_modified_at from " + common.GetDBName(databaseName) +
+		".t_role_exclusion order by f_created_at desc, f_primary_id desc limit ? offset ?"
+	infos, err = r.query(strSQL, info.Limit, info.Offset)
+	if err != nil {
+		return 0, nil, err
+	}
+	return count, infos, nil
+}
+
+// GetAll 获取所有互斥角色集
+func (r *roleExclusion) GetAll(ctx context.Context) (infos []interfaces.RoleExclusionInfo, err error) {
+	strSQL := "select f_id, f_name, f_description, f_role_ids, f_created_at, f_modified_at from " + common.GetDBName(databaseName) +
+		".t_role_exclusion"
+	return r.query(strSQL)
+}
+
+func (r *roleExclusion) query(strSQL string, args ...any) (infos []interfaces.RoleExclusionInfo, err error) {
+	rows, err := r.db.Query(strSQL, args...)
+	if err != nil {
+		r.logger.Errorf("sql: %s, err: %v", strSQL, err)
+		return nil, err
+	}
+	defer func() {
+		if rows != nil {
+			if rowsErr := rows.Err(); rowsErr != nil {
+				r.logger.Errorln(rowsErr)
+			}
+
+			// 1、判断是否为空再关闭，2、如果不关闭而数据行并没有被scan的话，连接一直会被占用直到超时断开
+			if closeErr := rows.Close(); closeErr != nil {
+				r.logger.Errorln(closeErr)
+			}
+		}
+	}()
+
+	infos = make([]interfaces.RoleExclusionInfo, 0)
+	for rows.Next() {
+		var info interfaces.RoleExclusionInfo
+		var roleIDsStr string
+		err = rows.Scan(&info.ID, &info.Name, &info.Description, &roleIDsStr, &info.CreateTime, &info.ModifyTime)
+		if err != nil {
+			r.logger.Errorln(err, strSQL)
+			return nil, err
+		}
+		err = json.Unmarshal([]byte(roleIDsStr), &info.RoleIDs)
+		if err != nil {
+			r.logger.Errorln(err, strSQL)
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
@@ -0,0 +1,127 @@
+package dbaccess
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-playground/assert"
+	"github.com/kweaver-ai/proton-rds-sdk-go/sqlx"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"Authorization/common"
+	"Authorization/interfaces"
+)
+
+func newRoleExclusionForTest(t *testing.T) (*roleExclusion, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlx.New()
+	assert.Equal(t, err, nil)
+	r := &roleExclusion{
+		db:     db,
+		logger: common.NewLogger(),
+	}
+	return r, mock, func() { _ = db.Close() }
+}
+
+func TestRoleExclusion_Add(t *testing.T) {
+	Convey("TestRoleExclusion_Add", t, func() {
+		r, mock, closeDB := newRoleExclusionForTest(t)
+		defer closeDB()
+
+		ctx := context.Background()
+		info := &interfaces.RoleExclusionInfo{ID: "ex1", Name: "name1", RoleIDs: []string{"role1", "role2"}}
+
+		Convey("Add success", func() {
+			mock.ExpectExec("^insert into").WithArgs("ex1", "name1", "", `["role1","role2"]`, sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			err := r.Add(ctx, info)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, mock.ExpectationsWereMet(), nil)
+		})
+
+		Convey("Add error", func() {
+			mockErr := errors.New("test error")
+			mock.ExpectExec("^insert into").WillReturnError(mockErr)
+			err := r.Add(ctx, info)
+			assert.Equal(t, err, mockErr)
+		})
+	})
+}
+
+func TestRoleExclusion_Update(t *testing.T) {
+	Convey("TestRoleExclusion_Update", t, func() {
+		r, mock, closeDB := newRoleExclusionForTest(t)
+		defer closeDB()
+
+		ctx := context.Background()
+
+		Convey("只更新角色", func() {
+			mock.ExpectExec("set f_role_ids = \\?, f_modified_at = \\? where f_id = \\?").
+				WithArgs(`["role1","role3"]`, sqlmock.AnyArg(), "ex1").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			err := r.Update(ctx, "ex1", "", false, "", false, []string{"role1", "role3"}, true)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, mock.ExpectationsWereMet(), nil)
+		})
+
+		Convey("更新全部字段", func() {
+			mock.ExpectExec("set f_name = \\?, f_description = \\?, f_role_ids = \\?, f_modified_at = \\? where f_id = \\?").
+				WithArgs("name2", "desc", `["role1","role2"]`, sqlmock.AnyArg(), "ex1").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			err := r.Update(ctx, "ex1", "name2", true, "desc", true, []string{"role1", "role2"}, true)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, mock.ExpectationsWereMet(), nil)
+		})
+	})
+}
+
+func TestRoleExclusion_Get(t *testing.T) {
+	Convey("TestRoleExclusion_Get", t, func() {
+		r, mock, closeDB := newRoleExclusionForTest(t)
+		defer closeDB()
+
+		ctx := context.Background()
+		columns := []string{"f_id", "f_name", "f_description", "f_role_ids", "f_created_at", "f_modified_at"}
+
+		Convey("GetByID 不存在", func() {
+			mock.ExpectQuery("where f_id = \\?").WithArgs("ex1").WillReturnRows(sqlmock.NewRows(columns))
+			info, err := r.GetByID(ctx, "ex1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, info.ID, "")
+		})
+
+		Convey("GetByName 成功", func() {
+			mock.ExpectQuery("where f_name = \\?").WithArgs("name1").
+				WillReturnRows(sqlmock.NewRows(columns).AddRow("ex1", "name1", "", `["role1","role2"]`, 1, 2))
+			info, err := r.GetByName(ctx, "name1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, info.ID, "ex1")
+			assert.Equal(t, info.RoleIDs, []string{"role1", "role2"})
+		})
+
+		Convey("分页获取", func() {
+			mock.ExpectQuery("select count\\(1\\)").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+			mock.ExpectQuery("limit \\? offset \\?").WithArgs(1, 2).
+				WillReturnRows(sqlmock.NewRows(columns).AddRow("ex3", "name3", "desc", `["role1","role2"]`, 1, 2))
+			count, infos, err := r.Get(ctx, &interfaces.RoleExclusionSearchInfo{Offset: 2, Limit: 1})
+			assert.Equal(t, err, nil)
+			assert.Equal(t, count, 3)
+			assert.Equal(t, len(infos), 1)
+			assert.Equal(t, infos[0].Description, "desc")
+		})
+
+		Convey("GetAll 角色数据格式错误", func() {
+			mock.ExpectQuery("^select").WillReturnRows(sqlmock.NewRows(columns).AddRow("ex1", "name1", "", "bad", 1, 2))
+			_, err := r.GetAll(ctx)
+			assert.NotEqual(t, err, nil)
+		})
+
+		Convey("Delete 成功", func() {
+			mock.ExpectExec("^delete from").WithArgs("ex1").WillReturnResult(sqlmock.NewResult(0, 1))
+			err := r.Delete(ctx, "ex1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, mock.ExpectationsWereMet(), nil)
+		})
+	})
+}
@@ -3,6 +3,7 @@ package dbaccess
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strings"
 	"sync"
@@ -57,13 +58,13 @@ func (r *roleMember) AddRoleMembers(ctx context.Context, id string, infos []inte
 	var inserts []any
 	// 批量插入
 	for i := range infos {
-		valuesStr = append(valuesStr, "(?, ?, ?, ?, ?, ?)")
-		inserts = append(inserts, id, infos[i].ID, infos[i].MemberType, infos[i].Name, currentTime, currentTime)
+		valuesStr = append(valuesStr, "(?, ?, ?, ?, ?, ?, ?, ?)")
+		inserts = append(inserts, id, infos[i].ID, infos[i].MemberType, infos[i].Name, infos[i].StartTime, infos[i].EndTime, currentTime, currentTime)
 	}
 	valueStr := strings.Join(valuesStr, ",")
 
 	strSQL := "insert into " + common.GetDBName(databaseName) +
-		".t_role_member(f_role_id, f_member_id, f_member_type, f_member_name, f_created_time, f_modify_time) values " + valueStr
+		".t_role_member(f_role_id, f_member_id, f_member_type, f_member_name, f_start_time, f_end_time, f_created_time, f_modify_time) values " + valueStr
 
 	_, err = r.db.Exec(strSQL, inserts...)
 	if err != nil {
@@ -164,7 +165,7 @@ func (r *roleMember) GetPaginationByRoleID(ctx context.Context, id string, info
 		fliterStr += "and f_member_name like ?"
 		args = append(args, "%"+info.Keyword+"%")
 	}
-	strSQL := `select f_member_id, f_member_type, f_member_name from %s.t_role_member where f_role_id = ? ` + fliterStr + ` order by f_modify_time desc, f_primary_id desc LIMIT ?, ?`
+	strSQL := `select f_member_id, f_member_type, f_member_name, f_start_time, f_end_time from %s.t_role_member where f_role_id = ? ` + fliterStr + ` order by f_modify_time desc, f_primary_id desc LIMIT ?, ?`
 	strSQL = fmt.Sprintf(strSQL, dbName)
 	args = append(args, info.Offset, info.Limit)
 
@@ -190,7 +191,7 @@ func (r *roleMember) GetPaginationByRoleID(ctx context.Context, id string, info
 	outInfo = make([]interfaces.RoleMemberInfo, 0)
 	for rows.Next() {
 		var tmpInfo interfaces.RoleMemberInfo
-		if scanErr := rows.Scan(&tmpInfo.ID, &tmpInfo.MemberType, &tmpInfo.Name); scanErr != nil {
+		if scanErr := rows.Scan(&tmpInfo.ID, &tmpInfo.MemberType, &tmpInfo.Name, &tmpInfo.StartTime, &tmpInfo.EndTime); scanErr != nil {
 			r.logger.Errorln(scanErr, strSQL)
 			return nil, scanErr
 		}
@@ -204,7 +205,7 @@ func (r *roleMember) GetPaginationByRoleID(ctx context.Context, id string, info
 func (r *roleMember) GetRoleMembersByRoleID(ctx context.Context, id string) (outInfo []interfaces.RoleMemberInfo, err error) {
 	var args []any
 	dbName := common.GetDBName(databaseName)
-	strSQL := `select f_member_id, f_member_type, f_member_name from %s.t_role_member where f_role_id = ? `
+	strSQL := `select f_member_id, f_member_type, f_member_name, f_start_time, f_end_time from %s.t_role_member where f_role_id = ? `
 	strSQL = fmt.Sprintf(strSQL, dbName)
 	args = append(args, id)
 
@@ -230,7 +231,7 @@ func (r *roleMember) GetRoleMembersByRoleID(ctx context.Context, id string) (out
 	outInfo = make([]interfaces.RoleMemberInfo, 0)
 	for rows.Next() {
 		var tmpInfo interfaces.RoleMemberInfo
-		if scanErr := rows.Scan(&tmpInfo.ID, &tmpInfo.MemberType, &tmpInfo.Name); scanErr != nil {
+		if scanErr := rows.Scan(&tmpInfo.ID, &tmpInfo.MemberType, &tmpInfo.Name, &tmpInfo.StartTime, &tmpInfo.EndTime); scanErr != nil {
 			r.logger.Errorln(scanErr, strSQL)
 			return nil, scanErr
 		}
@@ -240,13 +241,17 @@ func (r *roleMember) GetRoleMembersByRoleID(ctx context.Context, id string) (out
 	return outInfo, nil
 }
 
-// GetRoleByMembers 通过成员获取角色
-func (r *roleMember) GetRoleByMembers(ctx context.Context, memberIDs []string) (outInfo []interfaces.RoleInfo, err error) {
+// GetRoleByMembers 通过成员获取角色, curTime 大于 0 时只返回 curTime 生效中的成员关系
+func (r *roleMember) GetRoleByMembers(ctx context.Context, memberIDs []string, curTime int64) (outInfo []interfaces.RoleInfo, err error) {
 	memberSet, memberIDGroup := getFindInSetSQL(memberIDs)
 	var paramList []any
 	paramList = append(paramList, memberIDGroup...)
 	dbName := common.GetDBName(databaseName)
 	strSQL := "select f_role_id from %s.t_role_member where f_member_id in (" + memberSet + ")"
+	if curTime > 0 {
+		strSQL += " and f_start_time <= ? and (f_end_time = -1 or f_end_time >= ?)"
+		paramList = append(paramList, curTime, curTime)
+	}
 	strSQL = fmt.Sprintf(strSQL, dbName)
 	rows, err := r.db.Query(strSQL, paramList...)
 	if err != nil {
@@ -304,6 +309,78 @@ func (r *roleMember) DeleteByRoleID(ctx context.Context, roleID string) error {
 	return nil
 }
 
+// DeleteExpiredMembers 删除过期的角色成员, 返回删除的成员, key 为角色ID
+// 在同一事务中锁定并删除查询到的记录, 保证返回的成员与删除的成员一致
+func (r *roleMember) DeleteExpiredMembers(ctx context.Context, curTime int64) (outInfo map[string][]interfaces.RoleMemberInfo, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		r.logger.Errorf("DeleteExpiredMembers begin transaction err: %v", err)
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				r.logger.Errorf("DeleteExpiredMembers rollback err: %v", rollbackErr)
+			}
+			return
+		}
+		if err = tx.Commit(); err != nil {
+			r.logger.Errorf("DeleteExpiredMembers commit err: %v", err)
+			outInfo = nil
+		}
+	}()
+
+	outInfo, primaryIDs, err := r.lockExpiredMembers(tx, curTime)
+	if err != nil {
+		return nil, err
+	}
+	if len(primaryIDs) == 0 {
+		return outInfo, nil
+	}
+
+	strSQL := "delete from " + common.GetDBName(databaseName) + ".t_role_member where f_primary_id in (" +
+		strings.TrimSuffix(strings.Repeat("?,", len(primaryIDs)), ",") + ")"
+	if _, err = tx.Exec(strSQL, primaryIDs...); err != nil {
+		r.logger.Errorf("DeleteExpiredMembers sql: %s, err: %v", strSQL, err)
+		return nil, err
+	}
+	return outInfo, nil
+}
+
+// lockExpiredMembers 查询并锁定过期的角色成员, 同时返回记录主键
+func (r *roleMember) lockExpiredMembers(tx *sql.Tx, curTime int64) (outInfo map[string][]interfaces.RoleMemberInfo, primaryIDs []any, err error) {
+	strSQL := "select f_primary_id, f_role_id, f_member_id, f_member_type, f_member_name, f_start_time, f_end_time from " + common.GetDBName(databaseName) +
+		".t_role_member where f_end_time < ? and f_end_time != -1 for update"
+	rows, err := tx.Query(strSQL, curTime)
+	if err != nil {
+		r.logger.Errorf("lockExpiredMembers sql: %s, err: %v", strSQL, err)
+		return nil, nil, err
+	}
+	defer func() {
+		if rowsErr := rows.Err(); rowsErr != nil {
+			r.logger.Errorln(rowsErr)
+		}
+		// 事务中需要先关闭结果集, 才能在同一连接上继续执行语句
+		if closeErr := rows.Close(); closeErr != nil {
+			r.logger.Errorln(closeErr)
+		}
+	}()
+
+	outInfo = make(map[string][]interfaces.RoleMemberInfo)
+	for rows.Next() {
+		var primaryID int64
+		var roleID string
+		var tmpInfo interfaces.RoleMemberInfo
+		if scanErr := rows.Scan(&primaryID, &roleID, &tmpInfo.ID, &tmpInfo.MemberType, &tmpInfo.Name, &tmpInfo.StartTime, &tmpInfo.EndTime); scanErr != nil {
+			r.logger.Errorln(scanErr, strSQL)
+			return nil, nil, scanErr
+		}
+		primaryIDs = append(primaryIDs, primaryID)
+		outInfo[roleID] = append(outInfo[roleID], tmpInfo)
+	}
+	return outInfo, primaryIDs, nil
+}
+
 func (r *roleMember) UpdateMemberName(memberID, name string) error {
 	strSQL := "update " + common.GetDBName(databaseName) + ".t_role_member set f_member_name = ? where f_member_id = ?"
 	_, err := r.db.Exec(strSQL, name, memberID)
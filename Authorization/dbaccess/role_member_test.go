@@ -241,8 +241,8 @@ func TestDBGetPaginationByRoleID(t *testing.T) {
 		})
 
 		Convey("scan error", func() {
-			rows := sqlmock.NewRows([]string{"f_member_id", "f_member_type", "f_member_name"}).
-				AddRow("test-member-id", "invalid-type", "test-member-name")
+			rows := sqlmock.NewRows([]string{"f_member_id", "f_member_type", "f_member_name", "f_start_time", "f_end_time"}).
+				AddRow("test-member-id", "invalid-type", "test-member-name", 0, -1)
 			mock.ExpectQuery("^select").WillReturnRows(rows)
 			members, err := b.GetPaginationByRoleID(ctx, "test-role-id", searchInfo)
 			assert.NotEqual(t, err, nil)
@@ -251,9 +251,9 @@ func TestDBGetPaginationByRoleID(t *testing.T) {
 		})
 
 		Convey("Success", func() {
-			rows := sqlmock.NewRows([]string{"f_member_id", "f_member_type", "f_member_name"}).
-				AddRow("test-member-1", interfaces.AccessorUser, "test-member-1-name").
-				AddRow("test-member-2", interfaces.AccessorDepartment, "test-member-2-name")
+			rows := sqlmock.NewRows([]string{"f_member_id", "f_member_type", "f_member_name", "f_start_time", "f_end_time"}).
+				AddRow("test-member-1", interfaces.AccessorUser, "test-member-1-name", 0, -1).
+				AddRow("test-member-2", interfaces.AccessorDepartment, "test-member-2-name", 100, 200)
 			mock.ExpectQuery("^select").WillReturnRows(rows)
 			members, err := b.GetPaginationByRoleID(ctx, "test-role-id", searchInfo)
 			assert.Equal(t, err, nil)
@@ -264,6 +264,8 @@ func TestDBGetPaginationByRoleID(t *testing.T) {
 			assert.Equal(t, members[1].ID, "test-member-2")
 			assert.Equal(t, members[1].MemberType, interfaces.AccessorDepartment)
 			assert.Equal(t, members[1].Name, "test-member-2-name")
+			assert.Equal(t, members[1].StartTime, int64(100))
+			assert.Equal(t, members[1].EndTime, int64(200))
 			assert.Equal(t, mock.ExpectationsWereMet(), nil)
 		})
 	})
@@ -299,8 +301,8 @@ func TestDBGetRoleMembersByRoleID(t *testing.T) {
 		})
 
 		Convey("scan error", func() {
-			rows := sqlmock.NewRows([]string{"f_member_id", "f_member_type", "f_member_name"}).
-				AddRow("test-member-id", "invalid-type", "test-member-name")
+			rows := sqlmock.NewRows([]string{"f_member_id", "f_member_type", "f_member_name", "f_start_time", "f_end_time"}).
+				AddRow("test-member-id", "invalid-type", "test-member-name", 0, -1)
 			mock.ExpectQuery("^select").WillReturnRows(rows)
 			members, err := b.GetRoleMembersByRoleID(ctx, "test-role-id")
 			assert.NotEqual(t, err, nil)
@@ -309,9 +311,9 @@ func TestDBGetRoleMembersByRoleID(t *testing.T) {
 		})
 
 		Convey("Success", func() {
-			rows := sqlmock.NewRows([]string{"f_member_id", "f_member_type", "f_member_name"}).
-				AddRow("test-member-1", interfaces.AccessorUser, "test-member-1-name").
-				AddRow("test-member-2", interfaces.AccessorDepartment, "test-member-2-name")
+			rows := sqlmock.NewRows([]string{"f_member_id", "f_member_type", "f_member_name", "f_start_time", "f_end_time"}).
+				AddRow("test-member-1", interfaces.AccessorUser, "test-member-1-name", 0, -1).
+				AddRow("test-member-2", interfaces.AccessorDepartment, "test-member-2-name", 100, 200)
 			mock.ExpectQuery("^select").WillReturnRows(rows)
 			members, err := b.GetRoleMembersByRoleID(ctx, "test-role-id")
 			assert.Equal(t, err, nil)
@@ -322,6 +324,8 @@ func TestDBGetRoleMembersByRoleID(t *testing.T) {
 			assert.Equal(t, members[1].ID, "test-member-2")
 			assert.Equal(t, members[1].MemberType, interfaces.AccessorDepartment)
 			assert.Equal(t, members[1].Name, "test-member-2-name")
+			assert.Equal(t, members[1].StartTime, int64(100))
+			assert.Equal(t, members[1].EndTime, int64(200))
 			assert.Equal(t, mock.ExpectationsWereMet(), nil)
 		})
 	})
@@ -352,7 +356,7 @@ func TestDBGetRoleByMembers(t *testing.T) {
 
 		Convey("query error", func() {
 			mock.ExpectQuery("^select").WillReturnError(mockErr)
-			roles, err := b.GetRoleByMembers(ctx, memberIDs)
+			roles, err := b.GetRoleByMembers(ctx, memberIDs, 0)
 			assert.Equal(t, err, mockErr)
 			assert.Equal(t, roles, nil)
 			assert.Equal(t, mock.ExpectationsWereMet(), nil)
@@ -361,7 +365,7 @@ func TestDBGetRoleByMembers(t *testing.T) {
 		Convey("scan Success has one role", func() {
 			rows := sqlmock.NewRows([]string{"f_role_id"}).AddRow("test-role-id")
 			mock.ExpectQuery("^select").WillReturnRows(rows)
-			roles, err := b.GetRoleByMembers(ctx, memberIDs)
+			roles, err := b.GetRoleByMembers(ctx, memberIDs, 0)
 			assert.Equal(t, err, nil)
 			assert.Equal(t, len(roles), 1)
 			assert.Equal(t, mock.ExpectationsWereMet(), nil)
@@ -372,13 +376,95 @@ func TestDBGetRoleByMembers(t *testing.T) {
 				AddRow("test-role-1").
 				AddRow("test-role-2")
 			mock.ExpectQuery("^select").WillReturnRows(rows)
-			roles, err := b.GetRoleByMembers(ctx, memberIDs)
+			roles, err := b.GetRoleByMembers(ctx, memberIDs, 0)
 			assert.Equal(t, err, nil)
 			assert.Equal(t, len(roles), 2)
 			assert.Equal(t, roles[0].ID, "test-role-1")
 			assert.Equal(t, roles[1].ID, "test-role-2")
 			assert.Equal(t, mock.ExpectationsWereMet(), nil)
 		})
+
+		Convey("只返回生效中的成员关系", func() {
+			rows := sqlmock.NewRows([]string{"f_role_id"}).AddRow("test-role-1")
+			mock.ExpectQuery("f_start_time <= \\? and \\(f_end_time = -1 or f_end_time >= \\?\\)").
+				WithArgs("test-member-1", "test-member-2", int64(100), int64(100)).WillReturnRows(rows)
+			roles, err := b.GetRoleByMembers(ctx, memberIDs, 100)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(roles), 1)
+			assert.Equal(t, mock.ExpectationsWereMet(), nil)
+		})
+	})
+}
+
+func TestDBDeleteExpiredMembers(t *testing.T) {
+	Convey("TestDBDeleteExpiredMembers", t, func() {
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+		defer func(db *sqlx.DB) {
+			err := db.Close()
+			if err != nil {
+				return
+			}
+		}(db)
+
+		mockErr := errors.New("test error")
+		ctx := context.Background()
+
+		b := &roleMember{
+			db:     db,
+			logger: common.NewLogger(),
+		}
+		columns := []string{"f_primary_id", "f_role_id", "f_member_id", "f_member_type", "f_member_name", "f_start_time", "f_end_time"}
+
+		Convey("query error", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery("^select .* for update$").WillReturnError(mockErr)
+			mock.ExpectRollback()
+			members, err := b.DeleteExpiredMembers(ctx, 100)
+			assert.Equal(t, err, mockErr)
+			assert.Equal(t, members, nil)
+			assert.Equal(t, mock.ExpectationsWereMet(), nil)
+		})
+
+		Convey("no expired members", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery("^select .* for update$").WithArgs(int64(100)).WillReturnRows(sqlmock.NewRows(columns))
+			mock.ExpectCommit()
+			members, err := b.DeleteExpiredMembers(ctx, 100)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(members), 0)
+			assert.Equal(t, mock.ExpectationsWereMet(), nil)
+		})
+
+		Convey("delete error", func() {
+			rows := sqlmock.NewRows(columns).AddRow(1, "role1", "user1", interfaces.AccessorUser, "user1-name", 0, 50)
+			mock.ExpectBegin()
+			mock.ExpectQuery("^select .* for update$").WithArgs(int64(100)).WillReturnRows(rows)
+			mock.ExpectExec("^delete").WithArgs(int64(1)).WillReturnError(mockErr)
+			mock.ExpectRollback()
+			members, err := b.DeleteExpiredMembers(ctx, 100)
+			assert.Equal(t, err, mockErr)
+			assert.Equal(t, members, nil)
+			assert.Equal(t, mock.ExpectationsWereMet(), nil)
+		})
+
+		Convey("Success, only the locked records are deleted", func() {
+			rows := sqlmock.NewRows(columns).
+				AddRow(1, "role1", "user1", interfaces.AccessorUser, "user1-name", 0, 50).
+				AddRow(2, "role1", "user2", interfaces.AccessorUser, "user2-name", 0, 60).
+				AddRow(5, "role2", "user1", interfaces.AccessorUser, "user1-name", 10, 70)
+			mock.ExpectBegin()
+			mock.ExpectQuery("^select .* for update$").WithArgs(int64(100)).WillReturnRows(rows)
+			mock.ExpectExec("^delete .* where f_primary_id in \\(\\?,\\?,\\?\\)$").WithArgs(int64(1), int64(2), int64(5)).WillReturnResult(sqlmock.NewResult(0, 3))
+			mock.ExpectCommit()
+			members, err := b.DeleteExpiredMembers(ctx, 100)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(members), 2)
+			assert.Equal(t, len(members["role1"]), 2)
+			assert.Equal(t, members["role2"][0].ID, "user1")
+			assert.Equal(t, members["role2"][0].EndTime, int64(70))
+			assert.Equal(t, mock.ExpectationsWereMet(), nil)
+		})
 	})
 }
 
@@ -0,0 +1,56 @@
+// Package drivenadapters 消息队列
+package drivenadapters
+
+import (
+	"sync"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"Authorization/common"
+	"Authorization/interfaces"
+)
+
+// topicRoleMembersExpired 角色成员过期消息主题
+const topicRoleMembersExpired = "authorization.role.members.expired"
+
+var (
+	mqClient interfaces.MQClient
+
+	messageBrokerOnce sync.Once
+	messageBroker     *messageBrokerSvc
+)
+
+// SetMQClient 设置消息队列客户端
+func SetMQClient(i interfaces.MQClient) {
+	mqClient = i
+}
+
+type messageBrokerSvc struct {
+	log      common.Logger
+	mqClient interfaces.MQClient
+}
+
+// NewMessageBroker 创建消息发送对象
+func NewMessageBroker() *messageBrokerSvc {
+	messageBrokerOnce.Do(func() {
+		messageBroker = &messageBrokerSvc{
+			log:      common.NewLogger(),
+			mqClient: mqClient,
+		}
+	})
+	return messageBroker
+}
+
+// RoleMembersExpired 发布角色成员过期消息
+func (m *messageBrokerSvc) RoleMembersExpired(roleID string, memberIDs []string) (err error) {
+	payload := map[string]interface{}{
+		"role_id":    roleID,
+		"member_ids": memberIDs,
+	}
+	payloadBytes, err := jsoniter.Marshal(payload)
+	if err != nil {
+		m.log.Errorf("RoleMembersExpired marshal payload failed, role: %s, err: %v", roleID, err)
+		return err
+	}
+	return m.mqClient.Pub(topicRoleMembersExpired, payloadBytes)
+}
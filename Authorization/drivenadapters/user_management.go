@@ -208,6 +208,55 @@ func (u *usermgntSvc) GetParentDepartmentsByDepartmentID(ctx context.Context, de
 	return
 }
 
+// GetDepartmentAllUserIDs 获取部门及其子部门下的所有用户ID
+func (u *usermgntSvc) GetDepartmentAllUserIDs(ctx context.Context, departmentID string) (userIDs []string, err error) {
+	target := fmt.Sprintf("%s/v1/departments/%s/all_user_ids", u.baseURL, departmentID)
+	headers := map[string]string{
+		"x-error-code": "string",
+	}
+	respParam, err := u.traceHTTPClient.Get(ctx, target, headers)
+	if err != nil {
+		u.log.Errorf("GetDepartmentAllUserIDs failed:%v, url:%v", err, target)
+		return
+	}
+
+	ids := respParam.(map[string]any)["all_user_ids"].([]any)
+	userIDs = make([]string, 0, len(ids))
+	for _, v := range ids {
+		userIDs = append(userIDs, v.(string))
+	}
+	return
+}
+
+// GetGroupMemberIDs 获取用户组的直接成员ID, 成员为用户或部门
+func (u *usermgntSvc) GetGroupMemberIDs(ctx context.Context, groupIDs []string) (userIDs, departmentIDs []string, err error) {
+	target := fmt.Sprintf("%s/v1/group-members", u.baseURL)
+	headers := map[string]string{
+		"x-error-code": "string",
+	}
+	reqParam := map[string]any{
+		"method":    "GET",
+		"group_ids": groupIDs,
+	}
+	_, respParam, err := u.traceHTTPClient.Post(ctx, target, headers, reqParam)
+	if err != nil {
+		u.log.Errorf("GetGroupMemberIDs failed:%v, url:%v", err, target)
+		return
+	}
+
+	userIDArr := respParam.(map[string]any)["user_ids"].([]any)
+	userIDs = make([]string, 0, len(userIDArr))
+	for _, v := range userIDArr {
+		userIDs = append(userIDs, v.(string))
+	}
+	depIDArr := respParam.(map[string]any)["department_ids"].([]any)
+	departmentIDs = make([]string, 0, len(depIDArr))
+	for _, v := range depIDArr {
+		departmentIDs = append(departmentIDs, v.(string))
+	}
+	return
+}
+
 //nolint:staticcheck,gocyclo
 func (u *usermgntSvc) GetNameByAccessorIDs(ctx context.Context, accessorIDs map[string]interfaces.AccessorType) (accessorNames map[string]string, err error) {
 	var orgInfo orgIDInfo
@@ -0,0 +1,377 @@
+// Package driveradapters AnyShare 入站适配器
+package driveradapters
+
+import (
+	"context"
+	_ "embed" // 标准用法
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xeipuuv/gojsonschema"
+
+	gerrors "github.com/kweaver-ai/go-lib/error"
+	"github.com/kweaver-ai/go-lib/rest"
+
+	"Authorization/interfaces"
+	"Authorization/logics"
+)
+
+//go:embed jsonschema/config_bundle/import.json
+var configBundleImportSchemaStr string
+
+var (
+	configBundleOnce    sync.Once
+	configBundleHandler RestHandler
+)
+
+/*
+配置导出导入
+导出的配置包中资源类型、角色、义务类型和策略的格式与 init_data 下的初始化数据一致
+*/
+type configBundleRestHandler struct {
+	configBundle      interfaces.LogicsConfigBundle
+	hydra             interfaces.Hydra
+	importSchema      *gojsonschema.Schema
+	accessorStrToType map[string]interfaces.AccessorType
+	accessorTypeToStr map[interfaces.AccessorType]string
+	roleSourceToStr   map[interfaces.RoleSource]string
+	conflictStrToMode map[string]interfaces.ImportConflictMode
+}
+
+// NewConfigBundleRestHandler 创建配置导出导入 handler 对象
+func NewConfigBundleRestHandler() RestHandler {
+	configBundleOnce.Do(func() {
+		configBundleHandler = &configBundleRestHandler{
+			configBundle: logics.NewConfigBundle(),
+			hydra:        newHydra(),
+			importSchema: newJSONSchema(configBundleImportSchemaStr),
+			accessorStrToType: map[string]interfaces.AccessorType{
+				"user":       interfaces.AccessorUser,
+				"department": interfaces.AccessorDepartment,
+				"group":      interfaces.AccessorGroup,
+				"role":       interfaces.AccessorRole,
+				"app":        interfaces.AccessorApp,
+			},
+			accessorTypeToStr: map[interfaces.AccessorType]string{
+				interfaces.AccessorUser:       "user",
+				interfaces.AccessorDepartment: "department",
+				interfaces.AccessorGroup:      "group",
+				interfaces.AccessorRole:       "role",
+				interfaces.AccessorApp:        "app",
+			},
+			roleSourceToStr: map[interfaces.RoleSource]string{
+				interfaces.RoleSourceSystem:   "system",
+				interfaces.RoleSourceBusiness: "business",
+				interfaces.RoleSourceUser:     "user",
+			},
+			conflictStrToMode: map[string]interfaces.ImportConflictMode{
+				"skip":      interfaces.ImportConflictSkip,
+				"overwrite": interfaces.ImportConflictOverwrite,
+				"fail":      interfaces.ImportConflictFail,
+			},
+		}
+	})
+	return configBundleHandler
+}
+
+// RegisterPrivate 注册内部API
+func (b *configBundleRestHandler) RegisterPrivate(_ *gin.Engine) {
+}
+
+// RegisterPublic 注册外部API
+func (b *configBundleRestHandler) RegisterPublic(engine *gin.Engine) {
+	engine.GET("/api/authorization/v1/config-bundle", b.export)
+	engine.POST("/api/authorization/v1/config-bundle/import", b.importBundle)
+}
+
+func (b *configBundleRestHandler) export(c *gin.Context) {
+	visitor, err := verify(c, b.hydra)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	bundle, err := b.configBundle.Export(context.Background(), &visitor, c.QueryArray("resource_type_id"))
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+	rest.ReplyOK(c, http.StatusOK, b.bundleToJSON(&bundle))
+}
+
+func (b *configBundleRestHandler) importBundle(c *gin.Context) {
+	visitor, err := verify(c, b.hydra)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	opts := interfaces.ConfigImportOptions{}
+	opts.DryRun, err = strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		rest.ReplyErrorV2(c, gerrors.NewError(gerrors.PublicBadRequest, "invalid dry_run"))
+		return
+	}
+	conflict := c.DefaultQuery("conflict", "skip")
+	mode, ok := b.conflictStrToMode[conflict]
+	if !ok {
+		rest.ReplyErrorV2(c, gerrors.NewError(gerrors.PublicBadRequest, fmt.Sprintf("invalid conflict %s", conflict)))
+		return
+	}
+	opts.Conflict = mode
+
+	var jsonReq map[string]any
+	if err = validateAndBindGin(c, b.importSchema, &jsonReq); err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+	bundle, err := b.bundleFromJSON(jsonReq)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	report, err := b.configBundle.Import(context.Background(), &visitor, &bundle, opts)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	stats := make(map[string]any, len(report.Stats))
+	for itemType, v := range report.Stats {
+		stats[string(itemType)] = map[string]any{
+			"created":   v.Created,
+			"updated":   v.Updated,
+			"unchanged": v.Unchanged,
+			"skipped":   v.Skipped,
+		}
+	}
+	conflicts := make([]any, 0, len(report.Conflicts))
+	for _, v := range report.Conflicts {
+		conflicts = append(conflicts, map[string]any{
+			"type": string(v.Type),
+			"id":   v.ID,
+			"name": v.Name,
+		})
+	}
+	rest.ReplyOK(c, http.StatusOK, map[string]any{
+		"dry_run":   report.DryRun,
+		"stats":     stats,
+		"conflicts": conflicts,
+	})
+}
+
+// bundleFromJSON 解析配置包, 请求体已经过 jsonschema 校验
+func (b *configBundleRestHandler) bundleFromJSON(jsonReq map[string]any) (bundle interfaces.ConfigBundle, err error) {
+	bundle.Version = int(jsonReq["version"].(float64))
+	if v, ok := jsonReq["resource_types"]; ok {
+		for _, item := range v.([]any) {
+			bundle.ResourceTypes = append(bundle.ResourceTypes, parseResourceTypeJSON(item.(map[string]any)))
+		}
+	}
+	if v, ok := jsonReq["roles"]; ok {
+		for _, item := range v.([]any) {
+			bundle.Roles = append(bundle.Roles, parseRoleJSON(item.(map[string]any)))
+		}
+	}
+	if v, ok := jsonReq["obligation_types"]; ok {
+		for _, item := range v.([]any) {
+			var obligationType interfaces.ObligationTypeInfo
+			obligationType, err = parseObligationTypeJSON(item.(map[string]any))
+			if err != nil {
+				err = gerrors.NewError(gerrors.PublicBadRequest, err.Error())
+				return
+			}
+			bundle.ObligationTypes = append(bundle.ObligationTypes, obligationType)
+		}
+	}
+	if v, ok := jsonReq["obligations"]; ok {
+		for _, item := range v.([]any) {
+			obligationDr := item.(map[string]any)
+			obligation := interfaces.ObligationInfo{
+				ID:     obligationDr["id"].(string),
+				TypeID: obligationDr["type_id"].(string),
+				Name:   obligationDr["name"].(string),
+				Value:  obligationDr["value"],
+			}
+			if description, ok := obligationDr["description"]; ok {
+				obligation.Description = description.(string)
+			}
+			bundle.Obligations = append(bundle.Obligations, obligation)
+		}
+	}
+	if v, ok := jsonReq["policies"]; ok {
+		for _, item := range v.([]any) {
+			var policy interfaces.PolicyInfo
+			policy, err = parsePolicyJSON(item.(map[string]any), b.accessorStrToType)
+			if err != nil {
+				err = gerrors.NewError(gerrors.PublicBadRequest, "param expires_at is invalid")
+				return
+			}
+			bundle.Policies = append(bundle.Policies, policy)
+		}
+	}
+	return
+}
+
+// bundleToJSON 配置包转为 json, 与 bundleFromJSON 对应
+//
+//nolint:funlen
+func (b *configBundleRestHandler) bundleToJSON(bundle *interfaces.ConfigBundle) map[string]any {
+	resourceTypes := make([]any, 0, len(bundle.ResourceTypes))
+	for i := range bundle.ResourceTypes {
+		operations := make([]any, 0, len(bundle.ResourceTypes[i].Operation))
+		for _, operation := range bundle.ResourceTypes[i].Operation {
+			names := make([]any, 0, len(operation.Name))
+			for _, name := range operation.Name {
+				names = append(names, map[string]any{"language": name.Language, "value": name.Value})
+			}
+			scopes := make([]any, 0, len(operation.Scope))
+			for _, scope := range operation.Scope {
+				scopes = append(scopes, string(scope))
+			}
+			operations = append(operations, map[string]any{
+				"id":          operation.ID,
+				"name":        names,
+				"description": operation.Description,
+				"scope":       scopes,
+			})
+		}
+		resourceTypes = append(resourceTypes, map[string]any{
+			"id":           bundle.ResourceTypes[i].ID,
+			"name":         bundle.ResourceTypes[i].Name,
+			"description":  bundle.ResourceTypes[i].Description,
+			"instance_url": bundle.ResourceTypes[i].InstanceURL,
+			"data_struct":  bundle.ResourceTypes[i].DataStruct,
+			"hidden":       bundle.ResourceTypes[i].Hidden,
+			"operation":    operations,
+		})
+	}
+
+	roles := make([]any, 0, len(bundle.Roles))
+	for i := range bundle.Roles {
+		scope := map[string]any{"unlimited": bundle.Roles[i].Unlimited}
+		if !bundle.Roles[i].Unlimited {
+			types := make([]any, 0, len(bundle.Roles[i].Types))
+			for _, v := range bundle.Roles[i].Types {
+				types = append(types, map[string]any{"id": v.ResourceTypeID, "name": v.ResourceTypeName})
+			}
+			scope["types"] = types
+		}
+		roles = append(roles, map[string]any{
+			"id":                  bundle.Roles[i].ID,
+			"name":                bundle.Roles[i].Name,
+			"description":         bundle.Roles[i].Description,
+			"source":              b.roleSourceToStr[bundle.Roles[i].RoleSource],
+			"resource_type_scope": scope,
+		})
+	}
+
+	obligationTypes := make([]any, 0, len(bundle.ObligationTypes))
+	for i := range bundle.ObligationTypes {
+		info := &bundle.ObligationTypes[i]
+		scope := map[string]any{"unlimited": info.ResourceTypeScope.Unlimited}
+		if !info.ResourceTypeScope.Unlimited {
+			types := make([]any, 0, len(info.ResourceTypeScope.Types))
+			for _, v := range info.ResourceTypeScope.Types {
+				operationsScope := map[string]any{"unlimited": v.OperationsScope.Unlimited}
+				if !v.OperationsScope.Unlimited {
+					operations := make([]any, 0, len(v.OperationsScope.Operations))
+					for _, operation := range v.OperationsScope.Operations {
+						operations = append(operations, map[string]any{"id": operation.ID})
+					}
+					operationsScope["operations"] = operations
+				}
+				types = append(types, map[string]any{"id": v.ResourceTypeID, "applicable_operations": operationsScope})
+			}
+			scope["resource_types"] = types
+		}
+		obligationType := map[string]any{
+			"id":                        info.ID,
+			"name":                      info.Name,
+			"description":               info.Description,
+			"schema":                    info.Schema,
+			"applicable_resource_types": scope,
+		}
+		// 未设置时不输出, 保证再次导入时无变化
+		if info.DefaultValue != nil {
+			obligationType["default_value"] = info.DefaultValue
+		}
+		if info.UiSchema != nil {
+			obligationType["ui_schema"] = info.UiSchema
+		}
+		obligationTypes = append(obligationTypes, obligationType)
+	}
+
+	obligations := make([]any, 0, len(bundle.Obligations))
+	for i := range bundle.Obligations {
+		obligations = append(obligations, map[string]any{
+			"id":          bundle.Obligations[i].ID,
+			"type_id":     bundle.Obligations[i].TypeID,
+			"name":        bundle.Obligations[i].Name,
+			"description": bundle.Obligations[i].Description,
+			"value":       bundle.Obligations[i].Value,
+		})
+	}
+
+	policies := make([]any, 0, len(bundle.Policies))
+	for i := range bundle.Policies {
+		policy := map[string]any{
+			"resource": map[string]any{
+				"id":   bundle.Policies[i].ResourceID,
+				"type": bundle.Policies[i].ResourceType,
+				"name": bundle.Policies[i].ResourceName,
+			},
+			"accessor": map[string]any{
+				"id":   bundle.Policies[i].AccessorID,
+				"type": b.accessorTypeToStr[bundle.Policies[i].AccessorType],
+				"name": bundle.Policies[i].AccessorName,
+			},
+			"operation": map[string]any{
+				"allow": b.operationsToJSON(bundle.Policies[i].Operation.Allow),
+				"deny":  b.operationsToJSON(bundle.Policies[i].Operation.Deny),
+			},
+			"condition": bundle.Policies[i].Condition,
+		}
+		// 数据库中 -1 表示永久, 不输出到期时间
+		if bundle.Policies[i].EndTime != -1 {
+			policy["expires_at"] = rest.TimeStampToString(bundle.Policies[i].EndTime * 1000)
+		}
+		policies = append(policies, policy)
+	}
+
+	return map[string]any{
+		"version":          bundle.Version,
+		"exported_at":      rest.TimeStampToString(bundle.ExportTime),
+		"resource_types":   resourceTypes,
+		"roles":            roles,
+		"obligation_types": obligationTypes,
+		"obligations":      obligations,
+		"policies":         policies,
+	}
+}
+
+func (b *configBundleRestHandler) operationsToJSON(operations []interfaces.PolicyOperationItem) (resp []any) {
+	resp = make([]any, 0, len(operations))
+	for i := range operations {
+		item := map[string]any{"id": operations[i].ID}
+		if len(operations[i].Obligations) > 0 {
+			obligations := make([]any, 0, len(operations[i].Obligations))
+			for _, v := range operations[i].Obligations {
+				obligation := map[string]any{"type_id": v.TypeID}
+				if v.ID != "" {
+					obligation["id"] = v.ID
+				} else {
+					obligation["value"] = v.Value
+				}
+				obligations = append(obligations, obligation)
+			}
+			item["obligations"] = obligations
+		}
+		resp = append(resp, item)
+	}
+	return
+}
@@ -0,0 +1,226 @@
+//nolint:gocritic
+package driveradapters
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+
+	"Authorization/interfaces"
+	"Authorization/interfaces/mock"
+)
+
+func newTestConfigBundleRestHandler(configBundle interfaces.LogicsConfigBundle, hydra interfaces.Hydra) *configBundleRestHandler {
+	return &configBundleRestHandler{
+		configBundle: configBundle,
+		hydra:        hydra,
+		importSchema: newJSONSchema(configBundleImportSchemaStr),
+		accessorStrToType: map[string]interfaces.AccessorType{
+			"user": interfaces.AccessorUser,
+			"role": interfaces.AccessorRole,
+		},
+		accessorTypeToStr: map[interfaces.AccessorType]string{
+			interfaces.AccessorUser: "user",
+			interfaces.AccessorRole: "role",
+		},
+		roleSourceToStr: map[interfaces.RoleSource]string{
+			interfaces.RoleSourceUser: "user",
+		},
+		conflictStrToMode: map[string]interfaces.ImportConflictMode{
+			"skip":      interfaces.ImportConflictSkip,
+			"overwrite": interfaces.ImportConflictOverwrite,
+		},
+	}
+}
+
+//nolint:funlen
+func TestConfigBundleRestHandler_Export(t *testing.T) {
+	Convey("export", t, func() {
+		test := setGinMode()
+		defer test()
+		r := gin.New()
+		r.Use(gin.Recovery())
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockConfigBundle := mock.NewMockLogicsConfigBundle(ctrl)
+		mockHydra := mock.NewMockHydra(ctrl)
+		handler := newTestConfigBundleRestHandler(mockConfigBundle, mockHydra)
+		handler.RegisterPublic(r)
+
+		mockHydra.EXPECT().Introspect("test-token").Return(interfaces.TokenIntrospectInfo{
+			Active:     true,
+			VisitorID:  "admin1",
+			VisitorTyp: interfaces.RealName,
+		}, nil)
+
+		Convey("导出成功, 导出结果可以再次导入", func() {
+			bundle := interfaces.ConfigBundle{
+				Version: interfaces.ConfigBundleVersion,
+				ResourceTypes: []interfaces.ResourceType{
+					{
+						ID:   "doc",
+						Name: "文档",
+						Operation: []interfaces.ResourceTypeOperation{
+							{
+								ID:    "read",
+								Name:  []interfaces.OperationName{{Language: "zh-cn", Value: "读取"}},
+								Scope: []interfaces.OperationScopeType{interfaces.ScopeType},
+							},
+						},
+					},
+				},
+				Roles: []interfaces.RoleInfo{
+					{ID: "role1", Name: "角色1", RoleSource: interfaces.RoleSourceUser, ResourceTypeScopeInfo: interfaces.ResourceTypeScopeInfo{Unlimited: true}},
+				},
+				ObligationTypes: []interfaces.ObligationTypeInfo{
+					{
+						ID:                "ob_type1",
+						Name:              "水印",
+						Schema:            map[string]any{"type": "object"},
+						ResourceTypeScope: interfaces.ObligationResourceTypeScopeInfo{Unlimited: true},
+					},
+				},
+				Obligations: []interfaces.ObligationInfo{
+					{ID: "ob1", TypeID: "ob_type1", Name: "水印1", Value: map[string]any{"text": "a"}},
+				},
+				Policies: []interfaces.PolicyInfo{
+					{
+						ResourceID:   "doc1",
+						ResourceType: "doc",
+						ResourceName: "文档1",
+						AccessorID:   "role1",
+						AccessorType: interfaces.AccessorRole,
+						AccessorName: "角色1",
+						EndTime:      -1,
+						Operation: interfaces.PolicyOperation{
+							Allow: []interfaces.PolicyOperationItem{
+								{ID: "read", Obligations: []interfaces.PolicyObligationItem{{TypeID: "ob_type1", ID: "ob1"}}},
+							},
+							Deny: []interfaces.PolicyOperationItem{},
+						},
+					},
+				},
+			}
+			mockConfigBundle.EXPECT().Export(gomock.Any(), gomock.Any(), []string{"doc"}).Return(bundle, nil)
+
+			req := httptest.NewRequest("GET", "/api/authorization/v1/config-bundle?resource_type_id=doc", nil)
+			req.Header.Set("Authorization", "Bearer test-token")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			var resp map[string]any
+			So(json.Unmarshal(w.Body.Bytes(), &resp), ShouldBeNil)
+			policies := resp["policies"].([]any)
+			So(len(policies), ShouldEqual, 1)
+			_, ok := policies[0].(map[string]any)["expires_at"]
+			So(ok, ShouldBeFalse)
+			obligationType := resp["obligation_types"].([]any)[0].(map[string]any)
+			_, ok = obligationType["default_value"]
+			So(ok, ShouldBeFalse)
+
+			// 导出结果符合导入的 schema, 解析后与导出内容一致
+			var importReq map[string]any
+			So(validateAndBind(w.Body.Bytes(), handler.importSchema, &importReq), ShouldBeNil)
+			parsed, err := handler.bundleFromJSON(importReq)
+			So(err, ShouldBeNil)
+			So(parsed.Version, ShouldEqual, bundle.Version)
+			So(parsed.ResourceTypes, ShouldResemble, bundle.ResourceTypes)
+			So(parsed.Roles, ShouldResemble, bundle.Roles)
+			So(parsed.Obligations, ShouldResemble, bundle.Obligations)
+			So(parsed.Policies, ShouldResemble, bundle.Policies)
+		})
+	})
+}
+
+func TestConfigBundleRestHandler_Import(t *testing.T) {
+	Convey("import", t, func() {
+		test := setGinMode()
+		defer test()
+		r := gin.New()
+		r.Use(gin.Recovery())
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockConfigBundle := mock.NewMockLogicsConfigBundle(ctrl)
+		mockHydra := mock.NewMockHydra(ctrl)
+		handler := newTestConfigBundleRestHandler(mockConfigBundle, mockHydra)
+		handler.RegisterPublic(r)
+
+		mockHydra.EXPECT().Introspect("test-token").Return(interfaces.TokenIntrospectInfo{
+			Active:     true,
+			VisitorID:  "admin1",
+			VisitorTyp: interfaces.RealName,
+		}, nil)
+
+		reqBody := map[string]any{
+			"version": 1,
+			"roles": []any{
+				map[string]any{
+					"id":                  "role1",
+					"name":                "角色1",
+					"description":         "",
+					"source":              "user",
+					"resource_type_scope": map[string]any{"unlimited": true},
+				},
+			},
+		}
+		reqBodyBytes, _ := json.Marshal(reqBody)
+
+		Convey("冲突处理方式无效", func() {
+			req := httptest.NewRequest("POST", "/api/authorization/v1/config-bundle/import?conflict=invalid", bytes.NewBuffer(reqBodyBytes))
+			req.Header.Set("Authorization", "Bearer test-token")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("缺少版本", func() {
+			body, _ := json.Marshal(map[string]any{"roles": []any{}})
+			req := httptest.NewRequest("POST", "/api/authorization/v1/config-bundle/import", bytes.NewBuffer(body))
+			req.Header.Set("Authorization", "Bearer test-token")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("dry run 成功", func() {
+			mockConfigBundle.EXPECT().Import(gomock.Any(), gomock.Any(), gomock.Any(), interfaces.ConfigImportOptions{
+				DryRun:   true,
+				Conflict: interfaces.ImportConflictOverwrite,
+			}).DoAndReturn(func(_, _, bundle, _ any) (interfaces.ConfigImportReport, error) {
+				So(len(bundle.(*interfaces.ConfigBundle).Roles), ShouldEqual, 1)
+				return interfaces.ConfigImportReport{
+					DryRun: true,
+					Stats: map[interfaces.ConfigItemType]interfaces.ConfigImportStats{
+						interfaces.ConfigItemRole: {Updated: 1},
+					},
+					Conflicts: []interfaces.ConfigImportConflict{{Type: interfaces.ConfigItemRole, ID: "role1", Name: "角色1"}},
+				}, nil
+			})
+
+			req := httptest.NewRequest("POST", "/api/authorization/v1/config-bundle/import?dry_run=true&conflict=overwrite", bytes.NewBuffer(reqBodyBytes))
+			req.Header.Set("Authorization", "Bearer test-token")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			var resp map[string]any
+			So(json.Unmarshal(w.Body.Bytes(), &resp), ShouldBeNil)
+			So(resp["dry_run"], ShouldEqual, true)
+			So(resp["stats"].(map[string]any)["role"].(map[string]any)["updated"], ShouldEqual, 1)
+			So(len(resp["conflicts"].([]any)), ShouldEqual, 1)
+		})
+	})
+}
@@ -96,55 +96,7 @@ func (i *initData) InitResourceType() {
 		return
 	}
 	for _, resourceTypeJson := range resourceTypesJson {
-		resourceTypeDr := resourceTypeJson.(map[string]any)
-		resourceType := interfaces.ResourceType{}
-		resourceType.ID = resourceTypeDr["id"].(string)
-		resourceType.Name = resourceTypeDr["name"].(string)
-		resourceType.Description = resourceTypeDr["description"].(string)
-		resourceType.InstanceURL = resourceTypeDr["instance_url"].(string)
-		resourceType.DataStruct = resourceTypeDr["data_struct"].(string)
-		// 判断hidden是否存在，如果存在则设置为hidden
-		hiddenJson, ok := resourceTypeDr["hidden"]
-		if ok {
-			resourceType.Hidden = hiddenJson.(bool)
-		}
-		operationsJson := resourceTypeDr["operation"].([]any)
-		for _, operationJson := range operationsJson {
-			operationDr := operationJson.(map[string]any)
-			operationID := operationDr["id"].(string)
-			var operationDescription string
-			opeDescriptionJson, ok := operationDr["description"]
-			if ok {
-				operationDescription = opeDescriptionJson.(string)
-			}
-
-			operationNameJson := operationDr["name"].([]any)
-			operationNames := []interfaces.OperationName{}
-			for _, name := range operationNameJson {
-				nameDr := name.(map[string]any)
-				operationName := interfaces.OperationName{
-					Language: nameDr["language"].(string),
-					Value:    nameDr["value"].(string),
-				}
-				operationNames = append(operationNames, operationName)
-			}
-
-			operationScope := []interfaces.OperationScopeType{}
-			operationScopeJson := operationDr["scope"].([]any)
-			for _, scope := range operationScopeJson {
-				scopeStr := scope.(string)
-				operationScope = append(operationScope, interfaces.OperationScopeType(scopeStr))
-			}
-
-			operation := interfaces.ResourceTypeOperation{
-				ID:          operationID,
-				Name:        operationNames,
-				Description: operationDescription,
-				Scope:       operationScope,
-			}
-			resourceType.Operation = append(resourceType.Operation, operation)
-		}
-		resourceTypeData = append(resourceTypeData, resourceType)
+		resourceTypeData = append(resourceTypeData, parseResourceTypeJSON(resourceTypeJson.(map[string]any)))
 	}
 	err = i.resourceType.InitResourceTypes(context.Background(), resourceTypeData)
 	if err != nil {
@@ -161,44 +113,8 @@ func (i *initData) InitRole() {
 		i.log.Errorf("unmarshal roleDataStr failed, err: %v", err)
 		return
 	}
-	roleSourceMap := map[string]interfaces.RoleSource{
-		"system":   interfaces.RoleSourceSystem,
-		"business": interfaces.RoleSourceBusiness,
-		"user":     interfaces.RoleSourceUser,
-	}
 	for _, roleJson := range rolesJson {
-		roleDr := roleJson.(map[string]any)
-		var roleID string
-		roleIDJson, ok := roleDr["id"]
-		if ok {
-			roleID = roleIDJson.(string)
-		}
-		roleName := roleDr["name"].(string)
-		roleDescription := roleDr["description"].(string)
-		roleSource := roleSourceMap[roleDr["source"].(string)]
-
-		roleResourceTypeScopesInfoJson := roleDr["resource_type_scope"].(map[string]any)
-		roleResourceTypeScopesInfo := interfaces.ResourceTypeScopeInfo{}
-		roleResourceTypeScopesInfo.Unlimited = roleResourceTypeScopesInfoJson["unlimited"].(bool)
-		typesJson, ok := roleResourceTypeScopesInfoJson["types"]
-		if ok {
-			roleResourceTypeScopesInfo.Types = make([]interfaces.ResourceTypeScope, 0, len(typesJson.([]any)))
-			for _, roleResourceTypeScopeInfoJson := range typesJson.([]any) {
-				roleResourceTypeScopeInfoDr := roleResourceTypeScopeInfoJson.(map[string]any)
-				roleResourceTypeScopesInfo.Types = append(roleResourceTypeScopesInfo.Types, interfaces.ResourceTypeScope{
-					ResourceTypeID:   roleResourceTypeScopeInfoDr["id"].(string),
-					ResourceTypeName: roleResourceTypeScopeInfoDr["name"].(string),
-				})
-			}
-		}
-
-		roleData = append(roleData, interfaces.RoleInfo{
-			ID:                    roleID,
-			Name:                  roleName,
-			Description:           roleDescription,
-			RoleSource:            roleSource,
-			ResourceTypeScopeInfo: roleResourceTypeScopesInfo,
-		})
+		roleData = append(roleData, parseRoleJSON(roleJson.(map[string]any)))
 	}
 	err = i.role.InitRoles(context.Background(), roleData)
 	if err != nil {
@@ -226,6 +142,8 @@ func (i *initData) InitRoleMembers() {
 			tmp.ID = memberDn["id"].(string)
 			tmp.MemberType = i.memberStringTypes[memberDn["type"].(string)]
 			tmp.Name = memberDn["name"].(string)
+			// 初始化的成员永久有效
+			tmp.EndTime = -1
 			roleMap[roleID] = append(roleMap[roleID], tmp)
 		}
 	}
@@ -264,57 +182,10 @@ func (i *initData) InitPolicy() {
 	}
 
 	for _, policyJson := range policiesJson {
-		policyDr := policyJson.(map[string]any)
-		policy := interfaces.PolicyInfo{}
-		expiresAtJson, ok := policyDr["expires_at"]
-		if !ok {
-			policy.EndTime = -1
-		} else {
-			// 权限到期时间
-			var timeStamp int64
-			timeStamp, err = rest.StringToTimeStamp(expiresAtJson.(string))
-			if err != nil {
-				i.log.Errorf("StringToTimeStamp failed, err: %v", err)
-				continue
-			}
-			// 数据库中 -1 表示永久 单位使用微妙
-			if timeStamp == 0 {
-				policy.EndTime = -1
-			} else {
-				policy.EndTime = timeStamp / 1000
-			}
-		}
-
-		resourceJson := policyDr["resource"].(map[string]any)
-		accessorJson := policyDr["accessor"].(map[string]any)
-
-		policy.ResourceID = resourceJson["id"].(string)
-		policy.ResourceType = resourceJson["type"].(string)
-		policy.ResourceName = resourceJson["name"].(string)
-		policy.AccessorID = accessorJson["id"].(string)
-		policy.AccessorType = i.accessorStrToType[accessorJson["type"].(string)]
-		policy.AccessorName = accessorJson["name"].(string)
-
-		operationJson := policyDr["operation"].(map[string]any)
-		allowJson := operationJson["allow"].([]any)
-		denyJson := operationJson["deny"].([]any)
-		allow := []interfaces.PolicyOperationItem{}
-		deny := []interfaces.PolicyOperationItem{}
-		for _, v := range allowJson {
-			item := v.(map[string]any)
-			allow = append(allow, interfaces.PolicyOperationItem{
-				ID: item["id"].(string),
-			})
-		}
-		for _, v := range denyJson {
-			item := v.(map[string]any)
-			deny = append(deny, interfaces.PolicyOperationItem{
-				ID: item["id"].(string),
-			})
-		}
-		policy.Operation = interfaces.PolicyOperation{
-			Allow: allow,
-			Deny:  deny,
+		policy, err := parsePolicyJSON(policyJson.(map[string]any), i.accessorStrToType)
+		if err != nil {
+			i.log.Errorf("StringToTimeStamp failed, err: %v", err)
+			continue
 		}
 		policyData = append(policyData, policy)
 	}
@@ -353,77 +224,248 @@ func (i *initData) InitObligationType() {
 	}
 
 	for _, obligationTypeJson := range obligationTypesJson {
-		obligationTypeDr := obligationTypeJson.(map[string]any)
-		obligationType := interfaces.ObligationTypeInfo{
-			ID:     obligationTypeDr["id"].(string),
-			Name:   obligationTypeDr["name"].(string),
-			Schema: obligationTypeDr["schema"],
+		obligationType, err := parseObligationTypeJSON(obligationTypeJson.(map[string]any))
+		if err != nil {
+			// 错误数据直接跳过
+			i.log.Errorf("InitObligationType %v", err)
+			continue
 		}
+		obligationTypeData = append(obligationTypeData, obligationType)
+	}
 
-		defJson, ok := obligationTypeDr["default_value"]
+	err = i.obligationType.InitObligationTypes(context.Background(), obligationTypeData)
+	if err != nil {
+		i.log.Errorf("InitObligationTypes failed, err: %v", err)
+		return
+	}
+}
+
+// parseResourceTypeJSON 解析资源类型, 格式与 init_data/resource_type.json 一致
+func parseResourceTypeJSON(resourceTypeDr map[string]any) (resourceType interfaces.ResourceType) {
+	resourceType.ID = resourceTypeDr["id"].(string)
+	resourceType.Name = resourceTypeDr["name"].(string)
+	resourceType.Description = resourceTypeDr["description"].(string)
+	resourceType.InstanceURL = resourceTypeDr["instance_url"].(string)
+	resourceType.DataStruct = resourceTypeDr["data_struct"].(string)
+	// 判断hidden是否存在，如果存在则设置为hidden
+	hiddenJson, ok := resourceTypeDr["hidden"]
+	if ok {
+		resourceType.Hidden = hiddenJson.(bool)
+	}
+	operationsJson := resourceTypeDr["operation"].([]any)
+	for _, operationJson := range operationsJson {
+		operationDr := operationJson.(map[string]any)
+		operationID := operationDr["id"].(string)
+		var operationDescription string
+		opeDescriptionJson, ok := operationDr["description"]
 		if ok {
-			obligationType.DefaultValue = defJson
+			operationDescription = opeDescriptionJson.(string)
 		}
 
-		descriptionJson, ok := obligationTypeDr["description"]
-		if ok {
-			obligationType.Description = descriptionJson.(string)
+		operationNameJson := operationDr["name"].([]any)
+		operationNames := []interfaces.OperationName{}
+		for _, name := range operationNameJson {
+			nameDr := name.(map[string]any)
+			operationName := interfaces.OperationName{
+				Language: nameDr["language"].(string),
+				Value:    nameDr["value"].(string),
+			}
+			operationNames = append(operationNames, operationName)
 		}
 
-		uiSchemaJson, ok := obligationTypeDr["ui_schema"]
-		if ok {
-			obligationType.UiSchema = uiSchemaJson
+		operationScope := []interfaces.OperationScopeType{}
+		operationScopeJson := operationDr["scope"].([]any)
+		for _, scope := range operationScopeJson {
+			scopeStr := scope.(string)
+			operationScope = append(operationScope, interfaces.OperationScopeType(scopeStr))
 		}
 
-		resourceTypeScopesJson := obligationTypeDr["applicable_resource_types"].(map[string]any)
-		obligationType.ResourceTypeScope.Unlimited = resourceTypeScopesJson["unlimited"].(bool)
+		operation := interfaces.ResourceTypeOperation{
+			ID:          operationID,
+			Name:        operationNames,
+			Description: operationDescription,
+			Scope:       operationScope,
+		}
+		resourceType.Operation = append(resourceType.Operation, operation)
+	}
+	return
+}
 
-		// 如果资源类型有范围限制，则需要设置资源类型范围
-		if !obligationType.ResourceTypeScope.Unlimited {
-			_, resourceTypesExist := resourceTypeScopesJson["resource_types"]
-			if !resourceTypesExist {
-				// 错误数据直接跳过
-				i.log.Errorf("InitObligationType InitObligationType ID %s resource_types is required", obligationType.ID)
-				continue
-			}
-			resourceTypesJson := resourceTypeScopesJson["resource_types"].([]any)
-			for _, resourceTypeJson := range resourceTypesJson {
-				// 遍历资源类型，每个资源类型信息 放入 resourceTypeScope
-				var resourceTypeScope interfaces.ObligationResourceTypeScope
-				resourceTypeJsonMap := resourceTypeJson.(map[string]any)
-				resourceTypeID := resourceTypeJsonMap["id"].(string)
-				operationsScopeJson := resourceTypeJsonMap["applicable_operations"].(map[string]any)
-				var operationsScopeInfo interfaces.ObligationOperationsScopeInfo
-				operationsScopeInfo.Unlimited = operationsScopeJson["unlimited"].(bool)
-				if !operationsScopeInfo.Unlimited {
-					_, operationsExist := operationsScopeJson["operations"]
-					if !operationsExist {
-						// 错误数据直接跳过
-						i.log.Errorf("InitObligationType InitObligationType ID %s resource_types %s operations is required", obligationType.ID, resourceTypeID)
-						continue
-					}
-					// 获取资源类型上的操作
-					operationsJson := operationsScopeJson["operations"].([]any)
-					for _, operationJson := range operationsJson {
-						operationJsonMap := operationJson.(map[string]any)
-						operationID := operationJsonMap["id"].(string)
-						var operation interfaces.ObligationOperation
-						operation.ID = operationID
-						operationsScopeInfo.Operations = append(operationsScopeInfo.Operations, operation)
-					}
+// parseRoleJSON 解析角色, 格式与 init_data/role.json 一致
+func parseRoleJSON(roleDr map[string]any) interfaces.RoleInfo {
+	roleSourceMap := map[string]interfaces.RoleSource{
+		"system":   interfaces.RoleSourceSystem,
+		"business": interfaces.RoleSourceBusiness,
+		"user":     interfaces.RoleSourceUser,
+	}
+	var roleID string
+	roleIDJson, ok := roleDr["id"]
+	if ok {
+		roleID = roleIDJson.(string)
+	}
+	roleName := roleDr["name"].(string)
+	roleDescription := roleDr["description"].(string)
+	roleSource := roleSourceMap[roleDr["source"].(string)]
+
+	roleResourceTypeScopesInfoJson := roleDr["resource_type_scope"].(map[string]any)
+	roleResourceTypeScopesInfo := interfaces.ResourceTypeScopeInfo{}
+	roleResourceTypeScopesInfo.Unlimited = roleResourceTypeScopesInfoJson["unlimited"].(bool)
+	typesJson, ok := roleResourceTypeScopesInfoJson["types"]
+	if ok {
+		roleResourceTypeScopesInfo.Types = make([]interfaces.ResourceTypeScope, 0, len(typesJson.([]any)))
+		for _, roleResourceTypeScopeInfoJson := range typesJson.([]any) {
+			roleResourceTypeScopeInfoDr := roleResourceTypeScopeInfoJson.(map[string]any)
+			roleResourceTypeScopesInfo.Types = append(roleResourceTypeScopesInfo.Types, interfaces.ResourceTypeScope{
+				ResourceTypeID:   roleResourceTypeScopeInfoDr["id"].(string),
+				ResourceTypeName: roleResourceTypeScopeInfoDr["name"].(string),
+			})
+		}
+	}
+
+	return interfaces.RoleInfo{
+		ID:                    roleID,
+		Name:                  roleName,
+		Description:           roleDescription,
+		RoleSource:            roleSource,
+		ResourceTypeScopeInfo: roleResourceTypeScopesInfo,
+	}
+}
+
+// parsePolicyJSON 解析策略, 格式与 init_data/policy 下的文件一致
+func parsePolicyJSON(policyDr map[string]any, accessorStrToType map[string]interfaces.AccessorType) (policy interfaces.PolicyInfo, err error) {
+	expiresAtJson, ok := policyDr["expires_at"]
+	if !ok {
+		policy.EndTime = -1
+	} else {
+		// 权限到期时间
+		var timeStamp int64
+		timeStamp, err = rest.StringToTimeStamp(expiresAtJson.(string))
+		if err != nil {
+			return
+		}
+		// 数据库中 -1 表示永久 单位使用微妙
+		if timeStamp == 0 {
+			policy.EndTime = -1
+		} else {
+			policy.EndTime = timeStamp / 1000
+		}
+	}
+
+	resourceJson := policyDr["resource"].(map[string]any)
+	accessorJson := policyDr["accessor"].(map[string]any)
+
+	policy.ResourceID = resourceJson["id"].(string)
+	policy.ResourceType = resourceJson["type"].(string)
+	policy.ResourceName = resourceJson["name"].(string)
+	policy.AccessorID = accessorJson["id"].(string)
+	policy.AccessorType = accessorStrToType[accessorJson["type"].(string)]
+	policy.AccessorName = accessorJson["name"].(string)
+
+	if conditionJson, exist := policyDr["condition"]; exist {
+		policy.Condition = conditionJson.(string)
+	}
+
+	operationJson := policyDr["operation"].(map[string]any)
+	allowJson := operationJson["allow"].([]any)
+	denyJson := operationJson["deny"].([]any)
+	allow := []interfaces.PolicyOperationItem{}
+	deny := []interfaces.PolicyOperationItem{}
+	for _, v := range allowJson {
+		item := v.(map[string]any)
+		allowItem := interfaces.PolicyOperationItem{
+			ID: item["id"].(string),
+		}
+		if obligationsJson, exist := item["obligations"]; exist {
+			for _, o := range obligationsJson.([]any) {
+				obligationDr := o.(map[string]any)
+				obligation := interfaces.PolicyObligationItem{
+					TypeID: obligationDr["type_id"].(string),
+					Value:  obligationDr["value"],
+				}
+				if idJson, exist := obligationDr["id"]; exist {
+					obligation.ID = idJson.(string)
 				}
-				resourceTypeScope.ResourceTypeID = resourceTypeID
-				resourceTypeScope.OperationsScope = operationsScopeInfo
-				// 将资源类型信息放入资源类型范围
-				obligationType.ResourceTypeScope.Types = append(obligationType.ResourceTypeScope.Types, resourceTypeScope)
+				allowItem.Obligations = append(allowItem.Obligations, obligation)
 			}
 		}
-		obligationTypeData = append(obligationTypeData, obligationType)
+		allow = append(allow, allowItem)
 	}
+	for _, v := range denyJson {
+		item := v.(map[string]any)
+		deny = append(deny, interfaces.PolicyOperationItem{
+			ID: item["id"].(string),
+		})
+	}
+	policy.Operation = interfaces.PolicyOperation{
+		Allow: allow,
+		Deny:  deny,
+	}
+	return
+}
 
-	err = i.obligationType.InitObligationTypes(context.Background(), obligationTypeData)
-	if err != nil {
-		i.log.Errorf("InitObligationTypes failed, err: %v", err)
-		return
+// parseObligationTypeJSON 解析义务类型, 格式与 init_data/obligation_type 下的文件一致
+func parseObligationTypeJSON(obligationTypeDr map[string]any) (obligationType interfaces.ObligationTypeInfo, err error) {
+	obligationType = interfaces.ObligationTypeInfo{
+		ID:     obligationTypeDr["id"].(string),
+		Name:   obligationTypeDr["name"].(string),
+		Schema: obligationTypeDr["schema"],
+	}
+
+	defJson, ok := obligationTypeDr["default_value"]
+	if ok {
+		obligationType.DefaultValue = defJson
+	}
+
+	descriptionJson, ok := obligationTypeDr["description"]
+	if ok {
+		obligationType.Description = descriptionJson.(string)
+	}
+
+	uiSchemaJson, ok := obligationTypeDr["ui_schema"]
+	if ok {
+		obligationType.UiSchema = uiSchemaJson
+	}
+
+	resourceTypeScopesJson := obligationTypeDr["applicable_resource_types"].(map[string]any)
+	obligationType.ResourceTypeScope.Unlimited = resourceTypeScopesJson["unlimited"].(bool)
+
+	// 如果资源类型有范围限制，则需要设置资源类型范围
+	if !obligationType.ResourceTypeScope.Unlimited {
+		_, resourceTypesExist := resourceTypeScopesJson["resource_types"]
+		if !resourceTypesExist {
+			err = fmt.Errorf("obligation type ID %s resource_types is required", obligationType.ID)
+			return
+		}
+		resourceTypesJson := resourceTypeScopesJson["resource_types"].([]any)
+		for _, resourceTypeJson := range resourceTypesJson {
+			// 遍历资源类型，每个资源类型信息 放入 resourceTypeScope
+			var resourceTypeScope interfaces.ObligationResourceTypeScope
+			resourceTypeJsonMap := resourceTypeJson.(map[string]any)
+			resourceTypeID := resourceTypeJsonMap["id"].(string)
+			operationsScopeJson := resourceTypeJsonMap["applicable_operations"].(map[string]any)
+			var operationsScopeInfo interfaces.ObligationOperationsScopeInfo
+			operationsScopeInfo.Unlimited = operationsScopeJson["unlimited"].(bool)
+			if !operationsScopeInfo.Unlimited {
+				_, operationsExist := operationsScopeJson["operations"]
+				if !operationsExist {
+					err = fmt.Errorf("obligation type ID %s resource_types %s operations is required", obligationType.ID, resourceTypeID)
+					return
+				}
+				// 获取资源类型上的操作
+				operationsJson := operationsScopeJson["operations"].([]any)
+				for _, operationJson := range operationsJson {
+					operationJsonMap := operationJson.(map[string]any)
+					operationID := operationJsonMap["id"].(string)
+					var operation interfaces.ObligationOperation
+					operation.ID = operationID
+					operationsScopeInfo.Operations = append(operationsScopeInfo.Operations, operation)
+				}
+			}
+			resourceTypeScope.ResourceTypeID = resourceTypeID
+			resourceTypeScope.OperationsScope = operationsScopeInfo
+			// 将资源类型信息放入资源类型范围
+			obligationType.ResourceTypeScope.Types = append(obligationType.ResourceTypeScope.Types, resourceTypeScope)
+		}
 	}
+	return
 }
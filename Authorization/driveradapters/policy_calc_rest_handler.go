@@ -20,6 +20,8 @@ import (
 var (
 	//go:embed jsonschema/policy_calc/check.json
 	checkSchemaStr string
+	//go:embed jsonschema/policy_calc/batch_check.json
+	batchCheckSchemaStr string
 	//go:embed jsonschema/policy_calc/resource_list.json
 	resourceListSchemaStr string
 	//go:embed jsonschema/policy_calc/resource_filter.json
@@ -43,6 +45,7 @@ type policyCalcRestHandler struct {
 	policyCalc                        interfaces.LogicsPolicyCalc
 	hydra                             interfaces.Hydra
 	checkSchema                       *gojsonschema.Schema
+	batchCheckSchema                  *gojsonschema.Schema
 	resourceListSchema                *gojsonschema.Schema
 	resourceFilterSchema              *gojsonschema.Schema
 	resourceOperationSchema           *gojsonschema.Schema
@@ -51,6 +54,8 @@ type policyCalcRestHandler struct {
 	resourceTypeOperationPublicSchema *gojsonschema.Schema
 	visitorStrToType                  map[string]interfaces.VisitorType
 	includeStrToType                  map[string]interfaces.PolicCalcyIncludeType
+	policyLevelToStr                  map[interfaces.PolicyLevel]string
+	accessorTypeToStr                 map[interfaces.AccessorType]string
 }
 
 // NewPolicyCalcRestHandler 策略计算适配器接口
@@ -60,6 +65,7 @@ func NewPolicyCalcRestHandler() RestHandler {
 			policyCalc:                        logics.NewPolicyCalc(),
 			hydra:                             newHydra(),
 			checkSchema:                       newJSONSchema(checkSchemaStr),
+			batchCheckSchema:                  newJSONSchema(batchCheckSchemaStr),
 			resourceListSchema:                newJSONSchema(resourceListSchemaStr),
 			resourceFilterSchema:              newJSONSchema(resourceFilterSchemaStr),
 			resourceOperationSchema:           newJSONSchema(resourceOperationSchemaStr),
@@ -72,6 +78,19 @@ func NewPolicyCalcRestHandler() RestHandler {
 			},
 			includeStrToType: map[string]interfaces.PolicCalcyIncludeType{
 				"operation_obligations": interfaces.PolicCalcyIncludeOperationObligations,
+				"explain":               interfaces.PolicCalcyIncludeExplain,
+			},
+			policyLevelToStr: map[interfaces.PolicyLevel]string{
+				interfaces.PolicyLevelSelf:     "self",
+				interfaces.PolicyLevelAncestor: "ancestor",
+				interfaces.PolicyLevelType:     "type",
+			},
+			accessorTypeToStr: map[interfaces.AccessorType]string{
+				interfaces.AccessorUser:       "user",
+				interfaces.AccessorDepartment: "department",
+				interfaces.AccessorGroup:      "group",
+				interfaces.AccessorApp:        "app",
+				interfaces.AccessorRole:       "role",
 			},
 		}
 	})
@@ -81,9 +100,11 @@ func NewPolicyCalcRestHandler() RestHandler {
 // RegisterPrivate 注册内部API
 func (p *policyCalcRestHandler) RegisterPrivate(engine *gin.Engine) {
 	engine.POST("/api/authorization/v1/operation-check", p.check)
+	engine.POST("/api/authorization/v1/operation-check/batch", p.batchCheck)
 	engine.POST("/api/authorization/v1/resource-list", p.resourceList)
 	engine.POST("/api/authorization/v1/resource-filter", p.resourceFilter)
 	engine.POST("/api/authorization/v1/resource-operation", p.resourceOperation)
+	engine.GET("/api/authorization/v1/policy-calc-cache/stats", p.cacheStats)
 }
 
 // RegisterPublic 注册外部API
@@ -118,8 +139,10 @@ func (p *policyCalcRestHandler) checkPublic(c *gin.Context) {
 	}
 
 	accessor := interfaces.AccessorInfo{
-		ID:   visitor.ID,
-		Type: visitor.Type,
+		ID:         visitor.ID,
+		Type:       visitor.Type,
+		IP:         visitor.IP,
+		ClientType: visitor.ClientType,
 	}
 
 	resource := interfaces.ResourceInfo{
@@ -172,11 +195,13 @@ func (p *policyCalcRestHandler) check(c *gin.Context) {
 		ID:   accessorID,
 		Type: accessorType,
 	}
+	setAccessorConditionAttrs(&accessor, accessorJson)
 
 	resource := interfaces.ResourceInfo{
-		ID:   resourceID,
-		Type: resourceType,
-		Name: resourceName,
+		ID:         resourceID,
+		Type:       resourceType,
+		Name:       resourceName,
+		Attributes: getResourceConditionAttrs(resourceJson),
 	}
 
 	operationsJson := jsonReq["operation"].([]any)
@@ -208,6 +233,13 @@ func (p *policyCalcRestHandler) check(c *gin.Context) {
 		return
 	}
 
+	rest.ReplyOK(c, http.StatusOK, p.checkResultToJSON(&checkResult, includeParams, includeMap))
+}
+
+// checkResultToJSON 检查结果转换为响应结构
+func (p *policyCalcRestHandler) checkResultToJSON(checkResult *interfaces.CheckResult, includeParams []interfaces.PolicCalcyIncludeType,
+	includeMap map[interfaces.PolicCalcyIncludeType]bool,
+) map[string]any {
 	oblistResult := make([]any, 0, len(checkResult.OperatrionOblist))
 	resp := map[string]any{
 		"result": checkResult.Result,
@@ -233,11 +265,121 @@ func (p *policyCalcRestHandler) check(c *gin.Context) {
 		}
 		inlcudeResp["operation_obligations"] = oblistResult
 	}
+	if includeMap[interfaces.PolicCalcyIncludeExplain] {
+		explains := make([]any, 0, len(checkResult.Explain))
+		for i := range checkResult.Explain {
+			explains = append(explains, map[string]any{
+				"operation":           checkResult.Explain[i].Operation,
+				"result":              checkResult.Explain[i].Result,
+				"decisive_policies":   p.explainPoliciesToJSON(checkResult.Explain[i].DecisivePolicies),
+				"obligation_policies": p.explainPoliciesToJSON(checkResult.Explain[i].ObligationPolicies),
+			})
+		}
+		inlcudeResp["explain"] = explains
+	}
 
 	if len(includeParams) > 0 {
 		resp["include"] = inlcudeResp
 	}
+	return resp
+}
+
+// explainPoliciesToJSON 决策说明中的策略转换为响应结构
+func (p *policyCalcRestHandler) explainPoliciesToJSON(policies []interfaces.ExplainPolicy) []any {
+	result := make([]any, 0, len(policies))
+	for i := range policies {
+		accessorType := p.accessorTypeToStr[policies[i].AccessorType]
+		if policies[i].SystemRole {
+			accessorType = "system_role"
+		}
+		obligations := make([]any, 0, len(policies[i].Obligations))
+		for _, item := range policies[i].Obligations {
+			obligations = append(obligations, map[string]any{
+				"type_id": item.TypeID,
+				"id":      item.ID,
+				"value":   item.Value,
+			})
+		}
+		result = append(result, map[string]any{
+			"id":          policies[i].PolicyID,
+			"resource_id": policies[i].ResourceID,
+			"level":       p.policyLevelToStr[policies[i].Level],
+			"accessor": map[string]any{
+				"id":   policies[i].AccessorID,
+				"type": accessorType,
+			},
+			"obligations": obligations,
+		})
+	}
+	return result
+}
+
+func (p *policyCalcRestHandler) batchCheck(c *gin.Context) {
+	var err error
+	var jsonReq map[string]any
+	if err = validateAndBindGin(c, p.batchCheckSchema, &jsonReq); err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	itemsJson := jsonReq["items"].([]any)
+	items := make([]interfaces.BatchCheckItem, 0, len(itemsJson))
+	for _, v := range itemsJson {
+		itemJson := v.(map[string]any)
+		accessorJson := itemJson["accessor"].(map[string]any)
+		resourceJson := itemJson["resource"].(map[string]any)
+
+		item := interfaces.BatchCheckItem{
+			Accessor: interfaces.AccessorInfo{
+				ID:   accessorJson["id"].(string),
+				Type: p.visitorStrToType[accessorJson["type"].(string)],
+			},
+			Resource: interfaces.ResourceInfo{
+				ID:         resourceJson["id"].(string),
+				Type:       resourceJson["type"].(string),
+				Attributes: getResourceConditionAttrs(resourceJson),
+			},
+		}
+		setAccessorConditionAttrs(&item.Accessor, accessorJson)
+		if nameJson, ok := resourceJson["name"]; ok {
+			item.Resource.Name = nameJson.(string)
+		}
+
+		operationsJson := itemJson["operation"].([]any)
+		item.Operation = make([]string, 0, len(operationsJson))
+		for _, operation := range operationsJson {
+			item.Operation = append(item.Operation, operation.(string))
+		}
+		items = append(items, item)
+	}
+
+	includeJson, ok := jsonReq["include"]
+	includeParams := make([]interfaces.PolicCalcyIncludeType, 0)
+	includeMap := map[interfaces.PolicCalcyIncludeType]bool{}
+	if ok {
+		includeInfo := includeJson.([]any)
+		for _, v := range includeInfo {
+			vStr := v.(string)
+			if _, ok := p.includeStrToType[vStr]; !ok {
+				err = gerrors.NewError(gerrors.PublicBadRequest, "include type not found :"+vStr)
+				rest.ReplyErrorV2(c, err)
+				return
+			}
+			includeParams = append(includeParams, p.includeStrToType[vStr])
+			includeMap[p.includeStrToType[vStr]] = true
+		}
+	}
 
+	checkResults, err := p.policyCalc.BatchCheck(context.Background(), items, includeParams)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	resp := make([]any, 0, len(checkResults))
+	for i := range checkResults {
+		resp = append(resp, p.checkResultToJSON(&checkResults[i], includeParams, includeMap))
+	}
 	rest.ReplyOK(c, http.StatusOK, resp)
 }
 
@@ -260,8 +402,9 @@ func (p *policyCalcRestHandler) resourceFilter(c *gin.Context) {
 		resourceID := resourceJson["id"].(string)
 		resourceType := resourceJson["type"].(string)
 		resources = append(resources, interfaces.ResourceInfo{
-			ID:   resourceID,
-			Type: resourceType,
+			ID:         resourceID,
+			Type:       resourceType,
+			Attributes: getResourceConditionAttrs(resourceJson),
 		})
 	}
 
@@ -281,6 +424,7 @@ func (p *policyCalcRestHandler) resourceFilter(c *gin.Context) {
 		ID:   accessorID,
 		Type: accessorType,
 	}
+	setAccessorConditionAttrs(&accessor, accessorJson)
 
 	includeJson, ok := jsonReq["include"]
 	includeParams := make([]interfaces.PolicCalcyIncludeType, 0)
@@ -366,8 +510,9 @@ func (p *policyCalcRestHandler) resourceOperation(c *gin.Context) {
 		resourceType := resourceJson["type"].(string)
 
 		resources = append(resources, interfaces.ResourceInfo{
-			ID:   resourceID,
-			Type: resourceType,
+			ID:         resourceID,
+			Type:       resourceType,
+			Attributes: getResourceConditionAttrs(resourceJson),
 		})
 	}
 
@@ -375,6 +520,7 @@ func (p *policyCalcRestHandler) resourceOperation(c *gin.Context) {
 		ID:   accessorID,
 		Type: accessorType,
 	}
+	setAccessorConditionAttrs(&accessor, accessorJson)
 
 	resourceOperationMap, resourceOperationObligationMap, err := p.policyCalc.GetResourceOperation(context.Background(), resources, &accessor)
 	if err != nil {
@@ -438,8 +584,10 @@ func (p *policyCalcRestHandler) resourceOperationPublic(c *gin.Context) {
 	}
 
 	accessor := interfaces.AccessorInfo{
-		ID:   visitor.ID,
-		Type: visitor.Type,
+		ID:         visitor.ID,
+		Type:       visitor.Type,
+		IP:         visitor.IP,
+		ClientType: visitor.ClientType,
 	}
 
 	resourceOperationMap, _, err := p.policyCalc.GetResourceOperation(context.Background(), resources, &accessor)
@@ -478,8 +626,10 @@ func (p *policyCalcRestHandler) resourceTypeOperationPublic(c *gin.Context) {
 	}
 
 	accessor := interfaces.AccessorInfo{
-		ID:   visitor.ID,
-		Type: visitor.Type,
+		ID:         visitor.ID,
+		Type:       visitor.Type,
+		IP:         visitor.IP,
+		ClientType: visitor.ClientType,
 	}
 
 	resourceOperationMap, err := p.policyCalc.GetResourceTypeOperation(context.Background(), resourceTypes, &accessor)
@@ -517,6 +667,7 @@ func (p *policyCalcRestHandler) resourceList(c *gin.Context) {
 		ID:   accessorID,
 		Type: accessorType,
 	}
+	setAccessorConditionAttrs(&accessor, accessorJson)
 
 	operationsJson := jsonReq["operation"].([]any)
 	operationsStr := make([]string, 0, len(operationsJson))
@@ -583,3 +734,45 @@ func (p *policyCalcRestHandler) resourceList(c *gin.Context) {
 	}
 	rest.ReplyOK(c, http.StatusOK, result)
 }
+
+// cacheStats 策略计算缓存统计信息
+func (p *policyCalcRestHandler) cacheStats(c *gin.Context) {
+	stats := p.policyCalc.GetCacheStats()
+	rest.ReplyOK(c, http.StatusOK, gin.H{
+		"enabled":      stats.Enabled,
+		"ttl_seconds":  stats.TTLSeconds,
+		"access_token": cacheStatsToJSON(&stats.AccessToken),
+		"policy":       cacheStatsToJSON(&stats.Policy),
+	})
+}
+
+func cacheStatsToJSON(stats *interfaces.CacheStats) gin.H {
+	return gin.H{
+		"size":      stats.Size,
+		"capacity":  stats.Capacity,
+		"hits":      stats.Hits,
+		"misses":    stats.Misses,
+		"evictions": stats.Evictions,
+	}
+}
+
+// setAccessorConditionAttrs 设置访问者的策略条件计算属性: 客户端IP、设备类型、访问者属性
+func setAccessorConditionAttrs(accessor *interfaces.AccessorInfo, accessorJson map[string]any) {
+	if ip, ok := accessorJson["ip"]; ok {
+		accessor.IP = ip.(string)
+	}
+	if clientType, ok := accessorJson["client_type"]; ok {
+		accessor.ClientType = clientTypeMap[clientType.(string)]
+	}
+	if attributes, ok := accessorJson["attributes"]; ok {
+		accessor.Attributes = attributes.(map[string]any)
+	}
+}
+
+// getResourceConditionAttrs 获取资源的策略条件计算属性
+func getResourceConditionAttrs(resourceJson map[string]any) map[string]any {
+	if attributes, ok := resourceJson["attributes"]; ok {
+		return attributes.(map[string]any)
+	}
+	return nil
+}
@@ -1092,3 +1092,203 @@ func TestPolicyCalcRestHandler_NewPolicyCalcRestHandler(t *testing.T) {
 		})
 	})
 }
+
+func TestPolicyCalcRestHandler_CacheStats(t *testing.T) {
+	Convey("cacheStats", t, func() {
+		test := setGinMode()
+		defer test()
+		r := gin.New()
+		r.Use(gin.Recovery())
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPolicyCalc := mock.NewMockLogicsPolicyCalc(ctrl)
+		handler := &policyCalcRestHandler{
+			policyCalc: mockPolicyCalc,
+		}
+		handler.RegisterPrivate(r)
+
+		Convey("返回缓存统计信息", func() {
+			mockPolicyCalc.EXPECT().GetCacheStats().Return(interfaces.PolicyCalcCacheStats{
+				Enabled:     true,
+				TTLSeconds:  10,
+				AccessToken: interfaces.CacheStats{Size: 1, Capacity: 10, Hits: 3, Misses: 1},
+				Policy:      interfaces.CacheStats{Size: 2, Capacity: 10, Hits: 5, Misses: 2, Evictions: 1},
+			})
+
+			req := httptest.NewRequest("GET", "/api/authorization/v1/policy-calc-cache/stats", http.NoBody)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			var response map[string]any
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			So(err, ShouldBeNil)
+			So(response["enabled"], ShouldEqual, true)
+			So(response["ttl_seconds"], ShouldEqual, 10)
+			So(response["access_token"].(map[string]any)["hits"], ShouldEqual, 3)
+			So(response["policy"].(map[string]any)["evictions"], ShouldEqual, 1)
+		})
+	})
+}
+
+func TestPolicyCalcRestHandler_BatchCheck(t *testing.T) {
+	Convey("batchCheck", t, func() {
+		test := setGinMode()
+		defer test()
+		r := gin.New()
+		r.Use(gin.Recovery())
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPolicyCalc := mock.NewMockLogicsPolicyCalc(ctrl)
+		handler := &policyCalcRestHandler{
+			policyCalc:       mockPolicyCalc,
+			batchCheckSchema: newJSONSchema(batchCheckSchemaStr),
+			visitorStrToType: map[string]interfaces.VisitorType{
+				"user": interfaces.RealName,
+				"app":  interfaces.App,
+			},
+			includeStrToType: map[string]interfaces.PolicCalcyIncludeType{
+				"operation_obligations": interfaces.PolicCalcyIncludeOperationObligations,
+			},
+		}
+		handler.RegisterPrivate(r)
+
+		Convey("参数错误", func() {
+			reqBody := map[string]any{
+				"items":  []any{},
+				"method": "GET",
+			}
+			reqBodyBytes, _ := json.Marshal(reqBody)
+			req := httptest.NewRequest("POST", "/api/authorization/v1/operation-check/batch", bytes.NewBuffer(reqBodyBytes))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("成功批量检查, 结果与入参顺序一致", func() {
+			reqBody := map[string]any{
+				"items": []any{
+					map[string]any{
+						"accessor":  map[string]any{"id": "user1", "type": "user"},
+						"resource":  map[string]any{"id": "resource1", "type": "doc"},
+						"operation": []string{"read"},
+					},
+					map[string]any{
+						"accessor":  map[string]any{"id": "app1", "type": "app"},
+						"resource":  map[string]any{"id": "resource2", "type": "doc", "name": "测试文档"},
+						"operation": []string{"read", "write"},
+					},
+				},
+				"method":  "GET",
+				"include": []string{"operation_obligations"},
+			}
+			reqBodyBytes, _ := json.Marshal(reqBody)
+
+			mockPolicyCalc.EXPECT().BatchCheck(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ any, items []interfaces.BatchCheckItem, _ []interfaces.PolicCalcyIncludeType) ([]interfaces.CheckResult, error) {
+					So(len(items), ShouldEqual, 2)
+					So(items[0].Accessor.Type, ShouldEqual, interfaces.RealName)
+					So(items[1].Accessor.Type, ShouldEqual, interfaces.App)
+					So(items[1].Resource.Name, ShouldEqual, "测试文档")
+					So(items[1].Operation, ShouldResemble, []string{"read", "write"})
+					return []interfaces.CheckResult{
+						{
+							Result: true,
+							OperatrionOblist: map[string][]interfaces.PolicyObligationItem{
+								"read": {{TypeID: "type1", Value: map[string]any{"key": "value"}}},
+							},
+						},
+						{Result: false},
+					}, nil
+				})
+
+			req := httptest.NewRequest("POST", "/api/authorization/v1/operation-check/batch", bytes.NewBuffer(reqBodyBytes))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			var response []map[string]any
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			So(err, ShouldBeNil)
+			So(len(response), ShouldEqual, 2)
+			So(response[0]["result"], ShouldEqual, true)
+			So(len(response[0]["include"].(map[string]any)["operation_obligations"].([]any)), ShouldEqual, 1)
+			So(response[1]["result"], ShouldEqual, false)
+		})
+	})
+}
+
+func TestPolicyCalcRestHandler_CheckExplain(t *testing.T) {
+	Convey("check explain", t, func() {
+		test := setGinMode()
+		defer test()
+		r := gin.New()
+		r.Use(gin.Recovery())
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPolicyCalc := mock.NewMockLogicsPolicyCalc(ctrl)
+		handler := NewPolicyCalcRestHandler().(*policyCalcRestHandler)
+		tmpHandler := *handler
+		tmpHandler.policyCalc = mockPolicyCalc
+		tmpHandler.RegisterPrivate(r)
+
+		Convey("返回决策说明", func() {
+			reqBody := map[string]any{
+				"accessor":  map[string]any{"id": "user1", "type": "user"},
+				"resource":  map[string]any{"id": "resource1", "type": "doc"},
+				"operation": []string{"read"},
+				"method":    "GET",
+				"include":   []string{"explain"},
+			}
+			reqBodyBytes, _ := json.Marshal(reqBody)
+
+			mockPolicyCalc.EXPECT().Check(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+				[]interfaces.PolicCalcyIncludeType{interfaces.PolicCalcyIncludeExplain}).Return(interfaces.CheckResult{
+				Result: false,
+				Explain: []interfaces.OperationExplain{
+					{
+						Operation: "read",
+						DecisivePolicies: []interfaces.ExplainPolicy{
+							{
+								PolicyID:     "policy1",
+								ResourceID:   "*",
+								Level:        interfaces.PolicyLevelType,
+								AccessorID:   "role1",
+								AccessorType: interfaces.AccessorRole,
+								SystemRole:   true,
+							},
+						},
+					},
+				},
+			}, nil)
+
+			req := httptest.NewRequest("POST", "/api/authorization/v1/operation-check", bytes.NewBuffer(reqBodyBytes))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			var response map[string]any
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			So(err, ShouldBeNil)
+			So(response["result"], ShouldEqual, false)
+			explains := response["include"].(map[string]any)["explain"].([]any)
+			So(len(explains), ShouldEqual, 1)
+			explain := explains[0].(map[string]any)
+			So(explain["operation"], ShouldEqual, "read")
+			policy := explain["decisive_policies"].([]any)[0].(map[string]any)
+			So(policy["id"], ShouldEqual, "policy1")
+			So(policy["level"], ShouldEqual, "type")
+			So(policy["accessor"].(map[string]any)["type"], ShouldEqual, "system_role")
+			So(len(explain["obligation_policies"].([]any)), ShouldEqual, 0)
+		})
+	})
+}
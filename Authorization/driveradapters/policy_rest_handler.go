@@ -2,8 +2,10 @@
 package driveradapters
 
 import (
+	"bytes"
 	"context"
 	_ "embed" // 标准用法
+	"encoding/csv"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -29,6 +31,8 @@ var (
 	modifyPolicySchemaStr string
 	//go:embed jsonschema/policy/policy_delete.json
 	policyDeleteSchemaStr string
+	//go:embed jsonschema/policy/policy_simulate.json
+	policySimulateSchemaStr string
 )
 
 var (
@@ -42,9 +46,13 @@ type policyRestHandler struct {
 	policySchema       *gojsonschema.Schema
 	modifyPolicySchema *gojsonschema.Schema
 	policyDeleteSchema *gojsonschema.Schema
+	simulateSchema     *gojsonschema.Schema
 	accessorStrToType  map[string]interfaces.AccessorType
+	visitorStrToType   map[string]interfaces.VisitorType
+	visitorTypeToStr   map[interfaces.VisitorType]string
 	accessorTypeToStr  map[interfaces.AccessorType]string
 	includeStrToType   map[string]interfaces.PolicyIncludeType
+	policyLevelToStr   map[interfaces.PolicyLevel]string
 	logger             common.Logger
 }
 
@@ -57,6 +65,7 @@ func NewPolicyRestHandler() RestHandler {
 			policySchema:       newJSONSchema(policySchemaStr),
 			modifyPolicySchema: newJSONSchema(modifyPolicySchemaStr),
 			policyDeleteSchema: newJSONSchema(policyDeleteSchemaStr),
+			simulateSchema:     newJSONSchema(policySimulateSchemaStr),
 			logger:             common.NewLogger(),
 			accessorStrToType: map[string]interfaces.AccessorType{
 				// 用户、部门、用户组
@@ -73,10 +82,23 @@ func NewPolicyRestHandler() RestHandler {
 				interfaces.AccessorApp:        "app",
 				interfaces.AccessorRole:       "role",
 			},
+			visitorStrToType: map[string]interfaces.VisitorType{
+				"user": interfaces.RealName,
+				"app":  interfaces.App,
+			},
+			visitorTypeToStr: map[interfaces.VisitorType]string{
+				interfaces.RealName: "user",
+				interfaces.App:      "app",
+			},
 			includeStrToType: map[string]interfaces.PolicyIncludeType{
 				"obligation_types": interfaces.PolicyIncludeObligationType,
 				"obligations":      interfaces.PolicyIncludeObligation,
 			},
+			policyLevelToStr: map[interfaces.PolicyLevel]string{
+				interfaces.PolicyLevelSelf:     "self",
+				interfaces.PolicyLevelAncestor: "ancestor",
+				interfaces.PolicyLevelType:     "type",
+			},
 		}
 	})
 	return policyHandler
@@ -94,8 +116,11 @@ func (p *policyRestHandler) RegisterPublic(engine *gin.Engine) {
 	engine.POST("/api/authorization/v1/policy", p.create)
 	engine.PUT("/api/authorization/v1/policy/:ids", p.set)
 	engine.DELETE("/api/authorization/v1/policy/:ids", p.delete)
+	engine.POST("/api/authorization/v1/policy-simulation", p.simulate)
 	engine.GET("/api/authorization/v1/resource-policy", p.getResourcePolicy)
 	engine.GET("/api/authorization/v1/accessor-policy", p.getAccessorPolicy)
+	engine.GET("/api/authorization/v1/effective-permissions", p.getEffectivePermissions)
+	engine.GET("/api/authorization/v1/effective-permissions/export", p.exportEffectivePermissions)
 }
 
 func (p *policyRestHandler) create(c *gin.Context) {
@@ -462,6 +487,152 @@ func (p *policyRestHandler) delete(c *gin.Context) {
 	rest.ReplyOK(c, http.StatusNoContent, gin.H{})
 }
 
+func (p *policyRestHandler) simulate(c *gin.Context) {
+	visitor, err := verify(c, p.hydra)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	var jsonReq map[string]any
+	if err = validateAndBindGin(c, p.simulateSchema, &jsonReq); err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	changes := interfaces.PolicySimulationChanges{}
+	changesJson := jsonReq["changes"].(map[string]any)
+	if createJson, ok := changesJson["create"]; ok {
+		for _, v := range createJson.([]any) {
+			item := v.(map[string]any)
+			accessor := item["accessor"].(map[string]any)
+			resource := item["resource"].(map[string]any)
+			policy := interfaces.PolicyInfo{
+				AccessorID:   accessor["id"].(string),
+				AccessorType: p.accessorStrToType[accessor["type"].(string)],
+				ResourceID:   resource["id"].(string),
+				ResourceType: resource["type"].(string),
+				ResourceName: resource["name"].(string),
+			}
+			policy.Operation, policy.Condition, policy.EndTime, err = p.getPolicyOperationInfo(item)
+			if err != nil {
+				rest.ReplyErrorV2(c, err)
+				return
+			}
+			changes.Create = append(changes.Create, policy)
+		}
+	}
+	if updateJson, ok := changesJson["update"]; ok {
+		for _, v := range updateJson.([]any) {
+			item := v.(map[string]any)
+			policy := interfaces.PolicyInfo{
+				ID: item["id"].(string),
+			}
+			policy.Operation, policy.Condition, policy.EndTime, err = p.getPolicyOperationInfo(item)
+			if err != nil {
+				rest.ReplyErrorV2(c, err)
+				return
+			}
+			changes.Update = append(changes.Update, policy)
+		}
+	}
+	if deleteJson, ok := changesJson["delete"]; ok {
+		for _, v := range deleteJson.([]any) {
+			changes.Delete = append(changes.Delete, v.(string))
+		}
+	}
+
+	accessorsJson := jsonReq["accessors"].([]any)
+	accessors := make([]interfaces.AccessorInfo, 0, len(accessorsJson))
+	for _, v := range accessorsJson {
+		item := v.(map[string]any)
+		accessors = append(accessors, interfaces.AccessorInfo{
+			ID:   item["id"].(string),
+			Type: p.visitorStrToType[item["type"].(string)],
+		})
+	}
+	resourcesJson := jsonReq["resources"].([]any)
+	resources := make([]interfaces.ResourceInfo, 0, len(resourcesJson))
+	for _, v := range resourcesJson {
+		item := v.(map[string]any)
+		resources = append(resources, interfaces.ResourceInfo{
+			ID:   item["id"].(string),
+			Type: item["type"].(string),
+		})
+	}
+
+	results, err := p.policy.Simulate(context.Background(), &visitor, &changes, accessors, resources)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	resp := make([]any, 0, len(results))
+	for i := range results {
+		resp = append(resp, map[string]any{
+			"accessor": map[string]any{
+				"id":   results[i].Accessor.ID,
+				"type": p.visitorTypeToStr[results[i].Accessor.Type],
+			},
+			"resource": map[string]any{
+				"id":   results[i].Resource.ID,
+				"type": results[i].Resource.Type,
+			},
+			"current":   results[i].Current,
+			"simulated": results[i].Simulated,
+			"added":     results[i].Added,
+			"removed":   results[i].Removed,
+		})
+	}
+	rest.ReplyOK(c, http.StatusOK, resp)
+}
+
+// getPolicyOperationInfo 解析策略的操作、条件和到期时间
+func (p *policyRestHandler) getPolicyOperationInfo(jsonReq map[string]any) (operation interfaces.PolicyOperation, condition string, endTime int64, err error) {
+	operationJson := jsonReq["operation"].(map[string]any)
+	operation.Allow = []interfaces.PolicyOperationItem{}
+	operation.Deny = []interfaces.PolicyOperationItem{}
+	for _, v := range operationJson["allow"].([]any) {
+		item := v.(map[string]any)
+		allowItem := interfaces.PolicyOperationItem{
+			ID: item["id"].(string),
+		}
+		obligationsJson, exist := item["obligations"]
+		if exist {
+			allowItem.Obligations, err = p.getObligations(obligationsJson)
+			if err != nil {
+				return
+			}
+		}
+		operation.Allow = append(operation.Allow, allowItem)
+	}
+	for _, v := range operationJson["deny"].([]any) {
+		item := v.(map[string]any)
+		operation.Deny = append(operation.Deny, interfaces.PolicyOperationItem{
+			ID: item["id"].(string),
+		})
+	}
+
+	if conditionJson, exist := jsonReq["condition"]; exist {
+		condition = conditionJson.(string)
+	}
+
+	// 数据库中 -1 表示永久 单位使用微妙
+	endTime = -1
+	if expiresAtJson, exist := jsonReq["expires_at"]; exist {
+		var timeStamp int64
+		timeStamp, err = rest.StringToTimeStamp(expiresAtJson.(string))
+		if err != nil {
+			err = gerrors.NewError(gerrors.PublicBadRequest, "param expires_at is invalid")
+			return
+		}
+		if timeStamp != 0 {
+			endTime = timeStamp / 1000
+		}
+	}
+	return
+}
+
 func (p *policyRestHandler) get(c *gin.Context) {
 	visitor, err := verify(c, p.hydra)
 	if err != nil {
@@ -837,3 +1008,149 @@ func (p *policyRestHandler) getResourcePolicy(c *gin.Context) {
 
 	rest.ReplyOK(c, http.StatusOK, resp)
 }
+
+// getEffectivePermissionParam 解析有效权限报表的访问者和资源类型参数
+func (p *policyRestHandler) getEffectivePermissionParam(c *gin.Context) (param interfaces.EffectivePermissionParam, err error) {
+	accessorType, ok := p.visitorStrToType[c.Query("accessor_type")]
+	if !ok {
+		err = gerrors.NewError(gerrors.PublicBadRequest, "accessor_type is invalid")
+		return
+	}
+	param.Accessor = interfaces.AccessorInfo{
+		ID:   c.Query("accessor_id"),
+		Type: accessorType,
+	}
+	param.ResourceType = c.Query("resource_type")
+	return
+}
+
+func (p *policyRestHandler) getEffectivePermissions(c *gin.Context) {
+	visitor, err := verify(c, p.hydra)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	param, err := p.getEffectivePermissionParam(c)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+	queryInfo, err := getListQueryParam(c)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+	param.Offset = queryInfo.offset
+	param.Limit = queryInfo.limit
+
+	count, permissions, err := p.policy.GetEffectivePermissions(context.Background(), &visitor, param)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusOK, map[string]any{
+		"total_count": count,
+		"entries":     p.effectivePermissionsToJSON(permissions),
+	})
+}
+
+// exportEffectivePermissions 导出访问者的全部有效权限, 支持 json 和 csv 格式
+func (p *policyRestHandler) exportEffectivePermissions(c *gin.Context) {
+	visitor, err := verify(c, p.hydra)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	param, err := p.getEffectivePermissionParam(c)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		rest.ReplyErrorV2(c, gerrors.NewError(gerrors.PublicBadRequest, "format is invalid"))
+		return
+	}
+
+	_, permissions, err := p.policy.GetEffectivePermissions(context.Background(), &visitor, param)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	fileName := fmt.Sprintf("effective-permissions-%s.%s", param.Accessor.ID, format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	if format == "json" {
+		c.JSON(http.StatusOK, p.effectivePermissionsToJSON(permissions))
+		return
+	}
+
+	// csv 每行为一个操作的一个来源策略
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"resource_type", "resource_id", "resource_name", "operation",
+		"policy_id", "policy_resource_id", "level", "accessor_type", "accessor_id", "accessor_name"})
+	for i := range permissions {
+		for _, op := range permissions[i].Operations {
+			for _, source := range op.Sources {
+				_ = w.Write([]string{
+					permissions[i].ResourceType, permissions[i].ResourceID, permissions[i].ResourceName, op.Operation,
+					source.PolicyID, source.ResourceID, p.policyLevelToStr[source.Level],
+					p.sourceAccessorTypeToStr(&source), source.AccessorID, source.AccessorName,
+				})
+			}
+		}
+	}
+	w.Flush()
+	if err = w.Error(); err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// sourceAccessorTypeToStr 权限来源的访问令牌类型, 系统角色单独标识
+func (p *policyRestHandler) sourceAccessorTypeToStr(source *interfaces.ExplainPolicy) string {
+	if source.SystemRole {
+		return "system_role"
+	}
+	return p.accessorTypeToStr[source.AccessorType]
+}
+
+func (p *policyRestHandler) effectivePermissionsToJSON(permissions []interfaces.EffectivePermission) []any {
+	entries := make([]any, 0, len(permissions))
+	for i := range permissions {
+		operations := make([]any, 0, len(permissions[i].Operations))
+		for _, op := range permissions[i].Operations {
+			sources := make([]any, 0, len(op.Sources))
+			for j := range op.Sources {
+				sources = append(sources, map[string]any{
+					"policy_id":   op.Sources[j].PolicyID,
+					"resource_id": op.Sources[j].ResourceID,
+					"level":       p.policyLevelToStr[op.Sources[j].Level],
+					"accessor": map[string]any{
+						"id":   op.Sources[j].AccessorID,
+						"type": p.sourceAccessorTypeToStr(&op.Sources[j]),
+						"name": op.Sources[j].AccessorName,
+					},
+				})
+			}
+			operations = append(operations, map[string]any{
+				"id":      op.Operation,
+				"sources": sources,
+			})
+		}
+		entries = append(entries, map[string]any{
+			"resource": map[string]any{
+				"id":   permissions[i].ResourceID,
+				"type": permissions[i].ResourceType,
+				"name": permissions[i].ResourceName,
+			},
+			"operations": operations,
+		})
+	}
+	return entries
+}
@@ -1375,3 +1375,225 @@ func TestPolicyRestHandler_GetObligations(t *testing.T) {
 		})
 	})
 }
+
+func TestPolicyRestHandler_Simulate(t *testing.T) {
+	Convey("simulate", t, func() {
+		test := setGinMode()
+		defer test()
+		r := gin.New()
+		r.Use(gin.Recovery())
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPolicy := mock.NewMockLogicsPolicy(ctrl)
+		mockHydra := mock.NewMockHydra(ctrl)
+		handler := &policyRestHandler{
+			policy:         mockPolicy,
+			hydra:          mockHydra,
+			simulateSchema: newJSONSchema(policySimulateSchemaStr),
+			accessorStrToType: map[string]interfaces.AccessorType{
+				"user": interfaces.AccessorUser,
+			},
+			visitorStrToType: map[string]interfaces.VisitorType{
+				"user": interfaces.RealName,
+				"app":  interfaces.App,
+			},
+			visitorTypeToStr: map[interfaces.VisitorType]string{
+				interfaces.RealName: "user",
+				interfaces.App:      "app",
+			},
+		}
+		handler.RegisterPublic(r)
+
+		mockHydra.EXPECT().Introspect("test-token").AnyTimes().Return(interfaces.TokenIntrospectInfo{
+			Active:     true,
+			VisitorID:  "admin1",
+			VisitorTyp: interfaces.RealName,
+		}, nil)
+
+		doRequest := func(reqBody map[string]any) *httptest.ResponseRecorder {
+			reqBodyBytes, _ := json.Marshal(reqBody)
+			req := httptest.NewRequest("POST", "/api/authorization/v1/policy-simulation", bytes.NewBuffer(reqBodyBytes))
+			req.Header.Set("Authorization", "Bearer test-token")
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			return w
+		}
+
+		Convey("参数错误, 访问者为空", func() {
+			w := doRequest(map[string]any{
+				"changes":   map[string]any{},
+				"accessors": []any{},
+				"resources": []any{map[string]any{"id": "resource1", "type": "doc"}},
+			})
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("成功", func() {
+			var changes *interfaces.PolicySimulationChanges
+			mockPolicy.EXPECT().Simulate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ any, _ *interfaces.Visitor, c *interfaces.PolicySimulationChanges, accessors []interfaces.AccessorInfo,
+					resources []interfaces.ResourceInfo,
+				) ([]interfaces.PolicySimulationResult, error) {
+					changes = c
+					return []interfaces.PolicySimulationResult{
+						{
+							Accessor:  accessors[0],
+							Resource:  resources[0],
+							Current:   []string{"read"},
+							Simulated: []string{"read", "write"},
+							Added:     []string{"write"},
+							Removed:   []string{},
+						},
+					}, nil
+				})
+			w := doRequest(map[string]any{
+				"changes": map[string]any{
+					"create": []any{
+						map[string]any{
+							"accessor":  map[string]any{"id": "user1", "type": "user"},
+							"resource":  map[string]any{"id": "resource1", "name": "测试文档", "type": "doc"},
+							"operation": map[string]any{"allow": []any{map[string]any{"id": "write"}}, "deny": []any{}},
+						},
+					},
+					"update": []any{
+						map[string]any{
+							"id":        "policy1",
+							"operation": map[string]any{"allow": []any{}, "deny": []any{map[string]any{"id": "delete"}}},
+						},
+					},
+					"delete": []any{"policy2"},
+				},
+				"accessors": []any{map[string]any{"id": "user1", "type": "user"}},
+				"resources": []any{map[string]any{"id": "resource1", "type": "doc"}},
+			})
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(len(changes.Create), ShouldEqual, 1)
+			So(changes.Create[0].AccessorType, ShouldEqual, interfaces.AccessorUser)
+			So(changes.Update[0].Operation.Deny[0].ID, ShouldEqual, "delete")
+			So(changes.Delete, ShouldResemble, []string{"policy2"})
+
+			var resp []map[string]any
+			_ = json.Unmarshal(w.Body.Bytes(), &resp)
+			So(len(resp), ShouldEqual, 1)
+			So(resp[0]["accessor"].(map[string]any)["type"], ShouldEqual, "user")
+			So(resp[0]["added"], ShouldResemble, []any{"write"})
+			So(resp[0]["removed"], ShouldResemble, []any{})
+		})
+
+		Convey("模拟失败", func() {
+			mockPolicy.EXPECT().Simulate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(nil, gerrors.NewError(gerrors.PublicForbidden, "forbidden"))
+			w := doRequest(map[string]any{
+				"changes":   map[string]any{"delete": []any{"policy2"}},
+				"accessors": []any{map[string]any{"id": "user1", "type": "user"}},
+				"resources": []any{map[string]any{"id": "resource1", "type": "doc"}},
+			})
+			So(w.Code, ShouldEqual, http.StatusForbidden)
+		})
+	})
+}
+
+func TestPolicyRestHandler_EffectivePermissions(t *testing.T) {
+	Convey("effective permissions", t, func() {
+		test := setGinMode()
+		defer test()
+		r := gin.New()
+		r.Use(gin.Recovery())
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPolicy := mock.NewMockLogicsPolicy(ctrl)
+		mockHydra := mock.NewMockHydra(ctrl)
+		handler := &policyRestHandler{
+			policy: mockPolicy,
+			hydra:  mockHydra,
+			visitorStrToType: map[string]interfaces.VisitorType{
+				"user": interfaces.RealName,
+				"app":  interfaces.App,
+			},
+			accessorTypeToStr: map[interfaces.AccessorType]string{
+				interfaces.AccessorDepartment: "department",
+				interfaces.AccessorRole:       "role",
+			},
+			policyLevelToStr: map[interfaces.PolicyLevel]string{
+				interfaces.PolicyLevelSelf: "self",
+				interfaces.PolicyLevelType: "type",
+			},
+		}
+		handler.RegisterPublic(r)
+
+		mockHydra.EXPECT().Introspect("test-token").AnyTimes().Return(interfaces.TokenIntrospectInfo{
+			Active:     true,
+			VisitorID:  "admin1",
+			VisitorTyp: interfaces.RealName,
+		}, nil)
+
+		doRequest := func(url string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", url, http.NoBody)
+			req.Header.Set("Authorization", "Bearer test-token")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			return w
+		}
+
+		permissions := []interfaces.EffectivePermission{
+			{
+				ResourceID:   "resource1",
+				ResourceType: "doc",
+				ResourceName: "文档1",
+				Operations: []interfaces.EffectiveOperation{
+					{Operation: "read", Sources: []interfaces.ExplainPolicy{
+						{PolicyID: "policy1", ResourceID: "*", Level: interfaces.PolicyLevelType, AccessorID: "role1", AccessorType: interfaces.AccessorRole, AccessorName: "角色1"},
+						{PolicyID: "policy2", ResourceID: "resource1", Level: interfaces.PolicyLevelSelf, AccessorID: "role2", AccessorType: interfaces.AccessorRole, SystemRole: true},
+					}},
+				},
+			},
+		}
+
+		Convey("访问者类型错误", func() {
+			w := doRequest("/api/authorization/v1/effective-permissions?accessor_id=user1&accessor_type=department")
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("分页获取", func() {
+			mockPolicy.EXPECT().GetEffectivePermissions(gomock.Any(), gomock.Any(), interfaces.EffectivePermissionParam{
+				Accessor:     interfaces.AccessorInfo{ID: "user1", Type: interfaces.RealName},
+				ResourceType: "doc",
+				Offset:       0,
+				Limit:        10,
+			}).Return(3, permissions, nil)
+			w := doRequest("/api/authorization/v1/effective-permissions?accessor_id=user1&accessor_type=user&resource_type=doc&limit=10")
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			var resp map[string]any
+			_ = json.Unmarshal(w.Body.Bytes(), &resp)
+			So(resp["total_count"], ShouldEqual, 3)
+			entry := resp["entries"].([]any)[0].(map[string]any)
+			So(entry["resource"], ShouldResemble, map[string]any{"id": "resource1", "type": "doc", "name": "文档1"})
+			sources := entry["operations"].([]any)[0].(map[string]any)["sources"].([]any)
+			So(sources[0].(map[string]any)["level"], ShouldEqual, "type")
+			So(sources[1].(map[string]any)["accessor"].(map[string]any)["type"], ShouldEqual, "system_role")
+		})
+
+		Convey("导出csv", func() {
+			mockPolicy.EXPECT().GetEffectivePermissions(gomock.Any(), gomock.Any(), interfaces.EffectivePermissionParam{
+				Accessor: interfaces.AccessorInfo{ID: "user1", Type: interfaces.RealName},
+			}).Return(1, permissions, nil)
+			w := doRequest("/api/authorization/v1/effective-permissions/export?accessor_id=user1&accessor_type=user&format=csv")
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Header().Get("Content-Disposition"), ShouldContainSubstring, "effective-permissions-user1.csv")
+			So(w.Body.String(), ShouldEqual, "resource_type,resource_id,resource_name,operation,policy_id,policy_resource_id,level,accessor_type,accessor_id,accessor_name\n"+
+				"doc,resource1,文档1,read,policy1,*,type,role,role1,角色1\n"+
+				"doc,resource1,文档1,read,policy2,resource1,self,system_role,role2,\n")
+		})
+
+		Convey("导出格式错误", func() {
+			w := doRequest("/api/authorization/v1/effective-permissions/export?accessor_id=user1&accessor_type=user&format=xml")
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+	})
+}
@@ -0,0 +1,324 @@
+// Package driveradapters AnyShare 入站适配器
+package driveradapters
+
+import (
+	"context"
+	_ "embed" // 标准用法
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xeipuuv/gojsonschema"
+
+	gerrors "github.com/kweaver-ai/go-lib/error"
+	"github.com/kweaver-ai/go-lib/rest"
+
+	"Authorization/interfaces"
+	"Authorization/logics"
+)
+
+//go:embed jsonschema/role_exclusion/add.json
+var addRoleExclusionSchemaStr string
+
+//go:embed jsonschema/role_exclusion/update.json
+var updateRoleExclusionSchemaStr string
+
+var (
+	roleExclusionOnce    sync.Once
+	roleExclusionHandler RestHandler
+)
+
+type roleExclusionRestHandler struct {
+	roleExclusion     interfaces.LogicsRoleExclusion
+	hydra             interfaces.Hydra
+	addSchemaStr      *gojsonschema.Schema
+	updateSchemaStr   *gojsonschema.Schema
+	accessorTypeToStr map[interfaces.AccessorType]string
+}
+
+// NewRoleExclusionRestHandler 互斥角色集适配器接口
+func NewRoleExclusionRestHandler() RestHandler {
+	roleExclusionOnce.Do(func() {
+		roleExclusionHandler = &roleExclusionRestHandler{
+			roleExclusion:   logics.NewRoleExclusion(),
+			hydra:           newHydra(),
+			addSchemaStr:    newJSONSchema(addRoleExclusionSchemaStr),
+			updateSchemaStr: newJSONSchema(updateRoleExclusionSchemaStr),
+			accessorTypeToStr: map[interfaces.AccessorType]string{
+				interfaces.AccessorUser: "user",
+				interfaces.AccessorApp:  "app",
+			},
+		}
+	})
+	return roleExclusionHandler
+}
+
+// RegisterPrivate 注册内部API
+func (r *roleExclusionRestHandler) RegisterPrivate(_ *gin.Engine) {
+}
+
+// RegisterPublic 注册外部API
+func (r *roleExclusionRestHandler) RegisterPublic(engine *gin.Engine) {
+	// 互斥角色集管理接口
+	engine.POST("/api/authorization/v1/role-exclusions", r.add)
+	engine.GET("/api/authorization/v1/role-exclusions", r.get)
+	engine.DELETE("/api/authorization/v1/role-exclusions/:id", r.delete)
+	engine.GET("/api/authorization/v1/role-exclusions/:id", r.getByID)
+	engine.PUT("/api/authorization/v1/role-exclusions/:id/:fields", r.update)
+
+	// 违反互斥角色集的访问者
+	engine.GET("/api/authorization/v1/role-exclusion-violations", r.getViolations)
+}
+
+func (r *roleExclusionRestHandler) add(c *gin.Context) {
+	visitor, err := verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	var exclusionDr map[string]any
+	if err = validateAndBindGin(c, r.addSchemaStr, &exclusionDr); err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	info := interfaces.RoleExclusionInfo{
+		Name:    exclusionDr["name"].(string),
+		RoleIDs: anySliceToStrings(exclusionDr["role_ids"].([]any)),
+	}
+	descriptionJSON, ok := exclusionDr["description"]
+	if ok {
+		info.Description = descriptionJSON.(string)
+	}
+
+	id, err := r.roleExclusion.Add(context.Background(), &visitor, &info)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	c.Writer.Header().Set("Location", fmt.Sprintf("/api/authorization/v1/role-exclusions/%s", id))
+
+	rest.ReplyOK(c, http.StatusCreated, map[string]any{
+		"id": id,
+	})
+}
+
+func (r *roleExclusionRestHandler) update(c *gin.Context) {
+	visitor, err := verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		rest.ReplyErrorV2(c, gerrors.NewError(gerrors.PublicBadRequest, "id is required"))
+		return
+	}
+
+	fields := strings.Split(c.Param("fields"), ",")
+	var nameExist, descriptionExist, roleIDsExist bool
+	for _, v := range fields {
+		switch v {
+		case "name":
+			nameExist = true
+		case "description":
+			descriptionExist = true
+		case "role_ids":
+			roleIDsExist = true
+		default:
+			rest.ReplyErrorV2(c, gerrors.NewError(gerrors.PublicBadRequest, fmt.Sprintf("field %s is illegal", v)))
+			return
+		}
+	}
+
+	var exclusionDr map[string]any
+	if err = validateAndBindGin(c, r.updateSchemaStr, &exclusionDr); err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	name := ""
+	if nameExist {
+		nameJSON, ok := exclusionDr["name"]
+		if !ok {
+			rest.ReplyErrorV2(c, gerrors.NewError(gerrors.PublicBadRequest, "param name is illegal"))
+			return
+		}
+		name = nameJSON.(string)
+	}
+
+	description := ""
+	if descriptionExist {
+		descriptionJSON, ok := exclusionDr["description"]
+		if !ok {
+			rest.ReplyErrorV2(c, gerrors.NewError(gerrors.PublicBadRequest, "param description is illegal"))
+			return
+		}
+		description = descriptionJSON.(string)
+	}
+
+	var roleIDs []string
+	if roleIDsExist {
+		roleIDsJSON, ok := exclusionDr["role_ids"]
+		if !ok {
+			rest.ReplyErrorV2(c, gerrors.NewError(gerrors.PublicBadRequest, "param role_ids is illegal"))
+			return
+		}
+		roleIDs = anySliceToStrings(roleIDsJSON.([]any))
+	}
+
+	err = r.roleExclusion.Update(context.Background(), &visitor, id, name, nameExist,
+		description, descriptionExist, roleIDs, roleIDsExist)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusNoContent, nil)
+}
+
+func (r *roleExclusionRestHandler) delete(c *gin.Context) {
+	visitor, err := verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		rest.ReplyErrorV2(c, gerrors.NewError(gerrors.PublicBadRequest, "id is required"))
+		return
+	}
+
+	err = r.roleExclusion.Delete(context.Background(), &visitor, id)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusNoContent, nil)
+}
+
+func (r *roleExclusionRestHandler) getByID(c *gin.Context) {
+	visitor, err := verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		rest.ReplyErrorV2(c, gerrors.NewError(gerrors.PublicBadRequest, "id is required"))
+		return
+	}
+
+	info, err := r.roleExclusion.GetByID(context.Background(), &visitor, id)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusOK, r.exclusionToJSON(&info))
+}
+
+func (r *roleExclusionRestHandler) get(c *gin.Context) {
+	visitor, err := verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	queryInfo, err := getListQueryParam(c)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+	searchInfo := interfaces.RoleExclusionSearchInfo{
+		Offset: queryInfo.offset,
+		Limit:  queryInfo.limit,
+	}
+
+	count, infos, err := r.roleExclusion.Get(context.Background(), &visitor, &searchInfo)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	entries := make([]map[string]any, 0, len(infos))
+	for i := range infos {
+		entries = append(entries, r.exclusionToJSON(&infos[i]))
+	}
+
+	rest.ReplyOK(c, http.StatusOK, map[string]any{
+		"total_count": count,
+		"entries":     entries,
+	})
+}
+
+func (r *roleExclusionRestHandler) getViolations(c *gin.Context) {
+	visitor, err := verify(c, r.hydra)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	violations, err := r.roleExclusion.GetViolations(context.Background(), &visitor)
+	if err != nil {
+		rest.ReplyErrorV2(c, err)
+		return
+	}
+
+	entries := make([]map[string]any, 0, len(violations))
+	for i := range violations {
+		entries = append(entries, map[string]any{
+			"exclusion": map[string]any{
+				"id":   violations[i].Exclusion.ID,
+				"name": violations[i].Exclusion.Name,
+			},
+			"accessor": map[string]any{
+				"id":   violations[i].AccessorID,
+				"type": r.accessorTypeToStr[violations[i].AccessorType],
+				"name": violations[i].AccessorName,
+			},
+			"roles": nameInfosToJSON(violations[i].Roles),
+		})
+	}
+
+	rest.ReplyOK(c, http.StatusOK, map[string]any{
+		"total_count": len(entries),
+		"entries":     entries,
+	})
+}
+
+func (r *roleExclusionRestHandler) exclusionToJSON(info *interfaces.RoleExclusionInfo) map[string]any {
+	return map[string]any{
+		"id":          info.ID,
+		"name":        info.Name,
+		"description": info.Description,
+		"roles":       nameInfosToJSON(info.Roles),
+	}
+}
+
+func nameInfosToJSON(infos []interfaces.NameInfo) []map[string]any {
+	result := make([]map[string]any, 0, len(infos))
+	for _, info := range infos {
+		result = append(result, map[string]any{
+			"id":   info.ID,
+			"name": info.Name,
+		})
+	}
+	return result
+}
+
+func anySliceToStrings(values []any) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		result = append(result, v.(string))
+	}
+	return result
+}
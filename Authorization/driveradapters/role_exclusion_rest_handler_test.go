@@ -0,0 +1,164 @@
+//nolint:gocritic,funlen
+package driveradapters
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+
+	"Authorization/interfaces"
+	"Authorization/interfaces/mock"
+)
+
+func newRoleExclusionRestHandlerForTest(ctrl *gomock.Controller) (*roleExclusionRestHandler, *mock.MockLogicsRoleExclusion, *gin.Engine) {
+	mockRoleExclusion := mock.NewMockLogicsRoleExclusion(ctrl)
+	mockHydra := mock.NewMockHydra(ctrl)
+	mockHydra.EXPECT().Introspect("test-token").Return(interfaces.TokenIntrospectInfo{
+		Active:    true,
+		VisitorID: "user1",
+	}, nil).AnyTimes()
+
+	handler := &roleExclusionRestHandler{
+		roleExclusion:   mockRoleExclusion,
+		hydra:           mockHydra,
+		addSchemaStr:    newJSONSchema(addRoleExclusionSchemaStr),
+		updateSchemaStr: newJSONSchema(updateRoleExclusionSchemaStr),
+		accessorTypeToStr: map[interfaces.AccessorType]string{
+			interfaces.AccessorUser: "user",
+			interfaces.AccessorApp:  "app",
+		},
+	}
+	r := gin.New()
+	r.Use(gin.Recovery())
+	handler.RegisterPublic(r)
+	return handler, mockRoleExclusion, r
+}
+
+func doRoleExclusionRequest(r *gin.Engine, method, url string, body any) *httptest.ResponseRecorder {
+	var reqBody []byte
+	if body != nil {
+		reqBody, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, url, bytes.NewReader(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer test-token")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRoleExclusionRestHandler_Add(t *testing.T) {
+	Convey("add", t, func() {
+		test := setGinMode()
+		defer test()
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		_, mockRoleExclusion, r := newRoleExclusionRestHandlerForTest(ctrl)
+
+		Convey("角色少于两个", func() {
+			w := doRoleExclusionRequest(r, http.MethodPost, "/api/authorization/v1/role-exclusions", map[string]any{
+				"name":     "ex",
+				"role_ids": []string{"role1"},
+			})
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("添加成功", func() {
+			mockRoleExclusion.EXPECT().Add(gomock.Any(), gomock.Any(), &interfaces.RoleExclusionInfo{
+				Name:        "ex",
+				Description: "desc",
+				RoleIDs:     []string{"role1", "role2"},
+			}).Return("ex1", nil)
+			w := doRoleExclusionRequest(r, http.MethodPost, "/api/authorization/v1/role-exclusions", map[string]any{
+				"name":        "ex",
+				"description": "desc",
+				"role_ids":    []string{"role1", "role2"},
+			})
+			So(w.Code, ShouldEqual, http.StatusCreated)
+			So(w.Header().Get("Location"), ShouldEqual, "/api/authorization/v1/role-exclusions/ex1")
+		})
+	})
+}
+
+func TestRoleExclusionRestHandler_Update(t *testing.T) {
+	Convey("update", t, func() {
+		test := setGinMode()
+		defer test()
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		_, mockRoleExclusion, r := newRoleExclusionRestHandlerForTest(ctrl)
+
+		Convey("字段不合法", func() {
+			w := doRoleExclusionRequest(r, http.MethodPut, "/api/authorization/v1/role-exclusions/ex1/name,id", map[string]any{
+				"name": "ex",
+			})
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("缺少指定的字段", func() {
+			w := doRoleExclusionRequest(r, http.MethodPut, "/api/authorization/v1/role-exclusions/ex1/role_ids", map[string]any{
+				"name": "ex",
+			})
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("更新成功", func() {
+			mockRoleExclusion.EXPECT().Update(gomock.Any(), gomock.Any(), "ex1", "", false, "", false, []string{"role1", "role3"}, true).Return(nil)
+			w := doRoleExclusionRequest(r, http.MethodPut, "/api/authorization/v1/role-exclusions/ex1/role_ids", map[string]any{
+				"role_ids": []string{"role1", "role3"},
+			})
+			So(w.Code, ShouldEqual, http.StatusNoContent)
+		})
+	})
+}
+
+func TestRoleExclusionRestHandler_Get(t *testing.T) {
+	Convey("get", t, func() {
+		test := setGinMode()
+		defer test()
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		_, mockRoleExclusion, r := newRoleExclusionRestHandlerForTest(ctrl)
+
+		Convey("列举成功", func() {
+			mockRoleExclusion.EXPECT().Get(gomock.Any(), gomock.Any(), &interfaces.RoleExclusionSearchInfo{Offset: 0, Limit: 20}).Return(1, []interfaces.RoleExclusionInfo{
+				{ID: "ex1", Name: "ex", RoleIDs: []string{"role1", "role2"}, Roles: []interfaces.NameInfo{{ID: "role1", Name: "角色1"}, {ID: "role2", Name: "角色2"}}},
+			}, nil)
+			w := doRoleExclusionRequest(r, http.MethodGet, "/api/authorization/v1/role-exclusions?offset=0&limit=20", nil)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			var result map[string]any
+			So(json.Unmarshal(w.Body.Bytes(), &result), ShouldBeNil)
+			So(result["total_count"], ShouldEqual, 1)
+			entry := result["entries"].([]any)[0].(map[string]any)
+			So(entry["id"], ShouldEqual, "ex1")
+			So(len(entry["roles"].([]any)), ShouldEqual, 2)
+		})
+
+		Convey("获取违反互斥角色集的访问者", func() {
+			mockRoleExclusion.EXPECT().GetViolations(gomock.Any(), gomock.Any()).Return([]interfaces.RoleExclusionViolation{
+				{
+					Exclusion:    interfaces.NameInfo{ID: "ex1", Name: "ex"},
+					AccessorID:   "user2",
+					AccessorType: interfaces.AccessorUser,
+					AccessorName: "用户2",
+					Roles:        []interfaces.NameInfo{{ID: "role1", Name: "角色1"}, {ID: "role2", Name: "角色2"}},
+				},
+			}, nil)
+			w := doRoleExclusionRequest(r, http.MethodGet, "/api/authorization/v1/role-exclusion-violations", nil)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			var result map[string]any
+			So(json.Unmarshal(w.Body.Bytes(), &result), ShouldBeNil)
+			entry := result["entries"].([]any)[0].(map[string]any)
+			So(entry["accessor"], ShouldResemble, map[string]any{"id": "user2", "type": "user", "name": "用户2"})
+			So(entry["exclusion"], ShouldResemble, map[string]any{"id": "ex1", "name": "ex"})
+		})
+	})
+}
@@ -56,12 +56,14 @@ func NewRoleRestHandler() RestHandler {
 				"department": interfaces.AccessorDepartment,
 				"group":      interfaces.AccessorGroup,
 				"app":        interfaces.AccessorApp,
+				"role":       interfaces.AccessorRole,
 			},
 			memberIntTypes: map[interfaces.AccessorType]string{
 				interfaces.AccessorUser:       "user",
 				interfaces.AccessorDepartment: "department",
 				interfaces.AccessorGroup:      "group",
 				interfaces.AccessorApp:        "app",
+				interfaces.AccessorRole:       "role",
 			},
 			createRoleSchema:       newJSONSchema(createRoleSchemaStr),
 			addDeleteMembersSchema: newJSONSchema(addDeleteMembersSchemaStr),
@@ -442,6 +444,13 @@ func (r *roleRestHandler) getRoleMembers(c *gin.Context) {
 		}
 	}
 
+	// effective 为 true 时返回展开继承角色后的有效成员
+	effective, err := strconv.ParseBool(c.DefaultQuery("effective", "false"))
+	if err != nil {
+		rest.ReplyErrorV2(c, gerrors.NewError(gerrors.PublicBadRequest, "param effective is illegal"))
+		return
+	}
+
 	keyword := c.DefaultQuery("keyword", "")
 	searchInfo := interfaces.RoleMemberSearchInfo{
 		Offset:      queryInfo.offset,
@@ -451,7 +460,13 @@ func (r *roleRestHandler) getRoleMembers(c *gin.Context) {
 	}
 
 	// 列举组成员
-	num, infos, err := r.role.GetRoleMembers(c, &visitor, roleID, searchInfo)
+	var num int
+	var infos []interfaces.RoleMemberInfo
+	if effective {
+		num, infos, err = r.role.GetRoleEffectiveMembers(c, &visitor, roleID, searchInfo)
+	} else {
+		num, infos, err = r.role.GetRoleMembers(c, &visitor, roleID, searchInfo)
+	}
 	if err != nil {
 		rest.ReplyErrorV2(c, err)
 		return
@@ -508,6 +523,12 @@ func (r *roleRestHandler) addOrDeleteRoleMembers(c *gin.Context) {
 			rest.ReplyErrorV2(c, err)
 			return
 		}
+		var err error
+		member.StartTime, member.EndTime, err = r.getMemberTime(temp)
+		if err != nil {
+			rest.ReplyErrorV2(c, err)
+			return
+		}
 
 		memberInfos[member.ID] = member
 	}
@@ -526,6 +547,39 @@ func (r *roleRestHandler) addOrDeleteRoleMembers(c *gin.Context) {
 	rest.ReplyOK(c, http.StatusNoContent, nil)
 }
 
+// getMemberTime 获取成员的生效时间和过期时间, 单位微秒。未设置生效时间为 0, 未设置过期时间为 -1
+func (r *roleRestHandler) getMemberTime(member map[string]any) (startTime, endTime int64, err error) {
+	endTime = -1
+	if startsAt, ok := member["starts_at"]; ok {
+		var timeStamp int64
+		timeStamp, err = rest.StringToTimeStamp(startsAt.(string))
+		if err != nil || timeStamp < 0 {
+			return 0, 0, gerrors.NewError(gerrors.PublicBadRequest, "param starts_at is invalid")
+		}
+		startTime = timeStamp / 1000
+	}
+	if expiresAt, ok := member["expires_at"]; ok {
+		var timeStamp int64
+		timeStamp, err = rest.StringToTimeStamp(expiresAt.(string))
+		if err != nil {
+			return 0, 0, gerrors.NewError(gerrors.PublicBadRequest, "param expires_at is invalid")
+		}
+		// 数据库中 -1 表示永久
+		if timeStamp != 0 {
+			endTime = timeStamp / 1000
+		}
+	}
+	return startTime, endTime, nil
+}
+
+// memberTimeToString 成员时间转换为 RFC3339 字符串, 单位微秒, 永久有效和立即生效均返回 0 时刻
+func memberTimeToString(t int64) string {
+	if t <= 0 {
+		return rest.TimeStampToString(0)
+	}
+	return rest.TimeStampToString(t * 1000)
+}
+
 // handleGroupMemberInfos 处理用户组成员信息
 func (r *roleRestHandler) handleGroupMemberInfo(input *interfaces.RoleMemberInfo) any {
 	tempMemberInfo := make(map[string]any)
@@ -533,6 +587,11 @@ func (r *roleRestHandler) handleGroupMemberInfo(input *interfaces.RoleMemberInfo
 	tempMemberInfo["type"] = r.memberIntTypes[input.MemberType]
 	tempMemberInfo["name"] = input.Name
 	tempMemberInfo["parent_deps"] = input.ParentDeps
+	tempMemberInfo["starts_at"] = memberTimeToString(input.StartTime)
+	tempMemberInfo["expires_at"] = memberTimeToString(input.EndTime)
+	if input.ViaRoles != nil {
+		tempMemberInfo["via_roles"] = input.ViaRoles
+	}
 	return tempMemberInfo
 }
 
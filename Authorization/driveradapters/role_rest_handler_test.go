@@ -475,6 +475,54 @@ func TestRoleRestHandler_GetRoleMembers(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(response["total_count"], ShouldEqual, float64(2))
 		})
+
+		Convey("获取角色有效成员列表", func() {
+			mockHydra.EXPECT().Introspect("test-token").Return(interfaces.TokenIntrospectInfo{
+				Active:     true,
+				VisitorID:  "admin1",
+				VisitorTyp: interfaces.RealName,
+			}, nil)
+
+			expectedMembers := []interfaces.RoleMemberInfo{
+				{
+					ID:         "user1",
+					MemberType: interfaces.AccessorUser,
+					Name:       "用户1",
+					ParentDeps: [][]interfaces.Department{},
+					ViaRoles:   []interfaces.NameInfo{{ID: "role2", Name: "角色2"}},
+				},
+			}
+			mockRole.EXPECT().GetRoleEffectiveMembers(gomock.Any(), gomock.Any(), "role1", gomock.Any()).Return(1, expectedMembers, nil)
+
+			req := httptest.NewRequest("GET", "/api/authorization/v1/role-members/role1?offset=0&limit=10&effective=true", nil)
+			req.Header.Set("Authorization", "Bearer test-token")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			var response map[string]any
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			So(err, ShouldBeNil)
+			entry := response["entries"].([]any)[0].(map[string]any)
+			viaRoles := entry["via_roles"].([]any)
+			So(len(viaRoles), ShouldEqual, 1)
+			So(viaRoles[0].(map[string]any)["id"], ShouldEqual, "role2")
+		})
+
+		Convey("effective 参数无效", func() {
+			mockHydra.EXPECT().Introspect("test-token").Return(interfaces.TokenIntrospectInfo{
+				Active:     true,
+				VisitorID:  "admin1",
+				VisitorTyp: interfaces.RealName,
+			}, nil)
+
+			req := httptest.NewRequest("GET", "/api/authorization/v1/role-members/role1?effective=abc", nil)
+			req.Header.Set("Authorization", "Bearer test-token")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
 	})
 }
 
@@ -562,6 +610,66 @@ func TestRoleRestHandler_AddOrDeleteRoleMembers(t *testing.T) {
 			So(w.Code, ShouldEqual, http.StatusNoContent)
 		})
 
+		Convey("添加有效期成员", func() {
+			reqBody := map[string]any{
+				"method": "POST",
+				"members": []map[string]any{
+					{"id": "user1", "type": "user", "starts_at": "2030-01-01T00:00:00Z", "expires_at": "2030-02-01T00:00:00Z"},
+					{"id": "user2", "type": "user"},
+				},
+			}
+			reqBodyBytes, _ := json.Marshal(reqBody)
+
+			mockHydra.EXPECT().Introspect("test-token").Return(interfaces.TokenIntrospectInfo{
+				Active:     true,
+				VisitorID:  "admin1",
+				VisitorTyp: interfaces.RealName,
+			}, nil)
+
+			mockRole.EXPECT().AddOrDeleteRoleMemebers(gomock.Any(), gomock.Any(), "POST", "role1", gomock.Any()).
+				DoAndReturn(func(_, _ any, _, _ string, infos map[string]interfaces.RoleMemberInfo) error {
+					So(infos["user1"].StartTime, ShouldEqual, int64(1893456000000000))
+					So(infos["user1"].EndTime, ShouldEqual, int64(1896134400000000))
+					So(infos["user2"].StartTime, ShouldEqual, 0)
+					So(infos["user2"].EndTime, ShouldEqual, -1)
+					return nil
+				})
+
+			req := httptest.NewRequest("POST", "/api/authorization/v1/role-members/role1", bytes.NewBuffer(reqBodyBytes))
+			req.Header.Set("Authorization", "Bearer test-token")
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusNoContent)
+		})
+
+		Convey("过期时间格式错误", func() {
+			reqBody := map[string]any{
+				"method": "POST",
+				"members": []map[string]any{
+					{"id": "user1", "type": "user", "expires_at": "2030-01-01"},
+				},
+			}
+			reqBodyBytes, _ := json.Marshal(reqBody)
+
+			mockHydra.EXPECT().Introspect("test-token").Return(interfaces.TokenIntrospectInfo{
+				Active:     true,
+				VisitorID:  "admin1",
+				VisitorTyp: interfaces.RealName,
+			}, nil)
+
+			req := httptest.NewRequest("POST", "/api/authorization/v1/role-members/role1", bytes.NewBuffer(reqBodyBytes))
+			req.Header.Set("Authorization", "Bearer test-token")
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+
 		Convey("成功删除角色成员", func() {
 			// 准备请求数据
 			reqBody := map[string]any{
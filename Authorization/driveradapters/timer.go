@@ -25,6 +25,7 @@ type timer struct {
 	once   sync.Once
 	log    common.Logger
 	policy interfaces.LogicsPolicy
+	role   interfaces.LogicsRole
 }
 
 // NewTimer 创建定时任务对象
@@ -33,6 +34,7 @@ func NewTimer() Timer {
 		t = &timer{
 			log:    common.NewLogger(),
 			policy: logics.NewPolicy(),
+			role:   logics.NewLogicsRole(),
 		}
 	})
 
@@ -50,6 +52,10 @@ func (tt *timer) StartCleanThread() {
 				if err != nil {
 					tt.log.Errorln("policy DeleteByEndTime failed:", err)
 				}
+				err = tt.role.DeleteMembersByEndTime(curTime)
+				if err != nil {
+					tt.log.Errorln("role DeleteMembersByEndTime failed:", err)
+				}
 				time.Sleep(t * time.Hour)
 			}
 		}()
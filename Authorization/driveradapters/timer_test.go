@@ -13,10 +13,11 @@ import (
 	"Authorization/interfaces/mock"
 )
 
-func newTimer(a interfaces.LogicsPolicy) Timer {
+func newTimer(a interfaces.LogicsPolicy, r interfaces.LogicsRole) Timer {
 	return &timer{
 		log:    common.NewLogger(),
 		policy: a,
+		role:   r,
 	}
 }
 
@@ -26,16 +27,19 @@ func TestStartCleanThread(t *testing.T) {
 		defer ctrl.Finish()
 
 		a := mock.NewMockLogicsPolicy(ctrl)
-		timer := newTimer(a)
+		r := mock.NewMockLogicsRole(ctrl)
+		timer := newTimer(a, r)
 		assert.NotEqual(t, timer, nil)
 
 		Convey("DeleteByTime error", func() {
 			a.EXPECT().DeleteByEndTime(gomock.Any()).AnyTimes().Return(errors.New("delete error"))
+			r.EXPECT().DeleteMembersByEndTime(gomock.Any()).AnyTimes().Return(errors.New("delete error"))
 			timer.StartCleanThread()
 		})
 
 		Convey("success", func() {
 			a.EXPECT().DeleteByEndTime(gomock.Any()).AnyTimes().Return(nil)
+			r.EXPECT().DeleteMembersByEndTime(gomock.Any()).AnyTimes().Return(nil)
 			timer.StartCleanThread()
 		})
 	})
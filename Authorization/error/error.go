@@ -12,4 +12,12 @@ const (
 	RoleNameConflict = strPrefix + gerrors.Conflict + ".RoleNameConflict"
 	// 角色不存在 Authorization.NotFound.RoleNotFound
 	RoleNotFound = strPrefix + gerrors.NotFound + ".RoleNotFound"
+	// 角色继承存在循环 Authorization.Conflict.RoleInheritanceCycle
+	RoleInheritanceCycle = strPrefix + gerrors.Conflict + ".RoleInheritanceCycle"
+	// 违反互斥角色集 Authorization.Conflict.RoleExclusionViolated
+	RoleExclusionViolated = strPrefix + gerrors.Conflict + ".RoleExclusionViolated"
+	// 互斥角色集名称冲突 Authorization.Conflict.RoleExclusionNameConflict
+	RoleExclusionNameConflict = strPrefix + gerrors.Conflict + ".RoleExclusionNameConflict"
+	// 互斥角色集不存在 Authorization.NotFound.RoleExclusionNotFound
+	RoleExclusionNotFound = strPrefix + gerrors.NotFound + ".RoleExclusionNotFound"
 )
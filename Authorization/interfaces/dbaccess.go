@@ -84,6 +84,9 @@ type DBPolicy interface {
 	// 获取资源策略
 	GetByPolicyIDs(ctx context.Context, policyIDs []string) (policies map[string]PolicyInfo, err error)
 
+	// 获取资源类型下的所有策略
+	GetByResourceType(ctx context.Context, resourceType string) (policies []PolicyInfo, err error)
+
 	// 删除策略 根据资源id删除策略
 	DeleteByResourceIDs(ctx context.Context, resources []PolicyDeleteResourceInfo) error
 
@@ -167,15 +170,36 @@ type DBRoleMember interface {
 	// GetRoleMembersByRoleID 根据角色ID获取角色成员
 	GetRoleMembersByRoleID(ctx context.Context, id string) (outInfo []RoleMemberInfo, err error)
 
-	// GetRoleByMembers 通过成员获取角色
-	GetRoleByMembers(ctx context.Context, memberIDs []string) (outInfo []RoleInfo, err error)
+	// GetRoleByMembers 通过成员获取角色, curTime 大于 0 时只返回 curTime 生效中的成员关系
+	GetRoleByMembers(ctx context.Context, memberIDs []string, curTime int64) (outInfo []RoleInfo, err error)
 
 	// 删除成员 根据成员id
 	DeleteByMemberIDs(memberIDs []string) error
+
+	// DeleteExpiredMembers 删除过期的角色成员, 返回删除的成员, key 为角色ID
+	DeleteExpiredMembers(ctx context.Context, curTime int64) (outInfo map[string][]RoleMemberInfo, err error)
 	// UpdateMemberName 更新成员名称
 	UpdateMemberName(memberID, name string) error
 }
 
+// DBRoleExclusion 互斥角色集 数据访问层处理接口
+type DBRoleExclusion interface {
+	// Add 添加互斥角色集
+	Add(ctx context.Context, info *RoleExclusionInfo) (err error)
+	// Update 更新互斥角色集
+	Update(ctx context.Context, id, name string, nameChanged bool, description string, descriptionChanged bool, roleIDs []string, roleIDsChanged bool) (err error)
+	// Delete 删除互斥角色集
+	Delete(ctx context.Context, id string) (err error)
+	// GetByID 指定ID获取互斥角色集
+	GetByID(ctx context.Context, id string) (info RoleExclusionInfo, err error)
+	// GetByName 指定名称获取互斥角色集
+	GetByName(ctx context.Context, name string) (info RoleExclusionInfo, err error)
+	// Get 分页获取互斥角色集
+	Get(ctx context.Context, info *RoleExclusionSearchInfo) (count int, infos []RoleExclusionInfo, err error)
+	// GetAll 获取所有互斥角色集
+	GetAll(ctx context.Context) (infos []RoleExclusionInfo, err error)
+}
+
 type ObligationOperation struct {
 	ID   string
 	Name string
@@ -67,4 +67,14 @@ type DrivenUserMgnt interface {
 	GetAccessorIDsByUserID(ctx context.Context, userID string) (accessorIDs []string, err error)
 	// GetParentDepartmentsByDepartmentID 根据部门ID获取父部门信息
 	GetParentDepartmentsByDepartmentID(ctx context.Context, departmentID string) (parentDeps []Department, err error)
+	// GetDepartmentAllUserIDs 获取部门及其子部门下的所有用户ID
+	GetDepartmentAllUserIDs(ctx context.Context, departmentID string) (userIDs []string, err error)
+	// GetGroupMemberIDs 获取用户组的直接成员ID, 成员为用户或部门
+	GetGroupMemberIDs(ctx context.Context, groupIDs []string) (userIDs, departmentIDs []string, err error)
+}
+
+// DrivenMessageBroker 消息发送接口
+type DrivenMessageBroker interface {
+	// RoleMembersExpired 发布角色成员过期消息
+	RoleMembersExpired(roleID string, memberIDs []string) (err error)
 }
@@ -11,6 +11,40 @@ import (
 type CheckResult struct {
 	Result           bool
 	OperatrionOblist map[string][]PolicyObligationItem
+	Explain          []OperationExplain // 决策说明, 与请求的操作顺序一致
+}
+
+// PolicyLevel 策略所在的资源层级
+type PolicyLevel int
+
+// 策略所在的资源层级定义
+const (
+	_                   PolicyLevel = iota
+	PolicyLevelSelf                 // 资源自身
+	PolicyLevelAncestor             // 上层资源
+	PolicyLevelType                 // 资源类型, 即 *
+)
+
+// ExplainPolicy 参与决策的策略
+type ExplainPolicy struct {
+	PolicyID     string
+	ResourceID   string
+	Level        PolicyLevel
+	AccessorID   string // 匹配的访问令牌
+	AccessorType AccessorType
+	AccessorName string
+	SystemRole   bool                   // 访问令牌是否为系统角色
+	Obligations  []PolicyObligationItem // 策略在该操作上配置的义务
+}
+
+// OperationExplain 单个操作的决策说明
+type OperationExplain struct {
+	Operation string
+	Result    bool
+	// 决定结果的策略, 拒绝时为最近层级的拒绝策略, 允许时为最近层级的允许策略, 没有策略时为空
+	DecisivePolicies []ExplainPolicy
+	// 提供义务的允许策略, 包含本层及上层
+	ObligationPolicies []ExplainPolicy
 }
 
 // AccessorType 访问者类型
@@ -111,6 +145,11 @@ type LogicsEvent interface {
 	RoleNameModified(roleID, name string) (err error)
 	// RegisterRoleNameModified 注册角色名称更新
 	RegisterRoleNameModified(f func(string, string) error)
+
+	// RoleMembersExpired 角色成员过期
+	RoleMembersExpired(roleID string, memberIDs []string) (err error)
+	// RegisterRoleMembersExpired 注册角色成员过期
+	RegisterRoleMembersExpired(f func(string, []string) error)
 }
 
 // OperationName 操作名称结构体
@@ -255,6 +294,59 @@ type LogicsPolicy interface {
 
 	// 初始化策略
 	InitPolicy(ctx context.Context, policys []PolicyInfo) error
+
+	// 模拟策略变更, 返回访问者在资源上生效操作的变化
+	Simulate(ctx context.Context, visitor *Visitor, changes *PolicySimulationChanges, accessors []AccessorInfo, resources []ResourceInfo) (
+		results []PolicySimulationResult, err error)
+
+	// 获取访问者的有效权限报表
+	GetEffectivePermissions(ctx context.Context, visitor *Visitor, param EffectivePermissionParam) (count int, permissions []EffectivePermission, err error)
+}
+
+// EffectivePermissionParam 有效权限报表参数
+type EffectivePermissionParam struct {
+	Accessor     AccessorInfo // 用户或应用账户
+	ResourceType string       // 资源类型, 为空时包含所有资源类型
+	Offset       int
+	Limit        int // 小于等于0时返回全部, 用于导出
+}
+
+// EffectivePermission 访问者在一个资源上的有效权限
+type EffectivePermission struct {
+	ResourceID   string // 资源实例ID, * 表示资源类型
+	ResourceType string
+	ResourceName string
+	Operations   []EffectiveOperation
+}
+
+// EffectiveOperation 有效的操作及其来源
+type EffectiveOperation struct {
+	Operation string
+	// 授予该操作的策略, 即最近配置了该操作的层级中的允许策略
+	Sources []ExplainPolicy
+}
+
+// PolicySimulationChanges 模拟的策略变更, 字段与 Create、Update、Delete 的入参一致
+type PolicySimulationChanges struct {
+	Create []PolicyInfo
+	Update []PolicyInfo
+	Delete []string
+}
+
+// PolicySimulationResult 策略变更模拟结果
+type PolicySimulationResult struct {
+	Accessor  AccessorInfo
+	Resource  ResourceInfo
+	Current   []string // 当前生效的操作
+	Simulated []string // 变更后生效的操作
+	Added     []string // 变更后新增的操作
+	Removed   []string // 变更后移除的操作
+}
+
+// PolicyOverlay 未提交的策略, 叠加在已有策略上进行计算
+type PolicyOverlay struct {
+	Upserts   []PolicyInfo // 新增的策略, 以及修改后的完整策略
+	DeleteIDs []string     // 删除的策略ID
 }
 
 // ResourceInfo 资源对象信息, 用于策略计算
@@ -264,14 +356,25 @@ type ResourceInfo struct {
 	Type         string
 	Name         string
 	ParentIDPath string
+	Attributes   map[string]any // 资源属性, 用于策略条件计算
 }
 
 // AccessorInfo 访问者对象信息, 用于策略计算
 // 其他字段为系统属性，id,type为必传字段
 type AccessorInfo struct {
-	ID   string
-	Type VisitorType
-	Name string
+	ID         string
+	Type       VisitorType
+	Name       string
+	IP         string         // 客户端IP, 用于策略条件计算
+	ClientType ClientType     // 设备类型, 用于策略条件计算
+	Attributes map[string]any // 访问者属性, 用于策略条件计算
+}
+
+// BatchCheckItem 批量检查项
+type BatchCheckItem struct {
+	Resource  ResourceInfo
+	Accessor  AccessorInfo
+	Operation []string
 }
 
 // PolicCalcyIncludeType 策略计算包含类型
@@ -281,12 +384,15 @@ type PolicCalcyIncludeType int
 const (
 	_                                     PolicCalcyIncludeType = iota
 	PolicCalcyIncludeOperationObligations                       // 操作和义务
+	PolicCalcyIncludeExplain                                    // 决策说明
 )
 
 // LogicsPolicyCalc 策略计算逻辑层接口
 type LogicsPolicyCalc interface {
 	// 检查接口
 	Check(ctx context.Context, resource *ResourceInfo, accessor *AccessorInfo, operation []string, include []PolicCalcyIncludeType) (checkResult CheckResult, err error)
+	// 批量检查接口, 结果与入参顺序一致
+	BatchCheck(ctx context.Context, items []BatchCheckItem, include []PolicCalcyIncludeType) (results []CheckResult, err error)
 	// 获取指定资源类型上的资源列表
 	GetResourceList(ctx context.Context, resourceTypeID string, accessor *AccessorInfo, operation []string, include []PolicCalcyIncludeType) (resources []ResourceInfo,
 		resourceOperationObligationMap map[string]map[string][]PolicyObligationItem, err error)
@@ -298,6 +404,30 @@ type LogicsPolicyCalc interface {
 		resourceOperationObligationMap map[string]map[string][]PolicyObligationItem, err error)
 	// 获取资源类型操作
 	GetResourceTypeOperation(ctx context.Context, resourceTypes []string, accessor *AccessorInfo) (resourceTypeOperationMap map[string][]string, err error)
+	// 获取资源操作, 同时返回叠加未提交策略后的资源操作
+	SimulateResourceOperation(ctx context.Context, resources []ResourceInfo, accessor *AccessorInfo, overlay *PolicyOverlay) (
+		current, simulated map[string][]string, err error)
+	// 获取访问者的有效权限, resourceTypes 为空时包含所有资源类型
+	GetEffectivePermissions(ctx context.Context, accessor *AccessorInfo, resourceTypes []string) (permissions []EffectivePermission, err error)
+	// 获取策略计算缓存统计信息
+	GetCacheStats() PolicyCalcCacheStats
+}
+
+// CacheStats 缓存统计信息
+type CacheStats struct {
+	Size      int   // 当前条目数
+	Capacity  int   // 最大条目数
+	Hits      int64 // 命中次数
+	Misses    int64 // 未命中次数
+	Evictions int64 // 容量不足淘汰次数
+}
+
+// PolicyCalcCacheStats 策略计算缓存统计信息
+type PolicyCalcCacheStats struct {
+	Enabled     bool
+	TTLSeconds  int64 // 缓存有效期, 即其他实例缓存变更前数据的最长时间
+	AccessToken CacheStats
+	Policy      CacheStats
 }
 
 type ResourceTypeScope struct {
@@ -401,6 +531,56 @@ type RoleMemberInfo struct {
 	ParentDeps [][]Department
 	CreateTime int64
 	ModifyTime int64
+	// StartTime 生效时间, 单位微秒, 0 表示立即生效
+	StartTime int64
+	// EndTime 过期时间, 单位微秒, -1 表示永久有效
+	EndTime int64
+	// ViaRoles 有效成员的来源角色, 直接成员为当前角色, 其他为继承当前角色的角色
+	ViaRoles []NameInfo
+}
+
+// RoleExclusionInfo 互斥角色集, 同一访问者最多只能拥有集合中的一个角色
+type RoleExclusionInfo struct {
+	ID          string
+	Name        string
+	Description string
+	RoleIDs     []string
+	// Roles 角色信息, 列举时根据 RoleIDs 填充
+	Roles      []NameInfo
+	CreateTime int64
+	ModifyTime int64
+}
+
+// RoleExclusionSearchInfo 互斥角色集列举信息
+type RoleExclusionSearchInfo struct {
+	Offset int
+	Limit  int
+}
+
+// RoleExclusionViolation 违反互斥角色集的访问者
+type RoleExclusionViolation struct {
+	Exclusion    NameInfo
+	AccessorID   string
+	AccessorType AccessorType
+	AccessorName string
+	// Roles 访问者拥有的互斥角色
+	Roles []NameInfo
+}
+
+// LogicsRoleExclusion 互斥角色集
+type LogicsRoleExclusion interface {
+	// Add 添加互斥角色集
+	Add(ctx context.Context, visitor *Visitor, info *RoleExclusionInfo) (id string, err error)
+	// Delete 删除互斥角色集
+	Delete(ctx context.Context, visitor *Visitor, id string) (err error)
+	// Update 更新互斥角色集
+	Update(ctx context.Context, visitor *Visitor, id, name string, nameChanged bool, description string, descriptionChanged bool, roleIDs []string, roleIDsChanged bool) (err error)
+	// GetByID 获取指定的互斥角色集
+	GetByID(ctx context.Context, visitor *Visitor, id string) (info RoleExclusionInfo, err error)
+	// Get 互斥角色集列举
+	Get(ctx context.Context, visitor *Visitor, info *RoleExclusionSearchInfo) (count int, infos []RoleExclusionInfo, err error)
+	// GetViolations 列举当前违反互斥角色集的访问者
+	GetViolations(ctx context.Context, visitor *Visitor) (violations []RoleExclusionViolation, err error)
 }
 
 // NameInfo 名称信息
@@ -447,12 +627,18 @@ type LogicsRole interface {
 	// InitRoleMemebers 批量初始化角色成员
 	InitRoleMemebers(ctx context.Context, infos map[string][]RoleMemberInfo) (err error)
 
-	// GetRoleMembers 角色成员列举
+	// GetRoleMembers 角色成员列举, 成员可以是角色
 	GetRoleMembers(ctx context.Context, visitor *Visitor, roleID string, info RoleMemberSearchInfo) (count int, outInfo []RoleMemberInfo, err error)
 
-	// GetRoleByMembers 通过成员获取角色
+	// GetRoleEffectiveMembers 角色有效成员列举, 展开作为成员的角色, 不包含角色类型的成员
+	GetRoleEffectiveMembers(ctx context.Context, visitor *Visitor, roleID string, info RoleMemberSearchInfo) (count int, outInfo []RoleMemberInfo, err error)
+
+	// GetRoleByMembers 通过成员获取角色, 包含继承的角色, 只包含当前生效的成员关系
 	GetRoleByMembers(ctx context.Context, memberIDs []string) (outInfo []RoleInfo, err error)
 
+	// DeleteMembersByEndTime 删除过期的角色成员
+	DeleteMembersByEndTime(curTime int64) error
+
 	// 角色查询接口
 	// GetResourceTypeRoles 根据资源类型列举角色
 	GetResourceTypeRoles(ctx context.Context, visitor *Visitor, info ResourceTypeRoleSearchInfo) (count int, outInfo []RoleInfo, err error)
@@ -498,7 +684,84 @@ type LogicsObligation interface {
 	// 指定ID批量获取义务
 	GetByIDSInternal(ctx context.Context, obligationIDs map[string]bool) (infos []ObligationInfo, err error)
 
+	// 义务批量初始化
+	InitObligations(ctx context.Context, obligations []ObligationInfo) error
+
 	// 查询接口
 	// 查询义务
 	Query(ctx context.Context, visitor *Visitor, queryInfo *QueryObligationInfo) (resultInfos map[string][]ObligationInfo, err error)
 }
+
+// ConfigBundleVersion 配置包格式版本
+const ConfigBundleVersion = 1
+
+// ConfigBundle 配置包, 用于在不同环境之间迁移配置
+type ConfigBundle struct {
+	Version         int
+	ExportTime      int64
+	ResourceTypes   []ResourceType
+	Roles           []RoleInfo
+	ObligationTypes []ObligationTypeInfo
+	Obligations     []ObligationInfo
+	Policies        []PolicyInfo
+}
+
+// ConfigItemType 配置项类型
+type ConfigItemType string
+
+// 配置项类型
+const (
+	ConfigItemResourceType   ConfigItemType = "resource_type"
+	ConfigItemRole           ConfigItemType = "role"
+	ConfigItemObligationType ConfigItemType = "obligation_type"
+	ConfigItemObligation     ConfigItemType = "obligation"
+	ConfigItemPolicy         ConfigItemType = "policy"
+)
+
+// ImportConflictMode 导入冲突处理方式, 冲突指目标环境已存在且内容不同的配置项
+type ImportConflictMode int
+
+// 导入冲突处理方式
+const (
+	_                       ImportConflictMode = iota
+	ImportConflictSkip                         // 跳过, 保留目标环境配置
+	ImportConflictOverwrite                    // 覆盖目标环境配置
+	ImportConflictFail                         // 存在冲突时不导入任何配置
+)
+
+// ConfigImportOptions 导入选项
+type ConfigImportOptions struct {
+	DryRun   bool
+	Conflict ImportConflictMode
+}
+
+// ConfigImportStats 导入统计
+type ConfigImportStats struct {
+	Created   int
+	Updated   int
+	Unchanged int
+	Skipped   int
+}
+
+// ConfigImportConflict 导入冲突项
+type ConfigImportConflict struct {
+	Type ConfigItemType
+	ID   string
+	Name string
+}
+
+// ConfigImportReport 导入报告
+type ConfigImportReport struct {
+	DryRun    bool
+	Stats     map[ConfigItemType]ConfigImportStats
+	Conflicts []ConfigImportConflict
+}
+
+// LogicsConfigBundle 配置导出导入接口
+type LogicsConfigBundle interface {
+	// Export 导出配置, resourceTypeIDs 为空时导出全部
+	Export(ctx context.Context, visitor *Visitor, resourceTypeIDs []string) (bundle ConfigBundle, err error)
+
+	// Import 导入配置, 可重复执行
+	Import(ctx context.Context, visitor *Visitor, bundle *ConfigBundle, opts ConfigImportOptions) (report ConfigImportReport, err error)
+}
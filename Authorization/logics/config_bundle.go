@@ -0,0 +1,507 @@
+// Package logics config_bundle 配置导出导入
+package logics
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	gerrors "github.com/kweaver-ai/go-lib/error"
+
+	"Authorization/common"
+	"Authorization/interfaces"
+)
+
+// 导出角色时分页大小
+const configBundleRolePageSize = 1000
+
+var (
+	configBundleOnce      sync.Once
+	configBundleSingleton *configBundle
+)
+
+/*
+配置导出导入
+1. 导出资源类型、角色、义务类型、义务和策略, 角色成员与组织架构相关, 不导出
+2. 导入复用初始化接口, 按 资源类型 -> 义务类型 -> 义务 -> 角色 -> 策略 的顺序写入
+3. 资源类型、义务类型、义务按ID匹配, 角色按名称匹配, 策略按资源和访问者匹配
+4. 目标环境同名角色ID不同时, 策略中的角色访问者替换为目标环境的角色ID
+*/
+type configBundle struct {
+	resourceTypeDB   interfaces.DBResourceType
+	roleDB           interfaces.DBRole
+	obligationTypeDB interfaces.DBObligationType
+	obligationDB     interfaces.DBObligation
+	policyDB         interfaces.DBPolicy
+	userMgmt         interfaces.DrivenUserMgnt
+	resourceType     interfaces.LogicsResourceType
+	role             interfaces.LogicsRole
+	obligationType   interfaces.ObligationType
+	obligation       interfaces.LogicsObligation
+	policy           interfaces.LogicsPolicy
+	logger           common.Logger
+
+	// 变化检查与初始化接口保持一致
+	resourceTypeChanged   func(old, newInfo *interfaces.ResourceType) bool
+	roleChanged           func(old, newInfo *interfaces.RoleInfo) bool
+	obligationTypeChanged func(old, newInfo *interfaces.ObligationTypeInfo) bool
+	obligationChanged     func(old, newInfo *interfaces.ObligationInfo) bool
+	policySame            func(old, newInfo *interfaces.PolicyInfo) bool
+}
+
+// NewConfigBundle 创建配置导出导入对象
+func NewConfigBundle() *configBundle {
+	configBundleOnce.Do(func() {
+		resourceType := NewResourceType()
+		role := NewLogicsRole()
+		obligationType := NewObligationType()
+		obligation := NewObligation()
+		policy := NewPolicy()
+		configBundleSingleton = &configBundle{
+			resourceTypeDB:        dbResourceType,
+			roleDB:                dbRole,
+			obligationTypeDB:      dbObligationType,
+			obligationDB:          dbObligation,
+			policyDB:              dbPolicy,
+			userMgmt:              dnUserMgnt,
+			resourceType:          resourceType,
+			role:                  role,
+			obligationType:        obligationType,
+			obligation:            obligation,
+			policy:                policy,
+			logger:                common.NewLogger(),
+			resourceTypeChanged:   resourceType.checkResourceTypeChange,
+			roleChanged:           role.checkRoleChange,
+			obligationTypeChanged: obligationType.checkObligationTypeChange,
+			obligationChanged:     obligation.checkObligationChange,
+			policySame:            policy.cmpPolicy,
+		}
+	})
+	return configBundleSingleton
+}
+
+func (c *configBundle) checkVisitorType(ctx context.Context, visitor *interfaces.Visitor) (err error) {
+	var roleTypes []interfaces.SystemRoleType
+	if visitor.Type == interfaces.RealName {
+		roleTypes, err = c.userMgmt.GetUserRolesByUserID(ctx, visitor.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return checkVisitorType(
+		visitor,
+		roleTypes,
+		[]interfaces.VisitorType{interfaces.RealName},
+		[]interfaces.SystemRoleType{interfaces.SuperAdmin, interfaces.SystemAdmin, interfaces.SecurityAdmin},
+	)
+}
+
+// Export 导出配置
+// 指定资源类型时, 只导出适用于这些资源类型的角色和义务类型, 以及这些资源类型上的策略
+//
+//nolint:gocyclo
+func (c *configBundle) Export(ctx context.Context, visitor *interfaces.Visitor, resourceTypeIDs []string) (bundle interfaces.ConfigBundle, err error) {
+	err = c.checkVisitorType(ctx, visitor)
+	if err != nil {
+		return
+	}
+
+	bundle.Version = interfaces.ConfigBundleVersion
+	bundle.ExportTime = time.Now().UnixNano()
+
+	// 资源类型
+	filtered := len(resourceTypeIDs) > 0
+	if filtered {
+		var resourceTypeMap map[string]interfaces.ResourceType
+		resourceTypeMap, err = c.resourceTypeDB.GetByIDs(ctx, resourceTypeIDs)
+		if err != nil {
+			c.logger.Errorf("Export GetByIDs: %v", err)
+			return
+		}
+		for _, id := range resourceTypeIDs {
+			resourceType, ok := resourceTypeMap[id]
+			if !ok {
+				err = gerrors.NewError(gerrors.PublicBadRequest, fmt.Sprintf("resource type %s not found", id))
+				return
+			}
+			bundle.ResourceTypes = append(bundle.ResourceTypes, resourceType)
+		}
+	} else {
+		bundle.ResourceTypes, err = c.resourceTypeDB.GetAllInternal(ctx)
+		if err != nil {
+			c.logger.Errorf("Export GetAllInternal: %v", err)
+			return
+		}
+	}
+	typeSet := make(map[string]bool, len(bundle.ResourceTypes))
+	for i := range bundle.ResourceTypes {
+		typeSet[bundle.ResourceTypes[i].ID] = true
+	}
+
+	// 角色
+	searchInfo := interfaces.RoleSearchInfo{
+		Limit:       configBundleRolePageSize,
+		RoleSources: []interfaces.RoleSource{interfaces.RoleSourceSystem, interfaces.RoleSourceBusiness, interfaces.RoleSourceUser},
+	}
+	for {
+		var roles []interfaces.RoleInfo
+		roles, err = c.roleDB.GetRoles(ctx, searchInfo)
+		if err != nil {
+			c.logger.Errorf("Export GetRoles: %v", err)
+			return
+		}
+		for i := range roles {
+			if !filtered || roleApplicable(&roles[i], typeSet) {
+				bundle.Roles = append(bundle.Roles, roles[i])
+			}
+		}
+		if len(roles) < searchInfo.Limit {
+			break
+		}
+		searchInfo.Offset += searchInfo.Limit
+	}
+
+	// 义务类型和义务
+	obligationTypes, err := c.obligationTypeDB.GetAll(ctx)
+	if err != nil {
+		c.logger.Errorf("Export GetAll: %v", err)
+		return
+	}
+	obligationTypeIDs := make(map[string]bool)
+	for i := range obligationTypes {
+		if !filtered || obligationTypeApplicable(&obligationTypes[i], typeSet) {
+			bundle.ObligationTypes = append(bundle.ObligationTypes, obligationTypes[i])
+			obligationTypeIDs[obligationTypes[i].ID] = true
+		}
+	}
+	if len(obligationTypeIDs) > 0 {
+		var obligationMap map[string][]interfaces.ObligationInfo
+		obligationMap, err = c.obligationDB.GetByObligationTypeIDs(ctx, obligationTypeIDs)
+		if err != nil {
+			c.logger.Errorf("Export GetByObligationTypeIDs: %v", err)
+			return
+		}
+		for i := range bundle.ObligationTypes {
+			bundle.Obligations = append(bundle.Obligations, obligationMap[bundle.ObligationTypes[i].ID]...)
+		}
+	}
+
+	// 策略
+	for i := range bundle.ResourceTypes {
+		var policies []interfaces.PolicyInfo
+		policies, err = c.policyDB.GetByResourceType(ctx, bundle.ResourceTypes[i].ID)
+		if err != nil {
+			c.logger.Errorf("Export GetByResourceType: %v", err)
+			return
+		}
+		bundle.Policies = append(bundle.Policies, policies...)
+	}
+	return bundle, nil
+}
+
+// roleApplicable 角色是否适用于资源类型
+func roleApplicable(role *interfaces.RoleInfo, typeSet map[string]bool) bool {
+	if role.Unlimited {
+		return true
+	}
+	for _, scope := range role.Types {
+		if typeSet[scope.ResourceTypeID] {
+			return true
+		}
+	}
+	return false
+}
+
+// obligationTypeApplicable 义务类型是否适用于资源类型
+func obligationTypeApplicable(obligationType *interfaces.ObligationTypeInfo, typeSet map[string]bool) bool {
+	if obligationType.ResourceTypeScope.Unlimited {
+		return true
+	}
+	for _, scope := range obligationType.ResourceTypeScope.Types {
+		if typeSet[scope.ResourceTypeID] {
+			return true
+		}
+	}
+	return false
+}
+
+// configImportPlan 导入计划
+type configImportPlan struct {
+	report          interfaces.ConfigImportReport
+	resourceTypes   []interfaces.ResourceType
+	obligationTypes []interfaces.ObligationTypeInfo
+	obligations     []interfaces.ObligationInfo
+	roles           []interfaces.RoleInfo
+	createPolicies  []interfaces.PolicyInfo
+	updatePolicies  []interfaces.PolicyInfo
+}
+
+// add 记录配置项的处理结果, 冲突时按冲突处理方式决定是否写入
+func (p *configImportPlan) add(itemType interfaces.ConfigItemType, id, name string, exist, changed bool,
+	mode interfaces.ImportConflictMode,
+) (write bool) {
+	stats := p.report.Stats[itemType]
+	defer func() {
+		p.report.Stats[itemType] = stats
+	}()
+	switch {
+	case !exist:
+		stats.Created++
+		return true
+	case !changed:
+		stats.Unchanged++
+		return false
+	}
+	p.report.Conflicts = append(p.report.Conflicts, interfaces.ConfigImportConflict{Type: itemType, ID: id, Name: name})
+	if mode == interfaces.ImportConflictOverwrite {
+		stats.Updated++
+		return true
+	}
+	stats.Skipped++
+	return false
+}
+
+// Import 导入配置
+// 1. 先计算导入计划, 冲突处理方式为 fail 且存在冲突时返回错误, 不写入任何配置
+// 2. dry run 只返回导入报告
+//
+//nolint:gocyclo
+func (c *configBundle) Import(ctx context.Context, visitor *interfaces.Visitor, bundle *interfaces.ConfigBundle, opts interfaces.ConfigImportOptions) (
+	report interfaces.ConfigImportReport, err error,
+) {
+	err = c.checkVisitorType(ctx, visitor)
+	if err != nil {
+		return
+	}
+
+	if bundle.Version != interfaces.ConfigBundleVersion {
+		err = gerrors.NewError(gerrors.PublicBadRequest, fmt.Sprintf("unsupported bundle version %d", bundle.Version))
+		return
+	}
+
+	plan, err := c.getImportPlan(ctx, bundle, opts.Conflict)
+	if err != nil {
+		return
+	}
+	plan.report.DryRun = opts.DryRun
+
+	if opts.Conflict == interfaces.ImportConflictFail && len(plan.report.Conflicts) > 0 {
+		conflicts := make([]any, 0, len(plan.report.Conflicts))
+		for _, v := range plan.report.Conflicts {
+			conflicts = append(conflicts, map[string]any{"type": string(v.Type), "id": v.ID, "name": v.Name})
+		}
+		err = gerrors.NewError(gerrors.PublicConflict, fmt.Sprintf("%d conflicting items found", len(plan.report.Conflicts)),
+			gerrors.SetDetail(map[string]any{"conflicts": conflicts}))
+		return
+	}
+	if opts.DryRun {
+		return plan.report, nil
+	}
+
+	if err = c.resourceType.InitResourceTypes(ctx, plan.resourceTypes); err != nil {
+		c.logger.Errorf("Import InitResourceTypes: %v", err)
+		return
+	}
+	if err = c.obligationType.InitObligationTypes(ctx, plan.obligationTypes); err != nil {
+		c.logger.Errorf("Import InitObligationTypes: %v", err)
+		return
+	}
+	if err = c.obligation.InitObligations(ctx, plan.obligations); err != nil {
+		c.logger.Errorf("Import InitObligations: %v", err)
+		return
+	}
+	if err = c.role.InitRoles(ctx, plan.roles); err != nil {
+		c.logger.Errorf("Import InitRoles: %v", err)
+		return
+	}
+	if err = c.policy.InitPolicy(ctx, plan.createPolicies); err != nil {
+		c.logger.Errorf("Import InitPolicy: %v", err)
+		return
+	}
+	// InitPolicy 合并已有策略, 覆盖时直接修改
+	if err = c.policy.Update(ctx, visitor, plan.updatePolicies); err != nil {
+		c.logger.Errorf("Import Update: %v", err)
+		return
+	}
+	return plan.report, nil
+}
+
+// getImportPlan 与目标环境配置对比, 计算需要写入的配置
+//
+//nolint:gocyclo,funlen
+func (c *configBundle) getImportPlan(ctx context.Context, bundle *interfaces.ConfigBundle, mode interfaces.ImportConflictMode) (
+	plan configImportPlan, err error,
+) {
+	plan.report.Stats = map[interfaces.ConfigItemType]interfaces.ConfigImportStats{
+		interfaces.ConfigItemResourceType:   {},
+		interfaces.ConfigItemRole:           {},
+		interfaces.ConfigItemObligationType: {},
+		interfaces.ConfigItemObligation:     {},
+		interfaces.ConfigItemPolicy:         {},
+	}
+	plan.report.Conflicts = make([]interfaces.ConfigImportConflict, 0)
+
+	// 资源类型
+	if len(bundle.ResourceTypes) > 0 {
+		ids := make([]string, 0, len(bundle.ResourceTypes))
+		for i := range bundle.ResourceTypes {
+			ids = append(ids, bundle.ResourceTypes[i].ID)
+		}
+		var oldMap map[string]interfaces.ResourceType
+		oldMap, err = c.resourceTypeDB.GetByIDs(ctx, ids)
+		if err != nil {
+			c.logger.Errorf("getImportPlan resource type GetByIDs: %v", err)
+			return
+		}
+		for i := range bundle.ResourceTypes {
+			old, exist := oldMap[bundle.ResourceTypes[i].ID]
+			changed := exist && c.resourceTypeChanged(&old, &bundle.ResourceTypes[i])
+			if plan.add(interfaces.ConfigItemResourceType, bundle.ResourceTypes[i].ID, bundle.ResourceTypes[i].Name, exist, changed, mode) {
+				plan.resourceTypes = append(plan.resourceTypes, bundle.ResourceTypes[i])
+			}
+		}
+	}
+
+	// 义务类型
+	if len(bundle.ObligationTypes) > 0 {
+		ids := make([]string, 0, len(bundle.ObligationTypes))
+		for i := range bundle.ObligationTypes {
+			ids = append(ids, bundle.ObligationTypes[i].ID)
+		}
+		var olds []interfaces.ObligationTypeInfo
+		olds, err = c.obligationTypeDB.GetByIDs(ctx, ids)
+		if err != nil {
+			c.logger.Errorf("getImportPlan obligation type GetByIDs: %v", err)
+			return
+		}
+		oldMap := make(map[string]interfaces.ObligationTypeInfo, len(olds))
+		for i := range olds {
+			oldMap[olds[i].ID] = olds[i]
+		}
+		for i := range bundle.ObligationTypes {
+			old, exist := oldMap[bundle.ObligationTypes[i].ID]
+			changed := exist && c.obligationTypeChanged(&old, &bundle.ObligationTypes[i])
+			if plan.add(interfaces.ConfigItemObligationType, bundle.ObligationTypes[i].ID, bundle.ObligationTypes[i].Name, exist, changed, mode) {
+				plan.obligationTypes = append(plan.obligationTypes, bundle.ObligationTypes[i])
+			}
+		}
+	}
+
+	// 义务
+	if len(bundle.Obligations) > 0 {
+		ids := make([]string, 0, len(bundle.Obligations))
+		for i := range bundle.Obligations {
+			ids = append(ids, bundle.Obligations[i].ID)
+		}
+		var olds []interfaces.ObligationInfo
+		olds, err = c.obligationDB.GetByIDs(ctx, ids)
+		if err != nil {
+			c.logger.Errorf("getImportPlan obligation GetByIDs: %v", err)
+			return
+		}
+		oldMap := make(map[string]interfaces.ObligationInfo, len(olds))
+		for i := range olds {
+			oldMap[olds[i].ID] = olds[i]
+		}
+		for i := range bundle.Obligations {
+			old, exist := oldMap[bundle.Obligations[i].ID]
+			changed := exist && c.obligationChanged(&old, &bundle.Obligations[i])
+			if plan.add(interfaces.ConfigItemObligation, bundle.Obligations[i].ID, bundle.Obligations[i].Name, exist, changed, mode) {
+				plan.obligations = append(plan.obligations, bundle.Obligations[i])
+			}
+		}
+	}
+
+	// 角色, 按名称匹配
+	roleIDMap := make(map[string]string)
+	if len(bundle.Roles) > 0 {
+		ids := make([]string, 0, len(bundle.Roles))
+		for i := range bundle.Roles {
+			if bundle.Roles[i].ID != "" {
+				ids = append(ids, bundle.Roles[i].ID)
+			}
+		}
+		var idRoleMap map[string]interfaces.RoleInfo
+		idRoleMap, err = c.roleDB.GetRoleByIDs(ctx, ids)
+		if err != nil {
+			c.logger.Errorf("getImportPlan GetRoleByIDs: %v", err)
+			return
+		}
+		for i := range bundle.Roles {
+			var old interfaces.RoleInfo
+			old, err = c.roleDB.GetRoleByName(ctx, bundle.Roles[i].Name)
+			if err != nil {
+				c.logger.Errorf("getImportPlan GetRoleByName: %v", err)
+				return
+			}
+			if old.ID == "" {
+				// ID 已被其他名称的角色使用, 无法写入
+				if _, ok := idRoleMap[bundle.Roles[i].ID]; ok {
+					plan.report.Conflicts = append(plan.report.Conflicts, interfaces.ConfigImportConflict{
+						Type: interfaces.ConfigItemRole,
+						ID:   bundle.Roles[i].ID,
+						Name: bundle.Roles[i].Name,
+					})
+					stats := plan.report.Stats[interfaces.ConfigItemRole]
+					stats.Skipped++
+					plan.report.Stats[interfaces.ConfigItemRole] = stats
+					continue
+				}
+			} else if old.ID != bundle.Roles[i].ID {
+				roleIDMap[bundle.Roles[i].ID] = old.ID
+			}
+			exist := old.ID != ""
+			changed := exist && c.roleChanged(&old, &bundle.Roles[i])
+			if plan.add(interfaces.ConfigItemRole, bundle.Roles[i].ID, bundle.Roles[i].Name, exist, changed, mode) {
+				plan.roles = append(plan.roles, bundle.Roles[i])
+			}
+		}
+	}
+
+	// 策略, 按资源和访问者匹配
+	resourceTypeMap := make(map[string][]string)
+	for i := range bundle.Policies {
+		if bundle.Policies[i].AccessorType == interfaces.AccessorRole {
+			if id, ok := roleIDMap[bundle.Policies[i].AccessorID]; ok {
+				bundle.Policies[i].AccessorID = id
+			}
+		}
+		resourceTypeMap[bundle.Policies[i].ResourceType] = append(resourceTypeMap[bundle.Policies[i].ResourceType], bundle.Policies[i].ResourceID)
+	}
+	// oldPolicyMap [资源类型][资源实例ID][访问者ID]策略
+	oldPolicyMap := make(map[string]map[string]map[string]interfaces.PolicyInfo, len(resourceTypeMap))
+	for resourceType, resourceIDs := range resourceTypeMap {
+		var policiesMap map[string][]interfaces.PolicyInfo
+		policiesMap, err = c.policyDB.GetByResourceIDs(ctx, resourceType, resourceIDs)
+		if err != nil {
+			c.logger.Errorf("getImportPlan GetByResourceIDs: %v", err)
+			return
+		}
+		oldPolicyMap[resourceType] = make(map[string]map[string]interfaces.PolicyInfo, len(policiesMap))
+		for resourceID, policies := range policiesMap {
+			oldPolicyMap[resourceType][resourceID] = make(map[string]interfaces.PolicyInfo, len(policies))
+			for j := range policies {
+				oldPolicyMap[resourceType][resourceID][policies[j].AccessorID] = policies[j]
+			}
+		}
+	}
+	for i := range bundle.Policies {
+		policy := bundle.Policies[i]
+		old, exist := oldPolicyMap[policy.ResourceType][policy.ResourceID][policy.AccessorID]
+		// 双向比较, 已有策略包含额外的操作也视为冲突
+		changed := exist && !(c.policySame(&old, &policy) && c.policySame(&policy, &old))
+		id := policy.ResourceType + "/" + policy.ResourceID + "/" + policy.AccessorID
+		if !plan.add(interfaces.ConfigItemPolicy, id, policy.ResourceName, exist, changed, mode) {
+			continue
+		}
+		if exist {
+			policy.ID = old.ID
+			plan.updatePolicies = append(plan.updatePolicies, policy)
+		} else {
+			plan.createPolicies = append(plan.createPolicies, policy)
+		}
+	}
+	return plan, nil
+}
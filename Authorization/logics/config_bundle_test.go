@@ -0,0 +1,288 @@
+package logics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	gerrors "github.com/kweaver-ai/go-lib/error"
+
+	"Authorization/common"
+	"Authorization/interfaces"
+	"Authorization/interfaces/mock"
+)
+
+type configBundleMocks struct {
+	resourceTypeDB   *mock.MockDBResourceType
+	roleDB           *mock.MockDBRole
+	obligationTypeDB *mock.MockDBObligationType
+	obligationDB     *mock.MockDBObligation
+	policyDB         *mock.MockDBPolicy
+	userMgmt         *mock.MockDrivenUserMgnt
+	resourceType     *mock.MockLogicsResourceType
+	role             *mock.MockLogicsRole
+	obligationType   *mock.MockObligationType
+	obligation       *mock.MockLogicsObligation
+	policy           *mock.MockLogicsPolicy
+}
+
+func newTestConfigBundle(ctrl *gomock.Controller) (*configBundle, *configBundleMocks) {
+	m := &configBundleMocks{
+		resourceTypeDB:   mock.NewMockDBResourceType(ctrl),
+		roleDB:           mock.NewMockDBRole(ctrl),
+		obligationTypeDB: mock.NewMockDBObligationType(ctrl),
+		obligationDB:     mock.NewMockDBObligation(ctrl),
+		policyDB:         mock.NewMockDBPolicy(ctrl),
+		userMgmt:         mock.NewMockDrivenUserMgnt(ctrl),
+		resourceType:     mock.NewMockLogicsResourceType(ctrl),
+		role:             mock.NewMockLogicsRole(ctrl),
+		obligationType:   mock.NewMockObligationType(ctrl),
+		obligation:       mock.NewMockLogicsObligation(ctrl),
+		policy:           mock.NewMockLogicsPolicy(ctrl),
+	}
+	c := &configBundle{
+		resourceTypeDB:   m.resourceTypeDB,
+		roleDB:           m.roleDB,
+		obligationTypeDB: m.obligationTypeDB,
+		obligationDB:     m.obligationDB,
+		policyDB:         m.policyDB,
+		userMgmt:         m.userMgmt,
+		resourceType:     m.resourceType,
+		role:             m.role,
+		obligationType:   m.obligationType,
+		obligation:       m.obligation,
+		policy:           m.policy,
+		logger:           common.NewLogger(),
+		resourceTypeChanged: func(old, newInfo *interfaces.ResourceType) bool {
+			return old.Name != newInfo.Name
+		},
+		roleChanged: func(old, newInfo *interfaces.RoleInfo) bool {
+			return old.Description != newInfo.Description
+		},
+		obligationTypeChanged: func(old, newInfo *interfaces.ObligationTypeInfo) bool {
+			return old.Name != newInfo.Name
+		},
+		obligationChanged: func(old, newInfo *interfaces.ObligationInfo) bool {
+			return old.Name != newInfo.Name
+		},
+		policySame: (&policy{}).cmpPolicy,
+	}
+	return c, m
+}
+
+func TestConfigBundle_Export(t *testing.T) {
+	Convey("测试Export方法", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		c, m := newTestConfigBundle(ctrl)
+
+		ctx := context.Background()
+		visitor := &interfaces.Visitor{ID: "admin", Type: interfaces.RealName}
+
+		Convey("非管理员无权限", func() {
+			m.userMgmt.EXPECT().GetUserRolesByUserID(gomock.Any(), "admin").Return([]interfaces.SystemRoleType{interfaces.NormalUser}, nil)
+
+			_, err := c.Export(ctx, visitor, nil)
+			assert.Equal(t, gerrors.PublicForbidden, err.(*gerrors.Error).Code)
+		})
+
+		Convey("指定的资源类型不存在", func() {
+			m.userMgmt.EXPECT().GetUserRolesByUserID(gomock.Any(), "admin").Return([]interfaces.SystemRoleType{interfaces.SuperAdmin}, nil)
+			m.resourceTypeDB.EXPECT().GetByIDs(gomock.Any(), []string{"doc"}).Return(map[string]interfaces.ResourceType{}, nil)
+
+			_, err := c.Export(ctx, visitor, []string{"doc"})
+			assert.Equal(t, gerrors.PublicBadRequest, err.(*gerrors.Error).Code)
+		})
+
+		Convey("按资源类型过滤", func() {
+			m.userMgmt.EXPECT().GetUserRolesByUserID(gomock.Any(), "admin").Return([]interfaces.SystemRoleType{interfaces.SuperAdmin}, nil)
+			m.resourceTypeDB.EXPECT().GetByIDs(gomock.Any(), []string{"doc"}).Return(map[string]interfaces.ResourceType{
+				"doc": {ID: "doc", Name: "文档"},
+			}, nil)
+			m.roleDB.EXPECT().GetRoles(gomock.Any(), gomock.Any()).Return([]interfaces.RoleInfo{
+				{ID: "r1", Name: "r1", ResourceTypeScopeInfo: interfaces.ResourceTypeScopeInfo{Unlimited: true}},
+				{ID: "r2", Name: "r2", ResourceTypeScopeInfo: interfaces.ResourceTypeScopeInfo{Types: []interfaces.ResourceTypeScope{{ResourceTypeID: "doc"}}}},
+				{ID: "r3", Name: "r3", ResourceTypeScopeInfo: interfaces.ResourceTypeScopeInfo{Types: []interfaces.ResourceTypeScope{{ResourceTypeID: "menu"}}}},
+			}, nil)
+			m.obligationTypeDB.EXPECT().GetAll(gomock.Any()).Return([]interfaces.ObligationTypeInfo{
+				{ID: "o1", ResourceTypeScope: interfaces.ObligationResourceTypeScopeInfo{Types: []interfaces.ObligationResourceTypeScope{{ResourceTypeID: "doc"}}}},
+				{ID: "o2", ResourceTypeScope: interfaces.ObligationResourceTypeScopeInfo{Types: []interfaces.ObligationResourceTypeScope{{ResourceTypeID: "menu"}}}},
+			}, nil)
+			m.obligationDB.EXPECT().GetByObligationTypeIDs(gomock.Any(), map[string]bool{"o1": true}).Return(map[string][]interfaces.ObligationInfo{
+				"o1": {{ID: "ob1", TypeID: "o1"}},
+			}, nil)
+			m.policyDB.EXPECT().GetByResourceType(gomock.Any(), "doc").Return([]interfaces.PolicyInfo{{ID: "p1", ResourceType: "doc"}}, nil)
+
+			bundle, err := c.Export(ctx, visitor, []string{"doc"})
+			assert.NoError(t, err)
+			assert.Equal(t, interfaces.ConfigBundleVersion, bundle.Version)
+			assert.Equal(t, 1, len(bundle.ResourceTypes))
+			assert.Equal(t, 2, len(bundle.Roles))
+			assert.Equal(t, "r1", bundle.Roles[0].ID)
+			assert.Equal(t, "r2", bundle.Roles[1].ID)
+			assert.Equal(t, 1, len(bundle.ObligationTypes))
+			assert.Equal(t, "o1", bundle.ObligationTypes[0].ID)
+			assert.Equal(t, 1, len(bundle.Obligations))
+			assert.Equal(t, 1, len(bundle.Policies))
+		})
+
+		Convey("获取策略失败", func() {
+			testErr := errors.New("db error")
+			m.userMgmt.EXPECT().GetUserRolesByUserID(gomock.Any(), "admin").Return([]interfaces.SystemRoleType{interfaces.SuperAdmin}, nil)
+			m.resourceTypeDB.EXPECT().GetAllInternal(gomock.Any()).Return([]interfaces.ResourceType{{ID: "doc"}}, nil)
+			m.roleDB.EXPECT().GetRoles(gomock.Any(), gomock.Any()).Return(nil, nil)
+			m.obligationTypeDB.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
+			m.policyDB.EXPECT().GetByResourceType(gomock.Any(), "doc").Return(nil, testErr)
+
+			_, err := c.Export(ctx, visitor, nil)
+			assert.Equal(t, testErr, err)
+		})
+	})
+}
+
+//nolint:funlen
+func TestConfigBundle_Import(t *testing.T) {
+	Convey("测试Import方法", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		c, m := newTestConfigBundle(ctrl)
+
+		ctx := context.Background()
+		visitor := &interfaces.Visitor{ID: "admin", Type: interfaces.RealName}
+		m.userMgmt.EXPECT().GetUserRolesByUserID(gomock.Any(), "admin").Return([]interfaces.SystemRoleType{interfaces.SuperAdmin}, nil).AnyTimes()
+
+		oldPolicy := interfaces.PolicyInfo{
+			ID:           "old_policy",
+			ResourceType: "doc",
+			ResourceID:   "doc1",
+			AccessorID:   "target_role",
+			AccessorType: interfaces.AccessorRole,
+			EndTime:      -1,
+			Operation:    interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: "read"}}},
+		}
+		newBundle := func() *interfaces.ConfigBundle {
+			return &interfaces.ConfigBundle{
+				Version:       interfaces.ConfigBundleVersion,
+				ResourceTypes: []interfaces.ResourceType{{ID: "doc", Name: "文档"}, {ID: "menu", Name: "菜单"}},
+				Roles:         []interfaces.RoleInfo{{ID: "source_role", Name: "审计员", Description: "new"}},
+				Policies: []interfaces.PolicyInfo{
+					{
+						ResourceType: "doc",
+						ResourceID:   "doc1",
+						AccessorID:   "source_role",
+						AccessorType: interfaces.AccessorRole,
+						EndTime:      -1,
+						Operation:    interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: "read"}, {ID: "write"}}},
+					},
+					{
+						ResourceType: "doc",
+						ResourceID:   "doc2",
+						AccessorID:   "user1",
+						AccessorType: interfaces.AccessorUser,
+						EndTime:      -1,
+						Operation:    interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: "read"}}},
+					},
+				},
+			}
+		}
+		expectPlan := func() {
+			m.resourceTypeDB.EXPECT().GetByIDs(gomock.Any(), []string{"doc", "menu"}).Return(map[string]interfaces.ResourceType{
+				"doc": {ID: "doc", Name: "文档"},
+			}, nil)
+			m.roleDB.EXPECT().GetRoleByIDs(gomock.Any(), []string{"source_role"}).Return(map[string]interfaces.RoleInfo{}, nil)
+			m.roleDB.EXPECT().GetRoleByName(gomock.Any(), "审计员").Return(interfaces.RoleInfo{ID: "target_role", Name: "审计员", Description: "old"}, nil)
+			m.policyDB.EXPECT().GetByResourceIDs(gomock.Any(), "doc", []string{"doc1", "doc2"}).Return(map[string][]interfaces.PolicyInfo{
+				"doc1": {oldPolicy},
+			}, nil)
+		}
+
+		Convey("不支持的版本", func() {
+			bundle := newBundle()
+			bundle.Version = 99
+
+			_, err := c.Import(ctx, visitor, bundle, interfaces.ConfigImportOptions{Conflict: interfaces.ImportConflictSkip})
+			assert.Equal(t, gerrors.PublicBadRequest, err.(*gerrors.Error).Code)
+		})
+
+		Convey("dry run 不写入", func() {
+			expectPlan()
+
+			report, err := c.Import(ctx, visitor, newBundle(), interfaces.ConfigImportOptions{DryRun: true, Conflict: interfaces.ImportConflictSkip})
+			assert.NoError(t, err)
+			assert.True(t, report.DryRun)
+			assert.Equal(t, interfaces.ConfigImportStats{Created: 1, Unchanged: 1}, report.Stats[interfaces.ConfigItemResourceType])
+			assert.Equal(t, interfaces.ConfigImportStats{Skipped: 1}, report.Stats[interfaces.ConfigItemRole])
+			assert.Equal(t, interfaces.ConfigImportStats{Created: 1, Skipped: 1}, report.Stats[interfaces.ConfigItemPolicy])
+			assert.Equal(t, 2, len(report.Conflicts))
+			assert.Equal(t, "doc/doc1/target_role", report.Conflicts[1].ID)
+		})
+
+		Convey("冲突时失败", func() {
+			expectPlan()
+
+			_, err := c.Import(ctx, visitor, newBundle(), interfaces.ConfigImportOptions{Conflict: interfaces.ImportConflictFail})
+			assert.Equal(t, gerrors.PublicConflict, err.(*gerrors.Error).Code)
+		})
+
+		Convey("冲突时跳过", func() {
+			expectPlan()
+			m.resourceType.EXPECT().InitResourceTypes(gomock.Any(), []interfaces.ResourceType{{ID: "menu", Name: "菜单"}}).Return(nil)
+			m.obligationType.EXPECT().InitObligationTypes(gomock.Any(), gomock.Len(0)).Return(nil)
+			m.obligation.EXPECT().InitObligations(gomock.Any(), gomock.Len(0)).Return(nil)
+			m.role.EXPECT().InitRoles(gomock.Any(), gomock.Len(0)).Return(nil)
+			m.policy.EXPECT().InitPolicy(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, policies []interfaces.PolicyInfo) error {
+					assert.Equal(t, 1, len(policies))
+					assert.Equal(t, "doc2", policies[0].ResourceID)
+					return nil
+				})
+			m.policy.EXPECT().Update(gomock.Any(), visitor, gomock.Len(0)).Return(nil)
+
+			report, err := c.Import(ctx, visitor, newBundle(), interfaces.ConfigImportOptions{Conflict: interfaces.ImportConflictSkip})
+			assert.NoError(t, err)
+			assert.False(t, report.DryRun)
+		})
+
+		Convey("冲突时覆盖, 角色访问者替换为目标环境的角色ID", func() {
+			expectPlan()
+			m.resourceType.EXPECT().InitResourceTypes(gomock.Any(), gomock.Len(1)).Return(nil)
+			m.obligationType.EXPECT().InitObligationTypes(gomock.Any(), gomock.Len(0)).Return(nil)
+			m.obligation.EXPECT().InitObligations(gomock.Any(), gomock.Len(0)).Return(nil)
+			m.role.EXPECT().InitRoles(gomock.Any(), gomock.Len(1)).Return(nil)
+			m.policy.EXPECT().InitPolicy(gomock.Any(), gomock.Len(1)).Return(nil)
+			m.policy.EXPECT().Update(gomock.Any(), visitor, gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ *interfaces.Visitor, policies []interfaces.PolicyInfo) error {
+					assert.Equal(t, 1, len(policies))
+					assert.Equal(t, "old_policy", policies[0].ID)
+					assert.Equal(t, "target_role", policies[0].AccessorID)
+					assert.Equal(t, 2, len(policies[0].Operation.Allow))
+					return nil
+				})
+
+			report, err := c.Import(ctx, visitor, newBundle(), interfaces.ConfigImportOptions{Conflict: interfaces.ImportConflictOverwrite})
+			assert.NoError(t, err)
+			assert.Equal(t, interfaces.ConfigImportStats{Updated: 1}, report.Stats[interfaces.ConfigItemRole])
+			assert.Equal(t, interfaces.ConfigImportStats{Created: 1, Updated: 1}, report.Stats[interfaces.ConfigItemPolicy])
+		})
+
+		Convey("角色ID被其他角色占用", func() {
+			bundle := &interfaces.ConfigBundle{
+				Version: interfaces.ConfigBundleVersion,
+				Roles:   []interfaces.RoleInfo{{ID: "source_role", Name: "审计员"}},
+			}
+			m.roleDB.EXPECT().GetRoleByIDs(gomock.Any(), []string{"source_role"}).Return(map[string]interfaces.RoleInfo{
+				"source_role": {ID: "source_role", Name: "其他角色"},
+			}, nil)
+			m.roleDB.EXPECT().GetRoleByName(gomock.Any(), "审计员").Return(interfaces.RoleInfo{}, nil)
+
+			report, err := c.Import(ctx, visitor, bundle, interfaces.ConfigImportOptions{DryRun: true, Conflict: interfaces.ImportConflictOverwrite})
+			assert.NoError(t, err)
+			assert.Equal(t, interfaces.ConfigImportStats{Skipped: 1}, report.Stats[interfaces.ConfigItemRole])
+			assert.Equal(t, 1, len(report.Conflicts))
+		})
+	})
+}
@@ -75,3 +75,10 @@ var dbObligation interfaces.DBObligation
 func SetDBObligation(i interfaces.DBObligation) {
 	dbObligation = i
 }
+
+var dbRoleExclusion interfaces.DBRoleExclusion
+
+// SetDBRoleExclusion 设置实例
+func SetDBRoleExclusion(i interfaces.DBRoleExclusion) {
+	dbRoleExclusion = i
+}
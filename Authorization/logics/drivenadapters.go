@@ -10,3 +10,11 @@ var dnUserMgnt interfaces.DrivenUserMgnt
 func SetDnUserMgnt(i interfaces.DrivenUserMgnt) {
 	dnUserMgnt = i
 }
+
+// dnMessageBroker 实例
+var dnMessageBroker interfaces.DrivenMessageBroker
+
+// SetDnMessageBroker 设置实例
+func SetDnMessageBroker(i interfaces.DrivenMessageBroker) {
+	dnMessageBroker = i
+}
@@ -13,34 +13,36 @@ var (
 )
 
 type event struct {
-	userDeletedHandlers       []func(string) error
-	depDeletedHandlers        []func(string) error
-	userGroupDeletedHandlers  []func(string) error
-	orgNameModifiedHandlers   []func(string, string) error
-	userNameModifiedHandlers  []func(string, string) error
-	depNameModifiedHandlers   []func(string, string) error
-	groupNameModifiedHandlers []func(string, string) error
-	appDeletedHandlers        []func(string) error
-	appNameModifiedHandlers   []func(*interfaces.AppInfo) error
-	roleDeletedHandlers       []func(string) error
-	roleNameModifiedHandlers  []func(string, string) error
+	userDeletedHandlers        []func(string) error
+	depDeletedHandlers         []func(string) error
+	userGroupDeletedHandlers   []func(string) error
+	orgNameModifiedHandlers    []func(string, string) error
+	userNameModifiedHandlers   []func(string, string) error
+	depNameModifiedHandlers    []func(string, string) error
+	groupNameModifiedHandlers  []func(string, string) error
+	appDeletedHandlers         []func(string) error
+	appNameModifiedHandlers    []func(*interfaces.AppInfo) error
+	roleDeletedHandlers        []func(string) error
+	roleNameModifiedHandlers   []func(string, string) error
+	roleMembersExpiredHandlers []func(string, []string) error
 }
 
 // NewEvent 创建新的event对象
 func NewEvent() *event {
 	eOnce.Do(func() {
 		e = &event{
-			userDeletedHandlers:       make([]func(string) error, 0),
-			depDeletedHandlers:        make([]func(string) error, 0),
-			userGroupDeletedHandlers:  make([]func(string) error, 0),
-			orgNameModifiedHandlers:   make([]func(string, string) error, 0),
-			userNameModifiedHandlers:  make([]func(string, string) error, 0),
-			depNameModifiedHandlers:   make([]func(string, string) error, 0),
-			groupNameModifiedHandlers: make([]func(string, string) error, 0),
-			appDeletedHandlers:        make([]func(string) error, 0),
-			appNameModifiedHandlers:   make([]func(*interfaces.AppInfo) error, 0),
-			roleDeletedHandlers:       make([]func(string) error, 0),
-			roleNameModifiedHandlers:  make([]func(string, string) error, 0),
+			userDeletedHandlers:        make([]func(string) error, 0),
+			depDeletedHandlers:         make([]func(string) error, 0),
+			userGroupDeletedHandlers:   make([]func(string) error, 0),
+			orgNameModifiedHandlers:    make([]func(string, string) error, 0),
+			userNameModifiedHandlers:   make([]func(string, string) error, 0),
+			depNameModifiedHandlers:    make([]func(string, string) error, 0),
+			groupNameModifiedHandlers:  make([]func(string, string) error, 0),
+			appDeletedHandlers:         make([]func(string) error, 0),
+			appNameModifiedHandlers:    make([]func(*interfaces.AppInfo) error, 0),
+			roleDeletedHandlers:        make([]func(string) error, 0),
+			roleNameModifiedHandlers:   make([]func(string, string) error, 0),
+			roleMembersExpiredHandlers: make([]func(string, []string) error, 0),
 		}
 	})
 	return e
@@ -205,3 +207,19 @@ func (e *event) RoleNameModified(roleID, name string) (err error) {
 func (e *event) RegisterRoleNameModified(f func(string, string) error) {
 	e.roleNameModifiedHandlers = append(e.roleNameModifiedHandlers, f)
 }
+
+// RoleMembersExpired 角色成员过期
+func (e *event) RoleMembersExpired(roleID string, memberIDs []string) (err error) {
+	for _, f := range e.roleMembersExpiredHandlers {
+		err = f(roleID, memberIDs)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// RegisterRoleMembersExpired 注册角色成员过期
+func (e *event) RegisterRoleMembersExpired(f func(string, []string) error) {
+	e.roleMembersExpiredHandlers = append(e.roleMembersExpiredHandlers, f)
+}
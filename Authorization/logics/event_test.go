@@ -146,6 +146,22 @@ func TestRoleNameModified(t *testing.T) {
 	assert.True(t, handlerCalled)
 }
 
+func TestRoleMembersExpired(t *testing.T) {
+	e := newEvent()
+
+	handlerCalled := false
+	e.RegisterRoleMembersExpired(func(roleID string, memberIDs []string) error {
+		handlerCalled = true
+		assert.Equal(t, "testRoleID", roleID)
+		assert.Equal(t, []string{"testUserID"}, memberIDs)
+		return nil
+	})
+
+	err := e.RoleMembersExpired("testRoleID", []string{"testUserID"})
+	assert.Nil(t, err)
+	assert.True(t, handlerCalled)
+}
+
 func TestRegisterUserNameModified(t *testing.T) {
 	e := newEvent()
 
@@ -4,6 +4,7 @@ package logics
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"strings"
 	"sync"
 
@@ -315,3 +316,64 @@ func (o *obligation) GetByIDSInternal(ctx context.Context, obligationIDs map[str
 	}
 	return
 }
+
+// InitObligations 义务批量初始化, 已存在且无变化的义务跳过
+func (o *obligation) InitObligations(ctx context.Context, obligations []interfaces.ObligationInfo) error {
+	for i := range obligations {
+		info, err := o.db.GetByID(ctx, obligations[i].ID)
+		if err != nil {
+			o.logger.Errorf("InitObligations GetByID: %v", err)
+			return err
+		}
+
+		if info.ID == "" {
+			err = o.db.Add(ctx, &obligations[i])
+			if err != nil {
+				o.logger.Errorf("InitObligations Add: %v", err)
+				return err
+			}
+			continue
+		}
+
+		if !o.checkObligationChange(&info, &obligations[i]) {
+			continue
+		}
+		// 义务类型不可修改, 类型变化时重新添加, 保持ID不变
+		if info.TypeID != obligations[i].TypeID {
+			err = o.db.Delete(ctx, info.ID)
+			if err != nil {
+				o.logger.Errorf("InitObligations Delete: %v", err)
+				return err
+			}
+			err = o.db.Add(ctx, &obligations[i])
+			if err != nil {
+				o.logger.Errorf("InitObligations Add: %v", err)
+				return err
+			}
+			continue
+		}
+		err = o.db.Update(ctx, info.ID, obligations[i].Name, true, obligations[i].Description, true, obligations[i].Value, true)
+		if err != nil {
+			o.logger.Errorf("InitObligations Update: %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// checkObligationChange 检查义务是否发生变化， 有变化返回true
+func (o *obligation) checkObligationChange(old, newInfo *interfaces.ObligationInfo) bool {
+	if old.TypeID != newInfo.TypeID {
+		return true
+	}
+	if old.Name != newInfo.Name {
+		return true
+	}
+	if old.Description != newInfo.Description {
+		return true
+	}
+	if !reflect.DeepEqual(old.Value, newInfo.Value) {
+		return true
+	}
+	return false
+}
@@ -896,6 +896,71 @@ func TestObligation_GetByIDSInternal(t *testing.T) {
 	})
 }
 
+func TestObligation_InitObligations(t *testing.T) {
+	Convey("测试InitObligations方法", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBObligation(ctrl)
+		userMgnt := mock.NewMockDrivenUserMgnt(ctrl)
+		obligationType := mock.NewMockObligationType(ctrl)
+		ob := newObligation(db, userMgnt, obligationType)
+
+		ctx := context.Background()
+		info := interfaces.ObligationInfo{
+			ID:          testObligationID,
+			TypeID:      "type1",
+			Name:        testObligationName,
+			Description: testObligationDesc,
+			Value:       map[string]any{"a": "b"},
+		}
+
+		Convey("获取义务失败", func() {
+			testErr := errors.New("db error")
+			db.EXPECT().GetByID(gomock.Any(), testObligationID).Return(interfaces.ObligationInfo{}, testErr)
+
+			err := ob.InitObligations(ctx, []interfaces.ObligationInfo{info})
+			assert.Equal(t, testErr, err)
+		})
+
+		Convey("义务不存在时新增", func() {
+			db.EXPECT().GetByID(gomock.Any(), testObligationID).Return(interfaces.ObligationInfo{}, nil)
+			db.EXPECT().Add(gomock.Any(), gomock.Any()).Return(nil)
+
+			err := ob.InitObligations(ctx, []interfaces.ObligationInfo{info})
+			assert.NoError(t, err)
+		})
+
+		Convey("义务无变化时跳过", func() {
+			db.EXPECT().GetByID(gomock.Any(), testObligationID).Return(info, nil)
+
+			err := ob.InitObligations(ctx, []interfaces.ObligationInfo{info})
+			assert.NoError(t, err)
+		})
+
+		Convey("义务配置变化时更新", func() {
+			old := info
+			old.Value = map[string]any{"a": "c"}
+			db.EXPECT().GetByID(gomock.Any(), testObligationID).Return(old, nil)
+			db.EXPECT().Update(gomock.Any(), testObligationID, testObligationName, true, testObligationDesc, true, info.Value, true).Return(nil)
+
+			err := ob.InitObligations(ctx, []interfaces.ObligationInfo{info})
+			assert.NoError(t, err)
+		})
+
+		Convey("义务类型变化时重新添加", func() {
+			old := info
+			old.TypeID = "type2"
+			db.EXPECT().GetByID(gomock.Any(), testObligationID).Return(old, nil)
+			db.EXPECT().Delete(gomock.Any(), testObligationID).Return(nil)
+			db.EXPECT().Add(gomock.Any(), gomock.Any()).Return(nil)
+
+			err := ob.InitObligations(ctx, []interfaces.ObligationInfo{info})
+			assert.NoError(t, err)
+		})
+	})
+}
+
 func TestNewObligation(t *testing.T) {
 	Convey("测试NewObligation单例", t, func() {
 		// 注意：因为使用了sync.Once，这个测试可能会受到其他测试的影响
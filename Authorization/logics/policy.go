@@ -45,6 +45,7 @@ type policy struct {
 	obligationType interfaces.ObligationType
 	obligation     interfaces.LogicsObligation
 	i18n           *common.I18n
+	calcCache      *policyCalcCache
 }
 
 // NewPolicy 创建新的NewPolicy对象
@@ -61,6 +62,7 @@ func NewPolicy() *policy {
 			userMgmt:       dnUserMgnt,
 			event:          NewEvent(),
 			policyCalc:     NewPolicyCalc(),
+			calcCache:      newPolicyCalcCache(),
 			i18n: common.NewI18n(common.I18nMap{
 				i18nAccessorRoleNotFound: {
 					simplifiedChinese:  "角色不存在",
@@ -278,10 +280,20 @@ func (d *policy) checkPolicyOperationValid(operationIDMap map[string]bool, polic
 	return
 }
 
+// checkPolicyCondition 检查策略条件是否合法
+func (d *policy) checkPolicyCondition(policy *interfaces.PolicyInfo) (err error) {
+	if _, err = parseCondition(policy.Condition); err != nil {
+		err = gerrors.NewError(gerrors.PublicBadRequest, fmt.Sprintf("invalid condition: %v", err))
+		return
+	}
+	return
+}
+
 // cmpPolicy 检查新策略是否有修改
 // 以下条件认为无修改，结果返回true
 // 1. 过期时间是否相等
-// 2. 新策略的操作是否是旧策略的子集, 且操作的义务是否相等
+// 2. 条件是否相等
+// 3. 新策略的操作是否是旧策略的子集, 且操作的义务是否相等
 func (d *policy) cmpPolicy(old, newInfo *interfaces.PolicyInfo) (isSame bool) {
 	// 过期时间是否相等
 	if old.EndTime != newInfo.EndTime {
@@ -289,6 +301,12 @@ func (d *policy) cmpPolicy(old, newInfo *interfaces.PolicyInfo) (isSame bool) {
 		return
 	}
 
+	// 条件是否相等
+	if old.Condition != newInfo.Condition {
+		isSame = false
+		return
+	}
+
 	oldAllowMap := make(map[string]interfaces.PolicyOperationItem, len(old.Operation.Allow))
 	oldDenyMap := make(map[string]interfaces.PolicyOperationItem, len(old.Operation.Deny))
 	for _, deny := range old.Operation.Deny {
@@ -331,7 +349,7 @@ func (d *policy) cmpPolicy(old, newInfo *interfaces.PolicyInfo) (isSame bool) {
 }
 
 // mergeNewPolicy 合并策略 , 操作、到期时间
-// 为了方便，新的的义务会覆盖旧的义务
+// 为了方便，新的的义务会覆盖旧的义务, 新的条件会覆盖旧的条件
 func (d *policy) mergeNewPolicy(old, newInfo *interfaces.PolicyInfo) (newPolicy interfaces.PolicyInfo) {
 	allowMap := make(map[string]interfaces.PolicyOperationItem)
 	denyMap := make(map[string]interfaces.PolicyOperationItem)
@@ -384,6 +402,7 @@ func (d *policy) mergeNewPolicy(old, newInfo *interfaces.PolicyInfo) (newPolicy
 	newPolicy.AccessorID = old.AccessorID
 	newPolicy.AccessorType = old.AccessorType
 	newPolicy.AccessorName = old.AccessorName
+	newPolicy.Condition = newInfo.Condition
 	newPolicy.EndTime = d.calcMinEndTime(old.EndTime, newInfo.EndTime)
 	return
 }
@@ -509,6 +528,11 @@ func (d *policy) Create(ctx context.Context, visitor *interfaces.Visitor, policy
 		if err != nil {
 			return
 		}
+
+		err = d.checkPolicyCondition(&policy)
+		if err != nil {
+			return
+		}
 	}
 
 	idNameMap, err := d.getAccessorName(ctx, visitor, accessorTypeMap, accessorRoleMap)
@@ -542,6 +566,8 @@ func (d *policy) Create(ctx context.Context, visitor *interfaces.Visitor, policy
 				d.logger.Errorf("Create Transaction Commit Error:%v", err)
 				return
 			}
+			// 策略变更, 失效策略计算缓存
+			d.calcCache.invalidatePolicies()
 		default:
 			rollbackErr := tx.Rollback()
 			if rollbackErr != nil {
@@ -629,6 +655,12 @@ func (d *policy) CreatePrivate(ctx context.Context, policys []interfaces.PolicyI
 			d.logger.Errorf("CreatePrivate checkPolicyOperationValid : %v", err)
 			return
 		}
+
+		err = d.checkPolicyCondition(&policy)
+		if err != nil {
+			d.logger.Errorf("CreatePrivate checkPolicyCondition : %v", err)
+			return
+		}
 	}
 
 	// 内部接口默认中文
@@ -666,6 +698,8 @@ func (d *policy) CreatePrivate(ctx context.Context, policys []interfaces.PolicyI
 				d.logger.Errorf("CreatePrivate Transaction Commit Error:%v", err)
 				return
 			}
+			// 策略变更, 失效策略计算缓存
+			d.calcCache.invalidatePolicies()
 		default:
 			rollbackErr := tx.Rollback()
 			if rollbackErr != nil {
@@ -714,6 +748,11 @@ func (d *policy) Update(ctx context.Context, visitor *interfaces.Visitor, policy
 		if err != nil {
 			return
 		}
+		// 检查条件
+		err = d.checkPolicyCondition(&policys[i])
+		if err != nil {
+			return
+		}
 	}
 
 	oldPoliciesMap, err := d.db.GetByPolicyIDs(ctx, policyIDs)
@@ -779,6 +818,8 @@ func (d *policy) Update(ctx context.Context, visitor *interfaces.Visitor, policy
 				d.logger.Errorf("Update Transaction Commit Error:%v", err)
 				return
 			}
+			// 策略变更, 失效策略计算缓存
+			d.calcCache.invalidatePolicies()
 		default:
 			rollbackErr := tx.Rollback()
 			if rollbackErr != nil {
@@ -822,7 +863,12 @@ func (d *policy) Delete(ctx context.Context, visitor *interfaces.Visitor, ids []
 		return
 	}
 
-	return d.db.Delete(ctx, ids)
+	err = d.db.Delete(ctx, ids)
+	if err != nil {
+		return
+	}
+	d.calcCache.invalidatePolicies()
+	return
 }
 
 // DeleteByResourceIDs 删除策略 根据资源id删除策略
@@ -831,11 +877,21 @@ func (d *policy) DeleteByResourceIDs(ctx context.Context, resources []interfaces
 		return nil
 	}
 
-	return d.db.DeleteByResourceIDs(ctx, resources)
+	err := d.db.DeleteByResourceIDs(ctx, resources)
+	if err != nil {
+		return err
+	}
+	d.calcCache.invalidatePolicies()
+	return nil
 }
 
 func (d *policy) deletePolicyByAccessorID(accessorID string) error {
-	return d.db.DeleteByAccessorIDs([]string{accessorID})
+	err := d.db.DeleteByAccessorIDs([]string{accessorID})
+	if err != nil {
+		return err
+	}
+	d.calcCache.invalidatePolicies()
+	return nil
 }
 
 // updatePolicyAccessorName 更新策略访问者名称
@@ -855,7 +911,12 @@ func (d *policy) UpdateResourceName(ctx context.Context, resourceID, resourceTyp
 
 // DeleteByEndTime 删除过期策略
 func (d *policy) DeleteByEndTime(curTime int64) error {
-	return d.db.DeleteByEndTime(curTime)
+	err := d.db.DeleteByEndTime(curTime)
+	if err != nil {
+		return err
+	}
+	d.calcCache.invalidatePolicies()
+	return nil
 }
 
 // InitPolicy 初始化策略
@@ -898,6 +959,8 @@ func (d *policy) InitPolicy(ctx context.Context, policys []interfaces.PolicyInfo
 				d.logger.Errorf("InitPolicy Transaction Commit Error:%v", err)
 				return
 			}
+			// 策略变更, 失效策略计算缓存
+			d.calcCache.invalidatePolicies()
 		default:
 			rollbackErr := tx.Rollback()
 			if rollbackErr != nil {
@@ -960,8 +1023,10 @@ func (d *policy) checkVisitorAuthorize(ctx context.Context, visitor *interfaces.
 				Type: resourceType,
 			}
 			checkResult, err := d.policyCalc.Check(ctx, &resource, &interfaces.AccessorInfo{
-				ID:   visitor.ID,
-				Type: visitor.Type,
+				ID:         visitor.ID,
+				Type:       visitor.Type,
+				IP:         visitor.IP,
+				ClientType: visitor.ClientType,
 			}, operations, []interfaces.PolicCalcyIncludeType{})
 			if err != nil {
 				return err
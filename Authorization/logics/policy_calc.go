@@ -45,6 +45,10 @@ type policyCalc struct {
 	obligation   interfaces.LogicsObligation
 	// 义务优先级
 	obligationPriority map[interfaces.AccessorType]int
+	// 解析后的策略条件 [条件字符串]*policyCondition, 为 nil 时不缓存
+	conditions *lruCache
+	// 访问令牌和策略缓存, 为 nil 时不缓存
+	cache *policyCalcCache
 }
 
 // NewPolicyCalc 创建新的LogicsPolicyCalc对象
@@ -58,6 +62,8 @@ func NewPolicyCalc() interfaces.LogicsPolicyCalc {
 			event:        NewEvent(),
 			resourceType: NewResourceType(),
 			obligation:   NewObligation(),
+			cache:        newPolicyCalcCache(),
+			conditions:   newLRUCache(conditionCacheMaxEntries, conditionCacheTTL),
 			obligationPriority: map[interfaces.AccessorType]int{
 				interfaces.AccessorUser:       1,
 				interfaces.AccessorApp:        1,
@@ -81,7 +87,9 @@ func (d *policyCalc) Check(ctx context.Context, resource *interfaces.ResourceInf
 		return
 	}
 	// 获取资源策略
-	policies, err := d.db.GetPoliciesByResourcesAndAccessToken(ctx, []interfaces.ResourceInfo{*resource}, accessTokens)
+	policies, err := d.cache.loadPolicies(resource, accessTokens, func() ([]interfaces.PolicyInfo, error) {
+		return d.db.GetPoliciesByResourcesAndAccessToken(ctx, []interfaces.ResourceInfo{*resource}, accessTokens)
+	})
 	if err != nil {
 		d.logger.Errorf("Check GetPoliciesByResourcesAndAccessToken  err:%v", err)
 		return checkResult, err
@@ -95,39 +103,120 @@ func (d *policyCalc) Check(ctx context.Context, resource *interfaces.ResourceInf
 	time1 := common.GetCurrentMicrosecondTimestamp()
 	d.logger.Debugf("CheckpolicyMap, end, cost: %d", time1-time0)
 
+	checkResult = d.checkByPolicyMap(ctx, resource, accessor, operation, include, policyMap)
+	d.logger.Debugf("Check end result:%v, resource:%+v, accessor:%+v, operation: %v", checkResult.Result, *resource, *accessor, operation)
+	return checkResult, nil
+}
+
+// checkByPolicyMap 根据资源各层级的策略计算检查结果
+func (d *policyCalc) checkByPolicyMap(ctx context.Context, resource *interfaces.ResourceInfo, accessor *interfaces.AccessorInfo, operation []string,
+	include []interfaces.PolicCalcyIncludeType, policyMap map[string][]interfaces.PolicyInfo,
+) (checkResult interfaces.CheckResult) {
+	// 判断是否包含 操作义务信息、决策说明
+	includeMap := make(map[interfaces.PolicCalcyIncludeType]bool)
+	for _, v := range include {
+		includeMap[v] = true
+	}
+
 	// 计算每个单个资源ID的权限
 	// resourcePermMap[单个资源ID]操作权限
-	resourcePermMap := make(map[string]resourcePerm)
-	for resourceID, policies := range policyMap {
-		if len(policies) == 0 {
-			continue
-		}
-		resourcePermMap[resourceID] = d.calcOneResourcePerm(resourceID, policies)
+	env := d.newConditionEnv(accessor, resource)
+	resourcePermMap := d.calcResourcePermMap(policyMap, env)
+	if includeMap[interfaces.PolicCalcyIncludeExplain] {
+		checkResult.Explain = d.explainOperations(resource, policyMap, env, operation)
 	}
 
 	// 计算资源继承的权限
 	allowMap, denyMap, allowMapWithObligation := d.calcResourceInheritedOperation(resource, resourcePermMap)
-	checkResult.Result = true
 	// 所有的操作 都被允许 返回true，否则返回false
 	for _, v := range operation {
 		if denyMap[v] || !allowMap[v] {
 			// 如果没有允许或者被拒绝，则返回false
-			checkResult.Result = false
-			d.logger.Debugf("Check end result:%v, resource:%+v, accessor:%+v, operation: %v", checkResult.Result, *resource, *accessor, v)
+			d.logger.Debugf("Check result:false, resource:%+v, accessor:%+v, operation: %v", *resource, *accessor, v)
 			return
 		}
 	}
+	checkResult.Result = true
 
-	// 判断是否包含 操作义务信息
-	includeMap := make(map[interfaces.PolicCalcyIncludeType]bool)
-	for _, v := range include {
-		includeMap[v] = true
-	}
 	if includeMap[interfaces.PolicCalcyIncludeOperationObligations] {
 		checkResult.OperatrionOblist = d.calcObligationWithPriority(ctx, allowMapWithObligation)
 	}
-	d.logger.Debugf("Check end result:%v, resource:%+v, accessor:%+v, operation: %v", checkResult.Result, *resource, *accessor, operation)
-	return checkResult, nil
+	return
+}
+
+// BatchCheck 批量检查, 相同访问者只获取一次访问令牌, 相同资源类型只查询一次策略, 结果与入参顺序一致
+func (d *policyCalc) BatchCheck(ctx context.Context, items []interfaces.BatchCheckItem, include []interfaces.PolicCalcyIncludeType) (
+	results []interfaces.CheckResult, err error,
+) {
+	if len(items) == 0 {
+		return
+	}
+	d.logger.Debugf("BatchCheck start, items length: %v", len(items))
+	// 获取每个访问者的访问令牌 [访问者key]访问令牌
+	accessTokensMap := make(map[string][]string)
+	// 按资源类型汇总资源和访问令牌
+	typeResources := make(map[string][]interfaces.ResourceInfo)
+	typeAccessTokens := make(map[string]map[string]bool)
+	for i := range items {
+		key := accessTokenCacheKey(&items[i].Accessor)
+		if _, ok := accessTokensMap[key]; !ok {
+			var accessTokens []string
+			accessTokens, err = d.getAccessorIDs(ctx, &items[i].Accessor)
+			if err != nil {
+				return nil, err
+			}
+			accessTokensMap[key] = accessTokens
+		}
+
+		resourceType := items[i].Resource.Type
+		typeResources[resourceType] = append(typeResources[resourceType], items[i].Resource)
+		if _, ok := typeAccessTokens[resourceType]; !ok {
+			typeAccessTokens[resourceType] = make(map[string]bool)
+		}
+		for _, token := range accessTokensMap[key] {
+			typeAccessTokens[resourceType][token] = true
+		}
+	}
+
+	// 获取资源策略 [资源类型][资源ID]策略
+	typePolicyMap := make(map[string]map[string][]interfaces.PolicyInfo, len(typeResources))
+	for resourceType, resources := range typeResources {
+		accessTokens := make([]string, 0, len(typeAccessTokens[resourceType]))
+		for token := range typeAccessTokens[resourceType] {
+			accessTokens = append(accessTokens, token)
+		}
+		var policies []interfaces.PolicyInfo
+		policies, err = d.db.GetPoliciesByResourcesAndAccessToken(ctx, resources, accessTokens)
+		if err != nil {
+			d.logger.Errorf("BatchCheck GetPoliciesByResourcesAndAccessToken resourceType:%s err:%v", resourceType, err)
+			return nil, err
+		}
+		policyMap := make(map[string][]interfaces.PolicyInfo)
+		for i := range policies {
+			policyMap[policies[i].ResourceID] = append(policyMap[policies[i].ResourceID], policies[i])
+		}
+		typePolicyMap[resourceType] = policyMap
+	}
+
+	results = make([]interfaces.CheckResult, len(items))
+	for i := range items {
+		tokenMap := make(map[string]bool)
+		for _, token := range accessTokensMap[accessTokenCacheKey(&items[i].Accessor)] {
+			tokenMap[token] = true
+		}
+		// 只保留当前访问者的策略
+		policyMap := make(map[string][]interfaces.PolicyInfo)
+		for resourceID, policies := range typePolicyMap[items[i].Resource.Type] {
+			for j := range policies {
+				if tokenMap[policies[j].AccessorID] {
+					policyMap[resourceID] = append(policyMap[resourceID], policies[j])
+				}
+			}
+		}
+		results[i] = d.checkByPolicyMap(ctx, &items[i].Resource, &items[i].Accessor, items[i].Operation, include, policyMap)
+	}
+	d.logger.Debugf("BatchCheck end, items length: %v", len(items))
+	return results, nil
 }
 
 func (p *policyCalc) calcObligationWithPriority(ctx context.Context, allowMapWithObligation map[string][]policyObligationCalcItem) (result map[string][]interfaces.PolicyObligationItem) {
@@ -218,22 +307,36 @@ func (d *policyCalc) GetResourceList(ctx context.Context, resourceTypeID string,
 
 	// 计算每个单个资源ID的权限
 	// resourcePermMap[单个资源ID]操作权限
-	resourcePermMap := make(map[string]resourcePerm)
-	for resourceID, policies := range policyMap {
-		if len(policies) == 0 {
+	// 存在条件策略时, 条件与资源相关, 需要按资源分别计算
+	hasCondition := hasConditionPolicy(policies)
+	var resourcePermMap map[string]resourcePerm
+	if !hasCondition {
+		resourcePermMap = d.calcResourcePermMap(policyMap, d.newConditionEnv(accessor, &interfaces.ResourceInfo{Type: resourceTypeID}))
+	}
+
+	allResources := make([]interfaces.ResourceInfo, 0, len(policyMap))
+	resourcePermMaps := make(map[string]map[string]resourcePerm, len(policyMap))
+	for resourceID := range policyMap {
+		resource := interfaces.ResourceInfo{ID: resourceID, Type: resourceTypeID}
+		permMap := resourcePermMap
+		if hasCondition {
+			// 列举时只有资源ID和类型, 没有资源属性, 依赖资源属性的条件无法计算, 只有拒绝生效
+			// 资源列表上只继承资源类型的策略, 只需计算本资源和资源类型的策略
+			permMap = d.calcResourcePermMap(map[string][]interfaces.PolicyInfo{
+				"*":        policyMap["*"],
+				resourceID: policyMap[resourceID],
+			}, d.newConditionEnv(accessor, &resource))
+		}
+		if _, ok := permMap[resourceID]; !ok {
 			continue
 		}
-		resourcePermMap[resourceID] = d.calcOneResourcePerm(resourceID, policies)
-	}
-
-	allResources := make([]interfaces.ResourceInfo, 0, len(resourcePermMap))
-	for resourceID := range resourcePermMap {
 		allResources = append(allResources, interfaces.ResourceInfo{ID: resourceID})
+		resourcePermMaps[resourceID] = permMap
 	}
 	resources = make([]interfaces.ResourceInfo, 0, len(allResources))
 	resourceOperationObligationMap = make(map[string]map[string][]interfaces.PolicyObligationItem)
 	for _, resource := range allResources {
-		allowMap, denyMap, allowMapWithObligation := d.calcResourceInheritedOperation(&resource, resourcePermMap)
+		allowMap, denyMap, allowMapWithObligation := d.calcResourceInheritedOperation(&resource, resourcePermMaps[resource.ID])
 		checkResult := true
 		// 所有的操作 都被允许 返回true，否则false
 		for _, v := range operation {
@@ -286,19 +389,19 @@ func (d *policyCalc) ResourceFilter(ctx context.Context, resources []interfaces.
 
 	// 计算每个单个资源ID的权限
 	// resourcePermMap[单个资源ID]操作权限
-	resourcePermMap := make(map[string]resourcePerm)
-	for resourceID, policies := range policyMap {
-		if len(policies) == 0 {
-			continue
-		}
-		resourcePermMap[resourceID] = d.calcOneResourcePerm(resourceID, policies)
-	}
+	// 存在条件策略时, 条件与资源属性相关, 需要按资源分别计算
+	hasCondition := hasConditionPolicy(policies)
+	resourcePermMap := d.calcResourcePermMap(policyMap, d.newConditionEnv(accessor, nil))
 
 	resourceOperationMap = make(map[string][]string, len(resources))
 	// 过滤有权限的列表
 	resourceOperationObligationMap = make(map[string]map[string][]interfaces.PolicyObligationItem)
 	for _, resource := range resources {
-		allowMap, denyMap, allowMapWithObligation := d.calcResourceInheritedOperation(&resource, resourcePermMap)
+		permMap := resourcePermMap
+		if hasCondition {
+			permMap = d.calcResourcePermMap(policyMap, d.newConditionEnv(accessor, &resource))
+		}
+		allowMap, denyMap, allowMapWithObligation := d.calcResourceInheritedOperation(&resource, permMap)
 		hasOperation := true
 		// 所有的操作 都被允许 返回true，否则返回false
 		for _, v := range operation {
@@ -356,6 +459,7 @@ func (d *policyCalc) GetResourceTypeOperation(ctx context.Context, resourceTypes
 
 	resourcePermMap := make(map[string]resourcePerm)
 	for resourceType, policies := range policyMap {
+		policies = d.filterPoliciesByCondition(policies, d.newConditionEnv(accessor, &interfaces.ResourceInfo{ID: "*", Type: resourceType}))
 		if len(policies) == 0 {
 			continue
 		}
@@ -408,13 +512,9 @@ func (d *policyCalc) GetResourceOperation(ctx context.Context, resources []inter
 		policyMap[policies[i].ResourceID] = append(policyMap[policies[i].ResourceID], policies[i])
 	}
 
-	resourcePermMap := make(map[string]resourcePerm)
-	for resourceID, policies := range policyMap {
-		if len(policies) == 0 {
-			continue
-		}
-		resourcePermMap[resourceID] = d.calcOneResourcePerm(resourceID, policies)
-	}
+	// 存在条件策略时, 条件与资源属性相关, 需要按资源分别计算
+	hasCondition := hasConditionPolicy(policies)
+	resourcePermMap := d.calcResourcePermMap(policyMap, d.newConditionEnv(accessor, nil))
 
 	resourceTypeMap, err := d.resourceType.GetByIDsInternal(ctx, []string{resources[0].Type})
 	if err != nil {
@@ -428,7 +528,11 @@ func (d *policyCalc) GetResourceOperation(ctx context.Context, resources []inter
 	resourceOperationMap = make(map[string][]string)
 	resourceOperationObligationMap = make(map[string]map[string][]interfaces.PolicyObligationItem)
 	for _, resource := range resources {
-		allowMap, _, allowMapWithObligation := d.calcResourceInheritedOperation(&resource, resourcePermMap)
+		permMap := resourcePermMap
+		if hasCondition {
+			permMap = d.calcResourcePermMap(policyMap, d.newConditionEnv(accessor, &resource))
+		}
+		allowMap, _, allowMapWithObligation := d.calcResourceInheritedOperation(&resource, permMap)
 		resourceOperationMap[resource.ID] = make([]string, 0, len(allowMap))
 		resourceOperationObligationMap[resource.ID] = d.calcObligationWithPriority(ctx, allowMapWithObligation)
 		for key := range allowMap {
@@ -489,6 +593,98 @@ func (d *policyCalc) calcResourceInheritedOperation(resource *interfaces.Resourc
 	return
 }
 
+// newConditionEnv 创建条件计算上下文
+func (d *policyCalc) newConditionEnv(accessor *interfaces.AccessorInfo, resource *interfaces.ResourceInfo) *conditionEnv {
+	return &conditionEnv{
+		now:      common.Now(),
+		accessor: accessor,
+		resource: resource,
+	}
+}
+
+// hasConditionPolicy 是否存在配置了条件的策略
+func hasConditionPolicy(policies []interfaces.PolicyInfo) bool {
+	for i := range policies {
+		if policies[i].Condition != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// getCondition 获取解析后的策略条件, 解析结果按条件字符串缓存
+func (d *policyCalc) getCondition(condition string) (cond *policyCondition, err error) {
+	if d.conditions == nil {
+		return parseCondition(condition)
+	}
+	if v, ok := d.conditions.get(condition); ok {
+		return v.(*policyCondition), nil
+	}
+	generation := d.conditions.getGeneration()
+	cond, err = parseCondition(condition)
+	if err != nil {
+		return nil, err
+	}
+	d.conditions.set(condition, cond, generation)
+	return cond, nil
+}
+
+/*
+过滤条件不满足的策略
+条件无法解析或无法计算时按最严格处理: 允许的操作不生效, 拒绝的操作仍然生效
+*/
+func (d *policyCalc) filterPoliciesByCondition(policies []interfaces.PolicyInfo, env *conditionEnv) []interfaces.PolicyInfo {
+	if !hasConditionPolicy(policies) {
+		return policies
+	}
+	result := make([]interfaces.PolicyInfo, 0, len(policies))
+	for i := range policies {
+		cond, err := d.getCondition(policies[i].Condition)
+		if err != nil {
+			d.logger.Errorf("filterPoliciesByCondition policy:%s, condition:%s, err:%v", policies[i].ID, policies[i].Condition, err)
+			result = appendDenyOnly(result, &policies[i])
+			continue
+		}
+		if cond == nil {
+			result = append(result, policies[i])
+			continue
+		}
+		switch cond.eval(env) {
+		case conditionTrue:
+			result = append(result, policies[i])
+		case conditionUnknown:
+			d.logger.Debugf("filterPoliciesByCondition policy:%s condition cannot be evaluated, only deny takes effect", policies[i].ID)
+			result = appendDenyOnly(result, &policies[i])
+		default:
+			d.logger.Debugf("filterPoliciesByCondition policy:%s condition not satisfied", policies[i].ID)
+		}
+	}
+	return result
+}
+
+// appendDenyOnly 只保留策略中拒绝的操作
+func appendDenyOnly(policies []interfaces.PolicyInfo, policy *interfaces.PolicyInfo) []interfaces.PolicyInfo {
+	if len(policy.Operation.Deny) == 0 {
+		return policies
+	}
+	denyPolicy := *policy
+	denyPolicy.Operation = interfaces.PolicyOperation{Deny: policy.Operation.Deny}
+	return append(policies, denyPolicy)
+}
+
+// calcResourcePermMap 计算每个单个资源ID的权限, 条件不满足的策略不参与计算
+func (d *policyCalc) calcResourcePermMap(policyMap map[string][]interfaces.PolicyInfo, env *conditionEnv) (resourcePermMap map[string]resourcePerm) {
+	resourcePermMap = make(map[string]resourcePerm)
+	for resourceID, policies := range policyMap {
+		policies = d.filterPoliciesByCondition(policies, env)
+		if len(policies) == 0 {
+			continue
+		}
+		resourcePermMap[resourceID] = d.calcOneResourcePerm(resourceID, policies)
+	}
+	return
+}
+
 type policyObligationCalcItem struct {
 	interfaces.PolicyObligationItem
 	priority int // 优先级
@@ -547,9 +743,18 @@ func (d *policyCalc) calcOneResourcePerm(resourceID string, policies []interface
 1. 访问者自身ID
 2. 访问者所属的组织架构，应用账户目前没有组织架构
 3. 访问者和所属的组织架构关联的角色
+优先从缓存获取
 */
-//nolint:staticcheck
 func (d *policyCalc) getAccessorIDs(ctx context.Context, accessor *interfaces.AccessorInfo) (accessTokens []string, err error) {
+	return d.cache.loadAccessTokens(accessor, func() ([]string, error) {
+		return d.loadAccessorIDs(ctx, accessor)
+	})
+}
+
+// loadAccessorIDs 查询访问者的访问令牌
+//
+//nolint:staticcheck
+func (d *policyCalc) loadAccessorIDs(ctx context.Context, accessor *interfaces.AccessorInfo) (accessTokens []string, err error) {
 	d.logger.Debugf("getAccessorIDs start, accessor.ID: %s, accessor.Type: %v", accessor.ID, accessor.Type)
 	// 查找当前用户的组织架构
 	if accessor.Type == interfaces.RealName {
@@ -565,7 +770,7 @@ func (d *policyCalc) getAccessorIDs(ctx context.Context, accessor *interfaces.Ac
 		accessTokens = []string{accessor.ID}
 	}
 	d.logger.Debugf("accessor.ID: %s, accessor.Type: %v, before GetRoleByMembers length: %v, accessTokens: %v", accessor.ID, accessor.Type, len(accessTokens), accessTokens)
-	// 获取角色
+	// 获取角色, 包含继承的角色
 	roles, err := d.role.GetRoleByMembers(ctx, accessTokens)
 	if err != nil {
 		d.logger.Errorf("getRoleByMembers  userID:%s err:%v", accessor.ID, err)
@@ -608,6 +813,11 @@ func (d *policyCalc) getAccessorIDs(ctx context.Context, accessor *interfaces.Ac
 	return
 }
 
+// GetCacheStats 获取策略计算缓存统计信息
+func (d *policyCalc) GetCacheStats() interfaces.PolicyCalcCacheStats {
+	return d.cache.stats()
+}
+
 func (d *policyCalc) checkOperationScope(resourceID, operationID string, typeOperationMap, instanceOperationMap map[string]bool) (result bool) {
 	if resourceID == "*" {
 		if typeOperationMap[operationID] {
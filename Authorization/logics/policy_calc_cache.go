@@ -0,0 +1,283 @@
+// Package logics policy_calc_cache 策略计算缓存
+package logics
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"Authorization/common"
+	"Authorization/interfaces"
+)
+
+const (
+	defaultPolicyCalcCacheTTL        = 10 * time.Second
+	defaultPolicyCalcCacheMaxEntries = 100000
+)
+
+var (
+	policyCalcCacheOnce      sync.Once
+	policyCalcCacheSingleton *policyCalcCache
+)
+
+/*
+策略计算缓存, 包含访问令牌缓存和策略缓存
+1. 本服务内的角色成员变更、策略变更直接失效缓存
+2. 用户、部门、用户组、应用账户、角色删除通过 LogicsEvent 失效缓存
+3. 消息队列的消息只会投递到一个实例, 其他实例以及用户所属部门变更等没有消息的场景依赖有效期保证最终一致
+4. 授权撤销后, 其他实例最多在有效期 (policy_calc_cache.ttl_seconds, 默认 10 秒) 内仍返回撤销前的结果,
+对一致性要求更高时可调小有效期, 或通过 policy_calc_cache.disabled 关闭缓存
+缓存为 nil 时不缓存, 直接调用加载函数
+*/
+type policyCalcCache struct {
+	accessTokens *lruCache
+	policies     *lruCache
+}
+
+// newPolicyCalcCache 创建策略计算缓存, 配置关闭时返回 nil
+func newPolicyCalcCache() *policyCalcCache {
+	policyCalcCacheOnce.Do(func() {
+		conf := common.SvcConfig.PolicyCalcCache
+		if conf.Disabled {
+			return
+		}
+		ttl := defaultPolicyCalcCacheTTL
+		if conf.TTLSeconds > 0 {
+			ttl = time.Duration(conf.TTLSeconds) * time.Second
+		}
+		maxEntries := defaultPolicyCalcCacheMaxEntries
+		if conf.MaxEntries > 0 {
+			maxEntries = conf.MaxEntries
+		}
+		policyCalcCacheSingleton = &policyCalcCache{
+			accessTokens: newLRUCache(maxEntries, ttl),
+			policies:     newLRUCache(maxEntries, ttl),
+		}
+
+		event := NewEvent()
+		// 用户删除, 失效该用户的访问令牌和所有策略
+		event.RegisterUserDeleted(policyCalcCacheSingleton.userDeleted)
+		// 部门、用户组、应用账户、角色删除, 影响的访问者无法确定, 全部失效
+		event.RegisterDepartmentDeleted(policyCalcCacheSingleton.orgDeleted)
+		event.RegisterUserGroupDeleted(policyCalcCacheSingleton.orgDeleted)
+		event.RegisterAppDeleted(policyCalcCacheSingleton.orgDeleted)
+		event.RegisterRoleDeleted(policyCalcCacheSingleton.orgDeleted)
+	})
+	return policyCalcCacheSingleton
+}
+
+func (c *policyCalcCache) userDeleted(userID string) error {
+	c.accessTokens.remove(accessTokenCacheKey(&interfaces.AccessorInfo{ID: userID, Type: interfaces.RealName}))
+	c.policies.purge()
+	return nil
+}
+
+func (c *policyCalcCache) orgDeleted(string) error {
+	c.invalidateAll()
+	return nil
+}
+
+// invalidateAccessTokens 角色成员变更, 失效所有访问令牌
+func (c *policyCalcCache) invalidateAccessTokens() {
+	if c == nil {
+		return
+	}
+	c.accessTokens.purge()
+}
+
+// invalidatePolicies 策略变更, 失效所有策略
+func (c *policyCalcCache) invalidatePolicies() {
+	if c == nil {
+		return
+	}
+	c.policies.purge()
+}
+
+// invalidateAll 失效全部缓存
+func (c *policyCalcCache) invalidateAll() {
+	if c == nil {
+		return
+	}
+	c.accessTokens.purge()
+	c.policies.purge()
+}
+
+// loadAccessTokens 获取访问者的访问令牌, 未命中时调用 load 加载。返回的切片为共享数据, 调用方不可修改
+func (c *policyCalcCache) loadAccessTokens(accessor *interfaces.AccessorInfo, load func() ([]string, error)) ([]string, error) {
+	if c == nil {
+		return load()
+	}
+	key := accessTokenCacheKey(accessor)
+	if v, ok := c.accessTokens.get(key); ok {
+		return v.([]string), nil
+	}
+	generation := c.accessTokens.getGeneration()
+	accessTokens, err := load()
+	if err != nil {
+		return nil, err
+	}
+	c.accessTokens.set(key, accessTokens, generation)
+	return accessTokens, nil
+}
+
+// loadPolicies 获取资源上访问令牌对应的策略, 未命中时调用 load 加载。返回的切片为共享数据, 调用方不可修改
+func (c *policyCalcCache) loadPolicies(resource *interfaces.ResourceInfo, accessTokens []string,
+	load func() ([]interfaces.PolicyInfo, error),
+) ([]interfaces.PolicyInfo, error) {
+	if c == nil {
+		return load()
+	}
+	key := policyCacheKey(resource, accessTokens)
+	if v, ok := c.policies.get(key); ok {
+		return v.([]interfaces.PolicyInfo), nil
+	}
+	generation := c.policies.getGeneration()
+	policies, err := load()
+	if err != nil {
+		return nil, err
+	}
+	c.policies.set(key, policies, generation)
+	return policies, nil
+}
+
+// stats 缓存统计信息
+func (c *policyCalcCache) stats() (stats interfaces.PolicyCalcCacheStats) {
+	if c == nil {
+		return
+	}
+	stats.Enabled = true
+	stats.TTLSeconds = int64(c.policies.ttl / time.Second)
+	stats.AccessToken = c.accessTokens.stats()
+	stats.Policy = c.policies.stats()
+	return
+}
+
+func accessTokenCacheKey(accessor *interfaces.AccessorInfo) string {
+	return strconv.Itoa(int(accessor.Type)) + ":" + accessor.ID
+}
+
+// policyCacheKey 资源与访问令牌组合的缓存key, 访问令牌数量可能较多, 使用摘要
+func policyCacheKey(resource *interfaces.ResourceInfo, accessTokens []string) string {
+	tokens := slices.Clone(accessTokens)
+	slices.Sort(tokens)
+	sum := sha256.Sum256([]byte(strings.Join(tokens, ",")))
+	return strings.Join([]string{resource.Type, resource.ParentIDPath, resource.ID, hex.EncodeToString(sum[:])}, "\x00")
+}
+
+/*
+有容量上限和有效期的 LRU 缓存
+generation 在清空时递增, 加载前记录 generation, 加载期间发生清空时不写入, 避免写入过期数据
+*/
+type lruCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	ll         *list.List
+	items      map[string]*list.Element
+	generation uint64
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64
+}
+
+type lruEntry struct {
+	key      string
+	value    any
+	expireAt time.Time
+}
+
+func newLRUCache(maxEntries int, ttl time.Duration) *lruCache {
+	return &lruCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+func (c *lruCache) get(key string) (value any, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[key]
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if time.Now().After(entry.expireAt) {
+		c.removeElement(elem)
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.ll.MoveToFront(elem)
+	c.hits.Add(1)
+	return entry.value, true
+}
+
+func (c *lruCache) getGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+func (c *lruCache) set(key string, value any, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+	expireAt := time.Now().Add(c.ttl)
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.value = value
+		entry.expireAt = expireAt
+		c.ll.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&lruEntry{key: key, value: value, expireAt: expireAt})
+	for c.ll.Len() > c.maxEntries {
+		c.removeElement(c.ll.Back())
+		c.evictions.Add(1)
+	}
+}
+
+func (c *lruCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+func (c *lruCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	c.generation++
+}
+
+func (c *lruCache) removeElement(elem *list.Element) {
+	c.ll.Remove(elem)
+	delete(c.items, elem.Value.(*lruEntry).key)
+}
+
+func (c *lruCache) stats() interfaces.CacheStats {
+	c.mu.Lock()
+	size := c.ll.Len()
+	c.mu.Unlock()
+	return interfaces.CacheStats{
+		Size:      size,
+		Capacity:  c.maxEntries,
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+}
@@ -0,0 +1,135 @@
+package logics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"Authorization/interfaces"
+	"Authorization/interfaces/mock"
+)
+
+func newTestPolicyCalcCache(maxEntries int, ttl time.Duration) *policyCalcCache {
+	return &policyCalcCache{
+		accessTokens: newLRUCache(maxEntries, ttl),
+		policies:     newLRUCache(maxEntries, ttl),
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	Convey("LRU 缓存", t, func() {
+		c := newLRUCache(2, time.Minute)
+
+		Convey("超过容量淘汰最久未使用的条目", func() {
+			c.set("a", 1, c.getGeneration())
+			c.set("b", 2, c.getGeneration())
+			_, ok := c.get("a")
+			assert.Equal(t, ok, true)
+			c.set("c", 3, c.getGeneration())
+
+			_, ok = c.get("b")
+			assert.Equal(t, ok, false)
+			v, ok := c.get("a")
+			assert.Equal(t, ok, true)
+			assert.Equal(t, v, 1)
+			stats := c.stats()
+			assert.Equal(t, stats.Size, 2)
+			assert.Equal(t, stats.Evictions, int64(1))
+			assert.Equal(t, stats.Hits, int64(2))
+			assert.Equal(t, stats.Misses, int64(1))
+		})
+
+		Convey("过期条目不返回", func() {
+			c.ttl = -time.Second
+			c.set("a", 1, c.getGeneration())
+			_, ok := c.get("a")
+			assert.Equal(t, ok, false)
+			assert.Equal(t, c.stats().Size, 0)
+		})
+
+		Convey("加载期间清空缓存, 加载结果不写入", func() {
+			generation := c.getGeneration()
+			c.purge()
+			c.set("a", 1, generation)
+			_, ok := c.get("a")
+			assert.Equal(t, ok, false)
+		})
+	})
+}
+
+func TestPolicyCalcCheckWithCache(t *testing.T) {
+	Convey("单个检查接口, 使用缓存", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		pdb := mock.NewMockDBPolicyCalc(ctrl)
+		userMgnt := mock.NewMockDrivenUserMgnt(ctrl)
+		role := mock.NewMockLogicsRole(ctrl)
+		pc := newPolicyCalc(pdb, userMgnt, role)
+		pc.cache = newTestPolicyCalcCache(10, time.Minute)
+
+		ctx := context.Background()
+		resource := interfaces.ResourceInfo{ID: resourceID, Type: resourceTypeDoc}
+		accessor := interfaces.AccessorInfo{ID: accessorID, Type: interfaces.RealName}
+		includeParams := []interfaces.PolicCalcyIncludeType{}
+		policys := []interfaces.PolicyInfo{
+			{
+				ResourceID: resourceID,
+				Operation:  interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+			},
+		}
+
+		userMgnt.EXPECT().GetAccessorIDsByUserID(gomock.Any(), gomock.Any()).Return([]string{accessorID}, nil).Times(1)
+		role.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+		userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any()).Return([]interfaces.SystemRoleType{}, nil).Times(1)
+
+		Convey("第二次检查命中缓存", func() {
+			pdb.EXPECT().GetPoliciesByResourcesAndAccessToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(policys, nil).Times(1)
+			for range 2 {
+				checkResult, err := pc.Check(ctx, &resource, &accessor, []string{tmpOperation1}, includeParams)
+				assert.Equal(t, err, nil)
+				assert.Equal(t, checkResult.Result, true)
+			}
+			stats := pc.GetCacheStats()
+			assert.Equal(t, stats.Enabled, true)
+			assert.Equal(t, stats.TTLSeconds, int64(time.Minute/time.Second))
+			assert.Equal(t, stats.AccessToken.Hits, int64(1))
+			assert.Equal(t, stats.AccessToken.Misses, int64(1))
+			assert.Equal(t, stats.Policy.Hits, int64(1))
+			assert.Equal(t, stats.Policy.Misses, int64(1))
+		})
+
+		Convey("策略变更后重新查询策略", func() {
+			gomock.InOrder(
+				pdb.EXPECT().GetPoliciesByResourcesAndAccessToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(policys, nil),
+				pdb.EXPECT().GetPoliciesByResourcesAndAccessToken(gomock.Any(), gomock.Any(), gomock.Any()).Return([]interfaces.PolicyInfo{}, nil),
+			)
+			checkResult, err := pc.Check(ctx, &resource, &accessor, []string{tmpOperation1}, includeParams)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, checkResult.Result, true)
+
+			pc.cache.invalidatePolicies()
+			checkResult, err = pc.Check(ctx, &resource, &accessor, []string{tmpOperation1}, includeParams)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, checkResult.Result, false)
+		})
+
+		Convey("用户删除后重新获取访问令牌", func() {
+			pdb.EXPECT().GetPoliciesByResourcesAndAccessToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(policys, nil).Times(2)
+			_, err := pc.Check(ctx, &resource, &accessor, []string{tmpOperation1}, includeParams)
+			assert.Equal(t, err, nil)
+
+			err = pc.cache.userDeleted(accessorID)
+			assert.Equal(t, err, nil)
+			userMgnt.EXPECT().GetAccessorIDsByUserID(gomock.Any(), gomock.Any()).Return([]string{accessorID}, nil)
+			role.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any()).Return(nil, nil)
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any()).Return([]interfaces.SystemRoleType{}, nil)
+			_, err = pc.Check(ctx, &resource, &accessor, []string{tmpOperation1}, includeParams)
+			assert.Equal(t, err, nil)
+		})
+	})
+}
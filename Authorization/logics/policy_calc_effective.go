@@ -0,0 +1,116 @@
+// Package logics policy_calc_effective 访问者有效权限计算
+package logics
+
+import (
+	"context"
+	"sort"
+
+	"Authorization/interfaces"
+)
+
+/*
+GetEffectivePermissions 获取访问者的有效权限, 访问者为用户或应用账户
+1. 访问令牌与权限检查一致, 包含访问者自身、所属部门和用户组、角色(含继承)和系统角色
+2. 资源为访问令牌配置过策略的资源实例和资源类型(*), 资源实例继承资源类型上的权限
+3. 策略中没有资源的上层路径, 上层资源实例的配置不会继承到下层资源实例
+4. 每个操作返回授予该操作的策略, 结果按资源类型、资源ID排序, 操作按资源类型定义的顺序
+*/
+func (d *policyCalc) GetEffectivePermissions(ctx context.Context, accessor *interfaces.AccessorInfo, resourceTypes []string) (
+	permissions []interfaces.EffectivePermission, err error,
+) {
+	d.logger.Debugf("GetEffectivePermissions start, accessor: %+v, resourceTypes: %v", *accessor, resourceTypes)
+	accessTokens, err := d.getAccessorIDs(ctx, accessor)
+	if err != nil {
+		return nil, err
+	}
+
+	var typeInfos []interfaces.ResourceType
+	if len(resourceTypes) == 0 {
+		typeInfos, err = d.resourceType.GetAllInternal(ctx)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var typeInfoMap map[string]interfaces.ResourceType
+		typeInfoMap, err = d.resourceType.GetByIDsInternal(ctx, resourceTypes)
+		if err != nil {
+			return nil, err
+		}
+		for _, typeInfo := range typeInfoMap {
+			typeInfos = append(typeInfos, typeInfo)
+		}
+	}
+	sort.Slice(typeInfos, func(i, j int) bool { return typeInfos[i].ID < typeInfos[j].ID })
+
+	permissions = make([]interfaces.EffectivePermission, 0)
+	for i := range typeInfos {
+		var policies []interfaces.PolicyInfo
+		policies, err = d.db.GetPoliciesByResourceTypeAndAccessToken(ctx, typeInfos[i].ID, accessTokens)
+		if err != nil {
+			d.logger.Errorf("GetEffectivePermissions GetPoliciesByResourceTypeAndAccessToken resourceType:%s err:%v", typeInfos[i].ID, err)
+			return nil, err
+		}
+		if len(policies) == 0 {
+			continue
+		}
+		permissions = append(permissions, d.calcTypeEffectivePermissions(&typeInfos[i], accessor, policies)...)
+	}
+	d.logger.Debugf("GetEffectivePermissions end, accessor: %+v, permissions length: %v", *accessor, len(permissions))
+	return permissions, nil
+}
+
+// calcTypeEffectivePermissions 计算一个资源类型下的有效权限, 没有任何允许操作的资源不返回
+func (d *policyCalc) calcTypeEffectivePermissions(resourceType *interfaces.ResourceType, accessor *interfaces.AccessorInfo,
+	policies []interfaces.PolicyInfo,
+) (permissions []interfaces.EffectivePermission) {
+	policyMap := make(map[string][]interfaces.PolicyInfo)
+	resourceNames := make(map[string]string)
+	resourceIDs := make([]string, 0)
+	for i := range policies {
+		if _, ok := policyMap[policies[i].ResourceID]; !ok {
+			resourceIDs = append(resourceIDs, policies[i].ResourceID)
+			resourceNames[policies[i].ResourceID] = policies[i].ResourceName
+		}
+		policyMap[policies[i].ResourceID] = append(policyMap[policies[i].ResourceID], policies[i])
+	}
+	// 资源类型(*)排在最前
+	sort.Strings(resourceIDs)
+
+	typeOperationMap, instanceOperationMap := d.getTypeAndInstanceOperation(resourceType)
+	for _, resourceID := range resourceIDs {
+		resource := interfaces.ResourceInfo{ID: resourceID, Type: resourceType.ID, Name: resourceNames[resourceID]}
+		env := d.newConditionEnv(accessor, &resource)
+		allowMap, _, _ := d.calcResourceInheritedOperation(&resource, d.calcResourcePermMap(policyMap, env))
+
+		operations := make([]string, 0, len(allowMap))
+		for _, op := range resourceType.Operation {
+			if allowMap[op.ID] && d.checkOperationScope(resourceID, op.ID, typeOperationMap, instanceOperationMap) {
+				operations = append(operations, op.ID)
+			}
+		}
+		if len(operations) == 0 {
+			continue
+		}
+
+		// 授予操作的策略与决策说明中决定结果的策略一致
+		explains := d.explainOperations(&resource, policyMap, env, operations)
+		permission := interfaces.EffectivePermission{
+			ResourceID:   resourceID,
+			ResourceType: resourceType.ID,
+			ResourceName: resourceNames[resourceID],
+			Operations:   make([]interfaces.EffectiveOperation, 0, len(explains)),
+		}
+		for j := range explains {
+			for k := range explains[j].DecisivePolicies {
+				// 来源不需要义务信息
+				explains[j].DecisivePolicies[k].Obligations = nil
+			}
+			permission.Operations = append(permission.Operations, interfaces.EffectiveOperation{
+				Operation: explains[j].Operation,
+				Sources:   explains[j].DecisivePolicies,
+			})
+		}
+		permissions = append(permissions, permission)
+	}
+	return permissions
+}
@@ -0,0 +1,118 @@
+package logics
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"Authorization/interfaces"
+	"Authorization/interfaces/mock"
+)
+
+func TestPolicyCalcGetEffectivePermissions(t *testing.T) {
+	Convey("获取访问者有效权限", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		pdb := mock.NewMockDBPolicyCalc(ctrl)
+		userMgnt := mock.NewMockDrivenUserMgnt(ctrl)
+		role := mock.NewMockLogicsRole(ctrl)
+		resourceType := mock.NewMockLogicsResourceType(ctrl)
+		pc := newPolicyCalc(pdb, userMgnt, role)
+		pc.resourceType = resourceType
+
+		ctx := context.Background()
+		accessor := interfaces.AccessorInfo{ID: accessorID, Type: interfaces.RealName}
+		bothScope := []interfaces.OperationScopeType{interfaces.ScopeType, interfaces.ScopeInstance}
+		docType := interfaces.ResourceType{
+			ID: resourceTypeDoc,
+			Operation: []interfaces.ResourceTypeOperation{
+				{ID: tmpOperation1, Scope: bothScope},
+				{ID: tmpOperation2, Scope: bothScope},
+				{ID: tmpOperation3, Scope: []interfaces.OperationScopeType{interfaces.ScopeType}},
+			},
+		}
+
+		userMgnt.EXPECT().GetAccessorIDsByUserID(gomock.Any(), accessorID).Return([]string{accessorID, "depID1"}, nil)
+		role.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any()).Return([]interfaces.RoleInfo{{ID: "roleID1"}}, nil)
+		userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), accessorID).Return([]interfaces.SystemRoleType{interfaces.AuditAdmin}, nil)
+
+		Convey("资源实例继承资源类型的权限, 拒绝优先", func() {
+			resourceType.EXPECT().GetByIDsInternal(gomock.Any(), []string{resourceTypeDoc}).Return(map[string]interfaces.ResourceType{resourceTypeDoc: docType}, nil)
+			pdb.EXPECT().GetPoliciesByResourceTypeAndAccessToken(gomock.Any(), resourceTypeDoc, gomock.Any()).Return([]interfaces.PolicyInfo{
+				{
+					ID:           "policy1",
+					ResourceID:   allResourceID,
+					ResourceType: resourceTypeDoc,
+					AccessorID:   "roleID1",
+					AccessorType: interfaces.AccessorRole,
+					AccessorName: "角色1",
+					Operation:    interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1}, {ID: tmpOperation3}}},
+				},
+				{
+					ID:           "policy2",
+					ResourceID:   resourceID,
+					ResourceType: resourceTypeDoc,
+					ResourceName: "资源1",
+					AccessorID:   "depID1",
+					AccessorType: interfaces.AccessorDepartment,
+					AccessorName: "部门1",
+					Operation: interfaces.PolicyOperation{
+						Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation2}},
+						Deny:  []interfaces.PolicyOperationItem{{ID: tmpOperation1}},
+					},
+				},
+				{
+					ID:           "policy3",
+					ResourceID:   resourceID2,
+					ResourceType: resourceTypeDoc,
+					AccessorID:   auditAdminRoleID,
+					AccessorType: interfaces.AccessorRole,
+					Operation:    interfaces.PolicyOperation{Deny: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+				},
+			}, nil)
+
+			permissions, err := pc.GetEffectivePermissions(ctx, &accessor, []string{resourceTypeDoc})
+			assert.Equal(t, err, nil)
+			assert.Equal(t, permissions, []interfaces.EffectivePermission{
+				{
+					ResourceID:   allResourceID,
+					ResourceType: resourceTypeDoc,
+					Operations: []interfaces.EffectiveOperation{
+						{Operation: tmpOperation1, Sources: []interfaces.ExplainPolicy{{
+							PolicyID: "policy1", ResourceID: allResourceID, Level: interfaces.PolicyLevelType,
+							AccessorID: "roleID1", AccessorType: interfaces.AccessorRole, AccessorName: "角色1",
+						}}},
+						{Operation: tmpOperation3, Sources: []interfaces.ExplainPolicy{{
+							PolicyID: "policy1", ResourceID: allResourceID, Level: interfaces.PolicyLevelType,
+							AccessorID: "roleID1", AccessorType: interfaces.AccessorRole, AccessorName: "角色1",
+						}}},
+					},
+				},
+				{
+					ResourceID:   resourceID,
+					ResourceType: resourceTypeDoc,
+					ResourceName: "资源1",
+					Operations: []interfaces.EffectiveOperation{
+						{Operation: tmpOperation2, Sources: []interfaces.ExplainPolicy{{
+							PolicyID: "policy2", ResourceID: resourceID, Level: interfaces.PolicyLevelSelf,
+							AccessorID: "depID1", AccessorType: interfaces.AccessorDepartment, AccessorName: "部门1",
+						}}},
+					},
+				},
+			})
+		})
+
+		Convey("所有资源类型, 没有策略的资源类型不返回", func() {
+			resourceType.EXPECT().GetAllInternal(gomock.Any()).Return([]interfaces.ResourceType{{ID: resourceTypeMcp}, docType}, nil)
+			pdb.EXPECT().GetPoliciesByResourceTypeAndAccessToken(gomock.Any(), resourceTypeDoc, gomock.Any()).Return(nil, nil)
+			pdb.EXPECT().GetPoliciesByResourceTypeAndAccessToken(gomock.Any(), resourceTypeMcp, gomock.Any()).Return(nil, nil)
+			permissions, err := pc.GetEffectivePermissions(ctx, &accessor, nil)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(permissions), 0)
+		})
+	})
+}
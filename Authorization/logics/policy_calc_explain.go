@@ -0,0 +1,119 @@
+// Package logics policy_calc_explain 策略计算决策说明
+package logics
+
+import (
+	"strings"
+
+	"Authorization/interfaces"
+)
+
+// 系统角色ID
+var systemRoleIDs = map[string]bool{
+	superAdminRoleID:        true,
+	systemAdminRoleID:       true,
+	securityAdminRoleID:     true,
+	auditAdminRoleID:        true,
+	organizationAdminRoleID: true,
+	organizationAuditRoleID: true,
+}
+
+type explainLevel struct {
+	resourceID string
+	level      interfaces.PolicyLevel
+}
+
+/*
+计算每个操作的决策说明, 计算规则与 calcOneResourcePerm、calcResourceInheritedOperation 一致
+1. 从本层到上层, 第一个配置了该操作的层级决定结果, 同一层级拒绝优先
+2. 允许时, 本层及上层未拒绝该操作的允许策略提供义务
+*/
+func (d *policyCalc) explainOperations(resource *interfaces.ResourceInfo, policyMap map[string][]interfaces.PolicyInfo, env *conditionEnv,
+	operation []string,
+) (explains []interfaces.OperationExplain) {
+	levels := getExplainLevels(resource)
+	levelPolicies := make([][]interfaces.PolicyInfo, len(levels))
+	for i := range levels {
+		levelPolicies[i] = d.filterPoliciesByCondition(policyMap[levels[i].resourceID], env)
+	}
+
+	explains = make([]interfaces.OperationExplain, 0, len(operation))
+	for _, op := range operation {
+		explain := interfaces.OperationExplain{Operation: op}
+		decided := false
+		for i := range levels {
+			allows, denies := getOperationPolicies(levels[i], levelPolicies[i], op)
+			if !decided {
+				if len(denies) > 0 {
+					explain.DecisivePolicies = denies
+					break
+				}
+				if len(allows) > 0 {
+					explain.Result = true
+					explain.DecisivePolicies = allows
+					decided = true
+				}
+			}
+			// 同一层级拒绝优先, 该层级的允许策略不提供义务
+			if !decided || len(denies) > 0 {
+				continue
+			}
+			for j := range allows {
+				if len(allows[j].Obligations) > 0 {
+					explain.ObligationPolicies = append(explain.ObligationPolicies, allows[j])
+				}
+			}
+		}
+		explains = append(explains, explain)
+	}
+	return
+}
+
+// getExplainLevels 获取资源的层级, 第一个是本层, 后面的元素是上层, 最后是资源类型
+func getExplainLevels(resource *interfaces.ResourceInfo) (levels []explainLevel) {
+	levels = append(levels, explainLevel{resourceID: resource.ID, level: interfaces.PolicyLevelSelf})
+	if resource.ID == "*" {
+		levels[0].level = interfaces.PolicyLevelType
+		return
+	}
+	ids := strings.Split(resource.ParentIDPath, "/")
+	for i := len(ids) - 1; i >= 0; i-- {
+		if ids[i] == "" || ids[i] == resource.ID {
+			continue
+		}
+		levels = append(levels, explainLevel{resourceID: ids[i], level: interfaces.PolicyLevelAncestor})
+	}
+	levels = append(levels, explainLevel{resourceID: "*", level: interfaces.PolicyLevelType})
+	return
+}
+
+// getOperationPolicies 获取一个层级中允许、拒绝该操作的策略
+func getOperationPolicies(level explainLevel, policies []interfaces.PolicyInfo, op string) (allows, denies []interfaces.ExplainPolicy) {
+	for i := range policies {
+		for _, v := range policies[i].Operation.Deny {
+			if v.ID == op {
+				denies = append(denies, newExplainPolicy(level, &policies[i], nil))
+				break
+			}
+		}
+		for _, v := range policies[i].Operation.Allow {
+			if v.ID == op {
+				allows = append(allows, newExplainPolicy(level, &policies[i], v.Obligations))
+				break
+			}
+		}
+	}
+	return
+}
+
+func newExplainPolicy(level explainLevel, policy *interfaces.PolicyInfo, obligations []interfaces.PolicyObligationItem) interfaces.ExplainPolicy {
+	return interfaces.ExplainPolicy{
+		PolicyID:     policy.ID,
+		ResourceID:   policy.ResourceID,
+		Level:        level.level,
+		AccessorID:   policy.AccessorID,
+		AccessorType: policy.AccessorType,
+		AccessorName: policy.AccessorName,
+		SystemRole:   systemRoleIDs[policy.AccessorID],
+		Obligations:  obligations,
+	}
+}
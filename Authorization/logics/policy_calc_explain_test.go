@@ -0,0 +1,115 @@
+package logics
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"Authorization/interfaces"
+	"Authorization/interfaces/mock"
+)
+
+func TestGetExplainLevels(t *testing.T) {
+	Convey("获取资源层级", t, func() {
+		levels := getExplainLevels(&interfaces.ResourceInfo{ID: resourceID, ParentIDPath: resourceID3 + "/" + resourceID2})
+		assert.Equal(t, levels, []explainLevel{
+			{resourceID: resourceID, level: interfaces.PolicyLevelSelf},
+			{resourceID: resourceID2, level: interfaces.PolicyLevelAncestor},
+			{resourceID: resourceID3, level: interfaces.PolicyLevelAncestor},
+			{resourceID: allResourceID, level: interfaces.PolicyLevelType},
+		})
+
+		levels = getExplainLevels(&interfaces.ResourceInfo{ID: allResourceID})
+		assert.Equal(t, levels, []explainLevel{{resourceID: allResourceID, level: interfaces.PolicyLevelType}})
+	})
+}
+
+func TestPolicyCalcCheckWithExplain(t *testing.T) {
+	Convey("单个检查接口, 返回决策说明", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		pdb := mock.NewMockDBPolicyCalc(ctrl)
+		userMgnt := mock.NewMockDrivenUserMgnt(ctrl)
+		role := mock.NewMockLogicsRole(ctrl)
+		pc := newPolicyCalc(pdb, userMgnt, role)
+
+		ctx := context.Background()
+		resource := interfaces.ResourceInfo{ID: resourceID, Type: resourceTypeDoc, ParentIDPath: resourceID2}
+		accessor := interfaces.AccessorInfo{ID: accessorID, Type: interfaces.RealName}
+		includeParams := []interfaces.PolicCalcyIncludeType{interfaces.PolicCalcyIncludeExplain}
+
+		userMgnt.EXPECT().GetAccessorIDsByUserID(gomock.Any(), gomock.Any()).Return([]string{accessorID, "depID1"}, nil)
+		role.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any()).Return(nil, nil)
+		userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any()).Return([]interfaces.SystemRoleType{interfaces.AuditAdmin}, nil)
+
+		obligation := interfaces.PolicyObligationItem{TypeID: "watermark", ID: "obligation1"}
+		policys := []interfaces.PolicyInfo{
+			{
+				ID:           "policy1",
+				ResourceID:   resourceID,
+				AccessorID:   accessorID,
+				AccessorType: interfaces.AccessorUser,
+				Operation:    interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+			},
+			{
+				ID:           "policy2",
+				ResourceID:   resourceID2,
+				AccessorID:   "depID1",
+				AccessorType: interfaces.AccessorDepartment,
+				Operation: interfaces.PolicyOperation{
+					Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1, Obligations: []interfaces.PolicyObligationItem{obligation}}},
+					Deny:  []interfaces.PolicyOperationItem{{ID: tmpOperation2}},
+				},
+			},
+			{
+				ID:           "policy3",
+				ResourceID:   allResourceID,
+				AccessorID:   auditAdminRoleID,
+				AccessorType: interfaces.AccessorRole,
+				Operation:    interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation2}, {ID: tmpOperation3}}},
+			},
+		}
+		pdb.EXPECT().GetPoliciesByResourcesAndAccessToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(policys, nil)
+
+		checkResult, err := pc.Check(ctx, &resource, &accessor, []string{tmpOperation1, tmpOperation2, tmpOperation3, "other"}, includeParams)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, checkResult.Result, false)
+		assert.Equal(t, len(checkResult.Explain), 4)
+
+		// 本层允许, 上层允许策略提供义务
+		explain := checkResult.Explain[0]
+		assert.Equal(t, explain.Operation, tmpOperation1)
+		assert.Equal(t, explain.Result, true)
+		assert.Equal(t, len(explain.DecisivePolicies), 1)
+		assert.Equal(t, explain.DecisivePolicies[0].PolicyID, "policy1")
+		assert.Equal(t, explain.DecisivePolicies[0].Level, interfaces.PolicyLevelSelf)
+		assert.Equal(t, len(explain.ObligationPolicies), 1)
+		assert.Equal(t, explain.ObligationPolicies[0].PolicyID, "policy2")
+		assert.Equal(t, explain.ObligationPolicies[0].Level, interfaces.PolicyLevelAncestor)
+		assert.Equal(t, explain.ObligationPolicies[0].Obligations, []interfaces.PolicyObligationItem{obligation})
+
+		// 上层拒绝, 类型级别的系统角色允许不生效
+		explain = checkResult.Explain[1]
+		assert.Equal(t, explain.Result, false)
+		assert.Equal(t, len(explain.DecisivePolicies), 1)
+		assert.Equal(t, explain.DecisivePolicies[0].PolicyID, "policy2")
+		assert.Equal(t, explain.DecisivePolicies[0].AccessorType, interfaces.AccessorDepartment)
+		assert.Equal(t, len(explain.ObligationPolicies), 0)
+
+		// 类型级别的系统角色允许
+		explain = checkResult.Explain[2]
+		assert.Equal(t, explain.Result, true)
+		assert.Equal(t, len(explain.DecisivePolicies), 1)
+		assert.Equal(t, explain.DecisivePolicies[0].Level, interfaces.PolicyLevelType)
+		assert.Equal(t, explain.DecisivePolicies[0].SystemRole, true)
+
+		// 没有配置策略
+		explain = checkResult.Explain[3]
+		assert.Equal(t, explain.Result, false)
+		assert.Equal(t, len(explain.DecisivePolicies), 0)
+	})
+}
@@ -0,0 +1,101 @@
+// Package logics policy_calc_simulate 策略变更模拟计算
+package logics
+
+import (
+	"context"
+	"sort"
+
+	"Authorization/interfaces"
+)
+
+// SimulateResourceOperation 获取资源操作, 同时返回叠加未提交策略后的资源操作, 资源需属于同一资源类型
+func (d *policyCalc) SimulateResourceOperation(ctx context.Context, resources []interfaces.ResourceInfo, accessor *interfaces.AccessorInfo,
+	overlay *interfaces.PolicyOverlay,
+) (current, simulated map[string][]string, err error) {
+	if len(resources) == 0 {
+		return
+	}
+	d.logger.Debugf("SimulateResourceOperation start, resources length: %v, accessor: %+v", len(resources), *accessor)
+	accessTokens, err := d.getAccessorIDs(ctx, accessor)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	policies, err := d.db.GetPoliciesByResourcesAndAccessToken(ctx, resources, accessTokens)
+	if err != nil {
+		d.logger.Errorf("SimulateResourceOperation GetPoliciesByResourcesAndAccessToken err:%v", err)
+		return nil, nil, err
+	}
+
+	resourceTypeMap, err := d.resourceType.GetByIDsInternal(ctx, []string{resources[0].Type})
+	if err != nil {
+		return
+	}
+	resourceType := resourceTypeMap[resources[0].Type]
+	typeOperationMap, instanceOperationMap := d.getTypeAndInstanceOperation(&resourceType)
+
+	tokenMap := make(map[string]bool, len(accessTokens))
+	for _, token := range accessTokens {
+		tokenMap[token] = true
+	}
+	overlayPolicies := applyPolicyOverlay(policies, overlay, resources[0].Type, tokenMap)
+
+	current = d.calcEffectiveOperations(resources, accessor, policies, typeOperationMap, instanceOperationMap)
+	simulated = d.calcEffectiveOperations(resources, accessor, overlayPolicies, typeOperationMap, instanceOperationMap)
+	d.logger.Debugf("SimulateResourceOperation end")
+	return
+}
+
+// calcEffectiveOperations 计算每个资源上生效的操作, 结果按操作ID排序
+func (d *policyCalc) calcEffectiveOperations(resources []interfaces.ResourceInfo, accessor *interfaces.AccessorInfo, policies []interfaces.PolicyInfo,
+	typeOperationMap, instanceOperationMap map[string]bool,
+) (resourceOperationMap map[string][]string) {
+	policyMap := make(map[string][]interfaces.PolicyInfo)
+	for i := range policies {
+		policyMap[policies[i].ResourceID] = append(policyMap[policies[i].ResourceID], policies[i])
+	}
+
+	resourceOperationMap = make(map[string][]string, len(resources))
+	for i := range resources {
+		permMap := d.calcResourcePermMap(policyMap, d.newConditionEnv(accessor, &resources[i]))
+		allowMap, _, _ := d.calcResourceInheritedOperation(&resources[i], permMap)
+		operations := make([]string, 0, len(allowMap))
+		for key := range allowMap {
+			if d.checkOperationScope(resources[i].ID, key, typeOperationMap, instanceOperationMap) {
+				operations = append(operations, key)
+			}
+		}
+		sort.Strings(operations)
+		resourceOperationMap[resources[i].ID] = operations
+	}
+	return
+}
+
+// applyPolicyOverlay 在已有策略上叠加未提交的策略, 只保留资源类型和访问令牌匹配的策略
+func applyPolicyOverlay(policies []interfaces.PolicyInfo, overlay *interfaces.PolicyOverlay, resourceType string,
+	tokenMap map[string]bool,
+) (result []interfaces.PolicyInfo) {
+	if overlay == nil {
+		return policies
+	}
+	replaced := make(map[string]bool, len(overlay.DeleteIDs)+len(overlay.Upserts))
+	for _, id := range overlay.DeleteIDs {
+		replaced[id] = true
+	}
+	for i := range overlay.Upserts {
+		replaced[overlay.Upserts[i].ID] = true
+	}
+
+	result = make([]interfaces.PolicyInfo, 0, len(policies)+len(overlay.Upserts))
+	for i := range policies {
+		if !replaced[policies[i].ID] {
+			result = append(result, policies[i])
+		}
+	}
+	for i := range overlay.Upserts {
+		if overlay.Upserts[i].ResourceType == resourceType && tokenMap[overlay.Upserts[i].AccessorID] {
+			result = append(result, overlay.Upserts[i])
+		}
+	}
+	return
+}
@@ -1339,3 +1339,75 @@ func TestCalcResourceInheritedOperation(t *testing.T) {
 		})
 	})
 }
+
+func TestPolicyCalcBatchCheck(t *testing.T) {
+	Convey("批量检查接口", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		pdb := mock.NewMockDBPolicyCalc(ctrl)
+		userMgnt := mock.NewMockDrivenUserMgnt(ctrl)
+		role := mock.NewMockLogicsRole(ctrl)
+		pc := newPolicyCalc(pdb, userMgnt, role)
+
+		ctx := context.Background()
+		testErr := errors.New("some error")
+		includeParams := []interfaces.PolicCalcyIncludeType{}
+		user1 := interfaces.AccessorInfo{ID: accessorID, Type: interfaces.RealName}
+		app1 := interfaces.AccessorInfo{ID: "appID1", Type: interfaces.App}
+		items := []interfaces.BatchCheckItem{
+			{Resource: interfaces.ResourceInfo{ID: resourceID, Type: resourceTypeDoc}, Accessor: user1, Operation: []string{tmpOperation1}},
+			{Resource: interfaces.ResourceInfo{ID: resourceID2, Type: resourceTypeDoc}, Accessor: user1, Operation: []string{tmpOperation1}},
+			{Resource: interfaces.ResourceInfo{ID: resourceID, Type: resourceTypeDoc}, Accessor: app1, Operation: []string{tmpOperation1}},
+		}
+
+		Convey("空列表", func() {
+			results, err := pc.BatchCheck(ctx, nil, includeParams)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(results), 0)
+		})
+
+		Convey("获取访问令牌失败", func() {
+			userMgnt.EXPECT().GetAccessorIDsByUserID(gomock.Any(), accessorID).Return(nil, testErr)
+			_, err := pc.BatchCheck(ctx, items, includeParams)
+			assert.Equal(t, err, testErr)
+		})
+
+		Convey("相同访问者只获取一次访问令牌, 相同资源类型只查询一次策略", func() {
+			userMgnt.EXPECT().GetAccessorIDsByUserID(gomock.Any(), accessorID).Return([]string{accessorID}, nil).Times(1)
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), accessorID).Return([]interfaces.SystemRoleType{}, nil).Times(1)
+			role.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+			policys := []interfaces.PolicyInfo{
+				{
+					ResourceID: resourceID,
+					AccessorID: accessorID,
+					Operation:  interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+				},
+				{
+					ResourceID: resourceID2,
+					AccessorID: rootDepID,
+					Operation:  interfaces.PolicyOperation{Deny: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+				},
+				{
+					ResourceID: allResourceID,
+					AccessorID: "appID1",
+					Operation:  interfaces.PolicyOperation{Deny: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+				},
+			}
+			pdb.EXPECT().GetPoliciesByResourcesAndAccessToken(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, resources []interfaces.ResourceInfo, accessTokens []string) ([]interfaces.PolicyInfo, error) {
+					assert.Equal(t, len(resources), 3)
+					assert.ElementsMatch(t, accessTokens, []string{accessorID, rootDepID, "appID1"})
+					return policys, nil
+				}).Times(1)
+
+			results, err := pc.BatchCheck(ctx, items, includeParams)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(results), 3)
+			assert.Equal(t, results[0].Result, true)
+			assert.Equal(t, results[1].Result, false)
+			// 应用账户没有用户的策略, 只有类型级别拒绝
+			assert.Equal(t, results[2].Result, false)
+		})
+	})
+}
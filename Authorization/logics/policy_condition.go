@@ -0,0 +1,542 @@
+// Package logics perm Anyshare 业务逻辑层 -文档权限
+package logics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"time"
+
+	"Authorization/interfaces"
+)
+
+/*
+策略条件
+
+策略条件为 JSON 字符串，由组合节点和叶子节点构成，例如:
+
+	{
+	    "operator": "and",
+	    "conditions": [
+	        {"attribute": "env.time_of_day", "operator": "between", "value": ["09:00", "18:00"]},
+	        {"attribute": "client.ip", "operator": "in_cidr", "value": ["10.0.0.0/8"]},
+	        {"attribute": "client.type", "operator": "in", "value": ["web", "windows"]},
+	        {"attribute": "accessor.csf_level", "operator": "gte", "value": 5},
+	        {"attribute": "resource.tags", "operator": "contains", "value": "finance"}
+	    ]
+	}
+
+组合节点: operator 为 and/or/not, conditions 为子条件, not 只能有一个子条件
+叶子节点: attribute 为属性名, operator 为比较操作符, value 为比较值
+属性不存在时, 叶子节点结果为无法计算, 组合节点按三值逻辑计算:
+and 有 false 时为 false, or 有 true 时为 true, not 无法计算时仍为无法计算
+条件无法计算时, 允许策略不生效, 拒绝策略生效
+*/
+
+// conditionResult 条件计算结果
+type conditionResult int
+
+const (
+	conditionFalse conditionResult = iota
+	conditionTrue
+	conditionUnknown // 属性不存在, 无法计算
+)
+
+func toConditionResult(b bool) conditionResult {
+	if b {
+		return conditionTrue
+	}
+	return conditionFalse
+}
+
+// 组合操作符
+const (
+	conditionAnd = "and"
+	conditionOr  = "or"
+	conditionNot = "not"
+)
+
+// 比较操作符
+const (
+	conditionEq        = "eq"
+	conditionNe        = "ne"
+	conditionIn        = "in"
+	conditionNotIn     = "not_in"
+	conditionGt        = "gt"
+	conditionGte       = "gte"
+	conditionLt        = "lt"
+	conditionLte       = "lte"
+	conditionContains  = "contains"
+	conditionBetween   = "between"
+	conditionInCIDR    = "in_cidr"
+	conditionNotInCIDR = "not_in_cidr"
+)
+
+// 属性
+const (
+	conditionAttrTimeOfDay      = "env.time_of_day"
+	conditionAttrDayOfWeek      = "env.day_of_week"
+	conditionAttrClientIP       = "client.ip"
+	conditionAttrClientType     = "client.type"
+	conditionAttrAccessorPrefix = "accessor."
+	conditionAttrResourcePrefix = "resource."
+)
+
+const (
+	// 条件最大嵌套深度
+	conditionMaxDepth = 10
+	// 条件最大长度
+	conditionMaxLength = 64 * 1024
+	// 解析后条件缓存的最大条数, 条件解析结果不会变化, 有效期只用于淘汰不再使用的条件
+	conditionCacheMaxEntries = 10000
+	conditionCacheTTL        = time.Hour
+)
+
+// conditionClientTypeNames 设备类型名称, 与令牌内省结果中的 client_type 一致
+var conditionClientTypeNames = map[interfaces.ClientType]string{
+	interfaces.Unknown:      "unknown",
+	interfaces.IOS:          "ios",
+	interfaces.Android:      "android",
+	interfaces.WindowsPhone: "windows_phone",
+	interfaces.Windows:      "windows",
+	interfaces.MacOS:        "mac_os",
+	interfaces.Web:          "web",
+	interfaces.MobileWeb:    "mobile_web",
+	interfaces.Nas:          "nas",
+	interfaces.ConsoleWeb:   "console_web",
+	interfaces.DeployWeb:    "deploy_web",
+	interfaces.Linux:        "linux",
+	interfaces.APP:          "app",
+}
+
+// 通用属性支持的比较操作符
+var conditionGenericOperators = map[string]bool{
+	conditionEq:       true,
+	conditionNe:       true,
+	conditionIn:       true,
+	conditionNotIn:    true,
+	conditionGt:       true,
+	conditionGte:      true,
+	conditionLt:       true,
+	conditionLte:      true,
+	conditionContains: true,
+}
+
+// 内置属性支持的比较操作符
+var conditionBuiltinOperators = map[string]map[string]bool{
+	conditionAttrTimeOfDay: {
+		conditionBetween: true,
+	},
+	conditionAttrDayOfWeek: {
+		conditionEq:    true,
+		conditionNe:    true,
+		conditionIn:    true,
+		conditionNotIn: true,
+	},
+	conditionAttrClientIP: {
+		conditionEq:        true,
+		conditionNe:        true,
+		conditionIn:        true,
+		conditionNotIn:     true,
+		conditionInCIDR:    true,
+		conditionNotInCIDR: true,
+	},
+	conditionAttrClientType: {
+		conditionEq:    true,
+		conditionNe:    true,
+		conditionIn:    true,
+		conditionNotIn: true,
+	},
+}
+
+// policyCondition 策略条件节点
+type policyCondition struct {
+	Operator   string            `json:"operator"`
+	Conditions []policyCondition `json:"conditions,omitempty"`
+	Attribute  string            `json:"attribute,omitempty"`
+	Value      any               `json:"value,omitempty"`
+
+	// 校验时预处理的比较值
+	nets        []*net.IPNet
+	startMinute int
+	endMinute   int
+}
+
+// conditionEnv 条件计算上下文
+type conditionEnv struct {
+	now      time.Time
+	accessor *interfaces.AccessorInfo
+	resource *interfaces.ResourceInfo
+}
+
+// parseCondition 解析并校验策略条件, 空字符串表示无条件, 返回nil
+func parseCondition(condition string) (cond *policyCondition, err error) {
+	condition = strings.TrimSpace(condition)
+	if condition == "" {
+		return nil, nil
+	}
+	if len(condition) > conditionMaxLength {
+		return nil, fmt.Errorf("condition length exceeds %d", conditionMaxLength)
+	}
+
+	cond = &policyCondition{}
+	if err = json.Unmarshal([]byte(condition), cond); err != nil {
+		return nil, fmt.Errorf("condition is not a valid json object: %v", err)
+	}
+	if err = cond.validate(1); err != nil {
+		return nil, err
+	}
+	return cond, nil
+}
+
+// validate 校验条件节点, 并预处理比较值
+//
+//nolint:gocyclo
+func (c *policyCondition) validate(depth int) (err error) {
+	if depth > conditionMaxDepth {
+		return fmt.Errorf("condition depth exceeds %d", conditionMaxDepth)
+	}
+
+	switch c.Operator {
+	case conditionAnd, conditionOr, conditionNot:
+		if c.Attribute != "" || c.Value != nil {
+			return fmt.Errorf("operator %s cannot have attribute or value", c.Operator)
+		}
+		if len(c.Conditions) == 0 {
+			return fmt.Errorf("operator %s requires conditions", c.Operator)
+		}
+		if c.Operator == conditionNot && len(c.Conditions) != 1 {
+			return fmt.Errorf("operator %s requires exactly one condition", c.Operator)
+		}
+		for i := range c.Conditions {
+			if err = c.Conditions[i].validate(depth + 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(c.Conditions) > 0 {
+		return fmt.Errorf("operator %s cannot have conditions", c.Operator)
+	}
+	if c.Attribute == "" {
+		return fmt.Errorf("operator %s requires attribute", c.Operator)
+	}
+
+	// 检查属性和操作符是否匹配
+	if operators, ok := conditionBuiltinOperators[c.Attribute]; ok {
+		if !operators[c.Operator] {
+			return fmt.Errorf("operator %s is not supported by attribute %s", c.Operator, c.Attribute)
+		}
+	} else {
+		name, isAttr := strings.CutPrefix(c.Attribute, conditionAttrAccessorPrefix)
+		if !isAttr {
+			name, isAttr = strings.CutPrefix(c.Attribute, conditionAttrResourcePrefix)
+		}
+		if !isAttr || name == "" {
+			return fmt.Errorf("attribute %s not supported", c.Attribute)
+		}
+		if !conditionGenericOperators[c.Operator] {
+			return fmt.Errorf("operator %s is not supported by attribute %s", c.Operator, c.Attribute)
+		}
+	}
+
+	return c.validateValue()
+}
+
+// validateValue 校验叶子节点比较值
+//
+//nolint:gocyclo
+func (c *policyCondition) validateValue() (err error) {
+	values, isArray := c.Value.([]any)
+	switch c.Operator {
+	case conditionIn, conditionNotIn, conditionInCIDR, conditionNotInCIDR, conditionBetween:
+		if !isArray || len(values) == 0 {
+			return fmt.Errorf("operator %s requires a non-empty array value", c.Operator)
+		}
+	default:
+		if c.Value == nil || isArray {
+			return fmt.Errorf("operator %s requires a scalar value", c.Operator)
+		}
+		values = []any{c.Value}
+	}
+
+	switch c.Operator {
+	case conditionGt, conditionGte, conditionLt, conditionLte:
+		if _, ok := c.Value.(float64); !ok {
+			return fmt.Errorf("operator %s requires a number value", c.Operator)
+		}
+	case conditionBetween:
+		if len(values) != 2 {
+			return fmt.Errorf("operator %s requires [start, end]", c.Operator)
+		}
+		if c.startMinute, err = parseMinuteOfDay(values[0]); err != nil {
+			return err
+		}
+		if c.endMinute, err = parseMinuteOfDay(values[1]); err != nil {
+			return err
+		}
+	case conditionInCIDR, conditionNotInCIDR:
+		c.nets = make([]*net.IPNet, 0, len(values))
+		for _, v := range values {
+			str, ok := v.(string)
+			if !ok {
+				return fmt.Errorf("operator %s requires string values", c.Operator)
+			}
+			ipNet, parseErr := parseIPNet(str)
+			if parseErr != nil {
+				return parseErr
+			}
+			c.nets = append(c.nets, ipNet)
+		}
+	}
+
+	switch c.Attribute {
+	case conditionAttrDayOfWeek:
+		for _, v := range values {
+			day, ok := v.(float64)
+			if !ok || day < 1 || day > 7 || day != float64(int(day)) {
+				return fmt.Errorf("attribute %s requires integer values in [1, 7]", c.Attribute)
+			}
+		}
+	case conditionAttrClientType:
+		names := make(map[string]bool, len(conditionClientTypeNames))
+		for _, name := range conditionClientTypeNames {
+			names[name] = true
+		}
+		for _, v := range values {
+			name, ok := v.(string)
+			if !ok || !names[name] {
+				return fmt.Errorf("attribute %s value %v not supported", c.Attribute, v)
+			}
+		}
+	case conditionAttrClientIP:
+		if c.Operator == conditionInCIDR || c.Operator == conditionNotInCIDR {
+			break
+		}
+		for _, v := range values {
+			str, ok := v.(string)
+			if !ok || net.ParseIP(str) == nil {
+				return fmt.Errorf("attribute %s value %v is not a valid ip", c.Attribute, v)
+			}
+		}
+	}
+	return nil
+}
+
+// parseMinuteOfDay 解析 HH:MM 格式的时间, 返回当天分钟数
+func parseMinuteOfDay(v any) (minute int, err error) {
+	str, ok := v.(string)
+	if !ok {
+		return 0, fmt.Errorf("time of day %v must be HH:MM", v)
+	}
+	t, err := time.Parse("15:04", str)
+	if err != nil {
+		return 0, fmt.Errorf("time of day %s must be HH:MM", str)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// parseIPNet 解析CIDR, 单个IP视为掩码全1的网段
+func parseIPNet(str string) (ipNet *net.IPNet, err error) {
+	if strings.Contains(str, "/") {
+		_, ipNet, err = net.ParseCIDR(str)
+		if err != nil {
+			return nil, fmt.Errorf("cidr %s is invalid", str)
+		}
+		return ipNet, nil
+	}
+	ip := net.ParseIP(str)
+	if ip == nil {
+		return nil, fmt.Errorf("ip %s is invalid", str)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// eval 计算条件是否满足
+func (c *policyCondition) eval(env *conditionEnv) conditionResult {
+	switch c.Operator {
+	case conditionAnd:
+		result := conditionTrue
+		for i := range c.Conditions {
+			switch c.Conditions[i].eval(env) {
+			case conditionFalse:
+				return conditionFalse
+			case conditionUnknown:
+				result = conditionUnknown
+			}
+		}
+		return result
+	case conditionOr:
+		result := conditionFalse
+		for i := range c.Conditions {
+			switch c.Conditions[i].eval(env) {
+			case conditionTrue:
+				return conditionTrue
+			case conditionUnknown:
+				result = conditionUnknown
+			}
+		}
+		return result
+	case conditionNot:
+		switch c.Conditions[0].eval(env) {
+		case conditionTrue:
+			return conditionFalse
+		case conditionFalse:
+			return conditionTrue
+		}
+		return conditionUnknown
+	}
+
+	switch c.Operator {
+	case conditionBetween:
+		minute := env.now.Hour()*60 + env.now.Minute()
+		// 开始时间大于结束时间表示跨天, 如 22:00 - 06:00
+		if c.startMinute <= c.endMinute {
+			return toConditionResult(minute >= c.startMinute && minute < c.endMinute)
+		}
+		return toConditionResult(minute >= c.startMinute || minute < c.endMinute)
+	case conditionInCIDR, conditionNotInCIDR:
+		ip := net.ParseIP(env.accessor.IP)
+		if ip == nil {
+			return conditionUnknown
+		}
+		matched := false
+		for _, ipNet := range c.nets {
+			if ipNet.Contains(ip) {
+				matched = true
+				break
+			}
+		}
+		return toConditionResult(matched == (c.Operator == conditionInCIDR))
+	}
+
+	actual, ok := c.lookupAttribute(env)
+	if !ok {
+		return conditionUnknown
+	}
+	return toConditionResult(compareConditionValue(c.Operator, actual, c.Value))
+}
+
+// lookupAttribute 获取属性值
+func (c *policyCondition) lookupAttribute(env *conditionEnv) (value any, ok bool) {
+	switch c.Attribute {
+	case conditionAttrDayOfWeek:
+		// 周一为1, 周日为7
+		day := int(env.now.Weekday())
+		if day == 0 {
+			day = 7
+		}
+		return float64(day), true
+	case conditionAttrClientIP:
+		if env.accessor.IP == "" {
+			return nil, false
+		}
+		return env.accessor.IP, true
+	case conditionAttrClientType:
+		name, ok := conditionClientTypeNames[env.accessor.ClientType]
+		return name, ok
+	}
+
+	if name, isAttr := strings.CutPrefix(c.Attribute, conditionAttrAccessorPrefix); isAttr {
+		if name == "id" {
+			return env.accessor.ID, true
+		}
+		value, ok = env.accessor.Attributes[name]
+		return normalizeConditionValue(value), ok
+	}
+
+	name, _ := strings.CutPrefix(c.Attribute, conditionAttrResourcePrefix)
+	if env.resource == nil {
+		return nil, false
+	}
+	switch name {
+	case "id":
+		return env.resource.ID, true
+	case "type":
+		return env.resource.Type, true
+	}
+	value, ok = env.resource.Attributes[name]
+	return normalizeConditionValue(value), ok
+}
+
+// normalizeConditionValue 数值统一为float64, 以便与json解析后的比较值比较
+func normalizeConditionValue(value any) any {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return v.String()
+		}
+		return f
+	case []string:
+		values := make([]any, 0, len(v))
+		for _, s := range v {
+			values = append(values, s)
+		}
+		return values
+	}
+	return value
+}
+
+// compareConditionValue 比较属性值和条件值
+func compareConditionValue(operator string, actual, expected any) bool {
+	switch operator {
+	case conditionEq:
+		return reflect.DeepEqual(actual, expected)
+	case conditionNe:
+		return !reflect.DeepEqual(actual, expected)
+	case conditionIn, conditionNotIn:
+		found := false
+		for _, v := range expected.([]any) {
+			if reflect.DeepEqual(actual, v) {
+				found = true
+				break
+			}
+		}
+		return found == (operator == conditionIn)
+	case conditionContains:
+		values, ok := actual.([]any)
+		if !ok {
+			str, isStr := actual.(string)
+			sub, subIsStr := expected.(string)
+			return isStr && subIsStr && strings.Contains(str, sub)
+		}
+		for _, v := range values {
+			if reflect.DeepEqual(normalizeConditionValue(v), expected) {
+				return true
+			}
+		}
+		return false
+	case conditionGt, conditionGte, conditionLt, conditionLte:
+		a, ok := actual.(float64)
+		if !ok {
+			return false
+		}
+		e := expected.(float64)
+		switch operator {
+		case conditionGt:
+			return a > e
+		case conditionGte:
+			return a >= e
+		case conditionLt:
+			return a < e
+		default:
+			return a <= e
+		}
+	}
+	return false
+}
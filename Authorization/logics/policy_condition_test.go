@@ -0,0 +1,384 @@
+//nolint:govet
+package logics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"Authorization/interfaces"
+	"Authorization/interfaces/mock"
+)
+
+func TestParseCondition(t *testing.T) {
+	Convey("解析策略条件", t, func() {
+		Convey("空条件", func() {
+			cond, err := parseCondition("  ")
+			assert.Equal(t, err, nil)
+			assert.Nil(t, cond)
+		})
+
+		Convey("非法条件", func() {
+			invalids := []string{
+				`not json`,
+				`{"operator": "xor", "conditions": [{"attribute": "client.ip", "operator": "eq", "value": "1.1.1.1"}]}`,
+				`{"operator": "and"}`,
+				`{"operator": "not", "conditions": [{"attribute": "client.ip", "operator": "eq", "value": "1.1.1.1"}, {"attribute": "client.ip", "operator": "eq", "value": "1.1.1.2"}]}`,
+				`{"attribute": "unknown.attr", "operator": "eq", "value": "x"}`,
+				`{"attribute": "accessor.", "operator": "eq", "value": "x"}`,
+				`{"attribute": "env.time_of_day", "operator": "eq", "value": "09:00"}`,
+				`{"attribute": "env.time_of_day", "operator": "between", "value": ["09:00"]}`,
+				`{"attribute": "env.time_of_day", "operator": "between", "value": ["9am", "18:00"]}`,
+				`{"attribute": "env.day_of_week", "operator": "in", "value": [0, 8]}`,
+				`{"attribute": "client.ip", "operator": "in_cidr", "value": ["10.0.0.0/33"]}`,
+				`{"attribute": "client.ip", "operator": "eq", "value": "not-ip"}`,
+				`{"attribute": "client.type", "operator": "in", "value": ["fax"]}`,
+				`{"attribute": "accessor.csf_level", "operator": "gte", "value": "5"}`,
+				`{"attribute": "resource.tags", "operator": "in", "value": "finance"}`,
+			}
+			for _, v := range invalids {
+				_, err := parseCondition(v)
+				assert.NotEqual(t, err, nil, v)
+			}
+		})
+
+		Convey("合法条件", func() {
+			cond, err := parseCondition(`{"operator": "and", "conditions": [
+				{"attribute": "env.time_of_day", "operator": "between", "value": ["09:00", "18:00"]},
+				{"attribute": "client.ip", "operator": "in_cidr", "value": ["10.0.0.0/8", "192.168.1.1"]},
+				{"attribute": "client.type", "operator": "in", "value": ["web", "windows"]}
+			]}`)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(cond.Conditions), 3)
+			assert.Equal(t, cond.Conditions[0].startMinute, 9*60)
+			assert.Equal(t, cond.Conditions[0].endMinute, 18*60)
+			assert.Equal(t, len(cond.Conditions[1].nets), 2)
+		})
+	})
+}
+
+func TestPolicyConditionEval(t *testing.T) {
+	Convey("计算策略条件", t, func() {
+		// 2024-01-03 是周三
+		now := time.Date(2024, 1, 3, 10, 30, 0, 0, time.Local)
+		env := &conditionEnv{
+			now: now,
+			accessor: &interfaces.AccessorInfo{
+				ID:         accessorID,
+				IP:         "10.1.2.3",
+				ClientType: interfaces.Web,
+				Attributes: map[string]any{"csf_level": 5, "department": "finance"},
+			},
+			resource: &interfaces.ResourceInfo{
+				ID:         resourceID,
+				Type:       resourceTypeDoc,
+				Attributes: map[string]any{"tags": []string{"finance", "secret"}, "owner": accessorID},
+			},
+		}
+		cases := map[string]conditionResult{
+			`{"attribute": "env.time_of_day", "operator": "between", "value": ["09:00", "18:00"]}`:                   conditionTrue,
+			`{"attribute": "env.time_of_day", "operator": "between", "value": ["11:00", "18:00"]}`:                   conditionFalse,
+			`{"attribute": "env.time_of_day", "operator": "between", "value": ["22:00", "11:00"]}`:                   conditionTrue,
+			`{"attribute": "env.day_of_week", "operator": "in", "value": [1, 2, 3, 4, 5]}`:                           conditionTrue,
+			`{"attribute": "env.day_of_week", "operator": "in", "value": [6, 7]}`:                                    conditionFalse,
+			`{"attribute": "client.ip", "operator": "in_cidr", "value": ["10.0.0.0/8"]}`:                             conditionTrue,
+			`{"attribute": "client.ip", "operator": "not_in_cidr", "value": ["10.0.0.0/8"]}`:                         conditionFalse,
+			`{"attribute": "client.ip", "operator": "in_cidr", "value": ["192.168.0.0/16", "10.1.2.3"]}`:             conditionTrue,
+			`{"attribute": "client.ip", "operator": "eq", "value": "10.1.2.4"}`:                                      conditionFalse,
+			`{"attribute": "client.type", "operator": "in", "value": ["web", "windows"]}`:                            conditionTrue,
+			`{"attribute": "client.type", "operator": "eq", "value": "ios"}`:                                         conditionFalse,
+			`{"attribute": "accessor.csf_level", "operator": "gte", "value": 5}`:                                     conditionTrue,
+			`{"attribute": "accessor.csf_level", "operator": "gt", "value": 5}`:                                      conditionFalse,
+			`{"attribute": "accessor.department", "operator": "eq", "value": "finance"}`:                             conditionTrue,
+			`{"attribute": "accessor.missing", "operator": "ne", "value": "x"}`:                                      conditionUnknown,
+			`{"attribute": "resource.tags", "operator": "contains", "value": "secret"}`:                              conditionTrue,
+			`{"attribute": "resource.type", "operator": "eq", "value": "doc"}`:                                       conditionTrue,
+			`{"attribute": "resource.owner", "operator": "eq", "value": "accessorID1"}`:                              conditionTrue,
+			`{"operator": "not", "conditions": [{"attribute": "client.type", "operator": "eq", "value": "web"}]}`:    conditionFalse,
+			`{"operator": "not", "conditions": [{"attribute": "accessor.missing", "operator": "eq", "value": "x"}]}`: conditionUnknown,
+			`{"operator": "or", "conditions": [{"attribute": "accessor.missing", "operator": "eq", "value": "x"},
+				{"attribute": "client.type", "operator": "eq", "value": "web"}]}`: conditionTrue,
+			`{"operator": "or", "conditions": [{"attribute": "accessor.missing", "operator": "eq", "value": "x"},
+				{"attribute": "client.type", "operator": "eq", "value": "ios"}]}`: conditionUnknown,
+			`{"operator": "and", "conditions": [{"attribute": "accessor.missing", "operator": "eq", "value": "x"},
+				{"attribute": "client.type", "operator": "eq", "value": "ios"}]}`: conditionFalse,
+			`{"operator": "or", "conditions": [{"attribute": "client.type", "operator": "eq", "value": "ios"},
+				{"attribute": "client.ip", "operator": "in_cidr", "value": ["10.0.0.0/8"]}]}`: conditionTrue,
+			`{"operator": "and", "conditions": [{"attribute": "client.type", "operator": "eq", "value": "ios"},
+				{"attribute": "client.ip", "operator": "in_cidr", "value": ["10.0.0.0/8"]}]}`: conditionFalse,
+		}
+		for condition, expected := range cases {
+			cond, err := parseCondition(condition)
+			assert.Equal(t, err, nil, condition)
+			assert.Equal(t, cond.eval(env), expected, condition)
+		}
+
+		Convey("访问者没有IP", func() {
+			env.accessor.IP = ""
+			cond, err := parseCondition(`{"attribute": "client.ip", "operator": "not_in_cidr", "value": ["10.0.0.0/8"]}`)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, cond.eval(env), conditionUnknown)
+		})
+
+		Convey("没有资源信息", func() {
+			env.resource = nil
+			cond, err := parseCondition(`{"attribute": "resource.owner", "operator": "eq", "value": "accessorID1"}`)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, cond.eval(env), conditionUnknown)
+		})
+	})
+}
+
+func TestPolicyCalcCheckWithCondition(t *testing.T) {
+	Convey("单个检查接口, 条件策略", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		pdb := mock.NewMockDBPolicyCalc(ctrl)
+		userMgnt := mock.NewMockDrivenUserMgnt(ctrl)
+		role := mock.NewMockLogicsRole(ctrl)
+		pc := newPolicyCalc(pdb, userMgnt, role)
+		pc.conditions = newLRUCache(conditionCacheMaxEntries, conditionCacheTTL)
+
+		ctx := context.Background()
+		resource := interfaces.ResourceInfo{
+			ID:   resourceID,
+			Type: resourceTypeDoc,
+		}
+		accessor := interfaces.AccessorInfo{
+			ID:   accessorID,
+			Type: interfaces.RealName,
+			IP:   "10.1.2.3",
+		}
+
+		includeParams := []interfaces.PolicCalcyIncludeType{}
+		var outInfo []interfaces.RoleInfo
+		userMgnt.EXPECT().GetAccessorIDsByUserID(gomock.Any(), gomock.Any()).Return([]string{accessorID}, nil)
+		role.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any()).Return(outInfo, nil)
+		userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any()).Return([]interfaces.SystemRoleType{}, nil)
+
+		Convey("条件满足，check 结果为true", func() {
+			policys := []interfaces.PolicyInfo{
+				{
+					ResourceID: resourceID,
+					Operation:  interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+					Condition:  `{"attribute": "client.ip", "operator": "in_cidr", "value": ["10.0.0.0/8"]}`,
+				},
+			}
+			pdb.EXPECT().GetPoliciesByResourcesAndAccessToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(policys, nil)
+			checkResult, err := pc.Check(ctx, &resource, &accessor, []string{tmpOperation1}, includeParams)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, checkResult.Result, true)
+		})
+
+		Convey("条件不满足，策略不生效，check 结果为false", func() {
+			policys := []interfaces.PolicyInfo{
+				{
+					ResourceID: resourceID,
+					Operation:  interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+					Condition:  `{"attribute": "client.ip", "operator": "in_cidr", "value": ["192.168.0.0/16"]}`,
+				},
+			}
+			pdb.EXPECT().GetPoliciesByResourcesAndAccessToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(policys, nil)
+			checkResult, err := pc.Check(ctx, &resource, &accessor, []string{tmpOperation1}, includeParams)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, checkResult.Result, false)
+		})
+
+		Convey("条件不满足的拒绝策略不生效，check 结果为true", func() {
+			policys := []interfaces.PolicyInfo{
+				{
+					ResourceID: allResourceID,
+					Operation:  interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+				},
+				{
+					ResourceID: resourceID,
+					Operation:  interfaces.PolicyOperation{Deny: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+					Condition:  `{"attribute": "client.ip", "operator": "not_in_cidr", "value": ["10.0.0.0/8"]}`,
+				},
+			}
+			pdb.EXPECT().GetPoliciesByResourcesAndAccessToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(policys, nil)
+			checkResult, err := pc.Check(ctx, &resource, &accessor, []string{tmpOperation1}, includeParams)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, checkResult.Result, true)
+		})
+
+		Convey("条件依赖的属性不存在时拒绝策略生效，check 结果为false", func() {
+			policys := []interfaces.PolicyInfo{
+				{
+					ResourceID: allResourceID,
+					Operation:  interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+				},
+				{
+					ResourceID: resourceID,
+					Operation:  interfaces.PolicyOperation{Deny: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+					Condition:  `{"attribute": "accessor.csf_level", "operator": "lt", "value": 5}`,
+				},
+			}
+			pdb.EXPECT().GetPoliciesByResourcesAndAccessToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(policys, nil)
+			checkResult, err := pc.Check(ctx, &resource, &accessor, []string{tmpOperation1}, includeParams)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, checkResult.Result, false)
+		})
+
+		Convey("条件依赖的属性不存在时允许策略不生效，check 结果为false", func() {
+			policys := []interfaces.PolicyInfo{
+				{
+					ResourceID: resourceID,
+					Operation:  interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+					Condition:  `{"operator": "not", "conditions": [{"attribute": "accessor.csf_level", "operator": "lt", "value": 5}]}`,
+				},
+			}
+			pdb.EXPECT().GetPoliciesByResourcesAndAccessToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(policys, nil)
+			checkResult, err := pc.Check(ctx, &resource, &accessor, []string{tmpOperation1}, includeParams)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, checkResult.Result, false)
+		})
+
+		Convey("条件无法解析的拒绝策略仍然生效，check 结果为false", func() {
+			policys := []interfaces.PolicyInfo{
+				{
+					ResourceID: allResourceID,
+					Operation:  interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+				},
+				{
+					ResourceID: resourceID,
+					Operation: interfaces.PolicyOperation{
+						Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation2}},
+						Deny:  []interfaces.PolicyOperationItem{{ID: tmpOperation1}},
+					},
+					Condition: `invalid`,
+				},
+			}
+			pdb.EXPECT().GetPoliciesByResourcesAndAccessToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(policys, nil)
+			checkResult, err := pc.Check(ctx, &resource, &accessor, []string{tmpOperation1}, includeParams)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, checkResult.Result, false)
+		})
+
+		Convey("条件无法解析，允许的操作不生效", func() {
+			policys := []interfaces.PolicyInfo{
+				{
+					ResourceID: resourceID,
+					Operation:  interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+					Condition:  `invalid`,
+				},
+			}
+			pdb.EXPECT().GetPoliciesByResourcesAndAccessToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(policys, nil)
+			checkResult, err := pc.Check(ctx, &resource, &accessor, []string{tmpOperation1}, includeParams)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, checkResult.Result, false)
+		})
+	})
+}
+
+func TestPolicyCalcGetCondition(t *testing.T) {
+	Convey("解析后的条件缓存有容量上限", t, func() {
+		pc := &policyCalc{conditions: newLRUCache(2, conditionCacheTTL)}
+		conditions := []string{
+			`{"attribute": "client.ip", "operator": "eq", "value": "1.1.1.1"}`,
+			`{"attribute": "client.ip", "operator": "eq", "value": "1.1.1.2"}`,
+			`{"attribute": "client.ip", "operator": "eq", "value": "1.1.1.3"}`,
+		}
+		for _, condition := range conditions {
+			cond, err := pc.getCondition(condition)
+			assert.Nil(t, err)
+			assert.NotNil(t, cond)
+		}
+		stats := pc.conditions.stats()
+		assert.Equal(t, stats.Size, 2)
+		assert.Equal(t, stats.Evictions, int64(1))
+
+		_, err := pc.getCondition(`invalid`)
+		assert.NotNil(t, err)
+		assert.Equal(t, pc.conditions.stats().Size, 2)
+	})
+}
+
+func TestPolicyCalcGetResourceListWithCondition(t *testing.T) {
+	Convey("资源列表接口, 条件策略", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		pdb := mock.NewMockDBPolicyCalc(ctrl)
+		userMgnt := mock.NewMockDrivenUserMgnt(ctrl)
+		role := mock.NewMockLogicsRole(ctrl)
+		pc := newPolicyCalc(pdb, userMgnt, role)
+
+		ctx := context.Background()
+		accessor := interfaces.AccessorInfo{
+			ID:   accessorID,
+			Type: interfaces.RealName,
+			IP:   "10.1.2.3",
+		}
+		includeParams := []interfaces.PolicCalcyIncludeType{}
+		userMgnt.EXPECT().GetAccessorIDsByUserID(gomock.Any(), gomock.Any()).Return([]string{accessorID}, nil)
+		role.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any()).Return(nil, nil)
+		userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any()).Return([]interfaces.SystemRoleType{}, nil)
+
+		getResourceIDs := func(policys []interfaces.PolicyInfo) map[string]bool {
+			pdb.EXPECT().GetPoliciesByResourceTypeAndAccessToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(policys, nil)
+			resources, _, err := pc.GetResourceList(ctx, resourceTypeDoc, &accessor, []string{tmpOperation1}, includeParams)
+			assert.Equal(t, err, nil)
+			result := make(map[string]bool)
+			for _, resource := range resources {
+				result[resource.ID] = true
+			}
+			return result
+		}
+
+		Convey("资源ID条件按资源分别计算, 与单个资源检查结果一致", func() {
+			result := getResourceIDs([]interfaces.PolicyInfo{
+				{
+					ResourceID: resourceID,
+					Operation:  interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+					Condition:  `{"attribute": "resource.id", "operator": "eq", "value": "resourceID1"}`,
+				},
+				{
+					ResourceID: resourceID2,
+					Operation:  interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+					Condition:  `{"attribute": "resource.id", "operator": "eq", "value": "resourceID1"}`,
+				},
+			})
+			assert.Equal(t, result, map[string]bool{resourceID: true})
+		})
+
+		Convey("依赖资源属性的允许策略不生效", func() {
+			result := getResourceIDs([]interfaces.PolicyInfo{
+				{
+					ResourceID: resourceID,
+					Operation:  interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+					Condition:  `{"attribute": "resource.tags", "operator": "contains", "value": "public"}`,
+				},
+				{
+					ResourceID: resourceID2,
+					Operation:  interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+				},
+			})
+			assert.Equal(t, result, map[string]bool{resourceID2: true})
+		})
+
+		Convey("依赖资源属性的拒绝策略生效", func() {
+			result := getResourceIDs([]interfaces.PolicyInfo{
+				{
+					ResourceID: allResourceID,
+					Operation:  interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+				},
+				{
+					ResourceID: resourceID,
+					Operation:  interfaces.PolicyOperation{Deny: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+					Condition:  `{"attribute": "resource.tags", "operator": "contains", "value": "secret"}`,
+				},
+				{
+					ResourceID: resourceID2,
+					Operation:  interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+				},
+			})
+			assert.Equal(t, result, map[string]bool{allResourceID: true, resourceID2: true})
+		})
+	})
+}
@@ -0,0 +1,57 @@
+// Package logics policy_effective 访问者有效权限报表
+package logics
+
+import (
+	"context"
+
+	gerrors "github.com/kweaver-ai/go-lib/error"
+
+	"Authorization/interfaces"
+)
+
+// GetEffectivePermissions 获取访问者的有效权限报表, 仅超级管理员、安全管理员和审计管理员可以查看
+func (d *policy) GetEffectivePermissions(ctx context.Context, visitor *interfaces.Visitor, param interfaces.EffectivePermissionParam) (
+	count int, permissions []interfaces.EffectivePermission, err error,
+) {
+	var roleTypes []interfaces.SystemRoleType
+	if visitor.Type == interfaces.RealName {
+		roleTypes, err = d.userMgmt.GetUserRolesByUserID(ctx, visitor.ID)
+		if err != nil {
+			return
+		}
+	}
+	err = checkVisitorType(
+		visitor,
+		roleTypes,
+		[]interfaces.VisitorType{interfaces.RealName},
+		[]interfaces.SystemRoleType{interfaces.SuperAdmin, interfaces.SecurityAdmin, interfaces.AuditAdmin},
+	)
+	if err != nil {
+		return
+	}
+
+	if param.Accessor.ID == "" {
+		err = gerrors.NewError(gerrors.PublicBadRequest, "accessor_id is required")
+		return
+	}
+
+	var resourceTypes []string
+	if param.ResourceType != "" {
+		resourceTypes = []string{param.ResourceType}
+	}
+	permissions, err = d.policyCalc.GetEffectivePermissions(ctx, &param.Accessor, resourceTypes)
+	if err != nil {
+		d.logger.Errorf("GetEffectivePermissions: %v", err)
+		return
+	}
+
+	count = len(permissions)
+	if param.Limit <= 0 {
+		return count, permissions, nil
+	}
+	if param.Offset >= count {
+		return count, []interfaces.EffectivePermission{}, nil
+	}
+	end := min(param.Offset+param.Limit, count)
+	return count, permissions[param.Offset:end], nil
+}
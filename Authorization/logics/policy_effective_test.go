@@ -0,0 +1,65 @@
+package logics
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"Authorization/interfaces"
+	"Authorization/interfaces/mock"
+)
+
+func TestPolicyGetEffectivePermissions(t *testing.T) {
+	Convey("有效权限报表", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		tmpDB := mock.NewMockDBPolicy(ctrl)
+		userMgnt := mock.NewMockDrivenUserMgnt(ctrl)
+		role := mock.NewMockLogicsRole(ctrl)
+		resourceType := mock.NewMockLogicsResourceType(ctrl)
+		policyCalc := mock.NewMockLogicsPolicyCalc(ctrl)
+		p := newPolicy(tmpDB, userMgnt, role, resourceType, policyCalc)
+
+		ctx := context.Background()
+		visitor := &interfaces.Visitor{ID: "visitorID", Type: interfaces.RealName}
+		param := interfaces.EffectivePermissionParam{
+			Accessor:     interfaces.AccessorInfo{ID: accessorID, Type: interfaces.RealName},
+			ResourceType: resourceTypeDoc,
+			Offset:       1,
+			Limit:        1,
+		}
+
+		Convey("普通用户没有权限", func() {
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), "visitorID").Return([]interfaces.SystemRoleType{interfaces.NormalUser}, nil)
+			_, _, err := p.GetEffectivePermissions(ctx, visitor, param)
+			assert.NotEqual(t, err, nil)
+		})
+
+		Convey("审计管理员分页获取", func() {
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), "visitorID").Return([]interfaces.SystemRoleType{interfaces.AuditAdmin}, nil)
+			policyCalc.EXPECT().GetEffectivePermissions(gomock.Any(), &param.Accessor, []string{resourceTypeDoc}).Return([]interfaces.EffectivePermission{
+				{ResourceID: allResourceID}, {ResourceID: resourceID}, {ResourceID: resourceID2},
+			}, nil)
+			count, permissions, err := p.GetEffectivePermissions(ctx, visitor, param)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, count, 3)
+			assert.Equal(t, permissions, []interfaces.EffectivePermission{{ResourceID: resourceID}})
+		})
+
+		Convey("导出时返回全部", func() {
+			param.Limit = 0
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), "visitorID").Return([]interfaces.SystemRoleType{interfaces.SecurityAdmin}, nil)
+			policyCalc.EXPECT().GetEffectivePermissions(gomock.Any(), gomock.Any(), gomock.Any()).Return([]interfaces.EffectivePermission{
+				{ResourceID: allResourceID}, {ResourceID: resourceID},
+			}, nil)
+			count, permissions, err := p.GetEffectivePermissions(ctx, visitor, param)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, count, 2)
+			assert.Equal(t, len(permissions), 2)
+		})
+	})
+}
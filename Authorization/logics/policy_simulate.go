@@ -0,0 +1,224 @@
+// Package logics policy_simulate 策略变更模拟
+package logics
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	gerrors "github.com/kweaver-ai/go-lib/error"
+	"github.com/satori/uuid"
+
+	"Authorization/interfaces"
+)
+
+// Simulate 模拟策略变更, 不写入数据库
+// 1. 变更的检查规则与 Create、Update、Delete 一致
+// 2. 访问者需要有变更策略的资源和被计算资源的授权权限
+// 3. 返回每个访问者在每个资源上生效操作的变化, 顺序为 accessors x resources
+//
+//nolint:gocyclo
+func (d *policy) Simulate(ctx context.Context, visitor *interfaces.Visitor, changes *interfaces.PolicySimulationChanges,
+	accessors []interfaces.AccessorInfo, resources []interfaces.ResourceInfo,
+) (results []interfaces.PolicySimulationResult, err error) {
+	if len(accessors) == 0 || len(resources) == 0 {
+		return
+	}
+
+	// 获取修改和删除的策略
+	deleteMap := make(map[string]bool, len(changes.Delete))
+	policyIDs := make([]string, 0, len(changes.Update)+len(changes.Delete))
+	for _, id := range changes.Delete {
+		deleteMap[id] = true
+		policyIDs = append(policyIDs, id)
+	}
+	idDupCheck := make(map[string]bool)
+	for i := range changes.Update {
+		if idDupCheck[changes.Update[i].ID] {
+			err = gerrors.NewError(gerrors.PublicBadRequest, fmt.Sprintf("policy id duplicate, policy.id %s", changes.Update[i].ID))
+			return
+		}
+		idDupCheck[changes.Update[i].ID] = true
+		policyIDs = append(policyIDs, changes.Update[i].ID)
+		err = checkEndTime(changes.Update[i].EndTime)
+		if err != nil {
+			return
+		}
+		err = d.checkPolicyCondition(&changes.Update[i])
+		if err != nil {
+			return
+		}
+	}
+	oldPoliciesMap := make(map[string]interfaces.PolicyInfo)
+	if len(policyIDs) > 0 {
+		oldPoliciesMap, err = d.db.GetByPolicyIDs(ctx, policyIDs)
+		if err != nil {
+			d.logger.Errorf("Simulate: GetByPolicyIDs %v", err)
+			return
+		}
+	}
+
+	// 保存资源类型和对应的资源实例ID
+	createResourceTypeMap := make(map[string][]string)
+	resourceTypeMap := make(map[string][]string)
+	tmpMap := make(map[string]map[string]bool)
+	addResource := func(resourceType, resourceID string) {
+		if _, ok := tmpMap[resourceType]; !ok {
+			tmpMap[resourceType] = make(map[string]bool)
+		}
+		tmpMap[resourceType][resourceID] = true
+	}
+	for i := range changes.Create {
+		createResourceTypeMap[changes.Create[i].ResourceType] = append(createResourceTypeMap[changes.Create[i].ResourceType], changes.Create[i].ResourceID)
+		resourceTypeMap[changes.Create[i].ResourceType] = append(resourceTypeMap[changes.Create[i].ResourceType], changes.Create[i].ResourceID)
+		addResource(changes.Create[i].ResourceType, changes.Create[i].ResourceID)
+	}
+	for id := range oldPoliciesMap {
+		resourceTypeMap[oldPoliciesMap[id].ResourceType] = append(resourceTypeMap[oldPoliciesMap[id].ResourceType], oldPoliciesMap[id].ResourceID)
+		addResource(oldPoliciesMap[id].ResourceType, oldPoliciesMap[id].ResourceID)
+	}
+	for i := range resources {
+		addResource(resources[i].Type, resources[i].ID)
+	}
+
+	// 检查是否有授权的权限
+	err = d.checkVisitorAuthorize(ctx, visitor, tmpMap)
+	if err != nil {
+		d.logger.Errorf("Simulate: checkVisitorAuthorize %v", err)
+		return
+	}
+
+	resourceTypeOperationIDMap := make(map[string]map[string]bool)
+	if len(resourceTypeMap) > 0 {
+		resourceTypeOperationIDMap, err = d.getResourceTypeOperations(ctx, resourceTypeMap)
+		if err != nil {
+			d.logger.Errorf("Simulate: %v", err)
+			return
+		}
+	}
+
+	overlay := interfaces.PolicyOverlay{DeleteIDs: changes.Delete}
+	upsertMap := make(map[string]int)
+	addUpsert := func(policy *interfaces.PolicyInfo) {
+		if deleteMap[policy.ID] {
+			return
+		}
+		if i, ok := upsertMap[policy.ID]; ok {
+			overlay.Upserts[i] = *policy
+			return
+		}
+		upsertMap[policy.ID] = len(overlay.Upserts)
+		overlay.Upserts = append(overlay.Upserts, *policy)
+	}
+
+	for i := range changes.Update {
+		// 策略不存在直接跳过
+		old, ok := oldPoliciesMap[changes.Update[i].ID]
+		if !ok {
+			continue
+		}
+		err = d.checkPolicyOperationValid(resourceTypeOperationIDMap[old.ResourceType], &changes.Update[i])
+		if err != nil {
+			return
+		}
+		old.Operation = changes.Update[i].Operation
+		old.Condition = changes.Update[i].Condition
+		old.EndTime = changes.Update[i].EndTime
+		addUpsert(&old)
+	}
+
+	if len(changes.Create) > 0 {
+		for i := range changes.Create {
+			err = checkEndTime(changes.Create[i].EndTime)
+			if err != nil {
+				return
+			}
+			err = d.checkPolicyOperationValid(resourceTypeOperationIDMap[changes.Create[i].ResourceType], &changes.Create[i])
+			if err != nil {
+				return
+			}
+			err = d.checkPolicyCondition(&changes.Create[i])
+			if err != nil {
+				return
+			}
+		}
+		// 已有策略被删除时按新增处理, 其他与已有策略合并的规则与 Create 一致
+		deletedKeys := make(map[[3]string]bool)
+		for id := range deleteMap {
+			if old, ok := oldPoliciesMap[id]; ok {
+				deletedKeys[[3]string{old.ResourceType, old.ResourceID, old.AccessorID}] = true
+			}
+		}
+		mergePolicies := make([]interfaces.PolicyInfo, 0, len(changes.Create))
+		for i := range changes.Create {
+			if deletedKeys[[3]string{changes.Create[i].ResourceType, changes.Create[i].ResourceID, changes.Create[i].AccessorID}] {
+				tmpPolicy := changes.Create[i]
+				tmpPolicy.ID = uuid.NewV4().String()
+				addUpsert(&tmpPolicy)
+				continue
+			}
+			mergePolicies = append(mergePolicies, changes.Create[i])
+		}
+		var newPolicy, updatePolicy []interfaces.PolicyInfo
+		newPolicy, updatePolicy, _, err = d.getCreateAndUpdatePolicy(ctx, mergePolicies, createResourceTypeMap, map[string]string{})
+		if err != nil {
+			return
+		}
+		for i := range updatePolicy {
+			addUpsert(&updatePolicy[i])
+		}
+		for i := range newPolicy {
+			addUpsert(&newPolicy[i])
+		}
+	}
+
+	// 按资源类型分组计算
+	resourceTypes := make([]string, 0)
+	typeResources := make(map[string][]interfaces.ResourceInfo)
+	for i := range resources {
+		if _, ok := typeResources[resources[i].Type]; !ok {
+			resourceTypes = append(resourceTypes, resources[i].Type)
+		}
+		typeResources[resources[i].Type] = append(typeResources[resources[i].Type], resources[i])
+	}
+
+	results = make([]interfaces.PolicySimulationResult, 0, len(accessors)*len(resources))
+	for i := range accessors {
+		currentMap := make(map[string]map[string][]string, len(resourceTypes))
+		simulatedMap := make(map[string]map[string][]string, len(resourceTypes))
+		for _, resourceType := range resourceTypes {
+			currentMap[resourceType], simulatedMap[resourceType], err = d.policyCalc.SimulateResourceOperation(ctx, typeResources[resourceType], &accessors[i], &overlay)
+			if err != nil {
+				return nil, err
+			}
+		}
+		for j := range resources {
+			result := interfaces.PolicySimulationResult{
+				Accessor:  accessors[i],
+				Resource:  resources[j],
+				Current:   currentMap[resources[j].Type][resources[j].ID],
+				Simulated: simulatedMap[resources[j].Type][resources[j].ID],
+			}
+			result.Added, result.Removed = diffOperations(result.Current, result.Simulated)
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// diffOperations 计算操作的变化
+func diffOperations(current, simulated []string) (added, removed []string) {
+	added = make([]string, 0)
+	removed = make([]string, 0)
+	for _, v := range simulated {
+		if !slices.Contains(current, v) {
+			added = append(added, v)
+		}
+	}
+	for _, v := range current {
+		if !slices.Contains(simulated, v) {
+			removed = append(removed, v)
+		}
+	}
+	return
+}
@@ -0,0 +1,234 @@
+//nolint:govet
+package logics
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"Authorization/interfaces"
+	"Authorization/interfaces/mock"
+)
+
+func TestPolicySimulate(t *testing.T) {
+	Convey("策略变更模拟", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		tmpDB := mock.NewMockDBPolicy(ctrl)
+		userMgnt := mock.NewMockDrivenUserMgnt(ctrl)
+		role := mock.NewMockLogicsRole(ctrl)
+		resourceType := mock.NewMockLogicsResourceType(ctrl)
+		policyCalc := mock.NewMockLogicsPolicyCalc(ctrl)
+		p := newPolicy(tmpDB, userMgnt, role, resourceType, policyCalc)
+
+		ctx := context.Background()
+		visitor := &interfaces.Visitor{ID: "visitorID", Type: interfaces.RealName}
+		accessors := []interfaces.AccessorInfo{{ID: accessorID, Type: interfaces.RealName}}
+		resources := []interfaces.ResourceInfo{{ID: resourceID, Type: resourceTypeDoc}}
+		typeMap := map[string]interfaces.ResourceType{
+			resourceTypeDoc: {
+				ID: resourceTypeDoc,
+				Operation: []interfaces.ResourceTypeOperation{
+					{ID: tmpOperation1}, {ID: tmpOperation2}, {ID: tmpOperation3},
+				},
+			},
+		}
+
+		Convey("修改的策略ID重复", func() {
+			changes := &interfaces.PolicySimulationChanges{
+				Update: []interfaces.PolicyInfo{{ID: "policy1"}, {ID: "policy1"}},
+			}
+			_, err := p.Simulate(ctx, visitor, changes, accessors, resources)
+			assert.NotEqual(t, err, nil)
+		})
+
+		Convey("没有授权权限", func() {
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any()).Return([]interfaces.SystemRoleType{}, nil)
+			policyCalc.EXPECT().Check(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(interfaces.CheckResult{Result: false}, nil)
+			_, err := p.Simulate(ctx, visitor, &interfaces.PolicySimulationChanges{}, accessors, resources)
+			assert.NotEqual(t, err, nil)
+		})
+
+		Convey("操作不存在", func() {
+			changes := &interfaces.PolicySimulationChanges{
+				Create: []interfaces.PolicyInfo{
+					{
+						ResourceID:   resourceID2,
+						ResourceType: resourceTypeDoc,
+						AccessorID:   accessorID,
+						AccessorType: interfaces.AccessorUser,
+						Operation:    interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation4}}},
+						EndTime:      -1,
+					},
+				},
+			}
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any()).Return([]interfaces.SystemRoleType{interfaces.SuperAdmin}, nil)
+			resourceType.EXPECT().GetByIDsInternal(gomock.Any(), gomock.Any()).Return(typeMap, nil)
+			_, err := p.Simulate(ctx, visitor, changes, accessors, resources)
+			assert.NotEqual(t, err, nil)
+		})
+
+		Convey("新增、修改、删除策略, 返回操作变化", func() {
+			oldPolicies := map[string]interfaces.PolicyInfo{
+				"policy1": {
+					ID:           "policy1",
+					ResourceID:   resourceID,
+					ResourceType: resourceTypeDoc,
+					AccessorID:   accessorID,
+					AccessorType: interfaces.AccessorUser,
+					Operation:    interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+				},
+				"policy2": {
+					ID:           "policy2",
+					ResourceID:   resourceID2,
+					ResourceType: resourceTypeDoc,
+					AccessorID:   accessorID,
+					AccessorType: interfaces.AccessorUser,
+					Operation:    interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+				},
+			}
+			changes := &interfaces.PolicySimulationChanges{
+				Update: []interfaces.PolicyInfo{
+					{
+						ID:        "policy1",
+						Operation: interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation2}}},
+						EndTime:   -1,
+					},
+				},
+				Delete: []string{"policy2"},
+				Create: []interfaces.PolicyInfo{
+					{
+						ResourceID:   resourceID2,
+						ResourceType: resourceTypeDoc,
+						AccessorID:   accessorID,
+						AccessorType: interfaces.AccessorUser,
+						Operation:    interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation3}}},
+						EndTime:      -1,
+					},
+					{
+						ResourceID:   resourceID3,
+						ResourceType: resourceTypeDoc,
+						AccessorID:   accessorID,
+						AccessorType: interfaces.AccessorUser,
+						Operation:    interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation3}}},
+						EndTime:      -1,
+					},
+				},
+			}
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any()).Return([]interfaces.SystemRoleType{interfaces.SuperAdmin}, nil)
+			tmpDB.EXPECT().GetByPolicyIDs(gomock.Any(), gomock.Any()).Return(oldPolicies, nil)
+			resourceType.EXPECT().GetByIDsInternal(gomock.Any(), gomock.Any()).Return(typeMap, nil)
+			tmpDB.EXPECT().GetByResourceIDs(gomock.Any(), gomock.Any(), gomock.Any()).Return(map[string][]interfaces.PolicyInfo{}, nil)
+
+			var overlay *interfaces.PolicyOverlay
+			policyCalc.EXPECT().SimulateResourceOperation(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ []interfaces.ResourceInfo, _ *interfaces.AccessorInfo, o *interfaces.PolicyOverlay) (
+					map[string][]string, map[string][]string, error,
+				) {
+					overlay = o
+					return map[string][]string{resourceID: {tmpOperation1}},
+						map[string][]string{resourceID: {tmpOperation2}}, nil
+				})
+
+			results, err := p.Simulate(ctx, visitor, changes, accessors, resources)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(results), 1)
+			assert.Equal(t, results[0].Added, []string{tmpOperation2})
+			assert.Equal(t, results[0].Removed, []string{tmpOperation1})
+
+			assert.Equal(t, overlay.DeleteIDs, []string{"policy2"})
+			assert.Equal(t, len(overlay.Upserts), 3)
+			assert.Equal(t, overlay.Upserts[0].ID, "policy1")
+			assert.Equal(t, overlay.Upserts[0].Operation.Allow[0].ID, tmpOperation2)
+			upsertResources := map[string]bool{}
+			for i := range overlay.Upserts[1:] {
+				assert.NotEqual(t, overlay.Upserts[i+1].ID, "")
+				assert.NotEqual(t, overlay.Upserts[i+1].ID, "policy2")
+				upsertResources[overlay.Upserts[i+1].ResourceID] = true
+			}
+			assert.Equal(t, upsertResources, map[string]bool{resourceID2: true, resourceID3: true})
+		})
+	})
+}
+
+func TestPolicyCalcSimulateResourceOperation(t *testing.T) {
+	Convey("叠加未提交策略计算资源操作", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		pdb := mock.NewMockDBPolicyCalc(ctrl)
+		userMgnt := mock.NewMockDrivenUserMgnt(ctrl)
+		role := mock.NewMockLogicsRole(ctrl)
+		resourceType := mock.NewMockLogicsResourceType(ctrl)
+		pc := newPolicyCalc(pdb, userMgnt, role)
+		pc.resourceType = resourceType
+
+		ctx := context.Background()
+		accessor := &interfaces.AccessorInfo{ID: accessorID, Type: interfaces.RealName}
+		resources := []interfaces.ResourceInfo{
+			{ID: resourceID, Type: resourceTypeDoc},
+			{ID: resourceID2, Type: resourceTypeDoc},
+		}
+
+		userMgnt.EXPECT().GetAccessorIDsByUserID(gomock.Any(), gomock.Any()).Return([]string{accessorID}, nil)
+		role.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any()).Return([]interfaces.RoleInfo{}, nil)
+		userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), gomock.Any()).Return([]interfaces.SystemRoleType{}, nil)
+		resourceType.EXPECT().GetByIDsInternal(gomock.Any(), gomock.Any()).Return(map[string]interfaces.ResourceType{
+			resourceTypeDoc: {
+				ID: resourceTypeDoc,
+				Operation: []interfaces.ResourceTypeOperation{
+					{ID: tmpOperation1, Scope: []interfaces.OperationScopeType{interfaces.ScopeType, interfaces.ScopeInstance}},
+					{ID: tmpOperation2, Scope: []interfaces.OperationScopeType{interfaces.ScopeType, interfaces.ScopeInstance}},
+				},
+			},
+		}, nil)
+		pdb.EXPECT().GetPoliciesByResourcesAndAccessToken(gomock.Any(), gomock.Any(), gomock.Any()).Return([]interfaces.PolicyInfo{
+			{
+				ID:           "policy1",
+				ResourceID:   resourceID,
+				ResourceType: resourceTypeDoc,
+				AccessorID:   accessorID,
+				Operation:    interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+			},
+			{
+				ID:           "policy2",
+				ResourceID:   resourceID2,
+				ResourceType: resourceTypeDoc,
+				AccessorID:   accessorID,
+				Operation:    interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1}}},
+			},
+		}, nil)
+
+		overlay := &interfaces.PolicyOverlay{
+			DeleteIDs: []string{"policy2"},
+			Upserts: []interfaces.PolicyInfo{
+				{
+					ID:           "policy1",
+					ResourceID:   resourceID,
+					ResourceType: resourceTypeDoc,
+					AccessorID:   accessorID,
+					Operation:    interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation1}, {ID: tmpOperation2}}},
+				},
+				// 访问令牌不匹配的策略不生效
+				{
+					ID:           "policy3",
+					ResourceID:   resourceID2,
+					ResourceType: resourceTypeDoc,
+					AccessorID:   accessorID2,
+					Operation:    interfaces.PolicyOperation{Allow: []interfaces.PolicyOperationItem{{ID: tmpOperation2}}},
+				},
+			},
+		}
+		current, simulated, err := pc.SimulateResourceOperation(ctx, resources, accessor, overlay)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, current[resourceID], []string{tmpOperation1})
+		assert.Equal(t, current[resourceID2], []string{tmpOperation1})
+		assert.Equal(t, simulated[resourceID], []string{tmpOperation1, tmpOperation2})
+		assert.Equal(t, simulated[resourceID2], []string{})
+	})
+}
@@ -496,6 +496,12 @@ func TestPolicyUpdate(t *testing.T) {
 			assert.NotEqual(t, err, nil)
 		})
 
+		Convey("入参policys中条件非法", func() {
+			policyInfo.Condition = `{"attribute": "client.ip", "operator": "in_cidr", "value": ["not-cidr"]}`
+			err := policy.Update(ctx, &visitor, []interfaces.PolicyInfo{policyInfo})
+			assert.NotEqual(t, err, nil)
+		})
+
 		Convey("获取历史策略出错", func() {
 			tmpDB.EXPECT().GetByPolicyIDs(gomock.Any(), gomock.Any()).Return(nil, testErr)
 			err := policy.Update(ctx, &visitor, []interfaces.PolicyInfo{policyInfo})
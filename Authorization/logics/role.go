@@ -5,6 +5,7 @@ import (
 	"context"
 	_ "embed"
 	"encoding/json"
+	"fmt"
 	"reflect"
 	"slices"
 	"strings"
@@ -23,13 +24,17 @@ import (
 type role struct {
 	roleDB        interfaces.DBRole
 	roleMemberDB  interfaces.DBRoleMember
+	exclusionDB   interfaces.DBRoleExclusion
+	holders       *roleHolderResolver
 	userMgnt      interfaces.DrivenUserMgnt
+	messageBroker interfaces.DrivenMessageBroker
 	pool          *sqlx.DB
 	logger        common.Logger
 	event         interfaces.LogicsEvent
 	resourceType  interfaces.LogicsResourceType
 	roleSortOrder map[string]int // 角色排序
 	i18n          *common.I18n
+	calcCache     *policyCalcCache
 }
 
 var (
@@ -59,13 +64,17 @@ var (
 func NewLogicsRole() *role {
 	roleOnce.Do(func() {
 		roleLogics = &role{
-			roleDB:       dbRole,
-			roleMemberDB: dbRoleMember,
-			userMgnt:     dnUserMgnt,
-			pool:         dbPool,
-			logger:       common.NewLogger(),
-			event:        NewEvent(),
-			resourceType: NewResourceType(),
+			roleDB:        dbRole,
+			roleMemberDB:  dbRoleMember,
+			exclusionDB:   dbRoleExclusion,
+			holders:       newRoleHolderResolver(dbRoleMember, dnUserMgnt),
+			userMgnt:      dnUserMgnt,
+			messageBroker: dnMessageBroker,
+			pool:          dbPool,
+			logger:        common.NewLogger(),
+			event:         NewEvent(),
+			resourceType:  NewResourceType(),
+			calcCache:     newPolicyCalcCache(),
 			i18n: common.NewI18n(common.I18nMap{
 				i18nRoleNotFound: {
 					simplifiedChinese:  "角色不存在",
@@ -86,6 +95,12 @@ func NewLogicsRole() *role {
 		roleLogics.event.RegisterOrgNameModified(roleLogics.updateMemberName)
 		// 应用账户改名
 		roleLogics.event.RegisterAppNameModified(roleLogics.updateAppName)
+		// 角色删除, 删除该角色作为其他角色成员的记录
+		roleLogics.event.RegisterRoleDeleted(roleLogics.deleteMemberByMemberID)
+		// 角色改名, 更新该角色作为其他角色成员的名称
+		roleLogics.event.RegisterRoleNameModified(roleLogics.updateMemberName)
+		// 角色成员过期, 通过消息队列通知其他服务
+		roleLogics.event.RegisterRoleMembersExpired(roleLogics.publishMembersExpired)
 		// 初始化角色数据
 		roleLogics.initRoleOrder()
 	})
@@ -481,6 +496,7 @@ func (r *role) AddOrDeleteRoleMemebers(ctx context.Context, visitor *interfaces.
 }
 
 // AddRoleMembers 角色成员添加
+// 成员为角色时, 该角色的成员继承当前角色, 不允许形成循环
 func (r *role) AddRoleMembers(ctx context.Context, visitor *interfaces.Visitor, roleID string, infos map[string]interfaces.RoleMemberInfo) (err error) {
 	// 权限检查 visitor
 	err = r.checkVisitorType(ctx, visitor)
@@ -513,10 +529,19 @@ func (r *role) AddRoleMembers(ctx context.Context, visitor *interfaces.Visitor,
 	newMembers := make([]interfaces.RoleMemberInfo, 0, len(infos))
 	// 用于批量检查访问者ID与Type是否对应
 	idTypeMap := make(map[string]interfaces.AccessorType)
+	roleMemberIDs := make([]string, 0)
 	for _, temp := range infos {
+		err = checkRoleMemberTime(temp.StartTime, temp.EndTime)
+		if err != nil {
+			return
+		}
 		if _, ok := oldMembersMap[temp.ID]; !ok {
 			newMembers = append(newMembers, temp)
-			idTypeMap[temp.ID] = temp.MemberType
+			if temp.MemberType == interfaces.AccessorRole {
+				roleMemberIDs = append(roleMemberIDs, temp.ID)
+			} else {
+				idTypeMap[temp.ID] = temp.MemberType
+			}
 		}
 	}
 
@@ -525,24 +550,108 @@ func (r *role) AddRoleMembers(ctx context.Context, visitor *interfaces.Visitor,
 	}
 
 	// 获取用户信息
-	idNameMap, err := r.userMgnt.GetNameByAccessorIDs(ctx, idTypeMap)
-	if err != nil {
-		r.logger.Errorf("AddRoleMembers GetNameByAccessorIDs: %v", err)
-		return
+	idNameMap := make(map[string]string)
+	if len(idTypeMap) > 0 {
+		idNameMap, err = r.userMgnt.GetNameByAccessorIDs(ctx, idTypeMap)
+		if err != nil {
+			r.logger.Errorf("AddRoleMembers GetNameByAccessorIDs: %v", err)
+			return
+		}
+	}
+	// 角色成员检查, 获取角色名称
+	if len(roleMemberIDs) > 0 {
+		var roleNameMap map[string]string
+		roleNameMap, err = r.checkRoleMembers(ctx, roleID, roleMemberIDs)
+		if err != nil {
+			return
+		}
+		for k, v := range roleNameMap {
+			idNameMap[k] = v
+		}
 	}
 	// 添加角色成员
 	for i, temp := range newMembers {
 		newMembers[i].Name = idNameMap[temp.ID]
 	}
 
+	// 互斥角色集检查
+	err = r.checkRoleExclusion(ctx, roleID, newMembers)
+	if err != nil {
+		return
+	}
+
 	// 添加角色
 	err = r.roleMemberDB.AddRoleMembers(ctx, roleID, newMembers)
 	if err != nil {
 		return err
 	}
+	// 角色成员变更, 失效访问令牌缓存
+	r.calcCache.invalidateAccessTokens()
 	return err
 }
 
+/*
+检查作为成员的角色, 返回角色名称
+1. 角色必须存在, 且不能是系统角色, 系统角色的成员由账户管理服务维护, 无法展开
+2. 角色 A 作为角色 B 的成员, 表示 A 的成员继承 B。如果 B 已经直接或间接继承 A, 则形成循环
+*/
+func (r *role) checkRoleMembers(ctx context.Context, roleID string, memberRoleIDs []string) (nameMap map[string]string, err error) {
+	roleInfoMap, err := r.roleDB.GetRoleByIDs(ctx, memberRoleIDs)
+	if err != nil {
+		r.logger.Errorf("checkRoleMembers GetRoleByIDs: %v", err)
+		return nil, err
+	}
+	nameMap = make(map[string]string, len(memberRoleIDs))
+	for _, id := range memberRoleIDs {
+		memberRole, ok := roleInfoMap[id]
+		if !ok {
+			return nil, gerrors.NewError(gerrors.PublicBadRequest, fmt.Sprintf("member role %s does not exist", id))
+		}
+		if memberRole.RoleSource == interfaces.RoleSourceSystem {
+			return nil, gerrors.NewError(gerrors.PublicBadRequest, fmt.Sprintf("system role %s can not be a member", id))
+		}
+		nameMap[id] = memberRole.Name
+	}
+
+	// 当前角色继承的所有角色, 包含自身。未生效和已过期未清理的成员关系也参与检查
+	inheritedRoles, err := r.getRoleByMembers(ctx, []string{roleID}, 0)
+	if err != nil {
+		return nil, err
+	}
+	inheritedSet := map[string]bool{roleID: true}
+	for i := range inheritedRoles {
+		inheritedSet[inheritedRoles[i].ID] = true
+	}
+	for _, id := range memberRoleIDs {
+		if inheritedSet[id] {
+			return nil, gerrors.NewError(errors.RoleInheritanceCycle, fmt.Sprintf("role %s already inherits member role %s", roleID, id))
+		}
+	}
+	return nameMap, nil
+}
+
+// checkRoleMemberTime 检查成员的生效时间和过期时间
+func checkRoleMemberTime(startTime, endTime int64) (err error) {
+	if startTime < 0 {
+		return gerrors.NewError(gerrors.PublicBadRequest, "member start time is illegal")
+	}
+	if endTime == -1 {
+		return nil
+	}
+	if endTime <= common.GetCurrentMicrosecondTimestamp() {
+		return gerrors.NewError(gerrors.PublicBadRequest, "member expiration time cannot be earlier than the current time")
+	}
+	if startTime >= endTime {
+		return gerrors.NewError(gerrors.PublicBadRequest, "member start time must be earlier than the expiration time")
+	}
+	return nil
+}
+
+// isRoleMemberActive 成员关系在 curTime 是否生效
+func isRoleMemberActive(member *interfaces.RoleMemberInfo, curTime int64) bool {
+	return member.StartTime <= curTime && (member.EndTime == -1 || member.EndTime >= curTime)
+}
+
 // DeleteRoleMembers 角色成员删除
 func (r *role) DeleteRoleMembers(ctx context.Context, visitor *interfaces.Visitor, roleID string, infos map[string]interfaces.RoleMemberInfo) (err error) {
 	// 权限检查 visitor
@@ -572,12 +681,12 @@ func (r *role) DeleteRoleMembers(ctx context.Context, visitor *interfaces.Visito
 		r.logger.Errorf("DeleteRoleMembers DeleteRoleMembers err:%v", err)
 		return err
 	}
+	// 角色成员变更, 失效访问令牌缓存
+	r.calcCache.invalidateAccessTokens()
 	return err
 }
 
 // GetRoleMembers 角色成员列举
-//
-//nolint:staticcheck
 func (r *role) GetRoleMembers(ctx context.Context, visitor *interfaces.Visitor, roleID string, info interfaces.RoleMemberSearchInfo) (num int, outInfo []interfaces.RoleMemberInfo, err error) {
 	// 权限检查 visitor
 	err = r.checkVisitorType(ctx, visitor)
@@ -611,38 +720,130 @@ func (r *role) GetRoleMembers(ctx context.Context, visitor *interfaces.Visitor,
 			return num, outInfo, err
 		}
 
-		// 获取用户的部门信息
-		userIDs := []string{}
-		// 获取部门的父部门信息
-		for i, outInfo := range outInfos {
-			if outInfo.MemberType == interfaces.AccessorUser {
-				userIDs = append(userIDs, outInfo.ID)
-			} else if outInfo.MemberType == interfaces.AccessorDepartment {
-				var dep []interfaces.Department
-				dep, err = r.userMgnt.GetParentDepartmentsByDepartmentID(ctx, outInfo.ID)
-				if err != nil {
-					return num, outInfos, err
+		err = r.fillMemberParentDeps(ctx, outInfos)
+		if err != nil {
+			return num, outInfos, err
+		}
+	}
+
+	return num, outInfos, err
+}
+
+/*
+GetRoleEffectiveMembers 角色有效成员列举
+1. 展开作为成员的角色, 结果中不包含角色类型的成员
+2. 同一成员通过多个角色获得时只返回一次, ViaRoles 记录所有来源角色
+3. 按展开顺序内存分页, 直接成员在前
+4. 只包含当前生效的成员关系
+*/
+//nolint:gocyclo
+func (r *role) GetRoleEffectiveMembers(ctx context.Context, visitor *interfaces.Visitor, roleID string, info interfaces.RoleMemberSearchInfo) (num int, outInfo []interfaces.RoleMemberInfo, err error) {
+	// 权限检查 visitor
+	err = r.checkVisitorType(ctx, visitor)
+	if err != nil {
+		return
+	}
+	// 判断角色是否存在
+	roleInfo, err := r.roleDB.GetRoleByID(ctx, roleID)
+	if err != nil {
+		return num, outInfo, err
+	}
+	if roleInfo.ID == "" {
+		err = gerrors.NewError(errors.RoleNotFound, r.i18n.Load(i18nRoleNotFound, visitor.Language))
+		return num, outInfo, err
+	}
+
+	keyword := strings.ToLower(strings.TrimSpace(info.Keyword))
+	curTime := common.GetCurrentMicrosecondTimestamp()
+	members := make([]interfaces.RoleMemberInfo, 0)
+	memberIndex := make(map[string]int)
+	visited := map[string]bool{roleID: true}
+	queue := []interfaces.NameInfo{{ID: roleInfo.ID, Name: roleInfo.Name}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		var directMembers []interfaces.RoleMemberInfo
+		directMembers, err = r.roleMemberDB.GetRoleMembersByRoleID(ctx, current.ID)
+		if err != nil {
+			r.logger.Errorf("GetRoleEffectiveMembers GetRoleMembersByRoleID: %v", err)
+			return 0, nil, err
+		}
+		for _, member := range directMembers {
+			if !isRoleMemberActive(&member, curTime) {
+				continue
+			}
+			if member.MemberType == interfaces.AccessorRole {
+				if !visited[member.ID] {
+					visited[member.ID] = true
+					queue = append(queue, interfaces.NameInfo{ID: member.ID, Name: member.Name})
 				}
-				outInfos[i].ParentDeps = append(outInfos[i].ParentDeps, dep)
-			} else {
-				outInfos[i].ParentDeps = [][]interfaces.Department{}
+				continue
 			}
+			if len(info.MemberTypes) > 0 && !slices.Contains(info.MemberTypes, member.MemberType) {
+				continue
+			}
+			if keyword != "" && !strings.Contains(strings.ToLower(member.Name), keyword) {
+				continue
+			}
+			if i, ok := memberIndex[member.ID]; ok {
+				members[i].ViaRoles = append(members[i].ViaRoles, current)
+				continue
+			}
+			member.ViaRoles = []interfaces.NameInfo{current}
+			memberIndex[member.ID] = len(members)
+			members = append(members, member)
 		}
+	}
 
-		// 批量获取用户的父部门信息
-		var userInfoMaps map[string]interfaces.UserInfo
-		userInfoMaps, err = r.userMgnt.BatchGetUserInfoByID(ctx, userIDs)
+	num = len(members)
+	start := min(info.Offset, num)
+	end := num
+	if info.Limit >= 0 {
+		end = min(start+info.Limit, num)
+	}
+	outInfo = members[start:end]
+	if len(outInfo) > 0 {
+		err = r.fillMemberParentDeps(ctx, outInfo)
 		if err != nil {
-			return num, outInfos, err
+			return num, outInfo, err
 		}
-		for i, outInfo := range outInfos {
-			if outInfo.MemberType == interfaces.AccessorUser {
-				outInfos[i].ParentDeps = userInfoMaps[outInfo.ID].ParentDeps
+	}
+	return num, outInfo, nil
+}
+
+// fillMemberParentDeps 填充成员的父部门信息
+//
+//nolint:staticcheck
+func (r *role) fillMemberParentDeps(ctx context.Context, outInfos []interfaces.RoleMemberInfo) (err error) {
+	// 获取用户的部门信息
+	userIDs := []string{}
+	// 获取部门的父部门信息
+	for i, outInfo := range outInfos {
+		if outInfo.MemberType == interfaces.AccessorUser {
+			userIDs = append(userIDs, outInfo.ID)
+		} else if outInfo.MemberType == interfaces.AccessorDepartment {
+			var dep []interfaces.Department
+			dep, err = r.userMgnt.GetParentDepartmentsByDepartmentID(ctx, outInfo.ID)
+			if err != nil {
+				return err
 			}
+			outInfos[i].ParentDeps = append(outInfos[i].ParentDeps, dep)
+		} else {
+			outInfos[i].ParentDeps = [][]interfaces.Department{}
 		}
 	}
 
-	return num, outInfos, err
+	// 批量获取用户的父部门信息
+	userInfoMaps, err := r.userMgnt.BatchGetUserInfoByID(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+	for i, outInfo := range outInfos {
+		if outInfo.MemberType == interfaces.AccessorUser {
+			outInfos[i].ParentDeps = userInfoMaps[outInfo.ID].ParentDeps
+		}
+	}
+	return nil
 }
 
 // 检查有效性
@@ -658,13 +859,136 @@ func (r *role) checkName(name string) (err error) {
 	return nil
 }
 
-// GetRoleByMembers 通过成员获取角色
+// GetRoleByMembers 通过成员获取角色, 只包含当前生效的成员关系
+// 成员关系的生效依赖查询时间, 未到生效时间的成员关系由策略计算缓存的有效期保证最终一致
 func (r *role) GetRoleByMembers(ctx context.Context, memberIDs []string) (outInfo []interfaces.RoleInfo, err error) {
-	roles, err := r.roleMemberDB.GetRoleByMembers(ctx, memberIDs)
+	return r.getRoleByMembers(ctx, memberIDs, common.GetCurrentMicrosecondTimestamp())
+}
+
+// getRoleByMembers 通过成员获取角色, 角色可以作为其他角色的成员, 逐层展开继承的角色, 结果去重
+// curTime 大于 0 时只包含 curTime 生效中的成员关系
+func (r *role) getRoleByMembers(ctx context.Context, memberIDs []string, curTime int64) (outInfo []interfaces.RoleInfo, err error) {
+	roles, err := r.roleMemberDB.GetRoleByMembers(ctx, memberIDs, curTime)
 	if err != nil {
 		return nil, err
 	}
-	return roles, nil
+	visited := make(map[string]bool)
+	for len(roles) > 0 {
+		roleIDs := make([]string, 0, len(roles))
+		for i := range roles {
+			// 已展开的角色不再处理, 避免历史数据中的循环
+			if visited[roles[i].ID] {
+				continue
+			}
+			visited[roles[i].ID] = true
+			outInfo = append(outInfo, roles[i])
+			roleIDs = append(roleIDs, roles[i].ID)
+		}
+		if len(roleIDs) == 0 {
+			break
+		}
+		roles, err = r.roleMemberDB.GetRoleByMembers(ctx, roleIDs, curTime)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return outInfo, nil
+}
+
+/*
+checkRoleExclusion 检查新成员是否违反互斥角色集
+1. 成员获得当前角色以及当前角色作为成员的角色(继承)
+2. 互斥角色集中有两个及以上角色同时被获得, 或者成员对应的用户、应用账户已拥有集合中的其他角色, 则违反
+3. 未生效和已过期未清理的成员关系同样参与检查
+*/
+func (r *role) checkRoleExclusion(ctx context.Context, roleID string, members []interfaces.RoleMemberInfo) (err error) {
+	if len(members) == 0 {
+		return nil
+	}
+	exclusions, err := r.exclusionDB.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	if len(exclusions) == 0 {
+		return nil
+	}
+
+	// 成员获得的角色
+	gainedRoles, err := r.getRoleByMembers(ctx, []string{roleID}, 0)
+	if err != nil {
+		return err
+	}
+	gained := map[string]bool{roleID: true}
+	for i := range gainedRoles {
+		gained[gainedRoles[i].ID] = true
+	}
+
+	var holders map[string]interfaces.AccessorType
+	roleHolders := make(map[string]map[string]interfaces.AccessorType)
+	for i := range exclusions {
+		gainedIDs := make([]string, 0)
+		otherIDs := make([]string, 0)
+		for _, id := range exclusions[i].RoleIDs {
+			if gained[id] {
+				gainedIDs = append(gainedIDs, id)
+			} else {
+				otherIDs = append(otherIDs, id)
+			}
+		}
+		if len(gainedIDs) == 0 {
+			continue
+		}
+
+		if holders == nil {
+			holders, err = r.holders.memberHolders(ctx, members, 0)
+			if err != nil {
+				return err
+			}
+		}
+		if len(holders) == 0 {
+			return nil
+		}
+
+		if len(gainedIDs) >= minRoleExclusionRoles {
+			return r.roleExclusionError(&exclusions[i], gainedIDs, holders)
+		}
+		for _, otherID := range otherIDs {
+			otherHolders, ok := roleHolders[otherID]
+			if !ok {
+				otherHolders, err = r.holders.roleHolders(ctx, otherID, 0)
+				if err != nil {
+					return err
+				}
+				roleHolders[otherID] = otherHolders
+			}
+			conflicts := make(map[string]interfaces.AccessorType)
+			for id, accessorType := range holders {
+				if _, ok := otherHolders[id]; ok {
+					conflicts[id] = accessorType
+				}
+			}
+			if len(conflicts) > 0 {
+				return r.roleExclusionError(&exclusions[i], append(gainedIDs, otherID), conflicts)
+			}
+		}
+	}
+	return nil
+}
+
+// roleExclusionError 违反互斥角色集错误, 详情中包含互斥角色集、冲突的角色和访问者
+func (r *role) roleExclusionError(exclusion *interfaces.RoleExclusionInfo, roleIDs []string, accessors map[string]interfaces.AccessorType) error {
+	accessorIDs := make([]string, 0, len(accessors))
+	for id := range accessors {
+		accessorIDs = append(accessorIDs, id)
+	}
+	slices.Sort(accessorIDs)
+	return gerrors.NewError(errors.RoleExclusionViolated,
+		fmt.Sprintf("members violate role exclusion %s", exclusion.Name),
+		gerrors.SetDetail(map[string]any{
+			"exclusion":    map[string]any{"id": exclusion.ID, "name": exclusion.Name},
+			"role_ids":     roleIDs,
+			"accessor_ids": accessorIDs,
+		}))
 }
 
 // GetRolesByIDs 批量获取角色
@@ -747,6 +1071,12 @@ func (r *role) InitRoleMemebers(ctx context.Context, infoMap map[string][]interf
 			}
 		}
 
+		// 互斥角色集检查
+		err = r.checkRoleExclusion(ctx, roleID, newMembers)
+		if err != nil {
+			return err
+		}
+
 		// 添加角色成员
 		err = r.roleMemberDB.AddRoleMembers(ctx, roleID, newMembers)
 		if err != nil {
@@ -754,11 +1084,51 @@ func (r *role) InitRoleMemebers(ctx context.Context, infoMap map[string][]interf
 			return err
 		}
 	}
+	r.calcCache.invalidateAccessTokens()
 	return
 }
 
 func (r *role) deleteMemberByMemberID(memberID string) (err error) {
-	return r.roleMemberDB.DeleteByMemberIDs([]string{memberID})
+	err = r.roleMemberDB.DeleteByMemberIDs([]string{memberID})
+	if err != nil {
+		return
+	}
+	r.calcCache.invalidateAccessTokens()
+	return
+}
+
+// DeleteMembersByEndTime 删除过期的角色成员, 并通知各角色过期的成员
+func (r *role) DeleteMembersByEndTime(curTime int64) (err error) {
+	ctx := context.Background()
+	expiredMembers, err := r.roleMemberDB.DeleteExpiredMembers(ctx, curTime)
+	if err != nil {
+		r.logger.Errorf("DeleteMembersByEndTime DeleteExpiredMembers err:%v", err)
+		return err
+	}
+	if len(expiredMembers) == 0 {
+		return nil
+	}
+
+	// 角色成员变更, 失效访问令牌缓存
+	r.calcCache.invalidateAccessTokens()
+
+	for roleID, members := range expiredMembers {
+		memberIDs := make([]string, 0, len(members))
+		for i := range members {
+			memberIDs = append(memberIDs, members[i].ID)
+		}
+		r.logger.Infof("DeleteMembersByEndTime role: %s, expired members: %v", roleID, memberIDs)
+		// 成员已删除, 通知失败不影响其他角色
+		if eventErr := r.event.RoleMembersExpired(roleID, memberIDs); eventErr != nil {
+			r.logger.Errorf("DeleteMembersByEndTime RoleMembersExpired role: %s, err:%v", roleID, eventErr)
+		}
+	}
+	return nil
+}
+
+// publishMembersExpired 发布角色成员过期消息
+func (r *role) publishMembersExpired(roleID string, memberIDs []string) (err error) {
+	return r.messageBroker.RoleMembersExpired(roleID, memberIDs)
 }
 
 func (r *role) updateMemberName(memberID, name string) (err error) {
@@ -874,7 +1244,7 @@ func (r *role) GetAccessorRoles(ctx context.Context, param interfaces.AccessorRo
 	}
 	// 添加根部门ID， 表示 所有用户/部门/组织
 	accessTokens = append(accessTokens, rootDepID)
-	roles, err := r.roleMemberDB.GetRoleByMembers(ctx, accessTokens)
+	roles, err := r.GetRoleByMembers(ctx, accessTokens)
 	if err != nil {
 		r.logger.Errorf("GetAccessorRoles GetRoleByMembers accessTokens:%v  err:%v", accessTokens, err)
 		return 0, nil, err
@@ -0,0 +1,500 @@
+// Package logics role_exclusion 互斥角色集
+package logics
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	gerrors "github.com/kweaver-ai/go-lib/error"
+
+	"Authorization/common"
+	errors "Authorization/error"
+	"Authorization/interfaces"
+)
+
+const minRoleExclusionRoles = 2
+
+var (
+	roleExclusionOnce      sync.Once
+	roleExclusionSingleton *roleExclusion
+)
+
+/*
+互斥角色集(静态职责分离)
+1. 同一访问者最多只能拥有集合中的一个角色, 包含通过部门、用户组和继承角色获得的角色
+2. 添加角色成员时检查, 已存在的违反情况通过 GetViolations 列举
+*/
+type roleExclusion struct {
+	db       interfaces.DBRoleExclusion
+	roleDB   interfaces.DBRole
+	userMgnt interfaces.DrivenUserMgnt
+	holders  *roleHolderResolver
+	logger   common.Logger
+}
+
+// NewRoleExclusion 创建新的互斥角色集对象
+func NewRoleExclusion() *roleExclusion {
+	roleExclusionOnce.Do(func() {
+		roleExclusionSingleton = &roleExclusion{
+			db:       dbRoleExclusion,
+			roleDB:   dbRole,
+			userMgnt: dnUserMgnt,
+			holders:  newRoleHolderResolver(dbRoleMember, dnUserMgnt),
+			logger:   common.NewLogger(),
+		}
+		// 角色删除, 从互斥角色集中移除该角色
+		NewEvent().RegisterRoleDeleted(roleExclusionSingleton.roleDeleted)
+	})
+	return roleExclusionSingleton
+}
+
+func (re *roleExclusion) checkVisitorType(ctx context.Context, visitor *interfaces.Visitor) (err error) {
+	// 获取访问者角色
+	var roleTypes []interfaces.SystemRoleType
+
+	// 实名用户获取对应角色信息
+	if visitor.Type == interfaces.RealName {
+		// 获取用户角色信息
+		roleTypes, err = re.userMgnt.GetUserRolesByUserID(ctx, visitor.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return checkVisitorType(
+		visitor,
+		roleTypes,
+		[]interfaces.VisitorType{interfaces.RealName},
+		[]interfaces.SystemRoleType{interfaces.SuperAdmin, interfaces.SystemAdmin, interfaces.SecurityAdmin},
+	)
+}
+
+// checkName 检查名称, 名称不能重复
+func (re *roleExclusion) checkName(ctx context.Context, id, name string) (err error) {
+	length := utf8.RuneCountInString(name)
+	if strings.TrimSpace(name) == "" || length > 128 {
+		return gerrors.NewError(gerrors.PublicBadRequest, "role exclusion name is illegal")
+	}
+	info, err := re.db.GetByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	if info.ID != "" && info.ID != id {
+		return gerrors.NewError(errors.RoleExclusionNameConflict, fmt.Sprintf("role exclusion %s already exists", name))
+	}
+	return nil
+}
+
+// checkRoleIDs 检查互斥的角色, 返回去重后的角色ID。角色必须存在且不能是系统角色, 至少两个角色
+func (re *roleExclusion) checkRoleIDs(ctx context.Context, roleIDs []string) (outIDs []string, err error) {
+	outIDs = make([]string, 0, len(roleIDs))
+	for _, id := range roleIDs {
+		if !slices.Contains(outIDs, id) {
+			outIDs = append(outIDs, id)
+		}
+	}
+	if len(outIDs) < minRoleExclusionRoles {
+		return nil, gerrors.NewError(gerrors.PublicBadRequest, "role exclusion requires at least two different roles")
+	}
+
+	roleInfoMap, err := re.roleDB.GetRoleByIDs(ctx, outIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range outIDs {
+		info, ok := roleInfoMap[id]
+		if !ok {
+			return nil, gerrors.NewError(gerrors.PublicBadRequest, fmt.Sprintf("role %s does not exist", id))
+		}
+		// 系统角色的成员由账户管理服务维护, 无法计算持有者
+		if info.RoleSource == interfaces.RoleSourceSystem {
+			return nil, gerrors.NewError(gerrors.PublicBadRequest, fmt.Sprintf("system role %s can not be exclusive", id))
+		}
+	}
+	return outIDs, nil
+}
+
+// Add 添加互斥角色集
+func (re *roleExclusion) Add(ctx context.Context, visitor *interfaces.Visitor, info *interfaces.RoleExclusionInfo) (id string, err error) {
+	err = re.checkVisitorType(ctx, visitor)
+	if err != nil {
+		return
+	}
+
+	err = re.checkName(ctx, "", info.Name)
+	if err != nil {
+		return
+	}
+	info.RoleIDs, err = re.checkRoleIDs(ctx, info.RoleIDs)
+	if err != nil {
+		return
+	}
+
+	info.ID = uuid.New().String()
+	err = re.db.Add(ctx, info)
+	if err != nil {
+		re.logger.Errorf("Add: %v", err)
+		return
+	}
+	return info.ID, nil
+}
+
+// Delete 删除互斥角色集
+func (re *roleExclusion) Delete(ctx context.Context, visitor *interfaces.Visitor, id string) (err error) {
+	err = re.checkVisitorType(ctx, visitor)
+	if err != nil {
+		return
+	}
+
+	err = re.db.Delete(ctx, id)
+	if err != nil {
+		re.logger.Errorf("Delete: %v", err)
+		return
+	}
+	return nil
+}
+
+// Update 更新互斥角色集
+func (re *roleExclusion) Update(ctx context.Context, visitor *interfaces.Visitor, id, name string, nameChanged bool,
+	description string, descriptionChanged bool, roleIDs []string, roleIDsChanged bool,
+) (err error) {
+	err = re.checkVisitorType(ctx, visitor)
+	if err != nil {
+		return
+	}
+
+	info, err := re.db.GetByID(ctx, id)
+	if err != nil {
+		return
+	}
+	if info.ID == "" {
+		return gerrors.NewError(errors.RoleExclusionNotFound, fmt.Sprintf("role exclusion %s not found", id))
+	}
+
+	if nameChanged {
+		err = re.checkName(ctx, id, name)
+		if err != nil {
+			return
+		}
+	}
+	if roleIDsChanged {
+		roleIDs, err = re.checkRoleIDs(ctx, roleIDs)
+		if err != nil {
+			return
+		}
+	}
+
+	err = re.db.Update(ctx, id, name, nameChanged, description, descriptionChanged, roleIDs, roleIDsChanged)
+	if err != nil {
+		re.logger.Errorf("Update: %v", err)
+		return
+	}
+	return nil
+}
+
+// GetByID 获取指定的互斥角色集
+func (re *roleExclusion) GetByID(ctx context.Context, visitor *interfaces.Visitor, id string) (info interfaces.RoleExclusionInfo, err error) {
+	err = re.checkVisitorType(ctx, visitor)
+	if err != nil {
+		return
+	}
+
+	info, err = re.db.GetByID(ctx, id)
+	if err != nil {
+		return
+	}
+	if info.ID == "" {
+		return info, gerrors.NewError(errors.RoleExclusionNotFound, fmt.Sprintf("role exclusion %s not found", id))
+	}
+
+	infos := []interfaces.RoleExclusionInfo{info}
+	err = re.fillRoles(ctx, infos)
+	if err != nil {
+		return
+	}
+	return infos[0], nil
+}
+
+// Get 互斥角色集列举
+func (re *roleExclusion) Get(ctx context.Context, visitor *interfaces.Visitor, info *interfaces.RoleExclusionSearchInfo) (count int, infos []interfaces.RoleExclusionInfo, err error) {
+	err = re.checkVisitorType(ctx, visitor)
+	if err != nil {
+		return
+	}
+
+	count, infos, err = re.db.Get(ctx, info)
+	if err != nil {
+		return
+	}
+	err = re.fillRoles(ctx, infos)
+	if err != nil {
+		return
+	}
+	return count, infos, nil
+}
+
+// fillRoles 填充角色名称
+func (re *roleExclusion) fillRoles(ctx context.Context, infos []interfaces.RoleExclusionInfo) (err error) {
+	roleIDs := make([]string, 0)
+	for i := range infos {
+		roleIDs = append(roleIDs, infos[i].RoleIDs...)
+	}
+	if len(roleIDs) == 0 {
+		return nil
+	}
+	roleInfoMap, err := re.roleDB.GetRoleByIDs(ctx, roleIDs)
+	if err != nil {
+		return err
+	}
+	for i := range infos {
+		infos[i].Roles = make([]interfaces.NameInfo, 0, len(infos[i].RoleIDs))
+		for _, id := range infos[i].RoleIDs {
+			infos[i].Roles = append(infos[i].Roles, interfaces.NameInfo{ID: id, Name: roleInfoMap[id].Name})
+		}
+	}
+	return nil
+}
+
+/*
+GetViolations 列举当前违反互斥角色集的访问者
+1. 只包含当前生效的成员关系
+2. 访问者为用户或应用账户, 一个访问者违反多个互斥角色集时每个返回一条
+*/
+func (re *roleExclusion) GetViolations(ctx context.Context, visitor *interfaces.Visitor) (violations []interfaces.RoleExclusionViolation, err error) {
+	err = re.checkVisitorType(ctx, visitor)
+	if err != nil {
+		return
+	}
+
+	exclusions, err := re.db.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	violations = make([]interfaces.RoleExclusionViolation, 0)
+	if len(exclusions) == 0 {
+		return violations, nil
+	}
+
+	curTime := common.GetCurrentMicrosecondTimestamp()
+	roleHolders := make(map[string]map[string]interfaces.AccessorType)
+	roleIDs := make([]string, 0)
+	accessorTypes := make(map[string]interfaces.AccessorType)
+	for i := range exclusions {
+		// 访问者拥有的互斥角色, 按集合中角色的顺序
+		accessorRoles := make(map[string][]string)
+		accessorIDs := make([]string, 0)
+		for _, roleID := range exclusions[i].RoleIDs {
+			holders, ok := roleHolders[roleID]
+			if !ok {
+				holders, err = re.holders.roleHolders(ctx, roleID, curTime)
+				if err != nil {
+					return nil, err
+				}
+				roleHolders[roleID] = holders
+				roleIDs = append(roleIDs, roleID)
+			}
+			for accessorID := range holders {
+				if _, ok := accessorRoles[accessorID]; !ok {
+					accessorIDs = append(accessorIDs, accessorID)
+				}
+				accessorRoles[accessorID] = append(accessorRoles[accessorID], roleID)
+			}
+		}
+
+		slices.Sort(accessorIDs)
+		for _, accessorID := range accessorIDs {
+			if len(accessorRoles[accessorID]) < minRoleExclusionRoles {
+				continue
+			}
+			roles := make([]interfaces.NameInfo, 0, len(accessorRoles[accessorID]))
+			for _, roleID := range accessorRoles[accessorID] {
+				roles = append(roles, interfaces.NameInfo{ID: roleID})
+			}
+			for _, holders := range roleHolders {
+				if accessorType, ok := holders[accessorID]; ok {
+					accessorTypes[accessorID] = accessorType
+					break
+				}
+			}
+			violations = append(violations, interfaces.RoleExclusionViolation{
+				Exclusion:    interfaces.NameInfo{ID: exclusions[i].ID, Name: exclusions[i].Name},
+				AccessorID:   accessorID,
+				AccessorType: accessorTypes[accessorID],
+				Roles:        roles,
+			})
+		}
+	}
+	if len(violations) == 0 {
+		return violations, nil
+	}
+
+	// 填充角色和访问者名称
+	roleInfoMap, err := re.roleDB.GetRoleByIDs(ctx, roleIDs)
+	if err != nil {
+		return nil, err
+	}
+	accessorNames, err := re.userMgnt.GetNameByAccessorIDs(ctx, accessorTypes)
+	if err != nil {
+		return nil, err
+	}
+	for i := range violations {
+		violations[i].AccessorName = accessorNames[violations[i].AccessorID]
+		for j := range violations[i].Roles {
+			violations[i].Roles[j].Name = roleInfoMap[violations[i].Roles[j].ID].Name
+		}
+	}
+	return violations, nil
+}
+
+// roleDeleted 角色删除, 从互斥角色集中移除该角色, 剩余角色不足两个时删除互斥角色集
+func (re *roleExclusion) roleDeleted(roleID string) (err error) {
+	ctx := context.Background()
+	exclusions, err := re.db.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range exclusions {
+		if !slices.Contains(exclusions[i].RoleIDs, roleID) {
+			continue
+		}
+		roleIDs := slices.DeleteFunc(slices.Clone(exclusions[i].RoleIDs), func(id string) bool { return id == roleID })
+		if len(roleIDs) < minRoleExclusionRoles {
+			err = re.db.Delete(ctx, exclusions[i].ID)
+		} else {
+			err = re.db.Update(ctx, exclusions[i].ID, "", false, "", false, roleIDs, true)
+		}
+		if err != nil {
+			re.logger.Errorf("roleDeleted exclusion: %s, err: %v", exclusions[i].ID, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// roleHolderResolver 解析角色的持有者, 持有者为用户或应用账户
+type roleHolderResolver struct {
+	roleMemberDB interfaces.DBRoleMember
+	userMgnt     interfaces.DrivenUserMgnt
+}
+
+func newRoleHolderResolver(roleMemberDB interfaces.DBRoleMember, userMgnt interfaces.DrivenUserMgnt) *roleHolderResolver {
+	return &roleHolderResolver{
+		roleMemberDB: roleMemberDB,
+		userMgnt:     userMgnt,
+	}
+}
+
+// roleHolders 获取拥有角色的用户和应用账户, curTime 大于 0 时只包含 curTime 生效中的成员关系
+func (h *roleHolderResolver) roleHolders(ctx context.Context, roleID string, curTime int64) (holders map[string]interfaces.AccessorType, err error) {
+	members, err := h.expandRoleMembers(ctx, []string{roleID}, curTime)
+	if err != nil {
+		return nil, err
+	}
+	return h.memberHolders(ctx, members, curTime)
+}
+
+// expandRoleMembers 获取角色以及作为其成员的角色的成员, 结果不包含角色类型的成员
+func (h *roleHolderResolver) expandRoleMembers(ctx context.Context, roleIDs []string, curTime int64) (members []interfaces.RoleMemberInfo, err error) {
+	visited := make(map[string]bool, len(roleIDs))
+	queue := make([]string, 0, len(roleIDs))
+	for _, id := range roleIDs {
+		if !visited[id] {
+			visited[id] = true
+			queue = append(queue, id)
+		}
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		var directMembers []interfaces.RoleMemberInfo
+		directMembers, err = h.roleMemberDB.GetRoleMembersByRoleID(ctx, current)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range directMembers {
+			if curTime > 0 && !isRoleMemberActive(&member, curTime) {
+				continue
+			}
+			if member.MemberType != interfaces.AccessorRole {
+				members = append(members, member)
+				continue
+			}
+			if !visited[member.ID] {
+				visited[member.ID] = true
+				queue = append(queue, member.ID)
+			}
+		}
+	}
+	return members, nil
+}
+
+// memberHolders 获取成员对应的用户和应用账户
+// 部门展开为部门及子部门下的用户, 用户组展开为组内用户和组内部门下的用户, 角色展开为角色的成员
+func (h *roleHolderResolver) memberHolders(ctx context.Context, members []interfaces.RoleMemberInfo, curTime int64) (holders map[string]interfaces.AccessorType, err error) {
+	holders = make(map[string]interfaces.AccessorType)
+	depIDs := make([]string, 0)
+	groupIDs := make([]string, 0)
+	roleIDs := make([]string, 0)
+	for i := range members {
+		switch members[i].MemberType {
+		case interfaces.AccessorUser, interfaces.AccessorApp:
+			holders[members[i].ID] = members[i].MemberType
+		case interfaces.AccessorDepartment:
+			depIDs = append(depIDs, members[i].ID)
+		case interfaces.AccessorGroup:
+			groupIDs = append(groupIDs, members[i].ID)
+		case interfaces.AccessorRole:
+			roleIDs = append(roleIDs, members[i].ID)
+		}
+	}
+
+	if len(roleIDs) > 0 {
+		var roleMembers []interfaces.RoleMemberInfo
+		roleMembers, err = h.expandRoleMembers(ctx, roleIDs, curTime)
+		if err != nil {
+			return nil, err
+		}
+		var roleHolders map[string]interfaces.AccessorType
+		roleHolders, err = h.memberHolders(ctx, roleMembers, curTime)
+		if err != nil {
+			return nil, err
+		}
+		for id, accessorType := range roleHolders {
+			holders[id] = accessorType
+		}
+	}
+
+	if len(groupIDs) > 0 {
+		var userIDs, groupDepIDs []string
+		userIDs, groupDepIDs, err = h.userMgnt.GetGroupMemberIDs(ctx, groupIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range userIDs {
+			holders[id] = interfaces.AccessorUser
+		}
+		depIDs = append(depIDs, groupDepIDs...)
+	}
+
+	visitedDeps := make(map[string]bool, len(depIDs))
+	for _, depID := range depIDs {
+		if visitedDeps[depID] {
+			continue
+		}
+		visitedDeps[depID] = true
+		var userIDs []string
+		userIDs, err = h.userMgnt.GetDepartmentAllUserIDs(ctx, depID)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range userIDs {
+			holders[id] = interfaces.AccessorUser
+		}
+	}
+	return holders, nil
+}
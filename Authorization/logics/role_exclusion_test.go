@@ -0,0 +1,214 @@
+package logics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	gerrors "github.com/kweaver-ai/go-lib/error"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"Authorization/common"
+	autherrors "Authorization/error"
+	"Authorization/interfaces"
+	"Authorization/interfaces/mock"
+)
+
+func newRoleExclusionForTest(db interfaces.DBRoleExclusion, roleDB interfaces.DBRole,
+	roleMemberDB interfaces.DBRoleMember, userMgnt interfaces.DrivenUserMgnt,
+) *roleExclusion {
+	return &roleExclusion{
+		db:       db,
+		roleDB:   roleDB,
+		userMgnt: userMgnt,
+		holders:  newRoleHolderResolver(roleMemberDB, userMgnt),
+		logger:   common.NewLogger(),
+	}
+}
+
+func TestRoleExclusion_Add(t *testing.T) {
+	Convey("测试Add方法", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBRoleExclusion(ctrl)
+		roleDB := mock.NewMockDBRole(ctrl)
+		roleMemberDB := mock.NewMockDBRoleMember(ctrl)
+		userMgnt := mock.NewMockDrivenUserMgnt(ctrl)
+		re := newRoleExclusionForTest(db, roleDB, roleMemberDB, userMgnt)
+
+		ctx := context.Background()
+		visitor := &interfaces.Visitor{ID: "user1", Type: interfaces.RealName}
+
+		Convey("权限检查失败", func() {
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), "user1").Return([]interfaces.SystemRoleType{interfaces.NormalUser}, nil)
+			_, err := re.Add(ctx, visitor, &interfaces.RoleExclusionInfo{Name: "ex", RoleIDs: []string{"role1", "role2"}})
+			assert.Error(t, err)
+		})
+
+		userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), "user1").Return([]interfaces.SystemRoleType{interfaces.SecurityAdmin}, nil).AnyTimes()
+
+		Convey("名称重复", func() {
+			db.EXPECT().GetByName(gomock.Any(), "ex").Return(interfaces.RoleExclusionInfo{ID: "ex0"}, nil)
+			_, err := re.Add(ctx, visitor, &interfaces.RoleExclusionInfo{Name: "ex", RoleIDs: []string{"role1", "role2"}})
+			assert.Equal(t, autherrors.RoleExclusionNameConflict, err.(*gerrors.Error).Code)
+		})
+
+		Convey("去重后角色不足两个", func() {
+			db.EXPECT().GetByName(gomock.Any(), "ex").Return(interfaces.RoleExclusionInfo{}, nil)
+			_, err := re.Add(ctx, visitor, &interfaces.RoleExclusionInfo{Name: "ex", RoleIDs: []string{"role1", "role1"}})
+			assert.Equal(t, gerrors.PublicBadRequest, err.(*gerrors.Error).Code)
+		})
+
+		Convey("角色不存在", func() {
+			db.EXPECT().GetByName(gomock.Any(), "ex").Return(interfaces.RoleExclusionInfo{}, nil)
+			roleDB.EXPECT().GetRoleByIDs(gomock.Any(), []string{"role1", "role2"}).Return(map[string]interfaces.RoleInfo{
+				"role1": {ID: "role1", RoleSource: interfaces.RoleSourceUser},
+			}, nil)
+			_, err := re.Add(ctx, visitor, &interfaces.RoleExclusionInfo{Name: "ex", RoleIDs: []string{"role1", "role2"}})
+			assert.Equal(t, gerrors.PublicBadRequest, err.(*gerrors.Error).Code)
+		})
+
+		Convey("系统角色不能互斥", func() {
+			db.EXPECT().GetByName(gomock.Any(), "ex").Return(interfaces.RoleExclusionInfo{}, nil)
+			roleDB.EXPECT().GetRoleByIDs(gomock.Any(), []string{"role1", "role2"}).Return(map[string]interfaces.RoleInfo{
+				"role1": {ID: "role1", RoleSource: interfaces.RoleSourceUser},
+				"role2": {ID: "role2", RoleSource: interfaces.RoleSourceSystem},
+			}, nil)
+			_, err := re.Add(ctx, visitor, &interfaces.RoleExclusionInfo{Name: "ex", RoleIDs: []string{"role1", "role2"}})
+			assert.Equal(t, gerrors.PublicBadRequest, err.(*gerrors.Error).Code)
+		})
+
+		Convey("添加成功", func() {
+			db.EXPECT().GetByName(gomock.Any(), "ex").Return(interfaces.RoleExclusionInfo{}, nil)
+			roleDB.EXPECT().GetRoleByIDs(gomock.Any(), []string{"role1", "role2"}).Return(map[string]interfaces.RoleInfo{
+				"role1": {ID: "role1", RoleSource: interfaces.RoleSourceUser},
+				"role2": {ID: "role2", RoleSource: interfaces.RoleSourceBusiness},
+			}, nil)
+			db.EXPECT().Add(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, info *interfaces.RoleExclusionInfo) error {
+				assert.Equal(t, info.RoleIDs, []string{"role1", "role2"})
+				return nil
+			})
+			id, err := re.Add(ctx, visitor, &interfaces.RoleExclusionInfo{Name: "ex", RoleIDs: []string{"role1", "role2", "role1"}})
+			assert.NoError(t, err)
+			assert.NotEmpty(t, id)
+		})
+	})
+}
+
+func TestRoleExclusion_Update(t *testing.T) {
+	Convey("测试Update方法", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBRoleExclusion(ctrl)
+		roleDB := mock.NewMockDBRole(ctrl)
+		roleMemberDB := mock.NewMockDBRoleMember(ctrl)
+		userMgnt := mock.NewMockDrivenUserMgnt(ctrl)
+		re := newRoleExclusionForTest(db, roleDB, roleMemberDB, userMgnt)
+
+		ctx := context.Background()
+		visitor := &interfaces.Visitor{ID: "user1", Type: interfaces.RealName}
+		userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), "user1").Return([]interfaces.SystemRoleType{interfaces.SuperAdmin}, nil)
+
+		Convey("互斥角色集不存在", func() {
+			db.EXPECT().GetByID(gomock.Any(), "ex1").Return(interfaces.RoleExclusionInfo{}, nil)
+			err := re.Update(ctx, visitor, "ex1", "", false, "desc", true, nil, false)
+			assert.Equal(t, autherrors.RoleExclusionNotFound, err.(*gerrors.Error).Code)
+		})
+
+		Convey("同名为自身时可以更新", func() {
+			db.EXPECT().GetByID(gomock.Any(), "ex1").Return(interfaces.RoleExclusionInfo{ID: "ex1"}, nil)
+			db.EXPECT().GetByName(gomock.Any(), "ex").Return(interfaces.RoleExclusionInfo{ID: "ex1"}, nil)
+			db.EXPECT().Update(gomock.Any(), "ex1", "ex", true, "", false, nil, false).Return(nil)
+			err := re.Update(ctx, visitor, "ex1", "ex", true, "", false, nil, false)
+			assert.NoError(t, err)
+		})
+	})
+}
+
+func TestRoleExclusion_GetViolations(t *testing.T) {
+	Convey("测试GetViolations方法", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBRoleExclusion(ctrl)
+		roleDB := mock.NewMockDBRole(ctrl)
+		roleMemberDB := mock.NewMockDBRoleMember(ctrl)
+		userMgnt := mock.NewMockDrivenUserMgnt(ctrl)
+		re := newRoleExclusionForTest(db, roleDB, roleMemberDB, userMgnt)
+
+		ctx := context.Background()
+		visitor := &interfaces.Visitor{ID: "user1", Type: interfaces.RealName}
+		userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), "user1").Return([]interfaces.SystemRoleType{interfaces.SuperAdmin}, nil)
+		exclusion := interfaces.RoleExclusionInfo{ID: "ex1", Name: "ex", RoleIDs: []string{"role1", "role2"}}
+
+		Convey("获取互斥角色集失败", func() {
+			db.EXPECT().GetAll(gomock.Any()).Return(nil, errors.New("db error"))
+			_, err := re.GetViolations(ctx, visitor)
+			assert.Error(t, err)
+		})
+
+		Convey("通过部门、用户组和继承角色获得互斥角色", func() {
+			db.EXPECT().GetAll(gomock.Any()).Return([]interfaces.RoleExclusionInfo{exclusion}, nil)
+			// role1: 部门dep1, 用户user3(已过期)
+			roleMemberDB.EXPECT().GetRoleMembersByRoleID(gomock.Any(), "role1").Return([]interfaces.RoleMemberInfo{
+				{ID: "dep1", MemberType: interfaces.AccessorDepartment, EndTime: -1},
+				{ID: "user3", MemberType: interfaces.AccessorUser, EndTime: 1},
+			}, nil)
+			userMgnt.EXPECT().GetDepartmentAllUserIDs(gomock.Any(), "dep1").Return([]string{"user2", "user4"}, nil)
+			// role2: 角色role3, role3的成员为用户组group1
+			roleMemberDB.EXPECT().GetRoleMembersByRoleID(gomock.Any(), "role2").Return([]interfaces.RoleMemberInfo{
+				{ID: "role3", MemberType: interfaces.AccessorRole, EndTime: -1},
+				{ID: "user3", MemberType: interfaces.AccessorUser, EndTime: -1},
+			}, nil)
+			roleMemberDB.EXPECT().GetRoleMembersByRoleID(gomock.Any(), "role3").Return([]interfaces.RoleMemberInfo{
+				{ID: "group1", MemberType: interfaces.AccessorGroup, EndTime: -1},
+			}, nil)
+			userMgnt.EXPECT().GetGroupMemberIDs(gomock.Any(), []string{"group1"}).Return([]string{"user2"}, []string{}, nil)
+
+			roleDB.EXPECT().GetRoleByIDs(gomock.Any(), []string{"role1", "role2"}).Return(map[string]interfaces.RoleInfo{
+				"role1": {ID: "role1", Name: "角色1"},
+				"role2": {ID: "role2", Name: "角色2"},
+			}, nil)
+			userMgnt.EXPECT().GetNameByAccessorIDs(gomock.Any(), map[string]interfaces.AccessorType{"user2": interfaces.AccessorUser}).
+				Return(map[string]string{"user2": "用户2"}, nil)
+
+			violations, err := re.GetViolations(ctx, visitor)
+			assert.NoError(t, err)
+			assert.Equal(t, violations, []interfaces.RoleExclusionViolation{
+				{
+					Exclusion:    interfaces.NameInfo{ID: "ex1", Name: "ex"},
+					AccessorID:   "user2",
+					AccessorType: interfaces.AccessorUser,
+					AccessorName: "用户2",
+					Roles:        []interfaces.NameInfo{{ID: "role1", Name: "角色1"}, {ID: "role2", Name: "角色2"}},
+				},
+			})
+		})
+	})
+}
+
+func TestRoleExclusion_roleDeleted(t *testing.T) {
+	Convey("测试roleDeleted方法", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db := mock.NewMockDBRoleExclusion(ctrl)
+		re := newRoleExclusionForTest(db, nil, nil, nil)
+
+		Convey("移除角色, 不足两个时删除", func() {
+			db.EXPECT().GetAll(gomock.Any()).Return([]interfaces.RoleExclusionInfo{
+				{ID: "ex1", RoleIDs: []string{"role1", "role2"}},
+				{ID: "ex2", RoleIDs: []string{"role1", "role2", "role3"}},
+				{ID: "ex3", RoleIDs: []string{"role2", "role3"}},
+			}, nil)
+			db.EXPECT().Delete(gomock.Any(), "ex1").Return(nil)
+			db.EXPECT().Update(gomock.Any(), "ex2", "", false, "", false, []string{"role2", "role3"}, true).Return(nil)
+			err := re.roleDeleted("role1")
+			assert.NoError(t, err)
+		})
+	})
+}
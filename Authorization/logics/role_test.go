@@ -7,11 +7,13 @@ import (
 	"strings"
 	"testing"
 
+	gerrors "github.com/kweaver-ai/go-lib/error"
 	. "github.com/smartystreets/goconvey/convey"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 
 	"Authorization/common"
+	autherrors "Authorization/error"
 	"Authorization/interfaces"
 	"Authorization/interfaces/mock"
 )
@@ -30,6 +32,7 @@ func newRole(
 	return &role{
 		roleDB:       roleDB,
 		roleMemberDB: roleMemberDB,
+		holders:      newRoleHolderResolver(roleMemberDB, userMgnt),
 		userMgnt:     userMgnt,
 		logger:       logger, // 可mock logger
 		event:        event,  // 可mock event
@@ -223,12 +226,14 @@ func TestRole_AddRoleMembers(t *testing.T) {
 		logger := common.NewLogger()
 		event := mock.NewMockLogicsEvent(ctrl)
 		r := newRole(roleDB, roleMemberDB, userMgnt, logger, event)
+		exclusionDB := mock.NewMockDBRoleExclusion(ctrl)
+		r.exclusionDB = exclusionDB
 
 		ctx := context.Background()
 		visitor := &interfaces.Visitor{ID: "user1", Type: interfaces.RealName}
 		roleID := roleTmpID
 		roleInfo := interfaces.RoleInfo{ID: roleID}
-		memberInfo := interfaces.RoleMemberInfo{ID: "member1", MemberType: interfaces.AccessorUser}
+		memberInfo := interfaces.RoleMemberInfo{ID: "member1", MemberType: interfaces.AccessorUser, EndTime: -1}
 		infos := map[string]interfaces.RoleMemberInfo{"member1": memberInfo}
 
 		Convey("权限检查失败", func() {
@@ -249,10 +254,141 @@ func TestRole_AddRoleMembers(t *testing.T) {
 			roleDB.EXPECT().GetRoleByID(gomock.Any(), roleID).Return(roleInfo, nil)
 			roleMemberDB.EXPECT().GetRoleMembersByRoleID(gomock.Any(), roleID).Return([]interfaces.RoleMemberInfo{}, nil)
 			userMgnt.EXPECT().GetNameByAccessorIDs(gomock.Any(), gomock.Any()).Return(map[string]string{"member1": "name1"}, nil)
+			exclusionDB.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
 			roleMemberDB.EXPECT().AddRoleMembers(gomock.Any(), roleID, gomock.Any()).Return(nil)
 			err := r.AddRoleMembers(ctx, visitor, roleID, infos)
 			assert.NoError(t, err)
 		})
+
+		Convey("违反互斥角色集", func() {
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), "user1").Return([]interfaces.SystemRoleType{interfaces.SuperAdmin}, nil)
+			roleDB.EXPECT().GetRoleByID(gomock.Any(), roleID).Return(roleInfo, nil)
+			roleMemberDB.EXPECT().GetRoleMembersByRoleID(gomock.Any(), roleID).Return([]interfaces.RoleMemberInfo{}, nil)
+			userMgnt.EXPECT().GetNameByAccessorIDs(gomock.Any(), gomock.Any()).Return(map[string]string{"member1": "name1"}, nil)
+
+			Convey("成员已通过部门拥有互斥角色", func() {
+				exclusionDB.EXPECT().GetAll(gomock.Any()).Return([]interfaces.RoleExclusionInfo{
+					{ID: "ex1", Name: "ex", RoleIDs: []string{roleID, "role2"}},
+				}, nil)
+				roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), []string{roleID}, int64(0)).Return(nil, nil)
+				roleMemberDB.EXPECT().GetRoleMembersByRoleID(gomock.Any(), "role2").Return([]interfaces.RoleMemberInfo{
+					{ID: "dep1", MemberType: interfaces.AccessorDepartment, EndTime: -1},
+				}, nil)
+				userMgnt.EXPECT().GetDepartmentAllUserIDs(gomock.Any(), "dep1").Return([]string{"member1"}, nil)
+				err := r.AddRoleMembers(ctx, visitor, roleID, infos)
+				assert.Equal(t, autherrors.RoleExclusionViolated, err.(*gerrors.Error).Code)
+			})
+
+			Convey("继承的角色与当前角色互斥", func() {
+				exclusionDB.EXPECT().GetAll(gomock.Any()).Return([]interfaces.RoleExclusionInfo{
+					{ID: "ex1", Name: "ex", RoleIDs: []string{roleID, "role3"}},
+				}, nil)
+				roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), []string{roleID}, int64(0)).Return([]interfaces.RoleInfo{{ID: "role3"}}, nil)
+				roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), []string{"role3"}, int64(0)).Return(nil, nil)
+				err := r.AddRoleMembers(ctx, visitor, roleID, infos)
+				assert.Equal(t, autherrors.RoleExclusionViolated, err.(*gerrors.Error).Code)
+			})
+
+			Convey("无关的互斥角色集", func() {
+				exclusionDB.EXPECT().GetAll(gomock.Any()).Return([]interfaces.RoleExclusionInfo{
+					{ID: "ex1", Name: "ex", RoleIDs: []string{"role2", "role3"}},
+				}, nil)
+				roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), []string{roleID}, int64(0)).Return(nil, nil)
+				roleMemberDB.EXPECT().AddRoleMembers(gomock.Any(), roleID, gomock.Any()).Return(nil)
+				err := r.AddRoleMembers(ctx, visitor, roleID, infos)
+				assert.NoError(t, err)
+			})
+		})
+
+		Convey("成员有效期", func() {
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), "user1").Return([]interfaces.SystemRoleType{interfaces.SuperAdmin}, nil)
+			roleDB.EXPECT().GetRoleByID(gomock.Any(), roleID).Return(roleInfo, nil)
+			roleMemberDB.EXPECT().GetRoleMembersByRoleID(gomock.Any(), roleID).Return([]interfaces.RoleMemberInfo{}, nil)
+			curTime := common.GetCurrentMicrosecondTimestamp()
+
+			Convey("过期时间早于当前时间", func() {
+				expired := map[string]interfaces.RoleMemberInfo{"member1": {ID: "member1", MemberType: interfaces.AccessorUser, EndTime: curTime - 1000}}
+				err := r.AddRoleMembers(ctx, visitor, roleID, expired)
+				assert.Error(t, err)
+			})
+
+			Convey("生效时间晚于过期时间", func() {
+				invalid := map[string]interfaces.RoleMemberInfo{
+					"member1": {ID: "member1", MemberType: interfaces.AccessorUser, StartTime: curTime + 2000000, EndTime: curTime + 1000000},
+				}
+				err := r.AddRoleMembers(ctx, visitor, roleID, invalid)
+				assert.Error(t, err)
+			})
+
+			Convey("添加临时成员成功", func() {
+				temporary := map[string]interfaces.RoleMemberInfo{
+					"member1": {ID: "member1", MemberType: interfaces.AccessorUser, StartTime: curTime, EndTime: curTime + 1000000},
+				}
+				userMgnt.EXPECT().GetNameByAccessorIDs(gomock.Any(), gomock.Any()).Return(map[string]string{"member1": "name1"}, nil)
+				exclusionDB.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
+				roleMemberDB.EXPECT().AddRoleMembers(gomock.Any(), roleID, []interfaces.RoleMemberInfo{
+					{ID: "member1", MemberType: interfaces.AccessorUser, Name: "name1", StartTime: curTime, EndTime: curTime + 1000000},
+				}).Return(nil)
+				err := r.AddRoleMembers(ctx, visitor, roleID, temporary)
+				assert.NoError(t, err)
+			})
+		})
+
+		Convey("添加角色成员", func() {
+			roleInfos := map[string]interfaces.RoleMemberInfo{"role2": {ID: "role2", MemberType: interfaces.AccessorRole, EndTime: -1}}
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), "user1").Return([]interfaces.SystemRoleType{interfaces.SuperAdmin}, nil)
+			roleDB.EXPECT().GetRoleByID(gomock.Any(), roleID).Return(roleInfo, nil)
+			roleMemberDB.EXPECT().GetRoleMembersByRoleID(gomock.Any(), roleID).Return([]interfaces.RoleMemberInfo{}, nil)
+
+			Convey("角色不存在", func() {
+				roleDB.EXPECT().GetRoleByIDs(gomock.Any(), []string{"role2"}).Return(map[string]interfaces.RoleInfo{}, nil)
+				err := r.AddRoleMembers(ctx, visitor, roleID, roleInfos)
+				assert.Error(t, err)
+			})
+
+			Convey("系统角色不能作为成员", func() {
+				roleDB.EXPECT().GetRoleByIDs(gomock.Any(), []string{"role2"}).Return(map[string]interfaces.RoleInfo{
+					"role2": {ID: "role2", RoleSource: interfaces.RoleSourceSystem},
+				}, nil)
+				err := r.AddRoleMembers(ctx, visitor, roleID, roleInfos)
+				assert.Error(t, err)
+			})
+
+			Convey("角色不能作为自身成员", func() {
+				selfInfos := map[string]interfaces.RoleMemberInfo{roleID: {ID: roleID, MemberType: interfaces.AccessorRole, EndTime: -1}}
+				roleDB.EXPECT().GetRoleByIDs(gomock.Any(), []string{roleID}).Return(map[string]interfaces.RoleInfo{
+					roleID: {ID: roleID, RoleSource: interfaces.RoleSourceUser},
+				}, nil)
+				roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), []string{roleID}, int64(0)).Return(nil, nil)
+				err := r.AddRoleMembers(ctx, visitor, roleID, selfInfos)
+				assert.Error(t, err)
+			})
+
+			Convey("继承形成循环", func() {
+				roleDB.EXPECT().GetRoleByIDs(gomock.Any(), []string{"role2"}).Return(map[string]interfaces.RoleInfo{
+					"role2": {ID: "role2", Name: "角色2", RoleSource: interfaces.RoleSourceUser},
+				}, nil)
+				// role1 已经是 role3 的成员, role3 是 role2 的成员
+				roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), []string{roleID}, int64(0)).Return([]interfaces.RoleInfo{{ID: "role3"}}, nil)
+				roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), []string{"role3"}, int64(0)).Return([]interfaces.RoleInfo{{ID: "role2"}}, nil)
+				roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), []string{"role2"}, int64(0)).Return(nil, nil)
+				err := r.AddRoleMembers(ctx, visitor, roleID, roleInfos)
+				assert.Error(t, err)
+			})
+
+			Convey("添加成功, 使用角色名称", func() {
+				roleDB.EXPECT().GetRoleByIDs(gomock.Any(), []string{"role2"}).Return(map[string]interfaces.RoleInfo{
+					"role2": {ID: "role2", Name: "角色2", RoleSource: interfaces.RoleSourceUser},
+				}, nil)
+				roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), []string{roleID}, int64(0)).Return(nil, nil)
+				exclusionDB.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
+				roleMemberDB.EXPECT().AddRoleMembers(gomock.Any(), roleID, []interfaces.RoleMemberInfo{
+					{ID: "role2", MemberType: interfaces.AccessorRole, Name: "角色2", EndTime: -1},
+				}).Return(nil)
+				err := r.AddRoleMembers(ctx, visitor, roleID, roleInfos)
+				assert.NoError(t, err)
+			})
+		})
 	})
 }
 
@@ -352,6 +488,81 @@ func TestRole_GetRoleMembers(t *testing.T) {
 	})
 }
 
+func TestRole_GetRoleEffectiveMembers(t *testing.T) {
+	Convey("测试GetRoleEffectiveMembers方法", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		roleDB := mock.NewMockDBRole(ctrl)
+		roleMemberDB := mock.NewMockDBRoleMember(ctrl)
+		userMgnt := mock.NewMockDrivenUserMgnt(ctrl)
+		logger := common.NewLogger()
+		event := mock.NewMockLogicsEvent(ctrl)
+		r := newRole(roleDB, roleMemberDB, userMgnt, logger, event)
+
+		ctx := context.Background()
+		visitor := &interfaces.Visitor{ID: "user1", Type: interfaces.RealName}
+		roleID := roleTmpID
+		roleInfo := interfaces.RoleInfo{ID: roleID, Name: "角色1"}
+		searchInfo := interfaces.RoleMemberSearchInfo{Offset: 0, Limit: 10}
+
+		Convey("角色不存在", func() {
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), "user1").Return([]interfaces.SystemRoleType{interfaces.SuperAdmin}, nil)
+			roleDB.EXPECT().GetRoleByID(gomock.Any(), roleID).Return(interfaces.RoleInfo{}, nil)
+			_, _, err := r.GetRoleEffectiveMembers(ctx, visitor, roleID, searchInfo)
+			assert.Error(t, err)
+		})
+
+		Convey("展开角色成员", func() {
+			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), "user1").Return([]interfaces.SystemRoleType{interfaces.SuperAdmin}, nil)
+			roleDB.EXPECT().GetRoleByID(gomock.Any(), roleID).Return(roleInfo, nil)
+			curTime := common.GetCurrentMicrosecondTimestamp()
+			roleMemberDB.EXPECT().GetRoleMembersByRoleID(gomock.Any(), roleID).Return([]interfaces.RoleMemberInfo{
+				{ID: "user_a", MemberType: interfaces.AccessorUser, Name: "A", EndTime: -1},
+				{ID: "role2", MemberType: interfaces.AccessorRole, Name: "角色2", EndTime: -1},
+				// 未生效和已过期的成员不返回
+				{ID: "user_c", MemberType: interfaces.AccessorUser, Name: "C", StartTime: curTime + 1000000, EndTime: -1},
+				{ID: "role3", MemberType: interfaces.AccessorRole, Name: "角色3", EndTime: curTime - 1000000},
+			}, nil)
+			// role2 的成员中包含 role1, 循环不重复展开
+			roleMemberDB.EXPECT().GetRoleMembersByRoleID(gomock.Any(), "role2").Return([]interfaces.RoleMemberInfo{
+				{ID: "user_a", MemberType: interfaces.AccessorUser, Name: "A", EndTime: -1},
+				{ID: "app_b", MemberType: interfaces.AccessorApp, Name: "B", EndTime: curTime + 1000000},
+				{ID: roleID, MemberType: interfaces.AccessorRole, Name: "角色1", EndTime: -1},
+			}, nil)
+
+			Convey("返回全部有效成员", func() {
+				userMgnt.EXPECT().BatchGetUserInfoByID(gomock.Any(), []string{"user_a"}).Return(map[string]interfaces.UserInfo{"user_a": {ParentDeps: [][]interfaces.Department{}}}, nil)
+				num, infos, err := r.GetRoleEffectiveMembers(ctx, visitor, roleID, searchInfo)
+				assert.NoError(t, err)
+				assert.Equal(t, 2, num)
+				assert.Equal(t, "user_a", infos[0].ID)
+				assert.Equal(t, []interfaces.NameInfo{{ID: roleID, Name: "角色1"}, {ID: "role2", Name: "角色2"}}, infos[0].ViaRoles)
+				assert.Equal(t, "app_b", infos[1].ID)
+				assert.Equal(t, []interfaces.NameInfo{{ID: "role2", Name: "角色2"}}, infos[1].ViaRoles)
+			})
+
+			Convey("按类型过滤并分页", func() {
+				info := interfaces.RoleMemberSearchInfo{Offset: 0, Limit: 1, MemberTypes: []interfaces.AccessorType{interfaces.AccessorApp}}
+				userMgnt.EXPECT().BatchGetUserInfoByID(gomock.Any(), []string{}).Return(map[string]interfaces.UserInfo{}, nil)
+				num, infos, err := r.GetRoleEffectiveMembers(ctx, visitor, roleID, info)
+				assert.NoError(t, err)
+				assert.Equal(t, 1, num)
+				assert.Equal(t, 1, len(infos))
+				assert.Equal(t, "app_b", infos[0].ID)
+			})
+
+			Convey("偏移超出总数", func() {
+				info := interfaces.RoleMemberSearchInfo{Offset: 5, Limit: 10}
+				num, infos, err := r.GetRoleEffectiveMembers(ctx, visitor, roleID, info)
+				assert.NoError(t, err)
+				assert.Equal(t, 2, num)
+				assert.Equal(t, 0, len(infos))
+			})
+		})
+	})
+}
+
 func TestRole_checkName(t *testing.T) {
 	Convey("测试checkName方法", t, func() {
 		ctrl := gomock.NewController(t)
@@ -492,17 +703,20 @@ func TestRole_AddOrDeleteRoleMemebers(t *testing.T) {
 		logger := common.NewLogger()
 		event := mock.NewMockLogicsEvent(ctrl)
 		r := newRole(roleDB, roleMemberDB, userMgnt, logger, event)
+		exclusionDB := mock.NewMockDBRoleExclusion(ctrl)
+		r.exclusionDB = exclusionDB
 
 		ctx := context.Background()
 		visitor := &interfaces.Visitor{ID: "user1", Type: interfaces.RealName}
 		roleID := roleTmpID
-		infos := map[string]interfaces.RoleMemberInfo{"member1": {ID: "member1"}}
+		infos := map[string]interfaces.RoleMemberInfo{"member1": {ID: "member1", EndTime: -1}}
 
 		Convey("POST方法 - 添加成员", func() {
 			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), "user1").Return([]interfaces.SystemRoleType{interfaces.SuperAdmin}, nil)
 			roleDB.EXPECT().GetRoleByID(gomock.Any(), roleID).Return(interfaces.RoleInfo{ID: roleID}, nil)
 			roleMemberDB.EXPECT().GetRoleMembersByRoleID(gomock.Any(), roleID).Return([]interfaces.RoleMemberInfo{}, nil)
 			userMgnt.EXPECT().GetNameByAccessorIDs(gomock.Any(), gomock.Any()).Return(map[string]string{"member1": "name1"}, nil)
+			exclusionDB.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
 			roleMemberDB.EXPECT().AddRoleMembers(gomock.Any(), roleID, gomock.Any()).Return(nil)
 			err := r.AddOrDeleteRoleMemebers(ctx, visitor, "POST", roleID, infos)
 			assert.NoError(t, err)
@@ -540,14 +754,32 @@ func TestRole_GetRoleByMembers(t *testing.T) {
 
 		Convey("获取角色成功", func() {
 			expectedRoles := []interfaces.RoleInfo{{ID: "role1"}, {ID: "role2"}}
-			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), memberIDs).Return(expectedRoles, nil)
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), memberIDs, gomock.Any()).Return(expectedRoles, nil)
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), []string{"role1", "role2"}, gomock.Any()).Return(nil, nil)
 			roles, err := r.GetRoleByMembers(ctx, memberIDs)
 			assert.NoError(t, err)
 			assert.Equal(t, expectedRoles, roles)
 		})
 
+		Convey("展开继承的角色, 去重并忽略循环", func() {
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), memberIDs, gomock.Any()).Return([]interfaces.RoleInfo{{ID: "role1"}, {ID: "role2"}, {ID: "role1"}}, nil)
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), []string{"role1", "role2"}, gomock.Any()).Return([]interfaces.RoleInfo{{ID: "role3"}, {ID: "role2"}}, nil)
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), []string{"role3"}, gomock.Any()).Return([]interfaces.RoleInfo{{ID: "role1"}}, nil)
+			roles, err := r.GetRoleByMembers(ctx, memberIDs)
+			assert.NoError(t, err)
+			assert.Equal(t, []interfaces.RoleInfo{{ID: "role1"}, {ID: "role2"}, {ID: "role3"}}, roles)
+		})
+
+		Convey("展开继承的角色失败", func() {
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), memberIDs, gomock.Any()).Return([]interfaces.RoleInfo{{ID: "role1"}}, nil)
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), []string{"role1"}, gomock.Any()).Return(nil, errors.New("db error"))
+			roles, err := r.GetRoleByMembers(ctx, memberIDs)
+			assert.Error(t, err)
+			assert.Nil(t, roles)
+		})
+
 		Convey("数据库错误", func() {
-			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), memberIDs).Return(nil, errors.New("db error"))
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), memberIDs, gomock.Any()).Return(nil, errors.New("db error"))
 			roles, err := r.GetRoleByMembers(ctx, memberIDs)
 			assert.Error(t, err)
 			assert.Nil(t, roles)
@@ -725,6 +957,8 @@ func TestRole_InitRoleMemebers(t *testing.T) {
 		logger := common.NewLogger()
 		event := mock.NewMockLogicsEvent(ctrl)
 		r := newRole(roleDB, roleMemberDB, userMgnt, logger, event)
+		exclusionDB := mock.NewMockDBRoleExclusion(ctrl)
+		r.exclusionDB = exclusionDB
 
 		ctx := context.Background()
 		infoMap := map[string][]interfaces.RoleMemberInfo{
@@ -738,6 +972,7 @@ func TestRole_InitRoleMemebers(t *testing.T) {
 			// 获取现有成员
 			roleMemberDB.EXPECT().GetRoleMembersByRoleID(gomock.Any(), roleTmpID).Return([]interfaces.RoleMemberInfo{{ID: "existing_member"}}, nil)
 			// 添加新成员
+			exclusionDB.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
 			roleMemberDB.EXPECT().AddRoleMembers(gomock.Any(), roleTmpID, gomock.Any()).Return(nil)
 			err := r.InitRoleMemebers(ctx, infoMap)
 			assert.NoError(t, err)
@@ -751,6 +986,7 @@ func TestRole_InitRoleMemebers(t *testing.T) {
 
 		Convey("添加成员失败", func() {
 			roleMemberDB.EXPECT().GetRoleMembersByRoleID(gomock.Any(), "role1").Return([]interfaces.RoleMemberInfo{}, nil)
+			exclusionDB.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
 			roleMemberDB.EXPECT().AddRoleMembers(gomock.Any(), "role1", gomock.Any()).Return(errors.New("add error"))
 			err := r.InitRoleMemebers(ctx, infoMap)
 			assert.Error(t, err)
@@ -786,6 +1022,71 @@ func TestRole_deleteMemberByMemberID(t *testing.T) {
 	})
 }
 
+func TestRole_DeleteMembersByEndTime(t *testing.T) {
+	Convey("测试DeleteMembersByEndTime方法", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		roleDB := mock.NewMockDBRole(ctrl)
+		roleMemberDB := mock.NewMockDBRoleMember(ctrl)
+		userMgnt := mock.NewMockDrivenUserMgnt(ctrl)
+		logger := common.NewLogger()
+		event := mock.NewMockLogicsEvent(ctrl)
+		r := newRole(roleDB, roleMemberDB, userMgnt, logger, event)
+
+		curTime := int64(100)
+
+		Convey("删除过期成员失败", func() {
+			roleMemberDB.EXPECT().DeleteExpiredMembers(gomock.Any(), curTime).Return(nil, errors.New("delete error"))
+			err := r.DeleteMembersByEndTime(curTime)
+			assert.Error(t, err)
+		})
+
+		Convey("没有过期成员", func() {
+			roleMemberDB.EXPECT().DeleteExpiredMembers(gomock.Any(), curTime).Return(map[string][]interfaces.RoleMemberInfo{}, nil)
+			err := r.DeleteMembersByEndTime(curTime)
+			assert.NoError(t, err)
+		})
+
+		Convey("删除成功, 通知各角色过期的成员", func() {
+			roleMemberDB.EXPECT().DeleteExpiredMembers(gomock.Any(), curTime).Return(map[string][]interfaces.RoleMemberInfo{
+				"role1": {{ID: "user1"}, {ID: "user2"}},
+				"role2": {{ID: "user1"}},
+			}, nil)
+			event.EXPECT().RoleMembersExpired("role1", []string{"user1", "user2"}).Return(nil)
+			// 通知失败不影响其他角色
+			event.EXPECT().RoleMembersExpired("role2", []string{"user1"}).Return(errors.New("event error"))
+			err := r.DeleteMembersByEndTime(curTime)
+			assert.NoError(t, err)
+		})
+	})
+}
+
+func TestRole_DeleteMembersByEndTimeDelivery(t *testing.T) {
+	Convey("过期成员通过消息队列投递", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		roleDB := mock.NewMockDBRole(ctrl)
+		roleMemberDB := mock.NewMockDBRoleMember(ctrl)
+		userMgnt := mock.NewMockDrivenUserMgnt(ctrl)
+		messageBroker := mock.NewMockDrivenMessageBroker(ctrl)
+		e := newEvent()
+		r := newRole(roleDB, roleMemberDB, userMgnt, common.NewLogger(), e)
+		r.messageBroker = messageBroker
+		e.RegisterRoleMembersExpired(r.publishMembersExpired)
+
+		curTime := int64(100)
+		roleMemberDB.EXPECT().DeleteExpiredMembers(gomock.Any(), curTime).Return(map[string][]interfaces.RoleMemberInfo{
+			"role1": {{ID: "user1"}, {ID: "user2"}},
+		}, nil)
+		messageBroker.EXPECT().RoleMembersExpired("role1", []string{"user1", "user2"}).Return(nil)
+
+		err := r.DeleteMembersByEndTime(curTime)
+		assert.NoError(t, err)
+	})
+}
+
 func TestRole_updateMemberName(t *testing.T) {
 	Convey("测试updateMemberName方法", t, func() {
 		ctrl := gomock.NewController(t)
@@ -2182,7 +2483,7 @@ func TestRole_GetAccessorRoles(t *testing.T) {
 
 		Convey("GetRoleByMembers失败", func() {
 			userMgnt.EXPECT().GetAccessorIDsByUserID(gomock.Any(), "user1").Return([]string{"accessor1"}, nil)
-			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any()).Return(nil, errors.New("获取角色失败"))
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("获取角色失败"))
 			count, roles, err := r.GetAccessorRoles(ctx, param)
 			assert.Error(t, err)
 			assert.Equal(t, 0, count)
@@ -2207,7 +2508,9 @@ func TestRole_GetAccessorRoles(t *testing.T) {
 					ModifyTime:  2000,
 				},
 			}
-			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any()).Return(testRoles, nil)
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any(), gomock.Any()).Return(testRoles, nil)
+			// 展开继承的角色
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
 			roleDB.EXPECT().GetRoleByIDs(gomock.Any(), []string{"role1", "role2"}).Return(map[string]interfaces.RoleInfo{
 				"role1": testRoles[0],
 				"role2": testRoles[1],
@@ -2234,7 +2537,9 @@ func TestRole_GetAccessorRoles(t *testing.T) {
 					ModifyTime:  1000,
 				},
 			}
-			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any()).Return(testRoles, nil)
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any(), gomock.Any()).Return(testRoles, nil)
+			// 展开继承的角色
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
 			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), "user1").Return([]interfaces.SystemRoleType{interfaces.SuperAdmin}, nil)
 			roleDB.EXPECT().GetRoleByIDs(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, roleIDs []string) (map[string]interfaces.RoleInfo, error) {
 				result := make(map[string]interfaces.RoleInfo)
@@ -2262,7 +2567,7 @@ func TestRole_GetAccessorRoles(t *testing.T) {
 		Convey("成功获取角色 - 包含所有系统角色类型", func() {
 			param.RoleSources = []interfaces.RoleSource{interfaces.RoleSourceSystem}
 			userMgnt.EXPECT().GetAccessorIDsByUserID(gomock.Any(), "user1").Return([]string{"accessor1"}, nil)
-			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any()).Return([]interfaces.RoleInfo{}, nil)
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any(), gomock.Any()).Return([]interfaces.RoleInfo{}, nil)
 			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), "user1").Return([]interfaces.SystemRoleType{
 				interfaces.SuperAdmin,
 				interfaces.SystemAdmin,
@@ -2295,7 +2600,7 @@ func TestRole_GetAccessorRoles(t *testing.T) {
 		Convey("GetUserRolesByUserID失败 - 包含系统角色", func() {
 			param.RoleSources = []interfaces.RoleSource{interfaces.RoleSourceSystem}
 			userMgnt.EXPECT().GetAccessorIDsByUserID(gomock.Any(), "user1").Return([]string{"accessor1"}, nil)
-			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any()).Return([]interfaces.RoleInfo{}, nil)
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any(), gomock.Any()).Return([]interfaces.RoleInfo{}, nil)
 			userMgnt.EXPECT().GetUserRolesByUserID(gomock.Any(), "user1").Return(nil, errors.New("获取用户角色失败"))
 			count, roles, err := r.GetAccessorRoles(ctx, param)
 			assert.Error(t, err)
@@ -2314,7 +2619,9 @@ func TestRole_GetAccessorRoles(t *testing.T) {
 					ModifyTime:  1000,
 				},
 			}
-			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any()).Return(testRoles, nil)
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any(), gomock.Any()).Return(testRoles, nil)
+			// 展开继承的角色
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
 			roleDB.EXPECT().GetRoleByIDs(gomock.Any(), []string{"role1"}).Return(nil, errors.New("获取角色信息失败"))
 			count, roles, err := r.GetAccessorRoles(ctx, param)
 			assert.Error(t, err)
@@ -2348,7 +2655,9 @@ func TestRole_GetAccessorRoles(t *testing.T) {
 					ModifyTime:  3000,
 				},
 			}
-			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any()).Return(testRoles, nil)
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any(), gomock.Any()).Return(testRoles, nil)
+			// 展开继承的角色
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
 			roleDB.EXPECT().GetRoleByIDs(gomock.Any(), []string{"role1", "role2", "role3"}).Return(map[string]interfaces.RoleInfo{
 				"role1": testRoles[0],
 				"role2": testRoles[1],
@@ -2389,7 +2698,9 @@ func TestRole_GetAccessorRoles(t *testing.T) {
 					ModifyTime:  3000,
 				},
 			}
-			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any()).Return(testRoles, nil)
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any(), gomock.Any()).Return(testRoles, nil)
+			// 展开继承的角色
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
 			roleDB.EXPECT().GetRoleByIDs(gomock.Any(), []string{"role1", "role2", "role3"}).Return(map[string]interfaces.RoleInfo{
 				"role1": testRoles[0],
 				"role2": testRoles[1],
@@ -2417,7 +2728,9 @@ func TestRole_GetAccessorRoles(t *testing.T) {
 					ModifyTime:  int64(1000 + i),
 				})
 			}
-			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any()).Return(testRoles, nil)
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any(), gomock.Any()).Return(testRoles, nil)
+			// 展开继承的角色
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
 			roleMap := make(map[string]interfaces.RoleInfo)
 			for _, role := range testRoles {
 				roleMap[role.ID] = role
@@ -2447,7 +2760,9 @@ func TestRole_GetAccessorRoles(t *testing.T) {
 					ModifyTime:  int64(1000 + i),
 				})
 			}
-			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any()).Return(testRoles, nil)
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any(), gomock.Any()).Return(testRoles, nil)
+			// 展开继承的角色
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
 			roleMap := make(map[string]interfaces.RoleInfo)
 			for _, role := range testRoles {
 				roleMap[role.ID] = role
@@ -2473,7 +2788,9 @@ func TestRole_GetAccessorRoles(t *testing.T) {
 					ModifyTime:  1000,
 				},
 			}
-			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any()).Return(testRoles, nil)
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any(), gomock.Any()).Return(testRoles, nil)
+			// 展开继承的角色
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
 			roleDB.EXPECT().GetRoleByIDs(gomock.Any(), []string{"role1"}).Return(map[string]interfaces.RoleInfo{
 				"role1": testRoles[0],
 			}, nil)
@@ -2486,7 +2803,7 @@ func TestRole_GetAccessorRoles(t *testing.T) {
 
 		Convey("成功获取角色 - 空结果", func() {
 			userMgnt.EXPECT().GetAccessorIDsByUserID(gomock.Any(), "user1").Return([]string{"accessor1"}, nil)
-			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any()).Return([]interfaces.RoleInfo{}, nil)
+			roleMemberDB.EXPECT().GetRoleByMembers(gomock.Any(), gomock.Any(), gomock.Any()).Return([]interfaces.RoleInfo{}, nil)
 			// 不包含系统角色，所以不会调用 GetUserRolesByUserID
 
 			count, roles, err := r.GetAccessorRoles(ctx, param)
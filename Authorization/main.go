@@ -35,6 +35,8 @@ type Authorization struct {
 	policyCalcHandler         driveradapters.RestHandler
 	obligationTemplateHandler driveradapters.RestHandler
 	obligationHandler         driveradapters.RestHandler
+	configBundleHandler       driveradapters.RestHandler
+	roleExclusionHandler      driveradapters.RestHandler
 }
 
 // Start 开启服务
@@ -72,6 +74,8 @@ func (t *Authorization) Start() {
 		t.roleHandler.RegisterPublic(engine)
 		t.obligationTemplateHandler.RegisterPublic(engine)
 		t.obligationHandler.RegisterPublic(engine)
+		t.configBundleHandler.RegisterPublic(engine)
+		t.roleExclusionHandler.RegisterPublic(engine)
 		s := &http.Server{
 			Addr:    fmt.Sprintf("%s:%d", common.SvcConfig.SvcHost, common.SvcConfig.SvcPublicPort),
 			Handler: engine.Handler(),
@@ -196,6 +200,7 @@ func main() {
 		svcLog.Panicln(err)
 	}
 	driveradapters.SetMQClient(mqClient)
+	drivenadapters.SetMQClient(mqClient)
 
 	// 注入db实例到出栈
 
@@ -207,9 +212,11 @@ func main() {
 	logics.SetDBRoleMember(dbaccess.NewRoleMember())
 	logics.SetDBObligationType(dbaccess.NewObligationType())
 	logics.SetDBObligation(dbaccess.NewObligation())
+	logics.SetDBRoleExclusion(dbaccess.NewRoleExclusion())
 	// logics的drivenadapters依赖注入
 
 	logics.SetDnUserMgnt(drivenadapters.NewUserMgnt())
+	logics.SetDnMessageBroker(drivenadapters.NewMessageBroker())
 
 	server := &Authorization{
 		healthHandler:             driveradapters.NewHealthHandler(),
@@ -223,6 +230,8 @@ func main() {
 		timer:                     driveradapters.NewTimer(),
 		obligationTemplateHandler: driveradapters.NewObligationTemplateRestHandler(),
 		obligationHandler:         driveradapters.NewObligationRestHandler(),
+		configBundleHandler:       driveradapters.NewConfigBundleRestHandler(),
+		roleExclusionHandler:      driveradapters.NewRoleExclusionRestHandler(),
 	}
 
 	server.Start()
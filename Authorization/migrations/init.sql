@@ -63,15 +63,44 @@ CREATE TABLE IF NOT EXISTS `t_role_member` (
     `f_primary_id` bigint(20) NOT NULL AUTO_INCREMENT,
     `f_role_id` char(40) NOT NULL COMMENT '角色唯一标识',
     `f_member_id` char(40) NOT NULL COMMENT '成员唯一标识',
-    `f_member_type` tinyint(4) NOT NULL COMMENT '成员类型,1: 用户, 2: 组织/部门, 5: 用户组 6: 应用账户',
+    `f_member_type` tinyint(4) NOT NULL COMMENT '成员类型,1: 用户, 2: 组织/部门, 5: 用户组 6: 应用账户 7: 角色',
     `f_member_name` varchar(150) NOT NULL COMMENT '成员名称',
+    `f_start_time` bigint(20) NOT NULL DEFAULT 0 COMMENT '生效时间, 0: 立即生效',
+    `f_end_time` bigint(20) NOT NULL DEFAULT -1 COMMENT '过期时间, -1: 永久有效',
     `f_created_time` bigint(40) NOT NULL COMMENT '创建时间',
     `f_modify_time`  bigint(20) NOT NULL COMMENT '修改时间',
     KEY `idx_f_role_id` (`f_role_id`),
     KEY `idx_f_member_id` (`f_member_id`),
+    KEY `idx_f_end_time` (`f_end_time`),
     PRIMARY KEY (`f_primary_id`)
 ) ENGINE=InnoDB COMMENT='角色成员表';
 
+-- 升级: CREATE TABLE IF NOT EXISTS 不会修改已存在的表, 为已有的角色成员表添加有效期字段
+-- 根据 information_schema 判断字段是否存在, 重复执行时跳过
+SET @sql = (SELECT IF(COUNT(*) = 0,
+    'ALTER TABLE `t_role_member`
+        ADD COLUMN `f_start_time` bigint(20) NOT NULL DEFAULT 0 COMMENT ''生效时间, 0: 立即生效'' AFTER `f_member_name`,
+        ADD COLUMN `f_end_time` bigint(20) NOT NULL DEFAULT -1 COMMENT ''过期时间, -1: 永久有效'' AFTER `f_start_time`,
+        ADD KEY `idx_f_end_time` (`f_end_time`)',
+    'SELECT 1')
+    FROM information_schema.COLUMNS
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 't_role_member' AND COLUMN_NAME = 'f_end_time');
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+CREATE TABLE IF NOT EXISTS `t_role_exclusion` (
+    `f_primary_id` bigint(20) NOT NULL AUTO_INCREMENT,
+    `f_id` char(40) NOT NULL COMMENT '互斥角色集唯一标识',
+    `f_name` varchar(255) NOT NULL COMMENT '互斥角色集名称',
+    `f_description` text NOT NULL COMMENT '描述',
+    `f_role_ids` longtext NOT NULL COMMENT '互斥的角色ID, 格式是json数组',
+    `f_created_at` bigint(20) NOT NULL COMMENT '创建时间',
+    `f_modified_at` bigint(20) NOT NULL COMMENT '修改时间',
+    UNIQUE KEY `uk_id` (`f_id`),
+    PRIMARY KEY (`f_primary_id`)
+) ENGINE=InnoDB COMMENT='互斥角色集表';
+
 CREATE TABLE IF NOT EXISTS `t_obligation_type` (
     `f_primary_id` bigint(20) NOT NULL AUTO_INCREMENT,
     `f_id`  char(255) NOT NULL COMMENT '义务类型唯一标识',
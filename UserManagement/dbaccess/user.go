@@ -669,6 +669,101 @@ func (u *user) GetUserInfoByIDCard(id string) (info interfaces.UserDBInfo, err e
 	return info, nil
 }
 
+// GetUserInfosByEmail 根据邮箱获取用户信息，邮箱不保证唯一，返回所有匹配的用户
+func (u *user) GetUserInfosByEmail(email string) (infos []interfaces.UserDBInfo, err error) {
+	infos = make([]interfaces.UserDBInfo, 0)
+	if email == "" {
+		return
+	}
+
+	dbName := common.GetDBName("sharemgnt_db")
+	strSQL := `select f_user_id, f_status, f_auto_disable_status,
+				f_mail_address, f_auth_type, IFNULL(f_tel_number,'') , f_pwd_control,
+				f_login_name, f_pwd_error_latest_timestamp, f_pwd_error_cnt, f_ldap_server_type, f_domain_path
+				from %s.t_user
+				where f_mail_address = ? `
+	strSQL = fmt.Sprintf(strSQL, dbName)
+
+	rows, sqlErr := u.db.Query(strSQL, email)
+	defer func() {
+		if rows != nil {
+			if rowsErr := rows.Err(); rowsErr != nil {
+				u.logger.Errorln(rowsErr)
+			}
+
+			// 1、判断是否为空再关闭，2、如果不关闭而数据行并没有被scan的话，连接一直会被占用直到超时断开
+			if closeErr := rows.Close(); closeErr != nil {
+				u.logger.Errorln(closeErr)
+			}
+		}
+	}()
+
+	if sqlErr != nil {
+		u.logger.Errorln(sqlErr, strSQL)
+		return infos, sqlErr
+	}
+
+	for rows.Next() {
+		var temp userDBData
+		if err := rows.Scan(&temp.ID, &temp.DisableStatus, &temp.AutoDisableStatus,
+			&temp.Email, &temp.AuthType, &temp.TelNumber, &temp.PWDControl,
+			&temp.Account, &temp.PWDErrLatestTime, &temp.PWDErrCnt, &temp.LDAPType, &temp.DomainPath); err != nil {
+			u.logger.Errorln(err, strSQL)
+			return infos, err
+		}
+		infos = append(infos, handlerUserDBData(&temp))
+	}
+
+	return infos, nil
+}
+
+// GetUserInfoByThirdID 根据第三方系统ID获取用户信息
+func (u *user) GetUserInfoByThirdID(thirdID string) (info interfaces.UserDBInfo, err error) {
+	if thirdID == "" {
+		return
+	}
+
+	dbName := common.GetDBName("sharemgnt_db")
+	strSQL := `select f_user_id, f_status, f_auto_disable_status,
+				f_mail_address, f_auth_type, IFNULL(f_tel_number,'') , f_pwd_control,
+				f_login_name, f_pwd_error_latest_timestamp, f_pwd_error_cnt, f_ldap_server_type, f_domain_path
+				from %s.t_user
+				where f_third_party_id = ? `
+	strSQL = fmt.Sprintf(strSQL, dbName)
+
+	rows, sqlErr := u.db.Query(strSQL, thirdID)
+	defer func() {
+		if rows != nil {
+			if rowsErr := rows.Err(); rowsErr != nil {
+				u.logger.Errorln(rowsErr)
+			}
+
+			// 1、判断是否为空再关闭，2、如果不关闭而数据行并没有被scan的话，连接一直会被占用直到超时断开
+			if closeErr := rows.Close(); closeErr != nil {
+				u.logger.Errorln(closeErr)
+			}
+		}
+	}()
+
+	if sqlErr != nil {
+		u.logger.Errorln(sqlErr, strSQL)
+		return info, sqlErr
+	}
+
+	var temp userDBData
+	for rows.Next() {
+		if err := rows.Scan(&temp.ID, &temp.DisableStatus, &temp.AutoDisableStatus,
+			&temp.Email, &temp.AuthType, &temp.TelNumber, &temp.PWDControl,
+			&temp.Account, &temp.PWDErrLatestTime, &temp.PWDErrCnt, &temp.LDAPType, &temp.DomainPath); err != nil {
+			u.logger.Errorln(err, strSQL)
+			return info, err
+		}
+		info = handlerUserDBData(&temp)
+	}
+
+	return info, nil
+}
+
 // GetOrgAduitDepartInfo 获取组织审计员谁范围内部门
 func (u *user) GetOrgAduitDepartInfo(userID string) (out []string, err error) {
 	if userID == "" {
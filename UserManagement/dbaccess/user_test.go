@@ -422,6 +422,95 @@ func TestGetUserInfoByIDCard(t *testing.T) {
 	})
 }
 
+func TestGetUserInfosByEmail(t *testing.T) {
+	Convey("GetUserInfosByEmail, db is available", t, func() {
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+
+		assert.NotEqual(t, db, nil)
+		user := newUserDB(db)
+		fields := []string{
+			"f_user_id",
+			"f_status",
+			"f_auto_disable_status",
+			"f_mail_address",
+			"f_auth_type",
+			"f_tel_number",
+			"f_pwd_control",
+			"f_login_name",
+			"f_pwd_error_latest_timestamp",
+			"f_pwd_error_cnt",
+			"f_ldap_server_type",
+			"f_domain_path",
+		}
+
+		Convey("email is empty", func() {
+			infos, httpErr := user.GetUserInfosByEmail("")
+			assert.Equal(t, httpErr, nil)
+			assert.Equal(t, len(infos), 0)
+		})
+
+		Convey("db error", func() {
+			tmpErr := errors.New("db error")
+			mock.ExpectQuery("").WithArgs("1@qq.com").WillReturnError(tmpErr)
+			_, httpErr := user.GetUserInfosByEmail("1@qq.com")
+			assert.Equal(t, httpErr, tmpErr)
+		})
+
+		Convey("Success", func() {
+			mock.ExpectQuery("").WithArgs("1@qq.com").WillReturnRows(sqlmock.NewRows(fields).
+				AddRow("user1", 0, 0, "1@qq.com", 3, "", 0, "login_name_001", time.Now().Format(time.DateTime), 0, 0, "").
+				AddRow("user2", 0, 0, "1@qq.com", 1, "", 0, "login_name_002", time.Now().Format(time.DateTime), 0, 0, ""))
+			infos, httpErr := user.GetUserInfosByEmail("1@qq.com")
+			assert.Equal(t, httpErr, nil)
+			assert.Equal(t, len(infos), 2)
+			assert.Equal(t, infos[0].ID, "user1")
+			assert.Equal(t, infos[0].AuthType, interfaces.Third)
+			assert.Equal(t, infos[1].Account, "login_name_002")
+		})
+	})
+}
+
+func TestGetUserInfoByThirdID(t *testing.T) {
+	Convey("GetUserInfoByThirdID, db is available", t, func() {
+		db, mock, err := sqlx.New()
+		assert.Equal(t, err, nil)
+
+		assert.NotEqual(t, db, nil)
+		user := newUserDB(db)
+
+		Convey("third id is empty", func() {
+			info, httpErr := user.GetUserInfoByThirdID("")
+			assert.Equal(t, httpErr, nil)
+			assert.Equal(t, info.ID, "")
+		})
+
+		Convey("Success", func() {
+			fields := []string{
+				"f_user_id",
+				"f_status",
+				"f_auto_disable_status",
+				"f_mail_address",
+				"f_auth_type",
+				"f_tel_number",
+				"f_pwd_control",
+				"f_login_name",
+				"f_pwd_error_latest_timestamp",
+				"f_pwd_error_cnt",
+				"f_ldap_server_type",
+				"f_domain_path",
+			}
+			mock.ExpectQuery("").WithArgs("third1").WillReturnRows(sqlmock.NewRows(fields).AddRow("user1", 1, 0, "1@qq.com", 3, "", 0, "login_name_001", time.Now().Format(time.DateTime), 0, 0, ""))
+			info, httpErr := user.GetUserInfoByThirdID("third1")
+			assert.Equal(t, httpErr, nil)
+			assert.Equal(t, info.ID, "user1")
+			assert.Equal(t, info.AuthType, interfaces.Third)
+			assert.Equal(t, info.DisableStatus, interfaces.Disabled)
+			assert.Equal(t, info.Account, "login_name_001")
+		})
+	})
+}
+
 func TestGetOrgAduitDepartInfo(t *testing.T) {
 	Convey("GetOrgAduitDepartInfo, db is available", t, func() {
 		db, mock, err := sqlx.New()
@@ -204,6 +204,7 @@ func (h *userRestHandler) RegisterPrivate(engine *gin.Engine) {
 	engine.POST("/api/user-management/v1/batch-get-user-info", observable.MiddlewareTrace(common.SvcARTrace), h.getUserBaseInfoByPost)
 	engine.GET("/api/user-management/v1/pwd-retrieval-method", h.getPWDRetrievalMethod)
 	engine.GET("/api/user-management/v1/account-match", h.getUserInfoByAccount)
+	engine.GET("/api/user-management/v1/user-match", h.getUserInfoByMatch)
 	engine.GET("/api/user-management/v1/user-auth", h.userAuth)
 	engine.PUT("/api/user-management/v1/users/:user_id/pwd_err_info", h.updatePwdErrInfo)
 	engine.GET("/api/user-management/v1/org_managers/:org_manager_ids/:fields", h.getOrgManagersInfo)
@@ -371,6 +372,36 @@ func (h *userRestHandler) getUserInfoByAccount(c *gin.Context) {
 		return
 	}
 
+	rest.ReplyOK(c, http.StatusOK, h.matchResult(result, &userBaseInfo))
+}
+
+// getUserInfoByMatch 通过邮箱或第三方系统ID匹配账户信息，二者必须且只能指定一个
+func (h *userRestHandler) getUserInfoByMatch(c *gin.Context) {
+	email, hasEmail := c.GetQuery("email")
+	thirdID, hasThirdID := c.GetQuery("third_id")
+	if hasEmail == hasThirdID || (hasEmail && email == "") || (hasThirdID && thirdID == "") {
+		rest.ReplyError(c, rest.NewHTTPError("invalid type", rest.BadRequest, map[string]interface{}{"params": []string{"email", "third_id"}}))
+		return
+	}
+
+	var result bool
+	var userBaseInfo interfaces.UserBaseInfo
+	var err error
+	if hasEmail {
+		result, userBaseInfo, err = h.user.GetUserInfoByEmail(email)
+	} else {
+		result, userBaseInfo, err = h.user.GetUserInfoByThirdID(thirdID)
+	}
+	if err != nil {
+		rest.ReplyError(c, err)
+		return
+	}
+
+	rest.ReplyOK(c, http.StatusOK, h.matchResult(result, &userBaseInfo))
+}
+
+// matchResult 账户匹配结果
+func (h *userRestHandler) matchResult(result bool, userBaseInfo *interfaces.UserBaseInfo) map[string]interface{} {
 	outData := make(map[string]interface{})
 	outData["result"] = result
 	if result {
@@ -386,8 +417,7 @@ func (h *userRestHandler) getUserInfoByAccount(c *gin.Context) {
 
 		outData["user"] = user
 	}
-
-	rest.ReplyOK(c, http.StatusOK, outData)
+	return outData
 }
 
 // userAuth 本地认证
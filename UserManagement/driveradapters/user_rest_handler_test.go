@@ -754,6 +754,90 @@ func TestGetUserInfoByAccount(t *testing.T) {
 	})
 }
 
+func TestGetUserInfoByMatch(t *testing.T) {
+	Convey("通过邮箱或第三方系统ID匹配账户信息", t, func() {
+		test := setGinMode()
+		defer test()
+		r := gin.New()
+		r.Use(gin.Recovery())
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userLogics := mock.NewMockLogicsUser(ctrl)
+		testURestHandler := newUserRESTHandler(userLogics, nil, nil, nil)
+		testURestHandler.RegisterPrivate(r)
+
+		var user interfaces.UserBaseInfo
+		target := "/api/user-management/v1/user-match"
+		Convey("账户匹配失败--缺少请求参数", func() {
+			req := httptest.NewRequest("GET", target, http.NoBody)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			result := w.Result()
+
+			assert.Equal(t, result.StatusCode, http.StatusBadRequest)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+		Convey("账户匹配失败--同时指定邮箱和第三方系统ID", func() {
+			req := httptest.NewRequest("GET", target+"?email=a@b.com&third_id=1", http.NoBody)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			result := w.Result()
+
+			assert.Equal(t, result.StatusCode, http.StatusBadRequest)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+		Convey("账户匹配失败--服务内部错误", func() {
+			tmpErr := fmt.Errorf("xx err")
+			userLogics.EXPECT().GetUserInfoByThirdID("1").Return(false, user, tmpErr)
+
+			req := httptest.NewRequest("GET", target+"?third_id=1", http.NoBody)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			result := w.Result()
+
+			assert.Equal(t, result.StatusCode, http.StatusInternalServerError)
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+		Convey("账户匹配成功", func() {
+			user.ID = "003a75b4-1f0d-4a7e-929c-497a41b9037c"
+			user.Account = "user1"
+			user.AuthType = interfaces.Third
+			user.Enabled = true
+			userLogics.EXPECT().GetUserInfoByEmail("a@b.com").Return(true, user, nil)
+
+			req := httptest.NewRequest("GET", target+"?email=a@b.com", http.NoBody)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			result := w.Result()
+			respBody, _ := io.ReadAll(result.Body)
+			var res interface{}
+			_ = jsoniter.Unmarshal(respBody, &res)
+
+			assert.Equal(t, result.StatusCode, http.StatusOK)
+			assert.Equal(t, res.(map[string]interface{})["result"].(bool), true)
+			user := res.(map[string]interface{})["user"]
+			assert.Equal(t, user.(map[string]interface{})["id"].(string), "003a75b4-1f0d-4a7e-929c-497a41b9037c")
+			assert.Equal(t, user.(map[string]interface{})["account"].(string), "user1")
+			assert.Equal(t, user.(map[string]interface{})["disable_status"].(bool), false)
+
+			if err := result.Body.Close(); err != nil {
+				assert.Equal(t, err, nil)
+			}
+		})
+	})
+}
+
 func TestUserAuth(t *testing.T) {
 	Convey("本地认证-内部-接口层", t, func() {
 		test := setGinMode()
@@ -509,6 +509,12 @@ type DBUser interface {
 	// GetUserInfoByIDCard 根据身份号获取用户信息
 	GetUserInfoByIDCard(id string) (info UserDBInfo, err error)
 
+	// GetUserInfosByEmail 根据邮箱获取用户信息
+	GetUserInfosByEmail(email string) (infos []UserDBInfo, err error)
+
+	// GetUserInfoByThirdID 根据第三方系统ID获取用户信息
+	GetUserInfoByThirdID(thirdID string) (info UserDBInfo, err error)
+
 	// UpdatePwdErrInfo 更新账户密码错误信息
 	UpdatePwdErrInfo(id string, pwdErrCnt int, pwdErrLastTime int64) (err error)
 
@@ -529,6 +529,12 @@ type LogicsUser interface {
 	// GetUserInfoByAccount 通过账户名匹配账户信息
 	GetUserInfoByAccount(account string, enableIDCardLogin bool, enablePrefixMatch bool) (result bool, user UserBaseInfo, err error)
 
+	// GetUserInfoByEmail 通过邮箱匹配账户信息
+	GetUserInfoByEmail(email string) (result bool, user UserBaseInfo, err error)
+
+	// GetUserInfoByThirdID 通过第三方系统ID匹配账户信息
+	GetUserInfoByThirdID(thirdID string) (result bool, user UserBaseInfo, err error)
+
 	// GetUserBaseInfoInScope 获取范围内用户基本信息
 	GetUserBaseInfoInScope(visitor *Visitor, role Role, userIDs []string, info UserBaseInfoRange) ([]UserBaseInfo, error)
 
@@ -45,6 +45,17 @@ var (
 	uLogics *user
 
 	nOffsetTime = 5
+
+	// matchInfoRange 账户匹配返回的用户信息范围
+	matchInfoRange = interfaces.UserBaseInfoRange{
+		ShowAccount:        true,
+		ShowAuthType:       true,
+		ShowPwdErrCnt:      true,
+		ShowPwdErrLastTime: true,
+		ShowEnable:         true,
+		ShowLDAPType:       true,
+		ShowDomanPath:      true,
+	}
 )
 
 // NewUser 创建新的user对象
@@ -704,16 +715,38 @@ func (u *user) GetUserInfoByAccount(account string, enableIDCardLogin, enablePre
 		return
 	}
 
-	infoRange := interfaces.UserBaseInfoRange{
-		ShowAccount:        true,
-		ShowAuthType:       true,
-		ShowPwdErrCnt:      true,
-		ShowPwdErrLastTime: true,
-		ShowEnable:         true,
-		ShowLDAPType:       true,
-		ShowDomanPath:      true,
+	return true, u.handleUserBaseInfo(matchInfoRange, &userDBInfo), nil
+}
+
+// GetUserInfoByEmail 通过邮箱匹配账户信息，邮箱对应多个账户时视为未匹配
+func (u *user) GetUserInfoByEmail(email string) (result bool, userInfo interfaces.UserBaseInfo, err error) {
+	userDBInfos, err := u.userDB.GetUserInfosByEmail(email)
+	if err != nil {
+		return
 	}
-	return true, u.handleUserBaseInfo(infoRange, &userDBInfo), nil
+
+	if len(userDBInfos) != 1 {
+		if len(userDBInfos) > 1 {
+			u.logger.Warnf("email %s matches %d users", email, len(userDBInfos))
+		}
+		return
+	}
+
+	return true, u.handleUserBaseInfo(matchInfoRange, &userDBInfos[0]), nil
+}
+
+// GetUserInfoByThirdID 通过第三方系统ID匹配账户信息
+func (u *user) GetUserInfoByThirdID(thirdID string) (result bool, userInfo interfaces.UserBaseInfo, err error) {
+	userDBInfo, err := u.userDB.GetUserInfoByThirdID(thirdID)
+	if err != nil {
+		return
+	}
+
+	if userDBInfo.ID == "" {
+		return
+	}
+
+	return true, u.handleUserBaseInfo(matchInfoRange, &userDBInfo), nil
 }
 
 func (u *user) checkAccountLocked(user *interfaces.UserDBInfo) (err error) {
@@ -1979,6 +1979,109 @@ func TestGetUserInfoByAccount(t *testing.T) {
 	})
 }
 
+func TestGetUserInfoByEmail(t *testing.T) {
+	Convey("通过邮箱匹配账户信息", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userDB := mock.NewMockDBUser(ctrl)
+		u := &user{
+			userDB: userDB,
+			logger: common.NewLogger(),
+		}
+
+		email := "user1@example.com"
+		dbUser := interfaces.UserDBInfo{
+			ID:                "f35dcd71-7dda-45e5-b114-4446c7f43ea3",
+			Account:           "user1",
+			Email:             email,
+			DisableStatus:     interfaces.Enabled,
+			AutoDisableStatus: interfaces.AEnabled,
+		}
+		Convey("数据库异常", func() {
+			tmpErr := fmt.Errorf("err")
+			userDB.EXPECT().GetUserInfosByEmail(email).Return(nil, tmpErr)
+
+			_, _, err := u.GetUserInfoByEmail(email)
+
+			assert.Equal(t, err, tmpErr)
+		})
+		Convey("账户不存在", func() {
+			userDB.EXPECT().GetUserInfosByEmail(email).Return([]interfaces.UserDBInfo{}, nil)
+
+			result, _, err := u.GetUserInfoByEmail(email)
+
+			assert.Equal(t, result, false)
+			assert.Equal(t, err, nil)
+		})
+		Convey("邮箱对应多个账户", func() {
+			userDB.EXPECT().GetUserInfosByEmail(email).Return([]interfaces.UserDBInfo{dbUser, dbUser}, nil)
+
+			result, _, err := u.GetUserInfoByEmail(email)
+
+			assert.Equal(t, result, false)
+			assert.Equal(t, err, nil)
+		})
+		Convey("匹配成功", func() {
+			userDB.EXPECT().GetUserInfosByEmail(email).Return([]interfaces.UserDBInfo{dbUser}, nil)
+
+			result, userInfo, err := u.GetUserInfoByEmail(email)
+
+			assert.Equal(t, result, true)
+			assert.Equal(t, userInfo.ID, dbUser.ID)
+			assert.Equal(t, userInfo.Account, "user1")
+			assert.Equal(t, userInfo.Enabled, true)
+			assert.Equal(t, err, nil)
+		})
+	})
+}
+
+func TestGetUserInfoByThirdID(t *testing.T) {
+	Convey("通过第三方系统ID匹配账户信息", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userDB := mock.NewMockDBUser(ctrl)
+		u := &user{
+			userDB: userDB,
+		}
+
+		thirdID := "third1"
+		dbUser := interfaces.UserDBInfo{
+			ID:                "f35dcd71-7dda-45e5-b114-4446c7f43ea3",
+			Account:           "user1",
+			DisableStatus:     interfaces.Disabled,
+			AutoDisableStatus: interfaces.AEnabled,
+		}
+		Convey("数据库异常", func() {
+			tmpErr := fmt.Errorf("err")
+			userDB.EXPECT().GetUserInfoByThirdID(thirdID).Return(interfaces.UserDBInfo{}, tmpErr)
+
+			_, _, err := u.GetUserInfoByThirdID(thirdID)
+
+			assert.Equal(t, err, tmpErr)
+		})
+		Convey("账户不存在", func() {
+			userDB.EXPECT().GetUserInfoByThirdID(thirdID).Return(interfaces.UserDBInfo{}, nil)
+
+			result, _, err := u.GetUserInfoByThirdID(thirdID)
+
+			assert.Equal(t, result, false)
+			assert.Equal(t, err, nil)
+		})
+		Convey("匹配成功", func() {
+			userDB.EXPECT().GetUserInfoByThirdID(thirdID).Return(dbUser, nil)
+
+			result, userInfo, err := u.GetUserInfoByThirdID(thirdID)
+
+			assert.Equal(t, result, true)
+			assert.Equal(t, userInfo.ID, dbUser.ID)
+			assert.Equal(t, userInfo.Enabled, false)
+			assert.Equal(t, err, nil)
+		})
+	})
+}
+
 func TestGetNorlmalUserInfo(t *testing.T) {
 	Convey("获取普通用户自身信息", t, func() {
 		test := setGinMode()